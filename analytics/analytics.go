@@ -0,0 +1,257 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package analytics records AI feature usage and aggregates it for admin dashboards.
+package analytics
+
+import (
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Features tracked by the analytics service.
+const (
+	FeatureDirectMessage   = "direct_message"
+	FeatureMention         = "mention"
+	FeatureThreadAnalysis  = "thread_analysis"
+	FeatureChannelAnalysis = "channel_analysis"
+	FeatureChannelInterval = "channel_interval"
+	FeatureSearch          = "search"
+	FeatureDraftReply      = "draft_reply"
+	FeatureReactionTrigger = "reaction_trigger"
+	FeatureExplainPost     = "explain_post"
+	FeatureMentionsSummary = "mentions_summary"
+)
+
+// TeamIDDirect is used as team ID for usage in DMs and GMs that are not associated with a team.
+const TeamIDDirect = "direct"
+
+// Event is a single completed AI generation.
+type Event struct {
+	UserID    string
+	TeamID    string
+	ChannelID string
+	BotID     string
+	Feature   string
+	LatencyMS int64
+	ToolCalls int
+	Failed    bool
+}
+
+// Logger is the logging interface needed by the analytics service.
+type Logger interface {
+	LogError(msg string, keyValuePairs ...interface{})
+}
+
+// Service records usage events and builds usage reports.
+type Service struct {
+	db  *mmapi.DBClient
+	log Logger
+}
+
+// New creates a new analytics service.
+func New(db *mmapi.DBClient, log Logger) *Service {
+	return &Service{
+		db:  db,
+		log: log,
+	}
+}
+
+// NewEvent creates an event for a generation requested by userID in channel.
+func NewEvent(feature, botID, userID string, channel *model.Channel) Event {
+	event := Event{
+		Feature: feature,
+		BotID:   botID,
+		UserID:  userID,
+		TeamID:  TeamIDDirect,
+	}
+	if channel != nil {
+		event.ChannelID = channel.Id
+		if channel.TeamId != "" {
+			event.TeamID = channel.TeamId
+		}
+	}
+
+	return event
+}
+
+// Record stores a usage event.
+func (s *Service) Record(event Event) error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+
+	_, err := s.db.ExecBuilder(s.db.Builder().Insert("LLM_UsageEvents").
+		Columns("ID", "CreateAt", "UserID", "TeamID", "ChannelID", "BotID", "Feature", "LatencyMS", "ToolCalls", "Failed").
+		Values(model.NewId(), model.GetMillis(), event.UserID, event.TeamID, event.ChannelID, event.BotID, event.Feature, event.LatencyMS, event.ToolCalls, event.Failed))
+	if err != nil {
+		return fmt.Errorf("failed to record usage event: %w", err)
+	}
+
+	return nil
+}
+
+// TrackStream records event once the stream finishes, measuring the latency from now
+// until the end of the stream and counting tool calls. It is safe to call on a nil service.
+func (s *Service) TrackStream(stream *llm.TextStreamResult, event Event) *llm.TextStreamResult {
+	if s == nil || s.db == nil || stream == nil {
+		return stream
+	}
+
+	return trackStream(stream, event, func(event Event) {
+		if err := s.Record(event); err != nil {
+			s.log.LogError("Failed to record usage event", "feature", event.Feature, "error", err)
+		}
+	})
+}
+
+func trackStream(stream *llm.TextStreamResult, event Event, record func(Event)) *llm.TextStreamResult {
+	start := time.Now()
+	output := make(chan llm.TextStreamEvent)
+	go func() {
+		defer close(output)
+
+		recorded := false
+		finish := func() {
+			if recorded {
+				return
+			}
+			recorded = true
+			event.LatencyMS = time.Since(start).Milliseconds()
+			record(event)
+		}
+
+		for streamEvent := range stream.Stream {
+			switch streamEvent.Type {
+			case llm.EventTypeToolCalls:
+				if toolCalls, ok := streamEvent.Value.([]llm.ToolCall); ok {
+					event.ToolCalls += len(toolCalls)
+				}
+			case llm.EventTypeError:
+				event.Failed = true
+				finish()
+			case llm.EventTypeEnd:
+				finish()
+			}
+			output <- streamEvent
+		}
+		finish()
+	}()
+
+	return &llm.TextStreamResult{Stream: output}
+}
+
+// UsageQuery selects the events included in a usage report.
+type UsageQuery struct {
+	Since  int64
+	Until  int64
+	TeamID string
+}
+
+// UsageStats aggregates usage for a group of events.
+type UsageStats struct {
+	Requests         int64   `json:"requests" db:"requests"`
+	ActiveUsers      int64   `json:"active_users" db:"active_users"`
+	ToolCalls        int64   `json:"tool_calls" db:"tool_calls"`
+	Failures         int64   `json:"failures" db:"failures"`
+	AverageLatencyMS float64 `json:"average_latency_ms" db:"average_latency_ms"`
+}
+
+// FeatureUsage is the usage of a single feature.
+type FeatureUsage struct {
+	Feature string `json:"feature" db:"feature"`
+	UsageStats
+}
+
+// TeamUsage is the usage within a single team.
+type TeamUsage struct {
+	TeamID string `json:"team_id" db:"teamid"`
+	UsageStats
+}
+
+// DailyUsage is the usage for a single UTC day.
+type DailyUsage struct {
+	Day int64 `json:"day" db:"day"`
+	UsageStats
+}
+
+// UsageReport is the aggregated usage for a time range.
+type UsageReport struct {
+	Since     int64          `json:"since"`
+	Until     int64          `json:"until"`
+	Totals    UsageStats     `json:"totals"`
+	ByFeature []FeatureUsage `json:"by_feature"`
+	ByTeam    []TeamUsage    `json:"by_team"`
+	ByDay     []DailyUsage   `json:"by_day"`
+}
+
+const dayMillis = int64(24 * time.Hour / time.Millisecond)
+
+var statsColumns = []string{
+	"COUNT(*) AS requests",
+	"COUNT(DISTINCT UserID) AS active_users",
+	"COALESCE(SUM(ToolCalls), 0) AS tool_calls",
+	"COUNT(*) FILTER (WHERE Failed) AS failures",
+	"COALESCE(AVG(LatencyMS), 0) AS average_latency_ms",
+}
+
+func (s *Service) selectStats(query UsageQuery, extraColumns ...string) sq.SelectBuilder {
+	builder := s.db.Builder().
+		Select(append(extraColumns, statsColumns...)...).
+		From("LLM_UsageEvents").
+		Where(sq.GtOrEq{"CreateAt": query.Since}).
+		Where(sq.Lt{"CreateAt": query.Until})
+	if query.TeamID != "" {
+		builder = builder.Where(sq.Eq{"TeamID": query.TeamID})
+	}
+	return builder
+}
+
+// GetUsageReport aggregates the usage events matching query.
+func (s *Service) GetUsageReport(query UsageQuery) (*UsageReport, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("analytics not available")
+	}
+
+	report := &UsageReport{
+		Since:     query.Since,
+		Until:     query.Until,
+		ByFeature: []FeatureUsage{},
+		ByTeam:    []TeamUsage{},
+		ByDay:     []DailyUsage{},
+	}
+
+	var totals []UsageStats
+	if err := s.db.DoQuery(&totals, s.selectStats(query)); err != nil {
+		return nil, fmt.Errorf("failed to get usage totals: %w", err)
+	}
+	if len(totals) > 0 {
+		report.Totals = totals[0]
+	}
+
+	if err := s.db.DoQuery(&report.ByFeature, s.selectStats(query, "Feature AS feature").
+		GroupBy("Feature").
+		OrderBy("requests DESC")); err != nil {
+		return nil, fmt.Errorf("failed to get usage by feature: %w", err)
+	}
+
+	if err := s.db.DoQuery(&report.ByTeam, s.selectStats(query, "TeamID AS teamid").
+		GroupBy("TeamID").
+		OrderBy("requests DESC")); err != nil {
+		return nil, fmt.Errorf("failed to get usage by team: %w", err)
+	}
+
+	dayColumn := fmt.Sprintf("(CreateAt / %d) * %d AS day", dayMillis, dayMillis)
+	if err := s.db.DoQuery(&report.ByDay, s.selectStats(query, dayColumn).
+		GroupBy("day").
+		OrderBy("day ASC")); err != nil {
+		return nil, fmt.Errorf("failed to get usage by day: %w", err)
+	}
+
+	return report, nil
+}
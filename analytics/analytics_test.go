@@ -0,0 +1,127 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackStream(t *testing.T) {
+	tests := []struct {
+		name              string
+		events            []llm.TextStreamEvent
+		expectedToolCalls int
+		expectedFailed    bool
+	}{
+		{
+			name: "text response",
+			events: []llm.TextStreamEvent{
+				{Type: llm.EventTypeText, Value: "hello"},
+				{Type: llm.EventTypeEnd},
+			},
+		},
+		{
+			name: "tool calls are counted",
+			events: []llm.TextStreamEvent{
+				{Type: llm.EventTypeText, Value: "let me check"},
+				{Type: llm.EventTypeToolCalls, Value: []llm.ToolCall{{ID: "1"}, {ID: "2"}}},
+				{Type: llm.EventTypeEnd},
+			},
+			expectedToolCalls: 2,
+		},
+		{
+			name: "error marks the event as failed",
+			events: []llm.TextStreamEvent{
+				{Type: llm.EventTypeText, Value: "partial"},
+				{Type: llm.EventTypeError, Value: errors.New("boom")},
+			},
+			expectedFailed: true,
+		},
+		{
+			name:   "stream closed without end is still recorded",
+			events: []llm.TextStreamEvent{{Type: llm.EventTypeText, Value: "cut off"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			input := make(chan llm.TextStreamEvent, len(tc.events))
+			for _, event := range tc.events {
+				input <- event
+			}
+			close(input)
+
+			var recorded []Event
+			tracked := trackStream(&llm.TextStreamResult{Stream: input}, NewEvent(FeatureMention, "botid", "userid", nil), func(event Event) {
+				recorded = append(recorded, event)
+			})
+
+			var received []llm.TextStreamEvent
+			for event := range tracked.Stream {
+				received = append(received, event)
+			}
+
+			assert.Equal(t, tc.events, received, "events must be passed through unchanged")
+			require.Len(t, recorded, 1)
+			assert.Equal(t, FeatureMention, recorded[0].Feature)
+			assert.Equal(t, tc.expectedToolCalls, recorded[0].ToolCalls)
+			assert.Equal(t, tc.expectedFailed, recorded[0].Failed)
+			assert.GreaterOrEqual(t, recorded[0].LatencyMS, int64(0))
+		})
+	}
+}
+
+func TestNewEvent(t *testing.T) {
+	tests := []struct {
+		name              string
+		channel           *model.Channel
+		expectedTeamID    string
+		expectedChannelID string
+	}{
+		{
+			name:           "no channel",
+			expectedTeamID: TeamIDDirect,
+		},
+		{
+			name:              "team channel",
+			channel:           &model.Channel{Id: "channelid", TeamId: "teamid", Type: model.ChannelTypeOpen},
+			expectedTeamID:    "teamid",
+			expectedChannelID: "channelid",
+		},
+		{
+			name:              "direct message",
+			channel:           &model.Channel{Id: "dmid", Type: model.ChannelTypeDirect},
+			expectedTeamID:    TeamIDDirect,
+			expectedChannelID: "dmid",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			event := NewEvent(FeatureDirectMessage, "botid", "userid", tc.channel)
+			assert.Equal(t, tc.expectedTeamID, event.TeamID)
+			assert.Equal(t, tc.expectedChannelID, event.ChannelID)
+			assert.Equal(t, "userid", event.UserID)
+			assert.Equal(t, "botid", event.BotID)
+		})
+	}
+}
+
+func TestNilServiceIsNoop(t *testing.T) {
+	var service *Service
+	stream := llm.NewStreamFromString("hello")
+
+	assert.Same(t, stream, service.TrackStream(stream, Event{}))
+	assert.NoError(t, service.Record(Event{}))
+
+	_, err := service.GetUsageReport(UsageQuery{Since: 0, Until: time.Now().UnixMilli()})
+	assert.Error(t, err)
+}
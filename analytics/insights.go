@@ -0,0 +1,173 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package analytics
+
+import (
+	"fmt"
+	"slices"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Metric is an aggregate of the usage events that insights can compute.
+type Metric string
+
+const (
+	MetricRequests         Metric = "requests"
+	MetricActiveUsers      Metric = "active_users"
+	MetricToolCalls        Metric = "tool_calls"
+	MetricFailures         Metric = "failures"
+	MetricAverageLatencyMS Metric = "average_latency_ms"
+)
+
+// Dimension is what insights can group the usage events by.
+type Dimension string
+
+const (
+	DimensionNone    Dimension = ""
+	DimensionFeature Dimension = "feature"
+	DimensionTeam    Dimension = "team"
+	DimensionBot     Dimension = "bot"
+	DimensionDay     Dimension = "day"
+)
+
+const (
+	// DefaultInsightsLimit is the number of groups returned when the query has no limit
+	DefaultInsightsLimit = 10
+	// MaxInsightsLimit is the largest number of groups returned at once
+	MaxInsightsLimit = 50
+)
+
+// insightsMetrics and insightsDimensions are the only SQL expressions insights run, so the
+// queries only ever read aggregates of LLM_UsageEvents and the names of teams and bots.
+var insightsMetrics = map[Metric]string{
+	MetricRequests:         "COUNT(*)",
+	MetricActiveUsers:      "COUNT(DISTINCT e.UserID)",
+	MetricToolCalls:        "COALESCE(SUM(e.ToolCalls), 0)",
+	MetricFailures:         "COUNT(*) FILTER (WHERE e.Failed)",
+	MetricAverageLatencyMS: "COALESCE(AVG(e.LatencyMS), 0)",
+}
+
+var insightsDimensions = map[Dimension]struct {
+	column string
+	join   string
+}{
+	DimensionFeature: {column: "e.Feature"},
+	DimensionTeam:    {column: "COALESCE(t.DisplayName, e.TeamID)", join: "Teams t ON t.Id = e.TeamID"},
+	DimensionBot:     {column: "COALESCE(u.Username, e.BotID)", join: "Users u ON u.Id = e.BotID"},
+	DimensionDay:     {column: "TO_CHAR(TO_TIMESTAMP(e.CreateAt / 1000) AT TIME ZONE 'UTC', 'YYYY-MM-DD')"},
+}
+
+// Metrics returns the metrics insights can compute.
+func Metrics() []Metric {
+	return []Metric{MetricRequests, MetricActiveUsers, MetricToolCalls, MetricFailures, MetricAverageLatencyMS}
+}
+
+// Dimensions returns the dimensions insights can group by, besides DimensionNone.
+func Dimensions() []Dimension {
+	return []Dimension{DimensionFeature, DimensionTeam, DimensionBot, DimensionDay}
+}
+
+// Features returns the features recorded in the usage events.
+func Features() []string {
+	return []string{FeatureDirectMessage, FeatureMention, FeatureThreadAnalysis, FeatureChannelAnalysis, FeatureChannelInterval, FeatureSearch, FeatureDraftReply, FeatureReactionTrigger, FeatureExplainPost, FeatureMentionsSummary}
+}
+
+// InsightsQuery is an aggregate of the usage events in [Since, Until), optionally grouped and
+// filtered by feature and team.
+type InsightsQuery struct {
+	Metric  Metric
+	GroupBy Dimension
+	Since   int64
+	Until   int64
+	Feature string
+	TeamID  string
+	Limit   int
+}
+
+// InsightsRow is the value of the metric for a group, Group is empty when not grouping.
+type InsightsRow struct {
+	Group string  `json:"group" db:"grp"`
+	Value float64 `json:"value" db:"value"`
+}
+
+// Validate checks the query only uses the allowed metrics, dimensions and features.
+func (q InsightsQuery) Validate() error {
+	if _, ok := insightsMetrics[q.Metric]; !ok {
+		return fmt.Errorf("unknown metric %q", q.Metric)
+	}
+	if _, ok := insightsDimensions[q.GroupBy]; !ok && q.GroupBy != DimensionNone {
+		return fmt.Errorf("unknown dimension %q", q.GroupBy)
+	}
+	if q.Feature != "" && !slices.Contains(Features(), q.Feature) {
+		return fmt.Errorf("unknown feature %q", q.Feature)
+	}
+	if q.Since >= q.Until {
+		return fmt.Errorf("the start must be before the end")
+	}
+	if q.Limit < 0 || q.Limit > MaxInsightsLimit {
+		return fmt.Errorf("the limit must be between 1 and %d", MaxInsightsLimit)
+	}
+	return nil
+}
+
+func buildInsightsQuery(builder sq.StatementBuilderType, query InsightsQuery) (sq.SelectBuilder, error) {
+	if err := query.Validate(); err != nil {
+		return sq.SelectBuilder{}, err
+	}
+
+	columns := []string{insightsMetrics[query.Metric] + " AS value"}
+	dimension, grouped := insightsDimensions[query.GroupBy]
+	if grouped {
+		columns = append([]string{dimension.column + " AS grp"}, columns...)
+	} else {
+		columns = append([]string{"'' AS grp"}, columns...)
+	}
+
+	selectBuilder := builder.Select(columns...).
+		From("LLM_UsageEvents e").
+		Where(sq.GtOrEq{"e.CreateAt": query.Since}).
+		Where(sq.Lt{"e.CreateAt": query.Until})
+	if dimension.join != "" {
+		selectBuilder = selectBuilder.LeftJoin(dimension.join)
+	}
+	if query.Feature != "" {
+		selectBuilder = selectBuilder.Where(sq.Eq{"e.Feature": query.Feature})
+	}
+	if query.TeamID != "" {
+		selectBuilder = selectBuilder.Where(sq.Eq{"e.TeamID": query.TeamID})
+	}
+	if !grouped {
+		return selectBuilder, nil
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultInsightsLimit
+	}
+	selectBuilder = selectBuilder.GroupBy("grp").Limit(uint64(limit))
+	if query.GroupBy == DimensionDay {
+		return selectBuilder.OrderBy("grp ASC"), nil
+	}
+	return selectBuilder.OrderBy("value DESC", "grp ASC"), nil
+}
+
+// QueryInsights computes an aggregate of the usage events. It never reads message content.
+func (s *Service) QueryInsights(query InsightsQuery) ([]InsightsRow, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("analytics not available")
+	}
+
+	selectBuilder, err := buildInsightsQuery(s.db.Builder(), query)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []InsightsRow{}
+	if err := s.db.DoQuery(&rows, selectBuilder); err != nil {
+		return nil, fmt.Errorf("failed to query usage insights: %w", err)
+	}
+
+	return rows, nil
+}
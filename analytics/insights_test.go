@@ -0,0 +1,82 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package analytics
+
+import (
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildInsightsQuery(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        InsightsQuery
+		expectedSQL  string
+		expectedArgs []any
+		expectError  bool
+	}{
+		{
+			name:         "total",
+			query:        InsightsQuery{Metric: MetricRequests, Since: 1, Until: 2},
+			expectedSQL:  "SELECT '' AS grp, COUNT(*) AS value FROM LLM_UsageEvents e WHERE e.CreateAt >= $1 AND e.CreateAt < $2",
+			expectedArgs: []any{int64(1), int64(2)},
+		},
+		{
+			name:         "by team for a feature",
+			query:        InsightsQuery{Metric: MetricActiveUsers, GroupBy: DimensionTeam, Feature: FeatureThreadAnalysis, Since: 1, Until: 2, Limit: 3},
+			expectedSQL:  "SELECT COALESCE(t.DisplayName, e.TeamID) AS grp, COUNT(DISTINCT e.UserID) AS value FROM LLM_UsageEvents e LEFT JOIN Teams t ON t.Id = e.TeamID WHERE e.CreateAt >= $1 AND e.CreateAt < $2 AND e.Feature = $3 GROUP BY grp ORDER BY value DESC, grp ASC LIMIT 3",
+			expectedArgs: []any{int64(1), int64(2), FeatureThreadAnalysis},
+		},
+		{
+			name:         "by day in a team",
+			query:        InsightsQuery{Metric: MetricFailures, GroupBy: DimensionDay, TeamID: "team1", Since: 1, Until: 2},
+			expectedSQL:  "SELECT TO_CHAR(TO_TIMESTAMP(e.CreateAt / 1000) AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS grp, COUNT(*) FILTER (WHERE e.Failed) AS value FROM LLM_UsageEvents e WHERE e.CreateAt >= $1 AND e.CreateAt < $2 AND e.TeamID = $3 GROUP BY grp ORDER BY grp ASC LIMIT 10",
+			expectedArgs: []any{int64(1), int64(2), "team1"},
+		},
+		{
+			name:        "unknown metric",
+			query:       InsightsQuery{Metric: "message", Since: 1, Until: 2},
+			expectError: true,
+		},
+		{
+			name:        "unknown dimension",
+			query:       InsightsQuery{Metric: MetricRequests, GroupBy: "channel", Since: 1, Until: 2},
+			expectError: true,
+		},
+		{
+			name:        "unknown feature",
+			query:       InsightsQuery{Metric: MetricRequests, Feature: "' OR 1=1", Since: 1, Until: 2},
+			expectError: true,
+		},
+		{
+			name:        "empty range",
+			query:       InsightsQuery{Metric: MetricRequests, Since: 2, Until: 2},
+			expectError: true,
+		},
+		{
+			name:        "limit too large",
+			query:       InsightsQuery{Metric: MetricRequests, GroupBy: DimensionBot, Since: 1, Until: 2, Limit: MaxInsightsLimit + 1},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			selectBuilder, err := buildInsightsQuery(sq.StatementBuilder.PlaceholderFormat(sq.Dollar), tc.query)
+			if tc.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			sql, args, err := selectBuilder.ToSql()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedSQL, sql)
+			assert.Equal(t, tc.expectedArgs, args)
+		})
+	}
+}
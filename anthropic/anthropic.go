@@ -6,10 +6,13 @@ package anthropic
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
+	"unicode/utf8"
 
 	anthropicSDK "github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
@@ -32,6 +35,7 @@ type messageState struct {
 	tools    []llm.Tool
 	resolver func(name string, argsGetter llm.ToolArgumentGetter, context *llm.Context) (string, error)
 	context  *llm.Context
+	budget   *llm.StepBudgetTracker
 }
 
 type Anthropic struct {
@@ -40,6 +44,7 @@ type Anthropic struct {
 	inputTokenLimit    int
 	outputTokenLimit   int
 	enabledNativeTools []string
+	webSearch          llm.NativeWebSearchConfig
 	reasoningEnabled   bool
 	thinkingBudget     int
 }
@@ -56,6 +61,7 @@ func New(llmService llm.ServiceConfig, botConfig llm.BotConfig, httpClient *http
 		inputTokenLimit:    llmService.InputTokenLimit,
 		outputTokenLimit:   llmService.OutputTokenLimit,
 		enabledNativeTools: botConfig.EnabledNativeTools,
+		webSearch:          botConfig.NativeWebSearch,
 		reasoningEnabled:   botConfig.ReasoningEnabled,
 		thinkingBudget:     botConfig.ThinkingBudget,
 	}
@@ -211,12 +217,9 @@ func (a *Anthropic) buildAPIParams(state *messageState) anthropicSDK.MessageNewP
 	if !state.config.ToolsDisabled {
 		params.Tools = convertTools(state.tools)
 
-		if a.isNativeToolEnabled("web_search") {
+		if a.isNativeToolEnabled("web_search") && !slices.Contains(state.config.DisabledNativeTools, "web_search") {
 			params.Tools = append(params.Tools, anthropicSDK.ToolUnionParam{
-				OfWebSearchTool20250305: &anthropicSDK.WebSearchTool20250305Param{
-					Name: "web_search",
-					Type: "web_search_20250305",
-				},
+				OfWebSearchTool20250305: a.webSearchTool(),
 			})
 		}
 	}
@@ -234,8 +237,8 @@ func (a *Anthropic) buildAPIParams(state *messageState) anthropicSDK.MessageNewP
 	return params
 }
 
-func (a *Anthropic) processStream(state *messageState, params anthropicSDK.MessageNewParams) streamResult {
-	stream := a.client.Messages.NewStreaming(context.Background(), params)
+func (a *Anthropic) processStream(ctx context.Context, state *messageState, params anthropicSDK.MessageNewParams) streamResult {
+	stream := a.client.Messages.NewStreaming(ctx, params)
 
 	var message anthropicSDK.Message
 	var thinkingBuffer, signatureBuffer strings.Builder
@@ -382,41 +385,53 @@ func (a *Anthropic) emitPostStreamEvents(state *messageState, message anthropicS
 		}
 	}
 
+	usage := llm.TokenUsage{
+		InputTokens:  message.Usage.InputTokens,
+		OutputTokens: message.Usage.OutputTokens,
+	}
+	state.budget.AddUsage(usage)
 	state.output <- llm.TextStreamEvent{
-		Type: llm.EventTypeUsage,
-		Value: llm.TokenUsage{
-			InputTokens:  message.Usage.InputTokens,
-			OutputTokens: message.Usage.OutputTokens,
-		},
+		Type:  llm.EventTypeUsage,
+		Value: usage,
 	}
 }
 
-func (a *Anthropic) streamChatWithTools(initialState messageState) {
+func (a *Anthropic) streamChatWithTools(ctx context.Context, initialState messageState) {
 	state := initialState
 
 	for state.depth < MaxToolResolutionDepth {
-		result := a.processStream(&state, a.buildAPIParams(&state))
+		result := a.processStream(ctx, &state, a.buildAPIParams(&state))
 
 		if result.err != nil {
-			state.output <- llm.TextStreamEvent{Type: llm.EventTypeError, Value: result.err}
+			state.output <- llm.TextStreamEvent{Type: llm.EventTypeError, Value: providerError(result.err)}
 			return
 		}
 
 		if len(result.pendingToolCalls) > 0 && llm.ShouldAutoRunTools(result.pendingToolCalls, state.config.AutoRunTools) {
+			a.emitPostStreamEvents(&state, result.message)
+			if state.budget.StopIfExceeded(state.output) {
+				return
+			}
+
 			state.messages = append(state.messages, buildAssistantMessage(result.message))
 
 			toolResults := llm.ExecuteAutoRunTools(
 				result.pendingToolCalls,
 				state.resolver,
 				state.context,
+				state.output,
 			)
 			state.messages = append(state.messages, buildToolResultsMessage(toolResults))
 
-			a.emitPostStreamEvents(&state, result.message)
 			state.depth++
 			continue
 		}
 
+		state.output <- llm.TextStreamEvent{
+			Type:  llm.EventTypeFinish,
+			Value: finishReason(result.message.StopReason),
+		}
+
 		if len(result.pendingToolCalls) > 0 {
 			state.output <- llm.TextStreamEvent{
 				Type:  llm.EventTypeToolCalls,
@@ -435,6 +450,29 @@ func (a *Anthropic) streamChatWithTools(initialState messageState) {
 	}
 }
 
+// providerError classifies an error returned by the Anthropic API so it can be explained to users.
+func providerError(err error) error {
+	var apiErr *anthropicSDK.Error
+	if errors.As(err, &apiErr) {
+		return llm.NewProviderError(apiErr.StatusCode, err)
+	}
+	return err
+}
+
+// finishReason maps the reason a message stopped to a finish reason of the stream
+func finishReason(stopReason anthropicSDK.StopReason) string {
+	switch stopReason {
+	case anthropicSDK.StopReasonMaxTokens:
+		return llm.FinishReasonLength
+	case anthropicSDK.StopReasonToolUse:
+		return llm.FinishReasonToolUse
+	case anthropicSDK.StopReasonRefusal:
+		return llm.FinishReasonRefusal
+	default:
+		return llm.FinishReasonStop
+	}
+}
+
 func (a *Anthropic) extractAnnotations(message anthropicSDK.Message) []llm.Annotation {
 	var annotations []llm.Annotation
 	textPosition := 0
@@ -451,7 +489,7 @@ func (a *Anthropic) extractAnnotations(message anthropicSDK.Message) []llm.Annot
 		}
 
 		startPos := textPosition
-		endPos := textPosition + len(textBlock.Text)
+		endPos := textPosition + utf8.RuneCountInString(textBlock.Text)
 		textPosition = endPos
 
 		for _, citation := range textBlock.Citations {
@@ -476,7 +514,7 @@ func (a *Anthropic) extractAnnotations(message anthropicSDK.Message) []llm.Annot
 	return annotations
 }
 
-func (a *Anthropic) ChatCompletion(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+func (a *Anthropic) ChatCompletion(ctx context.Context, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
 	eventStream := make(chan llm.TextStreamEvent)
 
 	cfg := a.createConfig(opts)
@@ -490,6 +528,7 @@ func (a *Anthropic) ChatCompletion(request llm.CompletionRequest, opts ...llm.La
 		depth:    0,
 		config:   cfg,
 		context:  request.Context,
+		budget:   llm.NewStepBudgetTracker(cfg.StepBudget),
 	}
 
 	if request.Context.Tools != nil {
@@ -499,15 +538,15 @@ func (a *Anthropic) ChatCompletion(request llm.CompletionRequest, opts ...llm.La
 
 	go func() {
 		defer close(eventStream)
-		a.streamChatWithTools(initialState)
+		a.streamChatWithTools(ctx, initialState)
 	}()
 
-	return &llm.TextStreamResult{Stream: eventStream}, nil
+	return &llm.TextStreamResult{Stream: llm.AggregateUsage(eventStream)}, nil
 }
 
-func (a *Anthropic) ChatCompletionNoStream(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
+func (a *Anthropic) ChatCompletionNoStream(ctx context.Context, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
 	// This could perform better if we didn't use the streaming API here, but the complexity is not worth it.
-	result, err := a.ChatCompletion(request, opts...)
+	result, err := a.ChatCompletion(ctx, request, opts...)
 	if err != nil {
 		return "", err
 	}
@@ -560,6 +599,50 @@ func (a *Anthropic) isNativeToolEnabled(toolName string) bool {
 	return false
 }
 
+// webSearchTool returns the native web search tool restricted by the bot's web search configuration.
+func (a *Anthropic) webSearchTool() *anthropicSDK.WebSearchTool20250305Param {
+	tool := &anthropicSDK.WebSearchTool20250305Param{
+		Name:           "web_search",
+		Type:           "web_search_20250305",
+		AllowedDomains: cleanDomains(a.webSearch.AllowedDomains),
+		BlockedDomains: cleanDomains(a.webSearch.BlockedDomains),
+	}
+
+	if a.webSearch.MaxUses > 0 {
+		tool.MaxUses = anthropicSDK.Int(int64(a.webSearch.MaxUses))
+	}
+
+	location := a.webSearch.UserLocation
+	if location != (llm.WebSearchUserLocation{}) {
+		tool.UserLocation = anthropicSDK.WebSearchTool20250305UserLocationParam{Type: "approximate"}
+		if location.City != "" {
+			tool.UserLocation.City = anthropicSDK.String(location.City)
+		}
+		if location.Region != "" {
+			tool.UserLocation.Region = anthropicSDK.String(location.Region)
+		}
+		if location.Country != "" {
+			tool.UserLocation.Country = anthropicSDK.String(strings.ToUpper(location.Country))
+		}
+		if location.Timezone != "" {
+			tool.UserLocation.Timezone = anthropicSDK.String(location.Timezone)
+		}
+	}
+
+	return tool
+}
+
+// cleanDomains trims the domains and drops empty ones, returning nil when none remain.
+func cleanDomains(domains []string) []string {
+	var cleaned []string
+	for _, domain := range domains {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			cleaned = append(cleaned, domain)
+		}
+	}
+	return cleaned
+}
+
 // calculateThinkingConfig returns the thinking configuration if reasoning is enabled and valid.
 func (a *Anthropic) calculateThinkingConfig(maxGeneratedTokens int) (anthropicSDK.ThinkingConfigParamUnion, bool) {
 	if !a.reasoningEnabled {
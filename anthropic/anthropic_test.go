@@ -682,3 +682,76 @@ func TestThinkingBudgetConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestWebSearchTool(t *testing.T) {
+	tests := []struct {
+		name      string
+		webSearch llm.NativeWebSearchConfig
+		expected  string
+	}{
+		{
+			name:     "unrestricted",
+			expected: `{"name":"web_search","type":"web_search_20250305"}`,
+		},
+		{
+			name: "allowed domains, max uses and location",
+			webSearch: llm.NativeWebSearchConfig{
+				AllowedDomains: []string{" docs.example.com ", "", "wiki.example.com"},
+				MaxUses:        3,
+				UserLocation: llm.WebSearchUserLocation{
+					City:     "Toronto",
+					Country:  "ca",
+					Timezone: "America/Toronto",
+				},
+			},
+			expected: `{"max_uses":3,"allowed_domains":["docs.example.com","wiki.example.com"],"user_location":{"city":"Toronto","country":"CA","timezone":"America/Toronto","type":"approximate"},"name":"web_search","type":"web_search_20250305"}`,
+		},
+		{
+			name: "blocked domains",
+			webSearch: llm.NativeWebSearchConfig{
+				BlockedDomains: []string{"spam.example.com"},
+			},
+			expected: `{"blocked_domains":["spam.example.com"],"name":"web_search","type":"web_search_20250305"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Anthropic{webSearch: tt.webSearch}
+
+			data, err := json.Marshal(a.webSearchTool())
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(data))
+		})
+	}
+}
+
+func TestDisabledNativeTools(t *testing.T) {
+	a := &Anthropic{enabledNativeTools: []string{"web_search"}}
+
+	params := a.buildAPIParams(&messageState{config: llm.LanguageModelConfig{}})
+	require.Len(t, params.Tools, 1)
+	assert.NotNil(t, params.Tools[0].OfWebSearchTool20250305)
+
+	params = a.buildAPIParams(&messageState{config: llm.LanguageModelConfig{DisabledNativeTools: []string{"web_search"}}})
+	assert.Empty(t, params.Tools)
+}
+
+func TestFinishReason(t *testing.T) {
+	tests := []struct {
+		stopReason anthropicSDK.StopReason
+		expected   string
+	}{
+		{stopReason: anthropicSDK.StopReasonEndTurn, expected: llm.FinishReasonStop},
+		{stopReason: anthropicSDK.StopReasonStopSequence, expected: llm.FinishReasonStop},
+		{stopReason: anthropicSDK.StopReasonMaxTokens, expected: llm.FinishReasonLength},
+		{stopReason: anthropicSDK.StopReasonToolUse, expected: llm.FinishReasonToolUse},
+		{stopReason: anthropicSDK.StopReasonRefusal, expected: llm.FinishReasonRefusal},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.stopReason), func(t *testing.T) {
+			assert.Equal(t, tc.expected, finishReason(tc.stopReason))
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package anthropic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	anthropicSDK "github.com/anthropics/anthropic-sdk-go"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+func (a *Anthropic) batchRequestParams(request llm.BatchRequest) anthropicSDK.MessageBatchNewParamsRequest {
+	maxTokens := request.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = a.outputTokenLimit
+	}
+	if maxTokens == 0 {
+		maxTokens = DefaultMaxTokens
+	}
+
+	params := anthropicSDK.MessageBatchNewParamsRequestParams{
+		Model:     anthropicSDK.Model(a.defaultModel),
+		MaxTokens: int64(maxTokens),
+		Messages: []anthropicSDK.MessageParam{
+			anthropicSDK.NewUserMessage(anthropicSDK.NewTextBlock(request.Prompt)),
+		},
+	}
+	if request.System != "" {
+		params.System = []anthropicSDK.TextBlockParam{{Text: request.System}}
+	}
+
+	return anthropicSDK.MessageBatchNewParamsRequest{
+		CustomID: request.CustomID,
+		Params:   params,
+	}
+}
+
+// SubmitBatch creates a message batch. Anthropic does not provide embeddings.
+func (a *Anthropic) SubmitBatch(ctx context.Context, batchType string, requests []llm.BatchRequest) (string, error) {
+	if batchType != llm.BatchTypeCompletion {
+		return "", fmt.Errorf("%w: %s", llm.ErrBatchUnsupported, batchType)
+	}
+
+	params := anthropicSDK.MessageBatchNewParams{
+		Requests: make([]anthropicSDK.MessageBatchNewParamsRequest, 0, len(requests)),
+	}
+	for _, request := range requests {
+		params.Requests = append(params.Requests, a.batchRequestParams(request))
+	}
+
+	batch, err := a.client.Messages.Batches.New(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to create message batch: %w", err)
+	}
+
+	return batch.ID, nil
+}
+
+func (a *Anthropic) BatchStatus(ctx context.Context, batchID string) (string, error) {
+	batch, err := a.client.Messages.Batches.Get(ctx, batchID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get message batch: %w", err)
+	}
+
+	if batch.ProcessingStatus != anthropicSDK.MessageBatchProcessingStatusEnded {
+		return llm.BatchStatusInProgress, nil
+	}
+	if !batch.CancelInitiatedAt.IsZero() {
+		return llm.BatchStatusCanceled, nil
+	}
+	return llm.BatchStatusCompleted, nil
+}
+
+// batchResult converts the result of one request of a message batch
+func batchResult(response anthropicSDK.MessageBatchIndividualResponse) llm.BatchResult {
+	result := llm.BatchResult{CustomID: response.CustomID}
+
+	switch response.Result.Type {
+	case "succeeded":
+		var output strings.Builder
+		for _, block := range response.Result.Message.Content {
+			if block.Type == "text" {
+				output.WriteString(block.Text)
+			}
+		}
+		result.Output = output.String()
+	case "errored":
+		result.Error = response.Result.Error.Error.Message
+	default:
+		// Canceled and expired requests have no further details
+		result.Error = response.Result.Type
+	}
+
+	return result
+}
+
+func (a *Anthropic) BatchResults(ctx context.Context, batchID string) ([]llm.BatchResult, error) {
+	stream := a.client.Messages.Batches.ResultsStreaming(ctx, batchID)
+	defer stream.Close()
+
+	var results []llm.BatchResult
+	for stream.Next() {
+		results = append(results, batchResult(stream.Current()))
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get message batch results: %w", err)
+	}
+
+	return results, nil
+}
+
+func (a *Anthropic) CancelBatch(ctx context.Context, batchID string) error {
+	if _, err := a.client.Messages.Batches.Cancel(ctx, batchID); err != nil {
+		return fmt.Errorf("failed to cancel message batch: %w", err)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,12 +13,22 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
 	"github.com/mattermost/mattermost-plugin-ai/anthropic"
+	"github.com/mattermost/mattermost-plugin-ai/batch"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost-plugin-ai/digests"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/faq"
+	"github.com/mattermost/mattermost-plugin-ai/glossary"
 	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/imagetext"
+	"github.com/mattermost/mattermost-plugin-ai/incidents"
 	"github.com/mattermost/mattermost-plugin-ai/indexer"
+	"github.com/mattermost/mattermost-plugin-ai/integrations"
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/llmcontext"
 	"github.com/mattermost/mattermost-plugin-ai/mcp"
@@ -26,8 +37,22 @@ import (
 	"github.com/mattermost/mattermost-plugin-ai/metrics"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
 	"github.com/mattermost/mattermost-plugin-ai/openai"
+	"github.com/mattermost/mattermost-plugin-ai/permalinks"
+	"github.com/mattermost/mattermost-plugin-ai/promptstore"
+	"github.com/mattermost/mattermost-plugin-ai/ratelimit"
+	"github.com/mattermost/mattermost-plugin-ai/reminders"
+	"github.com/mattermost/mattermost-plugin-ai/retention"
+	"github.com/mattermost/mattermost-plugin-ai/savedanswers"
 	"github.com/mattermost/mattermost-plugin-ai/search"
+	"github.com/mattermost/mattermost-plugin-ai/secrets"
+	"github.com/mattermost/mattermost-plugin-ai/standups"
 	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost-plugin-ai/summaryexports"
+	"github.com/mattermost/mattermost-plugin-ai/teaminstructions"
+	"github.com/mattermost/mattermost-plugin-ai/traces"
+	"github.com/mattermost/mattermost-plugin-ai/userdata"
+	"github.com/mattermost/mattermost-plugin-ai/userkeys"
+	"github.com/mattermost/mattermost-plugin-ai/verification"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
@@ -43,6 +68,9 @@ type Config interface {
 	GetDefaultBotName() string
 	MCP() mcp.Config
 	AllowUnsafeLinks() bool
+	EmbeddingSearchConfig() embeddings.EmbeddingSearchConfig
+	RateLimit() ratelimit.Config
+	GetMaxIntervalPosts() int
 }
 
 type MCPClientManager interface {
@@ -75,6 +103,32 @@ type API struct {
 	mcpClientManager      MCPClientManager
 	mcpHandlers           *mcpserver.PluginMCPHandlers
 	llmUpstreamHTTPClient *http.Client
+	jobsService           *jobs.Service
+	analyticsService      *analytics.Service
+	promptStore           *promptstore.Store
+	teamInstructions      *teaminstructions.Store
+	glossary              *glossary.Store
+	traceStore            *traces.Store
+	userKeys              *userkeys.Store
+	secrets               *secrets.Manager
+	batchService          *batch.Service
+	integrations          *integrations.Service
+	incidentCopilot       *incidents.Service
+	faqService            *faq.Service
+	standups              *standups.Service
+	digests               *digests.Service
+	retention             *retention.Service
+	userData              *userdata.Service
+	verification          *verification.Service
+	savedAnswers          *savedanswers.Store
+	reminders             *reminders.Service
+	imageText             *imagetext.Extractor
+	permalinks            *permalinks.Resolver
+	summaryExports        *summaryexports.Service
+	pluginVersion         string
+	// backgroundCtx is canceled when the plugin is deactivated
+	backgroundCtx context.Context
+	rateLimiter   *ratelimit.Limiter
 }
 
 // New creates a new API instance
@@ -97,6 +151,30 @@ func New(
 	mcpClientManager MCPClientManager,
 	mcpHandlers *mcpserver.PluginMCPHandlers,
 	llmUpstreamHTTPClient *http.Client,
+	jobsService *jobs.Service,
+	analyticsService *analytics.Service,
+	promptStore *promptstore.Store,
+	teamInstructions *teaminstructions.Store,
+	glossaryStore *glossary.Store,
+	traceStore *traces.Store,
+	userKeys *userkeys.Store,
+	secretsManager *secrets.Manager,
+	batchService *batch.Service,
+	integrationsService *integrations.Service,
+	incidentCopilot *incidents.Service,
+	faqService *faq.Service,
+	standupsService *standups.Service,
+	digestsService *digests.Service,
+	retentionService *retention.Service,
+	userDataService *userdata.Service,
+	verificationService *verification.Service,
+	savedAnswers *savedanswers.Store,
+	remindersService *reminders.Service,
+	summaryExports *summaryexports.Service,
+	imageText *imagetext.Extractor,
+	permalinksResolver *permalinks.Resolver,
+	pluginVersion string,
+	backgroundCtx context.Context,
 ) *API {
 	return &API{
 		bots:                  bots,
@@ -118,6 +196,31 @@ func New(
 		mcpClientManager:      mcpClientManager,
 		mcpHandlers:           mcpHandlers,
 		llmUpstreamHTTPClient: llmUpstreamHTTPClient,
+		jobsService:           jobsService,
+		analyticsService:      analyticsService,
+		promptStore:           promptStore,
+		teamInstructions:      teamInstructions,
+		glossary:              glossaryStore,
+		traceStore:            traceStore,
+		userKeys:              userKeys,
+		secrets:               secretsManager,
+		batchService:          batchService,
+		integrations:          integrationsService,
+		incidentCopilot:       incidentCopilot,
+		faqService:            faqService,
+		standups:              standupsService,
+		digests:               digestsService,
+		retention:             retentionService,
+		userData:              userDataService,
+		verification:          verificationService,
+		savedAnswers:          savedAnswers,
+		reminders:             remindersService,
+		imageText:             imageText,
+		permalinks:            permalinksResolver,
+		summaryExports:        summaryExports,
+		pluginVersion:         pluginVersion,
+		backgroundCtx:         backgroundCtx,
+		rateLimiter:           ratelimit.NewLimiter(),
 	}
 }
 
@@ -163,11 +266,24 @@ func (a *API) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Reques
 		})
 	}
 
+	// Uptime monitoring, the details are only returned to system admins
+	router.GET("/api/v1/health", a.handleHealth)
+
+	// Called by inbound email services, authenticated by the digests secret
+	router.POST("/digests/email", a.handleInboundEmail)
+
 	router.Use(a.MattermostAuthorizationRequired)
+	router.Use(a.rateLimitRequired)
 
 	router.GET("/oauth/callback", a.handleOAuthCallback)
 	router.GET("/ai_threads", a.handleGetAIThreads)
+	router.GET("/conversations", a.handleGetConversations)
 	router.GET("/ai_bots", a.handleGetAIBots)
+	router.GET("/ai_bots/resolve", a.handleResolveAIBot)
+	router.GET("/saved_answers", a.handleGetSavedAnswers)
+	router.DELETE("/saved_answers/:savedanswerid", a.handleDeleteSavedAnswer)
+	router.GET("/reminders", a.handleGetReminders)
+	router.DELETE("/reminders/:reminderid", a.handleCancelReminder)
 
 	botRequiredRouter := router.Group("")
 	botRequiredRouter.Use(a.aiBotRequired)
@@ -175,18 +291,65 @@ func (a *API) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Reques
 	postRouter := botRequiredRouter.Group("/post/:postid")
 	postRouter.Use(a.postAuthorizationRequired)
 	postRouter.POST("/react", a.handleReact)
-	postRouter.POST("/analyze", a.handleThreadAnalysis)
+	postRouter.POST("/analyze", a.channelPolicyConfirmationRequired, a.featureLicenseRequired(enterprise.FeatureThreadAnalysis), a.handleThreadAnalysis)
 	postRouter.POST("/transcribe/file/:fileid", a.handleTranscribeFile)
 	postRouter.POST("/summarize_transcription", a.handleSummarizeTranscription)
+	postRouter.POST("/translate_transcription", a.handleTranslateTranscription)
 	postRouter.POST("/stop", a.handleStop)
 	postRouter.POST("/regenerate", a.handleRegenerate)
-	postRouter.POST("/tool_call", a.handleToolCall)
+	postRouter.POST("/follow_up", a.handleFollowUp)
+	postRouter.POST("/regenerate_title", a.handleRegenerateTitle)
+	postRouter.POST("/tool_call", a.featureLicenseRequired(enterprise.FeatureToolCalls), a.handleToolCall)
 	postRouter.POST("/postback_summary", a.handlePostbackSummary)
+	postRouter.POST("/save", a.handleSaveAnswer)
+	postRouter.POST("/draft_reply", a.channelPolicyConfirmationRequired, a.handleDraftReply)
+	postRouter.POST("/explain", a.channelPolicyConfirmationRequired, a.handleExplainPost(false))
+	postRouter.POST("/simplify", a.channelPolicyConfirmationRequired, a.handleExplainPost(true))
 
 	channelRouter := botRequiredRouter.Group("/channel/:channelid")
 	channelRouter.Use(a.channelAuthorizationRequired)
-	channelRouter.POST("/analyze", a.handleChannelAnalysis)
-	channelRouter.POST("/interval", a.handleInterval)
+	channelRouter.POST("/analyze", a.channelPolicyConfirmationRequired, a.featureLicenseRequired(enterprise.FeatureChannelAnalysis), a.handleChannelAnalysis)
+	channelRouter.POST("/interval", a.channelPolicyConfirmationRequired, a.featureLicenseRequired(enterprise.FeatureChannelAnalysis), a.handleInterval)
+	channelRouter.GET("/incident_copilot", a.handleGetIncidentCopilot)
+	channelRouter.POST("/incident_copilot", a.channelPolicyConfirmationRequired, a.featureLicenseRequired(enterprise.FeatureIncidentCopilot), a.handleStartIncidentCopilot)
+	channelRouter.DELETE("/incident_copilot", a.handleStopIncidentCopilot)
+	channelRouter.POST("/incident_copilot/refresh", a.channelPolicyConfirmationRequired, a.featureLicenseRequired(enterprise.FeatureIncidentCopilot), a.handleRefreshIncidentCopilot)
+	channelRouter.GET("/faq", a.handleGetFAQ)
+	channelRouter.POST("/faq", a.channelPolicyConfirmationRequired, a.featureLicenseRequired(enterprise.FeatureFAQBuilder), a.handleBuildFAQ)
+	channelRouter.DELETE("/faq", a.handleDeleteFAQ)
+	channelRouter.GET("/faq/markdown", a.handleExportFAQ)
+
+	teamInstructionsRouter := router.Group("/teams/:teamid/instructions")
+	teamInstructionsRouter.Use(a.teamAuthorizationRequired)
+	teamInstructionsRouter.GET("", a.handleGetTeamInstructions)
+	teamInstructionsRouter.PUT("", a.teamAdminAuthorizationRequired, a.handleSaveTeamInstructions)
+	teamInstructionsRouter.DELETE("", a.teamAdminAuthorizationRequired, a.handleDeleteTeamInstructions)
+
+	standupRouter := botRequiredRouter.Group("/teams/:teamid/standup")
+	standupRouter.Use(a.teamAuthorizationRequired)
+	standupRouter.GET("", a.handleGetStandup)
+	standupRouter.PUT("", a.teamAdminAuthorizationRequired, a.handleSaveStandup)
+	standupRouter.DELETE("", a.teamAdminAuthorizationRequired, a.handleDeleteStandup)
+
+	userKeysRouter := router.Group("/user_api_keys")
+	userKeysRouter.GET("", a.handleListUserAPIKeys)
+	userKeyRouter := userKeysRouter.Group("/:serviceid")
+	userKeyRouter.Use(a.userKeyServiceRequired)
+	userKeyRouter.PUT("", a.handleSaveUserAPIKey)
+	userKeyRouter.DELETE("", a.handleDeleteUserAPIKey)
+
+	integrationsRouter := router.Group("/integrations")
+	integrationsRouter.GET("", a.handleListIntegrations)
+	integrationRouter := integrationsRouter.Group("/:integration")
+	integrationRouter.Use(a.integrationRequired)
+	integrationRouter.GET("/connect", a.handleConnectIntegration)
+	integrationRouter.GET("/callback", a.handleIntegrationCallback)
+	integrationRouter.DELETE("", a.handleDisconnectIntegration)
+
+	jobRouter := router.Group("/jobs/:jobid")
+	jobRouter.Use(a.jobAuthorizationRequired)
+	jobRouter.GET("", a.handleGetAnalysisJob)
+	jobRouter.POST("/cancel", a.handleCancelAnalysisJob)
 
 	adminRouter := router.Group("/admin")
 	adminRouter.Use(a.mattermostAdminAuthorizationRequired)
@@ -196,8 +359,55 @@ func (a *API) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Reques
 	adminRouter.GET("/mcp/tools", a.handleGetMCPTools)
 	adminRouter.POST("/mcp/tools/cache/clear", a.handleClearMCPToolsCache)
 	adminRouter.POST("/models/fetch", a.handleFetchModels)
+	adminRouter.POST("/models/validate", a.handleValidateModel)
+	adminRouter.GET("/models/warnings", a.handleGetModelWarnings)
+	adminRouter.GET("/analytics", a.handleGetUsageAnalytics)
+	adminRouter.POST("/evals/run", a.handleRunEvalSuite)
+
+	promptsRouter := adminRouter.Group("/prompts")
+	promptsRouter.GET("", a.handleListPrompts)
+	promptRouter := promptsRouter.Group("/:name")
+	promptRouter.Use(a.promptNameRequired)
+	promptRouter.GET("", a.handleGetPrompt)
+	promptRouter.POST("", a.handleSavePrompt)
+	promptRouter.DELETE("", a.handleResetPrompt)
+	promptRouter.POST("/activate", a.handleActivatePromptVersion)
+
+	glossaryRouter := adminRouter.Group("/glossary")
+	glossaryRouter.GET("", a.handleListGlossary)
+	glossaryRouter.POST("", a.handleCreateGlossaryTerm)
+	glossaryRouter.PUT("/:termid", a.handleUpdateGlossaryTerm)
+	glossaryRouter.DELETE("/:termid", a.handleDeleteGlossaryTerm)
+
+	adminRouter.GET("/traces/:postid", a.handleGetTrace)
+	adminRouter.GET("/payloads", a.handleGetCapturedPayloads)
+	adminRouter.DELETE("/payloads", a.handleClearCapturedPayloads)
+	adminRouter.GET("/secrets", a.handleGetSecretsStatus)
+	adminRouter.POST("/secrets/rotate", a.handleRotateSecrets)
+	adminRouter.GET("/retention", a.handleGetRetentionReport)
+	adminRouter.POST("/retention/purge", a.handlePurgeRetention)
+	adminRouter.GET("/users/:userid/data", a.handleExportUserData)
+	adminRouter.DELETE("/users/:userid/data", a.handleEraseUserData)
+	adminRouter.POST("/providers/:serviceid/test", a.handleTestProvider)
+
+	summaryExportsRouter := adminRouter.Group("/summaries")
+	summaryExportsRouter.POST("", a.aiBotRequired, a.featureLicenseRequired(enterprise.FeatureChannelAnalysis), a.handleStartSummaryExport)
+	summaryExportRouter := summaryExportsRouter.Group("/:jobid")
+	summaryExportRouter.Use(a.summaryExportJobRequired)
+	summaryExportRouter.GET("", a.handleGetSummaryExport)
+	summaryExportRouter.GET("/archive", a.handleDownloadSummaryExport)
+	summaryExportRouter.POST("/cancel", a.handleCancelSummaryExport)
+
+	batchesRouter := adminRouter.Group("/batches")
+	batchesRouter.GET("", a.handleListBatches)
+	batchesRouter.POST("", a.handleSubmitBatch)
+	batchesRouter.GET("/:batchid", a.handleGetBatch)
+	batchesRouter.POST("/:batchid/cancel", a.handleCancelBatch)
+
+	botRequiredRouter.POST("/mentions/summarize", a.featureLicenseRequired(enterprise.FeatureChannelAnalysis), a.handleSummarizeMentions)
 
 	searchRouter := botRequiredRouter.Group("/search")
+	searchRouter.Use(a.featureLicenseRequired(enterprise.FeatureSemanticSearch))
 	// Only returns search results
 	searchRouter.POST("", a.handleSearchQuery)
 	// Initiates a search and responds to the user in a DM with the selected bot
@@ -234,12 +444,22 @@ func (a *API) aiBotRequired(c *gin.Context) {
 	botUsername := c.Query("botUsername")
 	bot := a.bots.GetBotByUsernameOrFirst(botUsername)
 	if bot == nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get bot: %s", botUsername))
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("failed to get bot: %s", botUsername))
 		return
 	}
 	c.Set(ContextBotKey, bot)
 }
 
+// featureLicenseRequired rejects requests for a feature the license of the server does not include.
+func (a *API) featureLicenseRequired(feature enterprise.Feature) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := a.licenseChecker.CheckFeature(feature); err != nil {
+			a.abortWithError(c, http.StatusForbidden, err)
+			return
+		}
+	}
+}
+
 func (a *API) ginlogger(c *gin.Context) {
 	c.Next()
 
@@ -264,6 +484,12 @@ func (a *API) interPluginAuthorizationRequired(c *gin.Context) {
 	c.AbortWithStatus(http.StatusUnauthorized)
 }
 
+// abortWithUsageRestriction rejects a request the bot may not answer. Requests in channels
+// excluded from AI processing are told why, so the webapp can show it to the user.
+func (a *API) abortWithUsageRestriction(c *gin.Context, err error) {
+	a.abortWithError(c, http.StatusForbidden, err)
+}
+
 // enforceEmptyBody checks if the request body is empty returning an error if not
 func (a *API) enforceEmptyBody(c *gin.Context) error {
 	// Check the body is empty
@@ -273,12 +499,20 @@ func (a *API) enforceEmptyBody(c *gin.Context) error {
 	return nil
 }
 
+// generationErrorStatus returns the HTTP status to use for an error returned when starting a generation.
+func generationErrorStatus(err error) int {
+	if errors.Is(err, llm.ErrConcurrencyLimitReached) {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
 func (a *API) handleGetAIThreads(c *gin.Context) {
 	userID := c.GetHeader("Mattermost-User-Id")
 
 	threads, err := a.conversationsService.GetAIThreads(userID)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get posts for bot DM: %w", err))
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("failed to get posts for bot DM: %w", err))
 		return
 	}
 
@@ -317,26 +551,7 @@ func (a *API) getAIBotsForUser(userID string) ([]AIBotInfo, error) {
 			continue
 		}
 
-		// Get the bot DM channel ID. To avoid creating the channel unless nessary
-		/// we return "" if the channel doesn't exist.
-		dmChannelID := ""
-		channelName := model.GetDMNameFromIds(userID, bot.GetMMBot().UserId)
-		botDMChannel, err := a.pluginAPI.Channel.GetByName("", channelName, false)
-		if err == nil {
-			dmChannelID = botDMChannel.Id
-		}
-
-		bots = append(bots, AIBotInfo{
-			ID:                 bot.GetMMBot().UserId,
-			DisplayName:        bot.GetMMBot().DisplayName,
-			Username:           bot.GetMMBot().Username,
-			LastIconUpdate:     bot.GetMMBot().LastIconUpdate,
-			DMChannelID:        dmChannelID,
-			ChannelAccessLevel: bot.GetConfig().ChannelAccessLevel,
-			ChannelIDs:         bot.GetConfig().ChannelIDs,
-			UserAccessLevel:    bot.GetConfig().UserAccessLevel,
-			UserIDs:            bot.GetConfig().UserIDs,
-		})
+		bots = append(bots, a.aiBotInfo(bot, userID))
 		if bot.GetMMBot().Username == defaultBotName {
 			bots[0], bots[i] = bots[i], bots[0]
 		}
@@ -345,11 +560,35 @@ func (a *API) getAIBotsForUser(userID string) ([]AIBotInfo, error) {
 	return bots, nil
 }
 
+// aiBotInfo describes the bot for the user
+func (a *API) aiBotInfo(bot *bots.Bot, userID string) AIBotInfo {
+	// Get the bot DM channel ID. To avoid creating the channel unless nessary
+	/// we return "" if the channel doesn't exist.
+	dmChannelID := ""
+	channelName := model.GetDMNameFromIds(userID, bot.GetMMBot().UserId)
+	botDMChannel, err := a.pluginAPI.Channel.GetByName("", channelName, false)
+	if err == nil {
+		dmChannelID = botDMChannel.Id
+	}
+
+	return AIBotInfo{
+		ID:                 bot.GetMMBot().UserId,
+		DisplayName:        bot.GetMMBot().DisplayName,
+		Username:           bot.GetMMBot().Username,
+		LastIconUpdate:     bot.GetMMBot().LastIconUpdate,
+		DMChannelID:        dmChannelID,
+		ChannelAccessLevel: bot.GetConfig().ChannelAccessLevel,
+		ChannelIDs:         bot.GetConfig().ChannelIDs,
+		UserAccessLevel:    bot.GetConfig().UserAccessLevel,
+		UserIDs:            bot.GetConfig().UserIDs,
+	}
+}
+
 func (a *API) handleGetAIBots(c *gin.Context) {
 	userID := c.GetHeader("Mattermost-User-Id")
 	bots, err := a.getAIBotsForUser(userID)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -373,24 +612,37 @@ type FetchModelsRequest struct {
 func (a *API) handleFetchModels(c *gin.Context) {
 	var req FetchModelsRequest
 	if err := c.BindJSON(&req); err != nil {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
 		return
 	}
 
 	if req.ServiceType == "" {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("serviceType is required"))
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("serviceType is required"))
 		return
 	}
 
+	// Saved services send their stored key, which is encrypted
+	if secrets.IsEncrypted(req.APIKey) {
+		keyring, err := a.secrets.Keyring()
+		if err != nil {
+			a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("failed to decrypt apiKey: %w", err))
+			return
+		}
+		if req.APIKey, err = keyring.Decrypt(req.APIKey); err != nil {
+			a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("failed to decrypt apiKey: %w", err))
+			return
+		}
+	}
+
 	// API key is required for most services, but optional for openaicompatible (some don't require auth)
 	if req.APIKey == "" && req.ServiceType != "openaicompatible" {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("apiKey is required"))
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("apiKey is required"))
 		return
 	}
 
 	// For openaicompatible, require at least an API URL if no API key
 	if req.ServiceType == "openaicompatible" && req.APIKey == "" && req.APIURL == "" {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("apiURL is required for openaicompatible when apiKey is not provided"))
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("apiURL is required for openaicompatible when apiKey is not provided"))
 		return
 	}
 
@@ -400,17 +652,19 @@ func (a *API) handleFetchModels(c *gin.Context) {
 	switch req.ServiceType {
 	case "anthropic":
 		models, err = anthropic.FetchModels(req.APIKey, a.llmUpstreamHTTPClient)
-	case "openai", "azure", "openaicompatible":
+	case "openai", "openaicompatible":
 		models, err = openai.FetchModels(req.APIKey, req.APIURL, req.OrgID, a.llmUpstreamHTTPClient)
+	case "azure":
+		models, err = openai.FetchAzureDeployments(req.APIKey, req.APIURL, a.llmUpstreamHTTPClient)
 	default:
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("model fetching not supported for service type: %s", req.ServiceType))
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("model fetching not supported for service type: %s", req.ServiceType))
 		return
 	}
 
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to fetch models: %w", err))
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("failed to fetch models: %w", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, models)
+	c.JSON(http.StatusOK, a.bots.RecordFetchedModels(req.ServiceType, req.APIURL, models))
 }
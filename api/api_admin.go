@@ -17,11 +17,11 @@ import (
 // handleReindexPosts starts a background job to reindex all posts
 func (a *API) handleReindexPosts(c *gin.Context) {
 	if err := a.enforceEmptyBody(c); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		a.abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 	if a.indexerService == nil {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("search functionality is not configured"))
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("search functionality is not configured"))
 		return
 	}
 
@@ -32,7 +32,7 @@ func (a *API) handleReindexPosts(c *gin.Context) {
 			c.JSON(http.StatusConflict, jobStatus)
 			return
 		default:
-			c.AbortWithError(http.StatusInternalServerError, err)
+			a.abortWithError(c, http.StatusInternalServerError, err)
 			return
 		}
 	}
@@ -57,7 +57,7 @@ func (a *API) handleGetJobStatus(c *gin.Context) {
 			})
 			return
 		}
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get job status: %w", err))
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("failed to get job status: %w", err))
 		return
 	}
 
@@ -67,7 +67,7 @@ func (a *API) handleGetJobStatus(c *gin.Context) {
 // handleCancelJob cancels a running reindex job
 func (a *API) handleCancelJob(c *gin.Context) {
 	if err := a.enforceEmptyBody(c); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		a.abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -92,7 +92,7 @@ func (a *API) handleCancelJob(c *gin.Context) {
 			})
 			return
 		default:
-			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get job status: %w", err))
+			a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("failed to get job status: %w", err))
 			return
 		}
 	}
@@ -104,7 +104,7 @@ func (a *API) mattermostAdminAuthorizationRequired(c *gin.Context) {
 	userID := c.GetHeader("Mattermost-User-Id")
 
 	if !a.pluginAPI.User.HasPermissionTo(userID, model.PermissionManageSystem) {
-		c.AbortWithError(http.StatusForbidden, errors.New("must be a system admin"))
+		a.abortWithError(c, http.StatusForbidden, errors.New("must be a system admin"))
 		return
 	}
 }
@@ -136,7 +136,7 @@ func (a *API) handleGetMCPTools(c *gin.Context) {
 	userID := c.GetHeader("Mattermost-User-Id")
 
 	if err := a.enforceEmptyBody(c); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		a.abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -260,20 +260,20 @@ type ClearMCPToolsCacheResponse struct {
 // handleClearMCPToolsCache clears the tools cache for all MCP servers
 func (a *API) handleClearMCPToolsCache(c *gin.Context) {
 	if err := a.enforceEmptyBody(c); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		a.abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
 	toolsCache := a.mcpClientManager.GetToolsCache()
 	if toolsCache == nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("tools cache not available"))
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("tools cache not available"))
 		return
 	}
 
 	// Clear all cache entries
 	clearedCount, err := toolsCache.ClearAll()
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to clear cache: %w", err))
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("failed to clear cache: %w", err))
 		return
 	}
 
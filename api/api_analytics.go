@@ -0,0 +1,73 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const defaultAnalyticsRange = 30 * 24 * time.Hour
+
+func parseMillisParam(c *gin.Context, name string, defaultValue int64) (int64, error) {
+	value := c.Query(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid %s parameter: must be a timestamp in milliseconds", name)
+	}
+
+	return parsed, nil
+}
+
+func (a *API) handleGetUsageAnalytics(c *gin.Context) {
+	if a.analyticsService == nil {
+		a.abortWithError(c, http.StatusServiceUnavailable, fmt.Errorf("analytics not available"))
+		return
+	}
+
+	until, err := parseMillisParam(c, "until", model.GetMillis())
+	if err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	since, err := parseMillisParam(c, "since", until-defaultAnalyticsRange.Milliseconds())
+	if err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if since >= until {
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("since must be before until"))
+		return
+	}
+
+	teamID := c.Query("team_id")
+	if teamID != "" && teamID != analytics.TeamIDDirect && !model.IsValidId(teamID) {
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid team_id parameter"))
+		return
+	}
+
+	report, err := a.analyticsService.GetUsageReport(analytics.UsageQuery{
+		Since:  since,
+		Until:  until,
+		TeamID: teamID,
+	})
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/batch"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+// SubmitBatchRequest submits requests to the batch API of a service.
+type SubmitBatchRequest struct {
+	ServiceID string `json:"service_id"`
+	// Type is "completion" or "embedding"
+	Type string `json:"type"`
+	// Handler names the handler ingesting the results. Results are only stored if empty.
+	Handler  string             `json:"handler"`
+	Requests []llm.BatchRequest `json:"requests"`
+}
+
+// BatchResultsResponse contains a batch and its stored results.
+type BatchResultsResponse struct {
+	Batch   batch.Batch    `json:"batch"`
+	Results []batch.Result `json:"results"`
+}
+
+func (a *API) handleListBatches(c *gin.Context) {
+	batches, err := a.batchService.List()
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	if batches == nil {
+		batches = []batch.Batch{}
+	}
+
+	c.JSON(http.StatusOK, batches)
+}
+
+func (a *API) handleSubmitBatch(c *gin.Context) {
+	var req SubmitBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	submitted, err := a.batchService.Submit(req.ServiceID, req.Type, req.Handler, req.Requests)
+	switch {
+	case errors.Is(err, bots.ErrServiceNotFound):
+		a.abortWithError(c, http.StatusNotFound, err)
+		return
+	case errors.Is(err, batch.ErrInvalidBatch),
+		errors.Is(err, batch.ErrUnknownHandler),
+		errors.Is(err, llm.ErrBatchUnsupported),
+		errors.Is(err, bots.ErrBatchZeroDataRetention):
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	case err != nil:
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, submitted)
+}
+
+func (a *API) handleGetBatch(c *gin.Context) {
+	if err := a.enforceEmptyBody(c); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	found, err := a.batchService.Get(c.Param("batchid"))
+	if errors.Is(err, batch.ErrBatchNotFound) {
+		a.abortWithError(c, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	results, err := a.batchService.Results(found.ID)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	if results == nil {
+		results = []batch.Result{}
+	}
+
+	c.JSON(http.StatusOK, BatchResultsResponse{
+		Batch:   *found,
+		Results: results,
+	})
+}
+
+func (a *API) handleCancelBatch(c *gin.Context) {
+	if err := a.enforceEmptyBody(c); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	err := a.batchService.Cancel(c.Param("batchid"))
+	switch {
+	case errors.Is(err, batch.ErrBatchNotFound):
+		a.abortWithError(c, http.StatusNotFound, err)
+		return
+	case errors.Is(err, batch.ErrBatchFinished):
+		a.abortWithError(c, http.StatusConflict, err)
+		return
+	case err != nil:
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
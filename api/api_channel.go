@@ -13,9 +13,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/render"
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/channels"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmtools"
 	"github.com/mattermost/mattermost-plugin-ai/prompts"
 	"github.com/mattermost/mattermost-plugin-ai/streaming"
 	"github.com/mattermost/mattermost/server/public/model"
@@ -32,32 +35,44 @@ func (a *API) channelAuthorizationRequired(c *gin.Context) {
 
 	channel, err := a.pluginAPI.Channel.Get(channelID)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Set(ContextChannelKey, channel)
 
 	if !a.pluginAPI.User.HasPermissionToChannel(userID, channel.Id, model.PermissionReadChannel) {
-		c.AbortWithError(http.StatusForbidden, errors.New("user doesn't have permission to read channel"))
+		a.abortWithError(c, http.StatusForbidden, errors.New("user doesn't have permission to read channel"))
 		return
 	}
 
 	bot := c.MustGet(ContextBotKey).(*bots.Bot)
 	if err := a.bots.CheckUsageRestrictions(userID, bot, channel); err != nil {
-		c.AbortWithError(http.StatusForbidden, err)
+		a.abortWithUsageRestriction(c, err)
 		return
 	}
 }
 
-func (a *API) handleChannelAnalysis(c *gin.Context) {
-	userID := c.GetHeader("Mattermost-User-Id")
+// channelPolicyConfirmationRequired rejects requests sending the content of a shared channel or
+// a channel with guests to LLM providers, when its policy requires the user to confirm it first
+// with the confirm_policy query parameter
+func (a *API) channelPolicyConfirmationRequired(c *gin.Context) {
 	channel := c.MustGet(ContextChannelKey).(*model.Channel)
-	bot := c.MustGet(ContextBotKey).(*bots.Bot)
 
-	if !a.licenseChecker.IsBasicsLicensed() {
-		c.AbortWithError(http.StatusForbidden, errors.New("feature not licensed"))
+	policy, err := a.bots.ChannelPolicy(channel)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
+	if policy.RequireConfirmation && c.Query("confirm_policy") != "true" {
+		a.abortWithError(c, http.StatusPreconditionRequired, exclusions.ErrConfirmationRequired)
+		return
+	}
+}
+
+func (a *API) handleChannelAnalysis(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
 
 	var data struct {
 		AnalysisType string `json:"analysis_type" binding:"required"`
@@ -68,20 +83,20 @@ func (a *API) handleChannelAnalysis(c *gin.Context) {
 		TeamID       string `json:"team_id"`
 	}
 	if bindErr := c.ShouldBindJSON(&data); bindErr != nil {
-		c.AbortWithError(http.StatusBadRequest, bindErr)
+		a.abortWithError(c, http.StatusBadRequest, bindErr)
 		return
 	}
 
 	const maxAnalysisDays = 14
 	if data.Days < 0 || data.Days > maxAnalysisDays {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("days must be between 0 and %d", maxAnalysisDays))
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("days must be between 0 and %d", maxAnalysisDays))
 		return
 	}
 
 	// Get the user to build context
 	user, err := a.pluginAPI.User.Get(userID)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get user: %w", err))
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("unable to get user: %w", err))
 		return
 	}
 
@@ -113,7 +128,7 @@ func (a *API) handleChannelAnalysis(c *gin.Context) {
 		a.pluginAPI.Log.Error("Channel analysis failed: no tools available in context",
 			"userID", userID,
 			"channelID", channel.Id)
-		c.AbortWithError(http.StatusInternalServerError, errors.New("channel analysis requires MCP tools which are not available - check embedded server configuration"))
+		a.abortWithError(c, http.StatusInternalServerError, errors.New("channel analysis requires MCP tools which are not available - check embedded server configuration"))
 		return
 	}
 
@@ -133,7 +148,7 @@ func (a *API) handleChannelAnalysis(c *gin.Context) {
 			"userID", userID,
 			"channelID", channel.Id,
 			"availableTools", toolNames)
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("channel analysis requires read_channel tool which is not available (found %d tools: %v) - ensure embedded MCP server is enabled and working", len(availableTools), toolNames))
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("channel analysis requires read_channel tool which is not available (found %d tools: %v) - ensure embedded MCP server is enabled and working", len(availableTools), toolNames))
 		return
 	}
 
@@ -141,7 +156,7 @@ func (a *API) handleChannelAnalysis(c *gin.Context) {
 	// We need to initialize Channels service. Since it's not in API struct, we initialize it here.
 	// Ideally, it should be initialized in API constructor and passed as a dependency.
 	// For now, let's create it.
-	analyzer := channels.New(bot.LLM(), a.prompts, a.mmClient, a.dbClient)
+	analyzer := channels.New(a.verification.Wrap(bot.FeatureLLM(analytics.FeatureChannelAnalysis), analytics.FeatureChannelAnalysis), a.prompts, a.mmClient, a.dbClient)
 
 	// Prepare analysis data for the prompt
 	analysisData := map[string]any{
@@ -152,30 +167,45 @@ func (a *API) handleChannelAnalysis(c *gin.Context) {
 		"Prompt":       data.Prompt,
 	}
 
-	analysisStream, err := analyzer.AnalyzeChannel(llmContext, channel.Id, analysisData)
-	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to analyze channel: %w", err))
-		return
-	}
-
 	// Create analysis post
 	siteURL := a.pluginAPI.Configuration.GetConfig().ServiceSettings.SiteURL
 	if siteURL == nil || *siteURL == "" {
-		c.AbortWithError(http.StatusInternalServerError, errors.New("site URL not configured"))
+		a.abortWithError(c, http.StatusInternalServerError, errors.New("site URL not configured"))
 		return
 	}
 	analysisPost := a.makeAnalysisPost(user.Locale, "", data.AnalysisType, *siteURL)
 
-	if err := a.streamingService.StreamToNewDM(stdcontext.Background(), bot.GetMMBot().UserId, analysisStream, user.Id, analysisPost, ""); err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+	// Analyses over long periods can take minutes, so they run as a background job and give
+	// way to interactive requests when the service is rate limited
+	llmContext.Priority = llm.PriorityBackground
+	job, err := a.startAnalysisJob(analysisJobRequest{
+		jobType:   analytics.FeatureChannelAnalysis,
+		bot:       bot,
+		user:      user,
+		channelID: channel.Id,
+		post:      analysisPost,
+		startStream: func(ctx stdcontext.Context) (*llm.TextStreamResult, error) {
+			stream, analyzeErr := analyzer.AnalyzeChannel(ctx, llmContext, channel.Id, analysisData)
+			if analyzeErr != nil {
+				return nil, analyzeErr
+			}
+			return a.analyticsService.TrackStream(stream, analytics.NewEvent(analytics.FeatureChannelAnalysis, bot.GetMMBot().UserId, user.Id, channel)), nil
+		},
+		attachments: func() []string {
+			return mmtools.ConsumeGeneratedImages(llmContext)
+		},
+	})
+	if err != nil {
+		a.abortWithError(c, generationErrorStatus(err), fmt.Errorf("failed to start channel analysis: %w", err))
 		return
 	}
 
-	a.conversationsService.SaveTitleAsync(analysisPost.Id, TitleSummarizeChannel)
+	a.conversationsService.SaveTitleAsync(analysisPost.Id, a.localizedTitle(user.Locale, TitleSummarizeChannel))
 
 	c.JSON(http.StatusOK, map[string]string{
 		"postid":    analysisPost.Id,
 		"channelid": analysisPost.ChannelId,
+		"jobid":     job.ID,
 	})
 }
 
@@ -184,43 +214,41 @@ func (a *API) handleInterval(c *gin.Context) {
 	channel := c.MustGet(ContextChannelKey).(*model.Channel)
 	bot := c.MustGet(ContextBotKey).(*bots.Bot)
 
-	// Check license
-	if !a.licenseChecker.IsBasicsLicensed() {
-		c.AbortWithError(http.StatusForbidden, errors.New("feature not licensed"))
-		return
-	}
-
 	// Parse request data
 	data := struct {
 		StartTime    int64  `json:"start_time"`
 		EndTime      int64  `json:"end_time"` // 0 means "until present"
 		PresetPrompt string `json:"preset_prompt"`
 		Prompt       string `json:"prompt"`
+		// The options default to true when left out
+		IncludeReplies   *bool `json:"include_replies"`
+		IncludeReactions *bool `json:"include_reactions"`
+		MarkAnswered     *bool `json:"mark_answered"`
 	}{}
 	err := json.NewDecoder(c.Request.Body).Decode(&data)
 	if err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		a.abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 	defer c.Request.Body.Close()
 
 	// Validate time range
 	if data.EndTime != 0 && data.StartTime >= data.EndTime {
-		c.AbortWithError(http.StatusBadRequest, errors.New("start_time must be before end_time"))
+		a.abortWithError(c, http.StatusBadRequest, errors.New("start_time must be before end_time"))
 		return
 	}
 
 	// Cap the date range at 14 days
 	maxDuration := int64(14 * 24 * 60 * 60) // 14 days in seconds
 	if data.EndTime != 0 && (data.EndTime-data.StartTime) > maxDuration {
-		c.AbortWithError(http.StatusBadRequest, errors.New("date range cannot exceed 14 days"))
+		a.abortWithError(c, http.StatusBadRequest, errors.New("date range cannot exceed 14 days"))
 		return
 	}
 
 	// Get user
 	user, err := a.pluginAPI.User.Get(userID)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -249,29 +277,43 @@ func (a *API) handleInterval(c *gin.Context) {
 		promptPreset = prompts.PromptFindOpenQuestionsSystem
 		promptTitle = TitleFindOpenQuestions
 	default:
-		c.AbortWithError(http.StatusBadRequest, errors.New("invalid preset prompt"))
+		a.abortWithError(c, http.StatusBadRequest, errors.New("invalid preset prompt"))
+		return
+	}
+
+	policy, err := a.bots.ChannelPolicy(channel)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	// Call channels interval processing
-	resultStream, err := channels.New(bot.LLM(), a.prompts, a.mmClient, a.dbClient).Interval(context, channel.Id, data.StartTime, data.EndTime, promptPreset)
+	intervals := channels.New(a.verification.Wrap(bot.FeatureLLM(analytics.FeatureChannelInterval), analytics.FeatureChannelInterval), a.prompts, a.mmClient, a.dbClient)
+	intervals.SetChannelPolicy(policy)
+	intervals.SetMaxIntervalPosts(a.config.GetMaxIntervalPosts())
+	resultStream, err := intervals.Interval(a.backgroundCtx, context, channel.Id, data.StartTime, data.EndTime, promptPreset, channels.IntervalOptions{
+		ExpandThreads:    data.IncludeReplies == nil || *data.IncludeReplies,
+		IncludeReactions: data.IncludeReactions == nil || *data.IncludeReactions,
+		MarkAnswered:     data.MarkAnswered == nil || *data.MarkAnswered,
+	})
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		a.abortWithError(c, generationErrorStatus(err), err)
 		return
 	}
+	resultStream = a.analyticsService.TrackStream(resultStream, analytics.NewEvent(analytics.FeatureChannelInterval, bot.GetMMBot().UserId, user.Id, channel))
 
 	// Create post for the response
 	post := &model.Post{}
 	post.AddProp(streaming.NoRegen, "true")
 
 	// Stream result to new DM
-	if err := a.streamingService.StreamToNewDM(stdcontext.Background(), bot.GetMMBot().UserId, resultStream, user.Id, post, ""); err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+	if err := a.streamingService.StreamToNewDM(streaming.WithFeature(a.backgroundCtx, analytics.FeatureChannelInterval), bot.GetMMBot().UserId, resultStream, user.Id, post, ""); err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	// Save title asynchronously
-	a.conversationsService.SaveTitleAsync(post.Id, promptTitle)
+	a.conversationsService.SaveTitleAsync(post.Id, a.localizedTitle(user.Locale, promptTitle))
 
 	// Return result
 	result := map[string]string{
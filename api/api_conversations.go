@@ -0,0 +1,91 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	defaultConversationsPerPage = 20
+	maxConversationsPerPage     = 100
+	maxConversationTermsLength  = 200
+)
+
+// ConversationsResponse is a page of the conversations of a user
+type ConversationsResponse struct {
+	Conversations []conversations.Conversation `json:"conversations"`
+	HasMore       bool                         `json:"has_more"`
+}
+
+// parseConversationQuery reads the filters and page of the conversations of userID from the query string
+func parseConversationQuery(c *gin.Context, userID string) (conversations.ConversationQuery, error) {
+	query := conversations.ConversationQuery{
+		UserID:  userID,
+		BotID:   c.Query("bot_id"),
+		Terms:   c.Query("terms"),
+		PerPage: defaultConversationsPerPage,
+	}
+
+	if query.BotID != "" && !model.IsValidId(query.BotID) {
+		return query, fmt.Errorf("invalid bot_id parameter")
+	}
+	if len(query.Terms) > maxConversationTermsLength {
+		return query, fmt.Errorf("terms parameter is too long: at most %d characters", maxConversationTermsLength)
+	}
+
+	var err error
+	if query.Since, err = parseMillisParam(c, "since", 0); err != nil {
+		return query, err
+	}
+	if query.Until, err = parseMillisParam(c, "until", 0); err != nil {
+		return query, err
+	}
+	if query.Since > 0 && query.Until > 0 && query.Since >= query.Until {
+		return query, fmt.Errorf("since must be before until")
+	}
+
+	if page := c.Query("page"); page != "" {
+		query.Page, err = strconv.Atoi(page)
+		if err != nil || query.Page < 0 {
+			return query, fmt.Errorf("invalid page parameter")
+		}
+	}
+	if perPage := c.Query("per_page"); perPage != "" {
+		query.PerPage, err = strconv.Atoi(perPage)
+		if err != nil || query.PerPage < 1 || query.PerPage > maxConversationsPerPage {
+			return query, fmt.Errorf("invalid per_page parameter: must be between 1 and %d", maxConversationsPerPage)
+		}
+	}
+
+	return query, nil
+}
+
+// handleGetConversations lists and searches the conversations of the user with the bots
+func (a *API) handleGetConversations(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	query, err := parseConversationQuery(c, userID)
+	if err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	result, hasMore, err := a.conversationsService.SearchConversations(query)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ConversationsResponse{
+		Conversations: result,
+		HasMore:       hasMore,
+	})
+}
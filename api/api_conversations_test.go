@@ -0,0 +1,82 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConversationQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		queryString string
+		expected    conversations.ConversationQuery
+		expectError bool
+	}{
+		{
+			name:     "defaults",
+			expected: conversations.ConversationQuery{UserID: testUserID, PerPage: defaultConversationsPerPage},
+		},
+		{
+			name:        "all filters",
+			queryString: "terms=release+notes&bot_id=" + testBotUserID + "&since=1000&until=2000&page=2&per_page=50",
+			expected: conversations.ConversationQuery{
+				UserID:  testUserID,
+				BotID:   testBotUserID,
+				Terms:   "release notes",
+				Since:   1000,
+				Until:   2000,
+				Page:    2,
+				PerPage: 50,
+			},
+		},
+		{
+			name:        "invalid bot",
+			queryString: "bot_id=notanid",
+			expectError: true,
+		},
+		{
+			name:        "terms too long",
+			queryString: "terms=" + strings.Repeat("a", maxConversationTermsLength+1),
+			expectError: true,
+		},
+		{
+			name:        "since after until",
+			queryString: "since=2000&until=1000",
+			expectError: true,
+		},
+		{
+			name:        "negative page",
+			queryString: "page=-1",
+			expectError: true,
+		},
+		{
+			name:        "per page too large",
+			queryString: "per_page=101",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/conversations?"+tc.queryString, nil)
+
+			query, err := parseConversationQuery(c, testUserID)
+			if tc.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, query)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/digests"
+)
+
+// maxInboundEmailSize bounds the requests of the inbound email webhook, attachments included
+const maxInboundEmailSize = 10 * 1024 * 1024
+
+// handleInboundEmail receives the emails forwarded by an inbound email service. It's called by
+// that service rather than by Mattermost users, and is authenticated by the configured secret
+// sent in the X-Digest-Secret header or the secret query parameter.
+func (a *API) handleInboundEmail(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxInboundEmailSize)
+
+	secret := c.GetHeader("X-Digest-Secret")
+	if secret == "" {
+		secret = c.Query("secret")
+	}
+
+	var email digests.InboundEmail
+	if err := c.ShouldBind(&email); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	err := a.digests.ReceiveEmail(secret, email)
+	switch {
+	case errors.Is(err, digests.ErrUnauthorized):
+		a.abortWithError(c, http.StatusUnauthorized, err)
+		return
+	case errors.Is(err, digests.ErrUnknownRecipient):
+		a.abortWithError(c, http.StatusNotFound, err)
+		return
+	case err != nil:
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/threads"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// DraftReplyResponse is a reply drafted for the user, not posted
+type DraftReplyResponse struct {
+	Draft string `json:"draft"`
+}
+
+// handleDraftReply drafts a reply of the user to the thread of the post. The draft is returned
+// for the user to edit in the message composer rather than posted.
+func (a *API) handleDraftReply(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	var data threads.DraftOptions
+	if err := c.ShouldBindJSON(&data); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := data.Normalize(); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := a.pluginAPI.User.Get(userID)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("unable to get user: %w", err))
+		return
+	}
+
+	policy, err := a.bots.ChannelPolicy(channel)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	llmContext := a.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		user,
+		channel,
+		a.contextBuilder.WithLLMContextNoTools(),
+	)
+
+	drafter := a.threadAnalyzer(bot.FeatureLLM(analytics.FeatureDraftReply), bot, policy, userID)
+	start := time.Now()
+	draft, err := drafter.DraftReply(c.Request.Context(), post.Id, llmContext, data)
+	a.recordDraftUsage(bot, userID, channel, start, err)
+	if errors.Is(err, threads.ErrInvalidDraft) {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		a.abortWithError(c, generationErrorStatus(err), fmt.Errorf("failed to draft reply: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, DraftReplyResponse{Draft: draft})
+}
+
+func (a *API) recordDraftUsage(bot *bots.Bot, userID string, channel *model.Channel, start time.Time, draftErr error) {
+	if a.analyticsService == nil {
+		return
+	}
+
+	event := analytics.NewEvent(analytics.FeatureDraftReply, bot.GetMMBot().UserId, userID, channel)
+	event.LatencyMS = time.Since(start).Milliseconds()
+	event.Failed = draftErr != nil
+	if err := a.analyticsService.Record(event); err != nil {
+		a.pluginAPI.Log.Error("Failed to record draft usage", "error", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// ErrorCodeChannelExcluded is returned for requests in channels excluded from AI processing.
+	ErrorCodeChannelExcluded llm.ErrorCode = "channel_excluded"
+	// ErrorCodeChannelPolicyDisabled is returned for requests in shared channels or channels with
+	// guests whose policy disables AI features.
+	ErrorCodeChannelPolicyDisabled llm.ErrorCode = "channel_policy_disabled"
+	// ErrorCodeConfirmationRequired is returned when the user must confirm the content of a shared
+	// channel or a channel with guests can be sent to LLM providers, see channelPolicyConfirmationRequired.
+	ErrorCodeConfirmationRequired llm.ErrorCode = "channel_policy_confirmation_required"
+	// ErrorCodeEnterpriseLicenseRequired is returned for features that need an Enterprise license.
+	ErrorCodeEnterpriseLicenseRequired llm.ErrorCode = "enterprise_license_required"
+)
+
+// abortWithError aborts the request with a structured error the webapp can localize from its
+// code. The error is logged, and the detail of server errors is only returned to system admins.
+func (a *API) abortWithError(c *gin.Context, status int, err error) {
+	_ = c.Error(err)
+
+	apiErr := apiError(status, err)
+	if apiErr.Detail != "" && !a.isSystemAdmin(c) {
+		apiErr = apiErr.WithoutDetail()
+	}
+	c.AbortWithStatusJSON(status, apiErr)
+}
+
+func (a *API) isSystemAdmin(c *gin.Context) bool {
+	userID := c.GetHeader("Mattermost-User-Id")
+	return userID != "" && a.pluginAPI.User.HasPermissionTo(userID, model.PermissionManageSystem)
+}
+
+// apiError describes err for an API response with the given status. Messages of client errors
+// are written by the handlers for users, the message of server errors is generic and the
+// underlying error is kept as detail.
+func apiError(status int, err error) *llm.Error {
+	if classified := llm.ClassifyError(err); classified.Code != llm.ErrorCodeProvider {
+		return classified
+	}
+	var notLicensed *enterprise.FeatureNotLicensedError
+	if errors.As(err, &notLicensed) {
+		return llm.NewError(ErrorCodeEnterpriseLicenseRequired, "This feature requires a Mattermost Enterprise license.", false, nil)
+	}
+	if errors.Is(err, exclusions.ErrChannelExcluded) {
+		return llm.NewError(ErrorCodeChannelExcluded, exclusions.ErrChannelExcluded.Error(), false, nil)
+	}
+	if errors.Is(err, exclusions.ErrChannelPolicyDisabled) {
+		return llm.NewError(ErrorCodeChannelPolicyDisabled, exclusions.ErrChannelPolicyDisabled.Error(), false, nil)
+	}
+	if errors.Is(err, exclusions.ErrConfirmationRequired) {
+		return llm.NewError(ErrorCodeConfirmationRequired, exclusions.ErrConfirmationRequired.Error(), false, nil)
+	}
+
+	switch {
+	case status == http.StatusUnauthorized:
+		return llm.NewError(llm.ErrorCodeUnauthorized, err.Error(), false, nil)
+	case status == http.StatusForbidden:
+		return llm.NewError(llm.ErrorCodeForbidden, err.Error(), false, nil)
+	case status == http.StatusNotFound:
+		return llm.NewError(llm.ErrorCodeNotFound, err.Error(), false, nil)
+	case status == http.StatusConflict:
+		return llm.NewError(llm.ErrorCodeConflict, err.Error(), false, nil)
+	case status == http.StatusTooManyRequests:
+		return llm.NewError(llm.ErrorCodeRateLimited, err.Error(), true, nil)
+	case status == http.StatusServiceUnavailable:
+		return llm.NewError(llm.ErrorCodeProviderUnavailable, "The service is temporarily unavailable.", true, err)
+	case status >= http.StatusInternalServerError:
+		return llm.NewError(llm.ErrorCodeInternal, "An internal error occurred. See the server logs for details.", false, err)
+	default:
+		return llm.NewError(llm.ErrorCodeInvalidRequest, err.Error(), false, nil)
+	}
+}
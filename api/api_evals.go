@@ -0,0 +1,94 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/evals/suite"
+	"github.com/mattermost/mattermost-plugin-ai/search"
+)
+
+// EvalRunRequest runs a golden suite against one or more bots and optionally compares with a previous run.
+type EvalRunRequest struct {
+	Suite suite.Suite `json:"suite"`
+	// BotUsernames selects the bots (and therefore providers and models) to evaluate. Defaults to the default bot.
+	BotUsernames []string `json:"bot_usernames"`
+	// GraderBotUsername selects the bot used for LLM judge cases. Defaults to the default bot.
+	GraderBotUsername string `json:"grader_bot_username"`
+	// Baseline contains the reports of a previous run to detect regressions against.
+	Baseline []suite.Report `json:"baseline"`
+	// Tolerance is how much a score may drop before it counts as a regression.
+	Tolerance *float64 `json:"tolerance"`
+}
+
+// EvalRunResponse contains one report per bot and the regressions found against the baseline.
+type EvalRunResponse struct {
+	Reports     []suite.Report     `json:"reports"`
+	Regressions []suite.Regression `json:"regressions"`
+}
+
+func (a *API) handleRunEvalSuite(c *gin.Context) {
+	var req EvalRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	if err := req.Suite.IsValid(); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid suite: %w", err))
+		return
+	}
+
+	tolerance := suite.DefaultRegressionTolerance
+	if req.Tolerance != nil {
+		if *req.Tolerance < 0 || *req.Tolerance > 1 {
+			a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("tolerance must be between 0 and 1"))
+			return
+		}
+		tolerance = *req.Tolerance
+	}
+
+	if len(req.BotUsernames) == 0 {
+		req.BotUsernames = []string{a.config.GetDefaultBotName()}
+	}
+	evalBots := make([]*bots.Bot, 0, len(req.BotUsernames))
+	for _, username := range req.BotUsernames {
+		bot := a.bots.GetBotByUsername(username)
+		if bot == nil {
+			a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("bot not found: %s", username))
+			return
+		}
+		evalBots = append(evalBots, bot)
+	}
+
+	if req.GraderBotUsername == "" {
+		req.GraderBotUsername = a.config.GetDefaultBotName()
+	}
+	runner := &suite.Runner{}
+	if graderBot := a.bots.GetBotByUsernameOrFirst(req.GraderBotUsername); graderBot != nil {
+		runner.Grader = graderBot.LLM()
+	}
+	if provider, err := search.NewEmbeddingProvider(a.config.EmbeddingSearchConfig(), a.llmUpstreamHTTPClient); err == nil {
+		runner.Embeddings = provider
+	}
+
+	response := EvalRunResponse{
+		Reports: make([]suite.Report, 0, len(evalBots)),
+	}
+	for _, bot := range evalBots {
+		report, err := runner.Run(c.Request.Context(), req.Suite, bot.LLM(), bot.GetMMBot().Username)
+		if err != nil {
+			a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("failed to run suite against %s: %w", bot.GetMMBot().Username, err))
+			return
+		}
+		response.Reports = append(response.Reports, *report)
+	}
+	response.Regressions = suite.FindRegressions(req.Baseline, response.Reports, tolerance)
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// handleExplainPost streams an explanation of the post to the user by direct message, with the
+// start of its thread as context. With simplify, the post is explained as simply as to someone
+// new to its subject.
+func (a *API) handleExplainPost(simplify bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetHeader("Mattermost-User-Id")
+		post := c.MustGet(ContextPostKey).(*model.Post)
+		channel := c.MustGet(ContextChannelKey).(*model.Channel)
+		bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+		if err := a.enforceEmptyBody(c); err != nil {
+			a.abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		user, err := a.pluginAPI.User.Get(userID)
+		if err != nil {
+			a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("unable to get user: %w", err))
+			return
+		}
+
+		policy, err := a.bots.ChannelPolicy(channel)
+		if err != nil {
+			a.abortWithError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if policy.ExcludesAuthors() {
+			author, authorErr := a.pluginAPI.User.Get(post.UserId)
+			if authorErr != nil {
+				a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("unable to get author: %w", authorErr))
+				return
+			}
+			if policy.ExcludesPost(post, author) {
+				a.abortWithError(c, http.StatusForbidden, errors.New("the channel policy excludes the post"))
+				return
+			}
+		}
+
+		llmContext := a.contextBuilder.BuildLLMContextUserRequest(
+			bot,
+			user,
+			channel,
+			a.contextBuilder.WithLLMContextNoTools(),
+		)
+
+		explainer := a.threadAnalyzer(bot.FeatureLLM(analytics.FeatureExplainPost), bot, policy, userID)
+		title := TitleExplainPost
+		explain := explainer.Explain
+		if simplify {
+			title = TitleSimplifyPost
+			explain = explainer.Simplify
+		}
+		explanationStream, err := explain(a.backgroundCtx, post, llmContext)
+		if err != nil {
+			a.abortWithError(c, generationErrorStatus(err), fmt.Errorf("failed to explain post: %w", err))
+			return
+		}
+		explanationStream = a.analyticsService.TrackStream(explanationStream, analytics.NewEvent(analytics.FeatureExplainPost, bot.GetMMBot().UserId, user.Id, channel))
+
+		explanationPost := &model.Post{}
+		if err := a.streamingService.StreamToNewDM(streaming.WithFeature(a.backgroundCtx, analytics.FeatureExplainPost), bot.GetMMBot().UserId, explanationStream, user.Id, explanationPost, post.Id); err != nil {
+			a.abortWithError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		a.conversationsService.SaveTitleAsync(explanationPost.Id, a.localizedTitle(user.Locale, title))
+
+		c.JSON(http.StatusOK, map[string]string{
+			"postid":    explanationPost.Id,
+			"channelid": explanationPost.ChannelId,
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/faq"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+func (a *API) handleGetFAQ(c *gin.Context) {
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	channelFAQ, err := a.faqService.Get(channel.Id)
+	if err != nil {
+		a.abortWithFAQError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, channelFAQ)
+}
+
+func (a *API) handleBuildFAQ(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	var opts faq.Options
+	if err := c.ShouldBindJSON(&opts); err != nil && !errors.Is(err, io.EOF) {
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	if opts.Post && !a.pluginAPI.User.HasPermissionToChannel(userID, channel.Id, model.PermissionCreatePost) {
+		a.abortWithError(c, http.StatusForbidden, errors.New("user doesn't have permission to post in the channel"))
+		return
+	}
+
+	job, err := a.faqService.Build(userID, channel.Id, bot, opts)
+	if err != nil {
+		a.abortWithFAQError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (a *API) handleDeleteFAQ(c *gin.Context) {
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	if err := a.faqService.Delete(channel.Id); err != nil {
+		a.abortWithFAQError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (a *API) handleExportFAQ(c *gin.Context) {
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	markdown, err := a.faqService.Export(channel)
+	if err != nil {
+		a.abortWithFAQError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "faq-"+channel.Name+".md"))
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(markdown))
+}
+
+func (a *API) abortWithFAQError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, faq.ErrNotFound), errors.Is(err, faq.ErrNotGenerated):
+		a.abortWithError(c, http.StatusNotFound, err)
+	case errors.Is(err, faq.ErrNotEnabled):
+		a.abortWithError(c, http.StatusForbidden, err)
+	case errors.Is(err, faq.ErrBuilding):
+		a.abortWithError(c, http.StatusConflict, err)
+	case errors.Is(err, faq.ErrInvalidLookback), errors.Is(err, faq.ErrInvalidInterval):
+		a.abortWithError(c, http.StatusBadRequest, err)
+	default:
+		a.abortWithError(c, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/glossary"
+)
+
+// GlossaryTermRequest is the body for creating or updating a glossary term.
+type GlossaryTermRequest struct {
+	Term          string `json:"term"`
+	Definition    string `json:"definition"`
+	CaseSensitive bool   `json:"case_sensitive"`
+}
+
+func glossaryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, glossary.ErrTermNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, glossary.ErrDuplicateTerm):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (a *API) handleListGlossary(c *gin.Context) {
+	terms, err := a.glossary.List()
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, terms)
+}
+
+func (a *API) handleCreateGlossaryTerm(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	var data GlossaryTermRequest
+	if err := c.ShouldBindJSON(&data); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	term := glossary.Term{
+		Term:          data.Term,
+		Definition:    data.Definition,
+		CaseSensitive: data.CaseSensitive,
+	}
+	if err := term.IsValid(); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	created, err := a.glossary.Create(term, userID)
+	if err != nil {
+		a.abortWithError(c, glossaryErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, created)
+}
+
+func (a *API) handleUpdateGlossaryTerm(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	var data GlossaryTermRequest
+	if err := c.ShouldBindJSON(&data); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	term := glossary.Term{
+		ID:            c.Param("termid"),
+		Term:          data.Term,
+		Definition:    data.Definition,
+		CaseSensitive: data.CaseSensitive,
+	}
+	if err := term.IsValid(); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	updated, err := a.glossary.Update(term, userID)
+	if err != nil {
+		a.abortWithError(c, glossaryErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (a *API) handleDeleteGlossaryTerm(c *gin.Context) {
+	if err := a.glossary.Delete(c.Param("termid")); err != nil {
+		a.abortWithError(c, glossaryErrorStatus(err), err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
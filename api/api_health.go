@@ -0,0 +1,135 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// healthDatabaseTimeout bounds the database ping of a health check
+const healthDatabaseTimeout = 5 * time.Second
+
+// Overall statuses of the health check
+const (
+	HealthOK          = "ok"
+	HealthDegraded    = "degraded"
+	HealthUnavailable = "unavailable"
+)
+
+// Statuses of the components of the health check
+const (
+	ComponentOK       = "ok"
+	ComponentFailed   = "error"
+	ComponentDisabled = "disabled"
+)
+
+// HealthComponent is the status of a dependency of the plugin
+type HealthComponent struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthJobs is the backlog of work on this server
+type HealthJobs struct {
+	// Running is the number of analysis jobs that have not finished
+	Running int `json:"running"`
+	// QueuedRequests is the number of requests waiting for the rate limits of the providers
+	QueuedRequests int `json:"queued_requests"`
+}
+
+// HealthResponse is the health of the plugin's AI features on the server handling the request.
+// Only the status is returned to users other than system admins.
+type HealthResponse struct {
+	Status    string                `json:"status"`
+	Version   string                `json:"version,omitempty"`
+	Database  *HealthComponent      `json:"database,omitempty"`
+	MCPServer *HealthComponent      `json:"mcp_server,omitempty"`
+	Jobs      *HealthJobs           `json:"jobs,omitempty"`
+	Providers []bots.ProviderHealth `json:"providers,omitempty"`
+}
+
+// handleHealth reports whether the AI features are available, for uptime monitoring. It responds
+// with 503 when they are unavailable: the database can't be reached or no provider is reachable.
+func (a *API) handleHealth(c *gin.Context) {
+	health := HealthResponse{
+		Version:   a.pluginVersion,
+		Database:  a.databaseHealth(c.Request.Context()),
+		MCPServer: a.mcpServerHealth(),
+		Jobs:      &HealthJobs{},
+		Providers: a.bots.ProvidersHealth(),
+	}
+
+	if a.jobsService != nil {
+		health.Jobs.Running = a.jobsService.Running()
+	}
+
+	reachable := 0
+	failed := 0
+	for _, provider := range health.Providers {
+		health.Jobs.QueuedRequests += provider.QueuedRequests
+		if provider.Status == bots.ServiceCheckFailed {
+			failed++
+		} else {
+			reachable++
+		}
+	}
+
+	switch {
+	case health.Database.Status == ComponentFailed || (reachable == 0 && failed > 0):
+		health.Status = HealthUnavailable
+	case failed > 0 || health.MCPServer.Status == ComponentFailed:
+		health.Status = HealthDegraded
+	default:
+		health.Status = HealthOK
+	}
+
+	statusCode := http.StatusOK
+	if health.Status == HealthUnavailable {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	userID := c.GetHeader("Mattermost-User-Id")
+	if userID == "" || !a.pluginAPI.User.HasPermissionTo(userID, model.PermissionManageSystem) {
+		c.JSON(statusCode, HealthResponse{Status: health.Status})
+		return
+	}
+
+	c.JSON(statusCode, health)
+}
+
+func (a *API) databaseHealth(ctx context.Context) *HealthComponent {
+	if a.dbClient == nil {
+		return &HealthComponent{Status: ComponentDisabled}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthDatabaseTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := a.dbClient.PingContext(ctx)
+	component := &HealthComponent{Status: ComponentOK, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		component.Status = ComponentFailed
+		component.Error = err.Error()
+	}
+	return component
+}
+
+func (a *API) mcpServerHealth() *HealthComponent {
+	switch {
+	case !a.config.MCP().EnablePluginServer:
+		return &HealthComponent{Status: ComponentDisabled}
+	case a.mcpHandlers == nil:
+		return &HealthComponent{Status: ComponentFailed, Error: "the embedded MCP server failed to start"}
+	default:
+		return &HealthComponent{Status: ComponentOK}
+	}
+}
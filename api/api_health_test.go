@@ -0,0 +1,93 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleHealth(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+
+	tests := []struct {
+		name             string
+		providerStatus   int
+		admin            bool
+		expectedStatus   int
+		expectedHealth   string
+		expectedProvider string
+	}{
+		{
+			name:             "admins get the details",
+			providerStatus:   http.StatusOK,
+			admin:            true,
+			expectedStatus:   http.StatusOK,
+			expectedHealth:   HealthOK,
+			expectedProvider: bots.ServiceCheckOK,
+		},
+		{
+			name:           "unreachable providers make the features unavailable",
+			providerStatus: http.StatusUnauthorized,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedHealth: HealthUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.providerStatus)
+				_, _ = io.WriteString(w, `{"object":"list","data":[{"id":"model","object":"model"}]}`)
+			}))
+			defer provider.Close()
+
+			e := SetupTestEnvironment(t)
+			defer e.Cleanup(t)
+			e.api.pluginVersion = "1.2.3"
+
+			bot := bots.NewBot(llm.BotConfig{Name: "ai", ServiceID: "service"}, llm.ServiceConfig{
+				ID:     "service",
+				Type:   llm.ServiceTypeOpenAICompatible,
+				APIURL: provider.URL,
+			}, &model.Bot{UserId: testBotUserID}, nil)
+			e.bots.SetBotsForTesting([]*bots.Bot{bot})
+			e.mockAPI.On("HasPermissionTo", "userid", model.PermissionManageSystem).Return(test.admin)
+
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
+			request.Header.Add("Mattermost-User-ID", "userid")
+			recorder := httptest.NewRecorder()
+			e.api.ServeHTTP(&plugin.Context{}, recorder, request)
+			require.Equal(t, test.expectedStatus, recorder.Code)
+
+			var health HealthResponse
+			require.NoError(t, json.NewDecoder(recorder.Body).Decode(&health))
+			assert.Equal(t, test.expectedHealth, health.Status)
+			if !test.admin {
+				assert.Empty(t, health.Version)
+				assert.Empty(t, health.Providers)
+				return
+			}
+
+			assert.Equal(t, "1.2.3", health.Version)
+			assert.Equal(t, ComponentDisabled, health.Database.Status)
+			assert.Equal(t, ComponentDisabled, health.MCPServer.Status)
+			require.Len(t, health.Providers, 1)
+			assert.Equal(t, test.expectedProvider, health.Providers[0].Status)
+		})
+	}
+}
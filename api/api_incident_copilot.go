@@ -0,0 +1,106 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/incidents"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// StartIncidentCopilotRequest starts the incident copilot of a channel.
+type StartIncidentCopilotRequest struct {
+	// IntervalMinutes is how often the summary is updated, the configured interval if 0
+	IntervalMinutes int `json:"interval_minutes"`
+}
+
+func (a *API) handleGetIncidentCopilot(c *gin.Context) {
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	copilot, err := a.incidentCopilot.Get(channel.Id)
+	if err != nil {
+		a.abortWithIncidentCopilotError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, copilot)
+}
+
+func (a *API) handleStartIncidentCopilot(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	if !a.pluginAPI.User.HasPermissionToChannel(userID, channel.Id, model.PermissionCreatePost) {
+		a.abortWithError(c, http.StatusForbidden, errors.New("user doesn't have permission to post in the channel"))
+		return
+	}
+
+	var req StartIncidentCopilotRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	copilot, err := a.incidentCopilot.StartCopilot(userID, channel.Id, bot, req.IntervalMinutes)
+	if err != nil {
+		a.abortWithIncidentCopilotError(c, err)
+		return
+	}
+
+	// Write the first summary right away rather than at the next interval
+	go a.refreshIncidentCopilot(channel.Id)
+
+	c.JSON(http.StatusOK, copilot)
+}
+
+func (a *API) handleStopIncidentCopilot(c *gin.Context) {
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	if err := a.incidentCopilot.StopCopilot(channel.Id); err != nil {
+		a.abortWithIncidentCopilotError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (a *API) handleRefreshIncidentCopilot(c *gin.Context) {
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	if _, err := a.incidentCopilot.Get(channel.Id); err != nil {
+		a.abortWithIncidentCopilotError(c, err)
+		return
+	}
+
+	// Generating the summary can take longer than the request
+	go a.refreshIncidentCopilot(channel.Id)
+
+	c.Status(http.StatusAccepted)
+}
+
+func (a *API) refreshIncidentCopilot(channelID string) {
+	if err := a.incidentCopilot.Refresh(a.backgroundCtx, channelID); err != nil {
+		a.pluginAPI.Log.Warn("Failed to refresh incident copilot", "channel_id", channelID, "error", err)
+	}
+}
+
+func (a *API) abortWithIncidentCopilotError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, incidents.ErrNotRunning), errors.Is(err, incidents.ErrNoRun):
+		a.abortWithError(c, http.StatusNotFound, err)
+	case errors.Is(err, incidents.ErrNotEnabled):
+		a.abortWithError(c, http.StatusForbidden, err)
+	case errors.Is(err, incidents.ErrRunFinished), errors.Is(err, incidents.ErrInvalidInterval):
+		a.abortWithError(c, http.StatusBadRequest, err)
+	default:
+		a.abortWithError(c, http.StatusInternalServerError, err)
+	}
+}
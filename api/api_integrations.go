@@ -0,0 +1,123 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/integrations"
+)
+
+const ContextIntegrationKey = "integration"
+
+// IntegrationConnection is an integration users can connect to, with the account the user
+// connected
+type IntegrationConnection struct {
+	ID         string                   `json:"id"`
+	Name       string                   `json:"name"`
+	ConnectURL string                   `json:"connect_url"`
+	Connection *integrations.Connection `json:"connection"`
+}
+
+func (a *API) integrationRequired(c *gin.Context) {
+	id := c.Param("integration")
+	if !a.integrations.Enabled(id) {
+		a.abortWithError(c, http.StatusNotFound, errors.New("integration not found or not enabled"))
+		return
+	}
+	c.Set(ContextIntegrationKey, id)
+}
+
+func (a *API) handleListIntegrations(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	result := []IntegrationConnection{}
+	for _, id := range a.integrations.EnabledIntegrations() {
+		connection, err := a.integrations.GetConnection(userID, id)
+		if err != nil {
+			a.abortWithError(c, http.StatusInternalServerError, err)
+			return
+		}
+		result = append(result, IntegrationConnection{
+			ID:         id,
+			Name:       a.integrations.Name(id),
+			ConnectURL: a.integrations.ConnectURL(id),
+			Connection: connection,
+		})
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleConnectIntegration redirects the user to the authorization page of the service, which
+// redirects back to handleIntegrationCallback.
+func (a *API) handleConnectIntegration(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	id := c.GetString(ContextIntegrationKey)
+
+	authURL, err := a.integrations.AuthorizationURL(userID, id)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+func (a *API) handleIntegrationCallback(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	id := c.GetString(ContextIntegrationKey)
+
+	locale := ""
+	if user, err := a.pluginAPI.User.Get(userID); err == nil {
+		locale = user.Locale
+	}
+	T := i18n.LocalizerFunc(a.i18nBundle, locale)
+	name := a.integrations.Name(id)
+
+	if errorParam := c.Query("error"); errorParam != "" {
+		a.pluginAPI.Log.Warn("Integration authorization failed", "integration", id, "error", errorParam, "description", c.Query("error_description"))
+		writeIntegrationPage(c, http.StatusBadRequest, T("agents.integrations.connect_failed", "Your %s account could not be connected. Please try again.", name))
+		return
+	}
+
+	if _, err := a.integrations.CompleteConnection(c.Request.Context(), userID, id, c.Query("state"), c.Query("code")); err != nil {
+		a.pluginAPI.Log.Error("Failed to connect integration account", "integration", id, "error", err)
+		writeIntegrationPage(c, http.StatusBadRequest, T("agents.integrations.connect_failed", "Your %s account could not be connected. Please try again.", name))
+		return
+	}
+
+	writeIntegrationPage(c, http.StatusOK, T("agents.integrations.connected", "Your %s account is connected. You can close this window and ask the agent again.", name))
+}
+
+func writeIntegrationPage(c *gin.Context, status int, message string) {
+	message = html.EscapeString(message)
+	c.Header("Content-Type", "text/html")
+	c.String(status, fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+	<title>%s</title>
+</head>
+<body>
+	<p>%s</p>
+</body>
+</html>`, message, message))
+}
+
+func (a *API) handleDisconnectIntegration(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	id := c.GetString(ContextIntegrationKey)
+
+	if err := a.integrations.Disconnect(userID, id); err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const ContextJobKey = "job"
+
+// analysisJobRequest describes a long-running analysis whose result is streamed into a DM post.
+type analysisJobRequest struct {
+	// jobType is also the feature the analysis is streamed as, such as "channel_analysis"
+	jobType   string
+	bot       *bots.Bot
+	user      *model.User
+	channelID string
+	post      *model.Post
+	// startStream begins the generation once the job is running, stopping it when ctx is canceled.
+	startStream func(ctx context.Context) (*llm.TextStreamResult, error)
+	// attachments returns the IDs of files generated during the analysis to attach to the post. Optional.
+	attachments func() []string
+}
+
+// startAnalysisJob posts a placeholder DM and runs the analysis in the background,
+// editing progress into the placeholder until the result starts streaming.
+func (a *API) startAnalysisJob(req analysisJobRequest) (*jobs.Job, error) {
+	T := i18n.LocalizerFunc(a.i18nBundle, req.user.Locale)
+	botID := req.bot.GetMMBot().UserId
+	post := req.post
+
+	// The generation only starts once the job runs, so reject the request up front
+	// when the limits are already reached instead of failing in the background
+	if err := llm.CheckConcurrency(req.bot.LLM(), req.user.Id); err != nil {
+		return nil, err
+	}
+
+	streaming.ModifyPostForBot(botID, req.user.Id, post, "")
+	post.Message = T("agents.analysis_job_queued", "Your request is queued and will start shortly...")
+	if err := a.mmClient.DM(botID, req.user.Id, post); err != nil {
+		return nil, fmt.Errorf("failed to create placeholder post: %w", err)
+	}
+	if err := a.conversationsService.SaveConversation(conversations.Conversation{
+		ID:        post.Id,
+		BotID:     botID,
+		UserID:    req.user.Id,
+		ChannelID: post.ChannelId,
+	}); err != nil {
+		a.pluginAPI.Log.Error("Failed to save analysis conversation", "post_id", post.Id, "error", err)
+	}
+
+	job := &jobs.Job{
+		Type:      req.jobType,
+		UserID:    req.user.Id,
+		ChannelID: req.channelID,
+		PostID:    post.Id,
+	}
+
+	return a.jobsService.Start(job, func(ctx context.Context, progress jobs.ProgressFunc) error {
+		reportProgress := func(message string) {
+			progress(message)
+			post.Message = message
+			if err := a.mmClient.UpdatePost(post); err != nil {
+				a.pluginAPI.Log.Error("Failed to update job progress post", "post_id", post.Id, "error", err)
+			}
+		}
+
+		reportProgress(T("agents.analysis_job_running", "Reading messages and preparing the analysis. This can take a few minutes..."))
+
+		// Get the streaming context first so stopping the post also stops the generation
+		streamCtx, err := a.streamingService.GetStreamingContext(ctx, post.Id)
+		if err != nil {
+			return err
+		}
+		defer a.streamingService.FinishStreaming(post.Id)
+
+		stream, err := req.startStream(streamCtx)
+		if err != nil {
+			reportProgress(T("agents.stream_to_post_access_llm_error", "Sorry! An error occurred while accessing the LLM. See server logs for details."))
+			return err
+		}
+
+		post.Message = ""
+		a.streamingService.StreamToPost(streaming.WithFeature(streamCtx, req.jobType), stream, post, req.user.Locale)
+
+		if req.attachments != nil {
+			if fileIDs := req.attachments(); len(fileIDs) > 0 {
+				post.FileIds = append(post.FileIds, fileIDs...)
+				if err := a.mmClient.UpdatePost(post); err != nil {
+					a.pluginAPI.Log.Error("Failed to attach generated files to analysis post", "post_id", post.Id, "error", err)
+				}
+			}
+		}
+
+		return streamCtx.Err()
+	})
+}
+
+func (a *API) jobAuthorizationRequired(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	job, err := a.jobsService.Get(c.Param("jobid"))
+	if errors.Is(err, jobs.ErrJobNotFound) {
+		a.abortWithError(c, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	if job.UserID != userID {
+		a.abortWithError(c, http.StatusForbidden, errors.New("only the user who started the job can access it"))
+		return
+	}
+
+	c.Set(ContextJobKey, job)
+}
+
+func (a *API) handleGetAnalysisJob(c *gin.Context) {
+	job := c.MustGet(ContextJobKey).(*jobs.Job)
+	c.JSON(http.StatusOK, job)
+}
+
+func (a *API) handleCancelAnalysisJob(c *gin.Context) {
+	job := c.MustGet(ContextJobKey).(*jobs.Job)
+
+	if err := a.enforceEmptyBody(c); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	canceledJob, err := a.jobsService.Cancel(job.ID)
+	if errors.Is(err, jobs.ErrJobNotRunning) {
+		c.JSON(http.StatusConflict, canceledJob)
+		return
+	}
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, canceledJob)
+}
@@ -164,7 +164,7 @@ func (a *API) streamLLMResponse(c *gin.Context, bot *bots.Bot, llmRequest llm.Co
 	c.Status(http.StatusOK)
 
 	// Make the streaming LLM call
-	streamResult, err := bot.LLM().ChatCompletion(llmRequest, opts...)
+	streamResult, err := bot.LLM().ChatCompletion(c.Request.Context(), llmRequest, opts...)
 	if err != nil {
 		// If streaming hasn't started, we can still send a JSON error
 		errorEvent := llm.TextStreamEvent{
@@ -201,7 +201,7 @@ func (a *API) streamLLMResponse(c *gin.Context, bot *bots.Bot, llmRequest llm.Co
 // handleNonStreamingLLMResponse handles non-streaming LLM responses
 func (a *API) handleNonStreamingLLMResponse(c *gin.Context, bot *bots.Bot, llmRequest llm.CompletionRequest, opts ...llm.LanguageModelOption) {
 	// Make the non-streaming LLM call
-	response, err := bot.LLM().ChatCompletionNoStream(llmRequest, opts...)
+	response, err := bot.LLM().ChatCompletionNoStream(c.Request.Context(), llmRequest, opts...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, bridgeclient.ErrorResponse{
 			Error: fmt.Sprintf("failed to complete LLM request: %v", err),
@@ -0,0 +1,100 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/mentions"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const TitleSummarizeMentions = "Mentions Summary"
+
+// handleSummarizeMentions streams to the user by direct message a prioritized summary of the
+// posts mentioning them in a time window, across the channels they can read
+func (a *API) handleSummarizeMentions(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	data := struct {
+		StartTime int64 `json:"start_time"`
+		EndTime   int64 `json:"end_time"` // 0 means "until present"
+	}{}
+	if err := json.NewDecoder(c.Request.Body).Decode(&data); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	defer c.Request.Body.Close()
+
+	// Times are in milliseconds, the last day by default
+	endTime := data.EndTime
+	if endTime == 0 {
+		endTime = model.GetMillis()
+	}
+	startTime := data.StartTime
+	if startTime == 0 {
+		startTime = endTime - (24 * time.Hour).Milliseconds()
+	}
+	if startTime >= endTime {
+		a.abortWithError(c, http.StatusBadRequest, errors.New("start_time must be before end_time"))
+		return
+	}
+	if endTime-startTime > mentions.MaxWindow.Milliseconds() {
+		a.abortWithError(c, http.StatusBadRequest, errors.New("time window cannot exceed 14 days"))
+		return
+	}
+
+	if err := a.bots.CheckUsageRestrictionsForUser(bot, userID); err != nil {
+		a.abortWithError(c, http.StatusForbidden, err)
+		return
+	}
+
+	user, err := a.pluginAPI.User.Get(userID)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("unable to get user: %w", err))
+		return
+	}
+
+	llmContext := a.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		user,
+		nil,
+		a.contextBuilder.WithLLMContextNoTools(),
+	)
+
+	summarizer := mentions.New(bot.FeatureLLM(analytics.FeatureMentionsSummary), a.prompts, a.mmClient, a.dbClient, a.bots)
+	resultStream, err := summarizer.Summarize(a.backgroundCtx, bot, llmContext, startTime, endTime)
+	if errors.Is(err, mentions.ErrNoMentions) {
+		a.abortWithError(c, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		a.abortWithError(c, generationErrorStatus(err), fmt.Errorf("failed to summarize mentions: %w", err))
+		return
+	}
+	resultStream = a.analyticsService.TrackStream(resultStream, analytics.NewEvent(analytics.FeatureMentionsSummary, bot.GetMMBot().UserId, user.Id, nil))
+
+	post := &model.Post{}
+	post.AddProp(streaming.NoRegen, "true")
+	if err := a.streamingService.StreamToNewDM(streaming.WithFeature(a.backgroundCtx, analytics.FeatureMentionsSummary), bot.GetMMBot().UserId, resultStream, user.Id, post, ""); err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	a.conversationsService.SaveTitleAsync(post.Id, a.localizedTitle(user.Locale, TitleSummarizeMentions))
+
+	c.JSON(http.StatusOK, map[string]string{
+		"postid":    post.Id,
+		"channelid": post.ChannelId,
+	})
+}
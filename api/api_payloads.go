@@ -0,0 +1,26 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetCapturedPayloads returns the sanitized payloads of the most recent provider requests
+func (a *API) handleGetCapturedPayloads(c *gin.Context) {
+	c.JSON(http.StatusOK, a.bots.CapturedPayloads())
+}
+
+// handleClearCapturedPayloads discards the captured payloads
+func (a *API) handleClearCapturedPayloads(c *gin.Context) {
+	if err := a.enforceEmptyBody(c); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	a.bots.ClearCapturedPayloads()
+	c.Status(http.StatusNoContent)
+}
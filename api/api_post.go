@@ -4,16 +4,20 @@
 package api
 
 import (
-	stdcontext "context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/render"
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/meetings"
 	"github.com/mattermost/mattermost-plugin-ai/react"
 	"github.com/mattermost/mattermost-plugin-ai/streaming"
 	"github.com/mattermost/mattermost-plugin-ai/threads"
@@ -24,34 +28,57 @@ const (
 	TitleThreadSummary     = "Thread Summary"
 	TitleFindActionItems   = "Action Items"
 	TitleFindOpenQuestions = "Open Questions"
+	TitleExplainPost       = "Explanation"
+	TitleSimplifyPost      = "Simple Explanation"
 )
 
+// titleTranslationIDs maps the canned conversation titles to their translation IDs
+var titleTranslationIDs = map[string]string{
+	TitleThreadSummary:     "agents.title_thread_summary",
+	TitleFindActionItems:   "agents.title_action_items",
+	TitleFindOpenQuestions: "agents.title_open_questions",
+	TitleExplainPost:       "agents.title_explain_post",
+	TitleSimplifyPost:      "agents.title_simplify_post",
+	TitleSummarizeUnreads:  "agents.title_summarize_unreads",
+	TitleSummarizeChannel:  "agents.title_summarize_channel",
+	TitleSummarizeMentions: "agents.title_summarize_mentions",
+}
+
+// localizedTitle translates a canned conversation title into the user's language
+func (a *API) localizedTitle(locale, title string) string {
+	id, ok := titleTranslationIDs[title]
+	if !ok {
+		return title
+	}
+	return i18n.LocalizerFunc(a.i18nBundle, locale)(id, title)
+}
+
 func (a *API) postAuthorizationRequired(c *gin.Context) {
 	userID := c.GetHeader("Mattermost-User-Id")
 	postID := c.Param("postid")
 
 	post, err := a.pluginAPI.Post.GetPost(postID)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Set(ContextPostKey, post)
 
 	channel, err := a.pluginAPI.Channel.Get(post.ChannelId)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 	c.Set(ContextChannelKey, channel)
 
 	if !a.pluginAPI.User.HasPermissionToChannel(userID, channel.Id, model.PermissionReadChannel) {
-		c.AbortWithError(http.StatusForbidden, errors.New("user doesn't have permission to read channel post in in"))
+		a.abortWithError(c, http.StatusForbidden, errors.New("user doesn't have permission to read channel post in in"))
 		return
 	}
 
 	bot := c.MustGet(ContextBotKey).(*bots.Bot)
 	if err := a.bots.CheckUsageRestrictions(userID, bot, channel); err != nil {
-		c.AbortWithError(http.StatusForbidden, err)
+		a.abortWithUsageRestriction(c, err)
 		return
 	}
 }
@@ -63,13 +90,13 @@ func (a *API) handleReact(c *gin.Context) {
 	bot := c.MustGet(ContextBotKey).(*bots.Bot)
 
 	if err := a.enforceEmptyBody(c); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		a.abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
 	requestingUser, err := a.pluginAPI.User.Get(userID)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -82,9 +109,9 @@ func (a *API) handleReact(c *gin.Context) {
 	emojiName, err := react.New(
 		bot.LLM(),
 		a.prompts,
-	).Resolve(post.Message, context)
+	).Resolve(c.Request.Context(), post.Message, context)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -94,7 +121,7 @@ func (a *API) handleReact(c *gin.Context) {
 		UserId:    userID,
 		PostId:    post.Id,
 	}); err != nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to add reaction: %w", err))
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("failed to add reaction: %w", err))
 	}
 
 	c.Status(http.StatusOK)
@@ -106,16 +133,11 @@ func (a *API) handleThreadAnalysis(c *gin.Context) {
 	channel := c.MustGet(ContextChannelKey).(*model.Channel)
 	bot := c.MustGet(ContextBotKey).(*bots.Bot)
 
-	if !a.licenseChecker.IsBasicsLicensed() {
-		c.AbortWithError(http.StatusForbidden, errors.New("feature not licensed"))
-		return
-	}
-
 	var data struct {
 		AnalysisType string `json:"analysis_type" binding:"required"`
 	}
 	if bindErr := c.ShouldBindJSON(&data); bindErr != nil {
-		c.AbortWithError(http.StatusBadRequest, bindErr)
+		a.abortWithError(c, http.StatusBadRequest, bindErr)
 		return
 	}
 
@@ -127,14 +149,14 @@ func (a *API) handleThreadAnalysis(c *gin.Context) {
 	case "open_questions":
 		// Valid analysis type for finding open questions
 	default:
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid analysis type: %s", data.AnalysisType))
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid analysis type: %s", data.AnalysisType))
 		return
 	}
 
 	// Get the user to build context
 	user, err := a.pluginAPI.User.Get(userID)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to get user: %w", err))
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("unable to get user: %w", err))
 		return
 	}
 
@@ -146,35 +168,56 @@ func (a *API) handleThreadAnalysis(c *gin.Context) {
 		a.contextBuilder.WithLLMContextDefaultTools(bot),
 	)
 
+	policy, err := a.bots.ChannelPolicy(channel)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
 	// Create thread analyzer
-	analyzer := threads.New(bot.LLM(), a.prompts, a.mmClient)
+	analyzer := a.threadAnalyzer(a.verification.Wrap(bot.FeatureLLM(analytics.FeatureThreadAnalysis), analytics.FeatureThreadAnalysis), bot, policy, user.Id)
 	var analysisStream *llm.TextStreamResult
 	var title string
 	switch data.AnalysisType {
 	case "summarize_thread":
 		title = TitleThreadSummary
-		analysisStream, err = analyzer.Summarize(post.Id, llmContext)
+		analysisStream, err = analyzer.Summarize(a.backgroundCtx, post.Id, llmContext)
 	case "action_items":
 		title = TitleFindActionItems
-		analysisStream, err = analyzer.FindActionItems(post.Id, llmContext)
+		analysisStream, err = analyzer.FindActionItems(a.backgroundCtx, post.Id, llmContext)
 	case "open_questions":
 		title = TitleFindOpenQuestions
-		analysisStream, err = analyzer.FindOpenQuestions(post.Id, llmContext)
+		analysisStream, err = analyzer.FindOpenQuestions(a.backgroundCtx, post.Id, llmContext)
 	}
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to analyze thread: %w", err))
+		a.abortWithError(c, generationErrorStatus(err), fmt.Errorf("failed to analyze thread: %w", err))
 		return
 	}
+	analysisStream = a.analyticsService.TrackStream(analysisStream, analytics.NewEvent(analytics.FeatureThreadAnalysis, bot.GetMMBot().UserId, user.Id, channel))
 
 	// Create analysis post
 	siteURL := a.pluginAPI.Configuration.GetConfig().ServiceSettings.SiteURL
 	analysisPost := a.makeAnalysisPost(user.Locale, post.Id, data.AnalysisType, *siteURL)
-	if err := a.streamingService.StreamToNewDM(stdcontext.Background(), bot.GetMMBot().UserId, analysisStream, user.Id, analysisPost, post.Id); err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+	if err := a.streamingService.StreamToNewDM(streaming.WithFeature(a.backgroundCtx, analytics.FeatureThreadAnalysis), bot.GetMMBot().UserId, analysisStream, user.Id, analysisPost, post.Id); err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	a.conversationsService.SaveTitleAsync(post.Id, title)
+	// Replies to the analysis post follow up on the analysis of the thread
+	if err := a.conversationsService.SaveConversation(conversations.Conversation{
+		ID:        analysisPost.Id,
+		BotID:     bot.GetMMBot().UserId,
+		UserID:    user.Id,
+		ChannelID: analysisPost.ChannelId,
+		State: conversations.ConversationState{
+			ThreadID:     post.Id,
+			AnalysisType: data.AnalysisType,
+		},
+	}); err != nil {
+		a.pluginAPI.Log.Error("Failed to save thread analysis conversation", "error", err)
+	}
+
+	a.conversationsService.SaveTitleAsync(post.Id, a.localizedTitle(user.Locale, title))
 
 	c.JSON(http.StatusOK, map[string]string{
 		"postid":    analysisPost.Id,
@@ -190,13 +233,13 @@ func (a *API) handleTranscribeFile(c *gin.Context) {
 	bot := c.MustGet(ContextBotKey).(*bots.Bot)
 
 	if err := a.enforceEmptyBody(c); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		a.abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	result, err := a.meetingsService.HandleTranscribeFile(userID, bot, post, channel, fileID)
+	result, err := a.meetingsService.HandleTranscribeFile(a.backgroundCtx, userID, bot, post, channel, fileID)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -210,17 +253,49 @@ func (a *API) handleSummarizeTranscription(c *gin.Context) {
 	bot := c.MustGet(ContextBotKey).(*bots.Bot)
 
 	if err := a.enforceEmptyBody(c); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		a.abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	result, err := a.meetingsService.HandleSummarizeTranscription(userID, bot, post, channel)
+	result, err := a.meetingsService.HandleSummarizeTranscription(a.backgroundCtx, userID, bot, post, channel)
 	if err != nil {
 		if err.Error() == "not a calls or zoom bot post" {
-			c.AbortWithError(http.StatusBadRequest, errors.New("not a calls or zoom bot post"))
+			a.abortWithError(c, http.StatusBadRequest, errors.New("not a calls or zoom bot post"))
 			return
 		}
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to summarize transcription: %w", err))
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("unable to summarize transcription: %w", err))
+		return
+	}
+
+	c.Render(http.StatusOK, render.JSON{Data: result})
+}
+
+func (a *API) handleTranslateTranscription(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	var data struct {
+		Language string `json:"language"`
+	}
+	if err := json.NewDecoder(c.Request.Body).Decode(&data); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	defer c.Request.Body.Close()
+
+	result, err := a.meetingsService.HandleTranslateTranscription(a.backgroundCtx, userID, bot, post, channel, data.Language)
+	if err != nil {
+		if errors.Is(err, meetings.ErrInvalidLanguage) {
+			a.abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+		if err.Error() == "not a calls or zoom bot post" {
+			a.abortWithError(c, http.StatusBadRequest, errors.New("not a calls or zoom bot post"))
+			return
+		}
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("unable to translate transcription: %w", err))
 		return
 	}
 
@@ -232,18 +307,18 @@ func (a *API) handleStop(c *gin.Context) {
 	post := c.MustGet(ContextPostKey).(*model.Post)
 
 	if err := a.enforceEmptyBody(c); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		a.abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
 	botID := post.UserId
 	if !a.bots.IsAnyBot(botID) {
-		c.AbortWithError(http.StatusBadRequest, errors.New("not a bot post"))
+		a.abortWithError(c, http.StatusBadRequest, errors.New("not a bot post"))
 		return
 	}
 
 	if post.GetProp(streaming.LLMRequesterUserID) != userID {
-		c.AbortWithError(http.StatusForbidden, errors.New("only the original poster can stop the stream"))
+		a.abortWithError(c, http.StatusForbidden, errors.New("only the original poster can stop the stream"))
 		return
 	}
 
@@ -257,32 +332,92 @@ func (a *API) handleRegenerate(c *gin.Context) {
 	channel := c.MustGet(ContextChannelKey).(*model.Channel)
 
 	if err := a.enforceEmptyBody(c); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		a.abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	err := a.conversationsService.HandleRegenerate(userID, post, channel)
+	err := a.conversationsService.HandleRegenerate(c.Request.Context(), userID, post, channel)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to regenerate post: %w", err))
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("unable to regenerate post: %w", err))
 		return
 	}
 
 	c.Status(http.StatusOK)
 }
 
-func (a *API) handleToolCall(c *gin.Context) {
+func (a *API) handleRegenerateTitle(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
+	if err := a.enforceEmptyBody(c); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	bot := a.bots.GetBotForDMChannel(channel)
+	if bot == nil {
+		a.abortWithError(c, http.StatusBadRequest, conversations.ErrNotAIConversation)
+		return
+	}
+
+	user, err := a.pluginAPI.User.Get(userID)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	title, err := a.conversationsService.RegenerateTitle(c.Request.Context(), bot, user, post, channel)
+	if err != nil {
+		if errors.Is(err, conversations.ErrNotAIConversation) {
+			a.abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("unable to regenerate title: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{
+		"title": title,
+	})
+}
+
+func (a *API) handleFollowUp(c *gin.Context) {
 	userID := c.GetHeader("Mattermost-User-Id")
 	post := c.MustGet(ContextPostKey).(*model.Post)
 	channel := c.MustGet(ContextChannelKey).(*model.Channel)
 
-	if !a.licenseChecker.IsBasicsLicensed() {
-		c.AbortWithError(http.StatusForbidden, errors.New("feature not licensed"))
+	var data struct {
+		Index *int `json:"index" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
+	followUpPost, err := a.conversationsService.AskFollowUp(userID, post, channel, *data.Index)
+	if err != nil {
+		if errors.Is(err, conversations.ErrFollowUpNotFound) {
+			a.abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("unable to ask follow-up question: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{
+		"postid": followUpPost.Id,
+	})
+}
+
+func (a *API) handleToolCall(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+	channel := c.MustGet(ContextChannelKey).(*model.Channel)
+
 	// Only the original requester can approve/reject tool calls
 	if post.GetProp(streaming.LLMRequesterUserID) != userID {
-		c.AbortWithError(http.StatusForbidden, errors.New("only the original requester can approve/reject tool calls"))
+		a.abortWithError(c, http.StatusForbidden, errors.New("only the original requester can approve/reject tool calls"))
 		return
 	}
 
@@ -291,16 +426,16 @@ func (a *API) handleToolCall(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&data); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		a.abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	err := a.conversationsService.HandleToolCall(userID, post, channel, data.AcceptedToolIDs)
+	err := a.conversationsService.HandleToolCall(a.backgroundCtx, userID, post, channel, data.AcceptedToolIDs)
 	if err != nil {
 		if err.Error() == "post missing pending tool calls" || err.Error() == "post pending tool calls not valid JSON" {
-			c.AbortWithError(http.StatusBadRequest, err)
+			a.abortWithError(c, http.StatusBadRequest, err)
 		} else {
-			c.AbortWithError(http.StatusInternalServerError, err)
+			a.abortWithError(c, http.StatusInternalServerError, err)
 		}
 		return
 	}
@@ -313,16 +448,16 @@ func (a *API) handlePostbackSummary(c *gin.Context) {
 	post := c.MustGet(ContextPostKey).(*model.Post)
 
 	if err := a.enforceEmptyBody(c); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err)
+		a.abortWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
 	result, err := a.meetingsService.HandlePostbackSummary(userID, post)
 	if err != nil {
 		if err.Error() == "post missing reference to transcription post ID" {
-			c.AbortWithError(http.StatusBadRequest, err)
+			a.abortWithError(c, http.StatusBadRequest, err)
 		} else {
-			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to post back summary: %w", err))
+			a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("unable to post back summary: %w", err))
 		}
 		return
 	}
@@ -330,7 +465,23 @@ func (a *API) handlePostbackSummary(c *gin.Context) {
 	c.Render(http.StatusOK, render.JSON{Data: result})
 }
 
-// makeAnalysisPost creates a post for thread analysis results
+// threadAnalyzer creates the analyzer of threads for the user with the language model of the bot,
+// leaving out the posts the channel policy excludes, and adding the attached files and an excerpt
+// of the linked posts the user can read
+func (a *API) threadAnalyzer(languageModel llm.LanguageModel, bot *bots.Bot, policy exclusions.ChannelPolicy, userID string) *threads.Threads {
+	analyzer := threads.New(languageModel, a.prompts, a.mmClient)
+	analyzer.SetChannelPolicy(policy)
+	if a.imageText != nil {
+		analyzer.SetImageDescriber(a.imageText, bot)
+	}
+	if a.permalinks != nil {
+		analyzer.SetLinkResolver(a.permalinks, userID)
+	}
+	return analyzer
+}
+
+// makeAnalysisPost creates a post for thread analysis results. The analysis is stored with the
+// conversation, the props only tell the webapp how to display the post.
 func (a *API) makeAnalysisPost(locale string, postIDToAnalyze string, analysisType string, siteURL string) *model.Post {
 	post := &model.Post{}
 	post.AddProp(conversations.ThreadIDProp, postIDToAnalyze)
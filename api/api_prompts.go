@@ -0,0 +1,189 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/promptstore"
+)
+
+// PromptInfo describes a prompt template and the override active for a bot.
+type PromptInfo struct {
+	Name     string               `json:"name"`
+	Default  string               `json:"default"`
+	Override *promptstore.Version `json:"override"`
+}
+
+// PromptDetails contains a prompt template with all saved versions for a bot.
+type PromptDetails struct {
+	Name     string                `json:"name"`
+	Default  string                `json:"default"`
+	Versions []promptstore.Version `json:"versions"`
+}
+
+// validatePromptBotID checks that botID is empty, meaning all bots, or an existing bot.
+func (a *API) validatePromptBotID(botID string) error {
+	if botID == promptstore.AllBots {
+		return nil
+	}
+	if a.bots.GetBotByID(botID) == nil {
+		return fmt.Errorf("bot not found: %s", botID)
+	}
+	return nil
+}
+
+func (a *API) promptNameRequired(c *gin.Context) {
+	if !slices.Contains(a.prompts.Names(), c.Param("name")) {
+		a.abortWithError(c, http.StatusNotFound, fmt.Errorf("prompt not found: %s", c.Param("name")))
+		return
+	}
+}
+
+func (a *API) handleListPrompts(c *gin.Context) {
+	botID := c.Query("bot_id")
+	if err := a.validatePromptBotID(botID); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	active, err := a.promptStore.ListActive(botID)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	overrides := make(map[string]promptstore.Version, len(active))
+	for _, version := range active {
+		overrides[version.Name] = version
+	}
+
+	names := a.prompts.Names()
+	result := make([]PromptInfo, 0, len(names))
+	for _, name := range names {
+		defaultTemplate, defaultErr := a.prompts.Default(name)
+		if defaultErr != nil {
+			a.abortWithError(c, http.StatusInternalServerError, defaultErr)
+			return
+		}
+		info := PromptInfo{
+			Name:    name,
+			Default: defaultTemplate,
+		}
+		if override, ok := overrides[name]; ok {
+			info.Override = &override
+		}
+		result = append(result, info)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *API) handleGetPrompt(c *gin.Context) {
+	name := c.Param("name")
+	botID := c.Query("bot_id")
+	if err := a.validatePromptBotID(botID); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	defaultTemplate, err := a.prompts.Default(name)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	versions, err := a.promptStore.ListVersions(botID, name)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, PromptDetails{
+		Name:     name,
+		Default:  defaultTemplate,
+		Versions: versions,
+	})
+}
+
+func (a *API) handleSavePrompt(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	name := c.Param("name")
+
+	var data struct {
+		BotID    string `json:"bot_id"`
+		Template string `json:"template" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := a.validatePromptBotID(data.BotID); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := a.prompts.Validate(name, data.Template); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid template: %w", err))
+		return
+	}
+
+	version, err := a.promptStore.SaveVersion(data.BotID, name, data.Template, userID)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+func (a *API) handleActivatePromptVersion(c *gin.Context) {
+	name := c.Param("name")
+
+	var data struct {
+		BotID   string `json:"bot_id"`
+		Version int    `json:"version" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := a.validatePromptBotID(data.BotID); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	err := a.promptStore.ActivateVersion(data.BotID, name, data.Version)
+	if errors.Is(err, promptstore.ErrVersionNotFound) {
+		a.abortWithError(c, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (a *API) handleResetPrompt(c *gin.Context) {
+	name := c.Param("name")
+	botID := c.Query("bot_id")
+	if err := a.validatePromptBotID(botID); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := a.promptStore.Reset(botID, name); err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
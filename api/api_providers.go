@@ -0,0 +1,72 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+func (a *API) handleTestProvider(c *gin.Context) {
+	if err := a.enforceEmptyBody(c); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := a.bots.CheckService(c.Param("serviceid"))
+	if errors.Is(err, bots.ErrServiceNotFound) {
+		a.abortWithError(c, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+type ValidateModelRequest struct {
+	Service llm.ServiceConfig `json:"service"`
+	Bot     llm.BotConfig     `json:"bot"`
+}
+
+type ValidateModelResponse struct {
+	Capabilities *llm.ModelCapabilities `json:"capabilities"`
+	Warnings     []string               `json:"warnings"`
+}
+
+func (a *API) handleValidateModel(c *gin.Context) {
+	var req ValidateModelRequest
+	if err := c.BindJSON(&req); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	model := req.Service.DefaultModel
+	if req.Bot.Model != "" {
+		model = req.Bot.Model
+	}
+
+	response := ValidateModelResponse{
+		Warnings: a.bots.ValidateModelCapabilities(req.Service, req.Bot),
+	}
+	if capabilities, ok := a.bots.LookupModelCapabilities(req.Service, model); ok {
+		response.Capabilities = &capabilities
+	}
+	if response.Warnings == nil {
+		response.Warnings = []string{}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (a *API) handleGetModelWarnings(c *gin.Context) {
+	c.JSON(http.StatusOK, a.bots.ModelWarnings())
+}
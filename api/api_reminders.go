@@ -0,0 +1,41 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/reminders"
+)
+
+// handleGetReminders lists the scheduled reminders of the user
+func (a *API) handleGetReminders(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	result, err := a.reminders.List(userID)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleCancelReminder cancels a scheduled reminder of the user
+func (a *API) handleCancelReminder(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	if err := a.reminders.Cancel(userID, c.Param("reminderid")); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, reminders.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		a.abortWithError(c, status, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
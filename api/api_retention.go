@@ -0,0 +1,50 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/retention"
+)
+
+// RetentionReportResponse is the last purge of the data past its retention, nil if none ran yet
+type RetentionReportResponse struct {
+	Enabled    bool              `json:"enabled"`
+	LastReport *retention.Report `json:"last_report"`
+}
+
+func (a *API) handleGetRetentionReport(c *gin.Context) {
+	report, err := a.retention.LastReport()
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RetentionReportResponse{
+		Enabled:    a.retention.Enabled(),
+		LastReport: report,
+	})
+}
+
+func (a *API) handlePurgeRetention(c *gin.Context) {
+	if err := a.enforceEmptyBody(c); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := a.retention.Purge()
+	if errors.Is(err, retention.ErrNotAvailable) {
+		a.abortWithError(c, http.StatusNotImplemented, err)
+		return
+	}
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
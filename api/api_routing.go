@@ -0,0 +1,51 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/routing"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// ResolvedBotResponse is the agent answering the user in a channel
+type ResolvedBotResponse struct {
+	Bot    AIBotInfo      `json:"bot"`
+	Reason routing.Reason `json:"reason"`
+}
+
+// handleResolveAIBot returns the agent the routing rules choose for the user in the channel of
+// the channel_id query parameter, so clients don't have to pick one
+func (a *API) handleResolveAIBot(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	channelID := c.Query("channel_id")
+	if !model.IsValidId(channelID) {
+		a.abortWithError(c, http.StatusBadRequest, errors.New("invalid channel_id parameter"))
+		return
+	}
+
+	if !a.pluginAPI.User.HasPermissionToChannel(userID, channelID, model.PermissionReadChannel) {
+		a.abortWithError(c, http.StatusForbidden, errors.New("user doesn't have permission to read channel"))
+		return
+	}
+	channel, err := a.pluginAPI.Channel.Get(channelID)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	bot, reason := a.bots.ResolveBot(userID, channel)
+	if bot == nil {
+		a.abortWithError(c, http.StatusNotFound, errors.New("no agent is available to the user in this channel"))
+		return
+	}
+
+	c.JSON(http.StatusOK, ResolvedBotResponse{
+		Bot:    a.aiBotInfo(bot, userID),
+		Reason: reason,
+	})
+}
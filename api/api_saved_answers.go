@@ -0,0 +1,128 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/savedanswers"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const defaultSavedAnswersPerPage = 20
+
+// SaveAnswerRequest is the body for saving a bot response
+type SaveAnswerRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// SavedAnswersResponse is a page of the saved answers of a user
+type SavedAnswersResponse struct {
+	SavedAnswers []savedanswers.SavedAnswer `json:"saved_answers"`
+	HasMore      bool                       `json:"has_more"`
+}
+
+// parseSavedAnswersQuery reads the filters and page of the saved answers of userID from the query string
+func parseSavedAnswersQuery(c *gin.Context, userID string) (savedanswers.Query, error) {
+	query := savedanswers.Query{
+		UserID:  userID,
+		Tag:     c.Query("tag"),
+		Terms:   c.Query("terms"),
+		PerPage: defaultSavedAnswersPerPage,
+	}
+
+	if len(query.Tag) > savedanswers.MaxTagLength {
+		return query, fmt.Errorf("tag parameter is too long: at most %d characters", savedanswers.MaxTagLength)
+	}
+	if len(query.Terms) > savedanswers.MaxTermsLength {
+		return query, fmt.Errorf("terms parameter is too long: at most %d characters", savedanswers.MaxTermsLength)
+	}
+
+	var err error
+	if page := c.Query("page"); page != "" {
+		query.Page, err = strconv.Atoi(page)
+		if err != nil || query.Page < 0 {
+			return query, fmt.Errorf("invalid page parameter")
+		}
+	}
+	if perPage := c.Query("per_page"); perPage != "" {
+		query.PerPage, err = strconv.Atoi(perPage)
+		if err != nil || query.PerPage < 1 || query.PerPage > savedanswers.MaxPerPage {
+			return query, fmt.Errorf("invalid per_page parameter: must be between 1 and %d", savedanswers.MaxPerPage)
+		}
+	}
+
+	return query, nil
+}
+
+// handleSaveAnswer saves a bot response to the saved answers of the user
+func (a *API) handleSaveAnswer(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	post := c.MustGet(ContextPostKey).(*model.Post)
+
+	if !a.bots.IsAnyBot(post.UserId) {
+		a.abortWithError(c, http.StatusBadRequest, errors.New("not a bot post"))
+		return
+	}
+
+	var data SaveAnswerRequest
+	if err := c.ShouldBindJSON(&data); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := savedanswers.NormalizeTags(data.Tags); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	saved, err := a.savedAnswers.Save(userID, post, data.Tags)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// handleGetSavedAnswers lists and searches the saved answers of the user
+func (a *API) handleGetSavedAnswers(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	query, err := parseSavedAnswersQuery(c, userID)
+	if err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	result, hasMore, err := a.savedAnswers.List(query)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SavedAnswersResponse{
+		SavedAnswers: result,
+		HasMore:      hasMore,
+	})
+}
+
+// handleDeleteSavedAnswer removes a saved answer of the user
+func (a *API) handleDeleteSavedAnswer(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	if err := a.savedAnswers.Delete(userID, c.Param("savedanswerid")); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, savedanswers.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		a.abortWithError(c, status, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -7,8 +7,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 )
 
@@ -25,24 +27,24 @@ func (a *API) handleRunSearch(c *gin.Context) {
 	bot := c.MustGet(ContextBotKey).(*bots.Bot)
 
 	if !a.searchService.Enabled() {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("search functionality is not configured"))
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("search functionality is not configured"))
 		return
 	}
 
 	var req SearchRequest
 	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
 		return
 	}
 
 	if req.Query == "" {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("query cannot be empty"))
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("query cannot be empty"))
 		return
 	}
 
-	result, err := a.searchService.RunSearch(c.Request.Context(), userID, bot, req.Query, req.TeamID, req.ChannelID, req.MaxResults)
+	result, err := a.searchService.RunSearch(a.backgroundCtx, userID, bot, req.Query, req.TeamID, req.ChannelID, req.MaxResults)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -54,21 +56,40 @@ func (a *API) handleSearchQuery(c *gin.Context) {
 	bot := c.MustGet(ContextBotKey).(*bots.Bot)
 
 	if !a.searchService.Enabled() {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("search functionality is not configured"))
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("search functionality is not configured"))
 		return
 	}
 
 	var req SearchRequest
 	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
 		return
 	}
 
+	start := time.Now()
 	response, err := a.searchService.SearchQuery(c.Request.Context(), userID, bot, req.Query, req.TeamID, req.ChannelID, req.MaxResults)
+	a.recordSearchUsage(bot, userID, req, start, err)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+		a.abortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
+
+func (a *API) recordSearchUsage(bot *bots.Bot, userID string, req SearchRequest, start time.Time, searchErr error) {
+	if a.analyticsService == nil {
+		return
+	}
+
+	event := analytics.NewEvent(analytics.FeatureSearch, bot.GetMMBot().UserId, userID, nil)
+	event.ChannelID = req.ChannelID
+	if req.TeamID != "" {
+		event.TeamID = req.TeamID
+	}
+	event.LatencyMS = time.Since(start).Milliseconds()
+	event.Failed = searchErr != nil
+	if err := a.analyticsService.Record(event); err != nil {
+		a.pluginAPI.Log.Error("Failed to record search usage", "error", err)
+	}
+}
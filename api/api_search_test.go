@@ -18,6 +18,7 @@ import (
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	mmapimocks "github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
 	"github.com/mattermost/mattermost-plugin-ai/search"
+	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -92,6 +93,7 @@ func TestHandleRunSearch(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			e := SetupTestEnvironment(t)
+			e.setupLicense(model.NewTestLicenseSKU(model.LicenseShortSkuEnterprise))
 			defer e.Cleanup(t)
 
 			// Override the search service for this test
@@ -187,6 +189,7 @@ func TestHandleSearchQuery(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			e := SetupTestEnvironment(t)
+			e.setupLicense(model.NewTestLicenseSKU(model.LicenseShortSkuEnterprise))
 			defer e.Cleanup(t)
 
 			// Override the search service for this test
@@ -224,3 +227,26 @@ func TestHandleSearchQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchNotLicensed(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+
+	e := SetupTestEnvironment(t)
+	e.setupLicense(nil)
+	defer e.Cleanup(t)
+	e.setupTestBot(llm.BotConfig{Name: "test-bot", DisplayName: "Test Bot"})
+	e.mockAPI.On("LogError", mock.Anything).Maybe()
+	e.mockAPI.On("HasPermissionTo", "userid", model.PermissionManageSystem).Return(false).Maybe()
+
+	request := httptest.NewRequest(http.MethodPost, "/search?botUsername=test-bot", bytes.NewReader([]byte(`{"query":"test query"}`)))
+	request.Header.Add("Mattermost-User-ID", "userid")
+	recorder := httptest.NewRecorder()
+	e.api.ServeHTTP(&plugin.Context{}, recorder, request)
+
+	resp := recorder.Result()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	var apiErr llm.Error
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&apiErr))
+	require.Equal(t, ErrorCodeEnterpriseLicenseRequired, apiErr.Code)
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (a *API) handleGetSecretsStatus(c *gin.Context) {
+	statuses, err := a.secrets.Status()
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statuses)
+}
+
+func (a *API) handleRotateSecrets(c *gin.Context) {
+	if err := a.enforceEmptyBody(c); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	rotated, err := a.secrets.Rotate()
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// The API keys users registered are encrypted with the same keyring
+	rotatedUserKeys, err := a.userKeys.Rotate()
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("failed to rotate user API keys: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rotated": rotated + rotatedUserKeys})
+}
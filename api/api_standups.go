@@ -0,0 +1,79 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/standups"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+func (a *API) handleGetStandup(c *gin.Context) {
+	standup, err := a.standups.Get(c.Param("teamid"))
+	if err != nil {
+		a.abortWithStandupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, standup)
+}
+
+func (a *API) handleSaveStandup(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	teamID := c.Param("teamid")
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	var standup standups.Standup
+	if err := c.ShouldBindJSON(&standup); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	standup.TeamID = teamID
+
+	if !a.pluginAPI.User.HasPermissionToChannel(userID, standup.ChannelID, model.PermissionCreatePost) {
+		a.abortWithError(c, http.StatusForbidden, errors.New("user doesn't have permission to post in the report channel"))
+		return
+	}
+	for _, memberID := range standup.UserIDs {
+		if !a.pluginAPI.User.HasPermissionToTeam(memberID, teamID, model.PermissionViewTeam) {
+			a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("%w: user %s is not a member of the team", standups.ErrInvalid, memberID))
+			return
+		}
+	}
+
+	saved, err := a.standups.Save(userID, bot, &standup)
+	if err != nil {
+		a.abortWithStandupError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+func (a *API) handleDeleteStandup(c *gin.Context) {
+	if err := a.standups.Delete(c.Param("teamid")); err != nil {
+		a.abortWithStandupError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (a *API) abortWithStandupError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, standups.ErrNotFound):
+		a.abortWithError(c, http.StatusNotFound, err)
+	case errors.Is(err, standups.ErrNotEnabled):
+		a.abortWithError(c, http.StatusForbidden, err)
+	case errors.Is(err, standups.ErrInvalid):
+		a.abortWithError(c, http.StatusBadRequest, err)
+	default:
+		a.abortWithError(c, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/channels"
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/summaryexports"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const ContextSummaryExportJobKey = "summary_export_job"
+
+// SummaryExportResponse is the state of a summary export with the progress of each channel
+type SummaryExportResponse struct {
+	Job    *jobs.Job              `json:"job"`
+	Export *summaryexports.Export `json:"export,omitempty"`
+}
+
+// handleStartSummaryExport starts summarizing the channels over the period in the background
+func (a *API) handleStartSummaryExport(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	bot := c.MustGet(ContextBotKey).(*bots.Bot)
+
+	var data struct {
+		ChannelIDs []string `json:"channel_ids"`
+		StartTime  int64    `json:"start_time"`
+		EndTime    int64    `json:"end_time"` // 0 means "until present"
+	}
+	if err := json.NewDecoder(c.Request.Body).Decode(&data); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	defer c.Request.Body.Close()
+
+	if data.EndTime == 0 {
+		data.EndTime = model.GetMillis()
+	}
+	if data.StartTime >= data.EndTime {
+		a.abortWithError(c, http.StatusBadRequest, errors.New("start_time must be before end_time"))
+		return
+	}
+	if len(data.ChannelIDs) == 0 || len(data.ChannelIDs) > summaryexports.MaxChannels {
+		a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("between 1 and %d channels must be summarized", summaryexports.MaxChannels))
+		return
+	}
+
+	user, err := a.pluginAPI.User.Get(userID)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	export := summaryexports.Export{
+		BotUsername: bot.GetMMBot().Username,
+		StartTime:   data.StartTime,
+		EndTime:     data.EndTime,
+	}
+	channelsByID := make(map[string]*model.Channel, len(data.ChannelIDs))
+	teamNames := make(map[string]string)
+	for _, channelID := range data.ChannelIDs {
+		if _, ok := channelsByID[channelID]; ok {
+			continue
+		}
+		channel, err := a.pluginAPI.Channel.Get(channelID)
+		if err != nil {
+			a.abortWithError(c, http.StatusBadRequest, fmt.Errorf("failed to get channel %s: %w", channelID, err))
+			return
+		}
+		channelsByID[channelID] = channel
+
+		if _, ok := teamNames[channel.TeamId]; !ok && channel.TeamId != "" {
+			if team, teamErr := a.pluginAPI.Team.Get(channel.TeamId); teamErr == nil {
+				teamNames[channel.TeamId] = team.Name
+			}
+		}
+		export.Channels = append(export.Channels, summaryexports.ChannelSummary{
+			ChannelID:   channel.Id,
+			TeamName:    teamNames[channel.TeamId],
+			Name:        channel.Name,
+			DisplayName: channel.DisplayName,
+		})
+	}
+
+	job, err := a.summaryExports.Start(userID, export, func(ctx context.Context, channelID string) (string, error) {
+		return a.summarizeChannelForExport(ctx, bot, user, channelsByID[channelID], data.StartTime, data.EndTime)
+	})
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// summarizeChannelForExport summarizes the posts of the channel during the period, skipping the
+// channels the bot may not read.
+func (a *API) summarizeChannelForExport(ctx context.Context, bot *bots.Bot, user *model.User, channel *model.Channel, startTime, endTime int64) (string, error) {
+	if err := a.bots.CheckUsageRestrictionsForChannel(bot, channel); err != nil {
+		return "", fmt.Errorf("%w: %w", summaryexports.ErrChannelSkipped, err)
+	}
+
+	policy, err := a.bots.ChannelPolicy(channel)
+	if err != nil {
+		return "", fmt.Errorf("failed to get channel policy: %w", err)
+	}
+
+	llmContext := a.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		user,
+		channel,
+		a.contextBuilder.WithLLMContextNoTools(),
+	)
+
+	intervals := channels.New(a.verification.Wrap(bot.FeatureLLM(analytics.FeatureChannelInterval), analytics.FeatureChannelInterval), a.prompts, a.mmClient, a.dbClient)
+	intervals.SetChannelPolicy(policy)
+	intervals.SetMaxIntervalPosts(a.config.GetMaxIntervalPosts())
+	stream, err := intervals.Interval(ctx, llmContext, channel.Id, startTime, endTime, prompts.PromptSummarizeChannelRangeSystem, channels.IntervalOptions{
+		ExpandThreads: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	stream = a.analyticsService.TrackStream(stream, analytics.NewEvent(analytics.FeatureChannelInterval, bot.GetMMBot().UserId, user.Id, channel))
+
+	return stream.ReadAll()
+}
+
+// summaryExportJobRequired loads the job of a summary export. Every system admin can access
+// the exports, they are compliance and reporting data rather than personal analyses.
+func (a *API) summaryExportJobRequired(c *gin.Context) {
+	job, err := a.jobsService.Get(c.Param("jobid"))
+	if errors.Is(err, jobs.ErrJobNotFound) || (err == nil && job.Type != summaryexports.JobType) {
+		a.abortWithError(c, http.StatusNotFound, errors.New("summary export not found"))
+		return
+	}
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Set(ContextSummaryExportJobKey, job)
+}
+
+func (a *API) handleGetSummaryExport(c *gin.Context) {
+	job := c.MustGet(ContextSummaryExportJobKey).(*jobs.Job)
+
+	export, err := a.summaryExports.Get(job.ID)
+	if err != nil && !errors.Is(err, summaryexports.ErrNotFound) {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SummaryExportResponse{Job: job, Export: export})
+}
+
+// handleDownloadSummaryExport returns the zip archive of the summaries of a finished export
+func (a *API) handleDownloadSummaryExport(c *gin.Context) {
+	job := c.MustGet(ContextSummaryExportJobKey).(*jobs.Job)
+	if !job.IsFinished() {
+		a.abortWithError(c, http.StatusConflict, errors.New("the export is still running"))
+		return
+	}
+
+	export, err := a.summaryExports.Get(job.ID)
+	if errors.Is(err, summaryexports.ErrNotFound) {
+		a.abortWithError(c, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	archive, err := summaryexports.Archive(export)
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, fmt.Errorf("failed to build the archive: %w", err))
+		return
+	}
+
+	filename := fmt.Sprintf("summaries-%s.zip", time.UnixMilli(job.CreatedAt).UTC().Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+func (a *API) handleCancelSummaryExport(c *gin.Context) {
+	job := c.MustGet(ContextSummaryExportJobKey).(*jobs.Job)
+
+	if err := a.enforceEmptyBody(c); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	canceledJob, err := a.jobsService.Cancel(job.ID)
+	if errors.Is(err, jobs.ErrJobNotRunning) {
+		c.JSON(http.StatusConflict, canceledJob)
+		return
+	}
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, canceledJob)
+}
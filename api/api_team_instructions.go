@@ -0,0 +1,83 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/teaminstructions"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+func (a *API) teamAuthorizationRequired(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	teamID := c.Param("teamid")
+
+	if !a.pluginAPI.User.HasPermissionToTeam(userID, teamID, model.PermissionViewTeam) {
+		a.abortWithError(c, http.StatusForbidden, errors.New("user doesn't have permission to view the team"))
+		return
+	}
+}
+
+func (a *API) teamAdminAuthorizationRequired(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	teamID := c.Param("teamid")
+
+	if !a.pluginAPI.User.HasPermissionToTeam(userID, teamID, model.PermissionManageTeam) {
+		a.abortWithError(c, http.StatusForbidden, errors.New("must be a team admin to change the team settings"))
+		return
+	}
+}
+
+func (a *API) handleGetTeamInstructions(c *gin.Context) {
+	instructions, err := a.teamInstructions.Get(c.Param("teamid"))
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	if instructions == nil {
+		instructions = &teaminstructions.Instructions{TeamID: c.Param("teamid")}
+	}
+
+	c.JSON(http.StatusOK, instructions)
+}
+
+func (a *API) handleSaveTeamInstructions(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	teamID := c.Param("teamid")
+
+	var data struct {
+		Instructions string `json:"instructions"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	instructions, err := a.teamInstructions.Save(teamID, data.Instructions, userID)
+	if errors.Is(err, teaminstructions.ErrTooLong) {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	if instructions == nil {
+		instructions = &teaminstructions.Instructions{TeamID: teamID}
+	}
+
+	c.JSON(http.StatusOK, instructions)
+}
+
+func (a *API) handleDeleteTeamInstructions(c *gin.Context) {
+	if err := a.teamInstructions.Delete(c.Param("teamid")); err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -6,6 +6,8 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -15,12 +17,15 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
 	"github.com/mattermost/mattermost-plugin-ai/embeddings/mocks"
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mcp"
 	"github.com/mattermost/mattermost-plugin-ai/metrics"
 	"github.com/mattermost/mattermost-plugin-ai/public/bridgeclient"
+	"github.com/mattermost/mattermost-plugin-ai/ratelimit"
 	"github.com/mattermost/mattermost-plugin-ai/search"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
@@ -50,6 +55,7 @@ type TestEnvironment struct {
 // testConfigImpl is a minimal implementation of Config for testing
 type testConfigImpl struct {
 	allowUnsafeLinks bool
+	rateLimit        ratelimit.Config
 }
 
 func (tc *testConfigImpl) GetDefaultBotName() string {
@@ -64,6 +70,18 @@ func (tc *testConfigImpl) AllowUnsafeLinks() bool {
 	return tc.allowUnsafeLinks
 }
 
+func (tc *testConfigImpl) EmbeddingSearchConfig() embeddings.EmbeddingSearchConfig {
+	return embeddings.EmbeddingSearchConfig{}
+}
+
+func (tc *testConfigImpl) RateLimit() ratelimit.Config {
+	return tc.rateLimit
+}
+
+func (tc *testConfigImpl) GetMaxIntervalPosts() int {
+	return 0
+}
+
 // mockMCPClientManager is a minimal implementation of MCPClientManager for testing
 type mockMCPClientManager struct{}
 
@@ -134,6 +152,13 @@ func createTestBots(mockAPI *plugintest.API, client *pluginapi.Client) *bots.MMB
 	return testBots
 }
 
+// setupLicense checks the features against the license
+func (e *TestEnvironment) setupLicense(license *model.License) {
+	e.api.licenseChecker = enterprise.NewLicenseChecker(e.client)
+	e.mockAPI.On("GetConfig").Return(&model.Config{}).Maybe()
+	e.mockAPI.On("GetLicense").Return(license).Maybe()
+}
+
 // setupTestBot configures a test bot in the environment
 func (e *TestEnvironment) setupTestBot(botConfig llm.BotConfig) {
 	// Create a mock bot user
@@ -164,7 +189,7 @@ func SetupTestEnvironment(t *testing.T) *TestEnvironment {
 
 	cfg := &testConfigImpl{}
 
-	api := New(testBots, conversationsService, nil, nil, nil, client, noopMetrics, nil, cfg, nil, nil, nil, nil, nil, nil, &mockMCPClientManager{}, nil, nil)
+	api := New(testBots, conversationsService, nil, nil, nil, client, noopMetrics, nil, cfg, nil, nil, nil, nil, nil, nil, &mockMCPClientManager{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "", context.Background())
 
 	return &TestEnvironment{
 		api:     api,
@@ -188,6 +213,8 @@ func TestPostRouter(t *testing.T) {
 		"summarize_transcription": "/post/postid/summarize_transcription",
 		"stop":                    "/post/postid/stop",
 		"regenerate":              "/post/postid/regenerate",
+		"follow_up":               "/post/postid/follow_up",
+		"regenerate_title":        "/post/postid/regenerate_title",
 	} {
 		for name, test := range map[string]struct {
 			request        *http.Request
@@ -554,3 +581,146 @@ func TestHandleGetAIBots(t *testing.T) {
 		})
 	}
 }
+
+func TestAbortWithError(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+
+	tests := []struct {
+		name     string
+		status   int
+		err      error
+		isAdmin  bool
+		expected llm.Error
+	}{
+		{
+			name:     "client error keeps the handler message",
+			status:   http.StatusBadRequest,
+			err:      errors.New("serviceType is required"),
+			expected: llm.Error{Code: llm.ErrorCodeInvalidRequest, Message: "serviceType is required"},
+		},
+		{
+			name:     "server error hides the detail from users",
+			status:   http.StatusInternalServerError,
+			err:      errors.New("failed to get posts: pq: connection refused"),
+			expected: llm.Error{Code: llm.ErrorCodeInternal, Message: "An internal error occurred. See the server logs for details."},
+		},
+		{
+			name:     "server error shows the detail to admins",
+			status:   http.StatusInternalServerError,
+			err:      errors.New("failed to get posts: pq: connection refused"),
+			isAdmin:  true,
+			expected: llm.Error{Code: llm.ErrorCodeInternal, Message: "An internal error occurred. See the server logs for details.", Detail: "failed to get posts: pq: connection refused"},
+		},
+		{
+			name:     "generation limit is retryable",
+			status:   http.StatusTooManyRequests,
+			err:      fmt.Errorf("failed to analyze thread: %w", llm.ErrConcurrencyLimitReached),
+			expected: llm.Error{Code: llm.ErrorCodeRateLimited, Message: "Too many responses are being generated. Try again shortly.", Retryable: true},
+		},
+		{
+			name:     "excluded channel",
+			status:   http.StatusForbidden,
+			err:      exclusions.ErrChannelExcluded,
+			expected: llm.Error{Code: ErrorCodeChannelExcluded, Message: exclusions.ErrChannelExcluded.Error()},
+		},
+		{
+			name:     "channel policy requires confirmation",
+			status:   http.StatusPreconditionRequired,
+			err:      exclusions.ErrConfirmationRequired,
+			expected: llm.Error{Code: ErrorCodeConfirmationRequired, Message: exclusions.ErrConfirmationRequired.Error()},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := SetupTestEnvironment(t)
+			defer e.Cleanup(t)
+			e.mockAPI.On("HasPermissionTo", "userid", model.PermissionManageSystem).Return(tc.isAdmin).Maybe()
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.Header.Set("Mattermost-User-Id", "userid")
+
+			e.api.abortWithError(c, tc.status, tc.err)
+
+			require.Equal(t, tc.status, recorder.Code)
+			var body llm.Error
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+			require.Equal(t, tc.expected, body)
+		})
+	}
+}
+
+func TestRateLimitRequired(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+
+	type request struct {
+		userID string
+		path   string
+	}
+
+	tests := []struct {
+		name           string
+		config         ratelimit.Config
+		requests       []request
+		expectedStatus []int
+	}{
+		{
+			name:           "disabled",
+			config:         ratelimit.Config{Enabled: false, EndpointRequestsPerMinute: 1},
+			requests:       []request{{"user1", "/a"}, {"user1", "/a"}},
+			expectedStatus: []int{http.StatusOK, http.StatusOK},
+		},
+		{
+			name:           "endpoint limit is per endpoint and per user",
+			config:         ratelimit.Config{Enabled: true, EndpointRequestsPerMinute: 1},
+			requests:       []request{{"user1", "/a"}, {"user1", "/a"}, {"user1", "/b"}, {"user2", "/a"}},
+			expectedStatus: []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK, http.StatusOK},
+		},
+		{
+			name:           "user limit covers every endpoint",
+			config:         ratelimit.Config{Enabled: true, UserRequestsPerMinute: 2},
+			requests:       []request{{"user1", "/a"}, {"user1", "/b"}, {"user1", "/b"}, {"user2", "/b"}},
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+		},
+		{
+			name:           "endpoint override",
+			config:         ratelimit.Config{Enabled: true, EndpointRequestsPerMinute: 1, EndpointOverrides: map[string]int{"/a": 0}},
+			requests:       []request{{"user1", "/a"}, {"user1", "/a"}, {"user1", "/b"}, {"user1", "/b"}},
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := SetupTestEnvironment(t)
+			defer e.Cleanup(t)
+			e.config.rateLimit = tc.config
+			e.mockAPI.On("HasPermissionTo", mock.Anything, model.PermissionManageSystem).Return(false).Maybe()
+
+			router := gin.New()
+			router.Use(e.api.rateLimitRequired)
+			router.GET("/a", func(c *gin.Context) { c.Status(http.StatusOK) })
+			router.GET("/b", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			for i, req := range tc.requests {
+				request := httptest.NewRequest(http.MethodGet, req.path, nil)
+				request.Header.Set("Mattermost-User-Id", req.userID)
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, request)
+
+				require.Equal(t, tc.expectedStatus[i], recorder.Code, "request %d", i)
+				if recorder.Code == http.StatusTooManyRequests {
+					require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+					var body llm.Error
+					require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+					require.Equal(t, llm.ErrorCodeRateLimited, body.Code)
+					require.True(t, body.Retryable)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost-plugin-ai/traces"
+)
+
+// handleGetTrace returns the agent trace for a post. The post can be either the request
+// a bot answered or the bot's response to it.
+func (a *API) handleGetTrace(c *gin.Context) {
+	postID := c.Param("postid")
+
+	if err := a.enforceEmptyBody(c); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	requestPostID := postID
+	post, err := a.mmClient.GetPost(postID)
+	if err != nil {
+		a.abortWithError(c, http.StatusNotFound, err)
+		return
+	}
+	if respondingTo, ok := post.GetProp(streaming.RespondingToProp).(string); ok && respondingTo != "" {
+		requestPostID = respondingTo
+	}
+
+	trace, err := a.traceStore.Get(requestPostID)
+	if errors.Is(err, traces.ErrTraceNotFound) {
+		a.abortWithError(c, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, trace)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/userkeys"
+)
+
+const ContextUserKeyServiceKey = "user_key_service"
+
+// UserKeyService is a service that accepts user API keys, with the key the user registered for it
+type UserKeyService struct {
+	ServiceID   string            `json:"service_id"`
+	ServiceName string            `json:"service_name"`
+	ServiceType string            `json:"service_type"`
+	Key         *userkeys.KeyInfo `json:"key"`
+}
+
+// userKeyServices returns the services of the configured bots that accept user API keys
+func (a *API) userKeyServices() []llm.ServiceConfig {
+	var services []llm.ServiceConfig
+	seen := make(map[string]bool)
+	for _, bot := range a.bots.GetAllBots() {
+		service := bot.GetService()
+		if !service.AllowUserAPIKeys || seen[service.ID] {
+			continue
+		}
+		seen[service.ID] = true
+		services = append(services, service)
+	}
+	return services
+}
+
+func (a *API) userKeyServiceRequired(c *gin.Context) {
+	serviceID := c.Param("serviceid")
+	for _, service := range a.userKeyServices() {
+		if service.ID == serviceID {
+			c.Set(ContextUserKeyServiceKey, service)
+			return
+		}
+	}
+
+	a.abortWithError(c, http.StatusNotFound, errors.New("service not found or does not accept user API keys"))
+}
+
+func (a *API) handleListUserAPIKeys(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+
+	result := []UserKeyService{}
+	for _, service := range a.userKeyServices() {
+		info, err := a.userKeys.GetInfo(userID, service.ID)
+		if err != nil {
+			a.abortWithError(c, http.StatusInternalServerError, err)
+			return
+		}
+		result = append(result, UserKeyService{
+			ServiceID:   service.ID,
+			ServiceName: service.Name,
+			ServiceType: service.Type,
+			Key:         info,
+		})
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (a *API) handleSaveUserAPIKey(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	service := c.MustGet(ContextUserKeyServiceKey).(llm.ServiceConfig)
+
+	var data struct {
+		APIKey string `json:"api_key"`
+	}
+	if err := c.ShouldBindJSON(&data); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	info, err := a.userKeys.Save(userID, service.ID, data.APIKey)
+	if errors.Is(err, userkeys.ErrEmptyKey) {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+func (a *API) handleDeleteUserAPIKey(c *gin.Context) {
+	userID := c.GetHeader("Mattermost-User-Id")
+	service := c.MustGet(ContextUserKeyServiceKey).(llm.ServiceConfig)
+
+	if err := a.userKeys.Delete(userID, service.ID); err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
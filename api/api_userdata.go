@@ -0,0 +1,61 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/userdata"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+func (a *API) handleExportUserData(c *gin.Context) {
+	userID := c.Param("userid")
+	if !model.IsValidId(userID) {
+		a.abortWithError(c, http.StatusBadRequest, errors.New("invalid user ID"))
+		return
+	}
+
+	export, err := a.userData.Export(userID)
+	if errors.Is(err, userdata.ErrNotAvailable) {
+		a.abortWithError(c, http.StatusNotImplemented, err)
+		return
+	}
+	if err != nil {
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "ai-data-"+userID+".json"))
+	c.JSON(http.StatusOK, export)
+}
+
+func (a *API) handleEraseUserData(c *gin.Context) {
+	userID := c.Param("userid")
+	if !model.IsValidId(userID) {
+		a.abortWithError(c, http.StatusBadRequest, errors.New("invalid user ID"))
+		return
+	}
+	if err := a.enforceEmptyBody(c); err != nil {
+		a.abortWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	erasure, err := a.userData.Erase(userID)
+	if errors.Is(err, userdata.ErrNotAvailable) {
+		a.abortWithError(c, http.StatusNotImplemented, err)
+		return
+	}
+	if err != nil {
+		a.pluginAPI.Log.Error("Failed to erase user data", "user_id", userID, "error", err)
+		a.abortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	a.pluginAPI.Log.Info("Erased user data", "user_id", userID, "requested_by", c.GetHeader("Mattermost-User-Id"))
+	c.JSON(http.StatusOK, erasure)
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-plugin-ai/ratelimit"
+)
+
+// rateLimitRequired rejects the requests of users exceeding the configured rate limits, both
+// across the whole API and for the endpoint being called.
+func (a *API) rateLimitRequired(c *gin.Context) {
+	cfg := a.config.RateLimit()
+	if !cfg.Enabled {
+		return
+	}
+
+	userID := c.GetHeader("Mattermost-User-Id")
+	route := c.FullPath()
+	now := time.Now()
+
+	allowed, retryAfter := a.rateLimiter.Allow("user:"+userID, cfg.UserRequestsPerMinute, now)
+	if allowed {
+		allowed, retryAfter = a.rateLimiter.Allow("endpoint:"+userID+":"+c.Request.Method+" "+route, cfg.EndpointLimit(route), now)
+	}
+	if allowed {
+		return
+	}
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	a.abortWithError(c, http.StatusTooManyRequests, fmt.Errorf("%w, try again in %d seconds", ratelimit.ErrRateLimited, seconds))
+}
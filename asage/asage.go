@@ -6,6 +6,7 @@
 package asage
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
@@ -70,22 +71,22 @@ func (s *Provider) queryParamsFromConfig(cfg llm.LanguageModelConfig) QueryParam
 	}
 }
 
-func (s *Provider) ChatCompletion(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+func (s *Provider) ChatCompletion(ctx context.Context, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
 	// ASage does not support streaming.
-	result, err := s.ChatCompletionNoStream(request, opts...)
+	result, err := s.ChatCompletionNoStream(ctx, request, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return llm.NewStreamFromString(result), nil
 }
 
-func (s *Provider) ChatCompletionNoStream(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
+func (s *Provider) ChatCompletionNoStream(ctx context.Context, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
 	params := s.queryParamsFromConfig(s.createConfig(opts))
 	params.Message = conversationToMessagesList(request.Posts)
 	params.SystemPrompt = request.ExtractSystemMessage()
 	params.Persona = "default"
 
-	response, err := s.client.Query(params)
+	response, err := s.client.Query(ctx, params)
 	if err != nil {
 		return "", err
 	}
@@ -5,6 +5,7 @@ package asage
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -77,52 +78,52 @@ func NewClient(authToken string, httpClient *http.Client, serverBaseURL string)
 	}
 }
 
-func (c *Client) Query(params QueryParams) (*CompletionResponse, error) {
+func (c *Client) Query(ctx context.Context, params QueryParams) (*CompletionResponse, error) {
 	response := &CompletionResponse{}
-	if err := c.doServer(http.MethodPost, "/query", &params, response); err != nil {
+	if err := c.doServer(ctx, http.MethodPost, "/query", &params, response); err != nil {
 		return nil, err
 	}
 
 	return response, nil
 }
 
-func (c *Client) FollowUpQuestions(params FollowUpParams) (*CompletionResponse, error) {
+func (c *Client) FollowUpQuestions(ctx context.Context, params FollowUpParams) (*CompletionResponse, error) {
 	response := &CompletionResponse{}
-	if err := c.doServer(http.MethodPost, "/follow-up-questions", &params, response); err != nil {
+	if err := c.doServer(ctx, http.MethodPost, "/follow-up-questions", &params, response); err != nil {
 		return nil, err
 	}
 	return response, nil
 }
 
-func (c *Client) GetPersonas() ([]Persona, error) {
+func (c *Client) GetPersonas(ctx context.Context) ([]Persona, error) {
 	var response struct {
 		Response []Persona `json:"response"`
 	}
-	if err := c.doServer(http.MethodPost, "/get-personas", nil, &response); err != nil {
+	if err := c.doServer(ctx, http.MethodPost, "/get-personas", nil, &response); err != nil {
 		return nil, err
 	}
 	return response.Response, nil
 }
 
-func (c *Client) GetDatasets() ([]Dataset, error) {
+func (c *Client) GetDatasets(ctx context.Context) ([]Dataset, error) {
 	var response struct {
 		Response []Dataset `json:"dataset"`
 	}
-	if err := c.doServer(http.MethodPost, "/get-datasets", nil, &response); err != nil {
+	if err := c.doServer(ctx, http.MethodPost, "/get-datasets", nil, &response); err != nil {
 		return nil, err
 	}
 	return response.Response, nil
 }
 
-func (c *Client) doServer(method, path string, body, result interface{}) error {
+func (c *Client) doServer(ctx context.Context, method, path string, body, result interface{}) error {
 	fullURL, err := url.JoinPath(c.ServerBaseURL, path)
 	if err != nil {
 		return fmt.Errorf("failed to join URL path: %w", err)
 	}
-	return c.do(method, fullURL, body, result)
+	return c.do(ctx, method, fullURL, body, result)
 }
 
-func (c *Client) do(method, path string, body interface{}, result interface{}) error {
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
 	var req *http.Request
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
@@ -131,13 +132,13 @@ func (c *Client) do(method, path string, body interface{}, result interface{}) e
 		}
 		bodyBuffer := bytes.NewBuffer(jsonBody)
 
-		req, err = http.NewRequest(method, path, bodyBuffer)
+		req, err = http.NewRequestWithContext(ctx, method, path, bodyBuffer)
 		if err != nil {
 			return err
 		}
 	} else {
 		var err error
-		req, err = http.NewRequest(method, path, nil)
+		req, err = http.NewRequestWithContext(ctx, method, path, nil)
 		if err != nil {
 			return err
 		}
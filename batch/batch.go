@@ -0,0 +1,400 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package batch submits non-interactive work, such as bulk embeddings, digests and evaluations,
+// to the batch APIs of providers, which process it within a day at about half the cost of
+// interactive requests. Batches are tracked in the database and polled until their results can
+// be stored and handed to the handler the batch was submitted for.
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	// DefaultPollInterval is how often the status of batches in progress is checked
+	DefaultPollInterval = 5 * time.Minute
+	// MaxRequests bounds the number of requests of a batch
+	MaxRequests = 10000
+	// maxListedBatches bounds the number of batches returned by List
+	maxListedBatches = 100
+	// providerTimeout bounds each call made to the provider
+	providerTimeout = 5 * time.Minute
+	// resultsInsertChunk is the number of results stored per query
+	resultsInsertChunk = 500
+)
+
+var (
+	// ErrBatchNotFound is returned when no batch exists with the requested ID.
+	ErrBatchNotFound = errors.New("batch not found")
+	// ErrBatchFinished is returned when canceling a batch that is no longer in progress.
+	ErrBatchFinished = errors.New("batch already finished")
+	// ErrInvalidBatch is returned when submitting a batch with invalid requests.
+	ErrInvalidBatch = errors.New("invalid batch")
+	// ErrUnknownHandler is returned when submitting a batch for a handler that is not registered.
+	ErrUnknownHandler = errors.New("unknown batch handler")
+)
+
+// Batch is a batch of requests submitted to a provider.
+type Batch struct {
+	ID              string `json:"id" db:"id"`
+	ServiceID       string `json:"service_id" db:"serviceid"`
+	ProviderBatchID string `json:"provider_batch_id" db:"providerbatchid"`
+	Type            string `json:"type" db:"type"`
+	// Handler is the name of the handler ingesting the results, empty if results are only stored
+	Handler      string `json:"handler" db:"handler"`
+	Status       string `json:"status" db:"status"`
+	RequestCount int    `json:"request_count" db:"requestcount"`
+	Error        string `json:"error" db:"error"`
+	CreateAt     int64  `json:"create_at" db:"createat"`
+	UpdateAt     int64  `json:"update_at" db:"updateat"`
+	CompletedAt  int64  `json:"completed_at" db:"completedat"`
+}
+
+// Result is the stored result of one request of a batch. The output of an embedding request
+// is the JSON encoded embedding.
+type Result struct {
+	CustomID string `json:"custom_id" db:"customid"`
+	Output   string `json:"output" db:"output"`
+	Error    string `json:"error" db:"error"`
+}
+
+// Handler ingests the results of a finished batch. It is called once, on one node of the cluster.
+type Handler func(batch Batch, results []llm.BatchResult) error
+
+// ProviderSource returns the batch API of a configured service.
+type ProviderSource interface {
+	GetBatchProvider(serviceID string) (llm.BatchProvider, error)
+}
+
+// Service submits batches and polls the providers for their results.
+type Service struct {
+	db        *mmapi.DBClient
+	providers ProviderSource
+	mutexAPI  cluster.MutexPluginAPI
+	log       pluginapi.LogService
+
+	mu       sync.Mutex
+	handlers map[string]Handler
+	stop     chan struct{}
+}
+
+// New creates a new batch service. Call Start to begin polling.
+func New(db *mmapi.DBClient, providers ProviderSource, mutexAPI cluster.MutexPluginAPI, log pluginapi.LogService) *Service {
+	return &Service{
+		db:        db,
+		providers: providers,
+		mutexAPI:  mutexAPI,
+		log:       log,
+		handlers:  make(map[string]Handler),
+	}
+}
+
+// RegisterHandler registers the handler ingesting the results of batches submitted for name.
+func (s *Service) RegisterHandler(name string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[name] = handler
+}
+
+func (s *Service) handler(name string) (Handler, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	handler, ok := s.handlers[name]
+	return handler, ok
+}
+
+// validateRequests checks the batch has requests with unique custom IDs and content.
+func validateRequests(batchType string, requests []llm.BatchRequest) error {
+	if batchType != llm.BatchTypeCompletion && batchType != llm.BatchTypeEmbedding {
+		return fmt.Errorf("%w: unknown type %s", ErrInvalidBatch, batchType)
+	}
+	if len(requests) == 0 {
+		return fmt.Errorf("%w: no requests", ErrInvalidBatch)
+	}
+	if len(requests) > MaxRequests {
+		return fmt.Errorf("%w: more than %d requests", ErrInvalidBatch, MaxRequests)
+	}
+
+	seen := make(map[string]bool, len(requests))
+	for i, request := range requests {
+		if strings.TrimSpace(request.CustomID) == "" {
+			return fmt.Errorf("%w: request %d has no custom ID", ErrInvalidBatch, i)
+		}
+		if seen[request.CustomID] {
+			return fmt.Errorf("%w: duplicate custom ID %s", ErrInvalidBatch, request.CustomID)
+		}
+		seen[request.CustomID] = true
+		if strings.TrimSpace(request.Prompt) == "" {
+			return fmt.Errorf("%w: request %s has no prompt", ErrInvalidBatch, request.CustomID)
+		}
+	}
+
+	return nil
+}
+
+// Submit sends the requests to the batch API of the service. Once the batch finishes, its results
+// are stored and given to the handler registered for handlerName, if any.
+func (s *Service) Submit(serviceID, batchType, handlerName string, requests []llm.BatchRequest) (*Batch, error) {
+	if err := validateRequests(batchType, requests); err != nil {
+		return nil, err
+	}
+	if handlerName != "" {
+		if _, ok := s.handler(handlerName); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownHandler, handlerName)
+		}
+	}
+
+	provider, err := s.providers.GetBatchProvider(serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+	providerBatchID, err := provider.SubmitBatch(ctx, batchType, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	now := model.GetMillis()
+	batch := &Batch{
+		ID:              model.NewId(),
+		ServiceID:       serviceID,
+		ProviderBatchID: providerBatchID,
+		Type:            batchType,
+		Handler:         handlerName,
+		Status:          llm.BatchStatusInProgress,
+		RequestCount:    len(requests),
+		CreateAt:        now,
+		UpdateAt:        now,
+	}
+	if _, err := s.db.ExecBuilder(s.db.Builder().Insert("LLM_Batches").
+		Columns("ID", "ServiceID", "ProviderBatchID", "Type", "Handler", "Status", "RequestCount", "Error", "CreateAt", "UpdateAt", "CompletedAt").
+		Values(batch.ID, batch.ServiceID, batch.ProviderBatchID, batch.Type, batch.Handler, batch.Status, batch.RequestCount, batch.Error, batch.CreateAt, batch.UpdateAt, batch.CompletedAt)); err != nil {
+		return nil, fmt.Errorf("failed to save batch %s submitted as %s: %w", batch.ID, providerBatchID, err)
+	}
+
+	return batch, nil
+}
+
+func (s *Service) batchQuery() sq.SelectBuilder {
+	return s.db.Builder().
+		Select("ID", "ServiceID", "ProviderBatchID", "Type", "Handler", "Status", "RequestCount", "Error", "CreateAt", "UpdateAt", "CompletedAt").
+		From("LLM_Batches")
+}
+
+// List returns the most recent batches.
+func (s *Service) List() ([]Batch, error) {
+	var batches []Batch
+	if err := s.db.DoQuery(&batches, s.batchQuery().
+		OrderBy("CreateAt DESC").
+		Limit(maxListedBatches),
+	); err != nil {
+		return nil, fmt.Errorf("failed to list batches: %w", err)
+	}
+	return batches, nil
+}
+
+// Get returns the batch with the given ID.
+func (s *Service) Get(id string) (*Batch, error) {
+	var batches []Batch
+	if err := s.db.DoQuery(&batches, s.batchQuery().Where(sq.Eq{"ID": id})); err != nil {
+		return nil, fmt.Errorf("failed to get batch: %w", err)
+	}
+	if len(batches) == 0 {
+		return nil, ErrBatchNotFound
+	}
+	return &batches[0], nil
+}
+
+// Results returns the stored results of the batch with the given ID.
+func (s *Service) Results(id string) ([]Result, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	if err := s.db.DoQuery(&results, s.db.Builder().
+		Select("CustomID", "Output", "Error").
+		From("LLM_BatchResults").
+		Where(sq.Eq{"BatchID": id}).
+		OrderBy("CustomID"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to get batch results: %w", err)
+	}
+	return results, nil
+}
+
+// Cancel asks the provider to cancel the batch. Results of requests that already completed are
+// still ingested once the provider finishes canceling.
+func (s *Service) Cancel(id string) error {
+	batch, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if llm.IsBatchFinished(batch.Status) {
+		return ErrBatchFinished
+	}
+
+	provider, err := s.providers.GetBatchProvider(batch.ServiceID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+	return provider.CancelBatch(ctx, batch.ProviderBatchID)
+}
+
+// Start polls the batches in progress every interval until Stop is called.
+func (s *Service) Start(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.Poll()
+			}
+		}
+	}()
+}
+
+// Stop stops polling.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// Poll checks the batches in progress and ingests the results of the finished ones. Only one
+// node of the cluster polls at a time so results are ingested once.
+func (s *Service) Poll() {
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_batch_poll")
+	if err != nil {
+		s.log.Error("Failed to create batch poll mutex", "error", err)
+		return
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	var batches []Batch
+	if err := s.db.DoQuery(&batches, s.batchQuery().
+		Where(sq.Eq{"Status": llm.BatchStatusInProgress}).
+		OrderBy("CreateAt"),
+	); err != nil {
+		s.log.Error("Failed to get batches in progress", "error", err)
+		return
+	}
+
+	for _, batch := range batches {
+		if err := s.pollBatch(batch); err != nil {
+			s.log.Warn("Failed to poll batch", "batch_id", batch.ID, "provider_batch_id", batch.ProviderBatchID, "error", err)
+		}
+	}
+}
+
+func (s *Service) pollBatch(batch Batch) error {
+	provider, err := s.providers.GetBatchProvider(batch.ServiceID)
+	if err != nil {
+		return s.finish(batch, llm.BatchStatusFailed, fmt.Sprintf("service unavailable: %v", err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	status, err := provider.BatchStatus(ctx, batch.ProviderBatchID)
+	if err != nil {
+		return err
+	}
+	if !llm.IsBatchFinished(status) {
+		_, err = s.db.ExecBuilder(s.db.Builder().Update("LLM_Batches").
+			Set("UpdateAt", model.GetMillis()).
+			Where(sq.Eq{"ID": batch.ID}))
+		return err
+	}
+
+	results, err := provider.BatchResults(ctx, batch.ProviderBatchID)
+	if err != nil {
+		return err
+	}
+	if err = s.saveResults(batch.ID, results); err != nil {
+		return err
+	}
+
+	var ingestErr string
+	if batch.Handler != "" {
+		if handler, ok := s.handler(batch.Handler); !ok {
+			ingestErr = fmt.Sprintf("%v: %s", ErrUnknownHandler, batch.Handler)
+		} else if err := handler(batch, results); err != nil {
+			ingestErr = fmt.Sprintf("failed to ingest results: %v", err)
+		}
+	}
+
+	return s.finish(batch, status, ingestErr)
+}
+
+func (s *Service) saveResults(batchID string, results []llm.BatchResult) error {
+	for start := 0; start < len(results); start += resultsInsertChunk {
+		query := s.db.Builder().Insert("LLM_BatchResults").
+			Columns("BatchID", "CustomID", "Output", "Error").
+			Suffix("ON CONFLICT (BatchID, CustomID) DO NOTHING")
+
+		for _, result := range results[start:min(start+resultsInsertChunk, len(results))] {
+			output := result.Output
+			if result.Embedding != nil {
+				embedding, err := json.Marshal(result.Embedding)
+				if err != nil {
+					return fmt.Errorf("failed to encode embedding: %w", err)
+				}
+				output = string(embedding)
+			}
+			query = query.Values(batchID, result.CustomID, output, result.Error)
+		}
+
+		if _, err := s.db.ExecBuilder(query); err != nil {
+			return fmt.Errorf("failed to save batch results: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) finish(batch Batch, status, batchErr string) error {
+	now := model.GetMillis()
+	if _, err := s.db.ExecBuilder(s.db.Builder().Update("LLM_Batches").
+		Set("Status", status).
+		Set("Error", batchErr).
+		Set("UpdateAt", now).
+		Set("CompletedAt", now).
+		Where(sq.Eq{"ID": batch.ID})); err != nil {
+		return fmt.Errorf("failed to update batch: %w", err)
+	}
+	return nil
+}
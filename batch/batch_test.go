@@ -0,0 +1,77 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package batch
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		batchType string
+		requests  []llm.BatchRequest
+		expectErr bool
+	}{
+		{
+			name:      "valid completions",
+			batchType: llm.BatchTypeCompletion,
+			requests:  []llm.BatchRequest{{CustomID: "a", Prompt: "one"}, {CustomID: "b", Prompt: "two"}},
+		},
+		{
+			name:      "valid embeddings",
+			batchType: llm.BatchTypeEmbedding,
+			requests:  []llm.BatchRequest{{CustomID: "a", Prompt: "text"}},
+		},
+		{
+			name:      "unknown type",
+			batchType: "image",
+			requests:  []llm.BatchRequest{{CustomID: "a", Prompt: "one"}},
+			expectErr: true,
+		},
+		{
+			name:      "no requests",
+			batchType: llm.BatchTypeCompletion,
+			expectErr: true,
+		},
+		{
+			name:      "too many requests",
+			batchType: llm.BatchTypeEmbedding,
+			requests:  make([]llm.BatchRequest, MaxRequests+1),
+			expectErr: true,
+		},
+		{
+			name:      "missing custom ID",
+			batchType: llm.BatchTypeCompletion,
+			requests:  []llm.BatchRequest{{Prompt: "one"}},
+			expectErr: true,
+		},
+		{
+			name:      "duplicate custom ID",
+			batchType: llm.BatchTypeCompletion,
+			requests:  []llm.BatchRequest{{CustomID: "a", Prompt: "one"}, {CustomID: "a", Prompt: "two"}},
+			expectErr: true,
+		},
+		{
+			name:      "empty prompt",
+			batchType: llm.BatchTypeCompletion,
+			requests:  []llm.BatchRequest{{CustomID: "a", Prompt: " "}},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateRequests(tc.batchType, tc.requests)
+			if tc.expectErr {
+				assert.ErrorIs(t, err, ErrInvalidBatch)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package bedrock
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -37,6 +38,7 @@ type messageState struct {
 	tools    []llm.Tool
 	resolver func(name string, argsGetter llm.ToolArgumentGetter, context *llm.Context) (string, error)
 	context  *llm.Context
+	budget   *llm.StepBudgetTracker
 }
 
 type Bedrock struct {
@@ -387,6 +389,15 @@ func buildBedrockToolResultsMessage(results []llm.AutoRunResult) types.Message {
 	}
 }
 
+// providerError classifies an error returned by the Bedrock API so it can be explained to users.
+func providerError(err error) error {
+	var respErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &respErr) {
+		return llm.NewProviderError(respErr.HTTPStatusCode(), err)
+	}
+	return err
+}
+
 func toolResultStatus(isError bool) types.ToolResultStatus {
 	if isError {
 		return types.ToolResultStatusError
@@ -394,11 +405,11 @@ func toolResultStatus(isError bool) types.ToolResultStatus {
 	return types.ToolResultStatusSuccess
 }
 
-func (b *Bedrock) streamChatWithTools(initialState messageState) {
+func (b *Bedrock) streamChatWithTools(ctx context.Context, initialState messageState) {
 	state := initialState
 
 	sendError := func(err error) {
-		state.output <- llm.TextStreamEvent{Type: llm.EventTypeError, Value: err}
+		state.output <- llm.TextStreamEvent{Type: llm.EventTypeError, Value: providerError(err)}
 	}
 
 	for {
@@ -431,7 +442,7 @@ func (b *Bedrock) streamChatWithTools(initialState messageState) {
 			}
 		}
 
-		stream, err := b.client.ConverseStream(context.Background(), params)
+		stream, err := b.client.ConverseStream(ctx, params)
 		if err != nil {
 			sendError(fmt.Errorf("error starting stream: %w", err))
 			return
@@ -441,6 +452,7 @@ func (b *Bedrock) streamChatWithTools(initialState messageState) {
 		currentToolUseBlocks := make(map[int]*toolUseData)
 		var stopReason types.StopReason
 		var accumulatedText strings.Builder
+		var reasoning reasoningBuffer
 
 		for event := range eventStream.Events() {
 			switch e := event.(type) {
@@ -474,8 +486,13 @@ func (b *Bedrock) streamChatWithTools(initialState messageState) {
 					if toolBlock, ok := currentToolUseBlocks[idx]; ok {
 						toolBlock.inputJSON.WriteString(aws.ToString(delta.Value.Input))
 					}
+				case *types.ContentBlockDeltaMemberReasoningContent:
+					reasoning.add(delta.Value, state.output)
 				}
 
+			case *types.ConverseStreamOutputMemberContentBlockStop:
+				reasoning.end(state.output)
+
 			case *types.ConverseStreamOutputMemberMessageStop:
 				if e.Value.StopReason != "" {
 					stopReason = e.Value.StopReason
@@ -483,18 +500,21 @@ func (b *Bedrock) streamChatWithTools(initialState messageState) {
 
 			case *types.ConverseStreamOutputMemberMetadata:
 				if e.Value.Usage != nil {
+					usage := llm.TokenUsage{
+						InputTokens:  int64(aws.ToInt32(e.Value.Usage.InputTokens)),
+						OutputTokens: int64(aws.ToInt32(e.Value.Usage.OutputTokens)),
+					}
+					state.budget.AddUsage(usage)
 					state.output <- llm.TextStreamEvent{
-						Type: llm.EventTypeUsage,
-						Value: llm.TokenUsage{
-							InputTokens:  int64(aws.ToInt32(e.Value.Usage.InputTokens)),
-							OutputTokens: int64(aws.ToInt32(e.Value.Usage.OutputTokens)),
-						},
+						Type:  llm.EventTypeUsage,
+						Value: usage,
 					}
 				}
 			}
 		}
 
 		eventStream.Close()
+		reasoning.end(state.output)
 
 		if err := eventStream.Err(); err != nil {
 			sendError(fmt.Errorf("error from bedrock stream: %w", err))
@@ -505,6 +525,10 @@ func (b *Bedrock) streamChatWithTools(initialState messageState) {
 			pendingToolCalls := extractToolCallsFromBlocks(currentToolUseBlocks)
 
 			if llm.ShouldAutoRunTools(pendingToolCalls, state.config.AutoRunTools) {
+				if state.budget.StopIfExceeded(state.output) {
+					return
+				}
+
 				state.messages = append(state.messages,
 					buildBedrockAssistantMessage(accumulatedText.String(), currentToolUseBlocks))
 
@@ -512,6 +536,7 @@ func (b *Bedrock) streamChatWithTools(initialState messageState) {
 					pendingToolCalls,
 					state.resolver,
 					state.context,
+					state.output,
 				)
 
 				state.messages = append(state.messages, buildBedrockToolResultsMessage(toolResults))
@@ -519,7 +544,10 @@ func (b *Bedrock) streamChatWithTools(initialState messageState) {
 				continue
 			}
 
+			state.output <- llm.TextStreamEvent{Type: llm.EventTypeFinish, Value: llm.FinishReasonToolUse}
 			state.output <- llm.TextStreamEvent{Type: llm.EventTypeToolCalls, Value: pendingToolCalls}
+		} else {
+			state.output <- llm.TextStreamEvent{Type: llm.EventTypeFinish, Value: finishReason(stopReason)}
 		}
 
 		state.output <- llm.TextStreamEvent{Type: llm.EventTypeEnd, Value: nil}
@@ -527,7 +555,58 @@ func (b *Bedrock) streamChatWithTools(initialState messageState) {
 	}
 }
 
-func (b *Bedrock) ChatCompletion(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+// reasoningBuffer accumulates the reasoning content of a response, streamed like the
+// thinking of the other providers
+type reasoningBuffer struct {
+	text      strings.Builder
+	signature strings.Builder
+}
+
+// add sends the reasoning text of the delta and keeps its signature
+func (r *reasoningBuffer) add(delta types.ReasoningContentBlockDelta, output chan<- llm.TextStreamEvent) {
+	switch value := delta.(type) {
+	case *types.ReasoningContentBlockDeltaMemberText:
+		if value.Value == "" {
+			return
+		}
+		r.text.WriteString(value.Value)
+		output <- llm.TextStreamEvent{Type: llm.EventTypeReasoning, Value: value.Value}
+	case *types.ReasoningContentBlockDeltaMemberSignature:
+		r.signature.WriteString(value.Value)
+	}
+}
+
+// end sends the accumulated reasoning, if any, once its content block stops
+func (r *reasoningBuffer) end(output chan<- llm.TextStreamEvent) {
+	if r.text.Len() == 0 {
+		return
+	}
+	output <- llm.TextStreamEvent{
+		Type: llm.EventTypeReasoningEnd,
+		Value: llm.ReasoningData{
+			Text:      r.text.String(),
+			Signature: r.signature.String(),
+		},
+	}
+	r.text.Reset()
+	r.signature.Reset()
+}
+
+// finishReason maps the reason a response stopped to a finish reason of the stream
+func finishReason(stopReason types.StopReason) string {
+	switch stopReason {
+	case types.StopReasonMaxTokens, types.StopReasonModelContextWindowExceeded:
+		return llm.FinishReasonLength
+	case types.StopReasonGuardrailIntervened, types.StopReasonContentFiltered:
+		return llm.FinishReasonContentFilter
+	case types.StopReasonToolUse:
+		return llm.FinishReasonToolUse
+	default:
+		return llm.FinishReasonStop
+	}
+}
+
+func (b *Bedrock) ChatCompletion(ctx context.Context, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
 	eventStream := make(chan llm.TextStreamEvent)
 
 	cfg := b.createConfig(opts)
@@ -541,6 +620,7 @@ func (b *Bedrock) ChatCompletion(request llm.CompletionRequest, opts ...llm.Lang
 		depth:    0,
 		config:   cfg,
 		context:  request.Context,
+		budget:   llm.NewStepBudgetTracker(cfg.StepBudget),
 	}
 
 	if request.Context.Tools != nil {
@@ -550,15 +630,15 @@ func (b *Bedrock) ChatCompletion(request llm.CompletionRequest, opts ...llm.Lang
 
 	go func() {
 		defer close(eventStream)
-		b.streamChatWithTools(initialState)
+		b.streamChatWithTools(ctx, initialState)
 	}()
 
-	return &llm.TextStreamResult{Stream: eventStream}, nil
+	return &llm.TextStreamResult{Stream: llm.AggregateUsage(eventStream)}, nil
 }
 
-func (b *Bedrock) ChatCompletionNoStream(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
+func (b *Bedrock) ChatCompletionNoStream(ctx context.Context, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
 	// This could perform better if we didn't use the streaming API here, but the complexity is not worth it.
-	result, err := b.ChatCompletion(request, opts...)
+	result, err := b.ChatCompletion(ctx, request, opts...)
 	if err != nil {
 		return "", err
 	}
@@ -746,3 +746,46 @@ func TestBuildBedrockToolResultsMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestFinishReason(t *testing.T) {
+	tests := []struct {
+		stopReason types.StopReason
+		expected   string
+	}{
+		{stopReason: types.StopReasonEndTurn, expected: llm.FinishReasonStop},
+		{stopReason: types.StopReasonMaxTokens, expected: llm.FinishReasonLength},
+		{stopReason: types.StopReasonModelContextWindowExceeded, expected: llm.FinishReasonLength},
+		{stopReason: types.StopReasonGuardrailIntervened, expected: llm.FinishReasonContentFilter},
+		{stopReason: types.StopReasonContentFiltered, expected: llm.FinishReasonContentFilter},
+		{stopReason: types.StopReasonToolUse, expected: llm.FinishReasonToolUse},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.stopReason), func(t *testing.T) {
+			assert.Equal(t, tc.expected, finishReason(tc.stopReason))
+		})
+	}
+}
+
+func TestReasoningBuffer(t *testing.T) {
+	output := make(chan llm.TextStreamEvent, 10)
+	var reasoning reasoningBuffer
+
+	reasoning.end(output)
+	reasoning.add(&types.ReasoningContentBlockDeltaMemberText{Value: "Let me "}, output)
+	reasoning.add(&types.ReasoningContentBlockDeltaMemberText{Value: "think"}, output)
+	reasoning.add(&types.ReasoningContentBlockDeltaMemberSignature{Value: "signature"}, output)
+	reasoning.end(output)
+	reasoning.end(output)
+	close(output)
+
+	var events []llm.TextStreamEvent
+	for event := range output {
+		events = append(events, event)
+	}
+	assert.Equal(t, []llm.TextStreamEvent{
+		{Type: llm.EventTypeReasoning, Value: "Let me "},
+		{Type: llm.EventTypeReasoning, Value: "think"},
+		{Type: llm.EventTypeReasoningEnd, Value: llm.ReasoningData{Text: "Let me think", Signature: "signature"}},
+	}, events, "reasoning ends once, and only when there was some")
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package bots
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mattermost/mattermost-plugin-ai/anthropic"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/openai"
+)
+
+// ErrBatchZeroDataRetention is returned for services with zero data retention, as providers keep
+// the input and output of batches for days.
+var ErrBatchZeroDataRetention = errors.New("batches are not available for services with zero data retention")
+
+// GetBatchProvider returns the batch API of the service, using its default model.
+func (b *MMBots) GetBatchProvider(serviceID string) (llm.BatchProvider, error) {
+	service, ok := b.config.GetServiceByID(serviceID)
+	if !ok {
+		return nil, ErrServiceNotFound
+	}
+	if service.ZeroDataRetention {
+		return nil, ErrBatchZeroDataRetention
+	}
+
+	service.DefaultModel = service.ResolveModel(service.DefaultModel)
+	httpClient := b.upstreamHTTPClient(service)
+
+	switch service.Type {
+	case llm.ServiceTypeOpenAI:
+		return openai.New(config.OpenAIConfigFromServiceConfig(service, llm.BotConfig{}), httpClient), nil
+	case llm.ServiceTypeAnthropic:
+		return anthropic.New(service, llm.BotConfig{}, httpClient), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", llm.ErrBatchUnsupported, service.Type)
+}
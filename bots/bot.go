@@ -38,6 +38,20 @@ func (b *Bot) LLM() llm.LanguageModel {
 	return b.llm
 }
 
+// FeatureLLM returns the language model of the bot for the feature, such as
+// analytics.FeatureChannelAnalysis, without the native tools disabled in the feature
+func (b *Bot) FeatureLLM(feature string) llm.LanguageModel {
+	if len(b.cfg.DisabledNativeTools[feature]) == 0 {
+		return b.llm
+	}
+	return llm.NewOptionsWrapper(b.llm, b.NativeToolsOption(feature))
+}
+
+// NativeToolsOption returns the option leaving out the native tools disabled in the feature
+func (b *Bot) NativeToolsOption(feature string) llm.LanguageModelOption {
+	return llm.WithNativeToolsDisabled(b.cfg.DisabledNativeTools[feature])
+}
+
 func (b *Bot) GetService() llm.ServiceConfig {
 	return b.service
 }
@@ -4,9 +4,12 @@
 package bots
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"slices"
 	"sync"
 
 	"github.com/mattermost/mattermost-plugin-ai/anthropic"
@@ -14,6 +17,7 @@ import (
 	"github.com/mattermost/mattermost-plugin-ai/bedrock"
 	"github.com/mattermost/mattermost-plugin-ai/config"
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
 	"github.com/mattermost/mattermost-plugin-ai/openai"
@@ -38,6 +42,11 @@ type Transcriber interface {
 	Transcribe(file io.Reader) (*subtitles.Subtitles, error)
 }
 
+// ImageGenerator interface defines the contract for image generation services
+type ImageGenerator interface {
+	GenerateImage(ctx context.Context, prompt, size string) ([]byte, error)
+}
+
 type MMBots struct {
 	ensureBotsClusterMutex cluster.MutexPluginAPI
 	pluginAPI              *pluginapi.Client
@@ -46,6 +55,18 @@ type MMBots struct {
 	llmUpstreamHTTPClient  *http.Client
 	tokenLogger            *mlog.Logger
 	metrics                llm.MetricsObserver
+	glossaryProvider       llm.GlossaryProvider
+	guardrails             llm.Guardrails
+	channelExcluder        ChannelExcluder
+	userKeyStore           UserKeyStore
+	capabilities           *llm.CapabilityRegistry
+	router                 Router
+	payloadCapture         *llm.PayloadCapture
+
+	providerHealthCache providerHealthCache
+
+	schedulersLock sync.Mutex
+	schedulers     map[string]*llm.PriorityScheduler
 
 	botsLock sync.RWMutex
 	bots     []*Bot
@@ -64,7 +85,83 @@ func New(mutexPluginAPI cluster.MutexPluginAPI, pluginAPI *pluginapi.Client, lic
 		llmUpstreamHTTPClient:  llmUpstreamHTTPClient,
 		tokenLogger:            tokenLogger,
 		metrics:                metrics,
+		capabilities:           llm.NewCapabilityRegistry(),
+	}
+}
+
+// RecordFetchedModels stores the models fetched from a provider so configured models can be
+// validated against them, and returns the models with their known capabilities.
+func (b *MMBots) RecordFetchedModels(serviceType, apiURL string, models []llm.ModelInfo) []llm.ModelInfo {
+	return b.capabilities.RecordModels(serviceType, apiURL, models)
+}
+
+// LookupModelCapabilities returns the known capabilities of the model of the service
+func (b *MMBots) LookupModelCapabilities(service llm.ServiceConfig, model string) (llm.ModelCapabilities, bool) {
+	return b.capabilities.Lookup(service, model)
+}
+
+// ValidateModelCapabilities returns warnings about bot settings the model does not support
+func (b *MMBots) ValidateModelCapabilities(service llm.ServiceConfig, bot llm.BotConfig) []string {
+	return b.capabilities.Validate(service, bot)
+}
+
+// ChannelExcluder reports the channels whose content must never be sent to LLM providers, and
+// the policies of shared channels and channels with guests
+type ChannelExcluder interface {
+	CheckChannel(channel *model.Channel) error
+	ChannelPolicy(channel *model.Channel) (exclusions.ChannelPolicy, error)
+}
+
+// SetChannelExcluder sets the exclusions enforced when checking usage restrictions for a channel
+func (b *MMBots) SetChannelExcluder(excluder ChannelExcluder) {
+	b.channelExcluder = excluder
+}
+
+// ChannelPolicy returns what the shared and guest channel policies require in the channel
+func (b *MMBots) ChannelPolicy(channel *model.Channel) (exclusions.ChannelPolicy, error) {
+	if b == nil || b.channelExcluder == nil {
+		return exclusions.ChannelPolicy{}, nil
 	}
+	return b.channelExcluder.ChannelPolicy(channel)
+}
+
+// SetUserKeyStore sets the store of the API keys users register for themselves.
+// It must be called before the bots are ensured.
+func (b *MMBots) SetUserKeyStore(store UserKeyStore) {
+	b.userKeyStore = store
+}
+
+// SetGlossaryProvider sets the glossary used to explain organization terms to the models.
+// It must be called before the bots are ensured.
+func (b *MMBots) SetGlossaryProvider(provider llm.GlossaryProvider) {
+	b.glossaryProvider = provider
+}
+
+// SetGuardrails sets the guardrail policy compiled into the system prompts and the checks of
+// the responses. It must be called before the bots are ensured.
+func (b *MMBots) SetGuardrails(guardrails llm.Guardrails) {
+	b.guardrails = guardrails
+}
+
+// SetPayloadCapture sets the capture recording the payloads exchanged with providers for
+// debugging. It must be called before the bots are ensured.
+func (b *MMBots) SetPayloadCapture(capture *llm.PayloadCapture) {
+	b.payloadCapture = capture
+}
+
+// CapturedPayloads returns the most recent payloads exchanged with providers, the most recent first
+func (b *MMBots) CapturedPayloads() []llm.CapturedPayload {
+	return b.payloadCapture.Payloads()
+}
+
+// ClearCapturedPayloads discards the captured payloads
+func (b *MMBots) ClearCapturedPayloads() {
+	b.payloadCapture.Clear()
+}
+
+// upstreamHTTPClient returns the client used to send requests to the service
+func (b *MMBots) upstreamHTTPClient(service llm.ServiceConfig) *http.Client {
+	return llm.UpstreamHTTPClient(b.payloadCapture.Client(b.llmUpstreamHTTPClient, service), service)
 }
 
 // botConfigsEqual compares two bot config slices for equality
@@ -89,7 +186,19 @@ func botConfigsEqual(a, b []llm.BotConfig) bool {
 		if aCfg.Name != cfg.Name ||
 			aCfg.DisplayName != cfg.DisplayName ||
 			aCfg.ServiceID != cfg.ServiceID ||
-			aCfg.Model != cfg.Model {
+			aCfg.Model != cfg.Model ||
+			aCfg.OutputLanguage != cfg.OutputLanguage ||
+			aCfg.EnableFollowUpSuggestions != cfg.EnableFollowUpSuggestions ||
+			aCfg.RetrieveChannelHistory != cfg.RetrieveChannelHistory ||
+			aCfg.ReasoningDisplay != cfg.ReasoningDisplay ||
+			!maps.EqualFunc(aCfg.DisabledNativeTools, cfg.DisabledNativeTools, slices.Equal[[]string]) ||
+			aCfg.EnableIntentRouting != cfg.EnableIntentRouting ||
+			!slices.Equal(aCfg.IntentRoutes, cfg.IntentRoutes) ||
+			!slices.Equal(aCfg.ContextComponents, cfg.ContextComponents) ||
+			!slices.EqualFunc(aCfg.PostProcessors, cfg.PostProcessors, llm.PostProcessorConfig.Equal) ||
+			aCfg.RAGContext != cfg.RAGContext ||
+			aCfg.MaxConcurrentGenerations != cfg.MaxConcurrentGenerations ||
+			aCfg.MaxConcurrentGenerationsPerUser != cfg.MaxConcurrentGenerationsPerUser {
 			return false
 		}
 	}
@@ -138,7 +247,7 @@ func (b *MMBots) EnsureBots() error {
 
 	// Only allow one bot if not multi-LLM licensed
 	botCfgs := b.config.GetBots()
-	if len(botCfgs) > 1 && !b.licenseChecker.IsMultiLLMLicensed() {
+	if len(botCfgs) > 1 && !b.licenseChecker.IsFeatureLicensed(enterprise.FeatureMultiLLM) {
 		b.pluginAPI.Log.Error("Only one bot allowed with current license.")
 		botCfgs = botCfgs[:1]
 	}
@@ -174,6 +283,10 @@ func (b *MMBots) EnsureBots() error {
 			service.DefaultModel = botCfg.Model
 		}
 
+		for _, warning := range b.capabilities.Validate(service, botCfg) {
+			b.pluginAPI.Log.Warn("Bot model needs attention", "bot_name", botCfg.Name, "warning", warning)
+		}
+
 		bot := &Bot{cfg: botCfg, service: service}
 		bots = append(bots, bot)
 		aiBotsByUsername[botCfg.Name] = bot
@@ -242,51 +355,120 @@ func (b *MMBots) EnsureBots() error {
 }
 
 func (b *MMBots) getLLM(serviceConfig llm.ServiceConfig, botConfig llm.BotConfig) (llm.LanguageModel, error) {
+	result, err := b.newLanguageModel(serviceConfig, botConfig, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Users who registered their own key are answered by a model using it
+	if serviceConfig.AllowUserAPIKeys && b.userKeyStore != nil {
+		result = newUserKeyLanguageModel(result, serviceConfig.ID, b.userKeyStore, func(apiKey string) (llm.LanguageModel, error) {
+			userService := serviceConfig
+			userService.APIKey = apiKey
+			// IAM credentials would take precedence over the user's Bedrock key
+			userService.AWSAccessKeyID = ""
+			userService.AWSSecretAccessKey = ""
+			return b.newLanguageModel(userService, botConfig, true)
+		})
+	}
+
+	return result, nil
+}
+
+// newLanguageModel creates the model for the service and bot. Models using a user's own API key
+// are not counted against the shared concurrency limit of the bot.
+func (b *MMBots) newLanguageModel(serviceConfig llm.ServiceConfig, botConfig llm.BotConfig, userAPIKey bool) (llm.LanguageModel, error) {
+	if serviceConfig.ZeroDataRetention && !serviceConfig.EnforcesZeroDataRetention() {
+		b.pluginAPI.Log.Warn("Zero data retention can not be enforced per request for this service type, it must be arranged with the provider", "service_name", serviceConfig.Name, "service_type", serviceConfig.Type)
+	}
+	httpClient := b.upstreamHTTPClient(serviceConfig)
+
+	// Bots and services may name their model with an alias of the service
+	serviceConfig.DefaultModel = serviceConfig.ResolveModel(serviceConfig.DefaultModel)
+	botConfig.Model = serviceConfig.ResolveModel(botConfig.Model)
+
 	// Create the correct model
 	var result llm.LanguageModel
 	switch serviceConfig.Type {
 	case llm.ServiceTypeOpenAI:
-		result = openai.New(config.OpenAIConfigFromServiceConfig(serviceConfig, botConfig), b.llmUpstreamHTTPClient)
+		result = openai.New(config.OpenAIConfigFromServiceConfig(serviceConfig, botConfig), httpClient)
 	case llm.ServiceTypeOpenAICompatible:
-		result = openai.NewCompatible(config.OpenAIConfigFromServiceConfig(serviceConfig, botConfig), b.llmUpstreamHTTPClient)
+		result = openai.NewCompatible(config.OpenAIConfigFromServiceConfig(serviceConfig, botConfig), httpClient)
 	case llm.ServiceTypeAzure:
-		result = openai.NewAzure(config.OpenAIConfigFromServiceConfig(serviceConfig, botConfig), b.llmUpstreamHTTPClient)
+		result = openai.NewAzure(config.OpenAIConfigFromServiceConfig(serviceConfig, botConfig), httpClient)
 	case llm.ServiceTypeAnthropic:
-		result = anthropic.New(serviceConfig, botConfig, b.llmUpstreamHTTPClient)
+		result = anthropic.New(serviceConfig, botConfig, httpClient)
 	case llm.ServiceTypeBedrock:
 		var err error
-		result, err = bedrock.New(serviceConfig, b.llmUpstreamHTTPClient)
+		result, err = bedrock.New(serviceConfig, httpClient)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Bedrock client: %w", err)
 		}
 	case llm.ServiceTypeASage:
-		result = asage.New(serviceConfig, b.llmUpstreamHTTPClient)
+		result = asage.New(serviceConfig, httpClient)
 	case llm.ServiceTypeCohere:
 		// Set the Cohere OpenAI compatibility endpoint
 		cohereCfg := serviceConfig
 		cohereCfg.APIURL = "https://api.cohere.ai/compatibility/v1"
-		result = openai.NewCompatible(config.OpenAIConfigFromServiceConfig(cohereCfg, botConfig), b.llmUpstreamHTTPClient)
+		result = openai.NewCompatible(config.OpenAIConfigFromServiceConfig(cohereCfg, botConfig), httpClient)
 	case llm.ServiceTypeMistral:
 		// Set the Mistral OpenAI compatibility endpoint
 		mistralCfg := serviceConfig
 		mistralCfg.APIURL = "https://api.mistral.ai/v1"
-		result = openai.NewCompatible(config.OpenAIConfigFromServiceConfigWithOptions(mistralCfg, botConfig, true, true), b.llmUpstreamHTTPClient)
+		result = openai.NewCompatible(config.OpenAIConfigFromServiceConfigWithOptions(mistralCfg, botConfig, true, true), httpClient)
 	default:
 		b.pluginAPI.Log.Error("Unsupported service type for bot", "bot_name", botConfig.Name, "service_type", serviceConfig.Type)
 		return nil, fmt.Errorf("unsupported service type: %s", serviceConfig.Type)
 	}
 
+	// Share the capacity of rate limited services between bots. Users' own keys have their own limits.
+	if scheduler := b.serviceScheduler(serviceConfig); scheduler != nil && !userAPIKey {
+		result = llm.NewPrioritySchedulerWrapper(result, scheduler)
+	}
+
+	// Resolve aliases of the models requested per request
+	if len(serviceConfig.ModelAliases) > 0 {
+		result = llm.NewModelAliasWrapper(result, serviceConfig)
+	}
+
+	// Degrade requests to what the model supports, below truncation so it uses the model's context window
+	model := serviceConfig.DefaultModel
+	if botConfig.Model != "" {
+		model = botConfig.Model
+	}
+	if capabilities, ok := b.capabilities.Lookup(serviceConfig, model); ok {
+		inputTokenLimit := 0
+		if serviceConfig.InputTokenLimit == 0 {
+			inputTokenLimit = capabilities.MaxContext
+		}
+		result = llm.NewCapabilityWrapper(result, capabilities, inputTokenLimit)
+	}
+
+	// Glossary support, applied to the truncated conversation so definitions are never cut
+	if b.glossaryProvider != nil {
+		result = llm.NewGlossaryWrapper(result, b.glossaryProvider)
+	}
+
+	// Guardrails, applied to the truncated conversation so the policy is never cut
+	if b.guardrails != nil {
+		result = llm.NewGuardrailWrapper(result, botConfig.Name, b.guardrails)
+	}
+
 	// Truncation Support
 	result = llm.NewLLMTruncationWrapper(result)
 
 	// Token Usage Logging
 	if b.tokenLogger != nil && b.config.EnableTokenUsageLogging() {
-		result = llm.NewTokenUsageLoggingWrapper(
+		tokenUsageWrapper := llm.NewTokenUsageLoggingWrapper(
 			result,
 			botConfig.Name,
 			b.tokenLogger,
 			b.metrics,
 		)
+		if userAPIKey {
+			tokenUsageWrapper.SetUserAPIKey()
+		}
+		result = tokenUsageWrapper
 	}
 
 	// Logging
@@ -294,9 +476,43 @@ func (b *MMBots) getLLM(serviceConfig llm.ServiceConfig, botConfig llm.BotConfig
 		result = llm.NewLanguageModelLogWrapper(b.pluginAPI.Log, result)
 	}
 
+	// Concurrency limits
+	limits := llm.ConcurrencyLimits{
+		PerUser: botConfig.MaxConcurrentGenerationsPerUser,
+		Total:   botConfig.MaxConcurrentGenerations,
+	}
+	if userAPIKey {
+		limits.Total = 0
+	}
+	if limits.PerUser > 0 || limits.Total > 0 {
+		result = llm.NewConcurrencyLimitWrapper(result, limits)
+	}
+
 	return result, nil
 }
 
+// serviceScheduler returns the scheduler shared by every bot of the service, or nil if the
+// service is not rate limited
+func (b *MMBots) serviceScheduler(service llm.ServiceConfig) *llm.PriorityScheduler {
+	if service.RequestsPerMinute <= 0 {
+		return nil
+	}
+
+	b.schedulersLock.Lock()
+	defer b.schedulersLock.Unlock()
+
+	if scheduler, ok := b.schedulers[service.ID]; ok && scheduler.RequestsPerMinute() == service.RequestsPerMinute {
+		return scheduler
+	}
+
+	if b.schedulers == nil {
+		b.schedulers = make(map[string]*llm.PriorityScheduler)
+	}
+	scheduler := llm.NewPriorityScheduler(service.RequestsPerMinute)
+	b.schedulers[service.ID] = scheduler
+	return scheduler
+}
+
 // TODO: This really doesn't belong here. Figure out where to put this.
 func (b *MMBots) GetTranscribe() Transcriber {
 	// Get the configured transcript generator bot
@@ -307,13 +523,14 @@ func (b *MMBots) GetTranscribe() Transcriber {
 	}
 
 	service := bot.service
+	httpClient := b.upstreamHTTPClient(service)
 	switch service.Type {
 	case llm.ServiceTypeOpenAI:
-		return openai.New(config.OpenAIConfigFromServiceConfig(service, bot.cfg), b.llmUpstreamHTTPClient)
+		return openai.New(config.OpenAIConfigFromServiceConfig(service, bot.cfg), httpClient)
 	case llm.ServiceTypeOpenAICompatible:
-		return openai.NewCompatible(config.OpenAIConfigFromServiceConfig(service, bot.cfg), b.llmUpstreamHTTPClient)
+		return openai.NewCompatible(config.OpenAIConfigFromServiceConfig(service, bot.cfg), httpClient)
 	case llm.ServiceTypeAzure:
-		return openai.NewAzure(config.OpenAIConfigFromServiceConfig(service, bot.cfg), b.llmUpstreamHTTPClient)
+		return openai.NewAzure(config.OpenAIConfigFromServiceConfig(service, bot.cfg), httpClient)
 	default:
 		b.pluginAPI.Log.Error("Unsupported service type for transcript generator",
 			"bot_name", bot.GetMMBot().Username,
@@ -322,6 +539,27 @@ func (b *MMBots) GetTranscribe() Transcriber {
 	}
 }
 
+// GetImageGenerator returns an image generator using the service of the bot, or nil if the service
+// can't generate images.
+func (b *MMBots) GetImageGenerator(bot *Bot) ImageGenerator {
+	if bot == nil {
+		return nil
+	}
+
+	service := bot.service
+	httpClient := b.upstreamHTTPClient(service)
+	switch service.Type {
+	case llm.ServiceTypeOpenAI:
+		return openai.New(config.OpenAIConfigFromServiceConfig(service, bot.cfg), httpClient)
+	case llm.ServiceTypeOpenAICompatible:
+		return openai.NewCompatible(config.OpenAIConfigFromServiceConfig(service, bot.cfg), httpClient)
+	case llm.ServiceTypeAzure:
+		return openai.NewAzure(config.OpenAIConfigFromServiceConfig(service, bot.cfg), httpClient)
+	default:
+		return nil
+	}
+}
+
 func (b *MMBots) getTrasncriberBot() *Bot {
 	b.botsLock.RLock()
 	defer b.botsLock.RUnlock()
@@ -386,6 +624,26 @@ func (b *MMBots) GetBotByID(botID string) *Bot {
 	return nil
 }
 
+// ReasoningDisplay returns how the reasoning of the bot with the user ID is shown, empty when
+// the user is not a bot.
+func (b *MMBots) ReasoningDisplay(botID string) string {
+	bot := b.GetBotByID(botID)
+	if bot == nil {
+		return ""
+	}
+	return bot.GetConfig().ReasoningDisplay
+}
+
+// PostProcessors returns the post-processors configured on the bot with the user ID, none when
+// the user is not a bot.
+func (b *MMBots) PostProcessors(botID string) []llm.PostProcessorConfig {
+	bot := b.GetBotByID(botID)
+	if bot == nil {
+		return nil
+	}
+	return bot.GetConfig().PostProcessors
+}
+
 // GetBotForDMChannel returns the bot for the given DM channel.
 func (b *MMBots) GetBotForDMChannel(channel *model.Channel) *Bot {
 	b.botsLock.RLock()
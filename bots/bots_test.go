@@ -4,14 +4,17 @@
 package bots
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
+	llmmocks "github.com/mattermost/mattermost-plugin-ai/llm/mocks"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -217,3 +220,24 @@ func TestEnsureBots(t *testing.T) {
 		})
 	}
 }
+
+func TestFeatureLLM(t *testing.T) {
+	languageModel := llmmocks.NewMockLanguageModel(t)
+	bot := NewBot(llm.BotConfig{
+		DisabledNativeTools: map[string][]string{"channel_analysis": {"web_search"}},
+	}, llm.ServiceConfig{}, nil, languageModel)
+
+	assert.Same(t, languageModel, bot.FeatureLLM("direct_message"))
+
+	languageModel.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, _ llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
+		var cfg llm.LanguageModelConfig
+		for _, opt := range opts {
+			opt(&cfg)
+		}
+		assert.Equal(t, []string{"web_search"}, cfg.DisabledNativeTools)
+		return "summary", nil
+	}).Once()
+
+	_, err := bot.FeatureLLM("channel_analysis").ChatCompletionNoStream(context.Background(), llm.CompletionRequest{})
+	require.NoError(t, err)
+}
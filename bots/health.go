@@ -0,0 +1,96 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package bots
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+// providerHealthCacheDuration is how long the reachability of a provider is reused, so frequent
+// health checks don't send a request to the providers every time.
+const providerHealthCacheDuration = time.Minute
+
+// ProviderHealth is the reachability of a service used by the bots
+type ProviderHealth struct {
+	ServiceID   string `json:"service_id"`
+	ServiceName string `json:"service_name"`
+	ServiceType string `json:"service_type"`
+	ServiceCheckStep
+	// QueuedRequests is the number of requests waiting for the rate limit of the service on this server
+	QueuedRequests int `json:"queued_requests"`
+	// CheckedAt is when the provider was last contacted, in milliseconds
+	CheckedAt int64 `json:"checked_at"`
+}
+
+type providerHealthCache struct {
+	mu      sync.Mutex
+	results map[string]ProviderHealth
+}
+
+// ProvidersHealth lists the models of each service used by a bot to check that the provider is
+// reachable with the configured credentials. Listing models doesn't use tokens. Services whose
+// provider can't list models are reported as unsupported.
+func (b *MMBots) ProvidersHealth() []ProviderHealth {
+	var services []llm.ServiceConfig
+	for _, bot := range b.GetAllBots() {
+		service := bot.GetService()
+		if !slices.ContainsFunc(services, func(s llm.ServiceConfig) bool { return s.ID == service.ID }) {
+			services = append(services, service)
+		}
+	}
+
+	results := make([]ProviderHealth, len(services))
+	var wg sync.WaitGroup
+	for i, service := range services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = b.providerHealth(service)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (b *MMBots) providerHealth(service llm.ServiceConfig) ProviderHealth {
+	b.providerHealthCache.mu.Lock()
+	health, ok := b.providerHealthCache.results[service.ID]
+	b.providerHealthCache.mu.Unlock()
+
+	if !ok || health.ServiceType != service.Type || time.Since(time.UnixMilli(health.CheckedAt)) > providerHealthCacheDuration {
+		health = ProviderHealth{
+			ServiceID:   service.ID,
+			ServiceName: service.Name,
+			ServiceType: service.Type,
+			CheckedAt:   time.Now().UnixMilli(),
+		}
+		httpClient := b.upstreamHTTPClient(service)
+		health.ServiceCheckStep = runServiceCheckStep(func(context.Context) error {
+			_, err := fetchServiceModels(service, httpClient)
+			return err
+		})
+		if health.Status == ServiceCheckFailed && health.Error == ErrModelListUnsupported.Error() {
+			health.ServiceCheckStep = ServiceCheckStep{Status: ServiceCheckUnsupported}
+		}
+
+		b.providerHealthCache.mu.Lock()
+		if b.providerHealthCache.results == nil {
+			b.providerHealthCache.results = make(map[string]ProviderHealth)
+		}
+		b.providerHealthCache.results[service.ID] = health
+		b.providerHealthCache.mu.Unlock()
+	}
+
+	health.QueuedRequests = 0
+	if scheduler := b.serviceScheduler(service); scheduler != nil {
+		health.QueuedRequests = scheduler.Waiting()
+	}
+	return health
+}
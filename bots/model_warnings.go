@@ -0,0 +1,81 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package bots
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mattermost/mattermost-plugin-ai/anthropic"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/openai"
+)
+
+// ErrModelListUnsupported is returned when the models of a service type can not be listed
+var ErrModelListUnsupported = errors.New("listing models is not supported for this service type")
+
+// BotModelWarnings are the warnings about the model configured for a bot
+type BotModelWarnings struct {
+	BotName   string `json:"bot_name"`
+	ServiceID string `json:"service_id"`
+	// Model is the model the bot uses, after resolving aliases
+	Model    string   `json:"model"`
+	Warnings []string `json:"warnings"`
+}
+
+// fetchServiceModels lists the models offered by the provider of the service
+func fetchServiceModels(service llm.ServiceConfig, httpClient *http.Client) ([]llm.ModelInfo, error) {
+	switch service.Type {
+	case llm.ServiceTypeOpenAI, llm.ServiceTypeOpenAICompatible:
+		return openai.FetchModels(service.APIKey, service.APIURL, service.OrgID, httpClient)
+	case llm.ServiceTypeAzure:
+		return openai.FetchAzureDeployments(service.APIKey, service.APIURL, httpClient)
+	case llm.ServiceTypeAnthropic:
+		return anthropic.FetchModels(service.APIKey, httpClient)
+	default:
+		return nil, ErrModelListUnsupported
+	}
+}
+
+// ModelWarnings lists the models of the services used by the configured bots, and returns
+// the bots whose model is deprecated, no longer offered, or does not support their settings.
+func (b *MMBots) ModelWarnings() []BotModelWarnings {
+	result := []BotModelWarnings{}
+	fetched := make(map[string]bool)
+	for _, botCfg := range b.config.GetBots() {
+		service, ok := b.config.GetServiceByID(botCfg.ServiceID)
+		if !ok {
+			continue
+		}
+
+		if !fetched[service.ID] {
+			fetched[service.ID] = true
+			models, err := fetchServiceModels(service, b.upstreamHTTPClient(service))
+			switch {
+			case err == nil:
+				b.capabilities.RecordModels(service.Type, service.APIURL, models)
+			case !errors.Is(err, ErrModelListUnsupported):
+				b.pluginAPI.Log.Warn("Failed to list the models of a service", "service_name", service.Name, "error", err.Error())
+			}
+		}
+
+		warnings := b.capabilities.Validate(service, botCfg)
+		if len(warnings) == 0 {
+			continue
+		}
+
+		model := service.DefaultModel
+		if botCfg.Model != "" {
+			model = botCfg.Model
+		}
+		result = append(result, BotModelWarnings{
+			BotName:   botCfg.Name,
+			ServiceID: service.ID,
+			Model:     service.ResolveModel(model),
+			Warnings:  warnings,
+		})
+	}
+
+	return result
+}
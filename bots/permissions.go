@@ -6,6 +6,7 @@ package bots
 import (
 	"fmt"
 	"slices"
+	"strings"
 
 	"errors"
 
@@ -21,14 +22,26 @@ func (m *MMBots) CheckUsageRestrictions(requestingUserID string, bot *Bot, chann
 		return err
 	}
 
-	if err := m.checkUsageRestrictionsForChannel(bot, channel); err != nil {
+	if err := m.CheckUsageRestrictionsForChannel(bot, channel); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (m *MMBots) checkUsageRestrictionsForChannel(bot *Bot, channel *model.Channel) error {
+// CheckUsageRestrictionsForChannel checks the bot can be used in the channel, whoever the user is
+func (m *MMBots) CheckUsageRestrictionsForChannel(bot *Bot, channel *model.Channel) error {
+	if m.channelExcluder != nil {
+		if err := m.channelExcluder.CheckChannel(channel); err != nil {
+			return fmt.Errorf("%w: %w", ErrUsageRestriction, err)
+		}
+	}
+
+	return checkChannelAccessLevel(bot, channel)
+}
+
+// checkChannelAccessLevel checks the channel access level of the bot allows the channel
+func checkChannelAccessLevel(bot *Bot, channel *model.Channel) error {
 	switch bot.GetConfig().ChannelAccessLevel {
 	case llm.ChannelAccessLevelAll:
 		return nil
@@ -60,40 +73,96 @@ func (m *MMBots) isMemberOfTeam(teamID string, userID string) (bool, error) {
 	return member != nil && member.DeleteAt == 0, nil
 }
 
+// isMemberOfGroup checks whether the user is a member of one of the custom user groups, by name
+func (m *MMBots) isMemberOfGroup(groupNames []string, userID string) (bool, error) {
+	groups, err := m.pluginAPI.Group.ListForUser(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, group := range groups {
+		if group.DeleteAt == 0 && group.Name != nil && slices.ContainsFunc(groupNames, func(name string) bool {
+			return strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(name), "@"), *group.Name)
+		}) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasRole checks whether the user has one of the system roles
+func (m *MMBots) hasRole(roles []string, userID string) (bool, error) {
+	user, err := m.pluginAPI.User.Get(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, role := range user.GetRoles() {
+		if slices.Contains(roles, role) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// userAccessListMatch returns why the user is in the users, teams, groups or roles of the bot,
+// or an empty string when they are not
+func (m *MMBots) userAccessListMatch(cfg llm.BotConfig, requestingUserID string) (string, error) {
+	// Check direct user list
+	if slices.Contains(cfg.UserIDs, requestingUserID) {
+		return "user", nil
+	}
+	// Check team membership
+	for _, teamID := range cfg.TeamIDs {
+		isMember, err := m.isMemberOfTeam(teamID, requestingUserID)
+		if err != nil {
+			return "", err
+		}
+		if isMember {
+			return "user's team", nil
+		}
+	}
+	// Check group membership
+	if len(cfg.Groups) > 0 {
+		isMember, err := m.isMemberOfGroup(cfg.Groups, requestingUserID)
+		if err != nil {
+			return "", err
+		}
+		if isMember {
+			return "user's group", nil
+		}
+	}
+	// Check system roles
+	if len(cfg.Roles) > 0 {
+		hasRole, err := m.hasRole(cfg.Roles, requestingUserID)
+		if err != nil {
+			return "", err
+		}
+		if hasRole {
+			return "user's role", nil
+		}
+	}
+	return "", nil
+}
+
 func (m *MMBots) CheckUsageRestrictionsForUser(bot *Bot, requestingUserID string) error {
 	switch bot.GetConfig().UserAccessLevel {
 	case llm.UserAccessLevelAll:
 		return nil
 	case llm.UserAccessLevelAllow:
-		// Check direct user allowlist
-		if slices.Contains(bot.GetConfig().UserIDs, requestingUserID) {
-			return nil
+		match, err := m.userAccessListMatch(bot.GetConfig(), requestingUserID)
+		if err != nil {
+			return err
 		}
-		// Check team membership
-		for _, teamID := range bot.GetConfig().TeamIDs {
-			isMember, err := m.isMemberOfTeam(teamID, requestingUserID)
-			if err != nil {
-				return err
-			}
-			if isMember {
-				return nil
-			}
+		if match != "" {
+			return nil
 		}
 		return fmt.Errorf("user not allowed: %w", ErrUsageRestriction)
 	case llm.UserAccessLevelBlock:
-		// Check direct user blocklist
-		if slices.Contains(bot.GetConfig().UserIDs, requestingUserID) {
-			return fmt.Errorf("user blocked: %w", ErrUsageRestriction)
+		match, err := m.userAccessListMatch(bot.GetConfig(), requestingUserID)
+		if err != nil {
+			return err
 		}
-		// Check team membership
-		for _, teamID := range bot.GetConfig().TeamIDs {
-			isMember, err := m.isMemberOfTeam(teamID, requestingUserID)
-			if err != nil {
-				return err
-			}
-			if isMember {
-				return fmt.Errorf("user's team blocked: %w", ErrUsageRestriction)
-			}
+		if match != "" {
+			return fmt.Errorf("%s blocked: %w", match, ErrUsageRestriction)
 		}
 		return nil
 	case llm.UserAccessLevelNone:
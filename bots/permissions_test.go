@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
@@ -272,3 +273,96 @@ func TestUsageRestrictions(t *testing.T) {
 		})
 	}
 }
+
+func TestUsageRestrictionsChannelExclusions(t *testing.T) {
+	e := SetupTestEnvironment(t)
+	defer e.Cleanup(t)
+
+	e.bots.SetChannelExcluder(exclusions.New(func() exclusions.Config {
+		return exclusions.Config{
+			ChannelIDs: []string{"excludedchannel"},
+			TeamIDs:    []string{"excludedteam"},
+		}
+	}))
+
+	bot := &Bot{cfg: llm.BotConfig{
+		ChannelAccessLevel: llm.ChannelAccessLevelAll,
+		UserAccessLevel:    llm.UserAccessLevelAll,
+	}}
+
+	err := e.bots.CheckUsageRestrictions("user1", bot, &model.Channel{Id: "excludedchannel", TeamId: "team1"})
+	require.ErrorIs(t, err, ErrUsageRestriction)
+	require.ErrorIs(t, err, exclusions.ErrChannelExcluded)
+
+	err = e.bots.CheckUsageRestrictions("user1", bot, &model.Channel{Id: "channel1", TeamId: "excludedteam"})
+	require.ErrorIs(t, err, exclusions.ErrChannelExcluded)
+
+	require.NoError(t, e.bots.CheckUsageRestrictions("user1", bot, &model.Channel{Id: "channel1", TeamId: "team1"}))
+}
+
+func TestUsageRestrictionsGroupsAndRoles(t *testing.T) {
+	betaGroup := &model.Group{Id: "group1", Name: model.NewPointer("ai-beta")}
+
+	testCases := []struct {
+		name          string
+		cfg           llm.BotConfig
+		userRoles     string
+		userGroups    []*model.Group
+		expectedError error
+	}{
+		{
+			name:       "User allowed via group membership",
+			cfg:        llm.BotConfig{UserAccessLevel: llm.UserAccessLevelAllow, Groups: []string{"@AI-Beta"}},
+			userGroups: []*model.Group{betaGroup},
+		},
+		{
+			name:          "User not in allowed group",
+			cfg:           llm.BotConfig{UserAccessLevel: llm.UserAccessLevelAllow, Groups: []string{"ai-beta"}},
+			userGroups:    []*model.Group{{Id: "group2", Name: model.NewPointer("sales")}},
+			expectedError: ErrUsageRestriction,
+		},
+		{
+			name:          "Deleted group does not match",
+			cfg:           llm.BotConfig{UserAccessLevel: llm.UserAccessLevelAllow, Groups: []string{"ai-beta"}},
+			userGroups:    []*model.Group{{Id: "group1", Name: model.NewPointer("ai-beta"), DeleteAt: 1}},
+			expectedError: ErrUsageRestriction,
+		},
+		{
+			name:          "Guest blocked via role",
+			cfg:           llm.BotConfig{UserAccessLevel: llm.UserAccessLevelBlock, Roles: []string{model.SystemGuestRoleId}},
+			userRoles:     model.SystemGuestRoleId,
+			expectedError: ErrUsageRestriction,
+		},
+		{
+			name:      "User without blocked role",
+			cfg:       llm.BotConfig{UserAccessLevel: llm.UserAccessLevelBlock, Roles: []string{model.SystemGuestRoleId}},
+			userRoles: model.SystemUserRoleId,
+		},
+		{
+			name:      "User allowed via role",
+			cfg:       llm.BotConfig{UserAccessLevel: llm.UserAccessLevelAllow, Roles: []string{model.SystemAdminRoleId}},
+			userRoles: model.SystemUserRoleId + " " + model.SystemAdminRoleId,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := SetupTestEnvironment(t)
+			defer e.Cleanup(t)
+
+			if len(tc.cfg.Groups) > 0 {
+				e.mockAPI.On("GetGroupsForUser", "user1").Return(tc.userGroups, nil)
+			}
+			if len(tc.cfg.Roles) > 0 {
+				e.mockAPI.On("GetUser", "user1").Return(&model.User{Id: "user1", Roles: tc.userRoles}, nil)
+			}
+
+			err := e.bots.CheckUsageRestrictionsForUser(&Bot{cfg: tc.cfg}, "user1")
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package bots
+
+import (
+	"github.com/mattermost/mattermost-plugin-ai/routing"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Router chooses the agents answering in a channel, see routing.Router
+type Router interface {
+	Candidates(channel *model.Channel) []routing.Candidate
+	Alias() string
+}
+
+// SetRouter sets the rules used to resolve the agent of a channel
+func (b *MMBots) SetRouter(router Router) {
+	b.router = router
+}
+
+// ResolveBot returns the agent answering the user in the channel and why it was chosen: the
+// first agent of the routing rules the user may use there, else the default agent, else the
+// first agent they may use. It returns nil if the user may not use any agent in the channel.
+func (b *MMBots) ResolveBot(userID string, channel *model.Channel) (*Bot, routing.Reason) {
+	usable := func(bot *Bot) bool {
+		return bot != nil &&
+			b.CheckUsageRestrictionsForUser(bot, userID) == nil &&
+			checkChannelAccessLevel(bot, channel) == nil
+	}
+
+	if b.router != nil {
+		for _, candidate := range b.router.Candidates(channel) {
+			if bot := b.GetBotByUsername(candidate.BotName); usable(bot) {
+				return bot, candidate.Reason
+			}
+		}
+	}
+
+	if bot := b.GetBotByUsername(b.config.GetDefaultBotName()); usable(bot) {
+		return bot, routing.ReasonDefault
+	}
+	for _, bot := range b.GetAllBots() {
+		if usable(bot) {
+			return bot, routing.ReasonDefault
+		}
+	}
+
+	return nil, ""
+}
+
+// GetBotMentionedByAlias returns the agent answering the user in the channel if the text
+// mentions the routing alias, such as @ai, and nil otherwise.
+func (b *MMBots) GetBotMentionedByAlias(text, userID string, channel *model.Channel) *Bot {
+	if b.router == nil {
+		return nil
+	}
+	alias := b.router.Alias()
+	if alias == "" || !userIsMentionedMarkdown(text, alias) {
+		return nil
+	}
+
+	bot, _ := b.ResolveBot(userID, channel)
+	return bot
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package bots
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/routing"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveBot(t *testing.T) {
+	newBot := func(name string, cfg llm.BotConfig) *Bot {
+		cfg.Name = name
+		return &Bot{cfg: cfg, mmBot: &model.Bot{UserId: name + "id", Username: name}}
+	}
+
+	testbot := newBot("testbot", llm.BotConfig{})
+	support := newBot("support", llm.BotConfig{})
+	sales := newBot("sales", llm.BotConfig{UserAccessLevel: llm.UserAccessLevelBlock, UserIDs: []string{"user2"}})
+	blocked := newBot("blocked", llm.BotConfig{ChannelAccessLevel: llm.ChannelAccessLevelNone})
+
+	rules := routing.Config{
+		Alias: "ai",
+		Rules: []routing.Rule{
+			{BotName: "blocked", ChannelIDs: []string{"channel1"}},
+			{BotName: "support", ChannelIDs: []string{"channel1"}},
+			{BotName: "sales", TeamIDs: []string{"team1"}},
+			{BotName: "missing", TeamIDs: []string{"team2"}},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		bots           []*Bot
+		userID         string
+		channel        *model.Channel
+		expectedBot    *Bot
+		expectedReason routing.Reason
+	}{
+		{
+			name:           "channel rule, skipping agents blocked in the channel",
+			bots:           []*Bot{testbot, support, sales, blocked},
+			userID:         "user1",
+			channel:        &model.Channel{Id: "channel1", TeamId: "team1"},
+			expectedBot:    support,
+			expectedReason: routing.ReasonChannel,
+		},
+		{
+			name:           "team rule",
+			bots:           []*Bot{testbot, support, sales, blocked},
+			userID:         "user1",
+			channel:        &model.Channel{Id: "channel2", TeamId: "team1"},
+			expectedBot:    sales,
+			expectedReason: routing.ReasonTeam,
+		},
+		{
+			name:           "default agent when the user may not use the agent of the rule",
+			bots:           []*Bot{testbot, support, sales, blocked},
+			userID:         "user2",
+			channel:        &model.Channel{Id: "channel2", TeamId: "team1"},
+			expectedBot:    testbot,
+			expectedReason: routing.ReasonDefault,
+		},
+		{
+			name:           "default agent when the agent of the rule doesn't exist",
+			bots:           []*Bot{testbot, support},
+			userID:         "user1",
+			channel:        &model.Channel{Id: "channel2", TeamId: "team2"},
+			expectedBot:    testbot,
+			expectedReason: routing.ReasonDefault,
+		},
+		{
+			name:           "first usable agent without a default agent",
+			bots:           []*Bot{blocked, support},
+			userID:         "user1",
+			channel:        &model.Channel{Id: "channel3"},
+			expectedBot:    support,
+			expectedReason: routing.ReasonDefault,
+		},
+		{
+			name:    "no usable agent",
+			bots:    []*Bot{blocked},
+			userID:  "user1",
+			channel: &model.Channel{Id: "channel3"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := SetupTestEnvironment(t)
+			defer e.Cleanup(t)
+			e.bots.config = &mockConfig{}
+			e.bots.SetRouter(routing.New(func() routing.Config { return rules }))
+			e.bots.SetBotsForTesting(tc.bots)
+
+			bot, reason := e.bots.ResolveBot(tc.userID, tc.channel)
+			require.Equal(t, tc.expectedBot, bot)
+			require.Equal(t, tc.expectedReason, reason)
+
+			aliasBot := e.bots.GetBotMentionedByAlias("hey @ai, what's up?", tc.userID, tc.channel)
+			require.Equal(t, tc.expectedBot, aliasBot)
+			require.Nil(t, e.bots.GetBotMentionedByAlias("hey @aide", tc.userID, tc.channel))
+		})
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package bots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	anthropicSDK "github.com/anthropics/anthropic-sdk-go"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/openai"
+	openaiSDK "github.com/openai/openai-go/v2"
+)
+
+// serviceCheckTimeout bounds each call made while checking a service
+const serviceCheckTimeout = 30 * time.Second
+
+// ErrServiceNotFound is returned when checking a service that is not configured
+var ErrServiceNotFound = errors.New("service not found")
+
+// Statuses of a service check step
+const (
+	ServiceCheckOK          = "ok"
+	ServiceCheckFailed      = "error"
+	ServiceCheckUnsupported = "unsupported"
+)
+
+// Categories of the errors returned by a provider
+const (
+	ServiceErrorAuth       = "auth"
+	ServiceErrorNetwork    = "network"
+	ServiceErrorQuota      = "quota"
+	ServiceErrorNotFound   = "not_found"
+	ServiceErrorBadRequest = "bad_request"
+	ServiceErrorServer     = "server"
+	ServiceErrorUnknown    = "unknown"
+)
+
+// ServiceCheckStep is the result of one call made to the provider
+type ServiceCheckStep struct {
+	Status        string `json:"status"`
+	LatencyMs     int64  `json:"latency_ms"`
+	ErrorCategory string `json:"error_category,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// ServiceCheck is the result of checking that a service is correctly configured
+type ServiceCheck struct {
+	ServiceID  string           `json:"service_id"`
+	Completion ServiceCheckStep `json:"completion"`
+	Embedding  ServiceCheckStep `json:"embedding"`
+	Models     ServiceCheckStep `json:"models"`
+	// ModelCount is the number of models listed by the provider
+	ModelCount int `json:"model_count"`
+	// ModelAvailable is whether the default model of the service is in the list of models
+	ModelAvailable bool `json:"model_available"`
+}
+
+// CheckService makes a minimal completion, embedding and model list call to the service so
+// admins can validate its configuration before users hit failures.
+func (b *MMBots) CheckService(serviceID string) (*ServiceCheck, error) {
+	service, ok := b.config.GetServiceByID(serviceID)
+	if !ok {
+		return nil, ErrServiceNotFound
+	}
+
+	result := &ServiceCheck{ServiceID: serviceID}
+	httpClient := b.upstreamHTTPClient(service)
+
+	result.Completion = runServiceCheckStep(func(ctx context.Context) error {
+		model, err := b.newLanguageModel(service, llm.BotConfig{Name: "service check"}, false)
+		if err != nil {
+			return err
+		}
+		_, err = model.ChatCompletionNoStream(ctx, llm.CompletionRequest{
+			Posts:   []llm.Post{{Role: llm.PostRoleUser, Message: "Reply with OK."}},
+			Context: llm.NewContext(),
+		}, llm.WithMaxGeneratedTokens(64), llm.WithToolsDisabled())
+		return err
+	})
+
+	switch service.Type {
+	case llm.ServiceTypeOpenAI, llm.ServiceTypeOpenAICompatible, llm.ServiceTypeAzure:
+		result.Embedding = runServiceCheckStep(func(ctx context.Context) error {
+			embeddingConfig := config.OpenAIConfigFromServiceConfig(service, llm.BotConfig{})
+			var embeddings *openai.OpenAI
+			if service.Type == llm.ServiceTypeOpenAI {
+				embeddings = openai.NewEmbeddings(embeddingConfig, httpClient)
+			} else {
+				embeddings = openai.NewCompatibleEmbeddings(embeddingConfig, httpClient)
+			}
+			_, err := embeddings.CreateEmbedding(ctx, "test")
+			return err
+		})
+	default:
+		result.Embedding = ServiceCheckStep{Status: ServiceCheckUnsupported}
+	}
+
+	var models []llm.ModelInfo
+	result.Models = runServiceCheckStep(func(ctx context.Context) error {
+		var err error
+		models, err = fetchServiceModels(service, httpClient)
+		return err
+	})
+	if result.Models.Status == ServiceCheckFailed && result.Models.Error == ErrModelListUnsupported.Error() {
+		result.Models = ServiceCheckStep{Status: ServiceCheckUnsupported}
+	}
+
+	if result.Models.Status == ServiceCheckOK {
+		b.capabilities.RecordModels(service.Type, service.APIURL, models)
+	}
+	result.ModelCount = len(models)
+	defaultModel := service.ResolveModel(service.DefaultModel)
+	for _, model := range models {
+		if model.ID == defaultModel {
+			result.ModelAvailable = true
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// runServiceCheckStep times the call and categorizes its error. Calls taking longer than
+// serviceCheckTimeout are reported as network errors and left to finish in the background.
+func runServiceCheckStep(call func(ctx context.Context) error) ServiceCheckStep {
+	ctx, cancel := context.WithTimeout(context.Background(), serviceCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- call(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("no response after %s: %w", serviceCheckTimeout, ctx.Err())
+	}
+
+	step := ServiceCheckStep{
+		Status:    ServiceCheckOK,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		step.Status = ServiceCheckFailed
+		step.ErrorCategory = categorizeServiceError(err)
+		step.Error = err.Error()
+	}
+
+	return step
+}
+
+// categorizeServiceError tells apart the common causes of provider errors
+func categorizeServiceError(err error) string {
+	statusCode := 0
+	var openaiErr *openaiSDK.Error
+	var anthropicErr *anthropicSDK.Error
+	var awsErr interface{ HTTPStatusCode() int }
+	switch {
+	case errors.As(err, &openaiErr):
+		statusCode = openaiErr.StatusCode
+	case errors.As(err, &anthropicErr):
+		statusCode = anthropicErr.StatusCode
+	case errors.As(err, &awsErr):
+		statusCode = awsErr.HTTPStatusCode()
+	}
+
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ServiceErrorAuth
+	case statusCode == http.StatusTooManyRequests || statusCode == http.StatusPaymentRequired:
+		return ServiceErrorQuota
+	case statusCode == http.StatusNotFound:
+		return ServiceErrorNotFound
+	case statusCode >= 500:
+		return ServiceErrorServer
+	case statusCode >= 400:
+		// Some providers report exhausted credit as a bad request
+		if isQuotaMessage(err.Error()) {
+			return ServiceErrorQuota
+		}
+		return ServiceErrorBadRequest
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return ServiceErrorNetwork
+	}
+
+	message := strings.ToLower(err.Error())
+	switch {
+	case isQuotaMessage(message):
+		return ServiceErrorQuota
+	case strings.Contains(message, "api key") || strings.Contains(message, "unauthorized") || strings.Contains(message, "credentials"):
+		return ServiceErrorAuth
+	case strings.Contains(message, "connection refused") || strings.Contains(message, "no such host") || strings.Contains(message, "timeout"):
+		return ServiceErrorNetwork
+	}
+
+	return ServiceErrorUnknown
+}
+
+func isQuotaMessage(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "quota") || strings.Contains(message, "rate limit") || strings.Contains(message, "insufficient credit") || strings.Contains(message, "credit balance")
+}
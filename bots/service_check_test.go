@@ -0,0 +1,96 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package bots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	openaiSDK "github.com/openai/openai-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type awsResponseError struct {
+	statusCode int
+}
+
+func (e *awsResponseError) Error() string       { return fmt.Sprintf("http %d", e.statusCode) }
+func (e *awsResponseError) HTTPStatusCode() int { return e.statusCode }
+
+func TestCategorizeServiceError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "openai unauthorized",
+			err:      fmt.Errorf("stream failed: %w", &openaiSDK.Error{StatusCode: http.StatusUnauthorized}),
+			expected: ServiceErrorAuth,
+		},
+		{
+			name:     "openai rate limited",
+			err:      &openaiSDK.Error{StatusCode: http.StatusTooManyRequests},
+			expected: ServiceErrorQuota,
+		},
+		{
+			name:     "missing model",
+			err:      &openaiSDK.Error{StatusCode: http.StatusNotFound},
+			expected: ServiceErrorNotFound,
+		},
+		{
+			name:     "provider outage",
+			err:      &awsResponseError{statusCode: http.StatusServiceUnavailable},
+			expected: ServiceErrorServer,
+		},
+		{
+			name:     "aws forbidden",
+			err:      &awsResponseError{statusCode: http.StatusForbidden},
+			expected: ServiceErrorAuth,
+		},
+		{
+			name:     "network error",
+			err:      &net.OpError{Op: "dial", Err: errors.New("connection refused")},
+			expected: ServiceErrorNetwork,
+		},
+		{
+			name:     "timeout",
+			err:      fmt.Errorf("no response: %w", context.DeadlineExceeded),
+			expected: ServiceErrorNetwork,
+		},
+		{
+			name:     "quota message",
+			err:      errors.New("You exceeded your current quota"),
+			expected: ServiceErrorQuota,
+		},
+		{
+			name:     "unknown",
+			err:      errors.New("something happened"),
+			expected: ServiceErrorUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, categorizeServiceError(tt.err))
+		})
+	}
+}
+
+func TestRunServiceCheckStep(t *testing.T) {
+	step := runServiceCheckStep(func(ctx context.Context) error { return nil })
+	assert.Equal(t, ServiceCheckOK, step.Status)
+	assert.Empty(t, step.ErrorCategory)
+
+	step = runServiceCheckStep(func(ctx context.Context) error {
+		return errors.New("invalid api key provided")
+	})
+	assert.Equal(t, ServiceCheckFailed, step.Status)
+	assert.Equal(t, ServiceErrorAuth, step.ErrorCategory)
+	assert.NotEmpty(t, step.Error)
+}
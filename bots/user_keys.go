@@ -0,0 +1,99 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package bots
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+// UserKeyStore returns the API keys users registered for themselves
+type UserKeyStore interface {
+	// GetAPIKey returns the user's key for the service, or an empty string if none is set
+	GetAPIKey(userID, serviceID string) (string, error)
+}
+
+type userKeyModel struct {
+	apiKey string
+	model  llm.LanguageModel
+}
+
+// userKeyLanguageModel answers requests of users with their own API key using a model built
+// with that key, and everyone else with the shared model of the service.
+type userKeyLanguageModel struct {
+	shared    llm.LanguageModel
+	serviceID string
+	keys      UserKeyStore
+	build     func(apiKey string) (llm.LanguageModel, error)
+
+	mu     sync.Mutex
+	models map[string]userKeyModel
+}
+
+func newUserKeyLanguageModel(shared llm.LanguageModel, serviceID string, keys UserKeyStore, build func(apiKey string) (llm.LanguageModel, error)) *userKeyLanguageModel {
+	return &userKeyLanguageModel{
+		shared:    shared,
+		serviceID: serviceID,
+		keys:      keys,
+		build:     build,
+		models:    make(map[string]userKeyModel),
+	}
+}
+
+func (m *userKeyLanguageModel) modelFor(request llm.CompletionRequest) (llm.LanguageModel, error) {
+	if request.Context == nil || request.Context.RequestingUser == nil {
+		return m.shared, nil
+	}
+	userID := request.Context.RequestingUser.Id
+
+	apiKey, err := m.keys.GetAPIKey(userID, m.serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the user's API key: %w", err)
+	}
+	if apiKey == "" {
+		return m.shared, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cached, ok := m.models[userID]; ok && cached.apiKey == apiKey {
+		return cached.model, nil
+	}
+
+	model, err := m.build(apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create model with the user's API key: %w", err)
+	}
+	m.models[userID] = userKeyModel{apiKey: apiKey, model: model}
+
+	return model, nil
+}
+
+func (m *userKeyLanguageModel) ChatCompletion(ctx context.Context, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+	model, err := m.modelFor(request)
+	if err != nil {
+		return nil, err
+	}
+	return model.ChatCompletion(ctx, request, opts...)
+}
+
+func (m *userKeyLanguageModel) ChatCompletionNoStream(ctx context.Context, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
+	model, err := m.modelFor(request)
+	if err != nil {
+		return "", err
+	}
+	return model.ChatCompletionNoStream(ctx, request, opts...)
+}
+
+func (m *userKeyLanguageModel) CountTokens(text string) int {
+	return m.shared.CountTokens(text)
+}
+
+func (m *userKeyLanguageModel) InputTokenLimit() int {
+	return m.shared.InputTokenLimit()
+}
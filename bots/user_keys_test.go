@@ -0,0 +1,97 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package bots
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// namedModel answers every request with its name
+type namedModel struct {
+	name string
+}
+
+func (m *namedModel) ChatCompletion(ctx context.Context, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+	return llm.NewStreamFromString(m.name), nil
+}
+
+func (m *namedModel) ChatCompletionNoStream(ctx context.Context, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
+	return m.name, nil
+}
+
+func (m *namedModel) CountTokens(text string) int { return len(text) }
+
+func (m *namedModel) InputTokenLimit() int { return 100 }
+
+type mapKeyStore struct {
+	keys map[string]string
+	err  error
+}
+
+func (s *mapKeyStore) GetAPIKey(userID, serviceID string) (string, error) {
+	return s.keys[userID+"/"+serviceID], s.err
+}
+
+func requestFrom(userID string) llm.CompletionRequest {
+	return llm.CompletionRequest{
+		Context: &llm.Context{RequestingUser: &model.User{Id: userID}},
+	}
+}
+
+func TestUserKeyLanguageModel(t *testing.T) {
+	keys := &mapKeyStore{keys: map[string]string{"user1/service1": "user1-key"}}
+	builds := 0
+	wrapped := newUserKeyLanguageModel(&namedModel{name: "shared"}, "service1", keys, func(apiKey string) (llm.LanguageModel, error) {
+		builds++
+		return &namedModel{name: apiKey}, nil
+	})
+
+	t.Run("user without a key uses the shared model", func(t *testing.T) {
+		result, err := wrapped.ChatCompletionNoStream(context.Background(), requestFrom("user2"))
+		require.NoError(t, err)
+		assert.Equal(t, "shared", result)
+	})
+
+	t.Run("request without a user uses the shared model", func(t *testing.T) {
+		result, err := wrapped.ChatCompletionNoStream(context.Background(), llm.CompletionRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "shared", result)
+	})
+
+	t.Run("user with a key uses their own model", func(t *testing.T) {
+		result, err := wrapped.ChatCompletionNoStream(context.Background(), requestFrom("user1"))
+		require.NoError(t, err)
+		assert.Equal(t, "user1-key", result)
+
+		stream, err := wrapped.ChatCompletion(context.Background(), requestFrom("user1"))
+		require.NoError(t, err)
+		text, err := stream.ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, "user1-key", text)
+		assert.Equal(t, 1, builds, "the user's model is reused")
+	})
+
+	t.Run("changed key rebuilds the model", func(t *testing.T) {
+		keys.keys["user1/service1"] = "user1-new-key"
+		result, err := wrapped.ChatCompletionNoStream(context.Background(), requestFrom("user1"))
+		require.NoError(t, err)
+		assert.Equal(t, "user1-new-key", result)
+		assert.Equal(t, 2, builds)
+	})
+
+	t.Run("key store errors are returned", func(t *testing.T) {
+		keys.err = errors.New("decrypt failed")
+		defer func() { keys.err = nil }()
+
+		_, err := wrapped.ChatCompletionNoStream(context.Background(), requestFrom("user1"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,392 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package calc evaluates arithmetic, duration and date expressions so models don't have to
+// compute results themselves. Evaluation never runs code: the only operations are the
+// operators and functions listed in the documentation of Evaluate.
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxExpressionLength is the longest expression evaluated
+	MaxExpressionLength = 1000
+	maxDepth            = 100
+)
+
+// Kind is the type of a value
+type Kind int
+
+const (
+	KindNumber Kind = iota
+	// KindDuration values are a number of seconds
+	KindDuration
+	KindDate
+)
+
+// Value is the result of an expression
+type Value struct {
+	Kind   Kind
+	Number float64
+	Date   time.Time
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+var durationUnits = map[byte]float64{
+	'w': 7 * 24 * 3600,
+	'd': 24 * 3600,
+	'h': 3600,
+	'm': 60,
+	's': 1,
+}
+
+// Evaluate returns the value of the expression. It supports:
+//   - numbers and the operators + - * / % ^ with parentheses
+//   - durations such as 1h30m, 2d or 45s, with units w, d, h, m and s
+//   - dates in quotes such as "2024-03-01" or "2024-03-01 14:30", in UTC
+//   - the constants pi and e
+//   - the functions sqrt, abs, round (with optional digits), floor, ceil, ln, log10, exp,
+//     min, max, sum and avg, and weeks, days, hours, minutes and seconds to convert durations to numbers
+//
+// Durations can be added, subtracted, multiplied or divided by numbers and divided by each
+// other. Subtracting dates gives a duration and adding a duration to a date gives a date.
+func Evaluate(expression string) (Value, error) {
+	if strings.TrimSpace(expression) == "" {
+		return Value{}, errors.New("empty expression")
+	}
+	if len(expression) > MaxExpressionLength {
+		return Value{}, fmt.Errorf("expression is longer than %d characters", MaxExpressionLength)
+	}
+
+	p := &parser{input: expression}
+	value, err := p.parseExpression()
+	if err != nil {
+		return Value{}, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return Value{}, fmt.Errorf("unexpected %q at position %d", p.input[p.pos:p.pos+1], p.pos+1)
+	}
+	if value.Kind != KindDate && (math.IsInf(value.Number, 0) || math.IsNaN(value.Number)) {
+		return Value{}, errors.New("the result is not a finite number")
+	}
+
+	return value, nil
+}
+
+// String formats the value for people and models. Durations are shown in days, hours, minutes
+// and seconds along with their length in hours.
+func (v Value) String() string {
+	switch v.Kind {
+	case KindDuration:
+		return fmt.Sprintf("%s (%s hours)", formatDuration(v.Number), formatNumber(v.Number/3600))
+	case KindDate:
+		return v.Date.Format("2006-01-02 15:04:05 UTC (Monday)")
+	default:
+		return formatNumber(v.Number)
+	}
+}
+
+func formatNumber(n float64) string {
+	if math.Abs(n) >= 1e15 || (n != 0 && math.Abs(n) < 1e-9) {
+		return strconv.FormatFloat(n, 'g', 12, 64)
+	}
+	// Rounding hides binary floating point noise such as 0.1+0.2 = 0.30000000000000004
+	rounded := math.Round(n*1e10) / 1e10
+	if rounded == 0 {
+		rounded = 0 // Avoids -0
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+func formatDuration(seconds float64) string {
+	sign := ""
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+
+	var parts []string
+	for _, unit := range []struct {
+		suffix string
+		size   float64
+	}{{"d", 86400}, {"h", 3600}, {"m", 60}} {
+		if count := math.Floor(seconds / unit.size); count > 0 {
+			parts = append(parts, formatNumber(count)+unit.suffix)
+			seconds -= count * unit.size
+		}
+	}
+	if seconds > 1e-9 || len(parts) == 0 {
+		parts = append(parts, formatNumber(seconds)+"s")
+	}
+
+	return sign + strings.Join(parts, " ")
+}
+
+type parser struct {
+	input string
+	pos   int
+	depth int
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t' || p.input[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *parser) peek() byte {
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxDepth {
+		return errors.New("expression is nested too deeply")
+	}
+	return nil
+}
+
+func (p *parser) parseExpression() (Value, error) {
+	if err := p.enter(); err != nil {
+		return Value{}, err
+	}
+	defer func() { p.depth-- }()
+
+	left, err := p.parseTerm()
+	if err != nil {
+		return Value{}, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return Value{}, err
+		}
+		if left, err = apply(op, left, right); err != nil {
+			return Value{}, err
+		}
+	}
+}
+
+func (p *parser) parseTerm() (Value, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return Value{}, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return Value{}, err
+		}
+		if left, err = apply(op, left, right); err != nil {
+			return Value{}, err
+		}
+	}
+}
+
+func (p *parser) parseUnary() (Value, error) {
+	if err := p.enter(); err != nil {
+		return Value{}, err
+	}
+	defer func() { p.depth-- }()
+
+	switch p.peek() {
+	case '+':
+		p.pos++
+		return p.parseUnary()
+	case '-':
+		p.pos++
+		value, err := p.parseUnary()
+		if err != nil {
+			return Value{}, err
+		}
+		if value.Kind == KindDate {
+			return Value{}, errors.New("a date can't be negated")
+		}
+		value.Number = -value.Number
+		return value, nil
+	}
+	return p.parsePower()
+}
+
+func (p *parser) parsePower() (Value, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return Value{}, err
+	}
+	if p.peek() != '^' {
+		return base, nil
+	}
+	p.pos++
+	// Exponentiation is right associative and binds tighter than a unary minus on its left
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return Value{}, err
+	}
+	return apply('^', base, exponent)
+}
+
+func (p *parser) parsePrimary() (Value, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return Value{}, errors.New("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		value, err := p.parseExpression()
+		if err != nil {
+			return Value{}, err
+		}
+		if p.peek() != ')' {
+			return Value{}, fmt.Errorf("missing ) at position %d", p.pos+1)
+		}
+		p.pos++
+		return value, nil
+	case c == '"' || c == '\'':
+		return p.parseDate(c)
+	case isDigit(c) || c == '.':
+		return p.parseNumber()
+	case isLetter(c):
+		return p.parseIdentifier()
+	}
+	return Value{}, fmt.Errorf("unexpected %q at position %d", string(c), p.pos+1)
+}
+
+func (p *parser) parseNumber() (Value, error) {
+	start := p.pos
+	number, err := p.readNumber()
+	if err != nil {
+		return Value{}, err
+	}
+	if p.pos >= len(p.input) || durationUnits[p.input[p.pos]] == 0 {
+		return Value{Kind: KindNumber, Number: number}, nil
+	}
+
+	// A duration such as 1h30m, made of numbers each followed by a unit
+	seconds := 0.0
+	for {
+		unit := durationUnits[p.input[p.pos]]
+		p.pos++
+		if p.pos < len(p.input) && isLetter(p.input[p.pos]) {
+			return Value{}, fmt.Errorf("invalid duration %q, use the units w, d, h, m and s", p.input[start:p.pos+1])
+		}
+		seconds += number * unit
+		if p.pos >= len(p.input) || !isDigit(p.input[p.pos]) {
+			return Value{Kind: KindDuration, Number: seconds}, nil
+		}
+		if number, err = p.readNumber(); err != nil {
+			return Value{}, err
+		}
+		if p.pos >= len(p.input) || durationUnits[p.input[p.pos]] == 0 {
+			return Value{}, fmt.Errorf("missing unit in duration %q", p.input[start:p.pos])
+		}
+	}
+}
+
+func (p *parser) readNumber() (float64, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (isDigit(p.input[p.pos]) || p.input[p.pos] == '.' || p.input[p.pos] == '_') {
+		p.pos++
+	}
+	text := strings.ReplaceAll(p.input[start:p.pos], "_", "")
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", p.input[start:p.pos])
+	}
+	return number, nil
+}
+
+func (p *parser) parseDate(quote byte) (Value, error) {
+	start := p.pos + 1
+	end := strings.IndexByte(p.input[start:], quote)
+	if end < 0 {
+		return Value{}, fmt.Errorf("missing closing quote at position %d", p.pos+1)
+	}
+	text := strings.TrimSpace(p.input[start : start+end])
+	p.pos = start + end + 1
+
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, text); err == nil {
+			return Value{Kind: KindDate, Date: date.UTC()}, nil
+		}
+	}
+	return Value{}, fmt.Errorf("invalid date %q, use a format such as \"2024-03-01\" or \"2024-03-01 14:30\"", text)
+}
+
+func (p *parser) parseIdentifier() (Value, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (isLetter(p.input[p.pos]) || isDigit(p.input[p.pos]) || p.input[p.pos] == '_') {
+		p.pos++
+	}
+	name := strings.ToLower(p.input[start:p.pos])
+
+	if p.peek() != '(' {
+		switch name {
+		case "pi":
+			return Value{Kind: KindNumber, Number: math.Pi}, nil
+		case "e":
+			return Value{Kind: KindNumber, Number: math.E}, nil
+		}
+		return Value{}, fmt.Errorf("unknown name %q", name)
+	}
+	p.pos++
+
+	var args []Value
+	if p.peek() == ')' {
+		p.pos++
+	} else {
+		for {
+			arg, err := p.parseExpression()
+			if err != nil {
+				return Value{}, err
+			}
+			args = append(args, arg)
+			if c := p.peek(); c == ',' {
+				p.pos++
+				continue
+			} else if c == ')' {
+				p.pos++
+				break
+			}
+			return Value{}, fmt.Errorf("missing ) after the arguments of %s", name)
+		}
+	}
+
+	return call(name, args)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package calc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name        string
+		expression  string
+		expected    string
+		expectError bool
+	}{
+		{name: "precedence", expression: "2 + 3 * 4", expected: "14"},
+		{name: "parentheses", expression: "(2 + 3) * 4", expected: "20"},
+		{name: "power is right associative", expression: "2 ^ 3 ^ 2", expected: "512"},
+		{name: "power binds tighter than negation", expression: "-2 ^ 2", expected: "-4"},
+		{name: "floating point noise is hidden", expression: "0.1 + 0.2", expected: "0.3"},
+		{name: "modulo", expression: "17 % 5", expected: "2"},
+		{name: "digit separators", expression: "1_000 * 3", expected: "3000"},
+		{name: "functions", expression: "sqrt(16) + abs(-2) + round(2.345, 2)", expected: "8.35"},
+		{name: "aggregates", expression: "avg(1, 2, 3, 4) + max(1, 5) - min(2, 3) + sum(1, 1)", expected: "7.5"},
+		{name: "constants", expression: "round(pi * 2, 4)", expected: "6.2832"},
+		{name: "durations add up", expression: "1h30m + 45m + 2h", expected: "4h 15m (4.25 hours)"},
+		{name: "durations over a day", expression: "sum(20h, 6h, 30m)", expected: "1d 2h 30m (26.5 hours)"},
+		{name: "duration divided by a number", expression: "3h / 4", expected: "45m (0.75 hours)"},
+		{name: "duration ratio", expression: "1d / 6h", expected: "4"},
+		{name: "duration converted to minutes", expression: "minutes(1h15m)", expected: "75"},
+		{name: "date difference", expression: `"2024-03-01" - "2024-01-15"`, expected: "46d (1104 hours)"},
+		{name: "date difference in days", expression: `days("2024-03-01 12:00" - "2024-03-01 06:00")`, expected: "0.25"},
+		{name: "date plus duration", expression: `"2024-02-28 22:00" + 36h`, expected: "2024-03-01 10:00:00 UTC (Friday)"},
+		{name: "latest date", expression: `max("2024-01-01", "2024-05-01", "2024-03-01")`, expected: "2024-05-01 00:00:00 UTC (Wednesday)"},
+		{name: "division by zero", expression: "1 / 0", expectError: true},
+		{name: "invalid result", expression: "sqrt(-1)", expectError: true},
+		{name: "adding dates", expression: `"2024-01-01" + "2024-01-02"`, expectError: true},
+		{name: "adding a number to a duration", expression: "1h + 5", expectError: true},
+		{name: "invalid date", expression: `"yesterday" - "2024-01-01"`, expectError: true},
+		{name: "invalid duration unit", expression: "5y", expectError: true},
+		{name: "unknown function", expression: "system(1)", expectError: true},
+		{name: "unknown name", expression: "x + 1", expectError: true},
+		{name: "missing parenthesis", expression: "(1 + 2", expectError: true},
+		{name: "trailing input", expression: "1 2", expectError: true},
+		{name: "empty", expression: "  ", expectError: true},
+		{name: "too deeply nested", expression: strings.Repeat("(", 200) + "1" + strings.Repeat(")", 200), expectError: true},
+		{name: "too long", expression: strings.Repeat("1+", MaxExpressionLength) + "1", expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := Evaluate(test.expression)
+			if test.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, value.String())
+		})
+	}
+}
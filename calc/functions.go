@@ -0,0 +1,176 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindDuration:
+		return "duration"
+	case KindDate:
+		return "date"
+	default:
+		return "number"
+	}
+}
+
+func number(n float64) Value {
+	return Value{Kind: KindNumber, Number: n}
+}
+
+func duration(seconds float64) Value {
+	return Value{Kind: KindDuration, Number: seconds}
+}
+
+func addSeconds(date time.Time, seconds float64) Value {
+	return Value{Kind: KindDate, Date: date.Add(time.Duration(math.Round(seconds * float64(time.Second))))}
+}
+
+// apply evaluates a binary operator, checking that it makes sense for the kinds of its operands.
+func apply(op byte, left, right Value) (Value, error) {
+	switch {
+	case op == '+' && left.Kind == right.Kind && left.Kind != KindDate:
+		return Value{Kind: left.Kind, Number: left.Number + right.Number}, nil
+	case op == '+' && left.Kind == KindDate && right.Kind == KindDuration:
+		return addSeconds(left.Date, right.Number), nil
+	case op == '+' && left.Kind == KindDuration && right.Kind == KindDate:
+		return addSeconds(right.Date, left.Number), nil
+	case op == '-' && left.Kind == right.Kind && left.Kind != KindDate:
+		return Value{Kind: left.Kind, Number: left.Number - right.Number}, nil
+	case op == '-' && left.Kind == KindDate && right.Kind == KindDuration:
+		return addSeconds(left.Date, -right.Number), nil
+	case op == '-' && left.Kind == KindDate && right.Kind == KindDate:
+		return duration(left.Date.Sub(right.Date).Seconds()), nil
+	case op == '*' && left.Kind == KindNumber && right.Kind != KindDate:
+		return Value{Kind: right.Kind, Number: left.Number * right.Number}, nil
+	case op == '*' && left.Kind == KindDuration && right.Kind == KindNumber:
+		return duration(left.Number * right.Number), nil
+	case (op == '/' || op == '%') && right.Kind != KindDate && left.Kind != KindDate:
+		if right.Kind == KindDuration && left.Kind == KindNumber {
+			break
+		}
+		if right.Number == 0 {
+			return Value{}, errors.New("division by zero")
+		}
+		result := left.Number / right.Number
+		if op == '%' {
+			result = math.Mod(left.Number, right.Number)
+		}
+		// A duration divided by a duration is a ratio, otherwise the kind of the dividend is kept
+		if left.Kind == KindDuration && right.Kind == KindDuration && op == '/' {
+			return number(result), nil
+		}
+		return Value{Kind: left.Kind, Number: result}, nil
+	case op == '^' && left.Kind == KindNumber && right.Kind == KindNumber:
+		return number(math.Pow(left.Number, right.Number)), nil
+	}
+
+	return Value{}, fmt.Errorf("can't apply %s to a %s and a %s", string(op), left.Kind, right.Kind)
+}
+
+var unaryFunctions = map[string]func(float64) float64{
+	"sqrt":  math.Sqrt,
+	"abs":   math.Abs,
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"ln":    math.Log,
+	"log10": math.Log10,
+	"exp":   math.Exp,
+}
+
+var durationConversions = map[string]float64{
+	"weeks":   7 * 86400,
+	"days":    86400,
+	"hours":   3600,
+	"minutes": 60,
+	"seconds": 1,
+}
+
+// call evaluates a function.
+func call(name string, args []Value) (Value, error) {
+	if fn, ok := unaryFunctions[name]; ok {
+		if len(args) != 1 {
+			return Value{}, fmt.Errorf("%s takes 1 argument", name)
+		}
+		// abs, floor and ceil also make sense for durations
+		if args[0].Kind == KindDuration && (name == "abs" || name == "floor" || name == "ceil") {
+			return duration(fn(args[0].Number)), nil
+		}
+		if args[0].Kind != KindNumber {
+			return Value{}, fmt.Errorf("%s takes a number, not a %s", name, args[0].Kind)
+		}
+		return number(fn(args[0].Number)), nil
+	}
+
+	if unit, ok := durationConversions[name]; ok {
+		if len(args) != 1 || args[0].Kind != KindDuration {
+			return Value{}, fmt.Errorf("%s takes 1 duration", name)
+		}
+		return number(args[0].Number / unit), nil
+	}
+
+	switch name {
+	case "round":
+		if len(args) < 1 || len(args) > 2 || args[0].Kind != KindNumber || (len(args) == 2 && args[1].Kind != KindNumber) {
+			return Value{}, errors.New("round takes a number and optionally a number of digits")
+		}
+		scale := 1.0
+		if len(args) == 2 {
+			scale = math.Pow(10, math.Round(args[1].Number))
+		}
+		return number(math.Round(args[0].Number*scale) / scale), nil
+	case "min", "max", "sum", "avg":
+		return aggregate(name, args)
+	}
+
+	return Value{}, fmt.Errorf("unknown function %q", name)
+}
+
+// aggregate evaluates min, max, sum and avg over numbers or durations.
+func aggregate(name string, args []Value) (Value, error) {
+	if len(args) == 0 {
+		return Value{}, fmt.Errorf("%s takes at least 1 argument", name)
+	}
+	kind := args[0].Kind
+	if kind == KindDate && (name == "sum" || name == "avg") {
+		return Value{}, fmt.Errorf("%s can't be applied to dates", name)
+	}
+
+	result := args[0]
+	for _, arg := range args[1:] {
+		if arg.Kind != kind {
+			return Value{}, fmt.Errorf("the arguments of %s must all be of the same kind", name)
+		}
+		switch name {
+		case "min":
+			if less(arg, result) {
+				result = arg
+			}
+		case "max":
+			if less(result, arg) {
+				result = arg
+			}
+		default:
+			result.Number += arg.Number
+		}
+	}
+	if name == "avg" {
+		result.Number /= float64(len(args))
+	}
+
+	return result, nil
+}
+
+func less(a, b Value) bool {
+	if a.Kind == KindDate {
+		return a.Date.Before(b.Date)
+	}
+	return a.Number < b.Number
+}
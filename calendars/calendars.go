@@ -0,0 +1,325 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package calendars reads the events and availability of users from Google Calendar and
+// Microsoft 365, which users connect through OAuth applications configured by the admin.
+package calendars
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/integrations"
+	"golang.org/x/oauth2"
+)
+
+const (
+	ProviderGoogle    = "google_calendar"
+	ProviderMicrosoft = "microsoft_calendar"
+
+	// maxEvents is the largest number of events returned
+	maxEvents = 100
+)
+
+var (
+	// ErrNotEnabled is returned when the admin hasn't enabled and configured a calendar provider
+	ErrNotEnabled = integrations.ErrNotEnabled
+	// ErrNotConnected is returned when the user hasn't connected a calendar
+	ErrNotConnected = integrations.ErrNotConnected
+)
+
+// Config configures the calendar providers users can connect to
+type Config struct {
+	Google    ProviderConfig `json:"google"`
+	Microsoft ProviderConfig `json:"microsoft"`
+}
+
+// ProviderConfig configures one calendar provider and the OAuth application users connect with
+type ProviderConfig struct {
+	Enabled      bool   `json:"enabled"`
+	ClientID     string `json:"clientID"`
+	ClientSecret string `json:"clientSecret"`
+	// TenantID is the Microsoft Entra tenant of the application, "common" when empty. Unused by
+	// Google.
+	TenantID string `json:"tenantID"`
+}
+
+// Provider returns the configuration of the provider
+func (c Config) Provider(provider string) (ProviderConfig, bool) {
+	switch provider {
+	case ProviderGoogle:
+		return c.Google, true
+	case ProviderMicrosoft:
+		return c.Microsoft, true
+	}
+	return ProviderConfig{}, false
+}
+
+// IsConfigured returns whether the provider is enabled with an OAuth application
+func (c ProviderConfig) IsConfigured() bool {
+	return c.Enabled && strings.TrimSpace(c.ClientID) != "" && strings.TrimSpace(c.ClientSecret) != ""
+}
+
+// ProviderName returns the display name of the provider
+func ProviderName(provider string) string {
+	switch provider {
+	case ProviderGoogle:
+		return "Google Calendar"
+	case ProviderMicrosoft:
+		return "Outlook Calendar"
+	}
+	return provider
+}
+
+// Client reads the calendar of the connected user.
+type Client interface {
+	// Events returns the events of the user's primary calendar overlapping the range, ordered
+	// by start time.
+	Events(ctx context.Context, start, end time.Time) ([]Event, error)
+	// FreeBusy returns the busy times of the user followed by those of the given email
+	// addresses, in the range.
+	FreeBusy(ctx context.Context, emails []string, start, end time.Time) ([]Schedule, error)
+}
+
+// Event is a calendar event. The times of all day events are midnight UTC of their dates.
+type Event struct {
+	Title     string
+	Start     time.Time
+	End       time.Time
+	AllDay    bool
+	Location  string
+	Organizer string
+	Attendees []string
+	// ShowAs is how the event shows in free/busy, such as busy, free or tentative
+	ShowAs string
+	URL    string
+}
+
+// Schedule is the busy times of a person, or why they couldn't be read
+type Schedule struct {
+	Email string
+	Busy  []Interval
+	Error string
+}
+
+// Interval is a time range
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// FreeIntervals returns the gaps in the range between the busy intervals of all schedules
+func FreeIntervals(schedules []Schedule, start, end time.Time) []Interval {
+	var busy []Interval
+	for _, schedule := range schedules {
+		busy = append(busy, schedule.Busy...)
+	}
+	sort.Slice(busy, func(i, j int) bool {
+		return busy[i].Start.Before(busy[j].Start)
+	})
+
+	var free []Interval
+	cursor := start
+	for _, interval := range busy {
+		if interval.Start.After(cursor) {
+			free = append(free, Interval{Start: cursor, End: minTime(interval.Start, end)})
+		}
+		if interval.End.After(cursor) {
+			cursor = interval.End
+		}
+		if !cursor.Before(end) {
+			return free
+		}
+	}
+	if cursor.Before(end) {
+		free = append(free, Interval{Start: cursor, End: end})
+	}
+	return free
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// Service registers the calendar providers as integrations and creates clients reading the
+// calendars users connected.
+type Service struct {
+	getConfig    func() Config
+	integrations *integrations.Service
+
+	// URLs of the providers, replaced in tests
+	googleAuthURL     string
+	googleTokenURL    string
+	googleAPIURL      string
+	microsoftLoginURL string
+	microsoftGraphURL string
+}
+
+// New creates a new service and registers the calendar providers as integrations.
+func New(getConfig func() Config, integrationsService *integrations.Service) *Service {
+	s := &Service{
+		getConfig:         getConfig,
+		integrations:      integrationsService,
+		googleAuthURL:     "https://accounts.google.com/o/oauth2/v2/auth",
+		googleTokenURL:    "https://oauth2.googleapis.com/token",
+		googleAPIURL:      "https://www.googleapis.com",
+		microsoftLoginURL: "https://login.microsoftonline.com",
+		microsoftGraphURL: "https://graph.microsoft.com",
+	}
+	for _, provider := range []string{ProviderGoogle, ProviderMicrosoft} {
+		integrationsService.Register(provider, &calendarProvider{service: s, provider: provider})
+	}
+	return s
+}
+
+// Enabled returns whether users can connect to the provider
+func (s *Service) Enabled(provider string) bool {
+	if s == nil {
+		return false
+	}
+	cfg, ok := s.getConfig().Provider(provider)
+	return ok && cfg.IsConfigured()
+}
+
+// EnabledProviders returns the providers users can connect to
+func (s *Service) EnabledProviders() []string {
+	var providers []string
+	for _, provider := range []string{ProviderGoogle, ProviderMicrosoft} {
+		if s.Enabled(provider) {
+			providers = append(providers, provider)
+		}
+	}
+	return providers
+}
+
+// ConnectURL returns the page users open to connect their calendar on the provider
+func (s *Service) ConnectURL(provider string) string {
+	return s.integrations.ConnectURL(provider)
+}
+
+// Client returns a client reading the calendar the user connected, the first enabled provider
+// they connected to, or ErrNotConnected.
+func (s *Service) Client(ctx context.Context, userID string) (Client, error) {
+	for _, provider := range s.EnabledProviders() {
+		httpClient, connection, err := s.integrations.HTTPClient(ctx, userID, provider)
+		if errors.Is(err, ErrNotConnected) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return s.newClient(provider, httpClient, connection.Username), nil
+	}
+	return nil, ErrNotConnected
+}
+
+func (s *Service) newClient(provider string, httpClient *http.Client, email string) Client {
+	if provider == ProviderMicrosoft {
+		return &microsoftClient{baseURL: s.microsoftGraphURL, httpClient: httpClient, email: email}
+	}
+	return &googleClient{baseURL: s.googleAPIURL, httpClient: httpClient, email: email}
+}
+
+// calendarProvider is the integration of a calendar provider
+type calendarProvider struct {
+	service  *Service
+	provider string
+}
+
+func (p *calendarProvider) Name() string {
+	return ProviderName(p.provider)
+}
+
+func (p *calendarProvider) OAuthConfig() (*oauth2.Config, error) {
+	cfg, ok := p.service.getConfig().Provider(p.provider)
+	if !ok || !cfg.IsConfigured() {
+		return nil, ErrNotEnabled
+	}
+
+	oauthConfig := &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+	}
+	switch p.provider {
+	case ProviderGoogle:
+		oauthConfig.Endpoint = oauth2.Endpoint{
+			// Google only issues refresh tokens for offline access, on consent
+			AuthURL:  p.service.googleAuthURL + "?access_type=offline&prompt=consent",
+			TokenURL: p.service.googleTokenURL,
+		}
+		oauthConfig.Scopes = []string{"https://www.googleapis.com/auth/calendar.readonly"}
+	case ProviderMicrosoft:
+		tenant := strings.TrimSpace(cfg.TenantID)
+		if tenant == "" {
+			tenant = "common"
+		}
+		oauthConfig.Endpoint = oauth2.Endpoint{
+			AuthURL:  p.service.microsoftLoginURL + "/" + tenant + "/oauth2/v2.0/authorize",
+			TokenURL: p.service.microsoftLoginURL + "/" + tenant + "/oauth2/v2.0/token",
+		}
+		oauthConfig.Scopes = []string{"offline_access", "User.Read", "Calendars.Read"}
+	}
+
+	return oauthConfig, nil
+}
+
+func (p *calendarProvider) Account(ctx context.Context, httpClient *http.Client) (integrations.Account, error) {
+	var email string
+	var err error
+	switch p.provider {
+	case ProviderGoogle:
+		email, err = (&googleClient{baseURL: p.service.googleAPIURL, httpClient: httpClient}).account(ctx)
+	case ProviderMicrosoft:
+		email, err = (&microsoftClient{baseURL: p.service.microsoftGraphURL, httpClient: httpClient}).account(ctx)
+	default:
+		return integrations.Account{}, ErrNotEnabled
+	}
+	if err != nil {
+		return integrations.Account{}, err
+	}
+	return integrations.Account{Username: email}, nil
+}
+
+// doJSON sends the request with body encoded as JSON, when set, and decodes the response in out
+func doJSON(ctx context.Context, httpClient *http.Client, method, url string, body any, out any, headers map[string]string) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1000))
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, message)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
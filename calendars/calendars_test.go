@@ -0,0 +1,118 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package calendars
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/integrations"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mmapitest"
+	"github.com/stretchr/testify/require"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2025, 3, 10, hour, minute, 0, 0, time.UTC)
+}
+
+func TestFreeIntervals(t *testing.T) {
+	tests := []struct {
+		name      string
+		schedules []Schedule
+		expected  []Interval
+	}{
+		{
+			name:      "nobody busy",
+			schedules: []Schedule{{Email: "a"}},
+			expected:  []Interval{{Start: at(9, 0), End: at(17, 0)}},
+		},
+		{
+			name: "overlapping meetings of several people",
+			schedules: []Schedule{
+				{Email: "a", Busy: []Interval{{Start: at(10, 0), End: at(11, 0)}, {Start: at(14, 0), End: at(15, 0)}}},
+				{Email: "b", Busy: []Interval{{Start: at(10, 30), End: at(12, 0)}}},
+			},
+			expected: []Interval{
+				{Start: at(9, 0), End: at(10, 0)},
+				{Start: at(12, 0), End: at(14, 0)},
+				{Start: at(15, 0), End: at(17, 0)},
+			},
+		},
+		{
+			name: "busy intervals beyond the range",
+			schedules: []Schedule{
+				{Email: "a", Busy: []Interval{{Start: at(8, 0), End: at(9, 30)}, {Start: at(16, 0), End: at(18, 0)}}},
+			},
+			expected: []Interval{{Start: at(9, 30), End: at(16, 0)}},
+		},
+		{
+			name:      "busy all day",
+			schedules: []Schedule{{Email: "a", Busy: []Interval{{Start: at(0, 0), End: at(23, 0)}}}},
+			expected:  nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, FreeIntervals(test.schedules, at(9, 0), at(17, 0)))
+		})
+	}
+}
+
+func TestService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/google/token":
+			_, _ = w.Write([]byte(`{"access_token":"token-1","refresh_token":"refresh-1","token_type":"Bearer","expires_in":3600}`))
+		case "/calendar/v3/calendars/primary":
+			require.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"id":"alice@example.com"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cfg := Config{
+		Google:    ProviderConfig{Enabled: true, ClientID: "client", ClientSecret: "secret"},
+		Microsoft: ProviderConfig{Enabled: true, ClientID: "client", ClientSecret: "secret", TenantID: "contoso"},
+	}
+	connections := integrations.New(mmapitest.NewMemoryKV(), server.Client(), "https://mm.example.com/plugins/mattermost-ai")
+	service := New(func() Config { return cfg }, connections)
+	service.googleAuthURL = server.URL + "/google/auth"
+	service.googleTokenURL = server.URL + "/google/token"
+	service.googleAPIURL = server.URL
+	require.Equal(t, []string{ProviderGoogle, ProviderMicrosoft}, service.EnabledProviders())
+
+	authURL, err := connections.AuthorizationURL("user1", ProviderMicrosoft)
+	require.NoError(t, err)
+	require.Contains(t, authURL, "https://login.microsoftonline.com/contoso/oauth2/v2.0/authorize?")
+
+	authURL, err = connections.AuthorizationURL("user1", ProviderGoogle)
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	require.Equal(t, "offline", parsed.Query().Get("access_type"))
+	require.Equal(t, "https://mm.example.com/plugins/mattermost-ai/integrations/google_calendar/callback", parsed.Query().Get("redirect_uri"))
+
+	_, err = service.Client(context.Background(), "user1")
+	require.ErrorIs(t, err, ErrNotConnected)
+
+	connection, err := connections.CompleteConnection(context.Background(), "user1", ProviderGoogle, parsed.Query().Get("state"), "code")
+	require.NoError(t, err)
+	require.Equal(t, "alice@example.com", connection.Username)
+
+	client, err := service.Client(context.Background(), "user1")
+	require.NoError(t, err)
+	require.Equal(t, &googleClient{baseURL: server.URL, httpClient: client.(*googleClient).httpClient, email: "alice@example.com"}, client)
+
+	cfg.Google.Enabled = false
+	_, err = service.Client(context.Background(), "user1")
+	require.ErrorIs(t, err, ErrNotConnected)
+}
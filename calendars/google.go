@@ -0,0 +1,180 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package calendars
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// googleClient reads Google Calendar with the Calendar API v3
+type googleClient struct {
+	baseURL    string
+	httpClient *http.Client
+	email      string
+}
+
+type googleTime struct {
+	DateTime string `json:"dateTime"`
+	Date     string `json:"date"`
+}
+
+type googlePerson struct {
+	Email       string `json:"email"`
+	DisplayName string `json:"displayName"`
+	Self        bool   `json:"self"`
+	Response    string `json:"responseStatus"`
+}
+
+type googleEvent struct {
+	Summary      string         `json:"summary"`
+	Status       string         `json:"status"`
+	HTMLLink     string         `json:"htmlLink"`
+	Location     string         `json:"location"`
+	Transparency string         `json:"transparency"`
+	Start        googleTime     `json:"start"`
+	End          googleTime     `json:"end"`
+	Organizer    googlePerson   `json:"organizer"`
+	Attendees    []googlePerson `json:"attendees"`
+}
+
+func (c *googleClient) account(ctx context.Context) (string, error) {
+	var calendar struct {
+		ID string `json:"id"`
+	}
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, c.baseURL+"/calendar/v3/calendars/primary", nil, &calendar, nil); err != nil {
+		return "", fmt.Errorf("failed to get the primary calendar: %w", err)
+	}
+	return calendar.ID, nil
+}
+
+func (c *googleClient) Events(ctx context.Context, start, end time.Time) ([]Event, error) {
+	query := url.Values{}
+	query.Set("timeMin", start.UTC().Format(time.RFC3339))
+	query.Set("timeMax", end.UTC().Format(time.RFC3339))
+	query.Set("singleEvents", "true")
+	query.Set("orderBy", "startTime")
+	query.Set("maxResults", strconv.Itoa(maxEvents))
+
+	var result struct {
+		Items []googleEvent `json:"items"`
+	}
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, c.baseURL+"/calendar/v3/calendars/primary/events?"+query.Encode(), nil, &result, nil); err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	events := make([]Event, 0, len(result.Items))
+	for _, item := range result.Items {
+		if item.Status == "cancelled" {
+			continue
+		}
+		event := Event{
+			Title:     item.Summary,
+			Location:  item.Location,
+			Organizer: googlePersonName(item.Organizer),
+			ShowAs:    "busy",
+			URL:       item.HTMLLink,
+		}
+		var err error
+		if event.Start, event.AllDay, err = parseGoogleTime(item.Start); err != nil {
+			return nil, err
+		}
+		if event.End, _, err = parseGoogleTime(item.End); err != nil {
+			return nil, err
+		}
+		if item.Transparency == "transparent" {
+			event.ShowAs = "free"
+		}
+		for _, attendee := range item.Attendees {
+			if attendee.Self && attendee.Response == "declined" {
+				event.ShowAs = "declined"
+			}
+			if attendee.Self && attendee.Response == "tentative" {
+				event.ShowAs = "tentative"
+			}
+			event.Attendees = append(event.Attendees, googlePersonName(attendee))
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func googlePersonName(person googlePerson) string {
+	if person.DisplayName != "" {
+		return person.DisplayName
+	}
+	return person.Email
+}
+
+func parseGoogleTime(value googleTime) (time.Time, bool, error) {
+	if value.DateTime != "" {
+		parsed, err := time.Parse(time.RFC3339, value.DateTime)
+		return parsed, false, err
+	}
+	parsed, err := time.Parse(time.DateOnly, value.Date)
+	return parsed, true, err
+}
+
+func (c *googleClient) FreeBusy(ctx context.Context, emails []string, start, end time.Time) ([]Schedule, error) {
+	// The user's own calendar is requested as primary
+	ids := append([]string{"primary"}, emails...)
+	type item struct {
+		ID string `json:"id"`
+	}
+	request := struct {
+		TimeMin string `json:"timeMin"`
+		TimeMax string `json:"timeMax"`
+		Items   []item `json:"items"`
+	}{
+		TimeMin: start.UTC().Format(time.RFC3339),
+		TimeMax: end.UTC().Format(time.RFC3339),
+	}
+	for _, id := range ids {
+		request.Items = append(request.Items, item{ID: id})
+	}
+
+	var result struct {
+		Calendars map[string]struct {
+			Busy []struct {
+				Start time.Time `json:"start"`
+				End   time.Time `json:"end"`
+			} `json:"busy"`
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"calendars"`
+	}
+	if err := doJSON(ctx, c.httpClient, http.MethodPost, c.baseURL+"/calendar/v3/freeBusy", request, &result, nil); err != nil {
+		return nil, fmt.Errorf("failed to get free/busy: %w", err)
+	}
+
+	schedules := make([]Schedule, 0, len(ids))
+	for i, id := range ids {
+		schedule := Schedule{Email: id}
+		if i == 0 {
+			schedule.Email = c.email
+		}
+		calendar, ok := result.Calendars[id]
+		if !ok {
+			schedule.Error = "not returned"
+			schedules = append(schedules, schedule)
+			continue
+		}
+		var reasons []string
+		for _, calendarErr := range calendar.Errors {
+			reasons = append(reasons, calendarErr.Reason)
+		}
+		schedule.Error = strings.Join(reasons, ", ")
+		for _, busy := range calendar.Busy {
+			schedule.Busy = append(schedule.Busy, Interval{Start: busy.Start, End: busy.End})
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
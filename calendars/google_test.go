@@ -0,0 +1,76 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package calendars
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoogleClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/calendar/v3/calendars/primary/events":
+			require.Equal(t, "2025-03-10T00:00:00Z", r.URL.Query().Get("timeMin"))
+			require.Equal(t, "true", r.URL.Query().Get("singleEvents"))
+			_, _ = w.Write([]byte(`{"items":[
+				{"summary":"Standup","status":"confirmed","htmlLink":"https://calendar/1","start":{"dateTime":"2025-03-10T09:00:00+01:00"},"end":{"dateTime":"2025-03-10T09:15:00+01:00"},
+					"organizer":{"email":"bob@example.com","displayName":"Bob"},"attendees":[{"email":"alice@example.com","self":true,"responseStatus":"tentative"},{"email":"carol@example.com"}]},
+				{"summary":"Cancelled","status":"cancelled","start":{"dateTime":"2025-03-10T10:00:00Z"},"end":{"dateTime":"2025-03-10T11:00:00Z"}},
+				{"summary":"Holiday","status":"confirmed","transparency":"transparent","start":{"date":"2025-03-11"},"end":{"date":"2025-03-12"}}]}`))
+		case "/calendar/v3/freeBusy":
+			var body struct {
+				Items []struct {
+					ID string `json:"id"`
+				} `json:"items"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Len(t, body.Items, 3)
+			_, _ = w.Write([]byte(`{"calendars":{
+				"primary":{"busy":[{"start":"2025-03-10T09:00:00Z","end":"2025-03-10T10:00:00Z"}]},
+				"bob@example.com":{"busy":[],"errors":[{"domain":"global","reason":"notFound"}]},
+				"carol@example.com":{"busy":[{"start":"2025-03-10T13:00:00Z","end":"2025-03-10T14:00:00Z"}]}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := &googleClient{baseURL: server.URL, httpClient: server.Client(), email: "alice@example.com"}
+	start := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	end := start.Add(48 * time.Hour)
+
+	t.Run("events", func(t *testing.T) {
+		events, err := client.Events(context.Background(), start, end)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.Equal(t, "Standup", events[0].Title)
+		require.True(t, events[0].Start.Equal(start.Add(8*time.Hour)))
+		require.True(t, events[0].End.Equal(start.Add(8*time.Hour+15*time.Minute)))
+		require.Equal(t, "Bob", events[0].Organizer)
+		require.Equal(t, []string{"alice@example.com", "carol@example.com"}, events[0].Attendees)
+		require.Equal(t, "tentative", events[0].ShowAs)
+		require.Equal(t, "https://calendar/1", events[0].URL)
+		require.True(t, events[1].AllDay)
+		require.Equal(t, "free", events[1].ShowAs)
+		require.Equal(t, time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC), events[1].Start)
+	})
+
+	t.Run("free/busy", func(t *testing.T) {
+		schedules, err := client.FreeBusy(context.Background(), []string{"bob@example.com", "carol@example.com"}, start, end)
+		require.NoError(t, err)
+		require.Equal(t, []Schedule{
+			{Email: "alice@example.com", Busy: []Interval{{Start: start.Add(9 * time.Hour), End: start.Add(10 * time.Hour)}}},
+			{Email: "bob@example.com", Error: "notFound"},
+			{Email: "carol@example.com", Busy: []Interval{{Start: start.Add(13 * time.Hour), End: start.Add(14 * time.Hour)}}},
+		}, schedules)
+	})
+}
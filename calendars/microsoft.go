@@ -0,0 +1,184 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package calendars
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// microsoftDateTimeLayout is the layout of the times returned by Microsoft Graph, without zone
+const microsoftDateTimeLayout = "2006-01-02T15:04:05.9999999"
+
+// microsoftUTC asks Microsoft Graph to return times in UTC
+var microsoftUTC = map[string]string{"Prefer": `outlook.timezone="UTC"`}
+
+// microsoftClient reads the Outlook calendar of Microsoft 365 users with Microsoft Graph
+type microsoftClient struct {
+	baseURL    string
+	httpClient *http.Client
+	email      string
+}
+
+type microsoftDateTime struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+type microsoftEmailAddress struct {
+	EmailAddress struct {
+		Name    string `json:"name"`
+		Address string `json:"address"`
+	} `json:"emailAddress"`
+}
+
+func (p microsoftEmailAddress) name() string {
+	if p.EmailAddress.Name != "" {
+		return p.EmailAddress.Name
+	}
+	return p.EmailAddress.Address
+}
+
+type microsoftEvent struct {
+	Subject     string            `json:"subject"`
+	Start       microsoftDateTime `json:"start"`
+	End         microsoftDateTime `json:"end"`
+	IsAllDay    bool              `json:"isAllDay"`
+	IsCancelled bool              `json:"isCancelled"`
+	Location    struct {
+		DisplayName string `json:"displayName"`
+	} `json:"location"`
+	Organizer microsoftEmailAddress   `json:"organizer"`
+	Attendees []microsoftEmailAddress `json:"attendees"`
+	ShowAs    string                  `json:"showAs"`
+	WebLink   string                  `json:"webLink"`
+}
+
+func (c *microsoftClient) account(ctx context.Context) (string, error) {
+	var user struct {
+		Mail              string `json:"mail"`
+		UserPrincipalName string `json:"userPrincipalName"`
+	}
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, c.baseURL+"/v1.0/me?$select=mail,userPrincipalName", nil, &user, nil); err != nil {
+		return "", fmt.Errorf("failed to get the user: %w", err)
+	}
+	if user.Mail != "" {
+		return user.Mail, nil
+	}
+	return user.UserPrincipalName, nil
+}
+
+func parseMicrosoftTime(value microsoftDateTime) (time.Time, error) {
+	return time.Parse(microsoftDateTimeLayout, value.DateTime)
+}
+
+func (c *microsoftClient) Events(ctx context.Context, start, end time.Time) ([]Event, error) {
+	query := url.Values{}
+	query.Set("startDateTime", start.UTC().Format(time.RFC3339))
+	query.Set("endDateTime", end.UTC().Format(time.RFC3339))
+	query.Set("$orderby", "start/dateTime")
+	query.Set("$top", strconv.Itoa(maxEvents))
+	query.Set("$select", "subject,start,end,isAllDay,isCancelled,location,organizer,attendees,showAs,webLink")
+
+	var result struct {
+		Value []microsoftEvent `json:"value"`
+	}
+	if err := doJSON(ctx, c.httpClient, http.MethodGet, c.baseURL+"/v1.0/me/calendarView?"+query.Encode(), nil, &result, microsoftUTC); err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	events := make([]Event, 0, len(result.Value))
+	for _, item := range result.Value {
+		if item.IsCancelled {
+			continue
+		}
+		event := Event{
+			Title:     item.Subject,
+			AllDay:    item.IsAllDay,
+			Location:  item.Location.DisplayName,
+			Organizer: item.Organizer.name(),
+			ShowAs:    item.ShowAs,
+			URL:       item.WebLink,
+		}
+		var err error
+		if event.Start, err = parseMicrosoftTime(item.Start); err != nil {
+			return nil, err
+		}
+		if event.End, err = parseMicrosoftTime(item.End); err != nil {
+			return nil, err
+		}
+		for _, attendee := range item.Attendees {
+			event.Attendees = append(event.Attendees, attendee.name())
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (c *microsoftClient) FreeBusy(ctx context.Context, emails []string, start, end time.Time) ([]Schedule, error) {
+	request := struct {
+		Schedules []string          `json:"schedules"`
+		StartTime microsoftDateTime `json:"startTime"`
+		EndTime   microsoftDateTime `json:"endTime"`
+	}{
+		Schedules: append([]string{c.email}, emails...),
+		StartTime: microsoftDateTime{DateTime: start.UTC().Format(microsoftDateTimeLayout), TimeZone: "UTC"},
+		EndTime:   microsoftDateTime{DateTime: end.UTC().Format(microsoftDateTimeLayout), TimeZone: "UTC"},
+	}
+
+	var result struct {
+		Value []struct {
+			ScheduleID    string `json:"scheduleId"`
+			ScheduleItems []struct {
+				Status string            `json:"status"`
+				Start  microsoftDateTime `json:"start"`
+				End    microsoftDateTime `json:"end"`
+			} `json:"scheduleItems"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"value"`
+	}
+	if err := doJSON(ctx, c.httpClient, http.MethodPost, c.baseURL+"/v1.0/me/calendar/getSchedule", request, &result, microsoftUTC); err != nil {
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	byEmail := make(map[string]Schedule, len(result.Value))
+	for _, value := range result.Value {
+		schedule := Schedule{Email: value.ScheduleID}
+		if value.Error != nil {
+			schedule.Error = value.Error.Message
+		}
+		for _, item := range value.ScheduleItems {
+			if item.Status == "free" {
+				continue
+			}
+			itemStart, err := parseMicrosoftTime(item.Start)
+			if err != nil {
+				return nil, err
+			}
+			itemEnd, err := parseMicrosoftTime(item.End)
+			if err != nil {
+				return nil, err
+			}
+			schedule.Busy = append(schedule.Busy, Interval{Start: itemStart, End: itemEnd})
+		}
+		byEmail[strings.ToLower(value.ScheduleID)] = schedule
+	}
+
+	schedules := make([]Schedule, 0, len(request.Schedules))
+	for _, email := range request.Schedules {
+		schedule, ok := byEmail[strings.ToLower(email)]
+		if !ok {
+			schedule = Schedule{Email: email, Error: "not returned"}
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package calendars
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMicrosoftClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, `outlook.timezone="UTC"`, r.Header.Get("Prefer"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1.0/me/calendarView":
+			require.Equal(t, "2025-03-10T00:00:00Z", r.URL.Query().Get("startDateTime"))
+			_, _ = w.Write([]byte(`{"value":[
+				{"subject":"Planning","start":{"dateTime":"2025-03-10T14:00:00.0000000","timeZone":"UTC"},"end":{"dateTime":"2025-03-10T15:00:00.0000000","timeZone":"UTC"},
+					"isAllDay":false,"isCancelled":false,"location":{"displayName":"Room 1"},"organizer":{"emailAddress":{"name":"Bob","address":"bob@contoso.com"}},
+					"attendees":[{"emailAddress":{"name":"","address":"carol@contoso.com"}}],"showAs":"busy","webLink":"https://outlook/1"},
+				{"subject":"Moved","start":{"dateTime":"2025-03-10T16:00:00.0000000","timeZone":"UTC"},"end":{"dateTime":"2025-03-10T17:00:00.0000000","timeZone":"UTC"},"isCancelled":true}]}`))
+		case "/v1.0/me/calendar/getSchedule":
+			_, _ = w.Write([]byte(`{"value":[
+				{"scheduleId":"Alice@contoso.com","scheduleItems":[
+					{"status":"busy","start":{"dateTime":"2025-03-10T14:00:00.0000000"},"end":{"dateTime":"2025-03-10T15:00:00.0000000"}},
+					{"status":"free","start":{"dateTime":"2025-03-10T15:00:00.0000000"},"end":{"dateTime":"2025-03-10T16:00:00.0000000"}}]},
+				{"scheduleId":"external@example.com","scheduleItems":[],"error":{"message":"Unable to access the schedule"}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := &microsoftClient{baseURL: server.URL, httpClient: server.Client(), email: "alice@contoso.com"}
+	start := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	t.Run("events", func(t *testing.T) {
+		events, err := client.Events(context.Background(), start, end)
+		require.NoError(t, err)
+		require.Equal(t, []Event{{
+			Title:     "Planning",
+			Start:     start.Add(14 * time.Hour),
+			End:       start.Add(15 * time.Hour),
+			Location:  "Room 1",
+			Organizer: "Bob",
+			Attendees: []string{"carol@contoso.com"},
+			ShowAs:    "busy",
+			URL:       "https://outlook/1",
+		}}, events)
+	})
+
+	t.Run("schedules", func(t *testing.T) {
+		schedules, err := client.FreeBusy(context.Background(), []string{"external@example.com", "missing@example.com"}, start, end)
+		require.NoError(t, err)
+		require.Equal(t, []Schedule{
+			{Email: "Alice@contoso.com", Busy: []Interval{{Start: start.Add(14 * time.Hour), End: start.Add(15 * time.Hour)}}},
+			{Email: "external@example.com", Error: "Unable to access the schedule"},
+			{Email: "missing@example.com", Error: "not returned"},
+		}, schedules)
+	})
+}
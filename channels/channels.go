@@ -4,12 +4,15 @@
 package channels
 
 import (
+	"context"
 	"fmt"
 	"slices"
 
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
 	"github.com/mattermost/mattermost-plugin-ai/format"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/mmtools"
 	"github.com/mattermost/mattermost-plugin-ai/prompts"
 	"github.com/mattermost/mattermost/server/public/model"
 )
@@ -19,6 +22,9 @@ type Channels struct {
 	prompts  *llm.Prompts
 	client   mmapi.Client
 	dbClient *mmapi.DBClient
+	policy   exclusions.ChannelPolicy
+	// maxIntervalPosts limits the posts of a time range, see SetMaxIntervalPosts
+	maxIntervalPosts int
 }
 
 func New(
@@ -37,6 +43,7 @@ func New(
 
 // AnalyzeChannel uses MCP tools to analyze channel activity based on user request
 func (c *Channels) AnalyzeChannel(
+	ctx context.Context,
 	context *llm.Context,
 	channelID string,
 	analysisData map[string]any,
@@ -62,6 +69,12 @@ func (c *Channels) AnalyzeChannel(
 		"Analysis": analysisData,
 	}
 
+	// Charts are optional, they render the channel of the context so they need no bound parameters
+	generateChart := context.Tools.GetTool(mmtools.GenerateChartToolName)
+	if generateChart != nil {
+		context.Parameters["Charts"] = true
+	}
+
 	systemPrompt, err := c.prompts.Format(prompts.PromptSummarizeChannelSystem, context)
 	if err != nil {
 		return nil, fmt.Errorf("failed to format system prompt: %w", err)
@@ -83,9 +96,17 @@ func (c *Channels) AnalyzeChannel(
 	}
 	boundGetChannelInfo := getChannelInfo.WithBoundParams(map[string]interface{}{"channel_id": channelID})
 
+	scopedToolList := []llm.Tool{boundReadChannel, boundGetChannelInfo}
+	autoRunTools := []string{"read_channel", "get_channel_info"}
+
+	if generateChart != nil {
+		scopedToolList = append(scopedToolList, *generateChart)
+		autoRunTools = append(autoRunTools, mmtools.GenerateChartToolName)
+	}
+
 	// Create scoped tool store with bound tools
 	scopedTools := llm.NewToolStore(nil, false)
-	scopedTools.AddTools([]llm.Tool{boundReadChannel, boundGetChannelInfo})
+	scopedTools.AddTools(scopedToolList)
 	context.Tools = scopedTools
 
 	completionRequest := llm.CompletionRequest{
@@ -103,8 +124,8 @@ func (c *Channels) AnalyzeChannel(
 	}
 
 	// Auto-run the bound tools
-	resultStream, err := c.llm.ChatCompletion(completionRequest,
-		llm.WithAutoRunTools([]string{"read_channel", "get_channel_info"}),
+	resultStream, err := c.llm.ChatCompletion(ctx, completionRequest,
+		llm.WithAutoRunTools(autoRunTools),
 		llm.WithReasoningDisabled())
 	if err != nil {
 		return nil, err
@@ -114,11 +135,13 @@ func (c *Channels) AnalyzeChannel(
 }
 
 func (c *Channels) Interval(
+	ctx context.Context,
 	context *llm.Context,
 	channelID string,
 	startTime int64,
 	endTime int64,
 	promptName string,
+	opts IntervalOptions,
 ) (*llm.TextStreamResult, error) {
 	var posts *model.PostList
 	var err error
@@ -131,17 +154,38 @@ func (c *Channels) Interval(
 		return nil, err
 	}
 
+	if opts.ExpandThreads {
+		if err = c.expandThreads(posts); err != nil {
+			return nil, err
+		}
+	}
+
 	threadData, err := mmapi.GetMetadataForPosts(c.client, posts)
 	if err != nil {
 		return nil, err
 	}
 
-	// Remove deleted posts and system posts (like join/leave messages)
+	// Remove deleted posts, system posts (like join/leave messages) and the posts the channel
+	// policy excludes
 	threadData.Posts = slices.DeleteFunc(threadData.Posts, func(post *model.Post) bool {
-		return post.DeleteAt != 0 || post.Type != ""
+		return post.DeleteAt != 0 || post.Type != "" || c.policy.ExcludesPost(post, threadData.UsersByID[post.UserId])
 	})
 
 	formattedThread := format.ThreadData(threadData)
+	if opts.threaded() {
+		var reactions map[string][]mmapi.ReactionCount
+		if opts.IncludeReactions {
+			postIDs := make([]string, 0, len(threadData.Posts))
+			for _, post := range threadData.Posts {
+				postIDs = append(postIDs, post.Id)
+			}
+			reactions, err = c.dbClient.GetReactionCounts(postIDs)
+			if err != nil {
+				return nil, err
+			}
+		}
+		formattedThread = formatThreads(threadData, reactions, opts.MarkAnswered)
+	}
 
 	context.Parameters = map[string]any{
 		"Thread": formattedThread,
@@ -170,7 +214,7 @@ func (c *Channels) Interval(
 		Context: context,
 	}
 
-	resultStream, err := c.llm.ChatCompletion(completionRequest, llm.WithToolsDisabled())
+	resultStream, err := c.llm.ChatCompletion(ctx, completionRequest, llm.WithToolsDisabled())
 	if err != nil {
 		return nil, err
 	}
@@ -179,57 +223,28 @@ func (c *Channels) Interval(
 }
 
 const (
-	postsPerPage = 60
-	maxPosts     = 200
+	// DefaultMaxIntervalPosts is the number of posts summarized for a time range when not configured
+	DefaultMaxIntervalPosts = 200
+	// MaxIntervalPosts is the largest number of posts summarized for a time range
+	MaxIntervalPosts = 2000
 )
 
-func (c *Channels) getPostsByChannelBetween(channelID string, startTime, endTime int64) (*model.PostList, error) {
-	// Find the ID of first post in our time range
-	firstPostID, err := c.dbClient.GetFirstPostBeforeTimeRangeID(channelID, startTime, endTime)
-	if err != nil {
-		return nil, err
-	}
-
-	// Initialize result list
-	result := &model.PostList{
-		Posts: make(map[string]*model.Post),
-		Order: []string{},
-	}
-
-	// Keep fetching previous pages until we either:
-	// 1. Reach the endTime
-	// 2. Hit the maxPosts limit
-	// 3. Run out of posts
-	totalPosts := 0
-	page := 0
-
-	for totalPosts < maxPosts {
-		morePosts, err := c.client.GetPostsBefore(channelID, firstPostID, page, postsPerPage)
-		if err != nil {
-			return nil, err
-		}
-
-		if len(morePosts.Posts) == 0 {
-			break // No more posts
-		}
+// SetChannelPolicy sets the policy of the summarized channel, leaving out the posts it excludes
+func (c *Channels) SetChannelPolicy(policy exclusions.ChannelPolicy) {
+	c.policy = policy
+}
 
-		// Add posts that fall within our time range
-		for _, post := range morePosts.Posts {
-			if post.CreateAt >= startTime && post.CreateAt <= endTime {
-				result.Posts[post.Id] = post
-				result.Order = append([]string{post.Id}, result.Order...)
-				totalPosts++
-				if totalPosts >= maxPosts {
-					break
-				}
-			}
-			if post.CreateAt < startTime {
-				break // We've gone too far back
-			}
-		}
+// SetMaxIntervalPosts sets the number of posts summarized for a time range, the oldest first.
+func (c *Channels) SetMaxIntervalPosts(maxPosts int) {
+	c.maxIntervalPosts = maxPosts
+}
 
-		page++
+func (c *Channels) getPostsByChannelBetween(channelID string, startTime, endTime int64) (*model.PostList, error) {
+	maxPosts := c.maxIntervalPosts
+	if maxPosts <= 0 {
+		maxPosts = DefaultMaxIntervalPosts
 	}
+	maxPosts = min(maxPosts, MaxIntervalPosts)
 
-	return result, nil
+	return c.dbClient.GetPostsInTimeRange(channelID, startTime, endTime, maxPosts)
 }
@@ -0,0 +1,129 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package channels
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// MaxRepliesPerThread is the number of replies added to each thread when expanding threads
+	MaxRepliesPerThread = 20
+	// maxExpandedThreads is the number of threads expanded, the oldest first
+	maxExpandedThreads = 100
+)
+
+// IntervalOptions chooses the context given to the model with the posts of a time range
+type IntervalOptions struct {
+	// ExpandThreads adds the replies to the threads started in the range, including the replies
+	// posted after it, up to MaxRepliesPerThread per thread
+	ExpandThreads bool
+
+	// IncludeReactions adds the reactions to each post
+	IncludeReactions bool
+
+	// MarkAnswered marks the threads replied to by someone other than the author of the root post
+	MarkAnswered bool
+}
+
+func (o IntervalOptions) threaded() bool {
+	return o.ExpandThreads || o.IncludeReactions || o.MarkAnswered
+}
+
+// expandThreads adds the first replies of the threads started in posts
+func (c *Channels) expandThreads(posts *model.PostList) error {
+	var roots []*model.Post
+	for _, post := range posts.Posts {
+		if post.RootId == "" {
+			roots = append(roots, post)
+		}
+	}
+	slices.SortFunc(roots, func(a, b *model.Post) int {
+		return cmp.Compare(a.CreateAt, b.CreateAt)
+	})
+	if len(roots) > maxExpandedThreads {
+		roots = roots[:maxExpandedThreads]
+	}
+
+	rootIDs := make([]string, 0, len(roots))
+	for _, root := range roots {
+		rootIDs = append(rootIDs, root.Id)
+	}
+
+	replies, err := c.dbClient.GetThreadReplies(rootIDs, MaxRepliesPerThread)
+	if err != nil {
+		return err
+	}
+	for _, reply := range replies {
+		if posts.Posts[reply.Id] == nil {
+			posts.AddPost(reply)
+			posts.AddOrder(reply.Id)
+		}
+	}
+
+	return nil
+}
+
+// formatThreads formats the posts grouped by thread, with the replies indented under their root
+// post. Replies to threads started before the posts are listed on their own.
+func formatThreads(threadData *mmapi.ThreadData, reactions map[string][]mmapi.ReactionCount, markAnswered bool) string {
+	repliesByRoot := make(map[string][]*model.Post)
+	postIDs := make(map[string]bool, len(threadData.Posts))
+	for _, post := range threadData.Posts {
+		postIDs[post.Id] = true
+	}
+	for _, post := range threadData.Posts {
+		if post.RootId != "" && postIDs[post.RootId] {
+			repliesByRoot[post.RootId] = append(repliesByRoot[post.RootId], post)
+		}
+	}
+
+	var result strings.Builder
+	for _, post := range threadData.Posts {
+		if post.RootId != "" && postIDs[post.RootId] {
+			continue
+		}
+
+		writePost(&result, threadData, post, reactions, "")
+		if post.RootId != "" {
+			result.WriteString("[Reply to a thread started earlier]\n")
+		}
+
+		replies := repliesByRoot[post.Id]
+		if markAnswered && slices.ContainsFunc(replies, func(reply *model.Post) bool { return reply.UserId != post.UserId }) {
+			result.WriteString("[Thread answered: other participants replied]\n")
+		}
+		for _, reply := range replies {
+			writePost(&result, threadData, reply, reactions, "    ")
+		}
+		result.WriteString("\n")
+	}
+
+	return result.String()
+}
+
+func writePost(result *strings.Builder, threadData *mmapi.ThreadData, post *model.Post, reactions map[string][]mmapi.ReactionCount, indent string) {
+	username := post.UserId
+	if user := threadData.UsersByID[post.UserId]; user != nil {
+		username = user.Username
+	}
+
+	body := strings.ReplaceAll(format.PostBody(post), "\n", "\n"+indent)
+	result.WriteString(fmt.Sprintf("%s%s: %s\n", indent, username, body))
+
+	if counts := reactions[post.Id]; len(counts) > 0 {
+		formatted := make([]string, 0, len(counts))
+		for _, count := range counts {
+			formatted = append(formatted, fmt.Sprintf(":%s: %d", count.EmojiName, count.Count))
+		}
+		result.WriteString(fmt.Sprintf("%s[Reactions: %s]\n", indent, strings.Join(formatted, ", ")))
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package channels
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatThreads(t *testing.T) {
+	threadData := &mmapi.ThreadData{
+		Posts: []*model.Post{
+			{Id: "question", UserId: "alice", Message: "How do I deploy?", CreateAt: 1},
+			{Id: "earlier", UserId: "bob", RootId: "old", Message: "Still broken.", CreateAt: 2},
+			{Id: "selfreply", UserId: "carol", Message: "Is the VPN down?", CreateAt: 3},
+			{Id: "answer", UserId: "bob", RootId: "question", Message: "Run make deploy.\nThen check the logs.", CreateAt: 4},
+			{Id: "bump", UserId: "carol", RootId: "selfreply", Message: "Anyone?", CreateAt: 5},
+		},
+		UsersByID: map[string]*model.User{
+			"alice": {Username: "alice"},
+			"bob":   {Username: "bob"},
+			"carol": {Username: "carol"},
+		},
+	}
+	reactions := map[string][]mmapi.ReactionCount{
+		"answer": {{PostID: "answer", EmojiName: "+1", Count: 3}, {PostID: "answer", EmojiName: "tada", Count: 1}},
+	}
+
+	tests := []struct {
+		name         string
+		reactions    map[string][]mmapi.ReactionCount
+		markAnswered bool
+		expected     string
+	}{
+		{
+			name:         "replies, reactions and answered threads",
+			reactions:    reactions,
+			markAnswered: true,
+			expected: "alice: How do I deploy?\n" +
+				"[Thread answered: other participants replied]\n" +
+				"    bob: Run make deploy.\n    Then check the logs.\n" +
+				"    [Reactions: :+1: 3, :tada: 1]\n\n" +
+				"bob: Still broken.\n" +
+				"[Reply to a thread started earlier]\n\n" +
+				"carol: Is the VPN down?\n" +
+				"    carol: Anyone?\n\n",
+		},
+		{
+			name: "replies only",
+			expected: "alice: How do I deploy?\n" +
+				"    bob: Run make deploy.\n    Then check the logs.\n\n" +
+				"bob: Still broken.\n" +
+				"[Reply to a thread started earlier]\n\n" +
+				"carol: Is the VPN down?\n" +
+				"    carol: Anyone?\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, formatThreads(threadData, test.reactions, test.markAnswered))
+		})
+	}
+}
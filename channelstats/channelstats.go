@@ -0,0 +1,154 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package channelstats aggregates the activity of a channel over a time range, so analysis
+// prompts can include quantitative context without pulling every post.
+package channelstats
+
+import (
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+)
+
+// Query selects the posts of a channel included in the stats.
+type Query struct {
+	ChannelID string
+	Since     int64
+	Until     int64
+	// Limit is the number of entries of the top posters, busiest threads and reaction leaders
+	Limit int
+}
+
+// DailyCount is the number of posts for a single UTC day.
+type DailyCount struct {
+	Day   int64 `json:"day" db:"day"`
+	Posts int64 `json:"posts" db:"posts"`
+}
+
+// Poster is the number of posts of a user.
+type Poster struct {
+	UserID string `json:"user_id" db:"userid"`
+	Posts  int64  `json:"posts" db:"posts"`
+}
+
+// Thread is the number of replies to a root post.
+type Thread struct {
+	RootID  string `json:"root_id" db:"rootid"`
+	Replies int64  `json:"replies" db:"replies"`
+}
+
+// ReactionLeader is the number of reactions received by the posts of a user.
+type ReactionLeader struct {
+	UserID    string `json:"user_id" db:"userid"`
+	Reactions int64  `json:"reactions" db:"reactions"`
+}
+
+// Stats is the activity of a channel over a time range.
+type Stats struct {
+	Since           int64            `json:"since"`
+	Until           int64            `json:"until"`
+	Posts           int64            `json:"posts" db:"posts"`
+	Participants    int64            `json:"participants" db:"participants"`
+	ByDay           []DailyCount     `json:"by_day"`
+	TopPosters      []Poster         `json:"top_posters"`
+	BusiestThreads  []Thread         `json:"busiest_threads"`
+	ReactionLeaders []ReactionLeader `json:"reaction_leaders"`
+}
+
+// Service computes channel stats from the database.
+type Service struct {
+	db *mmapi.DBClient
+}
+
+// New creates a new channel stats service.
+func New(db *mmapi.DBClient) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+const dayMillis = int64(24 * time.Hour / time.Millisecond)
+
+// selectPosts selects the user posts of the channel created in the range, system messages and
+// deleted posts are left out.
+func (s *Service) selectPosts(query Query, columns ...string) sq.SelectBuilder {
+	return s.db.Builder().
+		Select(columns...).
+		From("Posts").
+		Where(sq.Eq{"ChannelId": query.ChannelID}).
+		Where(sq.Eq{"DeleteAt": 0}).
+		Where(sq.Eq{"Type": ""}).
+		Where(sq.GtOrEq{"CreateAt": query.Since}).
+		Where(sq.Lt{"CreateAt": query.Until})
+}
+
+// GetChannelStats aggregates the posts and reactions of the channel matching query. The caller
+// is responsible for checking the user can read the channel.
+func (s *Service) GetChannelStats(query Query) (*Stats, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("channel stats not available")
+	}
+
+	stats := &Stats{
+		Since:           query.Since,
+		Until:           query.Until,
+		ByDay:           []DailyCount{},
+		TopPosters:      []Poster{},
+		BusiestThreads:  []Thread{},
+		ReactionLeaders: []ReactionLeader{},
+	}
+
+	var totals []Stats
+	if err := s.db.DoQuery(&totals, s.selectPosts(query, "COUNT(*) AS posts", "COUNT(DISTINCT UserId) AS participants")); err != nil {
+		return nil, fmt.Errorf("failed to get channel totals: %w", err)
+	}
+	if len(totals) > 0 {
+		stats.Posts = totals[0].Posts
+		stats.Participants = totals[0].Participants
+	}
+	if stats.Posts == 0 {
+		return stats, nil
+	}
+
+	dayColumn := fmt.Sprintf("%s * %d AS day", s.db.IntegerDivision("CreateAt", dayMillis), dayMillis)
+	if err := s.db.DoQuery(&stats.ByDay, s.selectPosts(query, dayColumn, "COUNT(*) AS posts").
+		GroupBy("day").
+		OrderBy("day ASC")); err != nil {
+		return nil, fmt.Errorf("failed to get posts by day: %w", err)
+	}
+
+	if err := s.db.DoQuery(&stats.TopPosters, s.selectPosts(query, "UserId AS userid", "COUNT(*) AS posts").
+		GroupBy("UserId").
+		OrderBy("posts DESC", "userid ASC").
+		Limit(uint64(query.Limit))); err != nil {
+		return nil, fmt.Errorf("failed to get top posters: %w", err)
+	}
+
+	if err := s.db.DoQuery(&stats.BusiestThreads, s.selectPosts(query, "RootId AS rootid", "COUNT(*) AS replies").
+		Where(sq.NotEq{"RootId": ""}).
+		GroupBy("RootId").
+		OrderBy("replies DESC", "rootid ASC").
+		Limit(uint64(query.Limit))); err != nil {
+		return nil, fmt.Errorf("failed to get busiest threads: %w", err)
+	}
+
+	if err := s.db.DoQuery(&stats.ReactionLeaders, s.db.Builder().
+		Select("p.UserId AS userid", "COUNT(*) AS reactions").
+		From("Reactions r").
+		Join("Posts p ON p.Id = r.PostId").
+		Where(sq.Eq{"p.ChannelId": query.ChannelID}).
+		Where(sq.Eq{"p.DeleteAt": 0}).
+		Where(sq.Eq{"r.DeleteAt": 0}).
+		Where(sq.GtOrEq{"r.CreateAt": query.Since}).
+		Where(sq.Lt{"r.CreateAt": query.Until}).
+		GroupBy("p.UserId").
+		OrderBy("reactions DESC", "userid ASC").
+		Limit(uint64(query.Limit))); err != nil {
+		return nil, fmt.Errorf("failed to get reaction leaders: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package charts renders simple bar and line charts to PNG images on the server so they can be
+// attached to posts.
+package charts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+)
+
+const (
+	// MaxPoints is the largest number of values a chart can show
+	MaxPoints = 60
+
+	width        = 800
+	height       = 450
+	marginLeft   = 70
+	marginRight  = 30
+	marginTop    = 60
+	marginBottom = 50
+	gridLines    = 4
+	titleScale   = 2
+	labelScale   = 1
+)
+
+// Type is the kind of chart to render
+type Type string
+
+const (
+	TypeBar  Type = "bar"
+	TypeLine Type = "line"
+)
+
+var (
+	backgroundColor = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	textColor       = color.RGBA{R: 63, G: 67, B: 80, A: 255}
+	gridColor       = color.RGBA{R: 221, G: 223, B: 228, A: 255}
+	seriesColor     = color.RGBA{R: 28, G: 88, B: 217, A: 255}
+)
+
+// Chart is a single series of values with a label for each value
+type Chart struct {
+	Type   Type
+	Title  string
+	Labels []string
+	Values []float64
+}
+
+// Validate returns an error if the chart can't be rendered.
+func (c Chart) Validate() error {
+	if c.Type != TypeBar && c.Type != TypeLine {
+		return fmt.Errorf("unsupported chart type %q", c.Type)
+	}
+	if len(c.Values) == 0 {
+		return errors.New("chart has no values")
+	}
+	if len(c.Values) > MaxPoints {
+		return fmt.Errorf("chart has %d values, the maximum is %d", len(c.Values), MaxPoints)
+	}
+	if len(c.Labels) != len(c.Values) {
+		return fmt.Errorf("chart has %d labels for %d values", len(c.Labels), len(c.Values))
+	}
+	for _, value := range c.Values {
+		if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+			return fmt.Errorf("chart values must not be negative, got %v", value)
+		}
+	}
+	return nil
+}
+
+// Render draws the chart and returns it encoded as PNG.
+func Render(c Chart) ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: backgroundColor}, image.Point{}, draw.Src)
+
+	plot := image.Rect(marginLeft, marginTop, width-marginRight, height-marginBottom)
+	title := truncateText(c.Title, width-20, titleScale)
+	drawText(img, (width-textWidth(title, titleScale))/2, (marginTop-glyphHeight*titleScale)/2, title, titleScale, textColor)
+
+	maxValue := niceMax(c.Values)
+	for i := 0; i <= gridLines; i++ {
+		value := maxValue * float64(i) / gridLines
+		y := plot.Max.Y - int(math.Round(float64(plot.Dy())*float64(i)/gridLines))
+		fillRect(img, image.Rect(plot.Min.X, y, plot.Max.X, y+1), gridColor)
+		label := formatValue(value)
+		drawText(img, plot.Min.X-8-textWidth(label, labelScale), y-glyphHeight/2, label, labelScale, textColor)
+	}
+
+	slot := float64(plot.Dx()) / float64(len(c.Values))
+	valueY := func(value float64) int {
+		return plot.Max.Y - int(math.Round(float64(plot.Dy())*value/maxValue))
+	}
+	slotCenter := func(i int) int {
+		return plot.Min.X + int(math.Round(slot*(float64(i)+0.5)))
+	}
+
+	switch c.Type {
+	case TypeBar:
+		barWidth := max(int(slot*0.7), 1)
+		for i, value := range c.Values {
+			x := slotCenter(i) - barWidth/2
+			fillRect(img, image.Rect(x, valueY(value), x+barWidth, plot.Max.Y), seriesColor)
+		}
+	case TypeLine:
+		for i := range c.Values {
+			if i > 0 {
+				drawLine(img, slotCenter(i-1), valueY(c.Values[i-1]), slotCenter(i), valueY(c.Values[i]), seriesColor)
+			}
+			x, y := slotCenter(i), valueY(c.Values[i])
+			fillRect(img, image.Rect(x-3, y-3, x+4, y+4), seriesColor)
+		}
+	}
+
+	drawLabels(img, plot, slot, c.Labels, slotCenter)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawLabels draws the labels under the plot, skipping labels when they would overlap.
+func drawLabels(img *image.RGBA, plot image.Rectangle, slot float64, labels []string, slotCenter func(int) int) {
+	widest := 0
+	for _, label := range labels {
+		widest = max(widest, textWidth(label, labelScale))
+	}
+
+	// Long labels are truncated rather than spreading the labels too far apart
+	maxLabelWidth := textWidth("0000-00-00", labelScale)
+	step := max(int(math.Ceil(float64(min(widest, maxLabelWidth)+8)/slot)), 1)
+
+	for i := 0; i < len(labels); i += step {
+		label := truncateText(labels[i], int(float64(step)*slot)-8, labelScale)
+		drawText(img, slotCenter(i)-textWidth(label, labelScale)/2, plot.Max.Y+10, label, labelScale, textColor)
+	}
+}
+
+// niceMax returns a round number at least as large as the largest value, so the grid lines
+// are labeled with round numbers.
+func niceMax(values []float64) float64 {
+	largest := 0.0
+	for _, value := range values {
+		largest = max(largest, value)
+	}
+	if largest == 0 {
+		return gridLines
+	}
+
+	magnitude := math.Pow(10, math.Floor(math.Log10(largest/gridLines)))
+	for _, factor := range []float64{1, 2, 2.5, 5, 10} {
+		if step := factor * magnitude; step*gridLines >= largest {
+			return step * gridLines
+		}
+	}
+	return 10 * magnitude * gridLines
+}
+
+// formatValue formats the value of a grid line compactly, for example 25000 as 25K.
+func formatValue(value float64) string {
+	switch {
+	case value >= 1e6:
+		return strconv.FormatFloat(value/1e6, 'f', -1, 64) + "M"
+	case value >= 1e4:
+		return strconv.FormatFloat(value/1e3, 'f', -1, 64) + "K"
+	default:
+		return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+	}
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r.Intersect(img.Bounds()), &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+// drawLine draws a line two pixels thick between the points.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	steps := max(abs(x1-x0), abs(y1-y0))
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		x := x0 + int(math.Round(t*float64(x1-x0)))
+		y := y0 + int(math.Round(t*float64(y1-y0)))
+		fillRect(img, image.Rect(x-1, y-1, x+1, y+1), c)
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
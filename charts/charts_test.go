@@ -0,0 +1,94 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package charts
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name        string
+		chart       Chart
+		expectError string
+	}{
+		{
+			name:  "bar chart",
+			chart: Chart{Type: TypeBar, Title: "Bugs by team", Labels: []string{"Platform", "Mobile"}, Values: []float64{12, 7.5}},
+		},
+		{
+			name:  "line chart with zero values",
+			chart: Chart{Type: TypeLine, Title: "Messages per day", Labels: []string{"Oct 1", "Oct 2", "Oct 3"}, Values: []float64{0, 0, 0}},
+		},
+		{
+			name:        "unsupported type",
+			chart:       Chart{Type: "pie", Labels: []string{"a"}, Values: []float64{1}},
+			expectError: "unsupported chart type",
+		},
+		{
+			name:        "no values",
+			chart:       Chart{Type: TypeBar},
+			expectError: "no values",
+		},
+		{
+			name:        "labels do not match values",
+			chart:       Chart{Type: TypeBar, Labels: []string{"a"}, Values: []float64{1, 2}},
+			expectError: "1 labels for 2 values",
+		},
+		{
+			name:        "negative value",
+			chart:       Chart{Type: TypeLine, Labels: []string{"a"}, Values: []float64{-1}},
+			expectError: "must not be negative",
+		},
+		{
+			name:        "not a number",
+			chart:       Chart{Type: TypeLine, Labels: []string{"a"}, Values: []float64{math.NaN()}},
+			expectError: "must not be negative",
+		},
+		{
+			name:        "too many values",
+			chart:       Chart{Type: TypeBar, Labels: make([]string, MaxPoints+1), Values: make([]float64, MaxPoints+1)},
+			expectError: "the maximum is",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := Render(test.chart)
+			if test.expectError != "" {
+				require.ErrorContains(t, err, test.expectError)
+				return
+			}
+			require.NoError(t, err)
+
+			img, err := png.Decode(bytes.NewReader(data))
+			require.NoError(t, err)
+			require.Equal(t, width, img.Bounds().Dx())
+			require.Equal(t, height, img.Bounds().Dy())
+		})
+	}
+}
+
+func TestNiceMax(t *testing.T) {
+	tests := []struct {
+		values   []float64
+		expected float64
+	}{
+		{values: []float64{0}, expected: 4},
+		{values: []float64{3}, expected: 4},
+		{values: []float64{19}, expected: 20},
+		{values: []float64{37, 52}, expected: 80},
+		{values: []float64{0.3}, expected: 0.4},
+		{values: []float64{1234}, expected: 2000},
+	}
+
+	for _, test := range tests {
+		require.InDelta(t, test.expected, niceMax(test.values), 1e-9, "values %v", test.values)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package charts
+
+import (
+	"image"
+	"image/color"
+	"unicode"
+)
+
+const (
+	glyphWidth   = 5
+	glyphHeight  = 7
+	glyphSpacing = 1
+)
+
+// glyphs is a 5x7 bitmap font. Letters are drawn uppercase and characters without a glyph are
+// drawn as '?' so labels never need a font file on the server.
+var glyphs = map[rune][glyphHeight]string{
+	'A':  {"01110", "10001", "10001", "11111", "10001", "10001", "10001"},
+	'B':  {"11110", "10001", "10001", "11110", "10001", "10001", "11110"},
+	'C':  {"01110", "10001", "10000", "10000", "10000", "10001", "01110"},
+	'D':  {"11110", "10001", "10001", "10001", "10001", "10001", "11110"},
+	'E':  {"11111", "10000", "10000", "11110", "10000", "10000", "11111"},
+	'F':  {"11111", "10000", "10000", "11110", "10000", "10000", "10000"},
+	'G':  {"01110", "10001", "10000", "10111", "10001", "10001", "01111"},
+	'H':  {"10001", "10001", "10001", "11111", "10001", "10001", "10001"},
+	'I':  {"01110", "00100", "00100", "00100", "00100", "00100", "01110"},
+	'J':  {"00111", "00010", "00010", "00010", "00010", "10010", "01100"},
+	'K':  {"10001", "10010", "10100", "11000", "10100", "10010", "10001"},
+	'L':  {"10000", "10000", "10000", "10000", "10000", "10000", "11111"},
+	'M':  {"10001", "11011", "10101", "10101", "10001", "10001", "10001"},
+	'N':  {"10001", "10001", "11001", "10101", "10011", "10001", "10001"},
+	'O':  {"01110", "10001", "10001", "10001", "10001", "10001", "01110"},
+	'P':  {"11110", "10001", "10001", "11110", "10000", "10000", "10000"},
+	'Q':  {"01110", "10001", "10001", "10001", "10101", "10010", "01101"},
+	'R':  {"11110", "10001", "10001", "11110", "10100", "10010", "10001"},
+	'S':  {"01111", "10000", "10000", "01110", "00001", "00001", "11110"},
+	'T':  {"11111", "00100", "00100", "00100", "00100", "00100", "00100"},
+	'U':  {"10001", "10001", "10001", "10001", "10001", "10001", "01110"},
+	'V':  {"10001", "10001", "10001", "10001", "10001", "01010", "00100"},
+	'W':  {"10001", "10001", "10001", "10101", "10101", "10101", "01010"},
+	'X':  {"10001", "10001", "01010", "00100", "01010", "10001", "10001"},
+	'Y':  {"10001", "10001", "10001", "01010", "00100", "00100", "00100"},
+	'Z':  {"11111", "00001", "00010", "00100", "01000", "10000", "11111"},
+	'0':  {"01110", "10001", "10011", "10101", "11001", "10001", "01110"},
+	'1':  {"00100", "01100", "00100", "00100", "00100", "00100", "01110"},
+	'2':  {"01110", "10001", "00001", "00010", "00100", "01000", "11111"},
+	'3':  {"11111", "00010", "00100", "00010", "00001", "10001", "01110"},
+	'4':  {"00010", "00110", "01010", "10010", "11111", "00010", "00010"},
+	'5':  {"11111", "10000", "11110", "00001", "00001", "10001", "01110"},
+	'6':  {"00110", "01000", "10000", "11110", "10001", "10001", "01110"},
+	'7':  {"11111", "00001", "00010", "00100", "01000", "01000", "01000"},
+	'8':  {"01110", "10001", "10001", "01110", "10001", "10001", "01110"},
+	'9':  {"01110", "10001", "10001", "01111", "00001", "00010", "01100"},
+	' ':  {"00000", "00000", "00000", "00000", "00000", "00000", "00000"},
+	'.':  {"00000", "00000", "00000", "00000", "00000", "01100", "01100"},
+	',':  {"00000", "00000", "00000", "00000", "01100", "00100", "01000"},
+	':':  {"00000", "01100", "01100", "00000", "01100", "01100", "00000"},
+	'-':  {"00000", "00000", "00000", "11111", "00000", "00000", "00000"},
+	'+':  {"00000", "00100", "00100", "11111", "00100", "00100", "00000"},
+	'_':  {"00000", "00000", "00000", "00000", "00000", "00000", "11111"},
+	'/':  {"00000", "00001", "00010", "00100", "01000", "10000", "00000"},
+	'%':  {"11000", "11001", "00010", "00100", "01000", "10011", "00011"},
+	'(':  {"00010", "00100", "01000", "01000", "01000", "00100", "00010"},
+	')':  {"01000", "00100", "00010", "00010", "00010", "00100", "01000"},
+	'#':  {"01010", "01010", "11111", "01010", "11111", "01010", "01010"},
+	'&':  {"01100", "10010", "10100", "01000", "10101", "10010", "01101"},
+	'\'': {"01100", "00100", "01000", "00000", "00000", "00000", "00000"},
+	'!':  {"00100", "00100", "00100", "00100", "00100", "00000", "00100"},
+	'?':  {"01110", "10001", "00001", "00010", "00100", "00000", "00100"},
+}
+
+// textWidth returns the width in pixels of the text drawn at the scale.
+func textWidth(text string, scale int) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return (n*(glyphWidth+glyphSpacing) - glyphSpacing) * scale
+}
+
+// drawText draws the text with its top left corner at (x, y).
+func drawText(img *image.RGBA, x, y int, text string, scale int, c color.Color) {
+	for _, r := range text {
+		glyph, ok := glyphs[unicode.ToUpper(r)]
+		if !ok {
+			glyph = glyphs['?']
+		}
+		for row, bits := range glyph {
+			for col, bit := range bits {
+				if bit != '1' {
+					continue
+				}
+				fillRect(img, image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale), c)
+			}
+		}
+		x += (glyphWidth + glyphSpacing) * scale
+	}
+}
+
+// truncateText shortens the text to fit in width pixels at the scale.
+func truncateText(text string, width, scale int) string {
+	runes := []rune(text)
+	for len(runes) > 0 && textWidth(string(runes), scale) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package citations renders the citations of completed responses into their text as numbered
+// footnotes, so the sources of an answer are shown by every client.
+package citations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+var titleEscaper = strings.NewReplacer("[", "\\[", "]", "\\]", "\r", " ", "\n", " ")
+
+// edit replaces the runes of the message from start to end with the markers of sources
+type edit struct {
+	start, end int
+	sources    []int
+}
+
+// Footnotes returns the message with a marker such as [1] after each cited passage and a list of
+// the cited sources under heading. Sources cited more than once share a number, numbers follow the
+// order in which sources are first cited. Annotation indexes are rune offsets in the message.
+// Passages that are themselves links to their source, as in the inline citations of OpenAI, are
+// replaced by the marker. The message is returned unchanged without URL citations.
+func Footnotes(message string, annotations []llm.Annotation, heading string) string {
+	runes := []rune(message)
+
+	var cited []llm.Annotation
+	for _, annotation := range annotations {
+		if annotation.Type != llm.AnnotationTypeURLCitation || annotation.URL == "" {
+			continue
+		}
+		annotation.StartIndex = max(0, min(annotation.StartIndex, len(runes)))
+		annotation.EndIndex = max(annotation.StartIndex, min(annotation.EndIndex, len(runes)))
+		cited = append(cited, annotation)
+	}
+	if len(cited) == 0 {
+		return message
+	}
+
+	sort.SliceStable(cited, func(i, j int) bool {
+		if cited[i].EndIndex != cited[j].EndIndex {
+			return cited[i].EndIndex < cited[j].EndIndex
+		}
+		return cited[i].Index < cited[j].Index
+	})
+
+	numbers := make(map[string]int)
+	var sources []llm.Annotation
+	var edits []edit
+	for _, annotation := range cited {
+		number, ok := numbers[annotation.URL]
+		if !ok {
+			sources = append(sources, annotation)
+			number = len(sources)
+			numbers[annotation.URL] = number
+		}
+
+		start, end := annotation.EndIndex, annotation.EndIndex
+		if strings.Contains(string(runes[annotation.StartIndex:annotation.EndIndex]), annotation.URL) {
+			start = annotation.StartIndex
+			for start > 0 && (runes[start-1] == ' ' || runes[start-1] == '\t') {
+				start--
+			}
+		} else {
+			// Markers go right after the cited words, before any whitespace the passage ends with
+			for start > annotation.StartIndex && unicode.IsSpace(runes[start-1]) {
+				start--
+			}
+			end = start
+		}
+
+		if i := slices.IndexFunc(edits, func(e edit) bool { return e.start == start && e.end == end }); i >= 0 {
+			if !slices.Contains(edits[i].sources, number) {
+				edits[i].sources = append(edits[i].sources, number)
+			}
+			continue
+		}
+		edits = append(edits, edit{start: start, end: end, sources: []int{number}})
+	}
+
+	// Apply the edits from the end so the offsets of the remaining ones stay valid
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	var result []rune
+	last := len(runes)
+	for _, e := range edits {
+		if e.end > last {
+			continue
+		}
+		var markers strings.Builder
+		for _, number := range e.sources {
+			fmt.Fprintf(&markers, "[%d]", number)
+		}
+		result = append([]rune(markers.String()+string(runes[e.end:last])), result...)
+		last = e.start
+	}
+	result = append(runes[:last:last], result...)
+
+	var footnotes strings.Builder
+	footnotes.WriteString(strings.TrimRightFunc(string(result), unicode.IsSpace))
+	fmt.Fprintf(&footnotes, "\n\n**%s**\n", heading)
+	for i, source := range sources {
+		title := strings.TrimSpace(titleEscaper.Replace(source.Title))
+		if title == "" {
+			title = source.URL
+		}
+		fmt.Fprintf(&footnotes, "%d. [%s](%s)\n", i+1, title, strings.ReplaceAll(source.URL, " ", "%20"))
+	}
+
+	return strings.TrimRight(footnotes.String(), "\n")
+}
+
+// PostProcessor renders the annotations saved on completed responses as footnotes
+type PostProcessor struct {
+	i18n *i18n.Bundle
+}
+
+// NewPostProcessor creates a citation processor that translates the source list heading with bundle.
+func NewPostProcessor(bundle *i18n.Bundle) *PostProcessor {
+	return &PostProcessor{
+		i18n: bundle,
+	}
+}
+
+// ProcessPost renders the annotations of the post into its message and marks the post so the
+// webapp doesn't render them again. It must run before processors that change the message,
+// since annotation indexes refer to the message as streamed.
+func (p *PostProcessor) ProcessPost(ctx context.Context, post *model.Post, _ bool) {
+	annotationsJSON, ok := post.GetProp(streaming.AnnotationsProp).(string)
+	if !ok || annotationsJSON == "" {
+		return
+	}
+
+	var annotations []llm.Annotation
+	if err := json.Unmarshal([]byte(annotationsJSON), &annotations); err != nil {
+		return
+	}
+
+	T := i18n.LocalizerFunc(p.i18n, streaming.UserLocale(ctx))
+	message := Footnotes(post.Message, annotations, T("agents.citations_sources", "Sources"))
+	if message == post.Message {
+		return
+	}
+
+	post.Message = message
+	post.AddProp(streaming.CitationsRenderedProp, true)
+}
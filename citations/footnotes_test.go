@@ -0,0 +1,137 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package citations
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+func citation(start, end, index int, url, title string) llm.Annotation {
+	return llm.Annotation{
+		Type:       llm.AnnotationTypeURLCitation,
+		StartIndex: start,
+		EndIndex:   end,
+		URL:        url,
+		Title:      title,
+		Index:      index,
+	}
+}
+
+func TestFootnotes(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     string
+		annotations []llm.Annotation
+		expected    string
+	}{
+		{
+			name:    "zero width web search citations",
+			message: "Go is fast. It is simple.",
+			annotations: []llm.Annotation{
+				citation(11, 11, 1, "https://go.dev", "The Go Programming Language"),
+				citation(25, 25, 2, "https://go.dev/doc", "Documentation"),
+			},
+			expected: "Go is fast.[1] It is simple.[2]\n\n**Sources**\n1. [The Go Programming Language](https://go.dev)\n2. [Documentation](https://go.dev/doc)",
+		},
+		{
+			name:    "anthropic text blocks end before trailing whitespace",
+			message: "First claim.\n\nSecond claim.",
+			annotations: []llm.Annotation{
+				citation(0, 14, 1, "https://a.example", "A"),
+				citation(0, 14, 2, "https://b.example", "B"),
+				citation(14, 27, 3, "https://a.example", "A again"),
+			},
+			expected: "First claim.[1][2]\n\nSecond claim.[1]\n\n**Sources**\n1. [A](https://a.example)\n2. [B](https://b.example)",
+		},
+		{
+			name:    "openai inline citations are replaced by the marker",
+			message: "Rain is likely ([weather.example](https://weather.example/today)).",
+			annotations: []llm.Annotation{
+				citation(15, 65, 1, "https://weather.example/today", "Forecast"),
+			},
+			expected: "Rain is likely[1].\n\n**Sources**\n1. [Forecast](https://weather.example/today)",
+		},
+		{
+			name:    "indexes count runes",
+			message: "Café ☕ is open.",
+			annotations: []llm.Annotation{
+				citation(0, 6, 1, "https://cafe.example", ""),
+			},
+			expected: "Café ☕[1] is open.\n\n**Sources**\n1. [https://cafe.example](https://cafe.example)",
+		},
+		{
+			name:    "titles can't break the link and out of range indexes are clamped",
+			message: "Short.",
+			annotations: []llm.Annotation{
+				citation(2, 100, 1, "https://x.example/a b", "[Title]\nnext"),
+			},
+			expected: "Short.[1]\n\n**Sources**\n1. [\\[Title\\] next](https://x.example/a%20b)",
+		},
+		{
+			name:    "no url citations",
+			message: "Nothing cited.",
+			annotations: []llm.Annotation{
+				{Type: "file_citation", StartIndex: 0, EndIndex: 7, Index: 1},
+				citation(0, 7, 2, "", "No URL"),
+			},
+			expected: "Nothing cited.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, Footnotes(test.message, test.annotations, "Sources"))
+		})
+	}
+}
+
+func TestProcessPost(t *testing.T) {
+	annotationsJSON, err := json.Marshal([]llm.Annotation{citation(5, 5, 1, "https://a.example", "A")})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		annotations      interface{}
+		expectedMessage  string
+		expectedRendered bool
+	}{
+		{
+			name:             "renders annotations",
+			annotations:      string(annotationsJSON),
+			expectedMessage:  "Hello[1] world\n\n**Sources**\n1. [A](https://a.example)",
+			expectedRendered: true,
+		},
+		{
+			name:            "no annotations",
+			expectedMessage: "Hello world",
+		},
+		{
+			name:            "invalid annotations",
+			annotations:     "not json",
+			expectedMessage: "Hello world",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			post := &model.Post{Message: "Hello world"}
+			if test.annotations != nil {
+				post.AddProp(streaming.AnnotationsProp, test.annotations)
+			}
+
+			NewPostProcessor(i18n.Init()).ProcessPost(context.Background(), post, false)
+
+			require.Equal(t, test.expectedMessage, post.Message)
+			require.Equal(t, test.expectedRendered, post.GetProp(streaming.CitationsRenderedProp) == true)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package codehosts reads pull requests, issues and CI status from GitHub and GitLab on behalf
+// of users, who connect their accounts through OAuth applications configured by the admin.
+package codehosts
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/integrations"
+)
+
+const (
+	HostGitHub = "github"
+	HostGitLab = "gitlab"
+
+	// maxDiffLength is the largest number of characters of a diff returned
+	maxDiffLength = 30000
+	// maxComments is the largest number of comments of each kind returned
+	maxComments = 100
+	// maxChecks is the largest number of CI checks returned
+	maxChecks = 100
+)
+
+var (
+	// ErrNotEnabled is returned for hosts the admin hasn't enabled and configured
+	ErrNotEnabled = integrations.ErrNotEnabled
+	// ErrNotConnected is returned when the user hasn't connected their account on the host
+	ErrNotConnected = integrations.ErrNotConnected
+	// ErrNotFound is returned when the repository or item doesn't exist or the user can't see it
+	ErrNotFound = errors.New("not found or not accessible")
+)
+
+// Config configures the code hosts users can connect to
+type Config struct {
+	GitHub HostConfig `json:"github"`
+	GitLab HostConfig `json:"gitlab"`
+}
+
+// HostConfig configures one code host and the OAuth application users connect with
+type HostConfig struct {
+	Enabled bool `json:"enabled"`
+	// BaseURL is the URL of a GitHub Enterprise Server or self-managed GitLab instance, empty
+	// for github.com and gitlab.com
+	BaseURL      string `json:"baseURL"`
+	ClientID     string `json:"clientID"`
+	ClientSecret string `json:"clientSecret"`
+}
+
+// Host returns the configuration of the host
+func (c Config) Host(host string) (HostConfig, bool) {
+	switch host {
+	case HostGitHub:
+		return c.GitHub, true
+	case HostGitLab:
+		return c.GitLab, true
+	}
+	return HostConfig{}, false
+}
+
+// IsConfigured returns whether the host is enabled with an OAuth application
+func (c HostConfig) IsConfigured() bool {
+	return c.Enabled && strings.TrimSpace(c.ClientID) != "" && strings.TrimSpace(c.ClientSecret) != ""
+}
+
+func (c HostConfig) baseURL(defaultURL string) string {
+	if baseURL := strings.TrimSuffix(strings.TrimSpace(c.BaseURL), "/"); baseURL != "" {
+		return baseURL
+	}
+	return defaultURL
+}
+
+// HostName returns the display name of the host
+func HostName(host string) string {
+	switch host {
+	case HostGitHub:
+		return "GitHub"
+	case HostGitLab:
+		return "GitLab"
+	}
+	return host
+}
+
+// Client reads from a code host as the connected user. Repositories are "owner/name" on GitHub
+// and the full project path, such as "group/subgroup/project", on GitLab.
+type Client interface {
+	GetPullRequest(ctx context.Context, repository string, number int) (*PullRequest, error)
+	GetIssue(ctx context.Context, repository string, number int) (*Issue, error)
+	GetCIStatus(ctx context.Context, repository string, number int) (*CIStatus, error)
+}
+
+// PullRequest is a GitHub pull request or a GitLab merge request
+type PullRequest struct {
+	Number       int
+	Title        string
+	State        string
+	Author       string
+	URL          string
+	Body         string
+	SourceBranch string
+	TargetBranch string
+	Diff         string
+	// DiffTruncated is set when the diff was longer than could be returned
+	DiffTruncated bool
+	// Reviews are the approvals and change requests of reviewers
+	Reviews []Comment
+	// Comments are the discussion and review comments, oldest first
+	Comments []Comment
+}
+
+// Issue is an issue and its comments, oldest first
+type Issue struct {
+	Number   int
+	Title    string
+	State    string
+	Author   string
+	URL      string
+	Body     string
+	Labels   []string
+	Comments []Comment
+}
+
+// Comment is a comment or a review. Path and Line are set for comments on a line of the diff.
+type Comment struct {
+	Author string
+	Body   string
+	State  string
+	Path   string
+	Line   int
+}
+
+// CIStatus is the status of the checks or pipeline of the latest commit of a pull request
+type CIStatus struct {
+	Ref string
+	// State is success, failure, pending, none when there are no checks, or the status reported
+	// by the host
+	State  string
+	URL    string
+	Checks []Check
+}
+
+// Check is a CI check or job
+type Check struct {
+	Name   string
+	Status string
+	URL    string
+}
+
+// overallState combines the statuses of checks, failures taking precedence over pending checks
+func overallState(checks []Check) string {
+	if len(checks) == 0 {
+		return "none"
+	}
+	state := "success"
+	for _, check := range checks {
+		switch check.Status {
+		case "failure", "failed", "error", "timed_out", "cancelled", "canceled", "action_required":
+			return "failure"
+		case "success", "neutral", "skipped", "manual":
+		default:
+			state = "pending"
+		}
+	}
+	return state
+}
+
+// truncateDiff cuts the diff to maxDiffLength characters at a line boundary
+func truncateDiff(diff string) (string, bool) {
+	if len(diff) <= maxDiffLength {
+		return diff, false
+	}
+	diff = diff[:maxDiffLength]
+	if i := strings.LastIndexByte(diff, '\n'); i > 0 {
+		diff = diff[:i+1]
+	}
+	return diff, true
+}
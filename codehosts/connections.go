@@ -0,0 +1,137 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package codehosts
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/mattermost/mattermost-plugin-ai/integrations"
+	"golang.org/x/oauth2"
+)
+
+// Service creates clients acting as users on the code hosts they connected through the
+// integrations service.
+type Service struct {
+	getConfig    func() Config
+	integrations *integrations.Service
+}
+
+// New creates a new service and registers the code hosts as integrations.
+func New(getConfig func() Config, integrationsService *integrations.Service) *Service {
+	s := &Service{
+		getConfig:    getConfig,
+		integrations: integrationsService,
+	}
+	for _, host := range []string{HostGitHub, HostGitLab} {
+		integrationsService.Register(host, &hostProvider{host: host, getConfig: getConfig})
+	}
+	return s
+}
+
+// Enabled returns whether users can connect to the host
+func (s *Service) Enabled(host string) bool {
+	if s == nil {
+		return false
+	}
+	cfg, ok := s.getConfig().Host(host)
+	return ok && cfg.IsConfigured()
+}
+
+// EnabledHosts returns the hosts users can connect to
+func (s *Service) EnabledHosts() []string {
+	var hosts []string
+	for _, host := range []string{HostGitHub, HostGitLab} {
+		if s.Enabled(host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// ConnectURL returns the page users open to connect their account on the host
+func (s *Service) ConnectURL(host string) string {
+	return s.integrations.ConnectURL(host)
+}
+
+// Client returns a client acting as the user on the host, or ErrNotConnected.
+func (s *Service) Client(ctx context.Context, userID, host string) (Client, error) {
+	if !s.Enabled(host) {
+		return nil, ErrNotEnabled
+	}
+	httpClient, _, err := s.integrations.HTTPClient(ctx, userID, host)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(s.getConfig(), host, httpClient)
+}
+
+type hostClient interface {
+	Client
+	username(ctx context.Context) (string, error)
+}
+
+func newClient(cfg Config, host string, httpClient *http.Client) (hostClient, error) {
+	hostConfig, _ := cfg.Host(host)
+	switch host {
+	case HostGitHub:
+		return newGitHubClient(hostConfig.BaseURL, httpClient)
+	case HostGitLab:
+		return newGitLabClient(hostConfig.baseURL(defaultGitLabURL), httpClient), nil
+	}
+	return nil, ErrNotEnabled
+}
+
+// hostProvider is the integration of a code host
+type hostProvider struct {
+	host      string
+	getConfig func() Config
+}
+
+func (p *hostProvider) Name() string {
+	return HostName(p.host)
+}
+
+func (p *hostProvider) OAuthConfig() (*oauth2.Config, error) {
+	cfg, ok := p.getConfig().Host(p.host)
+	if !ok || !cfg.IsConfigured() {
+		return nil, ErrNotEnabled
+	}
+
+	oauthConfig := &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+	}
+	switch p.host {
+	case HostGitHub:
+		baseURL := cfg.baseURL(defaultGitHubURL)
+		oauthConfig.Endpoint = oauth2.Endpoint{
+			AuthURL:  baseURL + "/login/oauth/authorize",
+			TokenURL: baseURL + "/login/oauth/access_token",
+		}
+		// OAuth apps have no read only scope for private repositories
+		oauthConfig.Scopes = []string{"repo"}
+	case HostGitLab:
+		baseURL := cfg.baseURL(defaultGitLabURL)
+		oauthConfig.Endpoint = oauth2.Endpoint{
+			AuthURL:  baseURL + "/oauth/authorize",
+			TokenURL: baseURL + "/oauth/token",
+		}
+		oauthConfig.Scopes = []string{"read_api"}
+	}
+
+	return oauthConfig, nil
+}
+
+func (p *hostProvider) Account(ctx context.Context, httpClient *http.Client) (integrations.Account, error) {
+	client, err := newClient(p.getConfig(), p.host, httpClient)
+	if err != nil {
+		return integrations.Account{}, err
+	}
+	username, err := client.username(ctx)
+	if err != nil {
+		return integrations.Account{}, err
+	}
+	return integrations.Account{Username: username}, nil
+}
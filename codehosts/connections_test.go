@@ -0,0 +1,114 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package codehosts
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/integrations"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mmapitest"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeGitHub serves the OAuth token endpoint and the user endpoint of a GitHub Enterprise Server
+func newFakeGitHub(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login/oauth/access_token":
+			require.NoError(t, r.ParseForm())
+			if r.Form.Get("code") != "good-code" || r.Form.Get("code_verifier") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"bad_verification_code"}`))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"token-1","token_type":"bearer","scope":"repo"}`))
+		case "/api/v3/user":
+			require.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"login":"octocat"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestConnections(t *testing.T) {
+	server := newFakeGitHub(t)
+	defer server.Close()
+
+	cfg := Config{GitHub: HostConfig{Enabled: true, BaseURL: server.URL, ClientID: "client", ClientSecret: "secret"}}
+	connections := integrations.New(mmapitest.NewMemoryKV(), server.Client(), "https://mm.example.com/plugins/mattermost-ai")
+	service := New(func() Config { return cfg }, connections)
+
+	authURL, err := connections.AuthorizationURL("user1", HostGitHub)
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/login/oauth/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	require.Equal(t, "https://mm.example.com/plugins/mattermost-ai/integrations/github/callback", parsed.Query().Get("redirect_uri"))
+	require.Equal(t, "repo", parsed.Query().Get("scope"))
+
+	connection, err := connections.CompleteConnection(context.Background(), "user1", HostGitHub, parsed.Query().Get("state"), "good-code")
+	require.NoError(t, err)
+	require.Equal(t, "octocat", connection.Username)
+
+	_, err = service.Client(context.Background(), "user1", HostGitHub)
+	require.NoError(t, err)
+	_, err = service.Client(context.Background(), "user2", HostGitHub)
+	require.ErrorIs(t, err, ErrNotConnected)
+	_, err = service.Client(context.Background(), "user1", HostGitLab)
+	require.ErrorIs(t, err, ErrNotEnabled)
+}
+
+func TestEnabledHosts(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		expected []string
+	}{
+		{
+			name:     "nothing configured",
+			expected: nil,
+		},
+		{
+			name:     "enabled without an OAuth application",
+			cfg:      Config{GitHub: HostConfig{Enabled: true, ClientID: "client"}},
+			expected: nil,
+		},
+		{
+			name:     "configured but disabled",
+			cfg:      Config{GitLab: HostConfig{ClientID: "client", ClientSecret: "secret"}},
+			expected: nil,
+		},
+		{
+			name: "both hosts",
+			cfg: Config{
+				GitHub: HostConfig{Enabled: true, ClientID: "client", ClientSecret: "secret"},
+				GitLab: HostConfig{Enabled: true, ClientID: "client", ClientSecret: "secret"},
+			},
+			expected: []string{HostGitHub, HostGitLab},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			connections := integrations.New(mmapitest.NewMemoryKV(), http.DefaultClient, "")
+			service := New(func() Config { return test.cfg }, connections)
+			require.Equal(t, test.expected, service.EnabledHosts())
+			require.Equal(t, test.expected, connections.EnabledIntegrations())
+
+			for _, host := range []string{HostGitHub, HostGitLab} {
+				if !service.Enabled(host) {
+					_, err := connections.AuthorizationURL("user1", host)
+					require.ErrorIs(t, err, ErrNotEnabled)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package codehosts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v41/github"
+)
+
+const defaultGitHubURL = "https://github.com"
+
+var validGitHubName = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,100}$`)
+
+type gitHubClient struct {
+	client *github.Client
+}
+
+// newGitHubClient creates a client for github.com, or for the GitHub Enterprise Server at baseURL
+func newGitHubClient(baseURL string, httpClient *http.Client) (*gitHubClient, error) {
+	baseURL = strings.TrimSuffix(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" || baseURL == defaultGitHubURL {
+		return &gitHubClient{client: github.NewClient(httpClient)}, nil
+	}
+
+	client, err := github.NewEnterpriseClient(baseURL+"/api/v3/", baseURL+"/api/uploads/", httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub Enterprise client: %w", err)
+	}
+	return &gitHubClient{client: client}, nil
+}
+
+func splitGitHubRepository(repository string) (string, string, error) {
+	owner, name, ok := strings.Cut(strings.Trim(strings.TrimSpace(repository), "/"), "/")
+	if !ok || !validGitHubName.MatchString(owner) || !validGitHubName.MatchString(name) {
+		return "", "", fmt.Errorf("invalid GitHub repository %q, expected owner/name", repository)
+	}
+	return owner, name, nil
+}
+
+// gitHubError maps the errors of missing or hidden items to ErrNotFound
+func gitHubError(err error) error {
+	var responseErr *github.ErrorResponse
+	if errors.As(err, &responseErr) && responseErr.Response != nil && responseErr.Response.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (c *gitHubClient) username(ctx context.Context) (string, error) {
+	user, _, err := c.client.Users.Get(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	return user.GetLogin(), nil
+}
+
+func (c *gitHubClient) GetPullRequest(ctx context.Context, repository string, number int) (*PullRequest, error) {
+	owner, name, err := splitGitHubRepository(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, _, err := c.client.PullRequests.Get(ctx, owner, name, number)
+	if err != nil {
+		return nil, gitHubError(err)
+	}
+	state := pr.GetState()
+	if pr.GetMerged() {
+		state = "merged"
+	} else if pr.GetDraft() && state == "open" {
+		state = "draft"
+	}
+	result := &PullRequest{
+		Number:       pr.GetNumber(),
+		Title:        pr.GetTitle(),
+		State:        state,
+		Author:       pr.GetUser().GetLogin(),
+		URL:          pr.GetHTMLURL(),
+		Body:         pr.GetBody(),
+		SourceBranch: pr.GetHead().GetRef(),
+		TargetBranch: pr.GetBase().GetRef(),
+	}
+
+	diff, _, err := c.client.PullRequests.GetRaw(ctx, owner, name, number, github.RawOptions{Type: github.Diff})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diff: %w", gitHubError(err))
+	}
+	result.Diff, result.DiffTruncated = truncateDiff(diff)
+
+	reviews, _, err := c.client.PullRequests.ListReviews(ctx, owner, name, number, &github.ListOptions{PerPage: maxComments})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews: %w", gitHubError(err))
+	}
+	for _, review := range reviews {
+		// Reviews only holding line comments are listed as comments below
+		if review.GetState() == "COMMENTED" && review.GetBody() == "" {
+			continue
+		}
+		result.Reviews = append(result.Reviews, Comment{
+			Author: review.GetUser().GetLogin(),
+			Body:   review.GetBody(),
+			State:  strings.ToLower(review.GetState()),
+		})
+	}
+
+	comments, _, err := c.client.Issues.ListComments(ctx, owner, name, number, &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: maxComments}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", gitHubError(err))
+	}
+	for _, comment := range comments {
+		result.Comments = append(result.Comments, Comment{
+			Author: comment.GetUser().GetLogin(),
+			Body:   comment.GetBody(),
+		})
+	}
+
+	lineComments, _, err := c.client.PullRequests.ListComments(ctx, owner, name, number, &github.PullRequestListCommentsOptions{ListOptions: github.ListOptions{PerPage: maxComments}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review comments: %w", gitHubError(err))
+	}
+	for _, comment := range lineComments {
+		result.Comments = append(result.Comments, Comment{
+			Author: comment.GetUser().GetLogin(),
+			Body:   comment.GetBody(),
+			Path:   comment.GetPath(),
+			Line:   comment.GetLine(),
+		})
+	}
+
+	return result, nil
+}
+
+func (c *gitHubClient) GetIssue(ctx context.Context, repository string, number int) (*Issue, error) {
+	owner, name, err := splitGitHubRepository(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	issue, _, err := c.client.Issues.Get(ctx, owner, name, number)
+	if err != nil {
+		return nil, gitHubError(err)
+	}
+	result := &Issue{
+		Number: issue.GetNumber(),
+		Title:  issue.GetTitle(),
+		State:  issue.GetState(),
+		Author: issue.GetUser().GetLogin(),
+		URL:    issue.GetHTMLURL(),
+		Body:   issue.GetBody(),
+	}
+	for _, label := range issue.Labels {
+		result.Labels = append(result.Labels, label.GetName())
+	}
+
+	comments, _, err := c.client.Issues.ListComments(ctx, owner, name, number, &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: maxComments}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", gitHubError(err))
+	}
+	for _, comment := range comments {
+		result.Comments = append(result.Comments, Comment{
+			Author: comment.GetUser().GetLogin(),
+			Body:   comment.GetBody(),
+		})
+	}
+
+	return result, nil
+}
+
+func (c *gitHubClient) GetCIStatus(ctx context.Context, repository string, number int) (*CIStatus, error) {
+	owner, name, err := splitGitHubRepository(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, _, err := c.client.PullRequests.Get(ctx, owner, name, number)
+	if err != nil {
+		return nil, gitHubError(err)
+	}
+	sha := pr.GetHead().GetSHA()
+	result := &CIStatus{
+		Ref: sha,
+		URL: pr.GetHTMLURL() + "/checks",
+	}
+
+	// Checks are reported both as check runs, by GitHub Actions and apps, and as commit statuses
+	runs, _, err := c.client.Checks.ListCheckRunsForRef(ctx, owner, name, sha, &github.ListCheckRunsOptions{ListOptions: github.ListOptions{PerPage: maxChecks}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get check runs: %w", gitHubError(err))
+	}
+	for _, run := range runs.CheckRuns {
+		status := run.GetStatus()
+		if status == "completed" {
+			status = run.GetConclusion()
+		}
+		result.Checks = append(result.Checks, Check{
+			Name:   run.GetName(),
+			Status: status,
+			URL:    run.GetHTMLURL(),
+		})
+	}
+
+	combined, _, err := c.client.Repositories.GetCombinedStatus(ctx, owner, name, sha, &github.ListOptions{PerPage: maxChecks})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit statuses: %w", gitHubError(err))
+	}
+	for _, status := range combined.Statuses {
+		result.Checks = append(result.Checks, Check{
+			Name:   status.GetContext(),
+			Status: status.GetState(),
+			URL:    status.GetTargetURL(),
+		})
+	}
+
+	if len(result.Checks) > maxChecks {
+		result.Checks = result.Checks[:maxChecks]
+	}
+	result.State = overallState(result.Checks)
+	return result, nil
+}
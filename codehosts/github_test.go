@@ -0,0 +1,90 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package codehosts
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v3/repos/octo/app/pulls/12":
+			if strings.Contains(r.Header.Get("Accept"), "diff") {
+				_, _ = w.Write([]byte("diff --git a/main.go b/main.go\n+fix\n"))
+				return
+			}
+			_, _ = w.Write([]byte(`{"number":12,"title":"Fix crash","state":"closed","merged":true,"body":"Fixes #1",
+				"html_url":"https://github.example.com/octo/app/pull/12","user":{"login":"alice"},
+				"head":{"ref":"fix","sha":"abc123"},"base":{"ref":"main"}}`))
+		case "/api/v3/repos/octo/app/pulls/12/reviews":
+			_, _ = w.Write([]byte(`[{"state":"APPROVED","body":"","user":{"login":"bob"}},{"state":"COMMENTED","body":"","user":{"login":"carol"}}]`))
+		case "/api/v3/repos/octo/app/issues/12/comments":
+			_, _ = w.Write([]byte(`[{"body":"Thanks","user":{"login":"bob"}}]`))
+		case "/api/v3/repos/octo/app/pulls/12/comments":
+			_, _ = w.Write([]byte(`[{"body":"Nit","path":"main.go","line":3,"user":{"login":"carol"}}]`))
+		case "/api/v3/repos/octo/app/commits/abc123/check-runs":
+			_, _ = w.Write([]byte(`{"total_count":2,"check_runs":[
+				{"name":"build","status":"completed","conclusion":"success","html_url":"https://ci/1"},
+				{"name":"e2e","status":"in_progress","html_url":"https://ci/2"}]}`))
+		case "/api/v3/repos/octo/app/commits/abc123/status":
+			_, _ = w.Write([]byte(`{"state":"success","statuses":[{"context":"coverage","state":"success","target_url":"https://cov/1"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := newGitHubClient(server.URL, server.Client())
+	require.NoError(t, err)
+
+	t.Run("pull request", func(t *testing.T) {
+		pr, err := client.GetPullRequest(context.Background(), "octo/app", 12)
+		require.NoError(t, err)
+		require.Equal(t, &PullRequest{
+			Number:       12,
+			Title:        "Fix crash",
+			State:        "merged",
+			Author:       "alice",
+			URL:          "https://github.example.com/octo/app/pull/12",
+			Body:         "Fixes #1",
+			SourceBranch: "fix",
+			TargetBranch: "main",
+			Diff:         "diff --git a/main.go b/main.go\n+fix\n",
+			Reviews:      []Comment{{Author: "bob", State: "approved"}},
+			Comments: []Comment{
+				{Author: "bob", Body: "Thanks"},
+				{Author: "carol", Body: "Nit", Path: "main.go", Line: 3},
+			},
+		}, pr)
+	})
+
+	t.Run("CI status", func(t *testing.T) {
+		status, err := client.GetCIStatus(context.Background(), "octo/app", 12)
+		require.NoError(t, err)
+		require.Equal(t, "pending", status.State)
+		require.Equal(t, []Check{
+			{Name: "build", Status: "success", URL: "https://ci/1"},
+			{Name: "e2e", Status: "in_progress", URL: "https://ci/2"},
+			{Name: "coverage", Status: "success", URL: "https://cov/1"},
+		}, status.Checks)
+	})
+
+	t.Run("missing pull request", func(t *testing.T) {
+		_, err := client.GetPullRequest(context.Background(), "octo/app", 13)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("invalid repository", func(t *testing.T) {
+		_, err := client.GetIssue(context.Background(), "octo/app/extra", 1)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,306 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package codehosts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultGitLabURL = "https://gitlab.com"
+
+// gitLabClient reads from the GitLab REST API, see https://docs.gitlab.com/api/rest/
+type gitLabClient struct {
+	apiURL     string
+	httpClient *http.Client
+}
+
+func newGitLabClient(baseURL string, httpClient *http.Client) *gitLabClient {
+	return &gitLabClient{
+		apiURL:     baseURL + "/api/v4",
+		httpClient: httpClient,
+	}
+}
+
+type gitLabUser struct {
+	Username string `json:"username"`
+}
+
+type gitLabNote struct {
+	Body     string     `json:"body"`
+	Author   gitLabUser `json:"author"`
+	System   bool       `json:"system"`
+	Position *struct {
+		NewPath string `json:"new_path"`
+		NewLine int    `json:"new_line"`
+	} `json:"position"`
+}
+
+type gitLabPipeline struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+	SHA    string `json:"sha"`
+	WebURL string `json:"web_url"`
+}
+
+type gitLabMergeRequest struct {
+	IID          int             `json:"iid"`
+	Title        string          `json:"title"`
+	State        string          `json:"state"`
+	Draft        bool            `json:"draft"`
+	Description  string          `json:"description"`
+	WebURL       string          `json:"web_url"`
+	Author       gitLabUser      `json:"author"`
+	SourceBranch string          `json:"source_branch"`
+	TargetBranch string          `json:"target_branch"`
+	HeadPipeline *gitLabPipeline `json:"head_pipeline"`
+}
+
+// projectPath returns the escaped project path used as its ID in the API
+func projectPath(repository string) (string, error) {
+	repository = strings.Trim(strings.TrimSpace(repository), "/")
+	if !strings.Contains(repository, "/") || strings.Contains(repository, "//") || strings.Contains(repository, "..") {
+		return "", fmt.Errorf("invalid GitLab project %q, expected its full path such as group/project", repository)
+	}
+	return url.PathEscape(repository), nil
+}
+
+func (c *gitLabClient) get(ctx context.Context, path string, query url.Values, result any) error {
+	endpoint := c.apiURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create GitLab request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitLab request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("GitLab request failed: %s: %s", resp.Status, body)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode GitLab response: %w", err)
+	}
+	return nil
+}
+
+func pageQuery(perPage int) url.Values {
+	return url.Values{
+		"per_page": {fmt.Sprint(perPage)},
+	}
+}
+
+func notesQuery() url.Values {
+	query := pageQuery(maxComments)
+	query.Set("sort", "asc")
+	query.Set("order_by", "created_at")
+	return query
+}
+
+// notesToComments returns the comments of users, without the notes GitLab adds for events
+func notesToComments(notes []gitLabNote) []Comment {
+	var comments []Comment
+	for _, note := range notes {
+		if note.System {
+			continue
+		}
+		comment := Comment{
+			Author: note.Author.Username,
+			Body:   note.Body,
+		}
+		if note.Position != nil {
+			comment.Path = note.Position.NewPath
+			comment.Line = note.Position.NewLine
+		}
+		comments = append(comments, comment)
+	}
+	return comments
+}
+
+func (c *gitLabClient) username(ctx context.Context) (string, error) {
+	var user gitLabUser
+	if err := c.get(ctx, "/user", nil, &user); err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+func (c *gitLabClient) GetPullRequest(ctx context.Context, repository string, number int) (*PullRequest, error) {
+	project, err := projectPath(repository)
+	if err != nil {
+		return nil, err
+	}
+	mrPath := fmt.Sprintf("/projects/%s/merge_requests/%d", project, number)
+
+	var mr gitLabMergeRequest
+	if err = c.get(ctx, mrPath, nil, &mr); err != nil {
+		return nil, err
+	}
+	state := mr.State
+	if mr.Draft && state == "opened" {
+		state = "draft"
+	}
+	result := &PullRequest{
+		Number:       mr.IID,
+		Title:        mr.Title,
+		State:        state,
+		Author:       mr.Author.Username,
+		URL:          mr.WebURL,
+		Body:         mr.Description,
+		SourceBranch: mr.SourceBranch,
+		TargetBranch: mr.TargetBranch,
+	}
+
+	var diffs []struct {
+		OldPath string `json:"old_path"`
+		NewPath string `json:"new_path"`
+		Diff    string `json:"diff"`
+	}
+	if err = c.get(ctx, mrPath+"/diffs", pageQuery(100), &diffs); err != nil {
+		return nil, fmt.Errorf("failed to get diff: %w", err)
+	}
+	var diff strings.Builder
+	for _, file := range diffs {
+		fmt.Fprintf(&diff, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n%s", file.OldPath, file.NewPath, file.OldPath, file.NewPath, file.Diff)
+		if !strings.HasSuffix(file.Diff, "\n") {
+			diff.WriteString("\n")
+		}
+	}
+	result.Diff, result.DiffTruncated = truncateDiff(diff.String())
+
+	var approvals struct {
+		ApprovedBy []struct {
+			User gitLabUser `json:"user"`
+		} `json:"approved_by"`
+	}
+	if err = c.get(ctx, mrPath+"/approvals", nil, &approvals); err != nil {
+		return nil, fmt.Errorf("failed to get approvals: %w", err)
+	}
+	for _, approval := range approvals.ApprovedBy {
+		result.Reviews = append(result.Reviews, Comment{
+			Author: approval.User.Username,
+			State:  "approved",
+		})
+	}
+
+	var notes []gitLabNote
+	if err = c.get(ctx, mrPath+"/notes", notesQuery(), &notes); err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+	result.Comments = notesToComments(notes)
+
+	return result, nil
+}
+
+func (c *gitLabClient) GetIssue(ctx context.Context, repository string, number int) (*Issue, error) {
+	project, err := projectPath(repository)
+	if err != nil {
+		return nil, err
+	}
+	issuePath := fmt.Sprintf("/projects/%s/issues/%d", project, number)
+
+	var issue struct {
+		IID         int        `json:"iid"`
+		Title       string     `json:"title"`
+		State       string     `json:"state"`
+		Description string     `json:"description"`
+		WebURL      string     `json:"web_url"`
+		Author      gitLabUser `json:"author"`
+		Labels      []string   `json:"labels"`
+	}
+	if err = c.get(ctx, issuePath, nil, &issue); err != nil {
+		return nil, err
+	}
+
+	var notes []gitLabNote
+	if err = c.get(ctx, issuePath+"/notes", notesQuery(), &notes); err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+
+	return &Issue{
+		Number:   issue.IID,
+		Title:    issue.Title,
+		State:    issue.State,
+		Author:   issue.Author.Username,
+		URL:      issue.WebURL,
+		Body:     issue.Description,
+		Labels:   issue.Labels,
+		Comments: notesToComments(notes),
+	}, nil
+}
+
+func (c *gitLabClient) GetCIStatus(ctx context.Context, repository string, number int) (*CIStatus, error) {
+	project, err := projectPath(repository)
+	if err != nil {
+		return nil, err
+	}
+	mrPath := fmt.Sprintf("/projects/%s/merge_requests/%d", project, number)
+
+	var mr gitLabMergeRequest
+	if err = c.get(ctx, mrPath, nil, &mr); err != nil {
+		return nil, err
+	}
+	pipeline := mr.HeadPipeline
+	if pipeline == nil {
+		var pipelines []gitLabPipeline
+		if err = c.get(ctx, mrPath+"/pipelines", pageQuery(1), &pipelines); err != nil {
+			return nil, fmt.Errorf("failed to get pipelines: %w", err)
+		}
+		if len(pipelines) == 0 {
+			return &CIStatus{State: "none", URL: mr.WebURL}, nil
+		}
+		pipeline = &pipelines[0]
+	}
+
+	var jobs []struct {
+		Name   string `json:"name"`
+		Stage  string `json:"stage"`
+		Status string `json:"status"`
+		WebURL string `json:"web_url"`
+	}
+	if err = c.get(ctx, fmt.Sprintf("/projects/%s/pipelines/%d/jobs", project, pipeline.ID), pageQuery(maxChecks), &jobs); err != nil {
+		return nil, fmt.Errorf("failed to get pipeline jobs: %w", err)
+	}
+
+	result := &CIStatus{
+		Ref: pipeline.SHA,
+		URL: pipeline.WebURL,
+	}
+	for _, job := range jobs {
+		result.Checks = append(result.Checks, Check{
+			Name:   job.Stage + ": " + job.Name,
+			Status: job.Status,
+			URL:    job.WebURL,
+		})
+	}
+	switch pipeline.Status {
+	case "success":
+		result.State = "success"
+	case "failed", "canceled":
+		result.State = "failure"
+	case "created", "waiting_for_resource", "preparing", "pending", "running", "scheduled":
+		result.State = "pending"
+	default:
+		result.State = pipeline.Status
+	}
+
+	return result, nil
+}
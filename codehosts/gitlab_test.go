@@ -0,0 +1,120 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package codehosts
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeGitLab() *httptest.Server {
+	responses := map[string]string{
+		"/api/v4/projects/group/sub/app/merge_requests/7": `{"iid":7,"title":"Add dark mode","state":"opened","draft":true,"description":"Closes #3",
+			"web_url":"https://gitlab.example.com/group/sub/app/-/merge_requests/7","author":{"username":"alice"},
+			"source_branch":"dark-mode","target_branch":"main","head_pipeline":{"id":42,"status":"failed","sha":"abc123","web_url":"https://gitlab.example.com/pipelines/42"}}`,
+		"/api/v4/projects/group/sub/app/merge_requests/7/diffs":     `[{"old_path":"theme.go","new_path":"theme.go","diff":"@@ -1 +1 @@\n-light\n+dark\n"}]`,
+		"/api/v4/projects/group/sub/app/merge_requests/7/approvals": `{"approved_by":[{"user":{"username":"bob"}}]}`,
+		"/api/v4/projects/group/sub/app/merge_requests/7/notes": `[
+			{"body":"added 1 commit","author":{"username":"alice"},"system":true},
+			{"body":"Looks good","author":{"username":"bob"},"system":false},
+			{"body":"Typo here","author":{"username":"carol"},"system":false,"position":{"new_path":"theme.go","new_line":1}}]`,
+		"/api/v4/projects/group/sub/app/pipelines/42/jobs": `[{"name":"unit","stage":"test","status":"failed","web_url":"https://gitlab.example.com/jobs/1"},
+			{"name":"lint","stage":"test","status":"success","web_url":"https://gitlab.example.com/jobs/2"}]`,
+		"/api/v4/projects/group/sub/app/issues/3":       `{"iid":3,"title":"Dark mode","state":"opened","description":"Please","web_url":"https://gitlab.example.com/group/sub/app/-/issues/3","author":{"username":"dave"},"labels":["feature"]}`,
+		"/api/v4/projects/group/sub/app/issues/3/notes": `[{"body":"+1","author":{"username":"erin"},"system":false}]`,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Project paths are escaped in the request
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(response))
+	}))
+}
+
+func TestGitLabClient(t *testing.T) {
+	server := newFakeGitLab()
+	defer server.Close()
+	client := newGitLabClient(server.URL, server.Client())
+
+	t.Run("merge request", func(t *testing.T) {
+		mr, err := client.GetPullRequest(context.Background(), "group/sub/app", 7)
+		require.NoError(t, err)
+		require.Equal(t, &PullRequest{
+			Number:       7,
+			Title:        "Add dark mode",
+			State:        "draft",
+			Author:       "alice",
+			URL:          "https://gitlab.example.com/group/sub/app/-/merge_requests/7",
+			Body:         "Closes #3",
+			SourceBranch: "dark-mode",
+			TargetBranch: "main",
+			Diff:         "diff --git a/theme.go b/theme.go\n--- a/theme.go\n+++ b/theme.go\n@@ -1 +1 @@\n-light\n+dark\n",
+			Reviews:      []Comment{{Author: "bob", State: "approved"}},
+			Comments: []Comment{
+				{Author: "bob", Body: "Looks good"},
+				{Author: "carol", Body: "Typo here", Path: "theme.go", Line: 1},
+			},
+		}, mr)
+	})
+
+	t.Run("issue", func(t *testing.T) {
+		issue, err := client.GetIssue(context.Background(), "group/sub/app", 3)
+		require.NoError(t, err)
+		require.Equal(t, "Dark mode", issue.Title)
+		require.Equal(t, []string{"feature"}, issue.Labels)
+		require.Equal(t, []Comment{{Author: "erin", Body: "+1"}}, issue.Comments)
+	})
+
+	t.Run("pipeline", func(t *testing.T) {
+		status, err := client.GetCIStatus(context.Background(), "group/sub/app", 7)
+		require.NoError(t, err)
+		require.Equal(t, &CIStatus{
+			Ref:   "abc123",
+			State: "failure",
+			URL:   "https://gitlab.example.com/pipelines/42",
+			Checks: []Check{
+				{Name: "test: unit", Status: "failed", URL: "https://gitlab.example.com/jobs/1"},
+				{Name: "test: lint", Status: "success", URL: "https://gitlab.example.com/jobs/2"},
+			},
+		}, status)
+	})
+
+	t.Run("missing merge request", func(t *testing.T) {
+		_, err := client.GetPullRequest(context.Background(), "group/sub/app", 8)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("invalid project", func(t *testing.T) {
+		_, err := client.GetIssue(context.Background(), "app", 3)
+		require.Error(t, err)
+	})
+}
+
+func TestOverallState(t *testing.T) {
+	tests := []struct {
+		name     string
+		checks   []Check
+		expected string
+	}{
+		{name: "no checks", expected: "none"},
+		{name: "all passed", checks: []Check{{Status: "success"}, {Status: "skipped"}}, expected: "success"},
+		{name: "running", checks: []Check{{Status: "success"}, {Status: "in_progress"}}, expected: "pending"},
+		{name: "failure wins", checks: []Check{{Status: "queued"}, {Status: "failure"}}, expected: "failure"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, overallState(test.checks))
+		})
+	}
+}
@@ -9,32 +9,67 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/mattermost/mattermost-plugin-ai/calendars"
+	"github.com/mattermost/mattermost-plugin-ai/codehosts"
+	"github.com/mattermost/mattermost-plugin-ai/connectors"
+	"github.com/mattermost/mattermost-plugin-ai/customtools"
+	"github.com/mattermost/mattermost-plugin-ai/diagrams"
 	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/jiracloud"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mcp"
 	"github.com/mattermost/mattermost-plugin-ai/openai"
+	"github.com/mattermost/mattermost-plugin-ai/ratelimit"
+	"github.com/mattermost/mattermost-plugin-ai/routing"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
 )
 
 type Config struct {
-	Services                 []llm.ServiceConfig              `json:"services"`
-	Bots                     []llm.BotConfig                  `json:"bots"`
-	DefaultBotName           string                           `json:"defaultBotName"`
-	TranscriptGenerator      string                           `json:"transcriptBackend"`
-	EnableLLMTrace           bool                             `json:"enableLLMTrace"`
-	EnableTokenUsageLogging  bool                             `json:"enableTokenUsageLogging"`
-	AllowedUpstreamHostnames string                           `json:"allowedUpstreamHostnames"`
-	AllowUnsafeLinks         bool                             `json:"allowUnsafeLinks"`
-	EmbeddingSearchConfig    embeddings.EmbeddingSearchConfig `json:"embeddingSearchConfig"`
-	MCP                      mcp.Config                       `json:"mcp"`
-	WebSearch                WebSearchConfig                  `json:"webSearch"`
+	Services                 []llm.ServiceConfig               `json:"services"`
+	Bots                     []llm.BotConfig                   `json:"bots"`
+	DefaultBotName           string                            `json:"defaultBotName"`
+	TranscriptGenerator      string                            `json:"transcriptBackend"`
+	EnableLLMTrace           bool                              `json:"enableLLMTrace"`
+	EnableTokenUsageLogging  bool                              `json:"enableTokenUsageLogging"`
+	EnableAgentTracing       bool                              `json:"enableAgentTracing"`
+	PayloadCapture           llm.PayloadCaptureConfig          `json:"payloadCapture"`
+	AllowedUpstreamHostnames string                            `json:"allowedUpstreamHostnames"`
+	AllowUnsafeLinks         bool                              `json:"allowUnsafeLinks"`
+	EmbeddingSearchConfig    embeddings.EmbeddingSearchConfig  `json:"embeddingSearchConfig"`
+	MCP                      mcp.Config                        `json:"mcp"`
+	WebSearch                WebSearchConfig                   `json:"webSearch"`
+	Streaming                streaming.Config                  `json:"streaming"`
+	CustomTools              []customtools.ToolConfig          `json:"customTools"`
+	DataExclusions           exclusions.Config                 `json:"dataExclusions"`
+	RateLimit                ratelimit.Config                  `json:"rateLimit"`
+	Diagrams                 diagrams.Config                   `json:"diagrams"`
+	WolframAlpha             WolframAlphaConfig                `json:"wolframAlpha"`
+	CodeHosts                codehosts.Config                  `json:"codeHosts"`
+	Jira                     jiracloud.Config                  `json:"jira"`
+	Calendars                calendars.Config                  `json:"calendars"`
+	IncidentCopilot          IncidentCopilotConfig             `json:"incidentCopilot"`
+	SupportTriage            []SupportTriageChannelConfig      `json:"supportTriage"`
+	DuplicateQuestions       []DuplicateQuestionsChannelConfig `json:"duplicateQuestions"`
+	FAQBuilder               FAQBuilderConfig                  `json:"faqBuilder"`
+	Standups                 StandupsConfig                    `json:"standups"`
+	Digests                  DigestsConfig                     `json:"digests"`
+	Connectors               connectors.Config                 `json:"connectors"`
+	Routing                  routing.Config                    `json:"routing"`
+	MaxIntervalPosts         int                               `json:"maxIntervalPosts"` // Optional, defaults to 200 posts
+	Retention                RetentionConfig                   `json:"retention"`
+	Guardrails               llm.GuardrailPolicy               `json:"guardrails"`
+	Verification             VerificationConfig                `json:"verification"`
+	ReactionTriggers         ReactionTriggersConfig            `json:"reactionTriggers"`
 }
 
 type WebSearchConfig struct {
-	Enabled        bool                  `json:"enabled"`
-	Provider       string                `json:"provider"`
-	Google         WebSearchGoogleConfig `json:"google"`
-	Brave          WebSearchBraveConfig  `json:"brave"`
-	DomainDenylist []string              `json:"domainDenylist"`
+	Enabled        bool                   `json:"enabled"`
+	Provider       string                 `json:"provider"`
+	Google         WebSearchGoogleConfig  `json:"google"`
+	Brave          WebSearchBraveConfig   `json:"brave"`
+	SearxNG        WebSearchSearxNGConfig `json:"searxng"`
+	DomainDenylist []string               `json:"domainDenylist"`
 }
 
 type WebSearchGoogleConfig struct {
@@ -52,6 +87,121 @@ type WebSearchBraveConfig struct {
 	PollInterval int    `json:"pollInterval"`
 }
 
+// WebSearchSearxNGConfig configures a self-hosted SearxNG instance with the json format enabled
+type WebSearchSearxNGConfig struct {
+	APIURL      string `json:"apiURL"`
+	APIKey      string `json:"apiKey"` // Optional, sent as a bearer token to instances behind a proxy
+	ResultLimit int    `json:"resultLimit"`
+}
+
+// WolframAlphaConfig configures the wolfram_alpha tool, which sends queries to the Wolfram|Alpha LLM API
+type WolframAlphaConfig struct {
+	Enabled bool   `json:"enabled"`
+	AppID   string `json:"appID"`
+	APIURL  string `json:"apiURL"` // Optional, defaults to the public LLM API
+}
+
+// IncidentCopilotConfig configures the incident copilot, which keeps a rolling summary in the channels of Playbooks runs
+type IncidentCopilotConfig struct {
+	Enabled               bool `json:"enabled"`
+	UpdateIntervalMinutes int  `json:"updateIntervalMinutes"` // Optional, defaults to 15 minutes
+}
+
+// FAQBuilderConfig configures the FAQs drafted from the recurring questions of channels
+type FAQBuilderConfig struct {
+	Enabled    bool `json:"enabled"`
+	MaxEntries int  `json:"maxEntries"` // Optional, defaults to 15 questions
+}
+
+// RetentionConfig configures the daily purge of the data kept by the plugin. The retention of
+// each kind of data is in days, 0 keeps it until the Mattermost data retention policy of its
+// channel deletes the messages.
+type RetentionConfig struct {
+	Enabled          bool `json:"enabled"`
+	ConversationDays int  `json:"conversationDays"`
+	TraceDays        int  `json:"traceDays"`
+	UsageEventDays   int  `json:"usageEventDays"`
+	EmbeddingDays    int  `json:"embeddingDays"`
+	CacheDays        int  `json:"cacheDays"`
+}
+
+// VerificationConfig configures the second pass reviewing the responses of the features answering
+// from retrieved content, which flags the claims the content doesn't support before the response
+// is finalized.
+type VerificationConfig struct {
+	Features   []string `json:"features"`   // The features verified, for example channel_analysis
+	CheckerBot string   `json:"checkerBot"` // Optional, defaults to the agent answering
+}
+
+// StandupsConfig configures the daily standups team admins schedule for their teams
+type StandupsConfig struct {
+	Enabled bool `json:"enabled"`
+}
+
+// DigestsConfig configures the digests of RSS and Atom feeds and of inbound email posted to channels
+type DigestsConfig struct {
+	// InboundEmailSecret authenticates the requests of the inbound email webhook. Email sources
+	// receive nothing while it's empty.
+	InboundEmailSecret string               `json:"inboundEmailSecret"`
+	Sources            []DigestSourceConfig `json:"sources"`
+}
+
+// DigestSourceConfig registers a feed or an inbound email address whose content is summarized in a channel
+type DigestSourceConfig struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Type            string `json:"type"`    // "feed" or "email"
+	URL             string `json:"url"`     // The RSS or Atom feed of feed sources
+	Address         string `json:"address"` // The recipient address of email sources
+	ChannelID       string `json:"channelID"`
+	BotUsername     string `json:"botUsername"`     // Optional, defaults to the default bot
+	IntervalMinutes int    `json:"intervalMinutes"` // Optional, defaults to 60 minutes
+	Instructions    string `json:"instructions"`    // Optional guidance, such as what to focus on
+}
+
+// SupportTriageChannelConfig designates a channel whose incoming messages are triaged by an agent
+type SupportTriageChannelConfig struct {
+	ChannelID   string `json:"channelID"`
+	BotUsername string `json:"botUsername"` // Optional, defaults to the default bot
+	// TeamUsernames are the people answering in the channel, whose messages aren't triaged and who
+	// can be suggested as owners. When empty, the messages of everyone but bots are triaged.
+	TeamUsernames   []string `json:"teamUsernames"`
+	Categories      []string `json:"categories"`      // Optional, defaults to general support categories
+	Instructions    string   `json:"instructions"`    // Optional guidance, such as who handles which category
+	TriageChannelID string   `json:"triageChannelID"` // Optional, the classification is replied in the thread of the message when empty
+}
+
+// DuplicateQuestionsChannelConfig designates a channel where new questions are linked to similar questions answered before
+type DuplicateQuestionsChannelConfig struct {
+	ChannelID   string `json:"channelID"`
+	BotUsername string `json:"botUsername"` // Optional, defaults to the default bot
+	// MinScore is the similarity, between 0 and 1, previous questions must reach to be linked.
+	// Optional, defaults to 0.8.
+	MinScore   float32 `json:"minScore"`
+	MaxResults int     `json:"maxResults"` // Optional, defaults to 3
+	SearchTeam bool    `json:"searchTeam"` // Search every channel of the team instead of only the channel
+}
+
+// ReactionTriggersConfig lets users run AI actions on a post by reacting to it with an emoji,
+// the results being sent to them by direct message
+type ReactionTriggersConfig struct {
+	Enabled     bool   `json:"enabled"`
+	BotUsername string `json:"botUsername"` // Optional, defaults to the default bot
+	// Triggers map the emoji to their action. Optional, defaults to reactiontriggers.DefaultTriggers
+	Triggers []ReactionTrigger `json:"triggers"`
+	// ChannelIDs are the channels reactions run actions in. Optional, every channel when empty
+	ChannelIDs []string `json:"channelIDs"`
+	// DebounceSeconds is how long before a user can run the same action on a post again. Optional, defaults to 60
+	DebounceSeconds int `json:"debounceSeconds"`
+}
+
+// ReactionTrigger is an emoji running an action on the post it's added to
+type ReactionTrigger struct {
+	EmojiName string `json:"emojiName"` // The name of the emoji without colons, such as robot_face
+	Action    string `json:"action"`    // summarize_thread, translate or explain
+	Language  string `json:"language"`  // Optional, the language translated into, defaults to the language of the user
+}
+
 func (c *Config) Clone() *Config {
 	clone, err := DeepCopyJSON(*c)
 	if err != nil {
@@ -104,10 +254,170 @@ func (c *Container) EnableLLMLogging() bool {
 	return c.cfg.Load().EnableLLMTrace
 }
 
+// EnableAgentTracing returns whether the full traces of bot responses are stored for admins to inspect
+func (c *Container) EnableAgentTracing() bool {
+	return c.cfg.Load().EnableAgentTracing
+}
+
 func (c *Container) EnableTokenUsageLogging() bool {
 	return c.cfg.Load().EnableTokenUsageLogging
 }
 
+// GetPayloadCapture returns the configuration of the capture of provider payloads for debugging
+func (c *Container) GetPayloadCapture() llm.PayloadCaptureConfig {
+	return c.cfg.Load().PayloadCapture
+}
+
+// GetSupportTriageChannels returns the channels whose incoming messages are triaged
+func (c *Container) GetSupportTriageChannels() []SupportTriageChannelConfig {
+	return c.cfg.Load().SupportTriage
+}
+
+// GetDigests returns the configuration of the feed and email digests
+func (c *Container) GetDigests() DigestsConfig {
+	return c.cfg.Load().Digests
+}
+
+// GetConnectors returns the external sources of documents indexed for search
+func (c *Container) GetConnectors() connectors.Config {
+	return c.cfg.Load().Connectors
+}
+
+// GetDuplicateQuestionsChannels returns the channels where new questions are linked to previous answers
+func (c *Container) GetDuplicateQuestionsChannels() []DuplicateQuestionsChannelConfig {
+	return c.cfg.Load().DuplicateQuestions
+}
+
+// GetCustomTools returns the admin defined tools answered by HTTP endpoints
+func (c *Container) GetCustomTools() []customtools.ToolConfig {
+	return c.cfg.Load().CustomTools
+}
+
+// GetRouting returns the rules choosing the agent answering in a channel
+func (c *Container) GetRouting() routing.Config {
+	return c.cfg.Load().Routing
+}
+
+// GetMaxIntervalPosts returns the number of posts summarized for a time range, 0 for the default
+func (c *Container) GetMaxIntervalPosts() int {
+	return c.cfg.Load().MaxIntervalPosts
+}
+
+// GetRetention returns the retention of the data kept by the plugin
+func (c *Container) GetRetention() RetentionConfig {
+	return c.cfg.Load().Retention
+}
+
+// GetGuardrails returns the guardrail policy compiled into the system prompt of every request
+func (c *Container) GetGuardrails() llm.GuardrailPolicy {
+	return c.cfg.Load().Guardrails
+}
+
+// GetVerification returns the features whose responses are verified against their sources
+func (c *Container) GetVerification() VerificationConfig {
+	return c.cfg.Load().Verification
+}
+
+// GetReactionTriggers returns the emoji reactions running AI actions
+func (c *Container) GetReactionTriggers() ReactionTriggersConfig {
+	return c.cfg.Load().ReactionTriggers
+}
+
+// GetDataExclusions returns the channels and teams whose content is never sent to LLM providers
+func (c *Container) GetDataExclusions() exclusions.Config {
+	return c.cfg.Load().DataExclusions
+}
+
+// RateLimit returns the limits on how often users may call the plugin API
+func (c *Container) RateLimit() ratelimit.Config {
+	cfg := c.cfg.Load()
+	if cfg == nil {
+		return ratelimit.Config{}
+	}
+
+	return cfg.RateLimit
+}
+
+// Diagrams returns how diagram blocks in responses are rendered to images
+func (c *Container) Diagrams() diagrams.Config {
+	cfg := c.cfg.Load()
+	if cfg == nil {
+		return diagrams.Config{}
+	}
+
+	return cfg.Diagrams
+}
+
+// WolframAlpha returns the configuration of the wolfram_alpha tool
+func (c *Container) WolframAlpha() WolframAlphaConfig {
+	cfg := c.cfg.Load()
+	if cfg == nil {
+		return WolframAlphaConfig{}
+	}
+
+	return cfg.WolframAlpha
+}
+
+// CodeHosts returns the GitHub and GitLab instances users can connect to
+func (c *Container) CodeHosts() codehosts.Config {
+	cfg := c.cfg.Load()
+	if cfg == nil {
+		return codehosts.Config{}
+	}
+
+	return cfg.CodeHosts
+}
+
+// Jira returns the OAuth app users connect their Atlassian account with
+func (c *Container) Jira() jiracloud.Config {
+	cfg := c.cfg.Load()
+	if cfg == nil {
+		return jiracloud.Config{}
+	}
+
+	return cfg.Jira
+}
+
+// Calendars returns the calendar providers users can connect to
+func (c *Container) Calendars() calendars.Config {
+	cfg := c.cfg.Load()
+	if cfg == nil {
+		return calendars.Config{}
+	}
+
+	return cfg.Calendars
+}
+
+// FAQBuilder returns the configuration of the FAQ builder
+func (c *Container) FAQBuilder() FAQBuilderConfig {
+	cfg := c.cfg.Load()
+	if cfg == nil {
+		return FAQBuilderConfig{}
+	}
+
+	return cfg.FAQBuilder
+}
+
+// Standups returns the configuration of the daily standups
+func (c *Container) Standups() StandupsConfig {
+	cfg := c.cfg.Load()
+	if cfg == nil {
+		return StandupsConfig{}
+	}
+
+	return cfg.Standups
+}
+
+// IncidentCopilot returns the configuration of the copilot of Playbooks runs
+func (c *Container) IncidentCopilot() IncidentCopilotConfig {
+	cfg := c.cfg.Load()
+	if cfg == nil {
+		return IncidentCopilotConfig{}
+	}
+
+	return cfg.IncidentCopilot
+}
+
 func (c *Container) MCP() mcp.Config {
 	return c.cfg.Load().MCP
 }
@@ -121,6 +431,15 @@ func (c *Container) AllowUnsafeLinks() bool {
 	return cfg.AllowUnsafeLinks
 }
 
+func (c *Container) StreamingConfig() streaming.Config {
+	cfg := c.cfg.Load()
+	if cfg == nil {
+		return streaming.Config{}
+	}
+
+	return cfg.Streaming
+}
+
 func (c *Container) RegisterUpdateListener(listener UpdateListener) {
 	c.listeners = append(c.listeners, listener)
 }
@@ -191,6 +510,10 @@ func OpenAIConfigFromServiceConfig(serviceConfig llm.ServiceConfig, botConfig ll
 		EnabledNativeTools: botConfig.EnabledNativeTools,
 		ReasoningEnabled:   botConfig.ReasoningEnabled,
 		ReasoningEffort:    botConfig.ReasoningEffort,
+		ZeroDataRetention:  serviceConfig.EnforcesZeroDataRetention(),
+
+		WebSearchContextSize:  botConfig.NativeWebSearch.SearchContextSize,
+		WebSearchUserLocation: botConfig.NativeWebSearch.UserLocation,
 	}
 }
 
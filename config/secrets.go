@@ -0,0 +1,154 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SecretTransform returns the replacement of a provider credential, given a path describing
+// the credential and its current value
+type SecretTransform func(path, value string) (string, error)
+
+// TransformSecrets replaces every provider credential of the configuration with the result of
+// transform. See TransformSecretsInMap.
+func (c *Config) TransformSecrets(transform SecretTransform) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	var values map[string]any
+	if err = json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("failed to unmarshal configuration: %w", err)
+	}
+
+	transformErr := TransformSecretsInMap(values, transform)
+
+	data, err = json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	var transformed Config
+	if err = json.Unmarshal(data, &transformed); err != nil {
+		return fmt.Errorf("failed to unmarshal configuration: %w", err)
+	}
+	*c = transformed
+
+	return transformErr
+}
+
+// TransformSecretsInMap replaces every provider credential of the configuration, as stored in
+// the plugin settings, with the result of transform. Working on the stored form keeps fields
+// only known to older versions for the migrations. Empty values are skipped. All credentials
+// are transformed even when some fail, and the errors are joined.
+func TransformSecretsInMap(values map[string]any, transform SecretTransform) error {
+	var errs []error
+	apply := func(path string, object map[string]any, key string) {
+		value, ok := object[key].(string)
+		if !ok || value == "" {
+			return
+		}
+		transformed, err := transform(path, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			return
+		}
+		object[key] = transformed
+	}
+	applyService := func(prefix string, service map[string]any) {
+		apply(prefix+".apiKey", service, "apiKey")
+		apply(prefix+".awsSecretAccessKey", service, "awsSecretAccessKey")
+	}
+
+	for _, item := range objects(values["services"]) {
+		applyService(fmt.Sprintf("services.%v", item["name"]), item)
+	}
+
+	// Services embedded in bots are kept for backwards compatibility
+	for _, item := range objects(values["bots"]) {
+		if service, ok := item["service"].(map[string]any); ok {
+			applyService(fmt.Sprintf("bots.%v.service", item["name"]), service)
+		}
+	}
+
+	if webSearch, ok := values["webSearch"].(map[string]any); ok {
+		for _, provider := range []string{"google", "brave", "searxng"} {
+			if providerConfig, ok := webSearch[provider].(map[string]any); ok {
+				apply("webSearch."+provider+".apiKey", providerConfig, "apiKey")
+			}
+		}
+	}
+
+	if wolframAlpha, ok := values["wolframAlpha"].(map[string]any); ok {
+		apply("wolframAlpha.appID", wolframAlpha, "appID")
+	}
+
+	if codeHosts, ok := values["codeHosts"].(map[string]any); ok {
+		for _, host := range []string{"github", "gitlab"} {
+			if hostConfig, ok := codeHosts[host].(map[string]any); ok {
+				apply("codeHosts."+host+".clientSecret", hostConfig, "clientSecret")
+			}
+		}
+	}
+
+	if jira, ok := values["jira"].(map[string]any); ok {
+		apply("jira.clientSecret", jira, "clientSecret")
+	}
+
+	if calendars, ok := values["calendars"].(map[string]any); ok {
+		for _, provider := range []string{"google", "microsoft"} {
+			if providerConfig, ok := calendars[provider].(map[string]any); ok {
+				apply("calendars."+provider+".clientSecret", providerConfig, "clientSecret")
+			}
+		}
+	}
+
+	for _, item := range objects(values["customTools"]) {
+		apply(fmt.Sprintf("customTools.%v.authValue", item["name"]), item, "authValue")
+	}
+
+	if digests, ok := values["digests"].(map[string]any); ok {
+		apply("digests.inboundEmailSecret", digests, "inboundEmailSecret")
+	}
+
+	if connectors, ok := values["connectors"].(map[string]any); ok {
+		for _, source := range objects(connectors["sources"]) {
+			prefix := fmt.Sprintf("connectors.%v", source["name"])
+			if confluence, ok := source["confluence"].(map[string]any); ok {
+				apply(prefix+".confluence.apiToken", confluence, "apiToken")
+			}
+			if googleDrive, ok := source["googleDrive"].(map[string]any); ok {
+				apply(prefix+".googleDrive.serviceAccountKey", googleDrive, "serviceAccountKey")
+			}
+		}
+	}
+
+	if embeddingSearch, ok := values["embeddingSearchConfig"].(map[string]any); ok {
+		if provider, ok := embeddingSearch["embeddingProvider"].(map[string]any); ok {
+			if parameters, ok := provider["parameters"].(map[string]any); ok {
+				apply("embeddingSearchConfig.embeddingProvider.parameters.apiKey", parameters, "apiKey")
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// objects returns the objects of a JSON array
+func objects(value any) []map[string]any {
+	items, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+
+	result := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		if object, ok := item.(map[string]any); ok {
+			result = append(result, object)
+		}
+	}
+	return result
+}
@@ -0,0 +1,265 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	confluencePageSize = 50
+	// maxDocuments bounds the documents indexed of each source
+	maxDocuments = 10000
+	// maxResponseSize bounds the responses read from the sources
+	maxResponseSize = 10 * 1024 * 1024
+)
+
+// ConfluenceConfig configures a Confluence Cloud source, read with the API token of an account
+type ConfluenceConfig struct {
+	URL       string   `json:"url"` // For example https://example.atlassian.net
+	Email     string   `json:"email"`
+	APIToken  string   `json:"apiToken"`
+	SpaceKeys []string `json:"spaceKeys"`
+}
+
+// Confluence reads the pages of Confluence spaces. The read restrictions of the pages and of
+// their ancestors are mapped to principals.
+type Confluence struct {
+	source     SourceConfig
+	httpClient *http.Client
+}
+
+// NewConfluence creates a connector reading the Confluence spaces of source
+func NewConfluence(source SourceConfig, httpClient *http.Client) *Confluence {
+	return &Confluence{
+		source:     source,
+		httpClient: httpClient,
+	}
+}
+
+type confluenceRestrictions struct {
+	Read struct {
+		Restrictions struct {
+			User struct {
+				Results []struct {
+					Email string `json:"email"`
+				} `json:"results"`
+			} `json:"user"`
+			Group struct {
+				Results []struct {
+					Name string `json:"name"`
+				} `json:"results"`
+			} `json:"group"`
+		} `json:"restrictions"`
+	} `json:"read"`
+}
+
+type confluencePage struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Version struct {
+		When string `json:"when"`
+	} `json:"version"`
+	Restrictions confluenceRestrictions `json:"restrictions"`
+	Ancestors    []struct {
+		Restrictions confluenceRestrictions `json:"restrictions"`
+	} `json:"ancestors"`
+	Body struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body"`
+	Links struct {
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+}
+
+type confluencePages struct {
+	Results []confluencePage `json:"results"`
+	Size    int              `json:"size"`
+	Links   struct {
+		Base string `json:"base"`
+	} `json:"_links"`
+}
+
+// List lists the pages of the spaces with their restrictions
+func (c *Confluence) List(ctx context.Context) ([]Document, error) {
+	restrictionsExpand := "restrictions.read.restrictions.user,restrictions.read.restrictions.group"
+	var docs []Document
+	for _, spaceKey := range c.source.Confluence.SpaceKeys {
+		for start := 0; len(docs) < maxDocuments; start += confluencePageSize {
+			query := url.Values{}
+			query.Set("spaceKey", spaceKey)
+			query.Set("type", "page")
+			query.Set("status", "current")
+			query.Set("start", strconv.Itoa(start))
+			query.Set("limit", strconv.Itoa(confluencePageSize))
+			query.Set("expand", "version,"+restrictionsExpand+",ancestors."+strings.ReplaceAll(restrictionsExpand, ",", ",ancestors."))
+
+			var pages confluencePages
+			if err := c.get(ctx, "/wiki/rest/api/content?"+query.Encode(), &pages); err != nil {
+				return nil, fmt.Errorf("failed to list the pages of space %s: %w", spaceKey, err)
+			}
+			for _, page := range pages.Results {
+				docs = append(docs, c.document(page, pages.Links.Base))
+			}
+			if pages.Size < confluencePageSize {
+				break
+			}
+		}
+	}
+	return docs, nil
+}
+
+// Content returns the text of the page
+func (c *Confluence) Content(ctx context.Context, doc Document) (string, error) {
+	var page confluencePage
+	if err := c.get(ctx, "/wiki/rest/api/content/"+url.PathEscape(doc.ID)+"?expand=body.storage", &page); err != nil {
+		return "", fmt.Errorf("failed to get page %s: %w", doc.ID, err)
+	}
+	return storageText(page.Body.Storage.Value), nil
+}
+
+func (c *Confluence) document(page confluencePage, base string) Document {
+	updateAt := int64(0)
+	if when, err := time.Parse(time.RFC3339, page.Version.When); err == nil {
+		updateAt = when.UnixMilli()
+	}
+	pageURL := ""
+	if page.Links.WebUI != "" {
+		if base == "" {
+			base = strings.TrimRight(c.source.Confluence.URL, "/") + "/wiki"
+		}
+		pageURL = base + page.Links.WebUI
+	}
+
+	// Restrictions are inherited, the page can only be read by who all the restricted levels allow
+	var principals []string
+	restricted := false
+	levels := []confluenceRestrictions{page.Restrictions}
+	for _, ancestor := range page.Ancestors {
+		levels = append(levels, ancestor.Restrictions)
+	}
+	for _, level := range levels {
+		allowed, ok := c.restrictionPrincipals(level)
+		if !ok {
+			continue
+		}
+		if !restricted {
+			principals = allowed
+			restricted = true
+			continue
+		}
+		principals = intersect(principals, allowed)
+	}
+	if !restricted {
+		principals = c.source.unrestrictedPrincipals()
+	}
+
+	return Document{
+		ID:         page.ID,
+		Title:      page.Title,
+		URL:        pageURL,
+		UpdateAt:   updateAt,
+		Principals: normalizePrincipals(principals),
+	}
+}
+
+// restrictionPrincipals returns the principals allowed by the read restrictions, false when
+// nothing is restricted
+func (c *Confluence) restrictionPrincipals(restrictions confluenceRestrictions) ([]string, bool) {
+	users := restrictions.Read.Restrictions.User.Results
+	groups := restrictions.Read.Restrictions.Group.Results
+	if len(users) == 0 && len(groups) == 0 {
+		return nil, false
+	}
+	principals := []string{}
+	for _, user := range users {
+		// Users hiding their email address can't be mapped
+		if user.Email != "" {
+			principals = append(principals, EmailPrincipal(user.Email))
+		}
+	}
+	for _, group := range groups {
+		principals = append(principals, c.source.groupPrincipals(group.Name)...)
+	}
+	return principals, true
+}
+
+func (c *Confluence) get(ctx context.Context, path string, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(c.source.Confluence.URL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.source.Confluence.Email, c.source.Confluence.APIToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(result)
+}
+
+// storageText extracts the text of a page in the Confluence storage format, keeping paragraphs
+// and list items on lines of their own
+func storageText(storage string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(storage), nil)
+	if err != nil {
+		return storage
+	}
+
+	var builder strings.Builder
+	var extract func(n *html.Node)
+	extract = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+			return
+		}
+		if n.Type == html.TextNode {
+			builder.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			extract(child)
+		}
+		if n.Type == html.ElementNode && blockElements[n.Data] {
+			builder.WriteString("\n")
+		}
+	}
+	for _, node := range nodes {
+		extract(node)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(builder.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "table": true, "pre": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "blockquote": true,
+}
+
+// intersect returns the elements of a that are in b
+func intersect(a, b []string) []string {
+	return slices.DeleteFunc(slices.Clone(a), func(element string) bool { return !slices.Contains(b, element) })
+}
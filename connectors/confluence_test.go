@@ -0,0 +1,87 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package connectors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const confluencePagesResponse = `{
+  "results": [
+    {
+      "id": "1",
+      "title": "Onboarding",
+      "version": {"when": "2025-03-10T12:00:00.000Z"},
+      "restrictions": {"read": {"restrictions": {"user": {"results": []}, "group": {"results": []}}}},
+      "ancestors": [],
+      "_links": {"webui": "/spaces/ENG/pages/1/Onboarding"}
+    },
+    {
+      "id": "2",
+      "title": "Salaries",
+      "version": {"when": "2025-03-11T12:00:00.000Z"},
+      "restrictions": {"read": {"restrictions": {
+        "user": {"results": [{"email": "Alice@example.com"}, {"email": ""}, {"email": "bob@example.com"}]},
+        "group": {"results": [{"name": "HR"}, {"name": "unmapped"}]}
+      }}},
+      "ancestors": [
+        {"restrictions": {"read": {"restrictions": {"user": {"results": []}, "group": {"results": []}}}}},
+        {"restrictions": {"read": {"restrictions": {"user": {"results": [{"email": "alice@example.com"}]}, "group": {"results": [{"name": "hr"}]}}}}}
+      ],
+      "_links": {"webui": "/spaces/ENG/pages/2/Salaries"}
+    }
+  ],
+  "size": 2,
+  "_links": {"base": "https://example.atlassian.net/wiki"}
+}`
+
+func TestConfluence(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, token, ok := r.BasicAuth()
+		if !ok || user != "indexer@example.com" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/wiki/rest/api/content":
+			assert.Equal(t, "ENG", r.URL.Query().Get("spaceKey"))
+			_, _ = w.Write([]byte(confluencePagesResponse))
+		case "/wiki/rest/api/content/1":
+			_, _ = w.Write([]byte(`{"id": "1", "body": {"storage": {"value": "<h1>Welcome</h1><p>Read the  <strong>handbook</strong>.</p><ul><li>Laptop</li><li>Badge</li></ul>"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	confluence := NewConfluence(SourceConfig{
+		Type:       SourceTypeConfluence,
+		GroupTeams: map[string][]string{"hr": {"team-hr"}},
+		Confluence: ConfluenceConfig{URL: server.URL, Email: "indexer@example.com", APIToken: "secret", SpaceKeys: []string{"ENG"}},
+	}, server.Client())
+
+	docs, err := confluence.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	assert.Equal(t, Document{
+		ID:         "1",
+		Title:      "Onboarding",
+		URL:        "https://example.atlassian.net/wiki/spaces/ENG/pages/1/Onboarding",
+		UpdateAt:   1741608000000,
+		Principals: []string{PrincipalEveryone},
+	}, docs[0])
+	// Bob is allowed by the page but not by its parent
+	assert.Equal(t, []string{"email:alice@example.com", "team:team-hr"}, docs[1].Principals)
+
+	content, err := confluence.Content(context.Background(), docs[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome\nRead the handbook.\nLaptop\nBadge", content)
+}
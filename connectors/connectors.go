@@ -0,0 +1,142 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package connectors indexes the documents of external sources, such as Confluence spaces and
+// Google Drive folders, in the vector store so agents can ground their answers on them.
+//
+// The read access of each document in its source is mapped to principals: everyone, the email
+// address of a user, or a Mattermost team whose members are allowed what a group of the source
+// is. Search results only include the documents the requesting user is a principal of.
+package connectors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	SourceTypeConfluence  = "confluence"
+	SourceTypeGoogleDrive = "google_drive"
+
+	// PrincipalEveryone allows every user of the server to read a document
+	PrincipalEveryone = "everyone"
+	principalEmail    = "email:"
+	principalTeam     = "team:"
+)
+
+// Config configures the external sources indexed
+type Config struct {
+	Sources []SourceConfig `json:"sources"`
+}
+
+// SourceConfig registers an external source of documents
+type SourceConfig struct {
+	ID                  string `json:"id"`
+	Name                string `json:"name"`
+	Type                string `json:"type"`                // "confluence" or "google_drive"
+	SyncIntervalMinutes int    `json:"syncIntervalMinutes"` // Optional, defaults to 360 minutes
+
+	// GroupTeams maps the groups of the source, by name for Confluence and by email address for
+	// Google Drive, to the IDs of the teams whose members may read what the group can
+	GroupTeams map[string][]string `json:"groupTeams"`
+	// Teams are the IDs of the teams whose members may read the documents the source doesn't
+	// restrict. Optional, everyone may read them when empty.
+	Teams []string `json:"teams"`
+
+	Confluence  ConfluenceConfig  `json:"confluence"`
+	GoogleDrive GoogleDriveConfig `json:"googleDrive"`
+}
+
+// Document is a document of a source
+type Document struct {
+	ID       string
+	Title    string
+	URL      string
+	Content  string
+	UpdateAt int64 // In milliseconds
+	// Principals are who may read the document, see UserPrincipals
+	Principals []string
+}
+
+// Connector reads the documents of a source
+type Connector interface {
+	// List lists all the documents of the source, without their content
+	List(ctx context.Context) ([]Document, error)
+	// Content returns the text of a document
+	Content(ctx context.Context, doc Document) (string, error)
+}
+
+// NewConnector creates the connector reading the documents of source
+func NewConnector(source SourceConfig, httpClient *http.Client) (Connector, error) {
+	switch source.Type {
+	case SourceTypeConfluence:
+		return NewConfluence(source, httpClient), nil
+	case SourceTypeGoogleDrive:
+		return NewGoogleDrive(source, httpClient)
+	default:
+		return nil, fmt.Errorf("unknown source type %q", source.Type)
+	}
+}
+
+// EmailPrincipal is the principal of the user with the email address
+func EmailPrincipal(email string) string {
+	return principalEmail + strings.ToLower(strings.TrimSpace(email))
+}
+
+// TeamPrincipal is the principal of the members of the team
+func TeamPrincipal(teamID string) string {
+	return principalTeam + teamID
+}
+
+// UserPrincipals returns the principals of user, member of teams. The email address is only
+// trusted once verified, so nobody can read the documents of another user by taking their
+// address.
+func UserPrincipals(user *model.User, teams []*model.Team) []string {
+	principals := []string{PrincipalEveryone}
+	if user.EmailVerified && user.Email != "" {
+		principals = append(principals, EmailPrincipal(user.Email))
+	}
+	for _, team := range teams {
+		principals = append(principals, TeamPrincipal(team.Id))
+	}
+	return principals
+}
+
+// unrestrictedPrincipals are the principals of the documents the source doesn't restrict
+func (s SourceConfig) unrestrictedPrincipals() []string {
+	if len(s.Teams) == 0 {
+		return []string{PrincipalEveryone}
+	}
+	principals := make([]string, 0, len(s.Teams))
+	for _, teamID := range s.Teams {
+		principals = append(principals, TeamPrincipal(teamID))
+	}
+	return principals
+}
+
+// groupPrincipals are the principals of the teams mapped to a group of the source, none when the
+// group isn't mapped
+func (s SourceConfig) groupPrincipals(group string) []string {
+	var principals []string
+	for name, teamIDs := range s.GroupTeams {
+		if !strings.EqualFold(name, group) {
+			continue
+		}
+		for _, teamID := range teamIDs {
+			principals = append(principals, TeamPrincipal(teamID))
+		}
+	}
+	return principals
+}
+
+// normalizePrincipals sorts the principals and removes duplicates, so they can be compared
+func normalizePrincipals(principals []string) []string {
+	normalized := slices.Clone(principals)
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
@@ -0,0 +1,20 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package connectors
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserPrincipals(t *testing.T) {
+	user := &model.User{Id: "alice", Email: "Alice@Example.com", EmailVerified: true}
+	teams := []*model.Team{{Id: "team1"}, {Id: "team2"}}
+	assert.Equal(t, []string{"everyone", "email:alice@example.com", "team:team1", "team:team2"}, UserPrincipals(user, teams))
+
+	user.EmailVerified = false
+	assert.Equal(t, []string{"everyone"}, UserPrincipals(user, nil))
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	driveScope       = "https://www.googleapis.com/auth/drive.readonly"
+	driveAPIURL      = "https://www.googleapis.com"
+	drivePageSize    = 100
+	googleDocType    = "application/vnd.google-apps.document"
+	driveFileFields  = "id,name,mimeType,webViewLink,modifiedTime,permissions(type,emailAddress)"
+	permissionUser   = "user"
+	permissionGroup  = "group"
+	permissionDomain = "domain"
+	permissionAnyone = "anyone"
+)
+
+// driveTypes are the types of the files indexed, Google Docs are exported as text
+var driveTypes = []string{googleDocType, "text/plain", "text/markdown"}
+
+// GoogleDriveConfig configures a Google Drive source, read with a service account
+type GoogleDriveConfig struct {
+	// ServiceAccountKey is the JSON key of the service account
+	ServiceAccountKey string `json:"serviceAccountKey"`
+	// Subject is the user the service account impersonates with domain-wide delegation. Optional,
+	// the files shared with the service account are read when empty.
+	Subject string `json:"subject"`
+	// FolderIDs are the folders whose files are indexed. Optional, all the files the account can
+	// read are indexed when empty.
+	FolderIDs []string `json:"folderIDs"`
+	APIURL    string   `json:"apiURL"` // Optional, defaults to https://www.googleapis.com
+}
+
+// GoogleDrive reads the Google Docs and text files of Google Drive. The permissions of the files
+// are mapped to principals.
+type GoogleDrive struct {
+	source     SourceConfig
+	httpClient *http.Client
+	apiURL     string
+}
+
+type serviceAccountKey struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// NewGoogleDrive creates a connector reading the Google Drive of source. Requests are
+// authenticated with tokens of the service account, requested with httpClient.
+func NewGoogleDrive(source SourceConfig, httpClient *http.Client) (*GoogleDrive, error) {
+	var key serviceAccountKey
+	if err := json.Unmarshal([]byte(source.GoogleDrive.ServiceAccountKey), &key); err != nil {
+		return nil, fmt.Errorf("invalid service account key: %w", err)
+	}
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, errors.New("the service account key has no client email or private key")
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	jwtConfig := &jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyID,
+		Scopes:       []string{driveScope},
+		TokenURL:     key.TokenURI,
+		Subject:      source.GoogleDrive.Subject,
+	}
+	tokenCtx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient)
+
+	apiURL := strings.TrimRight(source.GoogleDrive.APIURL, "/")
+	if apiURL == "" {
+		apiURL = driveAPIURL
+	}
+
+	return &GoogleDrive{
+		source: source,
+		httpClient: &http.Client{
+			Transport: &oauth2.Transport{Source: jwtConfig.TokenSource(tokenCtx), Base: httpClient.Transport},
+			Timeout:   httpClient.Timeout,
+		},
+		apiURL: apiURL,
+	}, nil
+}
+
+type drivePermission struct {
+	Type         string `json:"type"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+type driveFile struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	MimeType     string            `json:"mimeType"`
+	WebViewLink  string            `json:"webViewLink"`
+	ModifiedTime string            `json:"modifiedTime"`
+	Permissions  []drivePermission `json:"permissions"`
+}
+
+// List lists the files with their permissions
+func (d *GoogleDrive) List(ctx context.Context) ([]Document, error) {
+	var typeFilters []string
+	for _, mimeType := range driveTypes {
+		typeFilters = append(typeFilters, fmt.Sprintf("mimeType = '%s'", mimeType))
+	}
+	filter := "trashed = false and (" + strings.Join(typeFilters, " or ") + ")"
+	if len(d.source.GoogleDrive.FolderIDs) > 0 {
+		var folderFilters []string
+		for _, folderID := range d.source.GoogleDrive.FolderIDs {
+			folderFilters = append(folderFilters, fmt.Sprintf("'%s' in parents", strings.ReplaceAll(folderID, "'", `\'`)))
+		}
+		filter += " and (" + strings.Join(folderFilters, " or ") + ")"
+	}
+
+	var docs []Document
+	pageToken := ""
+	for len(docs) < maxDocuments {
+		query := url.Values{}
+		query.Set("q", filter)
+		query.Set("fields", "nextPageToken,files("+driveFileFields+")")
+		query.Set("pageSize", fmt.Sprint(drivePageSize))
+		query.Set("supportsAllDrives", "true")
+		query.Set("includeItemsFromAllDrives", "true")
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+
+		var page struct {
+			NextPageToken string      `json:"nextPageToken"`
+			Files         []driveFile `json:"files"`
+		}
+		if err := d.get(ctx, "/drive/v3/files?"+query.Encode(), &page); err != nil {
+			return nil, fmt.Errorf("failed to list files: %w", err)
+		}
+		for _, file := range page.Files {
+			doc, err := d.document(ctx, file)
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, doc)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+	return docs, nil
+}
+
+// Content returns the text of the file, Google Docs are exported as plain text
+func (d *GoogleDrive) Content(ctx context.Context, doc Document) (string, error) {
+	fileID := url.PathEscape(doc.ID)
+	var file driveFile
+	if err := d.get(ctx, "/drive/v3/files/"+fileID+"?fields=mimeType&supportsAllDrives=true", &file); err != nil {
+		return "", fmt.Errorf("failed to get file %s: %w", doc.ID, err)
+	}
+
+	path := "/drive/v3/files/" + fileID + "?alt=media&supportsAllDrives=true"
+	if file.MimeType == googleDocType {
+		path = "/drive/v3/files/" + fileID + "/export?mimeType=text%2Fplain"
+	}
+	resp, err := d.request(ctx, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to download file %s: %w", doc.ID, err)
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", doc.ID, err)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(string(content), "\ufeff")), nil
+}
+
+func (d *GoogleDrive) document(ctx context.Context, file driveFile) (Document, error) {
+	updateAt := int64(0)
+	if modified, err := time.Parse(time.RFC3339, file.ModifiedTime); err == nil {
+		updateAt = modified.UnixMilli()
+	}
+
+	// The permissions of the files of shared drives are only listed file by file
+	permissions := file.Permissions
+	if len(permissions) == 0 {
+		var list struct {
+			Permissions []drivePermission `json:"permissions"`
+		}
+		if err := d.get(ctx, "/drive/v3/files/"+url.PathEscape(file.ID)+"/permissions?fields=permissions(type,emailAddress)&supportsAllDrives=true", &list); err != nil {
+			return Document{}, fmt.Errorf("failed to list the permissions of file %s: %w", file.ID, err)
+		}
+		permissions = list.Permissions
+	}
+
+	principals := []string{}
+	for _, permission := range permissions {
+		switch permission.Type {
+		case permissionUser:
+			principals = append(principals, EmailPrincipal(permission.EmailAddress))
+		case permissionGroup:
+			principals = append(principals, d.source.groupPrincipals(permission.EmailAddress)...)
+		case permissionDomain, permissionAnyone:
+			principals = append(principals, d.source.unrestrictedPrincipals()...)
+		}
+	}
+
+	return Document{
+		ID:         file.ID,
+		Title:      file.Name,
+		URL:        file.WebViewLink,
+		UpdateAt:   updateAt,
+		Principals: normalizePrincipals(principals),
+	}, nil
+}
+
+func (d *GoogleDrive) get(ctx context.Context, path string, result any) error {
+	resp, err := d.request(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(result)
+}
+
+func (d *GoogleDrive) request(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.apiURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp, nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package connectors
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoogleDrive(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token": "token", "token_type": "Bearer", "expires_in": 3600}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/drive/v3/files":
+			assert.Contains(t, r.URL.Query().Get("q"), "'folder1' in parents")
+			_, _ = w.Write([]byte(`{"files": [
+				{"id": "doc1", "name": "Expenses policy", "mimeType": "application/vnd.google-apps.document", "webViewLink": "https://docs.google.com/document/d/doc1", "modifiedTime": "2025-03-10T12:00:00Z",
+				 "permissions": [{"type": "user", "emailAddress": "alice@example.com"}, {"type": "group", "emailAddress": "Finance@example.com"}, {"type": "group", "emailAddress": "other@example.com"}]},
+				{"id": "doc2", "name": "notes.md", "mimeType": "text/markdown", "modifiedTime": "2025-03-11T12:00:00Z"}
+			]}`))
+		case "/drive/v3/files/doc2/permissions":
+			_, _ = w.Write([]byte(`{"permissions": [{"type": "domain"}]}`))
+		case "/drive/v3/files/doc1":
+			_, _ = w.Write([]byte(`{"mimeType": "application/vnd.google-apps.document"}`))
+		case "/drive/v3/files/doc1/export":
+			assert.Equal(t, "text/plain", r.URL.Query().Get("mimeType"))
+			_, _ = w.Write([]byte("\ufeffExpenses are reimbursed monthly.\r\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	key, err := json.Marshal(map[string]string{
+		"client_email": "indexer@project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes})),
+		"token_uri":    server.URL + "/token",
+	})
+	require.NoError(t, err)
+
+	drive, err := NewGoogleDrive(SourceConfig{
+		Type:       SourceTypeGoogleDrive,
+		GroupTeams: map[string][]string{"finance@example.com": {"team-finance"}},
+		Teams:      []string{"team-staff"},
+		GoogleDrive: GoogleDriveConfig{
+			ServiceAccountKey: string(key),
+			FolderIDs:         []string{"folder1"},
+			APIURL:            server.URL,
+		},
+	}, server.Client())
+	require.NoError(t, err)
+
+	docs, err := drive.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, Document{
+		ID:         "doc1",
+		Title:      "Expenses policy",
+		URL:        "https://docs.google.com/document/d/doc1",
+		UpdateAt:   1741608000000,
+		Principals: []string{"email:alice@example.com", "team:team-finance"},
+	}, docs[0])
+	assert.Equal(t, []string{"team:team-staff"}, docs[1].Principals)
+
+	content, err := drive.Content(context.Background(), docs[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Expenses are reimbursed monthly.", content)
+}
+
+func TestGoogleDriveInvalidKey(t *testing.T) {
+	_, err := NewGoogleDrive(SourceConfig{GoogleDrive: GoogleDriveConfig{ServiceAccountKey: "{}"}}, http.DefaultClient)
+	require.ErrorContains(t, err, "private key")
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package connectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/chunking"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	// DefaultSyncIntervalMinutes is how often a source is synchronized when it doesn't set it
+	DefaultSyncIntervalMinutes = 360
+	// MinSyncIntervalMinutes bounds how often a source is synchronized
+	MinSyncIntervalMinutes = 15
+	// PollInterval is how often the sources are checked for a synchronization
+	PollInterval = 5 * time.Minute
+
+	stateKeyPrefix = "connector_v1_"
+	syncTimeout    = time.Hour
+)
+
+// ErrNotEnabled is returned when searching while no source is indexed
+var ErrNotEnabled = errors.New("no external source is indexed")
+
+// IndexedDocument is what the store knows of an indexed document
+type IndexedDocument struct {
+	UpdateAt   int64
+	Principals []string
+}
+
+// SearchResult is a chunk of a document relevant to a search
+type SearchResult struct {
+	SourceID string
+	// Document is the document the chunk is from, its Content is the chunk
+	Document Document
+	Score    float32
+}
+
+// Store stores the chunks of the documents and their embeddings, see postgres.DocumentStore
+type Store interface {
+	// Documents returns the documents of the source indexed, by ID
+	Documents(ctx context.Context, sourceID string) (map[string]IndexedDocument, error)
+	// Store replaces the chunks of the document
+	Store(ctx context.Context, sourceID string, doc Document, chunks []string, embeddings [][]float32) error
+	// SetPrincipals updates who may read the document
+	SetPrincipals(ctx context.Context, sourceID, documentID string, principals []string) error
+	// Delete removes documents of the source
+	Delete(ctx context.Context, sourceID string, documentIDs []string) error
+	// DeleteOtherSources removes the documents of the sources not listed
+	DeleteOtherSources(ctx context.Context, sourceIDs []string) error
+	// Search returns the chunks closest to the embedding that any of the principals may read
+	Search(ctx context.Context, embedding []float32, principals []string, limit int) ([]SearchResult, error)
+}
+
+// TeamSource returns the teams of the users, see plugin.API
+type TeamSource interface {
+	GetTeamsForUser(userID string) ([]*model.Team, *model.AppError)
+}
+
+// State is the persisted state of a source
+type State struct {
+	LastSyncAt int64 `json:"last_sync_at"`
+}
+
+// Service synchronizes the documents of the sources with the store and searches them.
+type Service struct {
+	store      Store
+	embeddings embeddings.EmbeddingProvider
+	chunking   chunking.Options
+	client     mmapi.Client
+	teams      TeamSource
+	mutexAPI   cluster.MutexPluginAPI
+	httpClient *http.Client
+	getConfig  func() Config
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+// New creates a new connectors service reading the sources from getConfig. The store and the
+// embedding provider are nil when search isn't configured, nothing is indexed then. The default
+// chunking options are used when chunkingOptions has no chunk size. Call Start to begin
+// synchronizing.
+func New(
+	store Store,
+	embeddingProvider embeddings.EmbeddingProvider,
+	chunkingOptions chunking.Options,
+	client mmapi.Client,
+	teams TeamSource,
+	mutexAPI cluster.MutexPluginAPI,
+	httpClient *http.Client,
+	getConfig func() Config,
+) *Service {
+	if chunkingOptions.ChunkSize == 0 {
+		chunkingOptions = chunking.DefaultOptions()
+	}
+	return &Service{
+		store:      store,
+		embeddings: embeddingProvider,
+		chunking:   chunkingOptions,
+		client:     client,
+		teams:      teams,
+		mutexAPI:   mutexAPI,
+		httpClient: httpClient,
+		getConfig:  getConfig,
+	}
+}
+
+// Start checks every interval whether sources are due for a synchronization.
+func (s *Service) Start(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.Poll()
+			}
+		}
+	}()
+}
+
+// Stop stops synchronizing.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// Enabled reports whether sources are configured and can be indexed
+func (s *Service) Enabled() bool {
+	return s != nil && s.store != nil && s.embeddings != nil && len(s.getConfig().Sources) > 0
+}
+
+// Poll synchronizes the sources whose interval elapsed and removes the documents of the sources
+// no longer configured. Only one node of the cluster synchronizes at a time.
+func (s *Service) Poll() {
+	if s.store == nil || s.embeddings == nil {
+		return
+	}
+
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_connectors_sync")
+	if err != nil {
+		s.client.LogError("Failed to create connectors sync mutex", "error", err)
+		return
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	sources := s.getConfig().Sources
+	sourceIDs := make([]string, 0, len(sources))
+	for _, source := range sources {
+		sourceIDs = append(sourceIDs, source.ID)
+	}
+	if err := s.store.DeleteOtherSources(context.Background(), sourceIDs); err != nil {
+		s.client.LogError("Failed to remove the documents of removed sources", "error", err)
+	}
+
+	now := time.Now()
+	for _, source := range sources {
+		if err := s.process(source, now); err != nil {
+			s.client.LogWarn("Failed to synchronize source", "source", source.Name, "source_id", source.ID, "error", err)
+		}
+	}
+}
+
+// process synchronizes the source if its interval elapsed since the last synchronization
+func (s *Service) process(source SourceConfig, now time.Time) error {
+	if source.ID == "" {
+		return errors.New("source has no ID")
+	}
+
+	var state State
+	if err := s.client.KVGet(stateKeyPrefix+source.ID, &state); err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+	if now.Sub(time.UnixMilli(state.LastSyncAt)) < time.Duration(syncIntervalMinutes(source))*time.Minute {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+	syncErr := s.Sync(ctx, source)
+
+	// Failed synchronizations are retried at the next interval, not at every poll
+	state.LastSyncAt = now.UnixMilli()
+	if err := s.client.KVSet(stateKeyPrefix+source.ID, state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return syncErr
+}
+
+// Sync indexes the documents of the source created or updated since they were indexed, updates
+// who may read the others and removes the documents deleted from the source.
+func (s *Service) Sync(ctx context.Context, source SourceConfig) error {
+	connector, err := NewConnector(source, s.httpClient)
+	if err != nil {
+		return err
+	}
+	docs, err := connector.List(ctx)
+	if err != nil {
+		return err
+	}
+	indexed, err := s.store.Documents(ctx, source.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get indexed documents: %w", err)
+	}
+
+	listed := make(map[string]bool, len(docs))
+	var failed int
+	for _, doc := range docs {
+		listed[doc.ID] = true
+		current, ok := indexed[doc.ID]
+		switch {
+		case ok && current.UpdateAt == doc.UpdateAt && slices.Equal(normalizePrincipals(current.Principals), doc.Principals):
+			continue
+		case ok && current.UpdateAt == doc.UpdateAt:
+			err = s.store.SetPrincipals(ctx, source.ID, doc.ID, doc.Principals)
+		default:
+			err = s.index(ctx, connector, source.ID, doc)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			s.client.LogWarn("Failed to index document", "source_id", source.ID, "document_id", doc.ID, "error", err)
+		}
+	}
+
+	var deleted []string
+	for id := range indexed {
+		if !listed[id] {
+			deleted = append(deleted, id)
+		}
+	}
+	if len(deleted) > 0 {
+		if err := s.store.Delete(ctx, source.ID, deleted); err != nil {
+			return fmt.Errorf("failed to delete removed documents: %w", err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to index %d of %d documents", failed, len(docs))
+	}
+	return nil
+}
+
+// index replaces the chunks of the document with its current content
+func (s *Service) index(ctx context.Context, connector Connector, sourceID string, doc Document) error {
+	content, err := connector.Content(ctx, doc)
+	if err != nil {
+		return err
+	}
+
+	// The title is part of the text so documents are found by their name
+	chunks := chunking.ChunkText(strings.TrimSpace(doc.Title+"\n\n"+content), s.chunking)
+	texts := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		texts = append(texts, chunk.Content)
+	}
+	vectors, err := s.embeddings.BatchCreateEmbeddings(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to create embeddings: %w", err)
+	}
+
+	return s.store.Store(ctx, sourceID, doc, texts, vectors)
+}
+
+// Search returns the chunks of the documents user may read in their source that are the most
+// relevant to query
+func (s *Service) Search(ctx context.Context, user *model.User, query string, limit int) ([]SearchResult, error) {
+	if !s.Enabled() {
+		return nil, ErrNotEnabled
+	}
+
+	teams, appErr := s.teams.GetTeamsForUser(user.Id)
+	if appErr != nil {
+		return nil, fmt.Errorf("failed to get teams of user: %w", appErr)
+	}
+	embedding, err := s.embeddings.CreateEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding: %w", err)
+	}
+	results, err := s.store.Search(ctx, embedding, UserPrincipals(user, teams), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// The documents of removed sources are only deleted at the next poll
+	configured := make(map[string]bool)
+	for _, source := range s.getConfig().Sources {
+		configured[source.ID] = true
+	}
+	return slices.DeleteFunc(results, func(result SearchResult) bool { return !configured[result.SourceID] }), nil
+}
+
+// SourceName returns the name of the source, empty when it isn't configured
+func (s *Service) SourceName(sourceID string) string {
+	for _, source := range s.getConfig().Sources {
+		if source.ID == sourceID {
+			return source.Name
+		}
+	}
+	return ""
+}
+
+func syncIntervalMinutes(source SourceConfig) int {
+	if source.SyncIntervalMinutes <= 0 {
+		return DefaultSyncIntervalMinutes
+	}
+	return max(source.SyncIntervalMinutes, MinSyncIntervalMinutes)
+}
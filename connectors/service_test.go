@@ -0,0 +1,133 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package connectors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/chunking"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	mmapimocks "github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	indexed    map[string]IndexedDocument
+	stored     map[string][]string
+	principals map[string][]string
+	deleted    []string
+	results    []SearchResult
+	searchedBy []string
+}
+
+func (f *fakeStore) Documents(_ context.Context, _ string) (map[string]IndexedDocument, error) {
+	return f.indexed, nil
+}
+
+func (f *fakeStore) Store(_ context.Context, _ string, doc Document, chunks []string, _ [][]float32) error {
+	f.stored[doc.ID] = chunks
+	return nil
+}
+
+func (f *fakeStore) SetPrincipals(_ context.Context, _, documentID string, principals []string) error {
+	f.principals[documentID] = principals
+	return nil
+}
+
+func (f *fakeStore) Delete(_ context.Context, _ string, documentIDs []string) error {
+	f.deleted = append(f.deleted, documentIDs...)
+	return nil
+}
+
+func (f *fakeStore) DeleteOtherSources(_ context.Context, _ []string) error {
+	return nil
+}
+
+func (f *fakeStore) Search(_ context.Context, _ []float32, principals []string, _ int) ([]SearchResult, error) {
+	f.searchedBy = principals
+	return f.results, nil
+}
+
+type fakeTeams struct{}
+
+func (fakeTeams) GetTeamsForUser(_ string) ([]*model.Team, *model.AppError) {
+	return []*model.Team{{Id: "team1"}}, nil
+}
+
+func TestSync(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wiki/rest/api/content":
+			_, _ = w.Write([]byte(confluencePagesResponse))
+		case "/wiki/rest/api/content/1":
+			_, _ = w.Write([]byte(`{"id": "1", "body": {"storage": {"value": "<p>Read the handbook.</p>"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	source := SourceConfig{
+		ID:         "wiki",
+		Type:       SourceTypeConfluence,
+		Confluence: ConfluenceConfig{URL: server.URL, SpaceKeys: []string{"ENG"}},
+	}
+	store := &fakeStore{
+		indexed: map[string]IndexedDocument{
+			// Updated in the source
+			"1": {UpdateAt: 1, Principals: []string{PrincipalEveryone}},
+			// Unchanged, but its restrictions changed
+			"2": {UpdateAt: 1741694400000, Principals: []string{PrincipalEveryone}},
+			// Deleted from the source
+			"3": {UpdateAt: 1, Principals: []string{PrincipalEveryone}},
+		},
+		stored:     map[string][]string{},
+		principals: map[string][]string{},
+	}
+	service := New(store, embeddings.NewMockEmbeddingProvider(4), chunking.Options{}, mmapimocks.NewMockClient(t), fakeTeams{}, nil, server.Client(), func() Config {
+		return Config{Sources: []SourceConfig{source}}
+	})
+
+	require.NoError(t, service.Sync(context.Background(), source))
+	assert.Equal(t, map[string][]string{"1": {"Onboarding\n\nRead the handbook."}}, store.stored)
+	assert.Equal(t, map[string][]string{"2": {"email:alice@example.com"}}, store.principals)
+	assert.Equal(t, []string{"3"}, store.deleted)
+
+	// Unchanged documents are left as they are
+	store.indexed = map[string]IndexedDocument{
+		"1": {UpdateAt: 1741608000000, Principals: []string{PrincipalEveryone}},
+		"2": {UpdateAt: 1741694400000, Principals: []string{"email:alice@example.com"}},
+	}
+	store.stored = map[string][]string{}
+	store.principals = map[string][]string{}
+	store.deleted = nil
+	require.NoError(t, service.Sync(context.Background(), source))
+	assert.Empty(t, store.stored)
+	assert.Empty(t, store.principals)
+	assert.Empty(t, store.deleted)
+}
+
+func TestSearch(t *testing.T) {
+	store := &fakeStore{results: []SearchResult{
+		{SourceID: "wiki", Document: Document{ID: "1", Title: "Onboarding"}},
+		{SourceID: "removed", Document: Document{ID: "2", Title: "Old"}},
+	}}
+	service := New(store, embeddings.NewMockEmbeddingProvider(4), chunking.Options{}, nil, fakeTeams{}, nil, nil, func() Config {
+		return Config{Sources: []SourceConfig{{ID: "wiki", Name: "Wiki"}}}
+	})
+
+	results, err := service.Search(context.Background(), &model.User{Id: "alice", Email: "alice@example.com", EmailVerified: true}, "onboarding", 5)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "1", results[0].Document.ID)
+	assert.Equal(t, []string{PrincipalEveryone, "email:alice@example.com", "team:team1"}, store.searchedBy)
+
+	_, err = New(nil, nil, chunking.Options{}, nil, fakeTeams{}, nil, nil, func() Config { return Config{} }).Search(context.Background(), &model.User{}, "onboarding", 5)
+	require.ErrorIs(t, err, ErrNotEnabled)
+}
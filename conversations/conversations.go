@@ -4,12 +4,14 @@
 package conversations
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
 
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
 	"github.com/mattermost/mattermost-plugin-ai/format"
@@ -49,12 +51,24 @@ type Conversations struct {
 	licenseChecker   *enterprise.LicenseChecker
 	i18n             *i18n.Bundle
 	meetingsService  MeetingsService
+	analytics        *analytics.Service
+	searchService    SearchService
+	traceStore       TraceStore
+	imageText        ImageTextExtractor
+	permalinks       threads.LinkResolver
+}
+
+// ImageTextExtractor extracts the content of attached images for models that can't read them
+type ImageTextExtractor interface {
+	Enabled(bot *bots.Bot) bool
+	ExtractText(ctx context.Context, bot *bots.Bot, fileInfo *model.FileInfo) (string, error)
+	Describe(ctx context.Context, bot *bots.Bot, fileInfo *model.FileInfo) (string, error)
 }
 
 // MeetingsService defines the interface for meetings functionality needed by conversations
 type MeetingsService interface {
 	GetCaptionsFileIDFromProps(post *model.Post) (fileID string, err error)
-	SummarizeTranscription(bot *bots.Bot, transcription *subtitles.Subtitles, context *llm.Context) (*llm.TextStreamResult, error)
+	SummarizeTranscription(ctx context.Context, bot *bots.Bot, transcription *subtitles.Subtitles, context *llm.Context) (*llm.TextStreamResult, error)
 }
 
 func New(
@@ -86,8 +100,24 @@ func (c *Conversations) SetMeetingsService(meetingsService MeetingsService) {
 	c.meetingsService = meetingsService
 }
 
-// ProcessUserRequestWithContext is an internal helper that uses an existing context to process a message
-func (c *Conversations) ProcessUserRequestWithContext(bot *bots.Bot, postingUser *model.User, channel *model.Channel, post *model.Post, context *llm.Context) (*llm.TextStreamResult, error) {
+// SetImageTextExtractor sets the extractor of the content of images for bots without vision
+func (c *Conversations) SetImageTextExtractor(extractor ImageTextExtractor) {
+	c.imageText = extractor
+}
+
+// SetLinkResolver sets the resolver of the permalinks in the followed up thread analyses
+func (c *Conversations) SetLinkResolver(resolver threads.LinkResolver) {
+	c.permalinks = resolver
+}
+
+// SetAnalyticsService sets the service used to record usage of mentions and DMs
+func (c *Conversations) SetAnalyticsService(analyticsService *analytics.Service) {
+	c.analytics = analyticsService
+}
+
+// ProcessUserRequestWithContext is an internal helper that uses an existing context to process a message.
+// Options are passed on to the language model.
+func (c *Conversations) ProcessUserRequestWithContext(ctx context.Context, bot *bots.Bot, postingUser *model.User, channel *model.Channel, post *model.Post, context *llm.Context, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
 	isDM := mmapi.IsDMWith(bot.GetMMBot().UserId, channel)
 	var disabledToolsInfo []llm.ToolInfo
 	if !isDM && context != nil && context.Tools != nil {
@@ -95,9 +125,15 @@ func (c *Conversations) ProcessUserRequestWithContext(bot *bots.Bot, postingUser
 	}
 	if context != nil {
 		context.DisabledToolsInfo = disabledToolsInfo
+		context.ReportContextSufficiency = true
+		context.RootPostID = post.RootId
+		if context.RootPostID == "" {
+			context.RootPostID = post.Id
+		}
 	}
 
 	var posts []llm.Post
+	var state ConversationState
 	if post.RootId == "" {
 		// A new conversation
 		prompt, err := c.prompts.Format(prompts.PromptDirectMessageQuestionSystem, context)
@@ -118,11 +154,19 @@ func (c *Conversations) ProcessUserRequestWithContext(bot *bots.Bot, postingUser
 		}
 		previousConversation.CutoffBeforePostID(post.Id)
 
+		// Conversations started before they were stored get their state from the root post
 		var err error
+		state, err = c.conversationState(previousConversation.Posts[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get conversation state: %w", err)
+		}
+
 		posts, err = c.existingConversationToLLMPosts(bot, previousConversation, context)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert existing conversation to LLM posts: %w", err)
 		}
+
+		c.maybeRefreshTitleAsync(ctx, bot, postingUser, channel, post, previousConversation)
 	}
 
 	posts = append(posts, c.PostToAIPost(bot, post))
@@ -131,16 +175,33 @@ func (c *Conversations) ProcessUserRequestWithContext(bot *bots.Bot, postingUser
 		Posts:   posts,
 		Context: context,
 	}
-	var opts []llm.LanguageModelOption
+	feature := analytics.FeatureDirectMessage
 	if !isDM {
 		// In non-DM channels, disable tools for security but provide info about DM-only tools
 		opts = append(opts, llm.WithToolsDisabled())
+		feature = analytics.FeatureMention
 	}
-	result, err := bot.LLM().ChatCompletion(completionRequest, opts...)
+	opts = append(opts, bot.NativeToolsOption(feature))
+	result, err := c.chatCompletionWithTrace(ctx, bot, post.Id, completionRequest, opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	conversationID := post.RootId
+	if conversationID == "" {
+		conversationID = post.Id
+	}
+	if err := c.SaveConversation(Conversation{
+		ID:        conversationID,
+		BotID:     bot.GetMMBot().UserId,
+		UserID:    postingUser.Id,
+		ChannelID: channel.Id,
+		State:     state,
+	}); err != nil {
+		c.mmClient.LogError("Failed to save conversation", "error", err.Error())
+	}
+	result = c.trackConversationUsage(result, conversationID)
+
 	// Decorate the stream with web search annotations if available
 	webSearchData := mmtools.ConsumeWebSearchContexts(context)
 	c.mmClient.LogDebug("Checking for web search data in ProcessUserRequestWithContext", "has_data", len(webSearchData) > 0, "num_contexts", len(webSearchData))
@@ -148,19 +209,21 @@ func (c *Conversations) ProcessUserRequestWithContext(bot *bots.Bot, postingUser
 		result = mmtools.DecorateStreamWithAnnotations(result, webSearchData, nil)
 	}
 
-	go func() {
-		request := "Write a short title for the following request. Include only the title and nothing else, no quotations. Request:\n" + post.Message
-		if err := c.GenerateTitle(bot, request, post.Id, context); err != nil {
-			c.mmClient.LogError("Failed to generate title", "error", err.Error())
-			return
-		}
-	}()
+	if post.RootId == "" {
+		go func() {
+			request := "Write a short title for the following request. Include only the title and nothing else, no quotations. Request:\n" + post.Message
+			if err := c.GenerateTitle(ctx, bot, request, post.Id, context); err != nil {
+				c.mmClient.LogError("Failed to generate title", "error", err.Error())
+				return
+			}
+		}()
+	}
 
 	return result, nil
 }
 
 // ProcessUserRequest processes a user request to a bot
-func (c *Conversations) ProcessUserRequest(bot *bots.Bot, postingUser *model.User, channel *model.Channel, post *model.Post) (*llm.TextStreamResult, error) {
+func (c *Conversations) ProcessUserRequest(ctx context.Context, bot *bots.Bot, postingUser *model.User, channel *model.Channel, post *model.Post, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
 	// Extract web search context from conversation history to preserve citations
 	// This ensures citations from previous searches work in follow-up messages
 	webSearchParams := c.extractWebSearchContext(post)
@@ -202,16 +265,16 @@ func (c *Conversations) ProcessUserRequest(bot *bots.Bot, postingUser *model.Use
 		}
 	}
 
-	return c.ProcessUserRequestWithContext(bot, postingUser, channel, post, llmContext)
+	return c.ProcessUserRequestWithContext(ctx, bot, postingUser, channel, post, llmContext, opts...)
 }
 
-func (c *Conversations) GenerateTitle(bot *bots.Bot, request string, postID string, context *llm.Context) error {
+func (c *Conversations) GenerateTitle(ctx context.Context, bot *bots.Bot, request string, postID string, context *llm.Context) error {
 	titleRequest := llm.CompletionRequest{
 		Posts:   []llm.Post{{Role: llm.PostRoleUser, Message: request}},
 		Context: context,
 	}
 
-	conversationTitle, err := bot.LLM().ChatCompletionNoStream(titleRequest, llm.WithMaxGeneratedTokens(25), llm.WithReasoningDisabled())
+	conversationTitle, err := bot.LLM().ChatCompletionNoStream(ctx, titleRequest, llm.WithMaxGeneratedTokens(25), llm.WithReasoningDisabled())
 	if err != nil {
 		return fmt.Errorf("failed to get title: %w", err)
 	}
@@ -228,8 +291,12 @@ func (c *Conversations) GenerateTitle(bot *bots.Bot, request string, postID stri
 // existingConversationToLLMPosts converts existing conversation to LLM posts format
 func (c *Conversations) existingConversationToLLMPosts(bot *bots.Bot, conversation *mmapi.ThreadData, context *llm.Context) ([]llm.Post, error) {
 	// Handle thread summarization requests
-	originalThreadID, ok := conversation.Posts[0].GetProp(ThreadIDProp).(string)
-	if ok && originalThreadID != "" && conversation.Posts[0].UserId == bot.GetMMBot().UserId {
+	state, err := c.conversationState(conversation.Posts[0])
+	if err != nil {
+		return nil, err
+	}
+	originalThreadID := state.ThreadID
+	if originalThreadID != "" && conversation.Posts[0].UserId == bot.GetMMBot().UserId {
 		threadPost, err := c.mmClient.GetPost(originalThreadID)
 		if err != nil {
 			return nil, err
@@ -253,12 +320,23 @@ func (c *Conversations) existingConversationToLLMPosts(bot *bots.Bot, conversati
 			return nil, fmt.Errorf("user no longer has access to original thread")
 		}
 
-		analysisType, ok := conversation.Posts[0].GetProp(AnalysisTypeProp).(string)
-		if !ok {
+		if state.AnalysisType == "" {
 			return nil, fmt.Errorf("missing analysis type")
 		}
 
-		posts, err := threads.New(bot.LLM(), c.prompts, c.mmClient).FollowUpAnalyze(originalThreadID, context, analysisType)
+		policy, err := c.bots.ChannelPolicy(threadChannel)
+		if err != nil {
+			return nil, err
+		}
+		analyzer := threads.New(bot.FeatureLLM(analytics.FeatureThreadAnalysis), c.prompts, c.mmClient)
+		analyzer.SetChannelPolicy(policy)
+		if c.imageText != nil {
+			analyzer.SetImageDescriber(c.imageText, bot)
+		}
+		if c.permalinks != nil {
+			analyzer.SetLinkResolver(c.permalinks, context.RequestingUser.Id)
+		}
+		posts, err := analyzer.FollowUpAnalyze(originalThreadID, context, state.AnalysisType)
 		if err != nil {
 			return nil, err
 		}
@@ -328,10 +406,31 @@ func isImageMimeType(mimeType string) bool {
 	return strings.HasPrefix(mimeType, "image/")
 }
 
+// sendsImages reports whether attached images are sent to the model of the bot. Models whose
+// capabilities aren't known are assumed to read images when vision is enabled.
+func (c *Conversations) sendsImages(bot *bots.Bot) bool {
+	if !bot.GetConfig().EnableVision {
+		return false
+	}
+	if c.bots == nil {
+		return true
+	}
+	modelName := bot.GetConfig().Model
+	if modelName == "" {
+		modelName = bot.GetService().DefaultModel
+	}
+	if capabilities, ok := c.bots.LookupModelCapabilities(bot.GetService(), modelName); ok {
+		return capabilities.Vision
+	}
+	return true
+}
+
 func (c *Conversations) PostToAIPost(bot *bots.Bot, post *model.Post) llm.Post {
 	var filesForUpstream []llm.File
 	message := format.PostBody(post)
 	var extractedFileContents []string
+	sendImages := c.sendsImages(bot)
+	extractImageText := !sendImages && c.imageText != nil && c.imageText.Enabled(bot)
 
 	maxFileSize := defaultMaxFileSize
 	if bot.GetConfig().MaxFileSize > 0 {
@@ -371,7 +470,17 @@ func (c *Conversations) PostToAIPost(bot *bots.Bot, post *model.Post) llm.Post {
 			extractedFileContents = append(extractedFileContents, fileContent)
 		}
 
-		if bot.GetConfig().EnableVision && isImageMimeType(fileInfo.MimeType) {
+		// Models without vision get the content of images extracted by another agent instead
+		if extractImageText && content == "" && isImageMimeType(fileInfo.MimeType) {
+			text, err := c.imageText.ExtractText(context.Background(), bot, fileInfo)
+			if err != nil {
+				c.mmClient.LogError("Error extracting image text", "file_id", fileID, "error", err)
+				continue
+			}
+			extractedFileContents = append(extractedFileContents, fmt.Sprintf("File Name: %s\nContent extracted from the image: %s", fileInfo.Name, text))
+		}
+
+		if sendImages && isImageMimeType(fileInfo.MimeType) {
 			file, err := c.mmClient.GetFile(fileID)
 			if err != nil {
 				c.mmClient.LogError("Error getting file", "error", err)
@@ -424,6 +533,12 @@ func (c *Conversations) PostToAIPost(bot *bots.Bot, post *model.Post) llm.Post {
 		}
 	}
 
+	// Only the bot's own responses can be continued from, other bots may use other providers
+	responseID := ""
+	if post.UserId == bot.GetMMBot().UserId {
+		responseID, _ = post.GetProp(streaming.ResponseIDProp).(string)
+	}
+
 	return llm.Post{
 		Role:               role,
 		Message:            message,
@@ -431,6 +546,7 @@ func (c *Conversations) PostToAIPost(bot *bots.Bot, post *model.Post) llm.Post {
 		ToolUse:            tools,
 		Reasoning:          reasoning,
 		ReasoningSignature: reasoningSignature,
+		ResponseID:         responseID,
 	}
 }
 
@@ -0,0 +1,151 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/followups"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// FollowUpSuggestionsControl is the postupdate control sent when follow-up questions are added to a post.
+const FollowUpSuggestionsControl = "follow_up_suggestions"
+
+// ErrFollowUpNotFound is returned when asking a follow-up question the post does not suggest.
+var ErrFollowUpNotFound = errors.New("follow-up question not found")
+
+// withFollowUpSuggestions passes the stream through and once the reply is complete suggests
+// follow-up questions in the background, attaching them to the response post.
+func (c *Conversations) withFollowUpSuggestions(ctx context.Context, stream *llm.TextStreamResult, bot *bots.Bot, user *model.User, channel *model.Channel, question string, responsePost *model.Post) *llm.TextStreamResult {
+	if !bot.GetConfig().EnableFollowUpSuggestions {
+		return stream
+	}
+
+	output := make(chan llm.TextStreamEvent)
+	go func() {
+		defer close(output)
+
+		var answer strings.Builder
+		// Replies that failed or are waiting for tool approval are not complete answers
+		incomplete := false
+		for event := range stream.Stream {
+			switch event.Type {
+			case llm.EventTypeText:
+				if text, ok := event.Value.(string); ok {
+					answer.WriteString(text)
+				}
+			case llm.EventTypeError, llm.EventTypeToolCalls, llm.EventTypeStoppedEarly:
+				incomplete = true
+			case llm.EventTypeFinish:
+				if reason, ok := event.Value.(string); ok && llm.IsIncompleteFinish(reason) {
+					incomplete = true
+				}
+			case llm.EventTypeEnd:
+				if !incomplete && strings.TrimSpace(answer.String()) != "" {
+					go c.addFollowUpSuggestions(ctx, bot, user, channel, question, answer.String(), responsePost.Id)
+				}
+			}
+			output <- event
+		}
+	}()
+
+	return &llm.TextStreamResult{Stream: output}
+}
+
+// addFollowUpSuggestions generates follow-up questions for the reply and stores them on the post.
+func (c *Conversations) addFollowUpSuggestions(ctx context.Context, bot *bots.Bot, user *model.User, channel *model.Channel, question, answer, postID string) {
+	llmContext := c.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		user,
+		channel,
+		c.contextBuilder.WithLLMContextNoTools(),
+	)
+
+	questions, err := followups.New(bot.LLM(), c.prompts).Suggest(ctx, question, answer, llmContext)
+	if err != nil {
+		c.mmClient.LogError("Failed to suggest follow-up questions", "error", err, "post_id", postID)
+		return
+	}
+	if len(questions) == 0 {
+		return
+	}
+
+	questionsJSON, err := json.Marshal(questions)
+	if err != nil {
+		c.mmClient.LogError("Failed to marshal follow-up questions", "error", err, "post_id", postID)
+		return
+	}
+
+	// The streamed reply has been saved by the time the suggestions are ready, so load it again
+	// to avoid overwriting it.
+	post, err := c.mmClient.GetPost(postID)
+	if err != nil {
+		c.mmClient.LogError("Failed to get post to add follow-up questions", "error", err, "post_id", postID)
+		return
+	}
+	post.AddProp(followups.SuggestionsProp, string(questionsJSON))
+	if err := c.mmClient.UpdatePost(post); err != nil {
+		c.mmClient.LogError("Failed to save follow-up questions", "error", err, "post_id", postID)
+		return
+	}
+
+	c.mmClient.PublishWebSocketEvent("postupdate", map[string]interface{}{
+		"post_id":     postID,
+		"control":     FollowUpSuggestionsControl,
+		"suggestions": string(questionsJSON),
+	}, &model.WebsocketBroadcast{
+		ChannelId: channel.Id,
+	})
+}
+
+// AskFollowUp posts the follow-up question at index as the user, replying to the bot in the thread
+// of the post suggesting it. The bot then responds like to any other message.
+func (c *Conversations) AskFollowUp(userID string, post *model.Post, channel *model.Channel, index int) (*model.Post, error) {
+	bot := c.bots.GetBotByID(post.UserId)
+	if bot == nil {
+		return nil, fmt.Errorf("unable to get bot")
+	}
+
+	if post.GetProp(streaming.LLMRequesterUserID) != userID {
+		return nil, errors.New("only the original requester can ask follow-up questions")
+	}
+
+	questions := followups.FromProps(post.GetProps())
+	if index < 0 || index >= len(questions) {
+		return nil, ErrFollowUpNotFound
+	}
+
+	message := questions[index]
+	if !mmapi.IsDMWith(bot.GetMMBot().UserId, channel) {
+		message = "@" + bot.GetMMBot().Username + " " + message
+	}
+
+	rootID := post.RootId
+	if rootID == "" {
+		rootID = post.Id
+	}
+
+	followUpPost := &model.Post{
+		UserId:    userID,
+		ChannelId: channel.Id,
+		RootId:    rootID,
+		Message:   message,
+	}
+	// Posts created by plugins are ignored unless they ask for a response
+	followUpPost.AddProp(ActivateAIProp, "true")
+	if err := c.mmClient.CreatePost(followUpPost); err != nil {
+		return nil, fmt.Errorf("unable to create follow-up post: %w", err)
+	}
+
+	return followUpPost, nil
+}
@@ -8,9 +8,13 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
 	"github.com/mattermost/mattermost/server/public/model"
-	"github.com/mattermost/mattermost/server/public/plugin"
 )
 
 const (
@@ -26,8 +30,8 @@ var (
 	ErrNoResponse = errors.New("no response")
 )
 
-func (c *Conversations) MessageHasBeenPosted(ctx *plugin.Context, post *model.Post) {
-	if err := c.handleMessages(post); err != nil {
+func (c *Conversations) MessageHasBeenPosted(ctx context.Context, post *model.Post) {
+	if err := c.handleMessages(ctx, post); err != nil {
 		if errors.Is(err, ErrNoResponse) {
 			c.mmClient.LogDebug(err.Error())
 		} else {
@@ -36,7 +40,7 @@ func (c *Conversations) MessageHasBeenPosted(ctx *plugin.Context, post *model.Po
 	}
 }
 
-func (c *Conversations) handleMessages(post *model.Post) error {
+func (c *Conversations) handleMessages(ctx context.Context, post *model.Post) error {
 	// Don't respond to ourselves
 	if c.bots.IsAnyBot(post.UserId) {
 		return fmt.Errorf("not responding to ourselves: %w", ErrNoResponse)
@@ -79,26 +83,39 @@ func (c *Conversations) handleMessages(post *model.Post) error {
 
 	// Check we are mentioned like @ai
 	if bot := c.bots.GetBotMentioned(post.Message); bot != nil {
-		return c.handleMentions(bot, post, postingUser, channel)
+		return c.handleMentions(ctx, bot, post, postingUser, channel)
+	}
+
+	// Check we are mentioned by the routing alias, like @agent
+	if bot := c.bots.GetBotMentionedByAlias(post.Message, postingUser.Id, channel); bot != nil {
+		return c.handleMentions(ctx, bot, post, postingUser, channel)
 	}
 
 	// Check if this is post in the DM channel with any bot
 	if bot := c.bots.GetBotForDMChannel(channel); bot != nil {
-		return c.handleDMs(bot, channel, postingUser, post)
+		return c.handleDMs(ctx, bot, channel, postingUser, post)
 	}
 
 	return nil
 }
 
-func (c *Conversations) handleMentions(bot *bots.Bot, post *model.Post, postingUser *model.User, channel *model.Channel) error {
+func (c *Conversations) handleMentions(ctx context.Context, bot *bots.Bot, post *model.Post, postingUser *model.User, channel *model.Channel) error {
 	if err := c.bots.CheckUsageRestrictions(postingUser.Id, bot, channel); err != nil {
+		if errors.Is(err, exclusions.ErrChannelExcluded) {
+			c.notifyChannelExcluded(bot, postingUser, post)
+		}
 		return err
 	}
 
-	stream, err := c.ProcessUserRequest(bot, postingUser, channel, post)
+	stream, err := c.ProcessUserRequest(ctx, bot, postingUser, channel, post)
+	if errors.Is(err, llm.ErrConcurrencyLimitReached) {
+		c.notifyConcurrencyLimitReached(bot, postingUser, post)
+		return fmt.Errorf("unable to process bot mention: %w", err)
+	}
 	if err != nil {
 		return fmt.Errorf("unable to process bot mention: %w", err)
 	}
+	stream = c.analytics.TrackStream(stream, analytics.NewEvent(analytics.FeatureMention, bot.GetMMBot().UserId, postingUser.Id, channel))
 
 	responseRootID := post.Id
 	if post.RootId != "" {
@@ -109,23 +126,20 @@ func (c *Conversations) handleMentions(bot *bots.Bot, post *model.Post, postingU
 		ChannelId: channel.Id,
 		RootId:    responseRootID,
 	}
-	if err := c.streamingService.StreamToNewPost(context.Background(), bot.GetMMBot().UserId, postingUser.Id, stream, responsePost, post.Id); err != nil {
+	stream = c.withFollowUpSuggestions(ctx, stream, bot, postingUser, channel, post.Message, responsePost)
+	stream = c.withHistoryRetrieval(ctx, stream, bot, postingUser, channel, post)
+	if err := c.streamingService.StreamToNewPost(streaming.WithFeature(ctx, analytics.FeatureMention), bot.GetMMBot().UserId, postingUser.Id, stream, responsePost, post.Id); err != nil {
 		return fmt.Errorf("unable to stream response: %w", err)
 	}
 
 	return nil
 }
 
-func (c *Conversations) handleDMs(bot *bots.Bot, channel *model.Channel, postingUser *model.User, post *model.Post) error {
+func (c *Conversations) handleDMs(ctx context.Context, bot *bots.Bot, channel *model.Channel, postingUser *model.User, post *model.Post) error {
 	if err := c.bots.CheckUsageRestrictionsForUser(bot, postingUser.Id); err != nil {
 		return err
 	}
 
-	stream, err := c.ProcessUserRequest(bot, postingUser, channel, post)
-	if err != nil {
-		return fmt.Errorf("unable to process bot mention: %w", err)
-	}
-
 	responseRootID := post.Id
 	if post.RootId != "" {
 		responseRootID = post.RootId
@@ -135,9 +149,54 @@ func (c *Conversations) handleDMs(bot *bots.Bot, channel *model.Channel, posting
 		ChannelId: channel.Id,
 		RootId:    responseRootID,
 	}
-	if err := c.streamingService.StreamToNewPost(context.Background(), bot.GetMMBot().UserId, postingUser.Id, stream, responsePost, post.Id); err != nil {
+
+	stream, err := c.processDirectMessage(ctx, bot, postingUser, channel, post, responsePost)
+	if errors.Is(err, llm.ErrConcurrencyLimitReached) {
+		c.notifyConcurrencyLimitReached(bot, postingUser, post)
+		return fmt.Errorf("unable to process bot mention: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to process bot mention: %w", err)
+	}
+	stream = c.analytics.TrackStream(stream, analytics.NewEvent(analytics.FeatureDirectMessage, bot.GetMMBot().UserId, postingUser.Id, channel))
+
+	stream = c.withFollowUpSuggestions(ctx, stream, bot, postingUser, channel, post.Message, responsePost)
+	if err := c.streamingService.StreamToNewPost(streaming.WithFeature(ctx, analytics.FeatureDirectMessage), bot.GetMMBot().UserId, postingUser.Id, stream, responsePost, post.Id); err != nil {
 		return fmt.Errorf("unable to stream response: %w", err)
 	}
 
 	return nil
 }
+
+// notifyConcurrencyLimitReached lets the user know their message was not answered because
+// too many responses are already being generated for them or by the bot.
+func (c *Conversations) notifyConcurrencyLimitReached(bot *bots.Bot, postingUser *model.User, post *model.Post) {
+	rootID := post.Id
+	if post.RootId != "" {
+		rootID = post.RootId
+	}
+
+	T := i18n.LocalizerFunc(c.i18n, postingUser.Locale)
+	c.mmClient.SendEphemeralPost(postingUser.Id, &model.Post{
+		UserId:    bot.GetMMBot().UserId,
+		ChannelId: post.ChannelId,
+		RootId:    rootID,
+		Message:   T("agents.concurrency_limit_reached", "Too many responses are already being generated. Please wait for them to finish and try again."),
+	})
+}
+
+// notifyChannelExcluded tells the user the bot can't answer in a channel excluded from AI processing
+func (c *Conversations) notifyChannelExcluded(bot *bots.Bot, postingUser *model.User, post *model.Post) {
+	rootID := post.Id
+	if post.RootId != "" {
+		rootID = post.RootId
+	}
+
+	T := i18n.LocalizerFunc(c.i18n, postingUser.Locale)
+	c.mmClient.SendEphemeralPost(postingUser.Id, &model.Post{
+		UserId:    bot.GetMMBot().UserId,
+		ChannelId: post.ChannelId,
+		RootId:    rootID,
+		Message:   T("agents.channel_excluded", "AI features are disabled in this channel by a system admin, so its content can't be shared with the AI."),
+	})
+}
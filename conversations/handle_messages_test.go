@@ -4,6 +4,7 @@
 package conversations
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -54,7 +55,7 @@ func TestHandleMessages(t *testing.T) {
 
 	t.Run("don't respond to remote posts", func(t *testing.T) {
 		remoteid := "remoteid"
-		err := e.conversations.handleMessages(&model.Post{
+		err := e.conversations.handleMessages(context.Background(), &model.Post{
 			UserId:    "userid",
 			ChannelId: "channelid",
 			RemoteId:  &remoteid,
@@ -68,7 +69,7 @@ func TestHandleMessages(t *testing.T) {
 			ChannelId: "channelid",
 		}
 		post.AddProp("from_plugin", true)
-		err := e.conversations.handleMessages(post)
+		err := e.conversations.handleMessages(context.Background(), post)
 		require.ErrorIs(t, err, ErrNoResponse)
 	})
 
@@ -78,7 +79,7 @@ func TestHandleMessages(t *testing.T) {
 			ChannelId: "channelid",
 		}
 		post.AddProp("from_webhook", true)
-		err := e.conversations.handleMessages(post)
+		err := e.conversations.handleMessages(context.Background(), post)
 		require.ErrorIs(t, err, ErrNoResponse)
 	})
 }
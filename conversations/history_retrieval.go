@@ -0,0 +1,131 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// retrievedHistoryPosts is how many of the channel posts preceding a mention are retrieved
+const retrievedHistoryPosts = 30
+
+// withHistoryRetrieval answers the mention again with the recent messages of the channel when the
+// bot reports it lacked the context to answer it. Only mentions starting a thread are retried,
+// replies already have the thread as context.
+func (c *Conversations) withHistoryRetrieval(ctx context.Context, stream *llm.TextStreamResult, bot *bots.Bot, user *model.User, channel *model.Channel, post *model.Post) *llm.TextStreamResult {
+	if !bot.GetConfig().RetrieveChannelHistory || post.RootId != "" || mmapi.IsDMWith(bot.GetMMBot().UserId, channel) {
+		return stream
+	}
+
+	output := make(chan llm.TextStreamEvent)
+	go func() {
+		defer close(output)
+
+		insufficient := false
+		for event := range stream.Stream {
+			switch event.Type {
+			case llm.EventTypeContextSufficiency:
+				if sufficiency, ok := event.Value.(llm.ContextSufficiency); ok {
+					insufficient = !sufficiency.Sufficient
+				}
+			case llm.EventTypeEnd:
+				if insufficient {
+					go func() {
+						if err := c.answerWithChannelHistory(ctx, bot, user, channel, post); err != nil {
+							c.mmClient.LogError("Failed to answer with the channel history", "error", err, "post_id", post.Id)
+						}
+					}()
+				}
+			}
+			output <- event
+		}
+	}()
+
+	return &llm.TextStreamResult{Stream: output}
+}
+
+// answerWithChannelHistory replies to the mention in its thread with an answer given the posts
+// preceding it in the channel, leaving out the posts the channel policy excludes.
+func (c *Conversations) answerWithChannelHistory(ctx context.Context, bot *bots.Bot, user *model.User, channel *model.Channel, post *model.Post) error {
+	history, err := c.channelHistory(channel, post.Id)
+	if err != nil {
+		return err
+	}
+	if history == "" {
+		return nil
+	}
+
+	llmContext := c.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		user,
+		channel,
+		c.contextBuilder.WithLLMContextNoTools(),
+	)
+	llmContext.ReportContextSufficiency = true
+
+	systemPrompt, err := c.prompts.Format(prompts.PromptDirectMessageQuestionSystem, llmContext)
+	if err != nil {
+		return fmt.Errorf("failed to format prompt: %w", err)
+	}
+
+	question := c.PostToAIPost(bot, post)
+	question.Message = "Recent messages of the channel, for context:\n\n" + history + "\nMessage to answer:\n" + question.Message
+
+	result, err := c.chatCompletionWithTrace(ctx, bot, post.Id, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			question,
+		},
+		Context: llmContext,
+	}, llm.WithToolsDisabled())
+	if err != nil {
+		return fmt.Errorf("failed to get chat completion: %w", err)
+	}
+
+	responsePost := &model.Post{
+		ChannelId: channel.Id,
+		RootId:    post.Id,
+	}
+	if err := c.streamingService.StreamToNewPost(streaming.WithFeature(ctx, analytics.FeatureMention), bot.GetMMBot().UserId, user.Id, result, responsePost, post.Id); err != nil {
+		return fmt.Errorf("failed to stream result to new post: %w", err)
+	}
+
+	return nil
+}
+
+// channelHistory formats the posts preceding postID in the channel
+func (c *Conversations) channelHistory(channel *model.Channel, postID string) (string, error) {
+	policy, err := c.bots.ChannelPolicy(channel)
+	if err != nil {
+		return "", fmt.Errorf("failed to get channel policy: %w", err)
+	}
+
+	posts, err := c.mmClient.GetPostsBefore(channel.Id, postID, 0, retrievedHistoryPosts)
+	if err != nil {
+		return "", fmt.Errorf("failed to get channel history: %w", err)
+	}
+
+	threadData, err := mmapi.GetMetadataForPosts(c.mmClient, posts)
+	if err != nil {
+		return "", fmt.Errorf("failed to get channel history metadata: %w", err)
+	}
+
+	// Remove deleted posts, system posts and the posts the channel policy excludes
+	threadData.Posts = slices.DeleteFunc(threadData.Posts, func(post *model.Post) bool {
+		return post.DeleteAt != 0 || post.Type != "" || policy.ExcludesPost(post, threadData.UsersByID[post.UserId])
+	})
+
+	return format.ThreadData(threadData), nil
+}
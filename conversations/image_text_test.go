@@ -0,0 +1,79 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeImageTextExtractor struct {
+	extracted []string
+}
+
+func (f *fakeImageTextExtractor) Enabled(bot *bots.Bot) bool {
+	return bot.GetConfig().ImageTextBot != ""
+}
+
+func (f *fakeImageTextExtractor) ExtractText(_ context.Context, _ *bots.Bot, fileInfo *model.FileInfo) (string, error) {
+	f.extracted = append(f.extracted, fileInfo.Id)
+	return "Q3 roadmap: ship search", nil
+}
+
+func (f *fakeImageTextExtractor) Describe(ctx context.Context, bot *bots.Bot, fileInfo *model.FileInfo) (string, error) {
+	return f.ExtractText(ctx, bot, fileInfo)
+}
+
+func TestPostToAIPostImages(t *testing.T) {
+	tests := []struct {
+		name              string
+		botConfig         llm.BotConfig
+		expectFiles       bool
+		expectedExtracted bool
+	}{
+		{name: "vision sends the image", botConfig: llm.BotConfig{EnableVision: true, ImageTextBot: "vision"}, expectFiles: true},
+		{name: "without vision the text is extracted", botConfig: llm.BotConfig{ImageTextBot: "vision"}, expectedExtracted: true},
+		{name: "without vision nor image text agent the image is left out", botConfig: llm.BotConfig{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := SetupTestEnvironment(t)
+			defer e.Cleanup(t)
+
+			mmClient := e.conversations.mmClient.(*mocks.MockClient)
+			mmClient.EXPECT().GetFileInfo("file1").Return(&model.FileInfo{Id: "file1", Name: "whiteboard.jpg", MimeType: "image/jpeg", Size: 5}, nil)
+			if test.expectFiles {
+				mmClient.EXPECT().GetFile("file1").Return(io.NopCloser(strings.NewReader("image")), nil)
+			}
+
+			extractor := &fakeImageTextExtractor{}
+			e.conversations.SetImageTextExtractor(extractor)
+			bot := bots.NewBot(test.botConfig, llm.ServiceConfig{}, &model.Bot{UserId: "bot1"}, nil)
+
+			post := e.conversations.PostToAIPost(bot, &model.Post{UserId: "user1", Message: "What does this say?", FileIds: []string{"file1"}})
+
+			if test.expectFiles {
+				require.Len(t, post.Files, 1)
+			} else {
+				require.Empty(t, post.Files)
+			}
+			if test.expectedExtracted {
+				require.Equal(t, []string{"file1"}, extractor.extracted)
+				require.Contains(t, post.Message, "File Name: whiteboard.jpg\nContent extracted from the image: Q3 roadmap: ship search")
+			} else {
+				require.Empty(t, extractor.extracted)
+				require.Equal(t, "What does this say?", post.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/intents"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/search"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// IntentProp records the intent a routed direct message was classified with on the response post
+const IntentProp = "intent"
+
+// SearchService defines the interface for search functionality needed by conversations
+type SearchService interface {
+	Enabled() bool
+	AnswerStream(ctx context.Context, userID string, bot *bots.Bot, query, teamID, channelID string, maxResults int) (*llm.TextStreamResult, []search.RAGResult, error)
+}
+
+// SetSearchService sets the service used to answer direct messages routed to search
+func (c *Conversations) SetSearchService(searchService SearchService) {
+	c.searchService = searchService
+}
+
+// processDirectMessage answers a direct message. When the bot has intent routing enabled the
+// message is classified first, and answered by the flow and with the options configured for its intent.
+func (c *Conversations) processDirectMessage(ctx context.Context, bot *bots.Bot, postingUser *model.User, channel *model.Channel, post *model.Post, responsePost *model.Post) (*llm.TextStreamResult, error) {
+	if !bot.GetConfig().EnableIntentRouting {
+		return c.ProcessUserRequest(ctx, bot, postingUser, channel, post)
+	}
+
+	intent := c.classifyIntent(ctx, bot, postingUser, channel, post)
+	responsePost.AddProp(IntentProp, string(intent))
+
+	// Search answers a single question, so follow-ups in a thread continue as a conversation
+	if intent == intents.IntentSearch && post.RootId == "" && c.searchService != nil && c.searchService.Enabled() {
+		stream, err := c.answerWithSearch(ctx, bot, postingUser, post, responsePost)
+		if err == nil {
+			return stream, nil
+		}
+		if !errors.Is(err, search.ErrNoResults) {
+			c.mmClient.LogError("Failed to answer direct message with search, falling back to chat", "error", err)
+		}
+	}
+
+	var opts []llm.LanguageModelOption
+	botConfig := bot.GetConfig()
+	if route, ok := botConfig.GetIntentRoute(string(intent)); ok {
+		if route.Model != "" {
+			opts = append(opts, llm.WithModel(route.Model))
+		}
+		if route.DisableTools {
+			opts = append(opts, llm.WithToolsDisabled())
+		}
+	}
+
+	return c.ProcessUserRequest(ctx, bot, postingUser, channel, post, opts...)
+}
+
+// classifyIntent returns the intent of the post, falling back to chat when it can not be classified.
+func (c *Conversations) classifyIntent(ctx context.Context, bot *bots.Bot, postingUser *model.User, channel *model.Channel, post *model.Post) intents.Intent {
+	llmContext := c.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		postingUser,
+		channel,
+		c.contextBuilder.WithLLMContextNoTools(),
+	)
+
+	intent, err := intents.New(bot.LLM(), c.prompts).Classify(ctx, post.Message, llmContext)
+	if err != nil {
+		c.mmClient.LogError("Failed to classify direct message intent", "error", err, "post_id", post.Id)
+		return intents.IntentChat
+	}
+
+	return intent
+}
+
+// answerWithSearch streams an answer to the post based on relevant posts found on the server,
+// attaching the sources to the response post.
+func (c *Conversations) answerWithSearch(ctx context.Context, bot *bots.Bot, postingUser *model.User, post *model.Post, responsePost *model.Post) (*llm.TextStreamResult, error) {
+	stream, results, err := c.searchService.AnswerStream(ctx, postingUser.Id, bot, post.Message, "", "", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		return nil, err
+	}
+	responsePost.AddProp(search.SearchResultsProp, string(resultsJSON))
+
+	return stream, nil
+}
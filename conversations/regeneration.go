@@ -8,6 +8,8 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
+	"github.com/mattermost/mattermost-plugin-ai/followups"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
 	"github.com/mattermost/mattermost-plugin-ai/streaming"
@@ -22,7 +24,7 @@ const (
 )
 
 // HandleRegenerate handles post regeneration requests
-func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channel *model.Channel) error {
+func (c *Conversations) HandleRegenerate(ctx context.Context, userID string, post *model.Post, channel *model.Channel) error {
 	bot := c.bots.GetBotByID(post.UserId)
 	if bot == nil {
 		return fmt.Errorf("unable to get bot")
@@ -41,22 +43,36 @@ func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channe
 		return fmt.Errorf("unable to get user to regen post: %w", err)
 	}
 
-	ctx, err := c.streamingService.GetStreamingContext(context.Background(), post.Id)
+	ctx, err = c.streamingService.GetStreamingContext(ctx, post.Id)
 	if err != nil {
 		return fmt.Errorf("unable to get post streaming context: %w", err)
 	}
 	defer c.streamingService.FinishStreaming(post.Id)
 
-	threadIDProp := post.GetProp(ThreadIDProp)
-	analysisTypeProp := post.GetProp(AnalysisTypeProp)
+	// Analyses start their conversation, so only root posts can be one
+	var state ConversationState
+	if post.RootId == "" {
+		state, err = c.conversationState(post)
+		if err != nil {
+			return fmt.Errorf("unable to get conversation state: %w", err)
+		}
+	}
 	referenceRecordingFileIDProp := post.GetProp(ReferencedRecordingFileID)
 	referencedTranscriptPostProp := post.GetProp(ReferencedTranscriptPostID)
 	post.DelProp(streaming.ToolCallProp)
+	post.DelProp(followups.SuggestionsProp)
+	// The citations of the previous response don't apply to the new one
+	post.DelProp(streaming.AnnotationsProp)
+	post.DelProp(streaming.CitationsRenderedProp)
+	post.DelProp(streaming.ContextSufficiencyProp)
+	post.DelProp(streaming.ContextMissingProp)
+	post.DelProp(streaming.ReasoningDisplayProp)
+	post.DelProp(streaming.ResponseIDProp)
 	var result *llm.TextStreamResult
 	switch {
-	case threadIDProp != nil:
-		threadID := threadIDProp.(string)
-		analysisType := analysisTypeProp.(string)
+	case state.ThreadID != "":
+		threadID := state.ThreadID
+		analysisType := state.AnalysisType
 		threadPost, getPostErr := c.mmClient.GetPost(threadID)
 		if getPostErr != nil {
 			return fmt.Errorf("could not get thread post on regen: %w", getPostErr)
@@ -66,6 +82,15 @@ func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channe
 			return errors.New("user doesn't have permission to read channel original thread in in")
 		}
 
+		threadChannel, getChannelErr := c.mmClient.GetChannel(threadPost.ChannelId)
+		if getChannelErr != nil {
+			return fmt.Errorf("could not get thread channel on regen: %w", getChannelErr)
+		}
+		policy, policyErr := c.bots.ChannelPolicy(threadChannel)
+		if policyErr != nil {
+			return fmt.Errorf("could not get thread channel policy on regen: %w", policyErr)
+		}
+
 		llmContext := c.contextBuilder.BuildLLMContextUserRequest(
 			bot,
 			user,
@@ -73,14 +98,21 @@ func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channe
 			c.contextBuilder.WithLLMContextDefaultTools(bot),
 		)
 
-		analyzer := threads.New(bot.LLM(), c.prompts, c.mmClient)
+		analyzer := threads.New(bot.FeatureLLM(analytics.FeatureThreadAnalysis), c.prompts, c.mmClient)
+		analyzer.SetChannelPolicy(policy)
+		if c.imageText != nil {
+			analyzer.SetImageDescriber(c.imageText, bot)
+		}
+		if c.permalinks != nil {
+			analyzer.SetLinkResolver(c.permalinks, userID)
+		}
 		switch analysisType {
 		case "summarize_thread":
-			result, err = analyzer.Summarize(threadID, llmContext)
+			result, err = analyzer.Summarize(ctx, threadID, llmContext)
 		case "action_items":
-			result, err = analyzer.FindActionItems(threadID, llmContext)
+			result, err = analyzer.FindActionItems(ctx, threadID, llmContext)
 		case "open_questions":
-			result, err = analyzer.FindOpenQuestions(threadID, llmContext)
+			result, err = analyzer.FindOpenQuestions(ctx, threadID, llmContext)
 		default:
 			return fmt.Errorf("invalid analysis type: %s", analysisType)
 		}
@@ -122,7 +154,7 @@ func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channe
 			c.contextBuilder.WithLLMContextDefaultTools(bot),
 		)
 		var summaryErr error
-		result, summaryErr = c.meetingsService.SummarizeTranscription(bot, transcription, context)
+		result, summaryErr = c.meetingsService.SummarizeTranscription(ctx, bot, transcription, context)
 		if summaryErr != nil {
 			return fmt.Errorf("could not summarize transcription on regen: %w", summaryErr)
 		}
@@ -155,7 +187,7 @@ func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channe
 			c.contextBuilder.WithLLMContextDefaultTools(bot),
 		)
 		var summaryErr error
-		result, summaryErr = c.meetingsService.SummarizeTranscription(bot, transcription, context)
+		result, summaryErr = c.meetingsService.SummarizeTranscription(ctx, bot, transcription, context)
 		if summaryErr != nil {
 			return fmt.Errorf("unable to summarize transcription: %w", summaryErr)
 		}
@@ -193,7 +225,7 @@ func (c *Conversations) HandleRegenerate(userID string, post *model.Post, channe
 		// Process the user request with the context that has the callback
 		// Note: ProcessUserRequestWithContext internally checks if this is a DM and applies WithToolsDisabled() if not
 		var processErr error
-		result, processErr = c.ProcessUserRequestWithContext(bot, user, channel, respondingToPost, contextWithCallback)
+		result, processErr = c.ProcessUserRequestWithContext(ctx, bot, user, channel, respondingToPost, contextWithCallback)
 		if processErr != nil {
 			return fmt.Errorf("could not continue conversation on regen: %w", processErr)
 		}
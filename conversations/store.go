@@ -4,9 +4,12 @@
 package conversations
 
 import (
+	"encoding/json"
 	"fmt"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
 )
 
 // SaveTitleAsync saves a title asynchronously
@@ -18,18 +21,130 @@ func (c *Conversations) SaveTitleAsync(threadID, title string) {
 	}()
 }
 
-// SaveTitle saves a title for a thread
+// ConversationState is the state of a conversation that shapes how it is continued
+type ConversationState struct {
+	// ThreadID is the thread analyzed by the conversation, for conversations started by a thread analysis
+	ThreadID string `json:"thread_id,omitempty"`
+	// AnalysisType is the analysis of the thread, such as summarize_thread
+	AnalysisType string `json:"analysis_type,omitempty"`
+}
+
+// Conversation is a conversation between a user and a bot, identified by the root post of its thread
+type Conversation struct {
+	ID           string            `json:"id" db:"id"`
+	BotID        string            `json:"bot_id" db:"botid"`
+	UserID       string            `json:"user_id" db:"userid"`
+	ChannelID    string            `json:"channel_id" db:"channelid"`
+	Title        string            `json:"title" db:"title"`
+	State        ConversationState `json:"state" db:"-"`
+	InputTokens  int64             `json:"input_tokens" db:"inputtokens"`
+	OutputTokens int64             `json:"output_tokens" db:"outputtokens"`
+	ModelCalls   int64             `json:"model_calls" db:"modelcalls"`
+	CreateAt     int64             `json:"create_at" db:"createat"`
+	UpdateAt     int64             `json:"update_at" db:"updateat"`
+}
+
+// conversationRow is a row of LLM_Conversations, with the state as JSON
+type conversationRow struct {
+	Conversation
+	State string `db:"state"`
+}
+
+// SaveConversation stores a new conversation. A conversation already known only by its title,
+// such as one created by SaveTitle, gets its bot, user, channel and state filled in, others are left unchanged.
+func (c *Conversations) SaveConversation(conversation Conversation) error {
+	if c.db == nil {
+		return nil // Skip database operations when db is not available
+	}
+	state, err := json.Marshal(conversation.State)
+	if err != nil {
+		return fmt.Errorf("failed to marshal conversation state: %w", err)
+	}
+	now := model.GetMillis()
+	_, err = c.db.ExecBuilder(c.db.Builder().Insert("LLM_Conversations").
+		Columns("ID", "BotID", "UserID", "ChannelID", "Title", "State", "CreateAt", "UpdateAt").
+		Values(conversation.ID, conversation.BotID, conversation.UserID, conversation.ChannelID, conversation.Title, string(state), now, now).
+		Suffix(`ON CONFLICT (ID) DO UPDATE SET
+			BotID = EXCLUDED.BotID, UserID = EXCLUDED.UserID, ChannelID = EXCLUDED.ChannelID, State = EXCLUDED.State, UpdateAt = EXCLUDED.UpdateAt
+			WHERE LLM_Conversations.BotID = ''`))
+	if err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+	return nil
+}
+
+// GetConversation returns the conversation of a thread, or nil if it has none
+func (c *Conversations) GetConversation(threadID string) (*Conversation, error) {
+	if c.db == nil {
+		return nil, nil // Skip database operations when db is not available
+	}
+	var rows []conversationRow
+	if err := c.db.DoQuery(&rows, c.db.Builder().
+		Select("ID", "BotID", "UserID", "ChannelID", "Title", "State", "InputTokens", "OutputTokens", "ModelCalls", "CreateAt", "UpdateAt").
+		From("LLM_Conversations").
+		Where(sq.Eq{"ID": threadID}),
+	); err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	conversation := rows[0].Conversation
+	if err := json.Unmarshal([]byte(rows[0].State), &conversation.State); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal conversation state: %w", err)
+	}
+	return &conversation, nil
+}
+
+// AddConversationUsage adds the token usage of a response to the conversation of a thread
+func (c *Conversations) AddConversationUsage(threadID string, usage llm.RequestUsage) error {
+	if c.db == nil {
+		return nil // Skip database operations when db is not available
+	}
+	_, err := c.db.ExecBuilder(c.db.Builder().Update("LLM_Conversations").
+		Set("InputTokens", sq.Expr("InputTokens + ?", usage.InputTokens)).
+		Set("OutputTokens", sq.Expr("OutputTokens + ?", usage.OutputTokens)).
+		Set("ModelCalls", sq.Expr("ModelCalls + ?", usage.Calls)).
+		Set("UpdateAt", model.GetMillis()).
+		Where(sq.Eq{"ID": threadID}))
+	if err != nil {
+		return fmt.Errorf("failed to add conversation usage: %w", err)
+	}
+	return nil
+}
+
+// SaveTitle saves a title for a thread. Threads without a conversation yet get one with only their title.
 func (c *Conversations) SaveTitle(threadID, title string) error {
 	if c.db == nil {
 		return nil // Skip database operations when db is not available
 	}
-	_, err := c.db.ExecBuilder(c.db.Builder().Insert("LLM_PostMeta").
-		Columns("RootPostID", "Title").
-		Values(threadID, title).
-		Suffix("ON CONFLICT (RootPostID) DO UPDATE SET Title = ?", title))
+	now := model.GetMillis()
+	_, err := c.db.ExecBuilder(c.db.Builder().Insert("LLM_Conversations").
+		Columns("ID", "BotID", "UserID", "ChannelID", "Title", "State", "CreateAt", "UpdateAt").
+		Values(threadID, "", "", "", title, "{}", now, now).
+		Suffix("ON CONFLICT (ID) DO UPDATE SET Title = ?", title))
 	return err
 }
 
+// GetTitle returns the saved title of a thread, or an empty string if it has none
+func (c *Conversations) GetTitle(threadID string) (string, error) {
+	if c.db == nil {
+		return "", nil // Skip database operations when db is not available
+	}
+	var titles []string
+	if err := c.db.DoQuery(&titles, c.db.Builder().
+		Select("Title").
+		From("LLM_Conversations").
+		Where(sq.Eq{"ID": threadID}),
+	); err != nil {
+		return "", fmt.Errorf("failed to get title: %w", err)
+	}
+	if len(titles) == 0 {
+		return "", nil
+	}
+	return titles[0], nil
+}
+
 func (c *Conversations) getAIThreads(dmChannelIDs []string) ([]AIThread, error) {
 	var dbPosts []AIThread
 	if err := c.db.DoQuery(&dbPosts, c.db.Builder().
@@ -45,7 +160,7 @@ func (c *Conversations) getAIThreads(dmChannelIDs []string) ([]AIThread, error)
 		Where(sq.Eq{"ChannelID": dmChannelIDs}).
 		Where(sq.Eq{"RootId": ""}).
 		Where(sq.Eq{"DeleteAt": 0}).
-		LeftJoin("LLM_PostMeta as t ON t.RootPostID = p.Id").
+		LeftJoin("LLM_Conversations as t ON t.ID = p.Id").
 		OrderBy("CreateAt DESC").
 		Limit(60).
 		Offset(0),
@@ -55,3 +170,121 @@ func (c *Conversations) getAIThreads(dmChannelIDs []string) ([]AIThread, error)
 
 	return dbPosts, nil
 }
+
+// ConversationQuery selects the conversations of a user returned by SearchConversations
+type ConversationQuery struct {
+	UserID string
+	// BotID only keeps the conversations with the bot, when set
+	BotID string
+	// Terms are searched in the titles and the posts of the conversations, when set
+	Terms string
+	// Since and Until select the conversations started in the range, when set
+	Since   int64
+	Until   int64
+	Page    int
+	PerPage int
+}
+
+// SearchConversations returns a page of the conversations of a user matching query, most recently
+// updated first, and whether more pages follow. The conversations in channels the user can no
+// longer read are left out.
+func (c *Conversations) SearchConversations(query ConversationQuery) ([]Conversation, bool, error) {
+	if c.db == nil {
+		return []Conversation{}, false, nil // Skip database operations when db is not available
+	}
+
+	builder := c.db.Builder().
+		Select("c.ID", "c.BotID", "c.UserID", "c.ChannelID", "c.Title", "c.State", "c.InputTokens", "c.OutputTokens", "c.ModelCalls", "c.CreateAt", "c.UpdateAt").
+		From("LLM_Conversations AS c").
+		Where(sq.Eq{"c.UserID": query.UserID})
+	if query.BotID != "" {
+		builder = builder.Where(sq.Eq{"c.BotID": query.BotID})
+	}
+	if query.Since > 0 {
+		builder = builder.Where(sq.GtOrEq{"c.CreateAt": query.Since})
+	}
+	if query.Until > 0 {
+		builder = builder.Where(sq.Lt{"c.CreateAt": query.Until})
+	}
+	if query.Terms != "" {
+		// Matches the full-text index of the posts messages
+		builder = builder.Where(sq.Or{
+			sq.Expr("to_tsvector('english', c.Title) @@ plainto_tsquery('english', ?)", query.Terms),
+			sq.Expr(`EXISTS (
+				SELECT 1 FROM Posts AS p
+				WHERE (p.Id = c.ID OR p.RootId = c.ID) AND p.DeleteAt = 0
+				AND to_tsvector('english', p.Message) @@ plainto_tsquery('english', ?)
+			)`, query.Terms),
+		})
+	}
+
+	var rows []conversationRow
+	if err := c.db.DoQuery(&rows, builder.
+		OrderBy("c.UpdateAt DESC", "c.ID").
+		Limit(uint64(query.PerPage+1)).
+		Offset(uint64(query.Page*query.PerPage)),
+	); err != nil {
+		return nil, false, fmt.Errorf("failed to search conversations: %w", err)
+	}
+	hasMore := len(rows) > query.PerPage
+	if hasMore {
+		rows = rows[:query.PerPage]
+	}
+
+	result := make([]Conversation, 0, len(rows))
+	for _, row := range rows {
+		if !c.mmClient.HasPermissionToChannel(query.UserID, row.ChannelID, model.PermissionReadChannel) {
+			continue
+		}
+		conversation := row.Conversation
+		if err := json.Unmarshal([]byte(row.State), &conversation.State); err != nil {
+			return nil, false, fmt.Errorf("failed to unmarshal conversation state: %w", err)
+		}
+		result = append(result, conversation)
+	}
+
+	return result, hasMore, nil
+}
+
+// conversationState returns the state of the conversation started by rootPost. Conversations
+// started before their state was stored only have it in the props of their root post.
+func (c *Conversations) conversationState(rootPost *model.Post) (ConversationState, error) {
+	conversation, err := c.GetConversation(rootPost.Id)
+	if err != nil {
+		return ConversationState{}, err
+	}
+	if conversation != nil && conversation.BotID != "" {
+		return conversation.State, nil
+	}
+
+	threadID, _ := rootPost.GetProp(ThreadIDProp).(string)
+	analysisType, _ := rootPost.GetProp(AnalysisTypeProp).(string)
+	return ConversationState{
+		ThreadID:     threadID,
+		AnalysisType: analysisType,
+	}, nil
+}
+
+// trackConversationUsage adds the token usage of the response in stream to the conversation of the thread
+func (c *Conversations) trackConversationUsage(stream *llm.TextStreamResult, threadID string) *llm.TextStreamResult {
+	if c.db == nil || stream == nil {
+		return stream
+	}
+
+	output := make(chan llm.TextStreamEvent)
+	go func() {
+		defer close(output)
+		for event := range stream.Stream {
+			if event.Type == llm.EventTypeUsageTotal {
+				if usage, ok := event.Value.(llm.RequestUsage); ok {
+					if err := c.AddConversationUsage(threadID, usage); err != nil {
+						c.mmClient.LogError("Failed to add conversation usage", "error", err.Error())
+					}
+				}
+			}
+			output <- event
+		}
+	}()
+
+	return &llm.TextStreamResult{Stream: output}
+}
@@ -0,0 +1,27 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationStateFromLegacyProps(t *testing.T) {
+	c := &Conversations{}
+
+	analysisPost := &model.Post{Id: model.NewId()}
+	analysisPost.AddProp(ThreadIDProp, "thread1")
+	analysisPost.AddProp(AnalysisTypeProp, "summarize_thread")
+	state, err := c.conversationState(analysisPost)
+	require.NoError(t, err)
+	assert.Equal(t, ConversationState{ThreadID: "thread1", AnalysisType: "summarize_thread"}, state)
+
+	state, err = c.conversationState(&model.Post{Id: model.NewId()})
+	require.NoError(t, err)
+	assert.Equal(t, ConversationState{}, state)
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// TitleRefreshInterval is the number of posts after which the title of a DM conversation
+// is checked again, so it keeps describing the conversation as it drifts to other topics.
+const TitleRefreshInterval = 10
+
+// titleContextPosts is the number of most recent posts used to write a refreshed title.
+const titleContextPosts = 20
+
+// ErrNotAIConversation is returned when refreshing the title of a thread that is not a DM with a bot.
+var ErrNotAIConversation = errors.New("post is not part of a conversation with the bot")
+
+// shouldRefreshTitle reports whether a DM conversation with the given number of posts
+// has grown enough since the last title was written.
+func shouldRefreshTitle(threadLength int) bool {
+	return threadLength > 0 && threadLength%TitleRefreshInterval == 0
+}
+
+// maybeRefreshTitleAsync refreshes the title of long DM conversations in the background
+// every TitleRefreshInterval posts.
+func (c *Conversations) maybeRefreshTitleAsync(ctx context.Context, bot *bots.Bot, user *model.User, channel *model.Channel, post *model.Post, previousConversation *mmapi.ThreadData) {
+	if !mmapi.IsDMWith(bot.GetMMBot().UserId, channel) {
+		return
+	}
+
+	// The previous conversation is cut off before the post being answered
+	if !shouldRefreshTitle(len(previousConversation.Posts) + 1) {
+		return
+	}
+
+	go func() {
+		if _, err := c.RegenerateTitle(ctx, bot, user, post, channel); err != nil {
+			c.mmClient.LogError("Failed to refresh title", "error", err.Error(), "thread_id", post.RootId)
+		}
+	}()
+}
+
+// RefreshTitle writes a new title for the conversation in the thread, taking the current title
+// into account so it only changes when the conversation has moved on. Returns the saved title.
+func (c *Conversations) RefreshTitle(ctx context.Context, bot *bots.Bot, threadID string, context *llm.Context) (string, error) {
+	conversation, err := mmapi.GetThreadData(c.mmClient, threadID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if len(conversation.Posts) > titleContextPosts {
+		conversation.Posts = conversation.Posts[len(conversation.Posts)-titleContextPosts:]
+	}
+
+	currentTitle, err := c.GetTitle(threadID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get current title: %w", err)
+	}
+
+	context.Parameters = map[string]any{
+		"CurrentTitle": currentTitle,
+	}
+	systemPrompt, err := c.prompts.Format(prompts.PromptConversationTitleSystem, context)
+	if err != nil {
+		return "", fmt.Errorf("failed to format prompt: %w", err)
+	}
+
+	titleRequest := llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: format.ThreadData(conversation)},
+		},
+		Context: context,
+	}
+
+	title, err := bot.LLM().ChatCompletionNoStream(ctx, titleRequest,
+		llm.WithMaxGeneratedTokens(25),
+		llm.WithReasoningDisabled(),
+		llm.WithToolsDisabled(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to get title: %w", err)
+	}
+
+	title = strings.Trim(title, "\n \"'")
+	if title == "" {
+		return currentTitle, nil
+	}
+	if title == currentTitle {
+		return title, nil
+	}
+
+	if err := c.SaveTitle(threadID, title); err != nil {
+		return "", fmt.Errorf("failed to save title: %w", err)
+	}
+
+	return title, nil
+}
+
+// RegenerateTitle refreshes the title of the user's DM conversation with the bot on request.
+func (c *Conversations) RegenerateTitle(ctx context.Context, bot *bots.Bot, user *model.User, post *model.Post, channel *model.Channel) (string, error) {
+	if !mmapi.IsDMWith(bot.GetMMBot().UserId, channel) {
+		return "", ErrNotAIConversation
+	}
+
+	threadID := post.RootId
+	if threadID == "" {
+		threadID = post.Id
+	}
+
+	llmContext := c.contextBuilder.BuildLLMContextUserRequest(
+		bot,
+		user,
+		channel,
+		c.contextBuilder.WithLLMContextNoTools(),
+	)
+
+	return c.RefreshTitle(ctx, bot, threadID, llmContext)
+}
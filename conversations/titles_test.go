@@ -0,0 +1,30 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldRefreshTitle(t *testing.T) {
+	tests := []struct {
+		name         string
+		threadLength int
+		expected     bool
+	}{
+		{name: "empty thread", threadLength: 0, expected: false},
+		{name: "short thread", threadLength: 3, expected: false},
+		{name: "at the interval", threadLength: TitleRefreshInterval, expected: true},
+		{name: "past the interval", threadLength: TitleRefreshInterval + 1, expected: false},
+		{name: "at a later interval", threadLength: 3 * TitleRefreshInterval, expected: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, shouldRefreshTitle(tc.threadLength))
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"slices"
 
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
 	"github.com/mattermost/mattermost-plugin-ai/mmtools"
@@ -110,7 +111,7 @@ func (c *Conversations) unmarshalWebSearchContext(webSearchContextJSON string, p
 }
 
 // HandleToolCall handles tool call approval/rejection
-func (c *Conversations) HandleToolCall(userID string, post *model.Post, channel *model.Channel, acceptedToolIDs []string) error {
+func (c *Conversations) HandleToolCall(ctx context.Context, userID string, post *model.Post, channel *model.Channel, acceptedToolIDs []string) error {
 	bot := c.bots.GetBotByID(post.UserId)
 	if bot == nil {
 		return fmt.Errorf("unable to get bot")
@@ -147,6 +148,18 @@ func (c *Conversations) HandleToolCall(userID string, post *model.Post, channel
 		channel,
 		contextOpts...,
 	)
+	llmContext.ReportContextSufficiency = true
+
+	responseRootID := post.Id
+	if post.RootId != "" {
+		responseRootID = post.RootId
+	}
+	llmContext.RootPostID = responseRootID
+
+	// Leave the tool calls pending when the plugin is shutting down so they can be approved after the restart
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("tool calls left pending: %w", err)
+	}
 
 	for i := range tools {
 		if slices.Contains(acceptedToolIDs, tools[i].ID) {
@@ -167,11 +180,6 @@ func (c *Conversations) HandleToolCall(userID string, post *model.Post, channel
 		}
 	}
 
-	responseRootID := post.Id
-	if post.RootId != "" {
-		responseRootID = post.RootId
-	}
-
 	// Update post with the tool call results
 	resolvedToolsJSON, err := json.Marshal(tools)
 	if err != nil {
@@ -179,6 +187,11 @@ func (c *Conversations) HandleToolCall(userID string, post *model.Post, channel
 	}
 	post.AddProp(streaming.ToolCallProp, string(resolvedToolsJSON))
 
+	// Attach the generated images to the post, the server attaches new file IDs when the post is updated
+	if imageFileIDs := mmtools.ConsumeGeneratedImages(llmContext); len(imageFileIDs) > 0 {
+		post.FileIds = append(post.FileIds, imageFileIDs...)
+	}
+
 	// Persist web search context if it exists (so it's available for subsequent tool calls)
 	if webSearchParams := llmContext.Parameters; len(webSearchParams) > 0 {
 		if _, hasWebSearch := webSearchParams[mmtools.WebSearchContextKey]; hasWebSearch {
@@ -221,7 +234,7 @@ func (c *Conversations) HandleToolCall(userID string, post *model.Post, channel
 		Posts:   posts,
 		Context: llmContext,
 	}
-	result, err := bot.LLM().ChatCompletion(completionRequest)
+	result, err := c.chatCompletionWithTrace(ctx, bot, post.Id, completionRequest, bot.NativeToolsOption(analytics.FeatureDirectMessage))
 	if err != nil {
 		return fmt.Errorf("failed to get chat completion: %w", err)
 	}
@@ -239,7 +252,7 @@ func (c *Conversations) HandleToolCall(userID string, post *model.Post, channel
 		ChannelId: channel.Id,
 		RootId:    responseRootID,
 	}
-	if err := c.streamingService.StreamToNewPost(context.Background(), bot.GetMMBot().UserId, user.Id, result, responsePost, post.Id); err != nil {
+	if err := c.streamingService.StreamToNewPost(streaming.WithFeature(ctx, analytics.FeatureDirectMessage), bot.GetMMBot().UserId, user.Id, result, responsePost, post.Id); err != nil {
 		return fmt.Errorf("failed to stream result to new post: %w", err)
 	}
 
@@ -0,0 +1,55 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package conversations
+
+import (
+	"context"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+// TraceStore defines the interface for persisting agent traces needed by conversations
+type TraceStore interface {
+	Enabled() bool
+	Save(trace llm.Trace) error
+}
+
+// SetTraceStore sets the store agent traces of bot responses are saved to
+func (c *Conversations) SetTraceStore(traceStore TraceStore) {
+	c.traceStore = traceStore
+}
+
+// chatCompletionWithTrace runs the completion, recording its agent trace under the request post ID when tracing is enabled
+func (c *Conversations) chatCompletionWithTrace(ctx context.Context, bot *bots.Bot, requestPostID string, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+	if c.traceStore == nil || !c.traceStore.Enabled() || request.Context == nil {
+		result, err := bot.LLM().ChatCompletion(ctx, request, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return extractContextSufficiency(request, result), nil
+	}
+
+	recorder := llm.NewTraceRecorder(requestPostID, request)
+	request.Context.Trace = recorder
+
+	result, err := bot.LLM().ChatCompletion(ctx, request, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return extractContextSufficiency(request, recorder.WrapStream(result, func(trace llm.Trace) {
+		if err := c.traceStore.Save(trace); err != nil {
+			c.mmClient.LogError("failed to save agent trace", "error", err.Error())
+		}
+	})), nil
+}
+
+// extractContextSufficiency removes the context_check trailer the model was asked to end the response with
+func extractContextSufficiency(request llm.CompletionRequest, result *llm.TextStreamResult) *llm.TextStreamResult {
+	if request.Context == nil || !request.Context.ReportContextSufficiency {
+		return result
+	}
+	return llm.ExtractContextSufficiency(result)
+}
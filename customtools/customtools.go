@@ -0,0 +1,290 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package customtools lets admins declare tools answered by HTTP endpoints, without
+// having to write and run an MCP server.
+package customtools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+const (
+	// maxResponseSize is the largest endpoint response read
+	maxResponseSize = 1024 * 1024
+	// maxResultLength limits how much of a response is passed on to the model
+	maxResultLength = 16000
+)
+
+var toolNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ToolConfig declares a tool the model can call that is answered by an HTTP endpoint
+type ToolConfig struct {
+	// Name is the name of the tool shown to the model
+	Name string `json:"name"`
+
+	// Description tells the model what the tool does and when to use it
+	Description string `json:"description"`
+
+	// Schema is the JSON schema of the tool arguments. Defaults to an object without properties.
+	Schema string `json:"schema"`
+
+	// Method is the HTTP method used to call the endpoint, GET or POST. Defaults to POST.
+	// Arguments are sent as a JSON body for POST and as query parameters for GET.
+	Method string `json:"method"`
+
+	// URL is the endpoint called with the tool arguments
+	URL string `json:"url"`
+
+	// AuthHeader is the name of the header used to authenticate to the endpoint, for example "Authorization"
+	AuthHeader string `json:"authHeader"`
+
+	// AuthValue is the value of AuthHeader
+	AuthValue string `json:"authValue"`
+
+	// ResponsePath selects the part of a JSON response returned to the model, as dot separated
+	// keys and array indexes, for example "data.items". Defaults to the whole response.
+	ResponsePath string `json:"responsePath"`
+
+	// BotIDs restricts the tool to these bots. Empty makes the tool available to all bots.
+	BotIDs []string `json:"botIDs"`
+}
+
+// IsValid returns an error describing why the tool can not be used
+func (c ToolConfig) IsValid() error {
+	if !toolNameRegexp.MatchString(c.Name) {
+		return fmt.Errorf("invalid tool name %q: only letters, numbers, underscores and dashes are allowed", c.Name)
+	}
+	if strings.TrimSpace(c.Description) == "" {
+		return errors.New("description is required")
+	}
+	switch strings.ToUpper(c.Method) {
+	case "", http.MethodGet, http.MethodPost:
+	default:
+		return fmt.Errorf("unsupported method %q", c.Method)
+	}
+	endpoint, err := url.Parse(c.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return fmt.Errorf("invalid URL %q", c.URL)
+	}
+	if _, err := c.schema(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c ToolConfig) schema() (*jsonschema.Schema, error) {
+	if strings.TrimSpace(c.Schema) == "" {
+		return &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{}}, nil
+	}
+	var schema jsonschema.Schema
+	if err := json.Unmarshal([]byte(c.Schema), &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if schema.Type != "object" {
+		return nil, errors.New("invalid schema: arguments must be an object")
+	}
+	return &schema, nil
+}
+
+func (c ToolConfig) method() string {
+	if c.Method == "" {
+		return http.MethodPost
+	}
+	return strings.ToUpper(c.Method)
+}
+
+// Logger is the logging needed by the provider
+type Logger interface {
+	Error(message string, keyValuePairs ...any)
+}
+
+// Provider provides the custom tools configured for a bot
+type Provider struct {
+	getConfig  func() []ToolConfig
+	httpClient *http.Client
+	log        Logger
+}
+
+// NewProvider creates a new Provider. The tools are read from getConfig on every call,
+// so configuration changes apply to the next request.
+func NewProvider(getConfig func() []ToolConfig, httpClient *http.Client, log Logger) *Provider {
+	return &Provider{
+		getConfig:  getConfig,
+		httpClient: httpClient,
+		log:        log,
+	}
+}
+
+// GetToolsForBot returns the valid custom tools available to the bot with the given config ID
+func (p *Provider) GetToolsForBot(botID string) []llm.Tool {
+	var tools []llm.Tool
+	for _, cfg := range p.getConfig() {
+		if len(cfg.BotIDs) > 0 && !slices.Contains(cfg.BotIDs, botID) {
+			continue
+		}
+
+		if err := cfg.IsValid(); err != nil {
+			p.log.Error("Skipping invalid custom tool", "tool", cfg.Name, "error", err.Error())
+			continue
+		}
+
+		// Validated above
+		schema, _ := cfg.schema()
+		tools = append(tools, llm.Tool{
+			Name:        cfg.Name,
+			Description: cfg.Description,
+			Schema:      schema,
+			Resolver:    p.resolver(cfg),
+		})
+	}
+	return tools
+}
+
+func (p *Provider) resolver(cfg ToolConfig) llm.ToolResolver {
+	return func(_ *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+		args := map[string]any{}
+		if err := argsGetter(&args); err != nil {
+			return "invalid arguments", fmt.Errorf("failed to get arguments for tool %s: %w", cfg.Name, err)
+		}
+
+		req, err := buildRequest(cfg, args)
+		if err != nil {
+			return "failed to call the tool", err
+		}
+
+		resp, err := p.httpClient.Do(req)
+		if err != nil {
+			return "failed to call the tool", fmt.Errorf("request to tool %s failed: %w", cfg.Name, err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		if err != nil {
+			return "failed to read the tool response", fmt.Errorf("failed to read response of tool %s: %w", cfg.Name, err)
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Sprintf("the tool returned an error (status %d)", resp.StatusCode), fmt.Errorf("tool %s returned status %d", cfg.Name, resp.StatusCode)
+		}
+
+		result, err := mapResponse(body, cfg.ResponsePath)
+		if err != nil {
+			return "failed to read the tool response", fmt.Errorf("failed to map response of tool %s: %w", cfg.Name, err)
+		}
+
+		if len(result) > maxResultLength {
+			result = result[:maxResultLength] + "\n... (response truncated)"
+		}
+
+		return result, nil
+	}
+}
+
+// buildRequest creates the request calling the endpoint of the tool with the arguments
+func buildRequest(cfg ToolConfig, args map[string]any) (*http.Request, error) {
+	var req *http.Request
+	var err error
+	if cfg.method() == http.MethodGet {
+		endpoint, parseErr := url.Parse(cfg.URL)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid URL for tool %s: %w", cfg.Name, parseErr)
+		}
+		query := endpoint.Query()
+		for key, value := range args {
+			query.Set(key, queryValue(value))
+		}
+		endpoint.RawQuery = query.Encode()
+		req, err = http.NewRequest(http.MethodGet, endpoint.String(), nil)
+	} else {
+		body, marshalErr := json.Marshal(args)
+		if marshalErr != nil {
+			return nil, fmt.Errorf("failed to marshal arguments for tool %s: %w", cfg.Name, marshalErr)
+		}
+		req, err = http.NewRequest(http.MethodPost, cfg.URL, bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for tool %s: %w", cfg.Name, err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if cfg.AuthHeader != "" {
+		req.Header.Set(cfg.AuthHeader, cfg.AuthValue)
+	}
+
+	return req, nil
+}
+
+// queryValue formats an argument as a query parameter
+func queryValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
+
+// mapResponse returns the part of the response selected by path. Responses that are not JSON
+// are returned as is when no path is set.
+func mapResponse(body []byte, path string) (string, error) {
+	if path == "" {
+		return string(body), nil
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return "", fmt.Errorf("response is not JSON: %w", err)
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch current := value.(type) {
+		case map[string]any:
+			next, ok := current[key]
+			if !ok {
+				return "", fmt.Errorf("key %q not found in response", key)
+			}
+			value = next
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(current) {
+				return "", fmt.Errorf("index %q not found in response", key)
+			}
+			value = current[index]
+		default:
+			return "", fmt.Errorf("key %q not found in response", key)
+		}
+	}
+
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+
+	result, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal response: %w", err)
+	}
+	return string(result), nil
+}
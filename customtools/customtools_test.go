@@ -0,0 +1,167 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package customtools
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLogger struct {
+	errors []string
+}
+
+func (l *testLogger) Error(message string, keyValuePairs ...any) {
+	l.errors = append(l.errors, message)
+}
+
+func TestToolConfigIsValid(t *testing.T) {
+	valid := ToolConfig{
+		Name:        "lookup_order",
+		Description: "Look up an order",
+		URL:         "https://example.com/orders",
+		Schema:      `{"type": "object", "properties": {"id": {"type": "string"}}}`,
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(c *ToolConfig)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(c *ToolConfig) {}},
+		{name: "empty schema", modify: func(c *ToolConfig) { c.Schema = "" }},
+		{name: "invalid name", modify: func(c *ToolConfig) { c.Name = "lookup order" }, wantErr: true},
+		{name: "missing description", modify: func(c *ToolConfig) { c.Description = " " }, wantErr: true},
+		{name: "unsupported method", modify: func(c *ToolConfig) { c.Method = "DELETE" }, wantErr: true},
+		{name: "invalid url", modify: func(c *ToolConfig) { c.URL = "ftp://example.com" }, wantErr: true},
+		{name: "invalid schema", modify: func(c *ToolConfig) { c.Schema = "{" }, wantErr: true},
+		{name: "schema not an object", modify: func(c *ToolConfig) { c.Schema = `{"type": "string"}` }, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := valid
+			tc.modify(&cfg)
+			err := cfg.IsValid()
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetToolsForBot(t *testing.T) {
+	logger := &testLogger{}
+	provider := NewProvider(func() []ToolConfig {
+		return []ToolConfig{
+			{Name: "all_bots", Description: "Available to all bots", URL: "https://example.com"},
+			{Name: "other_bot", Description: "Available to another bot", URL: "https://example.com", BotIDs: []string{"other"}},
+			{Name: "invalid", URL: "https://example.com"},
+		}
+	}, http.DefaultClient, logger)
+
+	tools := provider.GetToolsForBot("bot")
+	require.Len(t, tools, 1)
+	assert.Equal(t, "all_bots", tools[0].Name)
+	assert.Len(t, logger.errors, 1)
+}
+
+func TestResolver(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		responsePath   string
+		status         int
+		response       string
+		expectedResult string
+		wantErr        bool
+	}{
+		{
+			name:           "post returns whole response",
+			response:       `{"status": "shipped"}`,
+			expectedResult: `{"status": "shipped"}`,
+		},
+		{
+			name:           "get with response path",
+			method:         http.MethodGet,
+			responsePath:   "data.items.1.status",
+			response:       `{"data": {"items": [{"status": "new"}, {"status": "shipped"}]}}`,
+			expectedResult: "shipped",
+		},
+		{
+			name:           "response path selecting an object",
+			responsePath:   "data",
+			response:       `{"data": {"status": "shipped"}}`,
+			expectedResult: `{"status":"shipped"}`,
+		},
+		{
+			name:         "missing response path",
+			responsePath: "data.missing",
+			response:     `{"data": {}}`,
+			wantErr:      true,
+		},
+		{
+			name:     "error status",
+			status:   http.StatusInternalServerError,
+			response: "boom",
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+				if tc.method == http.MethodGet {
+					assert.Equal(t, http.MethodGet, r.Method)
+					assert.Equal(t, "42", r.URL.Query().Get("id"))
+				} else {
+					assert.Equal(t, http.MethodPost, r.Method)
+					body, err := io.ReadAll(r.Body)
+					require.NoError(t, err)
+					assert.JSONEq(t, `{"id": "42"}`, string(body))
+				}
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+				}
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			provider := NewProvider(func() []ToolConfig {
+				return []ToolConfig{{
+					Name:         "lookup_order",
+					Description:  "Look up an order",
+					Method:       tc.method,
+					URL:          server.URL,
+					AuthHeader:   "X-Api-Key",
+					AuthValue:    "secret",
+					ResponsePath: tc.responsePath,
+				}}
+			}, server.Client(), &testLogger{})
+
+			tools := provider.GetToolsForBot("bot")
+			require.Len(t, tools, 1)
+
+			result, err := tools[0].Resolver(llm.NewContext(), func(args any) error {
+				return json.Unmarshal([]byte(`{"id": "42"}`), args)
+			})
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedResult, result)
+		})
+	}
+}
@@ -15,10 +15,46 @@ func SetupTables(db *sqlx.DB) error {
 		return fmt.Errorf("failed to create tables: %w", err)
 	}
 
+	if err := createLLMUsageEventsTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createLLMPromptVersionsTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createLLMGlossaryTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createLLMTracesTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createLLMBatchesTables(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createLLMConversationsTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createLLMSavedAnswersTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	if err := createLLMRemindersTable(db); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
 	if err := migrateOldTables(db); err != nil {
 		return fmt.Errorf("failed to migrate old tables: %w", err)
 	}
 
+	if err := migrateConversations(db); err != nil {
+		return fmt.Errorf("failed to migrate conversations: %w", err)
+	}
+
 	return nil
 }
 
@@ -36,6 +72,253 @@ func createLLMPostMetaTable(db *sqlx.DB) error {
 	return nil
 }
 
+// createLLMUsageEventsTable creates the LLM_UsageEvents table used for usage analytics
+func createLLMUsageEventsTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_UsageEvents (
+			ID TEXT NOT NULL PRIMARY KEY,
+			CreateAt BIGINT NOT NULL,
+			UserID TEXT NOT NULL,
+			TeamID TEXT NOT NULL,
+			ChannelID TEXT NOT NULL,
+			BotID TEXT NOT NULL,
+			Feature TEXT NOT NULL,
+			LatencyMS BIGINT NOT NULL,
+			ToolCalls INTEGER NOT NULL,
+			Failed BOOLEAN NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm usage events table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_llm_usageevents_createat ON LLM_UsageEvents (CreateAt);`); err != nil {
+		return fmt.Errorf("can't create llm usage events index: %w", err)
+	}
+
+	return nil
+}
+
+// createLLMPromptVersionsTable creates the LLM_PromptVersions table storing prompt overrides
+func createLLMPromptVersionsTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_PromptVersions (
+			ID TEXT NOT NULL PRIMARY KEY,
+			BotID TEXT NOT NULL,
+			Name TEXT NOT NULL,
+			Version INTEGER NOT NULL,
+			Template TEXT NOT NULL,
+			CreateAt BIGINT NOT NULL,
+			CreatedBy TEXT NOT NULL,
+			Active BOOLEAN NOT NULL,
+			UNIQUE (BotID, Name, Version)
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm prompt versions table: %w", err)
+	}
+
+	return nil
+}
+
+// createLLMGlossaryTable creates the LLM_Glossary table storing the workspace glossary
+func createLLMGlossaryTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_Glossary (
+			ID TEXT NOT NULL PRIMARY KEY,
+			Term TEXT NOT NULL,
+			Definition TEXT NOT NULL,
+			CaseSensitive BOOLEAN NOT NULL DEFAULT FALSE,
+			CreateAt BIGINT NOT NULL,
+			UpdateAt BIGINT NOT NULL,
+			UpdatedBy TEXT NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm glossary table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_llm_glossary_term ON LLM_Glossary (LOWER(Term));`); err != nil {
+		return fmt.Errorf("can't create llm glossary term index: %w", err)
+	}
+
+	return nil
+}
+
+// createLLMTracesTable creates the LLM_Traces table storing agent traces for debugging
+func createLLMTracesTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_Traces (
+			RequestPostID TEXT NOT NULL PRIMARY KEY,
+			BotUserID TEXT NOT NULL,
+			UserID TEXT NOT NULL,
+			ChannelID TEXT NOT NULL,
+			CreateAt BIGINT NOT NULL,
+			Trace TEXT NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm traces table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_llm_traces_createat ON LLM_Traces (CreateAt);`); err != nil {
+		return fmt.Errorf("can't create llm traces index: %w", err)
+	}
+
+	return nil
+}
+
+// createLLMBatchesTables creates the LLM_Batches table tracking batches submitted to providers
+// and the LLM_BatchResults table storing their results
+func createLLMBatchesTables(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_Batches (
+			ID TEXT NOT NULL PRIMARY KEY,
+			ServiceID TEXT NOT NULL,
+			ProviderBatchID TEXT NOT NULL,
+			Type TEXT NOT NULL,
+			Handler TEXT NOT NULL,
+			Status TEXT NOT NULL,
+			RequestCount INTEGER NOT NULL,
+			Error TEXT NOT NULL,
+			CreateAt BIGINT NOT NULL,
+			UpdateAt BIGINT NOT NULL,
+			CompletedAt BIGINT NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm batches table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_llm_batches_status ON LLM_Batches (Status);`); err != nil {
+		return fmt.Errorf("can't create llm batches index: %w", err)
+	}
+
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_BatchResults (
+			BatchID TEXT NOT NULL REFERENCES LLM_Batches(ID) ON DELETE CASCADE,
+			CustomID TEXT NOT NULL,
+			Output TEXT NOT NULL,
+			Error TEXT NOT NULL,
+			PRIMARY KEY (BatchID, CustomID)
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm batch results table: %w", err)
+	}
+
+	return nil
+}
+
+// createLLMConversationsTable creates the LLM_Conversations table storing the state of the
+// conversations with bots, keyed by the root post of their thread
+func createLLMConversationsTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_Conversations (
+			ID TEXT NOT NULL REFERENCES Posts(ID) ON DELETE CASCADE PRIMARY KEY,
+			BotID TEXT NOT NULL,
+			UserID TEXT NOT NULL,
+			ChannelID TEXT NOT NULL,
+			Title TEXT NOT NULL,
+			State TEXT NOT NULL,
+			InputTokens BIGINT NOT NULL DEFAULT 0,
+			OutputTokens BIGINT NOT NULL DEFAULT 0,
+			ModelCalls INTEGER NOT NULL DEFAULT 0,
+			CreateAt BIGINT NOT NULL,
+			UpdateAt BIGINT NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm conversations table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_llm_conversations_userid_updateat ON LLM_Conversations (UserID, UpdateAt);`); err != nil {
+		return fmt.Errorf("can't create llm conversations index: %w", err)
+	}
+
+	return nil
+}
+
+// createLLMSavedAnswersTable creates the LLM_SavedAnswers table storing the bot responses saved
+// by users, deleted with their post
+func createLLMSavedAnswersTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_SavedAnswers (
+			ID TEXT NOT NULL PRIMARY KEY,
+			UserID TEXT NOT NULL,
+			PostID TEXT NOT NULL REFERENCES Posts(ID) ON DELETE CASCADE,
+			BotID TEXT NOT NULL,
+			ChannelID TEXT NOT NULL,
+			Message TEXT NOT NULL,
+			Tags TEXT NOT NULL,
+			CreateAt BIGINT NOT NULL,
+			UpdateAt BIGINT NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm saved answers table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_llm_savedanswers_userid_postid ON LLM_SavedAnswers (UserID, PostID);`); err != nil {
+		return fmt.Errorf("can't create llm saved answers index: %w", err)
+	}
+
+	return nil
+}
+
+// createLLMRemindersTable creates the LLM_Reminders table storing the reminders scheduled by
+// users, deleted once delivered
+func createLLMRemindersTable(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS LLM_Reminders (
+			ID TEXT NOT NULL PRIMARY KEY,
+			UserID TEXT NOT NULL,
+			BotID TEXT NOT NULL,
+			PostID TEXT NOT NULL,
+			Message TEXT NOT NULL,
+			RemindAt BIGINT NOT NULL,
+			CreateAt BIGINT NOT NULL
+		);
+	`); err != nil {
+		return fmt.Errorf("can't create llm reminders table: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_llm_reminders_remindat ON LLM_Reminders (RemindAt);`); err != nil {
+		return fmt.Errorf("can't create llm reminders index: %w", err)
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_llm_reminders_userid ON LLM_Reminders (UserID);`); err != nil {
+		return fmt.Errorf("can't create llm reminders index: %w", err)
+	}
+
+	return nil
+}
+
+// migrateConversations creates the conversations of the threads titled before LLM_Conversations
+// existed. The bot and user come from the root post: a bot root post is an analysis requested by
+// the user in its llm_requester_user_id prop, with the analyzed thread in its props, otherwise
+// the bot is the first one to reply. Already migrated threads are skipped.
+func migrateConversations(db *sqlx.DB) error {
+	if _, err := db.Exec(`
+		INSERT INTO LLM_Conversations (ID, BotID, UserID, ChannelID, Title, State, CreateAt, UpdateAt)
+		SELECT
+			m.RootPostID,
+			CASE WHEN b.UserId IS NOT NULL THEN p.UserId ELSE COALESCE((
+				SELECT r.UserId FROM Posts r JOIN Bots rb ON rb.UserId = r.UserId
+				WHERE r.RootId = p.Id ORDER BY r.CreateAt LIMIT 1
+			), '') END,
+			CASE WHEN b.UserId IS NOT NULL THEN COALESCE(p.Props->>'llm_requester_user_id', '') ELSE p.UserId END,
+			p.ChannelId,
+			m.Title,
+			CASE WHEN b.UserId IS NOT NULL THEN json_build_object(
+				'thread_id', COALESCE(p.Props->>'referenced_thread', ''),
+				'analysis_type', COALESCE(p.Props->>'prompt_type', '')
+			)::text ELSE '{}' END,
+			p.CreateAt,
+			p.UpdateAt
+		FROM LLM_PostMeta m
+		JOIN Posts p ON p.Id = m.RootPostID
+		LEFT JOIN Bots b ON b.UserId = p.UserId
+		ON CONFLICT (ID) DO NOTHING;
+	`); err != nil {
+		return fmt.Errorf("can't migrate post titles to conversations: %w", err)
+	}
+
+	return nil
+}
+
 // migrateOldTables handles migration from older table structures
 func migrateOldTables(db *sqlx.DB) error {
 	// This fixes data retention issues when a post is deleted for an older version of the postmeta table.
@@ -0,0 +1,139 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package delegation lets agents ask questions to the other agents they delegate to with the
+// ask_agent tool, so specialist agents can be composed behind a generalist entry point. The
+// asked agent answers on behalf of the same user, with the same access restrictions.
+package delegation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmtools"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// maxAnswerLength is the largest number of characters of the answer given back to the asking agent
+	maxAnswerLength   = 20000
+	maxOutputTokens   = 4000
+	delegationTimeout = 5 * time.Minute
+)
+
+// ErrNotDelegate is returned when the agent is not one of the delegate agents of the asking agent.
+var ErrNotDelegate = errors.New("the agent is not a delegate of the asking agent")
+
+// BotSource returns the agents and checks the user may use them
+type BotSource interface {
+	GetBotByUsername(botUsername string) *bots.Bot
+	CheckUsageRestrictionsForUser(bot *bots.Bot, requestingUserID string) error
+	CheckUsageRestrictionsForChannel(bot *bots.Bot, channel *model.Channel) error
+}
+
+// ContextBuilder builds the LLM context of the asked agent.
+type ContextBuilder interface {
+	BuildLLMContextUserRequest(bot *bots.Bot, requestingUser *model.User, channel *model.Channel, opts ...llm.ContextOption) *llm.Context
+	WithLLMContextTools(bot *bots.Bot) llm.ContextOption
+}
+
+// Service asks questions to agents on behalf of other agents.
+type Service struct {
+	bots           BotSource
+	contextBuilder ContextBuilder
+	prompts        *llm.Prompts
+}
+
+// New creates a new delegation service
+func New(bots BotSource, contextBuilder ContextBuilder, prompts *llm.Prompts) *Service {
+	return &Service{
+		bots:           bots,
+		contextBuilder: contextBuilder,
+		prompts:        prompts,
+	}
+}
+
+// AskAgent asks the question to the agent for the agent of llmContext, and returns its answer.
+// The asked agent can only ask its own delegates in turn, see mmtools.MaxDelegationDepth.
+func (s *Service) AskAgent(llmContext *llm.Context, agentUsername, question string) (string, error) {
+	asker := s.bots.GetBotByUsername(llmContext.BotUsername)
+	if asker == nil || !slices.Contains(asker.GetConfig().DelegateAgents, agentUsername) {
+		return "", ErrNotDelegate
+	}
+	agent := s.bots.GetBotByUsername(agentUsername)
+	if agent == nil {
+		return "", ErrNotDelegate
+	}
+
+	if err := s.bots.CheckUsageRestrictionsForUser(agent, llmContext.RequestingUser.Id); err != nil {
+		return "", err
+	}
+	// In DMs with the asking agent, only the user restrictions apply like for the agent itself
+	if llmContext.Channel != nil && llmContext.Channel.Type != model.ChannelTypeDirect {
+		if err := s.bots.CheckUsageRestrictionsForChannel(agent, llmContext.Channel); err != nil {
+			return "", err
+		}
+	}
+
+	agentContext := s.contextBuilder.BuildLLMContextUserRequest(agent, llmContext.RequestingUser, llmContext.Channel, s.contextBuilder.WithLLMContextTools(agent))
+	agentContext.DelegatedBy = append(slices.Clone(llmContext.DelegatedBy), llmContext.BotUsername)
+	agentContext.Priority = llmContext.Priority
+
+	// The asked agent only gets the tool asking its own delegates, other tools need the approval of the user
+	opts := []llm.LanguageModelOption{llm.WithMaxGeneratedTokens(maxOutputTokens)}
+	var askAgent *llm.Tool
+	if agentContext.Tools != nil && len(agentContext.DelegatedBy) < mmtools.MaxDelegationDepth {
+		askAgent = agentContext.Tools.GetTool(mmtools.AskAgentToolName)
+	}
+	agentContext.Tools = llm.NewToolStore(nil, false)
+	if askAgent != nil {
+		agentContext.Tools.AddTools([]llm.Tool{*askAgent})
+		opts = append(opts, llm.WithAutoRunTools([]string{mmtools.AskAgentToolName}))
+	} else {
+		opts = append(opts, llm.WithToolsDisabled())
+	}
+
+	systemPrompt, err := s.prompts.Format(prompts.PromptDirectMessageQuestionSystem, agentContext)
+	if err != nil {
+		return "", fmt.Errorf("failed to format prompt: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), delegationTimeout)
+	defer cancel()
+
+	stream, err := agent.LLM().ChatCompletion(ctx, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{
+				Role: llm.PostRoleUser,
+				Message: fmt.Sprintf("The agent %s asks you this question while answering %s. Your answer is given to the agent, not shown to the user.\n\n%s",
+					asker.GetConfig().DisplayName, llmContext.RequestingUser.Username, question),
+			},
+		},
+		Context: agentContext,
+	}, opts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to ask agent: %w", err)
+	}
+	answer, err := stream.ReadAll()
+	if err != nil {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return fmt.Sprintf("%s did not answer.", agent.GetConfig().DisplayName), nil
+	}
+	if runes := []rune(answer); len(runes) > maxAnswerLength {
+		answer = string(runes[:maxAnswerLength]) + "\n... (answer truncated due to size limit)"
+	}
+
+	return answer, nil
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package delegation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	llmmocks "github.com/mattermost/mattermost-plugin-ai/llm/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/mmtools"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var errRestricted = errors.New("restricted")
+
+type fakeBots struct {
+	bots            map[string]*bots.Bot
+	blockedUser     bool
+	blockedChannels bool
+}
+
+func (f *fakeBots) GetBotByUsername(botUsername string) *bots.Bot {
+	return f.bots[botUsername]
+}
+
+func (f *fakeBots) CheckUsageRestrictionsForUser(*bots.Bot, string) error {
+	if f.blockedUser {
+		return errRestricted
+	}
+	return nil
+}
+
+func (f *fakeBots) CheckUsageRestrictionsForChannel(*bots.Bot, *model.Channel) error {
+	if f.blockedChannels {
+		return errRestricted
+	}
+	return nil
+}
+
+// fakeContextBuilder gives every agent the ask_agent tool
+type fakeContextBuilder struct{}
+
+func (fakeContextBuilder) BuildLLMContextUserRequest(bot *bots.Bot, requestingUser *model.User, channel *model.Channel, opts ...llm.ContextOption) *llm.Context {
+	llmContext := llm.NewContext(opts...)
+	llmContext.BotUsername = bot.GetConfig().Name
+	llmContext.RequestingUser = requestingUser
+	llmContext.Channel = channel
+	return llmContext
+}
+
+func (fakeContextBuilder) WithLLMContextTools(*bots.Bot) llm.ContextOption {
+	return func(c *llm.Context) {
+		c.Tools = llm.NewToolStore(nil, false)
+		c.Tools.AddTools([]llm.Tool{
+			{Name: mmtools.AskAgentToolName},
+			{Name: "create_jira_issue"},
+		})
+	}
+}
+
+func TestAskAgent(t *testing.T) {
+	tests := []struct {
+		name             string
+		agent            string
+		delegatedBy      []string
+		channel          *model.Channel
+		blockedUser      bool
+		blockedChannels  bool
+		expectedError    error
+		expectedAskAgent bool
+	}{
+		{name: "asks the delegate", agent: "sql", channel: &model.Channel{Id: "channel1", Type: model.ChannelTypeOpen}, expectedAskAgent: true},
+		{name: "delegate can't ask further at the depth limit", agent: "sql", delegatedBy: []string{"other"}},
+		{name: "not a delegate", agent: "legal", expectedError: ErrNotDelegate},
+		{name: "unknown agent", agent: "missing", expectedError: ErrNotDelegate},
+		{name: "user may not use the delegate", agent: "sql", blockedUser: true, expectedError: errRestricted},
+		{name: "delegate not allowed in the channel", agent: "sql", channel: &model.Channel{Id: "channel1", Type: model.ChannelTypeOpen}, blockedChannels: true, expectedError: errRestricted},
+		{name: "channel restrictions don't apply in DMs", agent: "sql", channel: &model.Channel{Id: "dm1", Type: model.ChannelTypeDirect}, blockedChannels: true, expectedAskAgent: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			promptsObj, err := llm.NewPrompts(prompts.PromptsFolder)
+			require.NoError(t, err)
+
+			languageModel := llmmocks.NewMockLanguageModel(t)
+			generalist := bots.NewBot(llm.BotConfig{Name: "ai", DisplayName: "AI", DelegateAgents: []string{"sql", "missing"}}, llm.ServiceConfig{}, &model.Bot{UserId: "bot1"}, nil)
+			sql := bots.NewBot(llm.BotConfig{Name: "sql", DisplayName: "SQL Expert"}, llm.ServiceConfig{}, &model.Bot{UserId: "bot2"}, languageModel)
+			legal := bots.NewBot(llm.BotConfig{Name: "legal"}, llm.ServiceConfig{}, &model.Bot{UserId: "bot3"}, nil)
+			source := &fakeBots{
+				bots:            map[string]*bots.Bot{"ai": generalist, "sql": sql, "legal": legal},
+				blockedUser:     test.blockedUser,
+				blockedChannels: test.blockedChannels,
+			}
+			service := New(source, fakeContextBuilder{}, promptsObj)
+
+			llmContext := llm.NewContext()
+			llmContext.BotUsername = "ai"
+			llmContext.RequestingUser = &model.User{Id: "user1", Username: "alice"}
+			llmContext.Channel = test.channel
+			llmContext.DelegatedBy = test.delegatedBy
+
+			if test.expectedError != nil {
+				_, err = service.AskAgent(llmContext, test.agent, "How do I list the largest tables?")
+				require.ErrorIs(t, err, test.expectedError)
+				return
+			}
+
+			languageModel.EXPECT().ChatCompletion(mock.Anything, mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, request llm.CompletionRequest, _ ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+				require.Equal(t, append(test.delegatedBy, "ai"), request.Context.DelegatedBy)
+				require.Nil(t, request.Context.Tools.GetTool("create_jira_issue"))
+				require.Equal(t, test.expectedAskAgent, request.Context.Tools.GetTool(mmtools.AskAgentToolName) != nil)
+				require.Contains(t, request.Posts[1].Message, "The agent AI asks you this question while answering alice.")
+				require.Contains(t, request.Posts[1].Message, "How do I list the largest tables?")
+				return llm.NewStreamFromString("  Query pg_class ordered by relpages.  "), nil
+			}).Once()
+
+			answer, err := service.AskAgent(llmContext, test.agent, "How do I list the largest tables?")
+			require.NoError(t, err)
+			require.Equal(t, "Query pg_class ordered by relpages.", answer)
+		})
+	}
+}
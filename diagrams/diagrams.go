@@ -0,0 +1,175 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package diagrams renders the Mermaid and PlantUML blocks of completed responses to images
+// attached to the post, since most readers can't make sense of raw diagram code.
+package diagrams
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	defaultMaxDiagrams = 5
+	// maxPostFiles is the number of files Mattermost allows on a post
+	maxPostFiles = 10
+	// maxImageSize is the largest rendered image read from the renderer
+	maxImageSize  = 10 * 1024 * 1024
+	renderTimeout = 30 * time.Second
+)
+
+// Config controls the rendering of diagrams in responses
+type Config struct {
+	// Enabled turns on the rendering of diagram blocks
+	Enabled bool `json:"enabled"`
+
+	// RendererURL is the base URL of a Kroki compatible service, diagrams are sent to
+	// {RendererURL}/{type}/png. For example "https://kroki.io".
+	RendererURL string `json:"rendererURL"`
+
+	// MaxDiagrams is the largest number of diagrams rendered in a response. Defaults to 5.
+	MaxDiagrams int `json:"maxDiagrams"`
+}
+
+// Block is a diagram found in a message
+type Block struct {
+	// Type is the diagram type of the renderer, "mermaid" or "plantuml"
+	Type   string
+	Source string
+	// Text is the whole fenced block in the message, including the fences
+	Text string
+}
+
+var blockRegexp = regexp.MustCompile("(?ms)^[ \\t]*```[ \\t]*(mermaid|plantuml|puml)[ \\t]*\\r?\\n(.*?)\\r?\\n[ \\t]*```[ \\t]*$")
+
+// FindBlocks returns the diagram blocks of the message in order.
+func FindBlocks(message string) []Block {
+	var blocks []Block
+	for _, match := range blockRegexp.FindAllStringSubmatch(message, -1) {
+		diagramType := strings.ToLower(match[1])
+		if diagramType == "puml" {
+			diagramType = "plantuml"
+		}
+		if strings.TrimSpace(match[2]) == "" {
+			continue
+		}
+		blocks = append(blocks, Block{
+			Type:   diagramType,
+			Source: match[2],
+			Text:   match[0],
+		})
+	}
+	return blocks
+}
+
+// Client is the Mattermost API used to attach rendered diagrams
+type Client interface {
+	UploadFile(data []byte, channelID, filename string) (*model.FileInfo, error)
+	LogWarn(msg string, keyValuePairs ...interface{})
+}
+
+// Processor renders the diagrams of completed responses and attaches them to the post
+type Processor struct {
+	config     func() Config
+	client     Client
+	httpClient *http.Client
+}
+
+// NewProcessor creates a diagram processor reading its configuration from config.
+func NewProcessor(config func() Config, client Client, httpClient *http.Client) *Processor {
+	return &Processor{
+		config:     config,
+		client:     client,
+		httpClient: httpClient,
+	}
+}
+
+// ProcessPost replaces the diagram blocks of the post that render successfully with image
+// attachments. Ephemeral posts can't have attachments so they are left unchanged.
+func (p *Processor) ProcessPost(ctx context.Context, post *model.Post, ephemeral bool) {
+	cfg := p.config()
+	if !cfg.Enabled || cfg.RendererURL == "" || ephemeral {
+		return
+	}
+
+	maxDiagrams := cfg.MaxDiagrams
+	if maxDiagrams <= 0 {
+		maxDiagrams = defaultMaxDiagrams
+	}
+
+	for i, block := range FindBlocks(post.Message) {
+		if i >= maxDiagrams || len(post.FileIds) >= maxPostFiles {
+			break
+		}
+
+		image, err := p.render(ctx, cfg.RendererURL, block)
+		if err != nil {
+			p.client.LogWarn("Failed to render diagram, leaving its source in the post", "post_id", post.Id, "type", block.Type, "error", err.Error())
+			continue
+		}
+
+		fileInfo, err := p.client.UploadFile(image, post.ChannelId, fmt.Sprintf("diagram_%d.png", i+1))
+		if err != nil {
+			p.client.LogWarn("Failed to upload rendered diagram", "post_id", post.Id, "error", err.Error())
+			continue
+		}
+
+		post.FileIds = append(post.FileIds, fileInfo.Id)
+		post.Message = strings.Replace(post.Message, block.Text, "", 1)
+	}
+
+	post.Message = strings.TrimSpace(post.Message)
+}
+
+// render sends the diagram to the renderer and returns the PNG image.
+func (p *Processor) render(ctx context.Context, rendererURL string, block Block) ([]byte, error) {
+	endpoint, err := url.JoinPath(rendererURL, block.Type, "png")
+	if err != nil {
+		return nil, fmt.Errorf("invalid renderer URL: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, renderTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(block.Source))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Accept", "image/png")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("renderer request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("renderer returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	image, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rendered diagram: %w", err)
+	}
+	if len(image) > maxImageSize {
+		return nil, errors.New("rendered diagram is too large")
+	}
+	if !bytes.HasPrefix(image, []byte("\x89PNG")) {
+		return nil, errors.New("renderer did not return a PNG image")
+	}
+
+	return image, nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package diagrams
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	uploads []string
+}
+
+func (c *fakeClient) UploadFile(_ []byte, channelID, filename string) (*model.FileInfo, error) {
+	c.uploads = append(c.uploads, channelID+"/"+filename)
+	return &model.FileInfo{Id: "file" + filename}, nil
+}
+
+func (c *fakeClient) LogWarn(string, ...interface{}) {}
+
+func TestFindBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected []Block
+	}{
+		{
+			name:    "mermaid and plantuml blocks",
+			message: "Flow:\n```mermaid\ngraph TD\n  A-->B\n```\nSequence:\n``` puml\n@startuml\nA -> B\n@enduml\n```",
+			expected: []Block{
+				{Type: "mermaid", Source: "graph TD\n  A-->B", Text: "```mermaid\ngraph TD\n  A-->B\n```"},
+				{Type: "plantuml", Source: "@startuml\nA -> B\n@enduml", Text: "``` puml\n@startuml\nA -> B\n@enduml\n```"},
+			},
+		},
+		{
+			name:    "other code blocks are ignored",
+			message: "```go\nfmt.Println(\"mermaid\")\n```",
+		},
+		{
+			name:    "empty diagrams are ignored",
+			message: "```mermaid\n   \n```",
+		},
+		{
+			name:    "unterminated block is ignored",
+			message: "```mermaid\ngraph TD\n  A-->B",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, FindBlocks(test.message))
+		})
+	}
+}
+
+func TestProcessPost(t *testing.T) {
+	renderer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "invalid") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("\x89PNG" + r.URL.Path))
+	}))
+	defer renderer.Close()
+
+	message := "Here is the flow:\n```mermaid\ngraph TD\n  A-->B\n```\nAnd a broken one:\n```plantuml\ninvalid\n```"
+
+	tests := []struct {
+		name            string
+		config          Config
+		ephemeral       bool
+		expectedMessage string
+		expectedUploads []string
+	}{
+		{
+			name:            "renders valid diagrams and keeps failed ones",
+			config:          Config{Enabled: true, RendererURL: renderer.URL},
+			expectedMessage: "Here is the flow:\n\nAnd a broken one:\n```plantuml\ninvalid\n```",
+			expectedUploads: []string{"channel1/diagram_1.png"},
+		},
+		{
+			name:            "disabled",
+			config:          Config{Enabled: false, RendererURL: renderer.URL},
+			expectedMessage: message,
+		},
+		{
+			name:            "no renderer",
+			config:          Config{Enabled: true},
+			expectedMessage: message,
+		},
+		{
+			name:            "ephemeral posts can't have attachments",
+			config:          Config{Enabled: true, RendererURL: renderer.URL},
+			ephemeral:       true,
+			expectedMessage: message,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &fakeClient{}
+			processor := NewProcessor(func() Config { return test.config }, client, renderer.Client())
+
+			post := &model.Post{Id: "post1", ChannelId: "channel1", Message: message}
+			processor.ProcessPost(context.Background(), post, test.ephemeral)
+
+			require.Equal(t, test.expectedMessage, post.Message)
+			require.Equal(t, test.expectedUploads, client.uploads)
+			require.Len(t, post.FileIds, len(test.expectedUploads))
+		})
+	}
+}
@@ -0,0 +1,493 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package digests posts digests of external content to channels. Admins register RSS and Atom
+// feeds, which are fetched periodically, and inbound email addresses, whose messages are
+// received by a webhook. The new content of each source is summarized by an agent at the
+// interval of the source and posted in its channel.
+//
+// The state of each source is stored in the plugin KV store. Only one node of the cluster polls
+// the sources at a time, so each digest is posted once.
+package digests
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	SourceTypeFeed  = "feed"
+	SourceTypeEmail = "email"
+
+	// DefaultIntervalMinutes is how often digests are posted when the source doesn't set it
+	DefaultIntervalMinutes = 60
+	// MinIntervalMinutes bounds how often digests are posted
+	MinIntervalMinutes = 5
+	// PollInterval is how often the sources are checked for digests to post
+	PollInterval = time.Minute
+	// DigestProp is the post prop marking digests, set to the ID of their source
+	DigestProp = "digest"
+
+	stateKeyPrefix = "digest_v1_"
+	// maxItemsPerDigest bounds the items summarized in each digest, the most recent are kept
+	maxItemsPerDigest = 30
+	// maxPendingEmails bounds the emails kept for the next digest, the oldest are dropped
+	maxPendingEmails = 100
+	// maxSeenItems bounds the IDs of the feed items remembered as already summarized. Feeds
+	// rarely list more items than this, so older items don't come back as new.
+	maxSeenItems = 500
+	fetchTimeout = 30 * time.Second
+	// generationTimeout bounds the generation of each digest
+	generationTimeout = 5 * time.Minute
+)
+
+var (
+	// ErrUnauthorized is returned when inbound email is received without the configured secret.
+	ErrUnauthorized = errors.New("invalid inbound email secret")
+	// ErrUnknownRecipient is returned when inbound email is sent to no registered address.
+	ErrUnknownRecipient = errors.New("no digest is registered for the recipient")
+)
+
+// State is the persisted state of a source.
+type State struct {
+	LastDigestAt int64 `json:"last_digest_at"`
+	// Seen are the IDs of the feed items already summarized, most recent last
+	Seen []string `json:"seen,omitempty"`
+	// Pending are the emails received since the last digest
+	Pending []Item `json:"pending,omitempty"`
+}
+
+// InboundEmail is an email received by the webhook. Both the JSON and the form fields of the
+// common inbound email services are accepted.
+type InboundEmail struct {
+	To        string `json:"to" form:"to"`
+	Recipient string `json:"recipient" form:"recipient"`
+	From      string `json:"from" form:"from"`
+	Sender    string `json:"sender" form:"sender"`
+	Subject   string `json:"subject" form:"subject"`
+	Text      string `json:"text" form:"text"`
+	BodyPlain string `json:"body-plain" form:"body-plain"`
+	HTML      string `json:"html" form:"html"`
+}
+
+// Config is the configuration the sources are read from
+type Config interface {
+	GetDigests() config.DigestsConfig
+	GetDefaultBotName() string
+}
+
+// BotSource returns the agents writing the digests
+type BotSource interface {
+	GetBotByUsernameOrFirst(botUsername string) *bots.Bot
+	CheckUsageRestrictionsForChannel(bot *bots.Bot, channel *model.Channel) error
+}
+
+// ContextBuilder builds the LLM context of the digests.
+type ContextBuilder interface {
+	BuildLLMContextUserRequest(bot *bots.Bot, requestingUser *model.User, channel *model.Channel, opts ...llm.ContextOption) *llm.Context
+}
+
+// Service fetches the sources, receives inbound email and posts the digests.
+type Service struct {
+	client         mmapi.Client
+	bots           BotSource
+	contextBuilder ContextBuilder
+	prompts        *llm.Prompts
+	i18n           *i18n.Bundle
+	mutexAPI       cluster.MutexPluginAPI
+	httpClient     *http.Client
+	config         Config
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+// New creates a new digests service. Call Start to begin polling.
+func New(
+	client mmapi.Client,
+	bots BotSource,
+	contextBuilder ContextBuilder,
+	prompts *llm.Prompts,
+	i18nBundle *i18n.Bundle,
+	mutexAPI cluster.MutexPluginAPI,
+	httpClient *http.Client,
+	config Config,
+) *Service {
+	return &Service{
+		client:         client,
+		bots:           bots,
+		contextBuilder: contextBuilder,
+		prompts:        prompts,
+		i18n:           i18nBundle,
+		mutexAPI:       mutexAPI,
+		httpClient:     httpClient,
+		config:         config,
+	}
+}
+
+// Start polls the sources every interval.
+func (s *Service) Start(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.Poll()
+			}
+		}
+	}()
+}
+
+// Stop stops polling.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// ReceiveEmail keeps the email for the next digest of each email source it's addressed to.
+func (s *Service) ReceiveEmail(secret string, email InboundEmail) error {
+	digestsConfig := s.config.GetDigests()
+	if digestsConfig.InboundEmailSecret == "" || !secretsEqual(secret, digestsConfig.InboundEmailSecret) {
+		return ErrUnauthorized
+	}
+
+	recipients := emailAddresses(firstNonEmpty(email.To, email.Recipient))
+	text := firstNonEmpty(email.Text, email.BodyPlain)
+	if text == "" {
+		text = htmlText(email.HTML)
+	}
+	item := Item{
+		ID:        model.NewId(),
+		Title:     strings.TrimSpace(email.Subject),
+		From:      firstNonEmpty(email.From, email.Sender),
+		Text:      truncate(strings.TrimSpace(text), maxItemTextLength),
+		Published: time.Now().UTC(),
+	}
+
+	received := false
+	for _, source := range digestsConfig.Sources {
+		if source.Type != SourceTypeEmail || !slices.Contains(recipients, strings.ToLower(strings.TrimSpace(source.Address))) {
+			continue
+		}
+		received = true
+		if err := s.updateState(source.ID, func(state *State) {
+			state.Pending = append(state.Pending, item)
+			if len(state.Pending) > maxPendingEmails {
+				state.Pending = state.Pending[len(state.Pending)-maxPendingEmails:]
+			}
+		}); err != nil {
+			return err
+		}
+	}
+	if !received {
+		return ErrUnknownRecipient
+	}
+
+	return nil
+}
+
+// Poll posts the digests of the sources whose interval elapsed. Only one node of the cluster
+// polls at a time.
+func (s *Service) Poll() {
+	sources := s.config.GetDigests().Sources
+	if len(sources) == 0 {
+		return
+	}
+
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_digests_poll")
+	if err != nil {
+		s.client.LogError("Failed to create digests poll mutex", "error", err)
+		return
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	now := time.Now()
+	for _, source := range sources {
+		if err := s.process(context.Background(), source, now); err != nil {
+			s.client.LogWarn("Failed to post digest", "source", source.Name, "source_id", source.ID, "error", err)
+		}
+	}
+}
+
+// process posts the digest of the source if its interval elapsed since the last one.
+func (s *Service) process(ctx context.Context, source config.DigestSourceConfig, now time.Time) error {
+	if source.ID == "" {
+		return errors.New("source has no ID")
+	}
+
+	state, err := s.getState(source.ID)
+	if err != nil {
+		return err
+	}
+	interval := time.Duration(intervalMinutes(source)) * time.Minute
+	if now.Sub(time.UnixMilli(state.LastDigestAt)) < interval {
+		return nil
+	}
+
+	var items []Item
+	var seen []string
+	switch source.Type {
+	case SourceTypeFeed:
+		items, seen, err = s.newFeedItems(ctx, source, state, now.Add(-interval))
+		if err != nil {
+			return err
+		}
+	case SourceTypeEmail:
+		items = state.Pending
+	default:
+		return fmt.Errorf("unknown source type %q", source.Type)
+	}
+
+	if len(items) > 0 {
+		if err := s.postDigest(ctx, source, items); err != nil {
+			return err
+		}
+	}
+
+	processed := map[string]bool{}
+	for _, item := range items {
+		processed[item.ID] = true
+	}
+	return s.updateState(source.ID, func(state *State) {
+		state.LastDigestAt = now.UnixMilli()
+		if seen != nil {
+			state.Seen = seen
+		}
+		// Emails received while the digest was written are kept for the next one
+		state.Pending = slices.DeleteFunc(state.Pending, func(item Item) bool { return processed[item.ID] })
+	})
+}
+
+// newFeedItems fetches the feed and returns the items not summarized yet, with the IDs of all the
+// items seen so far. The first time a feed is fetched, only the items published during the last
+// interval are new, so the digest doesn't summarize its whole history.
+func (s *Service) newFeedItems(ctx context.Context, source config.DigestSourceConfig, state *State, since time.Time) ([]Item, []string, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	fetched, err := fetchFeed(ctx, s.httpClient, source.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	firstFetch := state.LastDigestAt == 0
+	seen := slices.Clone(state.Seen)
+	var items []Item
+	// Feeds list their most recent items first, they are remembered in the order they were published
+	for _, item := range slices.Backward(fetched) {
+		if slices.Contains(seen, item.ID) {
+			continue
+		}
+		seen = append(seen, item.ID)
+		if firstFetch && (item.Published.IsZero() || item.Published.Before(since)) {
+			continue
+		}
+		items = append(items, item)
+	}
+	if len(seen) > maxSeenItems {
+		seen = seen[len(seen)-maxSeenItems:]
+	}
+
+	return items, seen, nil
+}
+
+// postDigest summarizes the items and posts the digest in the channel of the source.
+func (s *Service) postDigest(ctx context.Context, source config.DigestSourceConfig, items []Item) error {
+	if len(items) > maxItemsPerDigest {
+		items = items[len(items)-maxItemsPerDigest:]
+	}
+
+	channel, err := s.client.GetChannel(source.ChannelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+
+	botUsername := source.BotUsername
+	if botUsername == "" {
+		botUsername = s.config.GetDefaultBotName()
+	}
+	bot := s.bots.GetBotByUsernameOrFirst(botUsername)
+	if bot == nil {
+		return errors.New("no agent to write the digest with")
+	}
+	if err := s.bots.CheckUsageRestrictionsForChannel(bot, channel); err != nil {
+		return fmt.Errorf("agent can't be used in the channel: %w", err)
+	}
+
+	summary, err := s.summarize(ctx, bot, channel, source, items)
+	if err != nil {
+		return err
+	}
+
+	T := s.serverLocalizer()
+	post := &model.Post{
+		UserId:    bot.GetMMBot().UserId,
+		ChannelId: channel.Id,
+		Message:   T("agents.digests.header", "#### %s digest\n", source.Name) + summary,
+	}
+	post.AddProp(DigestProp, source.ID)
+	if err := s.client.CreatePost(post); err != nil {
+		return fmt.Errorf("failed to post digest: %w", err)
+	}
+
+	return nil
+}
+
+// summarize returns the digest the agent writes from the items.
+func (s *Service) summarize(ctx context.Context, bot *bots.Bot, channel *model.Channel, source config.DigestSourceConfig, items []Item) (string, error) {
+	// Digests aren't requested by anyone, the agent writes them as itself
+	botUser, err := s.client.GetUser(bot.GetMMBot().UserId)
+	if err != nil {
+		return "", fmt.Errorf("failed to get agent user: %w", err)
+	}
+
+	llmContext := s.contextBuilder.BuildLLMContextUserRequest(bot, botUser, channel)
+	llmContext.Priority = llm.PriorityBackground
+	llmContext.Parameters = map[string]any{
+		"SourceName":   source.Name,
+		"SourceType":   source.Type,
+		"Instructions": source.Instructions,
+		"Items":        formatItems(items),
+	}
+
+	systemPrompt, err := s.prompts.Format(prompts.PromptDigestSystem, llmContext)
+	if err != nil {
+		return "", fmt.Errorf("failed to format system prompt: %w", err)
+	}
+	userPrompt, err := s.prompts.Format(prompts.PromptDigestUser, llmContext)
+	if err != nil {
+		return "", fmt.Errorf("failed to format user prompt: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, generationTimeout)
+	defer cancel()
+
+	result, err := bot.LLM().ChatCompletionNoStream(ctx, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: userPrompt},
+		},
+		Context: llmContext,
+	}, llm.WithToolsDisabled())
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize digest: %w", err)
+	}
+
+	return strings.TrimSpace(result), nil
+}
+
+// formatItems lists the items for the prompt, oldest first.
+func formatItems(items []Item) string {
+	var builder strings.Builder
+	for i, item := range items {
+		fmt.Fprintf(&builder, "### Item %d: %s\n", i+1, item.Title)
+		if item.Link != "" {
+			fmt.Fprintf(&builder, "Link: %s\n", item.Link)
+		}
+		if item.From != "" {
+			fmt.Fprintf(&builder, "From: %s\n", item.From)
+		}
+		if !item.Published.IsZero() {
+			fmt.Fprintf(&builder, "Published: %s\n", item.Published.UTC().Format(time.RFC1123))
+		}
+		fmt.Fprintf(&builder, "\n%s\n\n", item.Text)
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+func (s *Service) serverLocalizer() i18n.TranslationFunc {
+	locale := ""
+	if serverConfig := s.client.GetConfig(); serverConfig != nil && serverConfig.LocalizationSettings.DefaultServerLocale != nil {
+		locale = *serverConfig.LocalizationSettings.DefaultServerLocale
+	}
+	return i18n.LocalizerFunc(s.i18n, locale)
+}
+
+func (s *Service) getState(sourceID string) (*State, error) {
+	var state State
+	if err := s.client.KVGet(stateKeyPrefix+sourceID, &state); err != nil {
+		return nil, fmt.Errorf("failed to get digest state: %w", err)
+	}
+	return &state, nil
+}
+
+// updateState updates the state of the source, which inbound email is received into from any node.
+func (s *Service) updateState(sourceID string, update func(state *State)) error {
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_digest_"+sourceID)
+	if err != nil {
+		return fmt.Errorf("failed to create digest mutex: %w", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	state, err := s.getState(sourceID)
+	if err != nil {
+		return err
+	}
+	update(state)
+	if err := s.client.KVSet(stateKeyPrefix+sourceID, state); err != nil {
+		return fmt.Errorf("failed to save digest state: %w", err)
+	}
+	return nil
+}
+
+func secretsEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func intervalMinutes(source config.DigestSourceConfig) int {
+	if source.IntervalMinutes <= 0 {
+		return DefaultIntervalMinutes
+	}
+	return max(source.IntervalMinutes, MinIntervalMinutes)
+}
+
+// emailAddresses returns the lowercased addresses of a list of recipients.
+func emailAddresses(recipients string) []string {
+	var addresses []string
+	parsed, err := mail.ParseAddressList(recipients)
+	if err != nil {
+		// Fall back to the raw list, some services send bare addresses
+		for _, address := range strings.Split(recipients, ",") {
+			addresses = append(addresses, strings.ToLower(strings.TrimSpace(address)))
+		}
+		return addresses
+	}
+	for _, address := range parsed {
+		addresses = append(addresses, strings.ToLower(address.Address))
+	}
+	return addresses
+}
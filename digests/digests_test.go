@@ -0,0 +1,240 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package digests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	llmmocks "github.com/mattermost/mattermost-plugin-ai/llm/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mmapitest"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient keeps the KV store and the posts in memory.
+type fakeClient struct {
+	mmapi.Client
+	kv    map[string][]byte
+	posts []*model.Post
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{kv: map[string][]byte{}}
+}
+
+func (f *fakeClient) KVGet(key string, value interface{}) error {
+	data, ok := f.kv[key]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (f *fakeClient) KVSet(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	f.kv[key] = data
+	return nil
+}
+
+func (f *fakeClient) GetUser(userID string) (*model.User, error) {
+	return &model.User{Id: userID, Username: "user-" + userID}, nil
+}
+
+func (f *fakeClient) GetChannel(channelID string) (*model.Channel, error) {
+	return &model.Channel{Id: channelID, TeamId: "team1", Name: "news"}, nil
+}
+
+func (f *fakeClient) CreatePost(post *model.Post) error {
+	post.Id = model.NewId()
+	f.posts = append(f.posts, post)
+	return nil
+}
+
+func (f *fakeClient) GetConfig() *model.Config {
+	return &model.Config{}
+}
+
+func (f *fakeClient) LogError(string, ...interface{}) {}
+
+func (f *fakeClient) LogWarn(string, ...interface{}) {}
+
+type fakeBots struct {
+	bot *bots.Bot
+}
+
+func (f *fakeBots) GetBotByUsernameOrFirst(string) *bots.Bot {
+	return f.bot
+}
+
+func (f *fakeBots) CheckUsageRestrictionsForChannel(*bots.Bot, *model.Channel) error {
+	return nil
+}
+
+type fakeContextBuilder struct{}
+
+func (fakeContextBuilder) BuildLLMContextUserRequest(_ *bots.Bot, user *model.User, channel *model.Channel, _ ...llm.ContextOption) *llm.Context {
+	llmContext := llm.NewContext()
+	llmContext.RequestingUser = user
+	llmContext.Channel = channel
+	return llmContext
+}
+
+type fakeConfig struct {
+	digests config.DigestsConfig
+}
+
+func (f *fakeConfig) GetDigests() config.DigestsConfig {
+	return f.digests
+}
+
+func (f *fakeConfig) GetDefaultBotName() string {
+	return "ai"
+}
+
+func newTestService(t *testing.T, client *fakeClient, cfg *fakeConfig, languageModel llm.LanguageModel) *Service {
+	promptsObj, err := llm.NewPrompts(prompts.PromptsFolder)
+	require.NoError(t, err)
+	bot := bots.NewBot(llm.BotConfig{}, llm.ServiceConfig{}, &model.Bot{UserId: "bot1"}, languageModel)
+	return New(client, &fakeBots{bot: bot}, fakeContextBuilder{}, promptsObj, i18n.Init(), mmapitest.MutexAPI{}, http.DefaultClient, cfg)
+}
+
+func feedWith(items ...string) string {
+	feed := `<rss version="2.0"><channel><title>News</title>`
+	for _, item := range items {
+		feed += item
+	}
+	return feed + `</channel></rss>`
+}
+
+func feedItem(id string, published time.Time) string {
+	return fmt.Sprintf(`<item><title>Item %s</title><guid>%s</guid><link>https://example.com/%s</link><pubDate>%s</pubDate><description>About %s</description></item>`,
+		id, id, id, published.Format(time.RFC1123Z), id)
+}
+
+func TestFeedDigest(t *testing.T) {
+	now := time.Date(2026, time.October, 12, 12, 0, 0, 0, time.UTC)
+	feed := feedWith(feedItem("new", now.Add(-30*time.Minute)), feedItem("old", now.Add(-48*time.Hour)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(feed))
+	}))
+	defer server.Close()
+
+	client := newFakeClient()
+	source := config.DigestSourceConfig{ID: "news", Name: "Vendor news", Type: SourceTypeFeed, URL: server.URL, ChannelID: "channel1", Instructions: "Focus on security."}
+	languageModel := llmmocks.NewMockLanguageModel(t)
+	service := newTestService(t, client, &fakeConfig{digests: config.DigestsConfig{Sources: []config.DigestSourceConfig{source}}}, languageModel)
+
+	// The first digest only covers the items published during the last interval
+	languageModel.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, request llm.CompletionRequest, _ ...llm.LanguageModelOption) (string, error) {
+		require.Equal(t, llm.PriorityBackground, request.Context.Priority)
+		require.Contains(t, request.Posts[0].Message, "Vendor news")
+		require.Contains(t, request.Posts[0].Message, "Focus on security.")
+		require.Contains(t, request.Posts[1].Message, "### Item 1: Item new")
+		require.Contains(t, request.Posts[1].Message, "Link: https://example.com/new")
+		require.NotContains(t, request.Posts[1].Message, "Item old")
+		return "- [Item new](https://example.com/new)", nil
+	}).Once()
+
+	require.NoError(t, service.process(context.Background(), source, now))
+	require.Len(t, client.posts, 1)
+	require.Equal(t, "channel1", client.posts[0].ChannelId)
+	require.Equal(t, "bot1", client.posts[0].UserId)
+	require.Equal(t, "#### Vendor news digest\n- [Item new](https://example.com/new)", client.posts[0].Message)
+	require.Equal(t, "news", client.posts[0].GetProp(DigestProp))
+
+	// Nothing is fetched before the interval elapsed
+	feed = feedWith(feedItem("newer", now.Add(10*time.Minute)), feedItem("new", now.Add(-30*time.Minute)))
+	require.NoError(t, service.process(context.Background(), source, now.Add(30*time.Minute)))
+	require.Len(t, client.posts, 1)
+
+	// Items already summarized aren't summarized again
+	languageModel.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, request llm.CompletionRequest, _ ...llm.LanguageModelOption) (string, error) {
+		require.Contains(t, request.Posts[1].Message, "Item newer")
+		require.NotContains(t, request.Posts[1].Message, "Item new\n")
+		return "- Item newer", nil
+	}).Once()
+	require.NoError(t, service.process(context.Background(), source, now.Add(time.Hour)))
+	require.Len(t, client.posts, 2)
+
+	// Nothing is posted when there's nothing new
+	require.NoError(t, service.process(context.Background(), source, now.Add(2*time.Hour)))
+	require.Len(t, client.posts, 2)
+}
+
+func TestReceiveEmail(t *testing.T) {
+	source := config.DigestSourceConfig{ID: "announce", Name: "Announcements", Type: SourceTypeEmail, Address: "Announce@Digests.example.com", ChannelID: "channel1"}
+	cfg := &fakeConfig{digests: config.DigestsConfig{InboundEmailSecret: "secret", Sources: []config.DigestSourceConfig{source}}}
+
+	tests := []struct {
+		name          string
+		secret        string
+		email         InboundEmail
+		expectedError error
+	}{
+		{name: "no secret", email: InboundEmail{To: "announce@digests.example.com"}, expectedError: ErrUnauthorized},
+		{name: "wrong secret", secret: "guess", email: InboundEmail{To: "announce@digests.example.com"}, expectedError: ErrUnauthorized},
+		{name: "unknown recipient", secret: "secret", email: InboundEmail{To: "other@digests.example.com"}, expectedError: ErrUnknownRecipient},
+		{name: "named recipient", secret: "secret", email: InboundEmail{To: "Team <announce@digests.example.com>, other@example.com", Subject: "Maintenance", Text: "Tonight"}},
+		{name: "mailgun fields", secret: "secret", email: InboundEmail{Recipient: "announce@digests.example.com", Subject: "Maintenance", BodyPlain: "Tonight"}},
+		{name: "html only", secret: "secret", email: InboundEmail{To: "announce@digests.example.com", Subject: "Maintenance", HTML: "<p>Tonight</p>"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newFakeClient()
+			service := newTestService(t, client, cfg, nil)
+
+			err := service.ReceiveEmail(test.secret, test.email)
+			state, stateErr := service.getState(source.ID)
+			require.NoError(t, stateErr)
+			if test.expectedError != nil {
+				require.ErrorIs(t, err, test.expectedError)
+				require.Empty(t, state.Pending)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, state.Pending, 1)
+			require.Equal(t, "Maintenance", state.Pending[0].Title)
+			require.Equal(t, "Tonight", state.Pending[0].Text)
+		})
+	}
+
+	t.Run("digest", func(t *testing.T) {
+		client := newFakeClient()
+		languageModel := llmmocks.NewMockLanguageModel(t)
+		service := newTestService(t, client, cfg, languageModel)
+
+		require.NoError(t, service.ReceiveEmail("secret", InboundEmail{To: "announce@digests.example.com", From: "ops@example.com", Subject: "Maintenance", Text: "The VPN is down tonight."}))
+
+		languageModel.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, request llm.CompletionRequest, _ ...llm.LanguageModelOption) (string, error) {
+			require.Contains(t, request.Posts[0].Message, "emails received since the last one")
+			require.Contains(t, request.Posts[1].Message, "From: ops@example.com")
+			require.Contains(t, request.Posts[1].Message, "The VPN is down tonight.")
+			return "- VPN maintenance tonight", nil
+		}).Once()
+
+		require.NoError(t, service.process(context.Background(), source, time.Now()))
+		require.Len(t, client.posts, 1)
+
+		state, err := service.getState(source.ID)
+		require.NoError(t, err)
+		require.Empty(t, state.Pending)
+	})
+}
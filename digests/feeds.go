@@ -0,0 +1,224 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package digests
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// maxFeedSize is the largest feed read, longer feeds are rejected
+	maxFeedSize = 5 * 1024 * 1024
+	// maxItemTextLength is the largest number of characters kept of each item
+	maxItemTextLength = 4000
+)
+
+// Item is an entry of a feed or an inbound email, to be summarized in the next digest
+type Item struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Link      string    `json:"link,omitempty"`
+	From      string    `json:"from,omitempty"`
+	Text      string    `json:"text"`
+	Published time.Time `json:"published"`
+}
+
+// xmlFeed holds the items of RSS 2.0, RSS 1.0 and Atom feeds. Elements are matched in any
+// namespace, so the same fields read the three formats.
+type xmlFeed struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"date"`
+	Description string `xml:"description"`
+	Content     string `xml:"encoded"`
+}
+
+type atomEntry struct {
+	Title     string `xml:"title"`
+	ID        string `xml:"id"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Links     []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+}
+
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+}
+
+// fetchFeed downloads the feed and returns its items.
+func fetchFeed(ctx context.Context, httpClient *http.Client, feedURL string) ([]Item, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.8")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch feed: status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	if len(body) > maxFeedSize {
+		return nil, fmt.Errorf("feed is larger than %d bytes", maxFeedSize)
+	}
+
+	return parseFeed(body)
+}
+
+// parseFeed returns the items of an RSS or Atom feed.
+func parseFeed(body []byte) ([]Item, error) {
+	var feed xmlFeed
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	decoder.CharsetReader = charset.NewReaderLabel
+	if err := decoder.Decode(&feed); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	var items []Item
+	for _, entry := range append(feed.Channel.Items, feed.Items...) {
+		text := entry.Content
+		if text == "" {
+			text = entry.Description
+		}
+		item := Item{
+			ID:        firstNonEmpty(entry.GUID, entry.Link, entry.Title),
+			Title:     htmlText(entry.Title),
+			Link:      strings.TrimSpace(entry.Link),
+			Text:      truncate(htmlText(text), maxItemTextLength),
+			Published: parseFeedDate(firstNonEmpty(entry.PubDate, entry.Date)),
+		}
+		if item.ID != "" {
+			items = append(items, item)
+		}
+	}
+
+	for _, entry := range feed.Entries {
+		link := ""
+		for _, l := range entry.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		text := entry.Content
+		if text == "" {
+			text = entry.Summary
+		}
+		item := Item{
+			ID:        firstNonEmpty(entry.ID, link, entry.Title),
+			Title:     htmlText(entry.Title),
+			Link:      strings.TrimSpace(link),
+			Text:      truncate(htmlText(text), maxItemTextLength),
+			Published: parseFeedDate(firstNonEmpty(entry.Published, entry.Updated)),
+		}
+		if item.ID != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+func parseFeedDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range feedDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
+
+// blockElements are separated from the text around them
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "td": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "pre": true, "blockquote": true,
+}
+
+// htmlText returns the text of an HTML fragment, as feeds commonly escape HTML in their
+// descriptions. Plain text is returned unchanged but for its whitespace.
+func htmlText(fragment string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), nil)
+	if err != nil {
+		return strings.Join(strings.Fields(fragment), " ")
+	}
+
+	var builder strings.Builder
+	var extract func(n *html.Node)
+	extract = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+			return
+		}
+		if n.Type == html.TextNode {
+			builder.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			extract(c)
+		}
+		if n.Type == html.ElementNode && blockElements[n.Data] {
+			builder.WriteString(" ")
+		}
+	}
+	for _, node := range nodes {
+		extract(node)
+	}
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:length])) + "…"
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package digests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const rssFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+  <channel>
+    <title>Release notes</title>
+    <item>
+      <title>Version 2.0</title>
+      <link>https://example.com/2.0</link>
+      <guid>release-2.0</guid>
+      <pubDate>Mon, 12 Oct 2026 09:00:00 +0000</pubDate>
+      <description>&lt;p&gt;Adds &lt;b&gt;dark mode&lt;/b&gt;.&lt;/p&gt;</description>
+    </item>
+    <item>
+      <title>Version 1.9</title>
+      <link>https://example.com/1.9</link>
+      <description>Bug fixes.</description>
+      <content:encoded><![CDATA[<p>Fixes the <em>login</em> bug.</p>]]></content:encoded>
+    </item>
+  </channel>
+</rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Engineering blog</title>
+  <entry>
+    <title>Scaling the database</title>
+    <id>tag:example.com,2026:scaling</id>
+    <link rel="alternate" href="https://example.com/scaling"/>
+    <link rel="edit" href="https://example.com/edit/scaling"/>
+    <updated>2026-10-11T08:30:00Z</updated>
+    <summary type="html">We moved to &lt;i&gt;sharding&lt;/i&gt;.</summary>
+  </entry>
+</feed>`
+
+const rdfFeed = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel><title>Old news</title></channel>
+  <item>
+    <title>Legacy item</title>
+    <link>https://example.com/legacy</link>
+    <dc:date>2026-10-10T10:00:00Z</dc:date>
+    <description>Still RSS 1.0.</description>
+  </item>
+</rdf:RDF>`
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectedItems []Item
+		expectError   bool
+	}{
+		{
+			name: "rss",
+			body: rssFeed,
+			expectedItems: []Item{
+				{ID: "release-2.0", Title: "Version 2.0", Link: "https://example.com/2.0", Text: "Adds dark mode.", Published: time.Date(2026, time.October, 12, 9, 0, 0, 0, time.UTC)},
+				{ID: "https://example.com/1.9", Title: "Version 1.9", Link: "https://example.com/1.9", Text: "Fixes the login bug."},
+			},
+		},
+		{
+			name: "atom",
+			body: atomFeed,
+			expectedItems: []Item{
+				{ID: "tag:example.com,2026:scaling", Title: "Scaling the database", Link: "https://example.com/scaling", Text: "We moved to sharding.", Published: time.Date(2026, time.October, 11, 8, 30, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "rss 1.0",
+			body: rdfFeed,
+			expectedItems: []Item{
+				{ID: "https://example.com/legacy", Title: "Legacy item", Link: "https://example.com/legacy", Text: "Still RSS 1.0.", Published: time.Date(2026, time.October, 10, 10, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:        "not a feed",
+			body:        "not xml at all",
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, err := parseFeed([]byte(test.body))
+			if test.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, items, len(test.expectedItems))
+			for i, expected := range test.expectedItems {
+				require.Equal(t, expected.ID, items[i].ID)
+				require.Equal(t, expected.Title, items[i].Title)
+				require.Equal(t, expected.Link, items[i].Link)
+				require.Equal(t, expected.Text, items[i].Text)
+				require.True(t, expected.Published.Equal(items[i].Published), "published %s", items[i].Published)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package duplicates links the questions posted in designated channels to similar questions
+// that were answered before, found in the embeddings index.
+package duplicates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// DefaultMinScore is the similarity previous questions must reach when the channel doesn't set one
+	DefaultMinScore = 0.8
+	// DefaultMaxResults is the number of previous questions linked when the channel doesn't set one
+	DefaultMaxResults = 3
+
+	// DuplicatesProp is the post prop holding the JSON encoded matches of a reply
+	DuplicatesProp = "duplicate_questions"
+
+	// candidatesPerResult is how many search results are checked for each linked question, as
+	// chunks of the same thread and unanswered questions are left out.
+	candidatesPerResult = 4
+	excerptLength       = 100
+)
+
+// errNotChecked is returned for the messages that aren't checked, such as replies.
+var errNotChecked = errors.New("message not checked")
+
+// Match is a previously answered question similar to a new one
+type Match struct {
+	PostID    string  `json:"post_id"`
+	ChannelID string  `json:"channel_id"`
+	Score     float32 `json:"score"`
+	Excerpt   string  `json:"excerpt"`
+}
+
+// Config is the configuration the checked channels are read from
+type Config interface {
+	GetDuplicateQuestionsChannels() []config.DuplicateQuestionsChannelConfig
+	GetDefaultBotName() string
+}
+
+// BotSource returns the agents replying in the channels
+type BotSource interface {
+	GetBotByUsernameOrFirst(botUsername string) *bots.Bot
+	IsAnyBot(userID string) bool
+	CheckUsageRestrictionsForChannel(bot *bots.Bot, channel *model.Channel) error
+}
+
+// Searcher searches the embeddings index
+type Searcher interface {
+	Enabled() bool
+	Search(ctx context.Context, query string, opts embeddings.SearchOptions) ([]embeddings.SearchResult, error)
+}
+
+// Service links new questions to the answers of similar ones
+type Service struct {
+	client mmapi.Client
+	bots   BotSource
+	search Searcher
+	i18n   *i18n.Bundle
+	config Config
+}
+
+// New creates a new duplicate questions service
+func New(
+	client mmapi.Client,
+	bots BotSource,
+	search Searcher,
+	i18nBundle *i18n.Bundle,
+	config Config,
+) *Service {
+	return &Service{
+		client: client,
+		bots:   bots,
+		search: search,
+		i18n:   i18nBundle,
+		config: config,
+	}
+}
+
+// MessageHasBeenPosted replies to the post with links to similar questions if it asks a question
+// in a checked channel
+func (s *Service) MessageHasBeenPosted(ctx context.Context, post *model.Post) {
+	if err := s.check(ctx, post); err != nil && !errors.Is(err, errNotChecked) {
+		s.client.LogError("Failed to look for duplicate questions", "post_id", post.Id, "channel_id", post.ChannelId, "error", err)
+	}
+}
+
+func (s *Service) check(ctx context.Context, post *model.Post) error {
+	channelConfig, ok := s.channelConfig(post.ChannelId)
+	if !ok {
+		return errNotChecked
+	}
+
+	if post.RootId != "" || post.IsSystemMessage() || s.bots.IsAnyBot(post.UserId) || !IsQuestion(post.Message) {
+		return errNotChecked
+	}
+
+	if !s.search.Enabled() {
+		return errors.New("search is not configured")
+	}
+
+	channel, err := s.client.GetChannel(post.ChannelId)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+
+	botUsername := channelConfig.BotUsername
+	if botUsername == "" {
+		botUsername = s.config.GetDefaultBotName()
+	}
+	bot := s.bots.GetBotByUsernameOrFirst(botUsername)
+	if bot == nil {
+		return errors.New("no agent to reply with")
+	}
+	if err := s.bots.CheckUsageRestrictionsForChannel(bot, channel); err != nil {
+		return fmt.Errorf("agent can't be used in the channel: %w", err)
+	}
+
+	matches, err := s.FindAnswered(ctx, channelConfig, channel, post)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	return s.postMatches(bot, post, matches)
+}
+
+// FindAnswered returns the previous questions similar to the post, most similar first. Only
+// questions that got a reply from someone other than their author, and that the author of the
+// post can see, are returned.
+func (s *Service) FindAnswered(ctx context.Context, channelConfig config.DuplicateQuestionsChannelConfig, channel *model.Channel, post *model.Post) ([]Match, error) {
+	minScore := channelConfig.MinScore
+	if minScore <= 0 {
+		minScore = DefaultMinScore
+	}
+	maxResults := channelConfig.MaxResults
+	if maxResults <= 0 {
+		maxResults = DefaultMaxResults
+	}
+
+	opts := embeddings.SearchOptions{
+		Limit:         maxResults * candidatesPerResult,
+		MinScore:      minScore,
+		ChannelID:     channel.Id,
+		UserID:        post.UserId,
+		CreatedBefore: post.CreateAt,
+	}
+	// The matches are posted in the channel, so questions of other channels are only linked when
+	// everyone in the team may read them, whatever the author of the post can read
+	if channelConfig.SearchTeam && channel.TeamId != "" {
+		opts.ChannelID = ""
+		opts.TeamID = channel.TeamId
+		opts.PublicChannelsOnly = true
+	}
+
+	results, err := s.search.Search(ctx, format.PostBody(post), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for similar questions: %w", err)
+	}
+
+	var matches []Match
+	seen := map[string]bool{post.Id: true}
+	for _, result := range results {
+		if len(matches) == maxResults {
+			break
+		}
+
+		postID := result.Document.PostID
+		if seen[postID] {
+			continue
+		}
+		seen[postID] = true
+
+		thread, err := s.client.GetPostThread(postID)
+		if err != nil {
+			s.client.LogWarn("Failed to get thread of similar question", "post_id", postID, "error", err)
+			continue
+		}
+		found, ok := thread.Posts[postID]
+		if !ok {
+			continue
+		}
+
+		// Replies are indexed too, the question starting their thread is linked instead
+		rootID := postID
+		if found.RootId != "" {
+			rootID = found.RootId
+			if seen[rootID] {
+				continue
+			}
+			seen[rootID] = true
+		}
+		root, ok := thread.Posts[rootID]
+		if !ok || !IsAnswered(root, thread) {
+			continue
+		}
+
+		matches = append(matches, Match{
+			PostID:    root.Id,
+			ChannelID: root.ChannelId,
+			Score:     result.Score,
+			Excerpt:   excerpt(root.Message),
+		})
+	}
+
+	return matches, nil
+}
+
+func (s *Service) postMatches(bot *bots.Bot, post *model.Post, matches []Match) error {
+	serverConfig := s.client.GetConfig()
+	locale := ""
+	siteURL := ""
+	if serverConfig != nil {
+		if serverConfig.LocalizationSettings.DefaultServerLocale != nil {
+			locale = *serverConfig.LocalizationSettings.DefaultServerLocale
+		}
+		if serverConfig.ServiceSettings.SiteURL != nil {
+			siteURL = *serverConfig.ServiceSettings.SiteURL
+		}
+	}
+	T := i18n.LocalizerFunc(s.i18n, locale)
+
+	var message strings.Builder
+	message.WriteString(T("agents.duplicate_questions.header", "This looks similar to questions answered before:"))
+	for _, match := range matches {
+		message.WriteString("\n- ")
+		message.WriteString(T("agents.duplicate_questions.match", "[%s](%s/_redirect/pl/%s) (%d%% similar)", match.Excerpt, siteURL, match.PostID, int(match.Score*100)))
+	}
+
+	encoded, err := json.Marshal(matches)
+	if err != nil {
+		return fmt.Errorf("failed to encode matches: %w", err)
+	}
+
+	reply := &model.Post{
+		UserId:    bot.GetMMBot().UserId,
+		ChannelId: post.ChannelId,
+		RootId:    post.Id,
+		Message:   message.String(),
+	}
+	reply.AddProp(DuplicatesProp, string(encoded))
+
+	if err := s.client.CreatePost(reply); err != nil {
+		return fmt.Errorf("failed to post similar questions: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) channelConfig(channelID string) (config.DuplicateQuestionsChannelConfig, bool) {
+	for _, channelConfig := range s.config.GetDuplicateQuestionsChannels() {
+		if channelConfig.ChannelID == channelID {
+			return channelConfig, true
+		}
+	}
+	return config.DuplicateQuestionsChannelConfig{}, false
+}
+
+// IsQuestion reports whether the message asks something. Short messages such as "any update?"
+// are left out as they have nothing to compare.
+func IsQuestion(message string) bool {
+	return strings.Contains(message, "?") && len(strings.Fields(message)) >= 3
+}
+
+// IsAnswered reports whether someone other than the author of the root post replied in the
+// thread. The links posted by this service don't count.
+func IsAnswered(root *model.Post, thread *model.PostList) bool {
+	for _, reply := range thread.Posts {
+		if reply.Id == root.Id || reply.UserId == root.UserId || reply.IsSystemMessage() || reply.DeleteAt != 0 {
+			continue
+		}
+		if reply.GetProp(DuplicatesProp) != nil {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// excerpt returns the first line of the message, shortened and safe to use as link text
+func excerpt(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	line = strings.NewReplacer("[", "(", "]", ")").Replace(line)
+	runes := []rune(line)
+	if len(runes) > excerptLength {
+		return strings.TrimSpace(string(runes[:excerptLength])) + "…"
+	}
+	return line
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package duplicates
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	mmapi.Client
+	threads map[string]*model.PostList
+	posts   []*model.Post
+	errors  []string
+}
+
+func (f *fakeClient) GetChannel(channelID string) (*model.Channel, error) {
+	return &model.Channel{Id: channelID, TeamId: "team1"}, nil
+}
+
+func (f *fakeClient) GetPostThread(postID string) (*model.PostList, error) {
+	return f.threads[postID], nil
+}
+
+func (f *fakeClient) GetConfig() *model.Config {
+	return &model.Config{ServiceSettings: model.ServiceSettings{SiteURL: model.NewPointer("https://mm.example.com")}}
+}
+
+func (f *fakeClient) CreatePost(post *model.Post) error {
+	f.posts = append(f.posts, post)
+	return nil
+}
+
+func (f *fakeClient) LogError(msg string, _ ...interface{}) {
+	f.errors = append(f.errors, msg)
+}
+
+func (f *fakeClient) LogWarn(string, ...interface{}) {}
+
+type fakeBots struct {
+	bot *bots.Bot
+}
+
+func (f *fakeBots) GetBotByUsernameOrFirst(string) *bots.Bot {
+	return f.bot
+}
+
+func (f *fakeBots) IsAnyBot(userID string) bool {
+	return userID == f.bot.GetMMBot().UserId
+}
+
+func (f *fakeBots) CheckUsageRestrictionsForChannel(*bots.Bot, *model.Channel) error {
+	return nil
+}
+
+type fakeSearch struct {
+	results []embeddings.SearchResult
+	opts    *embeddings.SearchOptions
+}
+
+func (f *fakeSearch) Enabled() bool {
+	return true
+}
+
+func (f *fakeSearch) Search(_ context.Context, _ string, opts embeddings.SearchOptions) ([]embeddings.SearchResult, error) {
+	f.opts = &opts
+	return f.results, nil
+}
+
+type fakeConfig struct {
+	channels []config.DuplicateQuestionsChannelConfig
+}
+
+func (f *fakeConfig) GetDuplicateQuestionsChannels() []config.DuplicateQuestionsChannelConfig {
+	return f.channels
+}
+
+func (f *fakeConfig) GetDefaultBotName() string {
+	return "ai"
+}
+
+func thread(posts ...*model.Post) *model.PostList {
+	list := model.NewPostList()
+	for _, post := range posts {
+		list.AddPost(post)
+		list.AddOrder(post.Id)
+	}
+	return list
+}
+
+func result(postID string, score float32) embeddings.SearchResult {
+	return embeddings.SearchResult{Document: embeddings.PostDocument{PostID: postID, ChannelID: "channel1"}, Score: score}
+}
+
+func TestMessageHasBeenPosted(t *testing.T) {
+	answered := &model.Post{Id: "answered", ChannelId: "channel1", UserId: "user2", Message: "How do I reset my [VPN] password?\nI tried the portal."}
+	answer := &model.Post{Id: "answer", RootId: "answered", ChannelId: "channel1", UserId: "user3", Message: "Use the self service page."}
+	unanswered := &model.Post{Id: "unanswered", ChannelId: "channel1", UserId: "user2", Message: "Where do I reset the VPN password?"}
+	followUp := &model.Post{Id: "followup", RootId: "unanswered", ChannelId: "channel1", UserId: "user2", Message: "Anyone?"}
+	linked := &model.Post{Id: "linked", RootId: "unanswered", ChannelId: "channel1", UserId: "bot1", Message: "Similar questions", Props: model.StringInterface{DuplicatesProp: "[]"}}
+	threads := map[string]*model.PostList{
+		"answered":   thread(answered, answer),
+		"answer":     thread(answered, answer),
+		"unanswered": thread(unanswered, followUp, linked),
+	}
+	question := &model.Post{Id: "question", ChannelId: "channel1", UserId: "user1", Message: "How can I reset my VPN password?", CreateAt: 1000}
+
+	tests := []struct {
+		name            string
+		channelConfig   config.DuplicateQuestionsChannelConfig
+		post            *model.Post
+		results         []embeddings.SearchResult
+		expectedMessage string
+		expectedOpts    *embeddings.SearchOptions
+	}{
+		{
+			name:            "links answered questions",
+			channelConfig:   config.DuplicateQuestionsChannelConfig{ChannelID: "channel1"},
+			post:            question,
+			results:         []embeddings.SearchResult{result("question", 1), result("unanswered", 0.95), result("answer", 0.9), result("answered", 0.85)},
+			expectedMessage: "This looks similar to questions answered before:\n- [How do I reset my (VPN) password?](https://mm.example.com/_redirect/pl/answered) (90% similar)",
+			expectedOpts:    &embeddings.SearchOptions{Limit: 12, MinScore: DefaultMinScore, ChannelID: "channel1", UserID: "user1", CreatedBefore: 1000},
+		},
+		{
+			name:          "searches the team",
+			channelConfig: config.DuplicateQuestionsChannelConfig{ChannelID: "channel1", SearchTeam: true, MinScore: 0.9, MaxResults: 1},
+			post:          question,
+			expectedOpts:  &embeddings.SearchOptions{Limit: 4, MinScore: 0.9, TeamID: "team1", UserID: "user1", CreatedBefore: 1000, PublicChannelsOnly: true},
+		},
+		{
+			name:          "no answered questions",
+			channelConfig: config.DuplicateQuestionsChannelConfig{ChannelID: "channel1"},
+			post:          question,
+			results:       []embeddings.SearchResult{result("unanswered", 0.95)},
+			expectedOpts:  &embeddings.SearchOptions{Limit: 12, MinScore: DefaultMinScore, ChannelID: "channel1", UserID: "user1", CreatedBefore: 1000},
+		},
+		{
+			name:          "not a question",
+			channelConfig: config.DuplicateQuestionsChannelConfig{ChannelID: "channel1"},
+			post:          &model.Post{Id: "post1", ChannelId: "channel1", UserId: "user1", Message: "The VPN is down again"},
+		},
+		{
+			name:          "reply",
+			channelConfig: config.DuplicateQuestionsChannelConfig{ChannelID: "channel1"},
+			post:          &model.Post{Id: "post1", RootId: "answered", ChannelId: "channel1", UserId: "user1", Message: "What about the VPN password?"},
+		},
+		{
+			name:          "other channel",
+			channelConfig: config.DuplicateQuestionsChannelConfig{ChannelID: "channel2"},
+			post:          question,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &fakeClient{threads: threads}
+			search := &fakeSearch{results: test.results}
+			bot := bots.NewBot(llm.BotConfig{Name: "ai"}, llm.ServiceConfig{}, &model.Bot{UserId: "bot1"}, nil)
+			service := New(client, &fakeBots{bot: bot}, search, i18n.Init(), &fakeConfig{channels: []config.DuplicateQuestionsChannelConfig{test.channelConfig}})
+
+			service.MessageHasBeenPosted(context.Background(), test.post)
+
+			require.Empty(t, client.errors)
+			require.Equal(t, test.expectedOpts, search.opts)
+			if test.expectedMessage == "" {
+				require.Empty(t, client.posts)
+				return
+			}
+			require.Len(t, client.posts, 1)
+			reply := client.posts[0]
+			require.Equal(t, "bot1", reply.UserId)
+			require.Equal(t, test.post.Id, reply.RootId)
+			require.Equal(t, test.expectedMessage, reply.Message)
+
+			var matches []Match
+			require.NoError(t, json.Unmarshal([]byte(reply.GetProp(DuplicatesProp).(string)), &matches))
+			require.Len(t, matches, 1)
+			require.Equal(t, "answered", matches[0].PostID)
+		})
+	}
+}
@@ -27,7 +27,9 @@ const (
 	SearchTypeComposite = "composite"
 )
 
-// PostDocument represents a Mattermost post with its metadata
+// PostDocument represents a Mattermost post with its metadata. Its team and channel are the
+// access metadata of its chunks: who may read them is evaluated at query time from the current
+// membership and type of the channel, never stored with the chunk.
 type PostDocument struct {
 	PostID    string // ID of the Mattermost post
 	CreateAt  int64  // Creation timestamp of the referenced post, not when this was indexed
@@ -52,9 +54,12 @@ type SearchOptions struct {
 	MinScore      float32
 	TeamID        string
 	ChannelID     string
-	UserID        string // User ID for permission checks
+	UserID        string // User ID for permission checks, required
 	CreatedAfter  int64
 	CreatedBefore int64
+	// PublicChannelsOnly only returns posts of public channels, for results shown to other people
+	// than UserID, such as in a reply posted in a channel
+	PublicChannelsOnly bool
 }
 
 // EmbeddingSearch defines the high-level interface for storing and searching using embeddings
@@ -77,7 +82,10 @@ type VectorStore interface {
 	// Store stores documents and their embeddings
 	Store(ctx context.Context, docs []PostDocument, embeddings [][]float32) error
 
-	// Search performs a similarity search using the provided embedding
+	// Search performs a similarity search using the provided embedding. Only the chunks of the
+	// posts opts.UserID may currently read are returned: the posts of the channels they are a
+	// member of, neither deleted nor archived, and of public channels only when
+	// opts.PublicChannelsOnly is set. It fails without opts.UserID.
 	Search(ctx context.Context, embedding []float32, opts SearchOptions) ([]SearchResult, error)
 
 	// Delete removes documents from the vector store
@@ -113,4 +121,8 @@ type EmbeddingSearchConfig struct {
 	Parameters        json.RawMessage  `json:"parameters"`
 	Dimensions        int              `json:"dimensions"`
 	ChunkingOptions   chunking.Options `json:"chunkingOptions"`
+
+	// RequestsPerMinute limits the requests sent to the embedding provider, serving search
+	// queries before indexing. 0 means unlimited.
+	RequestsPerMinute int `json:"requestsPerMinute"`
 }
@@ -0,0 +1,88 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.enterprise for license information.
+
+package enterprise
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+// Feature is a capability of the plugin that needs a license.
+type Feature string
+
+const (
+	FeatureMultiLLM        Feature = "multi_llm"
+	FeatureThreadAnalysis  Feature = "thread_analysis"
+	FeatureChannelAnalysis Feature = "channel_analysis"
+	FeatureToolCalls       Feature = "tool_calls"
+	FeatureSemanticSearch  Feature = "semantic_search"
+	FeatureIncidentCopilot Feature = "incident_copilot"
+	FeatureFAQBuilder      Feature = "faq_builder"
+)
+
+// SKU is a license tier, named after the SkuShortName of Mattermost licenses.
+type SKU string
+
+const (
+	SKUEnterprise SKU = "enterprise"
+)
+
+// featureSKUs is the minimum license tier of each feature.
+var featureSKUs = map[Feature]SKU{
+	FeatureMultiLLM:        SKUEnterprise,
+	FeatureThreadAnalysis:  SKUEnterprise,
+	FeatureChannelAnalysis: SKUEnterprise,
+	FeatureToolCalls:       SKUEnterprise,
+	FeatureSemanticSearch:  SKUEnterprise,
+	FeatureIncidentCopilot: SKUEnterprise,
+	FeatureFAQBuilder:      SKUEnterprise,
+}
+
+// RequiredSKU returns the minimum license tier of the feature. Unknown features require an
+// Enterprise license.
+func RequiredSKU(feature Feature) SKU {
+	if sku, ok := featureSKUs[feature]; ok {
+		return sku
+	}
+	return SKUEnterprise
+}
+
+// FeatureChecker checks the license of the server includes a feature, see LicenseChecker.
+type FeatureChecker interface {
+	CheckFeature(feature Feature) error
+}
+
+// FeatureNotLicensedError is returned when the license of the server does not include a feature.
+type FeatureNotLicensedError struct {
+	Feature     Feature
+	RequiredSKU SKU
+}
+
+func (e *FeatureNotLicensedError) Error() string {
+	return fmt.Sprintf("%s requires a Mattermost %s license", e.Feature, e.RequiredSKU)
+}
+
+func (e *FeatureNotLicensedError) Is(target error) bool {
+	return target == ErrNotLicensed
+}
+
+// IsFeatureLicensed returns true when the server either has a license including the feature or is configured for development.
+func (e *LicenseChecker) IsFeatureLicensed(feature Feature) bool {
+	config := e.pluginAPIClient.Configuration.GetConfig()
+	license := e.pluginAPIClient.System.GetLicense()
+
+	return pluginapi.IsE20LicensedOrDevelopment(config, license)
+}
+
+// CheckFeature returns a *FeatureNotLicensedError, matching ErrNotLicensed, when the feature is not licensed.
+func (e *LicenseChecker) CheckFeature(feature Feature) error {
+	if !e.IsFeatureLicensed(feature) {
+		return &FeatureNotLicensedError{
+			Feature:     feature,
+			RequiredSKU: RequiredSKU(feature),
+		}
+	}
+	return nil
+}
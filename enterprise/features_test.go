@@ -0,0 +1,80 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.enterprise for license information.
+
+package enterprise
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckFeature(t *testing.T) {
+	tests := []struct {
+		name     string
+		license  *model.License
+		feature  Feature
+		licensed bool
+	}{
+		{
+			name:     "no license",
+			feature:  FeatureThreadAnalysis,
+			licensed: false,
+		},
+		{
+			name:     "professional license",
+			license:  model.NewTestLicenseSKU(model.LicenseShortSkuProfessional),
+			feature:  FeatureThreadAnalysis,
+			licensed: false,
+		},
+		{
+			name:     "enterprise license",
+			license:  model.NewTestLicenseSKU(model.LicenseShortSkuEnterprise),
+			feature:  FeatureThreadAnalysis,
+			licensed: true,
+		},
+		{
+			name:     "unknown feature",
+			license:  model.NewTestLicenseSKU(model.LicenseShortSkuProfessional),
+			feature:  Feature("unknown"),
+			licensed: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAPI := &plugintest.API{}
+			defer mockAPI.AssertExpectations(t)
+			mockAPI.On("GetConfig").Return(&model.Config{})
+			mockAPI.On("GetLicense").Return(tc.license)
+
+			checker := NewLicenseChecker(pluginapi.NewClient(mockAPI, nil))
+			err := checker.CheckFeature(tc.feature)
+			if tc.licensed {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrNotLicensed)
+			var notLicensed *FeatureNotLicensedError
+			require.ErrorAs(t, err, &notLicensed)
+			require.Equal(t, SKUEnterprise, notLicensed.RequiredSKU)
+		})
+	}
+}
+
+func TestEveryFeatureHasSKU(t *testing.T) {
+	for _, feature := range []Feature{
+		FeatureMultiLLM,
+		FeatureThreadAnalysis,
+		FeatureChannelAnalysis,
+		FeatureToolCalls,
+		FeatureSemanticSearch,
+		FeatureIncidentCopilot,
+		FeatureFAQBuilder,
+	} {
+		require.Contains(t, featureSKUs, feature)
+	}
+}
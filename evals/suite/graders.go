@@ -0,0 +1,101 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package suite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+type RubricResult struct {
+	Reasoning string
+	Score     float64
+	Pass      bool
+}
+
+const llmRubricSystem = `You are grading output according to the specificed rebric. If the statemnt in the rubric is true, then the output passes the test. You must respond with a JSON object with this structure: {reasoning: string, score: number, pass: boolean}
+Examples:
+<Output>The steamclock is broken</Output>
+<Rubric>The content contains the state of the clock</Rubric>
+{"reasoning": "The output says the clock is broken", "score": 1.0, "pass": true}
+
+<Output>I am sorry I can not find the thread you referenced</Output>
+<Rubric>Contains a reference to the mentos project</Rubric>
+{"reasoning": "The output contains a failure message instead of a reference to the mentos project", "score": 0.0, "pass": false}`
+
+// GradeRubric asks the grader model whether output satisfies rubric.
+func GradeRubric(ctx context.Context, grader llm.LanguageModel, rubric, output string) (*RubricResult, error) {
+	req := llm.CompletionRequest{
+		Posts: []llm.Post{
+			{
+				Role:    llm.PostRoleSystem,
+				Message: llmRubricSystem,
+			},
+			{
+				Role:    llm.PostRoleUser,
+				Message: fmt.Sprintf("<Output>%s</Output>\n<Rubric>%s</Rubric>", output, rubric),
+			},
+		},
+		Context: llm.NewContext(),
+	}
+
+	llmResult, gradeErr := grader.ChatCompletionNoStream(ctx, req, llm.WithMaxGeneratedTokens(1000), llm.WithJSONOutput[RubricResult]())
+	if gradeErr != nil {
+		return nil, fmt.Errorf("failed to grade with llm: %w", gradeErr)
+	}
+
+	rubricResult := RubricResult{}
+	unmarshalErr := json.Unmarshal([]byte(llmResult), &rubricResult)
+	if unmarshalErr != nil {
+		return nil, fmt.Errorf("failed to unmarshal llm result: %w", unmarshalErr)
+	}
+
+	return &rubricResult, nil
+}
+
+// ExactMatch scores 1 when output equals expected, ignoring surrounding whitespace.
+func ExactMatch(expected, output string) (float64, bool) {
+	if strings.TrimSpace(expected) == strings.TrimSpace(output) {
+		return 1, true
+	}
+	return 0, false
+}
+
+// EmbeddingSimilarity returns the cosine similarity between the embeddings of expected and output.
+func EmbeddingSimilarity(ctx context.Context, provider embeddings.EmbeddingProvider, expected, output string) (float64, error) {
+	vectors, err := provider.BatchCreateEmbeddings(ctx, []string{expected, output})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+	if len(vectors) != 2 {
+		return 0, fmt.Errorf("expected 2 embeddings, got %d", len(vectors))
+	}
+
+	return cosineSimilarity(vectors[0], vectors[1])
+}
+
+func cosineSimilarity(a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("embedding dimensions differ: %d and %d", len(a), len(b))
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0, errors.New("cannot compare empty embeddings")
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package suite runs golden prompt suites against language models and scores the outputs.
+// Unlike the parent evals package it has no test dependencies so it can be used by the plugin server.
+package suite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+// Grader selects how the output of a case is scored.
+type Grader string
+
+const (
+	// GraderExactMatch passes when the output equals the expected output, ignoring surrounding whitespace.
+	GraderExactMatch Grader = "exact_match"
+	// GraderLLMJudge asks the grader model whether the output satisfies the rubric.
+	GraderLLMJudge Grader = "llm_judge"
+	// GraderEmbeddingSimilarity compares the cosine similarity of the output and expected output embeddings.
+	GraderEmbeddingSimilarity Grader = "embedding_similarity"
+)
+
+const (
+	// DefaultSimilarityThreshold is the minimum similarity for embedding similarity cases that don't set one.
+	DefaultSimilarityThreshold = 0.8
+	// DefaultRegressionTolerance is how much a score may drop before it is reported as a regression.
+	DefaultRegressionTolerance = 0.1
+	// MaxCases limits the size of a suite so a single run stays bounded.
+	MaxCases = 100
+)
+
+// ErrGraderUnavailable is reported for cases whose grader has no model or embedding provider configured.
+var ErrGraderUnavailable = errors.New("grader not available")
+
+// Case is a single recorded prompt and how to score the response to it.
+type Case struct {
+	Name      string  `json:"name"`
+	System    string  `json:"system"`
+	Prompt    string  `json:"prompt"`
+	Grader    Grader  `json:"grader"`
+	Expected  string  `json:"expected"`
+	Rubric    string  `json:"rubric"`
+	Threshold float64 `json:"threshold"`
+}
+
+// Suite is a named set of golden cases.
+type Suite struct {
+	Name  string `json:"name"`
+	Cases []Case `json:"cases"`
+}
+
+// IsValid checks that every case can be run and graded.
+func (s Suite) IsValid() error {
+	if len(s.Cases) == 0 {
+		return errors.New("suite has no cases")
+	}
+	if len(s.Cases) > MaxCases {
+		return fmt.Errorf("suite has %d cases, the maximum is %d", len(s.Cases), MaxCases)
+	}
+
+	names := make(map[string]bool, len(s.Cases))
+	for i, c := range s.Cases {
+		if c.Name == "" {
+			return fmt.Errorf("case %d has no name", i)
+		}
+		if names[c.Name] {
+			return fmt.Errorf("duplicate case name %q", c.Name)
+		}
+		names[c.Name] = true
+
+		if strings.TrimSpace(c.Prompt) == "" {
+			return fmt.Errorf("case %q has no prompt", c.Name)
+		}
+
+		switch c.Grader {
+		case GraderExactMatch, GraderEmbeddingSimilarity:
+			if c.Expected == "" {
+				return fmt.Errorf("case %q needs an expected output for grader %s", c.Name, c.Grader)
+			}
+		case GraderLLMJudge:
+			if c.Rubric == "" {
+				return fmt.Errorf("case %q needs a rubric for grader %s", c.Name, c.Grader)
+			}
+		default:
+			return fmt.Errorf("case %q has unknown grader %q", c.Name, c.Grader)
+		}
+
+		if c.Threshold < 0 || c.Threshold > 1 {
+			return fmt.Errorf("case %q threshold must be between 0 and 1", c.Name)
+		}
+	}
+
+	return nil
+}
+
+// CaseResult is the scored output of a single case.
+type CaseResult struct {
+	Case      string  `json:"case"`
+	Output    string  `json:"output"`
+	Score     float64 `json:"score"`
+	Pass      bool    `json:"pass"`
+	Reasoning string  `json:"reasoning,omitempty"`
+	Error     string  `json:"error,omitempty"`
+	LatencyMS int64   `json:"latency_ms"`
+}
+
+// Report is the result of running a suite against one model.
+type Report struct {
+	Suite        string       `json:"suite"`
+	Model        string       `json:"model"`
+	Results      []CaseResult `json:"results"`
+	Passed       int          `json:"passed"`
+	Failed       int          `json:"failed"`
+	AverageScore float64      `json:"average_score"`
+}
+
+// Runner runs suites. Grader is required for LLM judge cases and Embeddings for
+// embedding similarity cases; cases whose grader is unavailable fail with an error.
+type Runner struct {
+	Grader     llm.LanguageModel
+	Embeddings embeddings.EmbeddingProvider
+}
+
+// Run executes every case of the suite against model, identified in the report by modelName.
+// Cases are run sequentially and the run stops early if ctx is canceled.
+func (r *Runner) Run(ctx context.Context, suite Suite, model llm.LanguageModel, modelName string) (*Report, error) {
+	report := &Report{
+		Suite:   suite.Name,
+		Model:   modelName,
+		Results: make([]CaseResult, 0, len(suite.Cases)),
+	}
+
+	var totalScore float64
+	for _, c := range suite.Cases {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result := r.runCase(ctx, c, model)
+		if result.Pass {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		totalScore += result.Score
+		report.Results = append(report.Results, result)
+	}
+
+	if len(report.Results) > 0 {
+		report.AverageScore = totalScore / float64(len(report.Results))
+	}
+
+	return report, nil
+}
+
+func (r *Runner) runCase(ctx context.Context, c Case, model llm.LanguageModel) CaseResult {
+	result := CaseResult{Case: c.Name}
+
+	request := llm.CompletionRequest{
+		Context: llm.NewContext(),
+	}
+	if c.System != "" {
+		request.Posts = append(request.Posts, llm.Post{Role: llm.PostRoleSystem, Message: c.System})
+	}
+	request.Posts = append(request.Posts, llm.Post{Role: llm.PostRoleUser, Message: c.Prompt})
+
+	start := time.Now()
+	output, err := model.ChatCompletionNoStream(ctx, request)
+	result.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = fmt.Sprintf("completion failed: %v", err)
+		return result
+	}
+	result.Output = output
+
+	switch c.Grader {
+	case GraderExactMatch:
+		result.Score, result.Pass = ExactMatch(c.Expected, output)
+	case GraderLLMJudge:
+		if r.Grader == nil {
+			result.Error = fmt.Sprintf("%v: no grader model configured", ErrGraderUnavailable)
+			return result
+		}
+		rubricResult, gradeErr := GradeRubric(ctx, r.Grader, c.Rubric, output)
+		if gradeErr != nil {
+			result.Error = gradeErr.Error()
+			return result
+		}
+		result.Score = rubricResult.Score
+		result.Pass = rubricResult.Pass
+		result.Reasoning = rubricResult.Reasoning
+	case GraderEmbeddingSimilarity:
+		if r.Embeddings == nil {
+			result.Error = fmt.Sprintf("%v: no embedding provider configured", ErrGraderUnavailable)
+			return result
+		}
+		threshold := c.Threshold
+		if threshold == 0 {
+			threshold = DefaultSimilarityThreshold
+		}
+		similarity, simErr := EmbeddingSimilarity(ctx, r.Embeddings, c.Expected, output)
+		if simErr != nil {
+			result.Error = simErr.Error()
+			return result
+		}
+		result.Score = similarity
+		result.Pass = similarity >= threshold
+	}
+
+	return result
+}
+
+// Regression is a case that scores worse than in the baseline report.
+type Regression struct {
+	Model         string  `json:"model"`
+	Case          string  `json:"case"`
+	BaselineScore float64 `json:"baseline_score"`
+	Score         float64 `json:"score"`
+	BaselinePass  bool    `json:"baseline_pass"`
+	Pass          bool    `json:"pass"`
+}
+
+// FindRegressions compares the current reports with baseline reports of the same models.
+// A case regresses when it passed before and now fails, or its score dropped by more than tolerance.
+// Cases and models missing from the baseline are ignored.
+func FindRegressions(baseline, current []Report, tolerance float64) []Regression {
+	baselineScores := make(map[string]map[string]CaseResult, len(baseline))
+	for _, report := range baseline {
+		results := make(map[string]CaseResult, len(report.Results))
+		for _, result := range report.Results {
+			results[result.Case] = result
+		}
+		baselineScores[report.Model] = results
+	}
+
+	regressions := []Regression{}
+	for _, report := range current {
+		previous, ok := baselineScores[report.Model]
+		if !ok {
+			continue
+		}
+		for _, result := range report.Results {
+			before, ok := previous[result.Case]
+			if !ok {
+				continue
+			}
+			if (before.Pass && !result.Pass) || before.Score-result.Score > tolerance {
+				regressions = append(regressions, Regression{
+					Model:         report.Model,
+					Case:          result.Case,
+					BaselineScore: before.Score,
+					Score:         result.Score,
+					BaselinePass:  before.Pass,
+					Pass:          result.Pass,
+				})
+			}
+		}
+	}
+
+	return regressions
+}
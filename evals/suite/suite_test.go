@@ -0,0 +1,230 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package suite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticModel answers every request with the same output.
+type staticModel struct {
+	output string
+}
+
+func (m *staticModel) ChatCompletion(context.Context, llm.CompletionRequest, ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+	return llm.NewStreamFromString(m.output), nil
+}
+
+func (m *staticModel) ChatCompletionNoStream(context.Context, llm.CompletionRequest, ...llm.LanguageModelOption) (string, error) {
+	return m.output, nil
+}
+
+func (m *staticModel) CountTokens(text string) int {
+	return len(text)
+}
+
+func (m *staticModel) InputTokenLimit() int {
+	return 100000
+}
+
+func TestSuiteIsValid(t *testing.T) {
+	tests := []struct {
+		name      string
+		suite     Suite
+		expectErr bool
+	}{
+		{
+			name: "valid",
+			suite: Suite{Cases: []Case{
+				{Name: "exact", Prompt: "2+2?", Grader: GraderExactMatch, Expected: "4"},
+				{Name: "judge", Prompt: "Say hi", Grader: GraderLLMJudge, Rubric: "The output is a greeting"},
+				{Name: "similar", Prompt: "Describe the sky", Grader: GraderEmbeddingSimilarity, Expected: "The sky is blue", Threshold: 0.7},
+			}},
+		},
+		{
+			name:      "no cases",
+			suite:     Suite{},
+			expectErr: true,
+		},
+		{
+			name: "duplicate names",
+			suite: Suite{Cases: []Case{
+				{Name: "a", Prompt: "p", Grader: GraderExactMatch, Expected: "x"},
+				{Name: "a", Prompt: "p", Grader: GraderExactMatch, Expected: "x"},
+			}},
+			expectErr: true,
+		},
+		{
+			name:      "judge without rubric",
+			suite:     Suite{Cases: []Case{{Name: "a", Prompt: "p", Grader: GraderLLMJudge}}},
+			expectErr: true,
+		},
+		{
+			name:      "unknown grader",
+			suite:     Suite{Cases: []Case{{Name: "a", Prompt: "p", Grader: "regex", Expected: "x"}}},
+			expectErr: true,
+		},
+		{
+			name:      "threshold out of range",
+			suite:     Suite{Cases: []Case{{Name: "a", Prompt: "p", Grader: GraderEmbeddingSimilarity, Expected: "x", Threshold: 2}}},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.suite.IsValid()
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRunnerRun(t *testing.T) {
+	tests := []struct {
+		name          string
+		runner        *Runner
+		output        string
+		testCase      Case
+		expectedPass  bool
+		expectedScore float64
+		expectError   bool
+	}{
+		{
+			name:          "exact match ignores surrounding whitespace",
+			runner:        &Runner{},
+			output:        " 4\n",
+			testCase:      Case{Name: "c", Prompt: "2+2?", Grader: GraderExactMatch, Expected: "4"},
+			expectedPass:  true,
+			expectedScore: 1,
+		},
+		{
+			name:     "exact match mismatch",
+			runner:   &Runner{},
+			output:   "four",
+			testCase: Case{Name: "c", Prompt: "2+2?", Grader: GraderExactMatch, Expected: "4"},
+		},
+		{
+			name:          "llm judge",
+			runner:        &Runner{Grader: &staticModel{output: `{"reasoning": "it greets", "score": 0.9, "pass": true}`}},
+			output:        "Hello!",
+			testCase:      Case{Name: "c", Prompt: "Say hi", Grader: GraderLLMJudge, Rubric: "The output is a greeting"},
+			expectedPass:  true,
+			expectedScore: 0.9,
+		},
+		{
+			name:        "llm judge without grader",
+			runner:      &Runner{},
+			output:      "Hello!",
+			testCase:    Case{Name: "c", Prompt: "Say hi", Grader: GraderLLMJudge, Rubric: "The output is a greeting"},
+			expectError: true,
+		},
+		{
+			name:          "identical embeddings",
+			runner:        &Runner{Embeddings: embeddings.NewMockEmbeddingProvider(16)},
+			output:        "The sky is blue",
+			testCase:      Case{Name: "c", Prompt: "Describe the sky", Grader: GraderEmbeddingSimilarity, Expected: "The sky is blue"},
+			expectedPass:  true,
+			expectedScore: 1,
+		},
+		{
+			name:        "similarity without embeddings provider",
+			runner:      &Runner{},
+			output:      "The sky is blue",
+			testCase:    Case{Name: "c", Prompt: "Describe the sky", Grader: GraderEmbeddingSimilarity, Expected: "The sky is blue"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			report, err := tc.runner.Run(context.Background(), Suite{Name: "suite", Cases: []Case{tc.testCase}}, &staticModel{output: tc.output}, "model")
+			require.NoError(t, err)
+			require.Len(t, report.Results, 1)
+
+			result := report.Results[0]
+			assert.Equal(t, tc.expectedPass, result.Pass)
+			assert.InDelta(t, tc.expectedScore, result.Score, 0.0001)
+			assert.Equal(t, tc.expectError, result.Error != "")
+			assert.Equal(t, tc.output, result.Output)
+			if tc.expectedPass {
+				assert.Equal(t, 1, report.Passed)
+			} else {
+				assert.Equal(t, 1, report.Failed)
+			}
+		})
+	}
+}
+
+func TestRunnerRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	runner := &Runner{}
+	_, err := runner.Run(ctx, Suite{Cases: []Case{{Name: "c", Prompt: "p", Grader: GraderExactMatch, Expected: "x"}}}, &staticModel{}, "model")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFindRegressions(t *testing.T) {
+	baseline := []Report{{
+		Model: "gpt",
+		Results: []CaseResult{
+			{Case: "stable", Score: 0.9, Pass: true},
+			{Case: "now_failing", Score: 0.7, Pass: true},
+			{Case: "score_drop", Score: 0.9, Pass: true},
+			{Case: "small_drop", Score: 0.9, Pass: true},
+			{Case: "already_failing", Score: 0.2},
+		},
+	}}
+
+	tests := []struct {
+		name     string
+		current  []Report
+		expected []string
+	}{
+		{
+			name: "regressions detected",
+			current: []Report{{
+				Model: "gpt",
+				Results: []CaseResult{
+					{Case: "stable", Score: 0.9, Pass: true},
+					{Case: "now_failing", Score: 0.65},
+					{Case: "score_drop", Score: 0.6, Pass: true},
+					{Case: "small_drop", Score: 0.85, Pass: true},
+					{Case: "already_failing", Score: 0.1},
+					{Case: "new_case", Score: 0},
+				},
+			}},
+			expected: []string{"now_failing", "score_drop"},
+		},
+		{
+			name: "models missing from the baseline are ignored",
+			current: []Report{{
+				Model:   "claude",
+				Results: []CaseResult{{Case: "stable", Score: 0}},
+			}},
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			regressions := FindRegressions(baseline, tc.current, DefaultRegressionTolerance)
+			cases := make([]string, 0, len(regressions))
+			for _, regression := range regressions {
+				cases = append(cases, regression.Case)
+			}
+			assert.Equal(t, tc.expected, cases)
+		})
+	}
+}
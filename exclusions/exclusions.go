@@ -0,0 +1,188 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package exclusions implements the admin managed no-AI zones, channels and teams whose
+// content must never be sent to LLM providers, and the policies of shared channels and
+// channels with guests.
+package exclusions
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+var (
+	// ErrChannelExcluded is returned when AI features are used in an excluded channel
+	ErrChannelExcluded = errors.New("AI features are disabled in this channel by a system admin")
+	// ErrChannelPolicyDisabled is returned when AI features are used in a shared channel or a
+	// channel with guests whose policy disables them
+	ErrChannelPolicyDisabled = errors.New("AI features are disabled in shared channels or channels with guests by a system admin")
+	// ErrConfirmationRequired is returned when the user must confirm before the content of a
+	// shared channel or a channel with guests is sent to LLM providers
+	ErrConfirmationRequired = errors.New("this channel is shared or has guests, confirm its content can be sent to AI services")
+)
+
+// Policy is how AI features behave in shared channels or channels with guests
+type Policy string
+
+const (
+	// PolicyAllow leaves AI features unchanged
+	PolicyAllow Policy = ""
+	// PolicyDisable disables AI features as in an excluded channel
+	PolicyDisable Policy = "disable"
+	// PolicyExcludeContent leaves out the posts of guests in channels with guests, and of users
+	// from other servers in shared channels
+	PolicyExcludeContent Policy = "exclude_content"
+	// PolicyConfirm requires users to confirm before the content of the channel is sent to LLM providers
+	PolicyConfirm Policy = "confirm"
+)
+
+// Config lists the channels and teams excluded from AI processing
+type Config struct {
+	// ChannelIDs are the channels whose content is never sent to LLM providers
+	ChannelIDs []string `json:"channelIDs"`
+
+	// TeamIDs are the teams whose channels are never sent to LLM providers
+	TeamIDs []string `json:"teamIDs"`
+
+	// SharedChannels is the policy of channels shared with other Mattermost servers
+	SharedChannels Policy `json:"sharedChannels"`
+
+	// GuestChannels is the policy of channels with guest members
+	GuestChannels Policy `json:"guestChannels"`
+}
+
+// ChannelPolicy is what the shared and guest channel policies require in a channel
+type ChannelPolicy struct {
+	Disabled            bool
+	RequireConfirmation bool
+	ExcludeGuests       bool
+	ExcludeRemoteUsers  bool
+}
+
+// ExcludesAuthors returns whether the posts of some users must be left out
+func (p ChannelPolicy) ExcludesAuthors() bool {
+	return p.ExcludeGuests || p.ExcludeRemoteUsers
+}
+
+// ExcludesAuthor returns whether the posts of the user must be left out
+func (p ChannelPolicy) ExcludesAuthor(user *model.User) bool {
+	return (p.ExcludeGuests && user.IsGuest()) || (p.ExcludeRemoteUsers && user.IsRemote())
+}
+
+// ExcludesPost returns whether the post by author must be left out. Posts from other servers
+// and posts whose author is unknown are left out when some authors are excluded.
+func (p ChannelPolicy) ExcludesPost(post *model.Post, author *model.User) bool {
+	if !p.ExcludesAuthors() {
+		return false
+	}
+	if author == nil || (p.ExcludeRemoteUsers && post.GetRemoteID() != "") {
+		return true
+	}
+	return p.ExcludesAuthor(author)
+}
+
+// apply adds the requirements of a policy. Unknown policies disable AI features so a
+// misconfiguration never sends content.
+func (p *ChannelPolicy) apply(policy Policy, guests bool) {
+	switch policy {
+	case PolicyAllow:
+	case PolicyConfirm:
+		p.RequireConfirmation = true
+	case PolicyExcludeContent:
+		if guests {
+			p.ExcludeGuests = true
+		} else {
+			p.ExcludeRemoteUsers = true
+		}
+	default:
+		p.Disabled = true
+	}
+}
+
+// ChannelStatsService counts the guests of channels, see pluginapi.ChannelService
+type ChannelStatsService interface {
+	GetChannelStats(channelID string) (*model.ChannelStats, error)
+}
+
+// Checker checks channels against the configured exclusions
+type Checker struct {
+	getConfig    func() Config
+	channelStats ChannelStatsService
+}
+
+// New creates a checker reading the exclusions from the current configuration
+func New(getConfig func() Config) *Checker {
+	return &Checker{
+		getConfig: getConfig,
+	}
+}
+
+// SetChannelStatsService sets the service counting the guests of channels. Without it the
+// guest channels policy is not applied.
+func (c *Checker) SetChannelStatsService(channelStats ChannelStatsService) {
+	c.channelStats = channelStats
+}
+
+// IsChannelExcluded returns whether content of the channel, which belongs to the given team,
+// must not be sent to LLM providers. The team ID is empty for direct and group messages.
+func (c *Checker) IsChannelExcluded(channelID, teamID string) bool {
+	if c == nil {
+		return false
+	}
+
+	cfg := c.getConfig()
+	if channelID != "" && slices.Contains(cfg.ChannelIDs, channelID) {
+		return true
+	}
+
+	return teamID != "" && slices.Contains(cfg.TeamIDs, teamID)
+}
+
+// ChannelPolicy returns what the shared and guest channel policies require in the channel
+func (c *Checker) ChannelPolicy(channel *model.Channel) (ChannelPolicy, error) {
+	var policy ChannelPolicy
+	if c == nil || channel == nil {
+		return policy, nil
+	}
+
+	cfg := c.getConfig()
+	if channel.IsShared() {
+		policy.apply(cfg.SharedChannels, false)
+	}
+
+	if cfg.GuestChannels != PolicyAllow && c.channelStats != nil {
+		stats, err := c.channelStats.GetChannelStats(channel.Id)
+		if err != nil {
+			return policy, fmt.Errorf("failed to count the guests of the channel: %w", err)
+		}
+		if stats.GuestCount > 0 {
+			policy.apply(cfg.GuestChannels, true)
+		}
+	}
+
+	return policy, nil
+}
+
+// CheckChannel returns ErrChannelExcluded if the channel is excluded, and
+// ErrChannelPolicyDisabled if its policy disables AI features
+func (c *Checker) CheckChannel(channel *model.Channel) error {
+	if channel == nil {
+		return nil
+	}
+	if c.IsChannelExcluded(channel.Id, channel.TeamId) {
+		return ErrChannelExcluded
+	}
+
+	policy, err := c.ChannelPolicy(channel)
+	if err != nil {
+		return err
+	}
+	if policy.Disabled {
+		return ErrChannelPolicyDisabled
+	}
+	return nil
+}
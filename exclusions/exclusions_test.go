@@ -0,0 +1,155 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package exclusions
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker(t *testing.T) {
+	checker := New(func() Config {
+		return Config{
+			ChannelIDs: []string{"excludedchannel"},
+			TeamIDs:    []string{"excludedteam"},
+		}
+	})
+
+	tests := []struct {
+		name      string
+		channelID string
+		teamID    string
+		expected  bool
+	}{
+		{
+			name:      "excluded channel",
+			channelID: "excludedchannel",
+			teamID:    "team",
+			expected:  true,
+		},
+		{
+			name:      "channel of excluded team",
+			channelID: "channel",
+			teamID:    "excludedteam",
+			expected:  true,
+		},
+		{
+			name:      "channel not excluded",
+			channelID: "channel",
+			teamID:    "team",
+		},
+		{
+			name:      "direct message",
+			channelID: "dmchannel",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, checker.IsChannelExcluded(tc.channelID, tc.teamID))
+
+			err := checker.CheckChannel(&model.Channel{Id: tc.channelID, TeamId: tc.teamID})
+			if tc.expected {
+				assert.ErrorIs(t, err, ErrChannelExcluded)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("nil checker excludes nothing", func(t *testing.T) {
+		var nilChecker *Checker
+		assert.False(t, nilChecker.IsChannelExcluded("excludedchannel", "excludedteam"))
+		assert.NoError(t, nilChecker.CheckChannel(&model.Channel{Id: "excludedchannel"}))
+	})
+}
+
+type fakeChannelStats map[string]int64
+
+func (f fakeChannelStats) GetChannelStats(channelID string) (*model.ChannelStats, error) {
+	return &model.ChannelStats{ChannelId: channelID, GuestCount: f[channelID]}, nil
+}
+
+func TestChannelPolicy(t *testing.T) {
+	shared := &model.Channel{Id: "shared", Shared: model.NewPointer(true)}
+	withGuests := &model.Channel{Id: "guests"}
+	sharedWithGuests := &model.Channel{Id: "sharedguests", Shared: model.NewPointer(true)}
+	internal := &model.Channel{Id: "internal"}
+
+	tests := []struct {
+		name     string
+		config   Config
+		channel  *model.Channel
+		expected ChannelPolicy
+	}{
+		{
+			name:    "allowed by default",
+			channel: sharedWithGuests,
+		},
+		{
+			name:     "shared channel disabled",
+			config:   Config{SharedChannels: PolicyDisable},
+			channel:  shared,
+			expected: ChannelPolicy{Disabled: true},
+		},
+		{
+			name:     "guest channel excludes guests",
+			config:   Config{GuestChannels: PolicyExcludeContent},
+			channel:  withGuests,
+			expected: ChannelPolicy{ExcludeGuests: true},
+		},
+		{
+			name:     "shared channel with guests combines the policies",
+			config:   Config{SharedChannels: PolicyExcludeContent, GuestChannels: PolicyConfirm},
+			channel:  sharedWithGuests,
+			expected: ChannelPolicy{ExcludeRemoteUsers: true, RequireConfirmation: true},
+		},
+		{
+			name:    "channel neither shared nor with guests",
+			config:  Config{SharedChannels: PolicyDisable, GuestChannels: PolicyDisable},
+			channel: internal,
+		},
+		{
+			name:     "unknown policy disables",
+			config:   Config{SharedChannels: "something"},
+			channel:  shared,
+			expected: ChannelPolicy{Disabled: true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			checker := New(func() Config { return tc.config })
+			checker.SetChannelStatsService(fakeChannelStats{"guests": 2, "sharedguests": 1})
+
+			policy, err := checker.ChannelPolicy(tc.channel)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, policy)
+
+			err = checker.CheckChannel(tc.channel)
+			if tc.expected.Disabled {
+				assert.ErrorIs(t, err, ErrChannelPolicyDisabled)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("excludes the posts of guests and remote users", func(t *testing.T) {
+		guest := &model.User{Roles: model.SystemGuestRoleId}
+		remote := &model.User{Roles: model.SystemUserRoleId, RemoteId: model.NewPointer("remote1")}
+		member := &model.User{Roles: model.SystemUserRoleId}
+
+		policy := ChannelPolicy{ExcludeGuests: true}
+		assert.True(t, policy.ExcludesAuthor(guest))
+		assert.False(t, policy.ExcludesAuthor(remote))
+		assert.False(t, policy.ExcludesAuthor(member))
+
+		policy = ChannelPolicy{ExcludeRemoteUsers: true}
+		assert.False(t, policy.ExcludesAuthor(guest))
+		assert.True(t, policy.ExcludesAuthor(remote))
+	})
+}
@@ -0,0 +1,762 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package faq drafts the FAQ of a channel from its history. The answered questions of the channel
+// are grouped with the embeddings index so the most frequently asked ones come first, and an
+// agent writes one entry per group. FAQs are built as background jobs, posted in the channel or
+// exported as Markdown, and can be rebuilt on a schedule.
+//
+// FAQs are stored in the plugin KV store. Only one node of the cluster checks the schedules at a
+// time, so each refresh is started once.
+package faq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/duplicates"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	// JobType is the type of the jobs building FAQs
+	JobType = "faq"
+	// DefaultLookbackDays is how far back the channel history is read when the user doesn't choose
+	DefaultLookbackDays = 90
+	// MaxLookbackDays bounds how far back the channel history is read
+	MaxLookbackDays = 365
+	// MaxRefreshIntervalDays bounds the schedule of the FAQs, 0 meaning they aren't refreshed
+	MaxRefreshIntervalDays = 90
+	// DefaultMaxEntries is the number of questions of the FAQs when the configuration doesn't set one
+	DefaultMaxEntries = 15
+	// PollInterval is how often the FAQs are checked for due refreshes
+	PollInterval = time.Hour
+	// FAQProp is the post prop marking the FAQ posts
+	FAQProp = "channel_faq"
+
+	faqKeyPrefix = "channel_faq_v1_"
+	channelsKey  = "channel_faq_channels_v1"
+	// maxQuestions bounds the number of answered questions, the most recent ones, that are grouped
+	maxQuestions = 200
+	// maxThreadsPerEntry bounds the number of threads of each group given to the model
+	maxThreadsPerEntry = 5
+	// groupingMinScore is the similarity questions must reach to be grouped as the same question
+	groupingMinScore = 0.8
+	groupingLimit    = 10
+	// generationTimeout bounds the generation of the FAQ
+	generationTimeout = 10 * time.Minute
+)
+
+var (
+	// ErrNotEnabled is returned when the FAQ builder is disabled in the configuration.
+	ErrNotEnabled = errors.New("FAQ builder is not enabled")
+	// ErrNotFound is returned when no FAQ was built for the channel.
+	ErrNotFound = errors.New("channel has no FAQ")
+	// ErrNotGenerated is returned when exporting a FAQ whose first build didn't complete.
+	ErrNotGenerated = errors.New("FAQ has not been generated yet")
+	// ErrInvalidLookback is returned when the lookback is out of bounds.
+	ErrInvalidLookback = fmt.Errorf("lookback must be between 1 and %d days", MaxLookbackDays)
+	// ErrInvalidInterval is returned when the refresh interval is out of bounds.
+	ErrInvalidInterval = fmt.Errorf("refresh interval must be between 0 and %d days", MaxRefreshIntervalDays)
+	// ErrBuilding is returned when building a FAQ whose previous build is still running.
+	ErrBuilding = errors.New("FAQ is already being built")
+	// ErrNoQuestions is returned when the channel history has no answered question.
+	ErrNoQuestions = errors.New("no answered questions found in the channel history")
+)
+
+// FAQ is the persisted state of the FAQ of a channel.
+type FAQ struct {
+	ChannelID string `json:"channel_id"`
+	BotID     string `json:"bot_id"`
+	// UserID is the user who built the FAQ, whose permissions are used to search the channel
+	UserID string `json:"user_id"`
+	// LookbackDays is how far back the channel history is read
+	LookbackDays int `json:"lookback_days"`
+	// RefreshIntervalDays is how often the FAQ is rebuilt, never if 0
+	RefreshIntervalDays int `json:"refresh_interval_days"`
+	// Post is whether the FAQ is posted in the channel, rather than only exported
+	Post          bool   `json:"post"`
+	PostID        string `json:"post_id,omitempty"`
+	Markdown      string `json:"markdown"`
+	QuestionCount int    `json:"question_count"`
+	JobID         string `json:"job_id"`
+	CreateAt      int64  `json:"create_at"`
+	GeneratedAt   int64  `json:"generated_at"`
+}
+
+// Options are chosen by the user building the FAQ.
+type Options struct {
+	// LookbackDays is how far back the channel history is read, DefaultLookbackDays if 0
+	LookbackDays int `json:"lookback_days"`
+	// RefreshIntervalDays is how often the FAQ is rebuilt, never if 0
+	RefreshIntervalDays int `json:"refresh_interval_days"`
+	// Post is whether the FAQ is posted in the channel, rather than only exported
+	Post bool `json:"post"`
+}
+
+// BotSource returns the agents writing the FAQs.
+type BotSource interface {
+	GetBotByID(botID string) *bots.Bot
+	CheckUsageRestrictionsForChannel(bot *bots.Bot, channel *model.Channel) error
+	ChannelPolicy(channel *model.Channel) (exclusions.ChannelPolicy, error)
+}
+
+// ContextBuilder builds the LLM context of the generations.
+type ContextBuilder interface {
+	BuildLLMContextUserRequest(bot *bots.Bot, requestingUser *model.User, channel *model.Channel, opts ...llm.ContextOption) *llm.Context
+}
+
+// Searcher searches the embeddings index.
+type Searcher interface {
+	Enabled() bool
+	Search(ctx context.Context, query string, opts embeddings.SearchOptions) ([]embeddings.SearchResult, error)
+}
+
+// Service builds, exports and refreshes the FAQs.
+type Service struct {
+	client         mmapi.Client
+	bots           BotSource
+	contextBuilder ContextBuilder
+	search         Searcher
+	jobs           *jobs.Service
+	prompts        *llm.Prompts
+	i18n           *i18n.Bundle
+	mutexAPI       cluster.MutexPluginAPI
+	getConfig      func() config.FAQBuilderConfig
+	license        enterprise.FeatureChecker
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+// New creates a new FAQ service. Call Start to begin refreshing the FAQs on their schedule.
+func New(
+	client mmapi.Client,
+	bots BotSource,
+	contextBuilder ContextBuilder,
+	search Searcher,
+	jobsService *jobs.Service,
+	prompts *llm.Prompts,
+	i18nBundle *i18n.Bundle,
+	mutexAPI cluster.MutexPluginAPI,
+	getConfig func() config.FAQBuilderConfig,
+) *Service {
+	return &Service{
+		client:         client,
+		bots:           bots,
+		contextBuilder: contextBuilder,
+		search:         search,
+		jobs:           jobsService,
+		prompts:        prompts,
+		i18n:           i18nBundle,
+		mutexAPI:       mutexAPI,
+		getConfig:      getConfig,
+	}
+}
+
+// SetLicenseChecker pauses the scheduled FAQs while the license does not include them.
+func (s *Service) SetLicenseChecker(license enterprise.FeatureChecker) {
+	s.license = license
+}
+
+// Start checks the schedules of the FAQs every interval.
+func (s *Service) Start(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.Poll()
+			}
+		}
+	}()
+}
+
+// Stop stops checking the schedules.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// Get returns the FAQ of the channel.
+func (s *Service) Get(channelID string) (*FAQ, error) {
+	var faq *FAQ
+	if err := s.client.KVGet(faqKeyPrefix+channelID, &faq); err != nil {
+		return nil, fmt.Errorf("failed to get FAQ: %w", err)
+	}
+	if faq == nil {
+		return nil, ErrNotFound
+	}
+	return faq, nil
+}
+
+// Build saves the options of the FAQ of the channel and starts a job building it. Building the
+// FAQ of a channel that already has one replaces its options and keeps its post.
+func (s *Service) Build(userID, channelID string, bot *bots.Bot, opts Options) (*jobs.Job, error) {
+	if !s.getConfig().Enabled {
+		return nil, ErrNotEnabled
+	}
+	if opts.LookbackDays == 0 {
+		opts.LookbackDays = DefaultLookbackDays
+	}
+	if opts.LookbackDays < 1 || opts.LookbackDays > MaxLookbackDays {
+		return nil, ErrInvalidLookback
+	}
+	if opts.RefreshIntervalDays < 0 || opts.RefreshIntervalDays > MaxRefreshIntervalDays {
+		return nil, ErrInvalidInterval
+	}
+
+	faq, err := s.Get(channelID)
+	if errors.Is(err, ErrNotFound) {
+		faq = &FAQ{ChannelID: channelID, CreateAt: model.GetMillis()}
+	} else if err != nil {
+		return nil, err
+	} else if s.isBuilding(faq) {
+		return nil, ErrBuilding
+	}
+	faq.BotID = bot.GetMMBot().UserId
+	faq.UserID = userID
+	faq.LookbackDays = opts.LookbackDays
+	faq.RefreshIntervalDays = opts.RefreshIntervalDays
+	faq.Post = opts.Post
+
+	if err := s.updateChannels(func(channelIDs []string) []string {
+		if slices.Contains(channelIDs, channelID) {
+			return channelIDs
+		}
+		return append(channelIDs, channelID)
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.startJob(faq)
+}
+
+// Delete removes the FAQ of the channel, stopping its refreshes. Its post is left in the channel.
+func (s *Service) Delete(channelID string) error {
+	if _, err := s.Get(channelID); err != nil {
+		return err
+	}
+	if err := s.client.KVDelete(faqKeyPrefix + channelID); err != nil {
+		return fmt.Errorf("failed to delete FAQ: %w", err)
+	}
+	return s.updateChannels(func(channelIDs []string) []string {
+		return slices.DeleteFunc(channelIDs, func(id string) bool { return id == channelID })
+	})
+}
+
+// Export returns the FAQ of the channel as a Markdown document.
+func (s *Service) Export(channel *model.Channel) (string, error) {
+	faq, err := s.Get(channel.Id)
+	if err != nil {
+		return "", err
+	}
+	if faq.GeneratedAt == 0 {
+		return "", ErrNotGenerated
+	}
+
+	T, err := s.localizer(faq)
+	if err != nil {
+		return "", err
+	}
+	return T("agents.faq.export_title", "# %s FAQ\n\n", channel.DisplayName) + faq.Markdown + "\n", nil
+}
+
+// Poll starts the refreshes of the FAQs that are due. Only one node of the cluster polls at a time.
+func (s *Service) Poll() {
+	if !s.getConfig().Enabled {
+		return
+	}
+	if s.license != nil {
+		if err := s.license.CheckFeature(enterprise.FeatureFAQBuilder); err != nil {
+			s.client.LogDebug("Skipping scheduled FAQs", "error", err)
+			return
+		}
+	}
+
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_faq_poll")
+	if err != nil {
+		s.client.LogError("Failed to create FAQ poll mutex", "error", err)
+		return
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	var channelIDs []string
+	if err := s.client.KVGet(channelsKey, &channelIDs); err != nil {
+		s.client.LogError("Failed to get FAQ channels", "error", err)
+		return
+	}
+
+	now := model.GetMillis()
+	for _, channelID := range channelIDs {
+		faq, err := s.Get(channelID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			s.client.LogWarn("Failed to get FAQ", "channel_id", channelID, "error", err)
+			continue
+		}
+		if !s.refreshDue(faq, now) {
+			continue
+		}
+		if err := s.checkChannel(faq); err != nil {
+			// The channel may have been excluded or the agent restricted since the FAQ was built
+			s.client.LogDebug("Skipping FAQ refresh", "channel_id", channelID, "error", err)
+			continue
+		}
+		if _, err := s.startJob(faq); err != nil {
+			s.client.LogWarn("Failed to start FAQ refresh", "channel_id", channelID, "error", err)
+		}
+	}
+}
+
+// refreshDue reports whether the FAQ is scheduled and its interval elapsed since it was last
+// built. A FAQ whose build is still running isn't refreshed again.
+func (s *Service) refreshDue(faq *FAQ, now int64) bool {
+	if faq.RefreshIntervalDays == 0 {
+		return false
+	}
+	if s.isBuilding(faq) {
+		return false
+	}
+	lastBuild := max(faq.GeneratedAt, faq.CreateAt)
+	return now >= lastBuild+int64(faq.RefreshIntervalDays)*24*time.Hour.Milliseconds()
+}
+
+func (s *Service) isBuilding(faq *FAQ) bool {
+	if faq.JobID == "" {
+		return false
+	}
+	job, err := s.jobs.Get(faq.JobID)
+	return err == nil && !job.IsFinished()
+}
+
+// checkChannel checks the agent of the FAQ can still be used in its channel.
+func (s *Service) checkChannel(faq *FAQ) error {
+	bot := s.bots.GetBotByID(faq.BotID)
+	if bot == nil {
+		return fmt.Errorf("agent %s not found", faq.BotID)
+	}
+	channel, err := s.client.GetChannel(faq.ChannelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	return s.bots.CheckUsageRestrictionsForChannel(bot, channel)
+}
+
+// startJob saves the FAQ and starts the job building it.
+func (s *Service) startJob(faq *FAQ) (*jobs.Job, error) {
+	if err := s.save(faq); err != nil {
+		return nil, err
+	}
+
+	job, err := s.jobs.Start(&jobs.Job{
+		Type:      JobType,
+		UserID:    faq.UserID,
+		ChannelID: faq.ChannelID,
+	}, func(ctx context.Context, progress jobs.ProgressFunc) error {
+		return s.build(ctx, faq.ChannelID, progress)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The job may already have saved the FAQ it built
+	current, err := s.Get(faq.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	current.JobID = job.ID
+	if err := s.save(current); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// build drafts the FAQ from the answered questions of the channel history and saves it, updating
+// or creating its post if the FAQ is posted.
+func (s *Service) build(ctx context.Context, channelID string, progress jobs.ProgressFunc) error {
+	faq, err := s.Get(channelID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkChannel(faq); err != nil {
+		return err
+	}
+	T, err := s.localizer(faq)
+	if err != nil {
+		return err
+	}
+
+	progress(T("agents.faq.progress_reading", "Reading the channel history..."))
+	since := model.GetMillis() - int64(faq.LookbackDays)*24*time.Hour.Milliseconds()
+	questions, err := s.answeredQuestions(faq, since)
+	if err != nil {
+		return err
+	}
+	if len(questions) == 0 {
+		return ErrNoQuestions
+	}
+
+	progress(T("agents.faq.progress_grouping", "Grouping %d answered questions...", len(questions)))
+	groups, err := s.group(ctx, faq, questions, since)
+	if err != nil {
+		return err
+	}
+
+	maxEntries := s.getConfig().MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	if len(groups) > maxEntries {
+		groups = groups[:maxEntries]
+	}
+
+	progress(T("agents.faq.progress_drafting", "Drafting the FAQ..."))
+	markdown, err := s.generate(ctx, faq, groups)
+	if err != nil {
+		return err
+	}
+
+	// Reload the FAQ as its options may have changed, or it may have been deleted, while it was built
+	current, err := s.Get(channelID)
+	if err != nil {
+		return err
+	}
+	current.Markdown = markdown
+	current.QuestionCount = len(questions)
+	current.GeneratedAt = model.GetMillis()
+	if current.Post {
+		if err := s.upsertPost(T, current); err != nil {
+			return err
+		}
+	}
+
+	return s.save(current)
+}
+
+// thread is an answered question of the channel with its replies, oldest first.
+type thread struct {
+	root    *model.Post
+	replies []*model.Post
+}
+
+// answeredQuestions returns the most recent questions asked in the channel since the given time
+// that someone other than their author answered, leaving out the posts the channel policy excludes.
+func (s *Service) answeredQuestions(faq *FAQ, since int64) ([]*thread, error) {
+	channel, err := s.client.GetChannel(faq.ChannelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel: %w", err)
+	}
+	policy, err := s.bots.ChannelPolicy(channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel policy: %w", err)
+	}
+
+	posts, err := s.client.GetPostsSince(faq.ChannelID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel posts: %w", err)
+	}
+
+	authors := map[string]*model.User{}
+	threadsByRoot := map[string]*model.PostList{}
+	for _, post := range posts.Posts {
+		if post.DeleteAt != 0 || post.IsSystemMessage() || post.GetProp(FAQProp) != nil {
+			continue
+		}
+		if policy.ExcludesAuthors() {
+			author, ok := authors[post.UserId]
+			if !ok {
+				author, err = s.client.GetUser(post.UserId)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get post author: %w", err)
+				}
+				authors[post.UserId] = author
+			}
+			if policy.ExcludesPost(post, author) {
+				continue
+			}
+		}
+		rootID := post.RootId
+		if rootID == "" {
+			rootID = post.Id
+		}
+		list, ok := threadsByRoot[rootID]
+		if !ok {
+			list = model.NewPostList()
+			threadsByRoot[rootID] = list
+		}
+		list.AddPost(post)
+		list.AddOrder(post.Id)
+	}
+
+	var questions []*thread
+	for rootID, list := range threadsByRoot {
+		root, ok := list.Posts[rootID]
+		if !ok || root.UserId == faq.BotID || !duplicates.IsQuestion(root.Message) || !duplicates.IsAnswered(root, list) {
+			continue
+		}
+		question := &thread{root: root}
+		for _, post := range list.Posts {
+			if post.Id != rootID {
+				question.replies = append(question.replies, post)
+			}
+		}
+		sort.Slice(question.replies, func(i, j int) bool {
+			return question.replies[i].CreateAt < question.replies[j].CreateAt
+		})
+		questions = append(questions, question)
+	}
+
+	sort.Slice(questions, func(i, j int) bool {
+		return questions[i].root.CreateAt > questions[j].root.CreateAt
+	})
+	if len(questions) > maxQuestions {
+		questions = questions[:maxQuestions]
+	}
+
+	return questions, nil
+}
+
+// group groups the questions asking the same thing, the largest groups first. Questions are
+// grouped by searching the embeddings index for each of them, with the permissions of the user
+// who built the FAQ. Without the index, each question is its own group.
+func (s *Service) group(ctx context.Context, faq *FAQ, questions []*thread, since int64) ([][]*thread, error) {
+	parents := make([]int, len(questions))
+	indexByPostID := map[string]int{}
+	for i, question := range questions {
+		parents[i] = i
+		indexByPostID[question.root.Id] = i
+		for _, reply := range question.replies {
+			indexByPostID[reply.Id] = i
+		}
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+
+	if s.search.Enabled() {
+		for i, question := range questions {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			results, err := s.search.Search(ctx, format.PostBody(question.root), embeddings.SearchOptions{
+				Limit:        groupingLimit,
+				MinScore:     groupingMinScore,
+				ChannelID:    faq.ChannelID,
+				UserID:       faq.UserID,
+				CreatedAfter: since,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to search for similar questions: %w", err)
+			}
+			for _, result := range results {
+				if j, ok := indexByPostID[result.Document.PostID]; ok {
+					parents[find(j)] = find(i)
+				}
+			}
+		}
+	}
+
+	groupsByRoot := map[int][]*thread{}
+	for i, question := range questions {
+		root := find(i)
+		groupsByRoot[root] = append(groupsByRoot[root], question)
+	}
+	groups := make([][]*thread, 0, len(groupsByRoot))
+	for _, group := range groupsByRoot {
+		// Questions are sorted most recent first, so are the groups
+		groups = append(groups, group)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i]) != len(groups[j]) {
+			return len(groups[i]) > len(groups[j])
+		}
+		return groups[i][0].root.CreateAt > groups[j][0].root.CreateAt
+	})
+
+	return groups, nil
+}
+
+// generate returns the FAQ the bot writes from the groups of questions.
+func (s *Service) generate(ctx context.Context, faq *FAQ, groups [][]*thread) (string, error) {
+	bot := s.bots.GetBotByID(faq.BotID)
+	if bot == nil {
+		return "", fmt.Errorf("agent %s not found", faq.BotID)
+	}
+	user, err := s.client.GetUser(faq.UserID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	channel, err := s.client.GetChannel(faq.ChannelID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get channel: %w", err)
+	}
+
+	llmContext := s.contextBuilder.BuildLLMContextUserRequest(bot, user, channel)
+	llmContext.Priority = llm.PriorityBackground
+	llmContext.Parameters = map[string]any{
+		"ChannelName": channel.DisplayName,
+		"PreviousFAQ": faq.Markdown,
+		"Thread":      s.formatGroups(groups),
+	}
+
+	systemPrompt, err := s.prompts.Format(prompts.PromptFaqSystem, llmContext)
+	if err != nil {
+		return "", fmt.Errorf("failed to format system prompt: %w", err)
+	}
+	userPrompt, err := s.prompts.Format(prompts.PromptThreadUser, llmContext)
+	if err != nil {
+		return "", fmt.Errorf("failed to format user prompt: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, generationTimeout)
+	defer cancel()
+
+	result, err := bot.LLM().ChatCompletionNoStream(ctx, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: userPrompt},
+		},
+		Context: llmContext,
+	}, llm.WithToolsDisabled())
+	if err != nil {
+		return "", fmt.Errorf("failed to generate FAQ: %w", err)
+	}
+
+	return strings.TrimSpace(result), nil
+}
+
+// formatGroups formats the threads of each group with their time and author.
+func (s *Service) formatGroups(groups [][]*thread) string {
+	usernames := map[string]string{}
+	username := func(userID string) string {
+		if name, ok := usernames[userID]; ok {
+			return name
+		}
+		name := userID
+		if user, err := s.client.GetUser(userID); err == nil {
+			name = user.Username
+		}
+		usernames[userID] = name
+		return name
+	}
+
+	var result strings.Builder
+	for i, group := range groups {
+		fmt.Fprintf(&result, "## Group %d, threads: %d\n\n", i+1, len(group))
+		for _, question := range group[:min(len(group), maxThreadsPerEntry)] {
+			fmt.Fprintf(&result, "[%s] %s: %s\n", formatTime(question.root.CreateAt), username(question.root.UserId), format.PostBody(question.root))
+			for _, reply := range question.replies {
+				fmt.Fprintf(&result, "  [%s] %s: %s\n", formatTime(reply.CreateAt), username(reply.UserId), format.PostBody(reply))
+			}
+			result.WriteString("\n")
+		}
+	}
+	return result.String()
+}
+
+// upsertPost edits the FAQ post, posting a new one if it was deleted.
+func (s *Service) upsertPost(T i18n.TranslationFunc, faq *FAQ) error {
+	message := T("agents.faq.post_header", "#### Frequently asked questions\n_Drafted from %d answered questions of the last %d days._\n\n", faq.QuestionCount, faq.LookbackDays) + faq.Markdown
+
+	if faq.PostID != "" {
+		post, err := s.client.GetPost(faq.PostID)
+		if err == nil && post.DeleteAt == 0 {
+			post.Message = message
+			if err := s.client.UpdatePost(post); err != nil {
+				return fmt.Errorf("failed to update FAQ post: %w", err)
+			}
+			return nil
+		}
+	}
+
+	post := &model.Post{
+		UserId:    faq.BotID,
+		ChannelId: faq.ChannelID,
+		Message:   message,
+	}
+	post.AddProp(FAQProp, "true")
+	if err := s.client.CreatePost(post); err != nil {
+		return fmt.Errorf("failed to create FAQ post: %w", err)
+	}
+	faq.PostID = post.Id
+	return nil
+}
+
+func (s *Service) localizer(faq *FAQ) (i18n.TranslationFunc, error) {
+	user, err := s.client.GetUser(faq.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return i18n.LocalizerFunc(s.i18n, user.Locale), nil
+}
+
+func (s *Service) save(faq *FAQ) error {
+	if err := s.client.KVSet(faqKeyPrefix+faq.ChannelID, faq); err != nil {
+		return fmt.Errorf("failed to save FAQ: %w", err)
+	}
+	return nil
+}
+
+// updateChannels updates the list of channels with a FAQ, which the schedules are checked from.
+func (s *Service) updateChannels(update func(channelIDs []string) []string) error {
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_faq_channels")
+	if err != nil {
+		return fmt.Errorf("failed to create FAQ channels mutex: %w", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	var channelIDs []string
+	if err := s.client.KVGet(channelsKey, &channelIDs); err != nil {
+		return fmt.Errorf("failed to get FAQ channels: %w", err)
+	}
+	if err := s.client.KVSet(channelsKey, update(channelIDs)); err != nil {
+		return fmt.Errorf("failed to save FAQ channels: %w", err)
+	}
+	return nil
+}
+
+func formatTime(millis int64) string {
+	return time.UnixMilli(millis).UTC().Format("2006-01-02 15:04 UTC")
+}
@@ -0,0 +1,371 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package faq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	llmmocks "github.com/mattermost/mattermost-plugin-ai/llm/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mmapitest"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient keeps the KV store and the posts of a channel in memory. Jobs run in the
+// background, so it is safe for concurrent use.
+type fakeClient struct {
+	mmapi.Client
+	mu    sync.Mutex
+	kv    map[string][]byte
+	posts map[string]*model.Post
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{kv: map[string][]byte{}, posts: map[string]*model.Post{}}
+}
+
+func (f *fakeClient) KVGet(key string, value interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.kv[key]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (f *fakeClient) KVSet(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.kv[key] = data
+	return nil
+}
+
+func (f *fakeClient) KVDelete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.kv, key)
+	return nil
+}
+
+func (f *fakeClient) GetUser(userID string) (*model.User, error) {
+	user := &model.User{Id: userID, Username: "user-" + userID, Locale: "en", Roles: model.SystemUserRoleId}
+	if strings.HasPrefix(userID, "guest") {
+		user.Roles = model.SystemGuestRoleId
+	}
+	return user, nil
+}
+
+func (f *fakeClient) GetChannel(channelID string) (*model.Channel, error) {
+	return &model.Channel{Id: channelID, Name: "help", DisplayName: "Help"}, nil
+}
+
+func (f *fakeClient) GetPost(postID string) (*model.Post, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	post, ok := f.posts[postID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return post.Clone(), nil
+}
+
+func (f *fakeClient) CreatePost(post *model.Post) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if post.Id == "" {
+		post.Id = model.NewId()
+	}
+	if post.CreateAt == 0 {
+		post.CreateAt = model.GetMillis()
+	}
+	f.posts[post.Id] = post.Clone()
+	return nil
+}
+
+func (f *fakeClient) UpdatePost(post *model.Post) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.posts[post.Id] = post.Clone()
+	return nil
+}
+
+func (f *fakeClient) GetPostsSince(_ string, since int64) (*model.PostList, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	list := model.NewPostList()
+	for _, post := range f.posts {
+		if post.CreateAt >= since {
+			list.AddPost(post.Clone())
+			list.AddOrder(post.Id)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeClient) LogError(string, ...interface{}) {}
+
+func (f *fakeClient) LogWarn(string, ...interface{}) {}
+
+func (f *fakeClient) LogDebug(string, ...interface{}) {}
+
+func (f *fakeClient) faqPosts() []*model.Post {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var posts []*model.Post
+	for _, post := range f.posts {
+		if post.GetProp(FAQProp) != nil {
+			posts = append(posts, post)
+		}
+	}
+	return posts
+}
+
+type fakeBots struct {
+	bot         *bots.Bot
+	policy      exclusions.ChannelPolicy
+	restriction error
+}
+
+func (f *fakeBots) GetBotByID(string) *bots.Bot {
+	return f.bot
+}
+
+func (f *fakeBots) CheckUsageRestrictionsForChannel(*bots.Bot, *model.Channel) error {
+	return f.restriction
+}
+
+func (f *fakeBots) ChannelPolicy(*model.Channel) (exclusions.ChannelPolicy, error) {
+	return f.policy, nil
+}
+
+type fakeContextBuilder struct{}
+
+func (fakeContextBuilder) BuildLLMContextUserRequest(_ *bots.Bot, user *model.User, channel *model.Channel, _ ...llm.ContextOption) *llm.Context {
+	llmContext := llm.NewContext()
+	llmContext.RequestingUser = user
+	llmContext.Channel = channel
+	return llmContext
+}
+
+// fakeSearch returns the given posts as similar to each query.
+type fakeSearch struct {
+	similar map[string][]string
+}
+
+func (f *fakeSearch) Enabled() bool {
+	return f.similar != nil
+}
+
+func (f *fakeSearch) Search(_ context.Context, query string, _ embeddings.SearchOptions) ([]embeddings.SearchResult, error) {
+	var results []embeddings.SearchResult
+	for _, postID := range f.similar[query] {
+		results = append(results, embeddings.SearchResult{Document: embeddings.PostDocument{PostID: postID}, Score: 0.9})
+	}
+	return results, nil
+}
+
+func newTestService(t *testing.T, client *fakeClient, search *fakeSearch, languageModel llm.LanguageModel) (*Service, *bots.Bot) {
+	promptsObj, err := llm.NewPrompts(prompts.PromptsFolder)
+	require.NoError(t, err)
+	bot := bots.NewBot(llm.BotConfig{}, llm.ServiceConfig{}, &model.Bot{UserId: "bot1"}, languageModel)
+	cfg := config.FAQBuilderConfig{Enabled: true}
+	return New(client, &fakeBots{bot: bot}, fakeContextBuilder{}, search, jobs.New(client), promptsObj, i18n.Init(), mmapitest.MutexAPI{}, func() config.FAQBuilderConfig { return cfg }), bot
+}
+
+func waitForJob(t *testing.T, service *Service, jobID string) *jobs.Job {
+	t.Helper()
+	var job *jobs.Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = service.jobs.Get(jobID)
+		require.NoError(t, err)
+		return job.IsFinished()
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func addThread(t *testing.T, client *fakeClient, rootID, question string, replies ...string) {
+	t.Helper()
+	now := model.GetMillis()
+	require.NoError(t, client.CreatePost(&model.Post{Id: rootID, ChannelId: "channel1", UserId: "asker", Message: question, CreateAt: now}))
+	for i, reply := range replies {
+		require.NoError(t, client.CreatePost(&model.Post{Id: rootID + "-reply" + string(rune('a'+i)), RootId: rootID, ChannelId: "channel1", UserId: "helper", Message: reply, CreateAt: now + int64(i) + 1}))
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name          string
+		disabled      bool
+		opts          Options
+		expectedError error
+	}{
+		{name: "not enabled", disabled: true, expectedError: ErrNotEnabled},
+		{name: "lookback too long", opts: Options{LookbackDays: 400}, expectedError: ErrInvalidLookback},
+		{name: "negative interval", opts: Options{RefreshIntervalDays: -1}, expectedError: ErrInvalidInterval},
+		{name: "interval too long", opts: Options{RefreshIntervalDays: 91}, expectedError: ErrInvalidInterval},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, bot := newTestService(t, newFakeClient(), &fakeSearch{}, nil)
+			if test.disabled {
+				service.getConfig = func() config.FAQBuilderConfig { return config.FAQBuilderConfig{} }
+			}
+
+			_, err := service.Build("user1", "channel1", bot, test.opts)
+			require.ErrorIs(t, err, test.expectedError)
+			_, err = service.Get("channel1")
+			require.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
+
+func TestBuildAndRefresh(t *testing.T) {
+	client := newFakeClient()
+	addThread(t, client, "vpn1", "How do I reset my VPN password?", "Use the self service portal.")
+	addThread(t, client, "vpn2", "Where can I reset the VPN password?", "Self service portal, under Security.")
+	addThread(t, client, "printer", "Which printer is on the third floor?", "PRN-3, next to the kitchen.")
+	addThread(t, client, "unanswered", "Is the office open on Friday?")
+	addThread(t, client, "statement", "The VPN is down again", "Looking into it")
+
+	search := &fakeSearch{similar: map[string][]string{
+		"How do I reset my VPN password?": {"vpn1", "vpn2-replya"},
+	}}
+	languageModel := llmmocks.NewMockLanguageModel(t)
+	service, bot := newTestService(t, client, search, languageModel)
+
+	languageModel.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, request llm.CompletionRequest, _ ...llm.LanguageModelOption) (string, error) {
+		message := request.Posts[1].Message
+		require.Contains(t, message, "## Group 1, threads: 2")
+		require.Contains(t, message, "## Group 2, threads: 1")
+		require.Contains(t, message, "user-helper: PRN-3, next to the kitchen.")
+		require.NotContains(t, message, "Is the office open on Friday?")
+		require.NotContains(t, message, "The VPN is down again")
+		return "##### How do I reset my VPN password?\nUse the self service portal.", nil
+	}).Once()
+
+	job, err := service.Build("user1", "channel1", bot, Options{Post: true, RefreshIntervalDays: 7})
+	require.NoError(t, err)
+	require.Equal(t, JobType, job.Type)
+	job = waitForJob(t, service, job.ID)
+	require.Equal(t, jobs.StatusCompleted, job.Status, job.Error)
+
+	built, err := service.Get("channel1")
+	require.NoError(t, err)
+	require.Equal(t, job.ID, built.JobID)
+	require.Equal(t, DefaultLookbackDays, built.LookbackDays)
+	require.Equal(t, 3, built.QuestionCount)
+	require.Equal(t, "##### How do I reset my VPN password?\nUse the self service portal.", built.Markdown)
+
+	posts := client.faqPosts()
+	require.Len(t, posts, 1)
+	require.Equal(t, built.PostID, posts[0].Id)
+	require.Equal(t, "#### Frequently asked questions\n_Drafted from 3 answered questions of the last 90 days._\n\n"+built.Markdown, posts[0].Message)
+
+	markdown, err := service.Export(&model.Channel{Id: "channel1", DisplayName: "Help"})
+	require.NoError(t, err)
+	require.Equal(t, "# Help FAQ\n\n"+built.Markdown+"\n", markdown)
+
+	// The refresh isn't due until the interval elapsed
+	require.False(t, service.refreshDue(built, model.GetMillis()))
+	require.True(t, service.refreshDue(built, built.GeneratedAt+7*24*time.Hour.Milliseconds()))
+
+	// Refreshes update the FAQ post, and are given the previous FAQ to keep its wording
+	languageModel.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, request llm.CompletionRequest, _ ...llm.LanguageModelOption) (string, error) {
+		require.Contains(t, request.Posts[0].Message, "Use the self service portal.")
+		return "##### How do I reset my VPN password?\nUse the self service portal, under Security.", nil
+	}).Once()
+	job, err = service.Build("user1", "channel1", bot, Options{Post: true})
+	require.NoError(t, err)
+	job = waitForJob(t, service, job.ID)
+	require.Equal(t, jobs.StatusCompleted, job.Status, job.Error)
+
+	posts = client.faqPosts()
+	require.Len(t, posts, 1)
+	require.Contains(t, posts[0].Message, "under Security.")
+
+	require.NoError(t, service.Delete("channel1"))
+	_, err = service.Export(&model.Channel{Id: "channel1"})
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestBuildWithoutQuestions(t *testing.T) {
+	client := newFakeClient()
+	addThread(t, client, "unanswered", "Is the office open on Friday?")
+	service, bot := newTestService(t, client, &fakeSearch{}, nil)
+
+	job, err := service.Build("user1", "channel1", bot, Options{})
+	require.NoError(t, err)
+	job = waitForJob(t, service, job.ID)
+	require.Equal(t, jobs.StatusFailed, job.Status)
+	require.Equal(t, ErrNoQuestions.Error(), job.Error)
+
+	_, err = service.Export(&model.Channel{Id: "channel1"})
+	require.ErrorIs(t, err, ErrNotGenerated)
+}
+
+func TestRestrictedChannel(t *testing.T) {
+	client := newFakeClient()
+	addThread(t, client, "password", "How do I reset my password?", "Use the link on the login page.")
+	service, bot := newTestService(t, client, &fakeSearch{}, nil)
+	require.NoError(t, service.save(&FAQ{ChannelID: "channel1", BotID: "bot1", UserID: "user1", LookbackDays: 90, RefreshIntervalDays: 1, CreateAt: 1}))
+	require.NoError(t, client.KVSet(channelsKey, []string{"channel1"}))
+
+	// The channel was excluded after the FAQ was built
+	service.bots.(*fakeBots).restriction = fmt.Errorf("%w: %w", bots.ErrUsageRestriction, exclusions.ErrChannelExcluded)
+
+	// The due refresh isn't started
+	service.Poll()
+	faq, err := service.Get("channel1")
+	require.NoError(t, err)
+	require.Empty(t, faq.JobID)
+
+	// A job started anyway fails before reading the channel
+	job, err := service.Build("user1", "channel1", bot, Options{})
+	require.NoError(t, err)
+	job = waitForJob(t, service, job.ID)
+	require.Equal(t, jobs.StatusFailed, job.Status)
+	require.Contains(t, job.Error, exclusions.ErrChannelExcluded.Error())
+}
+
+func TestAnsweredQuestionsExcludesGuests(t *testing.T) {
+	client := newFakeClient()
+	addThread(t, client, "answered", "How do I reset my password?", "Use the link on the login page.")
+	now := model.GetMillis()
+	require.NoError(t, client.CreatePost(&model.Post{Id: "guest-question", ChannelId: "channel1", UserId: "guest1", Message: "Where is the VPN guide?", CreateAt: now}))
+	require.NoError(t, client.CreatePost(&model.Post{Id: "guest-reply", RootId: "guest-question", ChannelId: "channel1", UserId: "helper", Message: "In the wiki.", CreateAt: now + 1}))
+	require.NoError(t, client.CreatePost(&model.Post{Id: "guest-answered", ChannelId: "channel1", UserId: "asker", Message: "Which printer works?", CreateAt: now}))
+	require.NoError(t, client.CreatePost(&model.Post{Id: "guest-answer", RootId: "guest-answered", ChannelId: "channel1", UserId: "guest1", Message: "The one on floor 2.", CreateAt: now + 1}))
+
+	service, _ := newTestService(t, client, &fakeSearch{}, nil)
+	service.bots.(*fakeBots).policy = exclusions.ChannelPolicy{ExcludeGuests: true}
+
+	questions, err := service.answeredQuestions(&FAQ{ChannelID: "channel1", BotID: "bot1"}, 0)
+	require.NoError(t, err)
+	require.Len(t, questions, 1)
+	require.Equal(t, "answered", questions[0].root.Id)
+}
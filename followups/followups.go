@@ -0,0 +1,128 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package followups
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+)
+
+// SuggestionsProp is the post prop holding the JSON encoded follow-up questions of a bot reply.
+const SuggestionsProp = "follow_up_suggestions"
+
+// MaxSuggestions is the number of follow-up questions suggested for a reply.
+const MaxSuggestions = 3
+
+// maxQuestionLength drops suggestions that ignored the instructions to stay short.
+const maxQuestionLength = 200
+
+// Suggestions is the structured output requested from the model.
+type Suggestions struct {
+	Questions []string `json:"questions"`
+}
+
+// FollowUps suggests questions a user may want to ask after a bot reply
+type FollowUps struct {
+	llm     llm.LanguageModel
+	prompts *llm.Prompts
+}
+
+// New creates a new FollowUps
+func New(
+	llm llm.LanguageModel,
+	prompts *llm.Prompts,
+) *FollowUps {
+	return &FollowUps{
+		llm:     llm,
+		prompts: prompts,
+	}
+}
+
+// Suggest returns up to MaxSuggestions follow-up questions for the user's question and the bot's answer.
+func (f *FollowUps) Suggest(ctx context.Context, question, answer string, context *llm.Context) ([]string, error) {
+	context.Parameters = map[string]any{
+		"Count":    MaxSuggestions,
+		"Question": question,
+		"Answer":   answer,
+	}
+
+	systemPrompt, err := f.prompts.Format(prompts.PromptFollowUpQuestionsSystem, context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format system prompt: %w", err)
+	}
+
+	userPrompt, err := f.prompts.Format(prompts.PromptFollowUpQuestionsUser, context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format user prompt: %w", err)
+	}
+
+	completionRequest := llm.CompletionRequest{
+		Posts: []llm.Post{
+			{
+				Role:    llm.PostRoleSystem,
+				Message: systemPrompt,
+			},
+			{
+				Role:    llm.PostRoleUser,
+				Message: userPrompt,
+			},
+		},
+		Context: context,
+	}
+
+	result, err := f.llm.ChatCompletionNoStream(ctx, completionRequest,
+		llm.WithMaxGeneratedTokens(500),
+		llm.WithReasoningDisabled(),
+		llm.WithToolsDisabled(),
+		llm.WithJSONOutput[Suggestions](),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follow-up questions from LLM: %w", err)
+	}
+
+	var suggestions Suggestions
+	if err := json.Unmarshal([]byte(result), &suggestions); err != nil {
+		return nil, fmt.Errorf("failed to parse follow-up questions: %w", err)
+	}
+
+	return cleanQuestions(suggestions.Questions), nil
+}
+
+// cleanQuestions trims the questions and removes empty, overly long and duplicate ones.
+func cleanQuestions(questions []string) []string {
+	cleaned := make([]string, 0, MaxSuggestions)
+	seen := make(map[string]bool, len(questions))
+	for _, question := range questions {
+		question = strings.TrimSpace(question)
+		key := strings.ToLower(question)
+		if question == "" || len(question) > maxQuestionLength || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, question)
+		if len(cleaned) == MaxSuggestions {
+			break
+		}
+	}
+	return cleaned
+}
+
+// FromProps returns the follow-up questions stored in a post's props.
+func FromProps(props map[string]any) []string {
+	raw, ok := props[SuggestionsProp].(string)
+	if !ok || raw == "" {
+		return nil
+	}
+
+	var questions []string
+	if err := json.Unmarshal([]byte(raw), &questions); err != nil {
+		return nil
+	}
+	return questions
+}
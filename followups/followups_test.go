@@ -0,0 +1,79 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package followups_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/followups"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/llm/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		name          string
+		llmResponse   string
+		llmError      error
+		expected      []string
+		errorContains string
+	}{
+		{
+			name:        "success",
+			llmResponse: `{"questions": ["How do I deploy it?", "Can you show an example?", "What are the limits?"]}`,
+			expected:    []string{"How do I deploy it?", "Can you show an example?", "What are the limits?"},
+		},
+		{
+			name:        "cleans up questions",
+			llmResponse: `{"questions": ["  How do I deploy it? ", "", "how do I deploy it?", "Can you show an example?", "What are the limits?", "Is it free?"]}`,
+			expected:    []string{"How do I deploy it?", "Can you show an example?", "What are the limits?"},
+		},
+		{
+			name:        "no questions",
+			llmResponse: `{"questions": []}`,
+			expected:    []string{},
+		},
+		{
+			name:          "invalid json",
+			llmResponse:   "How do I deploy it?",
+			errorContains: "failed to parse follow-up questions",
+		},
+		{
+			name:          "llm error",
+			llmError:      errors.New("llm error"),
+			errorContains: "failed to get follow-up questions from LLM",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockLLM := mocks.NewMockLanguageModel(t)
+			prompts, err := llm.NewPrompts(prompts.PromptsFolder)
+			require.NoError(t, err)
+
+			mockLLM.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tc.llmResponse, tc.llmError)
+
+			questions, err := followups.New(mockLLM, prompts).Suggest(context.Background(), "What is the plugin?", "It adds AI to Mattermost.", llm.NewContext())
+			if tc.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, questions)
+		})
+	}
+}
+
+func TestFromProps(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, followups.FromProps(map[string]any{followups.SuggestionsProp: `["a","b"]`}))
+	assert.Nil(t, followups.FromProps(map[string]any{}))
+	assert.Nil(t, followups.FromProps(map[string]any{followups.SuggestionsProp: "not json"}))
+}
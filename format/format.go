@@ -4,8 +4,10 @@
 package format
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
@@ -15,12 +17,127 @@ import (
 func ThreadData(data *mmapi.ThreadData) string {
 	result := ""
 	for _, post := range data.Posts {
-		result += fmt.Sprintf("%s: %s\n\n", data.UsersByID[post.UserId].Username, PostBody(post))
+		result += threadPost(data, post)
 	}
 
 	return result
 }
 
+// ThreadDataWithinBudget formats the thread like ThreadData when it fits in maxTokens as counted
+// by countTokens. Longer threads are sampled instead of cut at the end: the root post is always
+// kept, then the posts with the most reactions, then the posts of the most active participants,
+// the most recent first. The kept posts stay in chronological order, with a note where messages
+// were left out. A maxTokens of zero or less means no budget.
+func ThreadDataWithinBudget(data *mmapi.ThreadData, maxTokens int, countTokens func(string) int) string {
+	formatted := make([]string, len(data.Posts))
+	for i, post := range data.Posts {
+		formatted[i] = threadPost(data, post)
+	}
+	full := strings.Join(formatted, "")
+	if maxTokens <= 0 || len(data.Posts) <= 1 || countTokens(full) <= maxTokens {
+		return full
+	}
+
+	postsByUser := make(map[string]int)
+	for _, post := range data.Posts {
+		postsByUser[post.UserId]++
+	}
+
+	candidates := make([]int, 0, len(data.Posts)-1)
+	for i := 1; i < len(data.Posts); i++ {
+		candidates = append(candidates, i)
+	}
+	slices.SortStableFunc(candidates, func(a, b int) int {
+		postA, postB := data.Posts[a], data.Posts[b]
+		if c := cmp.Compare(reactionCount(postB), reactionCount(postA)); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(postsByUser[postB.UserId], postsByUser[postA.UserId]); c != 0 {
+			return c
+		}
+		return cmp.Compare(b, a)
+	})
+
+	// Every kept post may open a gap of left out messages, so room for a note is kept with it
+	noteTokens := countTokens(leftOutNote(len(data.Posts)))
+	kept := make([]bool, len(data.Posts))
+	kept[0] = true
+	used := countTokens(formatted[0]) + noteTokens
+	for _, i := range candidates {
+		cost := countTokens(formatted[i]) + noteTokens
+		if used+cost > maxTokens {
+			continue
+		}
+		kept[i] = true
+		used += cost
+	}
+
+	result := strings.Builder{}
+	leftOut := 0
+	for i := range data.Posts {
+		if !kept[i] {
+			leftOut++
+			continue
+		}
+		if leftOut > 0 {
+			result.WriteString(leftOutNote(leftOut))
+			leftOut = 0
+		}
+		result.WriteString(formatted[i])
+	}
+	if leftOut > 0 {
+		result.WriteString(leftOutNote(leftOut))
+	}
+
+	return result.String()
+}
+
+func threadPost(data *mmapi.ThreadData, post *model.Post) string {
+	result := fmt.Sprintf("%s: %s\n", data.UsersByID[post.UserId].Username, PostBody(post))
+	for _, file := range data.FilesByPostID[post.Id] {
+		result += FileData(file)
+	}
+	for _, linked := range data.LinkedPostsByPostID[post.Id] {
+		result += LinkedPost(linked)
+	}
+	return result + "\n"
+}
+
+func leftOutNote(count int) string {
+	if count == 1 {
+		return "[1 message left out]\n\n"
+	}
+	return fmt.Sprintf("[%d messages left out]\n\n", count)
+}
+
+// reactionCount returns the number of reactions to the post, or one when the post is only known
+// to have reactions
+func reactionCount(post *model.Post) int {
+	if post.Metadata != nil && len(post.Metadata.Reactions) > 0 {
+		return len(post.Metadata.Reactions)
+	}
+	if post.HasReactions {
+		return 1
+	}
+	return 0
+}
+
+// FileData formats a file attached to a post, with its content when it's known
+func FileData(file mmapi.FileData) string {
+	if file.Content == "" {
+		return fmt.Sprintf("Attached file %s\n", file.Name)
+	}
+	return fmt.Sprintf("Attached file %s:\n%s\n", file.Name, file.Content)
+}
+
+// LinkedPost formats the excerpt of a post linked in a message
+func LinkedPost(linked mmapi.LinkedPost) string {
+	if linked.ChannelName == "" {
+		return fmt.Sprintf("Linked message from %s: %s\n", linked.Username, linked.Excerpt)
+	}
+	return fmt.Sprintf("Linked message from %s in %s: %s\n", linked.Username, linked.ChannelName, linked.Excerpt)
+}
+
 func PostBody(post *model.Post) string {
 	attachments := post.Attachments()
 	if len(attachments) > 0 {
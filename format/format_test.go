@@ -4,6 +4,7 @@
 package format
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
@@ -34,6 +35,30 @@ func TestThreadData(t *testing.T) {
 			},
 			expected: "johndoe: Hello world\n\n",
 		},
+		{
+			name: "post with attached files",
+			data: &mmapi.ThreadData{
+				Posts: []*model.Post{
+					{
+						Id:      "post1",
+						UserId:  "user1",
+						Message: "Numbers for Q3",
+					},
+				},
+				UsersByID: map[string]*model.User{
+					"user1": {
+						Username: "johndoe",
+					},
+				},
+				FilesByPostID: map[string][]mmapi.FileData{
+					"post1": {
+						{Name: "forecast.xlsx", Content: "Region,Revenue\nEMEA,1.2M"},
+						{Name: "archive.zip"},
+					},
+				},
+			},
+			expected: "johndoe: Numbers for Q3\nAttached file forecast.xlsx:\nRegion,Revenue\nEMEA,1.2M\nAttached file archive.zip\n\n",
+		},
 		{
 			name: "multiple posts thread",
 			data: &mmapi.ThreadData{
@@ -204,3 +229,34 @@ Valid field: "Valid value"
 		})
 	}
 }
+
+func TestThreadDataWithinBudget(t *testing.T) {
+	data := &mmapi.ThreadData{
+		Posts: []*model.Post{
+			{Id: "root", UserId: "alice", Message: "Root of the thread here"},
+			{Id: "reacted", UserId: "carol", Message: "a widely agreed upon answer", Metadata: &model.PostMetadata{Reactions: []*model.Reaction{{EmojiName: "+1"}, {EmojiName: "tada"}}}},
+			{Id: "bob1", UserId: "bob", Message: "first message from bob here"},
+			{Id: "dave1", UserId: "dave", Message: "only message from dave here"},
+			{Id: "bob2", UserId: "bob", Message: "second message from bob here"},
+			{Id: "bob3", UserId: "bob", Message: "third message from bob here"},
+		},
+		UsersByID: map[string]*model.User{
+			"alice": {Username: "alice"},
+			"bob":   {Username: "bob"},
+			"carol": {Username: "carol"},
+			"dave":  {Username: "dave"},
+		},
+	}
+	countWords := func(text string) int { return len(strings.Fields(text)) }
+
+	// The thread is formatted as a whole when it fits or without budget
+	full := ThreadData(data)
+	assert.Equal(t, full, ThreadDataWithinBudget(data, len(full), countWords))
+	assert.Equal(t, full, ThreadDataWithinBudget(data, 0, countWords))
+
+	// The root, the reacted post and the latest post of the most active participant are kept
+	assert.Equal(t, "alice: Root of the thread here\n\ncarol: a widely agreed upon answer\n\n[3 messages left out]\n\nbob: third message from bob here\n\n", ThreadDataWithinBudget(data, 30, countWords))
+
+	// The root is kept even when it doesn't fit
+	assert.Equal(t, "alice: Root of the thread here\n\n[5 messages left out]\n\n", ThreadDataWithinBudget(data, 1, countWords))
+}
@@ -0,0 +1,292 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package glossary stores the workspace glossary of internal terms and acronyms.
+// Terms used in a conversation are matched so their definitions can be given to the model.
+package glossary
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// MaxTermLength is the maximum length of a term.
+	MaxTermLength = 100
+	// MaxDefinitionLength is the maximum length of a definition.
+	MaxDefinitionLength = 1000
+	// MaxMatchedTerms limits how many definitions are added to a single request.
+	MaxMatchedTerms = 25
+)
+
+// cacheTTL bounds how long other cluster nodes may keep using outdated terms.
+const cacheTTL = 30 * time.Second
+
+var (
+	// ErrTermNotFound is returned when updating or deleting a term that does not exist.
+	ErrTermNotFound = errors.New("glossary term not found")
+	// ErrDuplicateTerm is returned when a term is already defined.
+	ErrDuplicateTerm = errors.New("glossary term already exists")
+)
+
+// Term is a glossary entry.
+type Term struct {
+	ID            string `json:"id" db:"id"`
+	Term          string `json:"term" db:"term"`
+	Definition    string `json:"definition" db:"definition"`
+	CaseSensitive bool   `json:"case_sensitive" db:"casesensitive"`
+	CreateAt      int64  `json:"create_at" db:"createat"`
+	UpdateAt      int64  `json:"update_at" db:"updateat"`
+	UpdatedBy     string `json:"updated_by" db:"updatedby"`
+}
+
+// IsValid checks the term and definition are set and within the length limits.
+func (t *Term) IsValid() error {
+	if strings.TrimSpace(t.Term) == "" {
+		return errors.New("term is required")
+	}
+	if len([]rune(t.Term)) > MaxTermLength {
+		return fmt.Errorf("term cannot be longer than %d characters", MaxTermLength)
+	}
+	if strings.TrimSpace(t.Definition) == "" {
+		return errors.New("definition is required")
+	}
+	if len([]rune(t.Definition)) > MaxDefinitionLength {
+		return fmt.Errorf("definition cannot be longer than %d characters", MaxDefinitionLength)
+	}
+	return nil
+}
+
+// Logger is the logging interface needed by the store.
+type Logger interface {
+	LogError(msg string, keyValuePairs ...interface{})
+}
+
+type matcher struct {
+	term    llm.GlossaryTerm
+	pattern *regexp.Regexp
+}
+
+// newMatcher matches the term as a whole word. Word boundaries are checked explicitly
+// since \b does not work for terms starting or ending with punctuation, like C++.
+func newMatcher(term Term) (matcher, error) {
+	flags := "(?i)"
+	if term.CaseSensitive {
+		flags = ""
+	}
+	pattern, err := regexp.Compile(flags + `(?:^|[^\p{L}\p{N}_])` + regexp.QuoteMeta(term.Term) + `(?:$|[^\p{L}\p{N}_])`)
+	if err != nil {
+		return matcher{}, err
+	}
+	return matcher{
+		term: llm.GlossaryTerm{
+			Term:       term.Term,
+			Definition: term.Definition,
+		},
+		pattern: pattern,
+	}, nil
+}
+
+// Store persists glossary terms and serves them as an llm.GlossaryProvider.
+type Store struct {
+	db  *mmapi.DBClient
+	log Logger
+
+	cacheLock      sync.Mutex
+	cache          []matcher
+	cacheExpiresAt time.Time
+}
+
+// New creates a new glossary store.
+func New(db *mmapi.DBClient, log Logger) *Store {
+	return &Store{
+		db:  db,
+		log: log,
+	}
+}
+
+// MatchGlossaryTerms returns the terms used in text, up to MaxMatchedTerms.
+// Errors are logged and result in no terms being matched.
+func (s *Store) MatchGlossaryTerms(text string) []llm.GlossaryTerm {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	matchers, err := s.matchers()
+	if err != nil {
+		s.log.LogError("Failed to load glossary", "error", err)
+		return nil
+	}
+
+	return match(matchers, text)
+}
+
+func match(matchers []matcher, text string) []llm.GlossaryTerm {
+	var matched []llm.GlossaryTerm
+	for _, m := range matchers {
+		if !m.pattern.MatchString(text) {
+			continue
+		}
+		matched = append(matched, m.term)
+		if len(matched) >= MaxMatchedTerms {
+			break
+		}
+	}
+	return matched
+}
+
+func (s *Store) matchers() ([]matcher, error) {
+	s.cacheLock.Lock()
+	defer s.cacheLock.Unlock()
+
+	if s.cache != nil && time.Now().Before(s.cacheExpiresAt) {
+		return s.cache, nil
+	}
+
+	terms, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	matchers := make([]matcher, 0, len(terms))
+	for _, term := range terms {
+		m, compileErr := newMatcher(term)
+		if compileErr != nil {
+			s.log.LogError("Failed to compile glossary term", "term", term.Term, "error", compileErr)
+			continue
+		}
+		matchers = append(matchers, m)
+	}
+
+	s.cache = matchers
+	s.cacheExpiresAt = time.Now().Add(cacheTTL)
+
+	return matchers, nil
+}
+
+func (s *Store) invalidateCache() {
+	s.cacheLock.Lock()
+	defer s.cacheLock.Unlock()
+	s.cache = nil
+}
+
+// List returns all glossary terms ordered by term.
+func (s *Store) List() ([]Term, error) {
+	terms := []Term{}
+	if err := s.db.DoQuery(&terms, s.db.Builder().
+		Select("ID", "Term", "Definition", "CaseSensitive", "CreateAt", "UpdateAt", "UpdatedBy").
+		From("LLM_Glossary").
+		OrderBy("LOWER(Term)"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to get glossary terms: %w", err)
+	}
+	return terms, nil
+}
+
+func (s *Store) termExists(term, excludeID string) (bool, error) {
+	var count []int
+	query := s.db.Builder().
+		Select("COUNT(*)").
+		From("LLM_Glossary").
+		Where(sq.Expr("LOWER(Term) = LOWER(?)", term))
+	if excludeID != "" {
+		query = query.Where(sq.NotEq{"ID": excludeID})
+	}
+	if err := s.db.DoQuery(&count, query); err != nil {
+		return false, fmt.Errorf("failed to check glossary term: %w", err)
+	}
+	return len(count) > 0 && count[0] > 0, nil
+}
+
+// Create adds a new term to the glossary.
+func (s *Store) Create(term Term, userID string) (*Term, error) {
+	term.Term = strings.TrimSpace(term.Term)
+	term.Definition = strings.TrimSpace(term.Definition)
+	if err := term.IsValid(); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.termExists(term.Term, "")
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrDuplicateTerm
+	}
+
+	now := model.GetMillis()
+	term.ID = model.NewId()
+	term.CreateAt = now
+	term.UpdateAt = now
+	term.UpdatedBy = userID
+
+	if _, err := s.db.ExecBuilder(s.db.Builder().Insert("LLM_Glossary").
+		Columns("ID", "Term", "Definition", "CaseSensitive", "CreateAt", "UpdateAt", "UpdatedBy").
+		Values(term.ID, term.Term, term.Definition, term.CaseSensitive, term.CreateAt, term.UpdateAt, term.UpdatedBy)); err != nil {
+		return nil, fmt.Errorf("failed to save glossary term: %w", err)
+	}
+	s.invalidateCache()
+
+	return &term, nil
+}
+
+// Update replaces the term, definition and case sensitivity of an existing term.
+func (s *Store) Update(term Term, userID string) (*Term, error) {
+	term.Term = strings.TrimSpace(term.Term)
+	term.Definition = strings.TrimSpace(term.Definition)
+	if err := term.IsValid(); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.termExists(term.Term, term.ID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrDuplicateTerm
+	}
+
+	term.UpdateAt = model.GetMillis()
+	term.UpdatedBy = userID
+
+	result, err := s.db.ExecBuilder(s.db.Builder().Update("LLM_Glossary").
+		Set("Term", term.Term).
+		Set("Definition", term.Definition).
+		Set("CaseSensitive", term.CaseSensitive).
+		Set("UpdateAt", term.UpdateAt).
+		Set("UpdatedBy", term.UpdatedBy).
+		Where(sq.Eq{"ID": term.ID}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update glossary term: %w", err)
+	}
+	if rows, rowsErr := result.RowsAffected(); rowsErr == nil && rows == 0 {
+		return nil, ErrTermNotFound
+	}
+	s.invalidateCache()
+
+	return &term, nil
+}
+
+// Delete removes a term from the glossary.
+func (s *Store) Delete(id string) error {
+	result, err := s.db.ExecBuilder(s.db.Builder().Delete("LLM_Glossary").
+		Where(sq.Eq{"ID": id}))
+	if err != nil {
+		return fmt.Errorf("failed to delete glossary term: %w", err)
+	}
+	if rows, rowsErr := result.RowsAffected(); rowsErr == nil && rows == 0 {
+		return ErrTermNotFound
+	}
+	s.invalidateCache()
+
+	return nil
+}
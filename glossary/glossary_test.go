@@ -0,0 +1,113 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package glossary
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	terms := []Term{
+		{Term: "QBR", Definition: "Quarterly Business Review"},
+		{Term: "IT", Definition: "Infrastructure Team", CaseSensitive: true},
+		{Term: "C++", Definition: "The language"},
+		{Term: "on-call", Definition: "The support rotation"},
+	}
+	matchers := make([]matcher, 0, len(terms))
+	for _, term := range terms {
+		m, err := newMatcher(term)
+		require.NoError(t, err)
+		matchers = append(matchers, m)
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{
+			name:     "case insensitive match",
+			text:     "When is the next qbr?",
+			expected: []string{"QBR"},
+		},
+		{
+			name: "whole words only",
+			text: "The QBRs are weekly",
+		},
+		{
+			name:     "case sensitive match",
+			text:     "Ask IT about it",
+			expected: []string{"IT"},
+		},
+		{
+			name: "case sensitive term not matched in other case",
+			text: "is it done?",
+		},
+		{
+			name:     "terms with punctuation",
+			text:     "C++ and on-call",
+			expected: []string{"C++", "on-call"},
+		},
+		{
+			name: "no terms",
+			text: "nothing to see here",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var matched []string
+			for _, term := range match(matchers, tc.text) {
+				matched = append(matched, term.Term)
+			}
+			assert.Equal(t, tc.expected, matched)
+		})
+	}
+}
+
+func TestMatchLimit(t *testing.T) {
+	var matchers []matcher
+	var text []string
+	for i := 0; i < MaxMatchedTerms+5; i++ {
+		m, err := newMatcher(Term{Term: fmt.Sprintf("T%d", i), Definition: "definition"})
+		require.NoError(t, err)
+		matchers = append(matchers, m)
+		text = append(text, fmt.Sprintf("T%d", i))
+	}
+
+	matched := match(matchers, strings.Join(text, " "))
+	assert.Len(t, matched, MaxMatchedTerms)
+	assert.Equal(t, llm.GlossaryTerm{Term: "T0", Definition: "definition"}, matched[0])
+}
+
+func TestTermIsValid(t *testing.T) {
+	tests := []struct {
+		name      string
+		term      Term
+		expectErr bool
+	}{
+		{name: "valid", term: Term{Term: "QBR", Definition: "Quarterly Business Review"}},
+		{name: "missing term", term: Term{Term: " ", Definition: "definition"}, expectErr: true},
+		{name: "missing definition", term: Term{Term: "QBR"}, expectErr: true},
+		{name: "term too long", term: Term{Term: strings.Repeat("a", MaxTermLength+1), Definition: "definition"}, expectErr: true},
+		{name: "definition too long", term: Term{Term: "QBR", Definition: strings.Repeat("a", MaxDefinitionLength+1)}, expectErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.term.IsValid()
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package guardrails enforces the guardrail policy of the admins: the topics the agents refuse,
+// the disclaimers they include and the phrases they never use. The policy is compiled into the
+// system prompt of every request, and the responses posted for users are verified once they
+// finish, optionally by a checker agent. Violations are recorded in the Mattermost audit log.
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// AuditEventViolation is the name of the audit records of guardrail violations
+	AuditEventViolation = "aiGuardrailViolation"
+
+	checkTimeout = 2 * time.Minute
+	// maxCheckedMessageLength bounds the user message sent to the checker agent with the response
+	maxCheckedMessageLength = 4000
+)
+
+// AuditLogger records events in the Mattermost audit log
+type AuditLogger interface {
+	LogAuditRec(rec *model.AuditRecord)
+}
+
+// checkResult is the structured output requested from the checker agent
+type checkResult struct {
+	Topics []string `json:"topics"`
+}
+
+// Service provides the guardrail policy to the bots, verifies their responses and records the violations.
+type Service struct {
+	getPolicy func() llm.GuardrailPolicy
+	bots      *bots.MMBots
+	prompts   *llm.Prompts
+	audit     AuditLogger
+	client    mmapi.Client
+}
+
+// New creates a new guardrails service reading the policy from getPolicy.
+func New(getPolicy func() llm.GuardrailPolicy, bots *bots.MMBots, prompts *llm.Prompts, audit AuditLogger, client mmapi.Client) *Service {
+	return &Service{
+		getPolicy: getPolicy,
+		bots:      bots,
+		prompts:   prompts,
+		audit:     audit,
+		client:    client,
+	}
+}
+
+// GuardrailPolicy returns the current policy
+func (s *Service) GuardrailPolicy() llm.GuardrailPolicy {
+	return s.getPolicy()
+}
+
+// VerifyResponse asks the checker agent of the policy which forbidden topics the response discusses.
+func (s *Service) VerifyResponse(ctx context.Context, policy llm.GuardrailPolicy, request llm.CompletionRequest, response string) ([]llm.GuardrailViolation, error) {
+	violations, err := s.verifyResponse(ctx, policy, request, response)
+	if err != nil {
+		s.client.LogWarn("Failed to verify response against the guardrail policy", "checker_bot", policy.CheckerBot, "error", err)
+	}
+	return violations, err
+}
+
+func (s *Service) verifyResponse(ctx context.Context, policy llm.GuardrailPolicy, request llm.CompletionRequest, response string) ([]llm.GuardrailViolation, error) {
+	checker := s.bots.GetBotByUsername(policy.CheckerBot)
+	if checker == nil {
+		return nil, fmt.Errorf("checker agent %s not found", policy.CheckerBot)
+	}
+
+	var topics []string
+	for _, topic := range policy.ForbiddenTopics {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+
+	llmContext := llm.NewContext()
+	llmContext.BotName = checker.GetConfig().DisplayName
+	llmContext.BotUsername = checker.GetConfig().Name
+	llmContext.Parameters = map[string]any{
+		"ForbiddenTopics": topics,
+	}
+
+	systemPrompt, err := s.prompts.Format(prompts.PromptGuardrailCheckSystem, llmContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format prompt: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	result, err := checker.LLM().ChatCompletionNoStream(ctx, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: fmt.Sprintf("User message:\n%s\n\nAssistant response:\n%s", lastUserMessage(request), response)},
+		},
+		Context: llmContext,
+	},
+		llm.WithMaxGeneratedTokens(500),
+		llm.WithReasoningDisabled(),
+		llm.WithToolsDisabled(),
+		llm.WithJSONOutput[checkResult](),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check response: %w", err)
+	}
+
+	var checked checkResult
+	if err := json.Unmarshal([]byte(result), &checked); err != nil {
+		return nil, fmt.Errorf("failed to parse check result: %w", err)
+	}
+
+	// Only the topics of the policy count, the checker may paraphrase or invent others
+	var violations []llm.GuardrailViolation
+	for _, topic := range checked.Topics {
+		index := slices.IndexFunc(topics, func(forbidden string) bool {
+			return strings.EqualFold(forbidden, strings.TrimSpace(topic))
+		})
+		if index >= 0 {
+			violations = append(violations, llm.GuardrailViolation{Rule: llm.GuardrailRuleForbiddenTopic, Detail: topics[index]})
+		}
+	}
+
+	return violations, nil
+}
+
+// lastUserMessage returns the message the response answers, truncated to maxCheckedMessageLength
+func lastUserMessage(request llm.CompletionRequest) string {
+	for i := len(request.Posts) - 1; i >= 0; i-- {
+		if request.Posts[i].Role != llm.PostRoleUser {
+			continue
+		}
+		message := request.Posts[i].Message
+		if runes := []rune(message); len(runes) > maxCheckedMessageLength {
+			message = string(runes[:maxCheckedMessageLength]) + "..."
+		}
+		return message
+	}
+	return ""
+}
+
+// ReportViolations records the violations of a response in the audit log.
+func (s *Service) ReportViolations(botName string, request llm.CompletionRequest, violations []llm.GuardrailViolation) {
+	record := &model.AuditRecord{
+		EventName: AuditEventViolation,
+		Status:    model.AuditStatusFail,
+		EventData: model.AuditEventData{
+			Parameters: map[string]any{
+				"bot":        botName,
+				"violations": violations,
+			},
+			ObjectType: "ai_response",
+		},
+		Meta: map[string]any{},
+	}
+
+	var userID, channelID string
+	if request.Context != nil {
+		if request.Context.RequestingUser != nil {
+			userID = request.Context.RequestingUser.Id
+			record.Actor.UserId = userID
+		}
+		if request.Context.Channel != nil {
+			channelID = request.Context.Channel.Id
+			record.EventData.Parameters["channel_id"] = channelID
+		}
+	}
+
+	s.audit.LogAuditRec(record)
+
+	rules := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		rules = append(rules, violation.Rule)
+	}
+	s.client.LogWarn("Response violated the guardrail policy", "bot_name", botName, "user_id", userID, "channel_id", channelID, "rules", strings.Join(rules, ","))
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package guardrails
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingAuditLogger struct {
+	records []*model.AuditRecord
+}
+
+func (l *recordingAuditLogger) LogAuditRec(rec *model.AuditRecord) {
+	l.records = append(l.records, rec)
+}
+
+func TestReportViolations(t *testing.T) {
+	client := mocks.NewMockClient(t)
+	client.On("LogWarn", "Response violated the guardrail policy", mock.Anything).Return()
+	audit := &recordingAuditLogger{}
+	s := New(nil, nil, nil, audit, client)
+
+	llmContext := llm.NewContext()
+	llmContext.RequestingUser = &model.User{Id: "user1"}
+	llmContext.Channel = &model.Channel{Id: "channel1"}
+	violations := []llm.GuardrailViolation{{Rule: llm.GuardrailRuleBannedPhrase, Detail: "trust me"}}
+	s.ReportViolations("bot", llm.CompletionRequest{Context: llmContext}, violations)
+
+	require.Len(t, audit.records, 1)
+	record := audit.records[0]
+	assert.Equal(t, AuditEventViolation, record.EventName)
+	assert.Equal(t, model.AuditStatusFail, record.Status)
+	assert.Equal(t, "user1", record.Actor.UserId)
+	assert.Equal(t, "channel1", record.EventData.Parameters["channel_id"])
+	assert.Equal(t, violations, record.EventData.Parameters["violations"])
+}
+
+func TestLastUserMessage(t *testing.T) {
+	assert.Empty(t, lastUserMessage(llm.CompletionRequest{}))
+	assert.Equal(t, "second", lastUserMessage(llm.CompletionRequest{Posts: []llm.Post{
+		{Role: llm.PostRoleSystem, Message: "system"},
+		{Role: llm.PostRoleUser, Message: "first"},
+		{Role: llm.PostRoleBot, Message: "answer"},
+		{Role: llm.PostRoleUser, Message: "second"},
+		{Role: llm.PostRoleBot, Message: "response"},
+	}}))
+
+	long := lastUserMessage(llm.CompletionRequest{Posts: []llm.Post{{Role: llm.PostRoleUser, Message: strings.Repeat("a", maxCheckedMessageLength+10)}}})
+	assert.Len(t, long, maxCheckedMessageLength+3)
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package imagetext extracts the content of attached images for agents whose model can't read
+// images. A vision capable agent transcribes the text of each image and describes its drawings,
+// and the result is added to the message like the content of other attachments.
+package imagetext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// CacheKeyPrefix stores the extracted content by file, as the same images are sent again
+	// with every response in their thread
+	CacheKeyPrefix = "image_text_v1_"
+	// maxImageSize is the largest image sent for extraction
+	maxImageSize = 20 * 1024 * 1024
+	// maxTextLength is the largest number of characters kept of the extracted content
+	maxTextLength     = 10000
+	maxOutputTokens   = 2000
+	extractionTimeout = 2 * time.Minute
+)
+
+// ErrNotConfigured is returned when the agent has no image text agent, or it can't read images.
+var ErrNotConfigured = errors.New("no image text agent is configured")
+
+// BotSource returns the agents extracting the content of images
+type BotSource interface {
+	GetBotByUsername(botUsername string) *bots.Bot
+}
+
+type cachedText struct {
+	Text string `json:"text"`
+}
+
+// Extractor extracts the content of images with the image text agent of each agent.
+type Extractor struct {
+	client  mmapi.Client
+	bots    BotSource
+	prompts *llm.Prompts
+}
+
+// New creates a new image text extractor
+func New(client mmapi.Client, bots BotSource, prompts *llm.Prompts) *Extractor {
+	return &Extractor{
+		client:  client,
+		bots:    bots,
+		prompts: prompts,
+	}
+}
+
+// Enabled reports whether the agent has an image text agent configured.
+func (e *Extractor) Enabled(bot *bots.Bot) bool {
+	return bot.GetConfig().ImageTextBot != ""
+}
+
+// ExtractText returns the text and the description of the image, extracted by the image text
+// agent of the bot. Results are cached, each image is only sent once.
+func (e *Extractor) ExtractText(ctx context.Context, bot *bots.Bot, fileInfo *model.FileInfo) (string, error) {
+	return e.extract(ctx, e.bots.GetBotByUsername(bot.GetConfig().ImageTextBot), fileInfo)
+}
+
+// Describe returns the content of the image like ExtractText, reading it with the agent itself
+// when it has vision, and with its image text agent otherwise.
+func (e *Extractor) Describe(ctx context.Context, bot *bots.Bot, fileInfo *model.FileInfo) (string, error) {
+	if !bot.GetConfig().EnableVision {
+		return e.ExtractText(ctx, bot, fileInfo)
+	}
+	return e.extract(ctx, bot, fileInfo)
+}
+
+// extract returns the cached content of the image, or extracts it with the extractor agent.
+func (e *Extractor) extract(ctx context.Context, extractor *bots.Bot, fileInfo *model.FileInfo) (string, error) {
+	var cached *cachedText
+	if err := e.client.KVGet(CacheKeyPrefix+fileInfo.Id, &cached); err != nil {
+		e.client.LogWarn("Failed to get cached image text", "file_id", fileInfo.Id, "error", err)
+	}
+	if cached != nil {
+		return cached.Text, nil
+	}
+
+	if extractor == nil || !extractor.GetConfig().EnableVision {
+		return "", ErrNotConfigured
+	}
+	if fileInfo.Size > maxImageSize {
+		return "", fmt.Errorf("image is larger than %d bytes", maxImageSize)
+	}
+
+	file, err := e.client.GetFile(fileInfo.Id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get image: %w", err)
+	}
+	defer file.Close()
+
+	systemPrompt, err := e.prompts.Format(prompts.PromptImageTextSystem, llm.NewContext())
+	if err != nil {
+		return "", fmt.Errorf("failed to format prompt: %w", err)
+	}
+
+	llmContext := llm.NewContext()
+	llmContext.BotName = extractor.GetConfig().DisplayName
+	llmContext.BotUsername = extractor.GetConfig().Name
+
+	ctx, cancel := context.WithTimeout(ctx, extractionTimeout)
+	defer cancel()
+
+	result, err := extractor.LLM().ChatCompletionNoStream(ctx, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{
+				Role:    llm.PostRoleUser,
+				Message: fmt.Sprintf("Extract the content of the attached image %s.", fileInfo.Name),
+				Files:   []llm.File{{Reader: file, MimeType: fileInfo.MimeType, Size: fileInfo.Size}},
+			},
+		},
+		Context: llmContext,
+	},
+		llm.WithMaxGeneratedTokens(maxOutputTokens),
+		llm.WithReasoningDisabled(),
+		llm.WithToolsDisabled(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract image text: %w", err)
+	}
+
+	text := strings.TrimSpace(result)
+	if runes := []rune(text); len(runes) > maxTextLength {
+		text = string(runes[:maxTextLength]) + "\n... (content truncated due to size limit)"
+	}
+
+	if err := e.client.KVSet(CacheKeyPrefix+fileInfo.Id, cachedText{Text: text}); err != nil {
+		e.client.LogWarn("Failed to cache image text", "file_id", fileInfo.Id, "error", err)
+	}
+
+	return text, nil
+}
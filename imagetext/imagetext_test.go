@@ -0,0 +1,115 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package imagetext
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	llmmocks "github.com/mattermost/mattermost-plugin-ai/llm/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient keeps the KV store in memory and serves every file.
+type fakeClient struct {
+	mmapi.Client
+	kv map[string][]byte
+}
+
+func (f *fakeClient) KVGet(key string, value interface{}) error {
+	data, ok := f.kv[key]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (f *fakeClient) KVSet(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	f.kv[key] = data
+	return nil
+}
+
+func (f *fakeClient) GetFile(string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("image")), nil
+}
+
+func (f *fakeClient) LogWarn(string, ...interface{}) {}
+
+type fakeBots struct {
+	bots map[string]*bots.Bot
+}
+
+func (f *fakeBots) GetBotByUsername(botUsername string) *bots.Bot {
+	return f.bots[botUsername]
+}
+
+func TestExtractText(t *testing.T) {
+	fileInfo := &model.FileInfo{Id: "file1", Name: "whiteboard.jpg", MimeType: "image/jpeg", Size: 5}
+
+	tests := []struct {
+		name          string
+		imageTextBot  string
+		enableVision  bool
+		expectedError error
+	}{
+		{name: "extracts the text", imageTextBot: "vision", enableVision: true},
+		{name: "image text agent without vision", imageTextBot: "vision", expectedError: ErrNotConfigured},
+		{name: "unknown image text agent", imageTextBot: "missing", enableVision: true, expectedError: ErrNotConfigured},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			promptsObj, err := llm.NewPrompts(prompts.PromptsFolder)
+			require.NoError(t, err)
+
+			languageModel := llmmocks.NewMockLanguageModel(t)
+			visionBot := bots.NewBot(llm.BotConfig{Name: "vision", EnableVision: test.enableVision}, llm.ServiceConfig{}, &model.Bot{UserId: "vision1"}, languageModel)
+			bot := bots.NewBot(llm.BotConfig{Name: "ai", ImageTextBot: test.imageTextBot}, llm.ServiceConfig{}, &model.Bot{UserId: "bot1"}, nil)
+
+			client := &fakeClient{kv: map[string][]byte{}}
+			extractor := New(client, &fakeBots{bots: map[string]*bots.Bot{"vision": visionBot}}, promptsObj)
+			require.True(t, extractor.Enabled(bot))
+
+			if test.expectedError != nil {
+				_, err = extractor.ExtractText(context.Background(), bot, fileInfo)
+				require.ErrorIs(t, err, test.expectedError)
+				return
+			}
+
+			languageModel.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, request llm.CompletionRequest, _ ...llm.LanguageModelOption) (string, error) {
+				require.Len(t, request.Posts, 2)
+				require.Len(t, request.Posts[1].Files, 1)
+				require.Equal(t, "image/jpeg", request.Posts[1].Files[0].MimeType)
+				return "  Q3 roadmap: ship search  ", nil
+			}).Once()
+
+			text, err := extractor.ExtractText(context.Background(), bot, fileInfo)
+			require.NoError(t, err)
+			require.Equal(t, "Q3 roadmap: ship search", text)
+
+			// The second extraction of the same image is served from the cache
+			text, err = extractor.ExtractText(context.Background(), bot, fileInfo)
+			require.NoError(t, err)
+			require.Equal(t, "Q3 roadmap: ship search", text)
+		})
+	}
+
+	t.Run("not enabled without image text agent", func(t *testing.T) {
+		extractor := New(&fakeClient{kv: map[string][]byte{}}, &fakeBots{}, nil)
+		require.False(t, extractor.Enabled(bots.NewBot(llm.BotConfig{}, llm.ServiceConfig{}, &model.Bot{UserId: "bot1"}, nil)))
+	})
+}
@@ -0,0 +1,637 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package incidents runs the incident copilot in the channels of Playbooks runs. An agent keeps
+// one summary post of the incident up to date, tracking the questions still open, and drafts
+// the skeleton of the postmortem once the run is finished.
+//
+// Copilots are stored in the plugin KV store. Only one node of the cluster polls them at a time,
+// so the summary posts are updated by one node.
+package incidents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	// DefaultUpdateIntervalMinutes is how often the summary is updated when neither the
+	// configuration nor the user starting the copilot set an interval
+	DefaultUpdateIntervalMinutes = 15
+	// MinUpdateIntervalMinutes and MaxUpdateIntervalMinutes bound the update interval
+	MinUpdateIntervalMinutes = 5
+	MaxUpdateIntervalMinutes = 24 * 60
+	// PollInterval is how often the copilots are checked for due updates and finished runs
+	PollInterval = time.Minute
+	// CopilotProp is the post prop marking the posts of the copilot, set to the kind of post
+	CopilotProp        = "incident_copilot"
+	PostKindSummary    = "summary"
+	PostKindPostmortem = "postmortem"
+
+	copilotKeyPrefix = "incident_copilot_v1_"
+	channelsKey      = "incident_copilot_channels_v1"
+	// maxPosts bounds the number of channel posts, the most recent ones, given to the model
+	maxPosts = 300
+	// generationTimeout bounds each summary or postmortem generation
+	generationTimeout = 5 * time.Minute
+)
+
+var (
+	// ErrNotEnabled is returned when the incident copilot is disabled in the configuration.
+	ErrNotEnabled = errors.New("incident copilot is not enabled")
+	// ErrNotRunning is returned when the channel has no copilot.
+	ErrNotRunning = errors.New("incident copilot is not running in this channel")
+	// ErrRunFinished is returned when starting a copilot for a run that is already finished.
+	ErrRunFinished = errors.New("playbook run is already finished")
+	// ErrInvalidInterval is returned when the update interval is out of bounds.
+	ErrInvalidInterval = fmt.Errorf("update interval must be between %d and %d minutes", MinUpdateIntervalMinutes, MaxUpdateIntervalMinutes)
+)
+
+// Copilot is the persisted state of the copilot of a channel.
+type Copilot struct {
+	ChannelID string `json:"channel_id"`
+	RunID     string `json:"run_id"`
+	BotID     string `json:"bot_id"`
+	// UserID is the user who started the copilot, whose permissions are used to read the run and the channel
+	UserID          string `json:"user_id"`
+	SummaryPostID   string `json:"summary_post_id"`
+	Summary         string `json:"summary"`
+	IntervalMinutes int    `json:"interval_minutes"`
+	CreateAt        int64  `json:"create_at"`
+	// UpdateAt is when the channel was last checked for new messages to summarize
+	UpdateAt int64 `json:"update_at"`
+}
+
+// RunSource reads Playbooks runs.
+type RunSource interface {
+	RunForChannel(userID, channelID string) (*Run, error)
+	GetRun(userID, runID string) (*Run, error)
+}
+
+// BotSource returns the agents the copilots run as and the policies of their channels.
+type BotSource interface {
+	GetBotByID(botID string) *bots.Bot
+	CheckUsageRestrictionsForChannel(bot *bots.Bot, channel *model.Channel) error
+	ChannelPolicy(channel *model.Channel) (exclusions.ChannelPolicy, error)
+}
+
+// ContextBuilder builds the LLM context of the generations.
+type ContextBuilder interface {
+	BuildLLMContextUserRequest(bot *bots.Bot, requestingUser *model.User, channel *model.Channel, opts ...llm.ContextOption) *llm.Context
+}
+
+// Service starts, stops and updates the incident copilots.
+type Service struct {
+	client         mmapi.Client
+	playbooks      RunSource
+	bots           BotSource
+	contextBuilder ContextBuilder
+	prompts        *llm.Prompts
+	i18n           *i18n.Bundle
+	mutexAPI       cluster.MutexPluginAPI
+	getConfig      func() config.IncidentCopilotConfig
+	license        enterprise.FeatureChecker
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+// New creates a new incident copilot service. Call Start to begin polling.
+func New(
+	client mmapi.Client,
+	playbooks RunSource,
+	bots BotSource,
+	contextBuilder ContextBuilder,
+	prompts *llm.Prompts,
+	i18nBundle *i18n.Bundle,
+	mutexAPI cluster.MutexPluginAPI,
+	getConfig func() config.IncidentCopilotConfig,
+) *Service {
+	return &Service{
+		client:         client,
+		playbooks:      playbooks,
+		bots:           bots,
+		contextBuilder: contextBuilder,
+		prompts:        prompts,
+		i18n:           i18nBundle,
+		mutexAPI:       mutexAPI,
+		getConfig:      getConfig,
+	}
+}
+
+// SetLicenseChecker pauses the scheduled incident copilots while the license does not include them.
+func (s *Service) SetLicenseChecker(license enterprise.FeatureChecker) {
+	s.license = license
+}
+
+// Start polls the copilots every interval.
+func (s *Service) Start(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.Poll()
+			}
+		}
+	}()
+}
+
+// Stop stops polling.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// Get returns the copilot of the channel.
+func (s *Service) Get(channelID string) (*Copilot, error) {
+	var copilot *Copilot
+	if err := s.client.KVGet(copilotKeyPrefix+channelID, &copilot); err != nil {
+		return nil, fmt.Errorf("failed to get incident copilot: %w", err)
+	}
+	if copilot == nil {
+		return nil, ErrNotRunning
+	}
+	return copilot, nil
+}
+
+// StartCopilot starts the copilot of the channel of a run, posting the summary post the bot keeps
+// up to date. The summary itself is written by the first Refresh. Starting a copilot that is
+// already running only changes its update interval, which defaults to the configured one when 0.
+func (s *Service) StartCopilot(userID, channelID string, bot *bots.Bot, intervalMinutes int) (*Copilot, error) {
+	cfg := s.getConfig()
+	if !cfg.Enabled {
+		return nil, ErrNotEnabled
+	}
+	if intervalMinutes == 0 {
+		intervalMinutes = cfg.UpdateIntervalMinutes
+	}
+	if intervalMinutes == 0 {
+		intervalMinutes = DefaultUpdateIntervalMinutes
+	}
+	if intervalMinutes < MinUpdateIntervalMinutes || intervalMinutes > MaxUpdateIntervalMinutes {
+		return nil, ErrInvalidInterval
+	}
+
+	if copilot, err := s.Get(channelID); err == nil {
+		copilot.IntervalMinutes = intervalMinutes
+		if err := s.save(copilot); err != nil {
+			return nil, err
+		}
+		return copilot, nil
+	} else if !errors.Is(err, ErrNotRunning) {
+		return nil, err
+	}
+
+	run, err := s.playbooks.RunForChannel(userID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if run.CurrentStatus == RunStatusFinished {
+		return nil, ErrRunFinished
+	}
+
+	copilot := &Copilot{
+		ChannelID:       channelID,
+		RunID:           run.ID,
+		BotID:           bot.GetMMBot().UserId,
+		UserID:          userID,
+		IntervalMinutes: intervalMinutes,
+		CreateAt:        model.GetMillis(),
+	}
+
+	T, err := s.localizer(copilot)
+	if err != nil {
+		return nil, err
+	}
+	post := &model.Post{
+		UserId:    copilot.BotID,
+		ChannelId: channelID,
+		Message:   summaryHeader(T, run, copilot) + T("agents.incident_copilot.preparing", "Preparing the incident summary..."),
+	}
+	post.AddProp(CopilotProp, PostKindSummary)
+	if err := s.client.CreatePost(post); err != nil {
+		return nil, fmt.Errorf("failed to create summary post: %w", err)
+	}
+	copilot.SummaryPostID = post.Id
+
+	if err := s.save(copilot); err != nil {
+		return nil, err
+	}
+	if err := s.updateChannels(func(channelIDs []string) []string {
+		if slices.Contains(channelIDs, channelID) {
+			return channelIDs
+		}
+		return append(channelIDs, channelID)
+	}); err != nil {
+		return nil, err
+	}
+
+	return copilot, nil
+}
+
+// StopCopilot stops the copilot of the channel. Its posts are left in the channel.
+func (s *Service) StopCopilot(channelID string) error {
+	if _, err := s.Get(channelID); err != nil {
+		return err
+	}
+	return s.remove(channelID)
+}
+
+// Refresh updates the summary of the channel now, or drafts the postmortem if the run is finished.
+func (s *Service) Refresh(ctx context.Context, channelID string) error {
+	copilot, err := s.Get(channelID)
+	if err != nil {
+		return err
+	}
+	return s.process(ctx, copilot, true)
+}
+
+// Poll updates the summaries that are due and finishes the copilots of finished runs. Only one
+// node of the cluster polls at a time.
+func (s *Service) Poll() {
+	if !s.getConfig().Enabled {
+		return
+	}
+	if s.license != nil {
+		if err := s.license.CheckFeature(enterprise.FeatureIncidentCopilot); err != nil {
+			s.client.LogDebug("Skipping scheduled incident copilots", "error", err)
+			return
+		}
+	}
+
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_incident_copilot_poll")
+	if err != nil {
+		s.client.LogError("Failed to create incident copilot poll mutex", "error", err)
+		return
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	var channelIDs []string
+	if err := s.client.KVGet(channelsKey, &channelIDs); err != nil {
+		s.client.LogError("Failed to get incident copilot channels", "error", err)
+		return
+	}
+
+	for _, channelID := range channelIDs {
+		copilot, err := s.Get(channelID)
+		if errors.Is(err, ErrNotRunning) {
+			continue
+		}
+		if err == nil {
+			err = s.checkChannel(copilot)
+			if errors.Is(err, bots.ErrUsageRestriction) {
+				// The channel was excluded or the agent restricted since the copilot was started
+				s.client.LogDebug("Skipping incident copilot", "channel_id", channelID, "error", err)
+				continue
+			}
+		}
+		if err == nil {
+			err = s.process(context.Background(), copilot, false)
+		}
+		if err != nil && !errors.Is(err, ErrNotRunning) {
+			s.client.LogWarn("Failed to update incident copilot", "channel_id", channelID, "error", err)
+		}
+	}
+}
+
+// checkChannel checks the agent of the copilot can still be used in its channel.
+func (s *Service) checkChannel(copilot *Copilot) error {
+	bot := s.bots.GetBotByID(copilot.BotID)
+	if bot == nil {
+		return fmt.Errorf("agent %s not found", copilot.BotID)
+	}
+	channel, err := s.client.GetChannel(copilot.ChannelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	return s.bots.CheckUsageRestrictionsForChannel(bot, channel)
+}
+
+// process finishes the copilot if its run is finished, otherwise updates the summary when forced
+// or due and there are new messages.
+func (s *Service) process(ctx context.Context, copilot *Copilot, force bool) error {
+	run, err := s.playbooks.GetRun(copilot.UserID, copilot.RunID)
+	if errors.Is(err, ErrNoRun) {
+		// The run was deleted or the user lost access to it
+		return s.remove(copilot.ChannelID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if run.CurrentStatus == RunStatusFinished {
+		return s.finish(ctx, copilot, run)
+	}
+
+	now := model.GetMillis()
+	if !force && now < copilot.UpdateAt+int64(copilot.IntervalMinutes)*time.Minute.Milliseconds() {
+		return nil
+	}
+
+	return s.updateSummary(ctx, copilot, run, force)
+}
+
+func (s *Service) updateSummary(ctx context.Context, copilot *Copilot, run *Run, force bool) error {
+	checkedAt := model.GetMillis()
+	threadData, err := s.channelPosts(copilot, run)
+	if err != nil {
+		return err
+	}
+
+	hasNewPosts := slices.ContainsFunc(threadData.Posts, func(post *model.Post) bool {
+		return post.CreateAt > copilot.UpdateAt
+	})
+	if !force && !hasNewPosts && copilot.Summary != "" {
+		copilot.UpdateAt = checkedAt
+		return s.saveIfRunning(copilot)
+	}
+
+	summary, err := s.generate(ctx, copilot, run, prompts.PromptIncidentSummarySystem, map[string]any{
+		"RunName":         run.Name,
+		"RunSummary":      run.Summary,
+		"Timeline":        formatTimeline(run),
+		"PreviousSummary": copilot.Summary,
+		"Thread":          formatPosts(threadData),
+	})
+	if err != nil {
+		return err
+	}
+
+	T, err := s.localizer(copilot)
+	if err != nil {
+		return err
+	}
+	copilot.Summary = summary
+	copilot.UpdateAt = checkedAt
+	if err := s.upsertSummaryPost(copilot, summaryHeader(T, run, copilot)+summary); err != nil {
+		return err
+	}
+
+	return s.saveIfRunning(copilot)
+}
+
+// finish writes the final summary and the postmortem draft, then stops the copilot.
+func (s *Service) finish(ctx context.Context, copilot *Copilot, run *Run) error {
+	if err := s.updateSummary(ctx, copilot, run, false); err != nil {
+		return fmt.Errorf("failed to write the final summary: %w", err)
+	}
+
+	threadData, err := s.channelPosts(copilot, run)
+	if err != nil {
+		return err
+	}
+	draft, err := s.generate(ctx, copilot, run, prompts.PromptIncidentPostmortemSystem, map[string]any{
+		"RunName":    run.Name,
+		"RunSummary": run.Summary,
+		"Timeline":   formatTimeline(run),
+		"Summary":    copilot.Summary,
+		"Thread":     formatPosts(threadData),
+	})
+	if err != nil {
+		return err
+	}
+
+	T, err := s.localizer(copilot)
+	if err != nil {
+		return err
+	}
+	post := &model.Post{
+		UserId:    copilot.BotID,
+		ChannelId: copilot.ChannelID,
+		Message: T("agents.incident_copilot.postmortem_header", "#### Postmortem draft: %s\n_Drafted from the incident channel when the run was finished. Review and complete it before sharing._\n\n", run.Name) +
+			draft,
+	}
+	post.AddProp(CopilotProp, PostKindPostmortem)
+	if err := s.client.CreatePost(post); err != nil {
+		return fmt.Errorf("failed to create postmortem post: %w", err)
+	}
+
+	return s.remove(copilot.ChannelID)
+}
+
+// generate formats the system prompt with the given parameters and returns the completion of
+// the bot of the copilot, generated with the permissions of the user who started it.
+func (s *Service) generate(ctx context.Context, copilot *Copilot, run *Run, promptName string, parameters map[string]any) (string, error) {
+	bot := s.bots.GetBotByID(copilot.BotID)
+	if bot == nil {
+		return "", fmt.Errorf("agent %s not found", copilot.BotID)
+	}
+	user, err := s.client.GetUser(copilot.UserID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	channel, err := s.client.GetChannel(copilot.ChannelID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get channel: %w", err)
+	}
+
+	llmContext := s.contextBuilder.BuildLLMContextUserRequest(bot, user, channel)
+	llmContext.Priority = llm.PriorityBackground
+	llmContext.Parameters = parameters
+
+	systemPrompt, err := s.prompts.Format(promptName, llmContext)
+	if err != nil {
+		return "", fmt.Errorf("failed to format system prompt: %w", err)
+	}
+	userPrompt, err := s.prompts.Format(prompts.PromptThreadUser, llmContext)
+	if err != nil {
+		return "", fmt.Errorf("failed to format user prompt: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, generationTimeout)
+	defer cancel()
+
+	result, err := bot.LLM().ChatCompletionNoStream(ctx, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: userPrompt},
+		},
+		Context: llmContext,
+	}, llm.WithToolsDisabled())
+	if err != nil {
+		return "", fmt.Errorf("failed to generate for run %s: %w", run.ID, err)
+	}
+
+	return strings.TrimSpace(result), nil
+}
+
+// channelPosts returns the most recent messages posted in the channel since the run started,
+// leaving out the posts of the copilot, system messages and the posts the channel policy excludes.
+func (s *Service) channelPosts(copilot *Copilot, run *Run) (*mmapi.ThreadData, error) {
+	channel, err := s.client.GetChannel(copilot.ChannelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel: %w", err)
+	}
+	policy, err := s.bots.ChannelPolicy(channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel policy: %w", err)
+	}
+
+	posts, err := s.client.GetPostsSince(copilot.ChannelID, run.CreateAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel posts: %w", err)
+	}
+
+	messages := model.NewPostList()
+	for _, post := range posts.Posts {
+		if post.DeleteAt != 0 || post.UserId == copilot.BotID || post.IsSystemMessage() {
+			continue
+		}
+		messages.AddPost(post)
+		messages.AddOrder(post.Id)
+	}
+
+	threadData, err := mmapi.GetMetadataForPosts(s.client, messages)
+	if err != nil {
+		return nil, err
+	}
+	threadData.Posts = slices.DeleteFunc(threadData.Posts, func(post *model.Post) bool {
+		return policy.ExcludesPost(post, threadData.UsersByID[post.UserId])
+	})
+	if len(threadData.Posts) > maxPosts {
+		threadData.Posts = threadData.Posts[len(threadData.Posts)-maxPosts:]
+	}
+
+	return threadData, nil
+}
+
+// upsertSummaryPost edits the summary post, posting a new one if it was deleted.
+func (s *Service) upsertSummaryPost(copilot *Copilot, message string) error {
+	post, err := s.client.GetPost(copilot.SummaryPostID)
+	if err == nil && post.DeleteAt == 0 {
+		post.Message = message
+		if err := s.client.UpdatePost(post); err != nil {
+			return fmt.Errorf("failed to update summary post: %w", err)
+		}
+		return nil
+	}
+
+	post = &model.Post{
+		UserId:    copilot.BotID,
+		ChannelId: copilot.ChannelID,
+		Message:   message,
+	}
+	post.AddProp(CopilotProp, PostKindSummary)
+	if err := s.client.CreatePost(post); err != nil {
+		return fmt.Errorf("failed to create summary post: %w", err)
+	}
+	copilot.SummaryPostID = post.Id
+	return nil
+}
+
+func (s *Service) localizer(copilot *Copilot) (i18n.TranslationFunc, error) {
+	user, err := s.client.GetUser(copilot.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return i18n.LocalizerFunc(s.i18n, user.Locale), nil
+}
+
+func summaryHeader(T i18n.TranslationFunc, run *Run, copilot *Copilot) string {
+	return T("agents.incident_copilot.summary_header", "#### Incident summary: %s\n_Kept up to date by the incident copilot every %d minutes while there is new activity._\n\n", run.Name, copilot.IntervalMinutes)
+}
+
+func (s *Service) save(copilot *Copilot) error {
+	if err := s.client.KVSet(copilotKeyPrefix+copilot.ChannelID, copilot); err != nil {
+		return fmt.Errorf("failed to save incident copilot: %w", err)
+	}
+	return nil
+}
+
+// saveIfRunning saves the copilot unless it was stopped while its summary was generated.
+func (s *Service) saveIfRunning(copilot *Copilot) error {
+	if _, err := s.Get(copilot.ChannelID); err != nil {
+		return err
+	}
+	return s.save(copilot)
+}
+
+func (s *Service) remove(channelID string) error {
+	if err := s.client.KVDelete(copilotKeyPrefix + channelID); err != nil {
+		return fmt.Errorf("failed to delete incident copilot: %w", err)
+	}
+	return s.updateChannels(func(channelIDs []string) []string {
+		return slices.DeleteFunc(channelIDs, func(id string) bool { return id == channelID })
+	})
+}
+
+// updateChannels updates the list of channels with a copilot, which the copilots are polled from.
+func (s *Service) updateChannels(update func(channelIDs []string) []string) error {
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_incident_copilot_channels")
+	if err != nil {
+		return fmt.Errorf("failed to create incident copilot channels mutex: %w", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	var channelIDs []string
+	if err := s.client.KVGet(channelsKey, &channelIDs); err != nil {
+		return fmt.Errorf("failed to get incident copilot channels: %w", err)
+	}
+	if err := s.client.KVSet(channelsKey, update(channelIDs)); err != nil {
+		return fmt.Errorf("failed to save incident copilot channels: %w", err)
+	}
+	return nil
+}
+
+// formatPosts formats the posts with their time, which the summary and postmortem timelines are built from.
+func formatPosts(threadData *mmapi.ThreadData) string {
+	var result strings.Builder
+	for _, post := range threadData.Posts {
+		username := post.UserId
+		if user, ok := threadData.UsersByID[post.UserId]; ok && user != nil {
+			username = user.Username
+		}
+		fmt.Fprintf(&result, "[%s] %s: %s\n\n", formatTime(post.CreateAt), username, format.PostBody(post))
+	}
+	return result.String()
+}
+
+func formatTimeline(run *Run) string {
+	var result strings.Builder
+	for _, event := range run.TimelineEvents {
+		if event.Summary == "" {
+			continue
+		}
+		fmt.Fprintf(&result, "- [%s] %s\n", formatTime(event.EventAt), event.Summary)
+	}
+	return result.String()
+}
+
+func formatTime(millis int64) string {
+	return time.UnixMilli(millis).UTC().Format("2006-01-02 15:04 UTC")
+}
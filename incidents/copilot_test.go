@@ -0,0 +1,335 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package incidents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	llmmocks "github.com/mattermost/mattermost-plugin-ai/llm/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mmapitest"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient keeps the KV store and the posts of a channel in memory.
+type fakeClient struct {
+	mmapi.Client
+	kv       map[string][]byte
+	posts    map[string]*model.Post
+	warnings []string
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{kv: map[string][]byte{}, posts: map[string]*model.Post{}}
+}
+
+func (f *fakeClient) KVGet(key string, value interface{}) error {
+	data, ok := f.kv[key]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (f *fakeClient) KVSet(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	f.kv[key] = data
+	return nil
+}
+
+func (f *fakeClient) KVDelete(key string) error {
+	delete(f.kv, key)
+	return nil
+}
+
+func (f *fakeClient) GetUser(userID string) (*model.User, error) {
+	user := &model.User{Id: userID, Username: "user-" + userID, Locale: "en", Roles: model.SystemUserRoleId}
+	if strings.HasPrefix(userID, "guest") {
+		user.Roles = model.SystemGuestRoleId
+	}
+	return user, nil
+}
+
+func (f *fakeClient) GetChannel(channelID string) (*model.Channel, error) {
+	return &model.Channel{Id: channelID}, nil
+}
+
+func (f *fakeClient) GetPost(postID string) (*model.Post, error) {
+	post, ok := f.posts[postID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return post.Clone(), nil
+}
+
+func (f *fakeClient) CreatePost(post *model.Post) error {
+	post.Id = model.NewId()
+	post.CreateAt = model.GetMillis()
+	f.posts[post.Id] = post.Clone()
+	return nil
+}
+
+func (f *fakeClient) UpdatePost(post *model.Post) error {
+	f.posts[post.Id] = post.Clone()
+	return nil
+}
+
+func (f *fakeClient) GetPostsSince(_ string, since int64) (*model.PostList, error) {
+	list := model.NewPostList()
+	for _, post := range f.posts {
+		if post.CreateAt >= since {
+			list.AddPost(post.Clone())
+			list.AddOrder(post.Id)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeClient) LogError(string, ...interface{}) {}
+
+func (f *fakeClient) LogDebug(string, ...interface{}) {}
+
+func (f *fakeClient) LogWarn(msg string, _ ...interface{}) {
+	f.warnings = append(f.warnings, msg)
+}
+
+func (f *fakeClient) postsOfKind(kind string) []*model.Post {
+	var posts []*model.Post
+	for _, post := range f.posts {
+		if post.GetProp(CopilotProp) == kind {
+			posts = append(posts, post)
+		}
+	}
+	return posts
+}
+
+type fakeRuns struct {
+	run *Run
+}
+
+func (f *fakeRuns) RunForChannel(string, string) (*Run, error) {
+	if f.run == nil {
+		return nil, ErrNoRun
+	}
+	return f.run, nil
+}
+
+func (f *fakeRuns) GetRun(string, string) (*Run, error) {
+	return f.RunForChannel("", "")
+}
+
+type fakeBots struct {
+	bot         *bots.Bot
+	policy      exclusions.ChannelPolicy
+	restriction error
+}
+
+func (f *fakeBots) GetBotByID(string) *bots.Bot {
+	return f.bot
+}
+
+func (f *fakeBots) CheckUsageRestrictionsForChannel(*bots.Bot, *model.Channel) error {
+	return f.restriction
+}
+
+func (f *fakeBots) ChannelPolicy(*model.Channel) (exclusions.ChannelPolicy, error) {
+	return f.policy, nil
+}
+
+type fakeContextBuilder struct{}
+
+func (fakeContextBuilder) BuildLLMContextUserRequest(_ *bots.Bot, user *model.User, channel *model.Channel, _ ...llm.ContextOption) *llm.Context {
+	llmContext := llm.NewContext()
+	llmContext.RequestingUser = user
+	llmContext.Channel = channel
+	return llmContext
+}
+
+func newTestService(t *testing.T, client *fakeClient, runs *fakeRuns, languageModel llm.LanguageModel) (*Service, *bots.Bot) {
+	promptsObj, err := llm.NewPrompts(prompts.PromptsFolder)
+	require.NoError(t, err)
+	bot := bots.NewBot(llm.BotConfig{}, llm.ServiceConfig{}, &model.Bot{UserId: "bot1"}, languageModel)
+	cfg := config.IncidentCopilotConfig{Enabled: true}
+	return New(client, runs, &fakeBots{bot: bot}, fakeContextBuilder{}, promptsObj, i18n.Init(), mmapitest.MutexAPI{}, func() config.IncidentCopilotConfig { return cfg }), bot
+}
+
+func TestStartCopilot(t *testing.T) {
+	tests := []struct {
+		name          string
+		disabled      bool
+		run           *Run
+		interval      int
+		expectedError error
+	}{
+		{name: "not enabled", disabled: true, run: &Run{ID: "run1"}, expectedError: ErrNotEnabled},
+		{name: "interval too short", run: &Run{ID: "run1"}, interval: 1, expectedError: ErrInvalidInterval},
+		{name: "no run", expectedError: ErrNoRun},
+		{name: "finished run", run: &Run{ID: "run1", CurrentStatus: RunStatusFinished}, expectedError: ErrRunFinished},
+		{name: "starts with the default interval", run: &Run{ID: "run1", Name: "Checkout down", CurrentStatus: RunStatusInProgress}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newFakeClient()
+			service, bot := newTestService(t, client, &fakeRuns{run: test.run}, nil)
+			if test.disabled {
+				service.getConfig = func() config.IncidentCopilotConfig { return config.IncidentCopilotConfig{} }
+			}
+
+			copilot, err := service.StartCopilot("user1", "channel1", bot, test.interval)
+			if test.expectedError != nil {
+				require.ErrorIs(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, DefaultUpdateIntervalMinutes, copilot.IntervalMinutes)
+			require.Equal(t, "run1", copilot.RunID)
+
+			stored, err := service.Get("channel1")
+			require.NoError(t, err)
+			require.Equal(t, copilot, stored)
+
+			summaryPost := client.posts[copilot.SummaryPostID]
+			require.Equal(t, "bot1", summaryPost.UserId)
+			require.Contains(t, summaryPost.Message, "#### Incident summary: Checkout down")
+
+			// Starting again only changes the interval
+			copilot, err = service.StartCopilot("user1", "channel1", bot, 30)
+			require.NoError(t, err)
+			require.Equal(t, 30, copilot.IntervalMinutes)
+			require.Len(t, client.postsOfKind(PostKindSummary), 1)
+
+			require.NoError(t, service.StopCopilot("channel1"))
+			_, err = service.Get("channel1")
+			require.ErrorIs(t, err, ErrNotRunning)
+			require.ErrorIs(t, service.StopCopilot("channel1"), ErrNotRunning)
+		})
+	}
+}
+
+func TestPoll(t *testing.T) {
+	client := newFakeClient()
+	runs := &fakeRuns{run: &Run{ID: "run1", Name: "Checkout down", CurrentStatus: RunStatusInProgress}}
+	languageModel := llmmocks.NewMockLanguageModel(t)
+	service, bot := newTestService(t, client, runs, languageModel)
+
+	copilot, err := service.StartCopilot("user1", "channel1", bot, 0)
+	require.NoError(t, err)
+	require.NoError(t, client.CreatePost(&model.Post{UserId: "user2", ChannelId: "channel1", Message: "Payments are timing out, who is looking at the gateway?"}))
+
+	languageModel.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, request llm.CompletionRequest, _ ...llm.LanguageModelOption) (string, error) {
+		require.Contains(t, request.Posts[1].Message, "user-user2: Payments are timing out")
+		require.NotContains(t, request.Posts[1].Message, "Preparing the incident summary")
+		return "##### Open questions\n- Who is looking at the gateway?", nil
+	}).Once()
+
+	// The first summary is due right away
+	service.Poll()
+	require.Empty(t, client.warnings)
+	copilot, err = service.Get("channel1")
+	require.NoError(t, err)
+	require.Equal(t, "##### Open questions\n- Who is looking at the gateway?", copilot.Summary)
+	require.True(t, strings.HasSuffix(client.posts[copilot.SummaryPostID].Message, copilot.Summary))
+
+	// The next one isn't due yet
+	service.Poll()
+
+	// Without new messages, a refresh doesn't need the model
+	copilot.UpdateAt = model.GetMillis() + 1
+	require.NoError(t, service.save(copilot))
+	require.NoError(t, service.process(context.Background(), copilot, false))
+
+	// Once the run is finished, the postmortem is drafted and the copilot stops
+	runs.run.CurrentStatus = RunStatusFinished
+	languageModel.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, request llm.CompletionRequest, _ ...llm.LanguageModelOption) (string, error) {
+		require.Contains(t, request.Posts[0].Message, "Who is looking at the gateway?")
+		return "##### Summary\n_To be completed_", nil
+	}).Once()
+	require.NoError(t, service.Refresh(context.Background(), "channel1"))
+
+	postmortems := client.postsOfKind(PostKindPostmortem)
+	require.Len(t, postmortems, 1)
+	require.Equal(t, "#### Postmortem draft: Checkout down\n_Drafted from the incident channel when the run was finished. Review and complete it before sharing._\n\n##### Summary\n_To be completed_", postmortems[0].Message)
+	_, err = service.Get("channel1")
+	require.ErrorIs(t, err, ErrNotRunning)
+
+	var channelIDs []string
+	require.NoError(t, client.KVGet(channelsKey, &channelIDs))
+	require.Empty(t, channelIDs)
+}
+
+type fakeLicense struct {
+	err error
+}
+
+func (f fakeLicense) CheckFeature(enterprise.Feature) error {
+	return f.err
+}
+
+func TestPollNotLicensed(t *testing.T) {
+	client := newFakeClient()
+	runs := &fakeRuns{run: &Run{ID: "run1", Name: "Checkout down", CurrentStatus: RunStatusInProgress}}
+	languageModel := llmmocks.NewMockLanguageModel(t)
+	service, bot := newTestService(t, client, runs, languageModel)
+	service.SetLicenseChecker(fakeLicense{err: enterprise.ErrNotLicensed})
+
+	copilot, err := service.StartCopilot("user1", "channel1", bot, 0)
+	require.NoError(t, err)
+
+	// The summary is due but the model isn't called
+	service.Poll()
+	copilot, err = service.Get(copilot.ChannelID)
+	require.NoError(t, err)
+	require.Empty(t, copilot.Summary)
+}
+
+func TestPollRestrictedChannel(t *testing.T) {
+	client := newFakeClient()
+	runs := &fakeRuns{run: &Run{ID: "run1", Name: "Checkout down", CurrentStatus: RunStatusInProgress}}
+	languageModel := llmmocks.NewMockLanguageModel(t)
+	service, bot := newTestService(t, client, runs, languageModel)
+
+	copilot, err := service.StartCopilot("user1", "channel1", bot, 0)
+	require.NoError(t, err)
+	require.NoError(t, client.CreatePost(&model.Post{UserId: "user2", ChannelId: "channel1", Message: "Payments are timing out"}))
+
+	// The channel was excluded after the copilot was started, the model isn't called
+	service.bots.(*fakeBots).restriction = fmt.Errorf("%w: %w", bots.ErrUsageRestriction, exclusions.ErrChannelExcluded)
+	service.Poll()
+	require.Empty(t, client.warnings)
+	copilot, err = service.Get(copilot.ChannelID)
+	require.NoError(t, err)
+	require.Empty(t, copilot.Summary)
+}
+
+func TestChannelPostsExcludesGuests(t *testing.T) {
+	client := newFakeClient()
+	service, _ := newTestService(t, client, &fakeRuns{}, nil)
+	service.bots.(*fakeBots).policy = exclusions.ChannelPolicy{ExcludeGuests: true}
+
+	require.NoError(t, client.CreatePost(&model.Post{UserId: "user2", ChannelId: "channel1", Message: "Payments are timing out"}))
+	require.NoError(t, client.CreatePost(&model.Post{UserId: "guest1", ChannelId: "channel1", Message: "The gateway is down"}))
+
+	threadData, err := service.channelPosts(&Copilot{ChannelID: "channel1", BotID: "bot1"}, &Run{})
+	require.NoError(t, err)
+	require.Len(t, threadData.Posts, 1)
+	require.Equal(t, "user2", threadData.Posts[0].UserId)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package incidents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// RunStatusInProgress is the status of a run that hasn't been finished
+	RunStatusInProgress = "InProgress"
+	// RunStatusFinished is the status of a run once it has been finished
+	RunStatusFinished = "Finished"
+)
+
+// ErrNoRun is returned when the channel isn't the channel of a Playbooks run the user can see.
+var ErrNoRun = errors.New("channel has no playbook run")
+
+// Run is the part of a Playbooks run used by the copilot.
+type Run struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Summary        string          `json:"summary"`
+	OwnerUserID    string          `json:"owner_user_id"`
+	ChannelID      string          `json:"channel_id"`
+	CurrentStatus  string          `json:"current_status"`
+	CreateAt       int64           `json:"create_at"`
+	EndAt          int64           `json:"end_at"`
+	TimelineEvents []TimelineEvent `json:"timeline_events"`
+}
+
+// TimelineEvent is an event of the timeline of a run, such as a status update or a role change.
+type TimelineEvent struct {
+	EventAt   int64  `json:"event_at"`
+	EventType string `json:"event_type"`
+	Summary   string `json:"summary"`
+}
+
+// PluginHTTPClient sends requests to other plugins.
+type PluginHTTPClient interface {
+	PluginHTTP(req *http.Request) *http.Response
+}
+
+// Playbooks reads runs from the Playbooks plugin with the permissions of a user.
+type Playbooks struct {
+	api PluginHTTPClient
+}
+
+// NewPlaybooks creates a client of the Playbooks plugin API.
+func NewPlaybooks(api PluginHTTPClient) *Playbooks {
+	return &Playbooks{api: api}
+}
+
+// RunForChannel returns the latest run whose channel is channelID.
+func (p *Playbooks) RunForChannel(userID, channelID string) (*Run, error) {
+	var runs struct {
+		Items []Run `json:"items"`
+	}
+	query := url.Values{
+		"channel_id": {channelID},
+		"sort":       {"create_at"},
+		"direction":  {"desc"},
+		"per_page":   {"1"},
+	}
+	if err := p.get(userID, "/runs?"+query.Encode(), &runs); err != nil {
+		return nil, err
+	}
+	if len(runs.Items) == 0 {
+		return nil, ErrNoRun
+	}
+
+	// Runs are listed without their timeline
+	return p.GetRun(userID, runs.Items[0].ID)
+}
+
+// GetRun returns the run with the given ID.
+func (p *Playbooks) GetRun(userID, runID string) (*Run, error) {
+	var run Run
+	if err := p.get(userID, "/runs/"+url.PathEscape(runID), &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (p *Playbooks) get(userID, path string, out any) error {
+	req, err := http.NewRequest(http.MethodGet, "/playbooks/api/v0"+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Mattermost-User-ID", userID)
+
+	resp := p.api.PluginHTTP(req)
+	if resp == nil {
+		return errors.New("failed to reach the playbooks plugin, response was nil")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusForbidden:
+		return ErrNoRun
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("playbooks request failed with status %s: %s", resp.Status, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode playbooks response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package incidents
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakePluginHTTP func(req *http.Request) (int, string)
+
+func (f fakePluginHTTP) PluginHTTP(req *http.Request) *http.Response {
+	status, body := f(req)
+	return &http.Response{StatusCode: status, Status: http.StatusText(status), Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestRunForChannel(t *testing.T) {
+	tests := []struct {
+		name          string
+		handler       fakePluginHTTP
+		expectedRun   *Run
+		expectedError error
+	}{
+		{
+			name: "latest run of the channel with its timeline",
+			handler: func(req *http.Request) (int, string) {
+				require.Equal(t, "user1", req.Header.Get("Mattermost-User-ID"))
+				switch req.URL.Path {
+				case "/playbooks/api/v0/runs":
+					require.Equal(t, "channel1", req.URL.Query().Get("channel_id"))
+					return http.StatusOK, `{"items":[{"id":"run1"}]}`
+				case "/playbooks/api/v0/runs/run1":
+					return http.StatusOK, `{"id":"run1","name":"Checkout down","current_status":"InProgress","create_at":1000,
+						"timeline_events":[{"event_at":2000,"event_type":"status_updated","summary":"Mitigation in progress"}]}`
+				}
+				return http.StatusNotFound, ""
+			},
+			expectedRun: &Run{ID: "run1", Name: "Checkout down", CurrentStatus: RunStatusInProgress, CreateAt: 1000,
+				TimelineEvents: []TimelineEvent{{EventAt: 2000, EventType: "status_updated", Summary: "Mitigation in progress"}}},
+		},
+		{
+			name:          "channel without run",
+			handler:       func(*http.Request) (int, string) { return http.StatusOK, `{"items":[]}` },
+			expectedError: ErrNoRun,
+		},
+		{
+			name:          "playbooks not installed",
+			handler:       func(*http.Request) (int, string) { return http.StatusNotFound, "" },
+			expectedError: ErrNoRun,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			run, err := NewPlaybooks(test.handler).RunForChannel("user1", "channel1")
+			if test.expectedError != nil {
+				require.ErrorIs(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectedRun, run)
+		})
+	}
+}
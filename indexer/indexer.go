@@ -11,15 +11,24 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
 	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
 )
 
 type Indexer struct {
-	search    embeddings.EmbeddingSearch
-	pluginAPI mmapi.Client
-	bots      *bots.MMBots
-	db        *sqlx.DB
+	search          embeddings.EmbeddingSearch
+	pluginAPI       mmapi.Client
+	bots            *bots.MMBots
+	db              *sqlx.DB
+	channelExcluder ChannelExcluder
+	mutexAPI        cluster.MutexPluginAPI
+}
+
+// ChannelExcluder reports the channels whose content must never be sent to LLM providers
+type ChannelExcluder interface {
+	IsChannelExcluded(channelID, teamID string) bool
 }
 
 func New(
@@ -27,15 +36,22 @@ func New(
 	pluginAPI mmapi.Client,
 	bots *bots.MMBots,
 	db *sqlx.DB,
+	mutexAPI cluster.MutexPluginAPI,
 ) *Indexer {
 	return &Indexer{
 		search:    search,
 		pluginAPI: pluginAPI,
 		bots:      bots,
 		db:        db,
+		mutexAPI:  mutexAPI,
 	}
 }
 
+// SetChannelExcluder sets the exclusions of channels that are never indexed
+func (s *Indexer) SetChannelExcluder(excluder ChannelExcluder) {
+	s.channelExcluder = excluder
+}
+
 // IndexPost indexes a post if it meets the criteria
 func (s *Indexer) IndexPost(ctx context.Context, post *model.Post, channel *model.Channel) error {
 	if !s.shouldIndexPost(post, channel) {
@@ -56,8 +72,8 @@ func (s *Indexer) IndexPost(ctx context.Context, post *model.Post, channel *mode
 		Content:   post.Message,
 	}
 
-	// Store the document
-	return s.search.Store(ctx, []embeddings.PostDocument{doc})
+	// Store the document, giving way to search queries when the embedding provider is rate limited
+	return s.search.Store(llm.ContextWithPriority(ctx, llm.PriorityIndexing), []embeddings.PostDocument{doc})
 }
 
 // DeletePost deletes a post from the index
@@ -76,9 +92,17 @@ func (s *Indexer) StartReindexJob() (JobStatus, error) {
 		return JobStatus{}, fmt.Errorf("search functionality is not configured")
 	}
 
+	// Only one node of the cluster may check and start the job at a time
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_reindex_start")
+	if err != nil {
+		return JobStatus{}, fmt.Errorf("failed to create reindex mutex: %w", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
 	// Check if a job is already running
 	var jobStatus JobStatus
-	err := s.pluginAPI.KVGet(ReindexJobKey, &jobStatus)
+	err = s.pluginAPI.KVGet(ReindexJobKey, &jobStatus)
 	if err != nil && err.Error() != "not found" {
 		return JobStatus{}, fmt.Errorf("failed to check job status: %w", err)
 	}
@@ -177,5 +201,10 @@ func (s *Indexer) shouldIndexPost(post *model.Post, channel *model.Channel) bool
 		return false
 	}
 
+	// Skip posts in channels excluded from AI processing
+	if channel != nil && s.channelExcluder != nil && s.channelExcluder.IsChannelExcluded(channel.Id, channel.TeamId) {
+		return false
+	}
+
 	return true
 }
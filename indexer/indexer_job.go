@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost/server/public/model"
 )
 
@@ -59,7 +60,8 @@ func (s *Indexer) runReindexJob(jobStatus *JobStatus) {
 		}
 	}()
 
-	ctx := context.Background()
+	// Reindexing gives way to other requests when the embedding provider is rate limited
+	ctx := llm.ContextWithPriority(context.Background(), llm.PriorityIndexing)
 
 	// Clear the existing index
 	if err := s.search.Clear(ctx); err != nil {
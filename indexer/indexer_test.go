@@ -84,7 +84,7 @@ func TestShouldIndexPost(t *testing.T) {
 
 	// Create indexer with empty bots
 	mockBots := &bots.MMBots{}
-	indexer := New(nil, nil, mockBots, nil)
+	indexer := New(nil, nil, mockBots, nil, nil)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -101,7 +101,7 @@ func TestDeletePost(t *testing.T) {
 
 	t.Run("does nothing when search is nil", func(t *testing.T) {
 		// Create indexer with nil search
-		indexer := New(nil, nil, mockBots, nil)
+		indexer := New(nil, nil, mockBots, nil, nil)
 
 		// Should not panic and should return no error
 		err := indexer.DeletePost(ctx, postID)
@@ -114,7 +114,7 @@ func TestIndexPost(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("does not index deleted post", func(t *testing.T) {
-		indexer := New(nil, nil, mockBots, nil)
+		indexer := New(nil, nil, mockBots, nil, nil)
 
 		post := &model.Post{
 			Id:       "post2",
@@ -138,7 +138,7 @@ func TestIndexPost(t *testing.T) {
 
 	t.Run("does nothing when search is nil", func(t *testing.T) {
 		// Create indexer with nil search
-		indexer := New(nil, nil, mockBots, nil)
+		indexer := New(nil, nil, mockBots, nil, nil)
 
 		post := &model.Post{
 			Id:       "post1",
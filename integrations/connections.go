@@ -0,0 +1,327 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package integrations
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"golang.org/x/oauth2"
+)
+
+const (
+	connectionKeyPrefix = "integration_connection_v1_"
+	stateKeyPrefix      = "integration_oauth_state_v1_"
+
+	// stateLifetime is how long users have to authorize the OAuth application
+	stateLifetime = 10 * time.Minute
+)
+
+// KVStore is the storage needed by the service.
+type KVStore interface {
+	KVGet(key string, value interface{}) error
+	KVSet(key string, value interface{}) error
+	KVDelete(key string) error
+}
+
+// Connection describes the account a user connected on a service, without its token
+type Connection struct {
+	Integration string            `json:"integration"`
+	Username    string            `json:"username"`
+	ConnectedAt int64             `json:"connected_at"`
+	Data        map[string]string `json:"data,omitempty"`
+}
+
+type storedConnection struct {
+	Connection
+	Token *oauth2.Token `json:"token"`
+}
+
+type oauthState struct {
+	UserID       string `json:"user_id"`
+	Integration  string `json:"integration"`
+	CodeVerifier string `json:"code_verifier"`
+	CreateAt     int64  `json:"create_at"`
+}
+
+// Service manages the connections of users to the registered integrations.
+type Service struct {
+	kv         KVStore
+	httpClient *http.Client
+	pluginURL  string
+
+	mu        sync.RWMutex
+	providers map[string]Provider
+	order     []string
+}
+
+// New creates a new service. pluginURL is the public URL of the plugin, which the OAuth
+// applications must redirect to at {pluginURL}/integrations/{integration}/callback.
+func New(kv KVStore, httpClient *http.Client, pluginURL string) *Service {
+	return &Service{
+		kv:         kv,
+		httpClient: httpClient,
+		pluginURL:  pluginURL,
+		providers:  make(map[string]Provider),
+	}
+}
+
+// Register adds an integration users can connect to, identified by id in URLs and storage.
+func (s *Service) Register(id string, provider Provider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.providers[id]; !ok {
+		s.order = append(s.order, id)
+	}
+	s.providers[id] = provider
+}
+
+func (s *Service) provider(id string) (Provider, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	provider, ok := s.providers[id]
+	return provider, ok
+}
+
+// Enabled returns whether users can connect to the integration
+func (s *Service) Enabled(id string) bool {
+	if s == nil {
+		return false
+	}
+	_, err := s.oauthConfig(id)
+	return err == nil
+}
+
+// EnabledIntegrations returns the integrations users can connect to, in registration order
+func (s *Service) EnabledIntegrations() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	order := append([]string(nil), s.order...)
+	s.mu.RUnlock()
+
+	var enabled []string
+	for _, id := range order {
+		if s.Enabled(id) {
+			enabled = append(enabled, id)
+		}
+	}
+	return enabled
+}
+
+// Name returns the display name of the integration
+func (s *Service) Name(id string) string {
+	if provider, ok := s.provider(id); ok {
+		return provider.Name()
+	}
+	return id
+}
+
+// ConnectURL returns the page users open to connect their account to the integration
+func (s *Service) ConnectURL(id string) string {
+	return fmt.Sprintf("%s/integrations/%s/connect", s.pluginURL, id)
+}
+
+func (s *Service) callbackURL(id string) string {
+	return fmt.Sprintf("%s/integrations/%s/callback", s.pluginURL, id)
+}
+
+func (s *Service) oauthConfig(id string) (*oauth2.Config, error) {
+	provider, ok := s.provider(id)
+	if !ok {
+		return nil, ErrNotEnabled
+	}
+	oauthConfig, err := provider.OAuthConfig()
+	if err != nil {
+		return nil, err
+	}
+	oauthConfig.RedirectURL = s.callbackURL(id)
+	return oauthConfig, nil
+}
+
+func (s *Service) oauthContext(ctx context.Context) context.Context {
+	if s.httpClient == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, s.httpClient)
+}
+
+// AuthorizationURL starts connecting the user's account and returns the URL of the
+// authorization page of the service.
+func (s *Service) AuthorizationURL(userID, id string) (string, error) {
+	oauthConfig, err := s.oauthConfig(id)
+	if err != nil {
+		return "", err
+	}
+
+	stateBytes := make([]byte, 24)
+	if _, err = rand.Read(stateBytes); err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	state := base64.RawURLEncoding.EncodeToString(stateBytes)
+	verifier := oauth2.GenerateVerifier()
+
+	if err = s.kv.KVSet(stateKeyPrefix+state, oauthState{
+		UserID:       userID,
+		Integration:  id,
+		CodeVerifier: verifier,
+		CreateAt:     model.GetMillis(),
+	}); err != nil {
+		return "", fmt.Errorf("failed to store OAuth state: %w", err)
+	}
+
+	return oauthConfig.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// CompleteConnection exchanges the authorization code the service redirected the user with
+// for a token, and stores it as the user's connection.
+func (s *Service) CompleteConnection(ctx context.Context, userID, id, state, code string) (*Connection, error) {
+	var saved *oauthState
+	if err := s.kv.KVGet(stateKeyPrefix+state, &saved); err != nil {
+		return nil, fmt.Errorf("failed to get OAuth state: %w", err)
+	}
+	if saved == nil {
+		return nil, errors.New("unknown OAuth state")
+	}
+	if err := s.kv.KVDelete(stateKeyPrefix + state); err != nil {
+		return nil, fmt.Errorf("failed to delete OAuth state: %w", err)
+	}
+	if saved.UserID != userID || saved.Integration != id {
+		return nil, errors.New("OAuth state belongs to another user or integration")
+	}
+	if time.Since(time.UnixMilli(saved.CreateAt)) > stateLifetime {
+		return nil, errors.New("OAuth state expired")
+	}
+
+	oauthConfig, err := s.oauthConfig(id)
+	if err != nil {
+		return nil, err
+	}
+	token, err := oauthConfig.Exchange(s.oauthContext(ctx), code, oauth2.VerifierOption(saved.CodeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	// The token isn't refreshed here, a rotated refresh token would be lost
+	provider, _ := s.provider(id)
+	account, err := provider.Account(ctx, oauth2.NewClient(s.oauthContext(ctx), oauth2.StaticTokenSource(token)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the connected account: %w", err)
+	}
+
+	connection := storedConnection{
+		Connection: Connection{
+			Integration: id,
+			Username:    account.Username,
+			ConnectedAt: model.GetMillis(),
+			Data:        account.Data,
+		},
+		Token: token,
+	}
+	if err := s.kv.KVSet(connectionKey(userID, id), connection); err != nil {
+		return nil, fmt.Errorf("failed to store connection: %w", err)
+	}
+
+	return &connection.Connection, nil
+}
+
+func connectionKey(userID, id string) string {
+	return connectionKeyPrefix + id + "_" + userID
+}
+
+// IsUserConnectionKey reports whether the KV key stores a connection of the user
+func IsUserConnectionKey(key, userID string) bool {
+	return strings.HasPrefix(key, connectionKeyPrefix) && strings.HasSuffix(key, "_"+userID)
+}
+
+func (s *Service) getStoredConnection(userID, id string) (*storedConnection, error) {
+	var connection *storedConnection
+	if err := s.kv.KVGet(connectionKey(userID, id), &connection); err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	if connection == nil || connection.Token == nil {
+		return nil, nil
+	}
+	return connection, nil
+}
+
+// GetConnection returns the user's connection to the integration, or nil if they haven't
+// connected.
+func (s *Service) GetConnection(userID, id string) (*Connection, error) {
+	connection, err := s.getStoredConnection(userID, id)
+	if err != nil || connection == nil {
+		return nil, err
+	}
+	return &connection.Connection, nil
+}
+
+// Disconnect removes the user's connection to the integration. The authorization stays listed
+// in the user's settings on the service until they revoke it there.
+func (s *Service) Disconnect(userID, id string) error {
+	if err := s.kv.KVDelete(connectionKey(userID, id)); err != nil {
+		return fmt.Errorf("failed to delete connection: %w", err)
+	}
+	return nil
+}
+
+// HTTPClient returns an HTTP client authorized as the user on the service, refreshing and
+// storing the token when it expires, with the user's connection. It returns ErrNotConnected
+// when the user hasn't connected or has to connect again.
+func (s *Service) HTTPClient(ctx context.Context, userID, id string) (*http.Client, *Connection, error) {
+	oauthConfig, err := s.oauthConfig(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	connection, err := s.getStoredConnection(userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if connection == nil {
+		return nil, nil, ErrNotConnected
+	}
+
+	tokenSource := &savingTokenSource{
+		source:     oauthConfig.TokenSource(s.oauthContext(ctx), connection.Token),
+		service:    s,
+		userID:     userID,
+		connection: connection,
+	}
+	return oauth2.NewClient(s.oauthContext(ctx), tokenSource), &connection.Connection, nil
+}
+
+// savingTokenSource stores the tokens refreshed by the source, as refresh tokens are rotated by
+// some services.
+type savingTokenSource struct {
+	source     oauth2.TokenSource
+	service    *Service
+	userID     string
+	connection *storedConnection
+}
+
+func (t *savingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := t.source.Token()
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		// The refresh token was revoked or expired, the user has to connect again
+		return nil, fmt.Errorf("%w: %v", ErrNotConnected, err)
+	} else if err != nil {
+		return nil, err
+	}
+	if token.AccessToken != t.connection.Token.AccessToken {
+		t.connection.Token = token
+		if err := t.service.kv.KVSet(connectionKey(t.userID, t.connection.Integration), t.connection); err != nil {
+			return nil, fmt.Errorf("failed to store refreshed token: %w", err)
+		}
+	}
+	return token, nil
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mmapitest"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// newFakeService serves an OAuth token endpoint issuing tokens expiring immediately, with
+// rotated refresh tokens, and an endpoint returning the token the request was made with.
+func newFakeService(t *testing.T) *httptest.Server {
+	issued := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			require.NoError(t, r.ParseForm())
+			switch {
+			case r.Form.Get("grant_type") == "authorization_code" && r.Form.Get("code") == "good-code" && r.Form.Get("code_verifier") != "":
+			case r.Form.Get("grant_type") == "refresh_token" && r.Form.Get("refresh_token") == fmt.Sprintf("refresh-%d", issued):
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			issued++
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"access_token":"token-%d","refresh_token":"refresh-%d","token_type":"bearer","expires_in":1}`, issued, issued)))
+		case "/me":
+			_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+type fakeProvider struct {
+	serverURL string
+	enabled   bool
+}
+
+func (p *fakeProvider) Name() string {
+	return "Fake"
+}
+
+func (p *fakeProvider) OAuthConfig() (*oauth2.Config, error) {
+	if !p.enabled {
+		return nil, ErrNotEnabled
+	}
+	return &oauth2.Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Endpoint:     oauth2.Endpoint{AuthURL: p.serverURL + "/authorize", TokenURL: p.serverURL + "/token"},
+	}, nil
+}
+
+func (p *fakeProvider) Account(_ context.Context, httpClient *http.Client) (Account, error) {
+	resp, err := httpClient.Get(p.serverURL + "/me")
+	if err != nil {
+		return Account{}, err
+	}
+	resp.Body.Close()
+	return Account{Username: "fake-user", Data: map[string]string{"site": "one"}}, nil
+}
+
+func stateFromAuthorizationURL(t *testing.T, authURL string) string {
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	return parsed.Query().Get("state")
+}
+
+func TestConnections(t *testing.T) {
+	server := newFakeService(t)
+	defer server.Close()
+
+	newService := func() *Service {
+		service := New(mmapitest.NewMemoryKV(), server.Client(), "https://mm.example.com/plugins/mattermost-ai")
+		service.Register("fake", &fakeProvider{serverURL: server.URL, enabled: true})
+		service.Register("disabled", &fakeProvider{serverURL: server.URL})
+		return service
+	}
+
+	t.Run("connects, refreshes and disconnects an account", func(t *testing.T) {
+		service := newService()
+		require.Equal(t, []string{"fake"}, service.EnabledIntegrations())
+		require.Equal(t, "https://mm.example.com/plugins/mattermost-ai/integrations/fake/connect", service.ConnectURL("fake"))
+
+		authURL, err := service.AuthorizationURL("user1", "fake")
+		require.NoError(t, err)
+		parsed, err := url.Parse(authURL)
+		require.NoError(t, err)
+		require.Equal(t, "https://mm.example.com/plugins/mattermost-ai/integrations/fake/callback", parsed.Query().Get("redirect_uri"))
+		require.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+
+		connection, err := service.CompleteConnection(context.Background(), "user1", "fake", parsed.Query().Get("state"), "good-code")
+		require.NoError(t, err)
+		require.Equal(t, "fake-user", connection.Username)
+		require.Equal(t, map[string]string{"site": "one"}, connection.Data)
+
+		stored, err := service.GetConnection("user1", "fake")
+		require.NoError(t, err)
+		require.Equal(t, connection, stored)
+
+		// The token expired, so each client refreshes it and stores the rotated refresh token
+		for _, expected := range []string{"Bearer token-2", "Bearer token-3"} {
+			httpClient, _, err := service.HTTPClient(context.Background(), "user1", "fake")
+			require.NoError(t, err)
+			resp, err := httpClient.Get(server.URL + "/me")
+			require.NoError(t, err)
+			var body [64]byte
+			n, _ := resp.Body.Read(body[:])
+			resp.Body.Close()
+			require.Equal(t, expected, string(body[:n]))
+		}
+
+		require.NoError(t, service.Disconnect("user1", "fake"))
+		_, _, err = service.HTTPClient(context.Background(), "user1", "fake")
+		require.ErrorIs(t, err, ErrNotConnected)
+	})
+
+	t.Run("revoked refresh token requires connecting again", func(t *testing.T) {
+		service := newService()
+		authURL, err := service.AuthorizationURL("user1", "fake")
+		require.NoError(t, err)
+		_, err = service.CompleteConnection(context.Background(), "user1", "fake", stateFromAuthorizationURL(t, authURL), "good-code")
+		require.NoError(t, err)
+
+		// Connecting another account rotates the refresh token the fake service accepts
+		authURL, err = service.AuthorizationURL("user2", "fake")
+		require.NoError(t, err)
+		_, err = service.CompleteConnection(context.Background(), "user2", "fake", stateFromAuthorizationURL(t, authURL), "good-code")
+		require.NoError(t, err)
+
+		httpClient, _, err := service.HTTPClient(context.Background(), "user1", "fake")
+		require.NoError(t, err)
+		_, err = httpClient.Get(server.URL + "/me")
+		require.ErrorIs(t, err, ErrNotConnected)
+	})
+
+	tests := []struct {
+		name        string
+		userID      string
+		integration string
+		state       func(service *Service) string
+		code        string
+	}{
+		{
+			name:        "unknown state",
+			userID:      "user1",
+			integration: "fake",
+			state:       func(*Service) string { return "unknown" },
+			code:        "good-code",
+		},
+		{
+			name:        "state of another user",
+			userID:      "user2",
+			integration: "fake",
+			state: func(service *Service) string {
+				authURL, err := service.AuthorizationURL("user1", "fake")
+				require.NoError(t, err)
+				return stateFromAuthorizationURL(t, authURL)
+			},
+			code: "good-code",
+		},
+		{
+			name:        "code rejected by the service",
+			userID:      "user1",
+			integration: "fake",
+			state: func(service *Service) string {
+				authURL, err := service.AuthorizationURL("user1", "fake")
+				require.NoError(t, err)
+				return stateFromAuthorizationURL(t, authURL)
+			},
+			code: "bad-code",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := newService()
+			_, err := service.CompleteConnection(context.Background(), test.userID, test.integration, test.state(service), test.code)
+			require.Error(t, err)
+
+			connection, err := service.GetConnection(test.userID, test.integration)
+			require.NoError(t, err)
+			require.Nil(t, connection)
+		})
+	}
+
+	t.Run("state can only be used once", func(t *testing.T) {
+		service := newService()
+		authURL, err := service.AuthorizationURL("user1", "fake")
+		require.NoError(t, err)
+		state := stateFromAuthorizationURL(t, authURL)
+
+		_, err = service.CompleteConnection(context.Background(), "user1", "fake", state, "bad-code")
+		require.Error(t, err)
+		_, err = service.CompleteConnection(context.Background(), "user1", "fake", state, "good-code")
+		require.Error(t, err)
+	})
+
+	t.Run("disabled and unknown integrations", func(t *testing.T) {
+		service := newService()
+		for _, id := range []string{"disabled", "unknown"} {
+			require.False(t, service.Enabled(id))
+			_, err := service.AuthorizationURL("user1", id)
+			require.ErrorIs(t, err, ErrNotEnabled)
+			_, _, err = service.HTTPClient(context.Background(), "user1", id)
+			require.ErrorIs(t, err, ErrNotEnabled)
+		}
+	})
+}
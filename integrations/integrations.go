@@ -0,0 +1,40 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package integrations connects the accounts of users on external services through OAuth,
+// stores their tokens and refreshes them, so tools can call the services as the user.
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrNotEnabled is returned for integrations the admin hasn't enabled and configured
+	ErrNotEnabled = errors.New("integration is not enabled")
+	// ErrNotConnected is returned when the user hasn't connected their account on the service
+	ErrNotConnected = errors.New("account is not connected")
+)
+
+// Provider is an external service users can connect their account to.
+type Provider interface {
+	// Name is the display name of the service
+	Name() string
+	// OAuthConfig returns the OAuth application users connect with, or ErrNotEnabled when the
+	// admin hasn't enabled and configured it. The redirect URL is set by the service.
+	OAuthConfig() (*oauth2.Config, error)
+	// Account returns the account the HTTP client is authorized as.
+	Account(ctx context.Context, httpClient *http.Client) (Account, error)
+}
+
+// Account is the account a user connected on a service
+type Account struct {
+	Username string
+	// Data is what the provider needs to call the service as the account, such as the site
+	// the account belongs to.
+	Data map[string]string
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package intents classifies incoming direct messages so they can be routed to the flow
+// and model best suited to answer them.
+package intents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+)
+
+// Intent is what the user wants the bot to do with a message
+type Intent string
+
+const (
+	// IntentChat is a general question or conversation, answered by the regular chat flow
+	IntentChat Intent = "chat"
+	// IntentSearch asks for information discussed somewhere on the Mattermost server
+	IntentSearch Intent = "search"
+	// IntentSummarize asks to summarize or condense content
+	IntentSummarize Intent = "summarize"
+	// IntentImageGeneration asks to create or edit an image
+	IntentImageGeneration Intent = "image_generation"
+)
+
+// AllIntents lists the intents messages are classified into
+var AllIntents = []Intent{IntentChat, IntentSearch, IntentSummarize, IntentImageGeneration}
+
+// IsValid reports whether the intent is one of AllIntents
+func (i Intent) IsValid() bool {
+	for _, intent := range AllIntents {
+		if i == intent {
+			return true
+		}
+	}
+	return false
+}
+
+// Classification is the structured output requested from the model
+type Classification struct {
+	Intent Intent `json:"intent"`
+}
+
+// Router classifies messages into intents
+type Router struct {
+	llm     llm.LanguageModel
+	prompts *llm.Prompts
+}
+
+// New creates a new Router
+func New(
+	llm llm.LanguageModel,
+	prompts *llm.Prompts,
+) *Router {
+	return &Router{
+		llm:     llm,
+		prompts: prompts,
+	}
+}
+
+// Classify returns the intent of the message. Messages the model can not classify are
+// treated as IntentChat so they are still answered.
+func (r *Router) Classify(ctx context.Context, message string, context *llm.Context) (Intent, error) {
+	context.Parameters = map[string]any{
+		"Intents": AllIntents,
+	}
+
+	prompt, err := r.prompts.Format(prompts.PromptIntentClassifySystem, context)
+	if err != nil {
+		return IntentChat, fmt.Errorf("failed to format prompt: %w", err)
+	}
+
+	completionRequest := llm.CompletionRequest{
+		Posts: []llm.Post{
+			{
+				Role:    llm.PostRoleSystem,
+				Message: prompt,
+			},
+			{
+				Role:    llm.PostRoleUser,
+				Message: message,
+			},
+		},
+		Context: context,
+	}
+
+	result, err := r.llm.ChatCompletionNoStream(ctx, completionRequest,
+		llm.WithMaxGeneratedTokens(100),
+		llm.WithReasoningDisabled(),
+		llm.WithToolsDisabled(),
+		llm.WithJSONOutput[Classification](),
+	)
+	if err != nil {
+		return IntentChat, fmt.Errorf("failed to classify message: %w", err)
+	}
+
+	var classification Classification
+	if err := json.Unmarshal([]byte(result), &classification); err != nil {
+		return IntentChat, fmt.Errorf("failed to parse classification: %w", err)
+	}
+
+	if !classification.Intent.IsValid() {
+		return IntentChat, nil
+	}
+
+	return classification.Intent, nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package intents_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/intents"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/llm/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name           string
+		llmResponse    string
+		llmError       error
+		expectedIntent intents.Intent
+		errorContains  string
+	}{
+		{
+			name:           "search",
+			llmResponse:    `{"intent": "search"}`,
+			expectedIntent: intents.IntentSearch,
+		},
+		{
+			name:           "image generation",
+			llmResponse:    `{"intent": "image_generation"}`,
+			expectedIntent: intents.IntentImageGeneration,
+		},
+		{
+			name:           "unknown intent falls back to chat",
+			llmResponse:    `{"intent": "weather"}`,
+			expectedIntent: intents.IntentChat,
+		},
+		{
+			name:           "invalid json",
+			llmResponse:    "search",
+			expectedIntent: intents.IntentChat,
+			errorContains:  "failed to parse classification",
+		},
+		{
+			name:           "llm error",
+			llmError:       errors.New("llm error"),
+			expectedIntent: intents.IntentChat,
+			errorContains:  "failed to classify message",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockLLM := mocks.NewMockLanguageModel(t)
+			prompts, err := llm.NewPrompts(prompts.PromptsFolder)
+			require.NoError(t, err)
+
+			mockLLM.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tc.llmResponse, tc.llmError)
+
+			intent, err := intents.New(mockLLM, prompts).Classify(context.Background(), "What did we decide about the launch?", llm.NewContext())
+			if tc.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorContains)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedIntent, intent)
+		})
+	}
+}
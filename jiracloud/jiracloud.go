@@ -0,0 +1,290 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package jiracloud reads and creates Jira Cloud issues on behalf of users, who connect their
+// Atlassian accounts through an OAuth 2.0 (3LO) app configured by the admin.
+package jiracloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/mattermost/mattermost-plugin-ai/integrations"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// IntegrationID identifies Jira in the integrations service
+	IntegrationID = "jira"
+
+	defaultAuthURL = "https://auth.atlassian.com"
+	defaultAPIURL  = "https://api.atlassian.com"
+)
+
+var (
+	// ErrNotEnabled is returned when the admin hasn't enabled and configured Jira
+	ErrNotEnabled = integrations.ErrNotEnabled
+	// ErrNotConnected is returned when the user hasn't connected their Atlassian account
+	ErrNotConnected = integrations.ErrNotConnected
+	// ErrNotFound is returned when the issue doesn't exist or the user can't see it
+	ErrNotFound = errors.New("issue not found or not accessible")
+)
+
+// Config configures the OAuth 2.0 (3LO) app users connect their Atlassian account with
+type Config struct {
+	Enabled      bool   `json:"enabled"`
+	ClientID     string `json:"clientID"`
+	ClientSecret string `json:"clientSecret"`
+	// SiteURL restricts connections to a Jira site, such as https://example.atlassian.net. The
+	// first site the user authorized is used when empty.
+	SiteURL string `json:"siteURL"`
+}
+
+// IsConfigured returns whether Jira is enabled with an OAuth app
+func (c Config) IsConfigured() bool {
+	return c.Enabled && strings.TrimSpace(c.ClientID) != "" && strings.TrimSpace(c.ClientSecret) != ""
+}
+
+// Client reads and creates issues on a Jira site as the connected user.
+type Client interface {
+	GetIssue(ctx context.Context, key string, fields []string) (*jira.Issue, error)
+	CreateIssue(ctx context.Context, input IssueInput) (*CreatedIssue, error)
+}
+
+// IssueInput is an issue to create. Description is in Jira wiki markup.
+type IssueInput struct {
+	ProjectKey  string
+	IssueType   string
+	Summary     string
+	Description string
+}
+
+// CreatedIssue is an issue created by CreateIssue
+type CreatedIssue struct {
+	Key string
+	URL string
+}
+
+// Service registers Jira as an integration and creates clients acting as the users who
+// connected it.
+type Service struct {
+	getConfig    func() Config
+	integrations *integrations.Service
+
+	authURL string
+	apiURL  string
+}
+
+// New creates a new service and registers Jira as an integration.
+func New(getConfig func() Config, integrationsService *integrations.Service) *Service {
+	s := &Service{
+		getConfig:    getConfig,
+		integrations: integrationsService,
+		authURL:      defaultAuthURL,
+		apiURL:       defaultAPIURL,
+	}
+	integrationsService.Register(IntegrationID, s)
+	return s
+}
+
+// Enabled returns whether users can connect their Atlassian account
+func (s *Service) Enabled() bool {
+	return s != nil && s.getConfig().IsConfigured()
+}
+
+// ConnectURL returns the page users open to connect their Atlassian account
+func (s *Service) ConnectURL() string {
+	return s.integrations.ConnectURL(IntegrationID)
+}
+
+// Client returns a client acting as the user on the Jira site they connected, or
+// ErrNotConnected.
+func (s *Service) Client(ctx context.Context, userID string) (Client, error) {
+	if !s.Enabled() {
+		return nil, ErrNotEnabled
+	}
+	httpClient, connection, err := s.integrations.HTTPClient(ctx, userID, IntegrationID)
+	if err != nil {
+		return nil, err
+	}
+	return s.newClient(httpClient, connection.Data[dataCloudID], connection.Data[dataSiteURL])
+}
+
+// Name implements integrations.Provider
+func (s *Service) Name() string {
+	return "Jira"
+}
+
+// OAuthConfig implements integrations.Provider
+func (s *Service) OAuthConfig() (*oauth2.Config, error) {
+	cfg := s.getConfig()
+	if !cfg.IsConfigured() {
+		return nil, ErrNotEnabled
+	}
+	return &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			// Atlassian requires the audience, and the consent prompt to issue refresh tokens
+			AuthURL:  s.authURL + "/authorize?audience=api.atlassian.com&prompt=consent",
+			TokenURL: s.authURL + "/oauth/token",
+		},
+		Scopes: []string{"read:jira-work", "write:jira-work", "read:jira-user", "offline_access"},
+	}, nil
+}
+
+const (
+	dataCloudID = "cloud_id"
+	dataSiteURL = "site_url"
+)
+
+type accessibleResource struct {
+	ID   string `json:"id"`
+	URL  string `json:"url"`
+	Name string `json:"name"`
+}
+
+// Account implements integrations.Provider, finding the Jira site the user authorized.
+func (s *Service) Account(ctx context.Context, httpClient *http.Client) (integrations.Account, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL+"/oauth/token/accessible-resources", nil)
+	if err != nil {
+		return integrations.Account{}, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return integrations.Account{}, fmt.Errorf("failed to get accessible sites: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return integrations.Account{}, fmt.Errorf("failed to get accessible sites: status %d", resp.StatusCode)
+	}
+	var resources []accessibleResource
+	if err = json.NewDecoder(resp.Body).Decode(&resources); err != nil {
+		return integrations.Account{}, fmt.Errorf("failed to decode accessible sites: %w", err)
+	}
+
+	site, err := selectSite(resources, s.getConfig().SiteURL)
+	if err != nil {
+		return integrations.Account{}, err
+	}
+
+	client, err := s.newClient(httpClient, site.ID, site.URL)
+	if err != nil {
+		return integrations.Account{}, err
+	}
+	user, _, err := client.jira.User.GetSelfWithContext(ctx)
+	if err != nil {
+		return integrations.Account{}, fmt.Errorf("failed to get the Jira user: %w", err)
+	}
+
+	return integrations.Account{
+		Username: user.DisplayName,
+		Data: map[string]string{
+			dataCloudID: site.ID,
+			dataSiteURL: site.URL,
+		},
+	}, nil
+}
+
+// selectSite returns the site matching siteURL, or the first site when siteURL is empty
+func selectSite(resources []accessibleResource, siteURL string) (accessibleResource, error) {
+	siteURL = normalizeSiteURL(siteURL)
+	for _, resource := range resources {
+		if siteURL == "" || normalizeSiteURL(resource.URL) == siteURL {
+			return resource, nil
+		}
+	}
+	if siteURL != "" {
+		return accessibleResource{}, fmt.Errorf("access to %s was not authorized", siteURL)
+	}
+	return accessibleResource{}, errors.New("access to a Jira site was not authorized")
+}
+
+func normalizeSiteURL(siteURL string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(siteURL), "/"))
+}
+
+type client struct {
+	jira    *jira.Client
+	siteURL string
+}
+
+func (s *Service) newClient(httpClient *http.Client, cloudID, siteURL string) (*client, error) {
+	if cloudID == "" {
+		return nil, ErrNotConnected
+	}
+	jiraClient, err := jira.NewClient(httpClient, fmt.Sprintf("%s/ex/jira/%s/", s.apiURL, cloudID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Jira client: %w", err)
+	}
+	return &client{jira: jiraClient, siteURL: strings.TrimSuffix(siteURL, "/")}, nil
+}
+
+func (c *client) GetIssue(ctx context.Context, key string, fields []string) (*jira.Issue, error) {
+	issue, resp, err := c.jira.Issue.GetWithContext(ctx, key, &jira.GetQueryOptions{Fields: strings.Join(fields, ",")})
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get issue: %w", err)
+	}
+	return issue, nil
+}
+
+func (c *client) CreateIssue(ctx context.Context, input IssueInput) (*CreatedIssue, error) {
+	issue, resp, err := c.jira.Issue.CreateWithContext(ctx, &jira.Issue{
+		Fields: &jira.IssueFields{
+			Project:     jira.Project{Key: input.ProjectKey},
+			Type:        jira.IssueType{Name: input.IssueType},
+			Summary:     input.Summary,
+			Description: input.Description,
+		},
+	})
+	if err != nil {
+		return nil, rejectionError(resp, err)
+	}
+	return &CreatedIssue{
+		Key: issue.Key,
+		URL: c.siteURL + "/browse/" + issue.Key,
+	}, nil
+}
+
+// RejectionError is returned when Jira rejects an issue, such as for an unknown project or
+// issue type, with the reasons given by Jira.
+type RejectionError struct {
+	Reasons []string
+}
+
+func (e *RejectionError) Error() string {
+	return "Jira rejected the issue: " + strings.Join(e.Reasons, "; ")
+}
+
+func rejectionError(resp *jira.Response, err error) error {
+	if resp == nil || resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("failed to create issue: %w", err)
+	}
+	var jiraErr *jira.Error
+	if !errors.As(jira.NewJiraError(resp, err), &jiraErr) {
+		return fmt.Errorf("failed to create issue: %w", err)
+	}
+
+	reasons := append([]string(nil), jiraErr.ErrorMessages...)
+	fields := make([]string, 0, len(jiraErr.Errors))
+	for field := range jiraErr.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		reasons = append(reasons, field+": "+jiraErr.Errors[field])
+	}
+	if len(reasons) == 0 {
+		return fmt.Errorf("failed to create issue: %w", err)
+	}
+	return &RejectionError{Reasons: reasons}
+}
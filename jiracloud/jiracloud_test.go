@@ -0,0 +1,140 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package jiracloud
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/integrations"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mmapitest"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeAtlassian serves the Atlassian token endpoint, the accessible resources of the token
+// and the Jira API of the site with cloud ID cloud-2.
+func newFakeAtlassian(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/oauth/token" {
+			require.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /oauth/token":
+			_, _ = w.Write([]byte(`{"access_token":"token-1","refresh_token":"refresh-1","token_type":"Bearer","expires_in":3600}`))
+		case "GET /oauth/token/accessible-resources":
+			_, _ = w.Write([]byte(`[{"id":"cloud-1","url":"https://other.atlassian.net","name":"other"},
+				{"id":"cloud-2","url":"https://example.atlassian.net","name":"example"}]`))
+		case "GET /ex/jira/cloud-2/rest/api/2/myself":
+			_, _ = w.Write([]byte(`{"accountId":"abc","displayName":"Alice Example"}`))
+		case "GET /ex/jira/cloud-2/rest/api/2/issue/MM-1":
+			require.Equal(t, "summary,comment", r.URL.Query().Get("fields"))
+			_, _ = w.Write([]byte(`{"key":"MM-1","fields":{"summary":"Crash on login"}}`))
+		case "POST /ex/jira/cloud-2/rest/api/2/issue":
+			var body map[string]map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			if body["fields"]["project"].(map[string]any)["key"] != "MM" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errorMessages":[],"errors":{"project":"valid project is required","issuetype":"valid issue type is required"}}`))
+				return
+			}
+			require.Equal(t, "Task", body["fields"]["issuetype"].(map[string]any)["name"])
+			require.Equal(t, "Login fails", body["fields"]["summary"])
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"10001","key":"MM-2"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"errorMessages":["Issue does not exist or you do not have permission to see it."]}`)
+		}
+	}))
+}
+
+func TestService(t *testing.T) {
+	server := newFakeAtlassian(t)
+	defer server.Close()
+
+	cfg := Config{Enabled: true, ClientID: "client", ClientSecret: "secret", SiteURL: "https://example.atlassian.net/"}
+	connections := integrations.New(mmapitest.NewMemoryKV(), server.Client(), "https://mm.example.com/plugins/mattermost-ai")
+	service := New(func() Config { return cfg }, connections)
+	service.authURL = server.URL
+	service.apiURL = server.URL
+
+	authURL, err := connections.AuthorizationURL("user1", IntegrationID)
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	require.Equal(t, "api.atlassian.com", parsed.Query().Get("audience"))
+	require.Equal(t, "consent", parsed.Query().Get("prompt"))
+	require.Contains(t, parsed.Query().Get("scope"), "offline_access")
+
+	_, err = service.Client(context.Background(), "user1")
+	require.ErrorIs(t, err, ErrNotConnected)
+
+	connection, err := connections.CompleteConnection(context.Background(), "user1", IntegrationID, parsed.Query().Get("state"), "code")
+	require.NoError(t, err)
+	require.Equal(t, "Alice Example", connection.Username)
+	require.Equal(t, "https://example.atlassian.net", connection.Data[dataSiteURL])
+
+	client, err := service.Client(context.Background(), "user1")
+	require.NoError(t, err)
+
+	t.Run("get issue", func(t *testing.T) {
+		issue, err := client.GetIssue(context.Background(), "MM-1", []string{"summary", "comment"})
+		require.NoError(t, err)
+		require.Equal(t, "Crash on login", issue.Fields.Summary)
+	})
+
+	t.Run("missing issue", func(t *testing.T) {
+		_, err := client.GetIssue(context.Background(), "MM-404", nil)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("create issue", func(t *testing.T) {
+		created, err := client.CreateIssue(context.Background(), IssueInput{ProjectKey: "MM", IssueType: "Task", Summary: "Login fails"})
+		require.NoError(t, err)
+		require.Equal(t, &CreatedIssue{Key: "MM-2", URL: "https://example.atlassian.net/browse/MM-2"}, created)
+	})
+
+	t.Run("issue rejected", func(t *testing.T) {
+		_, err := client.CreateIssue(context.Background(), IssueInput{ProjectKey: "NOPE", IssueType: "Story", Summary: "Login fails"})
+		var rejection *RejectionError
+		require.ErrorAs(t, err, &rejection)
+		require.Equal(t, []string{"issuetype: valid issue type is required", "project: valid project is required"}, rejection.Reasons)
+	})
+}
+
+func TestSelectSite(t *testing.T) {
+	resources := []accessibleResource{
+		{ID: "cloud-1", URL: "https://one.atlassian.net"},
+		{ID: "cloud-2", URL: "https://two.atlassian.net"},
+	}
+
+	tests := []struct {
+		name        string
+		siteURL     string
+		expectedID  string
+		expectError bool
+	}{
+		{name: "first site without a configured site", expectedID: "cloud-1"},
+		{name: "configured site", siteURL: "HTTPS://two.atlassian.net/", expectedID: "cloud-2"},
+		{name: "configured site not authorized", siteURL: "https://three.atlassian.net", expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			site, err := selectSite(resources, test.siteURL)
+			if test.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectedID, site.ID)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package jobs runs long-running analyses in the background.
+//
+// Job state lives in the plugin KV store so any node in a cluster can report
+// status or request cancellation. The node that started a job polls the store
+// and cancels the job's context when a cancellation is observed.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCanceled  = "canceled"
+
+	// KeyPrefix is the prefix of the KV keys of the jobs
+	KeyPrefix = "analysis_job_"
+
+	defaultCancelPollInterval = 2 * time.Second
+)
+
+var (
+	// ErrJobNotFound is returned when no job exists with the requested ID.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobNotRunning is returned when trying to cancel a job that already finished.
+	ErrJobNotRunning = errors.New("job not running")
+)
+
+// KVStore is the subset of the Mattermost client used to persist job state.
+type KVStore interface {
+	KVGet(key string, value interface{}) error
+	KVSet(key string, value interface{}) error
+	LogError(msg string, keyValuePairs ...interface{})
+}
+
+// Job is the persisted state of a background job.
+type Job struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	UserID    string `json:"user_id"`
+	ChannelID string `json:"channel_id"`
+	PostID    string `json:"post_id"`
+	Status    string `json:"status"`
+	Progress  string `json:"progress,omitempty"`
+	Error     string `json:"error,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// IsFinished returns true if the job reached a terminal state.
+func (j *Job) IsFinished() bool {
+	return j.Status == StatusCompleted || j.Status == StatusFailed || j.Status == StatusCanceled
+}
+
+// ProgressFunc records a human readable progress message for a running job.
+type ProgressFunc func(progress string)
+
+// RunFunc is the work performed by a job. It must return promptly once ctx is canceled.
+type RunFunc func(ctx context.Context, progress ProgressFunc) error
+
+// Service starts, tracks and cancels background jobs.
+type Service struct {
+	kv                 KVStore
+	cancelPollInterval time.Duration
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+}
+
+// New creates a job service backed by the given KV store.
+func New(kv KVStore) *Service {
+	return &Service{
+		kv:                 kv,
+		cancelPollInterval: defaultCancelPollInterval,
+		running:            make(map[string]context.CancelFunc),
+	}
+}
+
+// Running returns the number of jobs started on this server that have not finished yet
+func (s *Service) Running() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.running)
+}
+
+func jobKey(jobID string) string {
+	return KeyPrefix + jobID
+}
+
+// Start persists the job and runs it in the background. The job's ID, status and
+// timestamps are filled in by Start.
+func (s *Service) Start(job *Job, run RunFunc) (*Job, error) {
+	now := model.GetMillis()
+	job.ID = model.NewId()
+	job.Status = StatusQueued
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	if err := s.kv.KVSet(jobKey(job.ID), job); err != nil {
+		return nil, fmt.Errorf("failed to save job: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.running[job.ID] = cancel
+	s.mu.Unlock()
+
+	started := *job
+	go s.run(ctx, cancel, &started, run)
+
+	return job, nil
+}
+
+func (s *Service) run(ctx context.Context, cancel context.CancelFunc, job *Job, run RunFunc) {
+	defer func() {
+		cancel()
+		s.mu.Lock()
+		delete(s.running, job.ID)
+		s.mu.Unlock()
+	}()
+
+	go s.watchForCancellation(ctx, cancel, job.ID)
+
+	var jobMutex sync.Mutex
+	update := func(modify func(*Job)) {
+		jobMutex.Lock()
+		defer jobMutex.Unlock()
+
+		// Never overwrite a cancellation requested from another node
+		var current Job
+		if err := s.kv.KVGet(jobKey(job.ID), &current); err == nil && current.Status == StatusCanceled {
+			job.Status = StatusCanceled
+			cancel()
+		}
+
+		modify(job)
+		job.UpdatedAt = model.GetMillis()
+		if err := s.kv.KVSet(jobKey(job.ID), job); err != nil {
+			s.kv.LogError("Failed to save job status", "job_id", job.ID, "error", err)
+		}
+	}
+
+	update(func(j *Job) {
+		if j.Status != StatusCanceled {
+			j.Status = StatusRunning
+		}
+	})
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panicked: %v", r)
+			}
+		}()
+		return run(ctx, func(progress string) {
+			update(func(j *Job) {
+				j.Progress = progress
+			})
+		})
+	}()
+
+	update(func(j *Job) {
+		switch {
+		case j.Status == StatusCanceled || errors.Is(err, context.Canceled):
+			j.Status = StatusCanceled
+		case err != nil:
+			j.Status = StatusFailed
+			j.Error = err.Error()
+		default:
+			j.Status = StatusCompleted
+		}
+	})
+}
+
+// watchForCancellation cancels the job when another node marks it as canceled.
+func (s *Service) watchForCancellation(ctx context.Context, cancel context.CancelFunc, jobID string) {
+	ticker := time.NewTicker(s.cancelPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var current Job
+			if err := s.kv.KVGet(jobKey(jobID), &current); err != nil {
+				continue
+			}
+			if current.Status == StatusCanceled {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// Get returns the current state of a job.
+func (s *Service) Get(jobID string) (*Job, error) {
+	var job Job
+	if err := s.kv.KVGet(jobKey(jobID), &job); err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	if job.ID == "" {
+		return nil, ErrJobNotFound
+	}
+
+	return &job, nil
+}
+
+// Cancel requests cancellation of a job. The job stops immediately when it runs on
+// this node, otherwise the owning node picks up the request on its next poll.
+func (s *Service) Cancel(jobID string) (*Job, error) {
+	job, err := s.Get(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.IsFinished() {
+		return job, ErrJobNotRunning
+	}
+
+	job.Status = StatusCanceled
+	job.UpdatedAt = model.GetMillis()
+	if err := s.kv.KVSet(jobKey(jobID), job); err != nil {
+		return nil, fmt.Errorf("failed to save job: %w", err)
+	}
+
+	s.mu.Lock()
+	cancel, ok := s.running[jobID]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+
+	return job, nil
+}
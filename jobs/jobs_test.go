@@ -0,0 +1,123 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mmapitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForStatus(t *testing.T, service *Service, jobID string, status string) *Job {
+	t.Helper()
+	var job *Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = service.Get(jobID)
+		require.NoError(t, err)
+		return job.Status == status
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestJobLifecycle(t *testing.T) {
+	tests := []struct {
+		name           string
+		run            RunFunc
+		expectedStatus string
+		expectedError  string
+	}{
+		{
+			name: "completes",
+			run: func(_ context.Context, progress ProgressFunc) error {
+				progress("halfway")
+				return nil
+			},
+			expectedStatus: StatusCompleted,
+		},
+		{
+			name: "fails",
+			run: func(context.Context, ProgressFunc) error {
+				return errors.New("boom")
+			},
+			expectedStatus: StatusFailed,
+			expectedError:  "boom",
+		},
+		{
+			name: "panics",
+			run: func(context.Context, ProgressFunc) error {
+				panic("unexpected")
+			},
+			expectedStatus: StatusFailed,
+			expectedError:  "job panicked: unexpected",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			service := New(mmapitest.NewMemoryKV())
+
+			job, err := service.Start(&Job{Type: "test", UserID: "user1"}, tc.run)
+			require.NoError(t, err)
+			require.NotEmpty(t, job.ID)
+
+			finished := waitForStatus(t, service, job.ID, tc.expectedStatus)
+			assert.Equal(t, tc.expectedError, finished.Error)
+			assert.Equal(t, "user1", finished.UserID)
+		})
+	}
+}
+
+func TestJobCancel(t *testing.T) {
+	t.Run("cancel on the same node", func(t *testing.T) {
+		service := New(mmapitest.NewMemoryKV())
+		started := make(chan struct{})
+
+		job, err := service.Start(&Job{Type: "test"}, func(ctx context.Context, _ ProgressFunc) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		require.NoError(t, err)
+		<-started
+
+		_, err = service.Cancel(job.ID)
+		require.NoError(t, err)
+		waitForStatus(t, service, job.ID, StatusCanceled)
+
+		_, err = service.Cancel(job.ID)
+		require.ErrorIs(t, err, ErrJobNotRunning)
+	})
+
+	t.Run("cancel from another node", func(t *testing.T) {
+		kv := mmapitest.NewMemoryKV()
+		owner := New(kv)
+		owner.cancelPollInterval = 10 * time.Millisecond
+		other := New(kv)
+		started := make(chan struct{})
+
+		job, err := owner.Start(&Job{Type: "test"}, func(ctx context.Context, _ ProgressFunc) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		require.NoError(t, err)
+		<-started
+
+		_, err = other.Cancel(job.ID)
+		require.NoError(t, err)
+		waitForStatus(t, owner, job.ID, StatusCanceled)
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		service := New(mmapitest.NewMemoryKV())
+		_, err := service.Cancel("missing")
+		require.ErrorIs(t, err, ErrJobNotFound)
+	})
+}
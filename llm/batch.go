@@ -0,0 +1,64 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"errors"
+)
+
+// Types of the requests of a batch
+const (
+	BatchTypeCompletion = "completion"
+	BatchTypeEmbedding  = "embedding"
+)
+
+// Statuses of a batch
+const (
+	BatchStatusInProgress = "in_progress"
+	BatchStatusCompleted  = "completed"
+	BatchStatusFailed     = "failed"
+	BatchStatusCanceled   = "canceled"
+	BatchStatusExpired    = "expired"
+)
+
+// ErrBatchUnsupported is returned when the service can't process the type of batch
+var ErrBatchUnsupported = errors.New("batch processing is not supported by this service")
+
+// BatchRequest is one request of a batch
+type BatchRequest struct {
+	// CustomID identifies the request in the results, it must be unique in the batch
+	CustomID string `json:"custom_id"`
+	// System is the system prompt of a completion
+	System string `json:"system,omitempty"`
+	// Prompt is the user message of a completion, or the text to embed
+	Prompt string `json:"prompt"`
+	// MaxTokens bounds the generated tokens of a completion, the service's limit if 0
+	MaxTokens int `json:"max_tokens,omitempty"`
+}
+
+// BatchResult is the result of one request of a batch
+type BatchResult struct {
+	CustomID  string    `json:"custom_id"`
+	Output    string    `json:"output,omitempty"`
+	Embedding []float32 `json:"embedding,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// BatchProvider submits requests to the batch API of a provider, which processes them
+// asynchronously within a day at a lower cost than interactive requests.
+type BatchProvider interface {
+	// SubmitBatch submits the requests and returns the ID of the batch at the provider
+	SubmitBatch(ctx context.Context, batchType string, requests []BatchRequest) (string, error)
+	// BatchStatus returns one of the batch statuses
+	BatchStatus(ctx context.Context, batchID string) (string, error)
+	// BatchResults returns the results of a batch that is no longer in progress
+	BatchResults(ctx context.Context, batchID string) ([]BatchResult, error)
+	CancelBatch(ctx context.Context, batchID string) error
+}
+
+// IsBatchFinished returns whether a batch with the status will no longer change
+func IsBatchFinished(status string) bool {
+	return status != BatchStatusInProgress
+}
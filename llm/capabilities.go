@@ -0,0 +1,273 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ModelCapabilities describes what a model supports
+type ModelCapabilities struct {
+	Vision    bool `json:"vision"`
+	Tools     bool `json:"tools"`
+	Reasoning bool `json:"reasoning"`
+	JSONMode  bool `json:"jsonMode"`
+	// MaxContext is the size of the context window in tokens, 0 if unknown
+	MaxContext int `json:"maxContext"`
+}
+
+type staticCapabilities struct {
+	serviceType  string
+	modelPrefix  string
+	capabilities ModelCapabilities
+}
+
+// knownModelCapabilities lists the capabilities of well known models by model prefix. More
+// specific prefixes must come before the prefixes they start with.
+var knownModelCapabilities = []staticCapabilities{
+	{ServiceTypeOpenAI, "gpt-5", ModelCapabilities{Vision: true, Tools: true, Reasoning: true, JSONMode: true, MaxContext: 400000}},
+	{ServiceTypeOpenAI, "gpt-4.1", ModelCapabilities{Vision: true, Tools: true, JSONMode: true, MaxContext: 1047576}},
+	{ServiceTypeOpenAI, "gpt-4o", ModelCapabilities{Vision: true, Tools: true, JSONMode: true, MaxContext: 128000}},
+	{ServiceTypeOpenAI, "gpt-4-turbo", ModelCapabilities{Vision: true, Tools: true, JSONMode: true, MaxContext: 128000}},
+	{ServiceTypeOpenAI, "gpt-4", ModelCapabilities{Tools: true, MaxContext: 8192}},
+	{ServiceTypeOpenAI, "gpt-3.5-turbo", ModelCapabilities{Tools: true, JSONMode: true, MaxContext: 16385}},
+	{ServiceTypeOpenAI, "o1-mini", ModelCapabilities{Reasoning: true, MaxContext: 128000}},
+	{ServiceTypeOpenAI, "o1-preview", ModelCapabilities{Reasoning: true, MaxContext: 128000}},
+	{ServiceTypeOpenAI, "o1", ModelCapabilities{Vision: true, Tools: true, Reasoning: true, JSONMode: true, MaxContext: 200000}},
+	{ServiceTypeOpenAI, "o3-mini", ModelCapabilities{Tools: true, Reasoning: true, JSONMode: true, MaxContext: 200000}},
+	{ServiceTypeOpenAI, "o3", ModelCapabilities{Vision: true, Tools: true, Reasoning: true, JSONMode: true, MaxContext: 200000}},
+	{ServiceTypeOpenAI, "o4-mini", ModelCapabilities{Vision: true, Tools: true, Reasoning: true, JSONMode: true, MaxContext: 200000}},
+	{ServiceTypeAnthropic, "claude-3-7", ModelCapabilities{Vision: true, Tools: true, Reasoning: true, MaxContext: 200000}},
+	{ServiceTypeAnthropic, "claude-3-5-haiku", ModelCapabilities{Tools: true, MaxContext: 200000}},
+	{ServiceTypeAnthropic, "claude-3", ModelCapabilities{Vision: true, Tools: true, MaxContext: 200000}},
+	{ServiceTypeAnthropic, "claude-sonnet-4", ModelCapabilities{Vision: true, Tools: true, Reasoning: true, MaxContext: 200000}},
+	{ServiceTypeAnthropic, "claude-opus-4", ModelCapabilities{Vision: true, Tools: true, Reasoning: true, MaxContext: 200000}},
+	{ServiceTypeAnthropic, "claude-haiku-4", ModelCapabilities{Vision: true, Tools: true, Reasoning: true, MaxContext: 200000}},
+}
+
+// capabilityServiceType returns the service type whose static capabilities apply to the service type
+func capabilityServiceType(serviceType string) string {
+	// Azure deployments serve OpenAI models
+	if serviceType == ServiceTypeAzure {
+		return ServiceTypeOpenAI
+	}
+	return serviceType
+}
+
+// lookupStaticCapabilities returns the capabilities of well known models
+func lookupStaticCapabilities(serviceType, model string) (ModelCapabilities, bool) {
+	serviceType = capabilityServiceType(serviceType)
+	model = strings.ToLower(model)
+	for _, known := range knownModelCapabilities {
+		if known.serviceType == serviceType && strings.HasPrefix(model, known.modelPrefix) {
+			return known.capabilities, true
+		}
+	}
+	return ModelCapabilities{}, false
+}
+
+// CapabilityRegistry resolves the capabilities of the models of a provider from static data
+// on well known models, and keeps the models listed by each provider.
+type CapabilityRegistry struct {
+	mu sync.RWMutex
+	// listed holds the IDs of the models fetched from each provider
+	listed map[string]map[string]bool
+	// deployments holds the deployments fetched from each provider that lists deployments
+	deployments map[string]map[string]DeploymentInfo
+}
+
+func NewCapabilityRegistry() *CapabilityRegistry {
+	return &CapabilityRegistry{
+		listed:      make(map[string]map[string]bool),
+		deployments: make(map[string]map[string]DeploymentInfo),
+	}
+}
+
+// providerKey identifies a provider by its type and URL, as services of the same type may point
+// to different servers
+func providerKey(serviceType, apiURL string) string {
+	return serviceType + "|" + strings.TrimSuffix(apiURL, "/")
+}
+
+// RecordModels stores the models fetched from a provider and returns them with their known
+// capabilities.
+func (r *CapabilityRegistry) RecordModels(serviceType, apiURL string, models []ModelInfo) []ModelInfo {
+	listed := make(map[string]bool, len(models))
+	deployments := make(map[string]DeploymentInfo)
+	result := make([]ModelInfo, 0, len(models))
+	for _, model := range models {
+		listed[model.ID] = true
+		servedModel := model.ID
+		if model.Deployment != nil {
+			deployments[model.ID] = *model.Deployment
+			servedModel = model.Deployment.Model
+		}
+		if capabilities, ok := lookupStaticCapabilities(serviceType, servedModel); ok {
+			model.Capabilities = &capabilities
+		}
+		result = append(result, model)
+	}
+
+	r.mu.Lock()
+	r.listed[providerKey(serviceType, apiURL)] = listed
+	r.deployments[providerKey(serviceType, apiURL)] = deployments
+	r.mu.Unlock()
+
+	return result
+}
+
+// Lookup returns the capabilities of the model of the service, and false if they are unknown.
+// For deployments, the capabilities are those of the model they serve.
+func (r *CapabilityRegistry) Lookup(service ServiceConfig, model string) (ModelCapabilities, bool) {
+	if deployment, ok := r.deployment(service, model); ok {
+		model = deployment.Model
+	}
+	return lookupStaticCapabilities(service.Type, model)
+}
+
+// deployment returns the fetched deployment of the service with the name
+func (r *CapabilityRegistry) deployment(service ServiceConfig, name string) (DeploymentInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deployment, ok := r.deployments[providerKey(service.Type, service.APIURL)][name]
+	return deployment, ok
+}
+
+// ValidateDeployment returns a warning when the deployment doesn't exist on a service whose
+// deployments were fetched, or serves a model that doesn't support the capability, such as
+// DeploymentCapabilityChatCompletion. It returns an empty string otherwise.
+func (r *CapabilityRegistry) ValidateDeployment(service ServiceConfig, name string, capability string) string {
+	if listed, known := r.IsListed(service, name); known && !listed {
+		return fmt.Sprintf("deployment %s does not exist on the service", name)
+	}
+
+	deployment, ok := r.deployment(service, name)
+	if !ok || len(deployment.Capabilities) == 0 || slices.Contains(deployment.Capabilities, capability) {
+		return ""
+	}
+	return fmt.Sprintf("deployment %s serves model %s, which does not support %s requests", name, deployment.Model, strings.ReplaceAll(capability, "_", " "))
+}
+
+// IsListed returns whether the provider of the service lists the model, and false for known if
+// the models of the provider were never fetched.
+func (r *CapabilityRegistry) IsListed(service ServiceConfig, model string) (listed bool, known bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	models, ok := r.listed[providerKey(service.Type, service.APIURL)]
+	if !ok {
+		return false, false
+	}
+	return models[model], true
+}
+
+// Validate returns warnings about a deprecated or unlisted model, and bot settings the model of
+// the service does not support
+func (r *CapabilityRegistry) Validate(service ServiceConfig, bot BotConfig) []string {
+	model := service.DefaultModel
+	if bot.Model != "" {
+		model = bot.Model
+	}
+	if model == "" {
+		return nil
+	}
+	model = service.ResolveModel(model)
+
+	var warnings []string
+	if replacement, deprecated := DeprecatedModelReplacement(service.Type, model); deprecated {
+		warnings = append(warnings, fmt.Sprintf("model %s is deprecated by the provider, use %s instead", model, replacement))
+	}
+	if service.Type == ServiceTypeAzure {
+		if warning := r.ValidateDeployment(service, model, DeploymentCapabilityChatCompletion); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	} else if listed, known := r.IsListed(service, model); known && !listed {
+		warnings = append(warnings, fmt.Sprintf("model %s is not listed by the service", model))
+	}
+
+	capabilities, ok := r.Lookup(service, model)
+	if !ok {
+		return warnings
+	}
+	if bot.EnableVision && !capabilities.Vision {
+		if bot.ImageTextBot != "" {
+			warnings = append(warnings, fmt.Sprintf("vision is enabled but model %s does not support images, their content will be extracted by %s instead", model, bot.ImageTextBot))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("vision is enabled but model %s does not support images, images will not be sent", model))
+		}
+	}
+	if !bot.DisableTools && !capabilities.Tools {
+		warnings = append(warnings, fmt.Sprintf("tools are enabled but model %s does not support tools, tools will not be offered", model))
+	}
+	if bot.ReasoningEnabled && !capabilities.Reasoning {
+		warnings = append(warnings, fmt.Sprintf("reasoning is enabled but model %s does not support reasoning, reasoning will be disabled", model))
+	}
+	if capabilities.MaxContext > 0 && service.InputTokenLimit > capabilities.MaxContext {
+		warnings = append(warnings, fmt.Sprintf("input token limit %d exceeds the %d token context window of model %s", service.InputTokenLimit, capabilities.MaxContext, model))
+	}
+
+	return warnings
+}
+
+// CapabilityWrapper degrades requests to what the model supports, instead of letting the
+// provider reject them
+type CapabilityWrapper struct {
+	wrapped         LanguageModel
+	capabilities    ModelCapabilities
+	inputTokenLimit int
+}
+
+// NewCapabilityWrapper wraps the model so requests only use its capabilities. A non zero
+// inputTokenLimit overrides the limit of the wrapped model.
+func NewCapabilityWrapper(wrapped LanguageModel, capabilities ModelCapabilities, inputTokenLimit int) *CapabilityWrapper {
+	return &CapabilityWrapper{
+		wrapped:         wrapped,
+		capabilities:    capabilities,
+		inputTokenLimit: inputTokenLimit,
+	}
+}
+
+func (w *CapabilityWrapper) degrade(request CompletionRequest, opts []LanguageModelOption) (CompletionRequest, []LanguageModelOption) {
+	if !w.capabilities.Vision {
+		posts := make([]Post, len(request.Posts))
+		for i, post := range request.Posts {
+			post.Files = nil
+			posts[i] = post
+		}
+		request.Posts = posts
+	}
+	if !w.capabilities.Tools {
+		opts = append(opts, WithToolsDisabled())
+	}
+	if !w.capabilities.Reasoning {
+		opts = append(opts, WithReasoningDisabled())
+	}
+	return request, opts
+}
+
+func (w *CapabilityWrapper) ChatCompletion(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	request, opts = w.degrade(request, opts)
+	return w.wrapped.ChatCompletion(ctx, request, opts...)
+}
+
+func (w *CapabilityWrapper) ChatCompletionNoStream(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	request, opts = w.degrade(request, opts)
+	return w.wrapped.ChatCompletionNoStream(ctx, request, opts...)
+}
+
+func (w *CapabilityWrapper) CountTokens(text string) int {
+	return w.wrapped.CountTokens(text)
+}
+
+func (w *CapabilityWrapper) InputTokenLimit() int {
+	if w.inputTokenLimit > 0 {
+		return w.inputTokenLimit
+	}
+	return w.wrapped.InputTokenLimit()
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupCapabilities(t *testing.T) {
+	registry := NewCapabilityRegistry()
+
+	tests := []struct {
+		name        string
+		serviceType string
+		model       string
+		expectFound bool
+		expect      ModelCapabilities
+	}{
+		{
+			name:        "openai model",
+			serviceType: ServiceTypeOpenAI,
+			model:       "gpt-4o-mini",
+			expectFound: true,
+			expect:      ModelCapabilities{Vision: true, Tools: true, JSONMode: true, MaxContext: 128000},
+		},
+		{
+			name:        "more specific prefix wins",
+			serviceType: ServiceTypeOpenAI,
+			model:       "o1-mini-2024-09-12",
+			expectFound: true,
+			expect:      ModelCapabilities{Reasoning: true, MaxContext: 128000},
+		},
+		{
+			name:        "azure uses openai models",
+			serviceType: ServiceTypeAzure,
+			model:       "gpt-4.1",
+			expectFound: true,
+			expect:      ModelCapabilities{Vision: true, Tools: true, JSONMode: true, MaxContext: 1047576},
+		},
+		{
+			name:        "anthropic model",
+			serviceType: ServiceTypeAnthropic,
+			model:       "claude-3-5-haiku-latest",
+			expectFound: true,
+			expect:      ModelCapabilities{Tools: true, MaxContext: 200000},
+		},
+		{
+			name:        "model of another provider",
+			serviceType: ServiceTypeAnthropic,
+			model:       "gpt-4o",
+		},
+		{
+			name:        "unknown model",
+			serviceType: ServiceTypeOpenAICompatible,
+			model:       "llama3",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			capabilities, found := registry.Lookup(ServiceConfig{Type: tc.serviceType}, tc.model)
+			assert.Equal(t, tc.expectFound, found)
+			assert.Equal(t, tc.expect, capabilities)
+		})
+	}
+}
+
+func TestRecordModels(t *testing.T) {
+	registry := NewCapabilityRegistry()
+	service := ServiceConfig{Type: ServiceTypeOpenAICompatible, APIURL: "http://localhost:11434/v1"}
+
+	_, known := registry.IsListed(service, "llama3")
+	assert.False(t, known)
+
+	models := registry.RecordModels(ServiceTypeOpenAI, "", []ModelInfo{{ID: "gpt-4o"}, {ID: "custom-model"}})
+	require.Len(t, models, 2)
+	require.NotNil(t, models[0].Capabilities)
+	assert.True(t, models[0].Capabilities.Vision)
+	assert.Nil(t, models[1].Capabilities)
+
+	registry.RecordModels(service.Type, service.APIURL+"/", []ModelInfo{{ID: "llama3"}})
+	listed, known := registry.IsListed(service, "llama3")
+	assert.True(t, known)
+	assert.True(t, listed)
+
+	listed, known = registry.IsListed(service, "mistral")
+	assert.True(t, known)
+	assert.False(t, listed)
+}
+
+func TestValidateCapabilities(t *testing.T) {
+	registry := NewCapabilityRegistry()
+	registry.RecordModels(ServiceTypeOpenAI, "", []ModelInfo{{ID: "o1-mini"}, {ID: "gpt-4o"}})
+
+	tests := []struct {
+		name           string
+		service        ServiceConfig
+		bot            BotConfig
+		expectWarnings int
+	}{
+		{
+			name:    "supported configuration",
+			service: ServiceConfig{Type: ServiceTypeOpenAI, DefaultModel: "gpt-4o"},
+			bot:     BotConfig{EnableVision: true},
+		},
+		{
+			name:           "deprecated model without vision and tools",
+			service:        ServiceConfig{Type: ServiceTypeOpenAI, DefaultModel: "gpt-4o"},
+			bot:            BotConfig{Model: "o1-mini", EnableVision: true},
+			expectWarnings: 3,
+		},
+		{
+			name:           "model not listed",
+			service:        ServiceConfig{Type: ServiceTypeOpenAI, DefaultModel: "gpt-5"},
+			bot:            BotConfig{ReasoningEnabled: true},
+			expectWarnings: 1,
+		},
+		{
+			name:           "token limit above context window",
+			service:        ServiceConfig{Type: ServiceTypeOpenAI, DefaultModel: "gpt-4o", InputTokenLimit: 200000},
+			expectWarnings: 1,
+		},
+		{
+			name:    "unknown model",
+			service: ServiceConfig{Type: ServiceTypeOpenAICompatible, DefaultModel: "llama3"},
+			bot:     BotConfig{EnableVision: true, ReasoningEnabled: true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Len(t, registry.Validate(tc.service, tc.bot), tc.expectWarnings)
+		})
+	}
+}
+
+func TestValidateDeployments(t *testing.T) {
+	registry := NewCapabilityRegistry()
+	service := ServiceConfig{Type: ServiceTypeAzure, APIURL: "https://example.openai.azure.com"}
+
+	// Deployments are not checked before they are fetched
+	assert.Empty(t, registry.ValidateDeployment(service, "chat", DeploymentCapabilityChatCompletion))
+
+	models := registry.RecordModels(service.Type, service.APIURL, []ModelInfo{
+		{ID: "chat", Deployment: &DeploymentInfo{Model: "gpt-4o", Capabilities: []string{DeploymentCapabilityChatCompletion}}},
+		{ID: "embed", Deployment: &DeploymentInfo{Model: "text-embedding-3-small", Capabilities: []string{DeploymentCapabilityEmbeddings}}},
+		{ID: "custom", Deployment: &DeploymentInfo{Model: "fine-tuned"}},
+	})
+	require.NotNil(t, models[0].Capabilities, "deployments have the capabilities of their model")
+	assert.True(t, models[0].Capabilities.Vision)
+
+	capabilities, ok := registry.Lookup(service, "chat")
+	require.True(t, ok)
+	assert.True(t, capabilities.Tools)
+
+	assert.Empty(t, registry.ValidateDeployment(service, "chat", DeploymentCapabilityChatCompletion))
+	assert.Empty(t, registry.ValidateDeployment(service, "embed", DeploymentCapabilityEmbeddings))
+	assert.Empty(t, registry.ValidateDeployment(service, "custom", DeploymentCapabilityChatCompletion), "deployments of unknown capabilities are accepted")
+	assert.Equal(t, "deployment embed serves model text-embedding-3-small, which does not support chat completion requests",
+		registry.ValidateDeployment(service, "embed", DeploymentCapabilityChatCompletion))
+	assert.Equal(t, "deployment missing does not exist on the service", registry.ValidateDeployment(service, "missing", DeploymentCapabilityChatCompletion))
+
+	service.DefaultModel = "embed"
+	assert.Equal(t, []string{"deployment embed serves model text-embedding-3-small, which does not support chat completion requests"}, registry.Validate(service, BotConfig{}))
+}
+
+func TestCapabilityWrapper(t *testing.T) {
+	request := CompletionRequest{
+		Posts: []Post{{Role: PostRoleUser, Message: "What is in this image?", Files: []File{{MimeType: "image/png"}}}},
+	}
+
+	t.Run("degrades unsupported capabilities", func(t *testing.T) {
+		mockLLM := &MockLanguageModel{}
+		wrapper := NewCapabilityWrapper(mockLLM, ModelCapabilities{Reasoning: true}, 0)
+
+		var sent CompletionRequest
+		var opts []LanguageModelOption
+		mockLLM.On("ChatCompletionNoStream", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			sent = args.Get(0).(CompletionRequest)
+			opts = args.Get(1).([]LanguageModelOption)
+		}).Return("ok", nil)
+
+		_, err := wrapper.ChatCompletionNoStream(context.Background(), request)
+		require.NoError(t, err)
+
+		assert.Empty(t, sent.Posts[0].Files)
+		assert.Len(t, request.Posts[0].Files, 1, "the caller's request must not be modified")
+
+		cfg := LanguageModelConfig{}
+		for _, opt := range opts {
+			opt(&cfg)
+		}
+		assert.True(t, cfg.ToolsDisabled)
+		assert.False(t, cfg.ReasoningDisabled)
+	})
+
+	t.Run("keeps supported capabilities", func(t *testing.T) {
+		mockLLM := &MockLanguageModel{}
+		wrapper := NewCapabilityWrapper(mockLLM, ModelCapabilities{Vision: true, Tools: true, Reasoning: true}, 0)
+
+		mockLLM.On("ChatCompletionNoStream", mock.Anything, mock.Anything).Return("ok", nil)
+
+		_, err := wrapper.ChatCompletionNoStream(context.Background(), request)
+		require.NoError(t, err)
+
+		sent := mockLLM.Calls[0].Arguments.Get(0).(CompletionRequest)
+		assert.Len(t, sent.Posts[0].Files, 1)
+		assert.Empty(t, mockLLM.Calls[0].Arguments.Get(1))
+	})
+
+	t.Run("overrides input token limit", func(t *testing.T) {
+		mockLLM := &MockLanguageModel{}
+		mockLLM.On("InputTokenLimit").Return(100000)
+
+		assert.Equal(t, 200000, NewCapabilityWrapper(mockLLM, ModelCapabilities{}, 200000).InputTokenLimit())
+		assert.Equal(t, 100000, NewCapabilityWrapper(mockLLM, ModelCapabilities{}, 0).InputTokenLimit())
+	})
+}
@@ -30,6 +30,7 @@ type Post struct {
 	ToolUse            []ToolCall
 	Reasoning          string // Extended thinking/reasoning content from models that support it
 	ReasoningSignature string // Signature for thinking blocks (opaque verification field)
+	ResponseID         string // ID the provider stored the bot's response with, for continuing the conversation from it
 }
 
 type CompletionRequest struct {
@@ -0,0 +1,163 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrConcurrencyLimitReached is returned when starting a generation would exceed the configured concurrency limits.
+var ErrConcurrencyLimitReached = errors.New("too many concurrent generations")
+
+// ConcurrencyLimits configures how many streaming generations may run at the same time.
+// Zero means unlimited.
+type ConcurrencyLimits struct {
+	PerUser int
+	Total   int
+}
+
+// ConcurrencyLimiter tracks in-flight generations per user and in total.
+// Limits are enforced per server node.
+type ConcurrencyLimiter struct {
+	limits ConcurrencyLimits
+
+	mu      sync.Mutex
+	total   int
+	perUser map[string]int
+}
+
+// NewConcurrencyLimiter creates a limiter enforcing the given limits.
+func NewConcurrencyLimiter(limits ConcurrencyLimits) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		limits:  limits,
+		perUser: make(map[string]int),
+	}
+}
+
+// Acquire reserves a generation slot for the user. The returned function must be called
+// exactly once to release the slot.
+func (l *ConcurrencyLimiter) Acquire(userID string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.check(userID); err != nil {
+		return nil, err
+	}
+
+	l.total++
+	if userID != "" {
+		l.perUser[userID]++
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.release(userID)
+		})
+	}, nil
+}
+
+// Check returns ErrConcurrencyLimitReached if a generation for the user would be rejected
+// right now, without reserving a slot.
+func (l *ConcurrencyLimiter) Check(userID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.check(userID)
+}
+
+func (l *ConcurrencyLimiter) check(userID string) error {
+	if l.limits.Total > 0 && l.total >= l.limits.Total {
+		return fmt.Errorf("%w: bot limit of %d reached", ErrConcurrencyLimitReached, l.limits.Total)
+	}
+	if userID != "" && l.limits.PerUser > 0 && l.perUser[userID] >= l.limits.PerUser {
+		return fmt.Errorf("%w: user limit of %d reached", ErrConcurrencyLimitReached, l.limits.PerUser)
+	}
+	return nil
+}
+
+func (l *ConcurrencyLimiter) release(userID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.total--
+	if userID == "" {
+		return
+	}
+	l.perUser[userID]--
+	if l.perUser[userID] <= 0 {
+		delete(l.perUser, userID)
+	}
+}
+
+// CheckConcurrency returns ErrConcurrencyLimitReached if the model is limited and a streaming
+// generation for the user would be rejected right now. Models without limits always pass.
+func CheckConcurrency(model LanguageModel, userID string) error {
+	wrapper, ok := model.(*ConcurrencyLimitWrapper)
+	if !ok {
+		return nil
+	}
+	return wrapper.limiter.Check(userID)
+}
+
+// ConcurrencyLimitWrapper rejects streaming completions that exceed the configured concurrency limits.
+// A slot is held until the stream is closed. Non-streaming completions are short auxiliary calls
+// (titles, reactions) and are not gated.
+type ConcurrencyLimitWrapper struct {
+	wrapped LanguageModel
+	limiter *ConcurrencyLimiter
+}
+
+// NewConcurrencyLimitWrapper creates a new wrapper enforcing the given limits.
+func NewConcurrencyLimitWrapper(wrapped LanguageModel, limits ConcurrencyLimits) *ConcurrencyLimitWrapper {
+	return &ConcurrencyLimitWrapper{
+		wrapped: wrapped,
+		limiter: NewConcurrencyLimiter(limits),
+	}
+}
+
+func (w *ConcurrencyLimitWrapper) ChatCompletion(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	userID := ""
+	if request.Context != nil && request.Context.RequestingUser != nil {
+		userID = request.Context.RequestingUser.Id
+	}
+
+	release, err := w.limiter.Acquire(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := w.wrapped.ChatCompletion(ctx, request, opts...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	output := make(chan TextStreamEvent)
+	go func() {
+		defer close(output)
+		defer release()
+
+		for event := range result.Stream {
+			output <- event
+		}
+	}()
+
+	return &TextStreamResult{Stream: output}, nil
+}
+
+func (w *ConcurrencyLimitWrapper) ChatCompletionNoStream(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	return w.wrapped.ChatCompletionNoStream(ctx, request, opts...)
+}
+
+func (w *ConcurrencyLimitWrapper) CountTokens(text string) int {
+	return w.wrapped.CountTokens(text)
+}
+
+func (w *ConcurrencyLimitWrapper) InputTokenLimit() int {
+	return w.wrapped.InputTokenLimit()
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    ConcurrencyLimits
+		held      []string
+		userID    string
+		expectErr bool
+	}{
+		{
+			name:   "unlimited",
+			limits: ConcurrencyLimits{},
+			held:   []string{"user1", "user1", "user1"},
+			userID: "user1",
+		},
+		{
+			name:      "per user limit reached",
+			limits:    ConcurrencyLimits{PerUser: 2},
+			held:      []string{"user1", "user1"},
+			userID:    "user1",
+			expectErr: true,
+		},
+		{
+			name:   "per user limit does not affect other users",
+			limits: ConcurrencyLimits{PerUser: 2},
+			held:   []string{"user1", "user1"},
+			userID: "user2",
+		},
+		{
+			name:      "total limit reached",
+			limits:    ConcurrencyLimits{Total: 2},
+			held:      []string{"user1", "user2"},
+			userID:    "user3",
+			expectErr: true,
+		},
+		{
+			name:   "anonymous requests only count towards total",
+			limits: ConcurrencyLimits{PerUser: 1, Total: 3},
+			held:   []string{"", ""},
+			userID: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			limiter := NewConcurrencyLimiter(tc.limits)
+			for _, userID := range tc.held {
+				_, err := limiter.Acquire(userID)
+				require.NoError(t, err)
+			}
+
+			release, err := limiter.Acquire(tc.userID)
+			if tc.expectErr {
+				require.ErrorIs(t, err, ErrConcurrencyLimitReached)
+				return
+			}
+			require.NoError(t, err)
+			release()
+		})
+	}
+}
+
+func TestConcurrencyLimiterRelease(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimits{PerUser: 1})
+
+	release, err := limiter.Acquire("user1")
+	require.NoError(t, err)
+
+	_, err = limiter.Acquire("user1")
+	require.ErrorIs(t, err, ErrConcurrencyLimitReached)
+
+	// Releasing twice must not free more than one slot
+	release()
+	release()
+
+	release, err = limiter.Acquire("user1")
+	require.NoError(t, err)
+	_, err = limiter.Acquire("user1")
+	require.ErrorIs(t, err, ErrConcurrencyLimitReached)
+	release()
+}
+
+func TestConcurrencyLimitWrapperReleasesOnStreamClose(t *testing.T) {
+	mockLLM := &MockLanguageModel{}
+	mockLLM.On("ChatCompletion", mock.Anything, mock.Anything).Return(NewStreamFromString("hello"), nil).Once()
+	mockLLM.On("ChatCompletion", mock.Anything, mock.Anything).Return(NewStreamFromString("again"), nil).Once()
+	wrapper := NewConcurrencyLimitWrapper(mockLLM, ConcurrencyLimits{PerUser: 1})
+
+	request := CompletionRequest{
+		Context: &Context{RequestingUser: &model.User{Id: "user1"}},
+	}
+
+	result, err := wrapper.ChatCompletion(context.Background(), request)
+	require.NoError(t, err)
+
+	_, err = wrapper.ChatCompletion(context.Background(), request)
+	require.ErrorIs(t, err, ErrConcurrencyLimitReached)
+
+	text, err := result.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", text)
+
+	// ReadAll returns on the end event, drain the rest so the slot is released
+	for range result.Stream {
+	}
+
+	result, err = wrapper.ChatCompletion(context.Background(), request)
+	require.NoError(t, err)
+	text, err = result.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "again", text)
+}
+
+func TestCheckConcurrency(t *testing.T) {
+	mockLLM := &MockLanguageModel{}
+	mockLLM.On("ChatCompletion", mock.Anything, mock.Anything).Return(NewStreamFromString("hello"), nil).Once()
+	wrapper := NewConcurrencyLimitWrapper(mockLLM, ConcurrencyLimits{PerUser: 1})
+
+	require.NoError(t, CheckConcurrency(wrapper, "user1"))
+	require.NoError(t, CheckConcurrency(mockLLM, "user1"))
+
+	request := CompletionRequest{
+		Context: &Context{RequestingUser: &model.User{Id: "user1"}},
+	}
+	result, err := wrapper.ChatCompletion(context.Background(), request)
+	require.NoError(t, err)
+
+	// Checking does not reserve a slot, so it keeps failing only while the stream is open
+	require.ErrorIs(t, CheckConcurrency(wrapper, "user1"), ErrConcurrencyLimitReached)
+	require.NoError(t, CheckConcurrency(wrapper, "user2"))
+
+	for range result.Stream {
+	}
+	require.NoError(t, CheckConcurrency(wrapper, "user1"))
+}
@@ -3,6 +3,11 @@
 
 package llm
 
+import (
+	"slices"
+	"strings"
+)
+
 type ServiceConfig struct {
 	ID           string `json:"id"`
 	Name         string `json:"name"`
@@ -28,6 +33,26 @@ type ServiceConfig struct {
 	// UseResponsesAPI determines whether to use the new OpenAI Responses API
 	// Only applicable to OpenAI and OpenAI-compatible services
 	UseResponsesAPI bool `json:"useResponsesAPI"`
+
+	// ZeroDataRetention asks the provider not to store requests and responses.
+	// Only enforced per request by services that support it, see EnforcesZeroDataRetention.
+	ZeroDataRetention bool `json:"zeroDataRetention"`
+
+	// CustomHeaders are added to every request sent to the service, for example the data
+	// handling headers required by a gateway or an enterprise agreement
+	CustomHeaders map[string]string `json:"customHeaders"`
+
+	// AllowUserAPIKeys lets users register their own API key for this service. Requests of
+	// users with a key are sent with it instead of the shared APIKey.
+	AllowUserAPIKeys bool `json:"allowUserAPIKeys"`
+
+	// ModelAliases maps names used by bots and requests, for example "default-smart", to the
+	// models of the service, so a model can be replaced in one place when the provider retires it
+	ModelAliases map[string]string `json:"modelAliases"`
+
+	// RequestsPerMinute limits the requests sent to the service by all bots, serving
+	// interactive requests before background work. 0 means unlimited.
+	RequestsPerMinute int `json:"requestsPerMinute"`
 }
 
 type ChannelAccessLevel int
@@ -48,6 +73,17 @@ const (
 	UserAccessLevelNone
 )
 
+// Ways the reasoning of a bot is shown to users
+const (
+	// ReasoningDisplaySummary shows the reasoning collapsed under the response, the default
+	ReasoningDisplaySummary = "summary"
+	// ReasoningDisplayFull shows the reasoning expanded
+	ReasoningDisplayFull = "full"
+	// ReasoningDisplayHidden doesn't show the reasoning. It is still kept with the response so
+	// the model can continue from it after tool calls.
+	ReasoningDisplayHidden = "hidden"
+)
+
 type BotConfig struct {
 	ID                 string `json:"id"`
 	Name               string `json:"name"`
@@ -55,6 +91,10 @@ type BotConfig struct {
 	CustomInstructions string `json:"customInstructions"`
 	ServiceID          string `json:"serviceID"`
 
+	// OutputLanguage is the language the bot responds in regardless of the user's locale,
+	// for example "English" or "German". Empty means the user's language is used.
+	OutputLanguage string `json:"outputLanguage"`
+
 	// Model is the optional model override for this bot.
 	// If not specified, the service's DefaultModel will be used.
 	Model string `json:"model"`
@@ -69,13 +109,41 @@ type BotConfig struct {
 	UserAccessLevel    UserAccessLevel    `json:"userAccessLevel"`
 	UserIDs            []string           `json:"userIDs"`
 	TeamIDs            []string           `json:"teamIDs"`
-	MaxFileSize        int64              `json:"maxFileSize"`
+	// Groups are the names of the custom user groups and Roles the system roles, such as
+	// "system_guest", whose members are allowed or blocked by the UserAccessLevel like UserIDs
+	Groups      []string `json:"groups"`
+	Roles       []string `json:"roles"`
+	MaxFileSize int64    `json:"maxFileSize"`
+
+	// ImageTextBot is the username of the agent extracting the text of attached images when this
+	// bot's model can't read them, so text-only models still get their content. Its model must
+	// support images. Empty disables the extraction.
+	ImageTextBot string `json:"imageTextBot"`
+
+	// DelegateAgents are the usernames of the agents this agent can ask questions with the
+	// ask_agent tool, such as specialists of a domain. Empty disables the tool.
+	DelegateAgents []string `json:"delegateAgents"`
 
 	// EnabledNativeTools contains the list of enabled native tools for this bot
 	// For OpenAI: ["web_search", "file_search", "code_interpreter"] (only works when UseResponsesAPI is true)
 	// For Anthropic: ["web_search"]
 	EnabledNativeTools []string `json:"enabledNativeTools"`
 
+	// DisabledNativeTools lists by feature, such as "channel_analysis", the native tools of
+	// EnabledNativeTools the bot doesn't use in that feature
+	DisabledNativeTools map[string][]string `json:"disabledNativeTools"`
+
+	// EnableFetchURL gives the bot the fetch_url tool, which reads public web pages
+	EnableFetchURL bool `json:"enableFetchURL"`
+
+	// EnableUsageInsights gives the bot the read-only query_usage_insights tool, answering system
+	// admins' questions about the plugin usage from aggregates of the usage events
+	EnableUsageInsights bool `json:"enableUsageInsights"`
+
+	// NativeWebSearch configures the native web search when it is enabled in EnabledNativeTools.
+	// Applicable to OpenAI (with ResponsesAPI) and Anthropic
+	NativeWebSearch NativeWebSearchConfig `json:"nativeWebSearch"`
+
 	// ReasoningEnabled determines whether reasoning/thinking is enabled for this bot
 	// Applicable to OpenAI (with ResponsesAPI) and Anthropic
 	ReasoningEnabled bool `json:"reasoningEnabled"`
@@ -91,6 +159,148 @@ type BotConfig struct {
 	// Only applicable to Anthropic
 	// Default: 1/4 of OutputTokenLimit, capped at 8192
 	ThinkingBudget int `json:"thinkingBudget"`
+
+	// ReasoningDisplay is how the reasoning of the bot is shown to users, one of the
+	// ReasoningDisplay constants. Empty shows it collapsed, like ReasoningDisplaySummary.
+	ReasoningDisplay string `json:"reasoningDisplay"`
+
+	// MaxConcurrentGenerations limits how many responses this bot can generate at once.
+	// 0 means unlimited.
+	MaxConcurrentGenerations int `json:"maxConcurrentGenerations"`
+
+	// MaxConcurrentGenerationsPerUser limits how many responses a single user can have
+	// generating at once with this bot. 0 means unlimited.
+	MaxConcurrentGenerationsPerUser int `json:"maxConcurrentGenerationsPerUser"`
+
+	// EnableFollowUpSuggestions makes the bot suggest follow-up questions after each reply
+	// to a direct message or mention, at the cost of an additional short request.
+	EnableFollowUpSuggestions bool `json:"enableFollowUpSuggestions"`
+
+	// RetrieveChannelHistory answers mentions again in their thread with the recent messages of
+	// the channel when the bot reports it lacked the context to answer them.
+	RetrieveChannelHistory bool `json:"retrieveChannelHistory"`
+
+	// EnableIntentRouting classifies direct messages before answering them so they are
+	// handled by the flow and model suited to what the user asks for.
+	EnableIntentRouting bool `json:"enableIntentRouting"`
+
+	// IntentRoutes overrides how direct messages of an intent are answered when intent routing is enabled.
+	IntentRoutes []IntentRoute `json:"intentRoutes"`
+
+	// ContextComponents are the details about the request added to the bot's system prompt, in
+	// order. Empty adds the default details about the user, channel, team and time.
+	ContextComponents []ContextComponent `json:"contextComponents"`
+
+	// PostProcessors edit the completed responses of the bot before they are saved, in order,
+	// after the processors run on every response
+	PostProcessors []PostProcessorConfig `json:"postProcessors"`
+
+	// RAGContext configures how the search results the bot answers questions from are packed in
+	// its prompt
+	RAGContext RAGContextConfig `json:"ragContext"`
+}
+
+// NativeWebSearchConfig configures the sources, size and locale of a provider's native web search
+type NativeWebSearchConfig struct {
+	// AllowedDomains limits results to these domains, for example "docs.example.com".
+	// Can't be used with BlockedDomains. Only applicable to Anthropic
+	AllowedDomains []string `json:"allowedDomains"`
+
+	// BlockedDomains excludes these domains from results. Only applicable to Anthropic
+	BlockedDomains []string `json:"blockedDomains"`
+
+	// MaxUses limits the searches of a single request. 0 means the provider's default.
+	// Only applicable to Anthropic
+	MaxUses int `json:"maxUses"`
+
+	// SearchContextSize is how much of the context window search results may use
+	// Valid values: "low", "medium", "high". Empty means the provider's default, "medium"
+	// Only applicable to OpenAI
+	SearchContextSize string `json:"searchContextSize"`
+
+	// UserLocation is the approximate location of users, used to localize results
+	UserLocation WebSearchUserLocation `json:"userLocation"`
+}
+
+// WebSearchUserLocation is an approximate location. All fields are optional.
+type WebSearchUserLocation struct {
+	City   string `json:"city"`
+	Region string `json:"region"`
+	// Country is a two letter ISO 3166-1 country code, for example "US"
+	Country string `json:"country"`
+	// Timezone is an IANA time zone, for example "America/New_York"
+	Timezone string `json:"timezone"`
+}
+
+// IntentRoute configures how direct messages classified with an intent are answered
+type IntentRoute struct {
+	// Intent is the intent this route applies to, for example "search" or "summarize"
+	Intent string `json:"intent"`
+
+	// Model overrides the bot's model for messages with this intent
+	Model string `json:"model"`
+
+	// DisableTools answers messages with this intent without tools
+	DisableTools bool `json:"disableTools"`
+}
+
+// Types of the components of the context of a request
+const (
+	// ContextComponentUserProfile is the username, full name and position of the requesting user
+	ContextComponentUserProfile = "user_profile"
+	// ContextComponentChannel is the name of the channel of the request, outside of DMs
+	ContextComponentChannel = "channel"
+	// ContextComponentChannelPurpose is the purpose and header of the channel of the request
+	ContextComponentChannelPurpose = "channel_purpose"
+	// ContextComponentTeam is the name and description of the team of the request
+	ContextComponentTeam = "team"
+	// ContextComponentTime is the current time in the timezone of the requesting user
+	ContextComponentTime = "time"
+	// ContextComponentCustom is a value set by the admin, such as office hours or a support contact
+	ContextComponentCustom = "custom"
+)
+
+// DefaultContextComponentTokens is the token budget of the components that don't set one
+const DefaultContextComponentTokens = 250
+
+// ContextComponent is a detail about the request added to the system prompt
+type ContextComponent struct {
+	// Type is one of the ContextComponent constants
+	Type string `json:"type"`
+
+	// Key and Value are the label and text of custom components
+	Key   string `json:"key"`
+	Value string `json:"value"`
+
+	// MaxTokens is the budget of the component, whose text is truncated beyond it.
+	// 0 means DefaultContextComponentTokens
+	MaxTokens int `json:"maxTokens"`
+}
+
+// IsValid reports whether the component has a known type and, for custom components, a key
+func (c ContextComponent) IsValid() bool {
+	if c.MaxTokens < 0 {
+		return false
+	}
+
+	switch c.Type {
+	case ContextComponentUserProfile, ContextComponentChannel, ContextComponentChannelPurpose, ContextComponentTeam, ContextComponentTime:
+		return true
+	case ContextComponentCustom:
+		return c.Key != ""
+	default:
+		return false
+	}
+}
+
+// GetIntentRoute returns the route configured for the intent, if any
+func (c *BotConfig) GetIntentRoute(intent string) (IntentRoute, bool) {
+	for _, route := range c.IntentRoutes {
+		if route.Intent == intent {
+			return route, true
+		}
+	}
+	return IntentRoute{}, false
 }
 
 func (c *BotConfig) IsValid() bool {
@@ -107,6 +317,39 @@ func (c *BotConfig) IsValid() bool {
 		return false
 	}
 
+	if c.MaxConcurrentGenerations < 0 || c.MaxConcurrentGenerationsPerUser < 0 {
+		return false
+	}
+
+	// Domains can be allowed or blocked, not both
+	if len(c.NativeWebSearch.AllowedDomains) > 0 && len(c.NativeWebSearch.BlockedDomains) > 0 {
+		return false
+	}
+	if c.NativeWebSearch.MaxUses < 0 {
+		return false
+	}
+	switch c.NativeWebSearch.SearchContextSize {
+	case "", "low", "medium", "high":
+	default:
+		return false
+	}
+
+	for _, component := range c.ContextComponents {
+		if !component.IsValid() {
+			return false
+		}
+	}
+
+	for _, processor := range c.PostProcessors {
+		if !processor.IsValid() {
+			return false
+		}
+	}
+
+	if !c.RAGContext.IsValid() {
+		return false
+	}
+
 	return true
 }
 
@@ -141,3 +384,99 @@ func IsValidService(service ServiceConfig) bool {
 		return false
 	}
 }
+
+// Strategies ordering the search results packed in the prompt
+const (
+	// RAGStrategyRelevance orders the results by their relevance to the question
+	RAGStrategyRelevance = "relevance"
+	// RAGStrategyRecency orders the results from the most recent, for questions about things
+	// that change over time
+	RAGStrategyRecency = "recency"
+	// RAGStrategyTopK adds the most relevant results as they are, without removing duplicates
+	// nor enforcing the budget
+	RAGStrategyTopK = "top_k"
+)
+
+// RAGContextConfig configures the packing of the search results answers are based on. The zero
+// value packs results by relevance within DefaultRAGContextTokens.
+type RAGContextConfig struct {
+	// Strategy is one of the RAGStrategy constants. Empty means RAGStrategyRelevance.
+	Strategy string `json:"strategy"`
+
+	// MaxTokens is the token budget of the results. 0 means DefaultRAGContextTokens. The budget
+	// never exceeds half the input token limit of the model.
+	MaxTokens int `json:"maxTokens"`
+
+	// MaxPerSource limits the results of the same channel or document source, so a single busy
+	// channel doesn't fill the budget. 0 means unlimited.
+	MaxPerSource int `json:"maxPerSource"`
+}
+
+// DefaultRAGContextTokens is the token budget of the search results of bots that don't set one
+const DefaultRAGContextTokens = 4000
+
+// IsValid reports whether the strategy is known and the limits aren't negative
+func (c RAGContextConfig) IsValid() bool {
+	if c.MaxTokens < 0 || c.MaxPerSource < 0 {
+		return false
+	}
+
+	switch c.Strategy {
+	case "", RAGStrategyRelevance, RAGStrategyRecency, RAGStrategyTopK:
+		return true
+	default:
+		return false
+	}
+}
+
+// Types of the post-processors provided with the plugin, other types can be registered with the
+// streaming service
+const (
+	// PostProcessorDisclaimer adds the Text of the processor at the end of responses
+	PostProcessorDisclaimer = "disclaimer"
+	// PostProcessorWordFilter masks the Words of the processor in responses
+	PostProcessorWordFilter = "word_filter"
+	// PostProcessorLinkPreviews allows the previews of the links of responses when they all point
+	// to the Domains of the processor
+	PostProcessorLinkPreviews = "link_previews"
+)
+
+// PostProcessorConfig configures a post-processor of the responses of a bot
+type PostProcessorConfig struct {
+	// Type is one of the PostProcessor constants or the type of a registered processor
+	Type string `json:"type"`
+
+	// Text is the disclaimer added by PostProcessorDisclaimer
+	Text string `json:"text"`
+
+	// Words are the words masked by PostProcessorWordFilter, matched whole and ignoring case
+	Words []string `json:"words"`
+
+	// Domains are the domains whose links PostProcessorLinkPreviews allows previews of, with their
+	// subdomains
+	Domains []string `json:"domains"`
+}
+
+// IsValid reports whether the processor has a type and the settings its type requires
+func (c PostProcessorConfig) IsValid() bool {
+	switch c.Type {
+	case "":
+		return false
+	case PostProcessorDisclaimer:
+		return strings.TrimSpace(c.Text) != ""
+	case PostProcessorWordFilter:
+		return len(c.Words) > 0
+	case PostProcessorLinkPreviews:
+		return len(c.Domains) > 0
+	default:
+		return true
+	}
+}
+
+// Equal reports whether both processors have the same settings
+func (c PostProcessorConfig) Equal(other PostProcessorConfig) bool {
+	return c.Type == other.Type &&
+		c.Text == other.Text &&
+		slices.Equal(c.Words, other.Words) &&
+		slices.Equal(c.Domains, other.Domains)
+}
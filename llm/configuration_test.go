@@ -25,6 +25,9 @@ func TestBotConfig_IsValid(t *testing.T) {
 		UserIDs            []string
 		TeamIDs            []string
 		MaxFileSize        int64
+		NativeWebSearch    NativeWebSearchConfig
+		ContextComponents  []ContextComponent
+		RAGContext         RAGContextConfig
 	}
 	tests := []struct {
 		name   string
@@ -174,6 +177,146 @@ func TestBotConfig_IsValid(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "Bot with allowed and blocked web search domains should fail",
+			fields: fields{
+				ID:                 "xxx",
+				Name:               "xxx",
+				DisplayName:        "xxx",
+				ServiceID:          "service-id",
+				ChannelAccessLevel: ChannelAccessLevelAll,
+				UserAccessLevel:    UserAccessLevelAll,
+				NativeWebSearch: NativeWebSearchConfig{
+					AllowedDomains: []string{"docs.example.com"},
+					BlockedDomains: []string{"spam.example.com"},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "Bot with negative web search max uses should fail",
+			fields: fields{
+				ID:                 "xxx",
+				Name:               "xxx",
+				DisplayName:        "xxx",
+				ServiceID:          "service-id",
+				ChannelAccessLevel: ChannelAccessLevelAll,
+				UserAccessLevel:    UserAccessLevelAll,
+				NativeWebSearch:    NativeWebSearchConfig{MaxUses: -1},
+			},
+			want: false,
+		},
+		{
+			name: "Bot with unknown web search context size should fail",
+			fields: fields{
+				ID:                 "xxx",
+				Name:               "xxx",
+				DisplayName:        "xxx",
+				ServiceID:          "service-id",
+				ChannelAccessLevel: ChannelAccessLevelAll,
+				UserAccessLevel:    UserAccessLevelAll,
+				NativeWebSearch:    NativeWebSearchConfig{SearchContextSize: "huge"},
+			},
+			want: false,
+		},
+		{
+			name: "Bot with restricted web search should pass",
+			fields: fields{
+				ID:                 "xxx",
+				Name:               "xxx",
+				DisplayName:        "xxx",
+				ServiceID:          "service-id",
+				ChannelAccessLevel: ChannelAccessLevelAll,
+				UserAccessLevel:    UserAccessLevelAll,
+				NativeWebSearch: NativeWebSearchConfig{
+					AllowedDomains: []string{"docs.example.com"},
+					MaxUses:        3,
+				},
+			},
+			want: true,
+		},
+		{
+			name: "Bot with context components should pass",
+			fields: fields{
+				ID:                 "xxx",
+				Name:               "xxx",
+				DisplayName:        "xxx",
+				ServiceID:          "service-id",
+				ChannelAccessLevel: ChannelAccessLevelAll,
+				UserAccessLevel:    UserAccessLevelAll,
+				ContextComponents: []ContextComponent{
+					{Type: ContextComponentUserProfile},
+					{Type: ContextComponentChannelPurpose, MaxTokens: 100},
+					{Type: ContextComponentCustom, Key: "Office hours", Value: "9am to 5pm CET"},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "Bot with unknown context component should fail",
+			fields: fields{
+				ID:                 "xxx",
+				Name:               "xxx",
+				DisplayName:        "xxx",
+				ServiceID:          "service-id",
+				ChannelAccessLevel: ChannelAccessLevelAll,
+				UserAccessLevel:    UserAccessLevelAll,
+				ContextComponents:  []ContextComponent{{Type: "weather"}},
+			},
+			want: false,
+		},
+		{
+			name: "Bot with custom context component without key should fail",
+			fields: fields{
+				ID:                 "xxx",
+				Name:               "xxx",
+				DisplayName:        "xxx",
+				ServiceID:          "service-id",
+				ChannelAccessLevel: ChannelAccessLevelAll,
+				UserAccessLevel:    UserAccessLevelAll,
+				ContextComponents:  []ContextComponent{{Type: ContextComponentCustom, Value: "9am to 5pm CET"}},
+			},
+			want: false,
+		},
+		{
+			name: "Bot with recency RAG context should pass",
+			fields: fields{
+				ID:                 "xxx",
+				Name:               "xxx",
+				DisplayName:        "xxx",
+				ServiceID:          "service-id",
+				ChannelAccessLevel: ChannelAccessLevelAll,
+				UserAccessLevel:    UserAccessLevelAll,
+				RAGContext:         RAGContextConfig{Strategy: RAGStrategyRecency, MaxTokens: 2000, MaxPerSource: 2},
+			},
+			want: true,
+		},
+		{
+			name: "Bot with unknown RAG context strategy should fail",
+			fields: fields{
+				ID:                 "xxx",
+				Name:               "xxx",
+				DisplayName:        "xxx",
+				ServiceID:          "service-id",
+				ChannelAccessLevel: ChannelAccessLevelAll,
+				UserAccessLevel:    UserAccessLevelAll,
+				RAGContext:         RAGContextConfig{Strategy: "random"},
+			},
+			want: false,
+		},
+		{
+			name: "Bot with negative RAG context budget should fail",
+			fields: fields{
+				ID:                 "xxx",
+				Name:               "xxx",
+				DisplayName:        "xxx",
+				ServiceID:          "service-id",
+				ChannelAccessLevel: ChannelAccessLevelAll,
+				UserAccessLevel:    UserAccessLevelAll,
+				RAGContext:         RAGContextConfig{MaxTokens: -1},
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -192,6 +335,9 @@ func TestBotConfig_IsValid(t *testing.T) {
 				UserIDs:            tt.fields.UserIDs,
 				TeamIDs:            tt.fields.TeamIDs,
 				MaxFileSize:        tt.fields.MaxFileSize,
+				NativeWebSearch:    tt.fields.NativeWebSearch,
+				ContextComponents:  tt.fields.ContextComponents,
+				RAGContext:         tt.fields.RAGContext,
 			}
 			assert.Equalf(t, tt.want, c.IsValid(), "IsValid() for test case %q", tt.name)
 		})
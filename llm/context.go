@@ -31,9 +31,20 @@ type Context struct {
 	Team    *model.Team
 	Channel *model.Channel
 	Thread  []Post // Normalized posts that already have been formatted. nil if not in a thread or a root post
+	// RootPostID is the root post of the conversation the request is made in. Empty outside of conversations
+	RootPostID string
+	// TeamInstructions are provided by the admins of Team
+	TeamInstructions string
+	// Details replace the default details about the user, channel, team and time in the system
+	// prompt when the bot configures its context components
+	Details []ContextDetail
 
 	// User that is making the request
 	RequestingUser *model.User
+	// Locale of the requesting user, used to answer in their language
+	Locale string
+	// Timezone is the IANA time zone of Time, the requesting user's when known
+	Timezone string
 
 	// Bot Specific
 	BotName            string
@@ -41,10 +52,30 @@ type Context struct {
 	BotUserID          string
 	BotModel           string
 	CustomInstructions string
+	// OutputLanguage is the language the bot must always respond in, overriding the user's locale
+	OutputLanguage string
+	// ReportContextSufficiency asks the model to end its response with a context_check trailer,
+	// which ExtractContextSufficiency removes from the streamed response
+	ReportContextSufficiency bool
+	// DelegatedBy are the usernames of the agents that asked this agent with the ask_agent tool,
+	// the agent answering the user first. Empty if the agent answers the user.
+	DelegatedBy []string
 
 	Tools             *ToolStore
 	DisabledToolsInfo []ToolInfo // Info about tools that are unavailable in the current context (e.g., DM-only tools in a channel)
 	Parameters        map[string]interface{}
+
+	// Trace records the request when agent tracing is enabled. nil otherwise.
+	Trace *TraceRecorder
+
+	// Priority of the request when the capacity of the provider is limited
+	Priority Priority
+}
+
+// ContextDetail is a labeled detail about the request, built from a ContextComponent
+type ContextDetail struct {
+	Label string
+	Text  string
 }
 
 // ContextOption defines a function that configures a Context
@@ -53,7 +84,8 @@ type ContextOption func(*Context)
 // NewContext creates a new Context with the given options
 func NewContext(opts ...ContextOption) *Context {
 	c := &Context{
-		Time: time.Now().UTC().Format(time.RFC1123),
+		Time:     time.Now().UTC().Format(time.RFC1123),
+		Timezone: "UTC",
 	}
 
 	for _, opt := range opts {
@@ -0,0 +1,114 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// contextCheckOpening starts the trailer the model ends its responses with when asked to report
+// whether it had enough context, for example:
+//
+//	<context_check sufficient="false">the decisions of last week's meeting</context_check>
+const contextCheckOpening = "<context_check"
+
+var contextCheckPattern = regexp.MustCompile(`(?s)^<context_check\s+sufficient="(true|false)"\s*(?:/>|>(.*?)</context_check>)`)
+
+// ContextSufficiency is what the model reported about the context it answered with
+type ContextSufficiency struct {
+	Sufficient bool `json:"sufficient"`
+	// Missing describes the information the model lacked, empty when the context was sufficient
+	Missing string `json:"missing,omitempty"`
+}
+
+// parseContextCheck parses a context_check trailer, returning false when it is malformed
+func parseContextCheck(trailer string) (ContextSufficiency, bool) {
+	match := contextCheckPattern.FindStringSubmatch(strings.TrimSpace(trailer))
+	if match == nil {
+		return ContextSufficiency{}, false
+	}
+
+	sufficiency := ContextSufficiency{Sufficient: match[1] == "true"}
+	if !sufficiency.Sufficient {
+		sufficiency.Missing = strings.TrimSpace(match[2])
+	}
+	return sufficiency, true
+}
+
+// heldBackLength returns the length of the longest suffix of text that could start a trailer,
+// with the whitespace preceding it, which must be held back until the next chunk shows whether it does.
+func heldBackLength(text string) int {
+	held := 0
+	for length := min(len(text), len(contextCheckOpening)-1); length > 0; length-- {
+		if strings.HasPrefix(contextCheckOpening, text[len(text)-length:]) {
+			held = length
+			break
+		}
+	}
+	return len(text) - len(strings.TrimRight(text[:len(text)-held], " \t\n")) + held
+}
+
+// ExtractContextSufficiency removes the context_check trailer from the text of the stream, and
+// sends what it reports as an EventTypeContextSufficiency event before the stream ends.
+// Responses without a trailer pass through unchanged.
+func ExtractContextSufficiency(stream *TextStreamResult) *TextStreamResult {
+	output := make(chan TextStreamEvent)
+	go func() {
+		defer close(output)
+
+		var pending strings.Builder
+		var trailer strings.Builder
+		inTrailer := false
+		flush := func() {
+			if pending.Len() > 0 {
+				output <- TextStreamEvent{Type: EventTypeText, Value: pending.String()}
+				pending.Reset()
+			}
+		}
+
+		for event := range stream.Stream {
+			if event.Type == EventTypeText {
+				text, ok := event.Value.(string)
+				if !ok {
+					output <- event
+					continue
+				}
+				if inTrailer {
+					trailer.WriteString(text)
+					continue
+				}
+
+				pending.WriteString(text)
+				buffered := pending.String()
+				if index := strings.Index(buffered, contextCheckOpening); index >= 0 {
+					inTrailer = true
+					trailer.WriteString(buffered[index:])
+					pending.Reset()
+					pending.WriteString(strings.TrimRight(buffered[:index], " \t\n"))
+					flush()
+					continue
+				}
+
+				held := heldBackLength(buffered)
+				pending.Reset()
+				pending.WriteString(buffered[:len(buffered)-held])
+				flush()
+				pending.WriteString(buffered[len(buffered)-held:])
+				continue
+			}
+
+			flush()
+			if event.Type == EventTypeEnd && inTrailer {
+				if sufficiency, ok := parseContextCheck(trailer.String()); ok {
+					output <- TextStreamEvent{Type: EventTypeContextSufficiency, Value: sufficiency}
+				}
+			}
+			output <- event
+		}
+		flush()
+	}()
+
+	return &TextStreamResult{Stream: output}
+}
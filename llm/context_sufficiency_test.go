@@ -0,0 +1,77 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func streamOfChunks(chunks ...string) *TextStreamResult {
+	stream := make(chan TextStreamEvent, len(chunks)+1)
+	for _, chunk := range chunks {
+		stream <- TextStreamEvent{Type: EventTypeText, Value: chunk}
+	}
+	stream <- TextStreamEvent{Type: EventTypeEnd}
+	close(stream)
+	return &TextStreamResult{Stream: stream}
+}
+
+func TestExtractContextSufficiency(t *testing.T) {
+	tests := []struct {
+		name                string
+		chunks              []string
+		expectedText        string
+		expectedSufficiency *ContextSufficiency
+	}{
+		{
+			name:         "responses without a trailer are unchanged",
+			chunks:       []string{"The answer ", "is 42 < 43."},
+			expectedText: "The answer is 42 < 43.",
+		},
+		{
+			name:                "sufficient context",
+			chunks:              []string{"The answer is 42.\n", `<context_check sufficient="true"/>`},
+			expectedText:        "The answer is 42.",
+			expectedSufficiency: &ContextSufficiency{Sufficient: true},
+		},
+		{
+			name:                "trailer split across chunks",
+			chunks:              []string{"I don't know.\n\n<con", `text_check sufficient="false">the meeting `, "notes</context_check>"},
+			expectedText:        "I don't know.",
+			expectedSufficiency: &ContextSufficiency{Missing: "the meeting notes"},
+		},
+		{
+			name:         "malformed trailers are removed without a report",
+			chunks:       []string{"Hello\n<context_check sufficient=maybe>"},
+			expectedText: "Hello",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ExtractContextSufficiency(streamOfChunks(test.chunks...))
+
+			var text strings.Builder
+			var sufficiency *ContextSufficiency
+			var lastEvent EventType
+			for event := range result.Stream {
+				switch event.Type {
+				case EventTypeText:
+					text.WriteString(event.Value.(string))
+				case EventTypeContextSufficiency:
+					reported := event.Value.(ContextSufficiency)
+					sufficiency = &reported
+				}
+				lastEvent = event.Type
+			}
+
+			assert.Equal(t, test.expectedText, text.String())
+			assert.Equal(t, test.expectedSufficiency, sufficiency)
+			assert.Equal(t, EventTypeEnd, lastEvent)
+		})
+	}
+}
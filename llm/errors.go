@@ -0,0 +1,110 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrorCode identifies a kind of error so clients can localize and render it.
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest      ErrorCode = "invalid_request"
+	ErrorCodeUnauthorized        ErrorCode = "unauthorized"
+	ErrorCodeForbidden           ErrorCode = "forbidden"
+	ErrorCodeNotFound            ErrorCode = "not_found"
+	ErrorCodeConflict            ErrorCode = "conflict"
+	ErrorCodeRateLimited         ErrorCode = "rate_limited"
+	ErrorCodeTimeout             ErrorCode = "timeout"
+	ErrorCodeCanceled            ErrorCode = "canceled"
+	ErrorCodeProviderAuth        ErrorCode = "provider_auth"
+	ErrorCodeProviderUnavailable ErrorCode = "provider_unavailable"
+	ErrorCodeProvider            ErrorCode = "provider_error"
+	ErrorCodeInternal            ErrorCode = "internal"
+)
+
+// Error is an error with a code, a message that is safe to show to any user, whether retrying
+// may succeed and, for admins, the detail of the underlying error.
+type Error struct {
+	Code      ErrorCode `json:"code"`
+	Message   string    `json:"message"`
+	Retryable bool      `json:"retryable"`
+	// Detail is the underlying error, it may expose provider internals so only admins see it.
+	Detail string `json:"detail,omitempty"`
+
+	err error
+}
+
+// NewError creates a typed error wrapping err, which may be nil.
+func NewError(code ErrorCode, message string, retryable bool, err error) *Error {
+	e := &Error{
+		Code:      code,
+		Message:   message,
+		Retryable: retryable,
+		err:       err,
+	}
+	if err != nil {
+		e.Detail = err.Error()
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.err != nil {
+		return e.Message + ": " + e.err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// WithoutDetail returns a copy of the error that is safe to show to users who are not admins.
+func (e *Error) WithoutDetail() *Error {
+	safe := *e
+	safe.Detail = ""
+	return &safe
+}
+
+// NewProviderError classifies an error returned by a provider API from its HTTP status code.
+func NewProviderError(statusCode int, err error) *Error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return NewError(ErrorCodeRateLimited, "The LLM provider is rate limiting requests.", true, err)
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return NewError(ErrorCodeProviderAuth, "The LLM provider rejected the configured credentials.", false, err)
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		return NewError(ErrorCodeTimeout, "The LLM provider took too long to respond.", true, err)
+	case statusCode >= http.StatusInternalServerError:
+		return NewError(ErrorCodeProviderUnavailable, "The LLM provider is unavailable.", true, err)
+	case statusCode >= http.StatusBadRequest:
+		return NewError(ErrorCodeInvalidRequest, "The LLM provider could not process the request.", false, err)
+	default:
+		return NewError(ErrorCodeProvider, "An error occurred while accessing the LLM.", true, err)
+	}
+}
+
+// ClassifyError returns the typed error describing err. Errors that are not already typed and
+// not well known are reported as provider errors.
+func ClassifyError(err error) *Error {
+	var typed *Error
+	switch {
+	case errors.As(err, &typed):
+		return typed
+	case errors.Is(err, ErrConcurrencyLimitReached):
+		return NewError(ErrorCodeRateLimited, "Too many responses are being generated. Try again shortly.", true, err)
+	case errors.Is(err, ErrSchedulerTimeout):
+		return NewError(ErrorCodeRateLimited, "The LLM provider is busy. Try again shortly.", true, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewError(ErrorCodeTimeout, "The LLM provider took too long to respond.", true, err)
+	case errors.Is(err, context.Canceled):
+		return NewError(ErrorCodeCanceled, "The request was canceled.", false, err)
+	default:
+		return NewError(ErrorCodeProvider, "An error occurred while accessing the LLM.", true, err)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	providerErr := NewProviderError(http.StatusTooManyRequests, errors.New("429 slow down"))
+
+	tests := []struct {
+		name          string
+		err           error
+		expectCode    ErrorCode
+		expectRetry   bool
+		expectWrapped error
+	}{
+		{
+			name:          "typed error is kept",
+			err:           fmt.Errorf("stream failed: %w", providerErr),
+			expectCode:    ErrorCodeRateLimited,
+			expectRetry:   true,
+			expectWrapped: providerErr,
+		},
+		{
+			name:          "concurrency limit",
+			err:           ErrConcurrencyLimitReached,
+			expectCode:    ErrorCodeRateLimited,
+			expectRetry:   true,
+			expectWrapped: ErrConcurrencyLimitReached,
+		},
+		{
+			name:          "scheduler timeout",
+			err:           fmt.Errorf("%w: interactive request waited 1m", ErrSchedulerTimeout),
+			expectCode:    ErrorCodeRateLimited,
+			expectRetry:   true,
+			expectWrapped: ErrSchedulerTimeout,
+		},
+		{
+			name:          "deadline exceeded",
+			err:           context.DeadlineExceeded,
+			expectCode:    ErrorCodeTimeout,
+			expectRetry:   true,
+			expectWrapped: context.DeadlineExceeded,
+		},
+		{
+			name:          "canceled",
+			err:           context.Canceled,
+			expectCode:    ErrorCodeCanceled,
+			expectWrapped: context.Canceled,
+		},
+		{
+			name:        "unknown error",
+			err:         errors.New("connection reset"),
+			expectCode:  ErrorCodeProvider,
+			expectRetry: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			classified := ClassifyError(tc.err)
+			assert.Equal(t, tc.expectCode, classified.Code)
+			assert.Equal(t, tc.expectRetry, classified.Retryable)
+			assert.NotEmpty(t, classified.Message)
+			if tc.expectWrapped != nil {
+				assert.ErrorIs(t, classified, tc.expectWrapped)
+			}
+		})
+	}
+}
+
+func TestNewProviderError(t *testing.T) {
+	tests := []struct {
+		statusCode  int
+		expectCode  ErrorCode
+		expectRetry bool
+	}{
+		{statusCode: http.StatusTooManyRequests, expectCode: ErrorCodeRateLimited, expectRetry: true},
+		{statusCode: http.StatusUnauthorized, expectCode: ErrorCodeProviderAuth},
+		{statusCode: http.StatusForbidden, expectCode: ErrorCodeProviderAuth},
+		{statusCode: http.StatusGatewayTimeout, expectCode: ErrorCodeTimeout, expectRetry: true},
+		{statusCode: http.StatusServiceUnavailable, expectCode: ErrorCodeProviderUnavailable, expectRetry: true},
+		{statusCode: http.StatusBadRequest, expectCode: ErrorCodeInvalidRequest},
+		{statusCode: 0, expectCode: ErrorCodeProvider, expectRetry: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(http.StatusText(tc.statusCode), func(t *testing.T) {
+			providerErr := NewProviderError(tc.statusCode, errors.New("provider said no"))
+			assert.Equal(t, tc.expectCode, providerErr.Code)
+			assert.Equal(t, tc.expectRetry, providerErr.Retryable)
+			assert.Equal(t, "provider said no", providerErr.Detail)
+			assert.Empty(t, providerErr.WithoutDetail().Detail)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"strings"
+)
+
+// GlossaryTerm is an organization specific term and what it means.
+type GlossaryTerm struct {
+	Term       string
+	Definition string
+}
+
+// GlossaryProvider finds the glossary terms used in a text.
+type GlossaryProvider interface {
+	MatchGlossaryTerms(text string) []GlossaryTerm
+}
+
+// GlossaryWrapper adds the definitions of glossary terms used in the conversation to the system prompt
+// so the model understands internal terminology without users explaining it.
+type GlossaryWrapper struct {
+	wrapped  LanguageModel
+	provider GlossaryProvider
+}
+
+func NewGlossaryWrapper(wrapped LanguageModel, provider GlossaryProvider) *GlossaryWrapper {
+	return &GlossaryWrapper{
+		wrapped:  wrapped,
+		provider: provider,
+	}
+}
+
+func (w *GlossaryWrapper) addGlossary(request CompletionRequest) CompletionRequest {
+	var conversation strings.Builder
+	for _, post := range request.Posts {
+		if post.Role == PostRoleSystem {
+			continue
+		}
+		conversation.WriteString(post.Message)
+		conversation.WriteString("\n")
+	}
+
+	terms := w.provider.MatchGlossaryTerms(conversation.String())
+	if len(terms) == 0 {
+		return request
+	}
+
+	var glossary strings.Builder
+	glossary.WriteString("The following terms have a specific meaning in this organization. Use these definitions when they appear in the conversation:\n")
+	for _, term := range terms {
+		glossary.WriteString("- ")
+		glossary.WriteString(term.Term)
+		glossary.WriteString(": ")
+		glossary.WriteString(term.Definition)
+		glossary.WriteString("\n")
+	}
+
+	// Copy the posts so the caller's request is left untouched
+	posts := make([]Post, 0, len(request.Posts)+1)
+	if len(request.Posts) > 0 && request.Posts[0].Role == PostRoleSystem {
+		system := request.Posts[0]
+		system.Message = strings.TrimRight(system.Message, "\n") + "\n\n" + glossary.String()
+		posts = append(posts, system)
+		posts = append(posts, request.Posts[1:]...)
+	} else {
+		posts = append(posts, Post{Role: PostRoleSystem, Message: glossary.String()})
+		posts = append(posts, request.Posts...)
+	}
+	request.Posts = posts
+
+	return request
+}
+
+func (w *GlossaryWrapper) ChatCompletion(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	return w.wrapped.ChatCompletion(ctx, w.addGlossary(request), opts...)
+}
+
+func (w *GlossaryWrapper) ChatCompletionNoStream(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	return w.wrapped.ChatCompletionNoStream(ctx, w.addGlossary(request), opts...)
+}
+
+func (w *GlossaryWrapper) CountTokens(text string) int {
+	return w.wrapped.CountTokens(text)
+}
+
+func (w *GlossaryWrapper) InputTokenLimit() int {
+	return w.wrapped.InputTokenLimit()
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type staticGlossary map[string]string
+
+func (g staticGlossary) MatchGlossaryTerms(text string) []GlossaryTerm {
+	var terms []GlossaryTerm
+	for term, definition := range g {
+		if strings.Contains(text, term) {
+			terms = append(terms, GlossaryTerm{Term: term, Definition: definition})
+		}
+	}
+	return terms
+}
+
+func TestGlossaryWrapper(t *testing.T) {
+	tests := []struct {
+		name             string
+		posts            []Post
+		expectedPosts    int
+		expectedGlossary bool
+	}{
+		{
+			name: "no matching terms leaves the request untouched",
+			posts: []Post{
+				{Role: PostRoleSystem, Message: "system"},
+				{Role: PostRoleUser, Message: "hello"},
+			},
+			expectedPosts: 2,
+		},
+		{
+			name: "definitions are appended to the system prompt",
+			posts: []Post{
+				{Role: PostRoleSystem, Message: "system"},
+				{Role: PostRoleUser, Message: "when is the QBR?"},
+			},
+			expectedPosts:    2,
+			expectedGlossary: true,
+		},
+		{
+			name: "a system post is added when the request has none",
+			posts: []Post{
+				{Role: PostRoleUser, Message: "when is the QBR?"},
+			},
+			expectedPosts:    2,
+			expectedGlossary: true,
+		},
+		{
+			name: "terms only in the system prompt are ignored",
+			posts: []Post{
+				{Role: PostRoleSystem, Message: "summarize the QBR notes"},
+				{Role: PostRoleUser, Message: "hello"},
+			},
+			expectedPosts: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockLLM := &MockLanguageModel{}
+			var sent CompletionRequest
+			mockLLM.On("ChatCompletionNoStream", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				sent = args.Get(0).(CompletionRequest)
+			}).Return("ok", nil)
+			wrapper := NewGlossaryWrapper(mockLLM, staticGlossary{"QBR": "Quarterly Business Review"})
+
+			original := append([]Post(nil), tc.posts...)
+			_, err := wrapper.ChatCompletionNoStream(context.Background(), CompletionRequest{Posts: tc.posts})
+			require.NoError(t, err)
+
+			assert.Equal(t, original, tc.posts, "the caller's posts must not be modified")
+			require.Len(t, sent.Posts, tc.expectedPosts)
+			assert.Equal(t, PostRoleSystem, sent.Posts[0].Role)
+			if tc.expectedGlossary {
+				assert.Contains(t, sent.Posts[0].Message, "- QBR: Quarterly Business Review")
+			} else {
+				assert.NotContains(t, sent.Posts[0].Message, "Quarterly Business Review")
+			}
+			assert.Equal(t, tc.posts[len(tc.posts)-1], sent.Posts[len(sent.Posts)-1])
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testGuardrails struct {
+	policy   GuardrailPolicy
+	checked  []GuardrailViolation
+	verified bool
+	reported chan []GuardrailViolation
+}
+
+func (g *testGuardrails) GuardrailPolicy() GuardrailPolicy {
+	return g.policy
+}
+
+func (g *testGuardrails) VerifyResponse(ctx context.Context, policy GuardrailPolicy, request CompletionRequest, response string) ([]GuardrailViolation, error) {
+	g.verified = true
+	return g.checked, nil
+}
+
+func (g *testGuardrails) ReportViolations(botName string, request CompletionRequest, violations []GuardrailViolation) {
+	g.reported <- violations
+}
+
+func TestGuardrailPolicyCheckResponse(t *testing.T) {
+	policy := GuardrailPolicy{
+		Disclaimers:   []string{"This is not legal advice.", " "},
+		BannedPhrases: []string{"Guaranteed Returns"},
+	}
+
+	assert.Empty(t, policy.CheckResponse("Here you go.\nThis is not\nlegal advice."))
+	assert.Equal(t, []GuardrailViolation{
+		{Rule: GuardrailRuleBannedPhrase, Detail: "Guaranteed Returns"},
+		{Rule: GuardrailRuleMissingDisclaimer, Detail: "This is not legal advice."},
+	}, policy.CheckResponse("This fund has guaranteed returns."))
+}
+
+func TestGuardrailPolicyIsEmpty(t *testing.T) {
+	assert.True(t, GuardrailPolicy{}.IsEmpty())
+	assert.True(t, GuardrailPolicy{ForbiddenTopics: []string{""}, CheckerBot: "checker"}.IsEmpty())
+	assert.False(t, GuardrailPolicy{ForbiddenTopics: []string{"politics"}}.IsEmpty())
+}
+
+func TestGuardrailWrapper(t *testing.T) {
+	policy := GuardrailPolicy{
+		ForbiddenTopics: []string{"politics"},
+		BannedPhrases:   []string{"trust me"},
+		CheckerBot:      "checker",
+	}
+
+	t.Run("the policy is appended to the system prompt", func(t *testing.T) {
+		mockLLM := &MockLanguageModel{}
+		var sent CompletionRequest
+		mockLLM.On("ChatCompletionNoStream", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			sent = args.Get(0).(CompletionRequest)
+		}).Return("ok", nil)
+		wrapper := NewGuardrailWrapper(mockLLM, "bot", &testGuardrails{policy: policy})
+
+		posts := []Post{{Role: PostRoleSystem, Message: "system"}, {Role: PostRoleUser, Message: "hello"}}
+		_, err := wrapper.ChatCompletionNoStream(context.Background(), CompletionRequest{Posts: posts})
+		require.NoError(t, err)
+		require.Len(t, sent.Posts, 2)
+		assert.Contains(t, sent.Posts[0].Message, "system\n\n")
+		assert.Contains(t, sent.Posts[0].Message, "politics")
+		assert.Equal(t, "system", posts[0].Message)
+	})
+
+	t.Run("requests of the checker are left untouched", func(t *testing.T) {
+		mockLLM := &MockLanguageModel{}
+		var sent CompletionRequest
+		mockLLM.On("ChatCompletionNoStream", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			sent = args.Get(0).(CompletionRequest)
+		}).Return("ok", nil)
+		wrapper := NewGuardrailWrapper(mockLLM, "bot", &testGuardrails{policy: policy})
+
+		_, err := wrapper.ChatCompletionNoStream(ContextWithoutGuardrails(context.Background()), CompletionRequest{Posts: []Post{{Role: PostRoleSystem, Message: "system"}}})
+		require.NoError(t, err)
+		assert.Equal(t, "system", sent.Posts[0].Message)
+	})
+
+	t.Run("streamed responses are verified once they end", func(t *testing.T) {
+		mockLLM := &MockLanguageModel{}
+		mockLLM.On("ChatCompletion", mock.Anything, mock.Anything).Return(NewStreamFromString("trust me, vote for them"), nil)
+		guardrails := &testGuardrails{
+			policy:   policy,
+			checked:  []GuardrailViolation{{Rule: GuardrailRuleForbiddenTopic, Detail: "politics"}},
+			reported: make(chan []GuardrailViolation, 1),
+		}
+		wrapper := NewGuardrailWrapper(mockLLM, "bot", guardrails)
+
+		result, err := wrapper.ChatCompletion(context.Background(), CompletionRequest{Posts: []Post{{Role: PostRoleUser, Message: "who should I vote for?"}}})
+		require.NoError(t, err)
+		text, err := result.ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, "trust me, vote for them", text)
+
+		select {
+		case violations := <-guardrails.reported:
+			assert.True(t, guardrails.verified)
+			assert.Equal(t, []GuardrailViolation{
+				{Rule: GuardrailRuleBannedPhrase, Detail: "trust me"},
+				{Rule: GuardrailRuleForbiddenTopic, Detail: "politics"},
+			}, violations)
+		case <-time.After(5 * time.Second):
+			t.Fatal("violations were not reported")
+		}
+	})
+
+	t.Run("an empty policy is not verified", func(t *testing.T) {
+		mockLLM := &MockLanguageModel{}
+		stream := NewStreamFromString("hello")
+		mockLLM.On("ChatCompletion", mock.Anything, mock.Anything).Return(stream, nil)
+		wrapper := NewGuardrailWrapper(mockLLM, "bot", &testGuardrails{})
+
+		result, err := wrapper.ChatCompletion(context.Background(), CompletionRequest{})
+		require.NoError(t, err)
+		assert.Same(t, stream, result)
+	})
+}
@@ -21,12 +21,15 @@
 package llm
 
 import (
+	"context"
+	"time"
+
 	"github.com/google/jsonschema-go/jsonschema"
 )
 
 type LanguageModel interface {
-	ChatCompletion(conversation CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error)
-	ChatCompletionNoStream(conversation CompletionRequest, opts ...LanguageModelOption) (string, error)
+	ChatCompletion(ctx context.Context, conversation CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error)
+	ChatCompletionNoStream(ctx context.Context, conversation CompletionRequest, opts ...LanguageModelOption) (string, error)
 
 	CountTokens(text string) int
 	InputTokenLimit() int
@@ -40,6 +43,9 @@ type LanguageModelConfig struct {
 	ToolsDisabled      bool
 	AutoRunTools       []string
 	ReasoningDisabled  bool
+	StepBudget         StepBudget
+	// DisabledNativeTools are the provider's native tools, such as web_search, left out of the request
+	DisabledNativeTools []string
 }
 
 type LanguageModelOption func(*LanguageModelConfig)
@@ -67,6 +73,13 @@ func WithToolsDisabled() LanguageModelOption {
 	}
 }
 
+// WithNativeToolsDisabled leaves the provider's native tools out of the request
+func WithNativeToolsDisabled(toolNames []string) LanguageModelOption {
+	return func(cfg *LanguageModelConfig) {
+		cfg.DisabledNativeTools = append(cfg.DisabledNativeTools, toolNames...)
+	}
+}
+
 func WithAutoRunTools(toolNames []string) LanguageModelOption {
 	return func(cfg *LanguageModelConfig) {
 		cfg.AutoRunTools = toolNames
@@ -79,4 +92,15 @@ func WithReasoningDisabled() LanguageModelOption {
 	}
 }
 
+// WithStepBudget limits the time and tokens a request may spend running tools automatically.
+// Zero values use the defaults.
+func WithStepBudget(maxDuration time.Duration, maxTokens int64) LanguageModelOption {
+	return func(cfg *LanguageModelConfig) {
+		cfg.StepBudget = StepBudget{
+			MaxDuration: maxDuration,
+			MaxTokens:   maxTokens,
+		}
+	}
+}
+
 type LanguageModelWrapper func(LanguageModel) LanguageModel
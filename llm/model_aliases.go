@@ -0,0 +1,93 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"strings"
+)
+
+type deprecatedModel struct {
+	serviceType string
+	modelPrefix string
+	replacement string
+}
+
+// deprecatedModels lists models retired or scheduled for retirement by their provider, by model
+// prefix, with the model to use instead
+var deprecatedModels = []deprecatedModel{
+	{ServiceTypeOpenAI, "gpt-4-32k", "gpt-4.1"},
+	{ServiceTypeOpenAI, "gpt-4-vision-preview", "gpt-4.1"},
+	{ServiceTypeOpenAI, "gpt-4-1106-preview", "gpt-4.1"},
+	{ServiceTypeOpenAI, "gpt-4-0125-preview", "gpt-4.1"},
+	{ServiceTypeOpenAI, "gpt-4.5-preview", "gpt-4.1"},
+	{ServiceTypeOpenAI, "gpt-3.5-turbo-0301", "gpt-4.1-mini"},
+	{ServiceTypeOpenAI, "gpt-3.5-turbo-0613", "gpt-4.1-mini"},
+	{ServiceTypeOpenAI, "gpt-3.5-turbo-16k", "gpt-4.1-mini"},
+	{ServiceTypeOpenAI, "text-davinci", "gpt-4.1-mini"},
+	{ServiceTypeOpenAI, "o1-preview", "o3"},
+	{ServiceTypeOpenAI, "o1-mini", "o4-mini"},
+	{ServiceTypeAnthropic, "claude-instant", "claude-haiku-4-5"},
+	{ServiceTypeAnthropic, "claude-2", "claude-sonnet-4-5"},
+	{ServiceTypeAnthropic, "claude-3-sonnet", "claude-sonnet-4-5"},
+	{ServiceTypeAnthropic, "claude-3-opus", "claude-opus-4-1"},
+	{ServiceTypeAnthropic, "claude-3-5-sonnet", "claude-sonnet-4-5"},
+}
+
+// DeprecatedModelReplacement returns the model replacing a deprecated model of the service type,
+// and false if the model is not known to be deprecated
+func DeprecatedModelReplacement(serviceType, model string) (string, bool) {
+	serviceType = capabilityServiceType(serviceType)
+	model = strings.ToLower(model)
+	for _, deprecated := range deprecatedModels {
+		if deprecated.serviceType == serviceType && strings.HasPrefix(model, deprecated.modelPrefix) {
+			return deprecated.replacement, true
+		}
+	}
+	return "", false
+}
+
+// ResolveModel returns the model an alias of the service refers to. Names that are not
+// aliases are returned unchanged.
+func (s ServiceConfig) ResolveModel(model string) string {
+	if resolved, ok := s.ModelAliases[model]; ok && resolved != "" {
+		return resolved
+	}
+	return model
+}
+
+// ModelAliasWrapper resolves the aliases of the models requested with WithModel
+type ModelAliasWrapper struct {
+	wrapped LanguageModel
+	service ServiceConfig
+}
+
+func NewModelAliasWrapper(wrapped LanguageModel, service ServiceConfig) *ModelAliasWrapper {
+	return &ModelAliasWrapper{
+		wrapped: wrapped,
+		service: service,
+	}
+}
+
+func (w *ModelAliasWrapper) resolveOption() LanguageModelOption {
+	return func(cfg *LanguageModelConfig) {
+		cfg.Model = w.service.ResolveModel(cfg.Model)
+	}
+}
+
+func (w *ModelAliasWrapper) ChatCompletion(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	return w.wrapped.ChatCompletion(ctx, request, append(opts, w.resolveOption())...)
+}
+
+func (w *ModelAliasWrapper) ChatCompletionNoStream(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	return w.wrapped.ChatCompletionNoStream(ctx, request, append(opts, w.resolveOption())...)
+}
+
+func (w *ModelAliasWrapper) CountTokens(text string) int {
+	return w.wrapped.CountTokens(text)
+}
+
+func (w *ModelAliasWrapper) InputTokenLimit() int {
+	return w.wrapped.InputTokenLimit()
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecatedModelReplacement(t *testing.T) {
+	tests := []struct {
+		name              string
+		serviceType       string
+		model             string
+		expectDeprecated  bool
+		expectReplacement string
+	}{
+		{
+			name:              "deprecated openai model",
+			serviceType:       ServiceTypeOpenAI,
+			model:             "gpt-4-vision-preview",
+			expectDeprecated:  true,
+			expectReplacement: "gpt-4.1",
+		},
+		{
+			name:              "dated snapshot of a deprecated anthropic model",
+			serviceType:       ServiceTypeAnthropic,
+			model:             "claude-3-opus-20240229",
+			expectDeprecated:  true,
+			expectReplacement: "claude-opus-4-1",
+		},
+		{
+			name:        "current model",
+			serviceType: ServiceTypeOpenAI,
+			model:       "gpt-4o",
+		},
+		{
+			name:        "deprecated name on another provider",
+			serviceType: ServiceTypeOpenAICompatible,
+			model:       "gpt-4-32k",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			replacement, deprecated := DeprecatedModelReplacement(tc.serviceType, tc.model)
+			assert.Equal(t, tc.expectDeprecated, deprecated)
+			assert.Equal(t, tc.expectReplacement, replacement)
+		})
+	}
+}
+
+func TestResolveModel(t *testing.T) {
+	service := ServiceConfig{
+		ModelAliases: map[string]string{
+			"default-smart": "gpt-4.1",
+			"empty":         "",
+		},
+	}
+
+	assert.Equal(t, "gpt-4.1", service.ResolveModel("default-smart"))
+	assert.Equal(t, "gpt-4o", service.ResolveModel("gpt-4o"))
+	assert.Equal(t, "empty", service.ResolveModel("empty"))
+	assert.Equal(t, "gpt-4o", ServiceConfig{}.ResolveModel("gpt-4o"))
+}
+
+func TestValidateResolvesAliases(t *testing.T) {
+	registry := NewCapabilityRegistry()
+	service := ServiceConfig{
+		Type:         ServiceTypeOpenAI,
+		DefaultModel: "default-smart",
+		ModelAliases: map[string]string{"default-smart": "gpt-4-32k"},
+	}
+
+	warnings := registry.Validate(service, BotConfig{})
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "gpt-4-32k is deprecated")
+
+	service.ModelAliases["default-smart"] = "gpt-4.1"
+	assert.Empty(t, registry.Validate(service, BotConfig{}))
+}
+
+func TestModelAliasWrapper(t *testing.T) {
+	mockLLM := &MockLanguageModel{}
+	wrapper := NewModelAliasWrapper(mockLLM, ServiceConfig{
+		ModelAliases: map[string]string{"default-fast": "gpt-4.1-mini"},
+	})
+
+	var opts []LanguageModelOption
+	mockLLM.On("ChatCompletionNoStream", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		opts = args.Get(1).([]LanguageModelOption)
+	}).Return("ok", nil)
+
+	resolve := func(requestOpts ...LanguageModelOption) string {
+		_, err := wrapper.ChatCompletionNoStream(context.Background(), CompletionRequest{}, requestOpts...)
+		require.NoError(t, err)
+
+		cfg := LanguageModelConfig{Model: "gpt-4o"}
+		for _, opt := range opts {
+			opt(&cfg)
+		}
+		return cfg.Model
+	}
+
+	assert.Equal(t, "gpt-4.1-mini", resolve(WithModel("default-fast")))
+	assert.Equal(t, "o3", resolve(WithModel("o3")))
+	assert.Equal(t, "gpt-4o", resolve())
+}
@@ -7,4 +7,24 @@ package llm
 type ModelInfo struct {
 	ID          string `json:"id"`
 	DisplayName string `json:"displayName"`
+	// Capabilities are the known capabilities of the model, nil if unknown
+	Capabilities *ModelCapabilities `json:"capabilities,omitempty"`
+	// Deployment describes the model served when the ID is a deployment, nil for models
+	Deployment *DeploymentInfo `json:"deployment,omitempty"`
+}
+
+// Kinds of requests a deployment can serve
+const (
+	DeploymentCapabilityChatCompletion = "chat_completion"
+	DeploymentCapabilityEmbeddings     = "embeddings"
+)
+
+// DeploymentInfo describes a deployment, for providers like Azure OpenAI where requests name a
+// deployment of a model instead of the model
+type DeploymentInfo struct {
+	// Model is the model the deployment serves
+	Model string `json:"model"`
+	// Capabilities are the kinds of requests the model serves, such as
+	// DeploymentCapabilityChatCompletion, empty if unknown
+	Capabilities []string `json:"capabilities,omitempty"`
 }
@@ -0,0 +1,35 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import "context"
+
+// OptionsWrapper adds options to the requests of the wrapped model, after the options of the caller
+type OptionsWrapper struct {
+	wrapped LanguageModel
+	opts    []LanguageModelOption
+}
+
+func NewOptionsWrapper(wrapped LanguageModel, opts ...LanguageModelOption) *OptionsWrapper {
+	return &OptionsWrapper{
+		wrapped: wrapped,
+		opts:    opts,
+	}
+}
+
+func (w *OptionsWrapper) ChatCompletion(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	return w.wrapped.ChatCompletion(ctx, request, append(opts, w.opts...)...)
+}
+
+func (w *OptionsWrapper) ChatCompletionNoStream(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	return w.wrapped.ChatCompletionNoStream(ctx, request, append(opts, w.opts...)...)
+}
+
+func (w *OptionsWrapper) CountTokens(text string) int {
+	return w.wrapped.CountTokens(text)
+}
+
+func (w *OptionsWrapper) InputTokenLimit() int {
+	return w.wrapped.InputTokenLimit()
+}
@@ -0,0 +1,391 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPayloadCaptureRequests is how many requests are kept when no limit is configured
+	DefaultPayloadCaptureRequests = 20
+	// maxPayloadCaptureRequests bounds the memory used by the captured payloads
+	maxPayloadCaptureRequests = 200
+	// maxCapturedBodyBytes is how much of each request and response body is kept
+	maxCapturedBodyBytes = 64 * 1024
+)
+
+// PayloadCaptureConfig configures the capture of the payloads exchanged with providers for debugging
+type PayloadCaptureConfig struct {
+	Enabled bool `json:"enabled"`
+	// MaxRequests is how many of the most recent requests are kept, DefaultPayloadCaptureRequests when unset
+	MaxRequests int `json:"maxRequests"`
+	// IncludeContent keeps the text of prompts and responses, which are redacted otherwise.
+	// Credentials are always redacted.
+	IncludeContent bool `json:"includeContent"`
+}
+
+// CapturedPayload is a request sent to a provider and its response, with credentials and,
+// unless configured otherwise, content redacted.
+type CapturedPayload struct {
+	ServiceName     string              `json:"service_name"`
+	ServiceType     string              `json:"service_type"`
+	StartedAt       time.Time           `json:"started_at"`
+	DurationMs      int64               `json:"duration_ms"`
+	Method          string              `json:"method"`
+	URL             string              `json:"url"`
+	RequestHeaders  map[string][]string `json:"request_headers"`
+	RequestBody     string              `json:"request_body,omitempty"`
+	StatusCode      int                 `json:"status_code,omitempty"`
+	ResponseHeaders map[string][]string `json:"response_headers,omitempty"`
+	ResponseBody    string              `json:"response_body,omitempty"`
+	Error           string              `json:"error,omitempty"`
+	// Truncated is set when a body was longer than what is kept of it
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// PayloadCapture keeps the sanitized payloads of the most recent provider requests
+type PayloadCapture struct {
+	config func() PayloadCaptureConfig
+
+	mu       sync.Mutex
+	payloads []*CapturedPayload
+}
+
+// NewPayloadCapture creates a capture reading its configuration on every request, so it can be
+// enabled and disabled without recreating the clients.
+func NewPayloadCapture(config func() PayloadCaptureConfig) *PayloadCapture {
+	return &PayloadCapture{config: config}
+}
+
+// Client returns a client capturing the requests sent with it to the service while the capture is enabled
+func (c *PayloadCapture) Client(client *http.Client, service ServiceConfig) *http.Client {
+	if c == nil {
+		return client
+	}
+
+	var capturing http.Client
+	if client != nil {
+		capturing = *client
+	}
+	capturing.Transport = &captureTransport{
+		base:    capturing.Transport,
+		capture: c,
+		service: service,
+	}
+	return &capturing
+}
+
+// Payloads returns the captured payloads, the most recent first
+func (c *PayloadCapture) Payloads() []CapturedPayload {
+	if c == nil {
+		return []CapturedPayload{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make([]CapturedPayload, 0, len(c.payloads))
+	for i := len(c.payloads) - 1; i >= 0; i-- {
+		payload := *c.payloads[i]
+		result = append(result, payload)
+	}
+	return result
+}
+
+// Clear discards the captured payloads
+func (c *PayloadCapture) Clear() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.payloads = nil
+}
+
+func (c *PayloadCapture) add(payload *CapturedPayload, limit int) {
+	if limit <= 0 {
+		limit = DefaultPayloadCaptureRequests
+	}
+	limit = min(limit, maxPayloadCaptureRequests)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.payloads = append(c.payloads, payload)
+	if len(c.payloads) > limit {
+		c.payloads = append([]*CapturedPayload(nil), c.payloads[len(c.payloads)-limit:]...)
+	}
+}
+
+// update changes a payload already added, under the lock guarding the reads of Payloads
+func (c *PayloadCapture) update(change func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	change()
+}
+
+// captureTransport records the requests sent through it in the capture
+type captureTransport struct {
+	base    http.RoundTripper
+	capture *PayloadCapture
+	service ServiceConfig
+}
+
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	config := t.capture.config()
+	if !config.Enabled {
+		return base.RoundTrip(req)
+	}
+
+	payload := &CapturedPayload{
+		ServiceName:    t.service.Name,
+		ServiceType:    t.service.Type,
+		StartedAt:      time.Now(),
+		Method:         req.Method,
+		URL:            redactURL(req.URL),
+		RequestHeaders: t.redactHeaders(req.Header),
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		payload.RequestBody, payload.Truncated = redactBody(body, config.IncludeContent)
+	}
+
+	resp, err := base.RoundTrip(req)
+	payload.DurationMs = time.Since(payload.StartedAt).Milliseconds()
+	if err != nil {
+		payload.Error = err.Error()
+		t.capture.add(payload, config.MaxRequests)
+		return nil, err
+	}
+
+	payload.StatusCode = resp.StatusCode
+	payload.ResponseHeaders = t.redactHeaders(resp.Header)
+	t.capture.add(payload, config.MaxRequests)
+
+	// Responses are streamed, their body is recorded once it has been read
+	resp.Body = &capturingBody{
+		ReadCloser:     resp.Body,
+		capture:        t.capture,
+		payload:        payload,
+		includeContent: config.IncludeContent,
+	}
+	return resp, nil
+}
+
+// sensitiveHeaderParts are the parts of the header names whose values are always redacted
+var sensitiveHeaderParts = []string{"auth", "key", "token", "secret", "cookie", "signature", "credential"}
+
+func (t *captureTransport) redactHeaders(headers http.Header) map[string][]string {
+	redacted := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		if t.isSensitiveHeader(name) {
+			redacted[name] = []string{"[redacted]"}
+			continue
+		}
+		redacted[name] = append([]string(nil), values...)
+	}
+	return redacted
+}
+
+func (t *captureTransport) isSensitiveHeader(name string) bool {
+	// Custom headers of the service often carry credentials of gateways
+	for custom := range t.service.CustomHeaders {
+		if strings.EqualFold(custom, name) {
+			return true
+		}
+	}
+
+	lower := strings.ToLower(name)
+	for _, part := range sensitiveHeaderParts {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL redacts the query parameters that may carry credentials
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	query := redacted.Query()
+	for name := range query {
+		lower := strings.ToLower(name)
+		for _, part := range sensitiveHeaderParts {
+			if strings.Contains(lower, part) {
+				query.Set(name, "[redacted]")
+				break
+			}
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// capturingBody records the response body in the payload as it is read
+type capturingBody struct {
+	io.ReadCloser
+	capture        *PayloadCapture
+	payload        *CapturedPayload
+	includeContent bool
+
+	buffer    bytes.Buffer
+	truncated bool
+	recorded  bool
+}
+
+func (b *capturingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		remaining := maxCapturedBodyBytes - b.buffer.Len()
+		if n > remaining {
+			b.truncated = true
+		}
+		b.buffer.Write(p[:min(n, max(remaining, 0))])
+	}
+	if err == io.EOF {
+		b.record()
+	}
+	return n, err
+}
+
+func (b *capturingBody) Close() error {
+	b.record()
+	return b.ReadCloser.Close()
+}
+
+func (b *capturingBody) record() {
+	if b.recorded {
+		return
+	}
+	b.recorded = true
+
+	body, truncated := redactBody(b.buffer.Bytes(), b.includeContent)
+	b.capture.update(func() {
+		b.payload.ResponseBody = body
+		b.payload.Truncated = b.payload.Truncated || truncated || b.truncated
+	})
+}
+
+// structuralFields are the JSON fields whose string values describe the shape of the exchange
+// rather than its content, and are kept when content is redacted.
+var structuralFields = map[string]bool{
+	"model":                true,
+	"type":                 true,
+	"role":                 true,
+	"id":                   true,
+	"object":               true,
+	"name":                 true,
+	"status":               true,
+	"event":                true,
+	"call_id":              true,
+	"tool_use_id":          true,
+	"tool_choice":          true,
+	"finish_reason":        true,
+	"stop_reason":          true,
+	"stop_sequence":        true,
+	"previous_response_id": true,
+	"reasoning_effort":     true,
+	"effort":               true,
+	"service_tier":         true,
+	"media_type":           true,
+	"anthropic_version":    true,
+	"code":                 true,
+}
+
+// redactBody returns the body to record, with the content redacted unless includeContent is
+// set, and whether it was truncated.
+func redactBody(body []byte, includeContent bool) (string, bool) {
+	truncated := len(body) > maxCapturedBodyBytes
+	if truncated {
+		body = body[:maxCapturedBodyBytes]
+	}
+	if includeContent {
+		return string(body), truncated
+	}
+
+	if redacted, ok := redactJSON(body); ok {
+		return redacted, truncated
+	}
+
+	// Server-sent events carry a JSON document per data line
+	if bytes.Contains(body, []byte("data:")) {
+		lines := strings.Split(string(body), "\n")
+		for i, line := range lines {
+			data, isData := strings.CutPrefix(line, "data:")
+			if !isData {
+				continue
+			}
+			if redacted, ok := redactJSON([]byte(strings.TrimSpace(data))); ok {
+				lines[i] = "data: " + redacted
+			} else if strings.TrimSpace(data) != "[DONE]" {
+				lines[i] = "data: " + redactedText(len(data))
+			}
+		}
+		return strings.Join(lines, "\n"), truncated
+	}
+
+	if len(body) == 0 {
+		return "", truncated
+	}
+	return redactedText(len(body)), truncated
+}
+
+func redactJSON(data []byte) (string, bool) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", false
+	}
+	redacted, err := json.Marshal(redactValue(value, ""))
+	if err != nil {
+		return "", false
+	}
+	return string(redacted), true
+}
+
+func redactValue(value any, field string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = redactValue(item, key)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item, field)
+		}
+		return v
+	case string:
+		if structuralFields[field] {
+			return v
+		}
+		return redactedText(len(v))
+	default:
+		return v
+	}
+}
+
+func redactedText(length int) string {
+	return fmt.Sprintf("[redacted %d bytes]", length)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadCapture(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"type\":\"delta\",\"text\":\"The answer is 42\"}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	config := PayloadCaptureConfig{Enabled: true, MaxRequests: 2}
+	capture := NewPayloadCapture(func() PayloadCaptureConfig { return config })
+	service := ServiceConfig{Name: "Gateway", Type: ServiceTypeOpenAICompatible, CustomHeaders: map[string]string{"X-Gateway": "secret"}}
+	client := UpstreamHTTPClient(capture.Client(&http.Client{}, service), service)
+
+	send := func(prompt string) string {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/chat?key=sk-123&api-version=1", strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"`+prompt+`"}]}`))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer sk-123")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return string(body)
+	}
+
+	t.Run("credentials and content are redacted", func(t *testing.T) {
+		body := send("What is the answer?")
+		assert.Contains(t, body, "The answer is 42", "the response is passed through unchanged")
+
+		payloads := capture.Payloads()
+		require.Len(t, payloads, 1)
+		payload := payloads[0]
+		assert.Equal(t, "Gateway", payload.ServiceName)
+		assert.Equal(t, http.StatusOK, payload.StatusCode)
+		assert.Equal(t, []string{"[redacted]"}, payload.RequestHeaders["Authorization"])
+		assert.Equal(t, []string{"[redacted]"}, payload.RequestHeaders["X-Gateway"])
+		assert.NotContains(t, payload.URL, "sk-123")
+		assert.Contains(t, payload.URL, "api-version=1")
+
+		assert.Contains(t, payload.RequestBody, `"model":"gpt-4o"`)
+		assert.Contains(t, payload.RequestBody, `"role":"user"`)
+		assert.NotContains(t, payload.RequestBody, "What is the answer?")
+		assert.Contains(t, payload.ResponseBody, `"type":"delta"`)
+		assert.Contains(t, payload.ResponseBody, "data: [DONE]")
+		assert.NotContains(t, payload.ResponseBody, "The answer is 42")
+	})
+
+	t.Run("content is kept when configured", func(t *testing.T) {
+		config.IncludeContent = true
+		defer func() { config.IncludeContent = false }()
+
+		send("Second question")
+		payload := capture.Payloads()[0]
+		assert.Contains(t, payload.RequestBody, "Second question")
+		assert.Contains(t, payload.ResponseBody, "The answer is 42")
+		assert.Equal(t, []string{"[redacted]"}, payload.RequestHeaders["Authorization"])
+	})
+
+	t.Run("only the most recent requests are kept", func(t *testing.T) {
+		send("Third question")
+		payloads := capture.Payloads()
+		require.Len(t, payloads, 2)
+		assert.Contains(t, payloads[1].RequestBody, "Second question")
+		assert.Contains(t, payloads[0].RequestBody, "[redacted 14 bytes]")
+	})
+
+	t.Run("nothing is captured while disabled", func(t *testing.T) {
+		capture.Clear()
+		config.Enabled = false
+		send("Fourth question")
+		assert.Empty(t, capture.Payloads())
+	})
+}
@@ -7,12 +7,21 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"sort"
 	"strings"
 	"text/template"
 )
 
+// PromptOverrides provides admin edited versions of the default prompt templates.
+type PromptOverrides interface {
+	// GetPromptOverrides returns the template code of every overridden prompt for the bot, keyed by prompt name.
+	GetPromptOverrides(botID string) map[string]string
+}
+
 type Prompts struct {
 	templates *template.Template
+	source    fs.FS
+	overrides PromptOverrides
 }
 
 const PromptExtension = "tmpl"
@@ -25,13 +34,63 @@ func NewPrompts(input fs.FS) (*Prompts, error) {
 
 	return &Prompts{
 		templates: templates,
+		source:    input,
 	}, nil
 }
 
+// SetOverrides sets the source of prompt overrides. Must be called before the prompts are used.
+func (p *Prompts) SetOverrides(overrides PromptOverrides) {
+	p.overrides = overrides
+}
+
 func withPromptExtension(filename string) string {
 	return filename + "." + PromptExtension
 }
 
+// Names returns the names of the default prompt templates.
+func (p *Prompts) Names() []string {
+	var names []string
+	for _, tmpl := range p.templates.Templates() {
+		if name, ok := strings.CutSuffix(tmpl.Name(), "."+PromptExtension); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default returns the code of the shipped template, used when no override is set.
+func (p *Prompts) Default(templateName string) (string, error) {
+	data, err := fs.ReadFile(p.source, withPromptExtension(templateName))
+	if err != nil {
+		return "", errors.New("template not found")
+	}
+	return string(data), nil
+}
+
+// Validate checks that templateCode parses as an override of templateName.
+func (p *Prompts) Validate(templateName, templateCode string) error {
+	_, err := p.withOverrides(map[string]string{templateName: templateCode})
+	return err
+}
+
+// withOverrides returns a copy of the templates with the overridden templates redefined,
+// so that overrides also apply where other templates include them.
+func (p *Prompts) withOverrides(overrides map[string]string) (*template.Template, error) {
+	templates, err := p.templates.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	for name, code := range overrides {
+		if _, err := templates.New(withPromptExtension(name)).Parse(code); err != nil {
+			return nil, fmt.Errorf("unable to parse override of %s: %w", name, err)
+		}
+	}
+
+	return templates, nil
+}
+
 func (p *Prompts) FormatString(templateCode string, context *Context) (string, error) {
 	template, err := p.templates.Clone()
 	if err != nil {
@@ -51,7 +110,18 @@ func (p *Prompts) FormatString(templateCode string, context *Context) (string, e
 }
 
 func (p *Prompts) Format(templateName string, context *Context) (string, error) {
-	tmpl := p.templates.Lookup(withPromptExtension(templateName))
+	templates := p.templates
+	if p.overrides != nil && context != nil {
+		if overrides := p.overrides.GetPromptOverrides(context.BotUserID); len(overrides) > 0 {
+			overridden, err := p.withOverrides(overrides)
+			if err != nil {
+				return "", err
+			}
+			templates = overridden
+		}
+	}
+
+	tmpl := templates.Lookup(withPromptExtension(templateName))
 	if tmpl == nil {
 		return "", errors.New("template not found")
 	}
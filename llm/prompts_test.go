@@ -0,0 +1,100 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticOverrides map[string]map[string]string
+
+func (o staticOverrides) GetPromptOverrides(botID string) map[string]string {
+	return o[botID]
+}
+
+func newTestPrompts(t *testing.T) *Prompts {
+	t.Helper()
+	prompts, err := NewPrompts(fstest.MapFS{
+		"personality.tmpl": {Data: []byte(`I am {{.BotName}}.`)},
+		"system.tmpl":      {Data: []byte(`{{template "personality.tmpl" .}} Answer the question.`)},
+	})
+	require.NoError(t, err)
+	return prompts
+}
+
+func TestPromptsFormatWithOverrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides staticOverrides
+		botID     string
+		prompt    string
+		expected  string
+	}{
+		{
+			name:     "no overrides",
+			prompt:   "system",
+			expected: "I am Copilot. Answer the question.",
+		},
+		{
+			name:      "override for another bot",
+			overrides: staticOverrides{"otherbot": {"system": "Other"}},
+			botID:     "botid",
+			prompt:    "system",
+			expected:  "I am Copilot. Answer the question.",
+		},
+		{
+			name:      "override of the requested prompt",
+			overrides: staticOverrides{"botid": {"system": "Be brief, {{.BotName}}."}},
+			botID:     "botid",
+			prompt:    "system",
+			expected:  "Be brief, Copilot.",
+		},
+		{
+			name:      "override of an included prompt",
+			overrides: staticOverrides{"botid": {"personality": "You are {{.BotName}}, a pirate."}},
+			botID:     "botid",
+			prompt:    "system",
+			expected:  "You are Copilot, a pirate. Answer the question.",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			prompts := newTestPrompts(t)
+			if tc.overrides != nil {
+				prompts.SetOverrides(tc.overrides)
+			}
+
+			result, err := prompts.Format(tc.prompt, &Context{BotName: "Copilot", BotUserID: tc.botID})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+
+			// Overrides must never leak into the shared default templates
+			prompts.SetOverrides(nil)
+			result, err = prompts.Format(tc.prompt, &Context{BotName: "Copilot"})
+			require.NoError(t, err)
+			assert.Equal(t, "I am Copilot. Answer the question.", result)
+		})
+	}
+}
+
+func TestPromptsDefaultsAndValidation(t *testing.T) {
+	prompts := newTestPrompts(t)
+
+	assert.Equal(t, []string{"personality", "system"}, prompts.Names())
+
+	defaultTemplate, err := prompts.Default("personality")
+	require.NoError(t, err)
+	assert.Equal(t, "I am {{.BotName}}.", defaultTemplate)
+
+	_, err = prompts.Default("missing")
+	assert.Error(t, err)
+
+	assert.NoError(t, prompts.Validate("system", `{{template "personality.tmpl" .}} Be brief.`))
+	assert.Error(t, prompts.Validate("system", `{{if .BotName}}unterminated`))
+}
@@ -0,0 +1,380 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNoRecordedInteraction is returned by the replay model when a request has no matching recording.
+var ErrNoRecordedInteraction = errors.New("no recorded interaction for request")
+
+// Recording is the fixture format written by RecordingModel and read by ReplayModel.
+type Recording struct {
+	InputTokenLimit int                   `json:"input_token_limit"`
+	Interactions    []RecordedInteraction `json:"interactions"`
+}
+
+// RecordedInteraction is a single request and the provider response to it.
+type RecordedInteraction struct {
+	// RequestHash identifies the request, see HashRequest.
+	RequestHash string `json:"request_hash"`
+	// Request is a readable copy of the request posts to make fixtures reviewable.
+	Request   []RecordedPost  `json:"request"`
+	Streaming bool            `json:"streaming"`
+	Response  string          `json:"response,omitempty"`
+	Events    []RecordedEvent `json:"events,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// RecordedPost is the readable form of a request post.
+type RecordedPost struct {
+	Role    PostRole   `json:"role"`
+	Message string     `json:"message"`
+	ToolUse []ToolCall `json:"tool_use,omitempty"`
+}
+
+// RecordedEvent is a stream event with the time elapsed since the previous event.
+type RecordedEvent struct {
+	DelayMS     int64          `json:"delay_ms"`
+	Type        EventType      `json:"type"`
+	Text        string         `json:"text,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	ToolCalls   []ToolCall     `json:"tool_calls,omitempty"`
+	Reasoning   *ReasoningData `json:"reasoning,omitempty"`
+	Annotations []Annotation   `json:"annotations,omitempty"`
+	Usage       *TokenUsage    `json:"usage,omitempty"`
+	TotalUsage  *RequestUsage  `json:"total_usage,omitempty"`
+}
+
+func newRecordedEvent(event TextStreamEvent, delay time.Duration) RecordedEvent {
+	recorded := RecordedEvent{
+		DelayMS: delay.Milliseconds(),
+		Type:    event.Type,
+	}
+
+	switch value := event.Value.(type) {
+	case string:
+		recorded.Text = value
+	case error:
+		recorded.Error = value.Error()
+	case []ToolCall:
+		recorded.ToolCalls = value
+	case ReasoningData:
+		recorded.Reasoning = &value
+	case []Annotation:
+		recorded.Annotations = value
+	case TokenUsage:
+		recorded.Usage = &value
+	case RequestUsage:
+		recorded.TotalUsage = &value
+	}
+
+	return recorded
+}
+
+func (r RecordedEvent) toStreamEvent() TextStreamEvent {
+	event := TextStreamEvent{Type: r.Type}
+
+	switch r.Type {
+	case EventTypeText, EventTypeReasoning, EventTypeStoppedEarly, EventTypeFinish, EventTypeRefusal, EventTypeToolRunning, EventTypeResponseID:
+		event.Value = r.Text
+	case EventTypeError:
+		event.Value = errors.New(r.Error)
+	case EventTypeToolCalls:
+		event.Value = r.ToolCalls
+	case EventTypeReasoningEnd:
+		if r.Reasoning != nil {
+			event.Value = *r.Reasoning
+		}
+	case EventTypeAnnotations:
+		event.Value = r.Annotations
+	case EventTypeUsage:
+		if r.Usage != nil {
+			event.Value = *r.Usage
+		}
+	case EventTypeUsageTotal:
+		if r.TotalUsage != nil {
+			event.Value = *r.TotalUsage
+		}
+	}
+
+	return event
+}
+
+// HashRequest returns a stable identifier for a request and the options affecting its output.
+// Contexts are reduced to the names of the available tools since user, channel and time
+// details only reach the model through the posts.
+func HashRequest(request CompletionRequest, opts ...LanguageModelOption) string {
+	cfg := LanguageModelConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var toolNames []string
+	if request.Context != nil && request.Context.Tools != nil && !cfg.ToolsDisabled {
+		for _, tool := range request.Context.Tools.GetTools() {
+			toolNames = append(toolNames, tool.Name)
+		}
+		sort.Strings(toolNames)
+	}
+
+	key := struct {
+		Posts              []RecordedPost
+		Tools              []string
+		Model              string
+		MaxGeneratedTokens int
+		JSONOutput         bool
+	}{
+		Posts:              recordedPosts(request.Posts),
+		Tools:              toolNames,
+		Model:              cfg.Model,
+		MaxGeneratedTokens: cfg.MaxGeneratedTokens,
+		JSONOutput:         cfg.JSONOutputFormat != nil,
+	}
+
+	// Marshalling plain structs, slices and strings cannot fail
+	data, _ := json.Marshal(key)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func recordedPosts(posts []Post) []RecordedPost {
+	recorded := make([]RecordedPost, 0, len(posts))
+	for _, post := range posts {
+		recorded = append(recorded, RecordedPost{
+			Role:    post.Role,
+			Message: post.Message,
+			ToolUse: post.ToolUse,
+		})
+	}
+	return recorded
+}
+
+// LoadRecording reads a fixture written by RecordingModel.Save.
+func LoadRecording(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+
+	var recording Recording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return nil, fmt.Errorf("failed to parse recording: %w", err)
+	}
+
+	return &recording, nil
+}
+
+// RecordingModel passes requests to a real provider and records the traffic so it can be replayed.
+type RecordingModel struct {
+	wrapped LanguageModel
+
+	mu           sync.Mutex
+	interactions []RecordedInteraction
+}
+
+// NewRecordingModel creates a model recording the traffic of wrapped.
+func NewRecordingModel(wrapped LanguageModel) *RecordingModel {
+	return &RecordingModel{
+		wrapped: wrapped,
+	}
+}
+
+func (r *RecordingModel) add(interaction RecordedInteraction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, interaction)
+}
+
+func (r *RecordingModel) ChatCompletion(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	interaction := RecordedInteraction{
+		RequestHash: HashRequest(request, opts...),
+		Request:     recordedPosts(request.Posts),
+		Streaming:   true,
+	}
+
+	result, err := r.wrapped.ChatCompletion(ctx, request, opts...)
+	if err != nil {
+		interaction.Error = err.Error()
+		r.add(interaction)
+		return nil, err
+	}
+
+	output := make(chan TextStreamEvent)
+	go func() {
+		defer close(output)
+
+		last := time.Now()
+		for event := range result.Stream {
+			now := time.Now()
+			interaction.Events = append(interaction.Events, newRecordedEvent(event, now.Sub(last)))
+			last = now
+			output <- event
+		}
+		r.add(interaction)
+	}()
+
+	return &TextStreamResult{Stream: output}, nil
+}
+
+func (r *RecordingModel) ChatCompletionNoStream(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	interaction := RecordedInteraction{
+		RequestHash: HashRequest(request, opts...),
+		Request:     recordedPosts(request.Posts),
+	}
+
+	response, err := r.wrapped.ChatCompletionNoStream(ctx, request, opts...)
+	if err != nil {
+		interaction.Error = err.Error()
+	}
+	interaction.Response = response
+	r.add(interaction)
+
+	return response, err
+}
+
+func (r *RecordingModel) CountTokens(text string) int {
+	return r.wrapped.CountTokens(text)
+}
+
+func (r *RecordingModel) InputTokenLimit() int {
+	return r.wrapped.InputTokenLimit()
+}
+
+// Recording returns the traffic recorded so far. Streams are only included once fully consumed.
+func (r *RecordingModel) Recording() *Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &Recording{
+		InputTokenLimit: r.wrapped.InputTokenLimit(),
+		Interactions:    append([]RecordedInteraction(nil), r.interactions...),
+	}
+}
+
+// Save writes the recorded traffic to path, creating parent directories as needed.
+func (r *RecordingModel) Save(path string) error {
+	data, err := json.MarshalIndent(r.Recording(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recording: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create recording directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+
+	return nil
+}
+
+// ReplayModel answers requests from a recording without contacting a provider.
+// Identical requests recorded several times are answered in the order they were recorded.
+type ReplayModel struct {
+	inputTokenLimit int
+	// PreserveTiming replays stream events with the recorded delays between them.
+	PreserveTiming bool
+
+	mu      sync.Mutex
+	pending map[string][]RecordedInteraction
+}
+
+// NewReplayModel creates a model replaying the given recording.
+func NewReplayModel(recording *Recording) *ReplayModel {
+	pending := make(map[string][]RecordedInteraction)
+	for _, interaction := range recording.Interactions {
+		pending[interaction.RequestHash] = append(pending[interaction.RequestHash], interaction)
+	}
+
+	return &ReplayModel{
+		inputTokenLimit: recording.InputTokenLimit,
+		pending:         pending,
+	}
+}
+
+func (r *ReplayModel) next(request CompletionRequest, streaming bool, opts ...LanguageModelOption) (RecordedInteraction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hash := HashRequest(request, opts...)
+	for i, interaction := range r.pending[hash] {
+		if interaction.Streaming != streaming {
+			continue
+		}
+		r.pending[hash] = append(r.pending[hash][:i:i], r.pending[hash][i+1:]...)
+		return interaction, nil
+	}
+
+	return RecordedInteraction{}, fmt.Errorf("%w: %s", ErrNoRecordedInteraction, hash)
+}
+
+func (r *ReplayModel) ChatCompletion(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	interaction, err := r.next(request, true, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" {
+		return nil, errors.New(interaction.Error)
+	}
+
+	output := make(chan TextStreamEvent)
+	go func() {
+		defer close(output)
+
+		for _, event := range interaction.Events {
+			if r.PreserveTiming && event.DelayMS > 0 {
+				time.Sleep(time.Duration(event.DelayMS) * time.Millisecond)
+			}
+			output <- event.toStreamEvent()
+		}
+	}()
+
+	return &TextStreamResult{Stream: output}, nil
+}
+
+func (r *ReplayModel) ChatCompletionNoStream(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	interaction, err := r.next(request, false, opts...)
+	if err != nil {
+		return "", err
+	}
+	if interaction.Error != "" {
+		return interaction.Response, errors.New(interaction.Error)
+	}
+
+	return interaction.Response, nil
+}
+
+// CountTokens approximates token counts since the recorded provider's tokenizer is not available.
+func (r *ReplayModel) CountTokens(text string) int {
+	return len(text) / 4
+}
+
+func (r *ReplayModel) InputTokenLimit() int {
+	return r.inputTokenLimit
+}
+
+// Remaining returns the number of recorded interactions that have not been replayed yet.
+func (r *ReplayModel) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remaining := 0
+	for _, interactions := range r.pending {
+		remaining += len(interactions)
+	}
+	return remaining
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func collectEvents(t *testing.T, result *TextStreamResult) []TextStreamEvent {
+	t.Helper()
+	var events []TextStreamEvent
+	for event := range result.Stream {
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator StreamGenerator
+	}{
+		{
+			name:      "text",
+			generator: StreamGenerator{TotalTextSize: 200, ChunkSize: 20},
+		},
+		{
+			name:      "tool calls",
+			generator: StreamGenerator{TotalTextSize: 100, ChunkSize: 25, IncludeToolCalls: true},
+		},
+		{
+			name: "reasoning, annotations and usage",
+			generator: StreamGenerator{
+				TotalTextSize:      100,
+				ChunkSize:          50,
+				IncludeReasoning:   true,
+				IncludeAnnotations: true,
+				IncludeUsage:       true,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			request := CompletionRequest{Posts: []Post{{Role: PostRoleUser, Message: "Hello " + tc.name}}}
+
+			mockLLM := &MockLanguageModel{}
+			mockLLM.On("ChatCompletion", mock.Anything, mock.Anything).Return(tc.generator.Generate(), nil).Once()
+			mockLLM.On("InputTokenLimit").Return(1000)
+
+			recorder := NewRecordingModel(mockLLM)
+			result, err := recorder.ChatCompletion(context.Background(), request)
+			require.NoError(t, err)
+			recorded := collectEvents(t, result)
+
+			path := filepath.Join(t.TempDir(), "fixtures", "recording.json")
+			require.NoError(t, recorder.Save(path))
+
+			recording, err := LoadRecording(path)
+			require.NoError(t, err)
+			replay := NewReplayModel(recording)
+			assert.Equal(t, 1000, replay.InputTokenLimit())
+
+			result, err = replay.ChatCompletion(context.Background(), request)
+			require.NoError(t, err)
+			replayed := collectEvents(t, result)
+
+			require.Len(t, replayed, len(recorded))
+			for i := range recorded {
+				assert.Equal(t, recorded[i].Type, replayed[i].Type)
+				if recorded[i].Type == EventTypeToolCalls {
+					expected := recorded[i].Value.([]ToolCall)
+					actual := replayed[i].Value.([]ToolCall)
+					require.Len(t, actual, len(expected))
+					assert.Equal(t, expected[0].Name, actual[0].Name)
+					assert.JSONEq(t, string(expected[0].Arguments), string(actual[0].Arguments))
+					continue
+				}
+				assert.Equal(t, recorded[i].Value, replayed[i].Value)
+			}
+			assert.Zero(t, replay.Remaining())
+		})
+	}
+}
+
+func TestReplayModel(t *testing.T) {
+	first := CompletionRequest{Posts: []Post{{Role: PostRoleUser, Message: "first"}}}
+	other := CompletionRequest{Posts: []Post{{Role: PostRoleUser, Message: "other"}}}
+
+	recording := &Recording{Interactions: []RecordedInteraction{
+		{RequestHash: HashRequest(first), Response: "one"},
+		{RequestHash: HashRequest(first), Response: "two"},
+		{RequestHash: HashRequest(first, WithMaxGeneratedTokens(10)), Response: "limited"},
+		{RequestHash: HashRequest(other), Error: "rate limited"},
+	}}
+
+	tests := []struct {
+		name          string
+		request       CompletionRequest
+		opts          []LanguageModelOption
+		expected      string
+		expectedError string
+	}{
+		{name: "first recording", request: first, expected: "one"},
+		{name: "repeated request replays in order", request: first, expected: "two"},
+		{name: "options are part of the request", request: first, opts: []LanguageModelOption{WithMaxGeneratedTokens(10)}, expected: "limited"},
+		{name: "recordings are consumed", request: first, expectedError: ErrNoRecordedInteraction.Error()},
+		{name: "recorded error", request: other, expectedError: "rate limited"},
+	}
+
+	// Cases run in order against the same replay model
+	replay := NewReplayModel(recording)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			response, err := replay.ChatCompletionNoStream(context.Background(), tc.request, tc.opts...)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, response)
+		})
+	}
+}
+
+func TestReplayModelPreservesTiming(t *testing.T) {
+	request := CompletionRequest{Posts: []Post{{Role: PostRoleUser, Message: "slow"}}}
+	replay := NewReplayModel(&Recording{Interactions: []RecordedInteraction{{
+		RequestHash: HashRequest(request),
+		Streaming:   true,
+		Events: []RecordedEvent{
+			{Type: EventTypeText, Text: "a"},
+			{Type: EventTypeText, Text: "b", DelayMS: 50},
+			{Type: EventTypeEnd, DelayMS: 50},
+		},
+	}}})
+	replay.PreserveTiming = true
+
+	start := time.Now()
+	result, err := replay.ChatCompletion(context.Background(), request)
+	require.NoError(t, err)
+	text, err := result.ReadAll()
+	require.NoError(t, err)
+
+	assert.Equal(t, "ab", text)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
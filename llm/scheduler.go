@@ -0,0 +1,246 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Priority orders requests competing for the capacity of a rate limited provider
+type Priority int
+
+const (
+	// PriorityInteractive is for requests a user is waiting on, such as chat responses
+	PriorityInteractive Priority = iota
+	// PriorityBackground is for requests no user is actively waiting on, such as analysis jobs
+	PriorityBackground
+	// PriorityIndexing is for bulk requests, such as indexing posts for search
+	PriorityIndexing
+
+	numPriorities
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityBackground:
+		return "background"
+	case PriorityIndexing:
+		return "indexing"
+	}
+	return fmt.Sprintf("priority(%d)", int(p))
+}
+
+// priorityReserve is the fraction of the capacity each priority leaves to higher priorities, so
+// background work can't use up the capacity interactive requests will need.
+var priorityReserve = [numPriorities]float64{
+	PriorityInteractive: 0,
+	PriorityBackground:  0.25,
+	PriorityIndexing:    0.5,
+}
+
+// DefaultSchedulerMaxWait bounds how long a request waits for the capacity of the provider
+const DefaultSchedulerMaxWait = 2 * time.Minute
+
+// maxSchedulerPoll bounds how long a waiting request sleeps before checking for capacity again
+const maxSchedulerPoll = time.Second
+
+// ErrSchedulerTimeout is returned when a request waited too long for the capacity of the provider
+var ErrSchedulerTimeout = errors.New("timed out waiting for provider capacity")
+
+type priorityContextKey struct{}
+
+// ContextWithPriority returns a context carrying the priority of the requests made with it
+func ContextWithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityContextKey{}, priority)
+}
+
+// PriorityFromContext returns the priority carried by the context, interactive by default
+func PriorityFromContext(ctx context.Context) Priority {
+	if priority, ok := ctx.Value(priorityContextKey{}).(Priority); ok {
+		return priority
+	}
+	return PriorityInteractive
+}
+
+type schedulerWaiter struct {
+	priority Priority
+}
+
+// PriorityScheduler rate limits the requests sent to a provider with a token bucket. Waiting
+// requests are served by priority, then in arrival order, and lower priorities can't use the
+// capacity reserved for higher ones. Limits are enforced per server node.
+type PriorityScheduler struct {
+	requestsPerMinute int
+	ratePerSecond     float64
+	burst             float64
+
+	mu      sync.Mutex
+	tokens  float64
+	updated time.Time
+	waiting [numPriorities][]*schedulerWaiter
+}
+
+// NewPriorityScheduler creates a scheduler allowing up to requestsPerMinute requests per minute
+func NewPriorityScheduler(requestsPerMinute int) *PriorityScheduler {
+	burst := math.Max(float64(requestsPerMinute), 1)
+	return &PriorityScheduler{
+		requestsPerMinute: requestsPerMinute,
+		ratePerSecond:     float64(requestsPerMinute) / 60,
+		burst:             burst,
+		tokens:            burst,
+		updated:           time.Now(),
+	}
+}
+
+// RequestsPerMinute returns the limit the scheduler was created with
+func (s *PriorityScheduler) RequestsPerMinute() int {
+	return s.requestsPerMinute
+}
+
+// Waiting returns the number of requests waiting for capacity
+func (s *PriorityScheduler) Waiting() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	waiting := 0
+	for _, waiters := range s.waiting {
+		waiting += len(waiters)
+	}
+	return waiting
+}
+
+// required returns the tokens that must be available for a request of the priority to start
+func (s *PriorityScheduler) required(priority Priority) float64 {
+	return math.Min(1+priorityReserve[priority]*s.burst, s.burst)
+}
+
+func (s *PriorityScheduler) refill() {
+	now := time.Now()
+	s.tokens = math.Min(s.burst, s.tokens+now.Sub(s.updated).Seconds()*s.ratePerSecond)
+	s.updated = now
+}
+
+// isNext returns whether no request of a higher priority, or of the same priority that
+// arrived earlier, is waiting
+func (s *PriorityScheduler) isNext(waiter *schedulerWaiter) bool {
+	for priority := PriorityInteractive; priority < waiter.priority; priority++ {
+		if len(s.waiting[priority]) > 0 {
+			return false
+		}
+	}
+	return s.waiting[waiter.priority][0] == waiter
+}
+
+func (s *PriorityScheduler) remove(waiter *schedulerWaiter) {
+	s.waiting[waiter.priority] = slices.DeleteFunc(s.waiting[waiter.priority], func(w *schedulerWaiter) bool {
+		return w == waiter
+	})
+}
+
+// Acquire waits until a request of the priority may be sent, or the context is done
+func (s *PriorityScheduler) Acquire(ctx context.Context, priority Priority) error {
+	if priority < PriorityInteractive || priority >= numPriorities {
+		priority = PriorityInteractive
+	}
+
+	waiter := &schedulerWaiter{priority: priority}
+
+	s.mu.Lock()
+	s.waiting[priority] = append(s.waiting[priority], waiter)
+	for {
+		s.refill()
+		required := s.required(priority)
+		if s.isNext(waiter) && s.tokens >= required {
+			s.tokens--
+			s.remove(waiter)
+			s.mu.Unlock()
+			return nil
+		}
+
+		// Sleep until enough tokens are available, checking again regularly as requests of
+		// higher priorities may take them first
+		wait := time.Duration((required - s.tokens) / s.ratePerSecond * float64(time.Second))
+		wait = max(min(wait, maxSchedulerPoll), time.Millisecond)
+		s.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.mu.Lock()
+			s.remove(waiter)
+			s.mu.Unlock()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		s.mu.Lock()
+	}
+}
+
+// PrioritySchedulerWrapper waits for the capacity of the provider before sending requests,
+// using the priority of the request's context. Each completion counts as one request.
+type PrioritySchedulerWrapper struct {
+	wrapped   LanguageModel
+	scheduler *PriorityScheduler
+	maxWait   time.Duration
+}
+
+// NewPrioritySchedulerWrapper creates a wrapper sending requests through the scheduler, which
+// may be shared by the models of a provider.
+func NewPrioritySchedulerWrapper(wrapped LanguageModel, scheduler *PriorityScheduler) *PrioritySchedulerWrapper {
+	return &PrioritySchedulerWrapper{
+		wrapped:   wrapped,
+		scheduler: scheduler,
+		maxWait:   DefaultSchedulerMaxWait,
+	}
+}
+
+func (w *PrioritySchedulerWrapper) acquire(ctx context.Context, request CompletionRequest) error {
+	priority := PriorityInteractive
+	if request.Context != nil {
+		priority = request.Context.Priority
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.maxWait)
+	defer cancel()
+
+	if err := w.scheduler.Acquire(ctx, priority); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		return fmt.Errorf("%w: %s request waited %s", ErrSchedulerTimeout, priority, w.maxWait)
+	}
+	return nil
+}
+
+func (w *PrioritySchedulerWrapper) ChatCompletion(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	if err := w.acquire(ctx, request); err != nil {
+		return nil, err
+	}
+	return w.wrapped.ChatCompletion(ctx, request, opts...)
+}
+
+func (w *PrioritySchedulerWrapper) ChatCompletionNoStream(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	if err := w.acquire(ctx, request); err != nil {
+		return "", err
+	}
+	return w.wrapped.ChatCompletionNoStream(ctx, request, opts...)
+}
+
+func (w *PrioritySchedulerWrapper) CountTokens(text string) int {
+	return w.wrapped.CountTokens(text)
+}
+
+func (w *PrioritySchedulerWrapper) InputTokenLimit() int {
+	return w.wrapped.InputTokenLimit()
+}
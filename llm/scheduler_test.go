@@ -0,0 +1,140 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestScheduler creates a scheduler with a custom rate, burst and initial tokens
+func newTestScheduler(ratePerSecond, burst, tokens float64) *PriorityScheduler {
+	return &PriorityScheduler{
+		ratePerSecond: ratePerSecond,
+		burst:         burst,
+		tokens:        tokens,
+		updated:       time.Now(),
+	}
+}
+
+func (s *PriorityScheduler) waitingCount(priority Priority) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.waiting[priority])
+}
+
+func TestPrioritySchedulerAcquire(t *testing.T) {
+	tests := []struct {
+		name          string
+		burst         float64
+		tokens        float64
+		priority      Priority
+		expectAcquire bool
+	}{
+		{
+			name:          "interactive within capacity",
+			burst:         10,
+			tokens:        1,
+			priority:      PriorityInteractive,
+			expectAcquire: true,
+		},
+		{
+			name:     "background can't use the interactive reserve",
+			burst:    10,
+			tokens:   3,
+			priority: PriorityBackground,
+		},
+		{
+			name:          "background above the reserve",
+			burst:         10,
+			tokens:        4,
+			priority:      PriorityBackground,
+			expectAcquire: true,
+		},
+		{
+			name:     "indexing leaves half of the capacity",
+			burst:    10,
+			tokens:   5,
+			priority: PriorityIndexing,
+		},
+		{
+			name:          "reserve never exceeds the capacity",
+			burst:         1,
+			tokens:        1,
+			priority:      PriorityIndexing,
+			expectAcquire: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			scheduler := newTestScheduler(0.001, tc.burst, tc.tokens)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			err := scheduler.Acquire(ctx, tc.priority)
+			if tc.expectAcquire {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			}
+			assert.Zero(t, scheduler.waitingCount(tc.priority))
+		})
+	}
+}
+
+func TestPrioritySchedulerServesHigherPriorityFirst(t *testing.T) {
+	scheduler := newTestScheduler(100, 1, 0)
+
+	order := make(chan Priority, 2)
+	acquire := func(priority Priority) {
+		require.NoError(t, scheduler.Acquire(context.Background(), priority))
+		order <- priority
+	}
+
+	go acquire(PriorityBackground)
+	require.Eventually(t, func() bool { return scheduler.waitingCount(PriorityBackground) == 1 }, time.Second, time.Millisecond)
+	go acquire(PriorityInteractive)
+
+	assert.Equal(t, PriorityInteractive, <-order)
+	assert.Equal(t, PriorityBackground, <-order)
+}
+
+func TestPriorityContext(t *testing.T) {
+	assert.Equal(t, PriorityInteractive, PriorityFromContext(context.Background()))
+	assert.Equal(t, PriorityIndexing, PriorityFromContext(ContextWithPriority(context.Background(), PriorityIndexing)))
+}
+
+func TestPrioritySchedulerWrapper(t *testing.T) {
+	t.Run("sends requests within capacity", func(t *testing.T) {
+		mockLLM := &MockLanguageModel{}
+		mockLLM.On("ChatCompletionNoStream", mock.Anything, mock.Anything).Return("ok", nil)
+		wrapper := NewPrioritySchedulerWrapper(mockLLM, NewPriorityScheduler(60))
+
+		result, err := wrapper.ChatCompletionNoStream(context.Background(), CompletionRequest{Context: &Context{Priority: PriorityBackground}})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", result)
+	})
+
+	t.Run("gives up when no capacity is available in time", func(t *testing.T) {
+		mockLLM := &MockLanguageModel{}
+		wrapper := NewPrioritySchedulerWrapper(mockLLM, newTestScheduler(0.001, 10, 2))
+		wrapper.maxWait = 20 * time.Millisecond
+
+		_, err := wrapper.ChatCompletionNoStream(context.Background(), CompletionRequest{Context: &Context{Priority: PriorityBackground}})
+		assert.ErrorIs(t, err, ErrSchedulerTimeout)
+		mockLLM.AssertNotCalled(t, "ChatCompletionNoStream", mock.Anything, mock.Anything)
+
+		// Interactive requests may use the reserved capacity
+		mockLLM.On("ChatCompletionNoStream", mock.Anything, mock.Anything).Return("ok", nil)
+		_, err = wrapper.ChatCompletionNoStream(context.Background(), CompletionRequest{})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"time"
+)
+
+const (
+	// DefaultStepBudgetDuration is the default wall-clock time a request may spend running tools automatically
+	DefaultStepBudgetDuration = 5 * time.Minute
+	// DefaultStepBudgetTokens is the default number of input and output tokens a request may use
+	// across all of its model calls while running tools automatically
+	DefaultStepBudgetTokens = 500000
+)
+
+// Reasons a request stopped early, sent as the value of EventTypeStoppedEarly events
+const (
+	StoppedEarlyTimeBudget  = "time_budget"
+	StoppedEarlyTokenBudget = "token_budget"
+)
+
+// StepBudget limits how much a request may spend looping over automatically run tools.
+// Zero values use the defaults.
+type StepBudget struct {
+	MaxDuration time.Duration
+	MaxTokens   int64
+}
+
+// StepBudgetTracker tracks the spending of a single request against its budget.
+// It is used from the goroutine running the request only.
+type StepBudgetTracker struct {
+	budget  StepBudget
+	started time.Time
+	tokens  int64
+}
+
+// NewStepBudgetTracker starts tracking a request against the budget
+func NewStepBudgetTracker(budget StepBudget) *StepBudgetTracker {
+	if budget.MaxDuration <= 0 {
+		budget.MaxDuration = DefaultStepBudgetDuration
+	}
+	if budget.MaxTokens <= 0 {
+		budget.MaxTokens = DefaultStepBudgetTokens
+	}
+
+	return &StepBudgetTracker{
+		budget:  budget,
+		started: time.Now(),
+	}
+}
+
+// AddUsage adds the tokens used by a model call of the request
+func (t *StepBudgetTracker) AddUsage(usage TokenUsage) {
+	t.tokens += usage.InputTokens + usage.OutputTokens
+}
+
+// Exceeded returns the reason the request must stop, if it spent its budget
+func (t *StepBudgetTracker) Exceeded() (string, bool) {
+	if time.Since(t.started) >= t.budget.MaxDuration {
+		return StoppedEarlyTimeBudget, true
+	}
+	if t.tokens >= t.budget.MaxTokens {
+		return StoppedEarlyTokenBudget, true
+	}
+	return "", false
+}
+
+// StopIfExceeded ends the stream with a stopped early event, keeping what was generated so far,
+// if the request spent its budget. Returns true if the stream was ended.
+func (t *StepBudgetTracker) StopIfExceeded(output chan<- TextStreamEvent) bool {
+	reason, exceeded := t.Exceeded()
+	if !exceeded {
+		return false
+	}
+
+	output <- TextStreamEvent{Type: EventTypeStoppedEarly, Value: reason}
+	output <- TextStreamEvent{Type: EventTypeEnd, Value: nil}
+	return true
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepBudgetTracker(t *testing.T) {
+	tests := []struct {
+		name           string
+		budget         StepBudget
+		usage          []TokenUsage
+		elapsed        time.Duration
+		expectExceeded bool
+		expectReason   string
+	}{
+		{
+			name:   "within budget",
+			budget: StepBudget{MaxDuration: time.Minute, MaxTokens: 100},
+			usage:  []TokenUsage{{InputTokens: 40, OutputTokens: 10}},
+		},
+		{
+			name:           "cumulative tokens exceed budget",
+			budget:         StepBudget{MaxDuration: time.Minute, MaxTokens: 100},
+			usage:          []TokenUsage{{InputTokens: 40, OutputTokens: 10}, {InputTokens: 45, OutputTokens: 5}},
+			expectExceeded: true,
+			expectReason:   StoppedEarlyTokenBudget,
+		},
+		{
+			name:           "wall clock exceeds budget",
+			budget:         StepBudget{MaxDuration: time.Minute, MaxTokens: 100},
+			elapsed:        2 * time.Minute,
+			expectExceeded: true,
+			expectReason:   StoppedEarlyTimeBudget,
+		},
+		{
+			name:  "zero budget uses defaults",
+			usage: []TokenUsage{{InputTokens: DefaultStepBudgetTokens - 1}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker := NewStepBudgetTracker(tc.budget)
+			tracker.started = tracker.started.Add(-tc.elapsed)
+			for _, usage := range tc.usage {
+				tracker.AddUsage(usage)
+			}
+
+			reason, exceeded := tracker.Exceeded()
+			assert.Equal(t, tc.expectExceeded, exceeded)
+			assert.Equal(t, tc.expectReason, reason)
+		})
+	}
+
+	t.Run("stopping ends the stream", func(t *testing.T) {
+		tracker := NewStepBudgetTracker(StepBudget{MaxTokens: 10})
+		tracker.AddUsage(TokenUsage{InputTokens: 10})
+
+		output := make(chan TextStreamEvent, 2)
+		require.True(t, tracker.StopIfExceeded(output))
+		close(output)
+
+		stopped := <-output
+		assert.Equal(t, EventTypeStoppedEarly, stopped.Type)
+		assert.Equal(t, StoppedEarlyTokenBudget, stopped.Value)
+		assert.Equal(t, EventTypeEnd, (<-output).Type)
+	})
+
+	t.Run("nothing is sent within budget", func(t *testing.T) {
+		tracker := NewStepBudgetTracker(StepBudget{})
+		output := make(chan TextStreamEvent, 2)
+		assert.False(t, tracker.StopIfExceeded(output))
+		assert.Empty(t, output)
+	})
+}
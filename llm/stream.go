@@ -25,8 +25,49 @@ const (
 	EventTypeAnnotations
 	// EventTypeUsage represents token usage data
 	EventTypeUsage
+	// EventTypeStoppedEarly represents a request that stopped before finishing because it spent its step budget.
+	// The value is the reason, for example StoppedEarlyTimeBudget. The stream ends after it.
+	EventTypeStoppedEarly
+	// EventTypeUsageTotal represents the token usage of all the model calls of a request, as a RequestUsage.
+	// It is sent once, before the stream ends or fails.
+	EventTypeUsageTotal
+	// EventTypeFinish represents the reason the model stopped generating, for example FinishReasonLength.
+	// It is sent before the stream ends or stops for tool calls.
+	EventTypeFinish
+	// EventTypeRefusal represents a chunk of the model's explanation of why it declined to answer.
+	// It is followed by an EventTypeFinish with FinishReasonRefusal.
+	EventTypeRefusal
+	// EventTypeContextSufficiency represents whether the model had enough context to answer, as a
+	// ContextSufficiency. It is sent before the stream ends, by ExtractContextSufficiency.
+	EventTypeContextSufficiency
+	// EventTypeToolRunning represents a tool the model called starting to run without approval.
+	// The value is the name of the tool.
+	EventTypeToolRunning
+	// EventTypeResponseID represents the ID the provider stored the response with, which the next
+	// request of the conversation can continue from instead of resending it. It is sent before the stream ends.
+	EventTypeResponseID
 )
 
+// Reasons the model stopped generating, sent as the value of EventTypeFinish events
+const (
+	// FinishReasonStop means the model finished its response
+	FinishReasonStop = "stop"
+	// FinishReasonLength means the response was cut off at the maximum number of output tokens
+	FinishReasonLength = "length"
+	// FinishReasonContentFilter means the provider's content filter blocked the response
+	FinishReasonContentFilter = "content_filter"
+	// FinishReasonRefusal means the model declined to answer
+	FinishReasonRefusal = "refusal"
+	// FinishReasonToolUse means the model is waiting for the results of tool calls
+	FinishReasonToolUse = "tool_use"
+)
+
+// IsIncompleteFinish returns whether the response of a model that stopped for the reason does
+// not fully answer the request
+func IsIncompleteFinish(reason string) bool {
+	return reason == FinishReasonLength || reason == FinishReasonContentFilter || reason == FinishReasonRefusal
+}
+
 // TokenUsage represents token usage statistics for an LLM request
 type TokenUsage struct {
 	InputTokens  int64 `json:"input_tokens"`
@@ -78,7 +119,7 @@ func (t *TextStreamResult) ReadAll() (string, error) {
 	result := ""
 	for event := range t.Stream {
 		switch event.Type {
-		case EventTypeText:
+		case EventTypeText, EventTypeRefusal:
 			if textChunk, ok := event.Value.(string); ok {
 				result += textChunk
 			}
@@ -90,7 +131,7 @@ func (t *TextStreamResult) ReadAll() (string, error) {
 			return result, nil
 		case EventTypeToolCalls:
 			return result, fmt.Errorf("Tool calls are not supported for read all")
-		case EventTypeAnnotations, EventTypeReasoning, EventTypeReasoningEnd, EventTypeUsage:
+		case EventTypeAnnotations, EventTypeReasoning, EventTypeReasoningEnd, EventTypeUsage, EventTypeStoppedEarly, EventTypeUsageTotal, EventTypeFinish, EventTypeContextSufficiency, EventTypeToolRunning, EventTypeResponseID:
 			// These event types are ignored in ReadAll, continue reading text
 			continue
 		}
@@ -4,6 +4,7 @@
 package llm
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -23,6 +24,7 @@ type TokenUsageLoggingWrapper struct {
 	botUsername string
 	tokenLogger *mlog.Logger
 	metrics     MetricsObserver
+	userAPIKey  bool
 }
 
 // NewTokenUsageLoggingWrapper creates a new wrapper that logs token usage
@@ -35,8 +37,22 @@ func NewTokenUsageLoggingWrapper(wrapped LanguageModel, botUsername string, toke
 	}
 }
 
+// SetUserAPIKey marks the usage as paid with the requesting user's own API key, so it can be
+// told apart from the usage of the shared key.
+func (w *TokenUsageLoggingWrapper) SetUserAPIKey() {
+	w.userAPIKey = true
+}
+
+// tokenLogFilename is the file token usage is logged to, relative to the server's directory
+const tokenLogFilename = "logs/agents/token_usage.log"
+
 // CreateTokenLogger creates a dedicated logger for token usage metrics
 func CreateTokenLogger() (*mlog.Logger, error) {
+	return createTokenLogger(tokenLogFilename)
+}
+
+// createTokenLogger creates a token usage logger writing to filename
+func createTokenLogger(filename string) (*mlog.Logger, error) {
 	logger, err := mlog.NewLogger()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create token logger: %w", err)
@@ -48,7 +64,7 @@ func CreateTokenLogger() (*mlog.Logger, error) {
 		Levels: []mlog.Level{mlog.LvlInfo, mlog.LvlDebug},
 	}
 	jsonFileOptions := map[string]interface{}{
-		"filename": "logs/agents/token_usage.log",
+		"filename": filename,
 		"max_size": 100,  // MB
 		"compress": true, // compress rotated files
 	}
@@ -68,9 +84,11 @@ func CreateTokenLogger() (*mlog.Logger, error) {
 	return logger, nil
 }
 
-// ChatCompletion intercepts the streaming response to extract and log token usage
-func (w *TokenUsageLoggingWrapper) ChatCompletion(request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
-	result, err := w.wrapped.ChatCompletion(request, opts...)
+// ChatCompletion intercepts the streaming response to extract and log token usage. Usage is
+// logged once per request, from the total usage of the request when the model sends it, or
+// from the sum of the usage of each model call otherwise.
+func (w *TokenUsageLoggingWrapper) ChatCompletion(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+	result, err := w.wrapped.ChatCompletion(ctx, request, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -84,68 +102,98 @@ func (w *TokenUsageLoggingWrapper) ChatCompletion(request CompletionRequest, opt
 	go func() {
 		defer close(interceptedStream)
 
-		for event := range result.Stream {
-			if event.Type != EventTypeUsage {
-				interceptedStream <- event
-				continue
+		var calls, total RequestUsage
+		hasTotal := false
+		logged := false
+		logOnce := func() {
+			if logged {
+				return
 			}
+			logged = true
 
-			usage, ok := event.Value.(TokenUsage)
-			if !ok {
-				continue
+			usage := calls
+			if hasTotal {
+				usage = total
+			}
+			if usage.Calls > 0 {
+				w.logUsage(request, usage)
 			}
+		}
 
-			userID := "unknown"
-			teamID := "unknown"
-			if request.Context != nil {
-				if request.Context.RequestingUser != nil {
-					userID = request.Context.RequestingUser.Id
+		for event := range result.Stream {
+			switch event.Type {
+			case EventTypeUsage:
+				if usage, ok := event.Value.(TokenUsage); ok {
+					calls.Add(usage)
 				}
-				if request.Context.Team != nil {
-					teamID = request.Context.Team.Id
-				} else if request.Context.Channel != nil {
-					// For DM and Group channels, use a special identifier
-					// instead of "unknown" to distinguish them in metrics
-					switch request.Context.Channel.Type {
-					case model.ChannelTypeDirect:
-						teamID = "dm"
-					case model.ChannelTypeGroup:
-						teamID = "group"
-					default:
-						teamID = "unknown"
-					}
+				continue
+			case EventTypeUsageTotal:
+				if usage, ok := event.Value.(RequestUsage); ok {
+					total = usage
+					hasTotal = true
 				}
+			case EventTypeEnd, EventTypeError:
+				// Log before forwarding, as readers may stop reading once the stream ends
+				logOnce()
 			}
-
-			w.tokenLogger.Info("Token Usage",
-				mlog.String("user_id", userID),
-				mlog.String("team_id", teamID),
-				mlog.String("bot_username", w.botUsername),
-				mlog.Int("input_tokens", usage.InputTokens),
-				mlog.Int("output_tokens", usage.OutputTokens),
-				mlog.Int("total_tokens", usage.InputTokens+usage.OutputTokens),
-			)
-
-			// Emit metrics if available (user_id not included in metrics)
-			if w.metrics != nil {
-				w.metrics.ObserveTokenUsage(
-					w.botUsername,
-					teamID,
-					"",
-					int(usage.InputTokens),
-					int(usage.OutputTokens),
-				)
-			}
+			interceptedStream <- event
 		}
+		logOnce()
 	}()
 
 	return &TextStreamResult{Stream: interceptedStream}, nil
 }
 
+func (w *TokenUsageLoggingWrapper) logUsage(request CompletionRequest, usage RequestUsage) {
+	userID := "unknown"
+	teamID := "unknown"
+	if request.Context != nil {
+		if request.Context.RequestingUser != nil {
+			userID = request.Context.RequestingUser.Id
+		}
+		if request.Context.Team != nil {
+			teamID = request.Context.Team.Id
+		} else if request.Context.Channel != nil {
+			// For DM and Group channels, use a special identifier
+			// instead of "unknown" to distinguish them in metrics
+			switch request.Context.Channel.Type {
+			case model.ChannelTypeDirect:
+				teamID = "dm"
+			case model.ChannelTypeGroup:
+				teamID = "group"
+			default:
+				teamID = "unknown"
+			}
+		}
+	}
+
+	w.tokenLogger.Info("Token Usage",
+		mlog.String("user_id", userID),
+		mlog.String("team_id", teamID),
+		mlog.String("bot_username", w.botUsername),
+		mlog.Int("input_tokens", usage.InputTokens),
+		mlog.Int("output_tokens", usage.OutputTokens),
+		mlog.Int("total_tokens", usage.InputTokens+usage.OutputTokens),
+		mlog.Int("model_calls", usage.Calls),
+		mlog.Bool("user_api_key", w.userAPIKey),
+	)
+
+	// Emit metrics if available (user_id not included in metrics)
+	if w.metrics != nil {
+		w.metrics.ObserveTokenUsage(
+			w.botUsername,
+			teamID,
+			"",
+			int(usage.InputTokens),
+			int(usage.OutputTokens),
+		)
+	}
+}
+
 // ChatCompletionNoStream uses the streaming method internally, so token usage
 // logging happens automatically when ReadAll() processes the intercepted stream
-func (w *TokenUsageLoggingWrapper) ChatCompletionNoStream(request CompletionRequest, opts ...LanguageModelOption) (string, error) {
-	result, err := w.ChatCompletion(request, opts...)
+func (w *TokenUsageLoggingWrapper) ChatCompletionNoStream(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+	result, err := w.ChatCompletion(ctx, request, opts...)
 	if err != nil {
 		return "", err
 	}
@@ -4,6 +4,7 @@
 package llm
 
 import (
+	"context"
 	"testing"
 
 	"github.com/mattermost/mattermost/server/public/model"
@@ -15,12 +16,12 @@ type benchFakeLLM struct {
 	generator StreamGenerator
 }
 
-func (f *benchFakeLLM) ChatCompletion(_ CompletionRequest, _ ...LanguageModelOption) (*TextStreamResult, error) {
+func (f *benchFakeLLM) ChatCompletion(_ context.Context, _ CompletionRequest, _ ...LanguageModelOption) (*TextStreamResult, error) {
 	return f.generator.Generate(), nil
 }
 
-func (f *benchFakeLLM) ChatCompletionNoStream(_ CompletionRequest, _ ...LanguageModelOption) (string, error) {
-	result, err := f.ChatCompletion(CompletionRequest{})
+func (f *benchFakeLLM) ChatCompletionNoStream(_ context.Context, _ CompletionRequest, _ ...LanguageModelOption) (string, error) {
+	result, err := f.ChatCompletion(context.Background(), CompletionRequest{})
 	if err != nil {
 		return "", err
 	}
@@ -37,10 +38,7 @@ func (f *benchFakeLLM) InputTokenLimit() int {
 
 // BenchmarkTokenTracking benchmarks the TokenUsageLoggingWrapper performance.
 func BenchmarkTokenTracking(b *testing.B) {
-	logger, err := CreateTokenLogger()
-	if err != nil {
-		b.Skip("Could not create token logger:", err)
-	}
+	logger := newTestTokenLogger(b)
 
 	scenarios := BenchmarkScenarios()
 
@@ -59,7 +57,7 @@ func BenchmarkTokenTracking(b *testing.B) {
 				fakeLLM := &benchFakeLLM{generator: generator}
 				wrapper := NewTokenUsageLoggingWrapper(fakeLLM, "bench-bot", logger, nil)
 
-				result, err := wrapper.ChatCompletion(CompletionRequest{
+				result, err := wrapper.ChatCompletion(context.Background(), CompletionRequest{
 					Context: &Context{
 						RequestingUser: &model.User{Id: "user-bench"},
 						Team:           &model.Team{Id: "team-bench"},
@@ -4,9 +4,12 @@
 package llm
 
 import (
+	"context"
+	"path/filepath"
 	"testing"
 
 	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/shared/mlog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -17,12 +20,12 @@ type MockLanguageModel struct {
 	mock.Mock
 }
 
-func (m *MockLanguageModel) ChatCompletion(request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
+func (m *MockLanguageModel) ChatCompletion(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (*TextStreamResult, error) {
 	args := m.Called(request, opts)
 	return args.Get(0).(*TextStreamResult), args.Error(1)
 }
 
-func (m *MockLanguageModel) ChatCompletionNoStream(request CompletionRequest, opts ...LanguageModelOption) (string, error) {
+func (m *MockLanguageModel) ChatCompletionNoStream(ctx context.Context, request CompletionRequest, opts ...LanguageModelOption) (string, error) {
 	args := m.Called(request, opts)
 	return args.String(0), args.Error(1)
 }
@@ -37,10 +40,20 @@ func (m *MockLanguageModel) InputTokenLimit() int {
 	return args.Int(0)
 }
 
+// newTestTokenLogger creates a token usage logger writing to a temporary directory
+func newTestTokenLogger(tb testing.TB) *mlog.Logger {
+	logger, err := createTokenLogger(filepath.Join(tb.TempDir(), "token_usage.log"))
+	require.NoError(tb, err)
+	tb.Cleanup(func() {
+		_ = logger.Shutdown()
+	})
+	return logger
+}
+
 func TestTokenTrackingWrapper_ChatCompletion(t *testing.T) {
 	t.Run("filters usage events from stream", func(t *testing.T) {
 		mockLLM := &MockLanguageModel{}
-		logger, _ := CreateTokenLogger()
+		logger := newTestTokenLogger(t)
 		wrapper := NewTokenUsageLoggingWrapper(mockLLM, "test-bot", logger, nil)
 
 		// Create a mock stream with usage event
@@ -60,7 +73,7 @@ func TestTokenTrackingWrapper_ChatCompletion(t *testing.T) {
 			},
 		}
 
-		result, err := wrapper.ChatCompletion(request)
+		result, err := wrapper.ChatCompletion(context.Background(), request)
 		require.NoError(t, err)
 		require.NotNil(t, result)
 
@@ -81,7 +94,7 @@ func TestTokenTrackingWrapper_ChatCompletion(t *testing.T) {
 
 	t.Run("handles nil context gracefully", func(t *testing.T) {
 		mockLLM := &MockLanguageModel{}
-		logger, _ := CreateTokenLogger()
+		logger := newTestTokenLogger(t)
 		wrapper := NewTokenUsageLoggingWrapper(mockLLM, "test-bot", logger, nil)
 
 		mockStream := make(chan TextStreamEvent, 2)
@@ -93,7 +106,7 @@ func TestTokenTrackingWrapper_ChatCompletion(t *testing.T) {
 		mockLLM.On("ChatCompletion", mock.Anything, mock.Anything).Return(mockResult, nil)
 
 		request := CompletionRequest{Context: &Context{}}
-		result, err := wrapper.ChatCompletion(request)
+		result, err := wrapper.ChatCompletion(context.Background(), request)
 		require.NoError(t, err)
 
 		// Should complete without panic even with nil context
@@ -110,7 +123,7 @@ func TestTokenTrackingWrapper_ChatCompletion(t *testing.T) {
 
 	t.Run("handles invalid usage event value", func(t *testing.T) {
 		mockLLM := &MockLanguageModel{}
-		logger, _ := CreateTokenLogger()
+		logger := newTestTokenLogger(t)
 		wrapper := NewTokenUsageLoggingWrapper(mockLLM, "test-bot", logger, nil)
 
 		mockStream := make(chan TextStreamEvent, 2)
@@ -122,7 +135,7 @@ func TestTokenTrackingWrapper_ChatCompletion(t *testing.T) {
 		mockLLM.On("ChatCompletion", mock.Anything, mock.Anything).Return(mockResult, nil)
 
 		request := CompletionRequest{Context: &Context{}}
-		result, err := wrapper.ChatCompletion(request)
+		result, err := wrapper.ChatCompletion(context.Background(), request)
 		require.NoError(t, err)
 
 		// Should complete without calling metrics (invalid value ignored)
@@ -136,10 +149,98 @@ func TestTokenTrackingWrapper_ChatCompletion(t *testing.T) {
 	})
 }
 
+type recordedTokenUsage struct {
+	inputTokens  int
+	outputTokens int
+}
+
+type fakeMetricsObserver struct {
+	observed []recordedTokenUsage
+}
+
+func (f *fakeMetricsObserver) ObserveTokenUsage(botName, teamID, userID string, inputTokens, outputTokens int) {
+	f.observed = append(f.observed, recordedTokenUsage{inputTokens: inputTokens, outputTokens: outputTokens})
+}
+
+func TestTokenTrackingWrapper_AccountsOncePerRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		events       []TextStreamEvent
+		expectUsage  []recordedTokenUsage
+		expectEvents []EventType
+	}{
+		{
+			name: "uses the total of a tool loop",
+			events: []TextStreamEvent{
+				{Type: EventTypeUsage, Value: TokenUsage{InputTokens: 100, OutputTokens: 20}},
+				{Type: EventTypeUsage, Value: TokenUsage{InputTokens: 150, OutputTokens: 30}},
+				{Type: EventTypeUsageTotal, Value: RequestUsage{TokenUsage: TokenUsage{InputTokens: 250, OutputTokens: 50}, Calls: 2}},
+				{Type: EventTypeEnd},
+			},
+			expectUsage:  []recordedTokenUsage{{inputTokens: 250, outputTokens: 50}},
+			expectEvents: []EventType{EventTypeUsageTotal, EventTypeEnd},
+		},
+		{
+			name: "sums model calls without a total",
+			events: []TextStreamEvent{
+				{Type: EventTypeUsage, Value: TokenUsage{InputTokens: 100, OutputTokens: 20}},
+				{Type: EventTypeUsage, Value: TokenUsage{InputTokens: 150, OutputTokens: 30}},
+				{Type: EventTypeEnd},
+			},
+			expectUsage:  []recordedTokenUsage{{inputTokens: 250, outputTokens: 50}},
+			expectEvents: []EventType{EventTypeEnd},
+		},
+		{
+			name: "accounts for failed requests",
+			events: []TextStreamEvent{
+				{Type: EventTypeUsage, Value: TokenUsage{InputTokens: 10, OutputTokens: 1}},
+				{Type: EventTypeError, Value: assert.AnError},
+				{Type: EventTypeEnd},
+			},
+			expectUsage:  []recordedTokenUsage{{inputTokens: 10, outputTokens: 1}},
+			expectEvents: []EventType{EventTypeError, EventTypeEnd},
+		},
+		{
+			name: "no usage",
+			events: []TextStreamEvent{
+				{Type: EventTypeEnd},
+			},
+			expectEvents: []EventType{EventTypeEnd},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockLLM := &MockLanguageModel{}
+			logger := newTestTokenLogger(t)
+			metrics := &fakeMetricsObserver{}
+			wrapper := NewTokenUsageLoggingWrapper(mockLLM, "test-bot", logger, metrics)
+
+			mockStream := make(chan TextStreamEvent, len(tc.events))
+			for _, event := range tc.events {
+				mockStream <- event
+			}
+			close(mockStream)
+			mockLLM.On("ChatCompletion", mock.Anything, mock.Anything).Return(&TextStreamResult{Stream: mockStream}, nil)
+
+			result, err := wrapper.ChatCompletion(context.Background(), CompletionRequest{Context: &Context{}})
+			require.NoError(t, err)
+
+			var events []EventType
+			for event := range result.Stream {
+				events = append(events, event.Type)
+			}
+
+			assert.Equal(t, tc.expectEvents, events)
+			assert.Equal(t, tc.expectUsage, metrics.observed)
+		})
+	}
+}
+
 func TestTokenTrackingWrapper_ChatCompletionNoStream(t *testing.T) {
 	t.Run("delegates to streaming method", func(t *testing.T) {
 		mockLLM := &MockLanguageModel{}
-		logger, _ := CreateTokenLogger()
+		logger := newTestTokenLogger(t)
 		wrapper := NewTokenUsageLoggingWrapper(mockLLM, "test-bot", logger, nil)
 
 		mockStream := make(chan TextStreamEvent, 3)
@@ -152,7 +253,7 @@ func TestTokenTrackingWrapper_ChatCompletionNoStream(t *testing.T) {
 		mockLLM.On("ChatCompletion", mock.Anything, mock.Anything).Return(mockResult, nil)
 
 		request := CompletionRequest{Context: &Context{}}
-		result, err := wrapper.ChatCompletionNoStream(request)
+		result, err := wrapper.ChatCompletionNoStream(context.Background(), request)
 		require.NoError(t, err)
 		assert.Equal(t, "Hello world", result)
 
@@ -162,7 +263,7 @@ func TestTokenTrackingWrapper_ChatCompletionNoStream(t *testing.T) {
 
 func TestTokenTrackingWrapper_DelegatedMethods(t *testing.T) {
 	mockLLM := &MockLanguageModel{}
-	logger, _ := CreateTokenLogger()
+	logger := newTestTokenLogger(t)
 	wrapper := NewTokenUsageLoggingWrapper(mockLLM, "test-llm", logger, nil)
 
 	t.Run("CountTokens delegates to wrapped model", func(t *testing.T) {
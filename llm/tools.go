@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/google/jsonschema-go/jsonschema"
@@ -301,16 +302,21 @@ func ShouldAutoRunTools(pendingToolCalls []ToolCall, autoRunTools []string) bool
 	return true
 }
 
-// ExecuteAutoRunTools executes the given tool calls using the provided resolver.
+// ExecuteAutoRunTools executes the given tool calls using the provided resolver, sending an
+// EventTypeToolRunning event to output, which may be nil, as each starts.
 // Returns the results for each tool call.
 func ExecuteAutoRunTools(
 	pendingToolCalls []ToolCall,
 	resolver func(name string, argsGetter ToolArgumentGetter, context *Context) (string, error),
 	context *Context,
+	output chan<- TextStreamEvent,
 ) []AutoRunResult {
 	results := make([]AutoRunResult, 0, len(pendingToolCalls))
 
 	for _, tc := range pendingToolCalls {
+		if output != nil {
+			output <- TextStreamEvent{Type: EventTypeToolRunning, Value: tc.Name}
+		}
 		getter := func(args any) error { return json.Unmarshal(tc.Arguments, args) }
 
 		result, err := resolver(tc.Name, getter, context)
@@ -335,8 +341,12 @@ type ToolStore struct {
 	log        TraceLog
 	doTrace    bool
 	authErrors []ToolAuthError
+	observers  []ToolResultObserver
 }
 
+// ToolResultObserver is called with the result of each tool resolved by the store
+type ToolResultObserver func(name string, result string, err error)
+
 type TraceLog interface {
 	Info(message string, keyValuePairs ...any)
 }
@@ -382,8 +392,19 @@ func (s *ToolStore) ResolveTool(name string, argsGetter ToolArgumentGetter, cont
 		s.TraceUnknown(name, argsGetter)
 		return "", errors.New("unknown tool " + name)
 	}
+	started := time.Now()
 	results, err := tool.Resolver(context, argsGetter)
 	s.TraceResolved(name, argsGetter, results, err)
+	for _, observer := range s.observers {
+		observer(name, results, err)
+	}
+	if context != nil && context.Trace != nil {
+		var args json.RawMessage
+		if getArgsErr := argsGetter(&args); getArgsErr != nil {
+			args = nil
+		}
+		context.Trace.RecordToolResolution(name, args, results, err, time.Since(started))
+	}
 	return results, err
 }
 
@@ -446,6 +467,11 @@ func (s *ToolStore) TraceResolved(name string, argsGetter ToolArgumentGetter, re
 	}
 }
 
+// AddResultObserver registers an observer called with the result of every tool resolved by the store
+func (s *ToolStore) AddResultObserver(observer ToolResultObserver) {
+	s.observers = append(s.observers, observer)
+}
+
 // AddAuthError adds an authentication error to the tool store
 func (s *ToolStore) AddAuthError(authError ToolAuthError) {
 	s.authErrors = append(s.authErrors, authError)
@@ -146,3 +146,25 @@ func TestToolCall_SanitizeArguments(t *testing.T) {
 		})
 	}
 }
+
+func TestExecuteAutoRunToolsReportsRunningTools(t *testing.T) {
+	output := make(chan TextStreamEvent, 2)
+	resolver := func(name string, _ ToolArgumentGetter, _ *Context) (string, error) {
+		return name + " result", nil
+	}
+
+	results := ExecuteAutoRunTools([]ToolCall{
+		{ID: "1", Name: "read_channel", Arguments: json.RawMessage(`{}`)},
+		{ID: "2", Name: "get_channel_info", Arguments: json.RawMessage(`{}`)},
+	}, resolver, nil, output)
+	close(output)
+
+	var running []string
+	for event := range output {
+		assert.Equal(t, EventTypeToolRunning, event.Type)
+		running = append(running, event.Value.(string))
+	}
+	assert.Equal(t, []string{"read_channel", "get_channel_info"}, running)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "read_channel result", results[0].Result)
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Trace records everything that happened while answering a request: the prompt sent to the
+// model, each model turn and the tools it called, and the tokens used.
+type Trace struct {
+	// RequestPostID is the post being answered
+	RequestPostID string `json:"request_post_id"`
+	BotUserID     string `json:"bot_user_id"`
+	UserID        string `json:"user_id"`
+	ChannelID     string `json:"channel_id"`
+
+	Prompt []TracePost `json:"prompt"`
+	Turns  []TraceTurn `json:"turns"`
+	Usage  TokenUsage  `json:"usage"`
+	Error  string      `json:"error,omitempty"`
+	// StoppedEarly is the reason the request stopped before finishing, if it spent its step budget
+	StoppedEarly string `json:"stopped_early,omitempty"`
+	// FinishReason is the reason the model last stopped generating
+	FinishReason string `json:"finish_reason,omitempty"`
+
+	StartedAt  int64 `json:"started_at"`
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// TracePost is a message of the prompt sent to the model
+type TracePost struct {
+	Role    string `json:"role"`
+	Message string `json:"message"`
+	// Files is the number of files attached to the message
+	Files int `json:"files,omitempty"`
+}
+
+// TraceTurn is a single response of the model
+type TraceTurn struct {
+	Text      string          `json:"text"`
+	Reasoning string          `json:"reasoning,omitempty"`
+	Refusal   string          `json:"refusal,omitempty"`
+	ToolCalls []TraceToolCall `json:"tool_calls,omitempty"`
+}
+
+// TraceToolCall is a tool call made by the model and, once it ran, its result
+type TraceToolCall struct {
+	ID         string          `json:"id,omitempty"`
+	Name       string          `json:"name"`
+	Arguments  json.RawMessage `json:"arguments,omitempty"`
+	Result     string          `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs int64           `json:"duration_ms"`
+	// Pending is set for tool calls waiting for the user's approval when the response ended
+	Pending bool `json:"pending,omitempty"`
+}
+
+// TraceRecorder builds a Trace as a request is answered. It is attached to the Context of the
+// request so tool resolutions are recorded too.
+type TraceRecorder struct {
+	mu        sync.Mutex
+	trace     Trace
+	started   time.Time
+	turnEnded bool
+}
+
+// NewTraceRecorder starts a trace of the request answering the post with the given ID
+func NewTraceRecorder(requestPostID string, request CompletionRequest) *TraceRecorder {
+	started := time.Now()
+	trace := Trace{
+		RequestPostID: requestPostID,
+		Prompt:        make([]TracePost, 0, len(request.Posts)),
+		Turns:         []TraceTurn{{}},
+		StartedAt:     started.UnixMilli(),
+	}
+
+	if request.Context != nil {
+		trace.BotUserID = request.Context.BotUserID
+		if request.Context.RequestingUser != nil {
+			trace.UserID = request.Context.RequestingUser.Id
+		}
+		if request.Context.Channel != nil {
+			trace.ChannelID = request.Context.Channel.Id
+		}
+	}
+
+	for _, post := range request.Posts {
+		trace.Prompt = append(trace.Prompt, TracePost{
+			Role:    tracePostRole(post.Role),
+			Message: post.Message,
+			Files:   len(post.Files),
+		})
+	}
+
+	return &TraceRecorder{
+		trace:   trace,
+		started: started,
+	}
+}
+
+func tracePostRole(role PostRole) string {
+	switch role {
+	case PostRoleSystem:
+		return "system"
+	case PostRoleBot:
+		return "assistant"
+	default:
+		return "user"
+	}
+}
+
+// currentTurn returns the turn events are recorded into. Must be called with the lock held.
+func (r *TraceRecorder) currentTurn() *TraceTurn {
+	if r.turnEnded {
+		r.trace.Turns = append(r.trace.Turns, TraceTurn{})
+		r.turnEnded = false
+	}
+	return &r.trace.Turns[len(r.trace.Turns)-1]
+}
+
+// RecordToolResolution records a tool that ran during the request. The model answers the results
+// in a new turn.
+func (r *TraceRecorder) RecordToolResolution(name string, arguments json.RawMessage, result string, err error, duration time.Duration) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	call := TraceToolCall{
+		Name:       name,
+		Arguments:  arguments,
+		Result:     result,
+		DurationMs: duration.Milliseconds(),
+	}
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	// Tools resolved together belong to the turn that requested them
+	turn := &r.trace.Turns[len(r.trace.Turns)-1]
+	turn.ToolCalls = append(turn.ToolCalls, call)
+	r.turnEnded = true
+}
+
+// recordEvent records a stream event into the current turn
+func (r *TraceRecorder) recordEvent(event TextStreamEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch event.Type {
+	case EventTypeText:
+		if text, ok := event.Value.(string); ok {
+			r.currentTurn().Text += text
+		}
+	case EventTypeReasoning:
+		if text, ok := event.Value.(string); ok {
+			r.currentTurn().Reasoning += text
+		}
+	case EventTypeRefusal:
+		if text, ok := event.Value.(string); ok {
+			r.currentTurn().Refusal += text
+		}
+	case EventTypeToolCalls:
+		if toolCalls, ok := event.Value.([]ToolCall); ok {
+			turn := r.currentTurn()
+			for _, toolCall := range toolCalls {
+				turn.ToolCalls = append(turn.ToolCalls, TraceToolCall{
+					ID:        toolCall.ID,
+					Name:      toolCall.Name,
+					Arguments: toolCall.Arguments,
+					Pending:   true,
+				})
+			}
+		}
+	case EventTypeUsage:
+		if usage, ok := event.Value.(TokenUsage); ok {
+			r.trace.Usage.InputTokens += usage.InputTokens
+			r.trace.Usage.OutputTokens += usage.OutputTokens
+		}
+	case EventTypeUsageTotal:
+		// The total replaces the usage of the model calls, which may not all have been seen
+		if usage, ok := event.Value.(RequestUsage); ok {
+			r.trace.Usage = usage.TokenUsage
+		}
+	case EventTypeStoppedEarly:
+		if reason, ok := event.Value.(string); ok {
+			r.trace.StoppedEarly = reason
+		}
+	case EventTypeFinish:
+		if reason, ok := event.Value.(string); ok {
+			r.trace.FinishReason = reason
+		}
+	case EventTypeError:
+		if err, ok := event.Value.(error); ok {
+			r.trace.Error = err.Error()
+		} else {
+			r.trace.Error = fmt.Sprint(event.Value)
+		}
+	}
+}
+
+// Trace returns a copy of the trace recorded so far
+func (r *TraceRecorder) Trace() Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trace := r.trace
+	trace.Prompt = append([]TracePost(nil), r.trace.Prompt...)
+	trace.Turns = make([]TraceTurn, len(r.trace.Turns))
+	for i, turn := range r.trace.Turns {
+		trace.Turns[i] = turn
+		trace.Turns[i].ToolCalls = append([]TraceToolCall(nil), turn.ToolCalls...)
+	}
+	trace.DurationMs = time.Since(r.started).Milliseconds()
+	return trace
+}
+
+// WrapStream records the events of the stream as they pass through and calls onComplete with
+// the finished trace once the stream is closed.
+func (r *TraceRecorder) WrapStream(stream *TextStreamResult, onComplete func(Trace)) *TextStreamResult {
+	output := make(chan TextStreamEvent)
+	go func() {
+		defer close(output)
+		for event := range stream.Stream {
+			r.recordEvent(event)
+			output <- event
+		}
+		onComplete(r.Trace())
+	}()
+
+	return &TextStreamResult{Stream: output}
+}
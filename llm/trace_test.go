@@ -0,0 +1,155 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceRecorder(t *testing.T) {
+	request := CompletionRequest{
+		Posts: []Post{
+			{Role: PostRoleSystem, Message: "system prompt"},
+			{Role: PostRoleUser, Message: "what is up?"},
+		},
+		Context: &Context{
+			BotUserID:      "botid",
+			RequestingUser: &model.User{Id: "userid"},
+			Channel:        &model.Channel{Id: "channelid"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		events   []TextStreamEvent
+		resolve  func(r *TraceRecorder)
+		validate func(t *testing.T, trace Trace)
+	}{
+		{
+			name: "text and usage",
+			events: []TextStreamEvent{
+				{Type: EventTypeReasoning, Value: "thinking"},
+				{Type: EventTypeText, Value: "Hello "},
+				{Type: EventTypeText, Value: "there"},
+				{Type: EventTypeUsage, Value: TokenUsage{InputTokens: 10, OutputTokens: 5}},
+				{Type: EventTypeEnd},
+			},
+			validate: func(t *testing.T, trace Trace) {
+				require.Len(t, trace.Turns, 1)
+				assert.Equal(t, "Hello there", trace.Turns[0].Text)
+				assert.Equal(t, "thinking", trace.Turns[0].Reasoning)
+				assert.Equal(t, int64(10), trace.Usage.InputTokens)
+				assert.Equal(t, int64(5), trace.Usage.OutputTokens)
+				assert.Empty(t, trace.Error)
+			},
+		},
+		{
+			name: "total usage of a tool loop",
+			events: []TextStreamEvent{
+				{Type: EventTypeUsageTotal, Value: RequestUsage{TokenUsage: TokenUsage{InputTokens: 250, OutputTokens: 50}, Calls: 2}},
+				{Type: EventTypeEnd},
+			},
+			validate: func(t *testing.T, trace Trace) {
+				assert.Equal(t, TokenUsage{InputTokens: 250, OutputTokens: 50}, trace.Usage)
+			},
+		},
+		{
+			name: "finish reason",
+			events: []TextStreamEvent{
+				{Type: EventTypeText, Value: "Partial"},
+				{Type: EventTypeFinish, Value: FinishReasonLength},
+				{Type: EventTypeEnd},
+			},
+			validate: func(t *testing.T, trace Trace) {
+				assert.Equal(t, FinishReasonLength, trace.FinishReason)
+			},
+		},
+		{
+			name: "resolved tools start a new turn",
+			resolve: func(r *TraceRecorder) {
+				r.RecordToolResolution("search", json.RawMessage(`{"q":"up"}`), "results", nil, 20*time.Millisecond)
+				r.RecordToolResolution("lookup", json.RawMessage(`{}`), "", errors.New("boom"), time.Millisecond)
+			},
+			events: []TextStreamEvent{
+				{Type: EventTypeText, Value: "Found it"},
+				{Type: EventTypeEnd},
+			},
+			validate: func(t *testing.T, trace Trace) {
+				require.Len(t, trace.Turns, 2)
+				require.Len(t, trace.Turns[0].ToolCalls, 2)
+				assert.Equal(t, "search", trace.Turns[0].ToolCalls[0].Name)
+				assert.Equal(t, "results", trace.Turns[0].ToolCalls[0].Result)
+				assert.Equal(t, int64(20), trace.Turns[0].ToolCalls[0].DurationMs)
+				assert.Equal(t, "boom", trace.Turns[0].ToolCalls[1].Error)
+				assert.Equal(t, "Found it", trace.Turns[1].Text)
+			},
+		},
+		{
+			name: "pending tool calls and errors",
+			events: []TextStreamEvent{
+				{Type: EventTypeToolCalls, Value: []ToolCall{{ID: "call1", Name: "create_post", Arguments: json.RawMessage(`{}`)}}},
+				{Type: EventTypeError, Value: errors.New("stream failed")},
+			},
+			validate: func(t *testing.T, trace Trace) {
+				require.Len(t, trace.Turns, 1)
+				require.Len(t, trace.Turns[0].ToolCalls, 1)
+				assert.Equal(t, "call1", trace.Turns[0].ToolCalls[0].ID)
+				assert.True(t, trace.Turns[0].ToolCalls[0].Pending)
+				assert.Equal(t, "stream failed", trace.Error)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := NewTraceRecorder("postid", request)
+			if tc.resolve != nil {
+				tc.resolve(recorder)
+			}
+
+			input := make(chan TextStreamEvent)
+			go func() {
+				defer close(input)
+				for _, event := range tc.events {
+					input <- event
+				}
+			}()
+
+			done := make(chan Trace, 1)
+			wrapped := recorder.WrapStream(&TextStreamResult{Stream: input}, func(trace Trace) {
+				done <- trace
+			})
+
+			var forwarded int
+			for range wrapped.Stream {
+				forwarded++
+			}
+			assert.Equal(t, len(tc.events), forwarded)
+
+			trace := <-done
+			assert.Equal(t, "postid", trace.RequestPostID)
+			assert.Equal(t, "botid", trace.BotUserID)
+			assert.Equal(t, "userid", trace.UserID)
+			assert.Equal(t, "channelid", trace.ChannelID)
+			require.Len(t, trace.Prompt, 2)
+			assert.Equal(t, "system", trace.Prompt[0].Role)
+			assert.Equal(t, "user", trace.Prompt[1].Role)
+			tc.validate(t, trace)
+		})
+	}
+
+	t.Run("nil recorder ignores tool resolutions", func(t *testing.T) {
+		var recorder *TraceRecorder
+		assert.NotPanics(t, func() {
+			recorder.RecordToolResolution("search", nil, "", nil, 0)
+		})
+	})
+}
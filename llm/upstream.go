@@ -0,0 +1,66 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import "net/http"
+
+// EnforcesZeroDataRetention returns whether zero data retention is configured for the service
+// and the service accepts a per request parameter to enforce it. Other providers only offer
+// zero data retention through an agreement with the account.
+func (c ServiceConfig) EnforcesZeroDataRetention() bool {
+	if !c.ZeroDataRetention {
+		return false
+	}
+
+	switch c.Type {
+	case ServiceTypeOpenAI, ServiceTypeOpenAICompatible, ServiceTypeAzure:
+		return true
+	default:
+		return false
+	}
+}
+
+// headerTransport adds the configured headers to every request
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Clone the request to avoid modifying the original
+	req = req.Clone(req.Context())
+
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// UpstreamHTTPClient returns the client used to send requests to the service, adding the
+// custom headers configured for it.
+func UpstreamHTTPClient(client *http.Client, service ServiceConfig) *http.Client {
+	if len(service.CustomHeaders) == 0 {
+		return client
+	}
+
+	headers := make(map[string]string, len(service.CustomHeaders))
+	for key, value := range service.CustomHeaders {
+		headers[key] = value
+	}
+
+	var withHeaders http.Client
+	if client != nil {
+		withHeaders = *client
+	}
+	withHeaders.Transport = &headerTransport{
+		base:    withHeaders.Transport,
+		headers: headers,
+	}
+	return &withHeaders
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnforcesZeroDataRetention(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  ServiceConfig
+		expected bool
+	}{
+		{
+			name:     "disabled",
+			service:  ServiceConfig{Type: ServiceTypeOpenAI},
+			expected: false,
+		},
+		{
+			name:     "openai",
+			service:  ServiceConfig{Type: ServiceTypeOpenAI, ZeroDataRetention: true},
+			expected: true,
+		},
+		{
+			name:     "azure",
+			service:  ServiceConfig{Type: ServiceTypeAzure, ZeroDataRetention: true},
+			expected: true,
+		},
+		{
+			name:     "openai compatible",
+			service:  ServiceConfig{Type: ServiceTypeOpenAICompatible, ZeroDataRetention: true},
+			expected: true,
+		},
+		{
+			name:     "anthropic has no request parameter",
+			service:  ServiceConfig{Type: ServiceTypeAnthropic, ZeroDataRetention: true},
+			expected: false,
+		},
+		{
+			name:     "bedrock has no request parameter",
+			service:  ServiceConfig{Type: ServiceTypeBedrock, ZeroDataRetention: true},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.service.EnforcesZeroDataRetention())
+		})
+	}
+}
+
+func TestUpstreamHTTPClient(t *testing.T) {
+	t.Run("no custom headers returns the same client", func(t *testing.T) {
+		client := &http.Client{}
+		assert.Same(t, client, UpstreamHTTPClient(client, ServiceConfig{}))
+	})
+
+	t.Run("custom headers are added to requests", func(t *testing.T) {
+		var received http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Clone()
+		}))
+		defer server.Close()
+
+		base := &http.Client{}
+		client := UpstreamHTTPClient(base, ServiceConfig{
+			CustomHeaders: map[string]string{"X-Data-Handling": "no-retention"},
+		})
+		assert.NotSame(t, base, client)
+		assert.Nil(t, base.Transport)
+
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer key")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, "no-retention", received.Get("X-Data-Handling"))
+		assert.Equal(t, "Bearer key", received.Get("Authorization"))
+		assert.Empty(t, req.Header.Get("X-Data-Handling"))
+	})
+}
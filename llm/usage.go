@@ -0,0 +1,53 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+// RequestUsage is the token usage of all the model calls made to answer a request
+type RequestUsage struct {
+	TokenUsage
+	// Calls is the number of model calls, more than one when tools were run automatically
+	Calls int `json:"calls"`
+}
+
+// Add adds the usage of a model call of the request
+func (u *RequestUsage) Add(usage TokenUsage) {
+	u.InputTokens += usage.InputTokens
+	u.OutputTokens += usage.OutputTokens
+	u.Calls++
+}
+
+// AggregateUsage forwards the events of a provider stream, which has a usage event per model
+// call, and sends the usage of the whole request as an EventTypeUsageTotal event before the
+// stream ends or fails.
+func AggregateUsage(stream <-chan TextStreamEvent) <-chan TextStreamEvent {
+	output := make(chan TextStreamEvent)
+
+	go func() {
+		defer close(output)
+
+		var total RequestUsage
+		sent := false
+		sendTotal := func() {
+			if !sent && total.Calls > 0 {
+				output <- TextStreamEvent{Type: EventTypeUsageTotal, Value: total}
+			}
+			sent = true
+		}
+
+		for event := range stream {
+			switch event.Type {
+			case EventTypeUsage:
+				if usage, ok := event.Value.(TokenUsage); ok {
+					total.Add(usage)
+				}
+			case EventTypeEnd, EventTypeError:
+				sendTotal()
+			}
+			output <- event
+		}
+		sendTotal()
+	}()
+
+	return output
+}
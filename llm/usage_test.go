@@ -0,0 +1,81 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateUsage(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      []TextStreamEvent
+		expectTypes []EventType
+		expectTotal *RequestUsage
+	}{
+		{
+			name: "tool loop with a usage event per model call",
+			events: []TextStreamEvent{
+				{Type: EventTypeUsage, Value: TokenUsage{InputTokens: 100, OutputTokens: 20}},
+				{Type: EventTypeText, Value: "Found it"},
+				{Type: EventTypeUsage, Value: TokenUsage{InputTokens: 150, OutputTokens: 30}},
+				{Type: EventTypeEnd},
+			},
+			expectTypes: []EventType{EventTypeUsage, EventTypeText, EventTypeUsage, EventTypeUsageTotal, EventTypeEnd},
+			expectTotal: &RequestUsage{TokenUsage: TokenUsage{InputTokens: 250, OutputTokens: 50}, Calls: 2},
+		},
+		{
+			name: "total is sent once before an error and the end",
+			events: []TextStreamEvent{
+				{Type: EventTypeUsage, Value: TokenUsage{InputTokens: 10, OutputTokens: 1}},
+				{Type: EventTypeError, Value: errors.New("boom")},
+				{Type: EventTypeEnd},
+			},
+			expectTypes: []EventType{EventTypeUsage, EventTypeUsageTotal, EventTypeError, EventTypeEnd},
+			expectTotal: &RequestUsage{TokenUsage: TokenUsage{InputTokens: 10, OutputTokens: 1}, Calls: 1},
+		},
+		{
+			name: "stream closed without an end",
+			events: []TextStreamEvent{
+				{Type: EventTypeUsage, Value: TokenUsage{InputTokens: 10, OutputTokens: 1}},
+			},
+			expectTypes: []EventType{EventTypeUsage, EventTypeUsageTotal},
+			expectTotal: &RequestUsage{TokenUsage: TokenUsage{InputTokens: 10, OutputTokens: 1}, Calls: 1},
+		},
+		{
+			name: "no usage",
+			events: []TextStreamEvent{
+				{Type: EventTypeText, Value: "Hello"},
+				{Type: EventTypeEnd},
+			},
+			expectTypes: []EventType{EventTypeText, EventTypeEnd},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := make(chan TextStreamEvent, len(tc.events))
+			for _, event := range tc.events {
+				stream <- event
+			}
+			close(stream)
+
+			var types []EventType
+			var total *RequestUsage
+			for event := range AggregateUsage(stream) {
+				types = append(types, event.Type)
+				if event.Type == EventTypeUsageTotal {
+					usage := event.Value.(RequestUsage)
+					total = &usage
+				}
+			}
+
+			assert.Equal(t, tc.expectTypes, types)
+			assert.Equal(t, tc.expectTotal, total)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package llmcontext
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// WithLLMContextComponents replaces the default details of the system prompt with the context
+// components configured for the bot, each truncated to its token budget. It must be applied
+// after the requesting user, channel and team are set.
+func (b *Builder) WithLLMContextComponents(bot *bots.Bot) llm.ContextOption {
+	return func(c *llm.Context) {
+		if bot == nil || len(bot.GetConfig().ContextComponents) == 0 {
+			return
+		}
+
+		countTokens := func(text string) int { return len(text) / 4 }
+		if model := bot.LLM(); model != nil {
+			countTokens = model.CountTokens
+		}
+
+		details := make([]llm.ContextDetail, 0, len(bot.GetConfig().ContextComponents))
+		for _, component := range bot.GetConfig().ContextComponents {
+			label, text := componentDetail(c, component)
+			text = strings.TrimSpace(text)
+			if text == "" {
+				continue
+			}
+
+			maxTokens := component.MaxTokens
+			if maxTokens == 0 {
+				maxTokens = llm.DefaultContextComponentTokens
+			}
+			details = append(details, llm.ContextDetail{
+				Label: label,
+				Text:  truncateToTokens(text, maxTokens, countTokens),
+			})
+		}
+		c.Details = details
+	}
+}
+
+// componentDetail returns the label and text of the component, the text being empty when the
+// request has nothing to add for it
+func componentDetail(c *llm.Context, component llm.ContextComponent) (string, string) {
+	switch component.Type {
+	case llm.ContextComponentUserProfile:
+		if c.RequestingUser == nil {
+			return "", ""
+		}
+		text := fmt.Sprintf("username '%s'", c.RequestingUser.Username)
+		if fullName := strings.TrimSpace(c.RequestingUser.FirstName + " " + c.RequestingUser.LastName); fullName != "" {
+			text += fmt.Sprintf(", full name %s", fullName)
+		}
+		if c.RequestingUser.Position != "" {
+			text += fmt.Sprintf(", position '%s'", c.RequestingUser.Position)
+		}
+		return "User making the request", text
+
+	case llm.ContextComponentChannel:
+		if !isNamedChannel(c.Channel) {
+			return "", ""
+		}
+		return "Channel", fmt.Sprintf("name '%s', display name '%s'", c.Channel.Name, c.Channel.DisplayName)
+
+	case llm.ContextComponentChannelPurpose:
+		if !isNamedChannel(c.Channel) {
+			return "", ""
+		}
+		var parts []string
+		if c.Channel.Purpose != "" {
+			parts = append(parts, "Purpose: "+c.Channel.Purpose)
+		}
+		if c.Channel.Header != "" {
+			parts = append(parts, "Header: "+c.Channel.Header)
+		}
+		return "Channel purpose", strings.Join(parts, "\n")
+
+	case llm.ContextComponentTeam:
+		if c.Team == nil {
+			return "", ""
+		}
+		text := fmt.Sprintf("name '%s', display name '%s'", c.Team.Name, c.Team.DisplayName)
+		if c.Team.Description != "" {
+			text += ", description: " + c.Team.Description
+		}
+		return "Team", text
+
+	case llm.ContextComponentTime:
+		if c.Timezone != "" {
+			return "Current time and date in the user's location", fmt.Sprintf("%s (%s time zone)", c.Time, c.Timezone)
+		}
+		return "Current time and date in the user's location", c.Time
+
+	case llm.ContextComponentCustom:
+		return component.Key, component.Value
+
+	default:
+		return "", ""
+	}
+}
+
+func isNamedChannel(channel *model.Channel) bool {
+	return channel != nil && channel.Type != model.ChannelTypeDirect && channel.Type != model.ChannelTypeGroup
+}
+
+// truncateToTokens cuts the text to about maxTokens, assuming four characters per token like
+// the truncation of conversations
+func truncateToTokens(text string, maxTokens int, countTokens func(string) int) string {
+	if countTokens(text) <= maxTokens {
+		return text
+	}
+
+	end := maxTokens * 4
+	if end >= len(text) {
+		return text
+	}
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return strings.TrimSpace(text[:end]) + "…"
+}
@@ -18,6 +18,11 @@ type ToolProvider interface {
 	GetTools(bot *bots.Bot) []llm.Tool
 }
 
+// CustomToolProvider provides the admin defined tools for a bot
+type CustomToolProvider interface {
+	GetToolsForBot(botID string) []llm.Tool
+}
+
 // MCPToolProvider provides MCP tools for a user
 type MCPToolProvider interface {
 	GetToolsForUser(userID string) ([]llm.Tool, *mcp.Errors)
@@ -29,12 +34,19 @@ type ConfigProvider interface {
 	GetServiceByID(id string) (llm.ServiceConfig, bool)
 }
 
+// TeamInstructionsProvider provides the instructions configured by team admins
+type TeamInstructionsProvider interface {
+	GetTeamInstructions(teamID string) string
+}
+
 // Builder builds contexts for LLM requests
 type Builder struct {
-	pluginAPI       *pluginapi.Client
-	toolProvider    ToolProvider
-	mcpToolProvider MCPToolProvider
-	configProvider  ConfigProvider
+	pluginAPI                *pluginapi.Client
+	toolProvider             ToolProvider
+	mcpToolProvider          MCPToolProvider
+	configProvider           ConfigProvider
+	teamInstructionsProvider TeamInstructionsProvider
+	customToolProvider       CustomToolProvider
 }
 
 // NewLLMContextBuilder creates a new LLM context builder
@@ -52,6 +64,11 @@ func NewLLMContextBuilder(
 	}
 }
 
+// SetCustomToolProvider sets the source of the admin defined tools added next to the built-in tools
+func (b *Builder) SetCustomToolProvider(provider CustomToolProvider) {
+	b.customToolProvider = provider
+}
+
 // BuildLLMContextUserRequest is a helper function to collect the required context for a user request.
 func (b *Builder) BuildLLMContextUserRequest(bot *bots.Bot, requestingUser *model.User, channel *model.Channel, opts ...llm.ContextOption) *llm.Context {
 	allOpts := []llm.ContextOption{
@@ -61,10 +78,27 @@ func (b *Builder) BuildLLMContextUserRequest(bot *bots.Bot, requestingUser *mode
 		b.WithLLMContextBot(bot),
 	}
 	allOpts = append(allOpts, opts...)
+	// Applied last so a team set by the caller's options is also taken into account
+	allOpts = append(allOpts, b.WithLLMContextTeamInstructions(), b.WithLLMContextComponents(bot))
 
 	return llm.NewContext(allOpts...)
 }
 
+// SetTeamInstructionsProvider sets the source of team-scoped instructions added to system prompts
+func (b *Builder) SetTeamInstructionsProvider(provider TeamInstructionsProvider) {
+	b.teamInstructionsProvider = provider
+}
+
+func (b *Builder) WithLLMContextTeamInstructions() llm.ContextOption {
+	return func(c *llm.Context) {
+		if b.teamInstructionsProvider == nil || c.Team == nil {
+			return
+		}
+
+		c.TeamInstructions = b.teamInstructionsProvider.GetTeamInstructions(c.Team.Id)
+	}
+}
+
 func (b *Builder) WithLLMContextServerInfo() llm.ContextOption {
 	return func(c *llm.Context) {
 		if b.pluginAPI.Configuration.GetConfig().TeamSettings.SiteName != nil {
@@ -102,10 +136,12 @@ func (b *Builder) WithLLMContextRequestingUser(user *model.User) llm.ContextOpti
 	return func(c *llm.Context) {
 		c.RequestingUser = user
 		if user != nil {
+			c.Locale = user.Locale
 			tz := user.GetPreferredTimezone()
 			loc, err := time.LoadLocation(tz)
 			if err == nil && loc != nil {
 				c.Time = time.Now().In(loc).Format(time.RFC1123)
+				c.Timezone = loc.String()
 			}
 		}
 	}
@@ -136,6 +172,11 @@ func (b *Builder) getToolsStoreForUser(c *llm.Context, bot *bots.Bot, userID str
 	// Create a tool store that requires user approval for tool calls
 	store := llm.NewToolStore(&b.pluginAPI.Log, b.configProvider.GetEnableLLMTrace())
 
+	// Add admin defined tools first so built-in and MCP tools with the same name take precedence
+	if b.customToolProvider != nil {
+		store.AddTools(b.customToolProvider.GetToolsForBot(bot.GetConfig().ID))
+	}
+
 	// Add built-in tools (always add for LLM awareness; execution controlled via WithToolsDisabled)
 	store.AddTools(b.toolProvider.GetTools(bot))
 
@@ -204,6 +245,7 @@ func (b *Builder) WithLLMContextBot(bot *bots.Bot) llm.ContextOption {
 		c.BotName = bot.GetConfig().DisplayName
 		c.BotUsername = bot.GetConfig().Name
 		c.CustomInstructions = bot.GetConfig().CustomInstructions
+		c.OutputLanguage = bot.GetConfig().OutputLanguage
 		// Set the bot user ID for AI-generated content tracking
 		if mmbot := bot.GetMMBot(); mmbot != nil {
 			c.BotUserID = mmbot.UserId
@@ -8,13 +8,18 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"golang.org/x/oauth2"
 )
 
+const (
+	oauthSessionKeyPrefix = "oauth_session"
+	oauthTokenKeyPrefix   = "mcp_oauth_token_v1"
+)
+
 func buildSessionKey(userID, state string) string {
-	oauthSessionKeyPrefix := "oauth_session"
 	return fmt.Sprintf("%s_%s_%s", oauthSessionKeyPrefix, userID, state)
 }
 
@@ -27,8 +32,13 @@ func buildClientCredentialsKey(serverURL string) string {
 }
 
 func buildTokenKey(userID, serverID string) string {
-	prefix := "mcp_oauth_token_v1"
-	return fmt.Sprintf("%s_%s_%s", prefix, userID, serverID)
+	return fmt.Sprintf("%s_%s_%s", oauthTokenKeyPrefix, userID, serverID)
+}
+
+// IsUserOAuthKey reports whether the KV key stores an OAuth token or session of the user
+func IsUserOAuthKey(key, userID string) bool {
+	return strings.HasPrefix(key, fmt.Sprintf("%s_%s_", oauthTokenKeyPrefix, userID)) ||
+		strings.HasPrefix(key, fmt.Sprintf("%s_%s_", oauthSessionKeyPrefix, userID))
 }
 
 // loadToken retrieves the OAuth token for a user and server from the KV store
@@ -3,6 +3,8 @@
 
 package mcpserver
 
+import "github.com/mattermost/mattermost-plugin-ai/mcpserver/tools"
+
 // BaseConfig represents common configuration for all MCP server types
 type BaseConfig struct {
 	// Mattermost server URL (e.g., "https://mattermost.company.com")
@@ -90,8 +92,13 @@ func (c HTTPConfig) GetTrackAIGenerated() bool {
 // Used for embedded MCP servers that run within the same process as the plugin
 type InMemoryConfig struct {
 	BaseConfig
-	// No additional configuration needed for in-memory transport
 	// Authentication is handled through session tokens passed via context
+
+	// ChannelExcluder reports the channels the read tools must not return content from
+	ChannelExcluder tools.ChannelExcluder `json:"-"`
+
+	// ChannelStats computes the stats of the get_channel_stats tool, which is only available with it
+	ChannelStats tools.ChannelStatsProvider `json:"-"`
 }
 
 // GetTrackAIGenerated returns whether to track AI-generated content
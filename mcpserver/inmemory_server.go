@@ -39,8 +39,10 @@ func NewInMemoryServer(config InMemoryConfig, logger loggerlib.Logger) (*Matterm
 
 	mattermostServer := &MattermostInMemoryMCPServer{
 		MattermostMCPServer: &MattermostMCPServer{
-			logger: logger,
-			config: config,
+			logger:          logger,
+			config:          config,
+			channelExcluder: config.ChannelExcluder,
+			channelStats:    config.ChannelStats,
 		},
 		config: config,
 	}
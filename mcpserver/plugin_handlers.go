@@ -24,8 +24,9 @@ type PluginMCPHandlers struct {
 }
 
 // NewPluginMCPHandlers creates MCP handlers for use within a Mattermost plugin
-// The handlers expect requests to have an Authorization Bearer token injected by the plugin middleware
-func NewPluginMCPHandlers(siteURL string, logger loggerlib.Logger) (*PluginMCPHandlers, error) {
+// The handlers expect requests to have an Authorization Bearer token injected by the plugin middleware.
+// The read tools don't return content of the channels excluded by channelExcluder, which may be nil.
+func NewPluginMCPHandlers(siteURL string, logger loggerlib.Logger, channelExcluder tools.ChannelExcluder) (*PluginMCPHandlers, error) {
 	if siteURL == "" {
 		return nil, fmt.Errorf("site URL cannot be empty")
 	}
@@ -71,6 +72,9 @@ func NewPluginMCPHandlers(siteURL string, logger loggerlib.Logger) (*PluginMCPHa
 		config,
 		tools.AccessModeRemote,
 	)
+	if channelExcluder != nil {
+		toolProvider.SetChannelExcluder(channelExcluder)
+	}
 	toolProvider.ProvideTools(mcpServer)
 
 	// Create streamable HTTP handler for modern MCP communication
@@ -18,11 +18,21 @@ type MattermostMCPServer struct {
 	authProvider auth.AuthenticationProvider
 	logger       loggerlib.Logger
 	config       types.ServerConfig
+	// channelExcluder is optional, without it no channel is excluded
+	channelExcluder tools.ChannelExcluder
+	// channelStats is optional, without it the get_channel_stats tool is not available
+	channelStats tools.ChannelStatsProvider
 }
 
 // registerTools registers all tools using the tool provider
 func (s *MattermostMCPServer) registerTools(accessMode tools.AccessMode) {
 	toolProvider := tools.NewMattermostToolProvider(s.authProvider, s.logger, s.config, accessMode)
+	if s.channelExcluder != nil {
+		toolProvider.SetChannelExcluder(s.channelExcluder)
+	}
+	if s.channelStats != nil {
+		toolProvider.SetChannelStats(s.channelStats)
+	}
 	toolProvider.ProvideTools(s.mcpServer)
 }
 
@@ -16,11 +16,23 @@ import (
 	"github.com/mattermost/mattermost/server/public/model"
 )
 
+const (
+	// readChannelPageSize is the number of posts fetched at once by read_channel when posts
+	// outside of the requested window are skipped
+	readChannelPageSize = 200
+	// readChannelMaxPages bounds the pages scanned by a single read_channel call looking for
+	// posts in the requested window
+	readChannelMaxPages = 10
+)
+
 // ReadChannelArgs represents arguments for the read_channel tool
 type ReadChannelArgs struct {
-	ChannelID string `json:"channel_id" jsonschema:"The ID of the channel to read from,minLength=26,maxLength=26"`
-	Limit     int    `json:"limit,omitempty" jsonschema:"Number of posts to retrieve (default: 20, max: 100),minimum=1,maximum=100"`
-	Since     string `json:"since,omitempty" jsonschema:"Only get posts since this timestamp (ISO 8601 format),format=date-time"`
+	ChannelID      string `json:"channel_id" jsonschema:"The ID of the channel to read from,minLength=26,maxLength=26"`
+	Limit          int    `json:"limit,omitempty" jsonschema:"Number of posts to retrieve (default: 20, max: 100),minimum=1,maximum=100"`
+	Since          string `json:"since,omitempty" jsonschema:"Only get posts since this timestamp (ISO 8601 format),format=date-time"`
+	Until          string `json:"until,omitempty" jsonschema:"Only get posts before this timestamp (ISO 8601 format),format=date-time"`
+	Cursor         string `json:"cursor,omitempty" jsonschema:"The next cursor returned by a previous call, to continue with the older posts,maxLength=26"`
+	IncludeThreads *bool  `json:"include_threads,omitempty" jsonschema:"Whether to include thread replies, otherwise only root posts are returned with their reply count (default: true)"`
 }
 
 // CreateChannelArgs represents arguments for the create_channel tool
@@ -66,7 +78,7 @@ func (p *MattermostToolProvider) getChannelTools() []MCPTool {
 	return []MCPTool{
 		{
 			Name:        "read_channel",
-			Description: "Read recent posts from a Mattermost channel. Parameters: channel_id (required), limit (1-100, default 20), since (ISO 8601 timestamp, optional). Returns post details including author, content, and timestamps. Example: {\"channel_id\": \"h5wqm8kxptbztfgzpaxbsqozah\", \"limit\": 10, \"since\": \"2024-01-01T00:00:00Z\"}",
+			Description: "Read posts from a Mattermost channel, newest first. Parameters: channel_id (required), limit (1-100, default 20), since and until (ISO 8601 timestamps, optional) to read a time window, cursor (optional, the next cursor of a previous call) to page through older posts, include_threads (boolean, default true, false returns only root posts). Returns post details including author, content, timestamps, and the reply count of threads, to be read with read_thread, and a next cursor when older posts remain. Example: {\"channel_id\": \"h5wqm8kxptbztfgzpaxbsqozah\", \"limit\": 10, \"since\": \"2024-01-01T00:00:00Z\", \"until\": \"2024-01-08T00:00:00Z\"}",
 			Schema:      llm.NewJSONSchemaFromStruct[ReadChannelArgs](),
 			Resolver:    p.toolReadChannel,
 		},
@@ -131,8 +143,8 @@ func (p *MattermostToolProvider) toolReadChannel(mcpContext *MCPToolContext, arg
 	client := mcpContext.Client
 	ctx := mcpContext.Ctx // Use request context for proper cancellation and timeout handling
 
-	// Parse since timestamp if provided
-	var since int64
+	// Parse since and until timestamps if provided
+	var since, until int64
 	if args.Since != "" {
 		parsedTime, parseErr := time.Parse(time.RFC3339, args.Since)
 		if parseErr != nil {
@@ -140,12 +152,31 @@ func (p *MattermostToolProvider) toolReadChannel(mcpContext *MCPToolContext, arg
 		}
 		since = parsedTime.Unix() * 1000 // Convert to milliseconds
 	}
+	if args.Until != "" {
+		parsedTime, parseErr := time.Parse(time.RFC3339, args.Until)
+		if parseErr != nil {
+			return "invalid until timestamp format", fmt.Errorf("invalid timestamp format: %w", parseErr)
+		}
+		until = parsedTime.Unix() * 1000 // Convert to milliseconds
+	}
+	if since != 0 && until != 0 && until <= since {
+		return "until must be after since", fmt.Errorf("until must be after since")
+	}
+
+	if args.Cursor != "" && !model.IsValidId(args.Cursor) {
+		return "invalid cursor format", fmt.Errorf("cursor must be a valid ID")
+	}
+	includeThreads := args.IncludeThreads == nil || *args.IncludeThreads
 
 	// Get channel info for context
 	channel, _, err := client.GetChannel(ctx, args.ChannelID, "")
 	if err != nil {
 		return "failed to fetch channel info", fmt.Errorf("error fetching channel: %w", err)
 	}
+	policy, err := p.checkChannel(channel)
+	if err != nil {
+		return channelErrorMessage(err), err
+	}
 
 	// Determine team display name; DMs/Groups have no team
 	channelDisplayName := channel.DisplayName
@@ -179,20 +210,16 @@ func (p *MattermostToolProvider) toolReadChannel(mcpContext *MCPToolContext, arg
 	}
 
 	// Get posts from the channel
-	posts, _, err := client.GetPostsForChannel(ctx, args.ChannelID, 0, args.Limit, "", false, false)
+	filteredPosts, nextCursor, err := fetchChannelPosts(ctx, client, args.ChannelID, args.Cursor, since, until, args.Limit, includeThreads)
 	if err != nil {
 		return "failed to fetch channel posts", fmt.Errorf("error fetching posts: %w", err)
 	}
-
-	// Filter by since timestamp if provided
-	var filteredPosts []*model.Post
-	for _, post := range posts.ToSlice() {
-		if since == 0 || post.CreateAt >= since {
-			filteredPosts = append(filteredPosts, post)
-		}
-	}
+	filteredPosts = p.filterPolicyPosts(ctx, client, policy, filteredPosts)
 
 	if len(filteredPosts) == 0 {
+		if nextCursor != "" {
+			return fmt.Sprintf("no posts found in the scanned range, older posts remain. Next cursor: %s", nextCursor), nil
+		}
 		return "no posts found in the specified timeframe", nil
 	}
 
@@ -224,12 +251,66 @@ func (p *MattermostToolProvider) toolReadChannel(mcpContext *MCPToolContext, arg
 		username := userCache[post.UserId]
 		result.WriteString(fmt.Sprintf("**Post %d** by %s:\n", i+1, username))
 		result.WriteString(fmt.Sprintf("Post ID: %s\n", post.Id))
+		if post.RootId == "" && post.ReplyCount > 0 {
+			result.WriteString(fmt.Sprintf("Replies: %d (use read_thread to read them)\n", post.ReplyCount))
+		}
 		result.WriteString(fmt.Sprintf("%s\n\n", post.Message))
 	}
 
+	if nextCursor != "" {
+		result.WriteString(fmt.Sprintf("Next cursor: %s (pass it as cursor to read older posts)\n", nextCursor))
+	}
+
 	return result.String(), nil
 }
 
+// fetchChannelPosts returns up to limit posts of the channel created in [since, until), newest
+// first, starting before the cursor post. Pages are scanned until enough posts are found or the
+// scan limit is reached. The returned cursor is empty when no older posts remain.
+func fetchChannelPosts(ctx context.Context, client *model.Client4, channelID, cursor string, since, until int64, limit int, includeThreads bool) ([]*model.Post, string, error) {
+	// Pages only need to be larger than the limit when posts are skipped
+	perPage := limit
+	if until != 0 || !includeThreads {
+		perPage = readChannelPageSize
+	}
+
+	var posts []*model.Post
+	before := cursor
+	for range readChannelMaxPages {
+		var postList *model.PostList
+		var err error
+		if before == "" {
+			postList, _, err = client.GetPostsForChannel(ctx, channelID, 0, perPage, "", !includeThreads, false)
+		} else {
+			postList, _, err = client.GetPostsBefore(ctx, channelID, before, 0, perPage, "", !includeThreads, false)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		page := postList.ToSlice()
+		for _, post := range page {
+			if since != 0 && post.CreateAt < since {
+				return posts, "", nil
+			}
+			if (until != 0 && post.CreateAt >= until) || (!includeThreads && post.RootId != "") {
+				continue
+			}
+			posts = append(posts, post)
+			if len(posts) == limit {
+				return posts, post.Id, nil
+			}
+		}
+
+		if len(page) < perPage {
+			return posts, "", nil
+		}
+		before = page[len(page)-1].Id
+	}
+
+	return posts, before, nil
+}
+
 // toolCreateChannel implements the create_channel tool
 func (p *MattermostToolProvider) toolCreateChannel(mcpContext *MCPToolContext, argsGetter llm.ToolArgumentGetter) (string, error) {
 	var args CreateChannelArgs
@@ -0,0 +1,144 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+// newPostsServer serves the posts of a channel, oldest first in posts, like the posts API does:
+// newest first, paged before a post, without replies when threads are collapsed.
+func newPostsServer(t *testing.T, posts []*model.Post) *model.Client4 {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		perPage, err := strconv.Atoi(query.Get("per_page"))
+		require.NoError(t, err)
+
+		end := len(posts)
+		if before := query.Get("before"); before != "" {
+			for i, post := range posts {
+				if post.Id == before {
+					end = i
+				}
+			}
+		}
+
+		postList := model.NewPostList()
+		for i := end - 1; i >= 0 && len(postList.Order) < perPage; i-- {
+			if query.Get("collapsedThreads") == "true" && posts[i].RootId != "" {
+				continue
+			}
+			postList.AddPost(posts[i])
+			postList.AddOrder(posts[i].Id)
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(postList))
+	}))
+	t.Cleanup(server.Close)
+
+	return model.NewAPIv4Client(server.URL)
+}
+
+func TestFetchChannelPosts(t *testing.T) {
+	// 500 posts one minute apart, every fifth post is a reply
+	var posts []*model.Post
+	for i := range 500 {
+		post := &model.Post{Id: fmt.Sprintf("post%022d", i), CreateAt: int64(i) * 60000, Message: strconv.Itoa(i)}
+		if i%5 == 4 {
+			post.RootId = posts[i-1].Id
+		}
+		posts = append(posts, post)
+	}
+	client := newPostsServer(t, posts)
+
+	tests := []struct {
+		name             string
+		cursor           string
+		since            int64
+		until            int64
+		limit            int
+		excludeThreads   bool
+		expectedMessages []string
+		expectedCursor   string
+	}{
+		{
+			name:             "most recent posts",
+			limit:            3,
+			expectedMessages: []string{"499", "498", "497"},
+			expectedCursor:   posts[497].Id,
+		},
+		{
+			name:             "older posts from the cursor",
+			cursor:           posts[497].Id,
+			limit:            3,
+			expectedMessages: []string{"496", "495", "494"},
+			expectedCursor:   posts[494].Id,
+		},
+		{
+			name:             "since stops at the window",
+			since:            497 * 60000,
+			limit:            10,
+			expectedMessages: []string{"499", "498", "497"},
+		},
+		{
+			name:             "until skips the newer posts across pages",
+			until:            3 * 60000,
+			limit:            10,
+			expectedMessages: []string{"2", "1", "0"},
+		},
+		{
+			name:             "window",
+			since:            100 * 60000,
+			until:            103 * 60000,
+			limit:            10,
+			expectedMessages: []string{"102", "101", "100"},
+		},
+		{
+			name:             "root posts only",
+			limit:            3,
+			excludeThreads:   true,
+			expectedMessages: []string{"498", "497", "496"},
+			expectedCursor:   posts[496].Id,
+		},
+		{
+			name:             "last page",
+			cursor:           posts[2].Id,
+			limit:            10,
+			expectedMessages: []string{"1", "0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, nextCursor, err := fetchChannelPosts(context.Background(), client, "channel1", test.cursor, test.since, test.until, test.limit, !test.excludeThreads)
+			require.NoError(t, err)
+
+			messages := make([]string, 0, len(result))
+			for _, post := range result {
+				messages = append(messages, post.Message)
+			}
+			require.Equal(t, test.expectedMessages, messages)
+			require.Equal(t, test.expectedCursor, nextCursor)
+		})
+	}
+
+	t.Run("scan limit returns a cursor to continue", func(t *testing.T) {
+		var manyPosts []*model.Post
+		for i := range readChannelPageSize*readChannelMaxPages + 10 {
+			manyPosts = append(manyPosts, &model.Post{Id: fmt.Sprintf("post%022d", i), CreateAt: int64(i) * 60000})
+		}
+		result, nextCursor, err := fetchChannelPosts(context.Background(), newPostsServer(t, manyPosts), "channel1", "", 0, 5*60000, 10, true)
+		require.NoError(t, err)
+		require.Empty(t, result)
+		require.Equal(t, manyPosts[10].Id, nextCursor)
+	})
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package tools
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// ChannelExcluder reports the channels whose content must never be sent to LLM providers, and
+// the policies of shared channels and channels with guests
+type ChannelExcluder interface {
+	IsChannelExcluded(channelID, teamID string) bool
+	ChannelPolicy(channel *model.Channel) (exclusions.ChannelPolicy, error)
+}
+
+// SetChannelExcluder sets the exclusions enforced by the tools reading posts. Without it no channel is excluded.
+func (p *MattermostToolProvider) SetChannelExcluder(excluder ChannelExcluder) {
+	p.channelExcluder = excluder
+}
+
+// isChannelExcluded returns whether the content of the channel must not be returned to the model
+func (p *MattermostToolProvider) isChannelExcluded(channel *model.Channel) bool {
+	return p.channelExcluder != nil && p.channelExcluder.IsChannelExcluded(channel.Id, channel.TeamId)
+}
+
+// checkChannel returns the policy of the channel, or an error when its content must not be
+// returned to the model. Policies requiring confirmation are satisfied by the user approving
+// the tool call.
+func (p *MattermostToolProvider) checkChannel(channel *model.Channel) (exclusions.ChannelPolicy, error) {
+	if p.channelExcluder == nil {
+		return exclusions.ChannelPolicy{}, nil
+	}
+	if p.isChannelExcluded(channel) {
+		return exclusions.ChannelPolicy{}, exclusions.ErrChannelExcluded
+	}
+
+	policy, err := p.channelExcluder.ChannelPolicy(channel)
+	if err != nil {
+		return policy, err
+	}
+	if policy.Disabled {
+		return policy, exclusions.ErrChannelPolicyDisabled
+	}
+	return policy, nil
+}
+
+// channelErrorMessage is the message returned to the model when checkChannel fails
+func channelErrorMessage(err error) string {
+	if errors.Is(err, exclusions.ErrChannelExcluded) || errors.Is(err, exclusions.ErrChannelPolicyDisabled) {
+		return err.Error()
+	}
+	return "failed to check the channel policy"
+}
+
+// filterExcludedPosts removes the posts of excluded channels and the posts the channel
+// policies leave out. Posts whose channel can't be fetched are removed too since they can't be checked.
+func (p *MattermostToolProvider) filterExcludedPosts(ctx context.Context, client *model.Client4, posts []*model.Post) []*model.Post {
+	if p.channelExcluder == nil {
+		return posts
+	}
+
+	type channelCheck struct {
+		excluded bool
+		policy   exclusions.ChannelPolicy
+	}
+	checks := make(map[string]channelCheck)
+	filtered := make([]*model.Post, 0, len(posts))
+	for _, post := range posts {
+		check, checked := checks[post.ChannelId]
+		if !checked {
+			channel, _, err := client.GetChannel(ctx, post.ChannelId, "")
+			if err == nil {
+				check.policy, err = p.checkChannel(channel)
+			}
+			check.excluded = err != nil
+			checks[post.ChannelId] = check
+		}
+		if !check.excluded {
+			filtered = append(filtered, post)
+		}
+	}
+
+	// Posts are filtered by author once for every channel whose policy excludes some
+	byChannel := make(map[string]exclusions.ChannelPolicy)
+	for channelID, check := range checks {
+		if !check.excluded && check.policy.ExcludesAuthors() {
+			byChannel[channelID] = check.policy
+		}
+	}
+	if len(byChannel) == 0 {
+		return filtered
+	}
+	authors := p.getPostAuthors(ctx, client, filtered)
+	result := make([]*model.Post, 0, len(filtered))
+	for _, post := range filtered {
+		if policy, ok := byChannel[post.ChannelId]; ok && policy.ExcludesPost(post, authors[post.UserId]) {
+			continue
+		}
+		result = append(result, post)
+	}
+	return result
+}
+
+// filterPolicyPosts removes the posts of the authors the policy of their channel leaves out
+func (p *MattermostToolProvider) filterPolicyPosts(ctx context.Context, client *model.Client4, policy exclusions.ChannelPolicy, posts []*model.Post) []*model.Post {
+	if !policy.ExcludesAuthors() {
+		return posts
+	}
+
+	authors := p.getPostAuthors(ctx, client, posts)
+	filtered := make([]*model.Post, 0, len(posts))
+	for _, post := range posts {
+		if !policy.ExcludesPost(post, authors[post.UserId]) {
+			filtered = append(filtered, post)
+		}
+	}
+	return filtered
+}
+
+// getPostAuthors fetches the authors of the posts at once
+func (p *MattermostToolProvider) getPostAuthors(ctx context.Context, client *model.Client4, posts []*model.Post) map[string]*model.User {
+	seen := make(map[string]bool)
+	var userIDs []string
+	for _, post := range posts {
+		if !seen[post.UserId] {
+			seen[post.UserId] = true
+			userIDs = append(userIDs, post.UserId)
+		}
+	}
+
+	authors := make(map[string]*model.User)
+	if len(userIDs) == 0 {
+		return authors
+	}
+	users, _, err := client.GetUsersByIds(ctx, userIDs)
+	if err != nil {
+		p.logger.Warn("failed to get post authors", "error", err)
+	}
+	for _, user := range users {
+		authors[user.Id] = user
+	}
+	return authors
+}
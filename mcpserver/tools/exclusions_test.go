@@ -0,0 +1,55 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterPolicyPosts(t *testing.T) {
+	member := &model.User{Id: model.NewId(), Roles: model.SystemUserRoleId}
+	guest := &model.User{Id: model.NewId(), Roles: model.SystemGuestRoleId}
+	remote := &model.User{Id: model.NewId(), Roles: model.SystemUserRoleId, RemoteId: model.NewPointer("remote1")}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v4/users/ids", r.URL.Path)
+		require.NoError(t, json.NewEncoder(w).Encode([]*model.User{member, guest, remote}))
+	}))
+	t.Cleanup(server.Close)
+	client := model.NewAPIv4Client(server.URL)
+
+	posts := []*model.Post{
+		{Id: "memberpost", UserId: member.Id},
+		{Id: "guestpost", UserId: guest.Id},
+		{Id: "remotepost", UserId: remote.Id},
+		{Id: "unknownpost", UserId: model.NewId()},
+	}
+	postIDs := func(posts []*model.Post) []string {
+		var ids []string
+		for _, post := range posts {
+			ids = append(ids, post.Id)
+		}
+		return ids
+	}
+
+	provider := &MattermostToolProvider{logger: &testLogger{t: t}}
+
+	filtered := provider.filterPolicyPosts(context.Background(), client, exclusions.ChannelPolicy{}, posts)
+	assert.Equal(t, posts, filtered)
+
+	filtered = provider.filterPolicyPosts(context.Background(), client, exclusions.ChannelPolicy{ExcludeGuests: true}, posts)
+	assert.Equal(t, []string{"memberpost", "remotepost"}, postIDs(filtered))
+
+	filtered = provider.filterPolicyPosts(context.Background(), client, exclusions.ChannelPolicy{ExcludeRemoteUsers: true}, posts)
+	assert.Equal(t, []string{"memberpost", "guestpost"}, postIDs(filtered))
+}
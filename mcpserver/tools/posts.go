@@ -5,7 +5,10 @@ package tools
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost/server/public/model"
@@ -17,6 +20,12 @@ type ReadPostArgs struct {
 	IncludeThread bool   `json:"include_thread,omitempty" jsonschema:"Whether to include the entire thread (default: true)"`
 }
 
+// ReadThreadArgs represents arguments for the read_thread tool
+type ReadThreadArgs struct {
+	PostID string `json:"post_id" jsonschema:"The ID of the root post of the thread (the ID of any reply in the thread also works),minLength=26,maxLength=26"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"Number of most recent replies to retrieve (default: 50, max: 200),minimum=1,maximum=200"`
+}
+
 // CreatePostArgs represents arguments for the create_post tool
 type CreatePostArgs struct {
 	ChannelID          string   `json:"channel_id" jsonschema:"The ID of the channel to post in,minLength=26,maxLength=26"`
@@ -63,6 +72,12 @@ func (p *MattermostToolProvider) getPostTools() []MCPTool {
 			Schema:      NewJSONSchemaForAccessMode[ReadPostArgs](string(p.accessMode)),
 			Resolver:    p.toolReadPost,
 		},
+		{
+			Name:        "read_thread",
+			Description: "Read a whole discussion thread from Mattermost. Use it to drill into a thread that read_channel or search_posts only show the top of. Parameters: post_id (required, the root post ID or the ID of any reply), limit (1-200, default 50, most recent replies). Returns the root post, the reply count, the participants with their number of posts, and the replies in order. Example: {\"post_id\": \"8xqzn3pfmtbyfkr9hqbw4hheoa\", \"limit\": 50}",
+			Schema:      NewJSONSchemaForAccessMode[ReadThreadArgs](string(p.accessMode)),
+			Resolver:    p.toolReadThread,
+		},
 		{
 			Name:        "create_post",
 			Description: createPostDesc,
@@ -158,11 +173,22 @@ func (p *MattermostToolProvider) toolReadPost(mcpContext *MCPToolContext, argsGe
 	if len(posts) > 0 {
 		channel, _, err := client.GetChannel(ctx, posts[0].ChannelId, "")
 		if err == nil {
+			policy, policyErr := p.checkChannel(channel)
+			if policyErr != nil {
+				return channelErrorMessage(policyErr), policyErr
+			}
+			posts = p.filterPolicyPosts(ctx, client, policy, posts)
+			if len(posts) == 0 {
+				return "no posts found", nil
+			}
 			channelName = channel.DisplayName
 			team, _, teamErr := client.GetTeam(ctx, channel.TeamId, "")
 			if teamErr == nil {
 				teamName = team.DisplayName
 			}
+		} else if p.channelExcluder != nil {
+			// The channel can't be checked against the exclusions
+			return "failed to fetch channel info", fmt.Errorf("error fetching channel: %w", err)
 		}
 	}
 
@@ -212,6 +238,156 @@ func (p *MattermostToolProvider) toolReadPost(mcpContext *MCPToolContext, argsGe
 	return result.String(), nil
 }
 
+// toolReadThread implements the read_thread tool
+func (p *MattermostToolProvider) toolReadThread(mcpContext *MCPToolContext, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args ReadThreadArgs
+	err := argsGetter(&args)
+	if err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool read_thread: %w", err)
+	}
+
+	// Validate post ID
+	if !model.IsValidId(args.PostID) {
+		return "invalid post_id format", fmt.Errorf("post_id must be a valid ID")
+	}
+
+	// Set defaults and validate
+	if args.Limit <= 0 {
+		args.Limit = 50
+	}
+	if args.Limit > 200 {
+		args.Limit = 200
+	}
+
+	// Get client from context
+	if mcpContext.Client == nil {
+		return "client not available", fmt.Errorf("client not available in context")
+	}
+	client := mcpContext.Client
+	ctx := mcpContext.Ctx // Use request context for proper cancellation and timeout handling
+
+	// The thread is fetched as the user, so the server checks they can read the channel
+	postList, _, err := client.GetPostThread(ctx, args.PostID, "", false)
+	if err != nil {
+		return "failed to fetch post thread", fmt.Errorf("error fetching post thread: %w", err)
+	}
+
+	posts := make([]*model.Post, 0, len(postList.Posts))
+	for _, post := range postList.Posts {
+		posts = append(posts, post)
+	}
+	if len(posts) == 0 {
+		return "no posts found", nil
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].CreateAt < posts[j].CreateAt
+	})
+
+	// The root post is the one without a root, the first post if it was deleted
+	root := posts[0]
+	for _, post := range posts {
+		if post.RootId == "" {
+			root = post
+			break
+		}
+	}
+	replies := make([]*model.Post, 0, len(posts))
+	for _, post := range posts {
+		if post.Id != root.Id {
+			replies = append(replies, post)
+		}
+	}
+
+	channel, _, err := client.GetChannel(ctx, root.ChannelId, "")
+	if err != nil {
+		return "failed to fetch channel info", fmt.Errorf("error fetching channel: %w", err)
+	}
+	policy, err := p.checkChannel(channel)
+	if err != nil {
+		return channelErrorMessage(err), err
+	}
+
+	// Posts the policy leaves out are removed, the root post only keeps its ID
+	rootExcluded := false
+	if policy.ExcludesAuthors() {
+		kept := p.filterPolicyPosts(ctx, client, policy, posts)
+		rootExcluded = !slices.Contains(kept, root)
+		replies = slices.DeleteFunc(replies, func(reply *model.Post) bool {
+			return !slices.Contains(kept, reply)
+		})
+		posts = kept
+	}
+
+	teamName := ""
+	if channel.TeamId != "" {
+		team, _, teamErr := client.GetTeam(ctx, channel.TeamId, "")
+		if teamErr == nil {
+			teamName = team.DisplayName
+		}
+	}
+
+	// Resolve the authors once, and count the posts of each participant in order of appearance
+	usernames := make(map[string]string)
+	postCounts := make(map[string]int)
+	var participants []string
+	for _, post := range posts {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("request cancelled while fetching user information: %w", ctx.Err())
+		}
+
+		if _, exists := usernames[post.UserId]; !exists {
+			user, _, userErr := client.GetUser(ctx, post.UserId, "")
+			if userErr != nil {
+				p.logger.Warn("failed to get user for post", "user_id", post.UserId, "error", userErr)
+				usernames[post.UserId] = "Unknown User"
+			} else {
+				usernames[post.UserId] = user.Username
+			}
+			participants = append(participants, post.UserId)
+		}
+		postCounts[post.UserId]++
+	}
+
+	// Format the response
+	var result strings.Builder
+	if teamName != "" {
+		result.WriteString(fmt.Sprintf("Channel: %s (Team: %s)\n", channel.DisplayName, teamName))
+	} else {
+		result.WriteString(fmt.Sprintf("Channel: %s\n", channel.DisplayName))
+	}
+	result.WriteString(fmt.Sprintf("Channel ID: %s\n", channel.Id))
+	result.WriteString(fmt.Sprintf("Root ID: %s\n", root.Id))
+	result.WriteString(fmt.Sprintf("Replies: %d\n", len(replies)))
+
+	participantList := make([]string, 0, len(participants))
+	for _, userID := range participants {
+		participantList = append(participantList, fmt.Sprintf("%s (%d)", usernames[userID], postCounts[userID]))
+	}
+	result.WriteString(fmt.Sprintf("Participants: %s\n\n", strings.Join(participantList, ", ")))
+
+	if rootExcluded {
+		result.WriteString("**Root post** left out by the channel policy\n")
+		result.WriteString(fmt.Sprintf("Post ID: %s\n\n", root.Id))
+	} else {
+		result.WriteString(fmt.Sprintf("**Root post** by %s at %s:\n", usernames[root.UserId], model.GetTimeForMillis(root.CreateAt).UTC().Format(time.RFC3339)))
+		result.WriteString(fmt.Sprintf("Post ID: %s\n", root.Id))
+		result.WriteString(fmt.Sprintf("%s\n\n", root.Message))
+	}
+
+	// Only the most recent replies are returned for long threads
+	if len(replies) > args.Limit {
+		result.WriteString(fmt.Sprintf("(%d earlier replies omitted)\n\n", len(replies)-args.Limit))
+		replies = replies[len(replies)-args.Limit:]
+	}
+	for i, reply := range replies {
+		result.WriteString(fmt.Sprintf("**Reply %d** by %s at %s:\n", i+1, usernames[reply.UserId], model.GetTimeForMillis(reply.CreateAt).UTC().Format(time.RFC3339)))
+		result.WriteString(fmt.Sprintf("Post ID: %s\n", reply.Id))
+		result.WriteString(fmt.Sprintf("%s\n\n", reply.Message))
+	}
+
+	return result.String(), nil
+}
+
 // toolCreatePost implements the create_post tool
 func (p *MattermostToolProvider) toolCreatePost(mcpContext *MCPToolContext, argsGetter llm.ToolArgumentGetter) (string, error) {
 	var args CreatePostArgs
@@ -51,6 +51,8 @@ type MattermostToolProvider struct {
 	devMode             bool
 	accessMode          AccessMode
 	trackAIGenerated    bool // Whether to add ai_generated_by props to posts
+	channelExcluder     ChannelExcluder
+	channelStats        ChannelStatsProvider
 }
 
 // NewMattermostToolProvider creates a new tool provider
@@ -80,6 +82,7 @@ func (p *MattermostToolProvider) ProvideTools(mcpServer *mcp.Server) {
 	// Add regular tools
 	mcpTools = append(mcpTools, p.getPostTools()...)
 	mcpTools = append(mcpTools, p.getChannelTools()...)
+	mcpTools = append(mcpTools, p.getChannelStatsTools()...)
 	mcpTools = append(mcpTools, p.getTeamTools()...)
 	mcpTools = append(mcpTools, p.getSearchTools()...)
 
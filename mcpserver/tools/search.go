@@ -101,6 +101,12 @@ func (p *MattermostToolProvider) toolSearchPosts(mcpContext *MCPToolContext, arg
 		posts = append(posts, post)
 	}
 
+	// Leave out posts of channels excluded from AI processing
+	posts = p.filterExcludedPosts(ctx, client, posts)
+	if len(posts) == 0 {
+		return "no posts found matching the search criteria", nil
+	}
+
 	// Limit results
 	if len(posts) > args.Limit {
 		posts = posts[:args.Limit]
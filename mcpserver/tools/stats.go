@@ -0,0 +1,178 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/channelstats"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// ChannelStatsProvider aggregates the activity of a channel over a time range
+type ChannelStatsProvider interface {
+	GetChannelStats(query channelstats.Query) (*channelstats.Stats, error)
+}
+
+// GetChannelStatsArgs represents arguments for the get_channel_stats tool
+type GetChannelStatsArgs struct {
+	ChannelID string `json:"channel_id" jsonschema:"The ID of the channel to get stats for,minLength=26,maxLength=26"`
+	Since     string `json:"since,omitempty" jsonschema:"Start of the range (ISO 8601 format, default: 7 days before until),format=date-time"`
+	Until     string `json:"until,omitempty" jsonschema:"End of the range (ISO 8601 format, default: now),format=date-time"`
+	Limit     int    `json:"limit,omitempty" jsonschema:"Number of top posters, busiest threads and reaction leaders (default: 5, max: 20),minimum=1,maximum=20"`
+}
+
+// SetChannelStats sets the provider of the get_channel_stats tool. Without it the tool is not available.
+func (p *MattermostToolProvider) SetChannelStats(stats ChannelStatsProvider) {
+	p.channelStats = stats
+}
+
+// getChannelStatsTools returns the channel statistics tools
+func (p *MattermostToolProvider) getChannelStatsTools() []MCPTool {
+	if p.channelStats == nil {
+		return nil
+	}
+
+	return []MCPTool{
+		{
+			Name:        "get_channel_stats",
+			Description: "Get activity statistics of a Mattermost channel over a time range, without reading its posts. Parameters: channel_id (required), since and until (ISO 8601 timestamps, optional, default the last 7 days), limit (1-20, default 5). Returns the number of posts and participants, the posts per day, the top posters, the busiest threads with their root post ID, to be read with read_thread, and the users whose posts received the most reactions. Example: {\"channel_id\": \"h5wqm8kxptbztfgzpaxbsqozah\", \"since\": \"2024-01-01T00:00:00Z\", \"until\": \"2024-02-01T00:00:00Z\"}",
+			Schema:      llm.NewJSONSchemaFromStruct[GetChannelStatsArgs](),
+			Resolver:    p.toolGetChannelStats,
+		},
+	}
+}
+
+// toolGetChannelStats implements the get_channel_stats tool
+func (p *MattermostToolProvider) toolGetChannelStats(mcpContext *MCPToolContext, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args GetChannelStatsArgs
+	err := argsGetter(&args)
+	if err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool get_channel_stats: %w", err)
+	}
+
+	// Validate channel ID
+	if !model.IsValidId(args.ChannelID) {
+		return "invalid channel_id format", fmt.Errorf("channel_id must be a valid ID")
+	}
+
+	// Set defaults and validate
+	if args.Limit <= 0 {
+		args.Limit = 5
+	}
+	if args.Limit > 20 {
+		args.Limit = 20
+	}
+
+	until := time.Now()
+	if args.Until != "" {
+		until, err = time.Parse(time.RFC3339, args.Until)
+		if err != nil {
+			return "invalid until timestamp format", fmt.Errorf("invalid timestamp format: %w", err)
+		}
+	}
+	since := until.AddDate(0, 0, -7)
+	if args.Since != "" {
+		since, err = time.Parse(time.RFC3339, args.Since)
+		if err != nil {
+			return "invalid since timestamp format", fmt.Errorf("invalid timestamp format: %w", err)
+		}
+	}
+	if !until.After(since) {
+		return "until must be after since", fmt.Errorf("until must be after since")
+	}
+
+	// Get client and context
+	if mcpContext.Client == nil {
+		return "client not available", fmt.Errorf("client not available in context")
+	}
+	client := mcpContext.Client
+	ctx := mcpContext.Ctx // Use request context for proper cancellation and timeout handling
+
+	// The stats are read from the database, so the channel is fetched as the user first to check
+	// they can read it
+	channel, _, err := client.GetChannel(ctx, args.ChannelID, "")
+	if err != nil {
+		return "failed to fetch channel", fmt.Errorf("error fetching channel: %w", err)
+	}
+	if _, err = p.checkChannel(channel); err != nil {
+		return channelErrorMessage(err), err
+	}
+
+	stats, err := p.channelStats.GetChannelStats(channelstats.Query{
+		ChannelID: channel.Id,
+		Since:     since.UnixMilli(),
+		Until:     until.UnixMilli(),
+		Limit:     args.Limit,
+	})
+	if err != nil {
+		return "failed to compute channel stats", fmt.Errorf("error computing channel stats: %w", err)
+	}
+
+	// Resolve the usernames of the top posters and reaction leaders at once
+	var userIDs []string
+	for _, poster := range stats.TopPosters {
+		userIDs = append(userIDs, poster.UserID)
+	}
+	for _, leader := range stats.ReactionLeaders {
+		userIDs = append(userIDs, leader.UserID)
+	}
+	usernames := make(map[string]string)
+	if len(userIDs) > 0 {
+		users, _, usersErr := client.GetUsersByIds(ctx, userIDs)
+		if usersErr != nil {
+			p.logger.Warn("failed to get users for channel stats", "error", usersErr)
+		}
+		for _, user := range users {
+			usernames[user.Id] = user.Username
+		}
+	}
+	username := func(userID string) string {
+		if name, ok := usernames[userID]; ok {
+			return name
+		}
+		return "Unknown User"
+	}
+
+	// Format the response
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Channel: %s\n", channel.DisplayName))
+	result.WriteString(fmt.Sprintf("Channel ID: %s\n", channel.Id))
+	result.WriteString(fmt.Sprintf("Range: %s to %s\n", since.UTC().Format(time.RFC3339), until.UTC().Format(time.RFC3339)))
+	result.WriteString(fmt.Sprintf("Posts: %d\n", stats.Posts))
+	result.WriteString(fmt.Sprintf("Participants: %d\n", stats.Participants))
+
+	if stats.Posts == 0 {
+		return result.String(), nil
+	}
+
+	result.WriteString("\n**Posts per day:**\n")
+	for _, day := range stats.ByDay {
+		result.WriteString(fmt.Sprintf("- %s: %d\n", model.GetTimeForMillis(day.Day).UTC().Format(time.DateOnly), day.Posts))
+	}
+
+	result.WriteString("\n**Top posters:**\n")
+	for _, poster := range stats.TopPosters {
+		result.WriteString(fmt.Sprintf("- %s: %d posts\n", username(poster.UserID), poster.Posts))
+	}
+
+	if len(stats.BusiestThreads) > 0 {
+		result.WriteString("\n**Busiest threads:**\n")
+		for _, thread := range stats.BusiestThreads {
+			result.WriteString(fmt.Sprintf("- Root ID %s: %d replies\n", thread.RootID, thread.Replies))
+		}
+	}
+
+	if len(stats.ReactionLeaders) > 0 {
+		result.WriteString("\n**Most reacted to:**\n")
+		for _, leader := range stats.ReactionLeaders {
+			result.WriteString(fmt.Sprintf("- %s: %d reactions\n", username(leader.UserID), leader.Reactions))
+		}
+	}
+
+	return result.String(), nil
+}
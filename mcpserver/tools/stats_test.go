@@ -0,0 +1,142 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/channelstats"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeChannelStats struct {
+	stats *channelstats.Stats
+	query channelstats.Query
+}
+
+func (f *fakeChannelStats) GetChannelStats(query channelstats.Query) (*channelstats.Stats, error) {
+	f.query = query
+	return f.stats, nil
+}
+
+type fakeChannelExcluder struct {
+	excluded string
+	policy   exclusions.ChannelPolicy
+}
+
+func (f fakeChannelExcluder) IsChannelExcluded(channelID, teamID string) bool {
+	return channelID == f.excluded
+}
+
+func (f fakeChannelExcluder) ChannelPolicy(channel *model.Channel) (exclusions.ChannelPolicy, error) {
+	return f.policy, nil
+}
+
+func TestToolGetChannelStats(t *testing.T) {
+	channel := &model.Channel{Id: model.NewId(), DisplayName: "Town Square"}
+	alice := &model.User{Id: model.NewId(), Username: "alice"}
+	bob := &model.User{Id: model.NewId(), Username: "bob"}
+	rootID := model.NewId()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/channels/" + channel.Id:
+			require.NoError(t, json.NewEncoder(w).Encode(channel))
+		case "/api/v4/users/ids":
+			require.NoError(t, json.NewEncoder(w).Encode([]*model.User{alice, bob}))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := model.NewAPIv4Client(server.URL)
+
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC).UnixMilli()
+	stats := &fakeChannelStats{stats: &channelstats.Stats{
+		Posts:           12,
+		Participants:    2,
+		ByDay:           []channelstats.DailyCount{{Day: day, Posts: 12}},
+		TopPosters:      []channelstats.Poster{{UserID: alice.Id, Posts: 8}, {UserID: bob.Id, Posts: 4}},
+		BusiestThreads:  []channelstats.Thread{{RootID: rootID, Replies: 6}},
+		ReactionLeaders: []channelstats.ReactionLeader{{UserID: bob.Id, Reactions: 3}},
+	}}
+
+	newProvider := func(excluder ChannelExcluder) *MattermostToolProvider {
+		provider := &MattermostToolProvider{logger: &testLogger{t: t}}
+		provider.SetChannelStats(stats)
+		if excluder != nil {
+			provider.SetChannelExcluder(excluder)
+		}
+		return provider
+	}
+	call := func(provider *MattermostToolProvider, args GetChannelStatsArgs) (string, error) {
+		return provider.toolGetChannelStats(&MCPToolContext{Ctx: context.Background(), Client: client}, func(target any) error {
+			*target.(*GetChannelStatsArgs) = args
+			return nil
+		})
+	}
+
+	t.Run("formats the stats of the range", func(t *testing.T) {
+		result, err := call(newProvider(nil), GetChannelStatsArgs{
+			ChannelID: channel.Id,
+			Since:     "2024-01-01T00:00:00Z",
+			Until:     "2024-01-08T00:00:00Z",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, channelstats.Query{
+			ChannelID: channel.Id,
+			Since:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli(),
+			Until:     time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC).UnixMilli(),
+			Limit:     5,
+		}, stats.query)
+		assert.Contains(t, result, "Posts: 12\n")
+		assert.Contains(t, result, "Participants: 2\n")
+		assert.Contains(t, result, "- 2024-01-02: 12\n")
+		assert.Contains(t, result, "- alice: 8 posts\n")
+		assert.Contains(t, result, "- Root ID "+rootID+": 6 replies\n")
+		assert.Contains(t, result, "- bob: 3 reactions\n")
+	})
+
+	t.Run("defaults to the last 7 days", func(t *testing.T) {
+		_, err := call(newProvider(nil), GetChannelStatsArgs{ChannelID: channel.Id, Limit: 50})
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(7*24*time.Hour/time.Millisecond), stats.query.Until-stats.query.Since)
+		assert.Equal(t, 20, stats.query.Limit)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := call(newProvider(nil), GetChannelStatsArgs{
+			ChannelID: channel.Id,
+			Since:     "2024-01-08T00:00:00Z",
+			Until:     "2024-01-01T00:00:00Z",
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("excluded channel", func(t *testing.T) {
+		_, err := call(newProvider(fakeChannelExcluder{excluded: channel.Id}), GetChannelStatsArgs{ChannelID: channel.Id})
+		require.Error(t, err)
+	})
+
+	t.Run("disabled by the channel policy", func(t *testing.T) {
+		_, err := call(newProvider(fakeChannelExcluder{policy: exclusions.ChannelPolicy{Disabled: true}}), GetChannelStatsArgs{ChannelID: channel.Id})
+		require.ErrorIs(t, err, exclusions.ErrChannelPolicyDisabled)
+	})
+
+	t.Run("not available without a provider", func(t *testing.T) {
+		provider := &MattermostToolProvider{logger: &testLogger{t: t}}
+		assert.Empty(t, provider.getChannelStatsTools())
+		assert.Len(t, newProvider(nil).getChannelStatsTools(), 1)
+	})
+}
@@ -279,6 +279,43 @@ func TestMCPToolsIntegration(t *testing.T) {
 		})
 	})
 
+	t.Run("ReadThreadTool", func(t *testing.T) {
+		// Create a thread with a reply
+		rootPost := testhelpers.CreateTestPost(t, client, testData.Channel.Id, "Should we move the release?")
+		reply, _, err := client.CreatePost(context.Background(), &model.Post{
+			ChannelId: testData.Channel.Id,
+			RootId:    rootPost.Id,
+			Message:   "Yes, to next week",
+		})
+		require.NoError(t, err)
+
+		t.Run("HappyPath", func(t *testing.T) {
+			args := map[string]interface{}{
+				"post_id": reply.Id,
+			}
+
+			result, err := executeToolWithMCP(t, suite, "read_thread", args)
+			require.NoError(t, err, "read_thread should succeed")
+			require.NotEmpty(t, result.Content, "read_thread should return content")
+
+			textContent, ok := result.Content[0].(*mcp.TextContent)
+			require.True(t, ok)
+			assert.Contains(t, textContent.Text, "Root ID: "+rootPost.Id)
+			assert.Contains(t, textContent.Text, "Replies: 1")
+			assert.Contains(t, textContent.Text, "Should we move the release?")
+			assert.Contains(t, textContent.Text, "Yes, to next week")
+		})
+
+		t.Run("InvalidPostID", func(t *testing.T) {
+			args := map[string]interface{}{
+				"post_id": "invalid-post-id",
+			}
+
+			_, err := executeToolWithMCP(t, suite, "read_thread", args)
+			require.Error(t, err, "read_thread with invalid ID should fail")
+		})
+	})
+
 	t.Run("CreateChannelTool", func(t *testing.T) {
 		t.Run("HappyPath", func(t *testing.T) {
 			args := map[string]interface{}{
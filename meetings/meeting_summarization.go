@@ -112,7 +112,7 @@ func (s *Service) createTranscription(recordingFileID string) (*subtitles.Subtit
 	return transcription, nil
 }
 
-func (s *Service) newCallRecordingThread(bot *bots.Bot, requestingUser *model.User, recordingPost *model.Post, channel *model.Channel, fileID string) (*model.Post, error) {
+func (s *Service) newCallRecordingThread(ctx context.Context, bot *bots.Bot, requestingUser *model.User, recordingPost *model.Post, channel *model.Channel, fileID string) (*model.Post, error) {
 	siteURL := s.pluginAPI.Configuration.GetConfig().ServiceSettings.SiteURL
 	T := i18n.LocalizerFunc(s.i18n, requestingUser.Locale)
 	surePost := &model.Post{
@@ -123,14 +123,14 @@ func (s *Service) newCallRecordingThread(bot *bots.Bot, requestingUser *model.Us
 		return nil, err
 	}
 
-	if err := s.summarizeCallRecording(bot, surePost.Id, requestingUser, fileID, channel); err != nil {
+	if err := s.summarizeCallRecording(ctx, bot, surePost.Id, requestingUser, fileID, channel); err != nil {
 		return nil, err
 	}
 
 	return surePost, nil
 }
 
-func (s *Service) newCallTranscriptionSummaryThread(bot *bots.Bot, requestingUser *model.User, transcriptionPost *model.Post, channel *model.Channel) (*model.Post, error) {
+func (s *Service) newCallTranscriptionSummaryThread(ctx context.Context, bot *bots.Bot, requestingUser *model.User, transcriptionPost *model.Post, channel *model.Channel) (*model.Post, error) {
 	if len(transcriptionPost.FileIds) != 1 {
 		return nil, errors.New("unexpected number of files in calls post")
 	}
@@ -158,37 +158,9 @@ func (s *Service) newCallTranscriptionSummaryThread(bot *bots.Bot, requestingUse
 			}
 		}()
 
-		transcriptionFileID, err := GetCaptionsFileIDFromProps(transcriptionPost)
+		text, err := s.readTranscription(transcriptionPost, channel)
 		if err != nil {
-			return fmt.Errorf("unable to get transcription file id: %w", err)
-		}
-		transcriptionFileInfo, err := s.pluginAPI.File.GetInfo(transcriptionFileID)
-		if err != nil {
-			return fmt.Errorf("unable to get transcription file info: %w", err)
-		}
-		transcriptionFilePost, err := s.pluginAPI.Post.GetPost(transcriptionFileInfo.PostId)
-		if err != nil {
-			return fmt.Errorf("unable to get transcription file post: %w", err)
-		}
-		if transcriptionFilePost.ChannelId != channel.Id {
-			return errors.New("strange configuration of calls transcription file")
-		}
-		transcriptionFileReader, err := s.pluginAPI.File.Get(transcriptionFileID)
-		if err != nil {
-			return fmt.Errorf("unable to read calls file: %w", err)
-		}
-
-		var text *subtitles.Subtitles
-		if transcriptionFilePost.Type == "custom_zoom_chat" {
-			text, err = subtitles.NewSubtitlesFromZoomChat(transcriptionFileReader)
-			if err != nil {
-				return fmt.Errorf("unable to parse transcription file: %w", err)
-			}
-		} else {
-			text, err = subtitles.NewSubtitlesFromVTT(transcriptionFileReader)
-			if err != nil {
-				return fmt.Errorf("unable to parse transcription file: %w", err)
-			}
+			return err
 		}
 
 		requestContext := s.contextBuilder.BuildLLMContextUserRequest(
@@ -197,7 +169,7 @@ func (s *Service) newCallTranscriptionSummaryThread(bot *bots.Bot, requestingUse
 			channel,
 			s.contextBuilder.WithLLMContextDefaultTools(bot),
 		)
-		summaryStream, err := s.SummarizeTranscription(bot, text, requestContext)
+		summaryStream, err := s.SummarizeTranscription(ctx, bot, text, requestContext)
 		if err != nil {
 			return fmt.Errorf("unable to summarize transcription: %w", err)
 		}
@@ -208,7 +180,7 @@ func (s *Service) newCallTranscriptionSummaryThread(bot *bots.Bot, requestingUse
 			Message:   "",
 		}
 		summaryPost.AddProp(ReferencedTranscriptPostID, transcriptionPost.Id)
-		if err := s.streamingService.StreamToNewPost(context.Background(), bot.GetMMBot().UserId, requestingUser.Id, summaryStream, summaryPost, transcriptionPost.Id); err != nil {
+		if err := s.streamingService.StreamToNewPost(ctx, bot.GetMMBot().UserId, requestingUser.Id, summaryStream, summaryPost, transcriptionPost.Id); err != nil {
 			return fmt.Errorf("unable to stream result to post: %w", err)
 		}
 
@@ -218,7 +190,45 @@ func (s *Service) newCallTranscriptionSummaryThread(bot *bots.Bot, requestingUse
 	return surePost, nil
 }
 
-func (s *Service) summarizeCallRecording(bot *bots.Bot, rootID string, requestingUser *model.User, recordingFileID string, channel *model.Channel) error {
+// readTranscription reads the captions attached to a Calls or Zoom transcription post
+func (s *Service) readTranscription(transcriptionPost *model.Post, channel *model.Channel) (*subtitles.Subtitles, error) {
+	transcriptionFileID, err := GetCaptionsFileIDFromProps(transcriptionPost)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get transcription file id: %w", err)
+	}
+	transcriptionFileInfo, err := s.pluginAPI.File.GetInfo(transcriptionFileID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get transcription file info: %w", err)
+	}
+	transcriptionFilePost, err := s.pluginAPI.Post.GetPost(transcriptionFileInfo.PostId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get transcription file post: %w", err)
+	}
+	if transcriptionFilePost.ChannelId != channel.Id {
+		return nil, errors.New("strange configuration of calls transcription file")
+	}
+	transcriptionFileReader, err := s.pluginAPI.File.Get(transcriptionFileID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read calls file: %w", err)
+	}
+
+	var text *subtitles.Subtitles
+	if transcriptionFilePost.Type == "custom_zoom_chat" {
+		text, err = subtitles.NewSubtitlesFromZoomChat(transcriptionFileReader)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse transcription file: %w", err)
+		}
+	} else {
+		text, err = subtitles.NewSubtitlesFromVTT(transcriptionFileReader)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse transcription file: %w", err)
+		}
+	}
+
+	return text, nil
+}
+
+func (s *Service) summarizeCallRecording(ctx context.Context, bot *bots.Bot, rootID string, requestingUser *model.User, recordingFileID string, channel *model.Channel) error {
 	T := i18n.LocalizerFunc(s.i18n, requestingUser.Locale)
 
 	transcriptPost := &model.Post{
@@ -258,22 +268,23 @@ func (s *Service) summarizeCallRecording(bot *bots.Bot, rootID string, requestin
 			channel,
 			s.contextBuilder.WithLLMContextDefaultTools(bot),
 		)
-		summaryStream, err := s.SummarizeTranscription(bot, transcription, llmContext)
+		// Get the streaming context first so stopping the post also stops the summarization
+		streamCtx, err := s.streamingService.GetStreamingContext(ctx, transcriptPost.Id)
 		if err != nil {
-			return fmt.Errorf("unable to summarize transcription: %w", err)
+			return fmt.Errorf("unable to get post streaming context: %w", err)
 		}
+		defer s.streamingService.FinishStreaming(transcriptPost.Id)
 
-		if err = s.updatePostWithFile(transcriptPost, transcriptFileInfo); err != nil {
-			return fmt.Errorf("unable to update transcript post: %w", err)
+		summaryStream, err := s.SummarizeTranscription(streamCtx, bot, transcription, llmContext)
+		if err != nil {
+			return fmt.Errorf("unable to summarize transcription: %w", err)
 		}
 
-		ctx, err := s.streamingService.GetStreamingContext(context.Background(), transcriptPost.Id)
-		if err != nil {
-			return fmt.Errorf("unable to get post streaming context: %w", err)
+		if err = s.updatePostWithFiles(transcriptPost, "", transcriptFileInfo); err != nil {
+			return fmt.Errorf("unable to update transcript post: %w", err)
 		}
-		defer s.streamingService.FinishStreaming(transcriptPost.Id)
 
-		s.streamingService.StreamToPost(ctx, summaryStream, transcriptPost, requestingUser.Locale)
+		s.streamingService.StreamToPost(streamCtx, summaryStream, transcriptPost, requestingUser.Locale)
 
 		return nil
 	}() //nolint:errcheck
@@ -281,7 +292,7 @@ func (s *Service) summarizeCallRecording(bot *bots.Bot, rootID string, requestin
 	return nil
 }
 
-func (s *Service) SummarizeTranscription(bot *bots.Bot, transcription *subtitles.Subtitles, context *llm.Context) (*llm.TextStreamResult, error) {
+func (s *Service) SummarizeTranscription(ctx context.Context, bot *bots.Bot, transcription *subtitles.Subtitles, context *llm.Context) (*llm.TextStreamResult, error) {
 	llmFormattedTranscription := transcription.FormatForLLM()
 	tokens := bot.LLM().CountTokens(llmFormattedTranscription)
 	tokenLimitWithMargin := int(float64(bot.LLM().InputTokenLimit())*0.75) - ContextTokenMargin
@@ -313,7 +324,7 @@ func (s *Service) SummarizeTranscription(bot *bots.Bot, transcription *subtitles
 				Context: context,
 			}
 
-			summarizedChunk, err := bot.LLM().ChatCompletionNoStream(request)
+			summarizedChunk, err := bot.LLM().ChatCompletionNoStream(ctx, request)
 			if err != nil {
 				return nil, fmt.Errorf("unable to get summarized chunk: %w", err)
 			}
@@ -346,7 +357,7 @@ func (s *Service) SummarizeTranscription(bot *bots.Bot, transcription *subtitles
 		Context: context,
 	}
 
-	summaryStream, err := bot.LLM().ChatCompletion(completionRequest, llm.WithToolsDisabled())
+	summaryStream, err := bot.LLM().ChatCompletion(ctx, completionRequest, llm.WithToolsDisabled())
 	if err != nil {
 		return nil, fmt.Errorf("unable to get meeting summary: %w", err)
 	}
@@ -354,20 +365,26 @@ func (s *Service) SummarizeTranscription(bot *bots.Bot, transcription *subtitles
 	return summaryStream, nil
 }
 
-func (s *Service) updatePostWithFile(post *model.Post, fileinfo *model.FileInfo) error {
+// updatePostWithFiles attaches the uploaded files to the post and replaces its message
+func (s *Service) updatePostWithFiles(post *model.Post, message string, fileinfos ...*model.FileInfo) error {
+	fileIDs := make([]string, 0, len(fileinfos))
+	for _, fileinfo := range fileinfos {
+		fileIDs = append(fileIDs, fileinfo.Id)
+	}
+
 	if _, err := s.db.ExecBuilder(s.db.Builder().
 		Update("FileInfo").
 		Set("PostId", post.Id).
 		Set("ChannelId", post.ChannelId).
 		Where(sq.And{
-			sq.Eq{"Id": fileinfo.Id},
+			sq.Eq{"Id": fileIDs},
 			sq.Eq{"PostId": ""},
 		})); err != nil {
 		return fmt.Errorf("unable to update file info: %w", err)
 	}
 
-	post.FileIds = []string{fileinfo.Id}
-	post.Message = ""
+	post.FileIds = fileIDs
+	post.Message = message
 	if err := s.pluginAPI.Post.UpdatePost(post); err != nil {
 		return fmt.Errorf("unable to update post: %w", err)
 	}
@@ -0,0 +1,231 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package meetings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost-plugin-ai/subtitles"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// translationBatchSize is how many cues are translated by each request, small enough for the
+	// model to keep every cue in place
+	translationBatchSize = 40
+	// translationAttempts is how many times a batch is requested when the model doesn't return a
+	// translation for each of its cues
+	translationAttempts = 2
+	// maxTranslationLanguageLength bounds the name of the target language sent to the model
+	maxTranslationLanguageLength = 64
+
+	TitleTranscriptTranslation = "Transcript Translation"
+)
+
+// ErrInvalidLanguage is returned when the target language of a translation is empty or too long
+var ErrInvalidLanguage = errors.New("invalid translation language")
+
+// transcriptTranslation is the structured output requested from the model
+type transcriptTranslation struct {
+	Translations []string `json:"translations"`
+}
+
+var unsafeLanguageCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// validateTranslationLanguage returns the language trimmed, or ErrInvalidLanguage. Languages are
+// named freely, e.g. "German" or "pt-BR", and only sent to the model.
+func validateTranslationLanguage(language string) (string, error) {
+	language = strings.TrimSpace(language)
+	if language == "" || len(language) > maxTranslationLanguageLength || strings.ContainsAny(language, "\r\n") {
+		return "", ErrInvalidLanguage
+	}
+	return language, nil
+}
+
+// TranslateTranscription translates the text of each cue of the transcription into the language,
+// keeping the timing of the cues.
+func (s *Service) TranslateTranscription(ctx context.Context, bot *bots.Bot, transcription *subtitles.Subtitles, language string, context *llm.Context) (*subtitles.Subtitles, error) {
+	context.Parameters = map[string]any{"Language": language}
+
+	texts := transcription.Texts()
+	translations := make([]string, 0, len(texts))
+	for start := 0; start < len(texts); start += translationBatchSize {
+		batch := texts[start:min(start+translationBatchSize, len(texts))]
+
+		var translated []string
+		var err error
+		for attempt := 0; attempt < translationAttempts; attempt++ {
+			translated, err = s.translateCues(ctx, bot, batch, context)
+			if err == nil {
+				break
+			}
+			s.pluginAPI.Log.Debug("Failed to translate transcription cues", "start", start, "attempt", attempt+1, "error", err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to translate cues %d to %d: %w", start+1, start+len(batch), err)
+		}
+
+		translations = append(translations, translated...)
+	}
+
+	return transcription.WithTexts(translations)
+}
+
+func (s *Service) translateCues(ctx context.Context, bot *bots.Bot, cues []string, context *llm.Context) ([]string, error) {
+	context.Parameters["Count"] = len(cues)
+	systemPrompt, err := s.prompts.Format(prompts.PromptTranscriptTranslationSystem, context)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get transcript translation prompt: %w", err)
+	}
+
+	input, err := json.Marshal(map[string][]string{"cues": cues})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := bot.LLM().ChatCompletionNoStream(ctx, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{
+				Role:    llm.PostRoleSystem,
+				Message: systemPrompt,
+			},
+			{
+				Role:    llm.PostRoleUser,
+				Message: string(input),
+			},
+		},
+		Context: context,
+	},
+		llm.WithToolsDisabled(),
+		llm.WithJSONOutput[transcriptTranslation](),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var translation transcriptTranslation
+	if err := json.Unmarshal([]byte(result), &translation); err != nil {
+		return nil, fmt.Errorf("unable to parse translation: %w", err)
+	}
+	if len(translation.Translations) != len(cues) {
+		return nil, fmt.Errorf("got %d translations for %d cues", len(translation.Translations), len(cues))
+	}
+
+	return translation.Translations, nil
+}
+
+// HandleTranslateTranscription translates the transcription of a Calls or Zoom post into the
+// language, and sends the user the translated captions and bilingual captions as VTT files.
+func (s *Service) HandleTranslateTranscription(ctx context.Context, userID string, bot *bots.Bot, post *model.Post, channel *model.Channel, language string) (map[string]string, error) {
+	language, err := validateTranslationLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.pluginAPI.User.Get(userID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get user: %w", err)
+	}
+
+	targetPostUser, err := s.pluginAPI.User.Get(post.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get calls user: %w", err)
+	}
+
+	if !targetPostUser.IsBot || (targetPostUser.Username != CallsBotUsername && targetPostUser.Username != ZoomBotUsername) {
+		return nil, errors.New("not a calls or zoom bot post")
+	}
+
+	createdPost, err := s.newTranscriptionTranslationThread(ctx, bot, user, post, channel, language)
+	if err != nil {
+		return nil, fmt.Errorf("unable to translate transcription: %w", err)
+	}
+
+	T := i18n.LocalizerFunc(s.i18n, user.Locale)
+	s.conversations.SaveTitleAsync(createdPost.Id, T("agents.title_transcript_translation", TitleTranscriptTranslation))
+
+	return map[string]string{
+		"postid":    createdPost.Id,
+		"channelid": createdPost.ChannelId,
+	}, nil
+}
+
+func (s *Service) newTranscriptionTranslationThread(ctx context.Context, bot *bots.Bot, requestingUser *model.User, transcriptionPost *model.Post, channel *model.Channel, language string) (*model.Post, error) {
+	siteURL := s.pluginAPI.Configuration.GetConfig().ServiceSettings.SiteURL
+	T := i18n.LocalizerFunc(s.i18n, requestingUser.Locale)
+	surePost := &model.Post{
+		Message: T("agents.translate_transcription", "Sure, I will translate this transcription into %s: %s/_redirect/pl/%s\n", language, *siteURL, transcriptionPost.Id),
+	}
+	surePost.AddProp(streaming.NoRegen, "true")
+	surePost.AddProp(ReferencedTranscriptPostID, transcriptionPost.Id)
+	if err := s.botDMNonResponse(bot.GetMMBot().UserId, requestingUser.Id, surePost); err != nil {
+		return nil, err
+	}
+
+	go func() (reterr error) {
+		// Update to an error if we return one.
+		defer func() {
+			if reterr != nil {
+				surePost.Message = T("agents.translate_transcription_error", "Sorry! Something went wrong. Check the server logs for details.")
+				if err := s.pluginAPI.Post.UpdatePost(surePost); err != nil {
+					s.pluginAPI.Log.Error("Failed to update post in error handling newTranscriptionTranslationThread", "error", err)
+				}
+				s.pluginAPI.Log.Error("Error in transcription translation post", "error", reterr)
+			}
+		}()
+
+		transcription, err := s.readTranscription(transcriptionPost, channel)
+		if err != nil {
+			return err
+		}
+
+		requestContext := s.contextBuilder.BuildLLMContextUserRequest(
+			bot,
+			requestingUser,
+			channel,
+			s.contextBuilder.WithLLMContextNoTools(),
+		)
+		translation, err := s.TranslateTranscription(ctx, bot, transcription, language, requestContext)
+		if err != nil {
+			return fmt.Errorf("unable to translate transcription: %w", err)
+		}
+
+		bilingual, err := subtitles.NewBilingualSubtitles(transcription, translation)
+		if err != nil {
+			return fmt.Errorf("unable to combine transcriptions: %w", err)
+		}
+
+		suffix := strings.Trim(unsafeLanguageCharacters.ReplaceAllString(strings.ToLower(language), "_"), "_")
+		if suffix == "" {
+			suffix = "translated"
+		}
+		translationFileInfo, err := s.pluginAPI.File.Upload(strings.NewReader(translation.FormatVTT()), "transcript."+suffix+".vtt", channel.Id)
+		if err != nil {
+			return fmt.Errorf("unable to upload translated transcript: %w", err)
+		}
+		bilingualFileInfo, err := s.pluginAPI.File.Upload(strings.NewReader(bilingual.FormatVTT()), "transcript.bilingual."+suffix+".vtt", channel.Id)
+		if err != nil {
+			return fmt.Errorf("unable to upload bilingual transcript: %w", err)
+		}
+
+		message := T("agents.translate_transcription_done", "Here is this transcription translated into %s, and with both languages: %s/_redirect/pl/%s\n", language, *siteURL, transcriptionPost.Id)
+		if err := s.updatePostWithFiles(surePost, message, translationFileInfo, bilingualFileInfo); err != nil {
+			return fmt.Errorf("unable to update translation post: %w", err)
+		}
+
+		return nil
+	}() //nolint:errcheck
+
+	return surePost, nil
+}
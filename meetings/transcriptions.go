@@ -4,11 +4,13 @@
 package meetings
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"slices"
 
 	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
 	"github.com/mattermost/mattermost-plugin-ai/streaming"
 	"github.com/mattermost/mattermost/server/public/model"
 )
@@ -20,8 +22,14 @@ const (
 	TitleMeetingSummary = "Meeting Summary"
 )
 
+// meetingSummaryTitle returns the conversation title for meeting summaries in the user's language
+func (s *Service) meetingSummaryTitle(locale string) string {
+	T := i18n.LocalizerFunc(s.i18n, locale)
+	return T("agents.title_meeting_summary", TitleMeetingSummary)
+}
+
 // HandleTranscribeFile handles file transcription requests
-func (s *Service) HandleTranscribeFile(userID string, bot *bots.Bot, post *model.Post, channel *model.Channel, fileID string) (map[string]string, error) {
+func (s *Service) HandleTranscribeFile(ctx context.Context, userID string, bot *bots.Bot, post *model.Post, channel *model.Channel, fileID string) (map[string]string, error) {
 	user, err := s.pluginAPI.User.Get(userID)
 	if err != nil {
 		return nil, err
@@ -36,12 +44,12 @@ func (s *Service) HandleTranscribeFile(userID string, bot *bots.Bot, post *model
 		return nil, errors.New("file not attached to specified post")
 	}
 
-	createdPost, err := s.newCallRecordingThread(bot, user, post, channel, fileID)
+	createdPost, err := s.newCallRecordingThread(ctx, bot, user, post, channel, fileID)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.conversations.SaveTitle(createdPost.Id, TitleMeetingSummary); err != nil {
+	if err := s.conversations.SaveTitle(createdPost.Id, s.meetingSummaryTitle(user.Locale)); err != nil {
 		return nil, fmt.Errorf("failed to save title: %w", err)
 	}
 
@@ -52,7 +60,7 @@ func (s *Service) HandleTranscribeFile(userID string, bot *bots.Bot, post *model
 }
 
 // HandleSummarizeTranscription handles transcription summarization requests
-func (s *Service) HandleSummarizeTranscription(userID string, bot *bots.Bot, post *model.Post, channel *model.Channel) (map[string]string, error) {
+func (s *Service) HandleSummarizeTranscription(ctx context.Context, userID string, bot *bots.Bot, post *model.Post, channel *model.Channel) (map[string]string, error) {
 	user, err := s.pluginAPI.User.Get(userID)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get user: %w", err)
@@ -67,12 +75,12 @@ func (s *Service) HandleSummarizeTranscription(userID string, bot *bots.Bot, pos
 		return nil, errors.New("not a calls or zoom bot post")
 	}
 
-	createdPost, err := s.newCallTranscriptionSummaryThread(bot, user, post, channel)
+	createdPost, err := s.newCallTranscriptionSummaryThread(ctx, bot, user, post, channel)
 	if err != nil {
 		return nil, fmt.Errorf("unable to summarize transcription: %w", err)
 	}
 
-	s.conversations.SaveTitleAsync(createdPost.Id, TitleMeetingSummary)
+	s.conversations.SaveTitleAsync(createdPost.Id, s.meetingSummaryTitle(user.Locale))
 
 	return map[string]string{
 		"postid":    createdPost.Id,
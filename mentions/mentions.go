@@ -0,0 +1,252 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package mentions summarizes the posts mentioning a user across the channels they are a member
+// of, so they can catch up on what is asked of them with links to answer.
+package mentions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// MaxMentions is the number of mentions summarized, the newest first
+	MaxMentions = 50
+	// MaxWindow is the longest time window of the mentions summarized
+	MaxWindow = 14 * 24 * time.Hour
+	// maxExcerptLength is the number of characters kept of the mentions and of the posts they reply to
+	maxExcerptLength = 1000
+)
+
+// ErrNoMentions is returned when no post the bot may read mentions the user in the time window
+var ErrNoMentions = errors.New("no mentions in the time window")
+
+// Store reads the posts mentioning a user, see mmapi.DBClient
+type Store interface {
+	GetMentions(userID, username string, startTime, endTime int64, maxPosts int) ([]*model.Post, error)
+}
+
+// ChannelChecker checks the bot may read the channels of the mentions, see bots.MMBots
+type ChannelChecker interface {
+	CheckUsageRestrictionsForChannel(bot *bots.Bot, channel *model.Channel) error
+	ChannelPolicy(channel *model.Channel) (exclusions.ChannelPolicy, error)
+}
+
+type Mentions struct {
+	llm      llm.LanguageModel
+	prompts  *llm.Prompts
+	client   mmapi.Client
+	store    Store
+	channels ChannelChecker
+}
+
+func New(
+	llm llm.LanguageModel,
+	prompts *llm.Prompts,
+	client mmapi.Client,
+	store Store,
+	channels ChannelChecker,
+) *Mentions {
+	return &Mentions{
+		llm:      llm,
+		prompts:  prompts,
+		client:   client,
+		store:    store,
+		channels: channels,
+	}
+}
+
+// Summarize streams a prioritized summary, with links and suggested responses, of the posts
+// mentioning the requesting user of context created between startTime and endTime, in
+// milliseconds. Only the posts the user can read, in channels where bot may be used, are
+// summarized.
+func (m *Mentions) Summarize(ctx context.Context, bot *bots.Bot, context *llm.Context, startTime, endTime int64) (*llm.TextStreamResult, error) {
+	user := context.RequestingUser
+	posts, err := m.store.GetMentions(user.Id, user.Username, startTime, endTime, MaxMentions)
+	if err != nil {
+		return nil, err
+	}
+
+	formatted := m.formatMentions(bot, user, posts)
+	if formatted == "" {
+		return nil, ErrNoMentions
+	}
+
+	context.Parameters = map[string]any{
+		"Mentions": formatted,
+	}
+	systemPrompt, err := m.prompts.Format(prompts.PromptSummarizeMentionsSystem, context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format system prompt: %w", err)
+	}
+
+	completionRequest := llm.CompletionRequest{
+		Posts: []llm.Post{
+			{
+				Role:    llm.PostRoleSystem,
+				Message: systemPrompt,
+			},
+			{
+				Role:    llm.PostRoleUser,
+				Message: "Please summarize my mentions as requested.",
+			},
+		},
+		Context: context,
+	}
+
+	return m.llm.ChatCompletion(ctx, completionRequest, llm.WithToolsDisabled())
+}
+
+// IsMention reports whether the message mentions username, and not a longer username starting
+// with it
+func IsMention(message, username string) bool {
+	if username == "" {
+		return false
+	}
+	// Usernames may end a sentence, so a trailing dot is only part of another username when
+	// followed by more of it
+	mentionRegexp := regexp.MustCompile(`(?i)(?:^|[^a-z0-9_.\-])@` + regexp.QuoteMeta(username) + `(?:$|[^a-z0-9_.\-]|\.(?:$|[^a-z0-9_\-]))`)
+	return mentionRegexp.MatchString(message)
+}
+
+// readableChannel is a channel of mentions with what its policy requires
+type readableChannel struct {
+	channel *model.Channel
+	policy  exclusions.ChannelPolicy
+}
+
+// formatMentions formats the mentions the user can read in channels where bot may be used, the
+// newest first. It's empty when there are none.
+func (m *Mentions) formatMentions(bot *bots.Bot, user *model.User, posts []*model.Post) string {
+	siteURL := ""
+	if config := m.client.GetConfig(); config != nil && config.ServiceSettings.SiteURL != nil {
+		siteURL = strings.TrimRight(*config.ServiceSettings.SiteURL, "/")
+	}
+	location, err := time.LoadLocation(user.GetPreferredTimezone())
+	if err != nil {
+		location = time.UTC
+	}
+
+	channels := make(map[string]*readableChannel)
+	users := make(map[string]*model.User)
+	getUser := func(userID string) *model.User {
+		if author, ok := users[userID]; ok {
+			return author
+		}
+		author, userErr := m.client.GetUser(userID)
+		if userErr != nil {
+			m.client.LogError("Failed to get author of mention", "user_id", userID, "error", userErr)
+			author = nil
+		}
+		users[userID] = author
+		return author
+	}
+
+	result := strings.Builder{}
+	count := 0
+	for _, post := range posts {
+		if !IsMention(post.Message, user.Username) {
+			continue
+		}
+
+		channel, ok := channels[post.ChannelId]
+		if !ok {
+			channel = m.readableChannel(bot, user.Id, post.ChannelId)
+			channels[post.ChannelId] = channel
+		}
+		if channel == nil {
+			continue
+		}
+
+		author := getUser(post.UserId)
+		if author == nil || channel.policy.ExcludesPost(post, author) {
+			continue
+		}
+
+		count++
+		fmt.Fprintf(&result, "Mention %d\n", count)
+		fmt.Fprintf(&result, "Channel: %s\n", channelName(channel.channel))
+		fmt.Fprintf(&result, "From: @%s\n", author.Username)
+		fmt.Fprintf(&result, "Time: %s\n", time.UnixMilli(post.CreateAt).In(location).Format("Mon Jan 2 15:04 MST"))
+		if siteURL != "" {
+			fmt.Fprintf(&result, "Link: %s/_redirect/pl/%s\n", siteURL, post.Id)
+		}
+		if root := m.rootExcerpt(post, channel.policy, getUser); root != "" {
+			fmt.Fprintf(&result, "In reply to: %s\n", root)
+		}
+		fmt.Fprintf(&result, "Message:\n%s\n\n", excerpt(format.PostBody(post)))
+	}
+
+	return result.String()
+}
+
+// readableChannel returns the channel when userID can read it, bot may be used in it and its
+// content may be sent without confirmation, nil otherwise
+func (m *Mentions) readableChannel(bot *bots.Bot, userID, channelID string) *readableChannel {
+	if !m.client.HasPermissionToChannel(userID, channelID, model.PermissionReadChannel) {
+		return nil
+	}
+	channel, err := m.client.GetChannel(channelID)
+	if err != nil {
+		m.client.LogError("Failed to get channel of mention", "channel_id", channelID, "error", err)
+		return nil
+	}
+	if m.channels.CheckUsageRestrictionsForChannel(bot, channel) != nil {
+		return nil
+	}
+	// The user didn't confirm sending the content of each channel, so channels requiring
+	// confirmation are left out
+	policy, err := m.channels.ChannelPolicy(channel)
+	if err != nil || policy.RequireConfirmation {
+		return nil
+	}
+	return &readableChannel{channel: channel, policy: policy}
+}
+
+// rootExcerpt returns an excerpt of the root of the thread of a reply, empty for root posts and
+// roots the policy excludes
+func (m *Mentions) rootExcerpt(post *model.Post, policy exclusions.ChannelPolicy, getUser func(string) *model.User) string {
+	if post.RootId == "" {
+		return ""
+	}
+	root, err := m.client.GetPost(post.RootId)
+	if err != nil || root.DeleteAt != 0 {
+		return ""
+	}
+	author := getUser(root.UserId)
+	if author == nil || policy.ExcludesPost(root, author) {
+		return ""
+	}
+	return fmt.Sprintf("@%s: %s", author.Username, strings.Join(strings.Fields(excerpt(format.PostBody(root))), " "))
+}
+
+func channelName(channel *model.Channel) string {
+	switch channel.Type {
+	case model.ChannelTypeDirect:
+		return "Direct message"
+	case model.ChannelTypeGroup:
+		return "Group message"
+	default:
+		return channel.DisplayName
+	}
+}
+
+func excerpt(text string) string {
+	if runes := []rune(text); len(runes) > maxExcerptLength {
+		return string(runes[:maxExcerptLength]) + "..."
+	}
+	return text
+}
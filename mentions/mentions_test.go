@@ -0,0 +1,113 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mentions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/llm/mocks"
+	mmapimocks "github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	posts []*model.Post
+}
+
+func (f *fakeStore) GetMentions(_, _ string, _, _ int64, _ int) ([]*model.Post, error) {
+	return f.posts, nil
+}
+
+type fakeChannelChecker struct {
+	restricted map[string]bool
+	policies   map[string]exclusions.ChannelPolicy
+}
+
+func (f *fakeChannelChecker) CheckUsageRestrictionsForChannel(_ *bots.Bot, channel *model.Channel) error {
+	if f.restricted[channel.Id] {
+		return bots.ErrUsageRestriction
+	}
+	return nil
+}
+
+func (f *fakeChannelChecker) ChannelPolicy(channel *model.Channel) (exclusions.ChannelPolicy, error) {
+	return f.policies[channel.Id], nil
+}
+
+func TestIsMention(t *testing.T) {
+	assert.True(t, IsMention("@alice can you review?", "alice"))
+	assert.True(t, IsMention("Thanks @Alice.", "alice"))
+	assert.True(t, IsMention("cc (@alice)", "alice"))
+	assert.False(t, IsMention("@alice.smith can you review?", "alice"))
+	assert.False(t, IsMention("@alice_b can you review?", "alice"))
+	assert.False(t, IsMention("mail alice@alice.com", "alice"))
+	assert.False(t, IsMention("no mention", ""))
+}
+
+func TestSummarize(t *testing.T) {
+	client := mmapimocks.NewMockClient(t)
+	client.EXPECT().GetConfig().Return(&model.Config{ServiceSettings: model.ServiceSettings{SiteURL: model.NewPointer("https://mm.example.com/")}})
+
+	question := &model.Post{Id: model.NewId(), ChannelId: "town-square", UserId: "bob", Message: "@alice can you approve the release?", RootId: "root"}
+	prefix := &model.Post{Id: model.NewId(), ChannelId: "town-square", UserId: "bob", Message: "@alice.smith ping"}
+	private := &model.Post{Id: model.NewId(), ChannelId: "leadership", UserId: "bob", Message: "@alice budget"}
+	restricted := &model.Post{Id: model.NewId(), ChannelId: "legal", UserId: "bob", Message: "@alice contract"}
+	guest := &model.Post{Id: model.NewId(), ChannelId: "partners", UserId: "guest", Message: "@alice pricing"}
+	store := &fakeStore{posts: []*model.Post{question, prefix, private, restricted, guest}}
+
+	client.EXPECT().HasPermissionToChannel("alice", "town-square", model.PermissionReadChannel).Return(true)
+	client.EXPECT().HasPermissionToChannel("alice", "leadership", model.PermissionReadChannel).Return(false)
+	client.EXPECT().HasPermissionToChannel("alice", "legal", model.PermissionReadChannel).Return(true)
+	client.EXPECT().HasPermissionToChannel("alice", "partners", model.PermissionReadChannel).Return(true)
+	client.EXPECT().GetChannel("town-square").Return(&model.Channel{Id: "town-square", Type: model.ChannelTypeOpen, DisplayName: "Town Square"}, nil)
+	client.EXPECT().GetChannel("legal").Return(&model.Channel{Id: "legal", Type: model.ChannelTypePrivate}, nil)
+	client.EXPECT().GetChannel("partners").Return(&model.Channel{Id: "partners", Type: model.ChannelTypeOpen}, nil)
+	client.EXPECT().GetUser("bob").Return(&model.User{Id: "bob", Username: "bob"}, nil)
+	client.EXPECT().GetUser("guest").Return(&model.User{Id: "guest", Username: "guest", Roles: model.SystemGuestRoleId}, nil)
+	client.EXPECT().GetPost("root").Return(&model.Post{Id: "root", UserId: "bob", Message: "Release 2.0 is ready"}, nil)
+
+	var request llm.CompletionRequest
+	mockLLM := mocks.NewMockLanguageModel(t)
+	mockLLM.EXPECT().ChatCompletion(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(_ context.Context, req llm.CompletionRequest, _ ...llm.LanguageModelOption) {
+			request = req
+		}).
+		Return(&llm.TextStreamResult{}, nil)
+
+	prompts, err := llm.NewPrompts(prompts.PromptsFolder)
+	require.NoError(t, err)
+	channels := &fakeChannelChecker{
+		restricted: map[string]bool{"legal": true},
+		policies:   map[string]exclusions.ChannelPolicy{"partners": {ExcludeGuests: true}},
+	}
+
+	llmContext := llm.NewContext()
+	llmContext.RequestingUser = &model.User{Id: "alice", Username: "alice", Locale: "en"}
+	_, err = New(mockLLM, prompts, client, store, channels).Summarize(context.Background(), nil, llmContext, 0, 1)
+	require.NoError(t, err)
+
+	require.Len(t, request.Posts, 2)
+	systemPrompt := request.Posts[0].Message
+	assert.Contains(t, systemPrompt, "Mention 1\nChannel: Town Square\nFrom: @bob\n")
+	assert.Contains(t, systemPrompt, "Link: https://mm.example.com/_redirect/pl/"+question.Id+"\nIn reply to: @bob: Release 2.0 is ready\nMessage:\n"+question.Message)
+	assert.NotContains(t, systemPrompt, "Mention 2")
+}
+
+func TestSummarizeNoMentions(t *testing.T) {
+	client := mmapimocks.NewMockClient(t)
+	client.EXPECT().GetConfig().Return(&model.Config{})
+
+	llmContext := llm.NewContext()
+	llmContext.RequestingUser = &model.User{Id: "alice", Username: "alice"}
+	_, err := New(mocks.NewMockLanguageModel(t), nil, client, &fakeStore{}, &fakeChannelChecker{}).Summarize(context.Background(), nil, llmContext, 0, 1)
+	require.ErrorIs(t, err, ErrNoMentions)
+}
@@ -4,6 +4,7 @@
 package mmapi
 
 import (
+	"bytes"
 	"io"
 	"net/http"
 
@@ -20,6 +21,7 @@ type Client interface {
 	GetPostsBefore(channelID, postID string, page, perPage int) (*model.PostList, error)
 	CreatePost(post *model.Post) error
 	UpdatePost(post *model.Post) error
+	DeletePost(postID string) error
 	DM(senderID, receiverID string, post *model.Post) error
 	GetChannel(channelID string) (*model.Channel, error)
 	GetDirectChannel(userID1, userID2 string) (*model.Channel, error)
@@ -30,6 +32,7 @@ type Client interface {
 	KVGet(key string, value interface{}) error
 	KVSet(key string, value interface{}) error
 	KVDelete(key string) error
+	KVList(page, perPage int) ([]string, error)
 	GetUserByUsername(username string) (*model.User, error)
 	GetUserStatus(userID string) (*model.Status, error)
 	HasPermissionTo(userID string, permission *model.Permission) bool
@@ -40,7 +43,9 @@ type Client interface {
 	HasPermissionToChannel(userID, channelID string, permission *model.Permission) bool
 	GetFileInfo(fileID string) (*model.FileInfo, error)
 	GetFile(fileID string) (io.ReadCloser, error)
+	UploadFile(data []byte, channelID, filename string) (*model.FileInfo, error)
 	SendEphemeralPost(userID string, post *model.Post)
+	UpdateEphemeralPost(userID string, post *model.Post)
 }
 
 func NewClient(pluginAPI *pluginapi.Client) Client {
@@ -94,6 +99,10 @@ func (m *client) KVDelete(key string) error {
 	return m.pluginAPI.KV.Delete(key)
 }
 
+func (m *client) KVList(page, perPage int) ([]string, error) {
+	return m.pluginAPI.KV.ListKeys(page, perPage)
+}
+
 func (m *client) GetUserByUsername(username string) (*model.User, error) {
 	return m.pluginAPI.User.GetByUsername(username)
 }
@@ -130,6 +139,14 @@ func (m *client) GetFile(fileID string) (io.ReadCloser, error) {
 	return io.NopCloser(file), nil
 }
 
+func (m *client) UploadFile(data []byte, channelID, filename string) (*model.FileInfo, error) {
+	return m.pluginAPI.File.Upload(bytes.NewReader(data), filename, channelID)
+}
+
 func (m *client) SendEphemeralPost(userID string, post *model.Post) {
 	m.PostService.SendEphemeralPost(userID, post)
 }
+
+func (m *client) UpdateEphemeralPost(userID string, post *model.Post) {
+	m.PostService.UpdateEphemeralPost(userID, post)
+}
@@ -13,6 +13,10 @@ import (
 	"github.com/mattermost/mattermost/server/public/pluginapi"
 )
 
+// DriverMySQL is the driver name of servers using MySQL. The model package only names Postgres
+// since the server dropped MySQL, but older servers supported by the plugin still use it.
+const DriverMySQL = "mysql"
+
 type builder interface {
 	ToSql() (string, []any, error)
 }
@@ -22,26 +26,65 @@ type DBClient struct {
 	builder sq.StatementBuilderType
 }
 
-// NewDBClient creates the DB part of the client, only supported on postgres, panics on failures.
+// NewDBClient creates the DB part of the client, supported on postgres and mysql, panics on failures.
 func NewDBClient(pluginAPI *pluginapi.Client) *DBClient {
-	driverName := pluginAPI.Store.DriverName()
-	if driverName != model.DatabaseDriverPostgres {
-		panic("this plugin is only supported on postgres")
-	}
 	origDB, err := pluginAPI.Store.GetMasterDB()
 	if err != nil {
 		panic(fmt.Sprintf("failed to get master db: %v", err))
 	}
 
-	builder := sq.StatementBuilder.PlaceholderFormat(sq.Question)
-	builder = builder.PlaceholderFormat(sq.Dollar)
+	db, err := NewDBClientFromDB(origDB, pluginAPI.Store.DriverName())
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return db
+}
+
+// NewDBClientFromDB creates a DB client for a connection using the postgres or mysql driver.
+func NewDBClientFromDB(db *sql.DB, driverName string) (*DBClient, error) {
+	var builder sq.StatementBuilderType
+	switch driverName {
+	case model.DatabaseDriverPostgres:
+		builder = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	case DriverMySQL:
+		builder = sq.StatementBuilder.PlaceholderFormat(sq.Question)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driverName)
+	}
 
 	return &DBClient{
-		DB:      sqlx.NewDb(origDB, driverName),
+		DB:      sqlx.NewDb(db, driverName),
 		builder: builder,
+	}, nil
+}
+
+// IsPostgres returns true when the server uses Postgres. The plugin's own tables and the
+// features storing data in them are only supported on Postgres.
+func (db *DBClient) IsPostgres() bool {
+	return db.DriverName() == model.DatabaseDriverPostgres
+}
+
+// TableExists reports whether the table exists, for the tables created on demand such as the
+// embeddings of the posts. Only supported on Postgres.
+func (db *DBClient) TableExists(table string) (bool, error) {
+	var exists bool
+	if err := db.DoQueryRow(&exists, db.Builder().Select().Column(sq.Expr("to_regclass(?) IS NOT NULL", table))); err != nil {
+		return false, fmt.Errorf("failed to check table %s: %w", table, err)
 	}
+	return exists, nil
 }
 
+// IntegerDivision returns the SQL expression dividing the integer expression by divisor and
+// discarding the remainder, as / divides integers as decimals on mysql.
+func (db *DBClient) IntegerDivision(expression string, divisor int64) string {
+	if db.DriverName() == DriverMySQL {
+		return fmt.Sprintf("(%s DIV %d)", expression, divisor)
+	}
+	return fmt.Sprintf("(%s / %d)", expression, divisor)
+}
+
+// DoQuery selects the rows of the query into dest, a pointer to a slice.
 func (db *DBClient) DoQuery(dest any, b builder) error {
 	sqlString, args, err := b.ToSql()
 	if err != nil {
@@ -53,6 +96,19 @@ func (db *DBClient) DoQuery(dest any, b builder) error {
 	return sqlx.Select(db, dest, sqlString, args...)
 }
 
+// DoQueryRow selects the single row of the query into dest, returning sql.ErrNoRows when there
+// is none.
+func (db *DBClient) DoQueryRow(dest any, b builder) error {
+	sqlString, args, err := b.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql: %w", err)
+	}
+
+	sqlString = db.Rebind(sqlString)
+
+	return sqlx.Get(db, dest, sqlString, args...)
+}
+
 func (db *DBClient) Builder() sq.StatementBuilderType {
 	return db.builder
 }
@@ -0,0 +1,162 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmapi
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDBClientFromDB(t *testing.T) {
+	_, err := NewDBClientFromDB(&sql.DB{}, "sqlite3")
+	require.Error(t, err)
+
+	for _, tc := range []struct {
+		driverName       string
+		expectedSQL      string
+		expectedDivision string
+		isPostgres       bool
+	}{
+		{
+			driverName:       model.DatabaseDriverPostgres,
+			expectedSQL:      "SELECT Id AS id, CreateAt AS createat, UserId AS userid, ChannelId AS channelid, RootId AS rootid, Message AS message, Type AS type, Props AS props FROM Posts WHERE ChannelId = $1 AND (CreateAt > $2 OR (CreateAt = $3 AND Id > $4)) AND CreateAt <= $5 AND DeleteAt = $6 AND Type = $7 ORDER BY CreateAt ASC, Id ASC LIMIT 10",
+			expectedDivision: "(CreateAt / 1000)",
+			isPostgres:       true,
+		},
+		{
+			driverName:       DriverMySQL,
+			expectedSQL:      "SELECT Id AS id, CreateAt AS createat, UserId AS userid, ChannelId AS channelid, RootId AS rootid, Message AS message, Type AS type, Props AS props FROM Posts WHERE ChannelId = ? AND (CreateAt > ? OR (CreateAt = ? AND Id > ?)) AND CreateAt <= ? AND DeleteAt = ? AND Type = ? ORDER BY CreateAt ASC, Id ASC LIMIT 10",
+			expectedDivision: "(CreateAt DIV 1000)",
+		},
+	} {
+		t.Run(tc.driverName, func(t *testing.T) {
+			db, err := NewDBClientFromDB(&sql.DB{}, tc.driverName)
+			require.NoError(t, err)
+			require.Equal(t, tc.isPostgres, db.IsPostgres())
+			require.Equal(t, tc.expectedDivision, db.IntegerDivision("CreateAt", 1000))
+
+			sqlString, args, err := db.postsInTimeRangeQuery("channel1", 100, "post1", 200, 10).ToSql()
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedSQL, db.Rebind(sqlString))
+			require.Equal(t, []any{"channel1", int64(100), int64(100), "post1", int64(200), 0, ""}, args)
+		})
+	}
+}
+
+func TestMentionsQuery(t *testing.T) {
+	for _, tc := range []struct {
+		driverName     string
+		expectedEscape string
+	}{
+		{driverName: model.DatabaseDriverPostgres, expectedEscape: `LIKE $5 ESCAPE '\'`},
+		{driverName: DriverMySQL, expectedEscape: `LIKE ? ESCAPE '\\'`},
+	} {
+		t.Run(tc.driverName, func(t *testing.T) {
+			db, err := NewDBClientFromDB(&sql.DB{}, tc.driverName)
+			require.NoError(t, err)
+
+			sqlString, args, err := db.mentionsQuery("user1", `Jo_Doe%\`, 100, 200, 10).ToSql()
+			require.NoError(t, err)
+			require.Contains(t, db.Rebind(sqlString), tc.expectedEscape)
+			require.Equal(t, []any{"user1", "user1", int64(100), int64(200), `%@jo\_doe\%\\%`, 0, ""}, args)
+		})
+	}
+}
+
+// TestGetPostsInTimeRangeIntegration runs against the databases configured with the
+// TEST_POSTGRES_DSN and TEST_MYSQL_DSN environment variables, and is skipped for the others.
+func TestGetPostsInTimeRangeIntegration(t *testing.T) {
+	for _, tc := range []struct {
+		driverName string
+		dsnEnv     string
+	}{
+		{driverName: model.DatabaseDriverPostgres, dsnEnv: "TEST_POSTGRES_DSN"},
+		{driverName: DriverMySQL, dsnEnv: "TEST_MYSQL_DSN"},
+	} {
+		t.Run(tc.driverName, func(t *testing.T) {
+			dsn := os.Getenv(tc.dsnEnv)
+			if dsn == "" {
+				t.Skipf("set %s to run against %s", tc.dsnEnv, tc.driverName)
+			}
+
+			sqlDB, err := sql.Open(tc.driverName, dsn)
+			require.NoError(t, err)
+			defer sqlDB.Close()
+			// Temporary tables only exist in the connection creating them, and hide Mattermost's table
+			sqlDB.SetMaxOpenConns(1)
+
+			db, err := NewDBClientFromDB(sqlDB, tc.driverName)
+			require.NoError(t, err)
+			_, err = db.Exec(`CREATE TEMPORARY TABLE Posts (
+				Id VARCHAR(26) PRIMARY KEY,
+				CreateAt BIGINT NOT NULL,
+				DeleteAt BIGINT NOT NULL,
+				UserId VARCHAR(26) NOT NULL,
+				ChannelId VARCHAR(26) NOT NULL,
+				RootId VARCHAR(26) NOT NULL,
+				Message TEXT NOT NULL,
+				Type VARCHAR(26) NOT NULL,
+				Props TEXT NOT NULL
+			)`)
+			require.NoError(t, err)
+
+			for _, post := range []struct {
+				id        string
+				channelID string
+				rootID    string
+				createAt  int64
+				deleteAt  int64
+				postType  string
+			}{
+				{id: "oldroot", channelID: "channel1", createAt: 50},
+				{id: "before", channelID: "channel1", createAt: 60},
+				{id: "deleted", channelID: "channel1", createAt: 110, deleteAt: 120},
+				{id: "joined", channelID: "channel1", createAt: 110, postType: model.PostTypeJoinChannel},
+				{id: "post1", channelID: "channel1", createAt: 120},
+				{id: "post2", channelID: "channel1", createAt: 120},
+				{id: "reply1", channelID: "channel1", rootID: "oldroot", createAt: 130},
+				{id: "reply2", channelID: "channel1", rootID: "post1", createAt: 140},
+				{id: "post3", channelID: "channel1", createAt: 150},
+				{id: "after", channelID: "channel1", createAt: 250},
+				{id: "other", channelID: "channel2", createAt: 100},
+			} {
+				_, err = db.ExecBuilder(db.Builder().Insert("Posts").
+					Columns("Id", "CreateAt", "DeleteAt", "UserId", "ChannelId", "RootId", "Message", "Type", "Props").
+					Values(post.id, post.createAt, post.deleteAt, "user1", post.channelID, post.rootID, "message "+post.id, post.postType, `{"from_bot":"false"}`))
+				require.NoError(t, err)
+			}
+
+			// Pages of two posts split the posts created at the same time
+			postsInTimeRangeBatchSize = 2
+			defer func() { postsInTimeRangeBatchSize = 100 }()
+
+			posts, err := db.GetPostsInTimeRange("channel1", 100, 200, 10)
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{"post1", "post2", "reply1", "oldroot", "reply2", "post3"}, posts.Order)
+			require.Equal(t, "message post1", posts.Posts["post1"].Message)
+			require.Equal(t, "false", posts.Posts["post1"].GetProp("from_bot"))
+
+			posts, err = db.GetPostsInTimeRange("channel1", 100, 200, 3)
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{"post1", "post2", "reply1", "oldroot"}, posts.Order)
+
+			_, err = db.ExecBuilder(db.Builder().Insert("Posts").
+				Columns("Id", "CreateAt", "DeleteAt", "UserId", "ChannelId", "RootId", "Message", "Type", "Props").
+				Values("reply3", 300, 0, "user2", "channel1", "oldroot", "message reply3", "", "{}"))
+			require.NoError(t, err)
+
+			replies, err := db.GetThreadReplies([]string{"oldroot", "post1", "post2"}, 1)
+			require.NoError(t, err)
+			require.Len(t, replies, 2)
+			require.Equal(t, "reply1", replies[0].Id)
+			require.Equal(t, "reply2", replies[1].Id)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmapi
+
+import "fmt"
+
+// KVListPageSize is the number of keys listed per page by KVListMatching
+const KVListPageSize = 1000
+
+// KVLister lists the KV keys of the plugin, see Client
+type KVLister interface {
+	KVList(page, perPage int) ([]string, error)
+}
+
+// KVListMatching lists the KV keys of the plugin accepted by match. All the keys are listed
+// before returning, so the caller can delete them without shifting the pages.
+func KVListMatching(client KVLister, match func(key string) bool) ([]string, error) {
+	var matching []string
+	for page := 0; ; page++ {
+		keys, err := client.KVList(page, KVListPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list keys: %w", err)
+		}
+		for _, key := range keys {
+			if match(key) {
+				matching = append(matching, key)
+			}
+		}
+		if len(keys) < KVListPageSize {
+			return matching, nil
+		}
+	}
+}
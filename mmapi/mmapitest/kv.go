@@ -0,0 +1,79 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package mmapitest provides in-memory fakes of the Mattermost plugin APIs for tests.
+package mmapitest
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+)
+
+// MemoryKV is an in-memory KV store with the same JSON semantics as the plugin KV store.
+type MemoryKV struct {
+	mu sync.Mutex
+
+	// Values are the stored values by key, in JSON
+	Values map[string][]byte
+	// GetErr is returned by KVGet when set, to test a failing store
+	GetErr error
+	// Logged counts the errors logged with LogError
+	Logged int
+}
+
+// NewMemoryKV returns an empty MemoryKV
+func NewMemoryKV() *MemoryKV {
+	return &MemoryKV{Values: make(map[string][]byte)}
+}
+
+func (m *MemoryKV) KVGet(key string, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.GetErr != nil {
+		return m.GetErr
+	}
+	data, ok := m.Values[key]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (m *MemoryKV) KVSet(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Values[key] = data
+	return nil
+}
+
+func (m *MemoryKV) KVDelete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Values, key)
+	return nil
+}
+
+func (m *MemoryKV) KVList(page, perPage int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.Values))
+	for key := range m.Values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	start := min(page*perPage, len(keys))
+	end := min(start+perPage, len(keys))
+	return keys[start:end], nil
+}
+
+func (m *MemoryKV) LogError(string, ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Logged++
+}
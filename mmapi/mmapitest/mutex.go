@@ -0,0 +1,15 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmapitest
+
+import "github.com/mattermost/mattermost/server/public/model"
+
+// MutexAPI is a cluster.MutexPluginAPI granting every lock, for tests that don't run concurrently.
+type MutexAPI struct{}
+
+func (MutexAPI) KVSetWithOptions(string, []byte, model.PluginKVSetOptions) (bool, *model.AppError) {
+	return true, nil
+}
+
+func (MutexAPI) LogError(string, ...any) {}
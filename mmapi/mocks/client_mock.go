@@ -129,6 +129,51 @@ func (_c *MockClient_CreatePost_Call) RunAndReturn(run func(post *model.Post) er
 	return _c
 }
 
+// DeletePost provides a mock function for the type MockClient
+func (_mock *MockClient) DeletePost(postID string) error {
+	ret := _mock.Called(postID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeletePost")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string) error); ok {
+		r0 = returnFunc(postID)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockClient_DeletePost_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeletePost'
+type MockClient_DeletePost_Call struct {
+	*mock.Call
+}
+
+// DeletePost is a helper method to define mock.On call
+//   - postID
+func (_e *MockClient_Expecter) DeletePost(postID interface{}) *MockClient_DeletePost_Call {
+	return &MockClient_DeletePost_Call{Call: _e.mock.On("DeletePost", postID)}
+}
+
+func (_c *MockClient_DeletePost_Call) Run(run func(postID string)) *MockClient_DeletePost_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_DeletePost_Call) Return(err error) *MockClient_DeletePost_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockClient_DeletePost_Call) RunAndReturn(run func(postID string) error) *MockClient_DeletePost_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DM provides a mock function for the type MockClient
 func (_mock *MockClient) DM(senderID string, receiverID string, post *model.Post) error {
 	ret := _mock.Called(senderID, receiverID, post)
@@ -1141,6 +1186,63 @@ func (_c *MockClient_KVGet_Call) RunAndReturn(run func(key string, value interfa
 	return _c
 }
 
+// KVList provides a mock function for the type MockClient
+func (_mock *MockClient) KVList(page int, perPage int) ([]string, error) {
+	ret := _mock.Called(page, perPage)
+
+	if len(ret) == 0 {
+		panic("no return value specified for KVList")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(int, int) ([]string, error)); ok {
+		return returnFunc(page, perPage)
+	}
+	if returnFunc, ok := ret.Get(0).(func(int, int) []string); ok {
+		r0 = returnFunc(page, perPage)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(int, int) error); ok {
+		r1 = returnFunc(page, perPage)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockClient_KVList_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'KVList'
+type MockClient_KVList_Call struct {
+	*mock.Call
+}
+
+// KVList is a helper method to define mock.On call
+//   - page
+//   - perPage
+func (_e *MockClient_Expecter) KVList(page interface{}, perPage interface{}) *MockClient_KVList_Call {
+	return &MockClient_KVList_Call{Call: _e.mock.On("KVList", page, perPage)}
+}
+
+func (_c *MockClient_KVList_Call) Run(run func(page int, perPage int)) *MockClient_KVList_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *MockClient_KVList_Call) Return(strings []string, err error) *MockClient_KVList_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *MockClient_KVList_Call) RunAndReturn(run func(page int, perPage int) ([]string, error)) *MockClient_KVList_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // KVSet provides a mock function for the type MockClient
 func (_mock *MockClient) KVSet(key string, value interface{}) error {
 	ret := _mock.Called(key, value)
@@ -1431,6 +1533,41 @@ func (_c *MockClient_SendEphemeralPost_Call) RunAndReturn(run func(userID string
 	return _c
 }
 
+// UpdateEphemeralPost provides a mock function for the type MockClient
+func (_mock *MockClient) UpdateEphemeralPost(userID string, post *model.Post) {
+	_mock.Called(userID, post)
+	return
+}
+
+// MockClient_UpdateEphemeralPost_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateEphemeralPost'
+type MockClient_UpdateEphemeralPost_Call struct {
+	*mock.Call
+}
+
+// UpdateEphemeralPost is a helper method to define mock.On call
+//   - userID
+//   - post
+func (_e *MockClient_Expecter) UpdateEphemeralPost(userID interface{}, post interface{}) *MockClient_UpdateEphemeralPost_Call {
+	return &MockClient_UpdateEphemeralPost_Call{Call: _e.mock.On("UpdateEphemeralPost", userID, post)}
+}
+
+func (_c *MockClient_UpdateEphemeralPost_Call) Run(run func(userID string, post *model.Post)) *MockClient_UpdateEphemeralPost_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(*model.Post))
+	})
+	return _c
+}
+
+func (_c *MockClient_UpdateEphemeralPost_Call) Return() *MockClient_UpdateEphemeralPost_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockClient_UpdateEphemeralPost_Call) RunAndReturn(run func(string, *model.Post)) *MockClient_UpdateEphemeralPost_Call {
+	_c.Run(run)
+	return _c
+}
+
 // UpdatePost provides a mock function for the type MockClient
 func (_mock *MockClient) UpdatePost(post *model.Post) error {
 	ret := _mock.Called(post)
@@ -1475,3 +1612,61 @@ func (_c *MockClient_UpdatePost_Call) RunAndReturn(run func(post *model.Post) er
 	_c.Call.Return(run)
 	return _c
 }
+
+// UploadFile provides a mock function for the type MockClient
+func (_mock *MockClient) UploadFile(data []byte, channelID string, filename string) (*model.FileInfo, error) {
+	ret := _mock.Called(data, channelID, filename)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UploadFile")
+	}
+
+	var r0 *model.FileInfo
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func([]byte, string, string) (*model.FileInfo, error)); ok {
+		return returnFunc(data, channelID, filename)
+	}
+	if returnFunc, ok := ret.Get(0).(func([]byte, string, string) *model.FileInfo); ok {
+		r0 = returnFunc(data, channelID, filename)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.FileInfo)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func([]byte, string, string) error); ok {
+		r1 = returnFunc(data, channelID, filename)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockClient_UploadFile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UploadFile'
+type MockClient_UploadFile_Call struct {
+	*mock.Call
+}
+
+// UploadFile is a helper method to define mock.On call
+//   - data
+//   - channelID
+//   - filename
+func (_e *MockClient_Expecter) UploadFile(data interface{}, channelID interface{}, filename interface{}) *MockClient_UploadFile_Call {
+	return &MockClient_UploadFile_Call{Call: _e.mock.On("UploadFile", data, channelID, filename)}
+}
+
+func (_c *MockClient_UploadFile_Call) Run(run func(data []byte, channelID string, filename string)) *MockClient_UploadFile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]byte), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockClient_UploadFile_Call) Return(fileInfo *model.FileInfo, err error) *MockClient_UploadFile_Call {
+	_c.Call.Return(fileInfo, err)
+	return _c
+}
+
+func (_c *MockClient_UploadFile_Call) RunAndReturn(run func(data []byte, channelID string, filename string) (*model.FileInfo, error)) *MockClient_UploadFile_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -4,8 +4,11 @@
 package mmapi
 
 import (
+	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/mattermost/mattermost/server/public/model"
@@ -14,6 +17,27 @@ import (
 type ThreadData struct {
 	Posts     []*model.Post
 	UsersByID map[string]*model.User
+	// FilesByPostID are the files attached to the posts, when they were loaded
+	FilesByPostID map[string][]FileData
+	// LinkedPostsByPostID are the posts linked by permalinks in the posts, when they were resolved
+	LinkedPostsByPostID map[string][]LinkedPost
+}
+
+// FileData is a file attached to a post in a thread
+type FileData struct {
+	Name string
+	// Content is an excerpt of the text of the file, or the description of an image. It's empty
+	// when the content of the file isn't known.
+	Content string
+}
+
+// LinkedPost is an excerpt of a post linked by a permalink in a thread
+type LinkedPost struct {
+	PostID   string
+	Username string
+	// ChannelName is the display name of the channel of the post, empty for direct messages
+	ChannelName string
+	Excerpt     string
 }
 
 func (t *ThreadData) CutoffBeforePostID(postID string) {
@@ -66,25 +90,246 @@ func GetMetadataForPosts(client Client, posts *model.PostList) (*ThreadData, err
 	}, nil
 }
 
-func (c *DBClient) GetFirstPostBeforeTimeRangeID(channelID string, startTime, endTime int64) (string, error) {
-	var result struct {
-		ID string `db:"id"`
+// postsInTimeRangeBatchSize is the number of posts read per query of GetPostsInTimeRange
+var postsInTimeRangeBatchSize = 100
+
+// postColumns are the columns of the posts read from the database. They are aliased as mysql
+// keeps the case of the column names.
+var postColumns = []string{
+	"Id AS id",
+	"CreateAt AS createat",
+	"UserId AS userid",
+	"ChannelId AS channelid",
+	"RootId AS rootid",
+	"Message AS message",
+	"Type AS type",
+	"Props AS props",
+}
+
+type postRow struct {
+	ID        string `db:"id"`
+	CreateAt  int64  `db:"createat"`
+	UserID    string `db:"userid"`
+	ChannelID string `db:"channelid"`
+	RootID    string `db:"rootid"`
+	Message   string `db:"message"`
+	Type      string `db:"type"`
+	Props     []byte `db:"props"`
+}
+
+func (r postRow) toPost() *model.Post {
+	post := &model.Post{
+		Id:        r.ID,
+		CreateAt:  r.CreateAt,
+		UserId:    r.UserID,
+		ChannelId: r.ChannelID,
+		RootId:    r.RootID,
+		Message:   r.Message,
+		Type:      r.Type,
 	}
-	err := c.DoQuery(&result, c.Builder().
-		Select("id").
+
+	// Props only add the attachments to the message, posts with invalid props keep their message
+	var props model.StringInterface
+	if len(r.Props) > 0 && json.Unmarshal(r.Props, &props) == nil {
+		post.SetProps(props)
+	}
+
+	return post
+}
+
+// postsInTimeRangeQuery selects the next user posts of the channel created until endTime, from
+// the post created at afterCreateAt with ID afterID excluded, or from afterCreateAt included when
+// afterID is empty. Posts are ordered by creation time and ID so pages never skip posts created
+// at the same time.
+func (c *DBClient) postsInTimeRangeQuery(channelID string, afterCreateAt int64, afterID string, endTime int64, limit int) sq.SelectBuilder {
+	var after sq.Sqlizer = sq.GtOrEq{"CreateAt": afterCreateAt}
+	if afterID != "" {
+		after = sq.Or{
+			sq.Gt{"CreateAt": afterCreateAt},
+			sq.And{sq.Eq{"CreateAt": afterCreateAt}, sq.Gt{"Id": afterID}},
+		}
+	}
+
+	return c.Builder().
+		Select(postColumns...).
 		From("Posts").
 		Where(sq.Eq{"ChannelId": channelID}).
-		Where(sq.And{
-			sq.GtOrEq{"CreateAt": startTime},
-			sq.LtOrEq{"CreateAt": endTime},
-			sq.Eq{"DeleteAt": 0},
-		}).
-		OrderBy("CreateAt ASC").
-		Limit(1))
+		Where(after).
+		Where(sq.LtOrEq{"CreateAt": endTime}).
+		Where(sq.Eq{"DeleteAt": 0}).
+		Where(sq.Eq{"Type": ""}).
+		OrderBy("CreateAt ASC", "Id ASC").
+		Limit(uint64(limit))
+}
 
-	if err != nil {
-		return "", fmt.Errorf("failed to get first post ID: %w", err)
+// GetPostsInTimeRange returns the first maxPosts user posts of the channel created between
+// startTime and endTime included, with the roots of the threads started before startTime that
+// have replies in the range. Deleted and system posts are left out.
+func (c *DBClient) GetPostsInTimeRange(channelID string, startTime, endTime int64, maxPosts int) (*model.PostList, error) {
+	result := model.NewPostList()
+
+	afterCreateAt, afterID := startTime, ""
+	for count := 0; count < maxPosts; {
+		limit := min(postsInTimeRangeBatchSize, maxPosts-count)
+
+		var rows []postRow
+		if err := c.DoQuery(&rows, c.postsInTimeRangeQuery(channelID, afterCreateAt, afterID, endTime, limit)); err != nil {
+			return nil, fmt.Errorf("failed to get posts in time range: %w", err)
+		}
+
+		// Posts are read oldest first, so the roots of the range are read before their replies
+		// and the missing roots are older than the range
+		var missingRootIDs []string
+		for _, row := range rows {
+			result.AddPost(row.toPost())
+			result.AddOrder(row.ID)
+			if row.RootID != "" && result.Posts[row.RootID] == nil && !slices.Contains(missingRootIDs, row.RootID) {
+				missingRootIDs = append(missingRootIDs, row.RootID)
+			}
+		}
+
+		if len(missingRootIDs) > 0 {
+			var roots []postRow
+			if err := c.DoQuery(&roots, c.Builder().
+				Select(postColumns...).
+				From("Posts").
+				Where(sq.Eq{"Id": missingRootIDs}).
+				Where(sq.Eq{"DeleteAt": 0}).
+				Where(sq.Eq{"Type": ""})); err != nil {
+				return nil, fmt.Errorf("failed to get thread roots: %w", err)
+			}
+			for _, root := range roots {
+				result.AddPost(root.toPost())
+				result.AddOrder(root.ID)
+			}
+		}
+
+		count += len(rows)
+		if len(rows) < limit {
+			break
+		}
+		afterCreateAt, afterID = rows[len(rows)-1].CreateAt, rows[len(rows)-1].ID
+	}
+
+	return result, nil
+}
+
+// GetThreadReplies returns the first maxPerThread replies of each thread, oldest first. Deleted
+// and system posts are left out.
+func (c *DBClient) GetThreadReplies(rootIDs []string, maxPerThread int) ([]*model.Post, error) {
+	if len(rootIDs) == 0 || maxPerThread <= 0 {
+		return nil, nil
+	}
+
+	replies := c.Builder().
+		Select(append(postColumns, "ROW_NUMBER() OVER (PARTITION BY RootId ORDER BY CreateAt ASC, Id ASC) AS replynumber")...).
+		From("Posts").
+		Where(sq.Eq{"RootId": rootIDs}).
+		Where(sq.Eq{"DeleteAt": 0}).
+		Where(sq.Eq{"Type": ""})
+
+	var rows []postRow
+	if err := c.DoQuery(&rows, c.Builder().
+		Select("id", "createat", "userid", "channelid", "rootid", "message", "type", "props").
+		FromSelect(replies, "r").
+		Where(sq.LtOrEq{"replynumber": maxPerThread}).
+		OrderBy("createat ASC", "id ASC")); err != nil {
+		return nil, fmt.Errorf("failed to get thread replies: %w", err)
+	}
+
+	posts := make([]*model.Post, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, row.toPost())
+	}
+
+	return posts, nil
+}
+
+// GetMentions returns the last maxPosts user posts created between startTime and endTime included
+// whose message contains @username, in the channels userID is a member of, the newest first.
+// Posts of userID, deleted and system posts are left out. The username may be followed by other
+// characters in the messages, callers check the mention isn't part of a longer username.
+func (c *DBClient) GetMentions(userID, username string, startTime, endTime int64, maxPosts int) ([]*model.Post, error) {
+	if username == "" || maxPosts <= 0 {
+		return nil, nil
+	}
+
+	var rows []postRow
+	if err := c.DoQuery(&rows, c.mentionsQuery(userID, username, startTime, endTime, maxPosts)); err != nil {
+		return nil, fmt.Errorf("failed to get mentions: %w", err)
+	}
+
+	posts := make([]*model.Post, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, row.toPost())
+	}
+
+	return posts, nil
+}
+
+// mentionsQuery selects the posts of GetMentions. The wildcards of LIKE are escaped in the
+// username, underscores being allowed in usernames, so posts that don't contain the username
+// don't count against the limit.
+func (c *DBClient) mentionsQuery(userID, username string, startTime, endTime int64, maxPosts int) sq.SelectBuilder {
+	return c.Builder().
+		Select(postColumns...).
+		From("Posts").
+		Where(sq.Expr("ChannelId IN (SELECT ChannelId FROM ChannelMembers WHERE UserId = ?)", userID)).
+		Where(sq.NotEq{"UserId": userID}).
+		Where(sq.GtOrEq{"CreateAt": startTime}).
+		Where(sq.LtOrEq{"CreateAt": endTime}).
+		Where(sq.Expr("LOWER(Message) LIKE ? "+c.likeEscape(), "%@"+escapeLike(strings.ToLower(username))+"%")).
+		Where(sq.Eq{"DeleteAt": 0}).
+		Where(sq.Eq{"Type": ""}).
+		OrderBy("CreateAt DESC", "Id DESC").
+		Limit(uint64(maxPosts))
+}
+
+// likeEscapeReplacer escapes the wildcards of LIKE patterns and the backslash escaping them
+var likeEscapeReplacer = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike returns text matching itself in LIKE patterns with the ESCAPE clause of likeEscape
+func escapeLike(text string) string {
+	return likeEscapeReplacer.Replace(text)
+}
+
+// likeEscape returns the ESCAPE clause making the backslash the escape character of LIKE
+// patterns. Backslashes are literal in PostgreSQL strings and escaped in MySQL strings.
+func (c *DBClient) likeEscape() string {
+	if c.IsPostgres() {
+		return `ESCAPE '\'`
+	}
+	return `ESCAPE '\\'`
+}
+
+// ReactionCount is the number of users who reacted to a post with an emoji
+type ReactionCount struct {
+	PostID    string `db:"postid"`
+	EmojiName string `db:"emojiname"`
+	Count     int    `db:"count"`
+}
+
+// GetReactionCounts returns the reactions to each post, the most used emoji first.
+func (c *DBClient) GetReactionCounts(postIDs []string) (map[string][]ReactionCount, error) {
+	result := make(map[string][]ReactionCount)
+	if len(postIDs) == 0 {
+		return result, nil
+	}
+
+	var counts []ReactionCount
+	if err := c.DoQuery(&counts, c.Builder().
+		Select("PostId AS postid", "EmojiName AS emojiname", "COUNT(*) AS count").
+		From("Reactions").
+		Where(sq.Eq{"PostId": postIDs}).
+		Where(sq.Eq{"DeleteAt": 0}).
+		GroupBy("PostId", "EmojiName").
+		OrderBy("postid ASC", "count DESC", "emojiname ASC")); err != nil {
+		return nil, fmt.Errorf("failed to get reaction counts: %w", err)
+	}
+
+	for _, count := range counts {
+		result[count.PostID] = append(result[count.PostID], count)
 	}
 
-	return result.ID, nil
+	return result, nil
 }
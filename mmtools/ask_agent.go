@@ -0,0 +1,96 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+const (
+	AskAgentToolName = "ask_agent"
+
+	// MaxDelegationDepth is how many agents can be asked in a row from the agent answering the user
+	MaxDelegationDepth = 2
+	// maxQuestionLength is the longest question sent to another agent
+	maxQuestionLength = 4000
+)
+
+// AgentDelegator asks questions to other agents on behalf of the requesting user of the context
+type AgentDelegator interface {
+	AskAgent(llmContext *llm.Context, agentUsername, question string) (string, error)
+}
+
+type AskAgentArgs struct {
+	Agent    string `jsonschema_description:"The username of the agent to ask, one of the agents listed in the description of the tool."`
+	Question string `jsonschema_description:"The question to ask, with all the context the agent needs to answer as it can't read the conversation."`
+}
+
+// SetAgentDelegator enables the ask_agent tool for the agents with delegate agents.
+func (p *MMToolProvider) SetAgentDelegator(delegator AgentDelegator) {
+	p.delegator = delegator
+}
+
+func (p *MMToolProvider) askAgentTool(bot *bots.Bot) *llm.Tool {
+	if p.delegator == nil || bot == nil {
+		return nil
+	}
+
+	// Empty entries are left by the system console while typing
+	var agents []string
+	for _, agent := range bot.GetConfig().DelegateAgents {
+		if agent = strings.TrimSpace(agent); agent != "" {
+			agents = append(agents, agent)
+		}
+	}
+	if len(agents) == 0 {
+		return nil
+	}
+
+	return &llm.Tool{
+		Name: AskAgentToolName,
+		Description: fmt.Sprintf("Ask a question to another agent specialized in a domain and get its answer. Available agents: %s. "+
+			"Use it when the question is in the domain of one of these agents.", strings.Join(agents, ", ")),
+		Schema:   llm.NewJSONSchemaFromStruct[AskAgentArgs](),
+		Resolver: p.toolAskAgent,
+	}
+}
+
+func (p *MMToolProvider) toolAskAgent(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args AskAgentArgs
+	if err := argsGetter(&args); err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool %s: %w", AskAgentToolName, err)
+	}
+
+	if llmContext.RequestingUser == nil {
+		return "Error: unable to identify the user", fmt.Errorf("no requesting user for tool %s", AskAgentToolName)
+	}
+	agent := strings.TrimPrefix(strings.TrimSpace(args.Agent), "@")
+	question := strings.TrimSpace(args.Question)
+	if agent == "" || question == "" {
+		return "Error: the agent and the question are required", fmt.Errorf("missing agent or question")
+	}
+	if len(question) > maxQuestionLength {
+		return fmt.Sprintf("Error: the question can be at most %d characters", maxQuestionLength), fmt.Errorf("question too long")
+	}
+
+	// Loops would never end, and agents asking themselves is a loop too
+	if agent == llmContext.BotUsername || slices.Contains(llmContext.DelegatedBy, agent) {
+		return fmt.Sprintf("Error: %s is already working on this request, answer without asking it", agent), fmt.Errorf("delegation loop to %s", agent)
+	}
+	if len(llmContext.DelegatedBy) >= MaxDelegationDepth {
+		return "Error: too many agents were asked in a row, answer without asking another agent", fmt.Errorf("delegation depth limit reached")
+	}
+
+	answer, err := p.delegator.AskAgent(llmContext, agent, question)
+	if err != nil {
+		return fmt.Sprintf("Error: unable to ask %s", agent), fmt.Errorf("failed to ask agent %s: %w", agent, err)
+	}
+
+	return answer, nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDelegator struct {
+	agent    string
+	question string
+}
+
+func (f *fakeDelegator) AskAgent(_ *llm.Context, agentUsername, question string) (string, error) {
+	f.agent = agentUsername
+	f.question = question
+	return "Use EXPLAIN ANALYZE.", nil
+}
+
+func TestAskAgentTool(t *testing.T) {
+	provider := NewMMToolProvider(nil, nil, nil, nil, nil)
+	delegator := &fakeDelegator{}
+	provider.SetAgentDelegator(delegator)
+
+	require.Nil(t, provider.askAgentTool(bots.NewBot(llm.BotConfig{Name: "ai"}, llm.ServiceConfig{}, &model.Bot{}, nil)))
+	tool := provider.askAgentTool(bots.NewBot(llm.BotConfig{Name: "ai", DelegateAgents: []string{"sql", "legal"}}, llm.ServiceConfig{}, &model.Bot{}, nil))
+	require.NotNil(t, tool)
+	require.Contains(t, tool.Description, "Available agents: sql, legal.")
+
+	tests := []struct {
+		name           string
+		agent          string
+		delegatedBy    []string
+		expectedResult string
+		expectedError  bool
+	}{
+		{name: "asks the agent", agent: "@sql", expectedResult: "Use EXPLAIN ANALYZE."},
+		{name: "agent asking itself", agent: "ai", expectedResult: "Error: ai is already working on this request, answer without asking it", expectedError: true},
+		{name: "agent asking an agent that asked it", agent: "sql", delegatedBy: []string{"sql"}, expectedResult: "Error: sql is already working on this request, answer without asking it", expectedError: true},
+		{name: "depth limit", agent: "legal", delegatedBy: []string{"generalist", "sql"}, expectedResult: "Error: too many agents were asked in a row, answer without asking another agent", expectedError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			*delegator = fakeDelegator{}
+			llmContext := llm.NewContext()
+			llmContext.BotUsername = "ai"
+			llmContext.RequestingUser = &model.User{Id: "user1"}
+			llmContext.DelegatedBy = test.delegatedBy
+
+			result, err := provider.toolAskAgent(llmContext, func(args any) error {
+				*args.(*AskAgentArgs) = AskAgentArgs{Agent: test.agent, Question: " Why is this query slow? "}
+				return nil
+			})
+			require.Equal(t, test.expectedResult, result)
+			if test.expectedError {
+				require.Error(t, err)
+				require.Empty(t, delegator.agent)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "sql", delegator.agent)
+			require.Equal(t, "Why is this query slow?", delegator.question)
+		})
+	}
+}
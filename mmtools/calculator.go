@@ -0,0 +1,44 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/calc"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+// CalculateToolName is the name of the expression evaluation tool
+const CalculateToolName = "calculate"
+
+type CalculateArgs struct {
+	Expression string `jsonschema_description:"The expression to evaluate. Supports + - * / % ^, parentheses, pi, e, the functions sqrt, abs, round(x, digits), floor, ceil, ln, log10, exp, min, max, sum and avg, durations such as 1h30m, 2d or 45s, and dates in quotes such as \"2024-03-01 14:30\" (UTC). Subtracting dates gives a duration, weeks(), days(), hours(), minutes() and seconds() convert a duration to a number. Example: sum(1h20m, 45m, 2h5m) or days(\"2024-03-01\" - \"2024-01-15\")"`
+}
+
+func (p *MMToolProvider) calculateTool() llm.Tool {
+	return llm.Tool{
+		Name:        CalculateToolName,
+		Description: "Evaluate an arithmetic, duration or date expression exactly. Always use this tool instead of computing numbers yourself when your answer depends on arithmetic, such as totals, averages, percentages, the total downtime across incidents or the time between two dates.",
+		Schema:      llm.NewJSONSchemaFromStruct[CalculateArgs](),
+		Resolver:    p.toolCalculate,
+	}
+}
+
+func (p *MMToolProvider) toolCalculate(_ *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args CalculateArgs
+	if err := argsGetter(&args); err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool calculate: %w", err)
+	}
+
+	expression := strings.TrimSpace(args.Expression)
+	value, err := calc.Evaluate(expression)
+	if err != nil {
+		// The model can fix the expression from the error
+		return fmt.Sprintf("failed to evaluate the expression: %s", err), fmt.Errorf("failed to evaluate expression: %w", err)
+	}
+
+	return fmt.Sprintf("%s = %s", expression, value), nil
+}
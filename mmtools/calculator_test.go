@@ -0,0 +1,141 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolCalculate(t *testing.T) {
+	tests := []struct {
+		name        string
+		expression  string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:       "total downtime across incidents",
+			expression: "sum(1h20m, 45m, 2h5m)",
+			expected:   "sum(1h20m, 45m, 2h5m) = 4h 10m (4.1666666667 hours)",
+		},
+		{
+			name:       "days between dates",
+			expression: ` days("2024-03-01" - "2024-01-15") `,
+			expected:   `days("2024-03-01" - "2024-01-15") = 46`,
+		},
+		{
+			name:        "invalid expression is explained to the model",
+			expression:  "2 +",
+			expected:    "failed to evaluate the expression: unexpected end of expression",
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := NewMMToolProvider(nil, nil, nil, nil, nil)
+			argsGetter := func(args any) error {
+				*args.(*CalculateArgs) = CalculateArgs{Expression: test.expression}
+				return nil
+			}
+
+			result, err := provider.toolCalculate(llm.NewContext(), argsGetter)
+			if test.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expected, result)
+		})
+	}
+}
+
+func TestToolWolframAlpha(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("appid") != "test-app" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Query().Get("input") {
+		case "10 km in miles":
+			_, _ = w.Write([]byte("Result:\n6.214 miles"))
+		case "gibberish":
+			w.WriteHeader(http.StatusNotImplemented)
+			_, _ = w.Write([]byte("Wolfram|Alpha could not understand: gibberish."))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		appID       string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{name: "returns the answer", appID: "test-app", input: "10 km in miles", expected: "Result:\n6.214 miles"},
+		{name: "explains inputs it doesn't understand", appID: "test-app", input: "gibberish", expected: "Wolfram|Alpha could not understand: gibberish."},
+		{name: "invalid AppID", appID: "wrong", input: "10 km in miles", expected: "Wolfram|Alpha rejected the request", expectError: true},
+		{name: "server error", appID: "test-app", input: "other", expected: "Wolfram|Alpha failed to answer", expectError: true},
+		{name: "empty input", appID: "test-app", input: " ", expected: "the input must not be empty", expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := NewMMToolProvider(nil, nil, server.Client(), nil, nil)
+			provider.SetWolframAlpha(func() config.WolframAlphaConfig {
+				return config.WolframAlphaConfig{Enabled: true, AppID: test.appID, APIURL: server.URL}
+			})
+			argsGetter := func(args any) error {
+				*args.(*WolframAlphaArgs) = WolframAlphaArgs{Input: test.input}
+				return nil
+			}
+
+			result, err := provider.toolWolframAlpha(llm.NewContext(), argsGetter)
+			if test.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expected, result)
+		})
+	}
+}
+
+func TestWolframAlphaToolEnablement(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *config.WolframAlphaConfig
+		expected bool
+	}{
+		{name: "not configured", cfg: nil, expected: false},
+		{name: "disabled", cfg: &config.WolframAlphaConfig{Enabled: false, AppID: "app"}, expected: false},
+		{name: "missing AppID", cfg: &config.WolframAlphaConfig{Enabled: true}, expected: false},
+		{name: "enabled", cfg: &config.WolframAlphaConfig{Enabled: true, AppID: "app"}, expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := NewMMToolProvider(nil, nil, &http.Client{}, nil, nil)
+			if test.cfg != nil {
+				provider.SetWolframAlpha(func() config.WolframAlphaConfig { return *test.cfg })
+			}
+
+			names := map[string]bool{}
+			for _, tool := range provider.GetTools(nil) {
+				names[tool.Name] = true
+			}
+			require.True(t, names[CalculateToolName])
+			require.Equal(t, test.expected, names[WolframAlphaToolName])
+		})
+	}
+}
@@ -0,0 +1,324 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/calendars"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	GetAvailabilityToolName = "get_availability"
+	UpcomingEventsToolName  = "upcoming_events"
+
+	calendarTimeout = 30 * time.Second
+
+	// defaultCalendarRange is the range read when the model doesn't give one
+	defaultCalendarRange = 7 * 24 * time.Hour
+	// maxCalendarRange is the longest range that can be read
+	maxCalendarRange = 31 * 24 * time.Hour
+	// maxParticipants is the largest number of other people whose availability can be read
+	maxParticipants = 20
+	// minFreeSlot is the shortest free time reported as a common free slot
+	minFreeSlot = 15 * time.Minute
+)
+
+// calendarTimeLayouts are the layouts accepted for the start and end of a range, the ones
+// without zone being in the user's time zone
+var calendarTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"}
+
+// CalendarService gives access to the calendar of the user, see calendars.Service.
+type CalendarService interface {
+	EnabledProviders() []string
+	ConnectURL(provider string) string
+	Client(ctx context.Context, userID string) (calendars.Client, error)
+}
+
+type UpcomingEventsArgs struct {
+	Start string `jsonschema_description:"The start of the range, as a date such as '2025-03-10' or a date and time such as '2025-03-10T14:00' in the user's time zone. Defaults to now."`
+	End   string `jsonschema_description:"The end of the range, in the same format. A date includes the whole day. Defaults to 7 days after the start, and can be at most 31 days after it."`
+}
+
+type GetAvailabilityArgs struct {
+	Participants []string `jsonschema_description:"The Mattermost usernames or email addresses of the other people to check, without the user who is always included. Example: ['alice', 'bob@example.com']"`
+	Start        string   `jsonschema_description:"The start of the range, as a date such as '2025-03-10' or a date and time such as '2025-03-10T14:00' in the user's time zone. Defaults to now."`
+	End          string   `jsonschema_description:"The end of the range, in the same format. A date includes the whole day. Defaults to 7 days after the start, and can be at most 31 days after it."`
+}
+
+// SetCalendars enables the calendar tools for the providers the service has enabled.
+func (p *MMToolProvider) SetCalendars(calendarService CalendarService) {
+	p.calendars = calendarService
+}
+
+func (p *MMToolProvider) calendarTools() []llm.Tool {
+	if p.calendars == nil || len(p.calendars.EnabledProviders()) == 0 {
+		return nil
+	}
+	return []llm.Tool{
+		{
+			Name:        UpcomingEventsToolName,
+			Description: "List the events on the user's calendar in a time range, with their times, location and attendees. Use it to answer questions about the user's schedule.",
+			Schema:      llm.NewJSONSchemaFromStruct[UpcomingEventsArgs](),
+			Resolver:    p.toolUpcomingEvents,
+		},
+		{
+			Name:        GetAvailabilityToolName,
+			Description: "Get when the user and other people are busy in a time range, and the times everyone is free. Use it to find a time to meet. Free times include nights and weekends, so prefer working hours unless asked otherwise.",
+			Schema:      llm.NewJSONSchemaFromStruct[GetAvailabilityArgs](),
+			Resolver:    p.toolGetAvailability,
+		},
+	}
+}
+
+// calendarErrorMessage explains the model why the calendar couldn't be read
+func calendarErrorMessage(service CalendarService, err error) string {
+	if errors.Is(err, calendars.ErrNotConnected) {
+		var links []string
+		for _, provider := range service.EnabledProviders() {
+			links = append(links, fmt.Sprintf("%s at %s", calendars.ProviderName(provider), service.ConnectURL(provider)))
+		}
+		return fmt.Sprintf("The user has not connected their calendar. Ask them to connect %s and then try again.", strings.Join(links, " or "))
+	}
+	return "Error: unable to read the calendar"
+}
+
+// userLocation returns the time zone of the user, UTC when unknown
+func userLocation(user *model.User) *time.Location {
+	location, err := time.LoadLocation(model.GetPreferredTimezone(user.Timezone))
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// parseCalendarRange parses the range given by the model in the user's time zone
+func parseCalendarRange(startValue, endValue string, now time.Time, location *time.Location) (time.Time, time.Time, error) {
+	start := now
+	if strings.TrimSpace(startValue) != "" {
+		parsed, _, err := parseCalendarTime(startValue, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		start = parsed
+	}
+
+	end := start.Add(defaultCalendarRange)
+	if strings.TrimSpace(endValue) != "" {
+		parsed, dateOnly, err := parseCalendarTime(endValue, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		end = parsed
+		if dateOnly {
+			end = end.AddDate(0, 0, 1)
+		}
+	}
+
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, errors.New("the end must be after the start")
+	}
+	if end.Sub(start) > maxCalendarRange {
+		return time.Time{}, time.Time{}, errors.New("the range can be at most 31 days")
+	}
+	return start, end, nil
+}
+
+func parseCalendarTime(value string, location *time.Location) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if parsed, err := time.ParseInLocation(time.DateOnly, value, location); err == nil {
+		return parsed, true, nil
+	}
+	for _, layout := range calendarTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, location); err == nil {
+			return parsed, false, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("invalid time %q", value)
+}
+
+// formatInterval formats a time range in the location, without repeating the date of the end
+// when it's on the same day
+func formatInterval(start, end time.Time, location *time.Location) string {
+	start, end = start.In(location), end.In(location)
+	if start.Format(time.DateOnly) == end.Format(time.DateOnly) {
+		return start.Format("Mon 2006-01-02 15:04") + " - " + end.Format("15:04")
+	}
+	return start.Format("Mon 2006-01-02 15:04") + " - " + end.Format("Mon 2006-01-02 15:04")
+}
+
+func (p *MMToolProvider) toolUpcomingEvents(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args UpcomingEventsArgs
+	if err := argsGetter(&args); err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool %s: %w", UpcomingEventsToolName, err)
+	}
+	if llmContext.RequestingUser == nil {
+		return "internal failure", errors.New("no requesting user")
+	}
+	location := userLocation(llmContext.RequestingUser)
+	start, end, err := parseCalendarRange(args.Start, args.End, time.Now(), location)
+	if err != nil {
+		return "invalid parameters to function: " + err.Error(), err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), calendarTimeout)
+	defer cancel()
+
+	client, err := p.calendars.Client(ctx, llmContext.RequestingUser.Id)
+	if err != nil {
+		return calendarErrorMessage(p.calendars, err), err
+	}
+	events, err := client.Events(ctx, start, end)
+	if err != nil {
+		return calendarErrorMessage(p.calendars, err), err
+	}
+
+	return formatEvents(events, start, end, location), nil
+}
+
+func formatEvents(events []calendars.Event, start, end time.Time, location *time.Location) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Events from %s, times in %s:\n", formatInterval(start, end, location), location)
+	if len(events) == 0 {
+		builder.WriteString("No events.\n")
+		return builder.String()
+	}
+	for _, event := range events {
+		if event.AllDay {
+			fmt.Fprintf(&builder, "- %s (all day)", event.Start.Format("Mon 2006-01-02"))
+			if days := int(event.End.Sub(event.Start).Hours() / 24); days > 1 {
+				fmt.Fprintf(&builder, " for %d days", days)
+			}
+		} else {
+			fmt.Fprintf(&builder, "- %s", formatInterval(event.Start, event.End, location))
+		}
+		fmt.Fprintf(&builder, ": %s", event.Title)
+		if event.ShowAs != "" && event.ShowAs != "busy" {
+			fmt.Fprintf(&builder, " (%s)", event.ShowAs)
+		}
+		builder.WriteString("\n")
+		if event.Location != "" {
+			fmt.Fprintf(&builder, "  Location: %s\n", event.Location)
+		}
+		if event.Organizer != "" {
+			fmt.Fprintf(&builder, "  Organizer: %s\n", event.Organizer)
+		}
+		if len(event.Attendees) > 0 {
+			fmt.Fprintf(&builder, "  Attendees: %s\n", strings.Join(event.Attendees, ", "))
+		}
+		if event.URL != "" {
+			fmt.Fprintf(&builder, "  Link: %s\n", event.URL)
+		}
+	}
+	return builder.String()
+}
+
+// resolveParticipants returns the email addresses of participants given as Mattermost
+// usernames or email addresses
+func (p *MMToolProvider) resolveParticipants(userID string, participants []string) ([]string, error) {
+	emails := make([]string, 0, len(participants))
+	for _, participant := range participants {
+		participant = strings.TrimPrefix(strings.TrimSpace(participant), "@")
+		if model.IsValidEmail(participant) {
+			emails = append(emails, participant)
+			continue
+		}
+		if !model.IsValidUsername(participant) {
+			return nil, fmt.Errorf("invalid participant %q", participant)
+		}
+		if !p.pluginAPI.HasPermissionTo(userID, model.PermissionViewMembers) {
+			return nil, errors.New("the user doesn't have permission to look up users")
+		}
+		user, err := p.pluginAPI.GetUserByUsername(participant)
+		if err != nil {
+			return nil, fmt.Errorf("user %q not found", participant)
+		}
+		emails = append(emails, user.Email)
+	}
+	return emails, nil
+}
+
+func (p *MMToolProvider) toolGetAvailability(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args GetAvailabilityArgs
+	if err := argsGetter(&args); err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool %s: %w", GetAvailabilityToolName, err)
+	}
+	if llmContext.RequestingUser == nil {
+		return "internal failure", errors.New("no requesting user")
+	}
+	if len(args.Participants) > maxParticipants {
+		return fmt.Sprintf("invalid parameters to function: at most %d participants can be checked", maxParticipants), errors.New("too many participants")
+	}
+	location := userLocation(llmContext.RequestingUser)
+	start, end, err := parseCalendarRange(args.Start, args.End, time.Now(), location)
+	if err != nil {
+		return "invalid parameters to function: " + err.Error(), err
+	}
+	emails, err := p.resolveParticipants(llmContext.RequestingUser.Id, args.Participants)
+	if err != nil {
+		return "invalid parameters to function: " + err.Error(), err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), calendarTimeout)
+	defer cancel()
+
+	client, err := p.calendars.Client(ctx, llmContext.RequestingUser.Id)
+	if err != nil {
+		return calendarErrorMessage(p.calendars, err), err
+	}
+	schedules, err := client.FreeBusy(ctx, emails, start, end)
+	if err != nil {
+		return calendarErrorMessage(p.calendars, err), err
+	}
+
+	// Schedules are labeled as the model named the participants
+	labels := append([]string{"You"}, args.Participants...)
+	return formatAvailability(schedules, labels, start, end, location), nil
+}
+
+func formatAvailability(schedules []calendars.Schedule, labels []string, start, end time.Time, location *time.Location) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Availability from %s, times in %s:\n", formatInterval(start, end, location), location)
+
+	var readable []calendars.Schedule
+	for i, schedule := range schedules {
+		label := schedule.Email
+		if i < len(labels) {
+			label = labels[i]
+		}
+		if schedule.Error != "" {
+			fmt.Fprintf(&builder, "\n%s - could not be read (%s)\n", label, schedule.Error)
+			continue
+		}
+		readable = append(readable, schedule)
+		if len(schedule.Busy) == 0 {
+			fmt.Fprintf(&builder, "\n%s - free the whole time\n", label)
+			continue
+		}
+		fmt.Fprintf(&builder, "\n%s - busy:\n", label)
+		for _, busy := range schedule.Busy {
+			fmt.Fprintf(&builder, "- %s\n", formatInterval(busy.Start, busy.End, location))
+		}
+	}
+
+	builder.WriteString("\nFree for everyone who could be read:\n")
+	found := false
+	for _, free := range calendars.FreeIntervals(readable, start, end) {
+		if free.End.Sub(free.Start) < minFreeSlot {
+			continue
+		}
+		found = true
+		fmt.Fprintf(&builder, "- %s\n", formatInterval(free.Start, free.End, location))
+	}
+	if !found {
+		builder.WriteString("No common free time.\n")
+	}
+	return builder.String()
+}
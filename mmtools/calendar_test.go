@@ -0,0 +1,222 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/calendars"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCalendars struct {
+	providers []string
+	client    *fakeCalendarClient
+}
+
+func (f *fakeCalendars) EnabledProviders() []string {
+	return f.providers
+}
+
+func (f *fakeCalendars) ConnectURL(provider string) string {
+	return "https://mm.example.com/plugins/mattermost-ai/integrations/" + provider + "/connect"
+}
+
+func (f *fakeCalendars) Client(context.Context, string) (calendars.Client, error) {
+	if f.client == nil {
+		return nil, calendars.ErrNotConnected
+	}
+	return f.client, nil
+}
+
+type fakeCalendarClient struct {
+	events    []calendars.Event
+	schedules []calendars.Schedule
+	emails    []string
+}
+
+func (f *fakeCalendarClient) Events(context.Context, time.Time, time.Time) ([]calendars.Event, error) {
+	return f.events, nil
+}
+
+func (f *fakeCalendarClient) FreeBusy(_ context.Context, emails []string, _, _ time.Time) ([]calendars.Schedule, error) {
+	f.emails = emails
+	return f.schedules, nil
+}
+
+func TestParseCalendarRange(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	now := time.Date(2025, 3, 10, 8, 30, 0, 0, paris)
+
+	tests := []struct {
+		name          string
+		start         string
+		end           string
+		expectedStart time.Time
+		expectedEnd   time.Time
+		expectError   bool
+	}{
+		{name: "defaults to the next 7 days", expectedStart: now, expectedEnd: now.Add(7 * 24 * time.Hour)},
+		{name: "whole day", start: "2025-03-11", end: "2025-03-11", expectedStart: time.Date(2025, 3, 11, 0, 0, 0, 0, paris), expectedEnd: time.Date(2025, 3, 12, 0, 0, 0, 0, paris)},
+		{name: "times in the user's time zone", start: "2025-03-11T14:00", end: "2025-03-11 16:30", expectedStart: time.Date(2025, 3, 11, 14, 0, 0, 0, paris), expectedEnd: time.Date(2025, 3, 11, 16, 30, 0, 0, paris)},
+		{name: "times with zone", start: "2025-03-11T14:00:00Z", end: "2025-03-11T15:00:00Z", expectedStart: time.Date(2025, 3, 11, 14, 0, 0, 0, time.UTC), expectedEnd: time.Date(2025, 3, 11, 15, 0, 0, 0, time.UTC)},
+		{name: "end before start", start: "2025-03-11T14:00", end: "2025-03-11T13:00", expectError: true},
+		{name: "range too long", start: "2025-03-01", end: "2025-05-01", expectError: true},
+		{name: "invalid time", start: "next tuesday", expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, end, err := parseCalendarRange(test.start, test.end, now, paris)
+			if test.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, test.expectedStart.Equal(start), "start %s", start)
+			require.True(t, test.expectedEnd.Equal(end), "end %s", end)
+		})
+	}
+}
+
+func TestToolUpcomingEvents(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		client         *fakeCalendarClient
+		expectError    bool
+		expectContains []string
+	}{
+		{
+			name: "lists events in the user's time zone",
+			client: &fakeCalendarClient{events: []calendars.Event{
+				{Title: "Standup", Start: day.Add(8 * time.Hour), End: day.Add(8*time.Hour + 15*time.Minute), Attendees: []string{"Bob"}, ShowAs: "tentative"},
+				{Title: "Offsite", Start: day.Add(24 * time.Hour), End: day.Add(72 * time.Hour), AllDay: true},
+			}},
+			expectContains: []string{
+				"times in Europe/Paris",
+				"- Mon 2025-03-10 09:00 - 09:15: Standup (tentative)\n  Attendees: Bob",
+				"- Tue 2025-03-11 (all day) for 2 days: Offsite",
+			},
+		},
+		{
+			name:           "no events",
+			client:         &fakeCalendarClient{},
+			expectContains: []string{"No events."},
+		},
+		{
+			name:           "asks the user to connect a calendar",
+			expectError:    true,
+			expectContains: []string{"connect Google Calendar at https://mm.example.com/plugins/mattermost-ai/integrations/google_calendar/connect or Outlook Calendar at"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := NewMMToolProvider(nil, nil, nil, nil, nil)
+			provider.SetCalendars(&fakeCalendars{providers: []string{calendars.ProviderGoogle, calendars.ProviderMicrosoft}, client: test.client})
+
+			llmContext := llm.NewContext()
+			llmContext.RequestingUser = &model.User{Id: "user1", Timezone: model.StringMap{"useAutomaticTimezone": "false", "manualTimezone": "Europe/Paris"}}
+			result, err := provider.toolUpcomingEvents(llmContext, func(args any) error {
+				*args.(*UpcomingEventsArgs) = UpcomingEventsArgs{Start: "2025-03-10", End: "2025-03-12"}
+				return nil
+			})
+			if test.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range test.expectContains {
+				require.Contains(t, result, expected)
+			}
+		})
+	}
+}
+
+func TestToolGetAvailability(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		participants   []string
+		setupAPI       func(api *mocks.MockClient)
+		expectError    bool
+		expectEmails   []string
+		expectContains []string
+	}{
+		{
+			name:         "usernames and email addresses",
+			participants: []string{"@alice", "bob@example.com"},
+			setupAPI: func(api *mocks.MockClient) {
+				api.On("HasPermissionTo", "user1", model.PermissionViewMembers).Return(true)
+				api.On("GetUserByUsername", "alice").Return(&model.User{Email: "alice@example.com"}, nil)
+			},
+			expectEmails: []string{"alice@example.com", "bob@example.com"},
+			expectContains: []string{
+				"You - busy:\n- Mon 2025-03-10 09:00 - 10:00",
+				"@alice - busy:\n- Mon 2025-03-10 09:30 - 12:00",
+				"bob@example.com - could not be read (notFound)",
+				"Free for everyone who could be read:\n- Mon 2025-03-10 08:00 - 09:00\n- Mon 2025-03-10 12:00 - 16:00\n",
+			},
+		},
+		{
+			name:         "unknown user",
+			participants: []string{"nobody"},
+			setupAPI: func(api *mocks.MockClient) {
+				api.On("HasPermissionTo", "user1", model.PermissionViewMembers).Return(true)
+				api.On("GetUserByUsername", "nobody").Return(nil, errors.New("not found"))
+			},
+			expectError:    true,
+			expectContains: []string{`user "nobody" not found`},
+		},
+		{
+			name:         "no permission to look up users",
+			participants: []string{"alice"},
+			setupAPI: func(api *mocks.MockClient) {
+				api.On("HasPermissionTo", "user1", model.PermissionViewMembers).Return(false)
+			},
+			expectError:    true,
+			expectContains: []string{"doesn't have permission"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			api := mocks.NewMockClient(t)
+			test.setupAPI(api)
+			client := &fakeCalendarClient{schedules: []calendars.Schedule{
+				{Email: "me@example.com", Busy: []calendars.Interval{{Start: day.Add(8 * time.Hour), End: day.Add(9 * time.Hour)}}},
+				{Email: "alice@example.com", Busy: []calendars.Interval{{Start: day.Add(8*time.Hour + 30*time.Minute), End: day.Add(11 * time.Hour)}}},
+				{Email: "bob@example.com", Error: "notFound"},
+			}}
+			provider := NewMMToolProvider(api, nil, nil, nil, nil)
+			provider.SetCalendars(&fakeCalendars{providers: []string{calendars.ProviderGoogle}, client: client})
+
+			llmContext := llm.NewContext()
+			llmContext.RequestingUser = &model.User{Id: "user1", Timezone: model.StringMap{"useAutomaticTimezone": "false", "manualTimezone": "Europe/Paris"}}
+			result, err := provider.toolGetAvailability(llmContext, func(args any) error {
+				*args.(*GetAvailabilityArgs) = GetAvailabilityArgs{Participants: test.participants, Start: "2025-03-10T08:00", End: "2025-03-10T16:00"}
+				return nil
+			})
+			if test.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expectEmails, client.emails)
+			}
+			for _, expected := range test.expectContains {
+				require.Contains(t, result, expected)
+			}
+		})
+	}
+}
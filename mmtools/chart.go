@@ -0,0 +1,126 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/charts"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// GenerateChartToolName is the name of the chart generation tool
+	GenerateChartToolName = "generate_chart"
+
+	// ChartTypeMessageVolume charts the number of messages posted per day in the channel
+	ChartTypeMessageVolume = "message_volume"
+
+	defaultMessageVolumeDays = 7
+	maxMessageVolumeDays     = 30
+)
+
+type GenerateChartArgs struct {
+	ChartType string    `jsonschema_description:"The kind of chart. 'message_volume' charts the number of messages posted per day in the current channel, 'bar' and 'line' chart the provided labels and values."`
+	Title     string    `jsonschema_description:"The title of the chart."`
+	Labels    []string  `jsonschema_description:"The label of each value, for 'bar' and 'line' charts. Example: ['Mon', 'Tue', 'Wed']"`
+	Values    []float64 `jsonschema_description:"The values to chart, one per label, for 'bar' and 'line' charts. Values must not be negative."`
+	Days      int       `jsonschema_description:"The number of days of messages to chart, for 'message_volume' charts. Between 1 and 30, defaults to 7."`
+}
+
+func (p *MMToolProvider) generateChartTool() llm.Tool {
+	return llm.Tool{
+		Name:        GenerateChartToolName,
+		Description: fmt.Sprintf("Render a simple chart to an image that is attached to your reply. Use it for the message volume of the channel over time or for numbers extracted from messages, with at most %d values.", charts.MaxPoints),
+		Schema:      llm.NewJSONSchemaFromStruct[GenerateChartArgs](),
+		Resolver:    p.toolGenerateChart,
+	}
+}
+
+func (p *MMToolProvider) toolGenerateChart(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args GenerateChartArgs
+	err := argsGetter(&args)
+	if err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool generate_chart: %w", err)
+	}
+
+	if llmContext.Channel == nil {
+		return "charts can only be generated in a channel", errors.New("no channel to upload the chart to")
+	}
+
+	chart := charts.Chart{
+		Type:   charts.Type(args.ChartType),
+		Title:  strings.TrimSpace(args.Title),
+		Labels: args.Labels,
+		Values: args.Values,
+	}
+	if args.ChartType == ChartTypeMessageVolume {
+		chart, err = p.messageVolumeChart(llmContext.Channel.Id, args.Title, args.Days, time.Now())
+		if err != nil {
+			return "failed to read the messages of the channel", err
+		}
+	}
+
+	image, err := charts.Render(chart)
+	if err != nil {
+		return "invalid chart: " + err.Error(), fmt.Errorf("failed to render chart: %w", err)
+	}
+
+	fileInfo, err := p.pluginAPI.UploadFile(image, llmContext.Channel.Id, "chart.png")
+	if err != nil {
+		return "failed to upload the chart", fmt.Errorf("failed to upload chart: %w", err)
+	}
+
+	addGeneratedImage(llmContext, fileInfo.Id)
+
+	return "The chart was generated and is attached to your reply. Do not include it or a link to it in your response.", nil
+}
+
+// messageVolumeChart builds a line chart of the number of messages posted in the channel on each
+// of the last days, in UTC.
+func (p *MMToolProvider) messageVolumeChart(channelID, title string, days int, now time.Time) (charts.Chart, error) {
+	if days <= 0 {
+		days = defaultMessageVolumeDays
+	}
+	days = min(days, maxMessageVolumeDays)
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+
+	posts, err := p.pluginAPI.GetPostsSince(channelID, model.GetMillisForTime(since))
+	if err != nil {
+		return charts.Chart{}, fmt.Errorf("failed to get posts for message volume: %w", err)
+	}
+
+	counts := make([]float64, days)
+	for _, post := range posts.Posts {
+		if post.DeleteAt != 0 || post.IsSystemMessage() {
+			continue
+		}
+		day := int(model.GetTimeForMillis(post.CreateAt).UTC().Sub(since) / (24 * time.Hour))
+		if day >= 0 && day < days {
+			counts[day]++
+		}
+	}
+
+	labels := make([]string, days)
+	for i := range labels {
+		labels[i] = since.AddDate(0, 0, i).Format("Jan 2")
+	}
+
+	if strings.TrimSpace(title) == "" {
+		title = fmt.Sprintf("Messages per day, last %d days", days)
+	}
+
+	return charts.Chart{
+		Type:   charts.TypeLine,
+		Title:  strings.TrimSpace(title),
+		Labels: labels,
+		Values: counts,
+	}, nil
+}
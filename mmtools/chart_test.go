@@ -0,0 +1,80 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/charts"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageVolumeChart(t *testing.T) {
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	at := func(day, hour int) int64 {
+		return model.GetMillisForTime(time.Date(2026, 10, day, hour, 0, 0, 0, time.UTC))
+	}
+
+	tests := []struct {
+		name           string
+		days           int
+		posts          []*model.Post
+		expectedSince  int64
+		expectedLabels []string
+		expectedValues []float64
+	}{
+		{
+			name: "counts messages per day",
+			days: 3,
+			posts: []*model.Post{
+				{Id: "1", CreateAt: at(14, 9)},
+				{Id: "2", CreateAt: at(14, 23)},
+				{Id: "3", CreateAt: at(16, 1)},
+			},
+			expectedSince:  at(14, 0),
+			expectedLabels: []string{"Oct 14", "Oct 15", "Oct 16"},
+			expectedValues: []float64{2, 0, 1},
+		},
+		{
+			name: "skips deleted and system messages",
+			days: 1,
+			posts: []*model.Post{
+				{Id: "1", CreateAt: at(16, 9)},
+				{Id: "2", CreateAt: at(16, 10), DeleteAt: at(16, 11)},
+				{Id: "3", CreateAt: at(16, 12), Type: model.PostTypeJoinChannel},
+			},
+			expectedSince:  at(16, 0),
+			expectedLabels: []string{"Oct 16"},
+			expectedValues: []float64{1},
+		},
+		{
+			name:           "defaults to a week",
+			expectedSince:  at(10, 0),
+			expectedLabels: []string{"Oct 10", "Oct 11", "Oct 12", "Oct 13", "Oct 14", "Oct 15", "Oct 16"},
+			expectedValues: []float64{0, 0, 0, 0, 0, 0, 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			postList := model.NewPostList()
+			for _, post := range test.posts {
+				postList.AddPost(post)
+			}
+			client := mocks.NewMockClient(t)
+			client.EXPECT().GetPostsSince("channel1", test.expectedSince).Return(postList, nil)
+
+			provider := NewMMToolProvider(client, nil, nil, nil, nil)
+			chart, err := provider.messageVolumeChart("channel1", "", test.days, now)
+			require.NoError(t, err)
+			require.Equal(t, charts.TypeLine, chart.Type)
+			require.NotEmpty(t, chart.Title)
+			require.Equal(t, test.expectedLabels, chart.Labels)
+			require.Equal(t, test.expectedValues, chart.Values)
+		})
+	}
+}
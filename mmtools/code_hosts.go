@@ -0,0 +1,237 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/codehosts"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+const (
+	GetPullRequestToolName = "get_pull_request"
+	GetCodeIssueToolName   = "get_code_issue"
+	GetCIStatusToolName    = "get_ci_status"
+
+	codeHostTimeout = 30 * time.Second
+)
+
+// CodeHostService gives access to GitHub and GitLab as the user, see codehosts.Service.
+type CodeHostService interface {
+	EnabledHosts() []string
+	ConnectURL(host string) string
+	Client(ctx context.Context, userID, host string) (codehosts.Client, error)
+}
+
+type CodeHostItemArgs struct {
+	Host       string `jsonschema_description:"The code host, 'github' or 'gitlab'."`
+	Repository string `jsonschema_description:"The repository, as owner/name on GitHub or the full project path such as group/subgroup/project on GitLab. Example: 'mattermost/mattermost-plugin-ai'"`
+	Number     int    `jsonschema_description:"The number of the pull request, merge request or issue. Example: 1234"`
+}
+
+// SetCodeHosts enables the GitHub and GitLab tools for the hosts the service has enabled.
+func (p *MMToolProvider) SetCodeHosts(codeHosts CodeHostService) {
+	p.codeHosts = codeHosts
+}
+
+func (p *MMToolProvider) codeHostEnabled(host string) bool {
+	return p.codeHosts != nil && slices.Contains(p.codeHosts.EnabledHosts(), host)
+}
+
+func (p *MMToolProvider) codeHostTools() []llm.Tool {
+	if p.codeHosts == nil {
+		return nil
+	}
+	hosts := p.codeHosts.EnabledHosts()
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	available := fmt.Sprintf(" Available hosts: %s.", strings.Join(hosts, ", "))
+	return []llm.Tool{
+		{
+			Name:        GetPullRequestToolName,
+			Description: "Retrieve a GitHub pull request or GitLab merge request with its description, diff, reviews and review comments, as the user. Use it to summarize or review changes." + available,
+			Schema:      llm.NewJSONSchemaFromStruct[CodeHostItemArgs](),
+			Resolver:    p.toolGetPullRequest,
+		},
+		{
+			Name:        GetCodeIssueToolName,
+			Description: "Retrieve a GitHub or GitLab issue with its description, labels and comments, as the user." + available,
+			Schema:      llm.NewJSONSchemaFromStruct[CodeHostItemArgs](),
+			Resolver:    p.toolGetCodeIssue,
+		},
+		{
+			Name:        GetCIStatusToolName,
+			Description: "Retrieve the CI checks or pipeline status of the latest commit of a GitHub pull request or GitLab merge request, as the user." + available,
+			Schema:      llm.NewJSONSchemaFromStruct[CodeHostItemArgs](),
+			Resolver:    p.toolGetCIStatus,
+		},
+	}
+}
+
+// codeHostClient returns the arguments of the tool and a client acting as the requesting user,
+// or the message explaining the model why it can't be used.
+func (p *MMToolProvider) codeHostClient(ctx context.Context, llmContext *llm.Context, argsGetter llm.ToolArgumentGetter, toolName string) (CodeHostItemArgs, codehosts.Client, string, error) {
+	var args CodeHostItemArgs
+	if err := argsGetter(&args); err != nil {
+		return args, nil, "invalid parameters to function", fmt.Errorf("failed to get arguments for tool %s: %w", toolName, err)
+	}
+	args.Host = strings.ToLower(strings.TrimSpace(args.Host))
+	if args.Number < 1 {
+		return args, nil, "invalid parameters to function", errors.New("invalid number")
+	}
+
+	if !p.codeHostEnabled(args.Host) {
+		return args, nil, fmt.Sprintf("the host %q is not available", args.Host), fmt.Errorf("code host %q is not enabled", args.Host)
+	}
+	if llmContext.RequestingUser == nil {
+		return args, nil, "internal failure", errors.New("no requesting user")
+	}
+
+	client, err := p.codeHosts.Client(ctx, llmContext.RequestingUser.Id, args.Host)
+	if err != nil {
+		return args, nil, codeHostErrorMessage(p.codeHosts, args.Host, err), err
+	}
+	return args, client, "", nil
+}
+
+// codeHostErrorMessage explains the model why a request to a code host failed
+func codeHostErrorMessage(service CodeHostService, host string, err error) string {
+	name := codehosts.HostName(host)
+	switch {
+	case errors.Is(err, codehosts.ErrNotConnected):
+		return fmt.Sprintf("The user has not connected their %s account. Ask them to connect it at %s and then try again.", name, service.ConnectURL(host))
+	case errors.Is(err, codehosts.ErrNotFound):
+		return fmt.Sprintf("Not found on %s, or the user doesn't have access to it.", name)
+	}
+	return fmt.Sprintf("Error: unable to get the data from %s", name)
+}
+
+func (p *MMToolProvider) toolGetPullRequest(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), codeHostTimeout)
+	defer cancel()
+
+	args, client, message, err := p.codeHostClient(ctx, llmContext, argsGetter, GetPullRequestToolName)
+	if err != nil {
+		return message, err
+	}
+	pr, err := client.GetPullRequest(ctx, args.Repository, args.Number)
+	if err != nil {
+		return codeHostErrorMessage(p.codeHosts, args.Host, err), fmt.Errorf("failed to get pull request: %w", err)
+	}
+
+	return formatPullRequest(pr), nil
+}
+
+func (p *MMToolProvider) toolGetCodeIssue(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), codeHostTimeout)
+	defer cancel()
+
+	args, client, message, err := p.codeHostClient(ctx, llmContext, argsGetter, GetCodeIssueToolName)
+	if err != nil {
+		return message, err
+	}
+	issue, err := client.GetIssue(ctx, args.Repository, args.Number)
+	if err != nil {
+		return codeHostErrorMessage(p.codeHosts, args.Host, err), fmt.Errorf("failed to get issue: %w", err)
+	}
+
+	return formatCodeIssue(issue), nil
+}
+
+func (p *MMToolProvider) toolGetCIStatus(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), codeHostTimeout)
+	defer cancel()
+
+	args, client, message, err := p.codeHostClient(ctx, llmContext, argsGetter, GetCIStatusToolName)
+	if err != nil {
+		return message, err
+	}
+	status, err := client.GetCIStatus(ctx, args.Repository, args.Number)
+	if err != nil {
+		return codeHostErrorMessage(p.codeHosts, args.Host, err), fmt.Errorf("failed to get CI status: %w", err)
+	}
+
+	return formatCIStatus(status), nil
+}
+
+func writeComments(builder *strings.Builder, title string, comments []codehosts.Comment) {
+	if len(comments) == 0 {
+		return
+	}
+	fmt.Fprintf(builder, "\n%s:\n", title)
+	for _, comment := range comments {
+		fmt.Fprintf(builder, "- %s", comment.Author)
+		if comment.State != "" {
+			fmt.Fprintf(builder, " (%s)", comment.State)
+		}
+		if comment.Path != "" {
+			fmt.Fprintf(builder, " on %s", comment.Path)
+			if comment.Line > 0 {
+				fmt.Fprintf(builder, " line %d", comment.Line)
+			}
+		}
+		if body := strings.TrimSpace(comment.Body); body != "" {
+			fmt.Fprintf(builder, ": %s", body)
+		}
+		builder.WriteString("\n")
+	}
+}
+
+func formatPullRequest(pr *codehosts.PullRequest) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Title: %s\nNumber: %d\nState: %s\nAuthor: %s\nURL: %s\nBranches: %s into %s\nDescription: %s\n",
+		pr.Title, pr.Number, pr.State, pr.Author, pr.URL, pr.SourceBranch, pr.TargetBranch, pr.Body)
+	writeComments(&builder, "Reviews", pr.Reviews)
+	writeComments(&builder, "Comments", pr.Comments)
+	if pr.Diff != "" {
+		builder.WriteString("\nDiff:\n```diff\n")
+		builder.WriteString(pr.Diff)
+		builder.WriteString("```\n")
+		if pr.DiffTruncated {
+			builder.WriteString("(The diff was truncated because it is too long.)\n")
+		}
+	}
+	return builder.String()
+}
+
+func formatCodeIssue(issue *codehosts.Issue) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Title: %s\nNumber: %d\nState: %s\nAuthor: %s\nURL: %s\n", issue.Title, issue.Number, issue.State, issue.Author, issue.URL)
+	if len(issue.Labels) > 0 {
+		fmt.Fprintf(&builder, "Labels: %s\n", strings.Join(issue.Labels, ", "))
+	}
+	fmt.Fprintf(&builder, "Description: %s\n", issue.Body)
+	writeComments(&builder, "Comments", issue.Comments)
+	return builder.String()
+}
+
+func formatCIStatus(status *codehosts.CIStatus) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Overall status: %s\n", status.State)
+	if status.Ref != "" {
+		fmt.Fprintf(&builder, "Commit: %s\n", status.Ref)
+	}
+	if status.URL != "" {
+		fmt.Fprintf(&builder, "URL: %s\n", status.URL)
+	}
+	if len(status.Checks) > 0 {
+		builder.WriteString("\nChecks:\n")
+		for _, check := range status.Checks {
+			fmt.Fprintf(&builder, "- %s: %s", check.Name, check.Status)
+			if check.URL != "" {
+				fmt.Fprintf(&builder, " (%s)", check.URL)
+			}
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/codehosts"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCodeHosts struct {
+	hosts     []string
+	connected map[string]bool
+}
+
+func (f *fakeCodeHosts) EnabledHosts() []string {
+	return f.hosts
+}
+
+func (f *fakeCodeHosts) ConnectURL(host string) string {
+	return "https://mm.example.com/plugins/mattermost-ai/integrations/" + host + "/connect"
+}
+
+func (f *fakeCodeHosts) Client(_ context.Context, userID, _ string) (codehosts.Client, error) {
+	if !f.connected[userID] {
+		return nil, codehosts.ErrNotConnected
+	}
+	return &fakeCodeHostClient{}, nil
+}
+
+type fakeCodeHostClient struct{}
+
+func (f *fakeCodeHostClient) GetPullRequest(_ context.Context, _ string, number int) (*codehosts.PullRequest, error) {
+	if number != 1234 {
+		return nil, codehosts.ErrNotFound
+	}
+	return &codehosts.PullRequest{
+		Number:       1234,
+		Title:        "Fix crash",
+		State:        "open",
+		Author:       "alice",
+		SourceBranch: "fix",
+		TargetBranch: "main",
+		Diff:         "+fix\n",
+		Reviews:      []codehosts.Comment{{Author: "bob", State: "approved"}},
+		Comments:     []codehosts.Comment{{Author: "carol", Body: "Nit", Path: "main.go", Line: 3}},
+	}, nil
+}
+
+func (f *fakeCodeHostClient) GetIssue(context.Context, string, int) (*codehosts.Issue, error) {
+	return &codehosts.Issue{Number: 1, Title: "Crash", State: "open", Labels: []string{"bug"}}, nil
+}
+
+func (f *fakeCodeHostClient) GetCIStatus(context.Context, string, int) (*codehosts.CIStatus, error) {
+	return &codehosts.CIStatus{State: "failure", Checks: []codehosts.Check{{Name: "build", Status: "failure"}}}, nil
+}
+
+func TestToolGetPullRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		args           CodeHostItemArgs
+		expectError    bool
+		expectContains []string
+	}{
+		{
+			name:   "returns the pull request with reviews and diff",
+			userID: "connected",
+			args:   CodeHostItemArgs{Host: "GitHub", Repository: "octo/app", Number: 1234},
+			expectContains: []string{
+				"Title: Fix crash",
+				"Branches: fix into main",
+				"- bob (approved)",
+				"- carol on main.go line 3: Nit",
+				"```diff\n+fix\n```",
+			},
+		},
+		{
+			name:           "asks the user to connect their account",
+			userID:         "other",
+			args:           CodeHostItemArgs{Host: "github", Repository: "octo/app", Number: 1234},
+			expectError:    true,
+			expectContains: []string{"has not connected their GitHub account", "/integrations/github/connect"},
+		},
+		{
+			name:           "missing pull request",
+			userID:         "connected",
+			args:           CodeHostItemArgs{Host: "github", Repository: "octo/app", Number: 1},
+			expectError:    true,
+			expectContains: []string{"Not found on GitHub"},
+		},
+		{
+			name:           "host not enabled",
+			userID:         "connected",
+			args:           CodeHostItemArgs{Host: "gitlab", Repository: "group/app", Number: 1234},
+			expectError:    true,
+			expectContains: []string{"not available"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := NewMMToolProvider(nil, nil, nil, nil, nil)
+			provider.SetCodeHosts(&fakeCodeHosts{hosts: []string{codehosts.HostGitHub}, connected: map[string]bool{"connected": true}})
+
+			llmContext := llm.NewContext()
+			llmContext.RequestingUser = &model.User{Id: test.userID}
+			argsGetter := func(args any) error {
+				*args.(*CodeHostItemArgs) = test.args
+				return nil
+			}
+
+			result, err := provider.toolGetPullRequest(llmContext, argsGetter)
+			if test.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range test.expectContains {
+				require.Contains(t, result, expected)
+			}
+		})
+	}
+}
+
+func TestCodeHostToolsEnablement(t *testing.T) {
+	tests := []struct {
+		name     string
+		hosts    []string
+		expected bool
+	}{
+		{name: "no hosts enabled", hosts: nil, expected: false},
+		{name: "a host enabled", hosts: []string{codehosts.HostGitLab}, expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := NewMMToolProvider(nil, nil, nil, nil, nil)
+			provider.SetCodeHosts(&fakeCodeHosts{hosts: test.hosts})
+
+			names := map[string]bool{}
+			for _, tool := range provider.GetTools(nil) {
+				names[tool.Name] = true
+			}
+			for _, name := range []string{GetPullRequestToolName, GetCodeIssueToolName, GetCIStatusToolName} {
+				require.Equal(t, test.expected, names[name])
+			}
+		})
+	}
+}
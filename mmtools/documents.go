@@ -0,0 +1,93 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/connectors"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	SearchDocumentsToolName = "search_documents"
+
+	// maxDocumentResults is the number of document excerpts given to the model at once
+	maxDocumentResults = 8
+)
+
+// DocumentSearchService searches the documents of external sources, see connectors.Service.
+type DocumentSearchService interface {
+	Enabled() bool
+	Search(ctx context.Context, user *model.User, query string, limit int) ([]connectors.SearchResult, error)
+	SourceName(sourceID string) string
+}
+
+type SearchDocumentsArgs struct {
+	Query string `jsonschema_description:"What to search for in the documents. Must be more than 3 and less than 300 characters."`
+}
+
+// SetDocumentSearch enables the search_documents tool.
+func (p *MMToolProvider) SetDocumentSearch(documents DocumentSearchService) {
+	p.documents = documents
+}
+
+func (p *MMToolProvider) searchDocumentsTool() llm.Tool {
+	return llm.Tool{
+		Name:        SearchDocumentsToolName,
+		Description: "Search the documentation of the organization, such as wiki pages and shared documents, using semantic search. Use this tool when the user asks about processes, policies, products or anything that could be documented, and cite the documents used with their links.",
+		Schema:      llm.NewJSONSchemaFromStruct[SearchDocumentsArgs](),
+		Resolver:    p.toolSearchDocuments,
+	}
+}
+
+func (p *MMToolProvider) toolSearchDocuments(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args SearchDocumentsArgs
+	if err := argsGetter(&args); err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool %s: %w", SearchDocumentsToolName, err)
+	}
+
+	if llmContext.RequestingUser == nil {
+		return "Error: unable to identify the user", fmt.Errorf("no requesting user for tool %s", SearchDocumentsToolName)
+	}
+	if len(args.Query) < MinSearchTermLength {
+		return "search query too short", errors.New("search query too short")
+	}
+	if len(args.Query) > MaxSearchTermLength {
+		return "search query too long", errors.New("search query too long")
+	}
+
+	results, err := p.documents.Search(context.Background(), llmContext.RequestingUser, args.Query, maxDocumentResults)
+	if err != nil {
+		return "there was an error searching the documents", fmt.Errorf("document search failed: %w", err)
+	}
+	if len(results) == 0 {
+		return "No relevant documents found.", nil
+	}
+
+	var builder strings.Builder
+	builder.WriteString("Found the following relevant document excerpts:\n\n")
+	for i, result := range results {
+		builder.WriteString(fmt.Sprintf("%d. %s", i+1, result.Document.Title))
+		if source := p.documents.SourceName(result.SourceID); source != "" {
+			builder.WriteString(fmt.Sprintf(" (%s)", source))
+		}
+		builder.WriteString("\n")
+		if result.Document.URL != "" {
+			builder.WriteString(fmt.Sprintf("   Link: %s\n", result.Document.URL))
+		}
+		for _, line := range strings.Split(result.Document.Content, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				builder.WriteString(fmt.Sprintf("   %s\n", line))
+			}
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String(), nil
+}
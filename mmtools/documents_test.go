@@ -0,0 +1,66 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/connectors"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDocumentSearch struct {
+	results []connectors.SearchResult
+	user    *model.User
+	query   string
+}
+
+func (f *fakeDocumentSearch) Enabled() bool {
+	return true
+}
+
+func (f *fakeDocumentSearch) Search(_ context.Context, user *model.User, query string, _ int) ([]connectors.SearchResult, error) {
+	f.user = user
+	f.query = query
+	return f.results, nil
+}
+
+func (f *fakeDocumentSearch) SourceName(sourceID string) string {
+	if sourceID == "wiki" {
+		return "Engineering wiki"
+	}
+	return ""
+}
+
+func TestToolSearchDocuments(t *testing.T) {
+	service := &fakeDocumentSearch{results: []connectors.SearchResult{
+		{SourceID: "wiki", Document: connectors.Document{Title: "Onboarding", URL: "https://wiki.example.com/onboarding", Content: "Onboarding\n\nRead the handbook."}},
+		{SourceID: "drive", Document: connectors.Document{Title: "Expenses policy", Content: "Expenses are reimbursed monthly."}},
+	}}
+	provider := NewMMToolProvider(nil, nil, nil, nil, nil)
+	provider.SetDocumentSearch(service)
+
+	llmContext := llm.NewContext()
+	llmContext.RequestingUser = &model.User{Id: "user1"}
+	result, err := provider.toolSearchDocuments(llmContext, func(args any) error {
+		*args.(*SearchDocumentsArgs) = SearchDocumentsArgs{Query: "onboarding"}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, llmContext.RequestingUser, service.user)
+	require.Equal(t, "onboarding", service.query)
+	require.Contains(t, result, "1. Onboarding (Engineering wiki)\n   Link: https://wiki.example.com/onboarding\n   Onboarding\n   Read the handbook.\n\n")
+	require.Contains(t, result, "2. Expenses policy\n   Expenses are reimbursed monthly.\n\n")
+
+	service.results = nil
+	result, err = provider.toolSearchDocuments(llmContext, func(args any) error {
+		*args.(*SearchDocumentsArgs) = SearchDocumentsArgs{Query: "vacation"}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "No relevant documents found.", result)
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+const (
+	// GeneratedImagesKey is the key used within llm.Context.Parameters to store the IDs of the
+	// files uploaded by the image and chart generation tools
+	GeneratedImagesKey = "mm_generated_images"
+
+	// ImageSizeSquare, ImageSizePortrait and ImageSizeLandscape are the sizes of generated images
+	ImageSizeSquare    = "1024x1024"
+	ImageSizePortrait  = "1024x1792"
+	ImageSizeLandscape = "1792x1024"
+
+	maxImagePromptLength   = 4000
+	imageGenerationTimeout = 2 * time.Minute
+)
+
+var imageSizes = []string{ImageSizeSquare, ImageSizePortrait, ImageSizeLandscape}
+
+// ImageGeneratorProvider returns the image generator to use for a bot, or nil if the bot can't generate images.
+type ImageGeneratorProvider interface {
+	GetImageGenerator(bot *bots.Bot) bots.ImageGenerator
+}
+
+type GenerateImageArgs struct {
+	Prompt string `jsonschema_description:"A detailed description of the image to generate."`
+	Size   string `jsonschema_description:"The size of the image. One of '1024x1024' (square), '1024x1792' (portrait) or '1792x1024' (landscape). Defaults to '1024x1024'."`
+}
+
+func (p *MMToolProvider) toolGenerateImage(generator bots.ImageGenerator) llm.ToolResolver {
+	return func(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+		var args GenerateImageArgs
+		err := argsGetter(&args)
+		if err != nil {
+			return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool generate_image: %w", err)
+		}
+
+		args.Prompt = strings.TrimSpace(args.Prompt)
+		if args.Prompt == "" || len(args.Prompt) > maxImagePromptLength {
+			return fmt.Sprintf("the prompt must be between 1 and %d characters", maxImagePromptLength), errors.New("invalid image prompt")
+		}
+		if args.Size == "" {
+			args.Size = ImageSizeSquare
+		}
+		if !slices.Contains(imageSizes, args.Size) {
+			return "invalid size, must be one of " + strings.Join(imageSizes, ", "), fmt.Errorf("invalid image size %q", args.Size)
+		}
+
+		if llmContext.Channel == nil {
+			return "images can only be generated in a channel", errors.New("no channel to upload the image to")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), imageGenerationTimeout)
+		defer cancel()
+
+		image, err := generator.GenerateImage(ctx, args.Prompt, args.Size)
+		if err != nil {
+			return "failed to generate the image", fmt.Errorf("failed to generate image: %w", err)
+		}
+
+		fileInfo, err := p.pluginAPI.UploadFile(image, llmContext.Channel.Id, "generated_image.png")
+		if err != nil {
+			return "failed to upload the image", fmt.Errorf("failed to upload generated image: %w", err)
+		}
+
+		addGeneratedImage(llmContext, fileInfo.Id)
+
+		return "The image was generated and is attached to your reply. Do not include it or a link to it in your response.", nil
+	}
+}
+
+// addGeneratedImage records an uploaded file to attach to the post of the response.
+func addGeneratedImage(llmContext *llm.Context, fileID string) {
+	if llmContext.Parameters == nil {
+		llmContext.Parameters = make(map[string]interface{})
+	}
+	fileIDs, _ := llmContext.Parameters[GeneratedImagesKey].([]string)
+	llmContext.Parameters[GeneratedImagesKey] = append(fileIDs, fileID)
+}
+
+// ConsumeGeneratedImages returns the IDs of the files uploaded by the image and chart tools and
+// removes them from the context so they are attached to a single post.
+func ConsumeGeneratedImages(ctx *llm.Context) []string {
+	if ctx == nil || ctx.Parameters == nil {
+		return nil
+	}
+
+	fileIDs, _ := ctx.Parameters[GeneratedImagesKey].([]string)
+	delete(ctx.Parameters, GeneratedImagesKey)
+
+	return fileIDs
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeImageGenerator struct {
+	err  error
+	size string
+}
+
+func (f *fakeImageGenerator) GenerateImage(_ context.Context, _, size string) ([]byte, error) {
+	f.size = size
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []byte("png"), nil
+}
+
+func TestToolGenerateImage(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          GenerateImageArgs
+		channel       *model.Channel
+		generatorErr  error
+		expectUpload  bool
+		expectError   bool
+		expectedSize  string
+		expectedFiles []string
+	}{
+		{
+			name:          "generates and uploads image with default size",
+			args:          GenerateImageArgs{Prompt: "a lighthouse at dusk"},
+			channel:       &model.Channel{Id: "channel1"},
+			expectUpload:  true,
+			expectedSize:  ImageSizeSquare,
+			expectedFiles: []string{"file1"},
+		},
+		{
+			name:          "uses requested size",
+			args:          GenerateImageArgs{Prompt: "a lighthouse at dusk", Size: ImageSizeLandscape},
+			channel:       &model.Channel{Id: "channel1"},
+			expectUpload:  true,
+			expectedSize:  ImageSizeLandscape,
+			expectedFiles: []string{"file1"},
+		},
+		{
+			name:        "rejects empty prompt",
+			args:        GenerateImageArgs{Prompt: "   "},
+			channel:     &model.Channel{Id: "channel1"},
+			expectError: true,
+		},
+		{
+			name:        "rejects unsupported size",
+			args:        GenerateImageArgs{Prompt: "a lighthouse", Size: "10x10"},
+			channel:     &model.Channel{Id: "channel1"},
+			expectError: true,
+		},
+		{
+			name:        "fails without a channel",
+			args:        GenerateImageArgs{Prompt: "a lighthouse"},
+			expectError: true,
+		},
+		{
+			name:         "fails when generation fails",
+			args:         GenerateImageArgs{Prompt: "a lighthouse"},
+			channel:      &model.Channel{Id: "channel1"},
+			generatorErr: errors.New("provider error"),
+			expectError:  true,
+			expectedSize: ImageSizeSquare,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := mocks.NewMockClient(t)
+			if test.expectUpload {
+				client.EXPECT().UploadFile([]byte("png"), "channel1", "generated_image.png").Return(&model.FileInfo{Id: "file1"}, nil)
+			}
+			generator := &fakeImageGenerator{err: test.generatorErr}
+			provider := NewMMToolProvider(client, nil, nil, nil, nil)
+
+			llmContext := &llm.Context{Channel: test.channel}
+			argsGetter := func(args any) error {
+				*args.(*GenerateImageArgs) = test.args
+				return nil
+			}
+
+			_, err := provider.toolGenerateImage(generator)(llmContext, argsGetter)
+			if test.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expectedSize, generator.size)
+			require.Equal(t, test.expectedFiles, ConsumeGeneratedImages(llmContext))
+			require.Empty(t, ConsumeGeneratedImages(llmContext))
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/jiracloud"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+const (
+	ReadJiraIssueToolName   = "read_jira_issue"
+	CreateJiraIssueToolName = "create_jira_issue"
+
+	jiraTimeout = 30 * time.Second
+
+	maxJiraSummaryLength     = 255
+	maxJiraDescriptionLength = 30000
+)
+
+// JiraService gives access to Jira Cloud as the user, see jiracloud.Service.
+type JiraService interface {
+	Enabled() bool
+	ConnectURL() string
+	Client(ctx context.Context, userID string) (jiracloud.Client, error)
+}
+
+type ReadJiraIssueArgs struct {
+	IssueKey string `jsonschema_description:"The key of the Jira issue. Example: 'MM-1234'"`
+}
+
+type CreateJiraIssueArgs struct {
+	ProjectKey  string `jsonschema_description:"The key of the Jira project to create the issue in. Example: 'MM'"`
+	IssueType   string `jsonschema_description:"The issue type, such as 'Task', 'Bug' or 'Story'. Defaults to 'Task'."`
+	Summary     string `jsonschema_description:"A short summary of the issue, used as its title."`
+	Description string `jsonschema_description:"The description of the issue in Jira wiki markup. When creating the issue from a conversation, summarize the problem, what was tried and what was decided."`
+}
+
+// SetJira enables the Jira tools acting as the user when the service is enabled.
+func (p *MMToolProvider) SetJira(jiraService JiraService) {
+	p.jira = jiraService
+}
+
+func (p *MMToolProvider) jiraEnabled() bool {
+	return p.jira != nil && p.jira.Enabled()
+}
+
+func (p *MMToolProvider) jiraTools() []llm.Tool {
+	if !p.jiraEnabled() {
+		return nil
+	}
+	return []llm.Tool{
+		{
+			Name:        ReadJiraIssueToolName,
+			Description: "Retrieve a Jira issue by key with its description, status and comments, as the user.",
+			Schema:      llm.NewJSONSchemaFromStruct[ReadJiraIssueArgs](),
+			Resolver:    p.toolReadJiraIssue,
+		},
+		{
+			Name:        CreateJiraIssueToolName,
+			Description: "Create a Jira issue as the user, for example to track a bug or a task discussed in the conversation. Only use it when the user asks to create an issue. Returns the key and the link of the new issue.",
+			Schema:      llm.NewJSONSchemaFromStruct[CreateJiraIssueArgs](),
+			Resolver:    p.toolCreateJiraIssue,
+		},
+	}
+}
+
+// jiraClient returns a client acting as the requesting user, or the message explaining the
+// model why it can't be used.
+func (p *MMToolProvider) jiraClient(ctx context.Context, llmContext *llm.Context) (jiracloud.Client, string, error) {
+	if !p.jiraEnabled() {
+		return nil, "Jira is not available", errors.New("jira is not enabled")
+	}
+	if llmContext.RequestingUser == nil {
+		return nil, "internal failure", errors.New("no requesting user")
+	}
+
+	client, err := p.jira.Client(ctx, llmContext.RequestingUser.Id)
+	if err != nil {
+		return nil, jiraErrorMessage(p.jira, err), err
+	}
+	return client, "", nil
+}
+
+// jiraErrorMessage explains the model why a request to Jira failed
+func jiraErrorMessage(service JiraService, err error) string {
+	var rejection *jiracloud.RejectionError
+	switch {
+	case errors.Is(err, jiracloud.ErrNotConnected):
+		return fmt.Sprintf("The user has not connected their Jira account. Ask them to connect it at %s and then try again.", service.ConnectURL())
+	case errors.Is(err, jiracloud.ErrNotFound):
+		return "The issue was not found in Jira, or the user doesn't have access to it."
+	case errors.As(err, &rejection):
+		return rejection.Error()
+	}
+	return "Error: unable to reach Jira"
+}
+
+func (p *MMToolProvider) toolReadJiraIssue(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args ReadJiraIssueArgs
+	if err := argsGetter(&args); err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool %s: %w", ReadJiraIssueToolName, err)
+	}
+	args.IssueKey = strings.ToUpper(strings.TrimSpace(args.IssueKey))
+	if len(args.IssueKey) > 50 || !validJiraIssueKey.MatchString(args.IssueKey) {
+		return "invalid parameters to function", errors.New("invalid issue key")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jiraTimeout)
+	defer cancel()
+
+	client, message, err := p.jiraClient(ctx, llmContext)
+	if err != nil {
+		return message, err
+	}
+	issue, err := client.GetIssue(ctx, args.IssueKey, fetchedFields)
+	if err != nil {
+		return jiraErrorMessage(p.jira, err), err
+	}
+
+	return formatJiraIssue(issue), nil
+}
+
+func (p *MMToolProvider) toolCreateJiraIssue(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args CreateJiraIssueArgs
+	if err := argsGetter(&args); err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool %s: %w", CreateJiraIssueToolName, err)
+	}
+	input := jiracloud.IssueInput{
+		ProjectKey:  strings.ToUpper(strings.TrimSpace(args.ProjectKey)),
+		IssueType:   strings.TrimSpace(args.IssueType),
+		Summary:     strings.TrimSpace(args.Summary),
+		Description: strings.TrimSpace(args.Description),
+	}
+	if input.IssueType == "" {
+		input.IssueType = "Task"
+	}
+	if input.ProjectKey == "" || input.Summary == "" {
+		return "invalid parameters to function: the project key and the summary are required", errors.New("missing project key or summary")
+	}
+	if len(input.Summary) > maxJiraSummaryLength || len(input.Description) > maxJiraDescriptionLength {
+		return fmt.Sprintf("invalid parameters to function: the summary is limited to %d characters and the description to %d", maxJiraSummaryLength, maxJiraDescriptionLength),
+			errors.New("summary or description too long")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jiraTimeout)
+	defer cancel()
+
+	client, message, err := p.jiraClient(ctx, llmContext)
+	if err != nil {
+		return message, err
+	}
+	created, err := client.CreateIssue(ctx, input)
+	if err != nil {
+		return jiraErrorMessage(p.jira, err), err
+	}
+
+	return fmt.Sprintf("Created the Jira issue %s: %s", created.Key, created.URL), nil
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/mattermost/mattermost-plugin-ai/jiracloud"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeJira struct {
+	enabled bool
+	created []jiracloud.IssueInput
+}
+
+func (f *fakeJira) Enabled() bool {
+	return f.enabled
+}
+
+func (f *fakeJira) ConnectURL() string {
+	return "https://mm.example.com/plugins/mattermost-ai/integrations/jira/connect"
+}
+
+func (f *fakeJira) Client(_ context.Context, userID string) (jiracloud.Client, error) {
+	if userID != "connected" {
+		return nil, jiracloud.ErrNotConnected
+	}
+	return &fakeJiraClient{service: f}, nil
+}
+
+type fakeJiraClient struct {
+	service *fakeJira
+}
+
+func (f *fakeJiraClient) GetIssue(_ context.Context, key string, _ []string) (*jira.Issue, error) {
+	if key != "MM-1" {
+		return nil, jiracloud.ErrNotFound
+	}
+	return &jira.Issue{Key: "MM-1", Fields: &jira.IssueFields{Summary: "Crash on login"}}, nil
+}
+
+func (f *fakeJiraClient) CreateIssue(_ context.Context, input jiracloud.IssueInput) (*jiracloud.CreatedIssue, error) {
+	if input.ProjectKey != "MM" {
+		return nil, &jiracloud.RejectionError{Reasons: []string{"project: valid project is required"}}
+	}
+	f.service.created = append(f.service.created, input)
+	return &jiracloud.CreatedIssue{Key: "MM-2", URL: "https://example.atlassian.net/browse/MM-2"}, nil
+}
+
+func TestToolReadJiraIssue(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		issueKey       string
+		expectError    bool
+		expectContains string
+	}{
+		{name: "returns the issue", userID: "connected", issueKey: "mm-1", expectContains: "Summary: Crash on login"},
+		{name: "asks the user to connect their account", userID: "other", issueKey: "MM-1", expectError: true, expectContains: "/integrations/jira/connect"},
+		{name: "missing issue", userID: "connected", issueKey: "MM-404", expectError: true, expectContains: "not found"},
+		{name: "invalid key", userID: "connected", issueKey: "not a key", expectError: true, expectContains: "invalid parameters"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := NewMMToolProvider(nil, nil, nil, nil, nil)
+			provider.SetJira(&fakeJira{enabled: true})
+
+			llmContext := llm.NewContext()
+			llmContext.RequestingUser = &model.User{Id: test.userID}
+			result, err := provider.toolReadJiraIssue(llmContext, func(args any) error {
+				args.(*ReadJiraIssueArgs).IssueKey = test.issueKey
+				return nil
+			})
+			if test.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Contains(t, result, test.expectContains)
+		})
+	}
+}
+
+func TestToolCreateJiraIssue(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           CreateJiraIssueArgs
+		expectError    bool
+		expectContains string
+		expectCreated  []jiracloud.IssueInput
+	}{
+		{
+			name:           "creates a task by default",
+			args:           CreateJiraIssueArgs{ProjectKey: "mm", Summary: " Login fails ", Description: "Seen in ~town-square"},
+			expectContains: "Created the Jira issue MM-2: https://example.atlassian.net/browse/MM-2",
+			expectCreated:  []jiracloud.IssueInput{{ProjectKey: "MM", IssueType: "Task", Summary: "Login fails", Description: "Seen in ~town-square"}},
+		},
+		{
+			name:           "missing summary",
+			args:           CreateJiraIssueArgs{ProjectKey: "MM"},
+			expectError:    true,
+			expectContains: "the project key and the summary are required",
+		},
+		{
+			name:           "rejected by Jira",
+			args:           CreateJiraIssueArgs{ProjectKey: "NOPE", IssueType: "Bug", Summary: "Login fails"},
+			expectError:    true,
+			expectContains: "Jira rejected the issue: project: valid project is required",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jiraService := &fakeJira{enabled: true}
+			provider := NewMMToolProvider(nil, nil, nil, nil, nil)
+			provider.SetJira(jiraService)
+
+			llmContext := llm.NewContext()
+			llmContext.RequestingUser = &model.User{Id: "connected"}
+			result, err := provider.toolCreateJiraIssue(llmContext, func(args any) error {
+				*args.(*CreateJiraIssueArgs) = test.args
+				return nil
+			})
+			if test.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Contains(t, result, test.expectContains)
+			require.Equal(t, test.expectCreated, jiraService.created)
+		})
+	}
+}
+
+func TestJiraToolsEnablement(t *testing.T) {
+	tests := []struct {
+		name            string
+		enabled         bool
+		expectConnected bool
+		expectPublic    bool
+	}{
+		{name: "public instances tool without the integration", enabled: false, expectConnected: false, expectPublic: true},
+		{name: "connected tools replace the public instances tool", enabled: true, expectConnected: true, expectPublic: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := NewMMToolProvider(nil, nil, http.DefaultClient, nil, nil)
+			provider.SetJira(&fakeJira{enabled: test.enabled})
+
+			names := map[string]bool{}
+			for _, tool := range provider.GetTools(nil) {
+				names[tool.Name] = true
+			}
+			require.Equal(t, test.expectConnected, names[ReadJiraIssueToolName])
+			require.Equal(t, test.expectConnected, names[CreateJiraIssueToolName])
+			require.Equal(t, test.expectPublic, names["GetJiraIssue"])
+		})
+	}
+}
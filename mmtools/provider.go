@@ -8,6 +8,9 @@ import (
 	"strings"
 
 	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/codehosts"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/enterprise"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
 	"github.com/mattermost/mattermost-plugin-ai/search"
@@ -25,15 +28,50 @@ type MMToolProvider struct {
 	search     *search.Search
 	httpClient *http.Client
 	webSearch  WebSearchService
+	images     ImageGeneratorProvider
+	// fetchClient is used by the fetch_url tool and only connects to public addresses
+	fetchClient *http.Client
+	// wolframAlphaConfig enables the wolfram_alpha tool, see SetWolframAlpha
+	wolframAlphaConfig func() config.WolframAlphaConfig
+	// codeHosts enables the GitHub and GitLab tools, see SetCodeHosts
+	codeHosts CodeHostService
+	// jira enables the Jira tools acting as the user, see SetJira
+	jira JiraService
+	// calendars enables the calendar tools, see SetCalendars
+	calendars CalendarService
+	// documents enables the search_documents tool, see SetDocumentSearch
+	documents DocumentSearchService
+	// savedAnswers enables the search_saved_answers tool, see SetSavedAnswers
+	savedAnswers SavedAnswersService
+	// reminders enables the schedule_reminder tool, see SetReminders
+	reminders RemindersService
+	// delegator enables the ask_agent tool, see SetAgentDelegator
+	delegator AgentDelegator
+	// insights enables the query_usage_insights tool, see SetUsageInsights
+	insights UsageInsightsService
+	// license leaves out the tools of features the license does not include, see SetLicenseChecker
+	license enterprise.FeatureChecker
+}
+
+// SetLicenseChecker leaves out the tools of features the license does not include.
+func (p *MMToolProvider) SetLicenseChecker(license enterprise.FeatureChecker) {
+	p.license = license
+}
+
+func (p *MMToolProvider) isLicensed(feature enterprise.Feature) bool {
+	return p.license == nil || p.license.CheckFeature(feature) == nil
 }
 
 // NewMMToolProvider creates a new tool provider
-func NewMMToolProvider(pluginAPI mmapi.Client, search *search.Search, httpClient *http.Client, webSearch WebSearchService) *MMToolProvider {
+func NewMMToolProvider(pluginAPI mmapi.Client, search *search.Search, httpClient *http.Client, webSearch WebSearchService, images ImageGeneratorProvider) *MMToolProvider {
 	return &MMToolProvider{
 		pluginAPI:  pluginAPI,
 		search:     search,
 		httpClient: httpClient,
 		webSearch:  webSearch,
+		images:     images,
+
+		fetchClient: newFetchURLClient(),
 	}
 }
 
@@ -41,10 +79,10 @@ func NewMMToolProvider(pluginAPI mmapi.Client, search *search.Search, httpClient
 // WithToolsDisabled() based on context (e.g., DM vs channel). This allows LLMs to be
 // aware of tool capabilities even when they can't be executed in the current context.
 func (p *MMToolProvider) GetTools(bot *bots.Bot) []llm.Tool {
-	builtInTools := []llm.Tool{}
+	builtInTools := []llm.Tool{p.calculateTool(), p.resolveRelativeDateTool()}
 
 	// Add search tool if search service is available and enabled
-	if p.search.Enabled() {
+	if p.search.Enabled() && p.isLicensed(enterprise.FeatureSemanticSearch) {
 		builtInTools = append(builtInTools, llm.Tool{
 			Name:        "SearchServer",
 			Description: "Search the Mattermost chat server the user is on for messages using semantic search. Use this tool whenever the user asks a question and you don't have the context to answer or you think your response would be more accurate with knowledge from the Mattermost server",
@@ -53,6 +91,10 @@ func (p *MMToolProvider) GetTools(bot *bots.Bot) []llm.Tool {
 		})
 	}
 
+	if p.documents != nil && p.documents.Enabled() && p.isLicensed(enterprise.FeatureSemanticSearch) {
+		builtInTools = append(builtInTools, p.searchDocumentsTool())
+	}
+
 	// Add user lookup tool if pluginAPI is available
 	if p.pluginAPI != nil {
 		builtInTools = append(builtInTools, llm.Tool{
@@ -62,9 +104,9 @@ func (p *MMToolProvider) GetTools(bot *bots.Bot) []llm.Tool {
 			Resolver:    p.toolResolveLookupMattermostUser,
 		})
 
-		// Add GitHub tool if plugin is available
+		// Add GitHub tool if plugin is available, unless users can connect to GitHub directly
 		status, err := p.pluginAPI.GetPluginStatus("github")
-		if err == nil && status != nil && status.State == model.PluginStateRunning {
+		if err == nil && status != nil && status.State == model.PluginStateRunning && !p.codeHostEnabled(codehosts.HostGitHub) {
 			builtInTools = append(builtInTools, llm.Tool{
 				Name:        "GetGithubIssue",
 				Description: "Retrieve a single GitHub issue by owner, repo, and issue number.",
@@ -83,10 +125,54 @@ func (p *MMToolProvider) GetTools(bot *bots.Bot) []llm.Tool {
 				builtInTools = append(builtInTools, *sourceTool)
 			}
 		}
+
+		builtInTools = append(builtInTools, p.generateChartTool())
+
+		if p.images != nil {
+			if generator := p.images.GetImageGenerator(bot); generator != nil {
+				builtInTools = append(builtInTools, llm.Tool{
+					Name:        "generate_image",
+					Description: "Generate an image from a text description. The image is attached to your reply. Only use this tool when the user asks for an image or a picture would clearly help answer the request.",
+					Schema:      llm.NewJSONSchemaFromStruct[GenerateImageArgs](),
+					Resolver:    p.toolGenerateImage(generator),
+				})
+			}
+		}
+	}
+
+	// Add fetch_url tool if enabled for the bot
+	if bot != nil && bot.GetConfig().EnableFetchURL {
+		builtInTools = append(builtInTools, p.fetchURLTool())
+	}
+
+	builtInTools = append(builtInTools, p.codeHostTools()...)
+
+	if p.wolframAlphaEnabled() {
+		builtInTools = append(builtInTools, p.wolframAlphaTool())
+	}
+
+	builtInTools = append(builtInTools, p.jiraTools()...)
+	builtInTools = append(builtInTools, p.calendarTools()...)
+
+	if p.savedAnswers != nil {
+		builtInTools = append(builtInTools, p.savedAnswersTool())
+	}
+
+	if p.reminders != nil {
+		builtInTools = append(builtInTools, p.scheduleReminderTool())
+	}
+
+	if tool := p.askAgentTool(bot); tool != nil {
+		builtInTools = append(builtInTools, *tool)
+	}
+
+	if p.insights != nil && p.pluginAPI != nil && bot != nil && bot.GetConfig().EnableUsageInsights {
+		builtInTools = append(builtInTools, p.usageInsightsTool())
 	}
 
-	// Add Jira tool if httpClient is available
-	if p.httpClient != nil {
+	// Add the tool for public Jira instances if httpClient is available, unless users connect
+	// their Jira account
+	if p.httpClient != nil && !p.jiraEnabled() {
 		builtInTools = append(builtInTools, llm.Tool{
 			Name:        "GetJiraIssue",
 			Description: "Retrieve a single Jira issue by issue key.",
@@ -44,7 +44,7 @@ func TestMMToolProvider_GetTools(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			// Create tool provider
-			provider := NewMMToolProvider(nil, test.searchService, &http.Client{}, nil)
+			provider := NewMMToolProvider(nil, test.searchService, &http.Client{}, nil, nil)
 
 			// Create a mock bot
 			bot := &bots.Bot{}
@@ -116,7 +116,7 @@ func TestMMToolProvider_toolSearchServer(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			// Create tool provider
-			provider := NewMMToolProvider(nil, test.searchService, &http.Client{}, nil)
+			provider := NewMMToolProvider(nil, test.searchService, &http.Client{}, nil, nil)
 
 			// Create mock LLM context
 			llmContext := &llm.Context{
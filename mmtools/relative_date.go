@@ -0,0 +1,86 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/reldate"
+)
+
+// ResolveRelativeDateToolName is the name of the relative date resolution tool
+const ResolveRelativeDateToolName = "resolve_relative_date"
+
+// relativeDateLayout shows the weekday and the time zone so the model doesn't have to work them out
+const relativeDateLayout = "Monday, 2006-01-02 15:04 MST"
+
+type ResolveRelativeDateArgs struct {
+	Expression string `jsonschema_description:"The relative date as the user wrote it, such as 'since Monday', 'yesterday', 'last week', 'past 24 hours', '3 days ago', 'in 2 hours' or 'next friday at 9am'."`
+	Timezone   string `jsonschema_description:"Optional IANA time zone such as 'Europe/Berlin' when the user refers to another time zone. Defaults to the user's time zone."`
+}
+
+func (p *MMToolProvider) resolveRelativeDateTool() llm.Tool {
+	return llm.Tool{
+		Name:        ResolveRelativeDateToolName,
+		Description: "Resolve a relative date or period, such as 'since Monday', 'last week' or 'tomorrow at 9am', into exact dates and times in the user's time zone. Always use this tool instead of working out dates yourself when the request refers to a relative date.",
+		Schema:      llm.NewJSONSchemaFromStruct[ResolveRelativeDateArgs](),
+		Resolver:    p.toolResolveRelativeDate,
+	}
+}
+
+func (p *MMToolProvider) toolResolveRelativeDate(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args ResolveRelativeDateArgs
+	if err := argsGetter(&args); err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool resolve_relative_date: %w", err)
+	}
+
+	location := contextLocation(llmContext)
+	if timezone := strings.TrimSpace(args.Timezone); timezone != "" {
+		var err error
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return fmt.Sprintf("unknown time zone %q, use an IANA time zone such as Europe/Berlin", timezone), fmt.Errorf("failed to load time zone: %w", err)
+		}
+	}
+
+	return resolveRelativeDate(args.Expression, time.Now().In(location))
+}
+
+// contextLocation returns the time zone of the requesting user, UTC when unknown
+func contextLocation(llmContext *llm.Context) *time.Location {
+	if llmContext != nil && llmContext.Timezone != "" {
+		if location, err := time.LoadLocation(llmContext.Timezone); err == nil {
+			return location
+		}
+	}
+	if llmContext != nil && llmContext.RequestingUser != nil {
+		return userLocation(llmContext.RequestingUser)
+	}
+	return time.UTC
+}
+
+func resolveRelativeDate(expression string, now time.Time) (string, error) {
+	expression = strings.TrimSpace(expression)
+	period, err := reldate.Resolve(expression, now)
+	if err != nil {
+		// The model can rephrase the expression from the error
+		return fmt.Sprintf("failed to resolve the date: %s", err), fmt.Errorf("failed to resolve relative date: %w", err)
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Now: %s\n", formatRelativeDate(now))
+	if period.IsInstant() {
+		fmt.Fprintf(&result, "%q is %s", expression, formatRelativeDate(period.Start))
+		return result.String(), nil
+	}
+	fmt.Fprintf(&result, "%q starts %s and ends %s (exclusive)", expression, formatRelativeDate(period.Start), formatRelativeDate(period.End))
+	return result.String(), nil
+}
+
+func formatRelativeDate(t time.Time) string {
+	return fmt.Sprintf("%s (%s, %s, Unix milliseconds %d)", t.Format(relativeDateLayout), t.Location(), t.UTC().Format(time.RFC3339), t.UnixMilli())
+}
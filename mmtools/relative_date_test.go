@@ -0,0 +1,47 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveRelativeDate(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	now := time.Date(2024, time.March, 13, 15, 30, 0, 0, location)
+
+	result, err := resolveRelativeDate(" since Monday ", now)
+	require.NoError(t, err)
+	require.Equal(t, "Now: Wednesday, 2024-03-13 15:30 CET (Europe/Berlin, 2024-03-13T14:30:00Z, Unix milliseconds 1710340200000)\n"+
+		`"since Monday" starts Monday, 2024-03-11 00:00 CET (Europe/Berlin, 2024-03-10T23:00:00Z, Unix milliseconds 1710111600000) `+
+		"and ends Wednesday, 2024-03-13 15:30 CET (Europe/Berlin, 2024-03-13T14:30:00Z, Unix milliseconds 1710340200000) (exclusive)", result)
+
+	result, err = resolveRelativeDate("tomorrow at 9am", now)
+	require.NoError(t, err)
+	require.Contains(t, result, `"tomorrow at 9am" is Thursday, 2024-03-14 09:00 CET`)
+
+	result, err = resolveRelativeDate("the day after the release", now)
+	require.Error(t, err)
+	require.Contains(t, result, "failed to resolve the date: unable to understand")
+}
+
+func TestContextLocation(t *testing.T) {
+	require.Equal(t, time.UTC, contextLocation(nil))
+
+	context := llm.NewContext()
+	require.Equal(t, "UTC", contextLocation(context).String())
+
+	context.Timezone = "Asia/Tokyo"
+	require.Equal(t, "Asia/Tokyo", contextLocation(context).String())
+
+	context.Timezone = ""
+	context.RequestingUser = &model.User{Timezone: model.StringMap{"useAutomaticTimezone": "false", "manualTimezone": "America/Chicago"}}
+	require.Equal(t, "America/Chicago", contextLocation(context).String())
+}
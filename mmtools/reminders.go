@@ -0,0 +1,92 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/reldate"
+	"github.com/mattermost/mattermost-plugin-ai/reminders"
+)
+
+// ScheduleReminderToolName is the name of the reminder scheduling tool
+const ScheduleReminderToolName = "schedule_reminder"
+
+// RemindersService schedules the reminders of the users, see reminders.Service.
+type RemindersService interface {
+	Create(reminder reminders.Reminder) (*reminders.Reminder, error)
+}
+
+type ScheduleReminderArgs struct {
+	When    string `jsonschema_description:"When to send the reminder, as the user wrote it, such as 'tomorrow at 9am', 'in 2 hours' or 'next monday at 14:30', or an RFC 3339 date and time."`
+	Message string `jsonschema_description:"What to remind the user about, addressed to the user. Example: 'Follow up on the release plan with the QA team'"`
+}
+
+// SetReminders enables the schedule_reminder tool.
+func (p *MMToolProvider) SetReminders(reminders RemindersService) {
+	p.reminders = reminders
+}
+
+func (p *MMToolProvider) scheduleReminderTool() llm.Tool {
+	return llm.Tool{
+		Name:        ScheduleReminderToolName,
+		Description: "Schedule a reminder sent to the user by direct message at the given time, with a link to the current thread. Use it when the user asks to be reminded or to follow up on something later.",
+		Schema:      llm.NewJSONSchemaFromStruct[ScheduleReminderArgs](),
+		Resolver:    p.toolScheduleReminder,
+	}
+}
+
+func (p *MMToolProvider) toolScheduleReminder(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args ScheduleReminderArgs
+	if err := argsGetter(&args); err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool %s: %w", ScheduleReminderToolName, err)
+	}
+
+	if llmContext.RequestingUser == nil || llmContext.BotUserID == "" {
+		return "Error: unable to identify the user", fmt.Errorf("no requesting user or bot for tool %s", ScheduleReminderToolName)
+	}
+
+	remindAt, err := reminderTime(args.When, time.Now().In(contextLocation(llmContext)))
+	if err != nil {
+		return fmt.Sprintf("Error: %s", err), fmt.Errorf("failed to resolve reminder time: %w", err)
+	}
+
+	reminder, err := p.reminders.Create(reminders.Reminder{
+		UserID:   llmContext.RequestingUser.Id,
+		BotID:    llmContext.BotUserID,
+		PostID:   llmContext.RootPostID,
+		Message:  args.Message,
+		RemindAt: remindAt.UnixMilli(),
+	})
+	if errors.Is(err, reminders.ErrInvalid) || errors.Is(err, reminders.ErrTooMany) {
+		return fmt.Sprintf("Error: %s", err), err
+	}
+	if err != nil {
+		return "Error: unable to schedule the reminder", fmt.Errorf("failed to create reminder: %w", err)
+	}
+
+	return fmt.Sprintf("Reminder %s scheduled for %s. It will be sent to the user by direct message.", reminder.ID, formatRelativeDate(remindAt)), nil
+}
+
+// reminderTime resolves when a reminder is sent. Periods such as "tomorrow" are refused so the
+// model asks the user for a time instead of guessing one.
+func reminderTime(when string, now time.Time) (time.Time, error) {
+	when = strings.TrimSpace(when)
+	if remindAt, err := time.Parse(time.RFC3339, when); err == nil {
+		return remindAt.In(now.Location()), nil
+	}
+
+	period, err := reldate.Resolve(when, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !period.IsInstant() {
+		return time.Time{}, fmt.Errorf("%q is a period rather than a time, include a time such as \"tomorrow at 9am\"", when)
+	}
+	return period.Start, nil
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/reminders"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeReminders struct {
+	created []reminders.Reminder
+}
+
+func (f *fakeReminders) Create(reminder reminders.Reminder) (*reminders.Reminder, error) {
+	reminder.ID = "reminder1"
+	f.created = append(f.created, reminder)
+	return &reminder, nil
+}
+
+func TestReminderTime(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	now := time.Date(2024, time.March, 13, 15, 30, 0, 0, location)
+
+	remindAt, err := reminderTime("tomorrow at 9am", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, time.March, 14, 9, 0, 0, 0, location), remindAt)
+
+	remindAt, err = reminderTime("2024-03-20T08:00:00Z", now)
+	require.NoError(t, err)
+	require.True(t, remindAt.Equal(time.Date(2024, time.March, 20, 8, 0, 0, 0, time.UTC)))
+
+	_, err = reminderTime("tomorrow", now)
+	require.ErrorContains(t, err, "is a period rather than a time")
+
+	_, err = reminderTime("after the release", now)
+	require.Error(t, err)
+}
+
+func TestToolScheduleReminder(t *testing.T) {
+	service := &fakeReminders{}
+	provider := NewMMToolProvider(nil, nil, nil, nil, nil)
+	provider.SetReminders(service)
+
+	llmContext := llm.NewContext()
+	llmContext.RequestingUser = &model.User{Id: "user1"}
+	llmContext.BotUserID = "bot1"
+	llmContext.RootPostID = "root1"
+	before := time.Now()
+	result, err := provider.toolScheduleReminder(llmContext, func(args any) error {
+		*args.(*ScheduleReminderArgs) = ScheduleReminderArgs{When: "in 2 hours", Message: "Follow up on the release plan"}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Contains(t, result, "Reminder reminder1 scheduled for ")
+
+	require.Len(t, service.created, 1)
+	created := service.created[0]
+	require.Equal(t, "user1", created.UserID)
+	require.Equal(t, "bot1", created.BotID)
+	require.Equal(t, "root1", created.PostID)
+	require.Equal(t, "Follow up on the release plan", created.Message)
+	require.GreaterOrEqual(t, created.RemindAt, before.Add(2*time.Hour).UnixMilli())
+
+	result, err = provider.toolScheduleReminder(llmContext, func(args any) error {
+		*args.(*ScheduleReminderArgs) = ScheduleReminderArgs{When: "next week", Message: "Follow up"}
+		return nil
+	})
+	require.Error(t, err)
+	require.Contains(t, result, "is a period rather than a time")
+	require.Len(t, service.created, 1)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/savedanswers"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	SearchSavedAnswersToolName = "search_saved_answers"
+
+	// maxSavedAnswersResults is the number of saved answers given to the model at once
+	maxSavedAnswersResults = 5
+)
+
+// SavedAnswersService gives access to the saved answers of the users, see savedanswers.Store.
+type SavedAnswersService interface {
+	List(query savedanswers.Query) ([]savedanswers.SavedAnswer, bool, error)
+}
+
+type SearchSavedAnswersArgs struct {
+	Terms string `jsonschema_description:"Words to search for in the saved answers. Leave empty to get the most recently saved ones."`
+	Tag   string `jsonschema_description:"Only return the saved answers with this tag. Example: 'onboarding'"`
+}
+
+// SetSavedAnswers enables the search_saved_answers tool.
+func (p *MMToolProvider) SetSavedAnswers(savedAnswers SavedAnswersService) {
+	p.savedAnswers = savedAnswers
+}
+
+func (p *MMToolProvider) savedAnswersTool() llm.Tool {
+	return llm.Tool{
+		Name:        SearchSavedAnswersToolName,
+		Description: "Search the answers the user saved to their personal collection. Only use it when the user asks to use, recall or refer to their saved answers.",
+		Schema:      llm.NewJSONSchemaFromStruct[SearchSavedAnswersArgs](),
+		Resolver:    p.toolSearchSavedAnswers,
+	}
+}
+
+func (p *MMToolProvider) toolSearchSavedAnswers(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args SearchSavedAnswersArgs
+	if err := argsGetter(&args); err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool %s: %w", SearchSavedAnswersToolName, err)
+	}
+
+	if llmContext.RequestingUser == nil {
+		return "Error: unable to identify the user", fmt.Errorf("no requesting user for tool %s", SearchSavedAnswersToolName)
+	}
+	if len(args.Terms) > savedanswers.MaxTermsLength {
+		return fmt.Sprintf("Error: the terms can be at most %d characters", savedanswers.MaxTermsLength), fmt.Errorf("terms too long")
+	}
+
+	answers, _, err := p.savedAnswers.List(savedanswers.Query{
+		UserID:  llmContext.RequestingUser.Id,
+		Tag:     args.Tag,
+		Terms:   args.Terms,
+		PerPage: maxSavedAnswersResults,
+	})
+	if err != nil {
+		return "Error: unable to read the saved answers", fmt.Errorf("failed to list saved answers: %w", err)
+	}
+	if len(answers) == 0 {
+		return "No saved answers found.", nil
+	}
+
+	var result strings.Builder
+	for i, answer := range answers {
+		if i > 0 {
+			result.WriteString("\n---\n\n")
+		}
+		result.WriteString(fmt.Sprintf("Saved on %s", model.GetTimeForMillis(answer.UpdateAt).UTC().Format(time.DateOnly)))
+		if len(answer.Tags) > 0 {
+			result.WriteString(fmt.Sprintf(", tags: %s", strings.Join(answer.Tags, ", ")))
+		}
+		result.WriteString("\n")
+		result.WriteString(answer.Message)
+		result.WriteString("\n")
+	}
+
+	return result.String(), nil
+}
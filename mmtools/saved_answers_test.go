@@ -0,0 +1,50 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/savedanswers"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSavedAnswers struct {
+	answers []savedanswers.SavedAnswer
+	query   savedanswers.Query
+}
+
+func (f *fakeSavedAnswers) List(query savedanswers.Query) ([]savedanswers.SavedAnswer, bool, error) {
+	f.query = query
+	return f.answers, false, nil
+}
+
+func TestToolSearchSavedAnswers(t *testing.T) {
+	savedAt := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC).UnixMilli()
+	service := &fakeSavedAnswers{answers: []savedanswers.SavedAnswer{
+		{Message: "Run `make deploy` from the release branch.", Tags: []string{"deploy", "release"}, UpdateAt: savedAt},
+		{Message: "The VPN config is in the wiki.", UpdateAt: savedAt},
+	}}
+	provider := NewMMToolProvider(nil, nil, nil, nil, nil)
+	provider.SetSavedAnswers(service)
+
+	llmContext := llm.NewContext()
+	llmContext.RequestingUser = &model.User{Id: "user1"}
+	result, err := provider.toolSearchSavedAnswers(llmContext, func(args any) error {
+		*args.(*SearchSavedAnswersArgs) = SearchSavedAnswersArgs{Terms: "deploy", Tag: "release"}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, savedanswers.Query{UserID: "user1", Tag: "release", Terms: "deploy", PerPage: maxSavedAnswersResults}, service.query)
+	require.Contains(t, result, "Saved on 2025-03-10, tags: deploy, release\nRun `make deploy` from the release branch.\n")
+	require.Contains(t, result, "Saved on 2025-03-10\nThe VPN config is in the wiki.\n")
+
+	service.answers = nil
+	result, err = provider.toolSearchSavedAnswers(llmContext, func(args any) error { return nil })
+	require.NoError(t, err)
+	require.Equal(t, "No saved answers found.", result)
+}
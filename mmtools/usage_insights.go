@@ -0,0 +1,147 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	QueryUsageInsightsToolName = "query_usage_insights"
+
+	// defaultInsightsDays is the range of the queries without dates, ending today
+	defaultInsightsDays = 7
+	// maxInsightsDays is the longest range of a query
+	maxInsightsDays = 366
+)
+
+// UsageInsightsService computes aggregates of the plugin usage, see analytics.Service.
+type UsageInsightsService interface {
+	QueryInsights(query analytics.InsightsQuery) ([]analytics.InsightsRow, error)
+}
+
+type QueryUsageInsightsArgs struct {
+	Metric    string `jsonschema_description:"The metric to compute: requests (number of AI responses), active_users (distinct users), tool_calls, failures or average_latency_ms."`
+	GroupBy   string `jsonschema_description:"Group the results by feature, team, bot or day. Leave empty for the total."`
+	Feature   string `jsonschema_description:"Only count this feature: direct_message, mention, thread_analysis (thread summaries and analysis), channel_analysis, channel_interval (channel summaries since a time), search, draft_reply (replies drafted for the user), reaction_trigger (posts translated or explained from an emoji reaction) explain_post (posts explained from the post menu) or mentions_summary (summaries of the mentions of a user). Leave empty for all features."`
+	StartDate string `jsonschema_description:"The first day included, in the format YYYY-MM-DD (UTC). Defaults to 7 days before the end date."`
+	EndDate   string `jsonschema_description:"The last day included, in the format YYYY-MM-DD (UTC). Defaults to today."`
+	Limit     int    `jsonschema_description:"The number of groups to return, the largest first. Defaults to 10, at most 50."`
+}
+
+// SetUsageInsights enables the query_usage_insights tool for the bots with usage insights enabled.
+func (p *MMToolProvider) SetUsageInsights(insights UsageInsightsService) {
+	p.insights = insights
+}
+
+func (p *MMToolProvider) usageInsightsTool() llm.Tool {
+	return llm.Tool{
+		Name:        QueryUsageInsightsToolName,
+		Description: "Query aggregated usage statistics of the AI plugin, such as the number of requests per team or the most used features over a period. Only counts are available, never the content of messages. Use it to answer questions about how the AI plugin is used.",
+		Schema:      llm.NewJSONSchemaFromStruct[QueryUsageInsightsArgs](),
+		Resolver:    p.toolQueryUsageInsights,
+	}
+}
+
+// parseInsightsRange returns the range in milliseconds from the start of the start date to the
+// end of the end date, both included
+func parseInsightsRange(startDate, endDate string, now time.Time) (int64, int64, error) {
+	end := now.UTC().Truncate(24 * time.Hour)
+	if endDate != "" {
+		parsed, err := time.Parse(time.DateOnly, endDate)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid end date %q", endDate)
+		}
+		end = parsed
+	}
+
+	start := end.AddDate(0, 0, -(defaultInsightsDays - 1))
+	if startDate != "" {
+		parsed, err := time.Parse(time.DateOnly, startDate)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid start date %q", startDate)
+		}
+		start = parsed
+	}
+
+	end = end.AddDate(0, 0, 1)
+	if !start.Before(end) {
+		return 0, 0, fmt.Errorf("the start date must not be after the end date")
+	}
+	if end.Sub(start) > maxInsightsDays*24*time.Hour {
+		return 0, 0, fmt.Errorf("the range can be at most %d days", maxInsightsDays)
+	}
+
+	return start.UnixMilli(), end.UnixMilli(), nil
+}
+
+func (p *MMToolProvider) toolQueryUsageInsights(llmContext *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args QueryUsageInsightsArgs
+	if err := argsGetter(&args); err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool %s: %w", QueryUsageInsightsToolName, err)
+	}
+
+	// The usage of all teams is only for system admins
+	if llmContext.RequestingUser == nil || !p.pluginAPI.HasPermissionTo(llmContext.RequestingUser.Id, model.PermissionManageSystem) {
+		return "Error: only system admins can query the usage statistics", fmt.Errorf("user may not use tool %s", QueryUsageInsightsToolName)
+	}
+
+	since, until, err := parseInsightsRange(args.StartDate, args.EndDate, time.Now())
+	if err != nil {
+		return "Error: " + err.Error(), err
+	}
+
+	query := analytics.InsightsQuery{
+		Metric:  analytics.Metric(strings.ToLower(strings.TrimSpace(args.Metric))),
+		GroupBy: analytics.Dimension(strings.ToLower(strings.TrimSpace(args.GroupBy))),
+		Feature: strings.ToLower(strings.TrimSpace(args.Feature)),
+		Since:   since,
+		Until:   until,
+		Limit:   args.Limit,
+	}
+	if err = query.Validate(); err != nil {
+		return "Error: " + err.Error(), err
+	}
+
+	rows, err := p.insights.QueryInsights(query)
+	if err != nil {
+		return "Error: unable to query the usage statistics", fmt.Errorf("failed to query usage insights: %w", err)
+	}
+
+	period := fmt.Sprintf("%s to %s (UTC)", time.UnixMilli(since).UTC().Format(time.DateOnly), time.UnixMilli(until-1).UTC().Format(time.DateOnly))
+	if len(rows) == 0 {
+		return fmt.Sprintf("No usage from %s.", period), nil
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%s from %s", query.Metric, period))
+	if query.Feature != "" {
+		result.WriteString(fmt.Sprintf(", feature %s", query.Feature))
+	}
+	if query.GroupBy == analytics.DimensionNone {
+		result.WriteString(fmt.Sprintf(": %s\n", formatInsightsValue(rows[0].Value)))
+		return result.String(), nil
+	}
+
+	result.WriteString(fmt.Sprintf(", by %s:\n", query.GroupBy))
+	for _, row := range rows {
+		group := row.Group
+		if query.GroupBy == analytics.DimensionTeam && group == analytics.TeamIDDirect {
+			group = "Direct and group messages"
+		}
+		result.WriteString(fmt.Sprintf("- %s: %s\n", group, formatInsightsValue(row.Value)))
+	}
+
+	return result.String(), nil
+}
+
+func formatInsightsValue(value float64) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", value), ".0")
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUsageInsights struct {
+	rows  []analytics.InsightsRow
+	query analytics.InsightsQuery
+}
+
+func (f *fakeUsageInsights) QueryInsights(query analytics.InsightsQuery) ([]analytics.InsightsRow, error) {
+	f.query = query
+	return f.rows, nil
+}
+
+func TestParseInsightsRange(t *testing.T) {
+	now := time.Date(2025, 3, 12, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		startDate     string
+		endDate       string
+		expectedSince time.Time
+		expectedUntil time.Time
+		expectError   bool
+	}{
+		{
+			name:          "defaults to the last 7 days",
+			expectedSince: time.Date(2025, 3, 6, 0, 0, 0, 0, time.UTC),
+			expectedUntil: time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "both dates are included",
+			startDate:     "2025-03-03",
+			endDate:       "2025-03-09",
+			expectedSince: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+			expectedUntil: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		},
+		{name: "invalid date", startDate: "last week", expectError: true},
+		{name: "start after end", startDate: "2025-03-10", endDate: "2025-03-09", expectError: true},
+		{name: "range too long", startDate: "2020-01-01", expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			since, until, err := parseInsightsRange(test.startDate, test.endDate, now)
+			if test.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectedSince.UnixMilli(), since)
+			require.Equal(t, test.expectedUntil.UnixMilli(), until)
+		})
+	}
+}
+
+func TestToolQueryUsageInsights(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           QueryUsageInsightsArgs
+		admin          bool
+		rows           []analytics.InsightsRow
+		expectError    bool
+		expectedResult string
+	}{
+		{
+			name:  "grouped by team",
+			args:  QueryUsageInsightsArgs{Metric: "requests", GroupBy: "Team", Feature: "thread_analysis", StartDate: "2025-03-03", EndDate: "2025-03-09"},
+			admin: true,
+			rows: []analytics.InsightsRow{
+				{Group: "Engineering", Value: 42},
+				{Group: analytics.TeamIDDirect, Value: 7},
+			},
+			expectedResult: "requests from 2025-03-03 to 2025-03-09 (UTC), feature thread_analysis, by team:\n- Engineering: 42\n- Direct and group messages: 7\n",
+		},
+		{
+			name:           "total",
+			args:           QueryUsageInsightsArgs{Metric: "average_latency_ms", StartDate: "2025-03-03", EndDate: "2025-03-09"},
+			admin:          true,
+			rows:           []analytics.InsightsRow{{Value: 1234.56}},
+			expectedResult: "average_latency_ms from 2025-03-03 to 2025-03-09 (UTC): 1234.6\n",
+		},
+		{
+			name:           "no usage",
+			args:           QueryUsageInsightsArgs{Metric: "requests", GroupBy: "bot", StartDate: "2025-03-03", EndDate: "2025-03-09"},
+			admin:          true,
+			expectedResult: "No usage from 2025-03-03 to 2025-03-09 (UTC).",
+		},
+		{
+			name:           "unknown metric",
+			args:           QueryUsageInsightsArgs{Metric: "messages"},
+			admin:          true,
+			expectError:    true,
+			expectedResult: `Error: unknown metric "messages"`,
+		},
+		{
+			name:           "not a system admin",
+			args:           QueryUsageInsightsArgs{Metric: "requests"},
+			expectError:    true,
+			expectedResult: "Error: only system admins can query the usage statistics",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			api := mocks.NewMockClient(t)
+			api.On("HasPermissionTo", "user1", model.PermissionManageSystem).Return(test.admin)
+			insights := &fakeUsageInsights{rows: test.rows}
+			provider := NewMMToolProvider(api, nil, nil, nil, nil)
+			provider.SetUsageInsights(insights)
+
+			llmContext := llm.NewContext()
+			llmContext.RequestingUser = &model.User{Id: "user1"}
+			result, err := provider.toolQueryUsageInsights(llmContext, func(args any) error {
+				*args.(*QueryUsageInsightsArgs) = test.args
+				return nil
+			})
+			require.Equal(t, test.expectedResult, result)
+			if test.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, analytics.Metric(test.args.Metric), insights.query.Metric)
+		})
+	}
+}
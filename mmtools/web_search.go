@@ -165,6 +165,17 @@ func (s *webSearchService) Tool() *llm.Tool {
 			s.httpClient,
 			s.logger,
 		)
+	case "searxng":
+		if webCfg.SearxNG.APIURL == "" {
+			s.logWarn("web search misconfigured: missing SearxNG API URL")
+			return nil
+		}
+		s.provider = websearch.NewSearxNGProvider(
+			webCfg.SearxNG.APIURL,
+			webCfg.SearxNG.APIKey,
+			s.httpClient,
+			s.logger,
+		)
 	default:
 		s.logDebug("web search provider not supported", "provider", webCfg.Provider)
 		return nil
@@ -203,6 +214,10 @@ func (s *webSearchService) SourceTool(bot *bots.Bot) *llm.Tool {
 		if webCfg.Brave.APIKey == "" {
 			return nil
 		}
+	case "searxng":
+		if webCfg.SearxNG.APIURL == "" {
+			return nil
+		}
 	default:
 		return nil
 	}
@@ -297,6 +312,8 @@ func (s *webSearchService) resolve(llmContext *llm.Context, argsGetter llm.ToolA
 		resultLimit = webCfg.Google.ResultLimit
 	case "brave":
 		resultLimit = webCfg.Brave.ResultLimit
+	case "searxng":
+		resultLimit = webCfg.SearxNG.ResultLimit
 	}
 
 	// Perform the search
@@ -556,7 +573,7 @@ func (s *webSearchService) resolveSource(bot *bots.Bot, llmContext *llm.Context,
 	}
 
 	// Perform recursive summarization
-	summary, err := s.summarizeContent(bot, textContent)
+	summary, err := s.summarizeContent(context.Background(), bot, textContent)
 	if err != nil {
 		s.logWarn("recursive summarization failed, falling back to raw content with warnings", "error", err)
 		return s.wrapSourceContentWithContext(textContent, matchedResult, llmContext), nil
@@ -565,7 +582,7 @@ func (s *webSearchService) resolveSource(bot *bots.Bot, llmContext *llm.Context,
 	return s.formatSummarizedContent(summary, matchedResult), nil
 }
 
-func (s *webSearchService) summarizeContent(bot *bots.Bot, content string) (string, error) {
+func (s *webSearchService) summarizeContent(ctx context.Context, bot *bots.Bot, content string) (string, error) {
 	if bot == nil {
 		return "", errors.New("bot instance is nil")
 	}
@@ -596,7 +613,7 @@ func (s *webSearchService) summarizeContent(bot *bots.Bot, content string) (stri
 	}
 
 	// Use a reasonable token limit for the summary (e.g. 4000 tokens)
-	return languageModel.ChatCompletionNoStream(req, llm.WithMaxGeneratedTokens(4000))
+	return languageModel.ChatCompletionNoStream(ctx, req, llm.WithMaxGeneratedTokens(4000))
 }
 
 func (s *webSearchService) formatSummarizedContent(summary string, matchedResult *WebSearchResult) string {
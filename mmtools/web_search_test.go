@@ -5,6 +5,7 @@ package mmtools
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"strings"
@@ -410,6 +411,23 @@ func TestWebSearchService(t *testing.T) {
 		require.Contains(t, tool.Description, "limited to 3 searches")
 		require.Contains(t, tool.Description, "DO NOT repeat a search query")
 	})
+
+	t.Run("returns tool for a self-hosted SearxNG instance", func(t *testing.T) {
+		cfg := &config.Config{
+			WebSearch: config.WebSearchConfig{
+				Enabled:  true,
+				Provider: "searxng",
+			},
+		}
+		service := NewWebSearchService(func() *config.Config { return cfg }, &mockLogger{}, http.DefaultClient)
+
+		require.Nil(t, service.Tool(), "Should return nil without an API URL")
+		require.Nil(t, service.SourceTool(nil), "Should return nil without an API URL")
+
+		cfg.WebSearch.SearxNG.APIURL = "http://searxng.internal:8080"
+		require.NotNil(t, service.Tool())
+		require.NotNil(t, service.SourceTool(nil))
+	})
 }
 
 func TestWebSearchResetBehavior(t *testing.T) {
@@ -614,10 +632,10 @@ func TestWebSearchSourceWhitelist(t *testing.T) {
 
 type mockLanguageModel struct{}
 
-func (m *mockLanguageModel) ChatCompletion(conversation llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+func (m *mockLanguageModel) ChatCompletion(ctx context.Context, conversation llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
 	return nil, nil
 }
-func (m *mockLanguageModel) ChatCompletionNoStream(conversation llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
+func (m *mockLanguageModel) ChatCompletionNoStream(ctx context.Context, conversation llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
 	return "Summarized content", nil
 }
 func (m *mockLanguageModel) CountTokens(text string) int { return 0 }
@@ -0,0 +1,117 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mmtools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+const (
+	// WolframAlphaToolName is the name of the Wolfram|Alpha query tool
+	WolframAlphaToolName = "wolfram_alpha"
+
+	// wolframAlphaAPIURL is the Wolfram|Alpha LLM API, which answers in plain text meant for models
+	wolframAlphaAPIURL     = "https://www.wolframalpha.com/api/v1/llm-api"
+	wolframAlphaTimeout    = 30 * time.Second
+	wolframAlphaMaxChars   = 6000
+	wolframAlphaMaxReadLen = 64 * 1024
+)
+
+type WolframAlphaArgs struct {
+	Input string `jsonschema_description:"The query in English, simplified to keywords where possible. Examples: 'integrate x^2 sin(x)', '10 km in miles', 'days between March 3 2024 and July 9 2024', 'population of France'."`
+}
+
+// SetWolframAlpha enables the wolfram_alpha tool when the configuration returned by getConfig is
+// enabled and has an AppID.
+func (p *MMToolProvider) SetWolframAlpha(getConfig func() config.WolframAlphaConfig) {
+	p.wolframAlphaConfig = getConfig
+}
+
+func (p *MMToolProvider) wolframAlphaEnabled() bool {
+	if p.wolframAlphaConfig == nil || p.httpClient == nil {
+		return false
+	}
+	cfg := p.wolframAlphaConfig()
+	return cfg.Enabled && strings.TrimSpace(cfg.AppID) != ""
+}
+
+func (p *MMToolProvider) wolframAlphaTool() llm.Tool {
+	return llm.Tool{
+		Name:        WolframAlphaToolName,
+		Description: "Query Wolfram|Alpha for math beyond simple arithmetic, unit and currency conversions, date calculations and scientific or geographic facts. Prefer the calculate tool for plain arithmetic on numbers from the conversation.",
+		Schema:      llm.NewJSONSchemaFromStruct[WolframAlphaArgs](),
+		Resolver:    p.toolWolframAlpha,
+	}
+}
+
+func (p *MMToolProvider) toolWolframAlpha(_ *llm.Context, argsGetter llm.ToolArgumentGetter) (string, error) {
+	var args WolframAlphaArgs
+	if err := argsGetter(&args); err != nil {
+		return "invalid parameters to function", fmt.Errorf("failed to get arguments for tool wolfram_alpha: %w", err)
+	}
+
+	input := strings.TrimSpace(args.Input)
+	if input == "" {
+		return "the input must not be empty", errors.New("empty wolfram_alpha input")
+	}
+	if !p.wolframAlphaEnabled() {
+		return "Wolfram|Alpha is not configured", errors.New("wolfram_alpha is not configured")
+	}
+	cfg := p.wolframAlphaConfig()
+
+	apiURL := wolframAlphaAPIURL
+	if cfg.APIURL != "" {
+		apiURL = cfg.APIURL
+	}
+	query := url.Values{}
+	query.Set("appid", strings.TrimSpace(cfg.AppID))
+	query.Set("input", input)
+	query.Set("maxchars", fmt.Sprint(wolframAlphaMaxChars))
+
+	ctx, cancel := context.WithTimeout(context.Background(), wolframAlphaTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return "unable to create request", err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		// The error contains the URL, and so the AppID
+		return "unable to reach Wolfram|Alpha", errors.New("wolfram_alpha request failed")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, wolframAlphaMaxReadLen))
+	if err != nil {
+		return "unable to read the Wolfram|Alpha response", fmt.Errorf("failed to read wolfram_alpha response: %w", err)
+	}
+	text := strings.TrimSpace(string(body))
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return text, nil
+	case resp.StatusCode == http.StatusNotImplemented:
+		// Wolfram|Alpha didn't understand the input, the body suggests how to rephrase it
+		if text == "" {
+			text = "Wolfram|Alpha did not understand the input, try rephrasing it"
+		}
+		return text, nil
+	case resp.StatusCode == http.StatusForbidden:
+		return "Wolfram|Alpha rejected the request", errors.New("wolfram_alpha rejected the AppID")
+	}
+
+	return "Wolfram|Alpha failed to answer", fmt.Errorf("wolfram_alpha request failed: %s", resp.Status)
+}
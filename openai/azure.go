@@ -0,0 +1,86 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package openai
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/azure"
+	"github.com/openai/openai-go/v2/option"
+)
+
+// API versions of the Azure OpenAI endpoints listing deployments and models. Deployments are
+// only listed by the versions preceding 2023-05-15.
+const (
+	azureDeploymentsAPIVersion = "2022-12-01"
+	azureModelsAPIVersion      = "2024-10-21"
+)
+
+type azureDeployment struct {
+	ID     string `json:"id"`
+	Model  string `json:"model"`
+	Status string `json:"status"`
+}
+
+type azureModel struct {
+	ID           string          `json:"id"`
+	Capabilities map[string]bool `json:"capabilities"`
+}
+
+type azureList[T any] struct {
+	Data []T `json:"data"`
+}
+
+// FetchAzureDeployments lists the deployments of an Azure OpenAI resource as models, as Azure
+// requests name a deployment instead of a model. Deployments that are not ready are left out.
+func FetchAzureDeployments(apiKey string, apiURL string, httpClient *http.Client) ([]llm.ModelInfo, error) {
+	newClient := func(apiVersion string) openai.Client {
+		return openai.NewClient(
+			azure.WithEndpoint(strings.TrimSuffix(apiURL, "/"), apiVersion),
+			azure.WithAPIKey(apiKey),
+			option.WithHTTPClient(httpClient),
+		)
+	}
+
+	var deployments azureList[azureDeployment]
+	deploymentsClient := newClient(azureDeploymentsAPIVersion)
+	if err := deploymentsClient.Get(context.Background(), "deployments", nil, &deployments); err != nil {
+		return nil, err
+	}
+
+	var models azureList[azureModel]
+	modelsClient := newClient(azureModelsAPIVersion)
+	if err := modelsClient.Get(context.Background(), "models", nil, &models); err != nil {
+		return nil, err
+	}
+	capabilities := make(map[string][]string, len(models.Data))
+	for _, model := range models.Data {
+		for _, capability := range []string{llm.DeploymentCapabilityChatCompletion, llm.DeploymentCapabilityEmbeddings} {
+			if model.Capabilities[capability] {
+				capabilities[model.ID] = append(capabilities[model.ID], capability)
+			}
+		}
+	}
+
+	result := make([]llm.ModelInfo, 0, len(deployments.Data))
+	for _, deployment := range deployments.Data {
+		if deployment.Status != "succeeded" {
+			continue
+		}
+		result = append(result, llm.ModelInfo{
+			ID:          deployment.ID,
+			DisplayName: deployment.ID + " (" + deployment.Model + ")",
+			Deployment: &llm.DeploymentInfo{
+				Model:        deployment.Model,
+				Capabilities: capabilities[deployment.Model],
+			},
+		})
+	}
+
+	return result, nil
+}
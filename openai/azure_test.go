@@ -0,0 +1,54 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchAzureDeployments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-key", r.Header.Get("Api-Key"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/openai/deployments":
+			assert.Equal(t, azureDeploymentsAPIVersion, r.URL.Query().Get("api-version"))
+			_, _ = w.Write([]byte(`{"data": [
+				{"id": "chat", "model": "gpt-4o", "status": "succeeded"},
+				{"id": "embed", "model": "text-embedding-3-small", "status": "succeeded"},
+				{"id": "pending", "model": "gpt-4o", "status": "running"}
+			]}`))
+		case "/openai/models":
+			assert.Equal(t, azureModelsAPIVersion, r.URL.Query().Get("api-version"))
+			_, _ = w.Write([]byte(`{"data": [
+				{"id": "gpt-4o", "capabilities": {"chat_completion": true, "embeddings": false}},
+				{"id": "text-embedding-3-small", "capabilities": {"chat_completion": false, "embeddings": true}}
+			]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	models, err := FetchAzureDeployments("test-key", server.URL+"/", server.Client())
+	require.NoError(t, err)
+	assert.Equal(t, []llm.ModelInfo{
+		{
+			ID:          "chat",
+			DisplayName: "chat (gpt-4o)",
+			Deployment:  &llm.DeploymentInfo{Model: "gpt-4o", Capabilities: []string{llm.DeploymentCapabilityChatCompletion}},
+		},
+		{
+			ID:          "embed",
+			DisplayName: "embed (text-embedding-3-small)",
+			Deployment:  &llm.DeploymentInfo{Model: "text-embedding-3-small", Capabilities: []string{llm.DeploymentCapabilityEmbeddings}},
+		},
+	}, models)
+}
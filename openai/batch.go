@@ -0,0 +1,248 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/openai/openai-go/v2"
+)
+
+// maxBatchLineSize bounds the size of one line of a batch output file
+const maxBatchLineSize = 16 * 1024 * 1024
+
+type batchInputLine struct {
+	CustomID string `json:"custom_id"`
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	Body     any    `json:"body"`
+}
+
+type batchOutputLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// buildBatchInput returns the JSONL input file of a batch and the endpoint its requests are sent to
+func (s *OpenAI) buildBatchInput(batchType string, requests []llm.BatchRequest) ([]byte, openai.BatchNewParamsEndpoint, error) {
+	var endpoint openai.BatchNewParamsEndpoint
+	switch batchType {
+	case llm.BatchTypeCompletion:
+		endpoint = openai.BatchNewParamsEndpointV1ChatCompletions
+	case llm.BatchTypeEmbedding:
+		endpoint = openai.BatchNewParamsEndpointV1Embeddings
+	default:
+		return nil, "", fmt.Errorf("%w: %s", llm.ErrBatchUnsupported, batchType)
+	}
+
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, request := range requests {
+		line := batchInputLine{
+			CustomID: request.CustomID,
+			Method:   "POST",
+			URL:      string(endpoint),
+		}
+		if batchType == llm.BatchTypeEmbedding {
+			line.Body = s.batchEmbeddingParams(request)
+		} else {
+			line.Body = s.batchCompletionParams(request)
+		}
+		if err := encoder.Encode(line); err != nil {
+			return nil, "", fmt.Errorf("failed to encode batch request %s: %w", request.CustomID, err)
+		}
+	}
+
+	return input.Bytes(), endpoint, nil
+}
+
+func (s *OpenAI) batchCompletionParams(request llm.BatchRequest) openai.ChatCompletionNewParams {
+	maxTokens := request.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = s.config.OutputTokenLimit
+	}
+	params := s.completionRequestFromConfig(llm.LanguageModelConfig{
+		Model:              s.config.DefaultModel,
+		MaxGeneratedTokens: maxTokens,
+	})
+
+	if request.System != "" {
+		params.Messages = append(params.Messages, openai.SystemMessage(request.System))
+	}
+	params.Messages = append(params.Messages, openai.UserMessage(request.Prompt))
+
+	return params
+}
+
+func (s *OpenAI) batchEmbeddingParams(request llm.BatchRequest) openai.EmbeddingNewParams {
+	model := s.config.EmbeddingModel
+	if model == "" {
+		model = openai.EmbeddingModelTextEmbedding3Large
+	}
+	params := openai.EmbeddingNewParams{
+		Input: openai.EmbeddingNewParamsInputUnion{
+			OfString: openai.String(request.Prompt),
+		},
+		Model: getEmbeddingModelConstant(model),
+	}
+	if s.config.EmbeddingDimensions > 0 {
+		params.Dimensions = openai.Int(int64(s.config.EmbeddingDimensions))
+	}
+	return params
+}
+
+// parseBatchOutput reads the results from a JSONL output or error file of a batch
+func parseBatchOutput(batchType string, output io.Reader) ([]llm.BatchResult, error) {
+	var results []llm.BatchResult
+
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBatchLineSize)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var line batchOutputLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("failed to decode batch output: %w", err)
+		}
+
+		result := llm.BatchResult{CustomID: line.CustomID}
+		switch {
+		case line.Error != nil:
+			result.Error = fmt.Sprintf("%s: %s", line.Error.Code, line.Error.Message)
+		case line.Response == nil:
+			result.Error = "no response"
+		case line.Response.StatusCode != 200:
+			result.Error = fmt.Sprintf("status %d: %s", line.Response.StatusCode, string(line.Response.Body))
+		case batchType == llm.BatchTypeEmbedding:
+			var response openai.CreateEmbeddingResponse
+			if err := json.Unmarshal(line.Response.Body, &response); err != nil || len(response.Data) == 0 {
+				result.Error = "invalid embedding response"
+				break
+			}
+			result.Embedding = make([]float32, len(response.Data[0].Embedding))
+			for i, v := range response.Data[0].Embedding {
+				result.Embedding[i] = float32(v)
+			}
+		default:
+			var response openai.ChatCompletion
+			if err := json.Unmarshal(line.Response.Body, &response); err != nil || len(response.Choices) == 0 {
+				result.Error = "invalid completion response"
+				break
+			}
+			result.Output = response.Choices[0].Message.Content
+		}
+		results = append(results, result)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch output: %w", err)
+	}
+
+	return results, nil
+}
+
+// SubmitBatch uploads the requests as a batch input file and creates a batch processing it
+func (s *OpenAI) SubmitBatch(ctx context.Context, batchType string, requests []llm.BatchRequest) (string, error) {
+	input, endpoint, err := s.buildBatchInput(batchType, requests)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := s.client.Files.New(ctx, openai.FileNewParams{
+		File:    openai.File(bytes.NewReader(input), "batch.jsonl", "application/jsonl"),
+		Purpose: openai.FilePurposeBatch,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload batch input: %w", err)
+	}
+
+	batch, err := s.client.Batches.New(ctx, openai.BatchNewParams{
+		CompletionWindow: openai.BatchNewParamsCompletionWindow24h,
+		Endpoint:         endpoint,
+		InputFileID:      file.ID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	return batch.ID, nil
+}
+
+func (s *OpenAI) BatchStatus(ctx context.Context, batchID string) (string, error) {
+	batch, err := s.client.Batches.Get(ctx, batchID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get batch: %w", err)
+	}
+
+	switch batch.Status {
+	case openai.BatchStatusCompleted:
+		return llm.BatchStatusCompleted, nil
+	case openai.BatchStatusFailed:
+		return llm.BatchStatusFailed, nil
+	case openai.BatchStatusExpired:
+		return llm.BatchStatusExpired, nil
+	case openai.BatchStatusCancelled:
+		return llm.BatchStatusCanceled, nil
+	default:
+		return llm.BatchStatusInProgress, nil
+	}
+}
+
+// BatchResults returns the results of the output file and the errors of the error file of the batch
+func (s *OpenAI) BatchResults(ctx context.Context, batchID string) ([]llm.BatchResult, error) {
+	batch, err := s.client.Batches.Get(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch: %w", err)
+	}
+
+	endpointType := llm.BatchTypeCompletion
+	if batch.Endpoint == string(openai.BatchNewParamsEndpointV1Embeddings) {
+		endpointType = llm.BatchTypeEmbedding
+	}
+
+	var results []llm.BatchResult
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		fileResults, err := s.batchFileResults(ctx, endpointType, fileID)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, fileResults...)
+	}
+
+	return results, nil
+}
+
+func (s *OpenAI) batchFileResults(ctx context.Context, batchType, fileID string) ([]llm.BatchResult, error) {
+	resp, err := s.client.Files.Content(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download batch output: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return parseBatchOutput(batchType, resp.Body)
+}
+
+func (s *OpenAI) CancelBatch(ctx context.Context, batchID string) error {
+	if _, err := s.client.Batches.Cancel(ctx, batchID); err != nil {
+		return fmt.Errorf("failed to cancel batch: %w", err)
+	}
+	return nil
+}
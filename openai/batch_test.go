@@ -0,0 +1,144 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/openai/openai-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildBatchInput(t *testing.T) {
+	client := &OpenAI{config: Config{DefaultModel: "gpt-4.1", OutputTokenLimit: 1000}}
+	requests := []llm.BatchRequest{
+		{CustomID: "a", System: "Summarize the thread", Prompt: "first thread"},
+		{CustomID: "b", Prompt: "second thread", MaxTokens: 200},
+	}
+
+	tests := []struct {
+		name           string
+		batchType      string
+		expectEndpoint openai.BatchNewParamsEndpoint
+		check          func(t *testing.T, body map[string]any, index int)
+		expectErr      bool
+	}{
+		{
+			name:           "completions",
+			batchType:      llm.BatchTypeCompletion,
+			expectEndpoint: openai.BatchNewParamsEndpointV1ChatCompletions,
+			check: func(t *testing.T, body map[string]any, index int) {
+				assert.Equal(t, "gpt-4.1", body["model"])
+				messages := body["messages"].([]any)
+				if index == 0 {
+					assert.Len(t, messages, 2)
+					assert.EqualValues(t, 1000, body["max_completion_tokens"])
+				} else {
+					assert.Len(t, messages, 1)
+					assert.EqualValues(t, 200, body["max_completion_tokens"])
+				}
+			},
+		},
+		{
+			name:           "embeddings use the default embedding model",
+			batchType:      llm.BatchTypeEmbedding,
+			expectEndpoint: openai.BatchNewParamsEndpointV1Embeddings,
+			check: func(t *testing.T, body map[string]any, index int) {
+				assert.Equal(t, "text-embedding-3-large", body["model"])
+				assert.Equal(t, requests[index].Prompt, body["input"])
+			},
+		},
+		{
+			name:      "unsupported type",
+			batchType: "image",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			input, endpoint, err := client.buildBatchInput(tc.batchType, requests)
+			if tc.expectErr {
+				assert.ErrorIs(t, err, llm.ErrBatchUnsupported)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectEndpoint, endpoint)
+
+			lines := strings.Split(strings.TrimSpace(string(input)), "\n")
+			require.Len(t, lines, len(requests))
+			for i, line := range lines {
+				var decoded struct {
+					CustomID string         `json:"custom_id"`
+					Method   string         `json:"method"`
+					URL      string         `json:"url"`
+					Body     map[string]any `json:"body"`
+				}
+				require.NoError(t, json.Unmarshal([]byte(line), &decoded))
+				assert.Equal(t, requests[i].CustomID, decoded.CustomID)
+				assert.Equal(t, "POST", decoded.Method)
+				assert.Equal(t, string(tc.expectEndpoint), decoded.URL)
+				tc.check(t, decoded.Body, i)
+			}
+		})
+	}
+}
+
+func TestParseBatchOutput(t *testing.T) {
+	tests := []struct {
+		name      string
+		batchType string
+		output    string
+		expect    []llm.BatchResult
+	}{
+		{
+			name:      "completion results and errors",
+			batchType: llm.BatchTypeCompletion,
+			output: `{"custom_id":"a","response":{"status_code":200,"body":{"choices":[{"message":{"role":"assistant","content":"summary"}}]}}}
+
+{"custom_id":"b","response":{"status_code":400,"body":{"error":"bad"}}}
+{"custom_id":"c","error":{"code":"batch_expired","message":"not processed in time"}}
+`,
+			expect: []llm.BatchResult{
+				{CustomID: "a", Output: "summary"},
+				{CustomID: "b", Error: `status 400: {"error":"bad"}`},
+				{CustomID: "c", Error: "batch_expired: not processed in time"},
+			},
+		},
+		{
+			name:      "embedding results",
+			batchType: llm.BatchTypeEmbedding,
+			output:    `{"custom_id":"a","response":{"status_code":200,"body":{"data":[{"embedding":[0.5,-1]}]}}}`,
+			expect: []llm.BatchResult{
+				{CustomID: "a", Embedding: []float32{0.5, -1}},
+			},
+		},
+		{
+			name:      "missing data",
+			batchType: llm.BatchTypeEmbedding,
+			output:    `{"custom_id":"a","response":{"status_code":200,"body":{"data":[]}}}`,
+			expect: []llm.BatchResult{
+				{CustomID: "a", Error: "invalid embedding response"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := parseBatchOutput(tc.batchType, bytes.NewBufferString(tc.output))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, results)
+		})
+	}
+
+	t.Run("invalid output", func(t *testing.T) {
+		_, err := parseBatchOutput(llm.BatchTypeCompletion, bytes.NewBufferString("not json"))
+		assert.Error(t, err)
+	})
+}
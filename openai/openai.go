@@ -4,16 +4,14 @@
 package openai
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"image"
-	"image/png"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -45,6 +43,11 @@ type Config struct {
 	ReasoningEffort      string        `json:"reasoningEffort"`
 	DisableStreamOptions bool          `json:"disableStreamOptions"` // For OpenAI-compatible APIs that don't support stream_options
 	UseMaxTokens         bool          `json:"useMaxTokens"`         // Use max_tokens instead of max_completion_tokens for compatible APIs
+	ZeroDataRetention    bool          `json:"zeroDataRetention"`    // Send store=false so the provider does not retain requests and responses
+
+	// WebSearchContextSize and WebSearchUserLocation configure the native web_search tool
+	WebSearchContextSize  string                    `json:"webSearchContextSize"`
+	WebSearchUserLocation llm.WebSearchUserLocation `json:"webSearchUserLocation"`
 }
 
 type OpenAI struct {
@@ -375,22 +378,46 @@ func appendToolResultMessages(
 	return messages
 }
 
+// autoRunOutcome is the result of handling the tool calls of a model turn
+type autoRunOutcome int
+
+const (
+	// autoRunNeedsApproval means the tool calls were sent to the user for approval
+	autoRunNeedsApproval autoRunOutcome = iota
+	// autoRunContinue means the tools were run and the loop should continue
+	autoRunContinue
+	// autoRunStoppedEarly means the request spent its step budget and the stream should end
+	autoRunStoppedEarly
+)
+
 // handleAutoRunTools processes auto-run tools and updates the message history.
-// Returns true if tools were auto-run and the loop should continue.
 func (s *OpenAI) handleAutoRunTools(
 	messages *[]openai.ChatCompletionMessageParamUnion,
 	pendingToolCalls []llm.ToolCall,
 	cfg llm.LanguageModelConfig,
 	llmContext *llm.Context,
+	budget *llm.StepBudgetTracker,
 	output chan<- llm.TextStreamEvent,
-) bool {
+) autoRunOutcome {
 	if !llm.ShouldAutoRunTools(pendingToolCalls, cfg.AutoRunTools) {
 		// Manual approval needed
+		output <- llm.TextStreamEvent{
+			Type:  llm.EventTypeFinish,
+			Value: llm.FinishReasonToolUse,
+		}
 		output <- llm.TextStreamEvent{
 			Type:  llm.EventTypeToolCalls,
 			Value: pendingToolCalls,
 		}
-		return false
+		return autoRunNeedsApproval
+	}
+
+	if reason, exceeded := budget.Exceeded(); exceeded {
+		output <- llm.TextStreamEvent{
+			Type:  llm.EventTypeStoppedEarly,
+			Value: reason,
+		}
+		return autoRunStoppedEarly
 	}
 
 	// Check recursion depth
@@ -407,7 +434,7 @@ func (s *OpenAI) handleAutoRunTools(
 			Type:  llm.EventTypeError,
 			Value: errors.New("too many function calls"),
 		}
-		return false
+		return autoRunNeedsApproval
 	}
 
 	// Add assistant message with tool calls
@@ -418,46 +445,57 @@ func (s *OpenAI) handleAutoRunTools(
 		pendingToolCalls,
 		llmContext.Tools.ResolveTool,
 		llmContext,
+		output,
 	)
 	*messages = appendToolResultMessages(*messages, results)
 
-	return true
+	return autoRunContinue
 }
 
-func (s *OpenAI) streamResultToChannels(params openai.ChatCompletionNewParams, llmContext *llm.Context, cfg llm.LanguageModelConfig, output chan<- llm.TextStreamEvent) {
+func (s *OpenAI) streamResultToChannels(ctx context.Context, params openai.ChatCompletionNewParams, chain responsesChain, llmContext *llm.Context, cfg llm.LanguageModelConfig, output chan<- llm.TextStreamEvent) {
+	budget := llm.NewStepBudgetTracker(cfg.StepBudget)
+
 	// Route to Responses API or Completions API based on configuration
 	if s.config.UseResponsesAPI {
-		s.streamResponsesAPIToChannels(params, llmContext, cfg, output)
+		s.streamResponsesAPIToChannels(ctx, params, chain, llmContext, cfg, budget, output)
 	} else {
-		s.streamCompletionsAPIToChannels(params, llmContext, cfg, output)
+		s.streamCompletionsAPIToChannels(ctx, params, llmContext, cfg, budget, output)
 	}
 }
 
 // streamCompletionsAPIToChannels uses the original Completions API for streaming
-func (s *OpenAI) streamCompletionsAPIToChannels(initialParams openai.ChatCompletionNewParams, llmContext *llm.Context, cfg llm.LanguageModelConfig, output chan<- llm.TextStreamEvent) {
+func (s *OpenAI) streamCompletionsAPIToChannels(ctx context.Context, initialParams openai.ChatCompletionNewParams, llmContext *llm.Context, cfg llm.LanguageModelConfig, budget *llm.StepBudgetTracker, output chan<- llm.TextStreamEvent) {
 	params := initialParams
 
 	for {
-		ctx, cancel := context.WithCancelCause(context.Background())
+		streamCtx, cancel := context.WithCancelCause(ctx)
 
-		watchdog, watchdogDone := s.startWatchdog(ctx, cancel)
-		stream := s.client.Chat.Completions.NewStreaming(ctx, params)
+		watchdog, watchdogDone := s.startWatchdog(streamCtx, cancel)
+		stream := s.client.Chat.Completions.NewStreaming(streamCtx, params)
 
 		var toolsBuffer map[int]*ToolBufferElement
 		shouldContinue := false
+		finishReason := ""
+		refused := false
 
 		for stream.Next() {
 			chunk := stream.Current()
-			watchdog <- struct{}{}
+			// The watchdog stops once the request is canceled
+			select {
+			case watchdog <- struct{}{}:
+			case <-streamCtx.Done():
+			}
 
 			// Emit usage data if available
 			if chunk.Usage.PromptTokens > 0 || chunk.Usage.CompletionTokens > 0 {
+				usage := llm.TokenUsage{
+					InputTokens:  chunk.Usage.PromptTokens,
+					OutputTokens: chunk.Usage.CompletionTokens,
+				}
+				budget.AddUsage(usage)
 				output <- llm.TextStreamEvent{
-					Type: llm.EventTypeUsage,
-					Value: llm.TokenUsage{
-						InputTokens:  chunk.Usage.PromptTokens,
-						OutputTokens: chunk.Usage.CompletionTokens,
-					},
+					Type:  llm.EventTypeUsage,
+					Value: usage,
 				}
 			}
 
@@ -480,18 +518,33 @@ func (s *OpenAI) streamCompletionsAPIToChannels(initialParams openai.ChatComplet
 				}
 			}
 
+			// Show the model's explanation when it declines to answer
+			if delta.Refusal != "" {
+				refused = true
+				output <- llm.TextStreamEvent{
+					Type:  llm.EventTypeRefusal,
+					Value: delta.Refusal,
+				}
+			}
+
 			// Handle finish reasons
 			switch choice.FinishReason {
-			case "stop":
-				continue
 			case "tool_calls":
 				pendingToolCalls := collectToolCalls(toolsBuffer)
-				shouldContinue = s.handleAutoRunTools(&params.Messages, pendingToolCalls, cfg, llmContext, output)
+				outcome := s.handleAutoRunTools(&params.Messages, pendingToolCalls, cfg, llmContext, budget, output)
 
 				stream.Close()
 				cancel(nil)
 				<-watchdogDone
 
+				if outcome == autoRunStoppedEarly {
+					output <- llm.TextStreamEvent{
+						Type:  llm.EventTypeEnd,
+						Value: nil,
+					}
+					return
+				}
+				shouldContinue = outcome == autoRunContinue
 				if shouldContinue {
 					break
 				}
@@ -499,10 +552,8 @@ func (s *OpenAI) streamCompletionsAPIToChannels(initialParams openai.ChatComplet
 			case "":
 				// Not done yet
 			default:
-				stream.Close()
-				cancel(nil)
-				<-watchdogDone
-				return
+				// Keep reading, the usage is sent after the finish reason
+				finishReason = completionsFinishReason(choice.FinishReason)
 			}
 
 			if shouldContinue {
@@ -511,12 +562,43 @@ func (s *OpenAI) streamCompletionsAPIToChannels(initialParams openai.ChatComplet
 		}
 
 		if !shouldContinue {
-			s.handleStreamEnd(ctx, stream, cancel, watchdogDone, output)
+			if refused {
+				finishReason = llm.FinishReasonRefusal
+			}
+			if finishReason != "" {
+				output <- llm.TextStreamEvent{
+					Type:  llm.EventTypeFinish,
+					Value: finishReason,
+				}
+			}
+			s.handleStreamEnd(streamCtx, stream, cancel, watchdogDone, output)
 			return
 		}
 	}
 }
 
+// completionsFinishReason maps a Chat Completions finish reason to a finish reason of the stream
+func completionsFinishReason(reason string) string {
+	switch reason {
+	case "length":
+		return llm.FinishReasonLength
+	case "content_filter":
+		return llm.FinishReasonContentFilter
+	case "tool_calls", "function_call":
+		return llm.FinishReasonToolUse
+	default:
+		return llm.FinishReasonStop
+	}
+}
+
+// responsesIncompleteReason maps the reason a Responses API response is incomplete to a finish reason of the stream
+func responsesIncompleteReason(reason string) string {
+	if reason == "content_filter" {
+		return llm.FinishReasonContentFilter
+	}
+	return llm.FinishReasonLength
+}
+
 // startWatchdog creates and starts a watchdog goroutine that cancels the context on timeout
 func (s *OpenAI) startWatchdog(ctx context.Context, cancel context.CancelCauseFunc) (chan<- struct{}, <-chan struct{}) {
 	watchdog := make(chan struct{})
@@ -572,6 +654,15 @@ func (s *OpenAI) bufferToolCalls(buffer map[int]*ToolBufferElement, toolCalls []
 	return buffer
 }
 
+// providerError classifies an error returned by the OpenAI API so it can be explained to users.
+func providerError(err error) error {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		return llm.NewProviderError(apiErr.StatusCode, err)
+	}
+	return err
+}
+
 // handleStreamEnd handles stream cleanup and error reporting
 func (s *OpenAI) handleStreamEnd(ctx context.Context, stream *ssestream.Stream[openai.ChatCompletionChunk], cancel context.CancelCauseFunc, watchdogDone <-chan struct{}, output chan<- llm.TextStreamEvent) {
 	if err := stream.Err(); err != nil {
@@ -583,7 +674,7 @@ func (s *OpenAI) handleStreamEnd(ctx context.Context, stream *ssestream.Stream[o
 		} else {
 			output <- llm.TextStreamEvent{
 				Type:  llm.EventTypeError,
-				Value: err,
+				Value: providerError(err),
 			}
 		}
 	}
@@ -606,6 +697,45 @@ type responsesStreamState struct {
 	reasoningComplete      bool
 	annotations            []llm.Annotation
 	fullMessageText        strings.Builder
+	refused                bool
+	// responseID is the ID of the completed response
+	responseID string
+}
+
+// responsesChain is the stored response a Responses API request continues from with
+// previous_response_id, so the messages it already has are not sent again
+type responsesChain struct {
+	// previousResponseID is empty when the whole conversation is sent
+	previousResponseID string
+	// sent is the number of messages of the conversation the previous response has
+	sent int
+}
+
+// conversationChain returns the chain continuing from the last stored response of the
+// conversation. Responses waiting for the results of tool calls can't be continued from, as their
+// results are sent as messages of the conversation.
+func conversationChain(posts []llm.Post) responsesChain {
+	for i := len(posts) - 1; i >= 0; i-- {
+		post := posts[i]
+		if post.Role != llm.PostRoleBot || post.ResponseID == "" {
+			continue
+		}
+		if len(post.ToolUse) > 0 {
+			return responsesChain{}
+		}
+		return responsesChain{
+			previousResponseID: post.ResponseID,
+			sent:               len(postsToChatCompletionMessages(posts[:i+1])),
+		}
+	}
+	return responsesChain{}
+}
+
+// isMissingResponse returns whether the error is the provider rejecting a previous response,
+// for example one that expired or was stored by another account
+func isMissingResponse(err error) bool {
+	var apiErr *openai.Error
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusBadRequest || apiErr.StatusCode == http.StatusNotFound)
 }
 
 // ensureToolBuffer initializes the tools buffer if needed and returns the element at the given index
@@ -620,24 +750,36 @@ func (s *responsesStreamState) ensureToolBuffer(idx int) *ToolBufferElement {
 }
 
 // streamResponsesAPIToChannels uses the new Responses API for streaming
-func (s *OpenAI) streamResponsesAPIToChannels(initialParams openai.ChatCompletionNewParams, llmContext *llm.Context, cfg llm.LanguageModelConfig, output chan<- llm.TextStreamEvent) {
+func (s *OpenAI) streamResponsesAPIToChannels(ctx context.Context, initialParams openai.ChatCompletionNewParams, chain responsesChain, llmContext *llm.Context, cfg llm.LanguageModelConfig, budget *llm.StepBudgetTracker, output chan<- llm.TextStreamEvent) {
 	params := initialParams
+	// Responses are only stored, and can be continued from, without zero data retention
+	if s.config.ZeroDataRetention {
+		chain = responsesChain{}
+	}
 
 	for {
-		ctx, cancel := context.WithCancelCause(context.Background())
-		watchdog, watchdogDone := s.startWatchdog(ctx, cancel)
+		streamCtx, cancel := context.WithCancelCause(ctx)
+		watchdog, watchdogDone := s.startWatchdog(streamCtx, cancel)
 
-		responseParams := s.convertToResponseParams(params, llmContext, cfg)
-		stream := s.client.Responses.NewStreaming(ctx, responseParams)
+		responseParams := s.convertToResponseParams(params, chain, llmContext, cfg)
+		stream := s.client.Responses.NewStreaming(streamCtx, responseParams)
 
 		state := &responsesStreamState{}
 		shouldContinue := false
+		received := false
+		// The assistant message with the tool calls is the next message when tools run
+		toolCallsMessage := len(params.Messages)
 
 		for stream.Next() {
+			received = true
 			event := stream.Current()
-			watchdog <- struct{}{}
+			// The watchdog stops once the request is canceled
+			select {
+			case watchdog <- struct{}{}:
+			case <-streamCtx.Done():
+			}
 
-			action := s.handleResponsesEvent(event, state, &params, cfg, llmContext, output)
+			action := s.handleResponsesEvent(event, state, &params, cfg, llmContext, budget, output)
 
 			switch action {
 			case responsesActionContinue:
@@ -666,9 +808,22 @@ func (s *OpenAI) streamResponsesAPIToChannels(initialParams openai.ChatCompletio
 		}
 
 		if !shouldContinue {
-			s.handleResponsesStreamEnd(ctx, stream, cancel, watchdogDone, output)
+			// Send the whole conversation when the previous response is no longer available
+			if !received && chain.previousResponseID != "" && isMissingResponse(stream.Err()) {
+				stream.Close()
+				cancel(nil)
+				<-watchdogDone
+				chain = responsesChain{}
+				continue
+			}
+			s.handleResponsesStreamEnd(streamCtx, stream, cancel, watchdogDone, output)
 			return
 		}
+
+		// The response with the tool calls has them, only their results are sent
+		if !s.config.ZeroDataRetention && state.responseID != "" {
+			chain = responsesChain{previousResponseID: state.responseID, sent: toolCallsMessage + 1}
+		}
 	}
 }
 
@@ -689,6 +844,7 @@ func (s *OpenAI) handleResponsesEvent(
 	params *openai.ChatCompletionNewParams,
 	cfg llm.LanguageModelConfig,
 	llmContext *llm.Context,
+	budget *llm.StepBudgetTracker,
 	output chan<- llm.TextStreamEvent,
 ) responsesAction {
 	switch event.Type {
@@ -724,13 +880,26 @@ func (s *OpenAI) handleResponsesEvent(
 		s.emitAnnotationsIfPresent(state, output)
 
 	case "response.completed":
-		return s.handleResponseCompleted(event, state, params, cfg, llmContext, output)
+		return s.handleResponseCompleted(event, state, params, cfg, llmContext, budget, output)
+
+	case "response.refusal.delta":
+		// Show the model's explanation when it declines to answer
+		state.refused = true
+		output <- llm.TextStreamEvent{
+			Type:  llm.EventTypeRefusal,
+			Value: event.Delta,
+		}
 
 	case "response.incomplete":
-		s.emitUsageIfPresent(event.Response.Usage, output)
+		// Keep what was generated so far and let the caller know why it's incomplete
+		s.emitUsageIfPresent(event.Response.Usage, budget, output)
 		output <- llm.TextStreamEvent{
-			Type:  llm.EventTypeError,
-			Value: errors.New("response incomplete: max tokens reached before completion"),
+			Type:  llm.EventTypeFinish,
+			Value: responsesIncompleteReason(event.Response.IncompleteDetails.Reason),
+		}
+		output <- llm.TextStreamEvent{
+			Type:  llm.EventTypeEnd,
+			Value: nil,
 		}
 		return responsesActionReturn
 
@@ -749,8 +918,11 @@ func (s *OpenAI) handleResponseCompleted(
 	params *openai.ChatCompletionNewParams,
 	cfg llm.LanguageModelConfig,
 	llmContext *llm.Context,
+	budget *llm.StepBudgetTracker,
 	output chan<- llm.TextStreamEvent,
 ) responsesAction {
+	state.responseID = event.Response.ID
+
 	sendReasoningEnd := func() {
 		if !state.reasoningComplete && state.reasoningSummaryBuffer.Len() > 0 {
 			output <- llm.TextStreamEvent{
@@ -769,13 +941,22 @@ func (s *OpenAI) handleResponseCompleted(
 		}
 	}
 
-	s.emitUsageIfPresent(event.Response.Usage, output)
+	s.emitUsageIfPresent(event.Response.Usage, budget, output)
 
 	if len(state.toolsBuffer) > 0 {
 		pendingToolCalls := collectToolCalls(state.toolsBuffer)
 
-		if s.handleAutoRunTools(&params.Messages, pendingToolCalls, cfg, llmContext, output) {
+		switch s.handleAutoRunTools(&params.Messages, pendingToolCalls, cfg, llmContext, budget, output) {
+		case autoRunContinue:
 			return responsesActionBreakLoop
+		case autoRunStoppedEarly:
+			// Keep what was generated so far
+			sendReasoningEnd()
+			output <- llm.TextStreamEvent{
+				Type:  llm.EventTypeEnd,
+				Value: nil,
+			}
+			return responsesActionReturn
 		}
 
 		// Manual approval path
@@ -785,6 +966,20 @@ func (s *OpenAI) handleResponseCompleted(
 
 	// No tools - complete the response
 	sendReasoningEnd()
+	if !s.config.ZeroDataRetention && state.responseID != "" {
+		output <- llm.TextStreamEvent{
+			Type:  llm.EventTypeResponseID,
+			Value: state.responseID,
+		}
+	}
+	finishReason := llm.FinishReasonStop
+	if state.refused {
+		finishReason = llm.FinishReasonRefusal
+	}
+	output <- llm.TextStreamEvent{
+		Type:  llm.EventTypeFinish,
+		Value: finishReason,
+	}
 	output <- llm.TextStreamEvent{
 		Type:  llm.EventTypeEnd,
 		Value: nil,
@@ -918,14 +1113,16 @@ func (s *OpenAI) handleResponseError(event responses.ResponseStreamEventUnion, o
 }
 
 // emitUsageIfPresent emits a usage event if tokens were used
-func (s *OpenAI) emitUsageIfPresent(usage responses.ResponseUsage, output chan<- llm.TextStreamEvent) {
+func (s *OpenAI) emitUsageIfPresent(usage responses.ResponseUsage, budget *llm.StepBudgetTracker, output chan<- llm.TextStreamEvent) {
 	if usage.InputTokens > 0 || usage.OutputTokens > 0 {
+		tokenUsage := llm.TokenUsage{
+			InputTokens:  usage.InputTokens,
+			OutputTokens: usage.OutputTokens,
+		}
+		budget.AddUsage(tokenUsage)
 		output <- llm.TextStreamEvent{
-			Type: llm.EventTypeUsage,
-			Value: llm.TokenUsage{
-				InputTokens:  usage.InputTokens,
-				OutputTokens: usage.OutputTokens,
-			},
+			Type:  llm.EventTypeUsage,
+			Value: tokenUsage,
 		}
 	}
 }
@@ -941,7 +1138,7 @@ func (s *OpenAI) handleResponsesStreamEnd(ctx context.Context, stream *ssestream
 		} else {
 			output <- llm.TextStreamEvent{
 				Type:  llm.EventTypeError,
-				Value: err,
+				Value: providerError(err),
 			}
 		}
 	}
@@ -951,14 +1148,18 @@ func (s *OpenAI) handleResponsesStreamEnd(ctx context.Context, stream *ssestream
 	<-watchdogDone
 }
 
-// convertToResponseParams converts ChatCompletionNewParams to ResponseNewParams
-func (s *OpenAI) convertToResponseParams(params openai.ChatCompletionNewParams, llmContext *llm.Context, cfg llm.LanguageModelConfig) responses.ResponseNewParams {
+// convertToResponseParams converts ChatCompletionNewParams to ResponseNewParams. When the chain
+// continues from a previous response, only the messages following it are sent.
+func (s *OpenAI) convertToResponseParams(params openai.ChatCompletionNewParams, chain responsesChain, llmContext *llm.Context, cfg llm.LanguageModelConfig) responses.ResponseNewParams {
 	result := responses.ResponseNewParams{
 		Model: params.Model,
 	}
 
+	// The Responses API has a single limit for the generated tokens
 	if params.MaxCompletionTokens.Valid() {
 		result.MaxOutputTokens = param.NewOpt(params.MaxCompletionTokens.Value)
+	} else if params.MaxTokens.Valid() {
+		result.MaxOutputTokens = param.NewOpt(params.MaxTokens.Value)
 	}
 	if params.Temperature.Valid() {
 		result.Temperature = param.NewOpt(params.Temperature.Value)
@@ -969,6 +1170,9 @@ func (s *OpenAI) convertToResponseParams(params openai.ChatCompletionNewParams,
 	if params.User.Valid() && s.config.SendUserID {
 		result.SafetyIdentifier = param.NewOpt(params.User.Value)
 	}
+	if s.config.ZeroDataRetention {
+		result.Store = param.NewOpt(false)
+	}
 	if s.config.ReasoningEnabled && !cfg.ReasoningDisabled {
 		result.Reasoning = shared.ReasoningParam{
 			Effort:  getReasoningEffort(s.config.ReasoningEffort),
@@ -976,16 +1180,35 @@ func (s *OpenAI) convertToResponseParams(params openai.ChatCompletionNewParams,
 		}
 	}
 
+	// Instructions are not kept by previous responses, they are always sent
+	var systemInstructions string
+	for _, msg := range params.Messages {
+		if msg.OfSystem != nil && msg.OfSystem.Content.OfString.Valid() {
+			systemInstructions = msg.OfSystem.Content.OfString.Value
+		}
+	}
+	if systemInstructions != "" {
+		result.Instructions = param.NewOpt(systemInstructions)
+	}
+
+	tools := s.convertTools(params.Tools, cfg)
+	if len(tools) > 0 {
+		result.Tools = tools
+	}
+
+	if chain.previousResponseID != "" {
+		result.PreviousResponseID = param.NewOpt(chain.previousResponseID)
+		result.Input = responses.ResponseNewParamsInputUnion{
+			OfInputItemList: responseInputItems(params.Messages[min(chain.sent, len(params.Messages)):]),
+		}
+		return result
+	}
+
 	// Convert messages to string input format for the Responses API
 	var inputBuilder strings.Builder
-	var systemInstructions string
 
 	for _, msg := range params.Messages {
 		switch {
-		case msg.OfSystem != nil:
-			if msg.OfSystem.Content.OfString.Valid() {
-				systemInstructions = msg.OfSystem.Content.OfString.Value
-			}
 		case msg.OfUser != nil:
 			s.appendRolePrefix(&inputBuilder, "User")
 			if msg.OfUser.Content.OfString.Valid() {
@@ -1013,23 +1236,43 @@ func (s *OpenAI) convertToResponseParams(params openai.ChatCompletionNewParams,
 		}
 	}
 
-	if systemInstructions != "" {
-		result.Instructions = param.NewOpt(systemInstructions)
-	}
 	if inputBuilder.Len() > 0 {
 		result.Input = responses.ResponseNewParamsInputUnion{
 			OfString: param.NewOpt(inputBuilder.String()),
 		}
 	}
 
-	tools := s.convertTools(params.Tools, cfg)
-	if len(tools) > 0 {
-		result.Tools = tools
-	}
-
 	return result
 }
 
+// responseInputItems converts the messages following a previous response to Responses API input
+// items. Tool results must be function call outputs to answer the calls of the previous response.
+func responseInputItems(messages []openai.ChatCompletionMessageParamUnion) responses.ResponseInputParam {
+	var items responses.ResponseInputParam
+	for _, msg := range messages {
+		switch {
+		case msg.OfUser != nil:
+			if msg.OfUser.Content.OfString.Valid() {
+				items = append(items, responses.ResponseInputItemParamOfMessage(msg.OfUser.Content.OfString.Value, responses.EasyInputMessageRoleUser))
+			}
+		case msg.OfAssistant != nil:
+			if msg.OfAssistant.Content.OfString.Valid() && msg.OfAssistant.Content.OfString.Value != "" {
+				items = append(items, responses.ResponseInputItemParamOfMessage(msg.OfAssistant.Content.OfString.Value, responses.EasyInputMessageRoleAssistant))
+			}
+			for _, tc := range msg.OfAssistant.ToolCalls {
+				if tc.OfFunction != nil {
+					items = append(items, responses.ResponseInputItemParamOfFunctionCall(tc.OfFunction.Function.Arguments, tc.OfFunction.ID, tc.OfFunction.Function.Name))
+				}
+			}
+		case msg.OfTool != nil:
+			if msg.OfTool.Content.OfString.Valid() {
+				items = append(items, responses.ResponseInputItemParamOfFunctionCallOutput(msg.OfTool.ToolCallID, msg.OfTool.Content.OfString.Value))
+			}
+		}
+	}
+	return items
+}
+
 // appendRolePrefix adds a role prefix to the input builder with appropriate spacing
 func (s *OpenAI) appendRolePrefix(builder *strings.Builder, role string) {
 	if builder.Len() > 0 {
@@ -1064,11 +1307,12 @@ func (s *OpenAI) convertTools(completionTools []openai.ChatCompletionToolUnionPa
 	// Add native tools if enabled
 	if !cfg.ToolsDisabled {
 		for _, nativeTool := range s.config.EnabledNativeTools {
+			if slices.Contains(cfg.DisabledNativeTools, nativeTool) {
+				continue
+			}
 			if nativeTool == "web_search" {
 				tools = append(tools, responses.ToolUnionParam{
-					OfWebSearchPreview: &responses.WebSearchToolParam{
-						Type: responses.WebSearchToolTypeWebSearchPreview,
-					},
+					OfWebSearchPreview: s.webSearchTool(),
 				})
 			}
 		}
@@ -1077,14 +1321,40 @@ func (s *OpenAI) convertTools(completionTools []openai.ChatCompletionToolUnionPa
 	return tools
 }
 
-func (s *OpenAI) streamResult(params openai.ChatCompletionNewParams, llmContext *llm.Context, cfg llm.LanguageModelConfig) (*llm.TextStreamResult, error) {
+// webSearchTool returns the native web search tool with the configured context size and user location.
+func (s *OpenAI) webSearchTool() *responses.WebSearchToolParam {
+	tool := &responses.WebSearchToolParam{
+		Type:              responses.WebSearchToolTypeWebSearchPreview,
+		SearchContextSize: responses.WebSearchToolSearchContextSize(s.config.WebSearchContextSize),
+	}
+
+	location := s.config.WebSearchUserLocation
+	if location != (llm.WebSearchUserLocation{}) {
+		if location.City != "" {
+			tool.UserLocation.City = param.NewOpt(location.City)
+		}
+		if location.Region != "" {
+			tool.UserLocation.Region = param.NewOpt(location.Region)
+		}
+		if location.Country != "" {
+			tool.UserLocation.Country = param.NewOpt(strings.ToUpper(location.Country))
+		}
+		if location.Timezone != "" {
+			tool.UserLocation.Timezone = param.NewOpt(location.Timezone)
+		}
+	}
+
+	return tool
+}
+
+func (s *OpenAI) streamResult(ctx context.Context, params openai.ChatCompletionNewParams, chain responsesChain, llmContext *llm.Context, cfg llm.LanguageModelConfig) (*llm.TextStreamResult, error) {
 	eventStream := make(chan llm.TextStreamEvent)
 	go func() {
 		defer close(eventStream)
-		s.streamResultToChannels(params, llmContext, cfg, eventStream)
+		s.streamResultToChannels(ctx, params, chain, llmContext, cfg, eventStream)
 	}()
 
-	return &llm.TextStreamResult{Stream: eventStream}, nil
+	return &llm.TextStreamResult{Stream: llm.AggregateUsage(eventStream)}, nil
 }
 
 func (s *OpenAI) GetDefaultConfig() llm.LanguageModelConfig {
@@ -1117,6 +1387,10 @@ func (s *OpenAI) completionRequestFromConfig(cfg llm.LanguageModelConfig) openai
 		}
 	}
 
+	if s.config.ZeroDataRetention {
+		params.Store = openai.Bool(false)
+	}
+
 	if cfg.JSONOutputFormat != nil {
 		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
 			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
@@ -1129,6 +1403,47 @@ func (s *OpenAI) completionRequestFromConfig(cfg llm.LanguageModelConfig) openai
 		}
 	}
 
+	if isReasoningModel(cfg.Model) {
+		params = withReasoningModelParams(params)
+	}
+
+	return params
+}
+
+// isReasoningModel returns whether the model is an o-series or gpt-5 class reasoning model. These
+// models reject the sampling parameters and max_tokens.
+func isReasoningModel(model string) bool {
+	model = strings.ToLower(model)
+	// Chat variants of gpt-5 are not reasoning models
+	if strings.HasPrefix(model, "gpt-5") {
+		return !strings.Contains(model, "-chat")
+	}
+	for _, series := range []string{"o1", "o3", "o4"} {
+		if model == series || strings.HasPrefix(model, series+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// withReasoningModelParams drops the parameters reasoning models reject, and moves a max_tokens
+// limit to max_completion_tokens
+func withReasoningModelParams(params openai.ChatCompletionNewParams) openai.ChatCompletionNewParams {
+	if params.MaxTokens.Valid() {
+		if !params.MaxCompletionTokens.Valid() {
+			params.MaxCompletionTokens = params.MaxTokens
+		}
+		params.MaxTokens = param.Opt[int64]{}
+	}
+
+	params.Temperature = param.Opt[float64]{}
+	params.TopP = param.Opt[float64]{}
+	params.PresencePenalty = param.Opt[float64]{}
+	params.FrequencyPenalty = param.Opt[float64]{}
+	params.Logprobs = param.Opt[bool]{}
+	params.TopLogprobs = param.Opt[int64]{}
+	params.LogitBias = nil
+
 	return params
 }
 
@@ -1172,7 +1487,7 @@ func getModelConstant(model string) shared.ChatModel {
 	}
 }
 
-func (s *OpenAI) ChatCompletion(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+func (s *OpenAI) ChatCompletion(ctx context.Context, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
 	cfg := s.createConfig(opts)
 	params := s.completionRequestFromConfig(cfg)
 	params = modifyCompletionRequestWithRequest(params, request, cfg)
@@ -1187,12 +1502,12 @@ func (s *OpenAI) ChatCompletion(request llm.CompletionRequest, opts ...llm.Langu
 			params.User = openai.String(request.Context.RequestingUser.Id)
 		}
 	}
-	return s.streamResult(params, request.Context, cfg)
+	return s.streamResult(ctx, params, conversationChain(request.Posts), request.Context, cfg)
 }
 
-func (s *OpenAI) ChatCompletionNoStream(request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
+func (s *OpenAI) ChatCompletionNoStream(ctx context.Context, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (string, error) {
 	// This could perform better if we didn't use the streaming API here, but the complexity is not worth it.
-	result, err := s.ChatCompletion(request, opts...)
+	result, err := s.ChatCompletion(ctx, request, opts...)
 	if err != nil {
 		return "", err
 	}
@@ -1220,40 +1535,32 @@ func (s *OpenAI) Transcribe(file io.Reader) (*subtitles.Subtitles, error) {
 	return timedTranscript, nil
 }
 
-func (s *OpenAI) GenerateImage(prompt string) (image.Image, error) {
+// GenerateImage generates a PNG image from the prompt. The size is one of the sizes supported by
+// DALL-E 3, for example "1024x1024".
+func (s *OpenAI) GenerateImage(ctx context.Context, prompt, size string) ([]byte, error) {
 	params := openai.ImageGenerateParams{
 		Prompt:         prompt,
-		Size:           openai.ImageGenerateParamsSize256x256,
+		Model:          openai.ImageModelDallE3,
+		Size:           openai.ImageGenerateParamsSize(size),
 		ResponseFormat: openai.ImageGenerateParamsResponseFormatB64JSON,
 		N:              openai.Int(1),
 	}
 
-	resp, err := s.client.Images.Generate(context.Background(), params)
+	resp, err := s.client.Images.Generate(ctx, params)
 	if err != nil {
-		return nil, err
+		return nil, providerError(err)
 	}
 
-	if len(resp.Data) == 0 {
+	if len(resp.Data) == 0 || resp.Data[0].B64JSON == "" {
 		return nil, errors.New("no image data returned")
 	}
 
-	var imgBytes []byte
-	if resp.Data[0].B64JSON != "" {
-		imgBytes, err = base64.StdEncoding.DecodeString(resp.Data[0].B64JSON)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		return nil, errors.New("no base64 image data")
-	}
-
-	r := bytes.NewReader(imgBytes)
-	imgData, err := png.Decode(r)
+	imgBytes, err := base64.StdEncoding.DecodeString(resp.Data[0].B64JSON)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("unable to decode image data: %w", err)
 	}
 
-	return imgData, nil
+	return imgBytes, nil
 }
 
 func (s *OpenAI) CountTokens(text string) int {
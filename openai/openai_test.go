@@ -5,6 +5,7 @@ package openai
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"testing"
 
@@ -811,7 +812,7 @@ func TestReasoningEffortConfiguration(t *testing.T) {
 			}
 
 			// Call the actual function that handles reasoning configuration
-			result := oai.convertToResponseParams(chatParams, &llm.Context{}, llm.LanguageModelConfig{
+			result := oai.convertToResponseParams(chatParams, responsesChain{}, &llm.Context{}, llm.LanguageModelConfig{
 				Model:              "gpt-4o",
 				MaxGeneratedTokens: 8192,
 			})
@@ -828,3 +829,190 @@ func TestReasoningEffortConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestConversationChain(t *testing.T) {
+	posts := []llm.Post{
+		{Role: llm.PostRoleSystem, Message: "You are a helpful assistant."},
+		{Role: llm.PostRoleUser, Message: "What is the weather?"},
+		{Role: llm.PostRoleBot, Message: "Sunny.", ResponseID: "resp_1"},
+		{Role: llm.PostRoleUser, Message: "And tomorrow?"},
+	}
+	assert.Equal(t, responsesChain{previousResponseID: "resp_1", sent: 3}, conversationChain(posts))
+
+	withoutIDs := []llm.Post{posts[0], posts[1], {Role: llm.PostRoleBot, Message: "Sunny."}, posts[3]}
+	assert.Equal(t, responsesChain{}, conversationChain(withoutIDs))
+
+	withToolCalls := []llm.Post{posts[0], posts[1], {Role: llm.PostRoleBot, ResponseID: "resp_1", ToolUse: []llm.ToolCall{{ID: "call_1", Name: "weather", Result: "Rain"}}}, posts[3]}
+	assert.Equal(t, responsesChain{}, conversationChain(withToolCalls), "responses waiting for tool results are not continued from")
+
+	oai := New(Config{APIKey: "test-key", DefaultModel: "gpt-4o", UseResponsesAPI: true}, &http.Client{})
+	chatParams := modifyCompletionRequestWithRequest(openai.ChatCompletionNewParams{Model: "gpt-4o"}, llm.CompletionRequest{Posts: posts, Context: &llm.Context{}}, llm.LanguageModelConfig{})
+
+	result := oai.convertToResponseParams(chatParams, conversationChain(posts), &llm.Context{}, llm.LanguageModelConfig{})
+	assert.Equal(t, "resp_1", result.PreviousResponseID.Value)
+	assert.Equal(t, "You are a helpful assistant.", result.Instructions.Value, "instructions are sent with every request")
+	assert.False(t, result.Input.OfString.Valid())
+	require.Len(t, result.Input.OfInputItemList, 1)
+	assert.Equal(t, "And tomorrow?", result.Input.OfInputItemList[0].OfMessage.Content.OfString.Value)
+
+	// Tool results answer the calls of the previous response
+	chatParams.Messages = append(chatParams.Messages, openai.ToolMessage("Rain", "call_1"))
+	result = oai.convertToResponseParams(chatParams, responsesChain{previousResponseID: "resp_2", sent: 4}, &llm.Context{}, llm.LanguageModelConfig{})
+	require.Len(t, result.Input.OfInputItemList, 1)
+	require.NotNil(t, result.Input.OfInputItemList[0].OfFunctionCallOutput)
+	assert.Equal(t, "call_1", result.Input.OfInputItemList[0].OfFunctionCallOutput.CallID)
+
+	result = oai.convertToResponseParams(chatParams, responsesChain{}, &llm.Context{}, llm.LanguageModelConfig{})
+	assert.False(t, result.PreviousResponseID.Valid())
+	assert.Contains(t, result.Input.OfString.Value, "User: What is the weather?")
+}
+
+func TestZeroDataRetention(t *testing.T) {
+	for name, zeroDataRetention := range map[string]bool{"enabled": true, "disabled": false} {
+		t.Run(name, func(t *testing.T) {
+			oai := New(Config{
+				APIKey:            "test-key",
+				DefaultModel:      "gpt-4o",
+				ZeroDataRetention: zeroDataRetention,
+			}, &http.Client{})
+			cfg := llm.LanguageModelConfig{Model: "gpt-4o"}
+
+			chatParams := oai.completionRequestFromConfig(cfg)
+			responseParams := oai.convertToResponseParams(chatParams, responsesChain{}, &llm.Context{}, cfg)
+
+			if !zeroDataRetention {
+				assert.False(t, chatParams.Store.Valid())
+				assert.False(t, responseParams.Store.Valid())
+				return
+			}
+
+			require.True(t, chatParams.Store.Valid())
+			assert.False(t, chatParams.Store.Value)
+			require.True(t, responseParams.Store.Valid())
+			assert.False(t, responseParams.Store.Value)
+		})
+	}
+}
+
+func TestReasoningModelParams(t *testing.T) {
+	tests := []struct {
+		model     string
+		reasoning bool
+	}{
+		{model: "gpt-4o", reasoning: false},
+		{model: "gpt-4.1-mini", reasoning: false},
+		{model: "o1", reasoning: true},
+		{model: "o1-mini", reasoning: true},
+		{model: "o3-2025-04-16", reasoning: true},
+		{model: "o4-mini", reasoning: true},
+		{model: "gpt-5", reasoning: true},
+		{model: "gpt-5-mini", reasoning: true},
+		{model: "gpt-5-chat-latest", reasoning: false},
+		{model: "omni-model", reasoning: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			assert.Equal(t, tt.reasoning, isReasoningModel(tt.model))
+
+			oai := New(Config{
+				APIKey:       "test-key",
+				DefaultModel: tt.model,
+				UseMaxTokens: true,
+			}, &http.Client{})
+			params := oai.completionRequestFromConfig(llm.LanguageModelConfig{Model: tt.model, MaxGeneratedTokens: 1000})
+
+			if !tt.reasoning {
+				assert.Equal(t, int64(1000), params.MaxTokens.Value)
+				assert.False(t, params.MaxCompletionTokens.Valid())
+				return
+			}
+			assert.False(t, params.MaxTokens.Valid(), "reasoning models reject max_tokens")
+			assert.Equal(t, int64(1000), params.MaxCompletionTokens.Value)
+		})
+	}
+
+	params := withReasoningModelParams(openai.ChatCompletionNewParams{
+		Temperature:         openai.Float(0.2),
+		TopP:                openai.Float(0.9),
+		PresencePenalty:     openai.Float(0.5),
+		FrequencyPenalty:    openai.Float(0.5),
+		MaxCompletionTokens: openai.Int(500),
+		MaxTokens:           openai.Int(1000),
+	})
+	assert.False(t, params.Temperature.Valid())
+	assert.False(t, params.TopP.Valid())
+	assert.False(t, params.PresencePenalty.Valid())
+	assert.False(t, params.FrequencyPenalty.Valid())
+	assert.False(t, params.MaxTokens.Valid())
+	assert.Equal(t, int64(500), params.MaxCompletionTokens.Value, "an explicit max_completion_tokens is kept")
+}
+
+func TestWebSearchTool(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{
+			name:     "defaults",
+			expected: `{"type":"web_search_preview"}`,
+		},
+		{
+			name: "context size and user location",
+			config: Config{
+				WebSearchContextSize: "high",
+				WebSearchUserLocation: llm.WebSearchUserLocation{
+					City:     "Berlin",
+					Country:  "de",
+					Timezone: "Europe/Berlin",
+				},
+			},
+			expected: `{"type":"web_search_preview","search_context_size":"high","user_location":{"city":"Berlin","country":"DE","timezone":"Europe/Berlin","type":"approximate"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.EnabledNativeTools = []string{"web_search"}
+			s := &OpenAI{config: tt.config}
+
+			tools := s.convertTools(nil, llm.LanguageModelConfig{})
+			require.Len(t, tools, 1)
+
+			data, err := json.Marshal(tools[0].OfWebSearchPreview)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(data))
+		})
+	}
+}
+
+func TestDisabledNativeTools(t *testing.T) {
+	s := &OpenAI{config: Config{EnabledNativeTools: []string{"web_search"}}}
+
+	assert.Len(t, s.convertTools(nil, llm.LanguageModelConfig{}), 1)
+	assert.Empty(t, s.convertTools(nil, llm.LanguageModelConfig{DisabledNativeTools: []string{"web_search"}}))
+}
+
+func TestFinishReasons(t *testing.T) {
+	tests := []struct {
+		name     string
+		mapper   func(string) string
+		reason   string
+		expected string
+	}{
+		{name: "completions stop", mapper: completionsFinishReason, reason: "stop", expected: llm.FinishReasonStop},
+		{name: "completions length", mapper: completionsFinishReason, reason: "length", expected: llm.FinishReasonLength},
+		{name: "completions content filter", mapper: completionsFinishReason, reason: "content_filter", expected: llm.FinishReasonContentFilter},
+		{name: "completions tool calls", mapper: completionsFinishReason, reason: "tool_calls", expected: llm.FinishReasonToolUse},
+		{name: "completions function call", mapper: completionsFinishReason, reason: "function_call", expected: llm.FinishReasonToolUse},
+		{name: "responses max output tokens", mapper: responsesIncompleteReason, reason: "max_output_tokens", expected: llm.FinishReasonLength},
+		{name: "responses content filter", mapper: responsesIncompleteReason, reason: "content_filter", expected: llm.FinishReasonContentFilter},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.mapper(tc.reason))
+		})
+	}
+}
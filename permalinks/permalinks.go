@@ -0,0 +1,150 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package permalinks resolves the links to other posts of the server in the analyzed threads, so
+// the models read an excerpt of what is referenced instead of a bare link. Only the posts the
+// requesting user can read, in channels whose content may be sent, are resolved.
+package permalinks
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// MaxResolvedLinks is the number of links resolved per thread
+	MaxResolvedLinks = 10
+	// maxExcerptLength is the number of characters kept of the linked posts
+	maxExcerptLength = 300
+)
+
+// ChannelChecker checks the channels of the linked posts, see exclusions.Checker
+type ChannelChecker interface {
+	CheckChannel(channel *model.Channel) error
+	ChannelPolicy(channel *model.Channel) (exclusions.ChannelPolicy, error)
+}
+
+// Resolver resolves the permalinks of posts
+type Resolver struct {
+	client   mmapi.Client
+	channels ChannelChecker
+}
+
+// New creates a new permalink resolver
+func New(client mmapi.Client, channels ChannelChecker) *Resolver {
+	return &Resolver{
+		client:   client,
+		channels: channels,
+	}
+}
+
+// FindPostIDs returns the IDs of the posts linked in the message by permalinks of the server at
+// siteURL, in order and without duplicates
+func FindPostIDs(message, siteURL string) []string {
+	siteURL = strings.TrimRight(siteURL, "/")
+	if siteURL == "" {
+		return nil
+	}
+
+	// Permalinks are {siteURL}/{team name}/pl/{post ID}, or _redirect instead of the team name
+	permalinkRegexp := regexp.MustCompile(regexp.QuoteMeta(siteURL) + `/[a-z0-9_-]+/pl/([a-z0-9]{26})\b`)
+	var postIDs []string
+	for _, match := range permalinkRegexp.FindAllStringSubmatch(message, -1) {
+		if !slices.Contains(postIDs, match[1]) {
+			postIDs = append(postIDs, match[1])
+		}
+	}
+	return postIDs
+}
+
+// Resolve returns an excerpt of the posts linked in posts that userID can read, by the ID of the
+// post linking them. Links to posts of the thread itself and to posts that can't be read are
+// left as they are.
+func (r *Resolver) Resolve(userID string, posts []*model.Post) map[string][]mmapi.LinkedPost {
+	siteURL := ""
+	if config := r.client.GetConfig(); config != nil && config.ServiceSettings.SiteURL != nil {
+		siteURL = *config.ServiceSettings.SiteURL
+	}
+
+	threadPostIDs := make(map[string]bool, len(posts))
+	for _, post := range posts {
+		threadPostIDs[post.Id] = true
+	}
+
+	result := make(map[string][]mmapi.LinkedPost)
+	resolved := 0
+	for _, post := range posts {
+		for _, linkedID := range FindPostIDs(post.Message, siteURL) {
+			if resolved >= MaxResolvedLinks {
+				return result
+			}
+			if threadPostIDs[linkedID] {
+				continue
+			}
+			resolved++
+
+			linked, ok := r.resolve(userID, linkedID)
+			if ok {
+				result[post.Id] = append(result[post.Id], linked)
+			}
+		}
+	}
+	return result
+}
+
+// resolve returns an excerpt of the post, if userID can read it and its content may be sent
+func (r *Resolver) resolve(userID, postID string) (mmapi.LinkedPost, bool) {
+	post, err := r.client.GetPost(postID)
+	if err != nil || post.DeleteAt != 0 {
+		return mmapi.LinkedPost{}, false
+	}
+	if !r.client.HasPermissionToChannel(userID, post.ChannelId, model.PermissionReadChannel) {
+		return mmapi.LinkedPost{}, false
+	}
+
+	channel, err := r.client.GetChannel(post.ChannelId)
+	if err != nil {
+		r.client.LogError("Failed to get channel of linked post", "post_id", postID, "error", err)
+		return mmapi.LinkedPost{}, false
+	}
+	author, err := r.client.GetUser(post.UserId)
+	if err != nil {
+		r.client.LogError("Failed to get author of linked post", "post_id", postID, "error", err)
+		return mmapi.LinkedPost{}, false
+	}
+
+	// The channel of the linked post wasn't confirmed by the user, so posts of channels requiring
+	// confirmation are left out too
+	if r.channels != nil {
+		if r.channels.CheckChannel(channel) != nil {
+			return mmapi.LinkedPost{}, false
+		}
+		policy, policyErr := r.channels.ChannelPolicy(channel)
+		if policyErr != nil || policy.RequireConfirmation || policy.ExcludesPost(post, author) {
+			return mmapi.LinkedPost{}, false
+		}
+	}
+
+	excerpt := strings.Join(strings.Fields(format.PostBody(post)), " ")
+	if runes := []rune(excerpt); len(runes) > maxExcerptLength {
+		excerpt = string(runes[:maxExcerptLength]) + "..."
+	}
+
+	channelName := ""
+	if channel.Type == model.ChannelTypeOpen || channel.Type == model.ChannelTypePrivate {
+		channelName = channel.DisplayName
+	}
+
+	return mmapi.LinkedPost{
+		PostID:      post.Id,
+		Username:    author.Username,
+		ChannelName: channelName,
+		Excerpt:     excerpt,
+	}, true
+}
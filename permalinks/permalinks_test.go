@@ -0,0 +1,87 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package permalinks
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	mmapimocks "github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeChannelChecker struct {
+	excluded map[string]bool
+	policies map[string]exclusions.ChannelPolicy
+}
+
+func (f *fakeChannelChecker) CheckChannel(channel *model.Channel) error {
+	if f.excluded[channel.Id] {
+		return exclusions.ErrChannelExcluded
+	}
+	return nil
+}
+
+func (f *fakeChannelChecker) ChannelPolicy(channel *model.Channel) (exclusions.ChannelPolicy, error) {
+	return f.policies[channel.Id], nil
+}
+
+func TestFindPostIDs(t *testing.T) {
+	postID := model.NewId()
+	otherID := model.NewId()
+	message := "See https://mm.example.com/team-a/pl/" + postID + " and (https://mm.example.com/_redirect/pl/" + otherID + "), " +
+		"again https://mm.example.com/team-a/pl/" + postID + " but not https://other.example.com/team-a/pl/" + model.NewId()
+
+	require.Equal(t, []string{postID, otherID}, FindPostIDs(message, "https://mm.example.com/"))
+	require.Empty(t, FindPostIDs(message, ""))
+	require.Empty(t, FindPostIDs("https://mm.example.com/team-a/pl/"+postID+"x", "https://mm.example.com"))
+}
+
+func TestResolve(t *testing.T) {
+	client := mmapimocks.NewMockClient(t)
+	client.EXPECT().GetConfig().Return(&model.Config{ServiceSettings: model.ServiceSettings{SiteURL: model.NewPointer("https://mm.example.com")}})
+
+	readable := &model.Post{Id: model.NewId(), ChannelId: "town-square", UserId: "alice", Message: "The rollout is paused\nuntil the fix lands"}
+	private := &model.Post{Id: model.NewId(), ChannelId: "leadership", UserId: "alice", Message: "Budget cuts"}
+	excluded := &model.Post{Id: model.NewId(), ChannelId: "legal", UserId: "alice", Message: "Privileged"}
+	guest := &model.Post{Id: model.NewId(), ChannelId: "partners", UserId: "guest", Message: "Partner pricing"}
+	missing := model.NewId()
+	inThread := &model.Post{Id: model.NewId(), Message: "Root"}
+
+	link := func(postID string) string {
+		return "https://mm.example.com/team-a/pl/" + postID
+	}
+	posts := []*model.Post{
+		inThread,
+		{Id: model.NewId(), Message: strings.Join([]string{link(readable.Id), link(private.Id), link(excluded.Id), link(guest.Id), link(missing), link(inThread.Id)}, " ")},
+	}
+
+	for _, post := range []*model.Post{readable, private, excluded, guest} {
+		client.EXPECT().GetPost(post.Id).Return(post, nil)
+	}
+	client.EXPECT().GetPost(missing).Return(nil, errors.New("not found"))
+	client.EXPECT().HasPermissionToChannel("user1", "town-square", model.PermissionReadChannel).Return(true)
+	client.EXPECT().HasPermissionToChannel("user1", "leadership", model.PermissionReadChannel).Return(false)
+	client.EXPECT().HasPermissionToChannel("user1", "legal", model.PermissionReadChannel).Return(true)
+	client.EXPECT().HasPermissionToChannel("user1", "partners", model.PermissionReadChannel).Return(true)
+	client.EXPECT().GetChannel("town-square").Return(&model.Channel{Id: "town-square", Type: model.ChannelTypeOpen, DisplayName: "Town Square"}, nil)
+	client.EXPECT().GetChannel("legal").Return(&model.Channel{Id: "legal", Type: model.ChannelTypePrivate}, nil)
+	client.EXPECT().GetChannel("partners").Return(&model.Channel{Id: "partners", Type: model.ChannelTypeOpen}, nil)
+	client.EXPECT().GetUser("alice").Return(&model.User{Id: "alice", Username: "alice"}, nil)
+	client.EXPECT().GetUser("guest").Return(&model.User{Id: "guest", Username: "guest", Roles: model.SystemGuestRoleId}, nil)
+
+	resolver := New(client, &fakeChannelChecker{
+		excluded: map[string]bool{"legal": true},
+		policies: map[string]exclusions.ChannelPolicy{"partners": {ExcludeGuests: true}},
+	})
+
+	result := resolver.Resolve("user1", posts)
+	require.Equal(t, map[string][]mmapi.LinkedPost{
+		posts[1].Id: {{PostID: readable.Id, Username: "alice", ChannelName: "Town Square", Excerpt: "The rollout is paused until the fix lands"}},
+	}, result)
+}
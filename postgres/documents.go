@@ -0,0 +1,204 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/mattermost/mattermost-plugin-ai/connectors"
+	"github.com/pgvector/pgvector-go"
+)
+
+// DocumentStore stores the chunks of the documents of external sources with their embeddings
+// and who may read them, see connectors.Store
+type DocumentStore struct {
+	db *sqlx.DB
+}
+
+func NewDocumentStore(db *sqlx.DB, config PGVectorConfig) (*DocumentStore, error) {
+	if _, err := db.Exec("CREATE EXTENSION IF NOT EXISTS vector"); err != nil {
+		return nil, fmt.Errorf("failed to create vector extension: %w", err)
+	}
+
+	createTableQuery := `
+		CREATE TABLE IF NOT EXISTS llm_document_embeddings (
+			id TEXT PRIMARY KEY,             -- Source ID, document ID and chunk index
+			source_id TEXT NOT NULL,
+			document_id TEXT NOT NULL,
+			title TEXT NOT NULL,
+			url TEXT NOT NULL,
+			content TEXT NOT NULL,
+			embedding vector(` + strconv.Itoa(config.Dimensions) + `),
+			updated_at BIGINT NOT NULL,      -- Update time of the document in its source
+			principals TEXT[] NOT NULL,      -- Who may read the document, see connectors.UserPrincipals
+			chunk_index INTEGER NOT NULL
+		)`
+	if _, err := db.Exec(createTableQuery); err != nil {
+		return nil, fmt.Errorf("failed to create llm_document_embeddings table: %w", err)
+	}
+
+	queries := []string{
+		"CREATE INDEX IF NOT EXISTS llm_document_embeddings_embedding_idx ON llm_document_embeddings USING hnsw (embedding vector_l2_ops)",
+		"CREATE INDEX IF NOT EXISTS llm_document_embeddings_document_idx ON llm_document_embeddings(source_id, document_id)",
+		"CREATE INDEX IF NOT EXISTS llm_document_embeddings_principals_idx ON llm_document_embeddings USING gin (principals)",
+	}
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return nil, fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+
+	return &DocumentStore{db: db}, nil
+}
+
+func (ds *DocumentStore) Documents(ctx context.Context, sourceID string) (map[string]connectors.IndexedDocument, error) {
+	rows, err := ds.db.QueryxContext(ctx, `
+		SELECT document_id, updated_at, principals
+		FROM llm_document_embeddings
+		WHERE source_id = $1 AND chunk_index = 0`,
+		sourceID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	documents := make(map[string]connectors.IndexedDocument)
+	for rows.Next() {
+		var documentID string
+		var updatedAt int64
+		var principals pq.StringArray
+		if err := rows.Scan(&documentID, &updatedAt, &principals); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		documents[documentID] = connectors.IndexedDocument{UpdateAt: updatedAt, Principals: principals}
+	}
+	return documents, rows.Err()
+}
+
+func (ds *DocumentStore) Store(ctx context.Context, sourceID string, doc connectors.Document, chunks []string, embeddings [][]float32) error {
+	tx, err := ds.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM llm_document_embeddings WHERE source_id = $1 AND document_id = $2", sourceID, doc.ID); err != nil {
+		return fmt.Errorf("failed to delete previous chunks: %w", err)
+	}
+	for i, chunk := range chunks {
+		_, err := tx.NamedExecContext(ctx, `
+			INSERT INTO llm_document_embeddings (
+				id, source_id, document_id, title, url, content, embedding, updated_at, principals, chunk_index
+			)
+			VALUES (
+				:id, :source_id, :document_id, :title, :url, :content, :embedding, :updated_at, :principals, :chunk_index
+			)`,
+			map[string]interface{}{
+				"id":          fmt.Sprintf("%s_%s_chunk_%d", sourceID, doc.ID, i),
+				"source_id":   sourceID,
+				"document_id": doc.ID,
+				"title":       doc.Title,
+				"url":         doc.URL,
+				"content":     chunk,
+				"embedding":   pgvector.NewVector(embeddings[i]),
+				"updated_at":  doc.UpdateAt,
+				"principals":  pq.StringArray(doc.Principals),
+				"chunk_index": i,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert chunk: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (ds *DocumentStore) SetPrincipals(ctx context.Context, sourceID, documentID string, principals []string) error {
+	_, err := ds.db.ExecContext(ctx,
+		"UPDATE llm_document_embeddings SET principals = $1 WHERE source_id = $2 AND document_id = $3",
+		pq.StringArray(principals), sourceID, documentID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update principals: %w", err)
+	}
+	return nil
+}
+
+func (ds *DocumentStore) Delete(ctx context.Context, sourceID string, documentIDs []string) error {
+	query, args, err := sq.
+		Delete("llm_document_embeddings").
+		Where(sq.Eq{"source_id": sourceID, "document_id": documentIDs}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to create query: %w", err)
+	}
+	if _, err := ds.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete documents: %w", err)
+	}
+	return nil
+}
+
+func (ds *DocumentStore) DeleteOtherSources(ctx context.Context, sourceIDs []string) error {
+	query, args, err := sq.
+		Delete("llm_document_embeddings").
+		Where(sq.NotEq{"source_id": sourceIDs}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to create query: %w", err)
+	}
+	if _, err := ds.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete documents: %w", err)
+	}
+	return nil
+}
+
+func (ds *DocumentStore) Search(ctx context.Context, embedding []float32, principals []string, limit int) ([]connectors.SearchResult, error) {
+	if len(principals) == 0 {
+		return nil, fmt.Errorf("principals are required to validate permissions")
+	}
+
+	rows, err := ds.db.QueryxContext(ctx, `
+		SELECT source_id, document_id, title, url, content, updated_at, (embedding <-> $1) AS distance
+		FROM llm_document_embeddings
+		WHERE principals && $2
+		ORDER BY distance ASC
+		LIMIT $3`,
+		pgvector.NewVector(embedding), pq.StringArray(principals), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	var results []connectors.SearchResult
+	for rows.Next() {
+		var result connectors.SearchResult
+		var distance float32
+		if err := rows.Scan(
+			&result.SourceID,
+			&result.Document.ID,
+			&result.Document.Title,
+			&result.Document.URL,
+			&result.Document.Content,
+			&result.Document.UpdateAt,
+			&distance,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result.Score = max(1-distance, 0)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/connectors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentStoreSearchWithPrincipals(t *testing.T) {
+	db := testDB(t)
+	defer cleanupDB(t, db)
+
+	store, err := NewDocumentStore(db, PGVectorConfig{Dimensions: 3})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	vector := []float32{0.5, 0.5, 0.5}
+	docs := []connectors.Document{
+		{ID: "handbook", Title: "Handbook", Principals: []string{connectors.PrincipalEveryone}},
+		{ID: "salaries", Title: "Salaries", Principals: []string{connectors.EmailPrincipal("alice@example.com"), connectors.TeamPrincipal("hr")}},
+		{ID: "roadmap", Title: "Roadmap", Principals: []string{connectors.TeamPrincipal("leadership")}},
+	}
+	for _, doc := range docs {
+		require.NoError(t, store.Store(ctx, "wiki", doc, []string{doc.Title}, [][]float32{vector}))
+	}
+
+	search := func(principals ...string) []string {
+		results, searchErr := store.Search(ctx, vector, principals, 10)
+		require.NoError(t, searchErr)
+		ids := []string{}
+		for _, result := range results {
+			ids = append(ids, result.Document.ID)
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, []string{"handbook"}, search(connectors.PrincipalEveryone, connectors.EmailPrincipal("bob@example.com")))
+	assert.ElementsMatch(t, []string{"handbook", "salaries"}, search(connectors.PrincipalEveryone, connectors.EmailPrincipal("alice@example.com")))
+	assert.ElementsMatch(t, []string{"handbook", "salaries", "roadmap"}, search(connectors.PrincipalEveryone, connectors.TeamPrincipal("hr"), connectors.TeamPrincipal("leadership")))
+
+	// Access removed in the source is removed from the results
+	require.NoError(t, store.SetPrincipals(ctx, "wiki", "salaries", []string{connectors.TeamPrincipal("hr")}))
+	assert.ElementsMatch(t, []string{"handbook"}, search(connectors.PrincipalEveryone, connectors.EmailPrincipal("alice@example.com")))
+
+	_, err = store.Search(ctx, vector, nil, 10)
+	require.Error(t, err)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/mattermost/mattermost-plugin-ai/chunking"
 	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/pgvector/pgvector-go"
 )
 
@@ -144,6 +145,10 @@ func (pv *PGVector) Search(ctx context.Context, embedding []float32, opts embedd
 		Where("p.DeleteAt = 0").
 		PlaceholderFormat(sq.Dollar)
 
+	if opts.PublicChannelsOnly {
+		queryBuilder = queryBuilder.Where(sq.Eq{"c.Type": string(model.ChannelTypeOpen)})
+	}
+
 	if opts.TeamID != "" {
 		queryBuilder = queryBuilder.Where(sq.Eq{"e.team_id": opts.TeamID})
 	}
@@ -901,6 +901,54 @@ func TestSearchWithPermissions(t *testing.T) {
 		}
 	})
 
+	t.Run("public channels only excludes private channels and direct messages", func(t *testing.T) {
+		ctx, pgVector, db, searchVector := setupPermissionSearchTest(t)
+		defer cleanupDB(t, db)
+
+		// user1 is a member of the public channel1, the private channel2, and now of a direct message
+		_, err := db.Exec("UPDATE Channels SET Type = $1 WHERE Id = $2", model.ChannelTypePrivate, "channel2")
+		require.NoError(t, err)
+		_, err = db.Exec("UPDATE Channels SET Type = $1 WHERE Id = $2", model.ChannelTypeDirect, "channel4")
+		require.NoError(t, err)
+		addTestChannelMembers(t, db, "channel4", []string{"user1"})
+
+		results, err := pgVector.Search(ctx, searchVector, embeddings.SearchOptions{
+			Limit:              10,
+			UserID:             "user1",
+			PublicChannelsOnly: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 1, "Should only return the post of the public channel")
+		assert.Equal(t, "post1", results[0].Document.PostID)
+
+		// The private content is still found by its members when the results are only for them
+		results, err = pgVector.Search(ctx, searchVector, embeddings.SearchOptions{
+			Limit:  10,
+			UserID: "user1",
+		})
+		require.NoError(t, err)
+		assert.Len(t, results, 4)
+	})
+
+	t.Run("private channel content is not found once the user leaves the channel", func(t *testing.T) {
+		ctx, pgVector, db, searchVector := setupPermissionSearchTest(t)
+		defer cleanupDB(t, db)
+
+		_, err := db.Exec("UPDATE Channels SET Type = $1 WHERE Id = $2", model.ChannelTypePrivate, "channel2")
+		require.NoError(t, err)
+		_, err = db.Exec("DELETE FROM ChannelMembers WHERE ChannelId = $1 AND UserId = $2", "channel2", "user1")
+		require.NoError(t, err)
+
+		results, err := pgVector.Search(ctx, searchVector, embeddings.SearchOptions{
+			Limit:  10,
+			UserID: "user1",
+		})
+		require.NoError(t, err)
+		for _, result := range results {
+			assert.NotEqual(t, "channel2", result.Document.ChannelID, "Should not include posts of a private channel the user left")
+		}
+	})
+
 	t.Run("deleted posts are excluded", func(t *testing.T) {
 		ctx, pgVector, db, searchVector := setupPermissionSearchTest(t)
 		defer cleanupDB(t, db)
@@ -0,0 +1,120 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package postprocessors provides the post-processors admins can configure on bots to edit their
+// completed responses, such as adding a disclaimer required by a policy.
+package postprocessors
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Register registers the post-processors of the plugin with the streaming service
+func Register(service *streaming.MMPostStreamService) {
+	service.RegisterBotPostProcessor(llm.PostProcessorDisclaimer, NewDisclaimer)
+	service.RegisterBotPostProcessor(llm.PostProcessorWordFilter, NewWordFilter)
+	service.RegisterBotPostProcessor(llm.PostProcessorLinkPreviews, NewLinkPreviews)
+}
+
+// Disclaimer adds a disclaimer at the end of responses
+type Disclaimer struct {
+	text string
+}
+
+// NewDisclaimer creates a processor adding the Text of config to responses
+func NewDisclaimer(config llm.PostProcessorConfig) streaming.PostProcessor {
+	return &Disclaimer{text: strings.TrimSpace(config.Text)}
+}
+
+// ProcessPost adds the disclaimer in a paragraph of its own
+func (d *Disclaimer) ProcessPost(_ context.Context, post *model.Post, _ bool) {
+	if d.text == "" {
+		return
+	}
+	post.Message = strings.TrimRight(post.Message, " \n") + "\n\n" + d.text
+}
+
+// WordFilter masks words in responses, such as profanity
+type WordFilter struct {
+	words *regexp.Regexp
+}
+
+// NewWordFilter creates a processor masking the Words of config, matched whole and ignoring case
+func NewWordFilter(config llm.PostProcessorConfig) streaming.PostProcessor {
+	quoted := make([]string, 0, len(config.Words))
+	for _, word := range config.Words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return &WordFilter{}
+	}
+	return &WordFilter{words: regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)}
+}
+
+// ProcessPost replaces every character of the filtered words with an asterisk
+func (f *WordFilter) ProcessPost(_ context.Context, post *model.Post, _ bool) {
+	if f.words == nil {
+		return
+	}
+	post.Message = f.words.ReplaceAllStringFunc(post.Message, func(word string) string {
+		return strings.Repeat(`\*`, len([]rune(word)))
+	})
+}
+
+var linkRegexp = regexp.MustCompile(`https?://[^\s<>()\[\]"'` + "`" + `]+`)
+
+// LinkPreviews allows the previews of the links of responses when they all point to trusted
+// domains. Links in responses could have been written by a prompt injection, so the previews
+// of responses with any other link stay disabled.
+type LinkPreviews struct {
+	domains []string
+}
+
+// NewLinkPreviews creates a processor trusting the Domains of config and their subdomains
+func NewLinkPreviews(config llm.PostProcessorConfig) streaming.PostProcessor {
+	domains := make([]string, 0, len(config.Domains))
+	for _, domain := range config.Domains {
+		if domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), "."); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return &LinkPreviews{domains: domains}
+}
+
+// ProcessPost removes the unsafe links mark of the post when it has links and all of them are
+// trusted
+func (l *LinkPreviews) ProcessPost(_ context.Context, post *model.Post, _ bool) {
+	links := linkRegexp.FindAllString(post.Message, -1)
+	if len(links) == 0 {
+		return
+	}
+	for _, link := range links {
+		if !l.trusted(link) {
+			return
+		}
+	}
+	post.DelProp(streaming.UnsafeLinksPostProp)
+}
+
+func (l *LinkPreviews) trusted(link string) bool {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, domain := range l.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package postprocessors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisclaimer(t *testing.T) {
+	post := &model.Post{Message: "The answer is 42.\n"}
+	NewDisclaimer(llm.PostProcessorConfig{Text: " _Responses may be inaccurate._ "}).ProcessPost(context.Background(), post, false)
+	assert.Equal(t, "The answer is 42.\n\n_Responses may be inaccurate._", post.Message)
+}
+
+func TestWordFilter(t *testing.T) {
+	post := &model.Post{Message: "Darn it, the DARN build failed again. Darning socks is fine."}
+	NewWordFilter(llm.PostProcessorConfig{Words: []string{"darn", " "}}).ProcessPost(context.Background(), post, false)
+	assert.Equal(t, `\*\*\*\* it, the \*\*\*\* build failed again. Darning socks is fine.`, post.Message)
+
+	post = &model.Post{Message: "Unchanged"}
+	NewWordFilter(llm.PostProcessorConfig{}).ProcessPost(context.Background(), post, false)
+	assert.Equal(t, "Unchanged", post.Message)
+}
+
+func TestLinkPreviews(t *testing.T) {
+	processor := NewLinkPreviews(llm.PostProcessorConfig{Domains: []string{"Example.com"}})
+
+	tests := []struct {
+		name          string
+		message       string
+		expectPreview bool
+	}{
+		{
+			name:          "trusted domain and subdomain",
+			message:       "See https://example.com/a and [docs](https://docs.example.com/b).",
+			expectPreview: true,
+		},
+		{
+			name:    "untrusted link",
+			message: "See https://example.com/a and https://attacker.test/?q=secret",
+		},
+		{
+			name:    "lookalike domain",
+			message: "See https://notexample.com/a",
+		},
+		{
+			name:    "no links",
+			message: "Nothing to preview",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			post := &model.Post{Message: tc.message}
+			post.AddProp(streaming.UnsafeLinksPostProp, "true")
+			processor.ProcessPost(context.Background(), post, false)
+			assert.Equal(t, tc.expectPreview, post.GetProp(streaming.UnsafeLinksPostProp) == nil)
+		})
+	}
+}
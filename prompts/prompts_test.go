@@ -0,0 +1,167 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package prompts
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageInstructions(t *testing.T) {
+	tests := []struct {
+		name           string
+		prompt         string
+		locale         string
+		outputLanguage string
+		contains       []string
+		notContains    []string
+	}{
+		{
+			name:        "no locale",
+			prompt:      PromptSummarizeThreadSystem,
+			notContains: []string{"Their locale is", "Always respond in"},
+		},
+		{
+			name:        "user locale",
+			prompt:      PromptSummarizeThreadSystem,
+			locale:      "es",
+			contains:    []string{"Their locale is 'es'"},
+			notContains: []string{"Always respond in"},
+		},
+		{
+			name:           "bot output language overrides the user locale",
+			prompt:         PromptSummarizeThreadSystem,
+			locale:         "es",
+			outputLanguage: "German",
+			contains:       []string{"Always respond in German"},
+			notContains:    []string{"Their locale is"},
+		},
+		{
+			name:        "direct messages answer in the language of the message",
+			prompt:      PromptDirectMessageQuestionSystem,
+			locale:      "es",
+			notContains: []string{"Their locale is", "Always respond in"},
+		},
+		{
+			name:           "direct messages enforce the bot output language",
+			prompt:         PromptDirectMessageQuestionSystem,
+			locale:         "es",
+			outputLanguage: "German",
+			contains:       []string{"Always respond in German"},
+		},
+		{
+			name:     "search uses the user locale",
+			prompt:   PromptSearchSystem,
+			locale:   "fr",
+			contains: []string{"Their locale is 'fr'"},
+		},
+		{
+			name:     "meeting summaries use the user locale",
+			prompt:   PromptMeetingSummarySystem,
+			locale:   "fr",
+			contains: []string{"Their locale is 'fr'"},
+		},
+	}
+
+	prompts, err := llm.NewPrompts(PromptsFolder)
+	require.NoError(t, err)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			context := llm.NewContext()
+			context.RequestingUser = &model.User{Username: "user", Locale: tc.locale}
+			context.Locale = tc.locale
+			context.OutputLanguage = tc.outputLanguage
+			context.Parameters = map[string]interface{}{
+				"IsChunked": "false",
+				"Results":   []interface{}{},
+			}
+
+			result, err := prompts.Format(tc.prompt, context)
+			require.NoError(t, err)
+			for _, expected := range tc.contains {
+				assert.Contains(t, result, expected)
+			}
+			for _, unexpected := range tc.notContains {
+				assert.NotContains(t, result, unexpected)
+			}
+		})
+	}
+}
+
+func TestTeamInstructions(t *testing.T) {
+	tests := []struct {
+		name             string
+		team             *model.Team
+		teamInstructions string
+		contains         []string
+		notContains      []string
+	}{
+		{
+			name:        "no team instructions",
+			team:        &model.Team{DisplayName: "Sales"},
+			notContains: []string{"provided by the administrators of the team"},
+		},
+		{
+			name:             "team instructions are added",
+			team:             &model.Team{DisplayName: "Sales"},
+			teamInstructions: "Refer to customers as partners.",
+			contains: []string{
+				"provided by the administrators of the team 'Sales'",
+				"Refer to customers as partners.",
+			},
+		},
+	}
+
+	prompts, err := llm.NewPrompts(PromptsFolder)
+	require.NoError(t, err)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			context := llm.NewContext()
+			context.RequestingUser = &model.User{Username: "user"}
+			context.Team = tc.team
+			context.TeamInstructions = tc.teamInstructions
+
+			result, err := prompts.Format(PromptDirectMessageQuestionSystem, context)
+			require.NoError(t, err)
+			for _, expected := range tc.contains {
+				assert.Contains(t, result, expected)
+			}
+			for _, unexpected := range tc.notContains {
+				assert.NotContains(t, result, unexpected)
+			}
+		})
+	}
+}
+
+func TestContextDetails(t *testing.T) {
+	prompts, err := llm.NewPrompts(PromptsFolder)
+	require.NoError(t, err)
+
+	context := llm.NewContext()
+	context.RequestingUser = &model.User{Username: "user", Position: "Engineer"}
+	context.Channel = &model.Channel{Name: "town-square", DisplayName: "Town Square", Type: model.ChannelTypeOpen}
+
+	result, err := prompts.Format(PromptDirectMessageQuestionSystem, context)
+	require.NoError(t, err)
+	assert.Contains(t, result, "Current time and date in the user's location is")
+	assert.Contains(t, result, "(UTC time zone)")
+	assert.Contains(t, result, "Their position is 'Engineer'")
+	assert.Contains(t, result, "has the name 'town-square'")
+
+	context.Details = []llm.ContextDetail{
+		{Label: "Office hours", Text: "9am to 5pm CET"},
+	}
+	result, err = prompts.Format(PromptDirectMessageQuestionSystem, context)
+	require.NoError(t, err)
+	assert.Contains(t, result, "Office hours: 9am to 5pm CET")
+	assert.NotContains(t, result, "Current time and date in the user's location is")
+	assert.NotContains(t, result, "Their position is")
+	assert.NotContains(t, result, "town-square")
+}
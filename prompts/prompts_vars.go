@@ -6,25 +6,47 @@ package prompts
 // Automatically generated convenience vars for the filenames in prompts/
 const (
 	PromptCitationFormat                   = "citation_format"
+	PromptContextCheck                     = "context_check"
+	PromptConversationTitleSystem          = "conversation_title_system"
+	PromptDigestSystem                     = "digest_system"
+	PromptDigestUser                       = "digest_user"
 	PromptDirectMessageQuestionSystem      = "direct_message_question_system"
+	PromptDraftReplySystem                 = "draft_reply_system"
 	PromptEmojiSelectSystem                = "emoji_select_system"
+	PromptExplainPostSystem                = "explain_post_system"
+	PromptFaqSystem                        = "faq_system"
 	PromptFindActionItemsSystem            = "find_action_items_system"
 	PromptFindActionItemsUser              = "find_action_items_user"
 	PromptFindOpenQuestionsSystem          = "find_open_questions_system"
 	PromptFindOpenQuestionsUser            = "find_open_questions_user"
+	PromptFollowUpQuestionsSystem          = "follow_up_questions_system"
+	PromptFollowUpQuestionsUser            = "follow_up_questions_user"
+	PromptGuardrailCheckSystem             = "guardrail_check_system"
+	PromptImageTextSystem                  = "image_text_system"
+	PromptIncidentPostmortemSystem         = "incident_postmortem_system"
+	PromptIncidentSummarySystem            = "incident_summary_system"
+	PromptIntentClassifySystem             = "intent_classify_system"
 	PromptLocale                           = "locale"
 	PromptMeetingSummaryGeneral            = "meeting_summary_general"
 	PromptMeetingSummarySystem             = "meeting_summary_system"
 	PromptMeetingSummaryUser               = "meeting_summary_user"
+	PromptOutputLanguage                   = "output_language"
 	PromptSearchResults                    = "search_results"
 	PromptSearchSystem                     = "search_system"
 	PromptSearchUser                       = "search_user"
+	PromptSimplifyPostSystem               = "simplify_post_system"
 	PromptStandardPersonality              = "standard_personality"
 	PromptStandardPersonalityWithoutLocale = "standard_personality_without_locale"
+	PromptStandupSummarySystem             = "standup_summary_system"
 	PromptSummarizeChannelRangeSystem      = "summarize_channel_range_system"
 	PromptSummarizeChannelSinceSystem      = "summarize_channel_since_system"
 	PromptSummarizeChannelSystem           = "summarize_channel_system"
 	PromptSummarizeChunkSystem             = "summarize_chunk_system"
+	PromptSummarizeMentionsSystem          = "summarize_mentions_system"
 	PromptSummarizeThreadSystem            = "summarize_thread_system"
+	PromptSupportTriageSystem              = "support_triage_system"
 	PromptThreadUser                       = "thread_user"
+	PromptTranscriptTranslationSystem      = "transcript_translation_system"
+	PromptTranslatePostSystem              = "translate_post_system"
+	PromptVerificationCheckSystem          = "verification_check_system"
 )
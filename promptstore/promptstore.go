@@ -0,0 +1,257 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package promptstore stores admin edited, versioned overrides of the prompt templates.
+// The templates shipped with the plugin remain the fallback when no override is active.
+package promptstore
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// AllBots is the bot ID of overrides that apply to every bot without an override of its own.
+const AllBots = ""
+
+// cacheTTL bounds how long other cluster nodes may keep using a replaced override.
+const cacheTTL = 30 * time.Second
+
+// ErrVersionNotFound is returned when activating a version that does not exist.
+var ErrVersionNotFound = errors.New("prompt version not found")
+
+// Version is a saved revision of a prompt template for a bot.
+type Version struct {
+	ID        string `json:"id" db:"id"`
+	BotID     string `json:"bot_id" db:"botid"`
+	Name      string `json:"name" db:"name"`
+	Version   int    `json:"version" db:"version"`
+	Template  string `json:"template" db:"template"`
+	CreateAt  int64  `json:"create_at" db:"createat"`
+	CreatedBy string `json:"created_by" db:"createdby"`
+	Active    bool   `json:"active" db:"active"`
+}
+
+// Logger is the logging interface needed by the store.
+type Logger interface {
+	LogError(msg string, keyValuePairs ...interface{})
+}
+
+type cacheEntry struct {
+	overrides map[string]string
+	expiresAt time.Time
+}
+
+// Store persists prompt versions and serves the active ones as llm.PromptOverrides.
+type Store struct {
+	db  *mmapi.DBClient
+	log Logger
+
+	cacheLock sync.Mutex
+	cache     map[string]cacheEntry
+}
+
+// New creates a new prompt store.
+func New(db *mmapi.DBClient, log Logger) *Store {
+	return &Store{
+		db:    db,
+		log:   log,
+		cache: make(map[string]cacheEntry),
+	}
+}
+
+// GetPromptOverrides returns the active overrides for the bot, with bot specific overrides
+// taking precedence over the ones for all bots. Errors are logged and result in the defaults being used.
+func (s *Store) GetPromptOverrides(botID string) map[string]string {
+	s.cacheLock.Lock()
+	entry, ok := s.cache[botID]
+	s.cacheLock.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.overrides
+	}
+
+	active, err := s.activeVersions(sq.Eq{"BotID": []string{AllBots, botID}})
+	if err != nil {
+		s.log.LogError("Failed to load prompt overrides, using defaults", "bot_id", botID, "error", err)
+		return nil
+	}
+
+	overrides := make(map[string]string, len(active))
+	for _, version := range active {
+		if _, exists := overrides[version.Name]; exists && version.BotID == AllBots {
+			continue
+		}
+		overrides[version.Name] = version.Template
+	}
+
+	s.cacheLock.Lock()
+	s.cache[botID] = cacheEntry{overrides: overrides, expiresAt: time.Now().Add(cacheTTL)}
+	s.cacheLock.Unlock()
+
+	return overrides
+}
+
+func (s *Store) invalidateCache() {
+	s.cacheLock.Lock()
+	defer s.cacheLock.Unlock()
+	s.cache = make(map[string]cacheEntry)
+}
+
+func (s *Store) activeVersions(where sq.Sqlizer) ([]Version, error) {
+	var versions []Version
+	if err := s.db.DoQuery(&versions, s.db.Builder().
+		Select("ID", "BotID", "Name", "Version", "Template", "CreateAt", "CreatedBy", "Active").
+		From("LLM_PromptVersions").
+		Where(where).
+		Where(sq.Eq{"Active": true}).
+		OrderBy("BotID DESC"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to get active prompt versions: %w", err)
+	}
+	return versions, nil
+}
+
+// ListActive returns the active overrides set for exactly this bot ID.
+func (s *Store) ListActive(botID string) ([]Version, error) {
+	return s.activeVersions(sq.Eq{"BotID": botID})
+}
+
+// ListVersions returns all versions of a prompt for the bot, newest first.
+func (s *Store) ListVersions(botID, name string) ([]Version, error) {
+	versions := []Version{}
+	if err := s.db.DoQuery(&versions, s.db.Builder().
+		Select("ID", "BotID", "Name", "Version", "Template", "CreateAt", "CreatedBy", "Active").
+		From("LLM_PromptVersions").
+		Where(sq.Eq{"BotID": botID}).
+		Where(sq.Eq{"Name": name}).
+		OrderBy("Version DESC"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to get prompt versions: %w", err)
+	}
+	return versions, nil
+}
+
+// SaveVersion stores template as the next version of the prompt and makes it active.
+func (s *Store) SaveVersion(botID, name, template, userID string) (*Version, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var latest int
+	query, args, err := s.db.Builder().
+		Select("COALESCE(MAX(Version), 0)").
+		From("LLM_PromptVersions").
+		Where(sq.Eq{"BotID": botID}).
+		Where(sq.Eq{"Name": name}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sql: %w", err)
+	}
+	if err = tx.Get(&latest, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get latest prompt version: %w", err)
+	}
+
+	version := &Version{
+		ID:        model.NewId(),
+		BotID:     botID,
+		Name:      name,
+		Version:   latest + 1,
+		Template:  template,
+		CreateAt:  model.GetMillis(),
+		CreatedBy: userID,
+		Active:    true,
+	}
+
+	if err = execTx(tx, s.db.Builder().Update("LLM_PromptVersions").
+		Set("Active", false).
+		Where(sq.Eq{"BotID": botID}).
+		Where(sq.Eq{"Name": name})); err != nil {
+		return nil, fmt.Errorf("failed to deactivate prompt versions: %w", err)
+	}
+
+	if err = execTx(tx, s.db.Builder().Insert("LLM_PromptVersions").
+		Columns("ID", "BotID", "Name", "Version", "Template", "CreateAt", "CreatedBy", "Active").
+		Values(version.ID, version.BotID, version.Name, version.Version, version.Template, version.CreateAt, version.CreatedBy, version.Active)); err != nil {
+		return nil, fmt.Errorf("failed to save prompt version: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit prompt version: %w", err)
+	}
+	s.invalidateCache()
+
+	return version, nil
+}
+
+// ActivateVersion makes an earlier version of the prompt active again.
+func (s *Store) ActivateVersion(botID, name string, version int) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	query, args, err := s.db.Builder().
+		Update("LLM_PromptVersions").
+		Set("Active", sq.Expr("Version = ?", version)).
+		Where(sq.Eq{"BotID": botID}).
+		Where(sq.Eq{"Name": name}).
+		Where(sq.Expr("EXISTS (SELECT 1 FROM LLM_PromptVersions WHERE BotID = ? AND Name = ? AND Version = ?)", botID, name, version)).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql: %w", err)
+	}
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to activate prompt version: %w", err)
+	}
+	if rows, rowsErr := result.RowsAffected(); rowsErr == nil && rows == 0 {
+		return ErrVersionNotFound
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit prompt activation: %w", err)
+	}
+	s.invalidateCache()
+
+	return nil
+}
+
+// Reset deactivates all versions of the prompt so the default template is used again.
+// Versions are kept so they can be activated later.
+func (s *Store) Reset(botID, name string) error {
+	if _, err := s.db.ExecBuilder(s.db.Builder().Update("LLM_PromptVersions").
+		Set("Active", false).
+		Where(sq.Eq{"BotID": botID}).
+		Where(sq.Eq{"Name": name})); err != nil {
+		return fmt.Errorf("failed to reset prompt: %w", err)
+	}
+	s.invalidateCache()
+
+	return nil
+}
+
+type sqlBuilder interface {
+	ToSql() (string, []any, error)
+}
+
+func execTx(tx *sqlx.Tx, b sqlBuilder) error {
+	query, args, err := b.ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build sql: %w", err)
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
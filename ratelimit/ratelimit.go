@@ -0,0 +1,104 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package ratelimit limits how often users may call the plugin API.
+package ratelimit
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when a request exceeds a rate limit.
+var ErrRateLimited = errors.New("too many requests")
+
+// Config configures the rate limits of the plugin API. Limits are enforced per server node,
+// zero means unlimited.
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// UserRequestsPerMinute limits the requests a user makes to all endpoints combined.
+	UserRequestsPerMinute int `json:"userRequestsPerMinute"`
+	// EndpointRequestsPerMinute limits the requests a user makes to any single endpoint.
+	EndpointRequestsPerMinute int `json:"endpointRequestsPerMinute"`
+	// EndpointOverrides replaces EndpointRequestsPerMinute for the endpoints it lists, keyed by
+	// route such as "/post/:postid/analyze".
+	EndpointOverrides map[string]int `json:"endpointOverrides"`
+}
+
+// EndpointLimit returns the per user limit of the endpoint with the given route.
+func (c Config) EndpointLimit(route string) int {
+	if limit, ok := c.EndpointOverrides[route]; ok {
+		return limit
+	}
+	return c.EndpointRequestsPerMinute
+}
+
+// sweepInterval is how often buckets that refilled completely are forgotten.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter tracks a token bucket per key. A bucket holds a minute worth of requests and
+// refills continuously, so short bursts are allowed as long as the rate is respected.
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter creates an empty limiter.
+func NewLimiter() *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from the bucket of key, which allows perMinute requests per minute. When
+// the bucket is empty it returns false and how long to wait before retrying.
+func (l *Limiter) Allow(key string, perMinute int, now time.Time) (bool, time.Duration) {
+	if perMinute <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	rate := float64(perMinute) / time.Minute.Seconds()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(perMinute), updated: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(float64(perMinute), b.tokens+elapsed*rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep forgets the buckets idle for a minute, which have refilled completely, so the limiter
+// does not grow with every user that ever made a request.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+}
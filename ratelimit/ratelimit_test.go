@@ -0,0 +1,79 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	start := time.Now()
+
+	type attempt struct {
+		key        string
+		after      time.Duration
+		allowed    bool
+		retryAfter time.Duration
+	}
+
+	tests := []struct {
+		name      string
+		perMinute int
+		attempts  []attempt
+	}{
+		{
+			name:      "unlimited",
+			perMinute: 0,
+			attempts: []attempt{
+				{key: "a", allowed: true},
+				{key: "a", allowed: true},
+			},
+		},
+		{
+			name:      "burst up to the limit then wait for a token",
+			perMinute: 2,
+			attempts: []attempt{
+				{key: "a", allowed: true},
+				{key: "a", allowed: true},
+				{key: "a", allowed: false, retryAfter: 30 * time.Second},
+				{key: "a", after: 10 * time.Second, allowed: false, retryAfter: 20 * time.Second},
+				{key: "a", after: 30 * time.Second, allowed: true},
+			},
+		},
+		{
+			name:      "keys are independent",
+			perMinute: 1,
+			attempts: []attempt{
+				{key: "a", allowed: true},
+				{key: "b", allowed: true},
+				{key: "a", allowed: false, retryAfter: time.Minute},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			limiter := NewLimiter()
+			for i, a := range tc.attempts {
+				allowed, retryAfter := limiter.Allow(a.key, tc.perMinute, start.Add(a.after))
+				assert.Equal(t, a.allowed, allowed, "attempt %d", i)
+				assert.InDelta(t, a.retryAfter, retryAfter, float64(time.Millisecond), "attempt %d", i)
+			}
+		})
+	}
+}
+
+func TestLimiterSweepsIdleBuckets(t *testing.T) {
+	start := time.Now()
+	limiter := NewLimiter()
+
+	limiter.Allow("a", 1, start)
+	limiter.Allow("b", 1, start.Add(2*time.Minute))
+
+	assert.NotContains(t, limiter.buckets, "a")
+	assert.Contains(t, limiter.buckets, "b")
+}
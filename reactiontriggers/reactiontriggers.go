@@ -0,0 +1,377 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package reactiontriggers runs AI actions on the posts users react to with configured emoji,
+// such as summarizing a thread when reacted to with :robot_face:. The results are sent to the
+// reacting user by direct message, so the actions are a tap away on mobile.
+package reactiontriggers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost-plugin-ai/threads"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+// Actions run by reactions
+const (
+	ActionSummarizeThread = "summarize_thread"
+	ActionTranslate       = "translate"
+	ActionExplain         = "explain"
+)
+
+const (
+	// DefaultDebounceSeconds is how long before a user can run the same action on a post again
+	DefaultDebounceSeconds = 60
+
+	debounceKeyPrefix = "reaction_trigger_"
+)
+
+// DefaultTriggers are used when the configuration doesn't list its own
+var DefaultTriggers = []config.ReactionTrigger{
+	{EmojiName: "robot_face", Action: ActionSummarizeThread},
+	{EmojiName: "globe_with_meridians", Action: ActionTranslate},
+	{EmojiName: "bulb", Action: ActionExplain},
+}
+
+// errNotTriggered is returned for the reactions that don't run an action, such as those of bots.
+var errNotTriggered = errors.New("reaction doesn't trigger an action")
+
+// Config is the configuration the triggers are read from
+type Config interface {
+	GetReactionTriggers() config.ReactionTriggersConfig
+	GetDefaultBotName() string
+}
+
+// BotSource returns the agent running the actions
+type BotSource interface {
+	GetBotByUsernameOrFirst(botUsername string) *bots.Bot
+	IsAnyBot(userID string) bool
+	CheckUsageRestrictions(requestingUserID string, bot *bots.Bot, channel *model.Channel) error
+	ChannelPolicy(channel *model.Channel) (exclusions.ChannelPolicy, error)
+}
+
+// ContextBuilder builds the LLM context of the actions
+type ContextBuilder interface {
+	BuildLLMContextUserRequest(bot *bots.Bot, requestingUser *model.User, channel *model.Channel, opts ...llm.ContextOption) *llm.Context
+	WithLLMContextNoTools() llm.ContextOption
+}
+
+// Conversations saves the thread summaries, so replies to them follow up on the thread
+type Conversations interface {
+	SaveConversation(conversation conversations.Conversation) error
+	SaveTitleAsync(threadID, title string)
+}
+
+// UsageTracker records the usage of the actions
+type UsageTracker interface {
+	TrackStream(stream *llm.TextStreamResult, event analytics.Event) *llm.TextStreamResult
+}
+
+// KVStore stores when users last ran an action on a post
+type KVStore interface {
+	Set(key string, value any, options ...pluginapi.KVSetOption) (bool, error)
+}
+
+// Service runs the actions of the reactions added to posts
+type Service struct {
+	client         mmapi.Client
+	bots           BotSource
+	contextBuilder ContextBuilder
+	prompts        *llm.Prompts
+	streaming      streaming.Service
+	conversations  Conversations
+	usage          UsageTracker
+	kv             KVStore
+	license        enterprise.FeatureChecker
+	images         threads.ImageDescriber
+	links          threads.LinkResolver
+	i18n           *i18n.Bundle
+	config         Config
+}
+
+// New creates a new reaction triggers service
+func New(
+	client mmapi.Client,
+	bots BotSource,
+	contextBuilder ContextBuilder,
+	prompts *llm.Prompts,
+	streamingService streaming.Service,
+	conversationsService Conversations,
+	usage UsageTracker,
+	kv KVStore,
+	i18nBundle *i18n.Bundle,
+	config Config,
+) *Service {
+	return &Service{
+		client:         client,
+		bots:           bots,
+		contextBuilder: contextBuilder,
+		prompts:        prompts,
+		streaming:      streamingService,
+		conversations:  conversationsService,
+		usage:          usage,
+		kv:             kv,
+		i18n:           i18nBundle,
+		config:         config,
+	}
+}
+
+// SetLicenseChecker restricts thread summaries to the licenses including thread analysis.
+func (s *Service) SetLicenseChecker(license enterprise.FeatureChecker) {
+	s.license = license
+}
+
+// SetImageDescriber describes the images attached to the summarized threads.
+func (s *Service) SetImageDescriber(images threads.ImageDescriber) {
+	s.images = images
+}
+
+// SetLinkResolver adds an excerpt of the posts linked in the summarized threads.
+func (s *Service) SetLinkResolver(links threads.LinkResolver) {
+	s.links = links
+}
+
+// MatchTrigger returns the trigger of the emoji among the triggers, or DefaultTriggers when
+// there are none.
+func MatchTrigger(triggers []config.ReactionTrigger, emojiName string) (config.ReactionTrigger, bool) {
+	if len(triggers) == 0 {
+		triggers = DefaultTriggers
+	}
+	for _, trigger := range triggers {
+		if strings.EqualFold(strings.Trim(trigger.EmojiName, ": "), emojiName) {
+			return trigger, true
+		}
+	}
+	return config.ReactionTrigger{}, false
+}
+
+// ReactionHasBeenAdded runs the action of the emoji, if any, on the post reacted to
+func (s *Service) ReactionHasBeenAdded(ctx context.Context, reaction *model.Reaction) {
+	if err := s.run(ctx, reaction); err != nil && !errors.Is(err, errNotTriggered) {
+		s.client.LogError("Failed to run reaction trigger", "post_id", reaction.PostId, "emoji", reaction.EmojiName, "error", err)
+	}
+}
+
+func (s *Service) run(ctx context.Context, reaction *model.Reaction) error {
+	cfg := s.config.GetReactionTriggers()
+	if !cfg.Enabled {
+		return errNotTriggered
+	}
+	trigger, ok := MatchTrigger(cfg.Triggers, reaction.EmojiName)
+	if !ok || s.bots.IsAnyBot(reaction.UserId) {
+		return errNotTriggered
+	}
+
+	post, err := s.client.GetPost(reaction.PostId)
+	if err != nil {
+		return fmt.Errorf("failed to get post: %w", err)
+	}
+	if len(cfg.ChannelIDs) > 0 && !slices.Contains(cfg.ChannelIDs, post.ChannelId) {
+		return errNotTriggered
+	}
+
+	channel, err := s.client.GetChannel(post.ChannelId)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	user, err := s.client.GetUser(reaction.UserId)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.IsBot {
+		return errNotTriggered
+	}
+
+	botUsername := cfg.BotUsername
+	if botUsername == "" {
+		botUsername = s.config.GetDefaultBotName()
+	}
+	bot := s.bots.GetBotByUsernameOrFirst(botUsername)
+	if bot == nil {
+		return errors.New("no agent to run the action with")
+	}
+	if err = s.bots.CheckUsageRestrictions(user.Id, bot, channel); err != nil {
+		return errNotTriggered
+	}
+
+	// Reactions can't ask for the confirmation some channels require before their content is
+	// sent, so the actions don't run in those channels
+	policy, err := s.bots.ChannelPolicy(channel)
+	if err != nil {
+		return err
+	}
+	if policy.Disabled || policy.RequireConfirmation {
+		return errNotTriggered
+	}
+
+	if trigger.Action == ActionSummarizeThread && s.license != nil {
+		if err = s.license.CheckFeature(enterprise.FeatureThreadAnalysis); err != nil {
+			return errNotTriggered
+		}
+	}
+
+	if debounced, err := s.debounce(cfg, user.Id, post.Id, trigger.Action); err != nil || debounced {
+		return err
+	}
+
+	llmContext := s.contextBuilder.BuildLLMContextUserRequest(bot, user, channel, s.contextBuilder.WithLLMContextNoTools())
+	switch trigger.Action {
+	case ActionSummarizeThread:
+		return s.summarizeThread(ctx, bot, user, channel, policy, post, llmContext)
+	case ActionTranslate:
+		return s.translate(ctx, bot, user, channel, policy, post, trigger.Language, llmContext)
+	case ActionExplain:
+		return s.explain(ctx, bot, user, channel, policy, post, llmContext)
+	default:
+		return fmt.Errorf("unknown action %q", trigger.Action)
+	}
+}
+
+// debounce returns whether the user already ran the action on the post recently, recording the
+// run otherwise. Adding, removing and adding the reaction again then runs the action once.
+func (s *Service) debounce(cfg config.ReactionTriggersConfig, userID, postID, action string) (bool, error) {
+	seconds := cfg.DebounceSeconds
+	if seconds <= 0 {
+		seconds = DefaultDebounceSeconds
+	}
+
+	key := debounceKey(userID, postID, action)
+	set, err := s.kv.Set(key, model.GetMillis(), pluginapi.SetAtomic(nil), pluginapi.SetExpiry(time.Duration(seconds)*time.Second))
+	if err != nil {
+		return false, fmt.Errorf("failed to debounce reaction: %w", err)
+	}
+	return !set, nil
+}
+
+func debounceKey(userID, postID, action string) string {
+	return debounceKeyPrefix + userID + "_" + postID + "_" + action
+}
+
+// summarizeThread DMs the user a summary of the thread of the post, which they can follow up on
+// like the summaries requested from the menu
+func (s *Service) summarizeThread(ctx context.Context, bot *bots.Bot, user *model.User, channel *model.Channel, policy exclusions.ChannelPolicy, post *model.Post, llmContext *llm.Context) error {
+	analyzer := threads.New(bot.FeatureLLM(analytics.FeatureThreadAnalysis), s.prompts, s.client)
+	analyzer.SetChannelPolicy(policy)
+	if s.images != nil {
+		analyzer.SetImageDescriber(s.images, bot)
+	}
+	if s.links != nil {
+		analyzer.SetLinkResolver(s.links, user.Id)
+	}
+	stream, err := analyzer.Summarize(ctx, post.Id, llmContext)
+	if err != nil {
+		return fmt.Errorf("failed to summarize thread: %w", err)
+	}
+	stream = s.usage.TrackStream(stream, analytics.NewEvent(analytics.FeatureThreadAnalysis, bot.GetMMBot().UserId, user.Id, channel))
+
+	summaryPost := &model.Post{}
+	summaryPost.AddProp(conversations.ThreadIDProp, post.Id)
+	summaryPost.AddProp(conversations.AnalysisTypeProp, ActionSummarizeThread)
+	if err := s.streaming.StreamToNewDM(streaming.WithFeature(ctx, analytics.FeatureThreadAnalysis), bot.GetMMBot().UserId, stream, user.Id, summaryPost, post.Id); err != nil {
+		return err
+	}
+
+	if err := s.conversations.SaveConversation(conversations.Conversation{
+		ID:        summaryPost.Id,
+		BotID:     bot.GetMMBot().UserId,
+		UserID:    user.Id,
+		ChannelID: summaryPost.ChannelId,
+		State: conversations.ConversationState{
+			ThreadID:     post.Id,
+			AnalysisType: ActionSummarizeThread,
+		},
+	}); err != nil {
+		s.client.LogError("Failed to save thread summary conversation", "error", err)
+	}
+
+	T := i18n.LocalizerFunc(s.i18n, user.Locale)
+	s.conversations.SaveTitleAsync(summaryPost.Id, T("agents.title_thread_summary", "Thread Summary"))
+	return nil
+}
+
+// translate DMs the user the post translated into language, or into the language of the user
+func (s *Service) translate(ctx context.Context, bot *bots.Bot, user *model.User, channel *model.Channel, policy exclusions.ChannelPolicy, post *model.Post, language string, llmContext *llm.Context) error {
+	if err := s.checkAuthor(policy, post); err != nil {
+		return err
+	}
+
+	llmContext.Parameters = map[string]any{"Language": language}
+	systemPrompt, err := s.prompts.Format(prompts.PromptTranslatePostSystem, llmContext)
+	if err != nil {
+		return fmt.Errorf("failed to format system prompt: %w", err)
+	}
+
+	stream, err := bot.FeatureLLM(analytics.FeatureReactionTrigger).ChatCompletion(ctx, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{
+				Role:    llm.PostRoleSystem,
+				Message: systemPrompt,
+			},
+			{
+				Role:    llm.PostRoleUser,
+				Message: format.PostBody(post),
+			},
+		},
+		Context: llmContext,
+	}, llm.WithToolsDisabled())
+	if err != nil {
+		return fmt.Errorf("failed to translate post: %w", err)
+	}
+
+	return s.streamToDM(ctx, bot, user, channel, post, stream)
+}
+
+// explain DMs the user an explanation of the post, with its thread as context
+func (s *Service) explain(ctx context.Context, bot *bots.Bot, user *model.User, channel *model.Channel, policy exclusions.ChannelPolicy, post *model.Post, llmContext *llm.Context) error {
+	if err := s.checkAuthor(policy, post); err != nil {
+		return err
+	}
+
+	explainer := threads.New(bot.FeatureLLM(analytics.FeatureReactionTrigger), s.prompts, s.client)
+	explainer.SetChannelPolicy(policy)
+	stream, err := explainer.Explain(ctx, post, llmContext)
+	if err != nil {
+		return fmt.Errorf("failed to explain post: %w", err)
+	}
+
+	return s.streamToDM(ctx, bot, user, channel, post, stream)
+}
+
+// checkAuthor leaves out the posts whose author the channel policy excludes
+func (s *Service) checkAuthor(policy exclusions.ChannelPolicy, post *model.Post) error {
+	if !policy.ExcludesAuthors() {
+		return nil
+	}
+	author, err := s.client.GetUser(post.UserId)
+	if err != nil {
+		return fmt.Errorf("failed to get author: %w", err)
+	}
+	if policy.ExcludesPost(post, author) {
+		return errNotTriggered
+	}
+	return nil
+}
+
+func (s *Service) streamToDM(ctx context.Context, bot *bots.Bot, user *model.User, channel *model.Channel, post *model.Post, stream *llm.TextStreamResult) error {
+	stream = s.usage.TrackStream(stream, analytics.NewEvent(analytics.FeatureReactionTrigger, bot.GetMMBot().UserId, user.Id, channel))
+	return s.streaming.StreamToNewDM(streaming.WithFeature(ctx, analytics.FeatureReactionTrigger), bot.GetMMBot().UserId, stream, user.Id, &model.Post{}, post.Id)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package reactiontriggers
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeKV struct {
+	keys map[string]bool
+}
+
+func (f *fakeKV) Set(key string, value any, options ...pluginapi.KVSetOption) (bool, error) {
+	if f.keys[key] {
+		return false, nil
+	}
+	f.keys[key] = true
+	return true, nil
+}
+
+func TestMatchTrigger(t *testing.T) {
+	trigger, ok := MatchTrigger(nil, "robot_face")
+	require.True(t, ok)
+	require.Equal(t, ActionSummarizeThread, trigger.Action)
+
+	_, ok = MatchTrigger(nil, "thumbsup")
+	require.False(t, ok)
+
+	triggers := []config.ReactionTrigger{
+		{EmojiName: ":flag-fr:", Action: ActionTranslate, Language: "French"},
+	}
+	trigger, ok = MatchTrigger(triggers, "flag-fr")
+	require.True(t, ok)
+	require.Equal(t, ActionTranslate, trigger.Action)
+	require.Equal(t, "French", trigger.Language)
+
+	// Configured triggers replace the default ones
+	_, ok = MatchTrigger(triggers, "robot_face")
+	require.False(t, ok)
+}
+
+func TestDebounce(t *testing.T) {
+	service := &Service{kv: &fakeKV{keys: map[string]bool{}}}
+	cfg := config.ReactionTriggersConfig{DebounceSeconds: 30}
+
+	debounced, err := service.debounce(cfg, "user1", "post1", ActionSummarizeThread)
+	require.NoError(t, err)
+	require.False(t, debounced)
+
+	debounced, err = service.debounce(cfg, "user1", "post1", ActionSummarizeThread)
+	require.NoError(t, err)
+	require.True(t, debounced)
+
+	// Other actions and users aren't debounced
+	debounced, err = service.debounce(cfg, "user1", "post1", ActionExplain)
+	require.NoError(t, err)
+	require.False(t, debounced)
+	debounced, err = service.debounce(cfg, "user2", "post1", ActionSummarizeThread)
+	require.NoError(t, err)
+	require.False(t, debounced)
+}
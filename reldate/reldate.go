@@ -0,0 +1,270 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package reldate resolves relative date expressions such as "since Monday" or "tomorrow at
+// 9am" in a time zone, so models don't have to work out dates themselves. Weeks start on Monday.
+package reldate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxExpressionLength is the longest expression resolved
+const MaxExpressionLength = 200
+
+// Supported describes the expressions Resolve understands, for the errors returned to models
+const Supported = `now, today, yesterday, tomorrow, a weekday ("monday", "last friday", "next tuesday"), ` +
+	`this/last/next week, month or year, "3 days ago", "in 2 hours", "past 24 hours", "last 3 weeks", ` +
+	`a date ("2024-03-01"), each optionally prefixed with "since" and followed by a time ("at 9am", "at 14:30")`
+
+// Range is the period an expression refers to. Start and End are equal for instants, such as
+// "in 2 hours" or "tomorrow at 9am". End is exclusive otherwise.
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+// IsInstant reports whether the expression referred to a single point in time
+func (r Range) IsInstant() bool {
+	return r.Start.Equal(r.End)
+}
+
+var (
+	timeSuffix = regexp.MustCompile(`^(.*?)\s+at\s+(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+	amount     = regexp.MustCompile(`^(\d+|a|an|one)\s+(minute|hour|day|week|month|year)s?$`)
+	weekdays   = map[string]time.Weekday{}
+)
+
+func init() {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		weekdays[name] = day
+		weekdays[name[:3]] = day
+	}
+}
+
+// Resolve returns the period the expression refers to, relative to now in the location of now
+func Resolve(expression string, now time.Time) (Range, error) {
+	if len(expression) > MaxExpressionLength {
+		return Range{}, fmt.Errorf("the expression is longer than %d characters", MaxExpressionLength)
+	}
+	expression = strings.Join(strings.Fields(strings.ToLower(expression)), " ")
+	if expression == "" {
+		return Range{}, errors.New("the expression is empty")
+	}
+
+	for _, prefix := range []string{"since ", "from "} {
+		if rest, ok := strings.CutPrefix(expression, prefix); ok {
+			period, err := Resolve(rest, now)
+			if err != nil {
+				return Range{}, err
+			}
+			if period.Start.After(now) {
+				return Range{}, fmt.Errorf("%q is in the future", rest)
+			}
+			return Range{Start: period.Start, End: now}, nil
+		}
+	}
+
+	// A time of day turns a day into an instant
+	if match := timeSuffix.FindStringSubmatch(expression); match != nil {
+		period, err := resolvePeriod(match[1], now)
+		if err != nil {
+			return Range{}, err
+		}
+		hour, minute, err := parseTimeOfDay(match[2], match[3], match[4])
+		if err != nil {
+			return Range{}, err
+		}
+		// Days last 23 to 25 hours with daylight saving time changes
+		if length := period.End.Sub(period.Start); length < 23*time.Hour || length > 25*time.Hour {
+			return Range{}, fmt.Errorf("a time can only follow a day, not %q", match[1])
+		}
+		instant := time.Date(period.Start.Year(), period.Start.Month(), period.Start.Day(), hour, minute, 0, 0, now.Location())
+		return Range{Start: instant, End: instant}, nil
+	}
+
+	return resolvePeriod(expression, now)
+}
+
+func resolvePeriod(expression string, now time.Time) (Range, error) {
+	today := startOfDay(now)
+
+	switch expression {
+	case "now":
+		return Range{Start: now, End: now}, nil
+	case "today":
+		return day(today), nil
+	case "yesterday":
+		return day(today.AddDate(0, 0, -1)), nil
+	case "tomorrow":
+		return day(today.AddDate(0, 0, 1)), nil
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02", expression, now.Location()); err == nil {
+		return day(date), nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02 15:04", expression, now.Location()); err == nil {
+		return Range{Start: date, End: date}, nil
+	}
+
+	if rest, ok := strings.CutSuffix(expression, " ago"); ok {
+		count, unit, err := parseAmount(rest)
+		if err != nil {
+			return Range{}, err
+		}
+		return unitAt(shift(now, unit, -count), unit), nil
+	}
+	if rest, ok := strings.CutPrefix(expression, "in "); ok {
+		count, unit, err := parseAmount(rest)
+		if err != nil {
+			return Range{}, err
+		}
+		return unitAt(shift(now, unit, count), unit), nil
+	}
+
+	qualifier, name, found := strings.Cut(expression, " ")
+	if !found {
+		qualifier, name = "", expression
+	}
+
+	// "past 3 days" and "last 3 days" end now
+	if qualifier == "past" || qualifier == "last" {
+		if count, unit, err := parseAmount(name); err == nil {
+			return Range{Start: shift(now, unit, -count), End: now}, nil
+		}
+		if qualifier == "past" {
+			if _, ok := units[name]; ok {
+				return Range{Start: shift(now, name, -1), End: now}, nil
+			}
+		}
+	}
+
+	if weekday, ok := weekdays[name]; ok {
+		// Days back to the most recent such weekday, today included
+		back := (int(today.Weekday()) - int(weekday) + 7) % 7
+		switch qualifier {
+		case "", "this":
+			return day(today.AddDate(0, 0, -back)), nil
+		case "last":
+			if back == 0 {
+				back = 7
+			}
+			return day(today.AddDate(0, 0, -back)), nil
+		case "next":
+			ahead := (int(weekday) - int(today.Weekday()) + 7) % 7
+			if ahead == 0 {
+				ahead = 7
+			}
+			return day(today.AddDate(0, 0, ahead)), nil
+		}
+	}
+
+	if _, ok := units[name]; ok && name != "minute" && name != "hour" {
+		offsets := map[string]int{"this": 0, "last": -1, "next": 1}
+		if offset, ok := offsets[qualifier]; ok {
+			return unitAt(shift(now, name, offset), name), nil
+		}
+	}
+
+	return Range{}, fmt.Errorf("unable to understand %q, supported expressions: %s", expression, Supported)
+}
+
+var units = map[string]bool{"minute": true, "hour": true, "day": true, "week": true, "month": true, "year": true}
+
+func parseAmount(value string) (int, string, error) {
+	match := amount.FindStringSubmatch(value)
+	if match == nil {
+		return 0, "", fmt.Errorf("unable to understand %q, expected an amount such as \"3 days\"", value)
+	}
+
+	count := 1
+	if number, err := strconv.Atoi(match[1]); err == nil {
+		count = number
+	}
+	if count > 10000 {
+		return 0, "", fmt.Errorf("%d is too many", count)
+	}
+	return count, match[2], nil
+}
+
+func parseTimeOfDay(hourValue, minuteValue, meridiem string) (int, int, error) {
+	hour, _ := strconv.Atoi(hourValue)
+	minute := 0
+	if minuteValue != "" {
+		minute, _ = strconv.Atoi(minuteValue)
+	}
+
+	switch meridiem {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, fmt.Errorf("invalid hour %d%s", hour, meridiem)
+		}
+		hour %= 12
+		if meridiem == "pm" {
+			hour += 12
+		}
+	default:
+		if hour > 23 {
+			return 0, 0, fmt.Errorf("invalid hour %d", hour)
+		}
+	}
+	if minute > 59 {
+		return 0, 0, fmt.Errorf("invalid minute %d", minute)
+	}
+	return hour, minute, nil
+}
+
+// shift moves t by count units
+func shift(t time.Time, unit string, count int) time.Time {
+	switch unit {
+	case "minute":
+		return t.Add(time.Duration(count) * time.Minute)
+	case "hour":
+		return t.Add(time.Duration(count) * time.Hour)
+	case "day":
+		return t.AddDate(0, 0, count)
+	case "week":
+		return t.AddDate(0, 0, 7*count)
+	case "month":
+		// The day is clamped to the length of the month so the 31st doesn't overflow into the next one
+		first := time.Date(t.Year(), t.Month()+time.Month(count), 1, 0, 0, 0, 0, t.Location())
+		lastDay := first.AddDate(0, 1, -1).Day()
+		return time.Date(first.Year(), first.Month(), min(t.Day(), lastDay), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	default:
+		return t.AddDate(count, 0, 0)
+	}
+}
+
+// unitAt returns the calendar unit containing t: the day, week, month or year. Minutes and
+// hours are instants.
+func unitAt(t time.Time, unit string) Range {
+	switch unit {
+	case "day":
+		return day(startOfDay(t))
+	case "week":
+		start := startOfDay(t).AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+		return Range{Start: start, End: start.AddDate(0, 0, 7)}
+	case "month":
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		return Range{Start: start, End: start.AddDate(0, 1, 0)}
+	case "year":
+		start := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+		return Range{Start: start, End: start.AddDate(1, 0, 0)}
+	default:
+		return Range{Start: t, End: t}
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func day(start time.Time) Range {
+	return Range{Start: start, End: start.AddDate(0, 0, 1)}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package reldate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// A Wednesday
+	now := time.Date(2024, time.March, 13, 15, 30, 0, 0, location)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, location)
+	}
+
+	tests := []struct {
+		expression  string
+		start       time.Time
+		end         time.Time
+		expectError bool
+	}{
+		{expression: "now", start: now, end: now},
+		{expression: "Today", start: at(time.March, 13, 0, 0), end: at(time.March, 14, 0, 0)},
+		{expression: "yesterday", start: at(time.March, 12, 0, 0), end: at(time.March, 13, 0, 0)},
+		{expression: "since Monday", start: at(time.March, 11, 0, 0), end: now},
+		{expression: "since wednesday", start: at(time.March, 13, 0, 0), end: now},
+		{expression: "last wednesday", start: at(time.March, 6, 0, 0), end: at(time.March, 7, 0, 0)},
+		{expression: "next monday", start: at(time.March, 18, 0, 0), end: at(time.March, 19, 0, 0)},
+		{expression: "friday", start: at(time.March, 8, 0, 0), end: at(time.March, 9, 0, 0)},
+		{expression: "tomorrow at 9am", start: at(time.March, 14, 9, 0), end: at(time.March, 14, 9, 0)},
+		{expression: "next friday at 14:30", start: at(time.March, 15, 14, 30), end: at(time.March, 15, 14, 30)},
+		{expression: "since yesterday at 12pm", start: at(time.March, 12, 12, 0), end: now},
+		{expression: "this week", start: at(time.March, 11, 0, 0), end: at(time.March, 18, 0, 0)},
+		{expression: "last week", start: at(time.March, 4, 0, 0), end: at(time.March, 11, 0, 0)},
+		{expression: "last month", start: at(time.February, 1, 0, 0), end: at(time.March, 1, 0, 0)},
+		{expression: "next year", start: time.Date(2025, time.January, 1, 0, 0, 0, 0, location), end: time.Date(2026, time.January, 1, 0, 0, 0, 0, location)},
+		{expression: "3 days ago", start: at(time.March, 10, 0, 0), end: at(time.March, 11, 0, 0)},
+		{expression: "in 2 hours", start: at(time.March, 13, 17, 30), end: at(time.March, 13, 17, 30)},
+		{expression: "an hour ago", start: at(time.March, 13, 14, 30), end: at(time.March, 13, 14, 30)},
+		{expression: "past 24 hours", start: at(time.March, 12, 15, 30), end: now},
+		{expression: "last 2 weeks", start: at(time.February, 28, 15, 30), end: now},
+		{expression: "past month", start: at(time.February, 13, 15, 30), end: now},
+		{expression: "2024-03-01", start: at(time.March, 1, 0, 0), end: at(time.March, 2, 0, 0)},
+		{expression: "since 2024-03-01 08:00", start: at(time.March, 1, 8, 0), end: now},
+		{expression: "since tomorrow", expectError: true},
+		{expression: "this week at 9am", expectError: true},
+		{expression: "tomorrow at 13pm", expectError: true},
+		{expression: "the day after the release", expectError: true},
+		{expression: "", expectError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expression, func(t *testing.T) {
+			period, err := Resolve(tc.expression, now)
+			if tc.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, tc.start.Equal(period.Start), "start %s, expected %s", period.Start, tc.start)
+			require.True(t, tc.end.Equal(period.End), "end %s, expected %s", period.End, tc.end)
+		})
+	}
+}
+
+func TestResolveMonthEnd(t *testing.T) {
+	now := time.Date(2024, time.March, 31, 10, 0, 0, 0, time.UTC)
+
+	period, err := Resolve("past month", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, time.February, 29, 10, 0, 0, 0, time.UTC), period.Start)
+
+	period, err = Resolve("last month", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), period.Start)
+}
+
+func TestResolveDaylightSavingTime(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// Clocks moved forward on March 10, 2024
+	now := time.Date(2024, time.March, 11, 9, 0, 0, 0, location)
+
+	period, err := Resolve("yesterday at 9am", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, time.March, 10, 9, 0, 0, 0, location), period.Start)
+	require.True(t, period.IsInstant())
+}
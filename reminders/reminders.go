@@ -0,0 +1,265 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package reminders delivers the reminders users ask agents to schedule, such as "remind me
+// about this thread tomorrow at 9am". When a reminder is due, the agent sends it to the user by
+// direct message with a link to the thread it is about, and the reminder is deleted.
+//
+// Reminders are stored in the LLM_Reminders table. Only one node of the cluster polls them at a
+// time, so each reminder is delivered once.
+package reminders
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	// PollInterval is how often due reminders are delivered
+	PollInterval = time.Minute
+	// MaxMessageLength is the maximum length of the message of a reminder
+	MaxMessageLength = 1000
+	// MaxPendingPerUser is the maximum number of reminders a user can have scheduled
+	MaxPendingPerUser = 50
+	// MaxAhead is how far in the future reminders can be scheduled
+	MaxAhead = 366 * 24 * time.Hour
+	// ReminderProp is the post prop marking delivered reminders, set to the ID of the reminder
+	ReminderProp = "reminder"
+
+	// deliverBatchSize bounds the reminders delivered by each poll
+	deliverBatchSize = 100
+)
+
+var (
+	// ErrNotFound is returned when canceling a reminder that does not exist or belongs to another user.
+	ErrNotFound = errors.New("reminder not found")
+	// ErrInvalid is returned when scheduling a reminder with an invalid message or time.
+	ErrInvalid = errors.New("invalid reminder")
+	// ErrTooMany is returned when the user already has MaxPendingPerUser reminders scheduled.
+	ErrTooMany = fmt.Errorf("at most %d reminders can be scheduled", MaxPendingPerUser)
+)
+
+// Reminder is a message an agent sends to a user at a given time.
+type Reminder struct {
+	ID     string `json:"id" db:"id"`
+	UserID string `json:"user_id" db:"userid"`
+	BotID  string `json:"bot_id" db:"botid"`
+	// PostID is the root post of the thread the reminder is about, empty for none
+	PostID  string `json:"post_id" db:"postid"`
+	Message string `json:"message" db:"message"`
+	// RemindAt is when the reminder is sent, in milliseconds
+	RemindAt int64 `json:"remind_at" db:"remindat"`
+	CreateAt int64 `json:"create_at" db:"createat"`
+}
+
+// Service schedules, lists and delivers reminders.
+type Service struct {
+	db       *mmapi.DBClient
+	client   mmapi.Client
+	i18n     *i18n.Bundle
+	mutexAPI cluster.MutexPluginAPI
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+// New creates a new reminders service. Call Start to begin delivering reminders.
+func New(db *mmapi.DBClient, client mmapi.Client, i18nBundle *i18n.Bundle, mutexAPI cluster.MutexPluginAPI) *Service {
+	return &Service{
+		db:       db,
+		client:   client,
+		i18n:     i18nBundle,
+		mutexAPI: mutexAPI,
+	}
+}
+
+// Start delivers the due reminders every interval.
+func (s *Service) Start(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.Poll()
+			}
+		}
+	}()
+}
+
+// Stop stops delivering reminders.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// validate trims the message of the reminder and checks it is due between now and MaxAhead.
+func validate(reminder *Reminder, now time.Time) error {
+	reminder.Message = strings.TrimSpace(reminder.Message)
+	if reminder.Message == "" {
+		return fmt.Errorf("%w: the message is empty", ErrInvalid)
+	}
+	if len([]rune(reminder.Message)) > MaxMessageLength {
+		return fmt.Errorf("%w: the message is longer than %d characters", ErrInvalid, MaxMessageLength)
+	}
+	if reminder.UserID == "" || reminder.BotID == "" {
+		return fmt.Errorf("%w: the user and agent are required", ErrInvalid)
+	}
+
+	remindAt := time.UnixMilli(reminder.RemindAt)
+	if !remindAt.After(now) {
+		return fmt.Errorf("%w: the time is in the past", ErrInvalid)
+	}
+	if remindAt.After(now.Add(MaxAhead)) {
+		return fmt.Errorf("%w: reminders can be scheduled at most %d days ahead", ErrInvalid, int(MaxAhead.Hours()/24))
+	}
+	return nil
+}
+
+// Create schedules the reminder of the user.
+func (s *Service) Create(reminder Reminder) (*Reminder, error) {
+	now := time.Now()
+	if err := validate(&reminder, now); err != nil {
+		return nil, err
+	}
+
+	var pending int
+	if err := s.db.DoQueryRow(&pending, s.db.Builder().
+		Select("COUNT(*)").
+		From("LLM_Reminders").
+		Where(sq.Eq{"UserID": reminder.UserID}),
+	); err != nil {
+		return nil, fmt.Errorf("failed to count reminders: %w", err)
+	}
+	if pending >= MaxPendingPerUser {
+		return nil, ErrTooMany
+	}
+
+	reminder.ID = model.NewId()
+	reminder.CreateAt = now.UnixMilli()
+	if _, err := s.db.ExecBuilder(s.db.Builder().Insert("LLM_Reminders").
+		Columns("ID", "UserID", "BotID", "PostID", "Message", "RemindAt", "CreateAt").
+		Values(reminder.ID, reminder.UserID, reminder.BotID, reminder.PostID, reminder.Message, reminder.RemindAt, reminder.CreateAt),
+	); err != nil {
+		return nil, fmt.Errorf("failed to save reminder: %w", err)
+	}
+
+	return &reminder, nil
+}
+
+// List returns the scheduled reminders of the user, the next one first.
+func (s *Service) List(userID string) ([]Reminder, error) {
+	result := []Reminder{}
+	if err := s.db.DoQuery(&result, s.db.Builder().
+		Select("ID", "UserID", "BotID", "PostID", "Message", "RemindAt", "CreateAt").
+		From("LLM_Reminders").
+		Where(sq.Eq{"UserID": userID}).
+		OrderBy("RemindAt", "ID"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+	return result, nil
+}
+
+// Cancel deletes a scheduled reminder of the user.
+func (s *Service) Cancel(userID, id string) error {
+	result, err := s.db.ExecBuilder(s.db.Builder().Delete("LLM_Reminders").
+		Where(sq.Eq{"ID": id, "UserID": userID}))
+	if err != nil {
+		return fmt.Errorf("failed to cancel reminder: %w", err)
+	}
+	if rows, rowsErr := result.RowsAffected(); rowsErr == nil && rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Poll delivers the reminders that are due. Only one node of the cluster polls at a time.
+func (s *Service) Poll() {
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_reminders_poll")
+	if err != nil {
+		s.client.LogError("Failed to create reminders poll mutex", "error", err)
+		return
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	var due []Reminder
+	if err := s.db.DoQuery(&due, s.db.Builder().
+		Select("ID", "UserID", "BotID", "PostID", "Message", "RemindAt", "CreateAt").
+		From("LLM_Reminders").
+		Where(sq.LtOrEq{"RemindAt": model.GetMillis()}).
+		OrderBy("RemindAt", "ID").
+		Limit(deliverBatchSize),
+	); err != nil {
+		s.client.LogError("Failed to get due reminders", "error", err)
+		return
+	}
+
+	for _, reminder := range due {
+		if err := s.deliver(reminder); err != nil {
+			s.client.LogWarn("Failed to deliver reminder", "reminder_id", reminder.ID, "user_id", reminder.UserID, "error", err)
+		}
+	}
+}
+
+// deliver sends the reminder to its user. The reminder is deleted first, so a reminder that
+// can't be delivered, for example because its agent was deleted, isn't retried every poll.
+func (s *Service) deliver(reminder Reminder) error {
+	result, err := s.db.ExecBuilder(s.db.Builder().Delete("LLM_Reminders").Where(sq.Eq{"ID": reminder.ID}))
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	if rows, rowsErr := result.RowsAffected(); rowsErr == nil && rows == 0 {
+		// Canceled since it was read
+		return nil
+	}
+
+	user, err := s.client.GetUser(reminder.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.DeleteAt != 0 {
+		return nil
+	}
+
+	T := i18n.LocalizerFunc(s.i18n, user.Locale)
+	message := T("agents.reminder", "#### Reminder\n%s", reminder.Message)
+	if reminder.PostID != "" {
+		siteURL := ""
+		if serverConfig := s.client.GetConfig(); serverConfig != nil && serverConfig.ServiceSettings.SiteURL != nil {
+			siteURL = *serverConfig.ServiceSettings.SiteURL
+		}
+		message += "\n\n" + T("agents.reminder_thread", "About this thread: %s/_redirect/pl/%s", siteURL, reminder.PostID)
+	}
+
+	post := &model.Post{Message: message}
+	post.AddProp(ReminderProp, reminder.ID)
+	if err := s.client.DM(reminder.BotID, reminder.UserID, post); err != nil {
+		return fmt.Errorf("failed to send reminder: %w", err)
+	}
+	return nil
+}
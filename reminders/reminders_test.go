@@ -0,0 +1,68 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package reminders
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		reminder        Reminder
+		expectedMessage string
+		expectError     bool
+	}{
+		{
+			name:            "valid reminder, message trimmed",
+			reminder:        Reminder{UserID: "user", BotID: "bot", Message: "  Follow up on the release  ", RemindAt: now.Add(time.Hour).UnixMilli()},
+			expectedMessage: "Follow up on the release",
+		},
+		{
+			name:        "empty message",
+			reminder:    Reminder{UserID: "user", BotID: "bot", Message: "   ", RemindAt: now.Add(time.Hour).UnixMilli()},
+			expectError: true,
+		},
+		{
+			name:        "message too long",
+			reminder:    Reminder{UserID: "user", BotID: "bot", Message: strings.Repeat("a", MaxMessageLength+1), RemindAt: now.Add(time.Hour).UnixMilli()},
+			expectError: true,
+		},
+		{
+			name:        "missing bot",
+			reminder:    Reminder{UserID: "user", Message: "Follow up", RemindAt: now.Add(time.Hour).UnixMilli()},
+			expectError: true,
+		},
+		{
+			name:        "in the past",
+			reminder:    Reminder{UserID: "user", BotID: "bot", Message: "Follow up", RemindAt: now.UnixMilli()},
+			expectError: true,
+		},
+		{
+			name:        "too far ahead",
+			reminder:    Reminder{UserID: "user", BotID: "bot", Message: "Follow up", RemindAt: now.Add(MaxAhead + time.Minute).UnixMilli()},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reminder := tc.reminder
+			err := validate(&reminder, now)
+			if tc.expectError {
+				require.ErrorIs(t, err, ErrInvalid)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedMessage, reminder.Message)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package retention
+
+import (
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// serverPolicy is the Mattermost data retention enforced by the server: the custom policies of
+// channels and teams, and the global policy of the channels without one.
+type serverPolicy struct {
+	now int64
+	// enforced is false on unlicensed servers, which never delete messages
+	enforced bool
+	// messageCutoff and fileCutoff are the global policy, 0 when disabled
+	messageCutoff int64
+	fileCutoff    int64
+}
+
+func newServerPolicy(cfg *model.Config, license *model.License, now time.Time) serverPolicy {
+	policy := serverPolicy{now: now.UnixMilli()}
+	if license == nil || license.Features == nil || license.Features.DataRetention == nil || !*license.Features.DataRetention {
+		return policy
+	}
+	policy.enforced = true
+
+	if cfg == nil {
+		return policy
+	}
+	settings := cfg.DataRetentionSettings
+	if settings.EnableMessageDeletion != nil && *settings.EnableMessageDeletion {
+		policy.messageCutoff = now.Add(-time.Duration(settings.GetMessageRetentionHours()) * time.Hour).UnixMilli()
+	}
+	if settings.EnableFileDeletion != nil && *settings.EnableFileDeletion {
+		policy.fileCutoff = now.Add(-time.Duration(settings.GetFileRetentionHours()) * time.Hour).UnixMilli()
+	}
+
+	return policy
+}
+
+// expired matches the rows whose time is before the plugin cutoff or past the retention of
+// their channel: the custom policy of the channel, else the custom policy of its team, else the
+// global policy. The columns must be qualified by their table, as they are used in subqueries.
+func (p serverPolicy) expired(channelColumn, timeColumn string, pluginCutoff int64) sq.Sqlizer {
+	conditions := sq.Or{}
+	if pluginCutoff > 0 {
+		conditions = append(conditions, sq.Lt{timeColumn: pluginCutoff})
+	}
+	if !p.enforced {
+		return conditions
+	}
+
+	conditions = append(conditions,
+		sq.Expr(fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM RetentionPoliciesChannels rpc
+			JOIN RetentionPolicies rp ON rp.Id = rpc.PolicyId
+			WHERE rpc.ChannelId = %[1]s AND rp.PostDuration > 0 AND %[2]s < ? - rp.PostDuration * ?
+		)`, channelColumn, timeColumn), p.now, dayMillis),
+		sq.Expr(fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM Channels c
+			JOIN RetentionPoliciesTeams rpt ON rpt.TeamId = c.TeamId
+			JOIN RetentionPolicies rp ON rp.Id = rpt.PolicyId
+			WHERE c.Id = %[1]s AND rp.PostDuration > 0 AND %[2]s < ? - rp.PostDuration * ?
+			AND NOT EXISTS (SELECT 1 FROM RetentionPoliciesChannels rpc WHERE rpc.ChannelId = c.Id)
+		)`, channelColumn, timeColumn), p.now, dayMillis),
+	)
+	if p.messageCutoff > 0 {
+		conditions = append(conditions, sq.And{
+			sq.Lt{timeColumn: p.messageCutoff},
+			sq.Expr(fmt.Sprintf(`NOT EXISTS (SELECT 1 FROM RetentionPoliciesChannels rpc WHERE rpc.ChannelId = %s)`, channelColumn)),
+			sq.Expr(fmt.Sprintf(`NOT EXISTS (
+				SELECT 1 FROM Channels c
+				JOIN RetentionPoliciesTeams rpt ON rpt.TeamId = c.TeamId
+				WHERE c.Id = %s
+			)`, channelColumn)),
+		})
+	}
+
+	return conditions
+}
+
+// messageExpired reports whether data of the given time is past the global message retention,
+// for data not tied to a channel.
+func (p serverPolicy) messageExpired(at int64) bool {
+	return p.messageCutoff > 0 && at < p.messageCutoff
+}
+
+// fileExpired reports whether a file created at the given time is past the global file retention.
+func (p serverPolicy) fileExpired(createAt int64) bool {
+	return p.fileCutoff > 0 && createAt < p.fileCutoff
+}
@@ -0,0 +1,344 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package retention purges the data the plugin keeps about messages once the messages are past
+// their retention: the conversation state, the agent traces, the usage events, the embeddings of
+// the posts and the caches of the KV store. The retention of each kind of data is the shortest of
+// the plugin setting and of the Mattermost data retention policy of its channel, so the plugin
+// never keeps anything derived from a message the server already deleted.
+//
+// The purge runs once a day on one node of the cluster, and the report of the last purge is kept
+// in the plugin KV store for admins.
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/imagetext"
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/summaryexports"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	// PollInterval is how often the service checks whether a purge is due
+	PollInterval = time.Hour
+	// PurgeInterval is how often the data is purged
+	PurgeInterval = 24 * time.Hour
+
+	reportKey = "retention_report_v1"
+	// deleteBatchSize bounds the rows deleted by each statement, so a purge never holds long locks
+	deleteBatchSize = 1000
+	dayMillis       = int64(24 * time.Hour / time.Millisecond)
+)
+
+// ErrNotAvailable is returned when purging on a database the plugin tables don't exist in.
+var ErrNotAvailable = errors.New("retention is only available on PostgreSQL")
+
+// Report is what a purge deleted.
+type Report struct {
+	StartedAt     int64    `json:"started_at"`
+	FinishedAt    int64    `json:"finished_at"`
+	Conversations int64    `json:"conversations"`
+	Traces        int64    `json:"traces"`
+	UsageEvents   int64    `json:"usage_events"`
+	Embeddings    int64    `json:"embeddings"`
+	CacheEntries  int64    `json:"cache_entries"`
+	Errors        []string `json:"errors,omitempty"`
+}
+
+// LicenseSource returns the license of the server, Mattermost only enforces data retention
+// policies on licensed servers
+type LicenseSource interface {
+	GetLicense() *model.License
+}
+
+// Service purges the plugin data past its retention.
+type Service struct {
+	db        *mmapi.DBClient
+	client    mmapi.Client
+	license   LicenseSource
+	mutexAPI  cluster.MutexPluginAPI
+	getConfig func() config.RetentionConfig
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+// New creates a new retention service reading the plugin settings from getConfig. Call Start
+// to begin purging.
+func New(db *mmapi.DBClient, client mmapi.Client, license LicenseSource, mutexAPI cluster.MutexPluginAPI, getConfig func() config.RetentionConfig) *Service {
+	return &Service{
+		db:        db,
+		client:    client,
+		license:   license,
+		mutexAPI:  mutexAPI,
+		getConfig: getConfig,
+	}
+}
+
+// Start checks every interval whether a purge is due.
+func (s *Service) Start(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.Poll()
+			}
+		}
+	}()
+}
+
+// Stop stops purging.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// Enabled reports whether the data is purged daily.
+func (s *Service) Enabled() bool {
+	return s.getConfig().Enabled
+}
+
+// Poll purges the data when enabled and PurgeInterval elapsed since the last purge.
+func (s *Service) Poll() {
+	if !s.Enabled() {
+		return
+	}
+
+	last, err := s.LastReport()
+	if err != nil {
+		s.client.LogError("Failed to get the last retention report", "error", err)
+		return
+	}
+	if last != nil && time.Since(time.UnixMilli(last.StartedAt)) < PurgeInterval {
+		return
+	}
+
+	report, err := s.Purge()
+	if err != nil {
+		s.client.LogError("Failed to purge data past retention", "error", err)
+		return
+	}
+	if len(report.Errors) > 0 {
+		s.client.LogWarn("Data retention purge finished with errors", "errors", strings.Join(report.Errors, "; "))
+	}
+}
+
+// LastReport returns the report of the last purge, nil if none ran yet.
+func (s *Service) LastReport() (*Report, error) {
+	var report *Report
+	if err := s.client.KVGet(reportKey, &report); err != nil {
+		return nil, fmt.Errorf("failed to get retention report: %w", err)
+	}
+	return report, nil
+}
+
+// Purge deletes the data past its retention and stores the report. Only one node of the
+// cluster purges at a time. Failures of each kind of data are recorded in the report rather
+// than stopping the purge.
+func (s *Service) Purge() (*Report, error) {
+	if s.db == nil || !s.db.IsPostgres() {
+		return nil, ErrNotAvailable
+	}
+
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_retention_purge")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create retention mutex: %w", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	now := time.Now()
+	cfg := s.getConfig()
+	policy := newServerPolicy(s.client.GetConfig(), s.license.GetLicense(), now)
+	report := &Report{StartedAt: now.UnixMilli()}
+
+	record := func(name string, count *int64, purge func() (int64, error)) {
+		deleted, err := purge()
+		*count += deleted
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+
+	record("conversations", &report.Conversations, func() (int64, error) {
+		return s.deleteInBatches("LLM_Conversations", "ID", sq.Or{
+			policy.expired("LLM_Conversations.ChannelID", "LLM_Conversations.UpdateAt", cutoff(cfg.ConversationDays, now)),
+			postDeleted("LLM_Conversations.ID"),
+		})
+	})
+	record("traces", &report.Traces, func() (int64, error) {
+		return s.deleteInBatches("LLM_Traces", "RequestPostID", sq.Or{
+			policy.expired("LLM_Traces.ChannelID", "LLM_Traces.CreateAt", cutoff(cfg.TraceDays, now)),
+			postDeleted("LLM_Traces.RequestPostID"),
+		})
+	})
+	record("usage events", &report.UsageEvents, func() (int64, error) {
+		return s.deleteInBatches("LLM_UsageEvents", "ID", policy.expired("LLM_UsageEvents.ChannelID", "LLM_UsageEvents.CreateAt", cutoff(cfg.UsageEventDays, now)))
+	})
+	record("embeddings", &report.Embeddings, func() (int64, error) {
+		exists, err := s.db.TableExists("llm_posts_embeddings")
+		if err != nil || !exists {
+			return 0, err
+		}
+		return s.deleteInBatches("llm_posts_embeddings", "id", sq.Or{
+			policy.expired("llm_posts_embeddings.channel_id", "llm_posts_embeddings.created_at", cutoff(cfg.EmbeddingDays, now)),
+			postDeleted("llm_posts_embeddings.post_id"),
+		})
+	})
+	record("image text cache", &report.CacheEntries, func() (int64, error) {
+		return s.purgeImageText(policy, cutoff(cfg.CacheDays, now))
+	})
+	record("analysis jobs", &report.CacheEntries, func() (int64, error) {
+		return s.purgeJobs(policy, cutoff(cfg.CacheDays, now))
+	})
+
+	report.FinishedAt = time.Now().UnixMilli()
+	if err := s.client.KVSet(reportKey, report); err != nil {
+		return report, fmt.Errorf("failed to save retention report: %w", err)
+	}
+
+	return report, nil
+}
+
+// cutoff returns the time before which the data is past a retention of days, 0 when days
+// doesn't limit the retention.
+func cutoff(days int, now time.Time) int64 {
+	if days <= 0 {
+		return 0
+	}
+	return now.AddDate(0, 0, -days).UnixMilli()
+}
+
+// postDeleted matches the rows of posts deleted by their users, which Mattermost keeps until
+// its retention deletes them.
+func postDeleted(postIDColumn string) sq.Sqlizer {
+	return sq.Expr(fmt.Sprintf("EXISTS (SELECT 1 FROM Posts p WHERE p.Id = %s AND p.DeleteAt > 0)", postIDColumn))
+}
+
+// deleteInBatches deletes the rows of the table matching where, deleteBatchSize rows at a time.
+func (s *Service) deleteInBatches(table, idColumn string, where sq.Sqlizer) (int64, error) {
+	var total int64
+	for {
+		// The subquery keeps the ? placeholders, the delete statement numbers them
+		ids := sq.Select(idColumn).
+			From(table).
+			Where(where).
+			Limit(deleteBatchSize)
+		idsSQL, args, err := ids.ToSql()
+		if err != nil {
+			return total, fmt.Errorf("failed to build query: %w", err)
+		}
+
+		result, err := s.db.ExecBuilder(s.db.Builder().
+			Delete(table).
+			Where(sq.Expr(idColumn+" IN ("+idsSQL+")", args...)))
+		if err != nil {
+			return total, fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("failed to count deleted rows: %w", err)
+		}
+		total += deleted
+		if deleted < deleteBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// purgeImageText deletes the text extracted from the images whose file was deleted or is past
+// its retention.
+func (s *Service) purgeImageText(policy serverPolicy, pluginCutoff int64) (int64, error) {
+	keys, err := mmapi.KVListMatching(s.client, func(key string) bool {
+		return strings.HasPrefix(key, imagetext.CacheKeyPrefix)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, key := range keys {
+		fileInfo, err := s.client.GetFileInfo(strings.TrimPrefix(key, imagetext.CacheKeyPrefix))
+		var appErr *model.AppError
+		switch {
+		case errors.As(err, &appErr) && appErr.StatusCode == http.StatusNotFound:
+		case err != nil:
+			return deleted, fmt.Errorf("failed to get file info: %w", err)
+		case fileInfo.DeleteAt > 0, policy.fileExpired(fileInfo.CreateAt), fileInfo.CreateAt < pluginCutoff:
+		default:
+			continue
+		}
+
+		if err := s.client.KVDelete(key); err != nil {
+			return deleted, fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// purgeJobs deletes the finished analysis jobs past their retention.
+func (s *Service) purgeJobs(policy serverPolicy, pluginCutoff int64) (int64, error) {
+	keys, err := mmapi.KVListMatching(s.client, func(key string) bool {
+		return strings.HasPrefix(key, jobs.KeyPrefix)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, key := range keys {
+		var job *jobs.Job
+		if err := s.client.KVGet(key, &job); err != nil {
+			return deleted, fmt.Errorf("failed to get %s: %w", key, err)
+		}
+		if job == nil || !job.IsFinished() {
+			continue
+		}
+		if job.UpdatedAt >= pluginCutoff && !policy.messageExpired(job.UpdatedAt) {
+			continue
+		}
+
+		if err := s.client.KVDelete(key); err != nil {
+			return deleted, fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+		deleted++
+
+		// The summaries of exports are deleted with their job
+		if job.Type == summaryexports.JobType {
+			if err := s.client.KVDelete(summaryexports.KeyPrefix + job.ID); err != nil {
+				return deleted, fmt.Errorf("failed to delete the export of %s: %w", key, err)
+			}
+		}
+	}
+
+	return deleted, nil
+}
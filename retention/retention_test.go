@@ -0,0 +1,128 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package retention
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/imagetext"
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func licensed() *model.License {
+	return &model.License{Features: &model.Features{DataRetention: model.NewPointer(true)}}
+}
+
+func TestNewServerPolicy(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	cfg := &model.Config{}
+	cfg.SetDefaults()
+	cfg.DataRetentionSettings.EnableMessageDeletion = model.NewPointer(true)
+	cfg.DataRetentionSettings.MessageRetentionHours = model.NewPointer(48)
+
+	t.Run("unlicensed servers don't enforce retention", func(t *testing.T) {
+		policy := newServerPolicy(cfg, nil, now)
+		assert.False(t, policy.enforced)
+		assert.Zero(t, policy.messageCutoff)
+	})
+
+	t.Run("licensed servers enforce the global policy when enabled", func(t *testing.T) {
+		policy := newServerPolicy(cfg, licensed(), now)
+		assert.True(t, policy.enforced)
+		assert.Equal(t, now.Add(-48*time.Hour).UnixMilli(), policy.messageCutoff)
+		assert.Zero(t, policy.fileCutoff)
+		assert.True(t, policy.messageExpired(now.Add(-49*time.Hour).UnixMilli()))
+		assert.False(t, policy.messageExpired(now.Add(-47*time.Hour).UnixMilli()))
+	})
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("nothing expires without a plugin setting or a server policy", func(t *testing.T) {
+		query, args, err := sq.Select("ID").From("LLM_Traces").
+			Where(serverPolicy{now: now.UnixMilli()}.expired("LLM_Traces.ChannelID", "LLM_Traces.CreateAt", 0)).
+			ToSql()
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT ID FROM LLM_Traces WHERE (1=0)", query)
+		assert.Empty(t, args)
+	})
+
+	t.Run("plugin setting only", func(t *testing.T) {
+		pluginCutoff := cutoff(30, now)
+		query, args, err := sq.Select("ID").From("LLM_Traces").
+			Where(serverPolicy{now: now.UnixMilli()}.expired("LLM_Traces.ChannelID", "LLM_Traces.CreateAt", pluginCutoff)).
+			ToSql()
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT ID FROM LLM_Traces WHERE (LLM_Traces.CreateAt < ?)", query)
+		assert.Equal(t, []any{now.AddDate(0, 0, -30).UnixMilli()}, args)
+	})
+
+	t.Run("custom and global policies", func(t *testing.T) {
+		policy := serverPolicy{now: now.UnixMilli(), enforced: true, messageCutoff: now.AddDate(0, 0, -90).UnixMilli()}
+		query, args, err := sq.Select("ID").From("LLM_Traces").
+			Where(policy.expired("LLM_Traces.ChannelID", "LLM_Traces.CreateAt", 0)).
+			ToSql()
+		require.NoError(t, err)
+		assert.Contains(t, query, "RetentionPoliciesChannels rpc")
+		assert.Contains(t, query, "RetentionPoliciesTeams rpt")
+		assert.Contains(t, query, "rpc.ChannelId = LLM_Traces.ChannelID")
+		assert.Contains(t, query, "LLM_Traces.CreateAt < ? - rp.PostDuration * ?")
+		assert.Equal(t, []any{now.UnixMilli(), dayMillis, now.UnixMilli(), dayMillis, policy.messageCutoff}, args)
+	})
+}
+
+func TestPurgeImageText(t *testing.T) {
+	now := time.Now()
+	client := mocks.NewMockClient(t)
+	client.On("KVList", 0, mmapi.KVListPageSize).Return([]string{
+		imagetext.CacheKeyPrefix + "kept",
+		imagetext.CacheKeyPrefix + "deleted",
+		imagetext.CacheKeyPrefix + "missing",
+		imagetext.CacheKeyPrefix + "old",
+		"unrelated",
+	}, nil)
+	client.On("GetFileInfo", "kept").Return(&model.FileInfo{Id: "kept", CreateAt: now.UnixMilli()}, nil)
+	client.On("GetFileInfo", "deleted").Return(&model.FileInfo{Id: "deleted", CreateAt: now.UnixMilli(), DeleteAt: now.UnixMilli()}, nil)
+	client.On("GetFileInfo", "missing").Return(nil, model.NewAppError("GetFileInfo", "not_found", nil, "", http.StatusNotFound))
+	client.On("GetFileInfo", "old").Return(&model.FileInfo{Id: "old", CreateAt: now.AddDate(0, 0, -10).UnixMilli()}, nil)
+	client.On("KVDelete", imagetext.CacheKeyPrefix+"deleted").Return(nil)
+	client.On("KVDelete", imagetext.CacheKeyPrefix+"missing").Return(nil)
+	client.On("KVDelete", imagetext.CacheKeyPrefix+"old").Return(nil)
+
+	s := &Service{client: client}
+	deleted, err := s.purgeImageText(serverPolicy{now: now.UnixMilli()}, cutoff(7, now))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+}
+
+func TestPurgeJobs(t *testing.T) {
+	now := time.Now()
+	stored := map[string]*jobs.Job{
+		jobs.KeyPrefix + "recent":  {ID: "recent", Status: jobs.StatusCompleted, UpdatedAt: now.UnixMilli()},
+		jobs.KeyPrefix + "old":     {ID: "old", Status: jobs.StatusFailed, UpdatedAt: now.AddDate(0, 0, -10).UnixMilli()},
+		jobs.KeyPrefix + "running": {ID: "running", Status: jobs.StatusRunning, UpdatedAt: now.AddDate(0, 0, -10).UnixMilli()},
+	}
+
+	client := mocks.NewMockClient(t)
+	client.On("KVList", 0, mmapi.KVListPageSize).Return([]string{jobs.KeyPrefix + "recent", jobs.KeyPrefix + "old", jobs.KeyPrefix + "running"}, nil)
+	client.On("KVGet", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(1).(**jobs.Job) = stored[args.String(0)]
+	}).Return(nil)
+	client.On("KVDelete", jobs.KeyPrefix+"old").Return(nil)
+
+	s := &Service{client: client}
+	deleted, err := s.purgeJobs(serverPolicy{now: now.UnixMilli()}, cutoff(7, now))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
@@ -0,0 +1,309 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package retrievalfeedback tunes the retrieval of search answers with the feedback users give
+// them. A thumbs up or down reaction of the user who asked records a vote on the channels of the
+// results the answer cited, and the results of channels cited in accepted answers are ranked
+// higher in later searches while those of rejected answers are ranked lower.
+package retrievalfeedback
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/search"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	// MaxScoreAdjustment is the largest change of the score of a result by the feedback on its
+	// channel, scores being similarities between 0 and 1
+	MaxScoreAdjustment = 0.1
+
+	// priorVotes smooths the adjustment of channels with few votes, so a single vote doesn't
+	// reorder the results
+	priorVotes = 5
+
+	// minQuoteLength is the shortest text of a result found in an answer that counts as a citation
+	minQuoteLength = 30
+
+	answerKeyPrefix = "retrieval_feedback_answer_"
+	sourceKeyPrefix = "retrieval_feedback_source_"
+)
+
+// Answer is the vote recorded on a search answer
+type Answer struct {
+	// Vote is 1 when the answer was accepted, -1 when it was rejected and 0 without a vote
+	Vote int `json:"vote"`
+	// Sources are the channels of the results the answer cited
+	Sources []string `json:"sources"`
+}
+
+// SourceStats counts the votes on the answers citing results of a channel
+type SourceStats struct {
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"`
+}
+
+// Adjustment returns how much the scores of the results of the channel change
+func (s SourceStats) Adjustment() float32 {
+	return MaxScoreAdjustment * float32(s.Accepted-s.Rejected) / float32(s.Accepted+s.Rejected+priorVotes)
+}
+
+// BotChecker reports the users that are AI bots
+type BotChecker interface {
+	IsAnyBot(userID string) bool
+}
+
+// Service records the feedback on search answers and adjusts the scores of search results with it
+type Service struct {
+	client   mmapi.Client
+	bots     BotChecker
+	mutexAPI cluster.MutexPluginAPI
+}
+
+// New creates a new retrieval feedback service
+func New(client mmapi.Client, bots BotChecker, mutexAPI cluster.MutexPluginAPI) *Service {
+	return &Service{
+		client:   client,
+		bots:     bots,
+		mutexAPI: mutexAPI,
+	}
+}
+
+// voteOf returns the vote of the emoji, 0 for the emoji that aren't votes
+func voteOf(emojiName string) int {
+	switch emojiName {
+	case "+1", "thumbsup":
+		return 1
+	case "-1", "thumbsdown":
+		return -1
+	default:
+		return 0
+	}
+}
+
+// ReactionHasBeenAdded records the vote of a thumbs up or down reaction on a search answer
+func (s *Service) ReactionHasBeenAdded(reaction *model.Reaction) {
+	vote := voteOf(reaction.EmojiName)
+	if vote == 0 {
+		return
+	}
+	if err := s.vote(reaction, vote); err != nil {
+		s.client.LogError("Failed to record search answer feedback", "post_id", reaction.PostId, "error", err)
+	}
+}
+
+// ReactionHasBeenRemoved withdraws the vote of a thumbs up or down reaction on a search answer
+func (s *Service) ReactionHasBeenRemoved(reaction *model.Reaction) {
+	vote := voteOf(reaction.EmojiName)
+	if vote == 0 {
+		return
+	}
+	if err := s.withdraw(reaction, vote); err != nil {
+		s.client.LogError("Failed to withdraw search answer feedback", "post_id", reaction.PostId, "error", err)
+	}
+}
+
+func (s *Service) vote(reaction *model.Reaction, vote int) error {
+	post, results, err := s.answer(reaction)
+	if err != nil || post == nil {
+		return err
+	}
+
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_retrieval_feedback")
+	if err != nil {
+		return fmt.Errorf("failed to create mutex: %w", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	var answer Answer
+	if err := s.client.KVGet(answerKeyPrefix+post.Id, &answer); err != nil {
+		return fmt.Errorf("failed to get answer feedback: %w", err)
+	}
+	if answer.Vote == vote {
+		return nil
+	}
+
+	updated := Answer{Vote: vote, Sources: sources(Cited(post.Message, results))}
+	return s.update(post.Id, answer, updated)
+}
+
+func (s *Service) withdraw(reaction *model.Reaction, vote int) error {
+	post, _, err := s.answer(reaction)
+	if err != nil || post == nil {
+		return err
+	}
+
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_retrieval_feedback")
+	if err != nil {
+		return fmt.Errorf("failed to create mutex: %w", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	var answer Answer
+	if err := s.client.KVGet(answerKeyPrefix+post.Id, &answer); err != nil {
+		return fmt.Errorf("failed to get answer feedback: %w", err)
+	}
+	if answer.Vote != vote {
+		return nil
+	}
+
+	return s.update(post.Id, answer, Answer{Sources: answer.Sources})
+}
+
+// answer returns the search answer reacted to with the results it was based on, or a nil post
+// when the reaction isn't feedback of the user who asked on a search answer
+func (s *Service) answer(reaction *model.Reaction) (*model.Post, []search.RAGResult, error) {
+	if s.bots.IsAnyBot(reaction.UserId) {
+		return nil, nil, nil
+	}
+
+	post, err := s.client.GetPost(reaction.PostId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	resultsJSON, ok := post.GetProp(search.SearchResultsProp).(string)
+	if !ok || post.RootId == "" || !s.bots.IsAnyBot(post.UserId) {
+		return nil, nil, nil
+	}
+
+	question, err := s.client.GetPost(post.RootId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get question post: %w", err)
+	}
+	if question.UserId != reaction.UserId {
+		return nil, nil, nil
+	}
+
+	var results []search.RAGResult
+	if err := json.Unmarshal([]byte(resultsJSON), &results); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse search results: %w", err)
+	}
+
+	return post, results, nil
+}
+
+// update replaces the vote of the answer, moving the votes counted on its sources
+func (s *Service) update(postID string, previous, updated Answer) error {
+	changes := make(map[string]SourceStats)
+	for _, source := range previous.Sources {
+		stats := changes[source]
+		count(&stats, previous.Vote, -1)
+		changes[source] = stats
+	}
+	for _, source := range updated.Sources {
+		stats := changes[source]
+		count(&stats, updated.Vote, 1)
+		changes[source] = stats
+	}
+
+	for source, change := range changes {
+		if change == (SourceStats{}) {
+			continue
+		}
+		var stats SourceStats
+		if err := s.client.KVGet(sourceKeyPrefix+source, &stats); err != nil {
+			return fmt.Errorf("failed to get source feedback: %w", err)
+		}
+		stats.Accepted = max(0, stats.Accepted+change.Accepted)
+		stats.Rejected = max(0, stats.Rejected+change.Rejected)
+		if err := s.client.KVSet(sourceKeyPrefix+source, stats); err != nil {
+			return fmt.Errorf("failed to save source feedback: %w", err)
+		}
+	}
+
+	if err := s.client.KVSet(answerKeyPrefix+postID, updated); err != nil {
+		return fmt.Errorf("failed to save answer feedback: %w", err)
+	}
+	return nil
+}
+
+// count adds delta to the counter of the vote
+func count(stats *SourceStats, vote, delta int) {
+	switch vote {
+	case 1:
+		stats.Accepted += delta
+	case -1:
+		stats.Rejected += delta
+	}
+}
+
+// AdjustScores moves the scores of the results by the feedback on their channels
+func (s *Service) AdjustScores(results []embeddings.SearchResult) []embeddings.SearchResult {
+	adjustments := make(map[string]float32)
+	adjusted := make([]embeddings.SearchResult, 0, len(results))
+	for _, result := range results {
+		channelID := result.Document.ChannelID
+		adjustment, ok := adjustments[channelID]
+		if !ok {
+			var stats SourceStats
+			if err := s.client.KVGet(sourceKeyPrefix+channelID, &stats); err != nil {
+				s.client.LogWarn("Failed to get source feedback", "channel_id", channelID, "error", err)
+			}
+			adjustment = stats.Adjustment()
+			adjustments[channelID] = adjustment
+		}
+		result.Score += adjustment
+		adjusted = append(adjusted, result)
+	}
+	return adjusted
+}
+
+// Cited returns the results the answer relies on: those whose author it names or whose text it
+// quotes
+func Cited(answer string, results []search.RAGResult) []search.RAGResult {
+	normalizedAnswer := normalizeText(answer)
+
+	var cited []search.RAGResult
+	for _, result := range results {
+		if mentions(normalizedAnswer, result.Username) || quotes(normalizedAnswer, result.Content) {
+			cited = append(cited, result)
+		}
+	}
+	return cited
+}
+
+// mentions reports whether the normalized answer names the user
+func mentions(normalizedAnswer, username string) bool {
+	if username == "" || username == search.UnknownUsername {
+		return false
+	}
+	return strings.Contains(normalizedAnswer, strings.ToLower(username))
+}
+
+// quotes reports whether the normalized answer contains a sentence of the content
+func quotes(normalizedAnswer, content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		for _, sentence := range strings.SplitAfter(line, ". ") {
+			sentence = normalizeText(strings.TrimSuffix(strings.TrimSpace(sentence), "."))
+			if len(sentence) >= minQuoteLength && strings.Contains(normalizedAnswer, sentence) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sources returns the channels of the results, once each
+func sources(results []search.RAGResult) []string {
+	channelIDs := []string{}
+	for _, result := range results {
+		if !slices.Contains(channelIDs, result.ChannelID) {
+			channelIDs = append(channelIDs, result.ChannelID)
+		}
+	}
+	return channelIDs
+}
+
+// normalizeText lowercases text and collapses its whitespace
+func normalizeText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
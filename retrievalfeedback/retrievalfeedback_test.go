@@ -0,0 +1,152 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package retrievalfeedback
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mmapitest"
+	"github.com/mattermost/mattermost-plugin-ai/search"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient keeps the KV store and the posts in memory.
+type fakeClient struct {
+	mmapi.Client
+	kv    map[string][]byte
+	posts map[string]*model.Post
+}
+
+func (f *fakeClient) KVGet(key string, value interface{}) error {
+	data, ok := f.kv[key]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (f *fakeClient) KVSet(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	f.kv[key] = data
+	return nil
+}
+
+func (f *fakeClient) GetPost(postID string) (*model.Post, error) {
+	post, ok := f.posts[postID]
+	if !ok {
+		return nil, fmt.Errorf("post %s not found", postID)
+	}
+	return post, nil
+}
+
+func (f *fakeClient) LogError(string, ...interface{}) {}
+
+func (f *fakeClient) LogWarn(string, ...interface{}) {}
+
+type fakeBots struct{}
+
+func (fakeBots) IsAnyBot(userID string) bool {
+	return userID == "bot"
+}
+
+func newService(t *testing.T) (*Service, *fakeClient) {
+	results, err := json.Marshal([]search.RAGResult{
+		{PostID: "p1", ChannelID: "releases", Username: "alice", Content: "The release is planned for the end of the quarter."},
+		{PostID: "p2", ChannelID: "random", Username: "bob", Content: "Lunch is at noon."},
+	})
+	require.NoError(t, err)
+
+	answer := &model.Post{Id: "answer", UserId: "bot", RootId: "question", Message: "According to alice in Releases, the release is planned for the end of the quarter."}
+	answer.AddProp(search.SearchResultsProp, string(results))
+	client := &fakeClient{
+		kv: map[string][]byte{},
+		posts: map[string]*model.Post{
+			"question": {Id: "question", UserId: "asker", Message: "When is the release?"},
+			"answer":   answer,
+			"other":    {Id: "other", UserId: "bot", RootId: "question", Message: "Hello"},
+		},
+	}
+	return New(client, fakeBots{}, mmapitest.MutexAPI{}), client
+}
+
+func sourceStats(t *testing.T, client *fakeClient, channelID string) SourceStats {
+	var stats SourceStats
+	require.NoError(t, client.KVGet(sourceKeyPrefix+channelID, &stats))
+	return stats
+}
+
+func TestFeedback(t *testing.T) {
+	t.Run("votes of the asker count on the cited channels", func(t *testing.T) {
+		service, client := newService(t)
+
+		service.ReactionHasBeenAdded(&model.Reaction{PostId: "answer", UserId: "asker", EmojiName: "+1"})
+		assert.Equal(t, SourceStats{Accepted: 1}, sourceStats(t, client, "releases"))
+		assert.Equal(t, SourceStats{}, sourceStats(t, client, "random"))
+
+		// Voting again doesn't count twice
+		service.ReactionHasBeenAdded(&model.Reaction{PostId: "answer", UserId: "asker", EmojiName: "thumbsup"})
+		assert.Equal(t, SourceStats{Accepted: 1}, sourceStats(t, client, "releases"))
+
+		// Changing the vote moves it
+		service.ReactionHasBeenAdded(&model.Reaction{PostId: "answer", UserId: "asker", EmojiName: "-1"})
+		assert.Equal(t, SourceStats{Rejected: 1}, sourceStats(t, client, "releases"))
+
+		// Removing a reaction that isn't the current vote changes nothing
+		service.ReactionHasBeenRemoved(&model.Reaction{PostId: "answer", UserId: "asker", EmojiName: "+1"})
+		assert.Equal(t, SourceStats{Rejected: 1}, sourceStats(t, client, "releases"))
+
+		service.ReactionHasBeenRemoved(&model.Reaction{PostId: "answer", UserId: "asker", EmojiName: "-1"})
+		assert.Equal(t, SourceStats{}, sourceStats(t, client, "releases"))
+	})
+
+	t.Run("other reactions are ignored", func(t *testing.T) {
+		service, client := newService(t)
+
+		service.ReactionHasBeenAdded(&model.Reaction{PostId: "answer", UserId: "someone-else", EmojiName: "+1"})
+		service.ReactionHasBeenAdded(&model.Reaction{PostId: "answer", UserId: "asker", EmojiName: "tada"})
+		service.ReactionHasBeenAdded(&model.Reaction{PostId: "other", UserId: "asker", EmojiName: "+1"})
+		service.ReactionHasBeenAdded(&model.Reaction{PostId: "question", UserId: "asker", EmojiName: "+1"})
+		assert.Empty(t, client.kv)
+	})
+}
+
+func TestAdjustScores(t *testing.T) {
+	service, client := newService(t)
+	require.NoError(t, client.KVSet(sourceKeyPrefix+"releases", SourceStats{Accepted: 5}))
+	require.NoError(t, client.KVSet(sourceKeyPrefix+"random", SourceStats{Rejected: 15}))
+
+	adjusted := service.AdjustScores([]embeddings.SearchResult{
+		{Document: embeddings.PostDocument{ChannelID: "releases"}, Score: 0.5},
+		{Document: embeddings.PostDocument{ChannelID: "random"}, Score: 0.5},
+		{Document: embeddings.PostDocument{ChannelID: "town-square"}, Score: 0.5},
+	})
+	assert.InDelta(t, 0.55, adjusted[0].Score, 0.0001)
+	assert.InDelta(t, 0.425, adjusted[1].Score, 0.0001)
+	assert.InDelta(t, 0.5, adjusted[2].Score, 0.0001)
+}
+
+func TestCited(t *testing.T) {
+	results := []search.RAGResult{
+		{PostID: "p1", Username: "alice", Content: "Short."},
+		{PostID: "p2", Username: "bob", Content: "Intro line.\nThe deploy freeze starts on Friday at noon. Ask in ~ops."},
+		{PostID: "p3", Username: search.UnknownUsername, Content: "Unrelated"},
+		{PostID: "p4", Username: "carol", Content: "Nothing quoted here"},
+	}
+
+	cited := Cited("As Alice said, THE deploy freeze  starts on Friday at noon. Unknown User agrees.", results)
+	ids := []string{}
+	for _, result := range cited {
+		ids = append(ids, result.PostID)
+	}
+	assert.Equal(t, []string{"p1", "p2"}, ids)
+}
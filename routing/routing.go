@@ -0,0 +1,98 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package routing implements the admin rules choosing the agent answering in a channel when
+// several agents are configured, so clients and users don't have to pick one themselves.
+package routing
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Reason is why an agent was chosen for a channel
+type Reason string
+
+const (
+	// ReasonChannel is a rule routing the channel to the agent
+	ReasonChannel Reason = "channel"
+	// ReasonTeam is a rule routing the team of the channel to the agent
+	ReasonTeam Reason = "team"
+	// ReasonDefault is the default agent, when no rule applies or the user may not use the agents of the rules
+	ReasonDefault Reason = "default"
+)
+
+// Rule routes channels and whole teams, such as the team of a department, to an agent
+type Rule struct {
+	// BotName is the username of the agent
+	BotName string `json:"botName"`
+
+	// ChannelIDs are the channels routed to the agent
+	ChannelIDs []string `json:"channelIDs"`
+
+	// TeamIDs are the teams whose channels are routed to the agent
+	TeamIDs []string `json:"teamIDs"`
+}
+
+// Config is the routing rules and the alias mentioning the agent routed to
+type Config struct {
+	// Alias is a username, such as "ai", whose mentions are answered by the agent routed to in
+	// the channel. Empty disables the alias.
+	Alias string `json:"alias"`
+
+	// Rules are checked in order, rules for the channel before rules for its team
+	Rules []Rule `json:"rules"`
+}
+
+// Candidate is an agent routed to in a channel
+type Candidate struct {
+	BotName string
+	Reason  Reason
+}
+
+// Router applies the routing rules of the configuration
+type Router struct {
+	getConfig func() Config
+}
+
+// New creates a router reading the rules from getConfig on each call
+func New(getConfig func() Config) *Router {
+	return &Router{
+		getConfig: getConfig,
+	}
+}
+
+// Candidates returns the agents routed to in the channel, the agents of channel rules before the
+// agents of team rules. The caller falls back to the default agent.
+func (r *Router) Candidates(channel *model.Channel) []Candidate {
+	if r == nil || channel == nil {
+		return nil
+	}
+
+	cfg := r.getConfig()
+	var candidates []Candidate
+	for _, rule := range cfg.Rules {
+		if rule.BotName != "" && slices.Contains(rule.ChannelIDs, channel.Id) {
+			candidates = append(candidates, Candidate{BotName: rule.BotName, Reason: ReasonChannel})
+		}
+	}
+	if channel.TeamId != "" {
+		for _, rule := range cfg.Rules {
+			if rule.BotName != "" && slices.Contains(rule.TeamIDs, channel.TeamId) {
+				candidates = append(candidates, Candidate{BotName: rule.BotName, Reason: ReasonTeam})
+			}
+		}
+	}
+
+	return candidates
+}
+
+// Alias returns the username mentioning the agent routed to, without the @, or "" if disabled
+func (r *Router) Alias() string {
+	if r == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(r.getConfig().Alias), "@"))
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package routing
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCandidates(t *testing.T) {
+	cfg := Config{
+		Alias: "@AI ",
+		Rules: []Rule{
+			{BotName: "sales", TeamIDs: []string{"team1"}},
+			{BotName: "support", ChannelIDs: []string{"channel1"}},
+			{BotName: "", ChannelIDs: []string{"channel1"}},
+		},
+	}
+	router := New(func() Config { return cfg })
+
+	tests := []struct {
+		name     string
+		channel  *model.Channel
+		expected []Candidate
+	}{
+		{
+			name:    "channel rules before team rules",
+			channel: &model.Channel{Id: "channel1", TeamId: "team1"},
+			expected: []Candidate{
+				{BotName: "support", Reason: ReasonChannel},
+				{BotName: "sales", Reason: ReasonTeam},
+			},
+		},
+		{
+			name:     "team rule",
+			channel:  &model.Channel{Id: "channel2", TeamId: "team1"},
+			expected: []Candidate{{BotName: "sales", Reason: ReasonTeam}},
+		},
+		{
+			name:    "no rule",
+			channel: &model.Channel{Id: "channel2", TeamId: "team2"},
+		},
+		{
+			name:    "direct message",
+			channel: &model.Channel{Id: "channel2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, router.Candidates(tc.channel))
+		})
+	}
+
+	require.Equal(t, "ai", router.Alias())
+}
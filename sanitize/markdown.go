@@ -0,0 +1,225 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package sanitize removes dangerous markdown from model output before it is saved in posts.
+package sanitize
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// MaxImageDimension is the largest width or height an inline image may request. Larger images
+// are turned into links so a response can't push the conversation off screen.
+const MaxImageDimension = 1024
+
+var (
+	// linkRegexp matches inline links and images: the image marker, text, destination and an
+	// optional title or Mattermost image size such as "=200x100". One level of parentheses is
+	// allowed in destinations, as found in Wikipedia URLs.
+	linkRegexp = regexp.MustCompile(`(!?)\[([^\[\]\n]*)\]\(\s*<?((?:[^()\s<>]|\([^()\s]*\))*)>?(?:\s+("[^"\n]*"|'[^'\n]*'|=\d*x\d*))?\s*\)`)
+
+	// autolinkRegexp matches autolinks such as <https://example.com>
+	autolinkRegexp = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9+.\-]*:[^<>\s]*)>`)
+
+	// referenceRegexp matches reference link definitions such as [1]: https://example.com
+	referenceRegexp = regexp.MustCompile(`(?m)^[ \t]{0,3}\[[^\]\n]+\]:[ \t]*<?(\S*?)>?(?:[ \t]+.*)?$`)
+
+	imageSizeRegexp = regexp.MustCompile(`^=(\d*)x(\d*)$`)
+
+	// codeRegexp matches fenced code blocks and inline code, where markdown isn't rendered
+	codeRegexp = regexp.MustCompile("(?s)```.*?(?:```|$)|`[^`\n]*`")
+)
+
+var safeSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// Markdown returns the message without dangerous markdown. Links with schemes that run code,
+// such as javascript:, keep only their text. Links whose text is a URL for a different host than
+// their destination show the destination instead. Images larger than MaxImageDimension become
+// links. Bidirectional controls, which can make text read differently than it is stored, are
+// escaped everywhere and link text and destinations are escaped with llm.SanitizeNonPrintableChars.
+// Code is left unchanged since it isn't rendered.
+func Markdown(message string) string {
+	var result strings.Builder
+	result.Grow(len(message))
+
+	last := 0
+	for _, loc := range codeRegexp.FindAllStringIndex(message, -1) {
+		result.WriteString(sanitizeText(message[last:loc[0]]))
+		result.WriteString(message[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	result.WriteString(sanitizeText(message[last:]))
+
+	return result.String()
+}
+
+func sanitizeText(text string) string {
+	text = escapeBidiControls(text)
+
+	text = referenceRegexp.ReplaceAllStringFunc(text, func(definition string) string {
+		destination := referenceRegexp.FindStringSubmatch(definition)[1]
+		if !isSafeDestination(destination) {
+			return ""
+		}
+		return definition
+	})
+
+	text = autolinkRegexp.ReplaceAllStringFunc(text, func(autolink string) string {
+		destination := autolinkRegexp.FindStringSubmatch(autolink)[1]
+		if !isSafeDestination(destination) {
+			return llm.SanitizeNonPrintableChars(destination)
+		}
+		return autolink
+	})
+
+	return linkRegexp.ReplaceAllStringFunc(text, func(link string) string {
+		match := linkRegexp.FindStringSubmatch(link)
+		isImage, label, destination, suffix := match[1] == "!", match[2], match[3], match[4]
+		label = llm.SanitizeNonPrintableChars(label)
+		destination = llm.SanitizeNonPrintableChars(destination)
+
+		if !isSafeDestination(destination) {
+			return label
+		}
+
+		if isDeceptive(label, destination) {
+			label = destination
+		}
+
+		if isImage {
+			if isOversizedImage(suffix) {
+				return fmt.Sprintf("[%s](%s)", label, destination)
+			}
+			return formatLink("!", label, destination, suffix)
+		}
+		return formatLink("", label, destination, suffix)
+	})
+}
+
+func formatLink(prefix, label, destination, suffix string) string {
+	if suffix != "" {
+		return fmt.Sprintf("%s[%s](%s %s)", prefix, label, destination, suffix)
+	}
+	return fmt.Sprintf("%s[%s](%s)", prefix, label, destination)
+}
+
+// isSafeDestination reports whether a link destination is relative or uses a scheme that
+// can't run code. Control characters and whitespace that browsers ignore in schemes are
+// removed first, so "java\tscript:" is treated like "javascript:".
+func isSafeDestination(destination string) bool {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, destination)
+
+	colon := strings.IndexByte(cleaned, ':')
+	if colon < 0 {
+		return true
+	}
+	// A colon after a path, query or fragment delimiter is not a scheme separator
+	if delimiter := strings.IndexAny(cleaned, "/?#"); delimiter >= 0 && delimiter < colon {
+		return true
+	}
+
+	return safeSchemes[strings.ToLower(cleaned[:colon])]
+}
+
+// isDeceptive reports whether the text of a link is a URL for a different
+// host than the one the link goes to, as in [https://bank.com](https://attacker.com).
+// Subdomains of the host in the text are not deceptive.
+func isDeceptive(label, destination string) bool {
+	labelHost := hostOf(label)
+	if labelHost == "" {
+		return false
+	}
+
+	destinationHost := hostOf(destination)
+	if destinationHost == "" {
+		return true
+	}
+
+	return labelHost != destinationHost && !strings.HasSuffix(destinationHost, "."+labelHost)
+}
+
+// hostOf returns the lowercased host of text that is a URL, or "" otherwise. Bare names are
+// only taken as hosts with a www. prefix, since names like README.md look like domains too.
+func hostOf(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.ContainsAny(text, " \t\n") {
+		return ""
+	}
+
+	candidate := text
+	if !strings.Contains(candidate, "://") {
+		if !strings.HasPrefix(strings.ToLower(candidate), "www.") {
+			return ""
+		}
+		candidate = "https://" + candidate
+	}
+
+	parsed, err := url.Parse(candidate)
+	if err != nil || parsed.Hostname() == "" {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+func isOversizedImage(suffix string) bool {
+	match := imageSizeRegexp.FindStringSubmatch(suffix)
+	if match == nil {
+		return false
+	}
+	for _, dimension := range match[1:] {
+		if size, err := strconv.Atoi(dimension); err == nil && size > MaxImageDimension {
+			return true
+		}
+	}
+	return false
+}
+
+func escapeBidiControls(text string) string {
+	if !strings.ContainsFunc(text, isBidiControl) {
+		return text
+	}
+
+	var result strings.Builder
+	result.Grow(len(text))
+	for _, r := range text {
+		if isBidiControl(r) {
+			fmt.Fprintf(&result, "[U+%04X]", r)
+		} else {
+			result.WriteRune(r)
+		}
+	}
+	return result.String()
+}
+
+// isBidiControl reports whether r is a bidirectional embedding, override or isolate. The marks
+// used in right-to-left text, such as U+200F, are left alone.
+func isBidiControl(r rune) bool {
+	return (r >= 0x202A && r <= 0x202E) || (r >= 0x2066 && r <= 0x2069)
+}
+
+// PostProcessor sanitizes the markdown of completed responses before they are saved
+type PostProcessor struct{}
+
+// ProcessPost replaces the message of the post with its sanitized markdown.
+func (PostProcessor) ProcessPost(_ context.Context, post *model.Post, _ bool) {
+	post.Message = Markdown(post.Message)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package sanitize
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "plain text is unchanged",
+			input:    "Hello **world**, see [the docs](https://docs.mattermost.com) ❤️",
+			expected: "Hello **world**, see [the docs](https://docs.mattermost.com) ❤️",
+		},
+		{
+			name:     "javascript link keeps its text",
+			input:    "Click [here](javascript:alert(1)) now",
+			expected: "Click here now",
+		},
+		{
+			name:     "scheme in mixed case",
+			input:    "[here](JaVaScRiPt:alert(1))",
+			expected: "here",
+		},
+		{
+			name:     "data and vbscript links",
+			input:    "[a](data:text/html;base64,PHNjcmlwdD4=) [b](vbscript:msgbox)",
+			expected: "a b",
+		},
+		{
+			name:     "relative and mailto links are kept",
+			input:    "[channel](/team/channels/town-square) [mail](mailto:someone@example.com) [time](/path?at=10:30)",
+			expected: "[channel](/team/channels/town-square) [mail](mailto:someone@example.com) [time](/path?at=10:30)",
+		},
+		{
+			name:     "deceptive link text shows the destination",
+			input:    "Log in at [https://bank.example.com](https://attacker.example.net/login)",
+			expected: "Log in at [https://attacker.example.net/login](https://attacker.example.net/login)",
+		},
+		{
+			name:     "deceptive www text shows the destination",
+			input:    "[www.bank.com](https://attacker.net)",
+			expected: "[https://attacker.net](https://attacker.net)",
+		},
+		{
+			name:     "link text for the same host or a subdomain is kept",
+			input:    "[https://example.com](https://www.example.com/a) [example](https://docs.example.com) [https://example.com](https://docs.example.com/b)",
+			expected: "[https://example.com](https://www.example.com/a) [example](https://docs.example.com) [https://example.com](https://docs.example.com/b)",
+		},
+		{
+			name:     "file names are not mistaken for hosts",
+			input:    "[README.md](https://github.com/mattermost/mattermost/blob/master/README.md)",
+			expected: "[README.md](https://github.com/mattermost/mattermost/blob/master/README.md)",
+		},
+		{
+			name:     "oversized image becomes a link",
+			input:    "![diagram](https://example.com/a.png =4000x200)",
+			expected: "[diagram](https://example.com/a.png)",
+		},
+		{
+			name:     "sized image within limits is kept",
+			input:    "![diagram](https://example.com/a.png =400x200) ![logo](https://example.com/logo.png \"Logo\")",
+			expected: "![diagram](https://example.com/a.png =400x200) ![logo](https://example.com/logo.png \"Logo\")",
+		},
+		{
+			name:     "unsafe autolink becomes text",
+			input:    "Open <javascript:alert(1)> or <https://example.com>",
+			expected: "Open javascript:alert(1) or <https://example.com>",
+		},
+		{
+			name:     "unsafe reference definition is removed",
+			input:    "See [docs][1] and [site][2]\n\n[1]: javascript:alert(1)\n[2]: https://example.com \"Site\"",
+			expected: "See [docs][1] and [site][2]\n\n\n[2]: https://example.com \"Site\"",
+		},
+		{
+			name:     "bidirectional overrides are escaped",
+			input:    "File: invoice‮fdp.exe",
+			expected: "File: invoice[U+202E]fdp.exe",
+		},
+		{
+			name:     "invisible characters in link text are escaped",
+			input:    "[exa​mple](https://example.com)",
+			expected: "[exa[U+200B]mple](https://example.com)",
+		},
+		{
+			name:     "wikipedia style parentheses in destinations",
+			input:    "[Go](https://en.wikipedia.org/wiki/Go_(programming_language))",
+			expected: "[Go](https://en.wikipedia.org/wiki/Go_(programming_language))",
+		},
+		{
+			name:     "code is left unchanged",
+			input:    "Use `[x](javascript:void(0))` or\n```\n[y](javascript:alert(1))\n```\nbut not [z](javascript:alert(1))",
+			expected: "Use `[x](javascript:void(0))` or\n```\n[y](javascript:alert(1))\n```\nbut not z",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, Markdown(test.input))
+		})
+	}
+}
+
+func TestPostProcessor(t *testing.T) {
+	post := &model.Post{Message: "[here](javascript:alert(1))"}
+	PostProcessor{}.ProcessPost(context.Background(), post, false)
+	require.Equal(t, "here", post.Message)
+}
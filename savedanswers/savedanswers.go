@@ -0,0 +1,196 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package savedanswers stores the bot responses users save to their personal collection.
+// Saved answers are tagged so they can be found later, and can be given to the model when the user asks for them.
+package savedanswers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const (
+	// MaxTags is the maximum number of tags of a saved answer.
+	MaxTags = 10
+	// MaxTagLength is the maximum length of a tag.
+	MaxTagLength = 50
+	// MaxTermsLength is the maximum length of the search terms.
+	MaxTermsLength = 200
+	// MaxPerPage is the maximum number of saved answers returned at once.
+	MaxPerPage = 100
+)
+
+// ErrNotFound is returned when deleting a saved answer that does not exist or belongs to another user.
+var ErrNotFound = errors.New("saved answer not found")
+
+// SavedAnswer is a bot response saved by a user. The message is a copy of the response at the time
+// it was saved, and the saved answer is deleted with the post.
+type SavedAnswer struct {
+	ID        string   `json:"id" db:"id"`
+	UserID    string   `json:"user_id" db:"userid"`
+	PostID    string   `json:"post_id" db:"postid"`
+	BotID     string   `json:"bot_id" db:"botid"`
+	ChannelID string   `json:"channel_id" db:"channelid"`
+	Message   string   `json:"message" db:"message"`
+	Tags      []string `json:"tags" db:"-"`
+	CreateAt  int64    `json:"create_at" db:"createat"`
+	UpdateAt  int64    `json:"update_at" db:"updateat"`
+}
+
+// savedAnswerRow is a row of LLM_SavedAnswers, with the tags as JSON
+type savedAnswerRow struct {
+	SavedAnswer
+	Tags string `db:"tags"`
+}
+
+// Query filters the saved answers of a user. Terms are searched in the message.
+type Query struct {
+	UserID  string
+	Tag     string
+	Terms   string
+	Page    int
+	PerPage int
+}
+
+// NormalizeTags lowercases and trims the tags, dropping empty and duplicate ones, and checks
+// they are within the limits.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > MaxTagLength {
+			return nil, fmt.Errorf("tags cannot be longer than %d characters", MaxTagLength)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("a saved answer cannot have more than %d tags", MaxTags)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// NormalizeTag lowercases and trims a tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// Store persists the saved answers of the users.
+type Store struct {
+	db *mmapi.DBClient
+}
+
+// New creates a new saved answers store.
+func New(db *mmapi.DBClient) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// Save adds the bot post to the saved answers of the user. Saving a post again replaces its tags
+// and refreshes its message.
+func (s *Store) Save(userID string, post *model.Post, tags []string) (*SavedAnswer, error) {
+	tags, err := NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tags: %w", err)
+	}
+
+	now := model.GetMillis()
+	var rows []savedAnswerRow
+	if err := s.db.DoQuery(&rows, s.db.Builder().Insert("LLM_SavedAnswers").
+		Columns("ID", "UserID", "PostID", "BotID", "ChannelID", "Message", "Tags", "CreateAt", "UpdateAt").
+		Values(model.NewId(), userID, post.Id, post.UserId, post.ChannelId, post.Message, string(tagsJSON), now, now).
+		Suffix(`ON CONFLICT (UserID, PostID) DO UPDATE SET
+			Message = EXCLUDED.Message, Tags = EXCLUDED.Tags, UpdateAt = EXCLUDED.UpdateAt
+			RETURNING ID, UserID, PostID, BotID, ChannelID, Message, Tags, CreateAt, UpdateAt`),
+	); err != nil {
+		return nil, fmt.Errorf("failed to save answer: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("failed to save answer: no row returned")
+	}
+
+	return rows[0].toSavedAnswer()
+}
+
+// List returns a page of the saved answers of the user matching the query, most recently saved
+// first, and whether there are more.
+func (s *Store) List(query Query) ([]SavedAnswer, bool, error) {
+	builder := s.db.Builder().
+		Select("ID", "UserID", "PostID", "BotID", "ChannelID", "Message", "Tags", "CreateAt", "UpdateAt").
+		From("LLM_SavedAnswers").
+		Where(sq.Eq{"UserID": query.UserID})
+
+	if tag := NormalizeTag(query.Tag); tag != "" {
+		tagJSON, err := json.Marshal([]string{tag})
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to marshal tag: %w", err)
+		}
+		builder = builder.Where(sq.Expr("Tags::jsonb @> ?::jsonb", string(tagJSON)))
+	}
+	if terms := strings.TrimSpace(query.Terms); terms != "" {
+		builder = builder.Where(sq.Expr("to_tsvector('english', Message) @@ plainto_tsquery('english', ?)", terms))
+	}
+
+	var rows []savedAnswerRow
+	if err := s.db.DoQuery(&rows, builder.
+		OrderBy("UpdateAt DESC", "ID").
+		Limit(uint64(query.PerPage+1)).
+		Offset(uint64(query.Page*query.PerPage)),
+	); err != nil {
+		return nil, false, fmt.Errorf("failed to list saved answers: %w", err)
+	}
+	hasMore := len(rows) > query.PerPage
+	if hasMore {
+		rows = rows[:query.PerPage]
+	}
+
+	result := make([]SavedAnswer, 0, len(rows))
+	for _, row := range rows {
+		answer, err := row.toSavedAnswer()
+		if err != nil {
+			return nil, false, err
+		}
+		result = append(result, *answer)
+	}
+
+	return result, hasMore, nil
+}
+
+// Delete removes a saved answer of the user.
+func (s *Store) Delete(userID, id string) error {
+	result, err := s.db.ExecBuilder(s.db.Builder().Delete("LLM_SavedAnswers").
+		Where(sq.Eq{"ID": id, "UserID": userID}))
+	if err != nil {
+		return fmt.Errorf("failed to delete saved answer: %w", err)
+	}
+	if rows, rowsErr := result.RowsAffected(); rowsErr == nil && rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r savedAnswerRow) toSavedAnswer() (*SavedAnswer, error) {
+	answer := r.SavedAnswer
+	if err := json.Unmarshal([]byte(r.Tags), &answer.Tags); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal saved answer tags: %w", err)
+	}
+	return &answer, nil
+}
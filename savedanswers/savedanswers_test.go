@@ -0,0 +1,53 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package savedanswers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		tags        []string
+		expected    []string
+		expectError bool
+	}{
+		{
+			name:     "no tags",
+			expected: []string{},
+		},
+		{
+			name:     "lowercases, trims, sorts and drops duplicates",
+			tags:     []string{" Release ", "deploy", "release", "  "},
+			expected: []string{"deploy", "release"},
+		},
+		{
+			name:        "tag too long",
+			tags:        []string{strings.Repeat("a", MaxTagLength+1)},
+			expectError: true,
+		},
+		{
+			name:        "too many tags",
+			tags:        []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tags, err := NormalizeTags(tc.tags)
+			if tc.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, tags)
+		})
+	}
+}
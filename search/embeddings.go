@@ -10,8 +10,10 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"github.com/mattermost/mattermost-plugin-ai/chunking"
+	"github.com/mattermost/mattermost-plugin-ai/connectors"
 	"github.com/mattermost/mattermost-plugin-ai/embeddings"
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/openai"
 	"github.com/mattermost/mattermost-plugin-ai/postgres"
 )
@@ -56,14 +58,23 @@ func newEmbeddingProvider(config embeddings.UpstreamConfig, dimensions int, http
 	return nil, fmt.Errorf("unsupported embedding provider type: %s", config.Type)
 }
 
+// NewEmbeddingProvider creates the embedding provider configured for search
+func NewEmbeddingProvider(cfg embeddings.EmbeddingSearchConfig, httpClient *http.Client) (embeddings.EmbeddingProvider, error) {
+	if cfg.Type == "" {
+		return nil, fmt.Errorf("search is disabled")
+	}
+
+	return newEmbeddingProvider(cfg.EmbeddingProvider, cfg.Dimensions, httpClient)
+}
+
 // InitEmbeddingsSearch creates and initializes the embedding search system
 func InitEmbeddingsSearch(db *sqlx.DB, httpClient *http.Client, cfg embeddings.EmbeddingSearchConfig, licenseChecker *enterprise.LicenseChecker) (embeddings.EmbeddingSearch, error) {
 	if cfg.Type == "" {
 		return nil, fmt.Errorf("search is disabled")
 	}
 
-	if !licenseChecker.IsBasicsLicensed() {
-		return nil, fmt.Errorf("search is unavailable without a valid license")
+	if err := licenseChecker.CheckFeature(enterprise.FeatureSemanticSearch); err != nil {
+		return nil, fmt.Errorf("search is unavailable: %w", err)
 	}
 
 	switch cfg.Type { //nolint:gocritic
@@ -76,6 +87,9 @@ func InitEmbeddingsSearch(db *sqlx.DB, httpClient *http.Client, cfg embeddings.E
 		if err != nil {
 			return nil, err
 		}
+		if cfg.RequestsPerMinute > 0 {
+			embeddor = newScheduledEmbeddingProvider(embeddor, llm.NewPriorityScheduler(cfg.RequestsPerMinute))
+		}
 
 		// Check if we have specific chunking options configured
 		chunkingOpts := cfg.ChunkingOptions
@@ -88,3 +102,39 @@ func InitEmbeddingsSearch(db *sqlx.DB, httpClient *http.Client, cfg embeddings.E
 
 	return nil, fmt.Errorf("unsupported search type: %s", cfg.Type)
 }
+
+// InitDocumentStore creates the store of the documents of external sources, in the vector store
+// of the embedding search, and the embedding provider indexing them
+func InitDocumentStore(db *sqlx.DB, httpClient *http.Client, cfg embeddings.EmbeddingSearchConfig, licenseChecker *enterprise.LicenseChecker) (connectors.Store, embeddings.EmbeddingProvider, error) {
+	if cfg.Type == "" {
+		return nil, nil, fmt.Errorf("search is disabled")
+	}
+
+	if err := licenseChecker.CheckFeature(enterprise.FeatureSemanticSearch); err != nil {
+		return nil, nil, fmt.Errorf("search is unavailable: %w", err)
+	}
+
+	if cfg.VectorStore.Type != embeddings.VectorStoreTypePGVector {
+		return nil, nil, fmt.Errorf("unsupported vector store type: %s", cfg.VectorStore.Type)
+	}
+	pgVectorConfig := postgres.PGVectorConfig{
+		Dimensions: cfg.Dimensions,
+	}
+	if err := json.Unmarshal(cfg.VectorStore.Parameters, &pgVectorConfig); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal pgvector config: %w", err)
+	}
+	store, err := postgres.NewDocumentStore(db, pgVectorConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	embeddor, err := newEmbeddingProvider(cfg.EmbeddingProvider, cfg.Dimensions, httpClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.RequestsPerMinute > 0 {
+		embeddor = newScheduledEmbeddingProvider(embeddor, llm.NewPriorityScheduler(cfg.RequestsPerMinute))
+	}
+
+	return store, embeddor, nil
+}
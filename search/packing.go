@@ -0,0 +1,147 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package search
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+const (
+	// packingCandidatesFactor is how many more results than requested are retrieved, so enough
+	// remain once duplicates and results over the per-source cap are removed
+	packingCandidatesFactor = 3
+
+	// minChunkOverlap is the shortest text shared by two chunks of a post removed from the second
+	minChunkOverlap = 20
+)
+
+// candidatesLimit returns how many results to retrieve to pack limit of them with config
+func candidatesLimit(config llm.RAGContextConfig, limit int) int {
+	if config.Strategy == llm.RAGStrategyTopK {
+		return limit
+	}
+	return limit * packingCandidatesFactor
+}
+
+// contextBudget returns the token budget of the results packed with config for a model with the
+// input token limit
+func contextBudget(config llm.RAGContextConfig, inputTokenLimit int) int {
+	budget := config.MaxTokens
+	if budget == 0 {
+		budget = llm.DefaultRAGContextTokens
+	}
+	if inputTokenLimit > 0 {
+		budget = min(budget, inputTokenLimit/2)
+	}
+	return budget
+}
+
+// packResults selects up to limit of the results to answer from with the strategy of config. The
+// results whose content was already selected are removed and the text a chunk shares with a
+// selected chunk of its post is trimmed, at most config.MaxPerSource results are selected by channel, and the
+// selected results fit in budget tokens counted with countTokens. The first result is cut to fit
+// the budget when it's too large on its own, so there's always something to answer from.
+func packResults(results []embeddings.SearchResult, config llm.RAGContextConfig, limit, budget int, countTokens func(string) int) []embeddings.SearchResult {
+	if config.Strategy == llm.RAGStrategyTopK {
+		if len(results) > limit {
+			return results[:limit]
+		}
+		return results
+	}
+
+	candidates := slices.Clone(results)
+	if config.Strategy == llm.RAGStrategyRecency {
+		slices.SortStableFunc(candidates, func(a, b embeddings.SearchResult) int {
+			return cmp.Compare(b.Document.CreateAt, a.Document.CreateAt)
+		})
+	} else {
+		slices.SortStableFunc(candidates, func(a, b embeddings.SearchResult) int {
+			return cmp.Compare(b.Score, a.Score)
+		})
+	}
+
+	packed := make([]embeddings.SearchResult, 0, limit)
+	perSource := make(map[string]int)
+	used := 0
+	for _, candidate := range candidates {
+		if len(packed) == limit {
+			break
+		}
+
+		content, ok := dedupe(candidate, packed)
+		if !ok {
+			continue
+		}
+
+		source := candidate.Document.ChannelID
+		if config.MaxPerSource > 0 && perSource[source] >= config.MaxPerSource {
+			continue
+		}
+
+		tokens := countTokens(content)
+		if used+tokens > budget {
+			if len(packed) > 0 || tokens == 0 {
+				continue
+			}
+			content = strings.ToValidUTF8(content[:len(content)*budget/tokens], "")
+			tokens = budget
+		}
+
+		candidate.Document.Content = content
+		packed = append(packed, candidate)
+		perSource[source]++
+		used += tokens
+	}
+
+	return packed
+}
+
+// dedupe returns the content of candidate not already in the packed results, and false when
+// there's none
+func dedupe(candidate embeddings.SearchResult, packed []embeddings.SearchResult) (string, bool) {
+	content := strings.TrimSpace(candidate.Document.Content)
+	normalized := normalizeText(content)
+	if normalized == "" {
+		return "", false
+	}
+
+	for _, result := range packed {
+		if strings.Contains(normalizeText(result.Document.Content), normalized) {
+			return "", false
+		}
+		if result.Document.PostID != candidate.Document.PostID {
+			continue
+		}
+		// Chunks of the same post overlap on their boundaries
+		if overlap := overlapLength(result.Document.Content, content); overlap >= minChunkOverlap {
+			content = strings.TrimSpace(content[overlap:])
+		}
+		if overlap := overlapLength(content, result.Document.Content); overlap >= minChunkOverlap {
+			content = strings.TrimSpace(content[:len(content)-overlap])
+		}
+	}
+
+	return content, content != ""
+}
+
+// overlapLength returns the length of the longest end of a that b starts with
+func overlapLength(a, b string) int {
+	for length := min(len(a), len(b)); length > 0; length-- {
+		if strings.HasSuffix(a, b[:length]) {
+			return length
+		}
+	}
+	return 0
+}
+
+// normalizeText lowercases text and collapses its whitespace, so the same content formatted
+// differently is found again
+func normalizeText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
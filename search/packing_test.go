@@ -0,0 +1,104 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/chunking"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/stretchr/testify/assert"
+)
+
+// countWords counts a token per word
+func countWords(text string) int {
+	return len(strings.Fields(text))
+}
+
+func packedResult(postID, channelID, content string, score float32, createAt int64) embeddings.SearchResult {
+	return embeddings.SearchResult{
+		Document: embeddings.PostDocument{PostID: postID, ChannelID: channelID, Content: content, CreateAt: createAt},
+		Score:    score,
+	}
+}
+
+func packedContents(results []embeddings.SearchResult) []string {
+	contents := []string{}
+	for _, result := range results {
+		contents = append(contents, result.Document.Content)
+	}
+	return contents
+}
+
+func TestPackResults(t *testing.T) {
+	shared := "the release is planned for the end of the quarter"
+	chunk1 := packedResult("post1", "town-square", "Status update: "+shared, 0.9, 100)
+	chunk1.Document.ChunkInfo = chunking.ChunkInfo{IsChunk: true, ChunkIndex: 0, TotalChunks: 2}
+	chunk2 := packedResult("post1", "town-square", shared+" and the beta starts next week", 0.8, 100)
+	chunk2.Document.ChunkInfo = chunking.ChunkInfo{IsChunk: true, ChunkIndex: 1, TotalChunks: 2}
+
+	results := []embeddings.SearchResult{
+		chunk1,
+		chunk2,
+		packedResult("post2", "town-square", "Status update:  THE release is planned for the end of the quarter", 0.7, 300),
+		packedResult("post3", "town-square", "The release notes are drafted", 0.6, 200),
+		packedResult("post4", "releases", "Release checklist is ready", 0.5, 50),
+	}
+
+	t.Run("removes duplicates and overlapping text", func(t *testing.T) {
+		packed := packResults(results, llm.RAGContextConfig{}, 5, 1000, countWords)
+		assert.Equal(t, []string{
+			"Status update: " + shared,
+			"and the beta starts next week",
+			"The release notes are drafted",
+			"Release checklist is ready",
+		}, packedContents(packed))
+	})
+
+	t.Run("orders by score", func(t *testing.T) {
+		reversed := []embeddings.SearchResult{results[4], results[3]}
+		packed := packResults(reversed, llm.RAGContextConfig{}, 5, 1000, countWords)
+		assert.Equal(t, []string{"The release notes are drafted", "Release checklist is ready"}, packedContents(packed))
+	})
+
+	t.Run("orders by recency", func(t *testing.T) {
+		packed := packResults(results, llm.RAGContextConfig{Strategy: llm.RAGStrategyRecency}, 2, 1000, countWords)
+		assert.Equal(t, []string{
+			"Status update:  THE release is planned for the end of the quarter",
+			"The release notes are drafted",
+		}, packedContents(packed))
+	})
+
+	t.Run("caps the results of a channel", func(t *testing.T) {
+		packed := packResults(results, llm.RAGContextConfig{MaxPerSource: 1}, 5, 1000, countWords)
+		assert.Equal(t, []string{"Status update: " + shared, "Release checklist is ready"}, packedContents(packed))
+	})
+
+	t.Run("enforces the token budget", func(t *testing.T) {
+		packed := packResults(results, llm.RAGContextConfig{}, 5, 22, countWords)
+		assert.Equal(t, []string{
+			"Status update: " + shared,
+			"and the beta starts next week",
+			"Release checklist is ready",
+		}, packedContents(packed))
+
+		packed = packResults(results, llm.RAGContextConfig{}, 5, 2, countWords)
+		assert.Len(t, packed, 1)
+		assert.True(t, strings.HasPrefix(chunk1.Document.Content, packed[0].Document.Content))
+		assert.LessOrEqual(t, countWords(packed[0].Document.Content), 2)
+	})
+
+	t.Run("top k keeps the results as they are", func(t *testing.T) {
+		packed := packResults(results, llm.RAGContextConfig{Strategy: llm.RAGStrategyTopK}, 3, 1, countWords)
+		assert.Equal(t, results[:3], packed)
+	})
+}
+
+func TestContextBudget(t *testing.T) {
+	assert.Equal(t, llm.DefaultRAGContextTokens, contextBudget(llm.RAGContextConfig{}, 0))
+	assert.Equal(t, 1000, contextBudget(llm.RAGContextConfig{MaxTokens: 1000}, 128000))
+	assert.Equal(t, 2000, contextBudget(llm.RAGContextConfig{}, 4000))
+}
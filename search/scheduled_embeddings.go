@@ -0,0 +1,60 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+)
+
+// scheduledEmbeddingProvider waits for the capacity of the embedding provider before each
+// request, using the priority carried by the request's context
+type scheduledEmbeddingProvider struct {
+	wrapped   embeddings.EmbeddingProvider
+	scheduler *llm.PriorityScheduler
+}
+
+func newScheduledEmbeddingProvider(wrapped embeddings.EmbeddingProvider, scheduler *llm.PriorityScheduler) *scheduledEmbeddingProvider {
+	return &scheduledEmbeddingProvider{
+		wrapped:   wrapped,
+		scheduler: scheduler,
+	}
+}
+
+func (p *scheduledEmbeddingProvider) acquire(ctx context.Context) error {
+	priority := llm.PriorityFromContext(ctx)
+
+	// Indexing waits as long as it has to, it is what gives way to the other requests
+	if priority != llm.PriorityIndexing {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, llm.DefaultSchedulerMaxWait)
+		defer cancel()
+	}
+
+	if err := p.scheduler.Acquire(ctx, priority); err != nil {
+		return fmt.Errorf("%w: %s embedding request: %w", llm.ErrSchedulerTimeout, priority, err)
+	}
+	return nil
+}
+
+func (p *scheduledEmbeddingProvider) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if err := p.acquire(ctx); err != nil {
+		return nil, err
+	}
+	return p.wrapped.CreateEmbedding(ctx, text)
+}
+
+func (p *scheduledEmbeddingProvider) BatchCreateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := p.acquire(ctx); err != nil {
+		return nil, err
+	}
+	return p.wrapped.BatchCreateEmbeddings(ctx, texts)
+}
+
+func (p *scheduledEmbeddingProvider) Dimensions() int {
+	return p.wrapped.Dimensions()
+}
@@ -6,8 +6,11 @@ package search
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/embeddings"
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
@@ -20,6 +23,16 @@ import (
 const (
 	SearchResultsProp = "search_results"
 	SearchQueryProp   = "search_query"
+
+	// UnknownUsername is the username of the results whose author couldn't be found
+	UnknownUsername = "Unknown User"
+)
+
+var (
+	// ErrNoResults is returned when no posts relevant to a query were found
+	ErrNoResults = errors.New("no relevant results found")
+	// ErrNoUser is returned when searching without the user whose permissions apply
+	ErrNoUser = errors.New("a user is required to check permissions")
 )
 
 // Request represents a search query request
@@ -55,6 +68,26 @@ type Search struct {
 	prompts          *llm.Prompts
 	streamingService streaming.Service
 	licenseChecker   *enterprise.LicenseChecker
+	channelExcluder  ChannelExcluder
+	resultScorer     ResultScorer
+	usage            UsageTracker
+}
+
+// ChannelExcluder reports the channels whose content must never be sent to LLM providers
+type ChannelExcluder interface {
+	IsChannelExcluded(channelID, teamID string) bool
+}
+
+// ResultScorer adjusts the scores of the results answers are based on, such as with the feedback
+// users gave to past answers
+type ResultScorer interface {
+	AdjustScores(results []embeddings.SearchResult) []embeddings.SearchResult
+}
+
+// UsageTracker records the usage of the searches answered in the background
+type UsageTracker interface {
+	Record(event analytics.Event) error
+	TrackStream(stream *llm.TextStreamResult, event analytics.Event) *llm.TextStreamResult
 }
 
 func New(
@@ -73,6 +106,70 @@ func New(
 	}
 }
 
+// SetChannelExcluder sets the exclusions of channels left out of search results
+func (s *Search) SetChannelExcluder(excluder ChannelExcluder) {
+	s.channelExcluder = excluder
+}
+
+// SetResultScorer sets what adjusts the scores of the results answers are based on
+func (s *Search) SetResultScorer(scorer ResultScorer) {
+	s.resultScorer = scorer
+}
+
+// SetUsageTracker records the usage of the searches run with RunSearch once they finish
+func (s *Search) SetUsageTracker(usage UsageTracker) {
+	s.usage = usage
+}
+
+// Search searches the index for the posts opts.UserID may read, leaving out posts of channels
+// excluded from AI processing that were indexed before their exclusion. The vector store
+// enforces the permissions, they are checked again here so content never leaks from a store
+// that doesn't, nor from channels whose access changed while the search ran.
+func (s *Search) Search(ctx context.Context, query string, opts embeddings.SearchOptions) ([]embeddings.SearchResult, error) {
+	if opts.UserID == "" {
+		return nil, ErrNoUser
+	}
+
+	results, err := s.EmbeddingSearch.Search(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	readable := make(map[string]bool)
+	filtered := make([]embeddings.SearchResult, 0, len(results))
+	for _, result := range results {
+		channelID := result.Document.ChannelID
+		allowed, ok := readable[channelID]
+		if !ok {
+			allowed = s.canRead(opts, result.Document)
+			readable[channelID] = allowed
+		}
+		if allowed {
+			filtered = append(filtered, result)
+		}
+	}
+
+	return filtered, nil
+}
+
+// canRead reports whether the results of the search may include the posts of the channel of doc
+func (s *Search) canRead(opts embeddings.SearchOptions, doc embeddings.PostDocument) bool {
+	if s.channelExcluder != nil && s.channelExcluder.IsChannelExcluded(doc.ChannelID, doc.TeamID) {
+		return false
+	}
+	if s.mmclient == nil {
+		return false
+	}
+	if !s.mmclient.HasPermissionToChannel(opts.UserID, doc.ChannelID, model.PermissionReadChannel) {
+		return false
+	}
+	if !opts.PublicChannelsOnly {
+		return true
+	}
+	channel, err := s.mmclient.GetChannel(doc.ChannelID)
+	return err == nil && channel.Type == model.ChannelTypeOpen && channel.DeleteAt == 0
+}
+
 // Enabled returns true if the search service is enabled and functional
 func (s *Search) Enabled() bool {
 	return s != nil && s.EmbeddingSearch != nil
@@ -104,7 +201,7 @@ func (s *Search) convertToRAGResults(searchResults []embeddings.SearchResult) []
 		user, userErr := s.mmclient.GetUser(result.Document.UserID)
 		if userErr != nil {
 			s.mmclient.LogWarn("Failed to get user", "error", userErr, "userID", result.Document.UserID)
-			username = "Unknown User"
+			username = UnknownUsername
 		} else {
 			username = user.Username
 		}
@@ -156,6 +253,13 @@ func (s *Search) RunSearch(ctx context.Context, userID string, bot *bots.Bot, qu
 
 	// Start processing the search asynchronously
 	go func(query, teamID, channelID string, maxResults int) {
+		start := time.Now()
+		event := analytics.NewEvent(analytics.FeatureSearch, bot.GetMMBot().UserId, userID, nil)
+		event.ChannelID = channelID
+		if teamID != "" {
+			event.TeamID = teamID
+		}
+
 		// Create response post as a reply
 		responsePost := &model.Post{
 			RootId: questionPost.Id,
@@ -165,13 +269,15 @@ func (s *Search) RunSearch(ctx context.Context, userID string, bot *bots.Bot, qu
 		if err := s.botDMNonResponse(bot.GetMMBot().UserId, userID, responsePost); err != nil {
 			// Not much point in retrying if this failed. (very unlikely beyond dev)
 			s.mmclient.LogError("Error creating bot DM", "error", err)
+			s.recordUsage(event, start, true)
 			return
 		}
 
-		// Setup error handling to update the post on error
+		// Setup error handling to update the post and record the failure on error
 		var processingError error
 		defer func() {
 			if processingError != nil {
+				s.recordUsage(event, start, true)
 				responsePost.Message = "I encountered an error while searching. Please try again later. See server logs for details."
 				if err := s.mmclient.UpdatePost(responsePost); err != nil {
 					s.mmclient.LogError("Error updating post on error", "error", err)
@@ -179,63 +285,26 @@ func (s *Search) RunSearch(ctx context.Context, userID string, bot *bots.Bot, qu
 			}
 		}()
 
-		// Perform search
-		if maxResults == 0 {
-			maxResults = 5
-		}
-
-		searchResults, err := s.Search(context.Background(), query, embeddings.SearchOptions{
-			Limit:     maxResults,
-			TeamID:    teamID,
-			ChannelID: channelID,
-			UserID:    userID,
-		})
+		// Get the streaming context first so stopping the post also stops the answer
+		streamContext, err := s.streamingService.GetStreamingContext(ctx, responsePost.Id)
 		if err != nil {
-			s.mmclient.LogError("Error performing search", "error", err)
+			s.mmclient.LogError("Error getting post streaming context", "error", err)
 			processingError = err
 			return
 		}
+		defer s.streamingService.FinishStreaming(responsePost.Id)
 
-		ragResults := s.convertToRAGResults(searchResults)
-		if len(ragResults) == 0 {
+		resultStream, ragResults, err := s.AnswerStream(streamContext, userID, bot, query, teamID, channelID, maxResults)
+		if errors.Is(err, ErrNoResults) {
+			s.recordUsage(event, start, false)
 			responsePost.Message = "I couldn't find any relevant messages for your query. Please try a different search term."
 			if updateErr := s.mmclient.UpdatePost(responsePost); updateErr != nil {
 				s.mmclient.LogError("Error updating post on error", "error", updateErr)
 			}
 			return
 		}
-
-		// Create context for generating answer
-		promptCtx := llm.NewContext()
-		promptCtx.Parameters = map[string]interface{}{
-			"Query":   query,
-			"Results": ragResults,
-		}
-
-		systemMessage, err := s.prompts.Format("search_system", promptCtx)
 		if err != nil {
-			s.mmclient.LogError("Error formatting system message", "error", err)
-			processingError = err
-			return
-		}
-
-		prompt := llm.CompletionRequest{
-			Posts: []llm.Post{
-				{
-					Role:    llm.PostRoleSystem,
-					Message: systemMessage,
-				},
-				{
-					Role:    llm.PostRoleUser,
-					Message: query,
-				},
-			},
-			Context: promptCtx,
-		}
-
-		resultStream, err := bot.LLM().ChatCompletion(prompt)
-		if err != nil {
-			s.mmclient.LogError("Error generating answer", "error", err)
+			s.mmclient.LogError("Error answering search query", "error", err)
 			processingError = err
 			return
 		}
@@ -255,14 +324,10 @@ func (s *Search) RunSearch(ctx context.Context, userID string, bot *bots.Bot, qu
 			return
 		}
 
-		streamContext, err := s.streamingService.GetStreamingContext(context.Background(), responsePost.Id)
-		if err != nil {
-			s.mmclient.LogError("Error getting post streaming context", "error", err)
-			processingError = err
-			return
+		if s.usage != nil {
+			resultStream = s.usage.TrackStream(resultStream, event)
 		}
-		defer s.streamingService.FinishStreaming(responsePost.Id)
-		s.streamingService.StreamToPost(streamContext, resultStream, responsePost, "")
+		s.streamingService.StreamToPost(streaming.WithFeature(streamContext, analytics.FeatureSearch), resultStream, responsePost, "")
 	}(query, teamID, channelID, maxResults)
 
 	return map[string]string{
@@ -271,28 +336,73 @@ func (s *Search) RunSearch(ctx context.Context, userID string, bot *bots.Bot, qu
 	}, nil
 }
 
-// SearchQuery performs a search and returns results immediately
-func (s *Search) SearchQuery(ctx context.Context, userID string, bot *bots.Bot, query, teamID, channelID string, maxResults int) (Response, error) {
+// recordUsage records the usage of a search answered in the background that ended before its
+// answer was streamed
+func (s *Search) recordUsage(event analytics.Event, start time.Time, failed bool) {
+	if s.usage == nil {
+		return
+	}
+	event.LatencyMS = time.Since(start).Milliseconds()
+	event.Failed = failed
+	if err := s.usage.Record(event); err != nil {
+		s.mmclient.LogError("Failed to record search usage", "error", err)
+	}
+}
+
+// AnswerStream searches for posts relevant to the query and streams an answer based on them.
+// Returns ErrNoResults when nothing relevant was found.
+func (s *Search) AnswerStream(ctx context.Context, userID string, bot *bots.Bot, query, teamID, channelID string, maxResults int) (*llm.TextStreamResult, []RAGResult, error) {
 	if !s.Enabled() {
-		return Response{}, fmt.Errorf("search functionality is not configured")
+		return nil, nil, fmt.Errorf("search functionality is not configured")
 	}
 
-	if maxResults == 0 {
-		maxResults = 5
+	ragResults, err := s.retrieve(ctx, userID, bot, query, teamID, channelID, maxResults)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(ragResults) == 0 {
+		return nil, nil, ErrNoResults
 	}
 
-	// Search for relevant posts using embeddings
-	searchResults, err := s.Search(ctx, query, embeddings.SearchOptions{
-		Limit:     maxResults,
-		TeamID:    teamID,
-		ChannelID: channelID,
-		UserID:    userID,
-	})
+	promptCtx := s.promptContext(userID, bot, query, ragResults)
+
+	systemMessage, err := s.prompts.Format("search_system", promptCtx)
 	if err != nil {
-		return Response{}, fmt.Errorf("search failed: %w", err)
+		return nil, nil, fmt.Errorf("failed to format system message: %w", err)
 	}
 
-	ragResults := s.convertToRAGResults(searchResults)
+	prompt := llm.CompletionRequest{
+		Posts: []llm.Post{
+			{
+				Role:    llm.PostRoleSystem,
+				Message: systemMessage,
+			},
+			{
+				Role:    llm.PostRoleUser,
+				Message: query,
+			},
+		},
+		Context: promptCtx,
+	}
+
+	resultStream, err := bot.FeatureLLM(analytics.FeatureSearch).ChatCompletion(ctx, prompt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate answer: %w", err)
+	}
+
+	return resultStream, ragResults, nil
+}
+
+// SearchQuery performs a search and returns results immediately
+func (s *Search) SearchQuery(ctx context.Context, userID string, bot *bots.Bot, query, teamID, channelID string, maxResults int) (Response, error) {
+	if !s.Enabled() {
+		return Response{}, fmt.Errorf("search functionality is not configured")
+	}
+
+	ragResults, err := s.retrieve(ctx, userID, bot, query, teamID, channelID, maxResults)
+	if err != nil {
+		return Response{}, err
+	}
 	if len(ragResults) == 0 {
 		return Response{
 			Answer:  "I couldn't find any relevant messages for your query. Please try a different search term.",
@@ -300,11 +410,7 @@ func (s *Search) SearchQuery(ctx context.Context, userID string, bot *bots.Bot,
 		}, nil
 	}
 
-	promptCtx := llm.NewContext()
-	promptCtx.Parameters = map[string]interface{}{
-		"Query":   query,
-		"Results": ragResults,
-	}
+	promptCtx := s.promptContext(userID, bot, query, ragResults)
 
 	systemMessage, err := s.prompts.Format("search_system", promptCtx)
 	if err != nil {
@@ -325,7 +431,7 @@ func (s *Search) SearchQuery(ctx context.Context, userID string, bot *bots.Bot,
 		Context: promptCtx,
 	}
 
-	answer, err := bot.LLM().ChatCompletionNoStream(prompt)
+	answer, err := bot.FeatureLLM(analytics.FeatureSearch).ChatCompletionNoStream(ctx, prompt)
 	if err != nil {
 		return Response{}, fmt.Errorf("failed to generate answer: %w", err)
 	}
@@ -336,6 +442,57 @@ func (s *Search) SearchQuery(ctx context.Context, userID string, bot *bots.Bot,
 	}, nil
 }
 
+// retrieve searches for the posts relevant to the query and packs up to maxResults of them within
+// the budget of the bot's RAG context configuration
+func (s *Search) retrieve(ctx context.Context, userID string, bot *bots.Bot, query, teamID, channelID string, maxResults int) ([]RAGResult, error) {
+	if maxResults == 0 {
+		maxResults = 5
+	}
+
+	config := bot.GetConfig().RAGContext
+	searchResults, err := s.Search(ctx, query, embeddings.SearchOptions{
+		Limit:     candidatesLimit(config, maxResults),
+		TeamID:    teamID,
+		ChannelID: channelID,
+		UserID:    userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	if len(searchResults) == 0 {
+		return nil, nil
+	}
+	if s.resultScorer != nil {
+		searchResults = s.resultScorer.AdjustScores(searchResults)
+	}
+
+	languageModel := bot.FeatureLLM(analytics.FeatureSearch)
+	budget := contextBudget(config, languageModel.InputTokenLimit())
+	packed := packResults(searchResults, config, maxResults, budget, languageModel.CountTokens)
+
+	return s.convertToRAGResults(packed), nil
+}
+
+// promptContext builds the context for answering query from results in the user's language
+func (s *Search) promptContext(userID string, bot *bots.Bot, query string, results []RAGResult) *llm.Context {
+	promptCtx := llm.NewContext()
+	promptCtx.Parameters = map[string]interface{}{
+		"Query":   query,
+		"Results": results,
+	}
+
+	if mmBot := bot.GetMMBot(); mmBot != nil {
+		promptCtx.BotUserID = mmBot.UserId
+	}
+	promptCtx.OutputLanguage = bot.GetConfig().OutputLanguage
+
+	if user, err := s.mmclient.GetUser(userID); err == nil {
+		promptCtx.Locale = user.Locale
+	}
+
+	return promptCtx
+}
+
 func (s *Search) botDMNonResponse(botid string, userID string, post *model.Post) error {
 	streaming.ModifyPostForBot(botid, userID, post, "")
 
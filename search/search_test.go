@@ -0,0 +1,131 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings"
+	"github.com/mattermost/mattermost-plugin-ai/embeddings/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	mmapimocks "github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeExcluder struct {
+	excluded string
+}
+
+func (f fakeExcluder) IsChannelExcluded(channelID, _ string) bool {
+	return channelID == f.excluded
+}
+
+func searchResult(postID, channelID string) embeddings.SearchResult {
+	return embeddings.SearchResult{Document: embeddings.PostDocument{PostID: postID, ChannelID: channelID, TeamID: "team1"}}
+}
+
+func postIDs(results []embeddings.SearchResult) []string {
+	ids := []string{}
+	for _, result := range results {
+		ids = append(ids, result.Document.PostID)
+	}
+	return ids
+}
+
+func TestSearchEnforcesPermissions(t *testing.T) {
+	// The store returns posts of every channel, as a store that doesn't enforce permissions would
+	storeResults := []embeddings.SearchResult{
+		searchResult("public1", "town-square"),
+		searchResult("private1", "leadership"),
+		searchResult("public2", "town-square"),
+		searchResult("dm1", "dm-alice-bob"),
+		searchResult("left1", "left-private"),
+		searchResult("excluded1", "excluded"),
+	}
+
+	newSearch := func(t *testing.T) (*Search, *mmapimocks.MockClient) {
+		store := mocks.NewMockEmbeddingSearch(t)
+		store.EXPECT().Search(mock.Anything, "budget", mock.Anything).Return(storeResults, nil)
+		client := mmapimocks.NewMockClient(t)
+		client.EXPECT().HasPermissionToChannel("alice", "town-square", model.PermissionReadChannel).Return(true).Maybe()
+		client.EXPECT().HasPermissionToChannel("alice", "leadership", model.PermissionReadChannel).Return(true).Maybe()
+		client.EXPECT().HasPermissionToChannel("alice", "dm-alice-bob", model.PermissionReadChannel).Return(true).Maybe()
+		client.EXPECT().HasPermissionToChannel("alice", "left-private", model.PermissionReadChannel).Return(false).Maybe()
+		search := New(store, client, nil, nil, nil)
+		search.SetChannelExcluder(fakeExcluder{excluded: "excluded"})
+		return search, client
+	}
+
+	t.Run("private content is only returned to its members", func(t *testing.T) {
+		search, _ := newSearch(t)
+		results, err := search.Search(context.Background(), "budget", embeddings.SearchOptions{UserID: "alice"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"public1", "private1", "public2", "dm1"}, postIDs(results))
+	})
+
+	t.Run("results shown to others only include public channels", func(t *testing.T) {
+		search, client := newSearch(t)
+		client.EXPECT().GetChannel("town-square").Return(&model.Channel{Id: "town-square", Type: model.ChannelTypeOpen}, nil).Once()
+		client.EXPECT().GetChannel("leadership").Return(&model.Channel{Id: "leadership", Type: model.ChannelTypePrivate}, nil).Once()
+		client.EXPECT().GetChannel("dm-alice-bob").Return(&model.Channel{Id: "dm-alice-bob", Type: model.ChannelTypeDirect}, nil).Once()
+
+		results, err := search.Search(context.Background(), "budget", embeddings.SearchOptions{UserID: "alice", PublicChannelsOnly: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"public1", "public2"}, postIDs(results))
+	})
+
+	t.Run("searching without a user fails", func(t *testing.T) {
+		search := New(mocks.NewMockEmbeddingSearch(t), mmapimocks.NewMockClient(t), nil, nil, nil)
+		_, err := search.Search(context.Background(), "budget", embeddings.SearchOptions{})
+		require.ErrorIs(t, err, ErrNoUser)
+	})
+}
+
+// fakeUsage hands the recorded events to the test
+type fakeUsage struct {
+	events chan analytics.Event
+}
+
+func (f fakeUsage) Record(event analytics.Event) error {
+	f.events <- event
+	return nil
+}
+
+func (f fakeUsage) TrackStream(stream *llm.TextStreamResult, _ analytics.Event) *llm.TextStreamResult {
+	return stream
+}
+
+func TestRunSearchRecordsUsageWhenFinished(t *testing.T) {
+	client := mmapimocks.NewMockClient(t)
+	client.On("DM", "alice", "bot", mock.Anything).Return(nil).Once()
+	client.On("DM", "bot", "alice", mock.Anything).Return(errors.New("DM failed")).Once()
+	client.On("LogError", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	usage := fakeUsage{events: make(chan analytics.Event, 1)}
+	search := New(mocks.NewMockEmbeddingSearch(t), client, nil, nil, nil)
+	search.SetUsageTracker(usage)
+	bot := bots.NewBot(llm.BotConfig{}, llm.ServiceConfig{}, &model.Bot{UserId: "bot"}, nil)
+
+	_, err := search.RunSearch(context.Background(), "alice", bot, "budget", "team1", "channel1", 5)
+	require.NoError(t, err)
+
+	select {
+	case event := <-usage.events:
+		assert.Equal(t, analytics.FeatureSearch, event.Feature)
+		assert.Equal(t, "alice", event.UserID)
+		assert.Equal(t, "team1", event.TeamID)
+		assert.Equal(t, "channel1", event.ChannelID)
+		assert.True(t, event.Failed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the search usage was not recorded")
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package secrets encrypts the provider credentials stored in the plugin configuration so they
+// are never persisted in plain text.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// encryptedPrefix marks encrypted values, followed by the ID of the key and the ciphertext:
+// enc:v1:<key id>:<base64 nonce and ciphertext>
+const encryptedPrefix = "enc:v1:"
+
+const (
+	// keyDerivationSalt is fixed so the same passphrase always derives the same key
+	keyDerivationSalt       = "mattermost-ai-secrets"
+	keyDerivationIterations = 100000
+)
+
+var (
+	// ErrNoPassphrase is returned when no passphrase is available to derive the encryption key
+	ErrNoPassphrase = errors.New("no secrets passphrase is configured")
+
+	// ErrUnknownKey is returned when a value was encrypted with a key that is not in the keyring
+	ErrUnknownKey = errors.New("secret was encrypted with an unknown key")
+)
+
+type key struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// Keyring encrypts secrets with its current key and decrypts secrets encrypted with the
+// current or any previous key, so keys can be rotated.
+type Keyring struct {
+	current  key
+	previous []key
+}
+
+// IsEncrypted returns whether the value was encrypted by a keyring
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+func deriveKey(passphrase string) (key, error) {
+	derived, err := pbkdf2.Key(sha256.New, passphrase, []byte(keyDerivationSalt), keyDerivationIterations, 32)
+	if err != nil {
+		return key{}, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return key{}, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return key{}, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	// The ID identifies the key without revealing it
+	sum := sha256.Sum256(derived)
+	return key{
+		id:   hex.EncodeToString(sum[:4]),
+		aead: aead,
+	}, nil
+}
+
+// NewPassphraseKeyring creates a keyring with a key derived from the current passphrase, and
+// keys derived from previous passphrases to decrypt secrets that were not rotated yet.
+func NewPassphraseKeyring(current string, previous []string) (*Keyring, error) {
+	if current == "" {
+		return nil, ErrNoPassphrase
+	}
+
+	currentKey, err := deriveKey(current)
+	if err != nil {
+		return nil, err
+	}
+
+	keyring := &Keyring{current: currentKey}
+	for _, passphrase := range previous {
+		if passphrase == "" || passphrase == current {
+			continue
+		}
+		previousKey, err := deriveKey(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		keyring.previous = append(keyring.previous, previousKey)
+	}
+
+	return keyring, nil
+}
+
+// Encrypt encrypts the value with the current key. Empty and already encrypted values are
+// returned unchanged.
+func (k *Keyring) Encrypt(value string) (string, error) {
+	return k.EncryptBound(value, "")
+}
+
+// EncryptBound encrypts the value like Encrypt, bound to binding: it can only be decrypted with
+// the same binding, so a value copied to another record can't be read.
+func (k *Keyring) EncryptBound(value, binding string) (string, error) {
+	if value == "" || IsEncrypted(value) {
+		return value, nil
+	}
+
+	nonce := make([]byte, k.current.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := k.current.aead.Seal(nonce, nonce, []byte(value), []byte(binding))
+
+	return encryptedPrefix + k.current.id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (k *Keyring) keyByID(id string) (key, bool) {
+	if k.current.id == id {
+		return k.current, true
+	}
+	for _, previous := range k.previous {
+		if previous.id == id {
+			return previous, true
+		}
+	}
+	return key{}, false
+}
+
+// Decrypt returns the plain text of an encrypted value. Values that are not encrypted are
+// returned unchanged.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	return k.DecryptBound(value, "")
+}
+
+// DecryptBound returns the plain text of a value encrypted with EncryptBound and the same binding
+func (k *Keyring) DecryptBound(value, binding string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	keyID, encoded, found := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if !found {
+		return "", errors.New("malformed encrypted secret")
+	}
+
+	decryptionKey, ok := k.keyByID(keyID)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted secret: %w", err)
+	}
+	if len(sealed) < decryptionKey.aead.NonceSize() {
+		return "", errors.New("malformed encrypted secret")
+	}
+
+	nonce, ciphertext := sealed[:decryptionKey.aead.NonceSize()], sealed[decryptionKey.aead.NonceSize():]
+	plaintext, err := decryptionKey.aead.Open(nil, nonce, ciphertext, []byte(binding))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// IsCurrent returns whether the value is encrypted with the current key
+func (k *Keyring) IsCurrent(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix+k.current.id+":")
+}
+
+// Rotate re-encrypts the value with the current key. Plain text values are encrypted.
+func (k *Keyring) Rotate(value string) (string, error) {
+	return k.RotateBound(value, "")
+}
+
+// RotateBound re-encrypts a value encrypted with EncryptBound with the current key
+func (k *Keyring) RotateBound(value, binding string) (string, error) {
+	if value == "" || k.IsCurrent(value) {
+		return value, nil
+	}
+
+	plaintext, err := k.DecryptBound(value, binding)
+	if err != nil {
+		return "", err
+	}
+	return k.EncryptBound(plaintext, binding)
+}
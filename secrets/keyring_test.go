@@ -0,0 +1,104 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyringEncryptDecrypt(t *testing.T) {
+	keyring, err := NewPassphraseKeyring("passphrase", nil)
+	require.NoError(t, err)
+
+	encrypted, err := keyring.Encrypt("sk-secret")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, encrypted, "sk-secret")
+	assert.True(t, keyring.IsCurrent(encrypted))
+
+	decrypted, err := keyring.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-secret", decrypted)
+
+	// Encrypting twice does not nest the encryption
+	again, err := keyring.Encrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, encrypted, again)
+
+	// Empty and plain text values are passed through
+	empty, err := keyring.Encrypt("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+	plain, err := keyring.Decrypt("sk-plain")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-plain", plain)
+}
+
+func TestKeyringErrors(t *testing.T) {
+	_, err := NewPassphraseKeyring("", nil)
+	assert.ErrorIs(t, err, ErrNoPassphrase)
+
+	keyring, err := NewPassphraseKeyring("passphrase", nil)
+	require.NoError(t, err)
+	other, err := NewPassphraseKeyring("other", nil)
+	require.NoError(t, err)
+
+	encrypted, err := other.Encrypt("sk-secret")
+	require.NoError(t, err)
+	_, err = keyring.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	_, err = keyring.Decrypt(encryptedPrefix + "nocolon")
+	assert.Error(t, err)
+	_, err = keyring.Decrypt(encryptedPrefix + keyring.current.id + ":bm90LWVub3VnaA==")
+	assert.Error(t, err)
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldKeyring, err := NewPassphraseKeyring("old", nil)
+	require.NoError(t, err)
+	encrypted, err := oldKeyring.Encrypt("sk-secret")
+	require.NoError(t, err)
+
+	keyring, err := NewPassphraseKeyring("new", []string{"old"})
+	require.NoError(t, err)
+	assert.False(t, keyring.IsCurrent(encrypted))
+
+	// Values encrypted with a previous key can still be read
+	decrypted, err := keyring.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-secret", decrypted)
+
+	rotated, err := keyring.Rotate(encrypted)
+	require.NoError(t, err)
+	assert.True(t, keyring.IsCurrent(rotated))
+
+	newOnly, err := NewPassphraseKeyring("new", nil)
+	require.NoError(t, err)
+	decrypted, err = newOnly.Decrypt(rotated)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-secret", decrypted)
+}
+
+func TestKeyringBound(t *testing.T) {
+	keyring, err := NewPassphraseKeyring("passphrase", nil)
+	require.NoError(t, err)
+
+	encrypted, err := keyring.EncryptBound("sk-secret", "user1")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+
+	decrypted, err := keyring.DecryptBound(encrypted, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-secret", decrypted)
+
+	// The value can't be read with another binding
+	_, err = keyring.DecryptBound(encrypted, "user2")
+	assert.Error(t, err)
+	_, err = keyring.Decrypt(encrypted)
+	assert.Error(t, err)
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/mattermost/mattermost-plugin-ai/config"
+)
+
+// pluginConfigKey is the key of the plugin settings holding the configuration
+const pluginConfigKey = "config"
+
+// maskedVisibleLength is the number of trailing characters of a secret shown when masked
+const maskedVisibleLength = 4
+
+// PluginConfigStore reads and writes the stored plugin settings
+type PluginConfigStore interface {
+	GetPluginConfig() map[string]any
+	SavePluginConfig(config map[string]any) error
+}
+
+// Passphrases are the passphrases keys are derived from. Secrets are encrypted with the key of
+// Current, Previous are only used to decrypt secrets until they are rotated.
+type Passphrases struct {
+	Current  string
+	Previous []string
+}
+
+// SecretStatus describes a stored secret without revealing it
+type SecretStatus struct {
+	Path string `json:"path"`
+	// Masked is the end of the secret, or empty if it can not be decrypted
+	Masked     string `json:"masked"`
+	Encrypted  bool   `json:"encrypted"`
+	CurrentKey bool   `json:"current_key"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Manager encrypts and decrypts the credentials of the plugin configuration
+type Manager struct {
+	store       PluginConfigStore
+	passphrases func() Passphrases
+
+	mu                sync.Mutex
+	keyring           *Keyring
+	keyringPassphrase Passphrases
+}
+
+// NewManager creates a manager deriving its keys from the passphrases returned by passphrases,
+// which is read on every use so it follows configuration changes.
+func NewManager(store PluginConfigStore, passphrases func() Passphrases) *Manager {
+	return &Manager{
+		store:       store,
+		passphrases: passphrases,
+	}
+}
+
+// Keyring returns the keyring for the current passphrases
+func (m *Manager) Keyring() (*Keyring, error) {
+	passphrases := m.passphrases()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Deriving keys is slow on purpose, only do it when the passphrases change
+	if m.keyring != nil && m.keyringPassphrase.Current == passphrases.Current && slices.Equal(m.keyringPassphrase.Previous, passphrases.Previous) {
+		return m.keyring, nil
+	}
+
+	keyring, err := NewPassphraseKeyring(passphrases.Current, passphrases.Previous)
+	if err != nil {
+		return nil, err
+	}
+	m.keyring = keyring
+	m.keyringPassphrase = passphrases
+
+	return keyring, nil
+}
+
+// DecryptConfig decrypts the credentials of the configuration in place. Credentials that can
+// not be decrypted are left encrypted and reported in the returned error.
+func (m *Manager) DecryptConfig(cfg *config.Config) error {
+	keyring, err := m.Keyring()
+	if err != nil {
+		hasEncrypted := false
+		_ = cfg.TransformSecrets(func(_, value string) (string, error) {
+			hasEncrypted = hasEncrypted || IsEncrypted(value)
+			return value, nil
+		})
+		if !hasEncrypted {
+			return nil
+		}
+		return err
+	}
+
+	return cfg.TransformSecrets(func(_, value string) (string, error) {
+		return keyring.Decrypt(value)
+	})
+}
+
+// transformPluginConfig applies transform to the credentials of the configuration held by the
+// plugin settings, returning whether any credential changed.
+func transformPluginConfig(pluginConfig map[string]any, transform config.SecretTransform) (bool, error) {
+	raw, ok := pluginConfig[pluginConfigKey]
+	if !ok || raw == nil {
+		return false, nil
+	}
+
+	values, ok := raw.(map[string]any)
+	if !ok {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return false, fmt.Errorf("failed to marshal plugin configuration: %w", err)
+		}
+		if err = json.Unmarshal(data, &values); err != nil {
+			return false, fmt.Errorf("failed to unmarshal plugin configuration: %w", err)
+		}
+		pluginConfig[pluginConfigKey] = values
+	}
+
+	changed := false
+	err := config.TransformSecretsInMap(values, func(path, value string) (string, error) {
+		transformed, transformErr := transform(path, value)
+		if transformErr == nil && transformed != value {
+			changed = true
+		}
+		return transformed, transformErr
+	})
+
+	return changed, err
+}
+
+// EncryptPluginConfig encrypts the plain text credentials of the plugin settings in place,
+// returning whether any credential was encrypted.
+func (m *Manager) EncryptPluginConfig(pluginConfig map[string]any) (bool, error) {
+	keyring, err := m.Keyring()
+	if err != nil {
+		return false, err
+	}
+
+	return transformPluginConfig(pluginConfig, func(_, value string) (string, error) {
+		return keyring.Encrypt(value)
+	})
+}
+
+// EncryptStored encrypts the plain text credentials of the stored configuration, for example
+// ones saved while the plugin was disabled, returning whether the configuration was saved.
+func (m *Manager) EncryptStored() (bool, error) {
+	pluginConfig := m.store.GetPluginConfig()
+	changed, err := m.EncryptPluginConfig(pluginConfig)
+	if err != nil || !changed {
+		return false, err
+	}
+
+	if err := m.store.SavePluginConfig(pluginConfig); err != nil {
+		return false, fmt.Errorf("failed to save encrypted configuration: %w", err)
+	}
+	return true, nil
+}
+
+// Rotate re-encrypts every stored credential with the current key and saves the
+// configuration, returning the number of rotated credentials.
+func (m *Manager) Rotate() (int, error) {
+	keyring, err := m.Keyring()
+	if err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	pluginConfig := m.store.GetPluginConfig()
+	changed, err := transformPluginConfig(pluginConfig, func(_, value string) (string, error) {
+		result, rotateErr := keyring.Rotate(value)
+		if rotateErr == nil && result != value {
+			rotated++
+		}
+		return result, rotateErr
+	})
+	if err != nil {
+		// Don't save a partially rotated configuration
+		return 0, fmt.Errorf("failed to rotate secrets: %w", err)
+	}
+	if !changed {
+		return 0, nil
+	}
+
+	if err := m.store.SavePluginConfig(pluginConfig); err != nil {
+		return 0, fmt.Errorf("failed to save rotated configuration: %w", err)
+	}
+	return rotated, nil
+}
+
+// Status describes every stored credential with masked values
+func (m *Manager) Status() ([]SecretStatus, error) {
+	keyring, keyringErr := m.Keyring()
+
+	statuses := []SecretStatus{}
+	_, err := transformPluginConfig(m.store.GetPluginConfig(), func(path, value string) (string, error) {
+		status := SecretStatus{
+			Path:      path,
+			Encrypted: IsEncrypted(value),
+		}
+
+		plaintext := value
+		switch {
+		case keyringErr != nil && status.Encrypted:
+			status.Error = keyringErr.Error()
+			plaintext = ""
+		case keyringErr == nil:
+			status.CurrentKey = keyring.IsCurrent(value)
+			decrypted, err := keyring.Decrypt(value)
+			if err != nil {
+				status.Error = err.Error()
+			}
+			plaintext = decrypted
+		}
+		status.Masked = mask(plaintext)
+
+		statuses = append(statuses, status)
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+// mask hides all but the end of the secret
+func mask(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= maskedVisibleLength*2 {
+		return "********"
+	}
+	return "********" + secret[len(secret)-maskedVisibleLength:]
+}
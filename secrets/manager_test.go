@@ -0,0 +1,205 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package secrets
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryConfigStore struct {
+	config map[string]any
+	saves  int
+}
+
+func (s *memoryConfigStore) GetPluginConfig() map[string]any {
+	// Return a copy like the plugin API does
+	data, _ := json.Marshal(s.config)
+	var result map[string]any
+	_ = json.Unmarshal(data, &result)
+	return result
+}
+
+func (s *memoryConfigStore) SavePluginConfig(cfg map[string]any) error {
+	s.config = cfg
+	s.saves++
+	return nil
+}
+
+func newStoreWithConfig(t *testing.T, cfg string) *memoryConfigStore {
+	var values map[string]any
+	require.NoError(t, json.Unmarshal([]byte(cfg), &values))
+	return &memoryConfigStore{config: map[string]any{"config": values}}
+}
+
+func storedValue(store *memoryConfigStore, path ...any) any {
+	var value any = store.config["config"]
+	for _, part := range path {
+		switch p := part.(type) {
+		case string:
+			value = value.(map[string]any)[p]
+		case int:
+			value = value.([]any)[p]
+		}
+	}
+	return value
+}
+
+const testConfig = `{
+	"services": [{"id": "1", "name": "OpenAI", "type": "openai", "apiKey": "sk-openai-key", "defaultModel": "gpt-4o"}],
+	"bots": [{"name": "ai", "service": {"apiKey": "sk-legacy-key"}, "legacyOnly": "kept"}],
+	"webSearch": {"google": {"apiKey": "google-key"}, "brave": {"apiKey": ""}},
+	"embeddingSearchConfig": {"embeddingProvider": {"type": "openai", "parameters": {"apiKey": "sk-embed-key", "embeddingModel": "m"}}}
+}`
+
+func passphrases(current string, previous ...string) func() Passphrases {
+	return func() Passphrases {
+		return Passphrases{Current: current, Previous: previous}
+	}
+}
+
+func TestEncryptStoredAndDecryptConfig(t *testing.T) {
+	store := newStoreWithConfig(t, testConfig)
+	manager := NewManager(store, passphrases("passphrase"))
+
+	encrypted, err := manager.EncryptStored()
+	require.NoError(t, err)
+	assert.True(t, encrypted)
+	assert.Equal(t, 1, store.saves)
+
+	for _, path := range [][]any{
+		{"services", 0, "apiKey"},
+		{"bots", 0, "service", "apiKey"},
+		{"webSearch", "google", "apiKey"},
+		{"embeddingSearchConfig", "embeddingProvider", "parameters", "apiKey"},
+	} {
+		assert.True(t, IsEncrypted(storedValue(store, path...).(string)), path)
+	}
+	assert.Equal(t, "", storedValue(store, "webSearch", "brave", "apiKey"))
+	assert.Equal(t, "gpt-4o", storedValue(store, "services", 0, "defaultModel"))
+	assert.Equal(t, "kept", storedValue(store, "bots", 0, "legacyOnly"), "fields unknown to the configuration are kept")
+
+	// Nothing left to encrypt
+	encrypted, err = manager.EncryptStored()
+	require.NoError(t, err)
+	assert.False(t, encrypted)
+	assert.Equal(t, 1, store.saves)
+
+	data, err := json.Marshal(store.config["config"])
+	require.NoError(t, err)
+	var cfg config.Config
+	require.NoError(t, json.Unmarshal(data, &cfg))
+
+	require.NoError(t, manager.DecryptConfig(&cfg))
+	assert.Equal(t, "sk-openai-key", cfg.Services[0].APIKey)
+	assert.Equal(t, "sk-legacy-key", cfg.Bots[0].Service.APIKey)
+	assert.Equal(t, "google-key", cfg.WebSearch.Google.APIKey)
+	assert.JSONEq(t, `{"apiKey": "sk-embed-key", "embeddingModel": "m"}`, string(cfg.EmbeddingSearchConfig.EmbeddingProvider.Parameters))
+}
+
+func TestEncryptedCredentialsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		path      []any
+		decrypted func(cfg config.Config) string
+	}{
+		{
+			name:      "confluence API token",
+			config:    `{"connectors": {"sources": [{"id": "1", "name": "Wiki", "type": "confluence", "confluence": {"url": "https://example.atlassian.net", "apiToken": "confluence-token"}}]}}`,
+			path:      []any{"connectors", "sources", 0, "confluence", "apiToken"},
+			decrypted: func(cfg config.Config) string { return cfg.Connectors.Sources[0].Confluence.APIToken },
+		},
+		{
+			name:      "google drive service account key",
+			config:    `{"connectors": {"sources": [{"id": "1", "name": "Drive", "type": "google_drive", "googleDrive": {"serviceAccountKey": "{\"private_key\": \"key\"}"}}]}}`,
+			path:      []any{"connectors", "sources", 0, "googleDrive", "serviceAccountKey"},
+			decrypted: func(cfg config.Config) string { return cfg.Connectors.Sources[0].GoogleDrive.ServiceAccountKey },
+		},
+		{
+			name:      "inbound email secret",
+			config:    `{"digests": {"inboundEmailSecret": "email-secret"}}`,
+			path:      []any{"digests", "inboundEmailSecret"},
+			decrypted: func(cfg config.Config) string { return cfg.Digests.InboundEmailSecret },
+		},
+		{
+			name:      "custom tool auth value",
+			config:    `{"customTools": [{"name": "lookup_order", "url": "https://example.com", "authHeader": "Authorization", "authValue": "Bearer tool-token"}]}`,
+			path:      []any{"customTools", 0, "authValue"},
+			decrypted: func(cfg config.Config) string { return cfg.CustomTools[0].AuthValue },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newStoreWithConfig(t, test.config)
+			manager := NewManager(store, passphrases("passphrase"))
+
+			plain := storedValue(store, test.path...).(string)
+			encrypted, err := manager.EncryptStored()
+			require.NoError(t, err)
+			assert.True(t, encrypted)
+			assert.True(t, IsEncrypted(storedValue(store, test.path...).(string)))
+
+			data, err := json.Marshal(store.config["config"])
+			require.NoError(t, err)
+			var cfg config.Config
+			require.NoError(t, json.Unmarshal(data, &cfg))
+			require.NoError(t, manager.DecryptConfig(&cfg))
+			assert.Equal(t, plain, test.decrypted(cfg))
+		})
+	}
+}
+
+func TestDecryptConfigWithoutPassphrase(t *testing.T) {
+	manager := NewManager(&memoryConfigStore{}, passphrases(""))
+
+	plain := config.Config{Services: []llm.ServiceConfig{{Name: "OpenAI", APIKey: "sk-plain"}}}
+	require.NoError(t, manager.DecryptConfig(&plain), "plain text configurations need no passphrase")
+	assert.Equal(t, "sk-plain", plain.Services[0].APIKey)
+
+	encrypted := config.Config{Services: []llm.ServiceConfig{{Name: "OpenAI", APIKey: encryptedPrefix + "abcd:AAAA"}}}
+	assert.ErrorIs(t, manager.DecryptConfig(&encrypted), ErrNoPassphrase)
+}
+
+func TestRotateAndStatus(t *testing.T) {
+	store := newStoreWithConfig(t, testConfig)
+	_, err := NewManager(store, passphrases("old")).EncryptStored()
+	require.NoError(t, err)
+
+	manager := NewManager(store, passphrases("new", "old"))
+
+	statuses, err := manager.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 4)
+	for _, status := range statuses {
+		assert.True(t, status.Encrypted)
+		assert.False(t, status.CurrentKey)
+		assert.Empty(t, status.Error)
+	}
+	assert.Equal(t, "services.OpenAI.apiKey", statuses[0].Path)
+	assert.Equal(t, "********-key", statuses[0].Masked)
+
+	rotated, err := manager.Rotate()
+	require.NoError(t, err)
+	assert.Equal(t, 4, rotated)
+
+	statuses, err = NewManager(store, passphrases("new")).Status()
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.True(t, status.CurrentKey)
+		assert.Empty(t, status.Error)
+	}
+
+	// A credential encrypted with an unknown key prevents saving a partial rotation
+	saves := store.saves
+	_, err = NewManager(store, passphrases("other")).Rotate()
+	assert.Error(t, err)
+	assert.Equal(t, saves, store.saves)
+}
@@ -59,6 +59,7 @@ func (p *Plugin) OnConfigurationChange() error {
 		finalConfig = potentiallyUpdatedConfig
 		pluginAPI.Log.Info("Configuration migrated in OnConfigurationChange")
 	}
+	p.decryptConfig(&finalConfig)
 	p.configuration.Update(&finalConfig)
 
 	return nil
@@ -8,16 +8,38 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
 	"github.com/mattermost/mattermost-plugin-ai/api"
+	"github.com/mattermost/mattermost-plugin-ai/batch"
 	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/calendars"
+	"github.com/mattermost/mattermost-plugin-ai/channelstats"
+	"github.com/mattermost/mattermost-plugin-ai/citations"
+	"github.com/mattermost/mattermost-plugin-ai/codehosts"
 	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/connectors"
 	"github.com/mattermost/mattermost-plugin-ai/conversations"
+	"github.com/mattermost/mattermost-plugin-ai/customtools"
 	"github.com/mattermost/mattermost-plugin-ai/database"
+	"github.com/mattermost/mattermost-plugin-ai/delegation"
+	"github.com/mattermost/mattermost-plugin-ai/diagrams"
+	"github.com/mattermost/mattermost-plugin-ai/digests"
+	"github.com/mattermost/mattermost-plugin-ai/duplicates"
 	"github.com/mattermost/mattermost-plugin-ai/enterprise"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
+	"github.com/mattermost/mattermost-plugin-ai/faq"
+	"github.com/mattermost/mattermost-plugin-ai/glossary"
+	"github.com/mattermost/mattermost-plugin-ai/guardrails"
 	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/imagetext"
+	"github.com/mattermost/mattermost-plugin-ai/incidents"
 	"github.com/mattermost/mattermost-plugin-ai/indexer"
+	"github.com/mattermost/mattermost-plugin-ai/integrations"
+	"github.com/mattermost/mattermost-plugin-ai/jiracloud"
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/llmcontext"
 	"github.com/mattermost/mattermost-plugin-ai/mcp"
@@ -26,9 +48,28 @@ import (
 	"github.com/mattermost/mattermost-plugin-ai/metrics"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
 	"github.com/mattermost/mattermost-plugin-ai/mmtools"
+	"github.com/mattermost/mattermost-plugin-ai/permalinks"
+	"github.com/mattermost/mattermost-plugin-ai/postprocessors"
 	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost-plugin-ai/promptstore"
+	"github.com/mattermost/mattermost-plugin-ai/reactiontriggers"
+	"github.com/mattermost/mattermost-plugin-ai/reminders"
+	"github.com/mattermost/mattermost-plugin-ai/retention"
+	"github.com/mattermost/mattermost-plugin-ai/retrievalfeedback"
+	"github.com/mattermost/mattermost-plugin-ai/routing"
+	"github.com/mattermost/mattermost-plugin-ai/sanitize"
+	"github.com/mattermost/mattermost-plugin-ai/savedanswers"
 	"github.com/mattermost/mattermost-plugin-ai/search"
+	"github.com/mattermost/mattermost-plugin-ai/secrets"
+	"github.com/mattermost/mattermost-plugin-ai/standups"
 	"github.com/mattermost/mattermost-plugin-ai/streaming"
+	"github.com/mattermost/mattermost-plugin-ai/summaryexports"
+	"github.com/mattermost/mattermost-plugin-ai/teaminstructions"
+	"github.com/mattermost/mattermost-plugin-ai/traces"
+	"github.com/mattermost/mattermost-plugin-ai/triage"
+	"github.com/mattermost/mattermost-plugin-ai/userdata"
+	"github.com/mattermost/mattermost-plugin-ai/userkeys"
+	"github.com/mattermost/mattermost-plugin-ai/verification"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
@@ -50,6 +91,26 @@ type Plugin struct {
 	indexerService       *indexer.Indexer
 	conversationsService *conversations.Conversations
 	mcpClientManager     *mcp.ClientManager
+	batchService         *batch.Service
+	incidentCopilot      *incidents.Service
+	faqService           *faq.Service
+	supportTriage        *triage.Service
+	duplicateQuestions   *duplicates.Service
+	reactionTriggers     *reactiontriggers.Service
+	retrievalFeedback    *retrievalfeedback.Service
+	standups             *standups.Service
+	digests              *digests.Service
+	connectors           *connectors.Service
+	retention            *retention.Service
+	reminders            *reminders.Service
+	streamingService     *streaming.MMPostStreamService
+
+	// ctx is canceled on deactivation to stop the generations still running
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	secretsOnce sync.Once
+	secrets     *secrets.Manager
 }
 
 type pluginLogger struct {
@@ -85,6 +146,8 @@ func (l *pluginLogger) Error(message string, keyValuePairs ...any) {
 }
 
 func (p *Plugin) OnActivate() error {
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
 	pluginAPI := pluginapi.NewClient(p.API, p.Driver)
 	mmClient := mmapi.NewClient(pluginAPI)
 	licenseChecker := enterprise.NewLicenseChecker(pluginAPI)
@@ -110,16 +173,42 @@ func (p *Plugin) OnActivate() error {
 	}
 	if wasUpdated {
 		// Update in-memory copy immediately (can't wait for async callbacks)
+		p.decryptConfig(&potentiallyUpdatedConfig)
 		p.configuration.Update(&potentiallyUpdatedConfig)
 		pluginAPI.Log.Info("In-memory configuration updated after migrations")
 	}
 
+	// Encrypt credentials saved in plain text, for example while the plugin was disabled
+	if encrypted, encryptErr := p.secretsManager().EncryptStored(); encryptErr != nil {
+		pluginAPI.Log.Error("Failed to encrypt stored provider credentials", "error", encryptErr)
+	} else if encrypted {
+		pluginAPI.Log.Info("Encrypted stored provider credentials")
+	}
+
 	tokenLogger, err := llm.CreateTokenLogger()
 	if err != nil {
 		return fmt.Errorf("failed to create token usage logger: %w", err)
 	}
 
+	channelExclusions := exclusions.New(p.configuration.GetDataExclusions)
+	channelExclusions.SetChannelStatsService(&pluginAPI.Channel)
+
+	prompts, promptManagerErr := llm.NewPrompts(prompts.PromptsFolder)
+	if promptManagerErr != nil {
+		pluginAPI.Log.Error("failed to initialize prompts", "error", promptManagerErr)
+		return promptManagerErr
+	}
+
 	bots := bots.New(p.API, pluginAPI, licenseChecker, &p.configuration, llmUpstreamHTTPClient, tokenLogger, metricsService)
+	bots.SetChannelExcluder(channelExclusions)
+	bots.SetRouter(routing.New(p.configuration.GetRouting))
+	bots.SetPayloadCapture(llm.NewPayloadCapture(p.configuration.GetPayloadCapture))
+	glossaryStore := glossary.New(dbClient, mmClient)
+	bots.SetGlossaryProvider(glossaryStore)
+	// Keys are protected with the keyring of the provider credentials, and rotated with them
+	userKeys := userkeys.New(mmClient, p.secretsManager().Keyring)
+	bots.SetUserKeyStore(userKeys)
+	bots.SetGuardrails(guardrails.New(p.configuration.GetGuardrails, bots, prompts, p.API, mmClient))
 	p.configuration.RegisterUpdateListener(func() {
 		if ensureErr := bots.EnsureBots(); ensureErr != nil {
 			pluginAPI.Log.Error("failed to ensure bots on configuration update", "error", ensureErr)
@@ -133,18 +222,29 @@ func (p *Plugin) OnActivate() error {
 		pluginAPI.Log.Error("failed to ensure bots", "error", ensureBotsErr)
 	}
 
-	if setupTablesErr := database.SetupTables(dbClient.DB); setupTablesErr != nil {
+	// The plugin's own tables use Postgres features, on MySQL only the features reading
+	// Mattermost's tables, like channel interval summaries, are available
+	if !dbClient.IsPostgres() {
+		pluginAPI.Log.Warn("The plugin's database tables are only supported on PostgreSQL, features storing data are unavailable", "driver", dbClient.DriverName())
+	} else if setupTablesErr := database.SetupTables(dbClient.DB); setupTablesErr != nil {
 		pluginAPI.Log.Error("failed to setup database tables", "error", setupTablesErr)
 		return setupTablesErr
 	}
 
-	prompts, promptManagerErr := llm.NewPrompts(prompts.PromptsFolder)
-	if promptManagerErr != nil {
-		pluginAPI.Log.Error("failed to initialize prompts", "error", promptManagerErr)
-		return promptManagerErr
-	}
-
-	streamingService := streaming.NewMMPostStreamService(mmClient, i18nBundle)
+	// Admin edited prompts take precedence over the embedded defaults
+	promptStore := promptstore.New(dbClient, mmClient)
+	prompts.SetOverrides(promptStore)
+
+	streamingService := streaming.NewMMPostStreamService(mmClient, i18nBundle, &p.configuration, p.API)
+	// Citations first, annotation indexes refer to the message as streamed
+	streamingService.AddPostProcessor(citations.NewPostProcessor(i18nBundle))
+	streamingService.AddPostProcessor(diagrams.NewProcessor(p.configuration.Diagrams, mmClient, untrustedHTTPClient))
+	// Sanitizing last covers the text added by the other processors
+	streamingService.AddPostProcessor(sanitize.PostProcessor{})
+	streamingService.SetTypingPublisher(p.API)
+	streamingService.SetReasoningDisplay(bots.ReasoningDisplay)
+	postprocessors.Register(streamingService)
+	streamingService.SetBotPostProcessors(bots.PostProcessors)
 
 	embeddingsSearch, err := search.InitEmbeddingsSearch(
 		dbClient.DB,
@@ -157,7 +257,8 @@ func (p *Plugin) OnActivate() error {
 		// Continue without search functionality
 	}
 
-	indexerService := indexer.New(embeddingsSearch, mmClient, bots, dbClient.DB)
+	indexerService := indexer.New(embeddingsSearch, mmClient, bots, dbClient.DB, p.API)
+	indexerService.SetChannelExcluder(channelExclusions)
 
 	searchService := search.New(
 		embeddingsSearch,
@@ -166,6 +267,9 @@ func (p *Plugin) OnActivate() error {
 		streamingService,
 		licenseChecker,
 	)
+	searchService.SetChannelExcluder(channelExclusions)
+	retrievalFeedback := retrievalfeedback.New(mmClient, bots, p.API)
+	searchService.SetResultScorer(retrievalFeedback)
 
 	webSearchService := mmtools.NewWebSearchService(func() *config.Config {
 		return p.configuration.Config()
@@ -176,7 +280,34 @@ func (p *Plugin) OnActivate() error {
 		searchService,
 		untrustedHTTPClient,
 		webSearchService,
+		bots,
+	)
+	toolProvider.SetWolframAlpha(p.configuration.WolframAlpha)
+	toolProvider.SetLicenseChecker(licenseChecker)
+	savedAnswersStore := savedanswers.New(dbClient)
+	toolProvider.SetSavedAnswers(savedAnswersStore)
+
+	documentStore, documentEmbeddings, err := search.InitDocumentStore(
+		dbClient.DB,
+		llmUpstreamHTTPClient,
+		p.configuration.EmbeddingSearchConfig(),
+		licenseChecker,
+	)
+	if err != nil {
+		pluginAPI.Log.Debug("External documents are not indexed", "error", err)
+	}
+	connectorsService := connectors.New(
+		documentStore,
+		documentEmbeddings,
+		p.configuration.EmbeddingSearchConfig().ChunkingOptions,
+		mmClient,
+		p.API,
+		p.API,
+		&http.Client{Timeout: time.Minute},
+		p.configuration.GetConnectors,
 	)
+	connectorsService.Start(connectors.PollInterval)
+	toolProvider.SetDocumentSearch(connectorsService)
 
 	// Build redirect URI
 	siteURL := pluginAPI.Configuration.GetConfig().ServiceSettings.SiteURL
@@ -186,10 +317,17 @@ func (p *Plugin) OnActivate() error {
 	manifestID := manifest.Id
 	oauthCallbackURL := fmt.Sprintf("%s/plugins/%s/oauth/callback", *siteURL, manifestID)
 
+	integrationsService := integrations.New(mmClient, untrustedHTTPClient, fmt.Sprintf("%s/plugins/%s", *siteURL, manifestID))
+	toolProvider.SetCodeHosts(codehosts.New(p.configuration.CodeHosts, integrationsService))
+	toolProvider.SetJira(jiracloud.New(p.configuration.Jira, integrationsService))
+	toolProvider.SetCalendars(calendars.New(p.configuration.Calendars, integrationsService))
+
+	channelStats := channelstats.New(dbClient)
+
 	// Create embedded MCP server if enabled
 	var embeddedMCPServer mcp.EmbeddedMCPServer
 	if p.configuration.MCP().EmbeddedServer.Enabled {
-		embeddedMCPServer, err = NewEmbeddedMCPServer(pluginAPI, pluginAPI.Log)
+		embeddedMCPServer, err = NewEmbeddedMCPServer(pluginAPI, pluginAPI.Log, channelExclusions, channelStats)
 		if err != nil {
 			pluginAPI.Log.Error("Failed to create embedded MCP server", "error", err)
 			// Continue without embedded server
@@ -203,7 +341,7 @@ func (p *Plugin) OnActivate() error {
 		var embeddedServer mcp.EmbeddedMCPServer
 		var embeddedErr error
 		if p.configuration.MCP().EmbeddedServer.Enabled {
-			embeddedServer, embeddedErr = NewEmbeddedMCPServer(pluginAPI, pluginAPI.Log)
+			embeddedServer, embeddedErr = NewEmbeddedMCPServer(pluginAPI, pluginAPI.Log, channelExclusions, channelStats)
 			if embeddedErr != nil {
 				pluginAPI.Log.Error("Failed to create embedded MCP server on config update", "error", embeddedErr)
 			}
@@ -219,6 +357,12 @@ func (p *Plugin) OnActivate() error {
 		&p.configuration,
 	)
 
+	toolProvider.SetAgentDelegator(delegation.New(bots, contextBuilder, prompts))
+
+	teamInstructions := teaminstructions.New(mmClient)
+	contextBuilder.SetTeamInstructionsProvider(teamInstructions)
+	contextBuilder.SetCustomToolProvider(customtools.NewProvider(p.configuration.GetCustomTools, untrustedHTTPClient, &pluginAPI.Log))
+
 	conversationsService := conversations.New(
 		prompts,
 		mmClient,
@@ -247,11 +391,24 @@ func (p *Plugin) OnActivate() error {
 	// TODO: Refactor to avoid circular dependency
 	conversationsService.SetMeetingsService(meetingsService)
 
+	analyticsService := analytics.New(dbClient, mmClient)
+	searchService.SetUsageTracker(analyticsService)
+	conversationsService.SetAnalyticsService(analyticsService)
+	toolProvider.SetUsageInsights(analyticsService)
+	imageText := imagetext.New(mmClient, bots, prompts)
+	conversationsService.SetImageTextExtractor(imageText)
+	permalinksResolver := permalinks.New(mmClient, channelExclusions)
+	conversationsService.SetLinkResolver(permalinksResolver)
+	conversationsService.SetSearchService(searchService)
+
+	traceStore := traces.New(dbClient, p.configuration.EnableAgentTracing)
+	conversationsService.SetTraceStore(traceStore)
+
 	// Initialize embedded MCP server handlers for plugin endpoints
 	var mcpHandlers *mcpserver.PluginMCPHandlers
 	// Create logger adapter to route MCP handler logs through plugin logging
 	mcpHandlerLogger := NewPluginAPILoggerAdapter(pluginAPI.Log)
-	handlers, err := mcpserver.NewPluginMCPHandlers(*siteURL, mcpHandlerLogger)
+	handlers, err := mcpserver.NewPluginMCPHandlers(*siteURL, mcpHandlerLogger, channelExclusions)
 	if err != nil {
 		pluginAPI.Log.Error("Failed to create MCP handlers", "error", err)
 	} else {
@@ -259,6 +416,42 @@ func (p *Plugin) OnActivate() error {
 		pluginAPI.Log.Info("Embedded MCP server handlers initialized successfully")
 	}
 
+	batchService := batch.New(dbClient, bots, p.API, pluginAPI.Log)
+	batchService.Start(batch.DefaultPollInterval)
+
+	incidentCopilot := incidents.New(mmClient, incidents.NewPlaybooks(mmClient), bots, contextBuilder, prompts, i18nBundle, p.API, p.configuration.IncidentCopilot)
+	incidentCopilot.SetLicenseChecker(licenseChecker)
+	incidentCopilot.Start(incidents.PollInterval)
+
+	supportTriage := triage.New(mmClient, bots, contextBuilder, prompts, i18nBundle, &p.configuration)
+	duplicateQuestions := duplicates.New(mmClient, bots, searchService, i18nBundle, &p.configuration)
+	reactionTriggers := reactiontriggers.New(mmClient, bots, contextBuilder, prompts, streamingService, conversationsService, analyticsService, &pluginAPI.KV, i18nBundle, &p.configuration)
+	reactionTriggers.SetLicenseChecker(licenseChecker)
+	reactionTriggers.SetImageDescriber(imageText)
+	reactionTriggers.SetLinkResolver(permalinksResolver)
+
+	jobsService := jobs.New(mmClient)
+	faqService := faq.New(mmClient, bots, contextBuilder, searchService, jobsService, prompts, i18nBundle, p.API, p.configuration.FAQBuilder)
+	faqService.SetLicenseChecker(licenseChecker)
+	faqService.Start(faq.PollInterval)
+
+	standupsService := standups.New(mmClient, bots, contextBuilder, prompts, i18nBundle, p.API, p.configuration.Standups)
+	standupsService.Start(standups.PollInterval)
+
+	digestsService := digests.New(mmClient, bots, contextBuilder, prompts, i18nBundle, p.API, &http.Client{Timeout: time.Minute}, &p.configuration)
+	digestsService.Start(digests.PollInterval)
+
+	remindersService := reminders.New(dbClient, mmClient, i18nBundle, p.API)
+	if dbClient.IsPostgres() {
+		toolProvider.SetReminders(remindersService)
+		remindersService.Start(reminders.PollInterval)
+	}
+
+	retentionService := retention.New(dbClient, mmClient, &pluginAPI.System, p.API, p.configuration.GetRetention)
+	if dbClient.IsPostgres() {
+		retentionService.Start(retention.PollInterval)
+	}
+
 	apiService := api.New(
 		bots,
 		conversationsService,
@@ -278,6 +471,30 @@ func (p *Plugin) OnActivate() error {
 		mcpClientManager,
 		mcpHandlers,
 		llmUpstreamHTTPClient,
+		jobsService,
+		analyticsService,
+		promptStore,
+		teamInstructions,
+		glossaryStore,
+		traceStore,
+		userKeys,
+		p.secretsManager(),
+		batchService,
+		integrationsService,
+		incidentCopilot,
+		faqService,
+		standupsService,
+		digestsService,
+		retentionService,
+		userdata.New(dbClient, mmClient),
+		verification.New(p.configuration.GetVerification, bots, prompts, i18nBundle, mmClient),
+		savedAnswersStore,
+		remindersService,
+		summaryexports.New(jobsService, mmClient),
+		imageText,
+		permalinksResolver,
+		manifest.Version,
+		p.ctx,
 	)
 
 	// Keep only what we need
@@ -287,14 +504,73 @@ func (p *Plugin) OnActivate() error {
 	p.indexerService = indexerService
 	p.conversationsService = conversationsService
 	p.mcpClientManager = mcpClientManager
+	p.batchService = batchService
+	p.incidentCopilot = incidentCopilot
+	p.faqService = faqService
+	p.supportTriage = supportTriage
+	p.duplicateQuestions = duplicateQuestions
+	p.reactionTriggers = reactionTriggers
+	p.retrievalFeedback = retrievalFeedback
+	p.standups = standupsService
+	p.digests = digestsService
+	p.connectors = connectorsService
+	p.retention = retentionService
+	p.reminders = remindersService
+	p.streamingService = streamingService
 
 	return nil
 }
 
+// shutdownDrainTimeout bounds how long deactivation waits for interrupted posts to be finalized.
+const shutdownDrainTimeout = 10 * time.Second
+
 func (p *Plugin) OnDeactivate() error {
+	// Finalize the posts still streaming before the rest of the in-flight work is canceled
+	if p.streamingService != nil {
+		drainCtx, cancelDrain := context.WithTimeout(context.Background(), shutdownDrainTimeout)
+		p.streamingService.Shutdown(drainCtx)
+		cancelDrain()
+	}
+
+	if p.cancel != nil {
+		p.cancel()
+	}
+
 	// Clean up MCP client manager if it exists
 	p.mcpClientManager.Close()
 
+	if p.batchService != nil {
+		p.batchService.Stop()
+	}
+
+	if p.incidentCopilot != nil {
+		p.incidentCopilot.Stop()
+	}
+
+	if p.faqService != nil {
+		p.faqService.Stop()
+	}
+
+	if p.standups != nil {
+		p.standups.Stop()
+	}
+
+	if p.digests != nil {
+		p.digests.Stop()
+	}
+
+	if p.connectors != nil {
+		p.connectors.Stop()
+	}
+
+	if p.retention != nil {
+		p.retention.Stop()
+	}
+
+	if p.reminders != nil {
+		p.reminders.Stop()
+	}
+
 	return nil
 }
 
@@ -312,7 +588,23 @@ func (p *Plugin) MessageHasBeenPosted(c *plugin.Context, post *model.Post) {
 		}
 	}
 
-	p.conversationsService.MessageHasBeenPosted(c, post)
+	// Replies to the standup questions are answers to collect, not messages for the agent
+	if p.standups.MessageHasBeenPosted(post) {
+		return
+	}
+
+	p.conversationsService.MessageHasBeenPosted(p.ctx, post)
+	p.supportTriage.MessageHasBeenPosted(p.ctx, post)
+	p.duplicateQuestions.MessageHasBeenPosted(p.ctx, post)
+}
+
+func (p *Plugin) ReactionHasBeenAdded(c *plugin.Context, reaction *model.Reaction) {
+	p.reactionTriggers.ReactionHasBeenAdded(p.ctx, reaction)
+	p.retrievalFeedback.ReactionHasBeenAdded(reaction)
+}
+
+func (p *Plugin) ReactionHasBeenRemoved(c *plugin.Context, reaction *model.Reaction) {
+	p.retrievalFeedback.ReactionHasBeenRemoved(reaction)
 }
 
 func (p *Plugin) MessageHasBeenUpdated(c *plugin.Context, newPost, oldPost *model.Post) {
@@ -0,0 +1,88 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/secrets"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+const (
+	// secretsPassphraseEnv sets the passphrase the key encrypting provider credentials is derived from.
+	// When unset, the server's at rest encryption key is used.
+	secretsPassphraseEnv = "MM_PLUGIN_AI_SECRETS_PASSPHRASE"
+
+	// secretsPreviousPassphrasesEnv lists comma separated passphrases that are only used to decrypt
+	// credentials until they are rotated.
+	secretsPreviousPassphrasesEnv = "MM_PLUGIN_AI_SECRETS_PREVIOUS_PASSPHRASES"
+)
+
+// secretsManager returns the manager of the encrypted provider credentials
+func (p *Plugin) secretsManager() *secrets.Manager {
+	p.secretsOnce.Do(func() {
+		pluginAPI := pluginapi.NewClient(p.API, p.Driver)
+		p.secrets = secrets.NewManager(&pluginAPI.Configuration, func() secrets.Passphrases {
+			return secretsPassphrases(pluginAPI.Configuration.GetUnsanitizedConfig())
+		})
+	})
+	return p.secrets
+}
+
+func secretsPassphrases(serverConfig *model.Config) secrets.Passphrases {
+	var atRestKey string
+	if serverConfig != nil && serverConfig.SqlSettings.AtRestEncryptKey != nil {
+		atRestKey = *serverConfig.SqlSettings.AtRestEncryptKey
+	}
+
+	var previous []string
+	for _, passphrase := range strings.Split(os.Getenv(secretsPreviousPassphrasesEnv), ",") {
+		if passphrase = strings.TrimSpace(passphrase); passphrase != "" {
+			previous = append(previous, passphrase)
+		}
+	}
+
+	current := os.Getenv(secretsPassphraseEnv)
+	if current == "" {
+		return secrets.Passphrases{Current: atRestKey, Previous: previous}
+	}
+
+	// Credentials encrypted before a passphrase was set can still be read until rotated
+	if atRestKey != "" {
+		previous = append(previous, atRestKey)
+	}
+	return secrets.Passphrases{Current: current, Previous: previous}
+}
+
+// decryptConfig decrypts the provider credentials of the configuration before it is used.
+// Credentials that can not be decrypted are logged and left encrypted.
+func (p *Plugin) decryptConfig(cfg *config.Config) {
+	if err := p.secretsManager().DecryptConfig(cfg); err != nil {
+		p.API.LogError("Failed to decrypt provider credentials, rotate them or check the secrets passphrases", "error", err.Error())
+	}
+}
+
+// ConfigurationWillBeSaved encrypts the provider credentials before the configuration is persisted.
+func (p *Plugin) ConfigurationWillBeSaved(newCfg *model.Config) (*model.Config, error) {
+	pluginConfig, ok := newCfg.PluginSettings.Plugins[manifest.Id]
+	if !ok {
+		return nil, nil
+	}
+
+	changed, err := p.secretsManager().EncryptPluginConfig(pluginConfig)
+	if err != nil {
+		// Saving the configuration must not be blocked, the credentials are encrypted on the next activation
+		p.API.LogError("Failed to encrypt provider credentials", "error", err.Error())
+		return nil, nil
+	}
+	if !changed {
+		return nil, nil
+	}
+
+	return newCfg, nil
+}
@@ -0,0 +1,734 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package standups runs the daily standups of teams. At the scheduled time an agent sends the
+// standup questions to each member by direct message, collects the replies to that message,
+// and once the collection window closes posts a report summarizing the answers in the channel
+// of the team.
+//
+// Standups and their rounds are stored in the plugin KV store. Only one node of the cluster
+// polls them at a time, so questions and reports are posted once.
+package standups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+)
+
+const (
+	// DefaultCollectMinutes is how long answers are collected when the standup doesn't set it
+	DefaultCollectMinutes = 120
+	// MinCollectMinutes and MaxCollectMinutes bound how long answers are collected
+	MinCollectMinutes = 15
+	MaxCollectMinutes = 12 * 60
+	// MaxQuestions bounds the number of questions of a standup
+	MaxQuestions = 10
+	// MaxMembers bounds the number of members asked the questions of a standup
+	MaxMembers = 100
+	// PollInterval is how often the standups are checked for questions to send and reports to post
+	PollInterval = time.Minute
+	// StandupProp is the post prop marking the posts of the standups, set to the kind of post
+	StandupProp     = "standup"
+	PostKindPrompt  = "prompt"
+	PostKindReport  = "report"
+	PostKindAnswers = "answers"
+
+	standupKeyPrefix = "standup_v1_"
+	roundKeyPrefix   = "standup_round_v1_"
+	pendingKeyPrefix = "standup_pending_v1_"
+	teamsKey         = "standup_teams_v1"
+	// answeredEmoji is added to the replies collected as answers
+	answeredEmoji = "white_check_mark"
+	// generationTimeout bounds the generation of each report summary
+	generationTimeout = 5 * time.Minute
+)
+
+// DefaultWeekdays are the days standups run on when they don't set any
+var DefaultWeekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+var (
+	// ErrNotEnabled is returned when standups are disabled in the configuration.
+	ErrNotEnabled = errors.New("standups are not enabled")
+	// ErrNotFound is returned when the team has no standup.
+	ErrNotFound = errors.New("team has no standup")
+	// ErrInvalid is returned when a standup is saved with invalid settings.
+	ErrInvalid = errors.New("invalid standup")
+)
+
+// Standup is the persisted configuration of the standup of a team.
+type Standup struct {
+	TeamID string `json:"team_id"`
+	// ChannelID is the channel of the team the reports are posted in
+	ChannelID string   `json:"channel_id"`
+	BotID     string   `json:"bot_id"`
+	Questions []string `json:"questions"`
+	// UserIDs are the members of the team asked the questions
+	UserIDs []string `json:"user_ids"`
+	// Time is when the questions are sent, as HH:MM in Timezone
+	Time     string         `json:"time"`
+	Timezone string         `json:"timezone"`
+	Weekdays []time.Weekday `json:"weekdays"`
+	// CollectMinutes is how long after the questions are sent the report is posted
+	CollectMinutes int    `json:"collect_minutes"`
+	UpdatedBy      string `json:"updated_by"`
+	UpdateAt       int64  `json:"update_at"`
+}
+
+// Round is the persisted state of the standup of a day, from the questions to the report.
+type Round struct {
+	TeamID    string `json:"team_id"`
+	Date      string `json:"date"`
+	StartedAt int64  `json:"started_at"`
+	ReportAt  int64  `json:"report_at"`
+	// PromptPostIDs are the direct messages asking the questions, by member
+	PromptPostIDs map[string]string `json:"prompt_post_ids"`
+	// Answers are the replies of the members to their direct message, by member
+	Answers      map[string][]string `json:"answers"`
+	ReportPostID string              `json:"report_post_id,omitempty"`
+}
+
+// BotSource returns the agents running the standups.
+type BotSource interface {
+	GetBotByID(botID string) *bots.Bot
+	CheckUsageRestrictionsForChannel(bot *bots.Bot, channel *model.Channel) error
+}
+
+// ContextBuilder builds the LLM context of the report summaries.
+type ContextBuilder interface {
+	BuildLLMContextUserRequest(bot *bots.Bot, requestingUser *model.User, channel *model.Channel, opts ...llm.ContextOption) *llm.Context
+}
+
+// Service configures the standups, sends their questions, collects the answers and posts the reports.
+type Service struct {
+	client         mmapi.Client
+	bots           BotSource
+	contextBuilder ContextBuilder
+	prompts        *llm.Prompts
+	i18n           *i18n.Bundle
+	mutexAPI       cluster.MutexPluginAPI
+	getConfig      func() config.StandupsConfig
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+// New creates a new standups service. Call Start to begin polling.
+func New(
+	client mmapi.Client,
+	bots BotSource,
+	contextBuilder ContextBuilder,
+	prompts *llm.Prompts,
+	i18nBundle *i18n.Bundle,
+	mutexAPI cluster.MutexPluginAPI,
+	getConfig func() config.StandupsConfig,
+) *Service {
+	return &Service{
+		client:         client,
+		bots:           bots,
+		contextBuilder: contextBuilder,
+		prompts:        prompts,
+		i18n:           i18nBundle,
+		mutexAPI:       mutexAPI,
+		getConfig:      getConfig,
+	}
+}
+
+// Start polls the standups every interval.
+func (s *Service) Start(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.Poll()
+			}
+		}
+	}()
+}
+
+// Stop stops polling.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// Get returns the standup of the team.
+func (s *Service) Get(teamID string) (*Standup, error) {
+	var standup *Standup
+	if err := s.client.KVGet(standupKeyPrefix+teamID, &standup); err != nil {
+		return nil, fmt.Errorf("failed to get standup: %w", err)
+	}
+	if standup == nil {
+		return nil, ErrNotFound
+	}
+	return standup, nil
+}
+
+// Save validates and saves the standup of the team, run by the given agent. Unset settings are
+// given their defaults.
+func (s *Service) Save(userID string, bot *bots.Bot, standup *Standup) (*Standup, error) {
+	if !s.getConfig().Enabled {
+		return nil, ErrNotEnabled
+	}
+
+	standup.BotID = bot.GetMMBot().UserId
+	if err := s.normalize(bot, standup); err != nil {
+		return nil, err
+	}
+	standup.UpdatedBy = userID
+	standup.UpdateAt = model.GetMillis()
+
+	if err := s.client.KVSet(standupKeyPrefix+standup.TeamID, standup); err != nil {
+		return nil, fmt.Errorf("failed to save standup: %w", err)
+	}
+	if err := s.updateTeams(func(teamIDs []string) []string {
+		if slices.Contains(teamIDs, standup.TeamID) {
+			return teamIDs
+		}
+		return append(teamIDs, standup.TeamID)
+	}); err != nil {
+		return nil, err
+	}
+
+	return standup, nil
+}
+
+// normalize checks the settings of the standup and fills in the defaults.
+func (s *Service) normalize(bot *bots.Bot, standup *Standup) error {
+	channel, err := s.client.GetChannel(standup.ChannelID)
+	if err != nil || channel.TeamId != standup.TeamID {
+		return fmt.Errorf("%w: the report channel must be a channel of the team", ErrInvalid)
+	}
+	if err := s.bots.CheckUsageRestrictionsForChannel(bot, channel); err != nil {
+		return fmt.Errorf("%w: the agent can't be used in the report channel: %w", ErrInvalid, err)
+	}
+
+	questions := make([]string, 0, len(standup.Questions))
+	for _, question := range standup.Questions {
+		if question = strings.TrimSpace(question); question != "" {
+			questions = append(questions, question)
+		}
+	}
+	if len(questions) == 0 || len(questions) > MaxQuestions {
+		return fmt.Errorf("%w: between 1 and %d questions are required", ErrInvalid, MaxQuestions)
+	}
+	standup.Questions = questions
+
+	slices.Sort(standup.UserIDs)
+	standup.UserIDs = slices.Compact(standup.UserIDs)
+	if len(standup.UserIDs) == 0 || len(standup.UserIDs) > MaxMembers {
+		return fmt.Errorf("%w: between 1 and %d members are required", ErrInvalid, MaxMembers)
+	}
+
+	if _, err := time.Parse("15:04", standup.Time); err != nil {
+		return fmt.Errorf("%w: the time must be formatted as HH:MM", ErrInvalid)
+	}
+	if standup.Timezone == "" {
+		standup.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(standup.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %s", ErrInvalid, standup.Timezone)
+	}
+
+	if len(standup.Weekdays) == 0 {
+		standup.Weekdays = DefaultWeekdays
+	}
+	for _, weekday := range standup.Weekdays {
+		if weekday < time.Sunday || weekday > time.Saturday {
+			return fmt.Errorf("%w: weekdays must be between 0 (Sunday) and 6 (Saturday)", ErrInvalid)
+		}
+	}
+
+	if standup.CollectMinutes == 0 {
+		standup.CollectMinutes = DefaultCollectMinutes
+	}
+	if standup.CollectMinutes < MinCollectMinutes || standup.CollectMinutes > MaxCollectMinutes {
+		return fmt.Errorf("%w: answers must be collected for between %d and %d minutes", ErrInvalid, MinCollectMinutes, MaxCollectMinutes)
+	}
+
+	return nil
+}
+
+// Delete removes the standup of the team. Answers collected for a report not posted yet are dropped.
+func (s *Service) Delete(teamID string) error {
+	if _, err := s.Get(teamID); err != nil {
+		return err
+	}
+
+	round, err := s.getRound(teamID)
+	if err != nil {
+		return err
+	}
+	if round != nil {
+		s.clearPending(round)
+		if err := s.client.KVDelete(roundKeyPrefix + teamID); err != nil {
+			return fmt.Errorf("failed to delete standup round: %w", err)
+		}
+	}
+
+	if err := s.client.KVDelete(standupKeyPrefix + teamID); err != nil {
+		return fmt.Errorf("failed to delete standup: %w", err)
+	}
+	return s.updateTeams(func(teamIDs []string) []string {
+		return slices.DeleteFunc(teamIDs, func(id string) bool { return id == teamID })
+	})
+}
+
+// MessageHasBeenPosted collects the post as a standup answer if it replies to the questions a
+// member was sent for a report not posted yet. It returns whether the post was collected, in
+// which case the agent must not answer it.
+func (s *Service) MessageHasBeenPosted(post *model.Post) bool {
+	if post.RootId == "" {
+		return false
+	}
+
+	var pending map[string]string
+	if err := s.client.KVGet(pendingKeyPrefix+post.UserId, &pending); err != nil {
+		s.client.LogError("Failed to get pending standups", "user_id", post.UserId, "error", err)
+		return false
+	}
+	teamID, ok := pending[post.RootId]
+	if !ok {
+		return false
+	}
+
+	collected, err := s.collectAnswer(teamID, post)
+	if err != nil {
+		s.client.LogError("Failed to collect standup answer", "team_id", teamID, "post_id", post.Id, "error", err)
+	}
+	return collected
+}
+
+func (s *Service) collectAnswer(teamID string, post *model.Post) (bool, error) {
+	unlock, err := s.lockRound(teamID)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	round, err := s.getRound(teamID)
+	if err != nil {
+		return false, err
+	}
+	if round == nil || round.ReportPostID != "" || round.PromptPostIDs[post.UserId] != post.RootId {
+		return false, nil
+	}
+
+	round.Answers[post.UserId] = append(round.Answers[post.UserId], format.PostBody(post))
+	if err := s.saveRound(round); err != nil {
+		return true, err
+	}
+
+	if err := s.client.AddReaction(&model.Reaction{
+		UserId:    s.promptSender(post),
+		PostId:    post.Id,
+		EmojiName: answeredEmoji,
+		ChannelId: post.ChannelId,
+	}); err != nil {
+		s.client.LogWarn("Failed to react to standup answer", "post_id", post.Id, "error", err)
+	}
+
+	return true, nil
+}
+
+// promptSender returns the agent that sent the questions the post replies to.
+func (s *Service) promptSender(post *model.Post) string {
+	prompt, err := s.client.GetPost(post.RootId)
+	if err != nil {
+		return ""
+	}
+	return prompt.UserId
+}
+
+// Poll sends the questions of the standups that are due and posts the reports whose collection
+// window closed. Only one node of the cluster polls at a time.
+func (s *Service) Poll() {
+	if !s.getConfig().Enabled {
+		return
+	}
+
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_standups_poll")
+	if err != nil {
+		s.client.LogError("Failed to create standups poll mutex", "error", err)
+		return
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	var teamIDs []string
+	if err := s.client.KVGet(teamsKey, &teamIDs); err != nil {
+		s.client.LogError("Failed to get standup teams", "error", err)
+		return
+	}
+
+	now := time.Now()
+	for _, teamID := range teamIDs {
+		standup, err := s.Get(teamID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err == nil {
+			err = s.process(context.Background(), standup, now)
+		}
+		if err != nil {
+			s.client.LogWarn("Failed to run standup", "team_id", teamID, "error", err)
+		}
+	}
+}
+
+// process posts the report of the current round once its collection window closed, then starts
+// the round of the day if it's due.
+func (s *Service) process(ctx context.Context, standup *Standup, now time.Time) error {
+	round, err := s.getRound(standup.TeamID)
+	if err != nil {
+		return err
+	}
+
+	if round != nil && round.ReportPostID == "" && now.UnixMilli() >= round.ReportAt {
+		if err := s.report(ctx, standup, round); err != nil {
+			return err
+		}
+	}
+
+	date, startAt, ok := s.due(standup, now)
+	if !ok || (round != nil && round.Date == date) {
+		return nil
+	}
+	if round != nil && round.ReportPostID == "" {
+		// The previous report failed, its answers are dropped for the new round
+		s.clearPending(round)
+	}
+
+	return s.startRound(standup, date, startAt)
+}
+
+// due returns the date and the time the questions of the day are sent at, and whether they're
+// due now. Questions are only sent during the collection window, so a standup configured or
+// polled after its report time waits for the next day.
+func (s *Service) due(standup *Standup, now time.Time) (string, time.Time, bool) {
+	location, err := time.LoadLocation(standup.Timezone)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	scheduled, err := time.Parse("15:04", standup.Time)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+
+	local := now.In(location)
+	if !slices.Contains(standup.Weekdays, local.Weekday()) {
+		return "", time.Time{}, false
+	}
+
+	startAt := time.Date(local.Year(), local.Month(), local.Day(), scheduled.Hour(), scheduled.Minute(), 0, 0, location)
+	reportAt := startAt.Add(time.Duration(standup.CollectMinutes) * time.Minute)
+	return local.Format(time.DateOnly), startAt, !now.Before(startAt) && now.Before(reportAt)
+}
+
+// startRound sends the questions to each member of the standup.
+func (s *Service) startRound(standup *Standup, date string, startAt time.Time) error {
+	unlock, err := s.lockRound(standup.TeamID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	channel, err := s.client.GetChannel(standup.ChannelID)
+	if err != nil {
+		return fmt.Errorf("failed to get report channel: %w", err)
+	}
+
+	round := &Round{
+		TeamID:        standup.TeamID,
+		Date:          date,
+		StartedAt:     model.GetMillis(),
+		ReportAt:      startAt.Add(time.Duration(standup.CollectMinutes) * time.Minute).UnixMilli(),
+		PromptPostIDs: map[string]string{},
+		Answers:       map[string][]string{},
+	}
+
+	for _, userID := range standup.UserIDs {
+		user, err := s.client.GetUser(userID)
+		if err != nil || user.DeleteAt != 0 {
+			continue
+		}
+
+		T := i18n.LocalizerFunc(s.i18n, user.Locale)
+		var message strings.Builder
+		message.WriteString(T("agents.standup.prompt", "#### Standup for %s\nReply in this thread to answer. Your answers are shared in ~%s when the report is posted, in %d minutes.\n", date, channel.Name, standup.CollectMinutes))
+		for i, question := range standup.Questions {
+			fmt.Fprintf(&message, "\n%d. %s", i+1, question)
+		}
+
+		post := &model.Post{Message: message.String()}
+		post.AddProp(StandupProp, PostKindPrompt)
+		if err := s.client.DM(standup.BotID, userID, post); err != nil {
+			s.client.LogWarn("Failed to send standup questions", "team_id", standup.TeamID, "user_id", userID, "error", err)
+			continue
+		}
+		round.PromptPostIDs[userID] = post.Id
+
+		if err := s.updatePending(userID, func(pending map[string]string) {
+			pending[post.Id] = standup.TeamID
+		}); err != nil {
+			s.client.LogWarn("Failed to save pending standup", "user_id", userID, "error", err)
+		}
+	}
+
+	return s.saveRound(round)
+}
+
+// report posts the summary of the answers of the round in the channel of the standup, with the
+// answers themselves in its thread.
+func (s *Service) report(ctx context.Context, standup *Standup, round *Round) error {
+	unlock, err := s.lockRound(standup.TeamID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Answers may have been collected since the round was read
+	round, err = s.getRound(standup.TeamID)
+	if err != nil || round == nil || round.ReportPostID != "" {
+		return err
+	}
+
+	T := s.serverLocalizer()
+	usernames := map[string]string{}
+	var answered, missing []string
+	for _, userID := range standup.UserIDs {
+		if _, ok := round.PromptPostIDs[userID]; !ok {
+			continue
+		}
+		username := userID
+		if user, err := s.client.GetUser(userID); err == nil {
+			username = user.Username
+		}
+		usernames[userID] = username
+		if len(round.Answers[userID]) > 0 {
+			answered = append(answered, userID)
+		} else {
+			missing = append(missing, "@"+username)
+		}
+	}
+
+	var answers strings.Builder
+	for _, userID := range answered {
+		fmt.Fprintf(&answers, "**@%s**\n", usernames[userID])
+		for _, answer := range round.Answers[userID] {
+			fmt.Fprintf(&answers, "%s\n", answer)
+		}
+		answers.WriteString("\n")
+	}
+
+	message := T("agents.standup.report_header", "#### Standup report for %s\n", round.Date)
+	if len(answered) == 0 {
+		message += T("agents.standup.no_answers", "Nobody answered the standup questions.")
+	} else {
+		summary, err := s.summarize(ctx, standup, round, answers.String())
+		if err != nil {
+			return err
+		}
+		message += summary
+	}
+	if len(missing) > 0 {
+		message += "\n\n" + T("agents.standup.missing", "_No answer from %s._", strings.Join(missing, ", "))
+	}
+
+	reportPost := &model.Post{
+		UserId:    standup.BotID,
+		ChannelId: standup.ChannelID,
+		Message:   message,
+	}
+	reportPost.AddProp(StandupProp, PostKindReport)
+	if err := s.client.CreatePost(reportPost); err != nil {
+		return fmt.Errorf("failed to post standup report: %w", err)
+	}
+
+	if len(answered) > 0 {
+		answersPost := &model.Post{
+			UserId:    standup.BotID,
+			ChannelId: standup.ChannelID,
+			RootId:    reportPost.Id,
+			Message:   strings.TrimSpace(answers.String()),
+		}
+		answersPost.AddProp(StandupProp, PostKindAnswers)
+		if err := s.client.CreatePost(answersPost); err != nil {
+			s.client.LogWarn("Failed to post standup answers", "team_id", standup.TeamID, "error", err)
+		}
+	}
+
+	round.ReportPostID = reportPost.Id
+	if err := s.saveRound(round); err != nil {
+		return err
+	}
+	s.clearPending(round)
+	return nil
+}
+
+// summarize returns the summary the agent writes from the answers of the round, with the
+// permissions of the team admin who last saved the standup.
+func (s *Service) summarize(ctx context.Context, standup *Standup, round *Round, answers string) (string, error) {
+	bot := s.bots.GetBotByID(standup.BotID)
+	if bot == nil {
+		return "", fmt.Errorf("agent %s not found", standup.BotID)
+	}
+	user, err := s.client.GetUser(standup.UpdatedBy)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	channel, err := s.client.GetChannel(standup.ChannelID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get channel: %w", err)
+	}
+	if err := s.bots.CheckUsageRestrictionsForChannel(bot, channel); err != nil {
+		return "", fmt.Errorf("agent can't be used in the report channel: %w", err)
+	}
+
+	llmContext := s.contextBuilder.BuildLLMContextUserRequest(bot, user, channel)
+	llmContext.Priority = llm.PriorityBackground
+	llmContext.Parameters = map[string]any{
+		"Date":      round.Date,
+		"Questions": standup.Questions,
+		"Thread":    answers,
+	}
+
+	systemPrompt, err := s.prompts.Format(prompts.PromptStandupSummarySystem, llmContext)
+	if err != nil {
+		return "", fmt.Errorf("failed to format system prompt: %w", err)
+	}
+	userPrompt, err := s.prompts.Format(prompts.PromptThreadUser, llmContext)
+	if err != nil {
+		return "", fmt.Errorf("failed to format user prompt: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, generationTimeout)
+	defer cancel()
+
+	result, err := bot.LLM().ChatCompletionNoStream(ctx, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: userPrompt},
+		},
+		Context: llmContext,
+	}, llm.WithToolsDisabled())
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize standup: %w", err)
+	}
+
+	return strings.TrimSpace(result), nil
+}
+
+func (s *Service) serverLocalizer() i18n.TranslationFunc {
+	locale := ""
+	if serverConfig := s.client.GetConfig(); serverConfig != nil && serverConfig.LocalizationSettings.DefaultServerLocale != nil {
+		locale = *serverConfig.LocalizationSettings.DefaultServerLocale
+	}
+	return i18n.LocalizerFunc(s.i18n, locale)
+}
+
+func (s *Service) getRound(teamID string) (*Round, error) {
+	var round *Round
+	if err := s.client.KVGet(roundKeyPrefix+teamID, &round); err != nil {
+		return nil, fmt.Errorf("failed to get standup round: %w", err)
+	}
+	return round, nil
+}
+
+func (s *Service) saveRound(round *Round) error {
+	if err := s.client.KVSet(roundKeyPrefix+round.TeamID, round); err != nil {
+		return fmt.Errorf("failed to save standup round: %w", err)
+	}
+	return nil
+}
+
+// lockRound locks the round of the team, which answers are collected into from any node.
+func (s *Service) lockRound(teamID string) (func(), error) {
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_standup_round_"+teamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create standup round mutex: %w", err)
+	}
+	mtx.Lock()
+	return mtx.Unlock, nil
+}
+
+// clearPending stops collecting the replies of the members to the questions of the round.
+func (s *Service) clearPending(round *Round) {
+	for userID, postID := range round.PromptPostIDs {
+		if err := s.updatePending(userID, func(pending map[string]string) {
+			delete(pending, postID)
+		}); err != nil {
+			s.client.LogWarn("Failed to clear pending standup", "user_id", userID, "error", err)
+		}
+	}
+}
+
+// IsUserPendingKey reports whether the KV key stores the questions the user can still answer
+func IsUserPendingKey(key, userID string) bool {
+	return key == pendingKeyPrefix+userID
+}
+
+// updatePending updates the questions a user was sent and can still answer, by post ID.
+func (s *Service) updatePending(userID string, update func(pending map[string]string)) error {
+	var pending map[string]string
+	if err := s.client.KVGet(pendingKeyPrefix+userID, &pending); err != nil {
+		return err
+	}
+	if pending == nil {
+		pending = map[string]string{}
+	}
+	update(pending)
+	if len(pending) == 0 {
+		return s.client.KVDelete(pendingKeyPrefix + userID)
+	}
+	return s.client.KVSet(pendingKeyPrefix+userID, pending)
+}
+
+// updateTeams updates the list of teams with a standup, which the standups are polled from.
+func (s *Service) updateTeams(update func(teamIDs []string) []string) error {
+	mtx, err := cluster.NewMutex(s.mutexAPI, "ai_standup_teams")
+	if err != nil {
+		return fmt.Errorf("failed to create standup teams mutex: %w", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	var teamIDs []string
+	if err := s.client.KVGet(teamsKey, &teamIDs); err != nil {
+		return fmt.Errorf("failed to get standup teams: %w", err)
+	}
+	if err := s.client.KVSet(teamsKey, update(teamIDs)); err != nil {
+		return fmt.Errorf("failed to save standup teams: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,358 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package standups
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	llmmocks "github.com/mattermost/mattermost-plugin-ai/llm/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mmapitest"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient keeps the KV store, the posts and the reactions in memory.
+type fakeClient struct {
+	mmapi.Client
+	kv        map[string][]byte
+	posts     map[string]*model.Post
+	reactions []*model.Reaction
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{kv: map[string][]byte{}, posts: map[string]*model.Post{}}
+}
+
+func (f *fakeClient) KVGet(key string, value interface{}) error {
+	data, ok := f.kv[key]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (f *fakeClient) KVSet(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	f.kv[key] = data
+	return nil
+}
+
+func (f *fakeClient) KVDelete(key string) error {
+	delete(f.kv, key)
+	return nil
+}
+
+func (f *fakeClient) GetUser(userID string) (*model.User, error) {
+	return &model.User{Id: userID, Username: "user-" + userID, Locale: "en"}, nil
+}
+
+func (f *fakeClient) GetChannel(channelID string) (*model.Channel, error) {
+	if channelID == "other-team-channel" {
+		return &model.Channel{Id: channelID, TeamId: "team2", Name: "elsewhere"}, nil
+	}
+	return &model.Channel{Id: channelID, TeamId: "team1", Name: "standup"}, nil
+}
+
+func (f *fakeClient) GetPost(postID string) (*model.Post, error) {
+	post, ok := f.posts[postID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return post.Clone(), nil
+}
+
+func (f *fakeClient) CreatePost(post *model.Post) error {
+	if post.Id == "" {
+		post.Id = model.NewId()
+	}
+	f.posts[post.Id] = post.Clone()
+	return nil
+}
+
+func (f *fakeClient) DM(senderID, receiverID string, post *model.Post) error {
+	post.UserId = senderID
+	post.ChannelId = "dm-" + receiverID
+	return f.CreatePost(post)
+}
+
+func (f *fakeClient) AddReaction(reaction *model.Reaction) error {
+	f.reactions = append(f.reactions, reaction)
+	return nil
+}
+
+func (f *fakeClient) GetConfig() *model.Config {
+	return &model.Config{}
+}
+
+func (f *fakeClient) LogError(string, ...interface{}) {}
+
+func (f *fakeClient) LogWarn(string, ...interface{}) {}
+
+func (f *fakeClient) postsOfKind(kind string) []*model.Post {
+	var posts []*model.Post
+	for _, post := range f.posts {
+		if post.GetProp(StandupProp) == kind {
+			posts = append(posts, post)
+		}
+	}
+	return posts
+}
+
+type fakeBots struct {
+	bot *bots.Bot
+}
+
+func (f *fakeBots) GetBotByID(string) *bots.Bot {
+	return f.bot
+}
+
+func (f *fakeBots) CheckUsageRestrictionsForChannel(*bots.Bot, *model.Channel) error {
+	return nil
+}
+
+type fakeContextBuilder struct{}
+
+func (fakeContextBuilder) BuildLLMContextUserRequest(_ *bots.Bot, user *model.User, channel *model.Channel, _ ...llm.ContextOption) *llm.Context {
+	llmContext := llm.NewContext()
+	llmContext.RequestingUser = user
+	llmContext.Channel = channel
+	return llmContext
+}
+
+func newTestService(t *testing.T, client *fakeClient, languageModel llm.LanguageModel) (*Service, *bots.Bot) {
+	promptsObj, err := llm.NewPrompts(prompts.PromptsFolder)
+	require.NoError(t, err)
+	bot := bots.NewBot(llm.BotConfig{}, llm.ServiceConfig{}, &model.Bot{UserId: "bot1"}, languageModel)
+	cfg := config.StandupsConfig{Enabled: true}
+	return New(client, &fakeBots{bot: bot}, fakeContextBuilder{}, promptsObj, i18n.Init(), mmapitest.MutexAPI{}, func() config.StandupsConfig { return cfg }), bot
+}
+
+func validStandup() *Standup {
+	return &Standup{
+		TeamID:    "team1",
+		ChannelID: "channel1",
+		Questions: []string{"What did you do yesterday?", "Anything blocking you?"},
+		UserIDs:   []string{"alice", "bob"},
+		Time:      "09:00",
+	}
+}
+
+func TestSave(t *testing.T) {
+	tests := []struct {
+		name          string
+		disabled      bool
+		update        func(standup *Standup)
+		expectedError error
+	}{
+		{name: "not enabled", disabled: true, expectedError: ErrNotEnabled},
+		{name: "channel of another team", update: func(s *Standup) { s.ChannelID = "other-team-channel" }, expectedError: ErrInvalid},
+		{name: "no questions", update: func(s *Standup) { s.Questions = []string{" ", ""} }, expectedError: ErrInvalid},
+		{name: "too many questions", update: func(s *Standup) { s.Questions = slices.Repeat([]string{"Any news?"}, MaxQuestions+1) }, expectedError: ErrInvalid},
+		{name: "no members", update: func(s *Standup) { s.UserIDs = nil }, expectedError: ErrInvalid},
+		{name: "invalid time", update: func(s *Standup) { s.Time = "9am" }, expectedError: ErrInvalid},
+		{name: "unknown timezone", update: func(s *Standup) { s.Timezone = "Mars/Olympus" }, expectedError: ErrInvalid},
+		{name: "invalid weekday", update: func(s *Standup) { s.Weekdays = []time.Weekday{7} }, expectedError: ErrInvalid},
+		{name: "collect window too short", update: func(s *Standup) { s.CollectMinutes = 5 }, expectedError: ErrInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, bot := newTestService(t, newFakeClient(), nil)
+			if test.disabled {
+				service.getConfig = func() config.StandupsConfig { return config.StandupsConfig{} }
+			}
+			standup := validStandup()
+			if test.update != nil {
+				test.update(standup)
+			}
+
+			_, err := service.Save("admin", bot, standup)
+			require.ErrorIs(t, err, test.expectedError)
+			_, err = service.Get("team1")
+			require.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		service, bot := newTestService(t, newFakeClient(), nil)
+		standup := validStandup()
+		standup.UserIDs = []string{"bob", "alice", "bob"}
+
+		saved, err := service.Save("admin", bot, standup)
+		require.NoError(t, err)
+		require.Equal(t, "bot1", saved.BotID)
+		require.Equal(t, "admin", saved.UpdatedBy)
+		require.Equal(t, []string{"alice", "bob"}, saved.UserIDs)
+		require.Equal(t, "UTC", saved.Timezone)
+		require.Equal(t, DefaultWeekdays, saved.Weekdays)
+		require.Equal(t, DefaultCollectMinutes, saved.CollectMinutes)
+
+		got, err := service.Get("team1")
+		require.NoError(t, err)
+		require.Equal(t, saved, got)
+	})
+}
+
+func TestDue(t *testing.T) {
+	standup := validStandup()
+	standup.Timezone = "America/New_York"
+	standup.Weekdays = DefaultWeekdays
+	standup.CollectMinutes = 60
+
+	// Monday October 12th 2026, 09:00 in New York is 13:00 UTC
+	monday := time.Date(2026, time.October, 12, 13, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		expectedDue bool
+	}{
+		{name: "before the time", now: monday.Add(-time.Minute)},
+		{name: "at the time", now: monday, expectedDue: true},
+		{name: "during the collect window", now: monday.Add(59 * time.Minute), expectedDue: true},
+		{name: "after the collect window", now: monday.Add(time.Hour)},
+		{name: "on a weekend", now: monday.Add(-48 * time.Hour)},
+	}
+
+	service, _ := newTestService(t, newFakeClient(), nil)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			date, startAt, due := service.due(standup, test.now)
+			require.Equal(t, test.expectedDue, due)
+			if due {
+				require.Equal(t, "2026-10-12", date)
+				require.True(t, startAt.Equal(monday))
+			}
+		})
+	}
+}
+
+func TestRound(t *testing.T) {
+	client := newFakeClient()
+	languageModel := llmmocks.NewMockLanguageModel(t)
+	service, bot := newTestService(t, client, languageModel)
+
+	standup, err := service.Save("admin", bot, validStandup())
+	require.NoError(t, err)
+
+	start := time.Date(2026, time.October, 12, 9, 5, 0, 0, time.UTC)
+	require.NoError(t, service.process(context.Background(), standup, start))
+
+	prompts := client.postsOfKind(PostKindPrompt)
+	require.Len(t, prompts, 2)
+	promptIDs := map[string]string{}
+	for _, prompt := range prompts {
+		require.Equal(t, "bot1", prompt.UserId)
+		require.Contains(t, prompt.Message, "1. What did you do yesterday?")
+		require.Contains(t, prompt.Message, "2. Anything blocking you?")
+		promptIDs[prompt.ChannelId[len("dm-"):]] = prompt.Id
+	}
+
+	// A second poll on the same day doesn't send the questions again
+	require.NoError(t, service.process(context.Background(), standup, start.Add(time.Minute)))
+	require.Len(t, client.postsOfKind(PostKindPrompt), 2)
+
+	answer := &model.Post{Id: "answer1", UserId: "alice", ChannelId: "dm-alice", RootId: promptIDs["alice"], Message: "Fixed the login bug. Blocked on the staging database."}
+	require.True(t, service.MessageHasBeenPosted(answer))
+	require.Len(t, client.reactions, 1)
+	require.Equal(t, "bot1", client.reactions[0].UserId)
+
+	notAnswers := []*model.Post{
+		{Id: "root", UserId: "alice", ChannelId: "dm-alice", Message: "Unrelated question"},
+		{Id: "other", UserId: "alice", ChannelId: "dm-alice", RootId: "another-thread", Message: "Unrelated reply"},
+		{Id: "wrong-user", UserId: "carol", ChannelId: "dm-alice", RootId: promptIDs["alice"], Message: "Not my standup"},
+	}
+	for _, post := range notAnswers {
+		require.False(t, service.MessageHasBeenPosted(post), post.Id)
+	}
+
+	// Answers aren't reported before the collect window closes
+	require.NoError(t, service.process(context.Background(), standup, start.Add(time.Hour)))
+	require.Empty(t, client.postsOfKind(PostKindReport))
+
+	languageModel.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, request llm.CompletionRequest, _ ...llm.LanguageModelOption) (string, error) {
+		require.Contains(t, request.Posts[0].Message, "What did you do yesterday?")
+		require.Contains(t, request.Posts[1].Message, "**@user-alice**\nFixed the login bug.")
+		require.NotContains(t, request.Posts[1].Message, "user-bob")
+		return "Alice fixed the login bug and is blocked on staging.", nil
+	}).Once()
+
+	require.NoError(t, service.process(context.Background(), standup, start.Add(2*time.Hour)))
+
+	reports := client.postsOfKind(PostKindReport)
+	require.Len(t, reports, 1)
+	require.Equal(t, "channel1", reports[0].ChannelId)
+	require.Contains(t, reports[0].Message, "Standup report for 2026-10-12")
+	require.Contains(t, reports[0].Message, "Alice fixed the login bug and is blocked on staging.")
+	require.Contains(t, reports[0].Message, "No answer from @user-bob")
+
+	answers := client.postsOfKind(PostKindAnswers)
+	require.Len(t, answers, 1)
+	require.Equal(t, reports[0].Id, answers[0].RootId)
+
+	// Replies after the report are left to the agent
+	late := &model.Post{Id: "late", UserId: "bob", ChannelId: "dm-bob", RootId: promptIDs["bob"], Message: "Sorry, late"}
+	require.False(t, service.MessageHasBeenPosted(late))
+
+	// The report is posted once
+	require.NoError(t, service.process(context.Background(), standup, start.Add(3*time.Hour)))
+	require.Len(t, client.postsOfKind(PostKindReport), 1)
+}
+
+func TestReportWithoutAnswers(t *testing.T) {
+	client := newFakeClient()
+	service, bot := newTestService(t, client, llmmocks.NewMockLanguageModel(t))
+
+	standup, err := service.Save("admin", bot, validStandup())
+	require.NoError(t, err)
+
+	start := time.Date(2026, time.October, 12, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, service.process(context.Background(), standup, start))
+	require.NoError(t, service.process(context.Background(), standup, start.Add(2*time.Hour)))
+
+	reports := client.postsOfKind(PostKindReport)
+	require.Len(t, reports, 1)
+	require.Contains(t, reports[0].Message, "Nobody answered the standup questions.")
+	require.Empty(t, client.postsOfKind(PostKindAnswers))
+}
+
+func TestDelete(t *testing.T) {
+	client := newFakeClient()
+	service, bot := newTestService(t, client, nil)
+
+	require.ErrorIs(t, service.Delete("team1"), ErrNotFound)
+
+	standup, err := service.Save("admin", bot, validStandup())
+	require.NoError(t, err)
+	require.NoError(t, service.process(context.Background(), standup, time.Date(2026, time.October, 12, 9, 0, 0, 0, time.UTC)))
+
+	require.NoError(t, service.Delete("team1"))
+	_, err = service.Get("team1")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// The questions already sent are no longer collected
+	for _, prompt := range client.postsOfKind(PostKindPrompt) {
+		reply := &model.Post{Id: model.NewId(), UserId: prompt.ChannelId[len("dm-"):], RootId: prompt.Id, Message: "Done"}
+		require.False(t, service.MessageHasBeenPosted(reply))
+	}
+
+	var teamIDs []string
+	require.NoError(t, client.KVGet(teamsKey, &teamIDs))
+	require.Empty(t, teamIDs)
+}
@@ -19,6 +19,10 @@ func (c *benchmarkClient) CreatePost(_ *model.Post) error {
 	return nil
 }
 
+func (c *benchmarkClient) SendEphemeralPost(_ string, _ *model.Post) {}
+
+func (c *benchmarkClient) UpdateEphemeralPost(_ string, _ *model.Post) {}
+
 func (c *benchmarkClient) DM(_, _ string, _ *model.Post) error {
 	return nil
 }
@@ -0,0 +1,89 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package streaming
+
+import (
+	"strings"
+)
+
+// markdownSafeLength returns the length of the longest prefix of message that can be rendered
+// without broken markdown: code blocks are shown once their closing fence arrives, tables once
+// their delimiter row arrives, and the last line up to its last word with balanced inline code
+// and emphasis markers.
+func markdownSafeLength(message string) int {
+	safe := 0
+	// fenceStart is the offset of the line opening the current code block, -1 outside of one
+	fenceStart := -1
+	fence := ""
+	// tableStart is the offset of the first row of the current table, -1 outside of one
+	tableStart := -1
+	tableRows := 0
+
+	offset := 0
+	for offset < len(message) {
+		end := strings.IndexByte(message[offset:], '\n')
+		if end < 0 {
+			break
+		}
+		line := message[offset : offset+end]
+		lineEnd := offset + end + 1
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case fenceStart >= 0:
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fenceStart = -1
+				safe = lineEnd
+			}
+		case codeFence(trimmed) != "":
+			tableStart = -1
+			fence = codeFence(trimmed)
+			fenceStart = offset
+		case strings.HasPrefix(trimmed, "|"):
+			if tableStart < 0 {
+				tableStart = offset
+				tableRows = 0
+			}
+			tableRows++
+			// A table is rendered once its delimiter row follows the header
+			if tableRows >= 2 {
+				safe = lineEnd
+			}
+		default:
+			tableStart = -1
+			safe = lineEnd
+		}
+
+		offset = lineEnd
+	}
+
+	if fenceStart >= 0 || tableStart >= 0 {
+		return safe
+	}
+
+	// The last line is still being written
+	partial := message[offset:]
+	trimmed := strings.TrimSpace(partial)
+	if strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "`") || strings.HasPrefix(trimmed, "~") {
+		return safe
+	}
+	for end := strings.LastIndexAny(partial, " \t"); end >= 0; end = strings.LastIndexAny(partial[:end], " \t") {
+		words := partial[:end]
+		if strings.Count(words, "`")%2 == 0 && strings.Count(words, "**")%2 == 0 {
+			return offset + end
+		}
+	}
+	return safe
+}
+
+// codeFence returns the fence opening a code block on the line, empty if the line doesn't open one
+func codeFence(line string) string {
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(line, marker) {
+			length := len(line) - len(strings.TrimLeft(line, marker[:1]))
+			return line[:length]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package streaming
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownSafeLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected string
+	}{
+		{
+			name:     "partial words are held back",
+			message:  "Hello wor",
+			expected: "Hello",
+		},
+		{
+			name:     "complete lines are sent",
+			message:  "Hello world\n",
+			expected: "Hello world\n",
+		},
+		{
+			name:     "open code blocks are held back",
+			message:  "Intro\n```go\nfmt.Println()\n",
+			expected: "Intro\n",
+		},
+		{
+			name:     "closed code blocks are sent",
+			message:  "Intro\n```go\nfmt.Println()\n```\nAfter",
+			expected: "Intro\n```go\nfmt.Println()\n```\n",
+		},
+		{
+			name:     "longer fences close code blocks containing shorter ones",
+			message:  "````\n```\n````\n",
+			expected: "````\n```\n````\n",
+		},
+		{
+			name:     "tables without their delimiter row are held back",
+			message:  "Results:\n| a | b |\n",
+			expected: "Results:\n",
+		},
+		{
+			name:     "complete rows of tables are sent",
+			message:  "| a | b |\n|---|---|\n| 1 | 2 |\n| 3 ",
+			expected: "| a | b |\n|---|---|\n| 1 | 2 |\n",
+		},
+		{
+			name:     "unclosed inline code is held back",
+			message:  "Run `go test ./...",
+			expected: "Run",
+		},
+		{
+			name:     "unclosed emphasis is held back",
+			message:  "This is **very important",
+			expected: "This is",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.message[:markdownSafeLength(test.message)])
+		})
+	}
+}
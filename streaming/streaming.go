@@ -6,13 +6,17 @@ package streaming
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/mattermost/mattermost-plugin-ai/i18n"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
 )
 
 // Client defines the minimal client interface needed for streaming operations.
@@ -20,6 +24,8 @@ type Client interface {
 	PublishWebSocketEvent(event string, payload map[string]interface{}, broadcast *model.WebsocketBroadcast)
 	UpdatePost(post *model.Post) error
 	CreatePost(post *model.Post) error
+	SendEphemeralPost(userID string, post *model.Post)
+	UpdateEphemeralPost(userID string, post *model.Post)
 	DM(senderID, receiverID string, post *model.Post) error
 	GetUser(userID string) (*model.User, error)
 	GetChannel(channelID string) (*model.Channel, error)
@@ -32,40 +38,175 @@ const PostStreamingControlCancel = "cancel"
 const PostStreamingControlEnd = "end"
 const PostStreamingControlStart = "start"
 
+// PostStreamingControlToolRunning is sent with the name of a tool that started running, until
+// the response continues
+const PostStreamingControlToolRunning = "tool_running"
+
+// typingInterval is how often the bot is shown typing while a response is generated without new text
+const typingInterval = 3 * time.Second
+
 const ToolCallProp = "pending_tool_call"
 const ReasoningSummaryProp = "reasoning_summary"
 const AnnotationsProp = "annotations"
 const WebSearchContextProp = "web_search_context"
 const ReasoningSignatureProp = "reasoning_signature"
 
+// ResponseIDProp is the ID the provider stored the response with, for continuing the conversation
+// from it
+const ResponseIDProp = "response_id"
+
+// ReasoningDisplayProp is how the reasoning of the response is shown, set when it isn't the default
+// llm.ReasoningDisplaySummary
+const ReasoningDisplayProp = "reasoning_display"
+const StoppedEarlyProp = "stopped_early"
+const FinishReasonProp = "finish_reason"
+const InterruptedProp = "interrupted"
+const ErrorProp = "llm_error"
+
+// ContextSufficiencyProp is "insufficient" on responses the model reported lacking the context to
+// answer well, with what was missing in ContextMissingProp, and "sufficient" otherwise
+const ContextSufficiencyProp = "context_sufficiency"
+const ContextMissingProp = "context_missing"
+
+// CitationsRenderedProp marks posts whose annotations were rendered into the message as footnotes
+const CitationsRenderedProp = "citations_rendered"
+
+// streamOwnershipWait bounds how long a node waits for another node streaming to the same post.
+const streamOwnershipWait = time.Second
+
+// shutdownToolCallWait bounds how long an interrupted stream waits for tool calls the
+// provider had already started sending, so they can be approved after the restart.
+const shutdownToolCallWait = 2 * time.Second
+
 type Service interface {
 	StreamToNewPost(ctx context.Context, botID string, requesterUserID string, stream *llm.TextStreamResult, post *model.Post, respondingToPostID string) error
 	StreamToNewDM(ctx context.Context, botID string, stream *llm.TextStreamResult, userID string, post *model.Post, respondingToPostID string) error
+	StreamToEphemeralPost(ctx context.Context, botID string, userID string, stream *llm.TextStreamResult, post *model.Post, respondingToPostID string) error
 	StreamToPost(ctx context.Context, stream *llm.TextStreamResult, post *model.Post, userLocale string)
 	StopStreaming(postID string)
 	GetStreamingContext(inCtx context.Context, postID string) (context.Context, error)
 	FinishStreaming(postID string)
 }
 
+// TypingPublisher shows the users of a channel that a user is typing
+type TypingPublisher interface {
+	PublishUserTyping(userID, channelID, parentID string) *model.AppError
+}
+
+// PostProcessor edits a completed response before the post is saved. ephemeral is true for
+// posts only the requester sees, which are not saved and can't have attachments.
+type PostProcessor interface {
+	ProcessPost(ctx context.Context, post *model.Post, ephemeral bool)
+}
+
+// BotPostProcessorFactory creates the processor of a post-processor configured on a bot, from its
+// configuration
+type BotPostProcessorFactory func(config llm.PostProcessorConfig) PostProcessor
+
+type userLocaleKey struct{}
+
+// UserLocale returns the locale of the user a completed response was streamed for, from the
+// context passed to post processors.
+func UserLocale(ctx context.Context) string {
+	locale, _ := ctx.Value(userLocaleKey{}).(string)
+	return locale
+}
+
+type featureKey struct{}
+
+// WithFeature marks the responses streamed with ctx as generated by feature, such as
+// "channel_analysis", so the streaming settings of the feature apply to them.
+func WithFeature(ctx context.Context, feature string) context.Context {
+	return context.WithValue(ctx, featureKey{}, feature)
+}
+
+func featureFromContext(ctx context.Context) string {
+	feature, _ := ctx.Value(featureKey{}).(string)
+	return feature
+}
+
 type postStreamContext struct {
-	cancel context.CancelFunc
+	cancel context.CancelCauseFunc
+	// owner is the cluster-wide lock held on the post while streaming, nil without a cluster
+	owner *cluster.Mutex
 }
 
 var ErrAlreadyStreamingToPost = fmt.Errorf("already streaming to post")
 
+// ErrShuttingDown is the cancellation cause of streams interrupted by the plugin shutting down.
+var ErrShuttingDown = errors.New("plugin is shutting down")
+
 type MMPostStreamService struct {
 	contexts      map[string]postStreamContext
 	contextsMutex sync.Mutex
+	shuttingDown  bool
+	drained       chan struct{}
 	mmClient      Client
 	i18n          *i18n.Bundle
+	config        ConfigProvider
+	mutexAPI      cluster.MutexPluginAPI
+	processors    []PostProcessor
+	// botProcessorFactories create the post-processors configured on bots, by type
+	botProcessorFactories map[string]BotPostProcessorFactory
+	// botPostProcessors returns the post-processors configured on a bot, from its user ID
+	botPostProcessors func(botID string) []llm.PostProcessorConfig
+	typing            TypingPublisher
+	// reasoningDisplay returns how the reasoning of a bot is shown, from its user ID
+	reasoningDisplay func(botID string) string
 }
 
-func NewMMPostStreamService(mmClient Client, i18n *i18n.Bundle) *MMPostStreamService {
+// NewMMPostStreamService creates a streaming service. config may be nil, in which case defaults are used.
+// mutexAPI makes a post owned by a single node of the cluster while it is streamed, it may be nil
+// when the plugin runs on a single node.
+func NewMMPostStreamService(mmClient Client, i18n *i18n.Bundle, config ConfigProvider, mutexAPI cluster.MutexPluginAPI) *MMPostStreamService {
 	return &MMPostStreamService{
 		contexts: make(map[string]postStreamContext),
 		mmClient: mmClient,
 		i18n:     i18n,
+		config:   config,
+		mutexAPI: mutexAPI,
+	}
+}
+
+// AddPostProcessor registers a processor run, in the order added, on every response that completes.
+// Processors must be added before streaming starts.
+func (p *MMPostStreamService) AddPostProcessor(processor PostProcessor) {
+	p.processors = append(p.processors, processor)
+}
+
+// RegisterBotPostProcessor registers how the post-processors of processorType configured on bots
+// are created. Processors must be registered before streaming starts.
+func (p *MMPostStreamService) RegisterBotPostProcessor(processorType string, factory BotPostProcessorFactory) {
+	if p.botProcessorFactories == nil {
+		p.botProcessorFactories = make(map[string]BotPostProcessorFactory)
 	}
+	p.botProcessorFactories[processorType] = factory
+}
+
+// SetBotPostProcessors sets what returns the post-processors configured on a bot, from its user
+// ID. They run on the bot's completed responses after the processors added with AddPostProcessor.
+// It must be set before streaming starts.
+func (p *MMPostStreamService) SetBotPostProcessors(botPostProcessors func(botID string) []llm.PostProcessorConfig) {
+	p.botPostProcessors = botPostProcessors
+}
+
+// SetTypingPublisher sets what shows the bot typing while it generates a response in a channel.
+// It must be set before streaming starts.
+func (p *MMPostStreamService) SetTypingPublisher(typing TypingPublisher) {
+	p.typing = typing
+}
+
+// SetReasoningDisplay sets what returns how the reasoning of a bot is shown, from its user ID.
+// It must be set before streaming starts.
+func (p *MMPostStreamService) SetReasoningDisplay(reasoningDisplay func(botID string) string) {
+	p.reasoningDisplay = reasoningDisplay
+}
+
+func (p *MMPostStreamService) streamingConfig() Config {
+	if p.config == nil {
+		return Config{}
+	}
+	return p.config.StreamingConfig()
 }
 
 func (p *MMPostStreamService) StreamToNewPost(ctx context.Context, botID string, requesterUserID string, stream *llm.TextStreamResult, post *model.Post, respondingToPostID string) error {
@@ -78,7 +219,8 @@ func (p *MMPostStreamService) StreamToNewPost(ctx context.Context, botID string,
 
 	// The callback is already set when creating the context
 
-	ctx, err := p.GetStreamingContext(context.Background(), post.Id)
+	// Streaming outlives the request, only the values of its context, such as the feature, are kept
+	ctx, err := p.GetStreamingContext(context.WithoutCancel(ctx), post.Id)
 	if err != nil {
 		return err
 	}
@@ -120,7 +262,8 @@ func (p *MMPostStreamService) StreamToNewDM(ctx context.Context, botID string, s
 
 	// The callback is already set when creating the context
 
-	ctx, err := p.GetStreamingContext(context.Background(), post.Id)
+	// Streaming outlives the request, only the values of its context, such as the feature, are kept
+	ctx, err := p.GetStreamingContext(context.WithoutCancel(ctx), post.Id)
 	if err != nil {
 		return err
 	}
@@ -152,6 +295,41 @@ func (p *MMPostStreamService) StreamToNewDM(ctx context.Context, botID string, s
 	return nil
 }
 
+// StreamToEphemeralPost streams the result to an ephemeral post that only userID can see.
+// Nothing is persisted to the channel, so this suits private answers in shared channels.
+func (p *MMPostStreamService) StreamToEphemeralPost(ctx context.Context, botID string, userID string, stream *llm.TextStreamResult, post *model.Post, respondingToPostID string) error {
+	ModifyPostForBot(botID, userID, post, respondingToPostID)
+	post.AddProp(NoRegen, "true")
+
+	p.mmClient.SendEphemeralPost(userID, post)
+	if post.Id == "" {
+		return fmt.Errorf("unable to create ephemeral post")
+	}
+
+	// Streaming outlives the request, only the values of its context, such as the feature, are kept
+	ctx, err := p.GetStreamingContext(context.WithoutCancel(ctx), post.Id)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer p.FinishStreaming(post.Id)
+		locale := *p.mmClient.GetConfig().LocalizationSettings.DefaultServerLocale
+		if user, userErr := p.mmClient.GetUser(userID); userErr == nil && user.Locale != "" {
+			locale = user.Locale
+		}
+
+		// Only the requesting user receives the streaming events for an ephemeral post.
+		broadcast := &model.WebsocketBroadcast{UserId: userID}
+		p.streamToPost(ctx, stream, post, locale, broadcast, true, func(post *model.Post) error {
+			p.mmClient.UpdateEphemeralPost(userID, post)
+			return nil
+		})
+	}()
+
+	return nil
+}
+
 func (p *MMPostStreamService) sendPostStreamingUpdateEventWithBroadcast(post *model.Post, message string, broadcast *model.WebsocketBroadcast) {
 	p.mmClient.PublishWebSocketEvent("postupdate", map[string]interface{}{
 		"post_id": post.Id,
@@ -171,6 +349,15 @@ func (p *MMPostStreamService) sendPostStreamingReasoningEventWithBroadcast(post
 		"post_id":   post.Id,
 		"control":   control,
 		"reasoning": reasoning,
+		"display":   post.GetProp(ReasoningDisplayProp),
+	}, broadcast)
+}
+
+func (p *MMPostStreamService) sendPostStreamingToolRunningEventWithBroadcast(post *model.Post, tool string, broadcast *model.WebsocketBroadcast) {
+	p.mmClient.PublishWebSocketEvent("postupdate", map[string]interface{}{
+		"post_id": post.Id,
+		"control": PostStreamingControlToolRunning,
+		"tool":    tool,
 	}, broadcast)
 }
 
@@ -186,23 +373,37 @@ func (p *MMPostStreamService) StopStreaming(postID string) {
 	p.contextsMutex.Lock()
 	defer p.contextsMutex.Unlock()
 	if streamContext, ok := p.contexts[postID]; ok {
-		streamContext.cancel()
+		streamContext.cancel(context.Canceled)
 	}
-	delete(p.contexts, postID)
+	p.removeContextLocked(postID)
 }
 
 func (p *MMPostStreamService) GetStreamingContext(inCtx context.Context, postID string) (context.Context, error) {
+	if err := p.checkCanStream(postID); err != nil {
+		return nil, err
+	}
+
+	// Another node may be streaming to the post, for example after a client reconnected
+	owner, err := p.acquireOwnership(inCtx, postID)
+	if err != nil {
+		return nil, err
+	}
+
 	p.contextsMutex.Lock()
 	defer p.contextsMutex.Unlock()
 
-	if _, ok := p.contexts[postID]; ok {
-		return nil, ErrAlreadyStreamingToPost
+	if err := p.checkCanStreamLocked(postID); err != nil {
+		if owner != nil {
+			owner.Unlock()
+		}
+		return nil, err
 	}
 
-	ctx, cancel := context.WithCancel(inCtx)
+	ctx, cancel := context.WithCancelCause(inCtx)
 
 	streamingContext := postStreamContext{
 		cancel: cancel,
+		owner:  owner,
 	}
 
 	p.contexts[postID] = streamingContext
@@ -215,24 +416,195 @@ func (p *MMPostStreamService) GetStreamingContext(inCtx context.Context, postID
 func (p *MMPostStreamService) FinishStreaming(postID string) {
 	p.contextsMutex.Lock()
 	defer p.contextsMutex.Unlock()
+	p.removeContextLocked(postID)
+}
+
+func (p *MMPostStreamService) checkCanStream(postID string) error {
+	p.contextsMutex.Lock()
+	defer p.contextsMutex.Unlock()
+	return p.checkCanStreamLocked(postID)
+}
+
+func (p *MMPostStreamService) checkCanStreamLocked(postID string) error {
+	if p.shuttingDown {
+		return ErrShuttingDown
+	}
+	if _, ok := p.contexts[postID]; ok {
+		return ErrAlreadyStreamingToPost
+	}
+	return nil
+}
+
+// acquireOwnership locks the post across the cluster so only one node edits it at a time.
+func (p *MMPostStreamService) acquireOwnership(ctx context.Context, postID string) (*cluster.Mutex, error) {
+	if p.mutexAPI == nil {
+		return nil, nil
+	}
+
+	owner, err := cluster.NewMutex(p.mutexAPI, "ai_stream_"+postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream ownership mutex: %w", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, streamOwnershipWait)
+	defer cancel()
+	if err := owner.LockWithContext(waitCtx); err != nil {
+		return nil, ErrAlreadyStreamingToPost
+	}
+	return owner, nil
+}
+
+// removeContextLocked forgets the streaming context of a post, releases its ownership and
+// signals a pending shutdown once the last stream is done. contextsMutex must be held.
+func (p *MMPostStreamService) removeContextLocked(postID string) {
+	streamContext, ok := p.contexts[postID]
+	if !ok {
+		return
+	}
+	if streamContext.owner != nil {
+		streamContext.owner.Unlock()
+	}
 	delete(p.contexts, postID)
+	if p.drained != nil && len(p.contexts) == 0 {
+		close(p.drained)
+		p.drained = nil
+	}
+}
+
+// Shutdown interrupts the streams in flight and waits until their posts are finalized or ctx
+// is done. Interrupted posts are marked as such and keep any tool calls awaiting approval.
+// New streams are refused once shutdown has started.
+func (p *MMPostStreamService) Shutdown(ctx context.Context) {
+	p.contextsMutex.Lock()
+	p.shuttingDown = true
+	if len(p.contexts) == 0 {
+		p.contextsMutex.Unlock()
+		return
+	}
+	for _, streamContext := range p.contexts {
+		streamContext.cancel(ErrShuttingDown)
+	}
+	if p.drained == nil {
+		p.drained = make(chan struct{})
+	}
+	drained := p.drained
+	inFlight := len(p.contexts)
+	p.contextsMutex.Unlock()
+
+	p.mmClient.LogDebug("Interrupting in-flight streams for shutdown", "streams", inFlight)
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		p.mmClient.LogError("Timed out waiting for streams to finish before shutdown", "error", ctx.Err())
+	}
 }
 
 // StreamToPost streams the result of a TextStreamResult to a post.
 // it will internally handle logging needs and updating the post.
 func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.TextStreamResult, post *model.Post, userLocale string) {
 	broadcast := &model.WebsocketBroadcast{ChannelId: post.ChannelId}
+	p.streamToPost(ctx, stream, post, userLocale, broadcast, false, p.mmClient.UpdatePost)
+}
+
+// streamToPost consumes the stream, sending events to broadcast and saving the post with persist.
+// ephemeral is passed on to the post processors.
+func (p *MMPostStreamService) streamToPost(ctx context.Context, stream *llm.TextStreamResult, post *model.Post, userLocale string, broadcast *model.WebsocketBroadcast, ephemeral bool, persist func(*model.Post) error) {
 	p.sendPostStreamingControlEventWithBroadcast(post, PostStreamingControlStart, broadcast)
 	defer func() {
 		p.sendPostStreamingControlEventWithBroadcast(post, PostStreamingControlEnd, broadcast)
 	}()
+	// Returning early on tool calls or cancellation leaves the producer running, so keep
+	// draining to avoid blocking it and the wrappers that release resources on close.
+	defer func() {
+		go drainStream(stream)
+	}()
 
 	var messageBuilder strings.Builder
 	messageBuilder.Grow(4096) // Pre-allocate for typical response size
 	var reasoningBuffer strings.Builder
 
+	// Hidden reasoning is kept with the response but not sent to clients as it streams
+	reasoningHidden := false
+	post.DelProp(ReasoningDisplayProp)
+	if p.reasoningDisplay != nil {
+		if display := p.reasoningDisplay(post.UserId); display != "" && display != llm.ReasoningDisplaySummary {
+			post.AddProp(ReasoningDisplayProp, display)
+			reasoningHidden = display == llm.ReasoningDisplayHidden
+		}
+	}
+
+	// Text chunks are coalesced so long generations don't produce an update per token.
+	streamingConfig := p.streamingConfig()
+	throttle := newUpdateThrottle(streamingConfig, time.Now())
+	flushTicker := time.NewTicker(throttle.tickInterval())
+	defer flushTicker.Stop()
+	flushPending := func() {
+		if throttle.hasPending(len(post.Message)) {
+			p.sendPostStreamingUpdateEventWithBroadcast(post, post.Message, broadcast)
+			throttle.markFlushed(len(post.Message), time.Now())
+		}
+	}
+	// Updates of the text still being generated stop at safe markdown boundaries for the configured features
+	markdownSafe := slices.Contains(streamingConfig.MarkdownSafeFeatures, featureFromContext(ctx))
+	flushGenerating := func() {
+		if !markdownSafe {
+			flushPending()
+			return
+		}
+		if safeLength := markdownSafeLength(post.Message); throttle.hasNew(safeLength) {
+			p.sendPostStreamingUpdateEventWithBroadcast(post, post.Message[:safeLength], broadcast)
+			throttle.markFlushed(safeLength, time.Now())
+		}
+	}
+
+	// The bot is shown typing while it reasons or runs tools, periodically until new text arrives.
+	// Ephemeral responses are private, so the channel is not told about them.
+	var typingTick <-chan time.Time
+	publishTyping := func() {
+		if appErr := p.typing.PublishUserTyping(post.UserId, post.ChannelId, post.RootId); appErr != nil {
+			p.mmClient.LogDebug("Failed to publish typing event", "post_id", post.Id, "error", appErr.Error())
+		}
+	}
+	if p.typing != nil && !ephemeral {
+		typingTicker := time.NewTicker(typingInterval)
+		defer typingTicker.Stop()
+		typingTick = typingTicker.C
+		publishTyping()
+	}
+	typedAtLength := len(post.Message)
+
+	stopped := func() {
+		flushPending()
+
+		if errors.Is(context.Cause(ctx), ErrShuttingDown) {
+			p.markInterrupted(post, stream, &messageBuilder, userLocale)
+			p.sendPostStreamingUpdateEventWithBroadcast(post, post.Message, broadcast)
+		}
+
+		// Persist any accumulated reasoning before canceling
+		if reasoningBuffer.Len() > 0 {
+			post.AddProp(ReasoningSummaryProp, reasoningBuffer.String())
+			p.mmClient.LogDebug("Saved partial reasoning summary on cancel", "post_id", post.Id, "reasoning_length", reasoningBuffer.Len())
+		}
+
+		if err := persist(post); err != nil {
+			p.mmClient.LogError("Error updating post on stop signaled", "error", err)
+			return
+		}
+		p.sendPostStreamingControlEventWithBroadcast(post, PostStreamingControlCancel, broadcast)
+	}
+
 	for {
 		select {
+		case <-flushTicker.C:
+			if throttle.shouldFlush(len(post.Message), time.Now()) {
+				flushGenerating()
+			}
+		case <-typingTick:
+			if len(post.Message) == typedAtLength {
+				publishTyping()
+			}
+			typedAtLength = len(post.Message)
 		case event := <-stream.Stream:
 			switch event.Type {
 			case llm.EventTypeText:
@@ -240,9 +612,22 @@ func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.Text
 				if textChunk, ok := event.Value.(string); ok {
 					messageBuilder.WriteString(textChunk)
 					post.Message = messageBuilder.String()
-					p.sendPostStreamingUpdateEventWithBroadcast(post, post.Message, broadcast)
+					if throttle.shouldFlush(len(post.Message), time.Now()) {
+						flushGenerating()
+					}
+				}
+			case llm.EventTypeRefusal:
+				// Show the model's explanation of why it declined to answer, the finish event notes the refusal
+				if refusalChunk, ok := event.Value.(string); ok {
+					messageBuilder.WriteString(refusalChunk)
+					post.Message = messageBuilder.String()
+					if throttle.shouldFlush(len(post.Message), time.Now()) {
+						flushGenerating()
+					}
 				}
 			case llm.EventTypeEnd:
+				flushPending()
+
 				// Stream has closed cleanly
 				if strings.TrimSpace(post.Message) == "" {
 					p.mmClient.LogError("LLM closed stream with no result")
@@ -251,6 +636,12 @@ func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.Text
 					p.sendPostStreamingUpdateEventWithBroadcast(post, post.Message, broadcast)
 				}
 
+				messageBefore := post.Message
+				p.processPost(context.WithValue(ctx, userLocaleKey{}, userLocale), post, ephemeral)
+				if post.Message != messageBefore {
+					p.sendPostStreamingUpdateEventWithBroadcast(post, post.Message, broadcast)
+				}
+
 				// Inline citations have already been cleaned in EventTypeAnnotations handler
 				// (if there were any citations, they were cleaned before annotations were sent)
 
@@ -259,12 +650,18 @@ func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.Text
 				if reasoningProp := post.GetProp(ReasoningSummaryProp); reasoningProp != nil {
 					p.mmClient.LogDebug("Persisting post with reasoning summary", "post_id", post.Id)
 				}
-				if err := p.mmClient.UpdatePost(post); err != nil {
+				if err := persist(post); err != nil {
 					p.mmClient.LogError("Streaming failed to update post", "error", err)
 					return
 				}
 				return
 			case llm.EventTypeError:
+				// A provider call canceled along with the stream fails, keep the partial result instead
+				if ctx.Err() != nil {
+					stopped()
+					return
+				}
+
 				// Handle error event
 				var err error
 				if errValue, ok := event.Value.(error); ok {
@@ -281,7 +678,13 @@ func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.Text
 				}
 				p.mmClient.LogError("Streaming result to post failed partway", "error", err)
 				T := i18n.LocalizerFunc(p.i18n, userLocale)
-				post.Message = T("agents.stream_to_post_access_llm_error", "Sorry! An error occurred while accessing the LLM. See server logs for details.")
+				llmErr := llm.ClassifyError(err)
+				post.Message = streamErrorMessage(T, llmErr.Code)
+
+				// Everyone who can read the post sees the error, so the provider detail stays in the logs
+				if errorJSON, marshalErr := json.Marshal(llmErr.WithoutDetail()); marshalErr == nil {
+					post.AddProp(ErrorProp, string(errorJSON))
+				}
 
 				// Persist any accumulated reasoning before erroring out
 				if reasoningBuffer.Len() > 0 {
@@ -289,24 +692,79 @@ func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.Text
 					p.mmClient.LogDebug("Saved partial reasoning summary on error", "post_id", post.Id, "reasoning_length", reasoningBuffer.Len())
 				}
 
-				if err := p.mmClient.UpdatePost(post); err != nil {
+				if err := persist(post); err != nil {
 					p.mmClient.LogError("Error recovering from streaming error", "error", err)
 					return
 				}
 				p.sendPostStreamingUpdateEventWithBroadcast(post, post.Message, broadcast)
 				return
+			case llm.EventTypeStoppedEarly:
+				// The request spent its step budget, keep the partial result and note why it is incomplete
+				if reason, ok := event.Value.(string); ok {
+					p.mmClient.LogDebug("LLM request stopped early", "post_id", post.Id, "reason", reason)
+					post.AddProp(StoppedEarlyProp, reason)
+					T := i18n.LocalizerFunc(p.i18n, userLocale)
+					if strings.TrimSpace(messageBuilder.String()) != "" {
+						messageBuilder.WriteString("\n\n")
+					}
+					messageBuilder.WriteString("_" + T("agents.stream_to_post_stopped_early", "The response was stopped early because it reached its time or token budget.") + "_")
+					post.Message = messageBuilder.String()
+					flushPending()
+				}
+			case llm.EventTypeResponseID:
+				if responseID, ok := event.Value.(string); ok {
+					post.AddProp(ResponseIDProp, responseID)
+				}
+			case llm.EventTypeToolRunning:
+				if tool, ok := event.Value.(string); ok {
+					flushGenerating()
+					p.sendPostStreamingToolRunningEventWithBroadcast(post, tool, broadcast)
+					if typingTick != nil {
+						publishTyping()
+						typedAtLength = len(post.Message)
+					}
+				}
+			case llm.EventTypeContextSufficiency:
+				if sufficiency, ok := event.Value.(llm.ContextSufficiency); ok {
+					post.DelProp(ContextMissingProp)
+					if sufficiency.Sufficient {
+						post.AddProp(ContextSufficiencyProp, "sufficient")
+					} else {
+						post.AddProp(ContextSufficiencyProp, "insufficient")
+						if sufficiency.Missing != "" {
+							post.AddProp(ContextMissingProp, sufficiency.Missing)
+						}
+					}
+				}
+			case llm.EventTypeFinish:
+				// Explain replies that were cut off, filtered or refused instead of letting them end silently
+				if reason, ok := event.Value.(string); ok && llm.IsIncompleteFinish(reason) {
+					p.mmClient.LogDebug("LLM response finished incomplete", "post_id", post.Id, "reason", reason)
+					post.AddProp(FinishReasonProp, reason)
+					T := i18n.LocalizerFunc(p.i18n, userLocale)
+					if strings.TrimSpace(messageBuilder.String()) != "" {
+						messageBuilder.WriteString("\n\n")
+					}
+					messageBuilder.WriteString("_" + finishReasonMessage(T, reason) + "_")
+					post.Message = messageBuilder.String()
+					flushPending()
+				}
 			case llm.EventTypeReasoning:
 				// Handle reasoning summary chunk - accumulate and stream
 				if reasoningChunk, ok := event.Value.(string); ok {
 					reasoningBuffer.WriteString(reasoningChunk)
 					// Send reasoning event with accumulated text so far
-					p.sendPostStreamingReasoningEventWithBroadcast(post, reasoningBuffer.String(), "reasoning_summary", broadcast)
+					if !reasoningHidden {
+						p.sendPostStreamingReasoningEventWithBroadcast(post, reasoningBuffer.String(), "reasoning_summary", broadcast)
+					}
 				}
 			case llm.EventTypeReasoningEnd:
 				// Reasoning summary completed - stream final and persist
 				if reasoningData, ok := event.Value.(llm.ReasoningData); ok {
 					// Send final reasoning event (only text goes to frontend)
-					p.sendPostStreamingReasoningEventWithBroadcast(post, reasoningData.Text, "reasoning_summary_done", broadcast)
+					if !reasoningHidden {
+						p.sendPostStreamingReasoningEventWithBroadcast(post, reasoningData.Text, "reasoning_summary_done", broadcast)
+					}
 
 					// Persist reasoning summary and signature to post props
 					// This will be saved when the post is updated at the end of the stream
@@ -321,24 +779,18 @@ func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.Text
 					reasoningBuffer.Reset()
 				}
 			case llm.EventTypeToolCalls:
+				flushPending()
+
 				// Handle tool call event
 				if toolCalls, ok := event.Value.([]llm.ToolCall); ok {
-					// Ensure all tool calls have Pending status and sanitize arguments
-					for i := range toolCalls {
-						toolCalls[i].Status = llm.ToolCallStatusPending
-						toolCalls[i].SanitizeArguments()
-					}
-
 					// Add the tool call as a prop to the post
-					toolCallJSON, err := json.Marshal(toolCalls)
+					toolCallJSON, err := addPendingToolCalls(post, toolCalls)
 					if err != nil {
 						p.mmClient.LogError("Failed to marshal tool call", "error", err)
-					} else {
-						post.AddProp(ToolCallProp, string(toolCallJSON))
 					}
 
 					// Update the post with the tool call and any reasoning that was previously added
-					if err := p.mmClient.UpdatePost(post); err != nil {
+					if err := persist(post); err != nil {
 						p.mmClient.LogError("Failed to update post with tool call", "error", err)
 					}
 
@@ -351,6 +803,8 @@ func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.Text
 				}
 				return
 			case llm.EventTypeAnnotations:
+				flushPending()
+
 				// Handle annotations - might include cleaned message for web search citations
 				if annotationMap, ok := event.Value.(map[string]interface{}); ok {
 					// Web search annotations with cleaned message
@@ -359,6 +813,7 @@ func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.Text
 							// Replace post message with cleaned version (citation markers removed)
 							post.Message = cleanedMsg
 							p.sendPostStreamingUpdateEventWithBroadcast(post, post.Message, broadcast)
+							throttle.markFlushed(len(post.Message), time.Now())
 							p.mmClient.LogDebug("Replaced post message with cleaned version", "post_id", post.Id, "original_length", len(post.Message), "cleaned_length", len(cleanedMsg))
 						}
 
@@ -384,18 +839,123 @@ func (p *MMPostStreamService) StreamToPost(ctx context.Context, stream *llm.Text
 				}
 			}
 		case <-ctx.Done():
-			// Persist any accumulated reasoning before canceling
-			if reasoningBuffer.Len() > 0 {
-				post.AddProp(ReasoningSummaryProp, reasoningBuffer.String())
-				p.mmClient.LogDebug("Saved partial reasoning summary on cancel", "post_id", post.Id, "reasoning_length", reasoningBuffer.Len())
-			}
+			stopped()
+			return
+		}
+	}
+}
 
-			if err := p.mmClient.UpdatePost(post); err != nil {
-				p.mmClient.LogError("Error updating post on stop signaled", "error", err)
-				return
+// markInterrupted notes on the post that its generation was cut short by a shutdown. Tool calls
+// the provider finishes sending while the stream winds down are kept pending so they can still
+// be approved once the plugin is back.
+// processPost runs the processors of every response, then the ones configured on the bot of the
+// post, on a completed response
+func (p *MMPostStreamService) processPost(ctx context.Context, post *model.Post, ephemeral bool) {
+	for _, processor := range p.processors {
+		processor.ProcessPost(ctx, post, ephemeral)
+	}
+
+	if p.botPostProcessors == nil {
+		return
+	}
+	for _, config := range p.botPostProcessors(post.UserId) {
+		factory, ok := p.botProcessorFactories[config.Type]
+		if !ok {
+			p.mmClient.LogError("Unknown post-processor configured on bot", "bot_id", post.UserId, "type", config.Type)
+			continue
+		}
+		factory(config).ProcessPost(ctx, post, ephemeral)
+	}
+}
+
+func (p *MMPostStreamService) markInterrupted(post *model.Post, stream *llm.TextStreamResult, messageBuilder *strings.Builder, userLocale string) {
+	post.AddProp(InterruptedProp, true)
+
+	if toolCalls := awaitToolCalls(stream, shutdownToolCallWait); len(toolCalls) > 0 {
+		if _, err := addPendingToolCalls(post, toolCalls); err != nil {
+			p.mmClient.LogError("Failed to marshal tool call", "error", err)
+		} else {
+			p.mmClient.LogDebug("Kept pending tool calls of interrupted stream", "post_id", post.Id, "tool_calls", len(toolCalls))
+		}
+	}
+
+	T := i18n.LocalizerFunc(p.i18n, userLocale)
+	if strings.TrimSpace(messageBuilder.String()) != "" {
+		messageBuilder.WriteString("\n\n")
+	}
+	messageBuilder.WriteString("_" + T("agents.stream_to_post_interrupted", "Generation was interrupted because the server is restarting. Regenerate the response to try again.") + "_")
+	post.Message = messageBuilder.String()
+}
+
+// awaitToolCalls reads the stream until it ends or wait elapses and returns any tool calls it delivers.
+func awaitToolCalls(stream *llm.TextStreamResult, wait time.Duration) []llm.ToolCall {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case event, ok := <-stream.Stream:
+			if !ok {
+				return nil
 			}
-			p.sendPostStreamingControlEventWithBroadcast(post, PostStreamingControlCancel, broadcast)
-			return
+			switch event.Type {
+			case llm.EventTypeToolCalls:
+				toolCalls, _ := event.Value.([]llm.ToolCall)
+				return toolCalls
+			case llm.EventTypeEnd, llm.EventTypeError:
+				return nil
+			}
+		case <-timer.C:
+			return nil
 		}
 	}
 }
+
+// addPendingToolCalls marks the tool calls as awaiting approval and stores them on the post.
+func addPendingToolCalls(post *model.Post, toolCalls []llm.ToolCall) (string, error) {
+	// Ensure all tool calls have Pending status and sanitize arguments
+	for i := range toolCalls {
+		toolCalls[i].Status = llm.ToolCallStatusPending
+		toolCalls[i].SanitizeArguments()
+	}
+
+	toolCallJSON, err := json.Marshal(toolCalls)
+	if err != nil {
+		return "", err
+	}
+	post.AddProp(ToolCallProp, string(toolCallJSON))
+	return string(toolCallJSON), nil
+}
+
+// streamErrorMessage returns the reply shown in place of a response that failed with the given error code.
+func streamErrorMessage(T i18n.TranslationFunc, code llm.ErrorCode) string {
+	switch code {
+	case llm.ErrorCodeRateLimited:
+		return T("agents.stream_to_post_error_rate_limited", "Sorry! The LLM is receiving too many requests. Try again in a moment.")
+	case llm.ErrorCodeTimeout:
+		return T("agents.stream_to_post_error_timeout", "Sorry! The LLM took too long to respond. Try again in a moment.")
+	case llm.ErrorCodeProviderAuth:
+		return T("agents.stream_to_post_error_provider_auth", "Sorry! The LLM rejected the credentials of this agent. Ask a system admin to check the agent's service configuration.")
+	case llm.ErrorCodeProviderUnavailable:
+		return T("agents.stream_to_post_error_provider_unavailable", "Sorry! The LLM is currently unavailable. Try again later.")
+	default:
+		return T("agents.stream_to_post_access_llm_error", "Sorry! An error occurred while accessing the LLM. See server logs for details.")
+	}
+}
+
+// finishReasonMessage returns the note shown on a reply that finished for an incomplete reason.
+func finishReasonMessage(T i18n.TranslationFunc, reason string) string {
+	switch reason {
+	case llm.FinishReasonLength:
+		return T("agents.stream_to_post_finish_length", "The response was cut off because it reached the maximum response length.")
+	case llm.FinishReasonContentFilter:
+		return T("agents.stream_to_post_finish_content_filter", "The response was blocked by the provider's content filter.")
+	default:
+		return T("agents.stream_to_post_finish_refusal", "The model declined to answer this request.")
+	}
+}
+
+// drainStream discards any remaining events until the stream is closed.
+func drainStream(stream *llm.TextStreamResult) {
+	for range stream.Stream {
+	}
+}
@@ -0,0 +1,525 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package streaming
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingClient captures the calls made by the streaming service.
+type recordingClient struct {
+	benchmarkClient
+
+	mu               sync.Mutex
+	broadcasts       []*model.WebsocketBroadcast
+	messageUpdates   []string
+	controls         []string
+	updatedPosts     []*model.Post
+	ephemeralUpdates chan *model.Post
+}
+
+func (c *recordingClient) PublishWebSocketEvent(_ string, payload map[string]interface{}, broadcast *model.WebsocketBroadcast) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.broadcasts = append(c.broadcasts, broadcast)
+	if message, ok := payload["next"].(string); ok {
+		c.messageUpdates = append(c.messageUpdates, message)
+	}
+	if control, ok := payload["control"].(string); ok {
+		c.controls = append(c.controls, control)
+	}
+}
+
+// recordingTyping records the typing events published for the bot
+type recordingTyping struct {
+	mu      sync.Mutex
+	parents []string
+}
+
+func (r *recordingTyping) PublishUserTyping(_, _, parentID string) *model.AppError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parents = append(r.parents, parentID)
+	return nil
+}
+
+type staticConfig Config
+
+func (c staticConfig) StreamingConfig() Config {
+	return Config(c)
+}
+
+func (c *recordingClient) UpdatePost(post *model.Post) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updatedPosts = append(c.updatedPosts, post.Clone())
+	return nil
+}
+
+func (c *recordingClient) SendEphemeralPost(_ string, post *model.Post) {
+	post.Id = model.NewId()
+}
+
+func (c *recordingClient) UpdateEphemeralPost(_ string, post *model.Post) {
+	c.ephemeralUpdates <- post.Clone()
+}
+
+// memoryMutexAPI stores cluster mutexes in memory, shared by the services of every simulated node.
+type memoryMutexAPI struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func (m *memoryMutexAPI) KVSetWithOptions(key string, value []byte, options model.PluginKVSetOptions) (bool, *model.AppError) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, exists := m.values[key]
+	if options.Atomic && (exists != (options.OldValue != nil) || !bytes.Equal(current, options.OldValue)) {
+		return false, nil
+	}
+	if value == nil {
+		delete(m.values, key)
+	} else {
+		m.values[key] = value
+	}
+	return true, nil
+}
+
+func (m *memoryMutexAPI) LogError(string, ...any) {}
+
+func TestStreamToEphemeralPost(t *testing.T) {
+	client := &recordingClient{ephemeralUpdates: make(chan *model.Post, 1)}
+	service := NewMMPostStreamService(client, i18n.Init(), nil, nil)
+
+	post := &model.Post{ChannelId: "channelid"}
+	err := service.StreamToEphemeralPost(context.Background(), "botid", "userid", llm.NewStreamFromString("private answer"), post, "rootid")
+	require.NoError(t, err)
+	require.NotEmpty(t, post.Id)
+
+	select {
+	case final := <-client.ephemeralUpdates:
+		assert.Equal(t, "private answer", final.Message)
+		assert.Equal(t, "botid", final.UserId)
+		assert.Equal(t, "rootid", final.GetProp(RespondingToProp))
+	case <-time.After(5 * time.Second):
+		t.Fatal("ephemeral post was never updated")
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	assert.Empty(t, client.updatedPosts, "ephemeral streams must not update persisted posts")
+	require.NotEmpty(t, client.broadcasts)
+	for _, broadcast := range client.broadcasts {
+		assert.Equal(t, "userid", broadcast.UserId)
+		assert.Empty(t, broadcast.ChannelId, "ephemeral stream events must not be sent to the channel")
+	}
+}
+
+func TestStreamToPostExplainsBlockedReplies(t *testing.T) {
+	tests := []struct {
+		name           string
+		events         []llm.TextStreamEvent
+		expectMessage  string
+		expectFinished string
+	}{
+		{
+			name: "refusal",
+			events: []llm.TextStreamEvent{
+				{Type: llm.EventTypeRefusal, Value: "I can't help with that."},
+				{Type: llm.EventTypeFinish, Value: llm.FinishReasonRefusal},
+				{Type: llm.EventTypeEnd},
+			},
+			expectMessage:  "I can't help with that.\n\n_The model declined to answer this request._",
+			expectFinished: llm.FinishReasonRefusal,
+		},
+		{
+			name: "content filter without text",
+			events: []llm.TextStreamEvent{
+				{Type: llm.EventTypeFinish, Value: llm.FinishReasonContentFilter},
+				{Type: llm.EventTypeEnd},
+			},
+			expectMessage:  "_The response was blocked by the provider's content filter._",
+			expectFinished: llm.FinishReasonContentFilter,
+		},
+		{
+			name: "complete reply",
+			events: []llm.TextStreamEvent{
+				{Type: llm.EventTypeText, Value: "All done"},
+				{Type: llm.EventTypeFinish, Value: llm.FinishReasonStop},
+				{Type: llm.EventTypeEnd},
+			},
+			expectMessage: "All done",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &recordingClient{ephemeralUpdates: make(chan *model.Post, 1)}
+			service := NewMMPostStreamService(client, i18n.Init(), nil, nil)
+
+			stream := make(chan llm.TextStreamEvent, len(tc.events))
+			for _, event := range tc.events {
+				stream <- event
+			}
+			close(stream)
+
+			post := &model.Post{ChannelId: "channelid"}
+			err := service.StreamToEphemeralPost(context.Background(), "botid", "userid", &llm.TextStreamResult{Stream: stream}, post, "rootid")
+			require.NoError(t, err)
+
+			select {
+			case final := <-client.ephemeralUpdates:
+				assert.Equal(t, tc.expectMessage, final.Message)
+				if tc.expectFinished != "" {
+					assert.Equal(t, tc.expectFinished, final.GetProp(FinishReasonProp))
+				} else {
+					assert.Nil(t, final.GetProp(FinishReasonProp))
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("ephemeral post was never updated")
+			}
+		})
+	}
+}
+
+func TestShutdownInterruptsStreams(t *testing.T) {
+	client := &recordingClient{ephemeralUpdates: make(chan *model.Post, 1)}
+	service := NewMMPostStreamService(client, i18n.Init(), nil, nil)
+
+	stream := make(chan llm.TextStreamEvent)
+	post := &model.Post{ChannelId: "channelid"}
+	err := service.StreamToEphemeralPost(context.Background(), "botid", "userid", &llm.TextStreamResult{Stream: stream}, post, "rootid")
+	require.NoError(t, err)
+	stream <- llm.TextStreamEvent{Type: llm.EventTypeText, Value: "Partial answer"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	service.Shutdown(ctx)
+	require.NoError(t, ctx.Err(), "shutdown should return once the stream is finalized")
+
+	select {
+	case final := <-client.ephemeralUpdates:
+		assert.Equal(t, "Partial answer\n\n_Generation was interrupted because the server is restarting. Regenerate the response to try again._", final.Message)
+		assert.Equal(t, true, final.GetProp(InterruptedProp))
+	case <-time.After(5 * time.Second):
+		t.Fatal("ephemeral post was never updated")
+	}
+
+	_, err = service.GetStreamingContext(context.Background(), model.NewId())
+	assert.ErrorIs(t, err, ErrShuttingDown)
+	close(stream)
+}
+
+func TestAwaitToolCalls(t *testing.T) {
+	toolCalls := []llm.ToolCall{{ID: "call1", Name: "search"}}
+
+	tests := []struct {
+		name     string
+		events   []llm.TextStreamEvent
+		close    bool
+		expected []llm.ToolCall
+	}{
+		{
+			name: "tool calls after text",
+			events: []llm.TextStreamEvent{
+				{Type: llm.EventTypeText, Value: "Let me check"},
+				{Type: llm.EventTypeToolCalls, Value: toolCalls},
+			},
+			expected: toolCalls,
+		},
+		{
+			name: "stream ends without tool calls",
+			events: []llm.TextStreamEvent{
+				{Type: llm.EventTypeEnd},
+				{Type: llm.EventTypeToolCalls, Value: toolCalls},
+			},
+		},
+		{
+			name:   "stream closed",
+			events: []llm.TextStreamEvent{{Type: llm.EventTypeText, Value: "partial"}},
+			close:  true,
+		},
+		{
+			name:   "wait elapses",
+			events: []llm.TextStreamEvent{{Type: llm.EventTypeText, Value: "partial"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := make(chan llm.TextStreamEvent, len(tc.events))
+			for _, event := range tc.events {
+				stream <- event
+			}
+			if tc.close {
+				close(stream)
+			}
+
+			assert.Equal(t, tc.expected, awaitToolCalls(&llm.TextStreamResult{Stream: stream}, 50*time.Millisecond))
+		})
+	}
+}
+
+func TestStreamingOwnershipAcrossNodes(t *testing.T) {
+	mutexAPI := &memoryMutexAPI{values: make(map[string][]byte)}
+	node1 := NewMMPostStreamService(&recordingClient{}, i18n.Init(), nil, mutexAPI)
+	node2 := NewMMPostStreamService(&recordingClient{}, i18n.Init(), nil, mutexAPI)
+	postID := model.NewId()
+
+	_, err := node1.GetStreamingContext(context.Background(), postID)
+	require.NoError(t, err)
+
+	_, err = node2.GetStreamingContext(context.Background(), postID)
+	assert.ErrorIs(t, err, ErrAlreadyStreamingToPost, "only one node may stream to a post")
+
+	_, err = node2.GetStreamingContext(context.Background(), model.NewId())
+	assert.NoError(t, err, "other posts are not affected")
+
+	node1.FinishStreaming(postID)
+	_, err = node2.GetStreamingContext(context.Background(), postID)
+	assert.NoError(t, err, "the post can be streamed once the owner is done")
+}
+
+// suffixProcessor appends to completed responses and records whether they were ephemeral.
+type suffixProcessor struct {
+	suffix    string
+	ephemeral []bool
+	locales   []string
+}
+
+func (p *suffixProcessor) ProcessPost(ctx context.Context, post *model.Post, ephemeral bool) {
+	post.Message += p.suffix
+	p.ephemeral = append(p.ephemeral, ephemeral)
+	p.locales = append(p.locales, UserLocale(ctx))
+}
+
+func TestPostProcessors(t *testing.T) {
+	tests := []struct {
+		name            string
+		events          []llm.TextStreamEvent
+		expectMessage   string
+		expectProcessed bool
+	}{
+		{
+			name: "processors run in order on completed responses",
+			events: []llm.TextStreamEvent{
+				{Type: llm.EventTypeText, Value: "answer"},
+				{Type: llm.EventTypeEnd},
+			},
+			expectMessage:   "answer [first] [second]",
+			expectProcessed: true,
+		},
+		{
+			name: "failed responses are not processed",
+			events: []llm.TextStreamEvent{
+				{Type: llm.EventTypeText, Value: "partial"},
+				{Type: llm.EventTypeError, Value: errors.New("provider failed")},
+			},
+			expectMessage: "Sorry! An error occurred while accessing the LLM. See server logs for details.",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &recordingClient{}
+			service := NewMMPostStreamService(client, i18n.Init(), nil, nil)
+			first := &suffixProcessor{suffix: " [first]"}
+			second := &suffixProcessor{suffix: " [second]"}
+			service.AddPostProcessor(first)
+			service.AddPostProcessor(second)
+
+			stream := make(chan llm.TextStreamEvent, len(tc.events))
+			for _, event := range tc.events {
+				stream <- event
+			}
+			close(stream)
+
+			post := &model.Post{Id: model.NewId(), ChannelId: "channelid"}
+			service.StreamToPost(context.Background(), &llm.TextStreamResult{Stream: stream}, post, "en")
+
+			assert.Equal(t, tc.expectMessage, post.Message)
+			if tc.expectProcessed {
+				assert.Equal(t, []bool{false}, first.ephemeral)
+				assert.Equal(t, []bool{false}, second.ephemeral)
+				assert.Equal(t, []string{"en"}, second.locales)
+			} else {
+				assert.Empty(t, first.ephemeral)
+			}
+		})
+	}
+}
+
+func TestBotPostProcessors(t *testing.T) {
+	client := &recordingClient{}
+	service := NewMMPostStreamService(client, i18n.Init(), nil, nil)
+	service.AddPostProcessor(&suffixProcessor{suffix: " [all]"})
+	service.RegisterBotPostProcessor("suffix", func(config llm.PostProcessorConfig) PostProcessor {
+		return &suffixProcessor{suffix: " " + config.Text}
+	})
+	service.SetBotPostProcessors(func(botID string) []llm.PostProcessorConfig {
+		if botID != "botid" {
+			return nil
+		}
+		return []llm.PostProcessorConfig{
+			{Type: "suffix", Text: "[bot]"},
+			{Type: "unknown"},
+		}
+	})
+
+	for botID, expectMessage := range map[string]string{
+		"botid":    "answer [all] [bot]",
+		"otherbot": "answer [all]",
+	} {
+		stream := make(chan llm.TextStreamEvent, 2)
+		stream <- llm.TextStreamEvent{Type: llm.EventTypeText, Value: "answer"}
+		stream <- llm.TextStreamEvent{Type: llm.EventTypeEnd}
+		close(stream)
+
+		post := &model.Post{Id: model.NewId(), ChannelId: "channelid", UserId: botID}
+		service.StreamToPost(context.Background(), &llm.TextStreamResult{Stream: stream}, post, "en")
+		assert.Equal(t, expectMessage, post.Message)
+	}
+}
+
+func TestStreamToPostMarkdownSafeUpdates(t *testing.T) {
+	chunks := []string{"Intro\n", "```go\nfmt", ".Println()\n", "```\n", "| a | b |\n", "|---|---|\n| 1 | 2 |\nDone"}
+	events := make([]llm.TextStreamEvent, 0, len(chunks)+1)
+	for _, chunk := range chunks {
+		events = append(events, llm.TextStreamEvent{Type: llm.EventTypeText, Value: chunk})
+	}
+	events = append(events, llm.TextStreamEvent{Type: llm.EventTypeEnd})
+
+	stream := func() *llm.TextStreamResult {
+		output := make(chan llm.TextStreamEvent, len(events))
+		for _, event := range events {
+			output <- event
+		}
+		close(output)
+		return &llm.TextStreamResult{Stream: output}
+	}
+	config := staticConfig{FlushIntervalMS: -1, MarkdownSafeFeatures: []string{"channel_analysis"}}
+
+	t.Run("updates of the configured features stop at safe boundaries", func(t *testing.T) {
+		client := &recordingClient{}
+		service := NewMMPostStreamService(client, i18n.Init(), config, nil)
+		post := &model.Post{Id: "postid", ChannelId: "channelid"}
+		service.StreamToPost(WithFeature(context.Background(), "channel_analysis"), stream(), post, "")
+
+		require.NotEmpty(t, client.messageUpdates)
+		for _, update := range client.messageUpdates {
+			assert.Equal(t, 0, strings.Count(update, "```")%2, "half written code block sent: %q", update)
+			if strings.Contains(update, "| a | b |") {
+				assert.Contains(t, update, "|---|---|")
+			}
+		}
+		assert.Equal(t, "Intro\n```go\nfmt.Println()\n```\n| a | b |\n|---|---|\n| 1 | 2 |\nDone", client.messageUpdates[len(client.messageUpdates)-1])
+	})
+
+	t.Run("the feature of new posts is kept after the request", func(t *testing.T) {
+		client := &recordingClient{}
+		service := NewMMPostStreamService(client, i18n.Init(), config, nil)
+		ctx, cancel := context.WithCancel(WithFeature(context.Background(), "channel_analysis"))
+		err := service.StreamToNewPost(ctx, "botid", "userid", stream(), &model.Post{ChannelId: "channelid"}, "")
+		require.NoError(t, err)
+		cancel()
+
+		require.Eventually(t, func() bool {
+			client.mu.Lock()
+			defer client.mu.Unlock()
+			return len(client.updatedPosts) > 0
+		}, 5*time.Second, 10*time.Millisecond)
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		assert.NotContains(t, client.messageUpdates, "Intro\n```go\nfmt")
+	})
+
+	t.Run("other features get every chunk", func(t *testing.T) {
+		client := &recordingClient{}
+		service := NewMMPostStreamService(client, i18n.Init(), config, nil)
+		post := &model.Post{Id: "postid", ChannelId: "channelid"}
+		service.StreamToPost(WithFeature(context.Background(), "mention"), stream(), post, "")
+
+		assert.Contains(t, client.messageUpdates, "Intro\n```go\nfmt")
+	})
+}
+
+func TestStreamToPostShowsTheBotWorking(t *testing.T) {
+	client := &recordingClient{}
+	typing := &recordingTyping{}
+	service := NewMMPostStreamService(client, i18n.Init(), nil, nil)
+	service.SetTypingPublisher(typing)
+
+	stream := make(chan llm.TextStreamEvent, 3)
+	stream <- llm.TextStreamEvent{Type: llm.EventTypeToolRunning, Value: "read_channel"}
+	stream <- llm.TextStreamEvent{Type: llm.EventTypeText, Value: "Done"}
+	stream <- llm.TextStreamEvent{Type: llm.EventTypeEnd}
+	close(stream)
+
+	post := &model.Post{Id: "postid", ChannelId: "channelid", RootId: "rootid", UserId: "botid"}
+	service.StreamToPost(context.Background(), &llm.TextStreamResult{Stream: stream}, post, "")
+
+	typing.mu.Lock()
+	assert.Equal(t, []string{"rootid", "rootid"}, typing.parents, "typing is shown when streaming starts and when a tool runs")
+	typing.mu.Unlock()
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	assert.Equal(t, "Done", post.Message)
+	assert.Contains(t, client.controls, PostStreamingControlToolRunning)
+}
+
+func TestStreamToPostReasoningDisplay(t *testing.T) {
+	tests := []struct {
+		display           string
+		expectedProp      any
+		expectedReasoning bool
+	}{
+		{display: "", expectedProp: nil, expectedReasoning: true},
+		{display: llm.ReasoningDisplayFull, expectedProp: llm.ReasoningDisplayFull, expectedReasoning: true},
+		{display: llm.ReasoningDisplayHidden, expectedProp: llm.ReasoningDisplayHidden, expectedReasoning: false},
+	}
+
+	for _, test := range tests {
+		t.Run("display "+test.display, func(t *testing.T) {
+			client := &recordingClient{}
+			service := NewMMPostStreamService(client, i18n.Init(), nil, nil)
+			service.SetReasoningDisplay(func(botID string) string {
+				assert.Equal(t, "botid", botID)
+				return test.display
+			})
+
+			stream := make(chan llm.TextStreamEvent, 4)
+			stream <- llm.TextStreamEvent{Type: llm.EventTypeReasoning, Value: "Thinking"}
+			stream <- llm.TextStreamEvent{Type: llm.EventTypeReasoningEnd, Value: llm.ReasoningData{Text: "Thinking", Signature: "signature"}}
+			stream <- llm.TextStreamEvent{Type: llm.EventTypeText, Value: "Done"}
+			stream <- llm.TextStreamEvent{Type: llm.EventTypeEnd}
+			close(stream)
+
+			post := &model.Post{Id: "postid", ChannelId: "channelid", UserId: "botid"}
+			service.StreamToPost(context.Background(), &llm.TextStreamResult{Stream: stream}, post, "")
+
+			assert.Equal(t, test.expectedProp, post.GetProp(ReasoningDisplayProp))
+			// The reasoning is kept for tool calls whether or not it is shown
+			assert.Equal(t, "Thinking", post.GetProp(ReasoningSummaryProp))
+			assert.Equal(t, "signature", post.GetProp(ReasoningSignatureProp))
+
+			client.mu.Lock()
+			defer client.mu.Unlock()
+			assert.Equal(t, test.expectedReasoning, slices.Contains(client.controls, "reasoning_summary_done"))
+		})
+	}
+}
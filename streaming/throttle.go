@@ -0,0 +1,101 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package streaming
+
+import "time"
+
+const (
+	defaultFlushIntervalMS = 100
+	defaultMinDeltaChars   = 1
+
+	// maxFlushDelay bounds how long buffered text can wait for MinDeltaChars
+	// to be reached before it is flushed anyway, so trailing text still shows
+	// up when the model pauses (for example while deciding on a tool call).
+	maxFlushDelay = time.Second
+)
+
+// Config controls how streamed text is batched before it is pushed to clients.
+// Every flush results in a websocket event that the server relays to all
+// cluster nodes, so coalescing chunks here bounds cluster-wide traffic
+// regardless of how fast the upstream model produces tokens.
+type Config struct {
+	// FlushIntervalMS is the minimum time between two post updates.
+	// Zero uses the default, a negative value sends an update for every chunk.
+	FlushIntervalMS int `json:"flushIntervalMs"`
+	// MinDeltaChars is the minimum number of new characters required before an update is sent.
+	MinDeltaChars int `json:"minDeltaChars"`
+	// MarkdownSafeFeatures are the features, such as "channel_analysis", whose updates only
+	// include the markdown completed so far, so clients don't render half written code blocks
+	// or tables. The completed response is always sent in full.
+	MarkdownSafeFeatures []string `json:"markdownSafeFeatures"`
+}
+
+// ConfigProvider gives access to the current streaming configuration.
+type ConfigProvider interface {
+	StreamingConfig() Config
+}
+
+// updateThrottle decides when buffered text should be flushed to clients.
+type updateThrottle struct {
+	interval  time.Duration
+	minDelta  int
+	lastFlush time.Time
+	flushedAt int
+}
+
+func newUpdateThrottle(cfg Config, now time.Time) *updateThrottle {
+	if cfg.FlushIntervalMS < 0 {
+		cfg.FlushIntervalMS = 0
+	} else if cfg.FlushIntervalMS == 0 {
+		cfg.FlushIntervalMS = defaultFlushIntervalMS
+	}
+	if cfg.MinDeltaChars <= 0 {
+		cfg.MinDeltaChars = defaultMinDeltaChars
+	}
+
+	return &updateThrottle{
+		interval:  time.Duration(cfg.FlushIntervalMS) * time.Millisecond,
+		minDelta:  cfg.MinDeltaChars,
+		lastFlush: now,
+	}
+}
+
+// shouldFlush reports whether a message of the given total length should be sent now.
+func (t *updateThrottle) shouldFlush(messageLen int, now time.Time) bool {
+	pending := messageLen - t.flushedAt
+	if pending <= 0 {
+		return false
+	}
+
+	elapsed := now.Sub(t.lastFlush)
+	if elapsed < t.interval {
+		return false
+	}
+
+	return pending >= t.minDelta || elapsed >= maxFlushDelay
+}
+
+// hasPending reports whether there is buffered text that has not been sent yet.
+func (t *updateThrottle) hasPending(messageLen int) bool {
+	return messageLen != t.flushedAt
+}
+
+// hasNew reports whether the message has grown past what has been sent.
+func (t *updateThrottle) hasNew(messageLen int) bool {
+	return messageLen > t.flushedAt
+}
+
+// markFlushed records that the message up to messageLen has been sent.
+func (t *updateThrottle) markFlushed(messageLen int, now time.Time) {
+	t.flushedAt = messageLen
+	t.lastFlush = now
+}
+
+// tickInterval returns how often the stream loop should check for stale buffered text.
+func (t *updateThrottle) tickInterval() time.Duration {
+	if t.interval <= 0 {
+		return maxFlushDelay
+	}
+	return t.interval
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package streaming
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateThrottleShouldFlush(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name       string
+		cfg        Config
+		flushedAt  int
+		messageLen int
+		elapsed    time.Duration
+		expected   bool
+	}{
+		{
+			name:       "defaults flush after interval",
+			cfg:        Config{},
+			messageLen: 5,
+			elapsed:    150 * time.Millisecond,
+			expected:   true,
+		},
+		{
+			name:       "defaults hold within interval",
+			cfg:        Config{},
+			messageLen: 5,
+			elapsed:    50 * time.Millisecond,
+			expected:   false,
+		},
+		{
+			name:       "negative interval flushes every chunk",
+			cfg:        Config{FlushIntervalMS: -1},
+			messageLen: 1,
+			elapsed:    0,
+			expected:   true,
+		},
+		{
+			name:       "nothing pending",
+			cfg:        Config{FlushIntervalMS: -1},
+			flushedAt:  10,
+			messageLen: 10,
+			elapsed:    time.Hour,
+			expected:   false,
+		},
+		{
+			name:       "below min delta holds",
+			cfg:        Config{FlushIntervalMS: 100, MinDeltaChars: 50},
+			flushedAt:  10,
+			messageLen: 30,
+			elapsed:    200 * time.Millisecond,
+			expected:   false,
+		},
+		{
+			name:       "min delta reached flushes",
+			cfg:        Config{FlushIntervalMS: 100, MinDeltaChars: 50},
+			flushedAt:  10,
+			messageLen: 60,
+			elapsed:    200 * time.Millisecond,
+			expected:   true,
+		},
+		{
+			name:       "stale text flushes below min delta",
+			cfg:        Config{FlushIntervalMS: 100, MinDeltaChars: 50},
+			flushedAt:  10,
+			messageLen: 11,
+			elapsed:    maxFlushDelay,
+			expected:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			throttle := newUpdateThrottle(tc.cfg, start)
+			throttle.markFlushed(tc.flushedAt, start)
+			assert.Equal(t, tc.expected, throttle.shouldFlush(tc.messageLen, start.Add(tc.elapsed)))
+		})
+	}
+}
@@ -108,6 +108,60 @@ func (s *Subtitles) IsEmpty() bool {
 	return s.storage.IsEmpty()
 }
 
+// Texts returns the text of each cue, in order
+func (s *Subtitles) Texts() []string {
+	texts := make([]string, 0, len(s.storage.Items))
+	for _, item := range s.storage.Items {
+		texts = append(texts, item.String())
+	}
+	return texts
+}
+
+// WithTexts returns a copy of the subtitles with the text of each cue replaced by the text at
+// the same index, keeping the timing of the cues.
+func (s *Subtitles) WithTexts(texts []string) (*Subtitles, error) {
+	if len(texts) != len(s.storage.Items) {
+		return nil, fmt.Errorf("got %d texts for %d cues", len(texts), len(s.storage.Items))
+	}
+
+	storage := astisub.NewSubtitles()
+	for i, item := range s.storage.Items {
+		storage.Items = append(storage.Items, &astisub.Item{
+			StartAt: item.StartAt,
+			EndAt:   item.EndAt,
+			Lines:   textLines(texts[i]),
+		})
+	}
+	return &Subtitles{storage: storage}, nil
+}
+
+// NewBilingualSubtitles combines subtitles with their translation, each cue showing the original
+// text followed by its translation.
+func NewBilingualSubtitles(original, translation *Subtitles) (*Subtitles, error) {
+	if len(original.storage.Items) != len(translation.storage.Items) {
+		return nil, fmt.Errorf("the translation has %d cues instead of %d", len(translation.storage.Items), len(original.storage.Items))
+	}
+
+	storage := astisub.NewSubtitles()
+	for i, item := range original.storage.Items {
+		lines := append(append([]astisub.Line(nil), item.Lines...), translation.storage.Items[i].Lines...)
+		storage.Items = append(storage.Items, &astisub.Item{
+			StartAt: item.StartAt,
+			EndAt:   item.EndAt,
+			Lines:   lines,
+		})
+	}
+	return &Subtitles{storage: storage}, nil
+}
+
+func textLines(text string) []astisub.Line {
+	var lines []astisub.Line
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		lines = append(lines, astisub.Line{Items: []astisub.LineItem{{Text: strings.TrimSpace(line)}}})
+	}
+	return lines
+}
+
 func formatDurationForLLM(dur time.Duration) string {
 	dur = dur.Round(time.Second)
 	hours := dur / time.Hour
@@ -53,3 +53,29 @@ func TestFormatTextOnly(t *testing.T) {
 
 	require.Equal(t, expectedFormatTextOnly, subtitles.FormatTextOnly())
 }
+
+func TestWithTexts(t *testing.T) {
+	subtitles, err := NewSubtitlesFromVTT(strings.NewReader(testSubtitles))
+	require.NoError(t, err)
+
+	texts := subtitles.Texts()
+	require.Len(t, texts, 4)
+	require.Equal(t, "simultaneously go back and just, you know, solicit that feedback in case there's some", texts[1])
+
+	_, err = subtitles.WithTexts(texts[:3])
+	require.Error(t, err)
+
+	translated, err := subtitles.WithTexts([]string{"Un", "Deux", "Trois", "Quatre\nCinq"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Un", "Deux", "Trois", "Quatre - Cinq"}, translated.Texts())
+	require.Contains(t, translated.FormatVTT(), "00:00:06.320 --> 00:00:09.840\nDeux\n")
+
+	bilingual, err := NewBilingualSubtitles(subtitles, translated)
+	require.NoError(t, err)
+	require.Contains(t, bilingual.FormatVTT(), "00:00:06.320 --> 00:00:09.840\nsimultaneously go back and just, you know, solicit that feedback in case there's some\nDeux\n")
+
+	empty, err := NewSubtitlesFromVTT(strings.NewReader("WEBVTT\n"))
+	require.NoError(t, err)
+	_, err = NewBilingualSubtitles(subtitles, empty)
+	require.Error(t, err)
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package summaryexports summarizes a set of channels over a period for compliance and reporting.
+//
+// Exports run as background jobs, summarizing one channel after the other. The summaries are
+// stored in the plugin KV store with the status of each channel, and downloaded as a zip archive
+// with a Markdown file per channel and a CSV file of all of them.
+package summaryexports
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
+)
+
+const (
+	// JobType is the type of the jobs running exports
+	JobType = "summary_export"
+	// KeyPrefix is the prefix of the KV keys of the exports, followed by the ID of their job
+	KeyPrefix = "summary_export_"
+	// MaxChannels is the largest number of channels summarized by an export
+	MaxChannels = 100
+)
+
+// Statuses of the channels of an export
+const (
+	ChannelPending   = "pending"
+	ChannelRunning   = "running"
+	ChannelCompleted = "completed"
+	ChannelFailed    = "failed"
+	ChannelSkipped   = "skipped"
+)
+
+var (
+	// ErrNotFound is returned when no export exists for a job
+	ErrNotFound = errors.New("export not found")
+	// ErrChannelSkipped is returned by a SummarizeFunc for the channels the bot may not read
+	ErrChannelSkipped = errors.New("channel skipped")
+)
+
+// ChannelSummary is the summary of a channel of an export
+type ChannelSummary struct {
+	ChannelID   string `json:"channel_id"`
+	TeamName    string `json:"team_name"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Status      string `json:"status"`
+	Summary     string `json:"summary,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Export is the state of the summaries of a set of channels over a period
+type Export struct {
+	JobID       string `json:"job_id"`
+	BotUsername string `json:"bot_username"`
+	// StartTime and EndTime are the period summarized, in milliseconds
+	StartTime int64            `json:"start_time"`
+	EndTime   int64            `json:"end_time"`
+	Channels  []ChannelSummary `json:"channels"`
+}
+
+// SummarizeFunc summarizes the posts of the channel during the period of the export
+type SummarizeFunc func(ctx context.Context, channelID string) (string, error)
+
+// KVStore is the subset of the Mattermost client used to persist exports
+type KVStore interface {
+	KVGet(key string, value interface{}) error
+	KVSet(key string, value interface{}) error
+}
+
+// Service starts exports and builds their archives
+type Service struct {
+	jobs *jobs.Service
+	kv   KVStore
+}
+
+// New creates the export service running exports with the job service
+func New(jobsService *jobs.Service, kv KVStore) *Service {
+	return &Service{
+		jobs: jobsService,
+		kv:   kv,
+	}
+}
+
+func exportKey(jobID string) string {
+	return KeyPrefix + jobID
+}
+
+// Start summarizes the channels of the export in the background, one after the other. The
+// failure of a channel is recorded in its status without stopping the export.
+func (s *Service) Start(userID string, export Export, summarize SummarizeFunc) (*jobs.Job, error) {
+	if len(export.Channels) == 0 {
+		return nil, errors.New("at least one channel is required")
+	}
+	if len(export.Channels) > MaxChannels {
+		return nil, fmt.Errorf("at most %d channels can be summarized at once", MaxChannels)
+	}
+	for i := range export.Channels {
+		export.Channels[i].Status = ChannelPending
+	}
+
+	// The export is saved once the job has an ID, before the job runs
+	saved := make(chan struct{})
+	job := &jobs.Job{
+		Type:   JobType,
+		UserID: userID,
+	}
+	job, err := s.jobs.Start(job, func(ctx context.Context, progress jobs.ProgressFunc) error {
+		<-saved
+		return s.run(ctx, &export, summarize, progress)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	export.JobID = job.ID
+	err = s.kv.KVSet(exportKey(job.ID), export)
+	close(saved)
+	if err != nil {
+		_, _ = s.jobs.Cancel(job.ID)
+		return nil, fmt.Errorf("failed to save export: %w", err)
+	}
+
+	return job, nil
+}
+
+func (s *Service) run(ctx context.Context, export *Export, summarize SummarizeFunc, progress jobs.ProgressFunc) error {
+	update := func(index int, modify func(summary *ChannelSummary)) error {
+		modify(&export.Channels[index])
+		if err := s.kv.KVSet(exportKey(export.JobID), export); err != nil {
+			return fmt.Errorf("failed to save export: %w", err)
+		}
+		return nil
+	}
+
+	for i := range export.Channels {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		progress(fmt.Sprintf("Summarizing channel %d of %d", i+1, len(export.Channels)))
+		if err := update(i, func(summary *ChannelSummary) { summary.Status = ChannelRunning }); err != nil {
+			return err
+		}
+
+		text, summarizeErr := summarize(ctx, export.Channels[i].ChannelID)
+		if err := update(i, func(summary *ChannelSummary) {
+			switch {
+			case errors.Is(summarizeErr, ErrChannelSkipped):
+				summary.Status = ChannelSkipped
+				summary.Error = summarizeErr.Error()
+			case summarizeErr != nil:
+				summary.Status = ChannelFailed
+				summary.Error = summarizeErr.Error()
+			default:
+				summary.Status = ChannelCompleted
+				summary.Summary = text
+			}
+		}); err != nil {
+			return err
+		}
+	}
+
+	progress(fmt.Sprintf("Summarized %d channels", len(export.Channels)))
+	return nil
+}
+
+// Get returns the export run by the job
+func (s *Service) Get(jobID string) (*Export, error) {
+	var export Export
+	if err := s.kv.KVGet(exportKey(jobID), &export); err != nil {
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+	if export.JobID == "" {
+		return nil, ErrNotFound
+	}
+
+	return &export, nil
+}
+
+var unsafeFileNameCharacters = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// Archive builds a zip archive of the summaries of the export: summaries.csv listing every
+// channel, and a Markdown file per channel that was summarized.
+func Archive(export *Export) ([]byte, error) {
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	csvFile, err := archive.Create("summaries.csv")
+	if err != nil {
+		return nil, err
+	}
+	csvWriter := csv.NewWriter(csvFile)
+	records := [][]string{{"team", "channel", "channel_id", "start", "end", "status", "summary", "error"}}
+	for _, summary := range export.Channels {
+		records = append(records, []string{
+			summary.TeamName,
+			summary.DisplayName,
+			summary.ChannelID,
+			formatTime(export.StartTime),
+			formatTime(export.EndTime),
+			summary.Status,
+			summary.Summary,
+			summary.Error,
+		})
+	}
+	if err := csvWriter.WriteAll(records); err != nil {
+		return nil, err
+	}
+
+	for i, summary := range export.Channels {
+		if summary.Status != ChannelCompleted {
+			continue
+		}
+
+		name := unsafeFileNameCharacters.ReplaceAllString(strings.Join([]string{summary.TeamName, summary.Name}, "-"), "_")
+		file, err := archive.Create(fmt.Sprintf("%03d-%s.md", i+1, name))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fmt.Fprintf(file, "# %s\n\n%s to %s\n\n%s\n", summary.DisplayName, formatTime(export.StartTime), formatTime(export.EndTime), summary.Summary); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func formatTime(millis int64) string {
+	return time.UnixMilli(millis).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package summaryexports
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/jobs"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mmapitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	kv := mmapitest.NewMemoryKV()
+	jobsService := jobs.New(kv)
+	service := New(jobsService, kv)
+
+	job, err := service.Start("admin", Export{
+		StartTime: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC).UnixMilli(),
+		EndTime:   time.Date(2026, 5, 8, 0, 0, 0, 0, time.UTC).UnixMilli(),
+		Channels: []ChannelSummary{
+			{ChannelID: "releases", TeamName: "eng", Name: "releases", DisplayName: "Releases"},
+			{ChannelID: "secret", TeamName: "eng", Name: "secret", DisplayName: "Secret"},
+			{ChannelID: "broken", TeamName: "eng", Name: "broken", DisplayName: "Broken"},
+		},
+	}, func(_ context.Context, channelID string) (string, error) {
+		switch channelID {
+		case "secret":
+			return "", ErrChannelSkipped
+		case "broken":
+			return "", errors.New("provider unavailable")
+		}
+		return "The release shipped, with a \"hotfix\".", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, JobType, job.Type)
+
+	require.Eventually(t, func() bool {
+		job, err = jobsService.Get(job.ID)
+		require.NoError(t, err)
+		return job.IsFinished()
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, jobs.StatusCompleted, job.Status)
+
+	export, err := service.Get(job.ID)
+	require.NoError(t, err)
+	require.Len(t, export.Channels, 3)
+	assert.Equal(t, ChannelCompleted, export.Channels[0].Status)
+	assert.Equal(t, ChannelSkipped, export.Channels[1].Status)
+	assert.Equal(t, ChannelFailed, export.Channels[2].Status)
+	assert.Equal(t, "provider unavailable", export.Channels[2].Error)
+
+	archive, err := Archive(export)
+	require.NoError(t, err)
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, file := range reader.File {
+		opened, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(opened)
+		require.NoError(t, err)
+		files[file.Name] = string(content)
+	}
+	require.Len(t, files, 2, "only the summarized channels have a Markdown file")
+	assert.Contains(t, files["001-eng-releases.md"], "# Releases\n\n2026-05-01T00:00:00Z to 2026-05-08T00:00:00Z")
+
+	records, err := csv.NewReader(bytes.NewReader([]byte(files["summaries.csv"]))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, []string{"eng", "Releases", "releases", "2026-05-01T00:00:00Z", "2026-05-08T00:00:00Z", ChannelCompleted, "The release shipped, with a \"hotfix\".", ""}, records[1])
+	assert.Equal(t, ChannelSkipped, records[2][5])
+}
+
+func TestExportLimits(t *testing.T) {
+	kv := mmapitest.NewMemoryKV()
+	service := New(jobs.New(kv), kv)
+	summarize := func(context.Context, string) (string, error) { return "", nil }
+
+	_, err := service.Start("admin", Export{}, summarize)
+	assert.Error(t, err)
+
+	_, err = service.Start("admin", Export{Channels: make([]ChannelSummary, MaxChannels+1)}, summarize)
+	assert.Error(t, err)
+
+	_, err = service.Get("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package teaminstructions stores team-scoped instructions that are appended to bot system prompts,
+// letting team admins describe their terminology, product names or compliance phrasing.
+package teaminstructions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// MaxLength limits the size of team instructions since they are added to every request in the team.
+const MaxLength = 4000
+
+const kvKeyPrefix = "team_instructions_"
+
+// ErrTooLong is returned when saving instructions longer than MaxLength.
+var ErrTooLong = fmt.Errorf("team instructions cannot be longer than %d characters", MaxLength)
+
+// KVStore is the storage needed by the store.
+type KVStore interface {
+	KVGet(key string, value interface{}) error
+	KVSet(key string, value interface{}) error
+	KVDelete(key string) error
+	LogError(msg string, keyValuePairs ...interface{})
+}
+
+// Instructions are the instructions configured for a team.
+type Instructions struct {
+	TeamID       string `json:"team_id"`
+	Instructions string `json:"instructions"`
+	UpdateAt     int64  `json:"update_at"`
+	UpdatedBy    string `json:"updated_by"`
+}
+
+// Store persists team instructions in the plugin KV store.
+type Store struct {
+	kv KVStore
+}
+
+// New creates a new team instructions store.
+func New(kv KVStore) *Store {
+	return &Store{
+		kv: kv,
+	}
+}
+
+func kvKey(teamID string) string {
+	return kvKeyPrefix + teamID
+}
+
+// Get returns the instructions for the team, or nil if none are set.
+func (s *Store) Get(teamID string) (*Instructions, error) {
+	var instructions *Instructions
+	if err := s.kv.KVGet(kvKey(teamID), &instructions); err != nil {
+		return nil, fmt.Errorf("failed to get team instructions: %w", err)
+	}
+	return instructions, nil
+}
+
+// Save sets the instructions for the team. Empty instructions remove them.
+func (s *Store) Save(teamID, text, userID string) (*Instructions, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, s.Delete(teamID)
+	}
+	if len([]rune(text)) > MaxLength {
+		return nil, ErrTooLong
+	}
+
+	instructions := &Instructions{
+		TeamID:       teamID,
+		Instructions: text,
+		UpdateAt:     model.GetMillis(),
+		UpdatedBy:    userID,
+	}
+	if err := s.kv.KVSet(kvKey(teamID), instructions); err != nil {
+		return nil, fmt.Errorf("failed to save team instructions: %w", err)
+	}
+
+	return instructions, nil
+}
+
+// Delete removes the instructions for the team.
+func (s *Store) Delete(teamID string) error {
+	if err := s.kv.KVDelete(kvKey(teamID)); err != nil {
+		return fmt.Errorf("failed to delete team instructions: %w", err)
+	}
+	return nil
+}
+
+// GetTeamInstructions returns the instruction text for the team for use in prompts.
+// Errors are logged and result in no instructions being added.
+func (s *Store) GetTeamInstructions(teamID string) string {
+	if teamID == "" {
+		return ""
+	}
+
+	instructions, err := s.Get(teamID)
+	if err != nil {
+		s.kv.LogError("Failed to load team instructions", "team_id", teamID, "error", err)
+		return ""
+	}
+	if instructions == nil {
+		return ""
+	}
+
+	return instructions.Instructions
+}
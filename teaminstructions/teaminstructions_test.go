@@ -0,0 +1,90 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package teaminstructions
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mmapitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSave(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		expectedErr   error
+		expectedText  string
+		expectDeleted bool
+	}{
+		{
+			name:         "saves trimmed instructions",
+			text:         "  Always call the product Acme Cloud.\n",
+			expectedText: "Always call the product Acme Cloud.",
+		},
+		{
+			name:          "empty instructions remove the existing ones",
+			text:          "   ",
+			expectDeleted: true,
+		},
+		{
+			name:         "accepts the maximum length",
+			text:         strings.Repeat("é", MaxLength),
+			expectedText: strings.Repeat("é", MaxLength),
+		},
+		{
+			name:        "rejects instructions that are too long",
+			text:        strings.Repeat("a", MaxLength+1),
+			expectedErr: ErrTooLong,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := New(mmapitest.NewMemoryKV())
+			_, err := store.Save("team1", "previous", "user1")
+			require.NoError(t, err)
+
+			saved, err := store.Save("team1", tc.text, "user2")
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+				assert.Equal(t, "previous", store.GetTeamInstructions("team1"))
+				return
+			}
+			require.NoError(t, err)
+
+			loaded, err := store.Get("team1")
+			require.NoError(t, err)
+			if tc.expectDeleted {
+				assert.Nil(t, saved)
+				assert.Nil(t, loaded)
+				return
+			}
+
+			require.NotNil(t, loaded)
+			assert.Equal(t, tc.expectedText, loaded.Instructions)
+			assert.Equal(t, "team1", loaded.TeamID)
+			assert.Equal(t, "user2", loaded.UpdatedBy)
+			assert.NotZero(t, loaded.UpdateAt)
+		})
+	}
+}
+
+func TestGetTeamInstructions(t *testing.T) {
+	kv := mmapitest.NewMemoryKV()
+	store := New(kv)
+	_, err := store.Save("team1", "Use formal language.", "user1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Use formal language.", store.GetTeamInstructions("team1"))
+	assert.Empty(t, store.GetTeamInstructions("team2"))
+	assert.Empty(t, store.GetTeamInstructions(""))
+
+	kv.GetErr = errors.New("kv unavailable")
+	assert.Empty(t, store.GetTeamInstructions("team1"))
+	assert.Equal(t, 1, kv.Logged)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package threads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+)
+
+const (
+	// MaxDraftIntentLength is the maximum length of what the user wants to say in a draft
+	MaxDraftIntentLength = 2000
+	// MaxDraftPersonaLength is the maximum length of the persona a draft is written as
+	MaxDraftPersonaLength = 200
+)
+
+// ErrInvalidDraft is returned when drafting a reply with invalid options
+var ErrInvalidDraft = errors.New("invalid draft options")
+
+// DraftTones are the tones a reply can be drafted in, with how they are described to the model
+var DraftTones = map[string]string{
+	"neutral":    "",
+	"friendly":   "warm and friendly",
+	"formal":     "formal and professional",
+	"concise":    "brief and to the point",
+	"empathetic": "empathetic and supportive",
+	"assertive":  "confident and direct",
+}
+
+// DraftOptions describe the reply to draft. Intent is what the user wants to say, Tone one of
+// DraftTones, and Persona who the user writes as, such as "the on-call engineer".
+type DraftOptions struct {
+	Intent  string `json:"intent"`
+	Tone    string `json:"tone"`
+	Persona string `json:"persona"`
+}
+
+// Normalize trims the options and checks they are valid.
+func (o *DraftOptions) Normalize() error {
+	o.Intent = strings.TrimSpace(o.Intent)
+	o.Tone = strings.ToLower(strings.TrimSpace(o.Tone))
+	o.Persona = strings.TrimSpace(o.Persona)
+
+	if o.Intent == "" {
+		return fmt.Errorf("%w: the intent is required", ErrInvalidDraft)
+	}
+	if len([]rune(o.Intent)) > MaxDraftIntentLength {
+		return fmt.Errorf("%w: the intent is longer than %d characters", ErrInvalidDraft, MaxDraftIntentLength)
+	}
+	if _, ok := DraftTones[o.Tone]; o.Tone != "" && !ok {
+		return fmt.Errorf("%w: unknown tone %q", ErrInvalidDraft, o.Tone)
+	}
+	if len([]rune(o.Persona)) > MaxDraftPersonaLength || strings.ContainsAny(o.Persona, "\r\n") {
+		return fmt.Errorf("%w: the persona must be a single line of at most %d characters", ErrInvalidDraft, MaxDraftPersonaLength)
+	}
+	return nil
+}
+
+// DraftReply drafts a reply of the requesting user to the thread, saying what the user intends
+// to. The draft is returned for the user to edit rather than posted.
+func (t *Threads) DraftReply(ctx context.Context, postID string, context *llm.Context, options DraftOptions) (string, error) {
+	if err := options.Normalize(); err != nil {
+		return "", err
+	}
+
+	formattedThread, err := t.formatThread(postID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get thread: %w", err)
+	}
+	context.Parameters = map[string]any{
+		"Thread":  formattedThread,
+		"Tone":    DraftTones[options.Tone],
+		"Persona": options.Persona,
+	}
+
+	systemPrompt, err := t.prompts.Format(prompts.PromptDraftReplySystem, context)
+	if err != nil {
+		return "", fmt.Errorf("failed to format system prompt: %w", err)
+	}
+
+	draft, err := t.llm.ChatCompletionNoStream(ctx, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{
+				Role:    llm.PostRoleSystem,
+				Message: systemPrompt,
+			},
+			{
+				Role:    llm.PostRoleUser,
+				Message: options.Intent,
+			},
+		},
+		Context: context,
+	}, llm.WithToolsDisabled())
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(draft), nil
+}
@@ -4,18 +4,53 @@
 package threads
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
 
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/exclusions"
 	"github.com/mattermost/mattermost-plugin-ai/format"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/mmapi"
 	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
 )
 
+const (
+	// explainContextReplies is the number of replies before an explained post given as its context
+	explainContextReplies = 5
+	// maxFileExcerptLength is the number of characters of the content of attached files given
+	maxFileExcerptLength = 1000
+	// maxDescribedImages is the number of images of a thread described by the image describer
+	maxDescribedImages = 5
+	// promptTokenReserve is the part of the input token limit of the model kept for the prompts
+	// around the formatted thread
+	promptTokenReserve = 2000
+)
+
+// ImageDescriber describes the content of the images attached to the analyzed threads
+type ImageDescriber interface {
+	Describe(ctx context.Context, bot *bots.Bot, fileInfo *model.FileInfo) (string, error)
+}
+
+// LinkResolver resolves the permalinks in the analyzed threads, see permalinks.Resolver
+type LinkResolver interface {
+	Resolve(userID string, posts []*model.Post) map[string][]mmapi.LinkedPost
+}
+
 type Threads struct {
-	llm     llm.LanguageModel
-	prompts *llm.Prompts
-	client  mmapi.Client
+	llm         llm.LanguageModel
+	prompts     *llm.Prompts
+	client      mmapi.Client
+	policy      exclusions.ChannelPolicy
+	images      ImageDescriber
+	bot         *bots.Bot
+	links       LinkResolver
+	linksUserID string
 }
 
 func New(
@@ -30,19 +65,76 @@ func New(
 	}
 }
 
-func (t *Threads) Summarize(threadRootID string, context *llm.Context) (*llm.TextStreamResult, error) {
-	return t.Analyze(threadRootID, context, prompts.PromptSummarizeThreadSystem)
+// SetImageDescriber describes the images attached to the analyzed threads with the bot, which
+// are otherwise only listed by name
+func (t *Threads) SetImageDescriber(images ImageDescriber, bot *bots.Bot) {
+	t.images = images
+	t.bot = bot
+}
+
+// SetLinkResolver adds an excerpt of the posts linked in the analyzed threads that the user can
+// read
+func (t *Threads) SetLinkResolver(links LinkResolver, userID string) {
+	t.links = links
+	t.linksUserID = userID
+}
+
+// SetChannelPolicy sets the policy of the channel of the analyzed threads, leaving out the posts it excludes
+func (t *Threads) SetChannelPolicy(policy exclusions.ChannelPolicy) {
+	t.policy = policy
+}
+
+func (t *Threads) Summarize(ctx context.Context, threadRootID string, context *llm.Context) (*llm.TextStreamResult, error) {
+	return t.Analyze(ctx, threadRootID, context, prompts.PromptSummarizeThreadSystem)
+}
+
+func (t *Threads) FindActionItems(ctx context.Context, threadRootID string, context *llm.Context) (*llm.TextStreamResult, error) {
+	return t.Analyze(ctx, threadRootID, context, prompts.PromptFindActionItemsSystem)
 }
 
-func (t *Threads) FindActionItems(threadRootID string, context *llm.Context) (*llm.TextStreamResult, error) {
-	return t.Analyze(threadRootID, context, prompts.PromptFindActionItemsSystem)
+func (t *Threads) FindOpenQuestions(ctx context.Context, threadRootID string, context *llm.Context) (*llm.TextStreamResult, error) {
+	return t.Analyze(ctx, threadRootID, context, prompts.PromptFindOpenQuestionsSystem)
 }
 
-func (t *Threads) FindOpenQuestions(threadRootID string, context *llm.Context) (*llm.TextStreamResult, error) {
-	return t.Analyze(threadRootID, context, prompts.PromptFindOpenQuestionsSystem)
+// Explain explains the post in plain language, with the start of its thread and the replies
+// before it as context
+func (t *Threads) Explain(ctx context.Context, post *model.Post, context *llm.Context) (*llm.TextStreamResult, error) {
+	return t.explain(ctx, post, context, prompts.PromptExplainPostSystem)
+}
+
+// Simplify explains the post as simply as to someone new to its subject, like Explain
+func (t *Threads) Simplify(ctx context.Context, post *model.Post, context *llm.Context) (*llm.TextStreamResult, error) {
+	return t.explain(ctx, post, context, prompts.PromptSimplifyPostSystem)
+}
+
+func (t *Threads) explain(ctx context.Context, post *model.Post, context *llm.Context, promptName string) (*llm.TextStreamResult, error) {
+	formattedContext, err := t.formatPostContext(post.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+	context.Parameters = map[string]any{"Thread": formattedContext}
+
+	systemPrompt, err := t.prompts.Format(promptName, context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format system prompt: %w", err)
+	}
+
+	return t.llm.ChatCompletion(ctx, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{
+				Role:    llm.PostRoleSystem,
+				Message: systemPrompt,
+			},
+			{
+				Role:    llm.PostRoleUser,
+				Message: format.PostBody(post),
+			},
+		},
+		Context: context,
+	}, llm.WithToolsDisabled())
 }
 
-func (t *Threads) Analyze(postIDToAnalyze string, context *llm.Context, promptName string) (*llm.TextStreamResult, error) {
+func (t *Threads) Analyze(ctx context.Context, postIDToAnalyze string, context *llm.Context, promptName string) (*llm.TextStreamResult, error) {
 	posts, err := t.createInitalPosts(postIDToAnalyze, context, promptName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create initial posts: %w", err)
@@ -52,7 +144,7 @@ func (t *Threads) Analyze(postIDToAnalyze string, context *llm.Context, promptNa
 		Posts:   posts,
 		Context: context,
 	}
-	analysisStream, err := t.llm.ChatCompletion(completionReqest, llm.WithToolsDisabled())
+	analysisStream, err := t.llm.ChatCompletion(ctx, completionReqest, llm.WithToolsDisabled())
 	if err != nil {
 		return nil, err
 	}
@@ -65,11 +157,10 @@ func (t *Threads) FollowUpAnalyze(postIDToAnalyze string, context *llm.Context,
 }
 
 func (t *Threads) createInitalPosts(postIDToAnalyze string, context *llm.Context, promptName string) ([]llm.Post, error) {
-	threadData, err := mmapi.GetThreadData(t.client, postIDToAnalyze)
+	formattedThread, err := t.formatThread(postIDToAnalyze)
 	if err != nil {
 		return nil, err
 	}
-	formattedThread := format.ThreadData(threadData)
 	context.Parameters = map[string]any{"Thread": formattedThread}
 
 	systemPromptName := prompts.PromptSummarizeThreadSystem
@@ -108,3 +199,107 @@ func (t *Threads) createInitalPosts(postIDToAnalyze string, context *llm.Context
 
 	return posts, nil
 }
+
+// formatThread formats the posts of the thread the channel policy doesn't exclude
+func (t *Threads) formatThread(postID string) (string, error) {
+	threadData, err := mmapi.GetThreadData(t.client, postID)
+	if err != nil {
+		return "", err
+	}
+	return t.formatPosts(threadData), nil
+}
+
+// formatPostContext formats the root of the thread of the post and the replies up to the post,
+// keeping only the last explainContextReplies replies before it
+func (t *Threads) formatPostContext(postID string) (string, error) {
+	threadData, err := mmapi.GetThreadData(t.client, postID)
+	if err != nil {
+		return "", err
+	}
+	if index := slices.IndexFunc(threadData.Posts, func(post *model.Post) bool { return post.Id == postID }); index > 0 {
+		start := max(1, index-explainContextReplies)
+		threadData.Posts = append(threadData.Posts[:1:1], threadData.Posts[start:index+1]...)
+	}
+	return t.formatPosts(threadData), nil
+}
+
+func (t *Threads) formatPosts(threadData *mmapi.ThreadData) string {
+	threadData.Posts = slices.DeleteFunc(threadData.Posts, func(post *model.Post) bool {
+		return t.policy.ExcludesPost(post, threadData.UsersByID[post.UserId])
+	})
+	t.loadFiles(threadData)
+	if t.links != nil {
+		threadData.LinkedPostsByPostID = t.links.Resolve(t.linksUserID, threadData.Posts)
+	}
+	return format.ThreadDataWithinBudget(threadData, t.threadTokenBudget(), t.llm.CountTokens)
+}
+
+// threadTokenBudget returns the number of tokens the formatted thread may use, so long threads
+// are sampled before the request is truncated. Zero means the model has no known limit.
+func (t *Threads) threadTokenBudget() int {
+	inputTokenLimit := t.llm.InputTokenLimit()
+	if inputTokenLimit <= 0 {
+		return 0
+	}
+	tokenLimit := int(math.Floor(float64(inputTokenLimit-llm.FunctionsTokenBudget) * llm.TokenLimitBufferSize))
+	return max(tokenLimit-promptTokenReserve, llm.MinTokens)
+}
+
+// loadFiles adds the files attached to the posts with an excerpt of their content, such as the
+// text the server extracted from documents and the description of images, so analyses don't
+// miss what was discussed in attachments
+func (t *Threads) loadFiles(threadData *mmapi.ThreadData) {
+	describedImages := 0
+	for _, post := range threadData.Posts {
+		for _, fileID := range post.FileIds {
+			fileInfo, err := t.client.GetFileInfo(fileID)
+			if err != nil {
+				t.client.LogError("Failed to get attached file", "file_id", fileID, "error", err)
+				continue
+			}
+
+			content := strings.TrimSpace(fileInfo.Content)
+			if content == "" && strings.HasPrefix(fileInfo.MimeType, "text/") {
+				content = t.readTextFile(fileID)
+			}
+			if content == "" && fileInfo.IsImage() && t.images != nil && describedImages < maxDescribedImages {
+				describedImages++
+				description, err := t.images.Describe(context.Background(), t.bot, fileInfo)
+				if err != nil {
+					t.client.LogError("Failed to describe attached image", "file_id", fileID, "error", err)
+				} else {
+					content = "Description of the image: " + description
+				}
+			}
+			if runes := []rune(content); len(runes) > maxFileExcerptLength {
+				content = string(runes[:maxFileExcerptLength]) + "..."
+			}
+
+			if threadData.FilesByPostID == nil {
+				threadData.FilesByPostID = make(map[string][]mmapi.FileData)
+			}
+			threadData.FilesByPostID[post.Id] = append(threadData.FilesByPostID[post.Id], mmapi.FileData{
+				Name:    fileInfo.Name,
+				Content: content,
+			})
+		}
+	}
+}
+
+// readTextFile returns the start of a text file the server didn't extract the content of
+func (t *Threads) readTextFile(fileID string) string {
+	file, err := t.client.GetFile(fileID)
+	if err != nil {
+		t.client.LogError("Failed to get attached file", "file_id", fileID, "error", err)
+		return ""
+	}
+	defer file.Close()
+
+	// Characters take up to four bytes
+	content, err := io.ReadAll(io.LimitReader(file, maxFileExcerptLength*4))
+	if err != nil {
+		t.client.LogError("Failed to read attached file", "file_id", fileID, "error", err)
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
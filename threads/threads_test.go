@@ -4,10 +4,15 @@
 package threads_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/mattermost/mattermost-plugin-ai/bots"
 	"github.com/mattermost/mattermost-plugin-ai/evals"
 	"github.com/mattermost/mattermost-plugin-ai/llm"
 	"github.com/mattermost/mattermost-plugin-ai/llm/mocks"
@@ -71,6 +76,7 @@ func TestThreadsAnalyze(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			// Setup
 			mockLLM := mocks.NewMockLanguageModel(t)
+			mockLLM.EXPECT().InputTokenLimit().Return(0).Maybe()
 			mockClient := mmapimocks.NewMockClient(t)
 			prompts, err := llm.NewPrompts(prompts.PromptsFolder)
 			require.NoError(t, err)
@@ -102,13 +108,13 @@ func TestThreadsAnalyze(t *testing.T) {
 			}
 
 			if tc.expectedLLMCalls > 0 {
-				mockLLM.EXPECT().ChatCompletion(mock.Anything, mock.Anything).Return(&llm.TextStreamResult{}, tc.llmError)
+				mockLLM.EXPECT().ChatCompletion(mock.Anything, mock.Anything, mock.Anything).Return(&llm.TextStreamResult{}, tc.llmError)
 			}
 
 			threadService := threads.New(mockLLM, prompts, mockClient)
 
 			// Execute
-			result, err := threadService.Analyze(tc.postID, ctx, tc.promptName)
+			result, err := threadService.Analyze(context.Background(), tc.postID, ctx, tc.promptName)
 
 			// Assert
 			if tc.expectedError {
@@ -142,7 +148,7 @@ func runThreadAnalysisEval(t *evals.EvalT, threadData *evals.ThreadExport, promp
 
 	// Do the thread analysis
 	threadService := threads.New(t.LLM, t.Prompts, mockClient)
-	result, err := threadService.Analyze(threadData.RootPost.Id, llmContext, promptName)
+	result, err := threadService.Analyze(context.Background(), threadData.RootPost.Id, llmContext, promptName)
 	require.NoError(t, err)
 	require.NotNil(t, result)
 	output, err := result.ReadAll()
@@ -152,6 +158,96 @@ func runThreadAnalysisEval(t *evals.EvalT, threadData *evals.ThreadExport, promp
 	return output
 }
 
+func TestThreadsExplain(t *testing.T) {
+	mockLLM := mocks.NewMockLanguageModel(t)
+	mockLLM.EXPECT().InputTokenLimit().Return(0).Maybe()
+	mockClient := mmapimocks.NewMockClient(t)
+	prompts, err := llm.NewPrompts(prompts.PromptsFolder)
+	require.NoError(t, err)
+
+	// The root and the last five replies before the explained post are its context
+	postList := model.NewPostList()
+	for i := range 9 {
+		postList.AddPost(&model.Post{Id: fmt.Sprintf("post%d", i), Message: fmt.Sprintf("message %d", i), UserId: "user123", CreateAt: int64(i)})
+		postList.AddOrder(fmt.Sprintf("post%d", i))
+	}
+	post := postList.Posts["post7"]
+	post.Message = "LGTM, ship it after the CAB"
+	mockClient.EXPECT().GetPostThread(post.Id).Return(postList, nil)
+	mockClient.EXPECT().GetUser("user123").Return(&model.User{Id: "user123", Username: "alice"}, nil)
+
+	var request llm.CompletionRequest
+	mockLLM.EXPECT().ChatCompletion(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(_ context.Context, req llm.CompletionRequest, _ ...llm.LanguageModelOption) {
+			request = req
+		}).
+		Return(&llm.TextStreamResult{}, nil)
+
+	llmContext := llm.NewContext()
+	llmContext.RequestingUser = &model.User{Id: "requester123", Username: "bob", Locale: "en"}
+
+	_, err = threads.New(mockLLM, prompts, mockClient).Explain(context.Background(), post, llmContext)
+	require.NoError(t, err)
+
+	require.Len(t, request.Posts, 2)
+	assert.Contains(t, request.Posts[0].Message, "message 0")
+	assert.NotContains(t, request.Posts[0].Message, "message 1")
+	assert.Contains(t, request.Posts[0].Message, "message 2")
+	assert.Contains(t, request.Posts[0].Message, post.Message)
+	assert.NotContains(t, request.Posts[0].Message, "message 8")
+	assert.Equal(t, post.Message, request.Posts[1].Message)
+}
+
+type fakeImageDescriber struct {
+	described []string
+}
+
+func (f *fakeImageDescriber) Describe(_ context.Context, _ *bots.Bot, fileInfo *model.FileInfo) (string, error) {
+	f.described = append(f.described, fileInfo.Id)
+	return "A bar chart of revenue by region", nil
+}
+
+func TestThreadsSummarizeAttachments(t *testing.T) {
+	mockLLM := mocks.NewMockLanguageModel(t)
+	mockLLM.EXPECT().InputTokenLimit().Return(0).Maybe()
+	mockClient := mmapimocks.NewMockClient(t)
+	prompts, err := llm.NewPrompts(prompts.PromptsFolder)
+	require.NoError(t, err)
+
+	post := &model.Post{Id: "post1", Message: "Here are the Q3 numbers", UserId: "user1", FileIds: []string{"sheet", "chart", "notes"}}
+	mockClient.EXPECT().GetPostThread(post.Id).Return(&model.PostList{
+		Order: []string{post.Id},
+		Posts: map[string]*model.Post{post.Id: post},
+	}, nil)
+	mockClient.EXPECT().GetUser(post.UserId).Return(&model.User{Id: post.UserId, Username: "alice"}, nil)
+	mockClient.EXPECT().GetFileInfo("sheet").Return(&model.FileInfo{Id: "sheet", Name: "forecast.xlsx", Content: "EMEA revenue 1.2M"}, nil)
+	mockClient.EXPECT().GetFileInfo("chart").Return(&model.FileInfo{Id: "chart", Name: "chart.png", Extension: "png", MimeType: "image/png"}, nil)
+	mockClient.EXPECT().GetFileInfo("notes").Return(&model.FileInfo{Id: "notes", Name: "notes.txt", MimeType: "text/plain"}, nil)
+	mockClient.EXPECT().GetFile("notes").Return(io.NopCloser(strings.NewReader("Ship by Friday")), nil)
+
+	var request llm.CompletionRequest
+	mockLLM.EXPECT().ChatCompletion(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(_ context.Context, req llm.CompletionRequest, _ ...llm.LanguageModelOption) {
+			request = req
+		}).
+		Return(&llm.TextStreamResult{}, nil)
+
+	llmContext := llm.NewContext()
+	llmContext.RequestingUser = &model.User{Id: "requester123", Username: "bob", Locale: "en"}
+
+	describer := &fakeImageDescriber{}
+	analyzer := threads.New(mockLLM, prompts, mockClient)
+	analyzer.SetImageDescriber(describer, nil)
+	_, err = analyzer.Summarize(context.Background(), post.Id, llmContext)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"chart"}, describer.described)
+	thread := request.Posts[1].Message
+	assert.Contains(t, thread, "Attached file forecast.xlsx:\nEMEA revenue 1.2M")
+	assert.Contains(t, thread, "Attached file chart.png:\nDescription of the image: A bar chart of revenue by region")
+	assert.Contains(t, thread, "Attached file notes.txt:\nShip by Friday")
+}
+
 func TestThreadsSummarizeFromExportedData(t *testing.T) {
 	// Define the evaluation rubrics for each thread
 	evalConfigs := []struct {
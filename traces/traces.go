@@ -0,0 +1,81 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package traces stores agent traces recording what the model and its tools did to answer a post,
+// so admins can debug bot responses.
+package traces
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+)
+
+// ErrTraceNotFound is returned when no trace was recorded for a post.
+var ErrTraceNotFound = errors.New("trace not found")
+
+// Store persists agent traces keyed by the post they answer.
+type Store struct {
+	db      *mmapi.DBClient
+	enabled func() bool
+}
+
+// New creates a new trace store. Traces are only recorded while enabled returns true.
+func New(db *mmapi.DBClient, enabled func() bool) *Store {
+	return &Store{
+		db:      db,
+		enabled: enabled,
+	}
+}
+
+// Enabled reports whether traces should be recorded.
+func (s *Store) Enabled() bool {
+	return s != nil && s.db != nil && s.enabled()
+}
+
+type traceRecord struct {
+	Trace string `db:"trace"`
+}
+
+// Save stores the trace, replacing an earlier trace of the same request, for example after a regeneration.
+func (s *Store) Save(trace llm.Trace) error {
+	traceJSON, err := json.Marshal(trace)
+	if err != nil {
+		return fmt.Errorf("failed to marshal trace: %w", err)
+	}
+
+	if _, err := s.db.ExecBuilder(s.db.Builder().Insert("LLM_Traces").
+		Columns("RequestPostID", "BotUserID", "UserID", "ChannelID", "CreateAt", "Trace").
+		Values(trace.RequestPostID, trace.BotUserID, trace.UserID, trace.ChannelID, trace.StartedAt, string(traceJSON)).
+		Suffix("ON CONFLICT (RequestPostID) DO UPDATE SET BotUserID = EXCLUDED.BotUserID, UserID = EXCLUDED.UserID, ChannelID = EXCLUDED.ChannelID, CreateAt = EXCLUDED.CreateAt, Trace = EXCLUDED.Trace")); err != nil {
+		return fmt.Errorf("failed to save trace: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the trace of the request answering the post with the given ID.
+func (s *Store) Get(requestPostID string) (*llm.Trace, error) {
+	var records []traceRecord
+	if err := s.db.DoQuery(&records, s.db.Builder().
+		Select("Trace").
+		From("LLM_Traces").
+		Where(sq.Eq{"RequestPostID": requestPostID}),
+	); err != nil {
+		return nil, fmt.Errorf("failed to get trace: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrTraceNotFound
+	}
+
+	var trace llm.Trace
+	if err := json.Unmarshal([]byte(records[0].Trace), &trace); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal trace: %w", err)
+	}
+
+	return &trace, nil
+}
@@ -0,0 +1,312 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package triage classifies the messages people outside the support team post in designated
+// channels, and posts the category, urgency and suggested owner for the team to act on.
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/format"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// Urgency is how soon the support team should handle a message
+type Urgency string
+
+const (
+	UrgencyLow    Urgency = "low"
+	UrgencyNormal Urgency = "normal"
+	UrgencyHigh   Urgency = "high"
+	UrgencyUrgent Urgency = "urgent"
+)
+
+// AllUrgencies lists the urgencies messages are classified into, from the least urgent
+var AllUrgencies = []Urgency{UrgencyLow, UrgencyNormal, UrgencyHigh, UrgencyUrgent}
+
+// DefaultCategories are used for channels that don't configure their own
+var DefaultCategories = []string{"Question", "Bug report", "Feature request", "Billing", "Account access", "Other"}
+
+// TriageProp is the post prop holding the JSON encoded classification of a triage post
+const TriageProp = "support_triage"
+
+// errNotTriaged is returned for the messages that aren't triaged, such as those of the support team.
+var errNotTriaged = errors.New("message not triaged")
+
+// Classification is the structured output requested from the model
+type Classification struct {
+	Category       string  `json:"category"`
+	Urgency        Urgency `json:"urgency"`
+	SuggestedOwner string  `json:"suggested_owner"`
+	Summary        string  `json:"summary"`
+	Reason         string  `json:"reason"`
+}
+
+// Config is the configuration the triaged channels are read from
+type Config interface {
+	GetSupportTriageChannels() []config.SupportTriageChannelConfig
+	GetDefaultBotName() string
+}
+
+// BotSource returns the agents triaging the channels
+type BotSource interface {
+	GetBotByUsernameOrFirst(botUsername string) *bots.Bot
+	IsAnyBot(userID string) bool
+	CheckUsageRestrictionsForChannel(bot *bots.Bot, channel *model.Channel) error
+}
+
+// ContextBuilder builds the LLM context of the classifications
+type ContextBuilder interface {
+	BuildLLMContextUserRequest(bot *bots.Bot, requestingUser *model.User, channel *model.Channel, opts ...llm.ContextOption) *llm.Context
+}
+
+// Service triages the messages posted in the configured channels
+type Service struct {
+	client         mmapi.Client
+	bots           BotSource
+	contextBuilder ContextBuilder
+	prompts        *llm.Prompts
+	i18n           *i18n.Bundle
+	config         Config
+}
+
+// New creates a new triage service
+func New(
+	client mmapi.Client,
+	bots BotSource,
+	contextBuilder ContextBuilder,
+	prompts *llm.Prompts,
+	i18nBundle *i18n.Bundle,
+	config Config,
+) *Service {
+	return &Service{
+		client:         client,
+		bots:           bots,
+		contextBuilder: contextBuilder,
+		prompts:        prompts,
+		i18n:           i18nBundle,
+		config:         config,
+	}
+}
+
+// MessageHasBeenPosted triages the post if it starts a conversation in a triaged channel
+func (s *Service) MessageHasBeenPosted(ctx context.Context, post *model.Post) {
+	if err := s.triage(ctx, post); err != nil && !errors.Is(err, errNotTriaged) {
+		s.client.LogError("Failed to triage message", "post_id", post.Id, "channel_id", post.ChannelId, "error", err)
+	}
+}
+
+func (s *Service) triage(ctx context.Context, post *model.Post) error {
+	channelConfig, ok := s.channelConfig(post.ChannelId)
+	if !ok {
+		return errNotTriaged
+	}
+
+	// Replies belong to the conversation their root post was triaged with
+	if post.RootId != "" || post.IsSystemMessage() || s.bots.IsAnyBot(post.UserId) {
+		return errNotTriaged
+	}
+
+	postingUser, err := s.client.GetUser(post.UserId)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if postingUser.IsBot || isTeamMember(channelConfig, postingUser, post) {
+		return errNotTriaged
+	}
+
+	channel, err := s.client.GetChannel(post.ChannelId)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+
+	botUsername := channelConfig.BotUsername
+	if botUsername == "" {
+		botUsername = s.config.GetDefaultBotName()
+	}
+	bot := s.bots.GetBotByUsernameOrFirst(botUsername)
+	if bot == nil {
+		return errors.New("no agent to triage with")
+	}
+	if err := s.bots.CheckUsageRestrictionsForChannel(bot, channel); err != nil {
+		return fmt.Errorf("agent can't be used in the channel: %w", err)
+	}
+
+	classification, err := s.Classify(ctx, bot, postingUser, channel, channelConfig, post)
+	if err != nil {
+		return err
+	}
+
+	return s.postClassification(bot, channelConfig, channel, postingUser, post, classification)
+}
+
+// Classify asks the model for the category, urgency and suggested owner of the post. Values
+// outside of the configured ones are replaced, so the result can always be posted.
+func (s *Service) Classify(ctx context.Context, bot *bots.Bot, postingUser *model.User, channel *model.Channel, channelConfig config.SupportTriageChannelConfig, post *model.Post) (*Classification, error) {
+	categories := channelConfig.Categories
+	if len(categories) == 0 {
+		categories = DefaultCategories
+	}
+
+	llmContext := s.contextBuilder.BuildLLMContextUserRequest(bot, postingUser, channel)
+	llmContext.Parameters = map[string]any{
+		"ChannelName":  channel.DisplayName,
+		"Categories":   categories,
+		"Urgencies":    AllUrgencies,
+		"Owners":       channelConfig.TeamUsernames,
+		"Instructions": channelConfig.Instructions,
+	}
+
+	prompt, err := s.prompts.Format(prompts.PromptSupportTriageSystem, llmContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format prompt: %w", err)
+	}
+
+	result, err := bot.LLM().ChatCompletionNoStream(ctx, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{
+				Role:    llm.PostRoleSystem,
+				Message: prompt,
+			},
+			{
+				Role:    llm.PostRoleUser,
+				Message: fmt.Sprintf("%s: %s", postingUser.Username, format.PostBody(post)),
+			},
+		},
+		Context: llmContext,
+	},
+		llm.WithMaxGeneratedTokens(500),
+		llm.WithReasoningDisabled(),
+		llm.WithToolsDisabled(),
+		llm.WithJSONOutput[Classification](),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to classify message: %w", err)
+	}
+
+	var classification Classification
+	if err := json.Unmarshal([]byte(result), &classification); err != nil {
+		return nil, fmt.Errorf("failed to parse classification: %w", err)
+	}
+
+	classification.Category = matchOption(categories, classification.Category)
+	if classification.Category == "" {
+		classification.Category = categories[len(categories)-1]
+	}
+	if !slices.Contains(AllUrgencies, classification.Urgency) {
+		classification.Urgency = UrgencyNormal
+	}
+	classification.SuggestedOwner = matchOption(channelConfig.TeamUsernames, strings.TrimPrefix(classification.SuggestedOwner, "@"))
+	classification.Summary = strings.TrimSpace(classification.Summary)
+	classification.Reason = strings.TrimSpace(classification.Reason)
+
+	return &classification, nil
+}
+
+// postClassification replies to the post with the classification, or posts it in the triage
+// channel with a link to the post when one is configured.
+func (s *Service) postClassification(bot *bots.Bot, channelConfig config.SupportTriageChannelConfig, channel *model.Channel, postingUser *model.User, post *model.Post, classification *Classification) error {
+	serverConfig := s.client.GetConfig()
+	locale := ""
+	if serverConfig != nil && serverConfig.LocalizationSettings.DefaultServerLocale != nil {
+		locale = *serverConfig.LocalizationSettings.DefaultServerLocale
+	}
+	T := i18n.LocalizerFunc(s.i18n, locale)
+
+	message := formatClassification(T, classification)
+	triagePost := &model.Post{
+		UserId:    bot.GetMMBot().UserId,
+		ChannelId: post.ChannelId,
+		RootId:    post.Id,
+	}
+	if channelConfig.TriageChannelID != "" {
+		siteURL := ""
+		if serverConfig != nil && serverConfig.ServiceSettings.SiteURL != nil {
+			siteURL = *serverConfig.ServiceSettings.SiteURL
+		}
+		triagePost.ChannelId = channelConfig.TriageChannelID
+		triagePost.RootId = ""
+		message = T("agents.support_triage.routed", "New message from @%s in ~%s: %s/_redirect/pl/%s\n", postingUser.Username, channel.Name, siteURL, post.Id) + message
+	}
+	triagePost.Message = message
+
+	encoded, err := json.Marshal(classification)
+	if err != nil {
+		return fmt.Errorf("failed to encode classification: %w", err)
+	}
+	triagePost.AddProp(TriageProp, string(encoded))
+
+	if err := s.client.CreatePost(triagePost); err != nil {
+		return fmt.Errorf("failed to post classification: %w", err)
+	}
+	return nil
+}
+
+func formatClassification(T i18n.TranslationFunc, classification *Classification) string {
+	urgencies := map[Urgency]string{
+		UrgencyLow:    T("agents.support_triage.urgency_low", "Low"),
+		UrgencyNormal: T("agents.support_triage.urgency_normal", "Normal"),
+		UrgencyHigh:   T("agents.support_triage.urgency_high", "High"),
+		UrgencyUrgent: T("agents.support_triage.urgency_urgent", "Urgent"),
+	}
+
+	var result strings.Builder
+	result.WriteString(T("agents.support_triage.classification", "**Category:** %s | **Urgency:** %s", classification.Category, urgencies[classification.Urgency]))
+	if classification.SuggestedOwner != "" {
+		result.WriteString(T("agents.support_triage.suggested_owner", " | **Suggested owner:** @%s", classification.SuggestedOwner))
+	}
+	if classification.Summary != "" {
+		result.WriteString("\n> ")
+		result.WriteString(classification.Summary)
+	}
+	if classification.Reason != "" {
+		result.WriteString("\n_")
+		result.WriteString(classification.Reason)
+		result.WriteString("_")
+	}
+
+	return result.String()
+}
+
+func (s *Service) channelConfig(channelID string) (config.SupportTriageChannelConfig, bool) {
+	for _, channelConfig := range s.config.GetSupportTriageChannels() {
+		if channelConfig.ChannelID == channelID {
+			return channelConfig, true
+		}
+	}
+	return config.SupportTriageChannelConfig{}, false
+}
+
+// isTeamMember reports whether the post was written by the support team. Guests and users of
+// shared channels are never part of it.
+func isTeamMember(channelConfig config.SupportTriageChannelConfig, user *model.User, post *model.Post) bool {
+	if user.IsGuest() || user.IsRemote() || (post.RemoteId != nil && *post.RemoteId != "") {
+		return false
+	}
+	return slices.ContainsFunc(channelConfig.TeamUsernames, func(username string) bool {
+		return strings.EqualFold(strings.TrimPrefix(username, "@"), user.Username)
+	})
+}
+
+// matchOption returns the option equal to value ignoring case, or "" if there is none.
+func matchOption(options []string, value string) string {
+	value = strings.TrimSpace(value)
+	for _, option := range options {
+		if strings.EqualFold(strings.TrimPrefix(option, "@"), value) {
+			return strings.TrimPrefix(option, "@")
+		}
+	}
+	return ""
+}
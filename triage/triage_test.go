@@ -0,0 +1,227 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/llm/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	mmapi.Client
+	users  map[string]*model.User
+	posts  []*model.Post
+	errors []string
+}
+
+func (f *fakeClient) GetUser(userID string) (*model.User, error) {
+	return f.users[userID], nil
+}
+
+func (f *fakeClient) GetChannel(channelID string) (*model.Channel, error) {
+	return &model.Channel{Id: channelID, Name: "support", DisplayName: "Support"}, nil
+}
+
+func (f *fakeClient) GetConfig() *model.Config {
+	return &model.Config{ServiceSettings: model.ServiceSettings{SiteURL: model.NewPointer("https://mm.example.com")}}
+}
+
+func (f *fakeClient) CreatePost(post *model.Post) error {
+	f.posts = append(f.posts, post)
+	return nil
+}
+
+func (f *fakeClient) LogError(msg string, _ ...interface{}) {
+	f.errors = append(f.errors, msg)
+}
+
+type fakeBots struct {
+	bot        *bots.Bot
+	restricted bool
+}
+
+func (f *fakeBots) GetBotByUsernameOrFirst(string) *bots.Bot {
+	return f.bot
+}
+
+func (f *fakeBots) IsAnyBot(userID string) bool {
+	return userID == f.bot.GetMMBot().UserId
+}
+
+func (f *fakeBots) CheckUsageRestrictionsForChannel(*bots.Bot, *model.Channel) error {
+	if f.restricted {
+		return bots.ErrUsageRestriction
+	}
+	return nil
+}
+
+type fakeConfig struct {
+	channels []config.SupportTriageChannelConfig
+}
+
+func (f *fakeConfig) GetSupportTriageChannels() []config.SupportTriageChannelConfig {
+	return f.channels
+}
+
+func (f *fakeConfig) GetDefaultBotName() string {
+	return "ai"
+}
+
+type fakeContextBuilder struct{}
+
+func (fakeContextBuilder) BuildLLMContextUserRequest(_ *bots.Bot, user *model.User, channel *model.Channel, _ ...llm.ContextOption) *llm.Context {
+	llmContext := llm.NewContext()
+	llmContext.RequestingUser = user
+	llmContext.Channel = channel
+	return llmContext
+}
+
+func TestMessageHasBeenPosted(t *testing.T) {
+	supportChannel := config.SupportTriageChannelConfig{
+		ChannelID:     "support",
+		TeamUsernames: []string{"alice", "@bob"},
+		Categories:    []string{"Billing", "Bug", "Other"},
+	}
+	routedChannel := supportChannel
+	routedChannel.TriageChannelID = "triage"
+
+	tests := []struct {
+		name            string
+		channelConfig   config.SupportTriageChannelConfig
+		post            *model.Post
+		restricted      bool
+		llmResponse     string
+		llmError        error
+		expectedMessage string
+		expectedChannel string
+		expectedRootID  string
+		expectedError   bool
+	}{
+		{
+			name:            "replies in the thread of a customer message",
+			channelConfig:   supportChannel,
+			post:            &model.Post{Id: "post1", ChannelId: "support", UserId: "customer", Message: "I was charged twice this month"},
+			llmResponse:     `{"category": "billing", "urgency": "high", "suggested_owner": "@Bob", "summary": "Customer was charged twice.", "reason": "Duplicate charge"}`,
+			expectedMessage: "**Category:** Billing | **Urgency:** High | **Suggested owner:** @bob\n> Customer was charged twice.\n_Duplicate charge_",
+			expectedChannel: "support",
+			expectedRootID:  "post1",
+		},
+		{
+			name:            "routes to the triage channel",
+			channelConfig:   routedChannel,
+			post:            &model.Post{Id: "post1", ChannelId: "support", UserId: "customer", Message: "The export fails"},
+			llmResponse:     `{"category": "Bug", "urgency": "normal", "suggested_owner": "", "summary": "", "reason": ""}`,
+			expectedMessage: "New message from @customer in ~support: https://mm.example.com/_redirect/pl/post1\n**Category:** Bug | **Urgency:** Normal",
+			expectedChannel: "triage",
+		},
+		{
+			name:            "replaces values outside of the configured ones",
+			channelConfig:   supportChannel,
+			post:            &model.Post{Id: "post1", ChannelId: "support", UserId: "customer", Message: "Hello?"},
+			llmResponse:     `{"category": "Sales", "urgency": "critical", "suggested_owner": "carol"}`,
+			expectedMessage: "**Category:** Other | **Urgency:** Normal",
+			expectedChannel: "support",
+			expectedRootID:  "post1",
+		},
+		{
+			name:            "guests are never part of the team",
+			channelConfig:   supportChannel,
+			post:            &model.Post{Id: "post1", ChannelId: "support", UserId: "guestalice", Message: "Hi"},
+			llmResponse:     `{"category": "Other", "urgency": "low"}`,
+			expectedMessage: "**Category:** Other | **Urgency:** Low",
+			expectedChannel: "support",
+			expectedRootID:  "post1",
+		},
+		{
+			name:          "ignores the support team",
+			channelConfig: supportChannel,
+			post:          &model.Post{Id: "post1", ChannelId: "support", UserId: "alice", Message: "Looking into it"},
+		},
+		{
+			name:          "ignores replies",
+			channelConfig: supportChannel,
+			post:          &model.Post{Id: "post2", RootId: "post1", ChannelId: "support", UserId: "customer", Message: "Any update?"},
+		},
+		{
+			name:          "ignores other channels",
+			channelConfig: supportChannel,
+			post:          &model.Post{Id: "post1", ChannelId: "town-square", UserId: "customer", Message: "Hi"},
+		},
+		{
+			name:          "ignores the bot",
+			channelConfig: supportChannel,
+			post:          &model.Post{Id: "post1", ChannelId: "support", UserId: "bot1", Message: "Hi"},
+		},
+		{
+			name:          "channel excluded for the agent",
+			channelConfig: supportChannel,
+			restricted:    true,
+			post:          &model.Post{Id: "post1", ChannelId: "support", UserId: "customer", Message: "Hi"},
+			expectedError: true,
+		},
+		{
+			name:          "model error",
+			channelConfig: supportChannel,
+			post:          &model.Post{Id: "post1", ChannelId: "support", UserId: "customer", Message: "Hi"},
+			llmError:      errors.New("llm error"),
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockLLM := mocks.NewMockLanguageModel(t)
+			if test.llmResponse != "" || test.llmError != nil {
+				mockLLM.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(test.llmResponse, test.llmError)
+			}
+			promptsObj, err := llm.NewPrompts(prompts.PromptsFolder)
+			require.NoError(t, err)
+
+			client := &fakeClient{users: map[string]*model.User{
+				"customer":   {Id: "customer", Username: "customer"},
+				"alice":      {Id: "alice", Username: "alice"},
+				"guestalice": {Id: "guestalice", Username: "alice", Roles: model.SystemGuestRoleId},
+				"bot1":       {Id: "bot1", Username: "ai", IsBot: true},
+			}}
+			bot := bots.NewBot(llm.BotConfig{Name: "ai"}, llm.ServiceConfig{}, &model.Bot{UserId: "bot1"}, mockLLM)
+			service := New(client, &fakeBots{bot: bot, restricted: test.restricted}, fakeContextBuilder{}, promptsObj, i18n.Init(), &fakeConfig{channels: []config.SupportTriageChannelConfig{test.channelConfig}})
+
+			service.MessageHasBeenPosted(context.Background(), test.post)
+
+			if test.expectedError {
+				require.Len(t, client.errors, 1)
+				require.Empty(t, client.posts)
+				return
+			}
+			require.Empty(t, client.errors)
+			if test.expectedMessage == "" {
+				require.Empty(t, client.posts)
+				return
+			}
+			require.Len(t, client.posts, 1)
+			triagePost := client.posts[0]
+			require.Equal(t, "bot1", triagePost.UserId)
+			require.Equal(t, test.expectedMessage, triagePost.Message)
+			require.Equal(t, test.expectedChannel, triagePost.ChannelId)
+			require.Equal(t, test.expectedRootID, triagePost.RootId)
+
+			var classification Classification
+			require.NoError(t, json.Unmarshal([]byte(triagePost.GetProp(TriageProp).(string)), &classification))
+			require.NotEmpty(t, classification.Category)
+		})
+	}
+}
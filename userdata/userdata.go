@@ -0,0 +1,391 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package userdata exports and erases the data the plugin keeps about a user, to answer the
+// data subject requests of privacy regulations such as the GDPR rights of access and erasure.
+//
+// The data of a user is their conversations with agents and the messages of the conversations
+// held in direct messages, their saved answers, scheduled reminders, usage events, agent traces,
+// the embeddings of their posts, and their credentials and pending standup questions in the KV
+// store. Credentials are only listed by the export, never their secret.
+package userdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-plugin-ai/integrations"
+	"github.com/mattermost/mattermost-plugin-ai/mcp"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/standups"
+	"github.com/mattermost/mattermost-plugin-ai/userkeys"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// messagesBatchSize bounds the conversations whose messages are read by each query
+const messagesBatchSize = 100
+
+// ErrNotAvailable is returned on databases the plugin tables don't exist in.
+var ErrNotAvailable = errors.New("user data is only available on PostgreSQL")
+
+// rawJSON is a JSON column exported as is
+type rawJSON json.RawMessage
+
+func (r *rawJSON) Scan(src any) error {
+	switch value := src.(type) {
+	case string:
+		*r = rawJSON(value)
+	case []byte:
+		*r = append(rawJSON(nil), value...)
+	case nil:
+		*r = nil
+	default:
+		return fmt.Errorf("unsupported JSON column type %T", src)
+	}
+	return nil
+}
+
+func (r rawJSON) MarshalJSON() ([]byte, error) {
+	if len(r) == 0 {
+		return []byte("null"), nil
+	}
+	if !json.Valid(r) {
+		return json.Marshal(string(r))
+	}
+	return r, nil
+}
+
+// Message is a post of a conversation
+type Message struct {
+	ID       string `json:"id" db:"id"`
+	RootID   string `json:"root_id" db:"rootid"`
+	UserID   string `json:"user_id" db:"userid"`
+	Message  string `json:"message" db:"message"`
+	CreateAt int64  `json:"create_at" db:"createat"`
+}
+
+// Conversation is a conversation of the user with an agent. Messages are only exported for
+// the conversations held in direct messages, other channels are shared with other users.
+type Conversation struct {
+	ID           string    `json:"id" db:"id"`
+	BotID        string    `json:"bot_id" db:"botid"`
+	ChannelID    string    `json:"channel_id" db:"channelid"`
+	Direct       bool      `json:"direct" db:"direct"`
+	Title        string    `json:"title" db:"title"`
+	State        rawJSON   `json:"state" db:"state"`
+	InputTokens  int64     `json:"input_tokens" db:"inputtokens"`
+	OutputTokens int64     `json:"output_tokens" db:"outputtokens"`
+	CreateAt     int64     `json:"create_at" db:"createat"`
+	UpdateAt     int64     `json:"update_at" db:"updateat"`
+	Messages     []Message `json:"messages,omitempty" db:"-"`
+}
+
+// SavedAnswer is a bot response the user saved
+type SavedAnswer struct {
+	ID        string  `json:"id" db:"id"`
+	PostID    string  `json:"post_id" db:"postid"`
+	BotID     string  `json:"bot_id" db:"botid"`
+	ChannelID string  `json:"channel_id" db:"channelid"`
+	Message   string  `json:"message" db:"message"`
+	Tags      rawJSON `json:"tags" db:"tags"`
+	CreateAt  int64   `json:"create_at" db:"createat"`
+}
+
+// Reminder is a reminder the user scheduled
+type Reminder struct {
+	ID       string `json:"id" db:"id"`
+	BotID    string `json:"bot_id" db:"botid"`
+	PostID   string `json:"post_id" db:"postid"`
+	Message  string `json:"message" db:"message"`
+	RemindAt int64  `json:"remind_at" db:"remindat"`
+	CreateAt int64  `json:"create_at" db:"createat"`
+}
+
+// UsageEvent is a request of the user to an agent
+type UsageEvent struct {
+	ID        string `json:"id" db:"id"`
+	CreateAt  int64  `json:"create_at" db:"createat"`
+	TeamID    string `json:"team_id" db:"teamid"`
+	ChannelID string `json:"channel_id" db:"channelid"`
+	BotID     string `json:"bot_id" db:"botid"`
+	Feature   string `json:"feature" db:"feature"`
+	LatencyMS int64  `json:"latency_ms" db:"latencyms"`
+	ToolCalls int    `json:"tool_calls" db:"toolcalls"`
+	Failed    bool   `json:"failed" db:"failed"`
+}
+
+// Trace is the agent trace of a request of the user
+type Trace struct {
+	RequestPostID string  `json:"request_post_id" db:"requestpostid"`
+	CreateAt      int64   `json:"create_at" db:"createat"`
+	Trace         rawJSON `json:"trace" db:"trace"`
+}
+
+// Export is all the data the plugin keeps about a user.
+type Export struct {
+	UserID        string         `json:"user_id"`
+	ExportedAt    int64          `json:"exported_at"`
+	Conversations []Conversation `json:"conversations"`
+	SavedAnswers  []SavedAnswer  `json:"saved_answers"`
+	Reminders     []Reminder     `json:"reminders"`
+	UsageEvents   []UsageEvent   `json:"usage_events"`
+	Traces        []Trace        `json:"traces"`
+	// IndexedPosts is the number of posts of the user indexed for semantic search
+	IndexedPosts int64 `json:"indexed_posts"`
+	// StoredKeys are the KV keys of the credentials and state of the user, without their values
+	StoredKeys []string `json:"stored_keys"`
+}
+
+// Erasure is what was deleted for a user.
+type Erasure struct {
+	UserID        string `json:"user_id"`
+	Conversations int64  `json:"conversations"`
+	// DirectThreads are the threads of the conversations in direct messages deleted with their messages
+	DirectThreads int64 `json:"direct_threads"`
+	SavedAnswers  int64 `json:"saved_answers"`
+	Reminders     int64 `json:"reminders"`
+	UsageEvents   int64 `json:"usage_events"`
+	Traces        int64 `json:"traces"`
+	Embeddings    int64 `json:"embeddings"`
+	StoredKeys    int64 `json:"stored_keys"`
+}
+
+// Service exports and erases the data of users.
+type Service struct {
+	db     *mmapi.DBClient
+	client mmapi.Client
+}
+
+// New creates a new user data service.
+func New(db *mmapi.DBClient, client mmapi.Client) *Service {
+	return &Service{
+		db:     db,
+		client: client,
+	}
+}
+
+// Export returns all the data the plugin keeps about the user.
+func (s *Service) Export(userID string) (*Export, error) {
+	if s.db == nil || !s.db.IsPostgres() {
+		return nil, ErrNotAvailable
+	}
+
+	export := &Export{
+		UserID:        userID,
+		ExportedAt:    model.GetMillis(),
+		Conversations: []Conversation{},
+		SavedAnswers:  []SavedAnswer{},
+		Reminders:     []Reminder{},
+		UsageEvents:   []UsageEvent{},
+		Traces:        []Trace{},
+		StoredKeys:    []string{},
+	}
+
+	if err := s.db.DoQuery(&export.Conversations, s.db.Builder().
+		Select("cv.ID AS id", "cv.BotID AS botid", "cv.ChannelID AS channelid", "COALESCE(ch.Type = 'D', FALSE) AS direct",
+			"cv.Title AS title", "cv.State AS state", "cv.InputTokens AS inputtokens", "cv.OutputTokens AS outputtokens",
+			"cv.CreateAt AS createat", "cv.UpdateAt AS updateat").
+		From("LLM_Conversations cv").
+		LeftJoin("Channels ch ON ch.Id = cv.ChannelID").
+		Where(sq.Eq{"cv.UserID": userID}).
+		OrderBy("cv.CreateAt", "cv.ID"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to export conversations: %w", err)
+	}
+	if err := s.addMessages(export.Conversations); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.DoQuery(&export.SavedAnswers, s.db.Builder().
+		Select("ID AS id", "PostID AS postid", "BotID AS botid", "ChannelID AS channelid", "Message AS message", "Tags AS tags", "CreateAt AS createat").
+		From("LLM_SavedAnswers").
+		Where(sq.Eq{"UserID": userID}).
+		OrderBy("CreateAt", "ID"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to export saved answers: %w", err)
+	}
+
+	if err := s.db.DoQuery(&export.Reminders, s.db.Builder().
+		Select("ID AS id", "BotID AS botid", "PostID AS postid", "Message AS message", "RemindAt AS remindat", "CreateAt AS createat").
+		From("LLM_Reminders").
+		Where(sq.Eq{"UserID": userID}).
+		OrderBy("RemindAt", "ID"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to export reminders: %w", err)
+	}
+
+	if err := s.db.DoQuery(&export.UsageEvents, s.db.Builder().
+		Select("ID AS id", "CreateAt AS createat", "TeamID AS teamid", "ChannelID AS channelid", "BotID AS botid",
+			"Feature AS feature", "LatencyMS AS latencyms", "ToolCalls AS toolcalls", "Failed AS failed").
+		From("LLM_UsageEvents").
+		Where(sq.Eq{"UserID": userID}).
+		OrderBy("CreateAt", "ID"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to export usage events: %w", err)
+	}
+
+	if err := s.db.DoQuery(&export.Traces, s.db.Builder().
+		Select("RequestPostID AS requestpostid", "CreateAt AS createat", "Trace AS trace").
+		From("LLM_Traces").
+		Where(sq.Eq{"UserID": userID}).
+		OrderBy("CreateAt", "RequestPostID"),
+	); err != nil {
+		return nil, fmt.Errorf("failed to export traces: %w", err)
+	}
+
+	exists, err := s.db.TableExists("llm_posts_embeddings")
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if err := s.db.DoQueryRow(&export.IndexedPosts, s.db.Builder().
+			Select("COUNT(DISTINCT post_id)").
+			From("llm_posts_embeddings").
+			Where(sq.Eq{"user_id": userID}),
+		); err != nil {
+			return nil, fmt.Errorf("failed to count indexed posts: %w", err)
+		}
+	}
+
+	keys, err := s.userKeys(userID)
+	if err != nil {
+		return nil, err
+	}
+	export.StoredKeys = append(export.StoredKeys, keys...)
+
+	return export, nil
+}
+
+// addMessages adds the messages of the conversations held in direct messages.
+func (s *Service) addMessages(conversations []Conversation) error {
+	byID := make(map[string]*Conversation)
+	var ids []string
+	for i := range conversations {
+		if conversations[i].Direct {
+			byID[conversations[i].ID] = &conversations[i]
+			ids = append(ids, conversations[i].ID)
+		}
+	}
+
+	for start := 0; start < len(ids); start += messagesBatchSize {
+		batch := ids[start:min(start+messagesBatchSize, len(ids))]
+		var messages []Message
+		if err := s.db.DoQuery(&messages, s.db.Builder().
+			Select("Id AS id", "RootId AS rootid", "UserId AS userid", "Message AS message", "CreateAt AS createat").
+			From("Posts").
+			Where(sq.Eq{"DeleteAt": 0}).
+			Where(sq.Or{sq.Eq{"Id": batch}, sq.Eq{"RootId": batch}}).
+			OrderBy("CreateAt", "Id"),
+		); err != nil {
+			return fmt.Errorf("failed to export conversation messages: %w", err)
+		}
+		for _, message := range messages {
+			threadID := message.RootID
+			if threadID == "" {
+				threadID = message.ID
+			}
+			if conversation := byID[threadID]; conversation != nil {
+				conversation.Messages = append(conversation.Messages, message)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Erase deletes all the data the plugin keeps about the user. The threads of the conversations
+// held in direct messages are deleted, the messages of the conversations in other channels are
+// left to their other members.
+func (s *Service) Erase(userID string) (*Erasure, error) {
+	if s.db == nil || !s.db.IsPostgres() {
+		return nil, ErrNotAvailable
+	}
+
+	erasure := &Erasure{UserID: userID}
+
+	var directThreads []string
+	if err := s.db.DoQuery(&directThreads, s.db.Builder().
+		Select("cv.ID").
+		From("LLM_Conversations cv").
+		Join("Channels ch ON ch.Id = cv.ChannelID").
+		Join("Posts p ON p.Id = cv.ID").
+		Where(sq.Eq{"cv.UserID": userID, "ch.Type": model.ChannelTypeDirect, "p.DeleteAt": 0}),
+	); err != nil {
+		return nil, fmt.Errorf("failed to get direct conversations: %w", err)
+	}
+	for _, threadID := range directThreads {
+		// Deleting the root post deletes its replies
+		if err := s.client.DeletePost(threadID); err != nil {
+			return erasure, fmt.Errorf("failed to delete conversation thread %s: %w", threadID, err)
+		}
+		erasure.DirectThreads++
+	}
+
+	if _, err := s.db.ExecBuilder(s.db.Builder().
+		Delete("LLM_PostMeta").
+		Where(sq.Expr("RootPostID IN (SELECT ID FROM LLM_Conversations WHERE UserID = ?)", userID)),
+	); err != nil {
+		return erasure, fmt.Errorf("failed to delete conversation titles: %w", err)
+	}
+
+	deletes := []tableDelete{
+		{"conversations", "LLM_Conversations", sq.Eq{"UserID": userID}, &erasure.Conversations},
+		{"saved answers", "LLM_SavedAnswers", sq.Eq{"UserID": userID}, &erasure.SavedAnswers},
+		{"reminders", "LLM_Reminders", sq.Eq{"UserID": userID}, &erasure.Reminders},
+		{"usage events", "LLM_UsageEvents", sq.Eq{"UserID": userID}, &erasure.UsageEvents},
+		{"traces", "LLM_Traces", sq.Eq{"UserID": userID}, &erasure.Traces},
+	}
+	exists, err := s.db.TableExists("llm_posts_embeddings")
+	if err != nil {
+		return erasure, err
+	}
+	if exists {
+		deletes = append(deletes, tableDelete{"embeddings", "llm_posts_embeddings", sq.Eq{"user_id": userID}, &erasure.Embeddings})
+	}
+	for _, d := range deletes {
+		result, err := s.db.ExecBuilder(s.db.Builder().Delete(d.table).Where(d.where))
+		if err != nil {
+			return erasure, fmt.Errorf("failed to delete %s: %w", d.name, err)
+		}
+		if *d.count, err = result.RowsAffected(); err != nil {
+			return erasure, fmt.Errorf("failed to count deleted %s: %w", d.name, err)
+		}
+	}
+
+	keys, err := s.userKeys(userID)
+	if err != nil {
+		return erasure, err
+	}
+	for _, key := range keys {
+		if err := s.client.KVDelete(key); err != nil {
+			return erasure, fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+		erasure.StoredKeys++
+	}
+
+	return erasure, nil
+}
+
+// tableDelete deletes the rows of the user from a table, counting them in the erasure
+type tableDelete struct {
+	name  string
+	table string
+	where sq.Sqlizer
+	count *int64
+}
+
+// userKeys lists the KV keys storing the credentials and state of the user.
+func (s *Service) userKeys(userID string) ([]string, error) {
+	keys, err := mmapi.KVListMatching(s.client, func(key string) bool {
+		return userkeys.IsUserKey(key, userID) ||
+			integrations.IsUserConnectionKey(key, userID) ||
+			mcp.IsUserOAuthKey(key, userID) ||
+			standups.IsUserPendingKey(key, userID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the stored keys of the user: %w", err)
+	}
+	return keys, nil
+}
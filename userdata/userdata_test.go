@@ -0,0 +1,76 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package userdata
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mocks"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserKeys(t *testing.T) {
+	userID := model.NewId()
+	otherUserID := model.NewId()
+
+	client := mocks.NewMockClient(t)
+	client.On("KVList", 0, mmapi.KVListPageSize).Return([]string{
+		"user_api_key_" + userID + "_openai",
+		"user_api_key_" + otherUserID + "_openai",
+		"integration_connection_v1_jira_" + userID,
+		"integration_connection_v1_jira_" + otherUserID,
+		"mcp_oauth_token_v1_" + userID + "_server",
+		"oauth_session_" + userID + "_state",
+		"mcp_oauth_client_v2_hash",
+		"standup_pending_v1_" + userID,
+		"standup_pending_v1_" + otherUserID,
+		"image_text_v1_" + userID,
+	}, nil)
+
+	s := New(nil, client)
+	keys, err := s.userKeys(userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"user_api_key_" + userID + "_openai",
+		"integration_connection_v1_jira_" + userID,
+		"mcp_oauth_token_v1_" + userID + "_server",
+		"oauth_session_" + userID + "_state",
+		"standup_pending_v1_" + userID,
+	}, keys)
+}
+
+func TestRawJSON(t *testing.T) {
+	for name, tc := range map[string]struct {
+		src      any
+		expected string
+	}{
+		"object":  {src: `{"thread_id":"abc"}`, expected: `{"state":{"thread_id":"abc"}}`},
+		"bytes":   {src: []byte(`["tag"]`), expected: `{"state":["tag"]}`},
+		"null":    {src: nil, expected: `{"state":null}`},
+		"invalid": {src: "not json", expected: `{"state":"not json"}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var value rawJSON
+			require.NoError(t, value.Scan(tc.src))
+			marshaled, err := json.Marshal(struct {
+				State rawJSON `json:"state"`
+			}{value})
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(marshaled))
+		})
+	}
+}
+
+func TestNotAvailableWithoutDatabase(t *testing.T) {
+	s := New(nil, mocks.NewMockClient(t))
+
+	_, err := s.Export(model.NewId())
+	assert.ErrorIs(t, err, ErrNotAvailable)
+	_, err = s.Erase(model.NewId())
+	assert.ErrorIs(t, err, ErrNotAvailable)
+}
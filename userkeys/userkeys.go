@@ -0,0 +1,199 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package userkeys stores the provider API keys users register for themselves, so their
+// requests are sent with their own key instead of the shared key of the service.
+package userkeys
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/secrets"
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+const kvKeyPrefix = "user_api_key_"
+
+// hintLength is the number of trailing characters of a key shown back to the user
+const hintLength = 4
+
+var (
+	// ErrEmptyKey is returned when saving an empty API key
+	ErrEmptyKey = errors.New("API key cannot be empty")
+)
+
+// KVStore is the storage needed by the store.
+type KVStore interface {
+	KVGet(key string, value interface{}) error
+	KVSet(key string, value interface{}) error
+	KVDelete(key string) error
+	KVList(page, perPage int) ([]string, error)
+}
+
+// KeyInfo describes a stored key without revealing it.
+type KeyInfo struct {
+	ServiceID string `json:"service_id"`
+	// Hint is the end of the key so users can tell which key is registered
+	Hint     string `json:"hint"`
+	CreateAt int64  `json:"create_at"`
+}
+
+type storedKey struct {
+	KeyInfo
+	// Secret is the API key encrypted by the keyring, bound to the KV key of the entry
+	Secret string `json:"secret"`
+}
+
+// Store persists encrypted user API keys in the plugin KV store.
+type Store struct {
+	kv      KVStore
+	keyring func() (*secrets.Keyring, error)
+}
+
+// New creates a new store. Keys are encrypted with the keyring returned by keyring, which is
+// read on every use so it follows configuration changes and key rotations.
+func New(kv KVStore, keyring func() (*secrets.Keyring, error)) *Store {
+	return &Store{
+		kv:      kv,
+		keyring: keyring,
+	}
+}
+
+func kvKey(userID, serviceID string) string {
+	return kvKeyPrefix + userID + "_" + serviceID
+}
+
+// IsUserKey reports whether the KV key stores an API key of the user
+func IsUserKey(key, userID string) bool {
+	return strings.HasPrefix(key, kvKeyPrefix+userID+"_")
+}
+
+func (s *Store) get(userID, serviceID string) (*storedKey, error) {
+	var stored *storedKey
+	if err := s.kv.KVGet(kvKey(userID, serviceID), &stored); err != nil {
+		return nil, fmt.Errorf("failed to get user API key: %w", err)
+	}
+	return stored, nil
+}
+
+// Save encrypts and stores the user's API key for the service, replacing any previous key.
+func (s *Store) Save(userID, serviceID, apiKey string) (*KeyInfo, error) {
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return nil, ErrEmptyKey
+	}
+
+	keyring, err := s.keyring()
+	if err != nil {
+		return nil, err
+	}
+	key := kvKey(userID, serviceID)
+	secret, err := keyring.EncryptBound(apiKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt user API key: %w", err)
+	}
+
+	hint := apiKey
+	if len(hint) > hintLength {
+		hint = hint[len(hint)-hintLength:]
+	}
+
+	stored := &storedKey{
+		KeyInfo: KeyInfo{
+			ServiceID: serviceID,
+			Hint:      hint,
+			CreateAt:  model.GetMillis(),
+		},
+		Secret: secret,
+	}
+	if err := s.kv.KVSet(key, stored); err != nil {
+		return nil, fmt.Errorf("failed to save user API key: %w", err)
+	}
+
+	return &stored.KeyInfo, nil
+}
+
+// GetInfo returns the description of the user's key for the service, or nil if none is set.
+func (s *Store) GetInfo(userID, serviceID string) (*KeyInfo, error) {
+	stored, err := s.get(userID, serviceID)
+	if err != nil || stored == nil {
+		return nil, err
+	}
+	return &stored.KeyInfo, nil
+}
+
+// GetAPIKey returns the user's decrypted key for the service, or an empty string if none is set.
+func (s *Store) GetAPIKey(userID, serviceID string) (string, error) {
+	stored, err := s.get(userID, serviceID)
+	if err != nil || stored == nil {
+		return "", err
+	}
+
+	if !secrets.IsEncrypted(stored.Secret) {
+		return "", errors.New("stored user API key is malformed")
+	}
+
+	keyring, err := s.keyring()
+	if err != nil {
+		return "", err
+	}
+	apiKey, err := keyring.DecryptBound(stored.Secret, kvKey(userID, serviceID))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt user API key: %w", err)
+	}
+
+	return apiKey, nil
+}
+
+// Delete removes the user's key for the service.
+func (s *Store) Delete(userID, serviceID string) error {
+	if err := s.kv.KVDelete(kvKey(userID, serviceID)); err != nil {
+		return fmt.Errorf("failed to delete user API key: %w", err)
+	}
+	return nil
+}
+
+// Rotate re-encrypts every stored key with the current key of the keyring, returning the number
+// of rotated keys. Keys that fail to rotate are left unchanged and reported in the error.
+func (s *Store) Rotate() (int, error) {
+	keyring, err := s.keyring()
+	if err != nil {
+		return 0, err
+	}
+
+	keys, err := mmapi.KVListMatching(s.kv, func(key string) bool {
+		return strings.HasPrefix(key, kvKeyPrefix)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	var errs []error
+	for _, key := range keys {
+		var stored *storedKey
+		if err := s.kv.KVGet(key, &stored); err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to get user API key: %w", key, err))
+			continue
+		}
+		if stored == nil || keyring.IsCurrent(stored.Secret) {
+			continue
+		}
+		secret, err := keyring.RotateBound(stored.Secret, key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		stored.Secret = secret
+		if err := s.kv.KVSet(key, stored); err != nil {
+			errs = append(errs, fmt.Errorf("%s: failed to save user API key: %w", key, err))
+			continue
+		}
+		rotated++
+	}
+
+	return rotated, errors.Join(errs...)
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package userkeys
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/mmapi/mmapitest"
+	"github.com/mattermost/mattermost-plugin-ai/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keyring returns a keyring with keys derived from the passphrases
+func keyring(current string, previous ...string) func() (*secrets.Keyring, error) {
+	return func() (*secrets.Keyring, error) {
+		return secrets.NewPassphraseKeyring(current, previous)
+	}
+}
+
+func TestSaveAndGet(t *testing.T) {
+	kv := mmapitest.NewMemoryKV()
+	store := New(kv, keyring("at-rest-secret"))
+
+	info, err := store.Save("user1", "service1", "  sk-test-1234abcd  ")
+	require.NoError(t, err)
+	assert.Equal(t, "service1", info.ServiceID)
+	assert.Equal(t, "abcd", info.Hint)
+	assert.NotZero(t, info.CreateAt)
+
+	apiKey, err := store.GetAPIKey("user1", "service1")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-test-1234abcd", apiKey)
+
+	gotInfo, err := store.GetInfo("user1", "service1")
+	require.NoError(t, err)
+	assert.Equal(t, info, gotInfo)
+
+	// The key is never stored in plain text
+	for _, data := range kv.Values {
+		assert.False(t, strings.Contains(string(data), "sk-test-1234abcd"))
+	}
+
+	// Keys are isolated per user and service
+	apiKey, err = store.GetAPIKey("user2", "service1")
+	require.NoError(t, err)
+	assert.Empty(t, apiKey)
+	apiKey, err = store.GetAPIKey("user1", "service2")
+	require.NoError(t, err)
+	assert.Empty(t, apiKey)
+}
+
+func TestSaveErrors(t *testing.T) {
+	_, err := New(mmapitest.NewMemoryKV(), keyring("secret")).Save("user1", "service1", "   ")
+	assert.ErrorIs(t, err, ErrEmptyKey)
+
+	_, err = New(mmapitest.NewMemoryKV(), keyring("")).Save("user1", "service1", "sk-test")
+	assert.ErrorIs(t, err, secrets.ErrNoPassphrase)
+}
+
+func TestGetWithChangedEncryptionKey(t *testing.T) {
+	kv := mmapitest.NewMemoryKV()
+	_, err := New(kv, keyring("old-secret")).Save("user1", "service1", "sk-test")
+	require.NoError(t, err)
+
+	_, err = New(kv, keyring("new-secret")).GetAPIKey("user1", "service1")
+	assert.Error(t, err)
+}
+
+func TestCiphertextBoundToUser(t *testing.T) {
+	kv := mmapitest.NewMemoryKV()
+	store := New(kv, keyring("secret"))
+	_, err := store.Save("user1", "service1", "sk-test")
+	require.NoError(t, err)
+
+	// A key copied to another user's entry can not be decrypted
+	kv.Values[kvKey("user2", "service1")] = kv.Values[kvKey("user1", "service1")]
+	_, err = store.GetAPIKey("user2", "service1")
+	assert.Error(t, err)
+}
+
+func TestDelete(t *testing.T) {
+	store := New(mmapitest.NewMemoryKV(), keyring("secret"))
+	_, err := store.Save("user1", "service1", "sk-test")
+	require.NoError(t, err)
+
+	require.NoError(t, store.Delete("user1", "service1"))
+
+	info, err := store.GetInfo("user1", "service1")
+	require.NoError(t, err)
+	assert.Nil(t, info)
+}
+
+func TestRotate(t *testing.T) {
+	kv := mmapitest.NewMemoryKV()
+	_, err := New(kv, keyring("old-secret")).Save("user1", "service1", "sk-test-1")
+	require.NoError(t, err)
+	_, err = New(kv, keyring("old-secret")).Save("user2", "service1", "sk-test-2")
+	require.NoError(t, err)
+
+	// Keys encrypted with a previous key are read until rotated
+	store := New(kv, keyring("new-secret", "old-secret"))
+	apiKey, err := store.GetAPIKey("user1", "service1")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-test-1", apiKey)
+
+	rotated, err := store.Rotate()
+	require.NoError(t, err)
+	assert.Equal(t, 2, rotated)
+
+	// Rotated keys no longer need the previous key
+	store = New(kv, keyring("new-secret"))
+	apiKey, err = store.GetAPIKey("user2", "service1")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-test-2", apiKey)
+
+	rotated, err = store.Rotate()
+	require.NoError(t, err)
+	assert.Zero(t, rotated)
+}
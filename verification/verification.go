@@ -0,0 +1,250 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+// Package verification reviews the responses of the features answering from retrieved content,
+// such as channel analysis, against that content before they are finalized. A checker agent
+// lists the claims of the draft the sources don't support, and they are flagged at the end of
+// the response.
+package verification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
+	"github.com/mattermost/mattermost-plugin-ai/bots"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	"github.com/mattermost/mattermost-plugin-ai/mmapi"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+)
+
+const (
+	checkTimeout = 2 * time.Minute
+	// maxFlaggedClaims bounds the claims listed under a response
+	maxFlaggedClaims = 10
+)
+
+// SupportedFeatures are the features whose responses can be verified
+var SupportedFeatures = []string{
+	analytics.FeatureChannelAnalysis,
+	analytics.FeatureChannelInterval,
+	analytics.FeatureThreadAnalysis,
+}
+
+// checkResult is the structured output requested from the checker agent
+type checkResult struct {
+	UnsupportedClaims []string `json:"unsupported_claims"`
+}
+
+// Service verifies the responses of the features enabled in the configuration.
+type Service struct {
+	getConfig func() config.VerificationConfig
+	bots      *bots.MMBots
+	prompts   *llm.Prompts
+	i18n      *i18n.Bundle
+	client    mmapi.Client
+}
+
+// New creates a new verification service reading the verified features from getConfig.
+func New(getConfig func() config.VerificationConfig, bots *bots.MMBots, prompts *llm.Prompts, i18nBundle *i18n.Bundle, client mmapi.Client) *Service {
+	return &Service{
+		getConfig: getConfig,
+		bots:      bots,
+		prompts:   prompts,
+		i18n:      i18nBundle,
+		client:    client,
+	}
+}
+
+// Wrap returns the model the feature generates its responses with, verifying the streamed
+// responses when the feature is enabled. The model is returned unchanged otherwise.
+func (s *Service) Wrap(model llm.LanguageModel, feature string) llm.LanguageModel {
+	if s == nil || !slices.Contains(s.getConfig().Features, feature) {
+		return model
+	}
+	return &verifiedModel{
+		LanguageModel: model,
+		service:       s,
+		feature:       feature,
+	}
+}
+
+// verifiedModel verifies the responses streamed by the wrapped model
+type verifiedModel struct {
+	llm.LanguageModel
+	service *Service
+	feature string
+}
+
+func (m *verifiedModel) ChatCompletion(ctx context.Context, request llm.CompletionRequest, opts ...llm.LanguageModelOption) (*llm.TextStreamResult, error) {
+	sources := newSources(request)
+	if request.Context != nil && request.Context.Tools != nil {
+		request.Context.Tools.AddResultObserver(sources.addToolResult)
+	}
+
+	result, err := m.LanguageModel.ChatCompletion(ctx, request, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	interceptedStream := make(chan llm.TextStreamEvent)
+	go func() {
+		defer close(interceptedStream)
+
+		var draft strings.Builder
+		for event := range result.Stream {
+			switch event.Type {
+			case llm.EventTypeText:
+				if text, ok := event.Value.(string); ok {
+					draft.WriteString(text)
+				}
+			case llm.EventTypeEnd:
+				// Flag the unsupported claims before the response is finalized
+				if note := m.service.verify(ctx, m.LanguageModel, m.feature, request, sources.String(), draft.String()); note != "" {
+					interceptedStream <- llm.TextStreamEvent{Type: llm.EventTypeText, Value: note}
+				}
+			}
+			interceptedStream <- event
+		}
+	}()
+
+	return &llm.TextStreamResult{Stream: interceptedStream}, nil
+}
+
+// verify returns the note listing the claims of the draft the sources don't support, empty when
+// they are all supported or the check failed.
+func (s *Service) verify(ctx context.Context, model llm.LanguageModel, feature string, request llm.CompletionRequest, sources, draft string) string {
+	if strings.TrimSpace(draft) == "" {
+		return ""
+	}
+
+	claims, err := s.check(ctx, model, request, sources, draft)
+	if err != nil {
+		s.client.LogWarn("Failed to verify response", "feature", feature, "error", err)
+		return ""
+	}
+	if len(claims) == 0 {
+		return ""
+	}
+
+	locale := ""
+	if request.Context != nil {
+		locale = request.Context.Locale
+	}
+	T := i18n.LocalizerFunc(s.i18n, locale)
+
+	var note strings.Builder
+	note.WriteString("\n\n---\n")
+	note.WriteString(T("agents.verification.unsupported_claims", "**Verification:** the following statements could not be verified against the sources and may be inaccurate:"))
+	note.WriteString("\n")
+	for _, claim := range claims {
+		note.WriteString("- ")
+		note.WriteString(claim)
+		note.WriteString("\n")
+	}
+	return note.String()
+}
+
+// check asks the checker agent, or the agent answering when none is configured, which claims
+// of the draft the sources don't support.
+func (s *Service) check(ctx context.Context, model llm.LanguageModel, request llm.CompletionRequest, sources, draft string) ([]string, error) {
+	llmContext := llm.NewContext()
+	if request.Context != nil {
+		llmContext.BotName = request.Context.BotName
+		llmContext.BotUsername = request.Context.BotUsername
+		llmContext.RequestingUser = request.Context.RequestingUser
+		llmContext.Channel = request.Context.Channel
+		llmContext.Priority = request.Context.Priority
+	}
+
+	if checkerBot := s.getConfig().CheckerBot; checkerBot != "" {
+		checker := s.bots.GetBotByUsername(checkerBot)
+		if checker == nil {
+			return nil, fmt.Errorf("checker agent %s not found", checkerBot)
+		}
+		model = checker.LLM()
+		llmContext.BotName = checker.GetConfig().DisplayName
+		llmContext.BotUsername = checker.GetConfig().Name
+	}
+
+	systemPrompt, err := s.prompts.Format(prompts.PromptVerificationCheckSystem, llmContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format prompt: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	result, err := model.ChatCompletionNoStream(ctx, llm.CompletionRequest{
+		Posts: []llm.Post{
+			{Role: llm.PostRoleSystem, Message: systemPrompt},
+			{Role: llm.PostRoleUser, Message: fmt.Sprintf("Sources:\n%s\n\nDraft response:\n%s", sources, draft)},
+		},
+		Context: llmContext,
+	},
+		llm.WithMaxGeneratedTokens(1000),
+		llm.WithReasoningDisabled(),
+		llm.WithToolsDisabled(),
+		llm.WithJSONOutput[checkResult](),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check response: %w", err)
+	}
+
+	var checked checkResult
+	if err := json.Unmarshal([]byte(result), &checked); err != nil {
+		return nil, fmt.Errorf("failed to parse check result: %w", err)
+	}
+
+	claims := make([]string, 0, len(checked.UnsupportedClaims))
+	for _, claim := range checked.UnsupportedClaims {
+		claim = strings.Join(strings.Fields(claim), " ")
+		if claim != "" && len(claims) < maxFlaggedClaims {
+			claims = append(claims, claim)
+		}
+	}
+
+	return claims, nil
+}
+
+// sources collects the content a response is generated from: the conversation sent to the model
+// and the results of the tools it calls.
+type sources struct {
+	mu      sync.Mutex
+	content strings.Builder
+}
+
+func newSources(request llm.CompletionRequest) *sources {
+	s := &sources{}
+	for _, post := range request.Posts {
+		if post.Role == llm.PostRoleSystem {
+			continue
+		}
+		s.content.WriteString(post.Message)
+		s.content.WriteString("\n\n")
+	}
+	return s
+}
+
+func (s *sources) addToolResult(name, result string, err error) {
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content.WriteString(fmt.Sprintf("Result of the %s tool:\n%s\n\n", name, result))
+}
+
+func (s *sources) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.content.String()
+}
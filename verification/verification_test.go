@@ -0,0 +1,105 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package verification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-ai/analytics"
+	"github.com/mattermost/mattermost-plugin-ai/config"
+	"github.com/mattermost/mattermost-plugin-ai/i18n"
+	"github.com/mattermost/mattermost-plugin-ai/llm"
+	llmmocks "github.com/mattermost/mattermost-plugin-ai/llm/mocks"
+	"github.com/mattermost/mattermost-plugin-ai/prompts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T, features ...string) *Service {
+	promptsObj, err := llm.NewPrompts(prompts.PromptsFolder)
+	require.NoError(t, err)
+	return New(func() config.VerificationConfig {
+		return config.VerificationConfig{Features: features}
+	}, nil, promptsObj, i18n.Init(), nil)
+}
+
+func TestWrap(t *testing.T) {
+	languageModel := llmmocks.NewMockLanguageModel(t)
+
+	var nilService *Service
+	assert.Same(t, languageModel, nilService.Wrap(languageModel, analytics.FeatureChannelAnalysis))
+
+	s := newTestService(t, analytics.FeatureChannelAnalysis)
+	assert.Same(t, languageModel, s.Wrap(languageModel, analytics.FeatureThreadAnalysis))
+	assert.NotSame(t, languageModel, s.Wrap(languageModel, analytics.FeatureChannelAnalysis))
+}
+
+func TestVerifiedModel(t *testing.T) {
+	tests := []struct {
+		name         string
+		checkResult  string
+		expectedNote bool
+	}{
+		{name: "supported claims are not flagged", checkResult: `{"unsupported_claims": []}`},
+		{name: "unsupported claims are flagged", checkResult: `{"unsupported_claims": ["The release is on May 3"]}`, expectedNote: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			languageModel := llmmocks.NewMockLanguageModel(t)
+			languageModel.EXPECT().ChatCompletion(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(llm.NewStreamFromString("The release is on May 3."), nil).Once()
+			languageModel.EXPECT().ChatCompletionNoStream(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, request llm.CompletionRequest, _ ...llm.LanguageModelOption) (string, error) {
+				require.Len(t, request.Posts, 2)
+				assert.Contains(t, request.Posts[1].Message, "alice: the release slipped")
+				assert.Contains(t, request.Posts[1].Message, "The release is on May 3.")
+				return test.checkResult, nil
+			}).Once()
+
+			s := newTestService(t, analytics.FeatureChannelInterval)
+			verified := s.Wrap(languageModel, analytics.FeatureChannelInterval)
+			result, err := verified.ChatCompletion(context.Background(), llm.CompletionRequest{
+				Posts: []llm.Post{
+					{Role: llm.PostRoleSystem, Message: "Summarize the posts."},
+					{Role: llm.PostRoleUser, Message: "alice: the release slipped"},
+				},
+				Context: llm.NewContext(),
+			})
+			require.NoError(t, err)
+
+			text, err := result.ReadAll()
+			require.NoError(t, err)
+			if !test.expectedNote {
+				assert.Equal(t, "The release is on May 3.", text)
+				return
+			}
+			assert.Contains(t, text, "The release is on May 3.\n\n---\n**Verification:**")
+			assert.Contains(t, text, "- The release is on May 3\n")
+		})
+	}
+}
+
+func TestSourcesIncludeToolResults(t *testing.T) {
+	tools := llm.NewNoTools()
+	tools.AddTools([]llm.Tool{{
+		Name: "read_channel",
+		Resolver: func(*llm.Context, llm.ToolArgumentGetter) (string, error) {
+			return "bob: we ship on Friday", nil
+		},
+	}})
+
+	collected := newSources(llm.CompletionRequest{Posts: []llm.Post{
+		{Role: llm.PostRoleSystem, Message: "instructions"},
+		{Role: llm.PostRoleUser, Message: "Please summarize the channel activity as requested."},
+	}})
+	tools.AddResultObserver(collected.addToolResult)
+
+	_, err := tools.ResolveTool("read_channel", func(any) error { return nil }, nil)
+	require.NoError(t, err)
+
+	assert.NotContains(t, collected.String(), "instructions")
+	assert.Contains(t, collected.String(), "Please summarize the channel activity as requested.")
+	assert.Contains(t, collected.String(), "Result of the read_channel tool:\nbob: we ship on Friday")
+}
@@ -26,6 +26,34 @@ function channelRoute(channelid: string): string {
     return `${baseRoute()}/channel/${channelid}`;
 }
 
+// errorFromResponse reads the structured error returned by the API so callers can localize it from its code.
+async function errorFromResponse(url: string, response: Response) {
+    const body = await response.json().catch(() => null);
+    return new ClientError(Client4.url, {
+        message: body?.message || '',
+        server_error_id: body?.code,
+        status_code: response.status,
+        url,
+    });
+}
+
+// fetchConfirmingPolicy sends a request reading the content of a channel. When the policy of a shared channel
+// or a channel with guests requires it, the user is asked to confirm and the request is sent again.
+async function fetchConfirmingPolicy(url: string, options: RequestInit) {
+    const response = await fetch(url, Client4.getOptions(options));
+    if (response.status !== 428) {
+        return {url, response};
+    }
+
+    const error = await errorFromResponse(url, response);
+    // eslint-disable-next-line no-alert
+    if (!window.confirm(error.message)) {
+        throw error;
+    }
+    const confirmedURL = `${url}${url.includes('?') ? '&' : '?'}confirm_policy=true`;
+    return {url: confirmedURL, response: await fetch(confirmedURL, Client4.getOptions(options))};
+}
+
 export async function doReaction(postid: string) {
     const url = `${postRoute(postid)}/react`;
     const response = await fetch(url, Client4.getOptions({
@@ -36,52 +64,38 @@ export async function doReaction(postid: string) {
         return;
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
 
 export async function doThreadAnalysis(postid: string, analysisType: string, botUsername: string) {
-    const url = `${postRoute(postid)}/analyze?botUsername=${botUsername}`;
-    const response = await fetch(url, Client4.getOptions({
+    const {url, response} = await fetchConfirmingPolicy(`${postRoute(postid)}/analyze?botUsername=${botUsername}`, {
         method: 'POST',
         body: JSON.stringify({
             analysis_type: analysisType,
         }),
-    }));
+    });
 
     if (response.ok) {
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
 
 export async function doChannelAnalysis(channelId: string, analysisType: string, botUsername: string, options?: any) {
-    const url = `${channelRoute(channelId)}/analyze?botUsername=${botUsername}`;
-    const response = await fetch(url, Client4.getOptions({
+    const {url, response} = await fetchConfirmingPolicy(`${channelRoute(channelId)}/analyze?botUsername=${botUsername}`, {
         method: 'POST',
         body: JSON.stringify({
             analysis_type: analysisType,
             ...options,
         }),
-    }));
+    });
 
     if (response.ok) {
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
 
 export async function doTranscribe(postid: string, fileID: string) {
@@ -94,11 +108,7 @@ export async function doTranscribe(postid: string, fileID: string) {
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
 
 export async function doSummarizeTranscription(postid: string) {
@@ -111,11 +121,56 @@ export async function doSummarizeTranscription(postid: string) {
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
+    throw await errorFromResponse(url, response);
+}
+
+export async function doTranslateTranscription(postid: string, language: string, botUsername: string) {
+    const {url, response} = await fetchConfirmingPolicy(`${postRoute(postid)}/translate_transcription?botUsername=${botUsername}`, {
+        method: 'POST',
+        body: JSON.stringify({
+            language,
+        }),
+    });
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw await errorFromResponse(url, response);
+}
+
+export type DraftReplyOptions = {
+    intent: string;
+    tone?: string;
+    persona?: string;
+};
+
+// doDraftReply drafts a reply to the thread of the post, returned for the composer rather than posted
+export async function doDraftReply(postid: string, options: DraftReplyOptions, botUsername: string): Promise<{draft: string}> {
+    const {url, response} = await fetchConfirmingPolicy(`${postRoute(postid)}/draft_reply?botUsername=${botUsername}`, {
+        method: 'POST',
+        body: JSON.stringify(options),
     });
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw await errorFromResponse(url, response);
+}
+
+// doExplainPost explains the post in a direct message from the bot, simply as to someone new to its subject with simplify
+export async function doExplainPost(postid: string, simplify: boolean, botUsername: string): Promise<{postid: string, channelid: string}> {
+    const action = simplify ? 'simplify' : 'explain';
+    const {url, response} = await fetchConfirmingPolicy(`${postRoute(postid)}/${action}?botUsername=${botUsername}`, {
+        method: 'POST',
+    });
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw await errorFromResponse(url, response);
 }
 
 export async function doStopGenerating(postid: string) {
@@ -128,11 +183,7 @@ export async function doStopGenerating(postid: string) {
         return;
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
 
 export async function doRegenerate(postid: string) {
@@ -145,11 +196,20 @@ export async function doRegenerate(postid: string) {
         return;
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
+}
+
+export async function doRegenerateTitle(postid: string) {
+    const url = `${postRoute(postid)}/regenerate_title`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw await errorFromResponse(url, response);
 }
 
 export async function doToolCall(postid: string, toolIDs: string[]) {
@@ -165,11 +225,7 @@ export async function doToolCall(postid: string, toolIDs: string[]) {
         return;
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
 
 export async function doPostbackSummary(postid: string) {
@@ -182,11 +238,7 @@ export async function doPostbackSummary(postid: string) {
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
 
 export async function viewMyChannel(channelID: string) {
@@ -214,11 +266,7 @@ export async function getAIThreads() {
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
 
 export async function getAIBots() {
@@ -231,11 +279,21 @@ export async function getAIBots() {
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
+}
+
+// resolveAIBot returns the agent the routing rules choose for the current user in the channel, and why.
+export async function resolveAIBot(channelID: string) {
+    const url = `${baseRoute()}/ai_bots/resolve?channel_id=${encodeURIComponent(channelID)}`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'GET',
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw await errorFromResponse(url, response);
 }
 
 export async function createPost(post: any) {
@@ -274,11 +332,24 @@ export async function doRunSearch(query: string, teamId: string, channelId: stri
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
+}
+
+export async function doSummarizeMentions(startTime: number, endTime: number, botUsername?: string): Promise<{postid: string, channelid: string}> {
+    const url = `${baseRoute()}/mentions/summarize${botUsername ? `?botUsername=${botUsername}` : ''}`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'POST',
+        body: JSON.stringify({
+            start_time: startTime,
+            end_time: endTime,
+        }),
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw await errorFromResponse(url, response);
 }
 
 export async function setUserProfilePictureByUsername(username: string, file: File) {
@@ -357,11 +428,7 @@ export async function doReindexPosts() {
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
 
 export async function getReindexStatus() {
@@ -374,11 +441,7 @@ export async function getReindexStatus() {
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
 
 export async function cancelReindex() {
@@ -391,11 +454,7 @@ export async function cancelReindex() {
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
 
 export async function getMCPTools() {
@@ -408,11 +467,7 @@ export async function getMCPTools() {
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
 
 export async function clearMCPToolsCache() {
@@ -425,11 +480,7 @@ export async function clearMCPToolsCache() {
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
 
 export async function fetchModels(serviceType: string, apiKey: string, apiURL: string, orgID: string) {
@@ -448,11 +499,20 @@ export async function fetchModels(serviceType: string, apiKey: string, apiURL: s
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
+}
+
+export async function getModelWarnings() {
+    const url = `${baseRoute()}/admin/models/warnings`;
+    const response = await fetch(url, Client4.getOptions({
+        method: 'GET',
+    }));
+
+    if (response.ok) {
+        return response.json();
+    }
+
+    throw await errorFromResponse(url, response);
 }
 
 export async function getChannelInterval(
@@ -463,8 +523,7 @@ export async function getChannelInterval(
     prompt?: string,
     botUsername?: string,
 ) {
-    const url = `${channelRoute(channelID)}/interval${botUsername ? `?botUsername=${botUsername}` : ''}`;
-    const response = await fetch(url, Client4.getOptions({
+    const {url, response} = await fetchConfirmingPolicy(`${channelRoute(channelID)}/interval${botUsername ? `?botUsername=${botUsername}` : ''}`, {
         method: 'POST',
         body: JSON.stringify({
             start_time: startTime,
@@ -472,15 +531,11 @@ export async function getChannelInterval(
             preset_prompt: presetPrompt,
             prompt: prompt || '',
         }),
-    }));
+    });
 
     if (response.ok) {
         return response.json();
     }
 
-    throw new ClientError(Client4.url, {
-        message: '',
-        status_code: response.status,
-        url,
-    });
+    throw await errorFromResponse(url, response);
 }
@@ -1,7 +1,7 @@
 // Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
 // See LICENSE.txt for license information.
 
-import {doRunSearch, getChannelInterval} from './client';
+import {doRunSearch, doSummarizeMentions, getChannelInterval} from './client';
 import {doSelectPost} from './hooks';
 
 export async function handleAskChannelCommand(
@@ -94,6 +94,30 @@ export async function handleSummarizeChannelCommand(
     }
 }
 
+export async function handleSummarizeMentionsCommand(
+    message: string,
+    store: any,
+    rhs: { showRHSPlugin: any },
+) {
+    const options = parseOptionsFromMessage(message);
+    const timeSince = calculateTimeSince(options.period || '24h');
+
+    try {
+        const result = await doSummarizeMentions(timeSince, 0, options.bot || '');
+
+        doSelectPost(result.postid, result.channelid, store.dispatch);
+        store.dispatch(rhs.showRHSPlugin);
+
+        return {};
+    } catch (error) {
+        return {
+            error: {
+                message: 'Failed to summarize mentions ' + error,
+            },
+        };
+    }
+}
+
 // Parses options from the command message
 function parseOptionsFromMessage(message: string): { bot?: string; period?: string } {
     const options: { bot?: string; period?: string } = {};
@@ -30,6 +30,8 @@ export interface PostUpdateWebsocketMessage {
     tool_call?: string
     reasoning?: string
     annotations?: string
+    tool?: string
+    display?: string
 }
 
 export enum ToolCallStatus {
@@ -93,6 +95,9 @@ export const LLMBotPost = (props: LLMBotPostProps) => {
     // Precontent is true when we're waiting for the first content to arrive
     // Initialize to true if post is empty AND has no reasoning AND no tool calls AND no annotations (fresh post)
     const persistedReasoning = props.post.props?.reasoning_summary || '';
+
+    // How the bot's reasoning is shown: 'summary' collapsed, 'full' expanded or 'hidden'
+    const reasoningDisplay = props.post.props?.reasoning_display || 'summary';
     const [precontent, setPrecontent] = useState(
         props.post.message === '' &&
         persistedReasoning === '' &&
@@ -100,6 +105,9 @@ export const LLMBotPost = (props: LLMBotPostProps) => {
         annotations.length === 0,
     );
 
+    // Name of the tool running while the response waits for its result
+    const [runningTool, setRunningTool] = useState('');
+
     // Stopped is a flag that is used to prevent the websocket from updating the message after the user has stopped the generation
     // Needs a ref because of the useEffect closure.
     const [stopped, setStopped] = useState(false);
@@ -112,9 +120,13 @@ export const LLMBotPost = (props: LLMBotPostProps) => {
     // Use the same persistedReasoning from above
     const [reasoningSummary, setReasoningSummary] = useState(persistedReasoning);
     const [showReasoning, setShowReasoning] = useState(persistedReasoning !== '');
-    const [isReasoningCollapsed, setIsReasoningCollapsed] = useState(true);
+    const [isReasoningCollapsed, setIsReasoningCollapsed] = useState(reasoningDisplay !== 'full');
     const [isReasoningLoading, setIsReasoningLoading] = useState(false);
 
+    // Needs a ref because of the useEffect closure.
+    const showReasoningRef = useRef(showReasoning);
+    showReasoningRef.current = showReasoning;
+
     const currentUserId = useSelector<GlobalState, string>((state) => state.entities.users.currentUserId);
     const rootPost = useSelector<GlobalState, any>((state) => state.entities.posts.posts[props.post.root_id]);
 
@@ -126,7 +138,7 @@ export const LLMBotPost = (props: LLMBotPostProps) => {
             if (persistedReasoning) {
                 setReasoningSummary(persistedReasoning);
                 setShowReasoning(true);
-                setIsReasoningCollapsed(true);
+                setIsReasoningCollapsed(reasoningDisplay !== 'full');
                 setIsReasoningLoading(false);
             } else {
                 // Reset reasoning state for posts without reasoning
@@ -213,6 +225,11 @@ export const LLMBotPost = (props: LLMBotPostProps) => {
                 if (data.control === 'reasoning_summary' && data.reasoning) {
                     // Replace entire reasoning with accumulated text from backend
                     setReasoningSummary(data.reasoning);
+
+                    // Bots showing their full reasoning start it expanded
+                    if (!showReasoningRef.current && data.display === 'full') {
+                        setIsReasoningCollapsed(false);
+                    }
                     setShowReasoning(true);
                     setIsReasoningLoading(true);
 
@@ -244,6 +261,13 @@ export const LLMBotPost = (props: LLMBotPostProps) => {
                     return;
                 }
 
+                // Show the tool the bot is running until the response continues
+                if (data.control === 'tool_running' && data.tool) {
+                    setRunningTool(data.tool);
+                    setPrecontent(false);
+                    return;
+                }
+
                 // Handle annotation events from the websocket
                 if (data.control === 'annotations' && data.annotations) {
                     try {
@@ -261,20 +285,24 @@ export const LLMBotPost = (props: LLMBotPostProps) => {
                 if (data.next && !stoppedRef.current) {
                     setGenerating(true);
                     setPrecontent(false);
+                    setRunningTool('');
                     setMessage(data.next);
                 } else if (data.control === 'end') {
                     setGenerating(false);
                     setPrecontent(false);
+                    setRunningTool('');
                     setStopped(false);
                     setIsReasoningLoading(false);
                 } else if (data.control === 'cancel') {
                     setGenerating(false);
                     setPrecontent(false);
+                    setRunningTool('');
                     setStopped(false);
                     setIsReasoningLoading(false);
                 } else if (data.control === 'start') {
                     setGenerating(true);
                     setPrecontent(true);
+                    setRunningTool('');
                     setStopped(false);
 
                     // Clear reasoning when starting new generation
@@ -355,7 +383,7 @@ export const LLMBotPost = (props: LLMBotPostProps) => {
     }
 
     // Consider both generating and reasoning loading states for determining if generation is in progress
-    const isGenerationInProgress = generating || isReasoningLoading;
+    const isGenerationInProgress = generating || isReasoningLoading || runningTool !== '';
 
     const showRegenerate = !isGenerationInProgress && requesterIsCurrentUser && !isNoShowRegen;
     const showPostbackButton = !isGenerationInProgress && requesterIsCurrentUser && isTranscriptionResult;
@@ -363,6 +391,13 @@ export const LLMBotPost = (props: LLMBotPostProps) => {
     const hasContent = message !== '' || reasoningSummary !== '';
     const showControlsBar = ((showRegenerate || showPostbackButton) && hasContent) || showStopGeneratingButton;
 
+    // Completed responses have their citations rendered into the message as footnotes by the server
+    const citationsRendered = Boolean(props.post.props?.citations_rendered);
+
+    // The model reported it lacked the context to answer well
+    const showInsufficientContext = !isGenerationInProgress && props.post.props?.context_sufficiency === 'insufficient';
+    const contextMissing = props.post.props?.context_missing || '';
+
     return (
         <PostBody
             data-testid='llm-bot-post'
@@ -373,7 +408,7 @@ export const LLMBotPost = (props: LLMBotPostProps) => {
                 {permalinkView}
             </>
             }
-            {showReasoning && (
+            {showReasoning && reasoningDisplay !== 'hidden' && (
                 <ReasoningDisplay
                     reasoningSummary={reasoningSummary}
                     isReasoningCollapsed={isReasoningCollapsed}
@@ -381,11 +416,18 @@ export const LLMBotPost = (props: LLMBotPostProps) => {
                     onToggleCollapse={setIsReasoningCollapsed}
                 />
             )}
-            {precontent && (
+            {(precontent || runningTool) && (
                 <MinimalReasoningContainer>
                     <SpinnerWrapper><LoadingSpinner/></SpinnerWrapper>
                     <span>
-                        <FormattedMessage defaultMessage='Starting...'/>
+                        {runningTool ? (
+                            <FormattedMessage
+                                defaultMessage='Running {tool}...'
+                                values={{tool: runningTool}}
+                            />
+                        ) : (
+                            <FormattedMessage defaultMessage='Starting...'/>
+                        )}
                     </span>
                 </MinimalReasoningContainer>
             )}
@@ -393,14 +435,29 @@ export const LLMBotPost = (props: LLMBotPostProps) => {
                 message={message}
                 channelID={props.post.channel_id}
                 postID={props.post.id}
-                showCursor={generating && !precontent}
-                annotations={annotations.length > 0 ? annotations : undefined} // eslint-disable-line no-undefined
+                showCursor={generating && !precontent && !runningTool}
+                annotations={!citationsRendered && annotations.length > 0 ? annotations : undefined} // eslint-disable-line no-undefined
             />
             {props.post.props?.[SearchResultsPropKey] && (
                 <SearchSources
                     sources={JSON.parse(props.post.props[SearchResultsPropKey])}
                 />
             )}
+            {showInsufficientContext && (
+                <InsufficientContextBadge data-testid='llm-bot-post-insufficient-context'>
+                    <i className='icon icon-alert-outline'/>
+                    <span>
+                        {contextMissing ? (
+                            <FormattedMessage
+                                defaultMessage='This answer may be incomplete. Missing context: {missing}'
+                                values={{missing: contextMissing}}
+                            />
+                        ) : (
+                            <FormattedMessage defaultMessage='This answer may be incomplete because some context was missing.'/>
+                        )}
+                    </span>
+                </InsufficientContextBadge>
+            )}
             {toolCalls && toolCalls.length > 0 && (
                 <ToolApprovalSet
                     postID={props.post.id}
@@ -449,3 +506,15 @@ const PostSummaryHelpMessage = styled.div`
 	margin-top: 16px;
 `;
 
+const InsufficientContextBadge = styled.div`
+	display: inline-flex;
+	align-items: center;
+	gap: 4px;
+	margin-top: 8px;
+	padding: 2px 8px;
+	border-radius: 4px;
+	font-size: 12px;
+	line-height: 16px;
+	color: var(--center-channel-color);
+	background: rgba(var(--away-indicator-rgb), 0.16);
+`;
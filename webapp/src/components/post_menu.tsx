@@ -8,7 +8,7 @@ import {Post} from '@mattermost/types/posts';
 
 import styled from 'styled-components';
 
-import {doReaction, doThreadAnalysis} from '../client';
+import {doExplainPost, doReaction, doThreadAnalysis, doTranslateTranscription} from '../client';
 
 import {useSelectPost} from '@/hooks';
 
@@ -41,6 +41,19 @@ const PostMenu = (props: Props) => {
         selectPost(result.postid, result.channelid);
     };
 
+    const explainPost = async (simplify: boolean) => {
+        const result = await doExplainPost(post.id, simplify, activeBot?.username || '');
+        selectPost(result.postid, result.channelid);
+    };
+
+    // Transcriptions are translated into the language of the user's locale
+    const hasCaptions = Array.isArray(post.props?.captions) && post.props.captions.length > 0;
+    const language = new Intl.DisplayNames(['en'], {type: 'language'}).of(intl.locale) ?? intl.locale;
+    const translateTranscription = async () => {
+        const result = await doTranslateTranscription(post.id, language, activeBot?.username || '');
+        selectPost(result.postid, result.channelid);
+    };
+
     if (!isBasicsLicensed) {
         return null;
     }
@@ -75,6 +88,23 @@ const PostMenu = (props: Props) => {
                 <span className='icon'><IconSparkleQuestionStyled/></span>
                 <FormattedMessage defaultMessage='Find open questions'/>
             </DropdownMenuItem>
+            <DropdownMenuItem onClick={() => explainPost(false)}>
+                <span className='icon'><IconSparkleQuestionStyled/></span>
+                <FormattedMessage defaultMessage='Explain this'/>
+            </DropdownMenuItem>
+            <DropdownMenuItem onClick={() => explainPost(true)}>
+                <span className='icon'><IconSparkleQuestionStyled/></span>
+                <FormattedMessage defaultMessage='Explain like I am five'/>
+            </DropdownMenuItem>
+            {hasCaptions && (
+                <DropdownMenuItem onClick={translateTranscription}>
+                    <span className='icon'><IconAI/></span>
+                    <FormattedMessage
+                        defaultMessage='Translate transcription to {language}'
+                        values={{language}}
+                    />
+                </DropdownMenuItem>
+            )}
             <DropdownMenuItem onClick={() => doReaction(post.id)}>
                 <span className='icon'><IconReactForMe/></span>
                 <FormattedMessage defaultMessage='React for me'/>
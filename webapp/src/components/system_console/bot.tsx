@@ -10,11 +10,11 @@ import {TrashCanOutlineIcon, ChevronDownIcon, AlertOutlineIcon, ChevronUpIcon} f
 import IconAI from '../assets/icon_ai';
 import {DangerPill} from '../pill';
 
-import {ButtonIcon} from '../assets/buttons';
+import {ButtonIcon, TertiaryButton} from '../assets/buttons';
 
 import {fetchModels} from '../../client';
 
-import {BooleanItem, ItemList, SelectionItem, SelectionItemOption, TextItem, ItemLabel, HelpText, ComboboxItem} from './item';
+import {BooleanItem, ItemList, SelectionItem, SelectionItemOption, TextItem, ItemLabel, HelpText, ComboboxItem, StyledInput} from './item';
 import AvatarItem from './avatar';
 import {ChannelAccessLevelItem, UserAccessLevelItem} from './llm_access';
 import {LLMService} from './service';
@@ -41,19 +41,152 @@ export type LLMBotConfig = {
     serviceID: string
     model: string
     customInstructions: string
+    outputLanguage?: string
+    enableFollowUpSuggestions?: boolean
+    retrieveChannelHistory?: boolean
     enableVision: boolean
+    imageTextBot?: string
+    delegateAgents?: string[]
     disableTools: boolean
+    enableFetchURL?: boolean
+    enableUsageInsights?: boolean
     channelAccessLevel: ChannelAccessLevel
     channelIDs: string[]
     userAccessLevel: UserAccessLevel
     userIDs: string[]
     teamIDs: string[]
+    groups?: string[]
+    roles?: string[]
     enabledNativeTools?: string[]
+    disabledNativeTools?: Record<string, string[]>
+    nativeWebSearch?: NativeWebSearchConfig
     reasoningEnabled?: boolean
     reasoningEffort?: string
     thinkingBudget?: number
+    reasoningDisplay?: string
+    contextComponents?: ContextComponent[]
+    postProcessors?: PostProcessorConfig[]
+    ragContext?: RAGContextConfig
 }
 
+export type RAGContextConfig = {
+    strategy?: string
+    maxTokens?: number
+    maxPerSource?: number
+}
+
+export type PostProcessorConfig = {
+    type: string
+    text?: string
+    words?: string[]
+    domains?: string[]
+}
+
+export type ContextComponent = {
+    type: string
+    key?: string
+    value?: string
+    maxTokens?: number
+}
+
+export type NativeWebSearchConfig = {
+    allowedDomains?: string[]
+    blockedDomains?: string[]
+    maxUses?: number
+    searchContextSize?: string
+    userLocation?: {
+        city?: string
+        region?: string
+        country?: string
+        timezone?: string
+    }
+}
+
+// Empty entries are kept while typing so a comma can be entered, the server ignores them
+const splitList = (value: string) => (value.trim() === '' ? [] : value.split(',').map((entry) => entry.trim()));
+
+// Component for configuring the native web search (OpenAI/Anthropic)
+type NativeWebSearchItemProps = {
+    config: NativeWebSearchConfig
+    onChange: (config: NativeWebSearchConfig) => void
+    provider: 'openai' | 'anthropic'
+}
+
+const NativeWebSearchItem = (props: NativeWebSearchItemProps) => {
+    const intl = useIntl();
+    const location = props.config.userLocation || {};
+    const setLocation = (field: string, value: string) => props.onChange({...props.config, userLocation: {...location, [field]: value}});
+
+    return (
+        <>
+            {props.provider === 'openai' && (
+                <SelectionItem
+                    label={intl.formatMessage({defaultMessage: 'Web search context size'})}
+                    value={props.config.searchContextSize || ''}
+                    onChange={(e) => props.onChange({...props.config, searchContextSize: e.target.value})}
+                    helptext={intl.formatMessage({defaultMessage: 'How much of the context window search results may use. Larger sizes give better answers at a higher cost.'})}
+                >
+                    <SelectionItemOption value=''>{intl.formatMessage({defaultMessage: 'Default'})}</SelectionItemOption>
+                    <SelectionItemOption value='low'>{intl.formatMessage({defaultMessage: 'Low'})}</SelectionItemOption>
+                    <SelectionItemOption value='medium'>{intl.formatMessage({defaultMessage: 'Medium'})}</SelectionItemOption>
+                    <SelectionItemOption value='high'>{intl.formatMessage({defaultMessage: 'High'})}</SelectionItemOption>
+                </SelectionItem>
+            )}
+            {props.provider === 'anthropic' && (
+                <>
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Web search allowed domains'})}
+                        placeholder='docs.example.com, wiki.example.com'
+                        value={(props.config.allowedDomains || []).join(', ')}
+                        onChange={(e) => props.onChange({...props.config, allowedDomains: splitList(e.target.value)})}
+                        helptext={intl.formatMessage({defaultMessage: 'Comma-separated list of domains web search results are limited to. Leave empty to search all domains. Can\'t be used with blocked domains.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Web search blocked domains'})}
+                        placeholder='example.com'
+                        value={(props.config.blockedDomains || []).join(', ')}
+                        onChange={(e) => props.onChange({...props.config, blockedDomains: splitList(e.target.value)})}
+                        helptext={intl.formatMessage({defaultMessage: 'Comma-separated list of domains excluded from web search results. Can\'t be used with allowed domains.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Web search max uses'})}
+                        type='number'
+                        min='0'
+                        value={String(props.config.maxUses ?? 0)}
+                        onChange={(e) => props.onChange({...props.config, maxUses: Math.max(0, parseInt(e.target.value, 10) || 0)})}
+                        helptext={intl.formatMessage({defaultMessage: 'The largest number of searches in a single response. 0 uses the provider default.'})}
+                    />
+                </>
+            )}
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Web search user city'})}
+                value={location.city || ''}
+                onChange={(e) => setLocation('city', e.target.value)}
+            />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Web search user region'})}
+                value={location.region || ''}
+                onChange={(e) => setLocation('region', e.target.value)}
+            />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Web search user country'})}
+                placeholder='US'
+                maxLength={2}
+                value={location.country || ''}
+                onChange={(e) => setLocation('country', e.target.value)}
+                helptext={intl.formatMessage({defaultMessage: 'Two letter ISO country code.'})}
+            />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Web search user timezone'})}
+                placeholder='America/New_York'
+                value={location.timezone || ''}
+                onChange={(e) => setLocation('timezone', e.target.value)}
+                helptext={intl.formatMessage({defaultMessage: 'The approximate location of users, used to localize web search results.'})}
+            />
+        </>
+    );
+};
+
 // Component for configuring native tools (OpenAI/Anthropic)
 type NativeToolsItemProps = {
     enabledTools: string[]
@@ -113,8 +246,243 @@ const NativeToolsItem = (props: NativeToolsItemProps) => {
     );
 };
 
+// Component choosing the features a native tool is used in
+type NativeToolFeaturesItemProps = {
+    tool: string
+    disabledTools: Record<string, string[]>
+    onChange: (disabledTools: Record<string, string[]>) => void
+}
+
+const NativeToolFeaturesItem = (props: NativeToolFeaturesItemProps) => {
+    const intl = useIntl();
+
+    const features = [
+        {id: 'direct_message', label: intl.formatMessage({defaultMessage: 'Direct messages'})},
+        {id: 'thread_analysis', label: intl.formatMessage({defaultMessage: 'Thread summaries and analysis'})},
+        {id: 'channel_analysis', label: intl.formatMessage({defaultMessage: 'Channel summaries'})},
+        {id: 'channel_interval', label: intl.formatMessage({defaultMessage: 'Unread channel summaries'})},
+        {id: 'search', label: intl.formatMessage({defaultMessage: 'Search'})},
+    ];
+
+    const isEnabled = (feature: string) => !(props.disabledTools[feature] || []).includes(props.tool);
+
+    const toggleFeature = (feature: string) => {
+        const disabled = props.disabledTools[feature] || [];
+        props.onChange({
+            ...props.disabledTools,
+            [feature]: isEnabled(feature) ? [...disabled, props.tool] : disabled.filter((t) => t !== props.tool),
+        });
+    };
+
+    return (
+        <>
+            <ItemLabel>
+                {intl.formatMessage({defaultMessage: 'Use web search in'})}
+            </ItemLabel>
+            <div>
+                {features.map((feature) => (
+                    <NativeToolContainer key={feature.id}>
+                        <StyledCheckbox
+                            type='checkbox'
+                            checked={isEnabled(feature.id)}
+                            onChange={() => toggleFeature(feature.id)}
+                        />
+                        <NativeToolLabel>
+                            <div>{feature.label}</div>
+                        </NativeToolLabel>
+                    </NativeToolContainer>
+                ))}
+            </div>
+        </>
+    );
+};
+
+// The components matching the details added to system prompts when none are configured
+const defaultContextComponents: ContextComponent[] = [
+    {type: 'user_profile'},
+    {type: 'channel'},
+    {type: 'team'},
+    {type: 'time'},
+];
+
+type ContextComponentsItemProps = {
+    components: ContextComponent[]
+    onChange: (components: ContextComponent[]) => void
+}
+
+const ContextComponentsItem = (props: ContextComponentsItemProps) => {
+    const intl = useIntl();
+
+    const builtIn = [
+        {type: 'user_profile', label: intl.formatMessage({defaultMessage: 'User profile'})},
+        {type: 'channel', label: intl.formatMessage({defaultMessage: 'Channel name'})},
+        {type: 'channel_purpose', label: intl.formatMessage({defaultMessage: 'Channel purpose and header'})},
+        {type: 'team', label: intl.formatMessage({defaultMessage: 'Team name and description'})},
+        {type: 'time', label: intl.formatMessage({defaultMessage: 'Current time in the user\'s timezone'})},
+    ];
+
+    const customized = props.components.length > 0;
+    const find = (type: string) => props.components.find((component) => component.type === type);
+
+    const toggle = (type: string) => {
+        if (find(type)) {
+            props.onChange(props.components.filter((component) => component.type !== type));
+            return;
+        }
+        props.onChange([...props.components, {type}]);
+    };
+
+    const update = (index: number, changes: Partial<ContextComponent>) => {
+        props.onChange(props.components.map((component, i) => (i === index ? {...component, ...changes} : component)));
+    };
+
+    const parseMaxTokens = (value: string) => {
+        const parsed = parseInt(value, 10);
+        return isNaN(parsed) || parsed < 0 ? 0 : parsed;
+    };
+
+    return (
+        <>
+            <BooleanItem
+                label={intl.formatMessage({defaultMessage: 'Customize request context'})}
+                value={customized}
+                onChange={(to: boolean) => props.onChange(to ? defaultContextComponents : [])}
+                helpText={intl.formatMessage({defaultMessage: 'Choose the details about the request added to the agent\'s instructions, and the token budget of each. Details longer than their budget are truncated. By default the user profile, channel, team and time are added.'})}
+            />
+            {customized && (
+                <>
+                    <ItemLabel>
+                        {intl.formatMessage({defaultMessage: 'Context details'})}
+                    </ItemLabel>
+                    <div>
+                        {builtIn.map((item) => {
+                            const index = props.components.findIndex((component) => component.type === item.type);
+                            return (
+                                <NativeToolContainer key={item.type}>
+                                    <StyledCheckbox
+                                        type='checkbox'
+                                        checked={index >= 0}
+                                        onChange={() => toggle(item.type)}
+                                    />
+                                    <NativeToolLabel>
+                                        <div>{item.label}</div>
+                                    </NativeToolLabel>
+                                    {index >= 0 && (
+                                        <ContextTokensInput
+                                            type='number'
+                                            min={0}
+                                            placeholder={intl.formatMessage({defaultMessage: '250 tokens'})}
+                                            value={props.components[index].maxTokens || ''}
+                                            onChange={(e: React.ChangeEvent<HTMLInputElement>) => update(index, {maxTokens: parseMaxTokens(e.target.value)})}
+                                        />
+                                    )}
+                                </NativeToolContainer>
+                            );
+                        })}
+                        {props.components.map((component, index) => (component.type === 'custom' && (
+                            <NativeToolContainer key={index}>
+                                <StyledInput
+                                    placeholder={intl.formatMessage({defaultMessage: 'Label, e.g. Office hours'})}
+                                    value={component.key ?? ''}
+                                    onChange={(e: React.ChangeEvent<HTMLInputElement>) => update(index, {key: e.target.value})}
+                                />
+                                <StyledInput
+                                    placeholder={intl.formatMessage({defaultMessage: 'Value, e.g. 9am to 5pm CET'})}
+                                    value={component.value ?? ''}
+                                    onChange={(e: React.ChangeEvent<HTMLInputElement>) => update(index, {value: e.target.value})}
+                                />
+                                <ContextTokensInput
+                                    type='number'
+                                    min={0}
+                                    placeholder={intl.formatMessage({defaultMessage: '250 tokens'})}
+                                    value={component.maxTokens || ''}
+                                    onChange={(e: React.ChangeEvent<HTMLInputElement>) => update(index, {maxTokens: parseMaxTokens(e.target.value)})}
+                                />
+                                <ButtonIcon onClick={() => props.onChange(props.components.filter((_, i) => i !== index))}>
+                                    <TrashIcon/>
+                                </ButtonIcon>
+                            </NativeToolContainer>
+                        )))}
+                        <TertiaryButton onClick={() => props.onChange([...props.components, {type: 'custom', key: '', value: ''}])}>
+                            <FormattedMessage defaultMessage='Add a custom value'/>
+                        </TertiaryButton>
+                    </div>
+                </>
+            )}
+        </>
+    );
+};
+
+type PostProcessorsItemProps = {
+    processors: PostProcessorConfig[]
+    onChange: (processors: PostProcessorConfig[]) => void
+}
+
+const PostProcessorsItem = (props: PostProcessorsItemProps) => {
+    const intl = useIntl();
+
+    const update = (index: number, changes: Partial<PostProcessorConfig>) => {
+        props.onChange(props.processors.map((processor, i) => (i === index ? {...processor, ...changes} : processor)));
+    };
+
+    const parseList = (value: string) => value.split(',').map((item) => item.trim()).filter((item) => item !== '');
+
+    return (
+        <>
+            <ItemLabel>
+                {intl.formatMessage({defaultMessage: 'Response post-processors'})}
+            </ItemLabel>
+            <div>
+                {props.processors.map((processor, index) => (
+                    <NativeToolContainer key={index}>
+                        <PostProcessorSelect
+                            value={processor.type}
+                            onChange={(e: React.ChangeEvent<HTMLSelectElement>) => update(index, {type: e.target.value})}
+                        >
+                            <option value='disclaimer'>{intl.formatMessage({defaultMessage: 'Disclaimer'})}</option>
+                            <option value='word_filter'>{intl.formatMessage({defaultMessage: 'Word filter'})}</option>
+                            <option value='link_previews'>{intl.formatMessage({defaultMessage: 'Link previews'})}</option>
+                        </PostProcessorSelect>
+                        {processor.type === 'disclaimer' && (
+                            <StyledInput
+                                placeholder={intl.formatMessage({defaultMessage: 'Text added at the end of responses'})}
+                                value={processor.text ?? ''}
+                                onChange={(e: React.ChangeEvent<HTMLInputElement>) => update(index, {text: e.target.value})}
+                            />
+                        )}
+                        {processor.type === 'word_filter' && (
+                            <StyledInput
+                                placeholder={intl.formatMessage({defaultMessage: 'Words masked in responses, separated by commas'})}
+                                value={(processor.words ?? []).join(', ')}
+                                onChange={(e: React.ChangeEvent<HTMLInputElement>) => update(index, {words: parseList(e.target.value)})}
+                            />
+                        )}
+                        {processor.type === 'link_previews' && (
+                            <StyledInput
+                                placeholder={intl.formatMessage({defaultMessage: 'Trusted domains, e.g. docs.example.com'})}
+                                value={(processor.domains ?? []).join(', ')}
+                                onChange={(e: React.ChangeEvent<HTMLInputElement>) => update(index, {domains: parseList(e.target.value)})}
+                            />
+                        )}
+                        <ButtonIcon onClick={() => props.onChange(props.processors.filter((_, i) => i !== index))}>
+                            <TrashIcon/>
+                        </ButtonIcon>
+                    </NativeToolContainer>
+                ))}
+                <TertiaryButton onClick={() => props.onChange([...props.processors, {type: 'disclaimer', text: ''}])}>
+                    <FormattedMessage defaultMessage='Add a post-processor'/>
+                </TertiaryButton>
+                <HelpText>
+                    {intl.formatMessage({defaultMessage: 'Edit the agent\'s completed responses before they are saved, in order: add a disclaimer, mask words such as profanity, or show link previews when all the links of a response point to trusted domains.'})}
+                </HelpText>
+            </div>
+        </>
+    );
+};
+
 type Props = {
     bot: LLMBotConfig
+    otherBots: LLMBotConfig[]
     services: LLMService[]
     onChange: (bot: LLMBotConfig) => void
     onDelete: () => void
@@ -292,6 +660,86 @@ const Bot = (props: Props) => {
                             value={props.bot.customInstructions}
                             onChange={(e) => props.onChange({...props.bot, customInstructions: e.target.value})}
                         />
+                        <TextItem
+                            label={intl.formatMessage({defaultMessage: 'Output language'})}
+                            placeholder={intl.formatMessage({defaultMessage: 'Leave empty to respond in the user\'s language'})}
+                            helptext={intl.formatMessage({defaultMessage: 'Optional: The language this agent always responds in, for example "English". By default the agent responds in the language of the user.'})}
+                            value={props.bot.outputLanguage ?? ''}
+                            onChange={(e) => props.onChange({...props.bot, outputLanguage: e.target.value})}
+                        />
+                        <ContextComponentsItem
+                            components={props.bot.contextComponents ?? []}
+                            onChange={(components: ContextComponent[]) => props.onChange({...props.bot, contextComponents: components})}
+                        />
+                        <PostProcessorsItem
+                            processors={props.bot.postProcessors ?? []}
+                            onChange={(processors: PostProcessorConfig[]) => props.onChange({...props.bot, postProcessors: processors})}
+                        />
+                        <SelectionItem
+                            label={intl.formatMessage({defaultMessage: 'Search context strategy'})}
+                            value={props.bot.ragContext?.strategy || 'relevance'}
+                            onChange={(e) => props.onChange({...props.bot, ragContext: {...props.bot.ragContext, strategy: e.target.value}})}
+                            helptext={intl.formatMessage({defaultMessage: 'How the search results the agent answers questions from are added to its prompt. Relevance and recency remove duplicated and overlapping results and order them by relevance or from the most recent. Top results adds the most relevant results as they are.'})}
+                        >
+                            <SelectionItemOption value='relevance'>{intl.formatMessage({defaultMessage: 'Relevance'})}</SelectionItemOption>
+                            <SelectionItemOption value='recency'>{intl.formatMessage({defaultMessage: 'Recency'})}</SelectionItemOption>
+                            <SelectionItemOption value='top_k'>{intl.formatMessage({defaultMessage: 'Top results'})}</SelectionItemOption>
+                        </SelectionItem>
+                        {props.bot.ragContext?.strategy !== 'top_k' && (
+                            <>
+                                <TextItem
+                                    label={intl.formatMessage({defaultMessage: 'Search context token budget'})}
+                                    type='number'
+                                    min='0'
+                                    value={String(props.bot.ragContext?.maxTokens ?? 0)}
+                                    onChange={(e) => props.onChange({...props.bot, ragContext: {...props.bot.ragContext, maxTokens: Math.max(0, parseInt(e.target.value, 10) || 0)}})}
+                                    helptext={intl.formatMessage({defaultMessage: 'The largest number of tokens of search results in the prompt, never more than half of the model\'s input limit. 0 uses 4000 tokens.'})}
+                                />
+                                <TextItem
+                                    label={intl.formatMessage({defaultMessage: 'Search results per channel'})}
+                                    type='number'
+                                    min='0'
+                                    value={String(props.bot.ragContext?.maxPerSource ?? 0)}
+                                    onChange={(e) => props.onChange({...props.bot, ragContext: {...props.bot.ragContext, maxPerSource: Math.max(0, parseInt(e.target.value, 10) || 0)}})}
+                                    helptext={intl.formatMessage({defaultMessage: 'The largest number of search results of the same channel in the prompt, so a busy channel doesn\'t fill it. 0 is unlimited.'})}
+                                />
+                            </>
+                        )}
+                        <BooleanItem
+                            label={intl.formatMessage({defaultMessage: 'Suggest follow-up questions'})}
+                            value={props.bot.enableFollowUpSuggestions ?? false}
+                            onChange={(to: boolean) => props.onChange({...props.bot, enableFollowUpSuggestions: to})}
+                            helpText={intl.formatMessage({defaultMessage: 'Suggest follow-up questions after each reply. This makes an additional short request to the model.'})}
+                        />
+                        <BooleanItem
+                            label={intl.formatMessage({defaultMessage: 'Retrieve channel history when context is missing'})}
+                            value={props.bot.retrieveChannelHistory ?? false}
+                            onChange={(to: boolean) => props.onChange({...props.bot, retrieveChannelHistory: to})}
+                            helpText={intl.formatMessage({defaultMessage: 'When the agent reports it lacked the context to answer a mention starting a thread, it answers again in the thread with the recent messages of the channel. This makes an additional request to the model.'})}
+                        />
+                        <SelectionItem
+                            label={intl.formatMessage({defaultMessage: 'Image text agent'})}
+                            value={props.bot.imageTextBot ?? ''}
+                            onChange={(e) => props.onChange({...props.bot, imageTextBot: e.target.value})}
+                            helptext={intl.formatMessage({defaultMessage: 'Optional: When this agent\'s model can\'t read images, the selected agent transcribes the text of attached images, such as photos of whiteboards and screenshots, and describes their drawings for it. Only agents with vision enabled are listed.'})}
+                        >
+                            <SelectionItemOption value=''>{intl.formatMessage({defaultMessage: 'None'})}</SelectionItemOption>
+                            {props.otherBots.filter((other) => other.enableVision && other.name).map((other) => (
+                                <SelectionItemOption
+                                    key={other.id}
+                                    value={other.name}
+                                >
+                                    {other.displayName || other.name}
+                                </SelectionItemOption>
+                            ))}
+                        </SelectionItem>
+                        <TextItem
+                            label={intl.formatMessage({defaultMessage: 'Delegate agents'})}
+                            placeholder={intl.formatMessage({defaultMessage: 'sql-expert, legal-reviewer'})}
+                            helptext={intl.formatMessage({defaultMessage: 'Optional: Comma-separated usernames of the agents this agent can ask questions to with the ask_agent tool, such as agents specialized in a domain. Delegate agents answer with their own model and instructions, and respect their own access controls.'})}
+                            value={(props.bot.delegateAgents ?? []).join(',')}
+                            onChange={(e) => props.onChange({...props.bot, delegateAgents: splitList(e.target.value)})}
+                        />
                         {(() => {
                             const selectedService = props.services.find((s) => s.id === props.bot.serviceID);
                             const supportsVisionAndTools = selectedService &&
@@ -315,6 +763,22 @@ const Bot = (props: Props) => {
                                         onChange={(to: boolean) => props.onChange({...props.bot, disableTools: !to})}
                                         helpText={intl.formatMessage({defaultMessage: 'By default some tool use is enabled to allow for features such as integrations with JIRA. Disabling this allows use of models that do not support or are not very good at tool use. Some features will not work without tools.'})}
                                     />
+                                    {!props.bot.disableTools && (
+                                        <BooleanItem
+                                            label={intl.formatMessage({defaultMessage: 'Enable URL Fetching'})}
+                                            value={Boolean(props.bot.enableFetchURL)}
+                                            onChange={(to: boolean) => props.onChange({...props.bot, enableFetchURL: to})}
+                                            helpText={intl.formatMessage({defaultMessage: 'Allow the bot to read public web pages users link to. Pages are fetched from the Mattermost server, which never connects to internal addresses for this tool.'})}
+                                        />
+                                    )}
+                                    {!props.bot.disableTools && (
+                                        <BooleanItem
+                                            label={intl.formatMessage({defaultMessage: 'Enable Usage Insights'})}
+                                            value={Boolean(props.bot.enableUsageInsights)}
+                                            onChange={(to: boolean) => props.onChange({...props.bot, enableUsageInsights: to})}
+                                            helpText={intl.formatMessage({defaultMessage: 'Allow the bot to answer system admins\' questions about the usage of the AI plugin, such as which team used thread summaries most last week. The bot only reads usage counts, never message content.'})}
+                                        />
+                                    )}
                                     {(() => {
                                         // Show native tools for Anthropic or OpenAI-based services with ResponsesAPI enabled
                                         const isAnthropic = selectedService.type === 'anthropic';
@@ -322,21 +786,53 @@ const Bot = (props: Props) => {
 
                                         if (isAnthropic) {
                                             return (
-                                                <NativeToolsItem
-                                                    enabledTools={props.bot.enabledNativeTools || []}
-                                                    onChange={(tools: string[]) => props.onChange({...props.bot, enabledNativeTools: tools})}
-                                                    provider='anthropic'
-                                                />
+                                                <>
+                                                    <NativeToolsItem
+                                                        enabledTools={props.bot.enabledNativeTools || []}
+                                                        onChange={(tools: string[]) => props.onChange({...props.bot, enabledNativeTools: tools})}
+                                                        provider='anthropic'
+                                                    />
+                                                    {props.bot.enabledNativeTools?.includes('web_search') && (
+                                                        <>
+                                                            <NativeWebSearchItem
+                                                                config={props.bot.nativeWebSearch || {}}
+                                                                onChange={(config: NativeWebSearchConfig) => props.onChange({...props.bot, nativeWebSearch: config})}
+                                                                provider='anthropic'
+                                                            />
+                                                            <NativeToolFeaturesItem
+                                                                tool='web_search'
+                                                                disabledTools={props.bot.disabledNativeTools || {}}
+                                                                onChange={(disabledTools: Record<string, string[]>) => props.onChange({...props.bot, disabledNativeTools: disabledTools})}
+                                                            />
+                                                        </>
+                                                    )}
+                                                </>
                                             );
                                         }
 
                                         if (isOpenAIWithResponses) {
                                             return (
-                                                <NativeToolsItem
-                                                    enabledTools={props.bot.enabledNativeTools || []}
-                                                    onChange={(tools: string[]) => props.onChange({...props.bot, enabledNativeTools: tools})}
-                                                    provider='openai'
-                                                />
+                                                <>
+                                                    <NativeToolsItem
+                                                        enabledTools={props.bot.enabledNativeTools || []}
+                                                        onChange={(tools: string[]) => props.onChange({...props.bot, enabledNativeTools: tools})}
+                                                        provider='openai'
+                                                    />
+                                                    {props.bot.enabledNativeTools?.includes('web_search') && (
+                                                        <>
+                                                            <NativeWebSearchItem
+                                                                config={props.bot.nativeWebSearch || {}}
+                                                                onChange={(config: NativeWebSearchConfig) => props.onChange({...props.bot, nativeWebSearch: config})}
+                                                                provider='openai'
+                                                            />
+                                                            <NativeToolFeaturesItem
+                                                                tool='web_search'
+                                                                disabledTools={props.bot.disabledNativeTools || {}}
+                                                                onChange={(disabledTools: Record<string, string[]>) => props.onChange({...props.bot, disabledNativeTools: disabledTools})}
+                                                            />
+                                                        </>
+                                                    )}
+                                                </>
                                             );
                                         }
 
@@ -348,6 +844,16 @@ const Bot = (props: Props) => {
                                         maxTokens={selectedService?.outputTokenLimit || 4096}
                                         onChange={props.onChange}
                                     />
+                                    <SelectionItem
+                                        label={intl.formatMessage({defaultMessage: 'Reasoning display'})}
+                                        value={props.bot.reasoningDisplay || 'summary'}
+                                        onChange={(e) => props.onChange({...props.bot, reasoningDisplay: e.target.value})}
+                                        helptext={intl.formatMessage({defaultMessage: 'How the reasoning of the bot is shown with its responses. Hidden reasoning is still used by the bot when it calls tools.'})}
+                                    >
+                                        <SelectionItemOption value='summary'>{intl.formatMessage({defaultMessage: 'Collapsed summary'})}</SelectionItemOption>
+                                        <SelectionItemOption value='full'>{intl.formatMessage({defaultMessage: 'Full'})}</SelectionItemOption>
+                                        <SelectionItemOption value='hidden'>{intl.formatMessage({defaultMessage: 'Hidden'})}</SelectionItemOption>
+                                    </SelectionItem>
                                 </>
                             );
                         })()}
@@ -365,6 +871,10 @@ const Bot = (props: Props) => {
                             userIDs={props.bot.userIDs ?? []}
                             teamIDs={props.bot.teamIDs ?? []}
                             onChangeIDs={(userIds: string[], teamIds: string[]) => props.onChange({...props.bot, userIDs: userIds, teamIDs: teamIds})}
+                            groups={props.bot.groups ?? []}
+                            onChangeGroups={(groups: string[]) => props.onChange({...props.bot, groups})}
+                            roles={props.bot.roles ?? []}
+                            onChangeRoles={(roles: string[]) => props.onChange({...props.bot, roles})}
                         />
 
                     </ItemList>
@@ -450,4 +960,19 @@ const StyledCheckbox = styled.input`
 	cursor: pointer;
 `;
 
+const ContextTokensInput = styled(StyledInput)`
+	width: 120px;
+	margin-left: auto;
+`;
+
+const PostProcessorSelect = styled.select`
+	height: 35px;
+	padding: 7px 12px;
+	border: 1px solid rgba(var(--center-channel-color-rgb), 0.16);
+	border-radius: 2px;
+	background: white;
+	font-size: 14px;
+	cursor: pointer;
+`;
+
 export default Bot;
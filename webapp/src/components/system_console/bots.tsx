@@ -83,6 +83,7 @@ const Bots = (props: Props) => {
                     <Bot
                         key={bot.id}
                         bot={bot}
+                        otherBots={props.bots.filter((b) => b.id !== bot.id)}
                         services={props.services}
                         onChange={onChange}
                         onDelete={() => onDelete(bot.id)}
@@ -9,16 +9,24 @@ import {setUserProfilePictureByUsername} from '@/client';
 
 import Panel, {PanelFooterText} from './panel';
 import Bots, {firstNewBot} from './bots';
+import ModelWarnings from './model_warnings';
 import {LLMBotConfig} from './bot';
 import Services, {firstNewService} from './services';
 import {LLMService} from './service';
-import {BooleanItem, ItemList, SelectionItem, SelectionItemOption, TextItem} from './item';
+import {BooleanItem, HelpText, ItemLabel, ItemList, SelectionItem, SelectionItemOption, TextItem} from './item';
 import NoBotsPage from './no_bots_page';
 import NoServicesPage from './no_services_page';
 import EmbeddingSearchPanel from './embedding_search/embedding_search_panel';
 import {EmbeddingSearchConfig} from './embedding_search/types';
 import MCPServers, {MCPConfig} from './mcp_servers';
 import WebSearchPanel, {WebSearchConfig as WebSearchSettings} from './web_search/web_search_panel';
+import {SelectChannel} from '../select';
+
+type PayloadCaptureConfig = {
+    enabled: boolean,
+    maxRequests: number,
+    includeContent: boolean,
+}
 
 type Config = {
     services: LLMService[],
@@ -27,12 +35,131 @@ type Config = {
     transcriptBackend: string,
     enableLLMTrace: boolean,
     enableTokenUsageLogging: boolean,
+    enableAgentTracing: boolean,
+    payloadCapture?: PayloadCaptureConfig,
     enableCallSummary: boolean,
     allowedUpstreamHostnames: string,
     allowUnsafeLinks: boolean,
+    maxIntervalPosts?: number,
     embeddingSearchConfig: EmbeddingSearchConfig,
     mcp: MCPConfig,
     webSearch: WebSearchSettings,
+    dataExclusions: DataExclusionsConfig,
+    routing: RoutingConfig,
+    rateLimit: RateLimitConfig,
+    diagrams: DiagramsConfig,
+    wolframAlpha: WolframAlphaConfig,
+    codeHosts: CodeHostsConfig,
+    jira: JiraConfig,
+    calendars: CalendarsConfig,
+    incidentCopilot: IncidentCopilotConfig,
+    faqBuilder: FAQBuilderConfig,
+    standups: StandupsConfig,
+    retention: RetentionConfig,
+    guardrails: GuardrailsConfig,
+    verification: VerificationConfig,
+}
+
+type RoutingRule = {
+    botName: string,
+    channelIDs: string[],
+    teamIDs: string[],
+}
+
+type RoutingConfig = {
+    alias: string,
+    rules: RoutingRule[],
+}
+
+type DataExclusionsConfig = {
+    channelIDs: string[],
+    teamIDs: string[],
+    sharedChannels?: string,
+    guestChannels?: string,
+}
+
+type RateLimitConfig = {
+    enabled: boolean,
+    userRequestsPerMinute: number,
+    endpointRequestsPerMinute: number,
+    endpointOverrides?: Record<string, number>,
+}
+
+type DiagramsConfig = {
+    enabled: boolean,
+    rendererURL: string,
+    maxDiagrams: number,
+}
+
+type WolframAlphaConfig = {
+    enabled: boolean,
+    appID: string,
+}
+
+type CodeHostConfig = {
+    enabled: boolean,
+    baseURL: string,
+    clientID: string,
+    clientSecret: string,
+}
+
+type CodeHostsConfig = {
+    github: CodeHostConfig,
+    gitlab: CodeHostConfig,
+}
+
+type CalendarProviderConfig = {
+    enabled: boolean,
+    clientID: string,
+    clientSecret: string,
+    tenantID: string,
+}
+
+type CalendarsConfig = {
+    google: CalendarProviderConfig,
+    microsoft: CalendarProviderConfig,
+}
+
+type IncidentCopilotConfig = {
+    enabled: boolean,
+    updateIntervalMinutes: number,
+}
+
+type FAQBuilderConfig = {
+    enabled: boolean,
+    maxEntries: number,
+}
+
+type StandupsConfig = {
+    enabled: boolean,
+}
+
+type RetentionConfig = {
+    enabled: boolean,
+    conversationDays: number,
+    traceDays: number,
+    usageEventDays: number,
+    embeddingDays: number,
+    cacheDays: number,
+}
+
+type GuardrailsConfig = {
+    forbiddenTopics: string[],
+    disclaimers: string[],
+    bannedPhrases: string[],
+    checkerBot: string,
+}
+
+type VerificationConfig = {
+    features: string[],
+    checkerBot: string,
+}
+
+type JiraConfig = {
+    enabled: boolean,
+    clientID: string,
+    clientSecret: string,
+    siteURL: string,
 }
 
 type Props = {
@@ -81,6 +208,12 @@ const defaultConfig = {
     transcriptBackend: '',
     enableLLMTrace: false,
     enableTokenUsageLogging: false,
+    enableAgentTracing: false,
+    payloadCapture: {
+        enabled: false,
+        maxRequests: 0,
+        includeContent: false,
+    },
     allowUnsafeLinks: false,
     embeddingSearchConfig: {
         type: 'disabled',
@@ -120,6 +253,76 @@ const defaultConfig = {
             resultLimit: 5,
             apiURL: '',
         },
+        searxng: {
+            apiURL: '',
+            apiKey: '',
+            resultLimit: 5,
+        },
+    },
+    dataExclusions: {
+        channelIDs: [],
+        teamIDs: [],
+    },
+    routing: {
+        alias: '',
+        rules: [],
+    },
+    rateLimit: {
+        enabled: false,
+        userRequestsPerMinute: 0,
+        endpointRequestsPerMinute: 0,
+    },
+    diagrams: {
+        enabled: false,
+        rendererURL: '',
+        maxDiagrams: 5,
+    },
+    wolframAlpha: {
+        enabled: false,
+        appID: '',
+    },
+    codeHosts: {
+        github: {enabled: false, baseURL: '', clientID: '', clientSecret: ''},
+        gitlab: {enabled: false, baseURL: '', clientID: '', clientSecret: ''},
+    },
+    jira: {
+        enabled: false,
+        clientID: '',
+        clientSecret: '',
+        siteURL: '',
+    },
+    calendars: {
+        google: {enabled: false, clientID: '', clientSecret: '', tenantID: ''},
+        microsoft: {enabled: false, clientID: '', clientSecret: '', tenantID: ''},
+    },
+    incidentCopilot: {
+        enabled: false,
+        updateIntervalMinutes: 15,
+    },
+    faqBuilder: {
+        enabled: false,
+        maxEntries: 15,
+    },
+    standups: {
+        enabled: false,
+    },
+    retention: {
+        enabled: false,
+        conversationDays: 0,
+        traceDays: 0,
+        usageEventDays: 0,
+        embeddingDays: 0,
+        cacheDays: 0,
+    },
+    guardrails: {
+        forbiddenTopics: [],
+        disclaimers: [],
+        bannedPhrases: [],
+        checkerBot: '',
+    },
+    verification: {
+        features: [],
+        checkerBot: '',
     },
 };
 
@@ -144,6 +347,18 @@ const BetaMessage = () => (
     </MessageContainer>
 );
 
+const ChannelPolicyOptions = () => {
+    const intl = useIntl();
+    return (
+        <>
+            <SelectionItemOption value=''>{intl.formatMessage({defaultMessage: 'Allow'})}</SelectionItemOption>
+            <SelectionItemOption value='disable'>{intl.formatMessage({defaultMessage: 'Disable AI features'})}</SelectionItemOption>
+            <SelectionItemOption value='exclude_content'>{intl.formatMessage({defaultMessage: 'Exclude content from guests and other servers'})}</SelectionItemOption>
+            <SelectionItemOption value='confirm'>{intl.formatMessage({defaultMessage: 'Require confirmation'})}</SelectionItemOption>
+        </>
+    );
+};
+
 const Config = (props: Props) => {
     const value = props.value || defaultConfig;
     const [avatarUpdates, setAvatarUpdates] = useState<{ [key: string]: File }>({});
@@ -228,6 +443,76 @@ const Config = (props: Props) => {
 
     // Initialize with default empty config if not provided
     const mcpConfig = value.mcp || defaultConfig.mcp;
+    const dataExclusions = value.dataExclusions || defaultConfig.dataExclusions;
+    const routing = value.routing || defaultConfig.routing;
+    const routingRule = (botName: string): RoutingRule => routing.rules?.find((rule) => rule.botName === botName) ?? {botName, channelIDs: [], teamIDs: []};
+    const updateRoutingRule = (rule: RoutingRule) => {
+        const rules = (routing.rules ?? []).filter((r) => r.botName !== rule.botName);
+        if (rule.channelIDs.length > 0 || rule.teamIDs.some(Boolean)) {
+            rules.push(rule);
+        }
+        props.onChange(props.id, {...value, routing: {...routing, rules}});
+        props.setSaveNeeded();
+    };
+    const rateLimit = value.rateLimit || defaultConfig.rateLimit;
+    const diagrams = value.diagrams || defaultConfig.diagrams;
+    const wolframAlpha = value.wolframAlpha || defaultConfig.wolframAlpha;
+    const codeHosts = {...defaultConfig.codeHosts, ...value.codeHosts};
+    const payloadCapture = {...defaultConfig.payloadCapture, ...value.payloadCapture};
+    const jira = value.jira || defaultConfig.jira;
+    const incidentCopilot = value.incidentCopilot || defaultConfig.incidentCopilot;
+    const updateIncidentCopilot = (update: Partial<IncidentCopilotConfig>) => {
+        props.onChange(props.id, {...value, incidentCopilot: {...incidentCopilot, ...update}});
+        props.setSaveNeeded();
+    };
+    const faqBuilder = value.faqBuilder || defaultConfig.faqBuilder;
+    const updateFAQBuilder = (update: Partial<FAQBuilderConfig>) => {
+        props.onChange(props.id, {...value, faqBuilder: {...faqBuilder, ...update}});
+        props.setSaveNeeded();
+    };
+    const standups = value.standups || defaultConfig.standups;
+    const updateStandups = (update: Partial<StandupsConfig>) => {
+        props.onChange(props.id, {...value, standups: {...standups, ...update}});
+        props.setSaveNeeded();
+    };
+    const retention = value.retention || defaultConfig.retention;
+    const updateRetention = (update: Partial<RetentionConfig>) => {
+        props.onChange(props.id, {...value, retention: {...retention, ...update}});
+        props.setSaveNeeded();
+    };
+    const guardrails = value.guardrails || defaultConfig.guardrails;
+    const updateGuardrails = (update: Partial<GuardrailsConfig>) => {
+        props.onChange(props.id, {...value, guardrails: {...guardrails, ...update}});
+        props.setSaveNeeded();
+    };
+    const verification = value.verification || defaultConfig.verification;
+    const updateVerification = (update: Partial<VerificationConfig>) => {
+        props.onChange(props.id, {...value, verification: {...verification, ...update}});
+        props.setSaveNeeded();
+    };
+    const verificationFeatures = verification.features ?? [];
+    const setFeatureVerified = (feature: string, verified: boolean) => {
+        const features = verificationFeatures.filter((f) => f !== feature);
+        updateVerification({features: verified ? [...features, feature] : features});
+    };
+    const siteURL = props.config?.ServiceSettings?.SiteURL || '';
+    const calendars = {...defaultConfig.calendars, ...value.calendars};
+    const updateCalendar = (provider: keyof CalendarsConfig, update: Partial<CalendarProviderConfig>) => {
+        props.onChange(props.id, {...value, calendars: {...calendars, [provider]: {...calendars[provider], ...update}}});
+        props.setSaveNeeded();
+    };
+    const updateJira = (update: Partial<JiraConfig>) => {
+        props.onChange(props.id, {...value, jira: {...jira, ...update}});
+        props.setSaveNeeded();
+    };
+    const updateCodeHost = (host: keyof CodeHostsConfig, update: Partial<CodeHostConfig>) => {
+        props.onChange(props.id, {...value, codeHosts: {...codeHosts, [host]: {...codeHosts[host], ...update}}});
+        props.setSaveNeeded();
+    };
+    const parseLimit = (input: string) => {
+        const limit = parseInt(input, 10);
+        return isNaN(limit) ? 0 : Math.max(0, limit);
+    };
 
     return (
         <ConfigContainer>
@@ -252,6 +537,7 @@ const Config = (props: Props) => {
                 title={intl.formatMessage({defaultMessage: 'AI Bots'})}
                 subtitle={intl.formatMessage({defaultMessage: 'Configure multiple AI bots with different personalities and capabilities.'})}
             >
+                <ModelWarnings/>
                 <Bots
                     bots={props.value.bots ?? []}
                     services={props.value.services ?? []}
@@ -304,6 +590,377 @@ const Config = (props: Props) => {
                         }}
                         helpText={intl.formatMessage({defaultMessage: 'When enabled, AI responses may contain clickable links, including potentially malicious destinations. Enable only if you trust the LLM output and have mitigations for exfiltration risks.'})}
                     />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Maximum posts in channel summaries'})}
+                        type='number'
+                        min='0'
+                        value={(value.maxIntervalPosts ?? 0).toString()}
+                        onChange={(e) => {
+                            props.onChange(props.id, {...value, maxIntervalPosts: parseLimit(e.target.value)});
+                            props.setSaveNeeded();
+                        }}
+                        helptext={intl.formatMessage({defaultMessage: 'The number of posts, the oldest first, read when summarizing a channel for a date range. 0 uses the default of 200, at most 2000.'})}
+                    />
+                </ItemList>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Agent Routing'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Choose the agent answering in channels and teams, such as the team of a department. The default bot answers elsewhere.'})}
+            >
+                <ItemList>
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Mention alias'})}
+                        value={routing.alias ?? ''}
+                        onChange={(e) => {
+                            props.onChange(props.id, {...value, routing: {...routing, alias: e.target.value}});
+                            props.setSaveNeeded();
+                        }}
+                        helptext={intl.formatMessage({defaultMessage: 'A username, such as ai, whose mentions are answered by the agent chosen for the channel. Leave empty to disable. It should not be the username of an existing user.'})}
+                    />
+                    {props.value.bots.map((bot: LLMBotConfig) => {
+                        const rule = routingRule(bot.name);
+                        return (
+                            <React.Fragment key={bot.name}>
+                                <ItemLabel>{intl.formatMessage({defaultMessage: '{bot} channels'}, {bot: bot.displayName})}</ItemLabel>
+                                <div>
+                                    <SelectChannel
+                                        channelIDs={rule.channelIDs ?? []}
+                                        onChangeChannelIDs={(channelIDs: string[]) => updateRoutingRule({...rule, channelIDs})}
+                                    />
+                                </div>
+                                <TextItem
+                                    label={intl.formatMessage({defaultMessage: '{bot} team IDs (csv)'}, {bot: bot.displayName})}
+                                    value={(rule.teamIDs ?? []).join(',')}
+                                    onChange={(e) => updateRoutingRule({...rule, teamIDs: e.target.value.split(',').map((id) => id.trim())})}
+                                    helptext={intl.formatMessage({defaultMessage: 'Comma separated list of team IDs whose channels are answered by this agent. Channel rules take precedence over team rules.'})}
+                                />
+                            </React.Fragment>
+                        );
+                    })}
+                </ItemList>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Data Exclusions'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Content from these channels and teams is never sent to AI services, indexed, or returned by AI tools.'})}
+            >
+                <ItemList>
+                    <ItemLabel>{intl.formatMessage({defaultMessage: 'Excluded channels'})}</ItemLabel>
+                    <div>
+                        <SelectChannel
+                            channelIDs={dataExclusions.channelIDs ?? []}
+                            onChangeChannelIDs={(channelIDs: string[]) => {
+                                props.onChange(props.id, {...value, dataExclusions: {...dataExclusions, channelIDs}});
+                                props.setSaveNeeded();
+                            }}
+                        />
+                        <HelpText>
+                            <FormattedMessage defaultMessage='Users cannot use AI features in these channels.'/>
+                        </HelpText>
+                    </div>
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Excluded team IDs (csv)'})}
+                        value={(dataExclusions.teamIDs ?? []).join(',')}
+                        onChange={(e) => {
+                            const teamIDs = e.target.value.split(',').map((id) => id.trim());
+                            props.onChange(props.id, {...value, dataExclusions: {...dataExclusions, teamIDs}});
+                            props.setSaveNeeded();
+                        }}
+                        helptext={intl.formatMessage({defaultMessage: 'Comma separated list of team IDs. Every channel of these teams is excluded.'})}
+                    />
+                    <SelectionItem
+                        label={intl.formatMessage({defaultMessage: 'Shared channels'})}
+                        value={dataExclusions.sharedChannels ?? ''}
+                        onChange={(e) => {
+                            props.onChange(props.id, {...value, dataExclusions: {...dataExclusions, sharedChannels: e.target.value}});
+                            props.setSaveNeeded();
+                        }}
+                        helptext={intl.formatMessage({defaultMessage: 'How AI features behave in channels shared with other Mattermost servers. Excluding content leaves out the posts of users from other servers.'})}
+                    >
+                        <ChannelPolicyOptions/>
+                    </SelectionItem>
+                    <SelectionItem
+                        label={intl.formatMessage({defaultMessage: 'Channels with guests'})}
+                        value={dataExclusions.guestChannels ?? ''}
+                        onChange={(e) => {
+                            props.onChange(props.id, {...value, dataExclusions: {...dataExclusions, guestChannels: e.target.value}});
+                            props.setSaveNeeded();
+                        }}
+                        helptext={intl.formatMessage({defaultMessage: 'How AI features behave in channels with guest members. Excluding content leaves out the posts of guests.'})}
+                    >
+                        <ChannelPolicyOptions/>
+                    </SelectionItem>
+                </ItemList>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Rate Limits'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Limit how often each user can call the plugin API. Limits apply to each server node.'})}
+            >
+                <ItemList>
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable rate limits'})}
+                        value={Boolean(rateLimit.enabled)}
+                        onChange={(to) => {
+                            props.onChange(props.id, {...value, rateLimit: {...rateLimit, enabled: to}});
+                            props.setSaveNeeded();
+                        }}
+                        helpText={intl.formatMessage({defaultMessage: 'Requests over the limits are rejected and the user is told when to try again.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Requests per user per minute'})}
+                        type='number'
+                        min='0'
+                        value={(rateLimit.userRequestsPerMinute ?? 0).toString()}
+                        onChange={(e) => {
+                            props.onChange(props.id, {...value, rateLimit: {...rateLimit, userRequestsPerMinute: parseLimit(e.target.value)}});
+                            props.setSaveNeeded();
+                        }}
+                        helptext={intl.formatMessage({defaultMessage: 'How many requests a user can make to all endpoints combined. 0 means unlimited.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Requests per user per endpoint per minute'})}
+                        type='number'
+                        min='0'
+                        value={(rateLimit.endpointRequestsPerMinute ?? 0).toString()}
+                        onChange={(e) => {
+                            props.onChange(props.id, {...value, rateLimit: {...rateLimit, endpointRequestsPerMinute: parseLimit(e.target.value)}});
+                            props.setSaveNeeded();
+                        }}
+                        helptext={intl.formatMessage({defaultMessage: 'How many requests a user can make to a single endpoint, such as summarizing a thread. 0 means unlimited.'})}
+                    />
+                </ItemList>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Diagrams'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Render Mermaid and PlantUML diagrams in responses to images attached to the post.'})}
+            >
+                <ItemList>
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable diagram rendering'})}
+                        value={Boolean(diagrams.enabled)}
+                        onChange={(to) => {
+                            props.onChange(props.id, {...value, diagrams: {...diagrams, enabled: to}});
+                            props.setSaveNeeded();
+                        }}
+                        helpText={intl.formatMessage({defaultMessage: 'Diagram code is sent to the renderer when a response is complete. Diagrams that fail to render are left as code.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Renderer URL'})}
+                        value={diagrams.rendererURL ?? ''}
+                        onChange={(e) => {
+                            props.onChange(props.id, {...value, diagrams: {...diagrams, rendererURL: e.target.value.trim()}});
+                            props.setSaveNeeded();
+                        }}
+                        helptext={intl.formatMessage({defaultMessage: 'Base URL of a Kroki compatible rendering service, for example https://kroki.io. Use a self-hosted instance to keep diagrams on your network.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Maximum diagrams per response'})}
+                        type='number'
+                        min='0'
+                        value={(diagrams.maxDiagrams ?? 0).toString()}
+                        onChange={(e) => {
+                            props.onChange(props.id, {...value, diagrams: {...diagrams, maxDiagrams: parseLimit(e.target.value)}});
+                            props.setSaveNeeded();
+                        }}
+                        helptext={intl.formatMessage({defaultMessage: '0 uses the default of 5.'})}
+                    />
+                </ItemList>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Incident Copilot'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Let users start a copilot in the channel of a Playbooks run that keeps a rolling incident summary with the open questions, and drafts the postmortem when the run is finished.'})}
+            >
+                <ItemList>
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable Incident Copilot'})}
+                        value={Boolean(incidentCopilot.enabled)}
+                        onChange={(to) => updateIncidentCopilot({enabled: to})}
+                        helpText={intl.formatMessage({defaultMessage: 'Requires the Playbooks plugin. The messages of the incident channel are sent to the AI service of the agent the copilot was started with.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Update interval (minutes)'})}
+                        type='number'
+                        min='5'
+                        max='1440'
+                        value={(incidentCopilot.updateIntervalMinutes ?? 15).toString()}
+                        onChange={(e) => updateIncidentCopilot({updateIntervalMinutes: parseLimit(e.target.value)})}
+                        helptext={intl.formatMessage({defaultMessage: 'How often the summary is updated while there is new activity in the channel, unless the user starting the copilot chooses another interval. Between 5 and 1440 minutes.'})}
+                        disabled={!incidentCopilot.enabled}
+                    />
+                </ItemList>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'FAQ Builder'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Let users draft the FAQ of a channel from the questions answered in its history, posted in the channel or exported as Markdown, and refreshed on a schedule.'})}
+            >
+                <ItemList>
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable FAQ Builder'})}
+                        value={Boolean(faqBuilder.enabled)}
+                        onChange={(to) => updateFAQBuilder({enabled: to})}
+                        helpText={intl.formatMessage({defaultMessage: 'Recurring questions are grouped with the embeddings index when embedding search is configured. The answered questions of the channel are sent to the AI service of the agent the FAQ is built with.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Maximum questions'})}
+                        type='number'
+                        min='1'
+                        value={(faqBuilder.maxEntries ?? 15).toString()}
+                        onChange={(e) => updateFAQBuilder({maxEntries: parseLimit(e.target.value)})}
+                        helptext={intl.formatMessage({defaultMessage: 'The number of the most frequently asked questions included in each FAQ. 0 uses the default of 15.'})}
+                        disabled={!faqBuilder.enabled}
+                    />
+                </ItemList>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Standups'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Let team admins schedule daily standups where an agent asks team members questions by direct message and posts a summarized report in a team channel.'})}
+            >
+                <ItemList>
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable Standups'})}
+                        value={Boolean(standups.enabled)}
+                        onChange={(to) => updateStandups({enabled: to})}
+                        helpText={intl.formatMessage({defaultMessage: 'The answers of each standup are sent to the AI service of the agent running it to write the report. Disabling pauses the configured standups without deleting them.'})}
+                    />
+                </ItemList>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Data Retention'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Purge the data the plugin keeps about messages daily. Data is deleted when its post is deleted, when the Mattermost data retention policy of its channel deletes the messages, or after the retention set here, whichever comes first.'})}
+            >
+                <ItemList>
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable Data Retention'})}
+                        value={Boolean(retention.enabled)}
+                        onChange={(to) => updateRetention({enabled: to})}
+                        helpText={intl.formatMessage({defaultMessage: 'Requires PostgreSQL. Mattermost data retention policies are only applied on servers licensed for data retention.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Conversation retention (days)'})}
+                        type='number'
+                        min='0'
+                        value={(retention.conversationDays ?? 0).toString()}
+                        onChange={(e) => updateRetention({conversationDays: parseLimit(e.target.value)})}
+                        helptext={intl.formatMessage({defaultMessage: 'The state of the conversations with agents, by their last activity. 0 follows the Mattermost data retention policy only.'})}
+                        disabled={!retention.enabled}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Trace retention (days)'})}
+                        type='number'
+                        min='0'
+                        value={(retention.traceDays ?? 0).toString()}
+                        onChange={(e) => updateRetention({traceDays: parseLimit(e.target.value)})}
+                        helptext={intl.formatMessage({defaultMessage: 'The agent traces recorded for debugging. 0 follows the Mattermost data retention policy only.'})}
+                        disabled={!retention.enabled}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Usage event retention (days)'})}
+                        type='number'
+                        min='0'
+                        value={(retention.usageEventDays ?? 0).toString()}
+                        onChange={(e) => updateRetention({usageEventDays: parseLimit(e.target.value)})}
+                        helptext={intl.formatMessage({defaultMessage: 'The usage events of the analytics dashboard and of usage insights. 0 follows the Mattermost data retention policy only.'})}
+                        disabled={!retention.enabled}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Embedding retention (days)'})}
+                        type='number'
+                        min='0'
+                        value={(retention.embeddingDays ?? 0).toString()}
+                        onChange={(e) => updateRetention({embeddingDays: parseLimit(e.target.value)})}
+                        helptext={intl.formatMessage({defaultMessage: 'The embeddings of the posts indexed for semantic search, by the time of their post. 0 follows the Mattermost data retention policy only.'})}
+                        disabled={!retention.enabled}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Cache retention (days)'})}
+                        type='number'
+                        min='0'
+                        value={(retention.cacheDays ?? 0).toString()}
+                        onChange={(e) => updateRetention({cacheDays: parseLimit(e.target.value)})}
+                        helptext={intl.formatMessage({defaultMessage: 'The text extracted from images and the finished analysis jobs. 0 follows the Mattermost data retention policy only.'})}
+                        disabled={!retention.enabled}
+                    />
+                </ItemList>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Guardrails'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Rules added to the system prompt of every agent. Responses posted for users are checked once they finish, and violations are recorded in the audit log.'})}
+            >
+                <ItemList>
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Forbidden topics'})}
+                        multiline={true}
+                        value={(guardrails.forbiddenTopics ?? []).join('\n')}
+                        onChange={(e) => updateGuardrails({forbiddenTopics: e.target.value.split('\n')})}
+                        helptext={intl.formatMessage({defaultMessage: 'Topics the agents refuse to discuss, one per line.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Required disclaimers'})}
+                        multiline={true}
+                        value={(guardrails.disclaimers ?? []).join('\n')}
+                        onChange={(e) => updateGuardrails({disclaimers: e.target.value.split('\n')})}
+                        helptext={intl.formatMessage({defaultMessage: 'Statements every response to users must include word for word, one per line.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Banned phrases'})}
+                        multiline={true}
+                        value={(guardrails.bannedPhrases ?? []).join('\n')}
+                        onChange={(e) => updateGuardrails({bannedPhrases: e.target.value.split('\n')})}
+                        helptext={intl.formatMessage({defaultMessage: 'Phrases the responses must never contain, one per line. Matched regardless of case.'})}
+                    />
+                    <SelectionItem
+                        label={intl.formatMessage({defaultMessage: 'Checker agent'})}
+                        value={guardrails.checkerBot ?? ''}
+                        onChange={(e) => updateGuardrails({checkerBot: e.target.value})}
+                        helptext={intl.formatMessage({defaultMessage: 'The agent asked whether each response discusses a forbidden topic. Without one, responses are only checked for banned phrases and disclaimers.'})}
+                    >
+                        <SelectionItemOption value=''>{intl.formatMessage({defaultMessage: 'None'})}</SelectionItemOption>
+                        {props.value.bots.map((bot: LLMBotConfig) => (
+                            <SelectionItemOption
+                                key={bot.name}
+                                value={bot.name}
+                            >
+                                {bot.displayName}
+                            </SelectionItemOption>
+                        ))}
+                    </SelectionItem>
+                </ItemList>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Response Verification'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Review the responses of the selected features against the messages and tool results they were generated from. Claims not supported by the sources are flagged at the end of the response. Each verified response costs one more request.'})}
+            >
+                <ItemList>
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Verify channel analysis'})}
+                        value={verificationFeatures.includes('channel_analysis')}
+                        onChange={(to) => setFeatureVerified('channel_analysis', to)}
+                    />
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Verify channel summaries of a time range'})}
+                        value={verificationFeatures.includes('channel_interval')}
+                        onChange={(to) => setFeatureVerified('channel_interval', to)}
+                    />
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Verify thread analysis'})}
+                        value={verificationFeatures.includes('thread_analysis')}
+                        onChange={(to) => setFeatureVerified('thread_analysis', to)}
+                    />
+                    <SelectionItem
+                        label={intl.formatMessage({defaultMessage: 'Checker agent'})}
+                        value={verification.checkerBot ?? ''}
+                        onChange={(e) => updateVerification({checkerBot: e.target.value})}
+                        helptext={intl.formatMessage({defaultMessage: 'The agent reviewing the responses. Defaults to the agent that answered.'})}
+                    >
+                        <SelectionItemOption value=''>{intl.formatMessage({defaultMessage: 'Agent that answered'})}</SelectionItemOption>
+                        {props.value.bots.map((bot: LLMBotConfig) => (
+                            <SelectionItemOption
+                                key={bot.name}
+                                value={bot.name}
+                            >
+                                {bot.displayName}
+                            </SelectionItemOption>
+                        ))}
+                    </SelectionItem>
                 </ItemList>
             </Panel>
             <Panel
@@ -323,6 +980,36 @@ const Config = (props: Props) => {
                         onChange={(to) => props.onChange(props.id, {...value, enableTokenUsageLogging: to})}
                         helpText={intl.formatMessage({defaultMessage: 'Enable logging of token usage for all LLM interactions.'})}
                     />
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable Agent Tracing'})}
+                        value={Boolean(value.enableAgentTracing)}
+                        onChange={(to) => props.onChange(props.id, {...value, enableAgentTracing: to})}
+                        helpText={intl.formatMessage({defaultMessage: 'Store the prompt, model turns, tool calls, and token usage of each bot response so admins can inspect what the bot did. Traces contain full conversation data.'})}
+                    />
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Capture Provider Payloads'})}
+                        value={Boolean(payloadCapture.enabled)}
+                        onChange={(to) => props.onChange(props.id, {...value, payloadCapture: {...payloadCapture, enabled: to}})}
+                        helpText={intl.formatMessage({defaultMessage: 'Keep the requests sent to LLM providers and their responses in memory so admins can retrieve them. Credentials are always redacted.'})}
+                    />
+                    {payloadCapture.enabled && (
+                        <>
+                            <TextItem
+                                label={intl.formatMessage({defaultMessage: 'Captured Requests'})}
+                                type='number'
+                                min='0'
+                                value={(payloadCapture.maxRequests ?? 0).toString()}
+                                onChange={(e) => props.onChange(props.id, {...value, payloadCapture: {...payloadCapture, maxRequests: parseLimit(e.target.value)}})}
+                                helptext={intl.formatMessage({defaultMessage: 'The number of most recent requests kept on each server. 0 uses the default of 20, at most 200.'})}
+                            />
+                            <BooleanItem
+                                label={intl.formatMessage({defaultMessage: 'Include Content in Captured Payloads'})}
+                                value={Boolean(payloadCapture.includeContent)}
+                                onChange={(to) => props.onChange(props.id, {...value, payloadCapture: {...payloadCapture, includeContent: to}})}
+                                helpText={intl.formatMessage({defaultMessage: 'Keep the text of prompts, tool results, and responses. When disabled, only the structure of the payloads, models, and roles are kept.'})}
+                            />
+                        </>
+                    )}
                 </ItemList>
             </Panel>
             <EmbeddingSearchPanel
@@ -339,6 +1026,177 @@ const Config = (props: Props) => {
                     props.setSaveNeeded();
                 }}
             />
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'GitHub and GitLab'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Let users connect their accounts so agents can read pull requests, merge requests, issues, and CI status on their behalf.'})}
+            >
+                <ItemList>
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable GitHub'})}
+                        value={Boolean(codeHosts.github.enabled)}
+                        onChange={(to) => updateCodeHost('github', {enabled: to})}
+                        helpText={intl.formatMessage({defaultMessage: 'Set the callback URL of the GitHub OAuth application to {siteURL}/plugins/mattermost-ai/integrations/github/callback.'}, {siteURL})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'GitHub URL'})}
+                        value={codeHosts.github.baseURL ?? ''}
+                        onChange={(e) => updateCodeHost('github', {baseURL: e.target.value.trim()})}
+                        helptext={intl.formatMessage({defaultMessage: 'Leave empty for github.com, or enter the URL of your GitHub Enterprise Server.'})}
+                        disabled={!codeHosts.github.enabled}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'GitHub Client ID'})}
+                        value={codeHosts.github.clientID ?? ''}
+                        onChange={(e) => updateCodeHost('github', {clientID: e.target.value.trim()})}
+                        disabled={!codeHosts.github.enabled}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'GitHub Client Secret'})}
+                        type='password'
+                        value={codeHosts.github.clientSecret ?? ''}
+                        onChange={(e) => updateCodeHost('github', {clientSecret: e.target.value.trim()})}
+                        disabled={!codeHosts.github.enabled}
+                    />
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable GitLab'})}
+                        value={Boolean(codeHosts.gitlab.enabled)}
+                        onChange={(to) => updateCodeHost('gitlab', {enabled: to})}
+                        helpText={intl.formatMessage({defaultMessage: 'Set the callback URL of the GitLab OAuth application to {siteURL}/plugins/mattermost-ai/integrations/gitlab/callback.'}, {siteURL})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'GitLab URL'})}
+                        value={codeHosts.gitlab.baseURL ?? ''}
+                        onChange={(e) => updateCodeHost('gitlab', {baseURL: e.target.value.trim()})}
+                        helptext={intl.formatMessage({defaultMessage: 'Leave empty for gitlab.com, or enter the URL of your self-managed GitLab instance.'})}
+                        disabled={!codeHosts.gitlab.enabled}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'GitLab Client ID'})}
+                        value={codeHosts.gitlab.clientID ?? ''}
+                        onChange={(e) => updateCodeHost('gitlab', {clientID: e.target.value.trim()})}
+                        disabled={!codeHosts.gitlab.enabled}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'GitLab Client Secret'})}
+                        type='password'
+                        value={codeHosts.gitlab.clientSecret ?? ''}
+                        onChange={(e) => updateCodeHost('gitlab', {clientSecret: e.target.value.trim()})}
+                        disabled={!codeHosts.gitlab.enabled}
+                    />
+                </ItemList>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Jira'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Let users connect their Atlassian accounts so agents can read and create Jira Cloud issues on their behalf.'})}
+            >
+                <ItemList>
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable Jira'})}
+                        value={Boolean(jira.enabled)}
+                        onChange={(to) => updateJira({enabled: to})}
+                        helpText={intl.formatMessage({defaultMessage: 'Set the callback URL of the Atlassian OAuth 2.0 (3LO) app to {siteURL}/plugins/mattermost-ai/integrations/jira/callback.'}, {siteURL})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Jira Site URL'})}
+                        value={jira.siteURL ?? ''}
+                        onChange={(e) => updateJira({siteURL: e.target.value.trim()})}
+                        helptext={intl.formatMessage({defaultMessage: 'The Jira Cloud site users connect to, for example https://example.atlassian.net. Leave empty to use the first site each user authorizes.'})}
+                        disabled={!jira.enabled}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Jira Client ID'})}
+                        value={jira.clientID ?? ''}
+                        onChange={(e) => updateJira({clientID: e.target.value.trim()})}
+                        disabled={!jira.enabled}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Jira Client Secret'})}
+                        type='password'
+                        value={jira.clientSecret ?? ''}
+                        onChange={(e) => updateJira({clientSecret: e.target.value.trim()})}
+                        disabled={!jira.enabled}
+                    />
+                </ItemList>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Calendars'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Let users connect their Google or Microsoft 365 calendar so agents can answer questions about their schedule and find times to meet.'})}
+            >
+                <ItemList>
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable Google Calendar'})}
+                        value={Boolean(calendars.google.enabled)}
+                        onChange={(to) => updateCalendar('google', {enabled: to})}
+                        helpText={intl.formatMessage({defaultMessage: 'Add {siteURL}/plugins/mattermost-ai/integrations/google_calendar/callback to the authorized redirect URIs of the Google OAuth client.'}, {siteURL})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Google Client ID'})}
+                        value={calendars.google.clientID ?? ''}
+                        onChange={(e) => updateCalendar('google', {clientID: e.target.value.trim()})}
+                        disabled={!calendars.google.enabled}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Google Client Secret'})}
+                        type='password'
+                        value={calendars.google.clientSecret ?? ''}
+                        onChange={(e) => updateCalendar('google', {clientSecret: e.target.value.trim()})}
+                        disabled={!calendars.google.enabled}
+                    />
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable Outlook Calendar'})}
+                        value={Boolean(calendars.microsoft.enabled)}
+                        onChange={(to) => updateCalendar('microsoft', {enabled: to})}
+                        helpText={intl.formatMessage({defaultMessage: 'Add {siteURL}/plugins/mattermost-ai/integrations/microsoft_calendar/callback as a web redirect URI of the Microsoft Entra app registration.'}, {siteURL})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Microsoft Tenant ID'})}
+                        value={calendars.microsoft.tenantID ?? ''}
+                        onChange={(e) => updateCalendar('microsoft', {tenantID: e.target.value.trim()})}
+                        helptext={intl.formatMessage({defaultMessage: 'The directory (tenant) ID of the app registration. Leave empty to allow accounts from any organization.'})}
+                        disabled={!calendars.microsoft.enabled}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Microsoft Client ID'})}
+                        value={calendars.microsoft.clientID ?? ''}
+                        onChange={(e) => updateCalendar('microsoft', {clientID: e.target.value.trim()})}
+                        disabled={!calendars.microsoft.enabled}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'Microsoft Client Secret'})}
+                        type='password'
+                        value={calendars.microsoft.clientSecret ?? ''}
+                        onChange={(e) => updateCalendar('microsoft', {clientSecret: e.target.value.trim()})}
+                        disabled={!calendars.microsoft.enabled}
+                    />
+                </ItemList>
+            </Panel>
+            <Panel
+                title={intl.formatMessage({defaultMessage: 'Wolfram|Alpha'})}
+                subtitle={intl.formatMessage({defaultMessage: 'Let bots with tools enabled query Wolfram|Alpha for math, unit conversions and facts. Bots always have a built-in calculator for arithmetic and date math.'})}
+            >
+                <ItemList>
+                    <BooleanItem
+                        label={intl.formatMessage({defaultMessage: 'Enable Wolfram|Alpha'})}
+                        value={Boolean(wolframAlpha.enabled)}
+                        onChange={(to) => {
+                            props.onChange(props.id, {...value, wolframAlpha: {...wolframAlpha, enabled: to}});
+                            props.setSaveNeeded();
+                        }}
+                        helpText={intl.formatMessage({defaultMessage: 'Queries written by the bot are sent to Wolfram|Alpha.'})}
+                    />
+                    <TextItem
+                        label={intl.formatMessage({defaultMessage: 'AppID'})}
+                        type='password'
+                        value={wolframAlpha.appID ?? ''}
+                        onChange={(e) => {
+                            props.onChange(props.id, {...value, wolframAlpha: {...wolframAlpha, appID: e.target.value.trim()}});
+                            props.setSaveNeeded();
+                        }}
+                        helptext={intl.formatMessage({defaultMessage: 'An AppID with access to the LLM API, created in the Wolfram|Alpha developer portal.'})}
+                        disabled={!wolframAlpha.enabled}
+                    />
+                </ItemList>
+            </Panel>
             <Panel
                 title={
                     <Horizontal>
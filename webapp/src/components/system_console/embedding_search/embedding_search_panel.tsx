@@ -188,6 +188,20 @@ const EmbeddingSearchPanel = ({value, onChange}: Props) => {
                             helptext={intl.formatMessage({defaultMessage: 'The number of dimensions for the vector embeddings. Common values are 768, 1024, or 1536 depending on the model.'})}
                         />
 
+                        <IntItem
+                            label={intl.formatMessage({defaultMessage: 'Requests Per Minute'})}
+                            placeholder='0'
+                            value={value?.requestsPerMinute ?? 0}
+                            onChange={(requestsPerMinute) => {
+                                onChange({
+                                    ...value,
+                                    requestsPerMinute,
+                                });
+                            }}
+                            min={0}
+                            helptext={intl.formatMessage({defaultMessage: 'Limits the requests sent to the embedding provider. When the limit is reached, search queries are sent before indexing. 0 means unlimited.'})}
+                        />
+
                         <ChunkingOptionsConfig
                             value={value}
                             onChange={onChange}
@@ -20,6 +20,7 @@ export interface EmbeddingSearchConfig {
     parameters: Record<string, unknown>;
     dimensions: number;
     chunkingOptions?: ChunkingOptions;
+    requestsPerMinute?: number;
 }
 
 // Match the server's JobStatus struct field names
@@ -9,7 +9,7 @@ import {SelectUser, SelectChannel} from '../select';
 
 import {ChannelAccessLevel, UserAccessLevel} from './bot';
 
-import {HelpText, ItemLabel, StyledRadio} from './item';
+import {HelpText, ItemLabel, StyledInput, StyledRadio} from './item';
 
 const AllowTypes = styled.div`
 	margin-top: 24px;
@@ -29,6 +29,9 @@ const SelectWrapper = styled.div`
     width: 90%;
 `;
 
+// Empty entries are kept while typing so a comma can be entered, the server ignores them
+const splitList = (value: string) => (value.trim() === '' ? [] : value.split(',').map((entry) => entry.trim()));
+
 type UserAccessLevelProps = {
     label: string;
     level: UserAccessLevel;
@@ -36,6 +39,10 @@ type UserAccessLevelProps = {
     userIDs: string[];
     teamIDs: string[];
     onChangeIDs: (userIds: string[], teamIds: string[]) => void;
+    groups: string[];
+    onChangeGroups: (groups: string[]) => void;
+    roles: string[];
+    onChangeRoles: (roles: string[]) => void;
 };
 
 export const UserAccessLevelItem = (props: UserAccessLevelProps) => {
@@ -86,6 +93,30 @@ export const UserAccessLevelItem = (props: UserAccessLevelProps) => {
                                 <FormattedMessage defaultMessage='Enter users to block for this bot'/>
                             )}
                         </HelpText>
+                        <ItemLabel>
+                            <FormattedMessage defaultMessage='User groups'/>
+                        </ItemLabel>
+                        <StyledInput
+                            type='text'
+                            placeholder='ai-beta, support'
+                            value={props.groups.join(', ')}
+                            onChange={(e) => props.onChangeGroups(splitList(e.target.value))}
+                        />
+                        <HelpText>
+                            <FormattedMessage defaultMessage='Comma-separated names of the custom user groups whose members are in the list.'/>
+                        </HelpText>
+                        <ItemLabel>
+                            <FormattedMessage defaultMessage='System roles'/>
+                        </ItemLabel>
+                        <StyledInput
+                            type='text'
+                            placeholder='system_guest'
+                            value={props.roles.join(', ')}
+                            onChange={(e) => props.onChangeRoles(splitList(e.target.value))}
+                        />
+                        <HelpText>
+                            <FormattedMessage defaultMessage='Comma-separated system roles whose users are in the list, such as system_guest, system_user or system_admin.'/>
+                        </HelpText>
                     </SelectWrapper>
                 )}
             </MainContainer>
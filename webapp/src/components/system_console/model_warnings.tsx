@@ -0,0 +1,86 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import React, {useEffect, useState} from 'react';
+import styled from 'styled-components';
+import {AlertOutlineIcon} from '@mattermost/compass-icons/components';
+import {FormattedMessage} from 'react-intl';
+
+import {getModelWarnings} from '../../client';
+
+// Type matching the backend API response
+export type BotModelWarnings = {
+    bot_name: string;
+    service_id: string;
+    model: string;
+    warnings: string[];
+};
+
+// ModelWarnings lists the saved bots whose model is deprecated, no longer offered by its
+// provider, or does not support the bot's settings
+const ModelWarnings = () => {
+    const [warnings, setWarnings] = useState<BotModelWarnings[]>([]);
+
+    useEffect(() => {
+        getModelWarnings().then(setWarnings).catch(() => setWarnings([]));
+    }, []);
+
+    if (warnings.length === 0) {
+        return null;
+    }
+
+    return (
+        <WarningContainer>
+            <AlertOutlineIcon size={20}/>
+            <div>
+                <WarningTitle>
+                    <FormattedMessage defaultMessage='Some bots use models that need attention'/>
+                </WarningTitle>
+                {warnings.map((bot) => (
+                    <WarningBot key={bot.bot_name}>
+                        <WarningBotName>{`${bot.bot_name} (${bot.model})`}</WarningBotName>
+                        <WarningList>
+                            {bot.warnings.map((warning) => (
+                                <li key={warning}>{warning}</li>
+                            ))}
+                        </WarningList>
+                    </WarningBot>
+                ))}
+            </div>
+        </WarningContainer>
+    );
+};
+
+const WarningContainer = styled.div`
+    display: flex;
+    align-items: flex-start;
+    gap: 12px;
+    padding: 16px;
+    margin-bottom: 16px;
+    color: var(--center-channel-color);
+    background-color: rgba(var(--away-indicator-rgb), 0.08);
+    border: 1px solid rgba(var(--away-indicator-rgb), 0.48);
+    border-radius: 4px;
+`;
+
+const WarningTitle = styled.div`
+    font-weight: 600;
+    margin-bottom: 8px;
+`;
+
+const WarningBot = styled.div`
+    margin-top: 8px;
+`;
+
+const WarningBotName = styled.div`
+    font-weight: 600;
+    font-size: 12px;
+`;
+
+const WarningList = styled.ul`
+    margin: 4px 0 0;
+    padding-left: 20px;
+    font-size: 12px;
+`;
+
+export default ModelWarnings;
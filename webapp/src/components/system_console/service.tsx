@@ -31,6 +31,11 @@ export type LLMService = {
     region: string
     awsAccessKeyID: string
     awsSecretAccessKey: string
+    zeroDataRetention: boolean
+    customHeaders: {[key: string]: string}
+    allowUserAPIKeys: boolean
+    modelAliases: {[key: string]: string}
+    requestsPerMinute: number
 }
 
 const mapServiceTypeToDisplayName = new Map<string, string>([
@@ -255,6 +260,43 @@ const ServiceFields = (props: ServiceFieldsProps) => {
                     props.onChange({...props.service, outputTokenLimit});
                 }}
             />
+            <TextItem
+                label={intl.formatMessage({defaultMessage: 'Requests Per Minute'})}
+                type='number'
+                value={props.service.requestsPerMinute?.toString() || '0'}
+                onChange={(e) => {
+                    const value = parseInt(e.target.value, 10);
+                    const requestsPerMinute = isNaN(value) || value < 0 ? 0 : value;
+                    props.onChange({...props.service, requestsPerMinute});
+                }}
+                helptext={intl.formatMessage({defaultMessage: 'Limits the requests all bots send to this service. When the limit is reached, chat responses are sent before background work such as channel analyses. 0 means unlimited.'})}
+            />
+            <BooleanItem
+                label={intl.formatMessage({defaultMessage: 'Zero Data Retention'})}
+                value={props.service.zeroDataRetention ?? false}
+                onChange={(to: boolean) => props.onChange({...props.service, zeroDataRetention: to})}
+                helpText={type === 'openai' || type === 'openaicompatible' || type === 'azure' ? intl.formatMessage({defaultMessage: 'Sends store=false with every request so the provider does not retain requests and responses.'}) : intl.formatMessage({defaultMessage: 'This provider has no request parameter for data retention. Zero data retention must be arranged with the provider for your account.'})}
+            />
+            <BooleanItem
+                label={intl.formatMessage({defaultMessage: 'Allow User API Keys'})}
+                value={props.service.allowUserAPIKeys ?? false}
+                onChange={(to: boolean) => props.onChange({...props.service, allowUserAPIKeys: to})}
+                helpText={intl.formatMessage({defaultMessage: 'Lets users register their own API key for this service. Their requests are sent with their key instead of the key above and do not count against the shared concurrency limits of the bots.'})}
+            />
+            <KeyValueItem
+                label={intl.formatMessage({defaultMessage: 'Custom Headers'})}
+                placeholder='X-Header-Name: value'
+                helptext={intl.formatMessage({defaultMessage: 'One "Name: value" header per line, added to every request sent to this service. Use it for data handling headers required by your gateway or provider agreement.'})}
+                values={props.service.customHeaders}
+                onChange={(customHeaders) => props.onChange({...props.service, customHeaders})}
+            />
+            <KeyValueItem
+                label={intl.formatMessage({defaultMessage: 'Model Aliases'})}
+                placeholder='default-smart: gpt-4.1'
+                helptext={intl.formatMessage({defaultMessage: 'One "alias: model" per line. Bots can use an alias instead of a model name, so a model retired by the provider is replaced here once for every bot using it.'})}
+                values={props.service.modelAliases}
+                onChange={(modelAliases) => props.onChange({...props.service, modelAliases})}
+            />
             {isOpenAIType && (
                 <TextItem
                     label={intl.formatMessage({defaultMessage: 'Streaming Timeout Seconds'})}
@@ -271,6 +313,50 @@ const ServiceFields = (props: ServiceFieldsProps) => {
     );
 };
 
+const mapToText = (values?: {[key: string]: string}) => {
+    return Object.entries(values ?? {}).map(([name, value]) => `${name}: ${value}`).join('\n');
+};
+
+const textToMap = (text: string) => {
+    const values: {[key: string]: string} = {};
+    for (const line of text.split('\n')) {
+        const separator = line.indexOf(':');
+        if (separator <= 0) {
+            continue;
+        }
+        values[line.slice(0, separator).trim()] = line.slice(separator + 1).trim();
+    }
+    return values;
+};
+
+type KeyValueItemProps = {
+    label: string
+    placeholder: string
+    helptext: string
+    values?: {[key: string]: string}
+    onChange: (values: {[key: string]: string}) => void
+};
+
+// KeyValueItem edits a map as one "key: value" pair per line
+const KeyValueItem = (props: KeyValueItemProps) => {
+    // Keep the raw text so lines being typed are not dropped before they are valid pairs
+    const [text, setText] = useState(() => mapToText(props.values));
+
+    return (
+        <TextItem
+            label={props.label}
+            multiline={true}
+            value={text}
+            placeholder={props.placeholder}
+            onChange={(e) => {
+                setText(e.target.value);
+                props.onChange(textToMap(e.target.value));
+            }}
+            helptext={props.helptext}
+        />
+    );
+};
+
 type Props = {
     service: LLMService
     onChange: (service: LLMService) => void
@@ -28,6 +28,11 @@ const defaultNewService: LLMService = {
     region: '',
     awsAccessKeyID: '',
     awsSecretAccessKey: '',
+    zeroDataRetention: false,
+    customHeaders: {},
+    modelAliases: {},
+    requestsPerMinute: 0,
+    allowUserAPIKeys: false,
 };
 
 export const firstNewService = {
@@ -20,11 +20,18 @@ export type WebSearchBraveConfig = {
     apiURL: string;
 };
 
+export type WebSearchSearxNGConfig = {
+    apiURL: string;
+    apiKey: string;
+    resultLimit: number;
+};
+
 export type WebSearchConfig = {
     enabled: boolean;
     provider: string;
     google: WebSearchGoogleConfig;
     brave: WebSearchBraveConfig;
+    searxng?: WebSearchSearxNGConfig;
     domainDenylist: string[];
 };
 
@@ -35,6 +42,7 @@ type Props = {
 
 const DEFAULT_GOOGLE_CONFIG = {apiKey: '', searchEngineId: '', resultLimit: 5, apiURL: ''};
 const DEFAULT_BRAVE_CONFIG = {apiKey: '', resultLimit: 5, apiURL: ''};
+const DEFAULT_SEARXNG_CONFIG = {apiURL: '', apiKey: '', resultLimit: 5};
 
 const WebSearchPanel = ({value, onChange}: Props) => {
     const intl = useIntl();
@@ -42,6 +50,7 @@ const WebSearchPanel = ({value, onChange}: Props) => {
     // Provide defaults for missing config objects
     const google = value.google || DEFAULT_GOOGLE_CONFIG;
     const brave = value.brave || DEFAULT_BRAVE_CONFIG;
+    const searxng = value.searxng || DEFAULT_SEARXNG_CONFIG;
     const domainDenylist = value.domainDenylist || [];
 
     const handleUpdate = (patch: Partial<WebSearchConfig>) => {
@@ -56,6 +65,10 @@ const WebSearchPanel = ({value, onChange}: Props) => {
         handleUpdate({brave: {...brave, ...patch}});
     };
 
+    const handleSearxNGUpdate = (patch: Partial<WebSearchSearxNGConfig>) => {
+        handleUpdate({searxng: {...searxng, ...patch}});
+    };
+
     return (
         <Panel
             title={<FormattedMessage defaultMessage='Web Search'/>}
@@ -76,6 +89,7 @@ const WebSearchPanel = ({value, onChange}: Props) => {
                 >
                     <SelectionItemOption value='google'>{'Google Custom Search'}</SelectionItemOption>
                     <SelectionItemOption value='brave'>{'Brave Search'}</SelectionItemOption>
+                    <SelectionItemOption value='searxng'>{'SearxNG (self-hosted)'}</SelectionItemOption>
                 </SelectionItem>
                 {value.provider === 'google' && (
                     <>
@@ -140,6 +154,36 @@ const WebSearchPanel = ({value, onChange}: Props) => {
                         />
                     </>
                 )}
+                {value.provider === 'searxng' && (
+                    <>
+                        <TextItem
+                            label={intl.formatMessage({defaultMessage: 'SearxNG URL'})}
+                            placeholder='https://searxng.example.com'
+                            value={searxng.apiURL}
+                            onChange={(e) => handleSearxNGUpdate({apiURL: e.target.value})}
+                            helptext={intl.formatMessage({defaultMessage: 'Base URL of your SearxNG instance. The json format must be enabled in its search.formats setting.'})}
+                            disabled={!value.enabled}
+                        />
+                        <TextItem
+                            label={intl.formatMessage({defaultMessage: 'SearxNG API Key (optional)'})}
+                            type='password'
+                            value={searxng.apiKey}
+                            onChange={(e) => handleSearxNGUpdate({apiKey: e.target.value})}
+                            helptext={intl.formatMessage({defaultMessage: 'Sent as a bearer token, for instances behind an authenticating proxy.'})}
+                            disabled={!value.enabled}
+                        />
+                        <TextItem
+                            label={intl.formatMessage({defaultMessage: 'SearxNG Result Limit'})}
+                            type='number'
+                            value={searxng.resultLimit.toString()}
+                            onChange={(e) => {
+                                const parsed = parseInt(e.target.value, 10);
+                                handleSearxNGUpdate({resultLimit: Number.isNaN(parsed) ? 5 : parsed});
+                            }}
+                            disabled={!value.enabled}
+                        />
+                    </>
+                )}
                 <TextItem
                     label={intl.formatMessage({defaultMessage: 'Domain Denylist (optional)'})}
                     value={domainDenylist.join(', ')}
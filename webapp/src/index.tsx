@@ -30,7 +30,7 @@ import {isRHSCompatable} from './mm_webapp';
 import SearchButton from './components/search_button';
 import AskChannelButton from './components/ask_channel_button';
 import {doSelectPost} from './hooks';
-import {handleAskChannelCommand, handleSummarizeChannelCommand} from './commands';
+import {handleAskChannelCommand, handleSummarizeChannelCommand, handleSummarizeMentionsCommand} from './commands';
 import SearchHints from './components/search_hints';
 import {useBotlist} from './bots';
 import AgentsTour from './components/tutorial/agents_tour';
@@ -191,6 +191,9 @@ export default class Plugin {
                 } else if (message.startsWith('/summarize-channel')) {
                     const commandParams = message.replace('/summarize-channel', '').trim();
                     return handleSummarizeChannelCommand(commandParams, args, store, rhs);
+                } else if (message.startsWith('/summarize-mentions')) {
+                    const commandParams = message.replace('/summarize-mentions', '').trim();
+                    return handleSummarizeMentionsCommand(commandParams, store, rhs);
                 }
                 return {message, args};
             });
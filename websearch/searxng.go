@@ -0,0 +1,120 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package websearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SearxNGProvider implements the Provider interface for a self-hosted SearxNG instance.
+// The instance must have the json format enabled in its search.formats setting.
+type SearxNGProvider struct {
+	apiURL     string
+	apiKey     string
+	httpClient *http.Client
+	logger     Logger
+}
+
+// NewSearxNGProvider creates a new SearxNGProvider instance. apiURL is the base URL of the
+// instance, apiKey is optional and sent as a bearer token for instances behind a proxy.
+func NewSearxNGProvider(apiURL, apiKey string, httpClient *http.Client, logger Logger) *SearxNGProvider {
+	return &SearxNGProvider{
+		apiURL:     apiURL,
+		apiKey:     apiKey,
+		httpClient: httpClient,
+		logger:     logger,
+	}
+}
+
+// Search performs a SearxNG search and returns the results.
+func (s *SearxNGProvider) Search(ctx context.Context, query string, limit int) (*SearchResponse, error) {
+	if limit <= 0 {
+		limit = 5
+	}
+	if limit > 10 {
+		limit = 10
+	}
+
+	endpoint := strings.TrimSuffix(strings.TrimSpace(s.apiURL), "/")
+	if endpoint == "" {
+		return nil, fmt.Errorf("searxng API URL is not configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/search", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create web search request: %w", err)
+	}
+
+	values := url.Values{}
+	values.Set("q", query)
+	values.Set("format", "json")
+	values.Set("categories", "general")
+	values.Set("safesearch", "1")
+	req.URL.RawQuery = values.Encode()
+	req.Header.Set("Accept", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	client := s.httpClient
+	if client == nil {
+		if s.logger != nil {
+			s.logger.Error("web search http client is not configured")
+		}
+		return nil, fmt.Errorf("web search http client is not configured")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if s.logger != nil {
+			s.logger.Error("searxng web search request failed", "error", err)
+		}
+		return nil, fmt.Errorf("searxng web search request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		// SearxNG answers 403 when the json format is not enabled
+		return nil, fmt.Errorf("searxng web search request failed: status %s", resp.Status)
+	}
+
+	var payload searxngSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode searxng web search response: %w", err)
+	}
+
+	results := make([]SearchResult, 0, limit)
+	for _, item := range payload.Results {
+		if len(results) >= limit {
+			break
+		}
+		itemURL := strings.TrimSpace(item.URL)
+		if itemURL == "" {
+			continue
+		}
+		results = append(results, SearchResult{
+			Title:   strings.TrimSpace(item.Title),
+			URL:     itemURL,
+			Snippet: strings.TrimSpace(item.Content),
+		})
+	}
+
+	return &SearchResponse{
+		Answer:  "", // SearxNG answers come from other engines without citations
+		Results: results,
+	}, nil
+}
+
+type searxngSearchResponse struct {
+	Results []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2023-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package websearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearxNGProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/searxng/search", r.URL.Path)
+		require.Equal(t, "json", r.URL.Query().Get("format"))
+
+		switch r.URL.Query().Get("q") {
+		case "forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case "invalid":
+			_, _ = w.Write([]byte("<html>"))
+		default:
+			require.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"results":[
+				{"title":" Go ","url":"https://go.dev","content":"The Go programming language"},
+				{"title":"No URL","url":"","content":"skipped"},
+				{"title":"Tour","url":"https://go.dev/tour","content":"A tour of Go"},
+				{"title":"Blog","url":"https://go.dev/blog","content":"The Go blog"}
+			]}`))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name            string
+		query           string
+		limit           int
+		expectedResults []SearchResult
+		expectError     bool
+	}{
+		{
+			name:  "returns results up to the limit",
+			query: "golang",
+			limit: 2,
+			expectedResults: []SearchResult{
+				{Title: "Go", URL: "https://go.dev", Snippet: "The Go programming language"},
+				{Title: "Tour", URL: "https://go.dev/tour", Snippet: "A tour of Go"},
+			},
+		},
+		{
+			name:        "json format disabled on the instance",
+			query:       "forbidden",
+			expectError: true,
+		},
+		{
+			name:        "invalid response",
+			query:       "invalid",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewSearxNGProvider(server.URL+"/searxng/", "test-key", http.DefaultClient, &mockLogger{})
+			resp, err := provider.Search(context.Background(), tt.query, tt.limit)
+
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Empty(t, resp.Answer)
+			require.Equal(t, tt.expectedResults, resp.Results)
+		})
+	}
+
+	t.Run("missing API URL", func(t *testing.T) {
+		provider := NewSearxNGProvider("", "", http.DefaultClient, &mockLogger{})
+		_, err := provider.Search(context.Background(), "golang", 5)
+		require.Error(t, err)
+	})
+}